@@ -4,6 +4,7 @@ newcoin generates a new coin cmd from a toml configuration file
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 
@@ -13,9 +14,11 @@ import (
 
 	"github.com/urfave/cli"
 
-	"github.com/skycoin/skycoin/src/fiber"
+	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/fiber"
 )
 
 const (
@@ -34,6 +37,7 @@ func init() {
 	app.Version = Version
 	commands := cli.Commands{
 		createCoinCommand(),
+		genesisCommand(),
 	}
 
 	app.Commands = commands
@@ -216,6 +220,87 @@ func createCoinCommand() cli.Command {
 	}
 }
 
+// genesisOutput is an output of the genesis block printed by the genesis command
+type genesisOutput struct {
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Hours   uint64 `json:"hours"`
+}
+
+// genesisBlock is the genesis block printed by the genesis command
+type genesisBlock struct {
+	Hash      string          `json:"hash"`
+	BodyHash  string          `json:"body_hash"`
+	Timestamp uint64          `json:"timestamp"`
+	Outputs   []genesisOutput `json:"outputs"`
+}
+
+func genesisCommand() cli.Command {
+	name := "genesis"
+	return cli.Command{
+		Name:  name,
+		Usage: "Print the genesis block of a config file",
+		Description: `Creates the genesis block of the config file and prints its hash and outputs.
+   If node.genesis_outputs is set, the genesis block has multiple outputs, which
+   must distribute node.genesis_coin_volume and have no coin hours.
+   Otherwise it has a single output to node.genesis_address_str.
+
+   The block hash must be signed with the blockchain secret key to create node.genesis_signature_str.`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "config-dir, cd",
+				Usage: "config directory path",
+				Value: "./",
+			},
+			cli.StringFlag{
+				Name:  "config-file, cf",
+				Usage: "config file path",
+				Value: "fiber.toml",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config, err := fiber.NewConfig(c.String("config-file"), c.String("config-dir"))
+			if err != nil {
+				log.Errorf("failed to create new fiber coin config")
+				return err
+			}
+
+			b, err := config.Node.NewGenesisBlock()
+			if err != nil {
+				log.Errorf("failed to create the genesis block")
+				return err
+			}
+
+			gb := genesisBlock{
+				Hash:      b.HashHeader().Hex(),
+				BodyHash:  b.Head.BodyHash.Hex(),
+				Timestamp: b.Head.Time,
+			}
+
+			for _, o := range b.Body.Transactions[0].Out {
+				coins, err := droplet.ToString(o.Coins)
+				if err != nil {
+					return err
+				}
+
+				gb.Outputs = append(gb.Outputs, genesisOutput{
+					Address: o.Address.String(),
+					Coins:   coins,
+					Hours:   o.Hours,
+				})
+			}
+
+			d, err := json.MarshalIndent(gb, "", "    ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(c.App.Writer, string(d))
+			return nil
+		},
+	}
+}
+
 func validateCoinName(s string) error {
 	x := regexp.MustCompile(fmt.Sprintf(`^%s$`, useragent.NamePattern))
 	if !x.MatchString(s) {
@@ -9,7 +9,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/skycoin/skycoin/src/cli"
+	"github.com/ness-network/privateness/src/cli"
 	"github.com/skycoin/skycoin/src/util/logging"
 )
 
@@ -28,7 +28,5 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := skyCLI.Execute(); err != nil {
-		os.Exit(1)
-	}
+	os.Exit(cli.Execute(skyCLI, os.Stderr))
 }
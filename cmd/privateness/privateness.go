@@ -13,10 +13,11 @@ import (
 	_ "net/http/pprof"
 	"os"
 
-	"github.com/skycoin/skycoin/src/fiber"
-	"github.com/skycoin/skycoin/src/readable"
-	"github.com/skycoin/skycoin/src/skycoin"
 	"github.com/skycoin/skycoin/src/util/logging"
+
+	"github.com/ness-network/privateness/src/fiber"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/skycoin"
 )
 
 var (
@@ -0,0 +1,725 @@
+/*
+protocol-conformance tests a node's implementation of the peer protocol.
+
+It connects to the node's peer port and runs a suite of cases, each on its own connection:
+handshakes at each protocol version supported by the node, invalid introductions, malformed frames,
+GetBlocks boundary conditions, oversized lists and unknown message IDs.
+Each case expects the node to keep the connection alive, to send a Disconnect message with a reason code
+and close the connection, or to close the connection without a Disconnect message, as gnet does for invalid frames.
+
+The tool learns the node's blockchain pubkey, genesis hash, verification parameters and supported
+protocol versions from the node's introduction message, and uses them in its own introductions.
+
+The node may penalize or blacklist the tester's IP address for the protocol violations of the suite,
+only run it against nodes you operate.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/ness-network/privateness/src/daemon/wire"
+)
+
+const (
+	defaultAddr      = "127.0.0.1:6006"
+	defaultTimeout   = 5 * time.Second
+	defaultCaseDelay = 500 * time.Millisecond
+	// defaultListenPort is advertised in introductions, no listener is started on it
+	defaultListenPort = 6000
+	userAgent         = "privateness:0.27.1(protocol-conformance)"
+)
+
+var help = fmt.Sprintf(`protocol-conformance tests a node's implementation of the peer protocol.
+
+It connects to the node at %s, which may be overridden with the -addr flag, and runs a suite of cases,
+each on a new connection. A PASS or FAIL line is printed for each case, followed by a summary.
+The exit code is 1 if any case failed.
+
+The node may penalize or blacklist the IP address of the tester for the protocol violations of the suite,
+only run it against nodes you operate.
+`, defaultAddr)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\nUsage of %s:\n", help, os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+// config configures the tester
+type config struct {
+	Addr    string
+	Timeout time.Duration
+	// ListenPort is the listen port advertised in introductions, the node requires a valid port
+	// and may add the tester's IP with the port to its peer list
+	ListenPort uint16
+	// MaxMessageLength is the max message length of the node, used to send an oversized frame
+	MaxMessageLength int
+	// CaseDelay is the delay between cases, so that the node closes the previous connection
+	// before the next one, which could otherwise exceed the node's connections per IP limit
+	CaseDelay time.Duration
+}
+
+// node is the node being tested, as described by its introduction message
+type node struct {
+	Intro              wire.Introduction
+	Extra              wire.IntroductionExtra
+	MinProtocolVersion int32
+}
+
+// tester runs the cases against a node
+type tester struct {
+	cfg  config
+	node node
+	rand *rand.Rand
+}
+
+// testCase is a conformance case, which returns an error if the node does not conform
+type testCase struct {
+	name string
+	run  func(t *tester) error
+}
+
+// disconnectError is returned when the node sent an unexpected Disconnect message
+type disconnectError struct {
+	code uint16
+}
+
+func (e disconnectError) Error() string {
+	return fmt.Sprintf("node disconnected with reason %s", wire.DisconnectCodeName(e.code))
+}
+
+// conn is a connection to the node
+type conn struct {
+	net.Conn
+	cfg config
+}
+
+func (c *conn) sendRaw(b []byte) error {
+	if err := c.SetWriteDeadline(time.Now().Add(c.cfg.Timeout)); err != nil {
+		return err
+	}
+	_, err := c.Write(b)
+	return err
+}
+
+func (c *conn) send(id string, msg interface{}) error {
+	b, err := wire.Encode(id, msg)
+	if err != nil {
+		return err
+	}
+	return c.sendRaw(b)
+}
+
+func (c *conn) read() (string, []byte, error) {
+	if err := c.SetReadDeadline(time.Now().Add(c.cfg.Timeout)); err != nil {
+		return "", nil, err
+	}
+	return wire.ReadFrame(c, wire.DefaultMaxMessageLength)
+}
+
+// readUntil reads messages until a message with the ID is received, decoding it into msg.
+// Other messages are passed to f if it's not nil, and are otherwise skipped.
+// Returns a disconnectError if the node sends a Disconnect message.
+func (c *conn) readUntil(id string, msg interface{}, f func(id string, body []byte) error) error {
+	for {
+		msgID, body, err := c.read()
+		if err != nil {
+			return fmt.Errorf("waiting for %s: %v", id, err)
+		}
+
+		switch msgID {
+		case id:
+			if msg == nil {
+				return nil
+			}
+			if err := wire.Decode(body, msg); err != nil {
+				return fmt.Errorf("invalid %s message: %v", id, err)
+			}
+			return nil
+		case wire.IDDisconnect:
+			var disc wire.Disconnect
+			if err := wire.Decode(body, &disc); err != nil {
+				return fmt.Errorf("invalid %s message: %v", wire.IDDisconnect, err)
+			}
+			return disconnectError{code: disc.ReasonCode}
+		}
+
+		if f != nil {
+			if err := f(msgID, body); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// expectAlive checks that the node answers a Ping with a Pong.
+// Messages received before the Pong are passed to f if it's not nil.
+func (c *conn) expectAlive(f func(id string, body []byte) error) error {
+	if err := c.send(wire.IDPing, nil); err != nil {
+		return err
+	}
+	return c.readUntil(wire.IDPong, nil, f)
+}
+
+// expectDisconnect checks that the node sends a Disconnect message with the reason code and closes the connection
+func (c *conn) expectDisconnect(code uint16) error {
+	var disc wire.Disconnect
+	if err := c.readUntil(wire.IDDisconnect, &disc, nil); err != nil {
+		return fmt.Errorf("expected disconnect reason %s: %v", wire.DisconnectCodeName(code), err)
+	}
+
+	if disc.ReasonCode != code {
+		return fmt.Errorf("expected disconnect reason %s, got %s", wire.DisconnectCodeName(code), wire.DisconnectCodeName(disc.ReasonCode))
+	}
+
+	return c.expectClosed()
+}
+
+// expectClosed checks that the node closes the connection, without sending a Disconnect message
+func (c *conn) expectClosed() error {
+	for {
+		id, body, err := c.read()
+		switch {
+		case err == nil:
+			if id == wire.IDDisconnect {
+				var disc wire.Disconnect
+				if err := wire.Decode(body, &disc); err == nil {
+					return disconnectError{code: disc.ReasonCode}
+				}
+			}
+		case isTimeout(err):
+			return errors.New("connection was not closed")
+		default:
+			// EOF, connection reset or a frame truncated by the close
+			return nil
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// dial connects to the node and reads its introduction message
+func (t *tester) dial() (*conn, *wire.Introduction, error) {
+	c, err := net.DialTimeout("tcp", t.cfg.Addr, t.cfg.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cn := &conn{
+		Conn: c,
+		cfg:  t.cfg,
+	}
+
+	var intro wire.Introduction
+	if err := cn.readUntil(wire.IDIntroduction, &intro, nil); err != nil {
+		c.Close()
+		return nil, nil, err
+	}
+
+	return cn, &intro, nil
+}
+
+// probe reads the introduction message of the node
+func (t *tester) probe() error {
+	c, intro, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	extra, err := wire.DecodeIntroductionExtra(intro.Extra)
+	if err != nil {
+		return fmt.Errorf("invalid introduction extra data: %v", err)
+	}
+
+	t.node = node{
+		Intro:              *intro,
+		Extra:              *extra,
+		MinProtocolVersion: intro.ProtocolVersion,
+	}
+	if extra.HasProtocolExtra {
+		t.node.MinProtocolVersion = extra.Protocol.MinProtocolVersion
+	}
+
+	return nil
+}
+
+// mirror returns a random mirror value that differs from the node's
+func (t *tester) mirror() uint32 {
+	for {
+		if m := t.rand.Uint32(); m != t.node.Intro.Mirror {
+			return m
+		}
+	}
+}
+
+// introduction returns a valid introduction message for the protocol version range
+func (t *tester) introduction(minVersion, version int32) *wire.Introduction {
+	extra := wire.IntroductionExtra{
+		Pubkey:           t.node.Extra.Pubkey,
+		VerifyTxn:        t.node.Extra.VerifyTxn,
+		UserAgent:        userAgent,
+		GenesisHash:      t.node.Extra.GenesisHash,
+		HasProtocolExtra: true,
+		Protocol: wire.IntroductionProtocolExtra{
+			MinProtocolVersion: minVersion,
+		},
+	}
+
+	return &wire.Introduction{
+		Mirror:          t.mirror(),
+		ListenPort:      t.cfg.ListenPort,
+		ProtocolVersion: version,
+		Extra:           extra.Encode(),
+	}
+}
+
+// introduce connects to the node and sends the introduction message
+func (t *tester) introduce(intro *wire.Introduction) (*conn, error) {
+	c, _, err := t.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.send(wire.IDIntroduction, intro); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// handshake connects to the node and completes a handshake at the node's highest protocol version
+func (t *tester) handshake() (*conn, error) {
+	c, err := t.introduce(t.introduction(t.node.Intro.ProtocolVersion, t.node.Intro.ProtocolVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.expectAlive(nil); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("handshake failed: %v", err)
+	}
+
+	return c, nil
+}
+
+// expectIntroductionDisconnect sends an introduction message and expects the node to disconnect with the code
+func (t *tester) expectIntroductionDisconnect(intro *wire.Introduction, code uint16) error {
+	c, err := t.introduce(intro)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	return c.expectDisconnect(code)
+}
+
+// expectFrameClosed completes a handshake, sends the raw frame and expects the node to close the connection
+func (t *tester) expectFrameClosed(frame []byte) error {
+	c, err := t.handshake()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.sendRaw(frame); err != nil {
+		return err
+	}
+
+	return c.expectClosed()
+}
+
+// expectMessageClosed completes a handshake, sends the message and expects the node to close the connection
+func (t *tester) expectMessageClosed(id string, msg interface{}) error {
+	b, err := wire.Encode(id, msg)
+	if err != nil {
+		return err
+	}
+	return t.expectFrameClosed(b)
+}
+
+// expectMessageAlive completes a handshake, sends the message and expects the node to keep the connection alive
+func (t *tester) expectMessageAlive(id string, msg interface{}) error {
+	c, err := t.handshake()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.send(id, msg); err != nil {
+		return err
+	}
+
+	return c.expectAlive(nil)
+}
+
+// expectGetBlocks completes a handshake, sends a GetBlocks message and checks the GiveBlocks replies
+// received before the node answers a Ping. If noBlocks is true the node must not send blocks.
+func (t *tester) expectGetBlocks(m wire.GetBlocks, noBlocks bool) error {
+	c, err := t.handshake()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.send(wire.IDGetBlocks, &m); err != nil {
+		return err
+	}
+
+	return c.expectAlive(func(id string, body []byte) error {
+		if id != wire.IDGiveBlocks {
+			return nil
+		}
+
+		var gb wire.GiveBlocks
+		if err := wire.Decode(body, &gb); err != nil {
+			return fmt.Errorf("invalid %s message: %v", wire.IDGiveBlocks, err)
+		}
+
+		if noBlocks && len(gb.Blocks) != 0 {
+			return fmt.Errorf("expected no blocks, got %d blocks", len(gb.Blocks))
+		}
+
+		if uint64(len(gb.Blocks)) > m.RequestedBlocks {
+			return fmt.Errorf("requested %d blocks, got %d blocks", m.RequestedBlocks, len(gb.Blocks))
+		}
+
+		for i, b := range gb.Blocks {
+			if expected := m.LastBlock + 1 + uint64(i); b.Head.BkSeq != expected {
+				return fmt.Errorf("expected block %d at index %d, got block %d", expected, i, b.Head.BkSeq)
+			}
+		}
+
+		return nil
+	})
+}
+
+func randHashes(n int) []cipher.SHA256 {
+	hashes := make([]cipher.SHA256, n)
+	for i := range hashes {
+		hashes[i] = cipher.SumSHA256(encoder.SerializeUint32(uint32(i)))
+	}
+	return hashes
+}
+
+func peers(n int) []wire.IPAddr {
+	peers := make([]wire.IPAddr, n)
+	for i := range peers {
+		peers[i] = wire.IPAddr{
+			// 10.0.0.0/8
+			IP:   0x0A000000 + uint32(i) + 1,
+			Port: 6000,
+		}
+	}
+	return peers
+}
+
+// cases returns the conformance cases for the node
+func (t *tester) cases() []testCase {
+	var cases []testCase
+
+	for i := int64(t.node.MinProtocolVersion); i <= int64(t.node.Intro.ProtocolVersion); i++ {
+		v := int32(i)
+		cases = append(cases, testCase{
+			name: fmt.Sprintf("handshake at protocol version %d", v),
+			run: func(t *tester) error {
+				c, err := t.introduce(t.introduction(v, v))
+				if err != nil {
+					return err
+				}
+				defer c.Close()
+				return c.expectAlive(nil)
+			},
+		})
+	}
+
+	if t.node.MinProtocolVersion > math.MinInt32 {
+		cases = append(cases, testCase{
+			name: "handshake below the min protocol version",
+			run: func(t *tester) error {
+				v := t.node.MinProtocolVersion - 1
+				return t.expectIntroductionDisconnect(t.introduction(v, v), wire.DisconnectVersionNotSupported)
+			},
+		})
+	}
+
+	if t.node.Intro.ProtocolVersion < math.MaxInt32 {
+		cases = append(cases, testCase{
+			name: "handshake above the max protocol version",
+			run: func(t *tester) error {
+				v := t.node.Intro.ProtocolVersion + 1
+				return t.expectIntroductionDisconnect(t.introduction(v, v), wire.DisconnectVersionNotSupported)
+			},
+		})
+	}
+
+	cases = append(cases, []testCase{
+		{
+			name: "message before introduction",
+			run: func(t *tester) error {
+				c, _, err := t.dial()
+				if err != nil {
+					return err
+				}
+				defer c.Close()
+
+				if err := c.send(wire.IDPing, nil); err != nil {
+					return err
+				}
+				return c.expectDisconnect(wire.DisconnectNoIntroduction)
+			},
+		},
+		{
+			name: "introduction with the node's mirror",
+			run: func(t *tester) error {
+				intro := t.introduction(t.node.Intro.ProtocolVersion, t.node.Intro.ProtocolVersion)
+				intro.Mirror = t.node.Intro.Mirror
+				return t.expectIntroductionDisconnect(intro, wire.DisconnectSelf)
+			},
+		},
+		{
+			name: "introduction with another blockchain pubkey",
+			run: func(t *tester) error {
+				intro := t.introduction(t.node.Intro.ProtocolVersion, t.node.Intro.ProtocolVersion)
+				pubkey, _ := cipher.GenerateKeyPair()
+				copy(intro.Extra, pubkey[:])
+				return t.expectIntroductionDisconnect(intro, wire.DisconnectBlockchainPubkeyNotMatched)
+			},
+		},
+		{
+			name: "introduction with truncated extra data",
+			run: func(t *tester) error {
+				intro := t.introduction(t.node.Intro.ProtocolVersion, t.node.Intro.ProtocolVersion)
+				intro.Extra = intro.Extra[:len(cipher.PubKey{})+3]
+				return t.expectIntroductionDisconnect(intro, wire.DisconnectInvalidExtraData)
+			},
+		},
+		{
+			name: "introduction with an invalid user agent",
+			run: func(t *tester) error {
+				intro := t.introduction(t.node.Intro.ProtocolVersion, t.node.Intro.ProtocolVersion)
+				extra := wire.IntroductionExtra{
+					Pubkey:      t.node.Extra.Pubkey,
+					VerifyTxn:   t.node.Extra.VerifyTxn,
+					UserAgent:   "protocol-conformance",
+					GenesisHash: t.node.Extra.GenesisHash,
+				}
+				intro.Extra = extra.Encode()
+				return t.expectIntroductionDisconnect(intro, wire.DisconnectInvalidUserAgent)
+			},
+		},
+		{
+			name: "truncated introduction",
+			run: func(t *tester) error {
+				c, _, err := t.dial()
+				if err != nil {
+					return err
+				}
+				defer c.Close()
+
+				frame, err := wire.EncodeFrame(wire.IDIntroduction, []byte{1, 2, 3})
+				if err != nil {
+					return err
+				}
+				if err := c.sendRaw(frame); err != nil {
+					return err
+				}
+				return c.expectClosed()
+			},
+		},
+		{
+			name: "frame length shorter than the message ID",
+			run: func(t *tester) error {
+				frame := append(encoder.SerializeUint32(wire.IDLen-1), "PIN"...)
+				return t.expectFrameClosed(frame)
+			},
+		},
+		{
+			name: "frame length above the max message length",
+			run: func(t *tester) error {
+				frame := append(encoder.SerializeUint32(uint32(t.cfg.MaxMessageLength+1)), wire.IDPing...)
+				return t.expectFrameClosed(frame)
+			},
+		},
+		{
+			name: "unknown message ID",
+			run: func(t *tester) error {
+				return t.expectMessageClosed("XXXX", nil)
+			},
+		},
+		{
+			name: "message with trailing data",
+			run: func(t *tester) error {
+				frame, err := wire.EncodeFrame(wire.IDAnnounceBlocks, make([]byte, 9))
+				if err != nil {
+					return err
+				}
+				return t.expectFrameClosed(frame)
+			},
+		},
+		{
+			name: fmt.Sprintf("AnnounceTxns with %d hashes", wire.MaxTxnHashes),
+			run: func(t *tester) error {
+				return t.expectMessageAlive(wire.IDAnnounceTxns, &wire.AnnounceTxns{
+					Transactions: randHashes(wire.MaxTxnHashes),
+				})
+			},
+		},
+		{
+			name: fmt.Sprintf("AnnounceTxns with %d hashes", wire.MaxTxnHashes+1),
+			run: func(t *tester) error {
+				return t.expectMessageClosed(wire.IDAnnounceTxns, &wire.AnnounceTxns{
+					Transactions: randHashes(wire.MaxTxnHashes + 1),
+				})
+			},
+		},
+		{
+			name: fmt.Sprintf("GetTxns with %d hashes", wire.MaxTxnHashes+1),
+			run: func(t *tester) error {
+				return t.expectMessageClosed(wire.IDGetTxns, &wire.GetTxns{
+					Transactions: randHashes(wire.MaxTxnHashes + 1),
+				})
+			},
+		},
+		{
+			name: fmt.Sprintf("GivePeers with %d peers", wire.MaxGivePeers+1),
+			run: func(t *tester) error {
+				return t.expectMessageClosed(wire.IDGivePeers, &wire.GivePeers{
+					Peers: peers(wire.MaxGivePeers + 1),
+				})
+			},
+		},
+		{
+			name: "GetBlocks of 0 blocks",
+			run: func(t *tester) error {
+				return t.expectGetBlocks(wire.GetBlocks{
+					LastBlock:       0,
+					RequestedBlocks: 0,
+				}, true)
+			},
+		},
+		{
+			name: "GetBlocks of the max number of blocks",
+			run: func(t *tester) error {
+				return t.expectGetBlocks(wire.GetBlocks{
+					LastBlock:       0,
+					RequestedBlocks: math.MaxUint64,
+				}, false)
+			},
+		},
+		{
+			name: "GetBlocks after the max block sequence",
+			run: func(t *tester) error {
+				return t.expectGetBlocks(wire.GetBlocks{
+					LastBlock:       math.MaxUint64,
+					RequestedBlocks: math.MaxUint64,
+				}, true)
+			},
+		},
+		{
+			name: "GetBlocks after a block beyond the head",
+			run: func(t *tester) error {
+				return t.expectGetBlocks(wire.GetBlocks{
+					LastBlock:       math.MaxUint64 / 2,
+					RequestedBlocks: 10,
+				}, true)
+			},
+		},
+	}...)
+
+	return cases
+}
+
+// result is the result of a case
+type result struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+func (t *tester) run(cases []testCase) []result {
+	results := make([]result, len(cases))
+	for i, tc := range cases {
+		if i != 0 {
+			time.Sleep(t.cfg.CaseDelay)
+		}
+
+		start := time.Now()
+		err := tc.run(t)
+		results[i] = result{
+			name:     tc.name,
+			err:      err,
+			duration: time.Since(start),
+		}
+	}
+
+	return results
+}
+
+func main() {
+	addr := flag.String("addr", defaultAddr, "ip:port of the node's peer listener")
+	timeout := flag.Duration("timeout", defaultTimeout, "connect, read and write timeout")
+	listenPort := flag.Uint("listen-port", defaultListenPort, "listen port advertised to the node in introductions")
+	maxMsgLen := flag.Int("max-msg-len", wire.DefaultMaxMessageLength, "max incoming message length of the node")
+	caseDelay := flag.Duration("case-delay", defaultCaseDelay, "delay between cases")
+
+	flag.Parse()
+
+	if *listenPort == 0 || *listenPort > math.MaxUint16 {
+		fmt.Fprintln(os.Stderr, "-listen-port must be between 1 and 65535")
+		os.Exit(1)
+	}
+
+	t := &tester{
+		cfg: config{
+			Addr:             *addr,
+			Timeout:          *timeout,
+			ListenPort:       uint16(*listenPort),
+			MaxMessageLength: *maxMsgLen,
+			CaseDelay:        *caseDelay,
+		},
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if err := t.probe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the introduction of %s: %v\n", *addr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Node %s: protocol versions %d-%d, user agent %q, genesis hash %s\n", *addr,
+		t.node.MinProtocolVersion, t.node.Intro.ProtocolVersion, t.node.Extra.UserAgent, t.node.Extra.GenesisHash.Hex())
+
+	results := t.run(t.cases())
+
+	var failed int
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			fmt.Printf("FAIL\t%s (%s): %v\n", r.name, r.duration.Round(time.Millisecond), r.err)
+		} else {
+			fmt.Printf("PASS\t%s (%s)\n", r.name, r.duration.Round(time.Millisecond))
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+
+	if failed != 0 {
+		os.Exit(1)
+	}
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/skycoin/skycoin/src/cipher"
@@ -28,8 +27,8 @@ func addressVerifyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req VerifyAddressRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	if err := decodeJSONRequest(r, &req); err != nil {
+		resp := NewJSONDecodeErrorResponse(err)
 		writeHTTPResponse(w, resp)
 		return
 	}
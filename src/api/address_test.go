@@ -46,7 +46,7 @@ func TestVerifyAddress(t *testing.T) {
 			method:       http.MethodPost,
 			contentType:  ContentTypeJSON,
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
 		},
 
 		{
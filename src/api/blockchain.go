@@ -3,29 +3,77 @@ package api
 // APIs for blockchain related information
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
-	"github.com/skycoin/skycoin/src/visor"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 // blockchainMetadataHandler returns the blockchain metadata
 // Method: GET
 // URI: /api/v1/blockchain/metadata
-func blockchainMetadataHandler(gateway Gatewayer) http.HandlerFunc {
+// Args:
+//
+//	wait_after_seq: Wait until the head block seq is greater than this value before responding [optional]
+//	timeout: Maximum number of seconds to wait, capped at maxTimeout. Defaults to maxTimeout [optional]
+func blockchainMetadataHandler(gateway Gatewayer, maxTimeout time.Duration) http.HandlerFunc {
+	if maxTimeout == 0 {
+		maxTimeout = defaultMaxLongPollTimeout
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			wh.Error405(w)
 			return
 		}
 
+		waitAfterSeqStr := r.FormValue("wait_after_seq")
+		timeoutStr := r.FormValue("timeout")
+
+		if waitAfterSeqStr == "" && timeoutStr != "" {
+			wh.Error400(w, "timeout requires wait_after_seq")
+			return
+		}
+
+		if waitAfterSeqStr != "" {
+			waitAfterSeq, err := strconv.ParseUint(waitAfterSeqStr, 10, 64)
+			if err != nil {
+				wh.Error400(w, "Invalid wait_after_seq value")
+				return
+			}
+
+			timeout := maxTimeout
+			if timeoutStr != "" {
+				timeoutSecs, err := strconv.ParseUint(timeoutStr, 10, 64)
+				if err != nil {
+					wh.Error400(w, "Invalid timeout value")
+					return
+				}
+
+				if timeoutSecs < uint64(maxTimeout/time.Second) {
+					timeout = time.Duration(timeoutSecs) * time.Second
+				}
+			}
+
+			// Waiting does not hold a db transaction, so many clients can wait at once.
+			// The metadata is returned whether the head changed or the timeout passed,
+			// the client compares the head seq to wait_after_seq.
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			gateway.WaitHeadSeqAfter(ctx, waitAfterSeq)
+			cancel()
+		}
+
 		visorMetadata, err := gateway.GetBlockchainMetadata()
 		if err != nil {
 			err = fmt.Errorf("gateway.GetBlockchainMetadata failed: %v", err)
@@ -84,13 +132,99 @@ func parseBoolFlag(v string) (bool, error) {
 	return strconv.ParseBool(v)
 }
 
+// BlockRaw is a readable block with its serialized form, returned by /api/v1/block when raw is set
+type BlockRaw struct {
+	readable.Block
+	Raw string `json:"raw"`
+}
+
+// BlockVerboseRaw is a verbose readable block with its serialized form, returned by /api/v1/block when raw and verbose are set
+type BlockVerboseRaw struct {
+	readable.BlockVerbose
+	Raw string `json:"raw"`
+}
+
+// serializeBlockHex returns the hex encoded serialization of a block,
+// in the encoding used to store it in the blockchain database
+func serializeBlockHex(b coin.Block) (string, error) {
+	return toPcoinBlock(b).SerializeHex()
+}
+
+// deserializeBlockHex decodes a hex encoded block serialization. All bytes must be consumed.
+func deserializeBlockHex(s string) (*coin.Block, error) {
+	pb, err := pcoin.DeserializeBlockHex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	b := fromPcoinBlock(pb)
+	return &b, nil
+}
+
+// toPcoinBlock converts a coin.Block to a pcoin.Block, whose encoder is the one of the blockchain database
+func toPcoinBlock(b coin.Block) pcoin.Block {
+	var txns pcoin.Transactions
+	for _, txn := range b.Body.Transactions {
+		var out []pcoin.TransactionOutput
+		for _, o := range txn.Out {
+			out = append(out, pcoin.TransactionOutput(o))
+		}
+
+		txns = append(txns, pcoin.Transaction{
+			Length:    txn.Length,
+			Type:      txn.Type,
+			InnerHash: txn.InnerHash,
+			Sigs:      txn.Sigs,
+			In:        txn.In,
+			Out:       out,
+		})
+	}
+
+	return pcoin.Block{
+		Head: pcoin.BlockHeader(b.Head),
+		Body: pcoin.BlockBody{
+			Transactions: txns,
+		},
+	}
+}
+
+// fromPcoinBlock converts a pcoin.Block to a coin.Block
+func fromPcoinBlock(pb pcoin.Block) coin.Block {
+	var txns coin.Transactions
+	for _, txn := range pb.Body.Transactions {
+		var out []coin.TransactionOutput
+		for _, o := range txn.Out {
+			out = append(out, coin.TransactionOutput(o))
+		}
+
+		txns = append(txns, coin.Transaction{
+			Length:    txn.Length,
+			Type:      txn.Type,
+			InnerHash: txn.InnerHash,
+			Sigs:      txn.Sigs,
+			In:        txn.In,
+			Out:       out,
+		})
+	}
+
+	return coin.Block{
+		Head: coin.BlockHeader(pb.Head),
+		Body: coin.BlockBody{
+			Transactions: txns,
+		},
+	}
+}
+
 // blockHandler returns a block by hash or seq
 // Method: GET
 // URI: /api/v1/block
 // Args:
-// 	hash [transaction hash string]
-//  seq [int]
-// 	Note: only one of hash or seq is allowed
+//
+//		hash [transaction hash string]
+//	 seq [int]
+//		Note: only one of hash or seq is allowed
+//	 verbose [bool]
+//	 raw [bool]: include the hex encoded serialized block in the "raw" field
 func blockHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -107,6 +241,12 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		raw, err := parseBoolFlag(r.FormValue("raw"))
+		if err != nil {
+			wh.Error400(w, "Invalid value for raw")
+			return
+		}
+
 		switch {
 		case hash == "" && seq == "":
 			wh.Error400(w, "should specify one filter, hash or seq")
@@ -163,6 +303,20 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
+			if raw {
+				rawBlock, err := serializeBlockHex(b.Block)
+				if err != nil {
+					wh.Error500(w, err.Error())
+					return
+				}
+
+				wh.SendJSONOr500(logger, w, BlockVerboseRaw{
+					BlockVerbose: *rb,
+					Raw:          rawBlock,
+				})
+				return
+			}
+
 			wh.SendJSONOr500(logger, w, rb)
 			return
 		}
@@ -191,10 +345,154 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if raw {
+			rawBlock, err := serializeBlockHex(b.Block)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			wh.SendJSONOr500(logger, w, BlockRaw{
+				Block: *rb,
+				Raw:   rawBlock,
+			})
+			return
+		}
+
 		wh.SendJSONOr500(logger, w, rb)
 	}
 }
 
+// DecodeBlockRequest is the request body for /api/v2/block/decode
+type DecodeBlockRequest struct {
+	Raw string `json:"raw"`
+}
+
+// decodeBlockHandler decodes a serialized block without consulting the blockchain
+// Method: POST
+// URI: /api/v2/block/decode
+// Args: JSON body, see DecodeBlockRequest
+// Response: readable.Block
+func decodeBlockHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	var req DecodeBlockRequest
+	if err := decodeJSONRequest(r, &req); err != nil {
+		resp := NewJSONDecodeErrorResponse(err)
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Raw == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "raw is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	b, err := deserializeBlockHex(req.Raw)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("decode block failed: %v", err))
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	rb, err := readable.NewBlock(*b)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: rb,
+	})
+}
+
+// BlockPreviewExcludedTxn is an unconfirmed transaction excluded from the previewed block
+type BlockPreviewExcludedTxn struct {
+	Txid   string `json:"txid"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+// BlockPreviewResponse is returned by GET /api/v2/block/preview
+type BlockPreviewResponse struct {
+	PoolSize int             `json:"pool_size"`
+	Block    *readable.Block `json:"block"`
+	// Excluded are the unconfirmed transactions not included in the block, with the reason for each
+	Excluded []BlockPreviewExcludedTxn `json:"excluded"`
+}
+
+// NewBlockPreviewResponse creates a BlockPreviewResponse from a visor.BlockPreview
+func NewBlockPreviewResponse(p *visor.BlockPreview) (*BlockPreviewResponse, error) {
+	resp := &BlockPreviewResponse{
+		PoolSize: p.PoolSize,
+		Excluded: make([]BlockPreviewExcludedTxn, len(p.Excluded)),
+	}
+
+	if p.Block != nil {
+		b, err := readable.NewBlock(*p.Block)
+		if err != nil {
+			return nil, err
+		}
+		resp.Block = b
+	}
+
+	for i, e := range p.Excluded {
+		resp.Excluded[i] = BlockPreviewExcludedTxn{
+			Txid:   e.Txid.Hex(),
+			Reason: e.Reason,
+			Detail: e.Detail,
+		}
+	}
+
+	return resp, nil
+}
+
+// blockPreviewHandler returns the block that the block publisher would create from the unconfirmed pool now.
+// The block is not signed nor executed, it is created by the same code that creates published blocks.
+// Only available on block publisher nodes.
+// Method: GET
+// URI: /api/v2/block/preview
+// Response: BlockPreviewResponse, block is null if no unconfirmed transaction can be included in a block
+func blockPreviewHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		p, err := gateway.PreviewBlock()
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrNotBlockPublisher:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		data, err := NewBlockPreviewResponse(p)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: data,
+		})
+	}
+}
+
 // blocksHandler returns blocks between a start and end point,
 // or an explicit list of sequences.
 // If using start and end, the block sequences include both the start and end point.
@@ -202,10 +500,11 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 // Method: GET, POST
 // URI: /api/v1/blocks
 // Args:
-//	start [int]
-//	end [int]
-//  seqs [comma separated list of ints]
-//  verbose [bool]
+//
+//		start [int]
+//		end [int]
+//	 seqs [comma separated list of ints]
+//	 verbose [bool]
 func blocksHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodPost {
@@ -339,8 +638,9 @@ func blocksHandler(gateway Gatewayer) http.HandlerFunc {
 // Method: GET
 // URI: /api/v1/last_blocks
 // Args:
-//	num [int]
-//  verbose [bool]
+//
+//		num [int]
+//	 verbose [bool]
 func lastBlocksHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
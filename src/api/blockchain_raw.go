@@ -0,0 +1,294 @@
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+const (
+	// rawBlocksBatchSize is the number of blocks loaded from the db at a time for the raw blocks export
+	rawBlocksBatchSize = 100
+	// HeadSeqHeaderName is the response header of /api/v2/blocks/raw with the head seq the export is anchored to
+	HeadSeqHeaderName = "X-Head-Seq"
+)
+
+// encodeRawBlock encodes a block record of the raw blocks export:
+// the little endian uint32 length of the serialized coin.SignedBlock, followed by the serialized block
+func encodeRawBlock(b coin.SignedBlock) []byte {
+	sb := encoder.Serialize(b)
+	rec := make([]byte, 4, 4+len(sb))
+	binary.LittleEndian.PutUint32(rec, uint32(len(sb)))
+	return append(rec, sb...)
+}
+
+// DecodeRawBlock decodes a block record of the /api/v2/blocks/raw export from r.
+// Returns io.EOF if r is at the end of the export.
+func DecodeRawBlock(r io.Reader) (*coin.SignedBlock, error) {
+	var n [4]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.LittleEndian.Uint32(n[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	var b coin.SignedBlock
+	if err := encoder.DeserializeRawExact(buf, &b); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// rawBlocksIndex caches the end offsets of the block records of the raw blocks export.
+// Executed blocks never change, so the offsets are valid for any head seq.
+type rawBlocksIndex struct {
+	sync.Mutex
+	ends []uint64
+}
+
+// load extends the index to the block headSeq and returns the end offsets of the blocks up to headSeq
+func (idx *rawBlocksIndex) load(gateway Gatewayer, headSeq uint64) ([]uint64, error) {
+	idx.Lock()
+	defer idx.Unlock()
+
+	for uint64(len(idx.ends)) <= headSeq {
+		start := uint64(len(idx.ends))
+		end := start + rawBlocksBatchSize - 1
+		if end > headSeq {
+			end = headSeq
+		}
+
+		blocks, err := gateway.GetBlocksInRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(blocks)) != end-start+1 {
+			return nil, fmt.Errorf("block %d does not exist", start+uint64(len(blocks)))
+		}
+
+		var offset uint64
+		if start > 0 {
+			offset = idx.ends[start-1]
+		}
+		for _, b := range blocks {
+			offset += uint64(len(encodeRawBlock(b)))
+			idx.ends = append(idx.ends, offset)
+		}
+	}
+
+	return idx.ends[:headSeq+1], nil
+}
+
+// rawBlocksReader is an io.ReadSeeker over the block records of the raw blocks export up to a head seq.
+// Blocks are loaded from the db in batches as they are read.
+type rawBlocksReader struct {
+	gateway Gatewayer
+	// ends are the end offsets of the block records, up to the head seq
+	ends   []uint64
+	offset int64
+	// buf holds the block records starting at bufStart
+	buf      []byte
+	bufStart int64
+}
+
+func (r *rawBlocksReader) size() int64 {
+	return int64(r.ends[len(r.ends)-1])
+}
+
+// Read implements io.Reader
+func (r *rawBlocksReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size() {
+		return 0, io.EOF
+	}
+
+	if r.offset < r.bufStart || r.offset >= r.bufStart+int64(len(r.buf)) {
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf[r.offset-r.bufStart:])
+	r.offset += int64(n)
+	return n, nil
+}
+
+// fill loads the batch of blocks starting with the block that contains the current offset
+func (r *rawBlocksReader) fill() error {
+	seq := uint64(sort.Search(len(r.ends), func(i int) bool {
+		return int64(r.ends[i]) > r.offset
+	}))
+
+	end := seq + rawBlocksBatchSize - 1
+	if last := uint64(len(r.ends) - 1); end > last {
+		end = last
+	}
+
+	blocks, err := r.gateway.GetBlocksInRange(seq, end)
+	if err != nil {
+		return err
+	}
+	if uint64(len(blocks)) != end-seq+1 {
+		return fmt.Errorf("block %d does not exist", seq+uint64(len(blocks)))
+	}
+
+	r.buf = r.buf[:0]
+	for _, b := range blocks {
+		r.buf = append(r.buf, encodeRawBlock(b)...)
+	}
+
+	r.bufStart = 0
+	if seq > 0 {
+		r.bufStart = int64(r.ends[seq-1])
+	}
+
+	if r.bufStart+int64(len(r.buf)) != int64(r.ends[end]) {
+		return errors.New("serialized blocks do not match the raw blocks index")
+	}
+
+	return nil
+}
+
+// Seek implements io.Seeker
+func (r *rawBlocksReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size()
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	r.offset = offset
+	return offset, nil
+}
+
+// blocksRawHandler exports the serialized blocks from the genesis block to a head seq.
+// The export is a sequence of block records, each the little endian uint32 length of
+// the serialized coin.SignedBlock followed by the serialized block. Use DecodeRawBlock to read them.
+// The content is determined by the head seq, which is returned in the X-Head-Seq header and ETag,
+// so that a download can be resumed with a byte range request for the same head seq.
+// Method: GET, HEAD
+// URI: /api/v2/blocks/raw
+// Args:
+//
+//	head_seq [int]: Last block of the export. Defaults to the blockchain head.
+//
+// Headers:
+//
+//	Range: A single byte range of the export, e.g. "bytes=1024-"
+//	If-Range: The ETag of a previous response, to resume that export
+//
+// Response: application/octet-stream, 206 Partial Content for range requests
+func blocksRawHandler(gateway Gatewayer) http.HandlerFunc {
+	var idx rawBlocksIndex
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		headSeq, ok, err := gateway.HeadBkSeq()
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+		if !ok {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "blockchain is empty")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if s := r.FormValue("head_seq"); s != "" {
+			seq, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid head_seq value")
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			if seq > headSeq {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "head_seq is greater than the blockchain head")
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			headSeq = seq
+		}
+
+		if rng := r.Header.Get("Range"); rng != "" && !isSingleByteRange(rng) {
+			resp := NewHTTPErrorResponse(http.StatusRequestedRangeNotSatisfiable, "only a single byte range is supported")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		head, err := gateway.GetSignedBlockBySeq(headSeq)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+		if head == nil {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		ends, err := idx.load(gateway, headSeq)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=blocks-%d.bin", headSeq))
+		w.Header().Set(HeadSeqHeaderName, strconv.FormatUint(headSeq, 10))
+		w.Header().Set("ETag", fmt.Sprintf(`"%d-%s"`, headSeq, head.HashHeader().Hex()))
+
+		http.ServeContent(w, r, "", time.Time{}, &rawBlocksReader{
+			gateway: gateway,
+			ends:    ends,
+		})
+	}
+}
+
+// isSingleByteRange returns true if the Range header value s requests one byte range
+func isSingleByteRange(s string) bool {
+	const prefix = "bytes="
+	if len(s) <= len(prefix) || s[:len(prefix)] != prefix {
+		return false
+	}
+
+	for _, c := range s[len(prefix):] {
+		if c == ',' {
+			return false
+		}
+	}
+
+	return true
+}
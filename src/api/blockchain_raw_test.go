@@ -0,0 +1,322 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+func TestBlocksRaw(t *testing.T) {
+	blocks := loadTestDBBlocks(t)
+	headSeq := uint64(len(blocks) - 1)
+
+	// export returns the raw blocks export up to seq
+	export := func(seq uint64) []byte {
+		var buf bytes.Buffer
+		for _, b := range blocks[:seq+1] {
+			buf.Write(encodeRawBlock(b))
+		}
+		return buf.Bytes()
+	}
+	full := export(headSeq)
+
+	etag := func(seq uint64) string {
+		return fmt.Sprintf(`"%d-%s"`, seq, blocks[seq].HashHeader().Hex())
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		query      string
+		headers    map[string]string
+		empty      bool
+		status     int
+		err        string
+		headSeq    uint64
+		body       []byte
+		contentLen int
+		contentRng string
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:   "404 - empty blockchain",
+			method: http.MethodGet,
+			empty:  true,
+			status: http.StatusNotFound,
+			err:    "blockchain is empty",
+		},
+		{
+			name:   "400 - invalid head_seq",
+			method: http.MethodGet,
+			query:  "head_seq=foo",
+			status: http.StatusBadRequest,
+			err:    "invalid head_seq value",
+		},
+		{
+			name:   "400 - head_seq above the head",
+			method: http.MethodGet,
+			query:  fmt.Sprintf("head_seq=%d", headSeq+1),
+			status: http.StatusBadRequest,
+			err:    "head_seq is greater than the blockchain head",
+		},
+		{
+			name:   "416 - multiple ranges",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Range": "bytes=0-9,20-29",
+			},
+			status: http.StatusRequestedRangeNotSatisfiable,
+			err:    "only a single byte range is supported",
+		},
+		{
+			name:   "416 - range past the end",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Range": fmt.Sprintf("bytes=%d-", len(full)),
+			},
+			status:  http.StatusRequestedRangeNotSatisfiable,
+			headSeq: headSeq,
+		},
+		{
+			name:       "200 - GET",
+			method:     http.MethodGet,
+			status:     http.StatusOK,
+			headSeq:    headSeq,
+			body:       full,
+			contentLen: len(full),
+		},
+		{
+			name:   "200 - GET not gzip compressed",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Accept-Encoding": "gzip",
+			},
+			status:     http.StatusOK,
+			headSeq:    headSeq,
+			body:       full,
+			contentLen: len(full),
+		},
+		{
+			name:       "200 - HEAD",
+			method:     http.MethodHead,
+			status:     http.StatusOK,
+			headSeq:    headSeq,
+			body:       []byte{},
+			contentLen: len(full),
+		},
+		{
+			name:       "200 - GET head_seq",
+			method:     http.MethodGet,
+			query:      "head_seq=3",
+			status:     http.StatusOK,
+			headSeq:    3,
+			body:       export(3),
+			contentLen: len(export(3)),
+		},
+		{
+			name:       "200 - HEAD head_seq",
+			method:     http.MethodHead,
+			query:      "head_seq=0",
+			status:     http.StatusOK,
+			headSeq:    0,
+			body:       []byte{},
+			contentLen: len(export(0)),
+		},
+		{
+			name:   "206 - range",
+			method: http.MethodGet,
+			query:  fmt.Sprintf("head_seq=%d", headSeq),
+			headers: map[string]string{
+				"Range":    "bytes=1000-",
+				"If-Range": etag(headSeq),
+			},
+			status:     http.StatusPartialContent,
+			headSeq:    headSeq,
+			body:       full[1000:],
+			contentLen: len(full) - 1000,
+			contentRng: fmt.Sprintf("bytes 1000-%d/%d", len(full)-1, len(full)),
+		},
+		{
+			name:   "206 - range within a block",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Range": "bytes=10-20",
+			},
+			status:     http.StatusPartialContent,
+			headSeq:    headSeq,
+			body:       full[10:21],
+			contentLen: 11,
+			contentRng: fmt.Sprintf("bytes 10-20/%d", len(full)),
+		},
+		{
+			name:   "200 - range with a stale If-Range",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Range":    "bytes=1000-",
+				"If-Range": etag(headSeq - 1),
+			},
+			status:     http.StatusOK,
+			headSeq:    headSeq,
+			body:       full,
+			contentLen: len(full),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.empty {
+				gateway.On("HeadBkSeq").Return(uint64(0), false, nil)
+			} else {
+				gateway.On("HeadBkSeq").Return(headSeq, true, nil)
+			}
+			gateway.On("GetSignedBlockBySeq", mock.Anything).Return(func(seq uint64) *coin.SignedBlock {
+				return &blocks[seq]
+			}, nil)
+			gateway.On("GetBlocksInRange", mock.Anything, mock.Anything).Return(func(start, end uint64) []coin.SignedBlock {
+				return blocks[start : end+1]
+			}, nil)
+
+			endpoint := "/api/v2/blocks/raw"
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if tc.err != "" {
+				var resp HTTPResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Equal(t, strconv.FormatUint(tc.headSeq, 10), rr.Header().Get(HeadSeqHeaderName))
+			require.Equal(t, etag(tc.headSeq), rr.Header().Get("ETag"))
+			if tc.body == nil {
+				return
+			}
+
+			require.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+			require.Equal(t, "bytes", rr.Header().Get("Accept-Ranges"))
+			require.Equal(t, strconv.Itoa(tc.contentLen), rr.Header().Get("Content-Length"))
+			require.Equal(t, tc.contentRng, rr.Header().Get("Content-Range"))
+			require.Empty(t, rr.Header().Get("Content-Encoding"))
+			if tc.method == http.MethodHead {
+				require.Empty(t, rr.Body.Bytes())
+			} else {
+				require.Equal(t, tc.body, rr.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestDecodeRawBlock(t *testing.T) {
+	blocks := loadTestDBBlocks(t)[:5]
+
+	var buf bytes.Buffer
+	for _, b := range blocks {
+		buf.Write(encodeRawBlock(b))
+	}
+	raw := buf.Bytes()
+
+	r := bytes.NewReader(raw)
+	for _, b := range blocks {
+		decoded, err := DecodeRawBlock(r)
+		require.NoError(t, err)
+		require.Equal(t, b, *decoded)
+	}
+
+	_, err := DecodeRawBlock(r)
+	require.Equal(t, io.EOF, err)
+
+	// Truncated record
+	_, err = DecodeRawBlock(bytes.NewReader(raw[:len(encodeRawBlock(blocks[0]))-1]))
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestRawBlocksReader(t *testing.T) {
+	blocks := loadTestDBBlocks(t)
+	headSeq := uint64(len(blocks) - 1)
+
+	gateway := &MockGatewayer{}
+	gateway.On("GetBlocksInRange", mock.Anything, mock.Anything).Return(func(start, end uint64) []coin.SignedBlock {
+		return blocks[start : end+1]
+	}, nil)
+
+	var idx rawBlocksIndex
+	ends, err := idx.load(gateway, 10)
+	require.NoError(t, err)
+	require.Len(t, ends, 11)
+
+	// Extending the index keeps the offsets of the loaded blocks
+	allEnds, err := idx.load(gateway, headSeq)
+	require.NoError(t, err)
+	require.Len(t, allEnds, len(blocks))
+	require.Equal(t, ends, allEnds[:11])
+
+	var full []byte
+	for i, b := range blocks {
+		full = append(full, encodeRawBlock(b)...)
+		require.Equal(t, uint64(len(full)), allEnds[i])
+	}
+
+	r := &rawBlocksReader{
+		gateway: gateway,
+		ends:    allEnds,
+	}
+
+	// Read in small chunks across batch boundaries
+	var out bytes.Buffer
+	_, err = io.CopyBuffer(&out, r, make([]byte, 777))
+	require.NoError(t, err)
+	require.Equal(t, full, out.Bytes())
+
+	// Seek back into the first batch
+	n, err := r.Seek(-int64(len(full))+5, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+
+	b := make([]byte, 10)
+	_, err = io.ReadFull(r, b)
+	require.NoError(t, err)
+	require.Equal(t, full[5:15], b)
+
+	_, err = r.Seek(-1, io.SeekStart)
+	require.Error(t, err)
+
+	// A missing block fails the read
+	missing := &MockGatewayer{}
+	missing.On("GetBlocksInRange", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err = (&rawBlocksReader{
+		gateway: missing,
+		ends:    allEnds,
+	}).Read(b)
+	require.EqualError(t, err, "block 0 does not exist")
+}
@@ -1,27 +1,38 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"encoding/json"
 
 	"math"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"errors"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 func TestGetBlockchainMetadata(t *testing.T) {
@@ -110,6 +121,156 @@ func TestGetBlockchainMetadata(t *testing.T) {
 	}
 }
 
+func TestGetBlockchainMetadataLongPoll(t *testing.T) {
+	metadata := &visor.BlockchainMetadata{
+		HeadBlock: coin.SignedBlock{
+			Block: coin.Block{
+				Head: coin.BlockHeader{
+					BkSeq: 11,
+				},
+			},
+		},
+		Unspents:    12,
+		Unconfirmed: 13,
+	}
+
+	doRequest := func(t *testing.T, cfg muxConfig, gateway Gatewayer, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/blockchain/metadata?"+query, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		newServerMux(cfg, gateway).ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("invalid params", func(t *testing.T) {
+		cases := []struct {
+			query string
+			err   string
+		}{
+			{"timeout=10", "400 Bad Request - timeout requires wait_after_seq"},
+			{"wait_after_seq=foo", "400 Bad Request - Invalid wait_after_seq value"},
+			{"wait_after_seq=-1", "400 Bad Request - Invalid wait_after_seq value"},
+			{"wait_after_seq=10&timeout=foo", "400 Bad Request - Invalid timeout value"},
+			{"wait_after_seq=10&timeout=-1", "400 Bad Request - Invalid timeout value"},
+		}
+
+		for _, tc := range cases {
+			rr := doRequest(t, defaultMuxConfig(), &MockGatewayer{}, tc.query)
+			require.Equal(t, http.StatusBadRequest, rr.Code, tc.query)
+			require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), tc.query)
+		}
+	})
+
+	t.Run("timeout is capped", func(t *testing.T) {
+		cfg := defaultMuxConfig()
+		cfg.maxLongPollTimeout = time.Second * 5
+
+		cases := []struct {
+			query   string
+			timeout time.Duration
+		}{
+			{"wait_after_seq=10", time.Second * 5},
+			{"wait_after_seq=10&timeout=2", time.Second * 2},
+			{"wait_after_seq=10&timeout=5", time.Second * 5},
+			{"wait_after_seq=10&timeout=1000", time.Second * 5},
+			{"wait_after_seq=10&timeout=0", 0},
+		}
+
+		for _, tc := range cases {
+			gateway := &MockGatewayer{}
+			gateway.On("GetBlockchainMetadata").Return(metadata, nil)
+			gateway.On("WaitHeadSeqAfter", mock.Anything, uint64(10)).Return(func(ctx context.Context, seq uint64) bool {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok)
+				remaining := time.Until(deadline)
+				require.True(t, remaining <= tc.timeout, "%s: %v > %v", tc.query, remaining, tc.timeout)
+				require.True(t, remaining > tc.timeout-time.Second, "%s: %v", tc.query, remaining)
+				return false
+			})
+
+			rr := doRequest(t, cfg, gateway, tc.query)
+			require.Equal(t, http.StatusOK, rr.Code, tc.query)
+			gateway.AssertNumberOfCalls(t, "WaitHeadSeqAfter", 1)
+		}
+	})
+
+	t.Run("timeout returns current metadata", func(t *testing.T) {
+		cfg := defaultMuxConfig()
+		cfg.maxLongPollTimeout = time.Millisecond * 50
+
+		gateway := &MockGatewayer{}
+		gateway.On("GetBlockchainMetadata").Return(metadata, nil)
+		gateway.On("WaitHeadSeqAfter", mock.Anything, uint64(11)).Return(func(ctx context.Context, seq uint64) bool {
+			<-ctx.Done()
+			return false
+		})
+
+		rr := doRequest(t, cfg, gateway, "wait_after_seq=11&timeout=30")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var msg readable.BlockchainMetadata
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &msg))
+		require.Equal(t, uint64(11), msg.Head.BkSeq)
+	})
+
+	t.Run("block wakes waiters", func(t *testing.T) {
+		// Simulate the visor applying a block by closing blockApplied
+		blockApplied := make(chan struct{})
+		var waiting sync.WaitGroup
+
+		var mx sync.Mutex
+		current := metadata
+
+		gateway := &MockGatewayer{}
+		gateway.On("GetBlockchainMetadata").Return(func() *visor.BlockchainMetadata {
+			mx.Lock()
+			defer mx.Unlock()
+			return current
+		}, nil)
+		gateway.On("WaitHeadSeqAfter", mock.Anything, uint64(11)).Return(func(ctx context.Context, seq uint64) bool {
+			waiting.Done()
+			select {
+			case <-blockApplied:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+
+		n := 50
+		waiting.Add(n)
+		results := make(chan *httptest.ResponseRecorder, n)
+		for i := 0; i < n; i++ {
+			go func() {
+				results <- doRequest(t, defaultMuxConfig(), gateway, "wait_after_seq=11")
+			}()
+		}
+
+		waiting.Wait()
+
+		mx.Lock()
+		next := *metadata
+		next.HeadBlock.Block.Head.BkSeq = 12
+		current = &next
+		mx.Unlock()
+		close(blockApplied)
+
+		for i := 0; i < n; i++ {
+			select {
+			case rr := <-results:
+				require.Equal(t, http.StatusOK, rr.Code)
+
+				var msg readable.BlockchainMetadata
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &msg))
+				require.Equal(t, uint64(12), msg.Head.BkSeq)
+			case <-time.After(time.Second * 5):
+				t.Fatal("waiter was not woken")
+			}
+		}
+	})
+}
+
 func TestGetBlockchainProgress(t *testing.T) {
 	addr1 := testutil.MakeAddress()
 	addr2 := testutil.MakeAddress()
@@ -597,6 +758,342 @@ func TestGetBlock(t *testing.T) {
 	}
 }
 
+// loadTestDBBlocks returns every block stored in the integration test database
+func loadTestDBBlocks(t *testing.T) []coin.SignedBlock {
+	db, err := visor.OpenDB(filepath.Join("integration", "testdata", "blockchain-180.db"), true)
+	require.NoError(t, err)
+	defer db.Close()
+
+	bc, err := blockdb.NewBlockchain(db, visor.DefaultWalker)
+	require.NoError(t, err)
+
+	var blocks []coin.SignedBlock
+	err = db.View("loadTestDBBlocks", func(tx *dbutil.Tx) error {
+		head, ok, err := bc.HeadSeq(tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		for seq := uint64(0); seq <= head; seq++ {
+			b, err := bc.GetSignedBlockBySeq(tx, seq)
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			blocks = append(blocks, *b)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, blocks)
+
+	return blocks
+}
+
+func TestGetBlockRawRoundTrip(t *testing.T) {
+	blocks := loadTestDBBlocks(t)
+
+	for _, b := range blocks {
+		b := b
+		gateway := &MockGatewayer{}
+		gateway.On("GetSignedBlockBySeq", b.Seq()).Return(&b, nil)
+		handler := newServerMux(defaultMuxConfig(), gateway)
+
+		v := url.Values{}
+		v.Add("seq", fmt.Sprint(b.Seq()))
+		v.Add("raw", "1")
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/block?"+v.Encode(), nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var rb BlockRaw
+		err = json.Unmarshal(rr.Body.Bytes(), &rb)
+		require.NoError(t, err)
+		require.Equal(t, b.HashHeader().Hex(), rb.Head.Hash)
+
+		decoded, err := deserializeBlockHex(rb.Raw)
+		require.NoError(t, err)
+		require.Equal(t, b.HashHeader(), decoded.HashHeader())
+		require.Equal(t, b.Block, *decoded)
+
+		// Decoding through the API gives the same readable block as the block endpoint
+		body, err := json.Marshal(DecodeBlockRequest{Raw: rb.Raw})
+		require.NoError(t, err)
+		req, err = http.NewRequest(http.MethodPost, "/api/v2/block/decode", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		setCSRFParameters(t, tokenValid, req)
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp struct {
+			Data readable.Block `json:"data"`
+		}
+		err = json.Unmarshal(rr.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		require.Equal(t, rb.Block, resp.Data)
+	}
+}
+
+func TestSerializeBlockHexBlockDB(t *testing.T) {
+	db, err := visor.OpenDB(filepath.Join("integration", "testdata", "blockchain-180.db"), true)
+	require.NoError(t, err)
+	defer db.Close()
+
+	n := 0
+	err = db.View("TestSerializeBlockHexBlockDB", func(tx *dbutil.Tx) error {
+		return dbutil.ForEach(tx, blockdb.BlocksBkt, func(k, v []byte) error {
+			b, err := deserializeBlockHex(hex.EncodeToString(v))
+			require.NoError(t, err)
+			h := b.HashHeader()
+			require.Equal(t, k, h[:])
+
+			// The raw block is the serialization stored by blockdb
+			raw, err := serializeBlockHex(*b)
+			require.NoError(t, err)
+			require.Equal(t, hex.EncodeToString(v), raw)
+
+			n++
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	require.NotZero(t, n)
+}
+
+func TestGetBlockRawVerbose(t *testing.T) {
+	b := loadTestDBBlocks(t)[0]
+	inputs := [][]visor.TransactionInput{{}}
+
+	gateway := &MockGatewayer{}
+	gateway.On("GetSignedBlockByHashVerbose", b.HashHeader()).Return(&b, inputs, nil)
+
+	v := url.Values{}
+	v.Add("hash", b.HashHeader().Hex())
+	v.Add("verbose", "1")
+	v.Add("raw", "true")
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/block?"+v.Encode(), nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var rb BlockVerboseRaw
+	err = json.Unmarshal(rr.Body.Bytes(), &rb)
+	require.NoError(t, err)
+
+	expected, err := readable.NewBlockVerbose(b.Block, inputs)
+	require.NoError(t, err)
+	require.Equal(t, *expected, rb.BlockVerbose)
+
+	decoded, err := deserializeBlockHex(rb.Raw)
+	require.NoError(t, err)
+	require.Equal(t, b.HashHeader(), decoded.HashHeader())
+}
+
+func TestDecodeBlock(t *testing.T) {
+	b := loadTestDBBlocks(t)[1]
+	raw, err := serializeBlockHex(b.Block)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		err    string
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:   "400 - invalid json",
+			method: http.MethodPost,
+			body:   "{",
+			status: http.StatusBadRequest,
+			err:    "invalid JSON: unexpected end of the request body",
+		},
+		{
+			name:   "400 - missing raw",
+			method: http.MethodPost,
+			body:   "{}",
+			status: http.StatusBadRequest,
+			err:    "raw is required",
+		},
+		{
+			name:   "400 - invalid hex",
+			method: http.MethodPost,
+			body:   `{"raw":"zz"}`,
+			status: http.StatusBadRequest,
+			err:    "decode block failed: encoding/hex: invalid byte: U+007A 'z'",
+		},
+		{
+			name:   "400 - trailing bytes",
+			method: http.MethodPost,
+			body:   fmt.Sprintf(`{"raw":"%s00"}`, raw),
+			status: http.StatusBadRequest,
+			err:    "decode block failed: Invalid block body: Bytes remain in buffer after deserializing object",
+		},
+		{
+			name:   "400 - truncated",
+			method: http.MethodPost,
+			body:   fmt.Sprintf(`{"raw":"%s"}`, raw[:len(raw)-2]),
+			status: http.StatusBadRequest,
+			err:    "decode block failed: Invalid block body: Not enough buffer data to deserialize",
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body:   fmt.Sprintf(`{"raw":"%s"}`, raw),
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/api/v2/block/decode", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), &MockGatewayer{}).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp struct {
+				Error *HTTPError      `json:"error"`
+				Data  *readable.Block `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.status != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			expected, err := readable.NewBlock(b.Block)
+			require.NoError(t, err)
+			require.Equal(t, *expected, *resp.Data)
+		})
+	}
+}
+
+func TestBlockPreview(t *testing.T) {
+	b := loadTestDBBlocks(t)[1]
+	rb, err := readable.NewBlock(b.Block)
+	require.NoError(t, err)
+
+	excludedTxid := testutil.RandSHA256(t)
+
+	cases := []struct {
+		name       string
+		method     string
+		preview    *visor.BlockPreview
+		previewErr error
+		status     int
+		err        string
+		result     *BlockPreviewResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:       "403 - not a block publisher",
+			method:     http.MethodGet,
+			previewErr: visor.ErrNotBlockPublisher,
+			status:     http.StatusForbidden,
+			err:        "Node is not a block publisher",
+		},
+		{
+			name:       "500 - preview failed",
+			method:     http.MethodGet,
+			previewErr: errors.New("preview failed"),
+			status:     http.StatusInternalServerError,
+			err:        "preview failed",
+		},
+		{
+			name:    "200 - empty pool",
+			method:  http.MethodGet,
+			preview: &visor.BlockPreview{},
+			status:  http.StatusOK,
+			result: &BlockPreviewResponse{
+				Excluded: []BlockPreviewExcludedTxn{},
+			},
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			preview: &visor.BlockPreview{
+				PoolSize: len(b.Body.Transactions) + 1,
+				Block:    &b.Block,
+				Excluded: []visor.ExcludedTxn{
+					{
+						Txid:   excludedTxid,
+						Reason: visor.ExcludeReasonConstraint,
+						Detail: "Transaction violates soft constraint: invalid amount, too many decimal places",
+					},
+				},
+			},
+			status: http.StatusOK,
+			result: &BlockPreviewResponse{
+				PoolSize: len(b.Body.Transactions) + 1,
+				Block:    rb,
+				Excluded: []BlockPreviewExcludedTxn{
+					{
+						Txid:   excludedTxid.Hex(),
+						Reason: "constraint",
+						Detail: "Transaction violates soft constraint: invalid amount, too many decimal places",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("PreviewBlock").Return(tc.preview, tc.previewErr)
+
+			req, err := http.NewRequest(tc.method, "/api/v2/block/preview", nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp struct {
+				Error *HTTPError            `json:"error"`
+				Data  *BlockPreviewResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.status != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
+
 func TestGetBlocks(t *testing.T) {
 	type httpBody struct {
 		Start   string
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ness-network/privateness/src/readable"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+const (
+	// blocksStreamBatchSize is the number of blocks loaded from the db at a time for the blocks stream.
+	// The stream is flushed after each batch.
+	blocksStreamBatchSize = 100
+	// blocksStreamBufferSize is the number of executed block notifications buffered for a following stream.
+	// A stream that falls further behind is ended, the client resumes it with since set to the next seq.
+	blocksStreamBufferSize = 256
+)
+
+// errBlocksStreamEnded is returned when a blocks stream ends before it caught up,
+// because the client went away or the maximum stream duration passed
+var errBlocksStreamEnded = errors.New("blocks stream ended")
+
+// blocksStreamHandler streams verbose blocks as newline-delimited JSON, one readable.BlockVerbose per line,
+// from a seq to the blockchain head. With follow, the stream stays open and blocks are written as they are executed.
+// The stream ends after maxDuration, or if the client does not keep up with the executed blocks;
+// the client resumes it with since set to the seq after the last block it received.
+// Method: GET
+// URI: /api/v2/blocks/stream
+// Args:
+//
+//	since [int]: Seq of the first block. Defaults to 0.
+//	follow [bool]: Keep the stream open and write the blocks executed after the head. Defaults to false.
+//
+// Headers:
+//
+//	Accept-Encoding: The stream is gzip compressed if gzip is accepted
+//
+// Response: application/x-ndjson, with the head seq at the start of the stream in the X-Head-Seq header
+func blocksStreamHandler(gateway Gatewayer, maxDuration time.Duration) http.HandlerFunc {
+	if maxDuration == 0 {
+		maxDuration = defaultMaxStreamDuration
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var since uint64
+		if s := r.FormValue("since"); s != "" {
+			var err error
+			since, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid since value")
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		var follow bool
+		if s := r.FormValue("follow"); s != "" {
+			var err error
+			follow, err = strconv.ParseBool(s)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid follow value")
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, "streaming is not supported")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		// Subscribe before reading the head, so that no block executed after the head is missed
+		var sub *visor.BlockSubscription
+		if follow {
+			sub = gateway.SubscribeBlocks(blocksStreamBufferSize)
+			defer sub.Close()
+		}
+
+		headSeq, ok, err := gateway.HeadBkSeq()
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+		if !ok {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "blockchain is empty")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if since > headSeq+1 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "since is greater than the blockchain head seq + 1")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Vary", "Accept-Encoding")
+		w.Header().Set(HeadSeqHeaderName, strconv.FormatUint(headSeq, 10))
+
+		bs := &blocksStream{
+			gateway: gateway,
+			w:       w,
+			flusher: flusher,
+			done:    r.Context().Done(),
+			timeout: time.After(maxDuration),
+		}
+
+		if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			w.Header().Set("Content-Encoding", "gzip")
+			bs.gz = gzip.NewWriter(w)
+			bs.w = bs.gz
+			defer bs.gz.Close()
+		}
+
+		bs.enc = json.NewEncoder(bs.w)
+
+		// Send the headers now, a following stream may not write a block for a while
+		w.WriteHeader(http.StatusOK)
+		if err := bs.flush(); err != nil {
+			return
+		}
+
+		// The response status has been sent, errors from here on end the stream
+		next := since
+		if since <= headSeq {
+			if err := bs.writeRange(since, headSeq); err != nil {
+				bs.logEnd(err)
+				return
+			}
+			next = headSeq + 1
+		}
+
+		if sub == nil {
+			return
+		}
+
+		for {
+			select {
+			case seq, ok := <-sub.C():
+				if !ok {
+					if sub.Overflowed() {
+						requestLogger(r).WithField("seq", next).Warning("blocksStreamHandler: client fell behind the executed blocks, ending the stream")
+					}
+					return
+				}
+
+				if seq < next {
+					continue
+				}
+
+				if err := bs.writeRange(next, seq); err != nil {
+					bs.logEnd(err)
+					return
+				}
+				next = seq + 1
+			case <-bs.done:
+				return
+			case <-bs.timeout:
+				return
+			}
+		}
+	}
+}
+
+// blocksStream writes the blocks of a blocks stream
+type blocksStream struct {
+	gateway Gatewayer
+	w       io.Writer
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	flusher http.Flusher
+	done    <-chan struct{}
+	timeout <-chan time.Time
+}
+
+// writeRange writes the blocks from start to end, including both, in batches, flushing after each batch.
+// Returns errBlocksStreamEnded if the stream ended before the blocks were written.
+func (bs *blocksStream) writeRange(start, end uint64) error {
+	for seq := start; seq <= end; seq += blocksStreamBatchSize {
+		select {
+		case <-bs.done:
+			return errBlocksStreamEnded
+		case <-bs.timeout:
+			return errBlocksStreamEnded
+		default:
+		}
+
+		batchEnd := seq + blocksStreamBatchSize - 1
+		if batchEnd > end {
+			batchEnd = end
+		}
+
+		blocks, inputs, err := bs.gateway.GetBlocksInRangeVerbose(seq, batchEnd)
+		if err != nil {
+			return err
+		}
+
+		for i, b := range blocks {
+			rb, err := readable.NewBlockVerbose(b.Block, inputs[i])
+			if err != nil {
+				return err
+			}
+
+			if err := bs.enc.Encode(rb); err != nil {
+				return err
+			}
+		}
+
+		if err := bs.flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flush flushes the gzip writer, if the stream is compressed, and the response
+func (bs *blocksStream) flush() error {
+	if bs.gz != nil {
+		if err := bs.gz.Flush(); err != nil {
+			return err
+		}
+	}
+
+	bs.flusher.Flush()
+	return nil
+}
+
+func (bs *blocksStream) logEnd(err error) {
+	if err != errBlocksStreamEnded {
+		logger.WithError(err).Error("blocksStreamHandler: stream failed")
+	}
+}
+
+// acceptsGzip returns true if the Accept-Encoding header value s accepts gzip
+func acceptsGzip(s string) bool {
+	for _, enc := range strings.Split(s, ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+
+		accepted := true
+		for _, p := range parts[1:] {
+			p = strings.Replace(p, " ", "", -1)
+			if strings.HasPrefix(p, "q=") {
+				q, err := strconv.ParseFloat(p[2:], 64)
+				accepted = err == nil && q > 0
+			}
+		}
+		return accepted
+	}
+
+	return false
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+// blocksStreamGateway returns a gateway of blocks with the given head seq
+func blocksStreamGateway(blocks []coin.SignedBlock, headSeq uint64) *MockGatewayer {
+	gateway := &MockGatewayer{}
+	gateway.On("HeadBkSeq").Return(headSeq, true, nil)
+	gateway.On("GetBlocksInRangeVerbose", mock.Anything, mock.Anything).Return(func(start, end uint64) []coin.SignedBlock {
+		return blocks[start : end+1]
+	}, func(start, end uint64) [][][]visor.TransactionInput {
+		inputs := make([][][]visor.TransactionInput, 0, end-start+1)
+		for _, b := range blocks[start : end+1] {
+			inputs = append(inputs, blockStreamInputs(b))
+		}
+		return inputs
+	}, nil)
+	return gateway
+}
+
+// blockStreamInputs returns placeholder verbose inputs of a block, the first input of a transaction has its output hours
+func blockStreamInputs(b coin.SignedBlock) [][]visor.TransactionInput {
+	inputs := make([][]visor.TransactionInput, len(b.Body.Transactions))
+	for i, txn := range b.Body.Transactions {
+		inputs[i] = make([]visor.TransactionInput, len(txn.In))
+		if len(txn.In) == 0 {
+			continue
+		}
+
+		hours, err := txn.OutputHours()
+		if err != nil {
+			panic(err)
+		}
+		inputs[i][0].CalculatedHours = hours
+	}
+	return inputs
+}
+
+// readBlocksStream decodes the blocks of a blocks stream until its end
+func readBlocksStream(t *testing.T, r io.Reader) []readable.BlockVerbose {
+	var blocks []readable.BlockVerbose
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var b readable.BlockVerbose
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &b))
+		blocks = append(blocks, b)
+	}
+	require.NoError(t, scanner.Err())
+	return blocks
+}
+
+func expectedStreamBlocks(t *testing.T, blocks []coin.SignedBlock) []readable.BlockVerbose {
+	var expected []readable.BlockVerbose
+	for _, b := range blocks {
+		rb, err := readable.NewBlockVerbose(b.Block, blockStreamInputs(b))
+		require.NoError(t, err)
+		expected = append(expected, *rb)
+	}
+	return expected
+}
+
+func TestBlocksStream(t *testing.T) {
+	blocks := loadTestDBBlocks(t)
+	headSeq := uint64(len(blocks) - 1)
+
+	cases := []struct {
+		name   string
+		method string
+		query  string
+		gzip   bool
+		empty  bool
+		status int
+		err    string
+		blocks []coin.SignedBlock
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:   "404 - empty blockchain",
+			method: http.MethodGet,
+			empty:  true,
+			status: http.StatusNotFound,
+			err:    "blockchain is empty",
+		},
+		{
+			name:   "400 - invalid since",
+			method: http.MethodGet,
+			query:  "since=foo",
+			status: http.StatusBadRequest,
+			err:    "invalid since value",
+		},
+		{
+			name:   "400 - invalid follow",
+			method: http.MethodGet,
+			query:  "follow=foo",
+			status: http.StatusBadRequest,
+			err:    "invalid follow value",
+		},
+		{
+			name:   "400 - since above the head",
+			method: http.MethodGet,
+			query:  fmt.Sprintf("since=%d", headSeq+2),
+			status: http.StatusBadRequest,
+			err:    "since is greater than the blockchain head seq + 1",
+		},
+		{
+			name:   "200 - all blocks",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			blocks: blocks,
+		},
+		{
+			name:   "200 - since",
+			method: http.MethodGet,
+			query:  "since=170",
+			status: http.StatusOK,
+			blocks: blocks[170:],
+		},
+		{
+			name:   "200 - since after the head",
+			method: http.MethodGet,
+			query:  fmt.Sprintf("since=%d", headSeq+1),
+			status: http.StatusOK,
+		},
+		{
+			name:   "200 - gzip",
+			method: http.MethodGet,
+			query:  "since=10",
+			gzip:   true,
+			status: http.StatusOK,
+			blocks: blocks[10:],
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := blocksStreamGateway(blocks, headSeq)
+			if tc.empty {
+				gateway = &MockGatewayer{}
+				gateway.On("HeadBkSeq").Return(uint64(0), false, nil)
+			}
+
+			endpoint := "/api/v2/blocks/stream"
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			if tc.gzip {
+				req.Header.Set("Accept-Encoding", "gzip")
+			}
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if tc.err != "" {
+				var resp HTTPResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+			require.Equal(t, strconv.FormatUint(headSeq, 10), rr.Header().Get(HeadSeqHeaderName))
+			require.True(t, rr.Flushed)
+
+			var body io.Reader = rr.Body
+			if tc.gzip {
+				require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+				gz, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+				body = gz
+			} else {
+				require.Empty(t, rr.Header().Get("Content-Encoding"))
+			}
+
+			require.Equal(t, expectedStreamBlocks(t, tc.blocks), readBlocksStream(t, body))
+		})
+	}
+}
+
+func TestBlocksStreamFollow(t *testing.T) {
+	blocks := loadTestDBBlocks(t)
+	headSeq := uint64(len(blocks) - 3)
+
+	t.Run("new blocks are streamed until the max duration", func(t *testing.T) {
+		var subs visor.BlockSubscriptions
+		subscribed := make(chan struct{})
+
+		gateway := blocksStreamGateway(blocks, headSeq)
+		gateway.On("SubscribeBlocks", blocksStreamBufferSize).Return(func(n int) *visor.BlockSubscription {
+			defer close(subscribed)
+			return subs.Subscribe(n)
+		})
+
+		cfg := defaultMuxConfig()
+		cfg.disableHeaderCheck = true
+		cfg.maxStreamDuration = time.Second
+		srv := httptest.NewServer(newServerMux(cfg, gateway))
+		defer srv.Close()
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v2/blocks/stream?since=%d&follow=1", srv.URL, headSeq), nil)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+		<-subscribed
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		dec := json.NewDecoder(gz)
+
+		// Each block is received as soon as it is executed, the stream is flushed through the compressor
+		expected := expectedStreamBlocks(t, blocks[headSeq:])
+		for i, e := range expected {
+			if i > 0 {
+				subs.Notify(headSeq + uint64(i))
+			}
+
+			var b readable.BlockVerbose
+			require.NoError(t, dec.Decode(&b))
+			require.Equal(t, e, b)
+		}
+
+		// The stream ends cleanly after the max duration
+		require.Equal(t, io.EOF, dec.Decode(&readable.BlockVerbose{}))
+	})
+
+	t.Run("stream ends if the client falls behind", func(t *testing.T) {
+		var subs visor.BlockSubscriptions
+
+		gateway := blocksStreamGateway(blocks, headSeq)
+		gateway.On("SubscribeBlocks", blocksStreamBufferSize).Return(func(n int) *visor.BlockSubscription {
+			// Two blocks are executed while the stream catches up, overflowing a buffer of one
+			sub := subs.Subscribe(1)
+			subs.Notify(headSeq + 1)
+			subs.Notify(headSeq + 2)
+			return sub
+		})
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v2/blocks/stream?since=%d&follow=true", headSeq), nil)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		setCSRFParameters(t, tokenValid, req)
+
+		rr := httptest.NewRecorder()
+		newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		// The buffered block is written before the stream ends
+		require.Equal(t, expectedStreamBlocks(t, blocks[headSeq:headSeq+2]), readBlocksStream(t, rr.Body))
+	})
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	cases := []struct {
+		header string
+		ok     bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip", true},
+		{"gzip;q=0.5", true},
+		{"gzip; q=0", false},
+		{"gzip;q=0.0", false},
+		{"deflate", false},
+		{"gzipx", false},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.ok, acceptsGzip(tc.header), tc.header)
+	}
+}
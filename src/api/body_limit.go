@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/params"
+)
+
+const (
+	// defaultMaxWalletBodySize is the default maximum request body size of the wallet endpoints
+	defaultMaxWalletBodySize = 32 * 1024
+	// defaultMaxStorageBodySize is the default maximum request body size of the key-value storage endpoints
+	defaultMaxStorageBodySize = 1024 * 1024
+	// defaultMaxBodySize is the default maximum request body size of the other endpoints.
+	// It allows a /api/v1/balance request of defaultMaxBalanceAddresses addresses.
+	defaultMaxBodySize = 2 * 1024 * 1024
+	// transactionBodyOverhead is the size allowed for the JSON encoding and the other fields
+	// of the body of a transaction endpoint, in addition to the hex encoded transactions
+	transactionBodyOverhead = 16 * 1024
+)
+
+// transactionBodyEndpoints are the endpoints whose request body contains transactions or a block
+var transactionBodyEndpoints = map[string]struct{}{
+	"/api/v1/injectTransaction":       {},
+	"/api/v1/wallet/transaction":      {},
+	"/api/v2/transaction":             {},
+	"/api/v2/transaction/verify":      {},
+	"/api/v2/transaction/test-accept": {},
+	"/api/v2/wallet/transaction/sign": {},
+	"/api/v2/wallet/transaction/bump": {},
+	"/api/v2/block/decode":            {},
+}
+
+// storageBodyEndpoints are the key-value storage endpoints
+var storageBodyEndpoints = map[string]struct{}{
+	"/api/v2/data": {},
+}
+
+// BodyLimits are the maximum request body sizes of the endpoints, in bytes.
+// A zero limit is replaced by its default.
+type BodyLimits struct {
+	// Wallet is the limit of the wallet endpoints, defaults to 32KB
+	Wallet int64 `json:"wallet"`
+	// Transaction is the limit of the endpoints whose body contains transactions or a block,
+	// defaults to TransactionBodyLimit of the default max block size
+	Transaction int64 `json:"transaction"`
+	// Storage is the limit of the key-value storage endpoints, defaults to 1MB
+	Storage int64 `json:"storage"`
+	// Default is the limit of the other endpoints, defaults to 2MB
+	Default int64 `json:"default"`
+}
+
+// TransactionBodyLimit returns the maximum request body size of the transaction endpoints for a max block size.
+// The transactions are hex encoded, which doubles their size.
+func TransactionBodyLimit(maxBlockSize uint32) int64 {
+	return 2*int64(maxBlockSize) + transactionBodyOverhead
+}
+
+// withDefaults returns the limits with the zero limits replaced by their defaults
+func (l BodyLimits) withDefaults() BodyLimits {
+	if l.Wallet == 0 {
+		l.Wallet = defaultMaxWalletBodySize
+	}
+	if l.Transaction == 0 {
+		l.Transaction = TransactionBodyLimit(params.UserVerifyTxn.MaxTransactionSize)
+	}
+	if l.Storage == 0 {
+		l.Storage = defaultMaxStorageBodySize
+	}
+	if l.Default == 0 {
+		l.Default = defaultMaxBodySize
+	}
+	return l
+}
+
+// endpoint returns the limit of an endpoint
+func (l BodyLimits) endpoint(endpoint string) int64 {
+	if _, ok := transactionBodyEndpoints[endpoint]; ok {
+		return l.Transaction
+	}
+
+	if _, ok := storageBodyEndpoints[endpoint]; ok {
+		return l.Storage
+	}
+
+	if strings.HasPrefix(endpoint, "/api/v1/wallet") || strings.HasPrefix(endpoint, "/api/v2/wallet") {
+		return l.Wallet
+	}
+
+	return l.Default
+}
+
+// bodyLimitHandler rejects a request whose body exceeds limit bytes with 413 Request Entity Too Large.
+// The body is read before the handler is called, so the handler is not called for a rejected request
+// and can't make a partial change.
+func bodyLimitHandler(apiVersion string, limit int64, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		msg := fmt.Sprintf("Request body exceeds the limit of %d bytes", limit)
+
+		if r.ContentLength > limit {
+			writeError(w, apiVersion, http.StatusRequestEntityTooLarge, msg)
+			return
+		}
+
+		// The Content-Length can be missing, e.g. for a chunked body
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			if int64(len(body)) >= limit {
+				writeError(w, apiVersion, http.StatusRequestEntityTooLarge, msg)
+			} else {
+				writeError(w, apiVersion, http.StatusBadRequest, fmt.Sprintf("Read request body failed: %v", err))
+			}
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/params"
+)
+
+func TestBodyLimitsEndpoint(t *testing.T) {
+	limits := BodyLimits{
+		Wallet:      1,
+		Transaction: 2,
+		Storage:     3,
+		Default:     4,
+	}
+
+	cases := []struct {
+		endpoint string
+		limit    int64
+	}{
+		{"/api/v1/wallet/create", 1},
+		{"/api/v1/wallets", 1},
+		{"/api/v2/wallet/seed", 1},
+		{"/api/v1/wallet/transaction", 2},
+		{"/api/v1/injectTransaction", 2},
+		{"/api/v2/transaction/verify", 2},
+		{"/api/v2/wallet/transaction/sign", 2},
+		{"/api/v2/data", 3},
+		{"/api/v1/balance", 4},
+		{"/api/v2/address/verify", 4},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.endpoint, func(t *testing.T) {
+			require.Equal(t, tc.limit, limits.endpoint(tc.endpoint))
+		})
+	}
+}
+
+func TestBodyLimitsWithDefaults(t *testing.T) {
+	require.Equal(t, BodyLimits{
+		Wallet:      32 * 1024,
+		Transaction: 2*int64(params.UserVerifyTxn.MaxTransactionSize) + 16*1024,
+		Storage:     1024 * 1024,
+		Default:     2 * 1024 * 1024,
+	}, BodyLimits{}.withDefaults())
+
+	require.Equal(t, BodyLimits{
+		Wallet:      10,
+		Transaction: 2*int64(params.UserVerifyTxn.MaxTransactionSize) + 16*1024,
+		Storage:     20,
+		Default:     2 * 1024 * 1024,
+	}, BodyLimits{
+		Wallet:  10,
+		Storage: 20,
+	}.withDefaults())
+}
+
+// oversizedBody returns a body of n bytes, valid for the content type up to the limit
+func oversizedBody(contentType string, n int64) []byte {
+	if contentType == ContentTypeForm {
+		return append([]byte("id="), bytes.Repeat([]byte("a"), int(n)-3)...)
+	}
+	return append(append([]byte(`{"id":"`), bytes.Repeat([]byte("a"), int(n)-9)...), '"', '}')
+}
+
+func TestBodyLimitHandlerOversizedBody(t *testing.T) {
+	cfg := defaultMuxConfig()
+	cfg.maxBodySizes = BodyLimits{
+		Wallet:      100,
+		Transaction: 200,
+		Storage:     300,
+		Default:     400,
+	}
+
+	for endpoint, methods := range endpointsMethods {
+		for _, method := range methods {
+			switch method {
+			case http.MethodPost, http.MethodPut, http.MethodDelete:
+			default:
+				continue
+			}
+
+			limit := cfg.maxBodySizes.endpoint(endpoint)
+			apiVersion := apiVersion1
+			contentType := ContentTypeForm
+			if strings.HasPrefix(endpoint, "/api/v2") {
+				apiVersion = apiVersion2
+				contentType = ContentTypeJSON
+			}
+
+			for _, chunked := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s %s chunked=%v", method, endpoint, chunked), func(t *testing.T) {
+					// The gateway has no expectations, a call to it would fail the test
+					gateway := &MockGatewayer{}
+
+					body := oversizedBody(contentType, limit+1)
+					require.Len(t, body, int(limit+1))
+
+					var r io.Reader = bytes.NewReader(body)
+					if chunked {
+						// Hide the Len method of the reader, for the request's ContentLength to be unknown
+						r = ioutil.NopCloser(r)
+					}
+
+					req, err := http.NewRequest(method, endpoint, r)
+					require.NoError(t, err)
+					req.Header.Set("Content-Type", contentType)
+					if chunked {
+						require.Equal(t, int64(0), req.ContentLength)
+						req.ContentLength = -1
+					}
+
+					rr := httptest.NewRecorder()
+					handler := newServerMux(cfg, gateway)
+					handler.ServeHTTP(rr, req)
+
+					require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+					msg := fmt.Sprintf("Request body exceeds the limit of %d bytes", limit)
+					switch apiVersion {
+					case apiVersion1:
+						require.Equal(t, "413 Request Entity Too Large - "+msg, strings.TrimSpace(rr.Body.String()))
+					case apiVersion2:
+						var rsp ReceivedHTTPResponse
+						require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+						require.NotNil(t, rsp.Error)
+						require.Equal(t, http.StatusRequestEntityTooLarge, rsp.Error.Code)
+						require.Equal(t, msg, rsp.Error.Message)
+					}
+
+					gateway.AssertExpectations(t)
+				})
+			}
+		}
+	}
+}
+
+func TestBodyLimitHandler(t *testing.T) {
+	var received []byte
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		received, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := []struct {
+		name    string
+		body    []byte
+		chunked bool
+		code    int
+	}{
+		{
+			name: "within limit",
+			body: bytes.Repeat([]byte("a"), 9),
+			code: http.StatusOK,
+		},
+		{
+			name: "at limit",
+			body: bytes.Repeat([]byte("a"), 10),
+			code: http.StatusOK,
+		},
+		{
+			name:    "chunked at limit",
+			body:    bytes.Repeat([]byte("a"), 10),
+			chunked: true,
+			code:    http.StatusOK,
+		},
+		{
+			name: "over limit",
+			body: bytes.Repeat([]byte("a"), 11),
+			code: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:    "chunked over limit",
+			body:    bytes.Repeat([]byte("a"), 11),
+			chunked: true,
+			code:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "empty",
+			code: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			received = nil
+
+			req, err := http.NewRequest(http.MethodPost, "/", ioutil.NopCloser(bytes.NewReader(tc.body)))
+			require.NoError(t, err)
+			req.ContentLength = int64(len(tc.body))
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+
+			rr := httptest.NewRecorder()
+			bodyLimitHandler(apiVersion2, 10, handler).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+			if tc.code == http.StatusOK {
+				require.Equal(t, string(tc.body), string(received))
+			} else {
+				require.Nil(t, received)
+			}
+		})
+	}
+}
@@ -10,13 +10,18 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/kvstorage"
-	"github.com/skycoin/skycoin/src/readable"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 const (
@@ -24,6 +29,12 @@ const (
 	httpClientTimeout   = 120 * time.Second
 	tlsHandshakeTimeout = 60 * time.Second
 
+	// balanceFormMaxAddresses is the number of addresses above which Balance sends a JSON request body
+	balanceFormMaxAddresses = 100
+
+	// walletRecoveryPollInterval is how often RecoverWallet checks the status of the recovery
+	walletRecoveryPollInterval = 500 * time.Millisecond
+
 	// ContentTypeJSON json content type header
 	ContentTypeJSON = "application/json"
 	// ContentTypeForm form data content type header
@@ -50,6 +61,16 @@ func (e ClientError) Error() string {
 	return e.Message
 }
 
+// PendingApprovalError is returned when a wallet's approval policy held a transaction
+// until the wallet's approver approves it, see Client.WalletApprove
+type PendingApprovalError struct {
+	Approval kvstorage.PendingApproval
+}
+
+func (e PendingApprovalError) Error() string {
+	return fmt.Sprintf("transaction %s requires approval, pending approval id is %s", e.Approval.TxID, e.Approval.ID)
+}
+
 // ReceivedHTTPResponse parsed a HTTPResponse received by the Client, for the V2 API
 type ReceivedHTTPResponse struct {
 	Error *HTTPError      `json:"error,omitempty"`
@@ -62,6 +83,14 @@ type Client struct {
 	Addr       string
 	Username   string
 	Password   string
+	// WalletToken is the wallet access token sent with each request, see SetWalletToken
+	WalletToken string
+	// APIKey is sent in the X-API-Key header of each request, if the node requires an API key
+	APIKey string
+
+	// verificationParams caches the response of VerificationParams
+	verificationParams     *VerificationParamsResponse
+	verificationParamsLock sync.Mutex
 }
 
 // NewClient creates a Client
@@ -91,12 +120,28 @@ func (c *Client) SetAuth(username, password string) {
 	c.Password = password
 }
 
+// SetAPIKey configures the API key required by the node's wallet, storage and admin endpoints
+func (c *Client) SetAPIKey(apiKey string) {
+	c.APIKey = apiKey
+}
+
 func (c *Client) applyAuth(req *http.Request) {
-	if c.Username == "" && c.Password == "" {
+	if c.APIKey != "" {
+		req.Header.Set(APIKeyHeader, c.APIKey)
+	}
+
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+		// The Authorization header is taken by the basic auth credentials
+		if c.WalletToken != "" {
+			req.Header.Set(WalletTokenHeader, c.WalletToken)
+		}
 		return
 	}
 
-	req.SetBasicAuth(c.Username, c.Password)
+	if c.WalletToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.WalletToken)
+	}
 }
 
 // GetV2 makes a GET request to an endpoint and unmarshals the response to respObj.
@@ -115,6 +160,14 @@ func (c *Client) Get(endpoint string, obj interface{}) error {
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusAccepted {
+		var e PendingApprovalError
+		if err := json.NewDecoder(resp.Body).Decode(&e.Approval); err != nil {
+			return err
+		}
+		return e
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
@@ -176,6 +229,18 @@ func (c *Client) PostJSON(endpoint string, reqObj, respObj interface{}) error {
 
 // Post makes a POST request to an endpoint.
 func (c *Client) Post(endpoint string, contentType string, body io.Reader, obj interface{}) error {
+	return c.requestWithCSRF(http.MethodPost, endpoint, contentType, body, obj)
+}
+
+// Delete makes a DELETE request to an endpoint and unmarshals the response to obj.
+// If the response is not 200 OK, returns an error
+func (c *Client) Delete(endpoint string, obj interface{}) error {
+	return c.requestWithCSRF(http.MethodDelete, endpoint, "", nil, obj)
+}
+
+// requestWithCSRF makes a `method` request with a CSRF token to an endpoint and unmarshals the response to obj.
+// The Content-Type header is not set if contentType is empty.
+func (c *Client) requestWithCSRF(method, endpoint string, contentType string, body io.Reader, obj interface{}) error {
 	csrf, err := c.CSRF()
 	if err != nil {
 		return err
@@ -184,7 +249,7 @@ func (c *Client) Post(endpoint string, contentType string, body io.Reader, obj i
 	endpoint = strings.TrimLeft(endpoint, "/")
 	endpoint = c.Addr + endpoint
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, body)
+	req, err := http.NewRequest(method, endpoint, body)
 	if err != nil {
 		return err
 	}
@@ -195,7 +260,9 @@ func (c *Client) Post(endpoint string, contentType string, body io.Reader, obj i
 		req.Header.Set(CSRFHeaderName, csrf)
 	}
 
-	req.Header.Set("Content-Type", contentType)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -204,6 +271,14 @@ func (c *Client) Post(endpoint string, contentType string, body io.Reader, obj i
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusAccepted {
+		var e PendingApprovalError
+		if err := json.NewDecoder(resp.Body).Decode(&e.Approval); err != nil {
+			return err
+		}
+		return e
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
@@ -297,6 +372,14 @@ func (c *Client) requestV2(method, endpoint string, body io.Reader, respObj inte
 		return false, NewClientError(resp.Status, resp.StatusCode, "Response has additional bytes after the first JSON object: "+string(respBody))
 	}
 
+	if resp.StatusCode == http.StatusAccepted {
+		var e PendingApprovalError
+		if err := json.Unmarshal(wrapObj.Data, &e.Approval); err != nil {
+			return false, err
+		}
+		return false, e
+	}
+
 	var rspErr error
 	if resp.StatusCode != http.StatusOK {
 		rspErr = NewClientError(resp.Status, resp.StatusCode, wrapObj.Error.Message)
@@ -355,6 +438,22 @@ func (c *Client) CSRF() (string, error) {
 	return token, nil
 }
 
+// RotateCSRF makes a request to POST /api/v1/csrf/rotate, which invalidates all outstanding CSRF tokens.
+// Returns a new CSRF token.
+func (c *Client) RotateCSRF() (string, error) {
+	var m map[string]string
+	if err := c.Post("/api/v1/csrf/rotate", "", nil, &m); err != nil {
+		return "", err
+	}
+
+	token, ok := m["csrf_token"]
+	if !ok {
+		return "", errors.New("csrf_token not found in response")
+	}
+
+	return token, nil
+}
+
 // Version makes a request to GET /api/v1/version
 func (c *Client) Version() (*readable.BuildInfo, error) {
 	var bi readable.BuildInfo
@@ -364,6 +463,15 @@ func (c *Client) Version() (*readable.BuildInfo, error) {
 	return &bi, nil
 }
 
+// Spec makes a request to GET /api/v1/spec.json
+func (c *Client) Spec() (json.RawMessage, error) {
+	var spec json.RawMessage
+	if err := c.Get("/api/v1/spec.json", &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
 // Outputs makes a request to GET /api/v1/outputs
 func (c *Client) Outputs() (*readable.UnspentOutputsSummary, error) {
 	var o readable.UnspentOutputsSummary
@@ -387,6 +495,20 @@ func (c *Client) OutputsForAddresses(addrs []string) (*readable.UnspentOutputsSu
 	return &o, nil
 }
 
+// OutputsSummary makes a request to POST /api/v1/outputs/summary?addrs=xxx
+func (c *Client) OutputsSummary(addrs []string) (*OutputsSummaryResponse, error) {
+	v := url.Values{}
+	v.Add("addrs", strings.Join(addrs, ","))
+
+	endpoint := "/api/v1/outputs/summary"
+
+	var o OutputsSummaryResponse
+	if err := c.PostForm(endpoint, strings.NewReader(v.Encode()), &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
 // OutputsForHashes makes a request to POST /api/v1/outputs?hashes=zzz
 func (c *Client) OutputsForHashes(hashes []string) (*readable.UnspentOutputsSummary, error) {
 	v := url.Values{}
@@ -400,6 +522,19 @@ func (c *Client) OutputsForHashes(hashes []string) (*readable.UnspentOutputsSumm
 	return &o, nil
 }
 
+// OutputsForTransaction makes a request to GET /api/v1/outputs?txid=xxx
+func (c *Client) OutputsForTransaction(txid string) (*TransactionOutputsResponse, error) {
+	v := url.Values{}
+	v.Add("txid", txid)
+	endpoint := "/api/v1/outputs?" + v.Encode()
+
+	var o TransactionOutputsResponse
+	if err := c.Get(endpoint, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
 // CoinSupply makes a request to GET /api/v1/coinSupply
 func (c *Client) CoinSupply() (*CoinSupply, error) {
 	var cs CoinSupply
@@ -409,6 +544,22 @@ func (c *Client) CoinSupply() (*CoinSupply, error) {
 	return &cs, nil
 }
 
+// CoinSupplyProjection makes a request to GET /api/v1/coinSupply/projection?at=xxx.
+// If start is 0, the unlock schedule starts at the genesis block timestamp
+func (c *Client) CoinSupplyProjection(at, start uint64) (*CoinSupplyProjection, error) {
+	v := url.Values{}
+	v.Add("at", strconv.FormatUint(at, 10))
+	if start != 0 {
+		v.Add("start", strconv.FormatUint(start, 10))
+	}
+
+	var p CoinSupplyProjection
+	if err := c.Get("/api/v1/coinSupply/projection?"+v.Encode(), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 // BlockByHash makes a request to GET /api/v1/block?hash=xxx
 func (c *Client) BlockByHash(hash string) (*readable.Block, error) {
 	v := url.Values{}
@@ -463,6 +614,152 @@ func (c *Client) BlockBySeqVerbose(seq uint64) (*readable.BlockVerbose, error) {
 	return &b, nil
 }
 
+// BlockByHashRaw makes a request to GET /api/v1/block?hash=xxx&raw=1
+func (c *Client) BlockByHashRaw(hash string) (*BlockRaw, error) {
+	v := url.Values{}
+	v.Add("hash", hash)
+	v.Add("raw", "1")
+	endpoint := "/api/v1/block?" + v.Encode()
+
+	var b BlockRaw
+	if err := c.Get(endpoint, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// BlockBySeqRaw makes a request to GET /api/v1/block?seq=xxx&raw=1
+func (c *Client) BlockBySeqRaw(seq uint64) (*BlockRaw, error) {
+	v := url.Values{}
+	v.Add("seq", fmt.Sprint(seq))
+	v.Add("raw", "1")
+	endpoint := "/api/v1/block?" + v.Encode()
+
+	var b BlockRaw
+	if err := c.Get(endpoint, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// DecodeBlock makes a request to POST /api/v2/block/decode
+func (c *Client) DecodeBlock(raw string) (*readable.Block, error) {
+	var b readable.Block
+	ok, err := c.PostJSONV2("/api/v2/block/decode", DecodeBlockRequest{
+		Raw: raw,
+	}, &b)
+	if ok {
+		return &b, err
+	}
+
+	return nil, err
+}
+
+// BlockPreview makes a request to GET /api/v2/block/preview
+func (c *Client) BlockPreview() (*BlockPreviewResponse, error) {
+	var p BlockPreviewResponse
+	ok, err := c.GetV2("/api/v2/block/preview", &p)
+	if ok {
+		return &p, err
+	}
+
+	return nil, err
+}
+
+// BlocksRaw makes a request to GET /api/v2/blocks/raw, reading the export from the byte offset.
+// If headSeq is nil, the export ends at the blockchain head.
+// Returns the response body and the head seq of the export, to pass as headSeq when resuming the download.
+// The caller must close the body. Use DecodeRawBlock to read the blocks.
+func (c *Client) BlocksRaw(headSeq *uint64, offset int64) (io.ReadCloser, uint64, error) {
+	endpoint := "/api/v2/blocks/raw"
+	if headSeq != nil {
+		v := url.Values{}
+		v.Add("head_seq", strconv.FormatUint(*headSeq, 10))
+		endpoint = "/api/v2/blocks/raw?" + v.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.Addr+strings.TrimLeft(endpoint, "/"), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.applyAuth(req)
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	expected := http.StatusOK
+	if offset > 0 {
+		expected = http.StatusPartialContent
+	}
+
+	if resp.StatusCode != expected {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, NewClientError(resp.Status, resp.StatusCode, string(body))
+	}
+
+	seq, err := strconv.ParseUint(resp.Header.Get(HeadSeqHeaderName), 10, 64)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("invalid %s header: %v", HeadSeqHeaderName, err)
+	}
+
+	return resp.Body, seq, nil
+}
+
+// BlocksStream makes a request to GET /api/v2/blocks/stream, streaming the verbose blocks from the since seq.
+// If follow is true, the stream stays open and the blocks executed after the head are written as they are executed.
+// Returns the response body and the head seq at the start of the stream.
+// The caller must close the body. Decode the readable.BlockVerbose blocks with a json.Decoder.
+// The stream is gzip compressed in transit, the http.Transport decompresses it.
+func (c *Client) BlocksStream(since uint64, follow bool) (io.ReadCloser, uint64, error) {
+	v := url.Values{}
+	v.Add("since", strconv.FormatUint(since, 10))
+	if follow {
+		v.Add("follow", "true")
+	}
+	endpoint := "/api/v2/blocks/stream?" + v.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, c.Addr+strings.TrimLeft(endpoint, "/"), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.applyAuth(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, NewClientError(resp.Status, resp.StatusCode, string(body))
+	}
+
+	seq, err := strconv.ParseUint(resp.Header.Get(HeadSeqHeaderName), 10, 64)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("invalid %s header: %v", HeadSeqHeaderName, err)
+	}
+
+	return resp.Body, seq, nil
+}
+
 // Blocks makes a request to POST /api/v1/blocks?seqs=
 func (c *Client) Blocks(seqs []uint64) (*readable.Blocks, error) {
 	sSeqs := make([]string, len(seqs))
@@ -565,6 +862,24 @@ func (c *Client) BlockchainMetadata() (*readable.BlockchainMetadata, error) {
 	return &b, nil
 }
 
+// BlockchainMetadataWaitAfterSeq makes a request to GET /api/v1/blockchain/metadata?wait_after_seq=xxx&timeout=xxx.
+// The request is held until the head block seq is greater than seq or the timeout passes.
+// A timeout of 0 uses the server's maximum timeout. The timeout is truncated to seconds.
+func (c *Client) BlockchainMetadataWaitAfterSeq(seq uint64, timeout time.Duration) (*readable.BlockchainMetadata, error) {
+	v := url.Values{}
+	v.Add("wait_after_seq", fmt.Sprint(seq))
+	if timeout != 0 {
+		v.Add("timeout", fmt.Sprint(uint64(timeout/time.Second)))
+	}
+	endpoint := "/api/v1/blockchain/metadata?" + v.Encode()
+
+	var b readable.BlockchainMetadata
+	if err := c.Get(endpoint, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
 // BlockchainProgress makes a request to GET /api/v1/blockchain/progress
 func (c *Client) BlockchainProgress() (*readable.BlockchainProgress, error) {
 	var b readable.BlockchainProgress
@@ -574,8 +889,17 @@ func (c *Client) BlockchainProgress() (*readable.BlockchainProgress, error) {
 	return &b, nil
 }
 
-// Balance makes a request to POST /api/v1/balance?addrs=xxx
+// Balance makes a request to POST /api/v1/balance?addrs=xxx.
+// Lists of more than balanceFormMaxAddresses addresses are sent as a JSON BalanceRequest body instead.
 func (c *Client) Balance(addrs []string) (*BalanceResponse, error) {
+	if len(addrs) > balanceFormMaxAddresses {
+		b, err := c.BalanceBatch(addrs)
+		if err != nil {
+			return nil, err
+		}
+		return &b.BalanceResponse, nil
+	}
+
 	v := url.Values{}
 	v.Add("addrs", strings.Join(addrs, ","))
 	endpoint := "/api/v1/balance"
@@ -587,6 +911,18 @@ func (c *Client) Balance(addrs []string) (*BalanceResponse, error) {
 	return &b, nil
 }
 
+// BalanceBatch makes a request to POST /api/v1/balance with a JSON BalanceRequest body.
+// The response's Balances are in the order of addrs.
+func (c *Client) BalanceBatch(addrs []string) (*BalanceBatchResponse, error) {
+	var b BalanceBatchResponse
+	if err := c.PostJSON("/api/v1/balance", BalanceRequest{
+		Addresses: addrs,
+	}, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
 // UxOut makes a request to GET /api/v1/uxout?uxid=xxx
 func (c *Client) UxOut(uxID string) (*readable.SpentOutput, error) {
 	v := url.Values{}
@@ -637,6 +973,19 @@ func (c *Client) Wallets() ([]WalletResponse, error) {
 	return wrs, nil
 }
 
+// WalletsByLabel makes a request to GET /api/v1/wallets?label=
+func (c *Client) WalletsByLabel(label string) ([]WalletResponse, error) {
+	v := url.Values{}
+	v.Add("label", label)
+
+	var wrs []WalletResponse
+	if err := c.Get("/api/v1/wallets?"+v.Encode(), &wrs); err != nil {
+		return nil, err
+	}
+
+	return wrs, nil
+}
+
 // CreateWalletOptions are the options for creating a wallet
 type CreateWalletOptions struct {
 	Type           string
@@ -692,19 +1041,81 @@ func (c *Client) NewWalletAddress(id string, n int, password string) ([]string,
 	return obj.Addresses, nil
 }
 
+// PreviewWalletAddresses makes a request to POST /api/v1/wallet/newAddress with preview set,
+// which returns the next addresses of the wallet without generating them.
+// if n is <= 0, defaults to 1
+func (c *Client) PreviewWalletAddresses(id string, n int, password string) ([]string, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	if n > 0 {
+		v.Add("num", fmt.Sprint(n))
+	}
+
+	v.Add("password", password)
+	v.Add("preview", "1")
+
+	var obj struct {
+		Addresses []string `json:"addresses"`
+	}
+	if err := c.PostForm("/api/v1/wallet/newAddress", strings.NewReader(v.Encode()), &obj); err != nil {
+		return nil, err
+	}
+	return obj.Addresses, nil
+}
+
+// CommitWalletAddresses makes a request to POST /api/v1/wallet/newAddress with commit set to the addresses
+// returned by PreviewWalletAddresses, which generates them unless they were generated since the preview
+func (c *Client) CommitWalletAddresses(id string, addrs []string, password string) ([]string, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("commit", strings.Join(addrs, ","))
+	v.Add("password", password)
+
+	var obj struct {
+		Addresses []string `json:"addresses"`
+	}
+	if err := c.PostForm("/api/v1/wallet/newAddress", strings.NewReader(v.Encode()), &obj); err != nil {
+		return nil, err
+	}
+	return obj.Addresses, nil
+}
+
 // WalletBalance makes a request to GET /api/v1/wallet/balance
-func (c *Client) WalletBalance(id string) (*BalanceResponse, error) {
+func (c *Client) WalletBalance(id string) (*WalletBalanceResponse, error) {
 	v := url.Values{}
 	v.Add("id", id)
 	endpoint := "/api/v1/wallet/balance?" + v.Encode()
 
-	var b BalanceResponse
+	var b WalletBalanceResponse
 	if err := c.Get(endpoint, &b); err != nil {
 		return nil, err
 	}
 	return &b, nil
 }
 
+// WalletBalanceHistory makes a request to GET /api/v1/wallet/balance/history.
+// granularity is optional, a zero from or to is not sent.
+func (c *Client) WalletBalanceHistory(id, granularity string, from, to uint64) (*WalletBalanceHistoryResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	if granularity != "" {
+		v.Add("granularity", granularity)
+	}
+	if from != 0 {
+		v.Add("from", fmt.Sprint(from))
+	}
+	if to != 0 {
+		v.Add("to", fmt.Sprint(to))
+	}
+	endpoint := "/api/v1/wallet/balance/history?" + v.Encode()
+
+	var h WalletBalanceHistoryResponse
+	if err := c.Get(endpoint, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
 // CreateTransactionRequest is sent to /api/v2/transaction
 type CreateTransactionRequest struct {
 	IgnoreUnconfirmed bool           `json:"ignore_unconfirmed"`
@@ -731,13 +1142,15 @@ type Receiver struct {
 
 // WalletCreateTransactionRequest is sent to /api/v1/wallet/transaction
 type WalletCreateTransactionRequest struct {
-	Unsigned bool   `json:"unsigned"`
-	WalletID string `json:"wallet_id"`
-	Password string `json:"password"`
+	Unsigned      bool     `json:"unsigned"`
+	WalletID      string   `json:"wallet_id"`
+	Password      string   `json:"password"`
+	AddressLabels []string `json:"address_labels,omitempty"`
 	CreateTransactionRequest
 }
 
-// WalletCreateTransaction makes a request to POST /api/v1/wallet/transaction
+// WalletCreateTransaction makes a request to POST /api/v1/wallet/transaction.
+// Returns PendingApprovalError if the wallet's approval policy held the transaction.
 func (c *Client) WalletCreateTransaction(req WalletCreateTransactionRequest) (*CreateTransactionResponse, error) {
 	var r CreateTransactionResponse
 	endpoint := "/api/v1/wallet/transaction"
@@ -748,7 +1161,8 @@ func (c *Client) WalletCreateTransaction(req WalletCreateTransactionRequest) (*C
 	return &r, nil
 }
 
-// WalletSignTransaction makes a request to POST /api/v2/wallet/transaction/sign
+// WalletSignTransaction makes a request to POST /api/v2/wallet/transaction/sign.
+// Returns PendingApprovalError if the wallet's approval policy held the transaction.
 func (c *Client) WalletSignTransaction(req WalletSignTransactionRequest) (*CreateTransactionResponse, error) {
 	var r CreateTransactionResponse
 	endpoint := "/api/v2/wallet/transaction/sign"
@@ -759,10 +1173,10 @@ func (c *Client) WalletSignTransaction(req WalletSignTransactionRequest) (*Creat
 	return nil, err
 }
 
-// CreateTransaction makes a request to POST /api/v2/transaction
-func (c *Client) CreateTransaction(req CreateTransactionRequest) (*CreateTransactionResponse, error) {
+// WalletBumpTransaction makes a request to POST /api/v2/wallet/transaction/bump
+func (c *Client) WalletBumpTransaction(req WalletBumpTransactionRequest) (*CreateTransactionResponse, error) {
 	var r CreateTransactionResponse
-	endpoint := "/api/v2/transaction"
+	endpoint := "/api/v2/wallet/transaction/bump"
 	ok, err := c.PostJSONV2(endpoint, req, &r)
 	if ok {
 		return &r, err
@@ -770,35 +1184,151 @@ func (c *Client) CreateTransaction(req CreateTransactionRequest) (*CreateTransac
 	return nil, err
 }
 
-// WalletUnconfirmedTransactions makes a request to GET /api/v1/wallet/transactions
-func (c *Client) WalletUnconfirmedTransactions(id string) (*UnconfirmedTxnsResponse, error) {
-	v := url.Values{}
-	v.Add("id", id)
-	endpoint := "/api/v1/wallet/transactions?" + v.Encode()
+// EncryptWalletAddress makes a request to POST /api/v2/wallet/address/encrypt
+func (c *Client) EncryptWalletAddress(req WalletAddressPasswordRequest) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/address/encrypt", req, nil)
+	return err
+}
 
-	var utx *UnconfirmedTxnsResponse
-	if err := c.Get(endpoint, &utx); err != nil {
-		return nil, err
-	}
-	return utx, nil
+// DecryptWalletAddress makes a request to POST /api/v2/wallet/address/decrypt
+func (c *Client) DecryptWalletAddress(req WalletAddressPasswordRequest) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/address/decrypt", req, nil)
+	return err
 }
 
-// WalletUnconfirmedTransactionsVerbose makes a request to GET /api/v1/wallet/transactions&verbose=1
-func (c *Client) WalletUnconfirmedTransactionsVerbose(id string) (*UnconfirmedTxnsVerboseResponse, error) {
-	v := url.Values{}
-	v.Add("id", id)
-	v.Add("verbose", "1")
-	endpoint := "/api/v1/wallet/transactions?" + v.Encode()
+// SetWalletToken makes a request to POST /api/v2/wallet/token and returns the new access token of the wallet.
+// Use the token by setting Client.WalletToken.
+func (c *Client) SetWalletToken(id, password string) (string, error) {
+	return c.walletTokenRequest("/api/v2/wallet/token", WalletTokenRequest{
+		WalletID: id,
+		Password: password,
+	})
+}
 
-	var utx *UnconfirmedTxnsVerboseResponse
-	if err := c.Get(endpoint, &utx); err != nil {
-		return nil, err
-	}
-	return utx, nil
+// RotateWalletToken makes a request to POST /api/v2/wallet/token/rotate and returns the new access token of the wallet.
+// Client.WalletToken must be the current token.
+func (c *Client) RotateWalletToken(id string) (string, error) {
+	return c.walletTokenRequest("/api/v2/wallet/token/rotate", WalletTokenRequest{
+		WalletID: id,
+	})
 }
 
-// UpdateWallet makes a request to POST /api/v1/wallet/update
-func (c *Client) UpdateWallet(id, label string) error {
+// RemoveWalletToken makes a request to POST /api/v2/wallet/token/remove
+func (c *Client) RemoveWalletToken(id, password string) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/token/remove", WalletTokenRequest{
+		WalletID: id,
+		Password: password,
+	}, nil)
+	return err
+}
+
+// SetWalletApprovalPolicy makes a request to POST /api/v2/wallet/approval/policy
+func (c *Client) SetWalletApprovalPolicy(req WalletApprovalPolicyRequest) (*WalletApprovalPolicyResponse, error) {
+	var r WalletApprovalPolicyResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/approval/policy", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// RemoveWalletApprovalPolicy makes a request to POST /api/v2/wallet/approval/policy/remove
+func (c *Client) RemoveWalletApprovalPolicy(id, password, approverPassword string) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/approval/policy/remove", WalletApprovalPolicyRemoveRequest{
+		WalletID:         id,
+		Password:         password,
+		ApproverPassword: approverPassword,
+	}, nil)
+	return err
+}
+
+// WalletApprovals makes a request to GET /api/v2/wallet/approvals
+func (c *Client) WalletApprovals(id string) (*WalletApprovalsResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	endpoint := "/api/v2/wallet/approvals?" + v.Encode()
+
+	var r WalletApprovalsResponse
+	ok, err := c.GetV2(endpoint, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// WalletApprove makes a request to POST /api/v2/wallet/approval/approve
+func (c *Client) WalletApprove(id, approvalID, approverPassword string, broadcast bool) (*WalletApprovalApproveResponse, error) {
+	var r WalletApprovalApproveResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/approval/approve", WalletApprovalRequest{
+		WalletID:         id,
+		ID:               approvalID,
+		ApproverPassword: approverPassword,
+		Broadcast:        broadcast,
+	}, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// WalletCancelApproval makes a request to POST /api/v2/wallet/approval/cancel
+func (c *Client) WalletCancelApproval(id, approvalID string) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/approval/cancel", WalletApprovalRequest{
+		WalletID: id,
+		ID:       approvalID,
+	}, nil)
+	return err
+}
+
+func (c *Client) walletTokenRequest(endpoint string, req WalletTokenRequest) (string, error) {
+	var r WalletTokenResponse
+	ok, err := c.PostJSONV2(endpoint, req, &r)
+	if ok {
+		return r.Token, err
+	}
+	return "", err
+}
+
+// CreateTransaction makes a request to POST /api/v2/transaction
+func (c *Client) CreateTransaction(req CreateTransactionRequest) (*CreateTransactionResponse, error) {
+	var r CreateTransactionResponse
+	endpoint := "/api/v2/transaction"
+	ok, err := c.PostJSONV2(endpoint, req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// WalletUnconfirmedTransactions makes a request to GET /api/v1/wallet/transactions
+func (c *Client) WalletUnconfirmedTransactions(id string) (*UnconfirmedTxnsResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	endpoint := "/api/v1/wallet/transactions?" + v.Encode()
+
+	var utx *UnconfirmedTxnsResponse
+	if err := c.Get(endpoint, &utx); err != nil {
+		return nil, err
+	}
+	return utx, nil
+}
+
+// WalletUnconfirmedTransactionsVerbose makes a request to GET /api/v1/wallet/transactions&verbose=1
+func (c *Client) WalletUnconfirmedTransactionsVerbose(id string) (*UnconfirmedTxnsVerboseResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("verbose", "1")
+	endpoint := "/api/v1/wallet/transactions?" + v.Encode()
+
+	var utx *UnconfirmedTxnsVerboseResponse
+	if err := c.Get(endpoint, &utx); err != nil {
+		return nil, err
+	}
+	return utx, nil
+}
+
+// UpdateWallet makes a request to POST /api/v1/wallet/update
+func (c *Client) UpdateWallet(id, label string) error {
 	v := url.Values{}
 	v.Add("id", id)
 	v.Add("label", label)
@@ -806,6 +1336,34 @@ func (c *Client) UpdateWallet(id, label string) error {
 	return c.PostForm("/api/v1/wallet/update", strings.NewReader(v.Encode()), nil)
 }
 
+// SetWalletReuseChange makes a request to POST /api/v1/wallet/update to set the reuse_change option of a bip44 wallet
+func (c *Client) SetWalletReuseChange(id string, reuse bool) error {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("reuse_change", strconv.FormatBool(reuse))
+
+	return c.PostForm("/api/v1/wallet/update", strings.NewReader(v.Encode()), nil)
+}
+
+// SetWalletStrictPrivacy makes a request to POST /api/v1/wallet/update to set the strict_privacy option of a wallet
+func (c *Client) SetWalletStrictPrivacy(id string, strict bool) error {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("strict_privacy", strconv.FormatBool(strict))
+
+	return c.PostForm("/api/v1/wallet/update", strings.NewReader(v.Encode()), nil)
+}
+
+// UpdateAddressLabel makes a request to POST /api/v1/wallet/address/label
+func (c *Client) UpdateAddressLabel(id, addr, label string) error {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("address", addr)
+	v.Add("label", label)
+
+	return c.PostForm("/api/v1/wallet/address/label", strings.NewReader(v.Encode()), nil)
+}
+
 // WalletFolderName makes a request to GET /api/v1/wallets/folderName
 func (c *Client) WalletFolderName() (*WalletFolder, error) {
 	var w WalletFolder
@@ -815,6 +1373,123 @@ func (c *Client) WalletFolderName() (*WalletFolder, error) {
 	return &w, nil
 }
 
+// WalletBackups makes a request to GET /api/v1/wallets/backups
+func (c *Client) WalletBackups(id string) (*WalletBackupsResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	endpoint := "/api/v1/wallets/backups?" + v.Encode()
+
+	var w WalletBackupsResponse
+	if err := c.Get(endpoint, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// WalletFormatStatus makes a request to GET /api/v1/wallets/format-status
+func (c *Client) WalletFormatStatus() (*WalletFormatStatusResponse, error) {
+	var w WalletFormatStatusResponse
+	if err := c.Get("/api/v1/wallets/format-status", &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// MigrateWalletFormat makes a request to POST /api/v1/wallets/migrate to upgrade a wallet file to the current format.
+// The password is required if the wallet is encrypted and a migration needs its seed.
+func (c *Client) MigrateWalletFormat(id, password string) (*WalletFormatMigrateResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	if password != "" {
+		v.Add("password", password)
+	}
+
+	var w WalletFormatMigrateResponse
+	if err := c.PostForm("/api/v1/wallets/migrate", strings.NewReader(v.Encode()), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// WalletTxNote makes a request to GET /api/v1/wallet/transaction/note
+func (c *Client) WalletTxNote(id, txid string) (*WalletTxNote, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("txid", txid)
+	endpoint := "/api/v1/wallet/transaction/note?" + v.Encode()
+
+	var n WalletTxNote
+	if err := c.Get(endpoint, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// WalletTxNotes makes a request to GET /api/v1/wallet/transaction/note without a txid
+func (c *Client) WalletTxNotes(id string) (*WalletTxNotesResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	endpoint := "/api/v1/wallet/transaction/note?" + v.Encode()
+
+	var n WalletTxNotesResponse
+	if err := c.Get(endpoint, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// SetWalletTxNote makes a request to POST /api/v1/wallet/transaction/note
+func (c *Client) SetWalletTxNote(id, txid, note string) (*WalletTxNote, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("txid", txid)
+	v.Add("note", note)
+
+	var n WalletTxNote
+	if err := c.PostForm("/api/v1/wallet/transaction/note", strings.NewReader(v.Encode()), &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// RemoveWalletTxNote makes a request to DELETE /api/v1/wallet/transaction/note
+func (c *Client) RemoveWalletTxNote(id, txid string) error {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("txid", txid)
+
+	return c.Delete("/api/v1/wallet/transaction/note?"+v.Encode(), nil)
+}
+
+// WalletTransactionDetail makes a request to GET /api/v1/wallet/transaction/detail
+func (c *Client) WalletTransactionDetail(id, txid string) (*WalletTransactionResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("txid", txid)
+	endpoint := "/api/v1/wallet/transaction/detail?" + v.Encode()
+
+	var r WalletTransactionResponse
+	if err := c.Get(endpoint, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// WalletTransactionDetailVerbose makes a request to GET /api/v1/wallet/transaction/detail?verbose=1
+func (c *Client) WalletTransactionDetailVerbose(id, txid string) (*WalletTransactionVerboseResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("txid", txid)
+	v.Add("verbose", "1")
+	endpoint := "/api/v1/wallet/transaction/detail?" + v.Encode()
+
+	var r WalletTransactionVerboseResponse
+	if err := c.Get(endpoint, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 // NewSeed makes a request to GET /api/v1/wallet/newSeed
 // entropy must be 128 or 256
 func (c *Client) NewSeed(entropy int) (string, error) {
@@ -856,13 +1531,93 @@ func (c *Client) WalletSeed(id string, password string) (*WalletSeedResponse, er
 	return &r, nil
 }
 
+// WalletMigrateRequest are the options of a wallet migration, see WalletMigrate
+type WalletMigrateRequest struct {
+	ID        string
+	To        string
+	Bip44Coin *uint32
+	Label     string
+	ScanN     uint64
+	DryRun    bool
+	Password  string
+}
+
+// WalletMigrate makes a request to POST /api/v1/wallet/migrate to migrate a deterministic wallet
+// to a bip44 wallet of the same mnemonic, moving its funds. The password is required if the wallet is encrypted.
+func (c *Client) WalletMigrate(req WalletMigrateRequest) (*WalletMigrateResponse, error) {
+	v := url.Values{}
+	v.Add("id", req.ID)
+	if req.To != "" {
+		v.Add("to", req.To)
+	}
+	if req.Bip44Coin != nil {
+		v.Add("bip44-coin", fmt.Sprint(*req.Bip44Coin))
+	}
+	if req.Label != "" {
+		v.Add("label", req.Label)
+	}
+	if req.ScanN != 0 {
+		v.Add("scan", fmt.Sprint(req.ScanN))
+	}
+	v.Add("dry-run", fmt.Sprint(req.DryRun))
+	if req.Password != "" {
+		v.Add("password", req.Password)
+	}
+
+	var r WalletMigrateResponse
+	if err := c.PostForm("/api/v1/wallet/migrate", strings.NewReader(v.Encode()), &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// WalletSweep makes a request to POST /api/v1/wallet/sweep to send the funds of hex encoded secret keys
+// to a new address of a wallet. The password is required if the wallet is encrypted.
+func (c *Client) WalletSweep(id string, secretKeys []string, password string) (*WalletSweepResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("secret_keys", strings.Join(secretKeys, ","))
+	if password != "" {
+		v.Add("password", password)
+	}
+
+	var r WalletSweepResponse
+	if err := c.PostForm("/api/v1/wallet/sweep", strings.NewReader(v.Encode()), &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// WalletDeriveChild makes a request to POST /api/v1/wallet/derive-child to create a bip44 wallet
+// from a bip85 child mnemonic of a bip44 wallet's seed. The password is required if the parent wallet is encrypted.
+func (c *Client) WalletDeriveChild(id string, index, words uint32, label, password string, scanN int) (*WalletDeriveChildResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("index", fmt.Sprint(index))
+	v.Add("words", fmt.Sprint(words))
+	v.Add("label", label)
+	v.Add("scan", strconv.Itoa(scanN))
+	if password != "" {
+		v.Add("password", password)
+	}
+
+	var r WalletDeriveChildResponse
+	if err := c.PostForm("/api/v1/wallet/derive-child", strings.NewReader(v.Encode()), &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 // NetworkConnection makes a request to GET /api/v1/network/connection
-func (c *Client) NetworkConnection(addr string) (*readable.Connection, error) {
+func (c *Client) NetworkConnection(addr string) (*Connection, error) {
 	v := url.Values{}
 	v.Add("addr", addr)
 	endpoint := "/api/v1/network/connection?" + v.Encode()
 
-	var dc readable.Connection
+	var dc Connection
 	if err := c.Get(endpoint, &dc); err != nil {
 		return nil, err
 	}
@@ -928,6 +1683,44 @@ func (c *Client) NetworkExchangedPeers() ([]string, error) {
 	return dc, nil
 }
 
+// NetworkPeers makes a request to GET /api/v1/network/peers
+func (c *Client) NetworkPeers() (*NetworkPeersResponse, error) {
+	var r NetworkPeersResponse
+	if err := c.Get("/api/v1/network/peers", &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// PeersExport makes a request to GET /api/v1/network/peers/export
+func (c *Client) PeersExport(f pex.BundleFilter, sign bool) (*pex.PeerBundle, error) {
+	v := url.Values{}
+	v.Add("min_score", fmt.Sprint(f.MinScore))
+	if f.MaxAge > 0 {
+		v.Add("max_age", f.MaxAge.String())
+	}
+	if f.Limit > 0 {
+		v.Add("limit", fmt.Sprint(f.Limit))
+	}
+	v.Add("sign", fmt.Sprint(sign))
+	endpoint := "/api/v1/network/peers/export?" + v.Encode()
+
+	var b pex.PeerBundle
+	if err := c.Get(endpoint, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// PeersImport makes a request to POST /api/v1/network/peers/import
+func (c *Client) PeersImport(b pex.PeerBundle) (*PeersImportResponse, error) {
+	var r PeersImportResponse
+	if err := c.PostJSON("/api/v1/network/peers/import", b, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 // PendingTransactions makes a request to GET /api/v1/pendingTxs
 func (c *Client) PendingTransactions() ([]readable.UnconfirmedTransactions, error) {
 	var v []readable.UnconfirmedTransactions
@@ -946,6 +1739,36 @@ func (c *Client) PendingTransactionsVerbose() ([]readable.UnconfirmedTransaction
 	return v, nil
 }
 
+// PendingTransactionsStuck makes a request to GET /api/v1/pendingTxs?stuck=1
+func (c *Client) PendingTransactionsStuck() ([]readable.UnconfirmedTransactions, error) {
+	var v []readable.UnconfirmedTransactions
+	if err := c.Get("/api/v1/pendingTxs?stuck=1", &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// AbandonPendingTransaction makes a request to DELETE /api/v1/pendingTxs
+func (c *Client) AbandonPendingTransaction(txid string) (*AbandonPendingTxnResponse, error) {
+	v := url.Values{}
+	v.Add("txid", txid)
+
+	var r AbandonPendingTxnResponse
+	if err := c.Delete("/api/v1/pendingTxs?"+v.Encode(), &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// PendingTransactionsConflicts makes a request to GET /api/v1/pendingTxs/conflicts
+func (c *Client) PendingTransactionsConflicts() (*UnconfirmedConflictsResponse, error) {
+	var v UnconfirmedConflictsResponse
+	if err := c.Get("/api/v1/pendingTxs/conflicts", &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
 // Transaction makes a request to GET /api/v1/transaction
 func (c *Client) Transaction(txid string) (*readable.TransactionWithStatus, error) {
 	v := url.Values{}
@@ -960,13 +1783,13 @@ func (c *Client) Transaction(txid string) (*readable.TransactionWithStatus, erro
 }
 
 // TransactionVerbose makes a request to GET /api/v1/transaction?verbose=1
-func (c *Client) TransactionVerbose(txid string) (*readable.TransactionWithStatusVerbose, error) {
+func (c *Client) TransactionVerbose(txid string) (*TransactionWithStatusVerbose, error) {
 	v := url.Values{}
 	v.Add("txid", txid)
 	v.Add("verbose", "1")
 	endpoint := "/api/v1/transaction?" + v.Encode()
 
-	var r readable.TransactionWithStatusVerbose
+	var r TransactionWithStatusVerbose
 	if err := c.Get(endpoint, &r); err != nil {
 		return nil, err
 	}
@@ -1029,13 +1852,13 @@ func (c *Client) UnconfirmedTransactions(addrs []string) ([]readable.Transaction
 }
 
 // TransactionsVerbose makes a request to POST /api/v1/transactions?verbose=1
-func (c *Client) TransactionsVerbose(addrs []string) ([]readable.TransactionWithStatusVerbose, error) {
+func (c *Client) TransactionsVerbose(addrs []string) ([]TransactionWithStatusVerbose, error) {
 	v := url.Values{}
 	v.Add("addrs", strings.Join(addrs, ","))
 	v.Add("verbose", "1")
 	endpoint := "/api/v1/transactions"
 
-	var r []readable.TransactionWithStatusVerbose
+	var r []TransactionWithStatusVerbose
 	if err := c.PostForm(endpoint, strings.NewReader(v.Encode()), &r); err != nil {
 		return nil, err
 	}
@@ -1043,14 +1866,14 @@ func (c *Client) TransactionsVerbose(addrs []string) ([]readable.TransactionWith
 }
 
 // ConfirmedTransactionsVerbose makes a request to POST /api/v1/transactions?confirmed=true&verbose=1
-func (c *Client) ConfirmedTransactionsVerbose(addrs []string) ([]readable.TransactionWithStatusVerbose, error) {
+func (c *Client) ConfirmedTransactionsVerbose(addrs []string) ([]TransactionWithStatusVerbose, error) {
 	v := url.Values{}
 	v.Add("addrs", strings.Join(addrs, ","))
 	v.Add("confirmed", "true")
 	v.Add("verbose", "1")
 	endpoint := "/api/v1/transactions"
 
-	var r []readable.TransactionWithStatusVerbose
+	var r []TransactionWithStatusVerbose
 	if err := c.PostForm(endpoint, strings.NewReader(v.Encode()), &r); err != nil {
 		return nil, err
 	}
@@ -1058,14 +1881,45 @@ func (c *Client) ConfirmedTransactionsVerbose(addrs []string) ([]readable.Transa
 }
 
 // UnconfirmedTransactionsVerbose makes a request to POST /api/v1/transactions?confirmed=false&verbose=1
-func (c *Client) UnconfirmedTransactionsVerbose(addrs []string) ([]readable.TransactionWithStatusVerbose, error) {
+func (c *Client) UnconfirmedTransactionsVerbose(addrs []string) ([]TransactionWithStatusVerbose, error) {
 	v := url.Values{}
 	v.Add("addrs", strings.Join(addrs, ","))
 	v.Add("confirmed", "false")
 	v.Add("verbose", "1")
 	endpoint := "/api/v1/transactions"
 
-	var r []readable.TransactionWithStatusVerbose
+	var r []TransactionWithStatusVerbose
+	if err := c.PostForm(endpoint, strings.NewReader(v.Encode()), &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TransactionsInBlockRange makes a request to POST /api/v1/transactions?start_block=start&end_block=end
+func (c *Client) TransactionsInBlockRange(addrs []string, start, end uint64) ([]readable.TransactionWithStatus, error) {
+	v := url.Values{}
+	v.Add("addrs", strings.Join(addrs, ","))
+	v.Add("start_block", fmt.Sprint(start))
+	v.Add("end_block", fmt.Sprint(end))
+	endpoint := "/api/v1/transactions"
+
+	var r []readable.TransactionWithStatus
+	if err := c.PostForm(endpoint, strings.NewReader(v.Encode()), &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TransactionsInBlockRangeVerbose makes a request to POST /api/v1/transactions?start_block=start&end_block=end&verbose=1
+func (c *Client) TransactionsInBlockRangeVerbose(addrs []string, start, end uint64) ([]TransactionWithStatusVerbose, error) {
+	v := url.Values{}
+	v.Add("addrs", strings.Join(addrs, ","))
+	v.Add("start_block", fmt.Sprint(start))
+	v.Add("end_block", fmt.Sprint(end))
+	v.Add("verbose", "1")
+	endpoint := "/api/v1/transactions"
+
+	var r []TransactionWithStatusVerbose
 	if err := c.PostForm(endpoint, strings.NewReader(v.Encode()), &r); err != nil {
 		return nil, err
 	}
@@ -1151,6 +2005,122 @@ func (c *Client) VerifyTransaction(req VerifyTransactionRequest) (*VerifyTransac
 	return nil, err
 }
 
+// TestAcceptTransactions makes a request to POST /api/v2/transaction/test-accept.
+func (c *Client) TestAcceptTransactions(rawTxns []string) ([]TestAcceptResult, error) {
+	req := TestAcceptTransactionsRequest{
+		RawTxns: rawTxns,
+	}
+
+	var rsp []TestAcceptResult
+	ok, err := c.PostJSONV2("/api/v2/transaction/test-accept", req, &rsp)
+	if ok {
+		return rsp, err
+	}
+
+	return nil, err
+}
+
+// CreateDBSnapshot makes a request to POST /api/v2/db/snapshot
+func (c *Client) CreateDBSnapshot() (*DBSnapshotResponse, error) {
+	var rsp DBSnapshotResponse
+	ok, err := c.PostJSONV2("/api/v2/db/snapshot", nil, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// StorageStats makes a request to GET /api/v2/storage/stats
+func (c *Client) StorageStats() (*StorageStatsResponse, error) {
+	var rsp StorageStatsResponse
+	ok, err := c.GetV2("/api/v2/storage/stats", &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// CompactStorage makes a request to POST /api/v2/storage/compact
+func (c *Client) CompactStorage() (*StorageStatsResponse, error) {
+	var rsp StorageStatsResponse
+	ok, err := c.PostJSONV2("/api/v2/storage/compact", nil, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// AbortStorageCompaction makes a request to DELETE /api/v2/storage/compact
+func (c *Client) AbortStorageCompaction() (*StorageStatsResponse, error) {
+	var rsp StorageStatsResponse
+	ok, err := c.DeleteV2("/api/v2/storage/compact", &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// PublisherStatus makes a request to GET /api/v2/publisher
+func (c *Client) PublisherStatus() (*PublisherStatusResponse, error) {
+	var rsp PublisherStatusResponse
+	ok, err := c.GetV2("/api/v2/publisher", &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// ArmPublisher makes a request to POST /api/v2/publisher/arm
+func (c *Client) ArmPublisher(secretKey string) (*PublisherStatusResponse, error) {
+	var rsp PublisherStatusResponse
+	ok, err := c.PostJSONV2("/api/v2/publisher/arm", PublisherArmRequest{
+		SecretKey: secretKey,
+	}, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// DisarmPublisher makes a request to POST /api/v2/publisher/disarm
+func (c *Client) DisarmPublisher() (*PublisherStatusResponse, error) {
+	var rsp PublisherStatusResponse
+	ok, err := c.PostJSONV2("/api/v2/publisher/disarm", nil, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// RequestBlocks makes a request to POST /api/v2/network/request-blocks
+func (c *Client) RequestBlocks(req NetworkRequestBlocksRequest) (*NetworkForcedRequestResponse, error) {
+	var rsp NetworkForcedRequestResponse
+	ok, err := c.PostJSONV2("/api/v2/network/request-blocks", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// RequestTxns makes a request to POST /api/v2/network/request-txns
+func (c *Client) RequestTxns(req NetworkRequestTxnsRequest) (*NetworkForcedRequestResponse, error) {
+	var rsp NetworkForcedRequestResponse
+	ok, err := c.PostJSONV2("/api/v2/network/request-txns", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
 // VerifyAddress makes a request to POST /api/v2/address/verify
 // The API may respond with an error but include data useful for processing,
 // so both return values may be non-nil.
@@ -1204,6 +2174,56 @@ func (c *Client) AddressCount() (uint64, error) {
 
 }
 
+// AddressExplorerParams are arguments to the /explorer/address endpoint.
+// The Skip fields skip sections of the response, TopOutputs and Limit use the server defaults if 0.
+type AddressExplorerParams struct {
+	SkipBalance      bool
+	SkipOutputs      bool
+	SkipPending      bool
+	SkipTransactions bool
+	SkipActivity     bool
+	TopOutputs       int
+	Limit            int
+}
+
+// AddressExplorer makes a request to GET /api/v1/explorer/address/{addr}
+func (c *Client) AddressExplorer(addr string, params *AddressExplorerParams) (*AddressExplorer, error) {
+	endpoint := "/api/v1/explorer/address/" + url.PathEscape(addr)
+
+	if params != nil {
+		v := url.Values{}
+		for _, f := range []struct {
+			name string
+			skip bool
+		}{
+			{"balance", params.SkipBalance},
+			{"outputs", params.SkipOutputs},
+			{"pending", params.SkipPending},
+			{"transactions", params.SkipTransactions},
+			{"activity", params.SkipActivity},
+		} {
+			if f.skip {
+				v.Add(f.name, "false")
+			}
+		}
+		if params.TopOutputs != 0 {
+			v.Add("top_outputs", fmt.Sprint(params.TopOutputs))
+		}
+		if params.Limit != 0 {
+			v.Add("limit", fmt.Sprint(params.Limit))
+		}
+		if len(v) != 0 {
+			endpoint += "?" + v.Encode()
+		}
+	}
+
+	var r AddressExplorer
+	if err := c.Get(endpoint, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 // UnloadWallet makes a request to POST /api/v1/wallet/unload
 func (c *Client) UnloadWallet(id string) error {
 	v := url.Values{}
@@ -1211,6 +2231,34 @@ func (c *Client) UnloadWallet(id string) error {
 	return c.PostForm("/api/v1/wallet/unload", strings.NewReader(v.Encode()), nil)
 }
 
+// WalletAccountXPub makes a request to GET /api/v1/wallet/xpub
+func (c *Client) WalletAccountXPub(id string, account uint32) (*WalletXPubResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("account", fmt.Sprint(account))
+
+	var r WalletXPubResponse
+	if err := c.Get("/api/v1/wallet/xpub?"+v.Encode(), &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UnlockWalletMetadata makes a request to POST /api/v1/wallet/metadata/unlock
+func (c *Client) UnlockWalletMetadata(id, password string) error {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("password", password)
+	return c.PostForm("/api/v1/wallet/metadata/unlock", strings.NewReader(v.Encode()), nil)
+}
+
+// LockWalletMetadata makes a request to POST /api/v1/wallet/metadata/lock
+func (c *Client) LockWalletMetadata(id string) error {
+	v := url.Values{}
+	v.Add("id", id)
+	return c.PostForm("/api/v1/wallet/metadata/lock", strings.NewReader(v.Encode()), nil)
+}
+
 // Health makes a request to GET /api/v1/health
 func (c *Client) Health() (*HealthResponse, error) {
 	var r HealthResponse
@@ -1221,6 +2269,49 @@ func (c *Client) Health() (*HealthResponse, error) {
 	return &r, nil
 }
 
+// UnspentHash makes a request to GET /api/v2/health/unspent-hash
+func (c *Client) UnspentHash() (*UnspentHashStatus, error) {
+	var r UnspentHashStatus
+	ok, err := c.GetV2("/api/v2/health/unspent-hash", &r)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &r, nil
+}
+
+// VerificationParams makes a request to GET /api/v1/verification-params.
+// The response is cached by the Client, since the parameters don't change while the node runs.
+func (c *Client) VerificationParams() (*VerificationParamsResponse, error) {
+	c.verificationParamsLock.Lock()
+	defer c.verificationParamsLock.Unlock()
+
+	if c.verificationParams != nil {
+		return c.verificationParams, nil
+	}
+
+	var r VerificationParamsResponse
+	if err := c.Get("/api/v1/verification-params", &r); err != nil {
+		return nil, err
+	}
+
+	c.verificationParams = &r
+	return &r, nil
+}
+
+// Node makes a request to GET /api/v1/node
+func (c *Client) Node() (*NodeResponse, error) {
+	var r NodeResponse
+	if err := c.Get("/api/v1/node", &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 // EncryptWallet makes a request to POST /api/v1/wallet/encrypt to encrypt a specific wallet with the given password
 func (c *Client) EncryptWallet(id, password string) (*WalletResponse, error) {
 	v := url.Values{}
@@ -1247,12 +2338,25 @@ func (c *Client) DecryptWallet(id, password string) (*WalletResponse, error) {
 	return &wlt, nil
 }
 
-// RecoverWallet makes a request to POST /api/v2/wallet/recover to recover an encrypted wallet by seed.
-// The password argument is optional, if provided, the recovered wallet will be encrypted with this password,
-// otherwise the recovered wallet will be unencrypted.
-func (c *Client) RecoverWallet(req WalletRecoverRequest) (*WalletResponse, error) {
-	var rsp WalletResponse
+// StartWalletRecovery makes a request to POST /api/v2/wallet/recover to start recovering a wallet by seed.
+// Returns the recovery job id.
+func (c *Client) StartWalletRecovery(req WalletRecoverRequest) (string, error) {
+	var rsp WalletRecoverResponse
 	ok, err := c.PostJSONV2("/api/v2/wallet/recover", req, &rsp)
+	if ok {
+		return rsp.JobID, err
+	}
+
+	return "", err
+}
+
+// WalletRecoveryStatus makes a request to GET /api/v2/wallet/recover/status
+func (c *Client) WalletRecoveryStatus(jobID string) (*WalletRecoveryStatusResponse, error) {
+	v := url.Values{}
+	v.Add("job", jobID)
+
+	var rsp WalletRecoveryStatusResponse
+	ok, err := c.GetV2("/api/v2/wallet/recover/status?"+v.Encode(), &rsp)
 	if ok {
 		return &rsp, err
 	}
@@ -1260,6 +2364,46 @@ func (c *Client) RecoverWallet(req WalletRecoverRequest) (*WalletResponse, error
 	return nil, err
 }
 
+// CancelWalletRecovery makes a request to DELETE /api/v2/wallet/recover
+func (c *Client) CancelWalletRecovery(jobID string) error {
+	v := url.Values{}
+	v.Add("job", jobID)
+
+	_, err := c.DeleteV2("/api/v2/wallet/recover?"+v.Encode(), nil)
+	return err
+}
+
+// RecoverWallet recovers a wallet by seed with StartWalletRecovery, waits for the recovery to finish
+// and returns the recovered wallet.
+// The password argument is optional, if provided, the recovered wallet will be encrypted with this password,
+// otherwise the recovered wallet will be unencrypted.
+func (c *Client) RecoverWallet(req WalletRecoverRequest) (*WalletResponse, error) {
+	jobID, err := c.StartWalletRecovery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		status, err := c.WalletRecoveryStatus(jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch wallet.RecoveryState(status.State) {
+		case wallet.RecoveryStateRunning:
+			time.Sleep(walletRecoveryPollInterval)
+			continue
+		case wallet.RecoveryStateCompleted:
+			return c.Wallet(req.ID)
+		default:
+			if status.Error != "" {
+				return nil, fmt.Errorf("wallet recovery %s: %s", status.State, status.Error)
+			}
+			return nil, fmt.Errorf("wallet recovery %s", status.State)
+		}
+	}
+}
+
 // Disconnect disconnect a connections by ID
 func (c *Client) Disconnect(id uint64) error {
 	v := url.Values{}
@@ -1269,6 +2413,76 @@ func (c *Client) Disconnect(id uint64) error {
 	return c.PostForm("/api/v1/network/connection/disconnect", strings.NewReader(v.Encode()), &obj)
 }
 
+// BandwidthLimits makes a request to GET /api/v1/network/bandwidth
+func (c *Client) BandwidthLimits() (*BandwidthLimitsResponse, error) {
+	var rsp BandwidthLimitsResponse
+	if err := c.Get("/api/v1/network/bandwidth", &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// SetBandwidthLimits makes a request to POST /api/v1/network/bandwidth to change the bandwidth limits
+// of block transfers, in kilobits per second. 0 is unlimited.
+func (c *Client) SetBandwidthLimits(maxUploadKbps, maxDownloadKbps uint64) (*BandwidthLimitsResponse, error) {
+	v := url.Values{}
+	v.Add("max_upload_kbps", fmt.Sprint(maxUploadKbps))
+	v.Add("max_download_kbps", fmt.Sprint(maxDownloadKbps))
+
+	var rsp BandwidthLimitsResponse
+	if err := c.PostForm("/api/v1/network/bandwidth", strings.NewReader(v.Encode()), &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// PeerBans makes a request to GET /api/v1/network/bans
+func (c *Client) PeerBans() (*PeerBansResponse, error) {
+	var rsp PeerBansResponse
+	if err := c.Get("/api/v1/network/bans", &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// Unban makes a request to POST /api/v1/network/unban to lift the ban of a peer IP
+func (c *Client) Unban(ip string) (*PeerBansResponse, error) {
+	v := url.Values{}
+	v.Add("ip", ip)
+
+	var rsp PeerBansResponse
+	if err := c.PostForm("/api/v1/network/unban", strings.NewReader(v.Encode()), &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// TransactionPropagation makes a request to GET /api/v1/network/propagation?txid=
+func (c *Client) TransactionPropagation(txid string) (*PropagationResponse, error) {
+	v := url.Values{}
+	v.Add("txid", txid)
+	endpoint := "/api/v1/network/propagation?" + v.Encode()
+
+	var rsp PropagationResponse
+	if err := c.Get(endpoint, &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// BlockPropagation makes a request to GET /api/v1/network/propagation?block=
+func (c *Client) BlockPropagation(hash string) (*PropagationResponse, error) {
+	v := url.Values{}
+	v.Add("block", hash)
+	endpoint := "/api/v1/network/propagation?" + v.Encode()
+
+	var rsp PropagationResponse
+	if err := c.Get(endpoint, &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
 // GetAllStorageValues makes a GET request to /api/v2/data to get all the values from the storage of
 // `storageType` type
 func (c *Client) GetAllStorageValues(storageType kvstorage.Type) (map[string]string, error) {
@@ -1312,3 +2526,77 @@ func (c *Client) RemoveStorageValue(storageType kvstorage.Type, key string) erro
 
 	return err
 }
+
+// Subscribe makes a POST request to /api/v2/notifications/subscriptions to subscribe to the
+// transactions of applied blocks touching addrs
+func (c *Client) Subscribe(addrs []string) (*SubscriptionResponse, error) {
+	var rsp SubscriptionResponse
+	ok, err := c.PostJSONV2("/api/v2/notifications/subscriptions", SubscriptionRequest{
+		Addresses: addrs,
+	}, &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return &rsp, err
+}
+
+// Unsubscribe makes a DELETE request to /api/v2/notifications/subscriptions to remove a subscription
+func (c *Client) Unsubscribe(id string) error {
+	v := url.Values{}
+	v.Add("id", id)
+
+	_, err := c.DeleteV2("/api/v2/notifications/subscriptions?"+v.Encode(), nil)
+
+	return err
+}
+
+// Notifications makes a GET request to /api/v2/notifications to get the events of a subscription
+// after the cursor. A limit of 0 uses the server's default.
+func (c *Client) Notifications(id string, after uint64, limit int) (*NotificationsResponse, error) {
+	v := url.Values{}
+	v.Add("subscription", id)
+	v.Add("after", fmt.Sprint(after))
+	if limit > 0 {
+		v.Add("limit", fmt.Sprint(limit))
+	}
+
+	var rsp NotificationsResponse
+	ok, err := c.GetV2("/api/v2/notifications?"+v.Encode(), &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return &rsp, err
+}
+
+// TransactionAncestry makes a GET request to /api/v2/transaction/ancestry to get the graph of the outputs
+// an output was created from. A depth of -1 or a maxNodes of 0 uses the server's default.
+func (c *Client) TransactionAncestry(uxid string, depth, maxNodes int) (*TxnGraphResponse, error) {
+	return c.txnGraph("/api/v2/transaction/ancestry", uxid, depth, maxNodes)
+}
+
+// TransactionDescendants makes a GET request to /api/v2/transaction/descendants to get the graph of the outputs
+// a spent output was spent into. A depth of -1 or a maxNodes of 0 uses the server's default.
+func (c *Client) TransactionDescendants(uxid string, depth, maxNodes int) (*TxnGraphResponse, error) {
+	return c.txnGraph("/api/v2/transaction/descendants", uxid, depth, maxNodes)
+}
+
+func (c *Client) txnGraph(endpoint, uxid string, depth, maxNodes int) (*TxnGraphResponse, error) {
+	v := url.Values{}
+	v.Add("uxid", uxid)
+	if depth >= 0 {
+		v.Add("depth", fmt.Sprint(depth))
+	}
+	if maxNodes > 0 {
+		v.Add("max_nodes", fmt.Sprint(maxNodes))
+	}
+
+	var rsp TxnGraphResponse
+	ok, err := c.GetV2(endpoint+"?"+v.Encode(), &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return &rsp, err
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"crypto/hmac"
@@ -20,7 +22,10 @@ const (
 	// CSRFHeaderName is the name of the CSRF header
 	CSRFHeaderName = "X-CSRF-Token"
 
-	// CSRFMaxAge is the lifetime of a CSRF token in seconds
+	// CSRFSessionHeaderName is the name of the header with the client's session id, which CSRF tokens are bound to
+	CSRFSessionHeaderName = "X-CSRF-Session"
+
+	// CSRFMaxAge is the default lifetime of a CSRF token
 	CSRFMaxAge = time.Second * 30
 
 	csrfSecretLength = 64
@@ -35,29 +40,73 @@ var (
 	ErrCSRFInvalidSignature = errors.New("invalid CSRF token signature")
 	// ErrCSRFExpired is returned when the csrf token has expired
 	ErrCSRFExpired = errors.New("csrf token expired")
+	// ErrCSRFBindingMismatch is returned when the csrf token was issued to another origin or session
+	ErrCSRFBindingMismatch = errors.New("csrf token was issued to another origin or session")
 )
 
-var csrfSecretKey []byte
+// csrfSecret is the server secret that CSRF tokens are signed with.
+// Rotating it invalidates all outstanding tokens.
+var csrfSecret = struct {
+	sync.RWMutex
+	key []byte
+}{
+	key: cipher.RandByte(csrfSecretLength),
+}
+
+func csrfSecretKey() []byte {
+	csrfSecret.RLock()
+	defer csrfSecret.RUnlock()
+	return csrfSecret.key
+}
 
-func init() {
-	csrfSecretKey = cipher.RandByte(csrfSecretLength)
+// rotateCSRFSecret replaces the CSRF server secret, invalidating all outstanding tokens
+func rotateCSRFSecret() {
+	csrfSecret.Lock()
+	defer csrfSecret.Unlock()
+	csrfSecret.key = cipher.RandByte(csrfSecretLength)
+}
+
+// CSRFBinding is the client session that a CSRF token is bound to
+type CSRFBinding struct {
+	// Origin is the origin of the request, from the Origin header, falling back on the Referer header
+	Origin string `json:",omitempty"`
+	// Session is the session id declared by the client in the X-CSRF-Session header
+	Session string `json:",omitempty"`
+}
+
+// newCSRFBinding returns the client session of a request
+func newCSRFBinding(r *http.Request) CSRFBinding {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Browsers don't send the Origin header with same-origin GET requests, use the origin of the Referer
+		if u, err := url.Parse(r.Header.Get("Referer")); err == nil && u.Host != "" {
+			origin = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+		}
+	}
+
+	return CSRFBinding{
+		Origin:  origin,
+		Session: r.Header.Get(CSRFSessionHeaderName),
+	}
 }
 
 // CSRFToken csrf token
 type CSRFToken struct {
 	Nonce     []byte
 	ExpiresAt time.Time
+	CSRFBinding
 }
 
-// newCSRFToken generates a new CSRF Token
-func newCSRFToken() (string, error) {
-	return newCSRFTokenWithTime(time.Now().Add(CSRFMaxAge))
+// newCSRFToken generates a new CSRF Token bound to a client session
+func newCSRFToken(b CSRFBinding, lifetime time.Duration) (string, error) {
+	return newCSRFTokenWithTime(b, time.Now().Add(lifetime))
 }
 
-func newCSRFTokenWithTime(expiresAt time.Time) (string, error) {
+func newCSRFTokenWithTime(b CSRFBinding, expiresAt time.Time) (string, error) {
 	token := &CSRFToken{
-		Nonce:     cipher.RandByte(csrfNonceLength),
-		ExpiresAt: expiresAt,
+		Nonce:       cipher.RandByte(csrfNonceLength),
+		ExpiresAt:   expiresAt,
+		CSRFBinding: b,
 	}
 
 	tokenJSON, err := json.Marshal(token)
@@ -65,7 +114,7 @@ func newCSRFTokenWithTime(expiresAt time.Time) (string, error) {
 		return "", err
 	}
 
-	h := hmac.New(sha256.New, csrfSecretKey)
+	h := hmac.New(sha256.New, csrfSecretKey())
 	_, err = h.Write([]byte(tokenJSON))
 	if err != nil {
 		return "", err
@@ -78,8 +127,9 @@ func newCSRFTokenWithTime(expiresAt time.Time) (string, error) {
 	return strings.Join([]string{signingString, sig}, "."), nil
 }
 
-// verifyCSRFToken checks validity of the given token
-func verifyCSRFToken(headerToken string) error {
+// verifyCSRFToken checks validity of the given token.
+// If b is not nil, the token must have been issued to the client session b.
+func verifyCSRFToken(headerToken string, b *CSRFBinding) error {
 	tokenParts := strings.Split(headerToken, ".")
 	if len(tokenParts) != 2 {
 		return ErrCSRFInvalid
@@ -90,7 +140,7 @@ func verifyCSRFToken(headerToken string) error {
 		return err
 	}
 
-	h := hmac.New(sha256.New, csrfSecretKey)
+	h := hmac.New(sha256.New, csrfSecretKey())
 	_, err = h.Write([]byte(signingString))
 	if err != nil {
 		return err
@@ -98,7 +148,7 @@ func verifyCSRFToken(headerToken string) error {
 
 	sig := base64.RawURLEncoding.EncodeToString(h.Sum(nil))
 
-	if sig != tokenParts[1] {
+	if !hmac.Equal([]byte(sig), []byte(tokenParts[1])) {
 		return ErrCSRFInvalidSignature
 	}
 
@@ -112,29 +162,71 @@ func verifyCSRFToken(headerToken string) error {
 		return ErrCSRFExpired
 	}
 
+	if b != nil && csrfToken.CSRFBinding != *b {
+		return ErrCSRFBindingMismatch
+	}
+
 	return nil
 }
 
-// Creates a new CSRF token. Previous CSRF tokens are invalidated by this call.
+// csrfConfig configures the CSRF check
+type csrfConfig struct {
+	disabled bool
+	// lifetime is the lifetime of the tokens
+	lifetime time.Duration
+	// stateless disables binding the tokens to the client session,
+	// any client can use a token. Deprecated, it will be removed in the next release.
+	stateless bool
+}
+
+// newToken creates a CSRF token for the client session of a request
+func (c csrfConfig) newToken(r *http.Request) (string, error) {
+	var b CSRFBinding
+	if !c.stateless {
+		b = newCSRFBinding(r)
+	}
+
+	lifetime := c.lifetime
+	if lifetime == 0 {
+		lifetime = CSRFMaxAge
+	}
+
+	return newCSRFToken(b, lifetime)
+}
+
+// verify checks the CSRF token of a request
+func (c csrfConfig) verify(r *http.Request) error {
+	token := r.Header.Get(CSRFHeaderName)
+
+	if c.stateless {
+		return verifyCSRFToken(token, nil)
+	}
+
+	b := newCSRFBinding(r)
+	return verifyCSRFToken(token, &b)
+}
+
+// Creates a new CSRF token, bound to the origin of the request and the session id in the X-CSRF-Session header.
 // URI: /api/v1/csrf
 // Method: GET
 // Response:
-//  csrf_token: CSRF token to use in POST requests
-func getCSRFToken(disabled bool) http.HandlerFunc {
+//
+//	csrf_token: CSRF token to use in POST requests
+func getCSRFToken(c csrfConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			wh.Error405(w)
 			return
 		}
 
-		if disabled {
+		if c.disabled {
 			logger.Warning("CSRF check disabled")
 			wh.Error404(w, "")
 			return
 		}
 
 		// generate a new token
-		csrfToken, err := newCSRFToken()
+		csrfToken, err := c.newToken(r)
 		if err != nil {
 			logger.Error(err)
 			wh.Error500(w, fmt.Sprintf("Failed to create a csrf token: %v", err))
@@ -145,15 +237,53 @@ func getCSRFToken(disabled bool) http.HandlerFunc {
 	}
 }
 
-// CSRFCheck verifies X-CSRF-Token header value
-func CSRFCheck(apiVersion string, disabled bool, handler http.Handler) http.Handler {
+// Rotates the CSRF server secret, invalidating all outstanding CSRF tokens,
+// and returns a new token for the client session of the request.
+// URI: /api/v1/csrf/rotate
+// Method: POST
+// Response:
+//
+//	csrf_token: CSRF token to use in POST requests
+func rotateCSRFHandler(c csrfConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		if c.disabled {
+			wh.Error404(w, "")
+			return
+		}
+
+		rotateCSRFSecret()
+		logger.Info("CSRF secret rotated")
+
+		csrfToken, err := c.newToken(r)
+		if err != nil {
+			logger.Error(err)
+			wh.Error500(w, fmt.Sprintf("Failed to create a csrf token: %v", err))
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, &map[string]string{"csrf_token": csrfToken})
+	}
+}
+
+// CSRFCheck verifies X-CSRF-Token header value.
+// Unless stateless is true, the token must have been issued to the origin and session of the request.
+func CSRFCheck(apiVersion string, disabled, stateless bool, handler http.Handler) http.Handler {
+	c := csrfConfig{
+		disabled:  disabled,
+		stateless: stateless,
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !disabled {
+		if !c.disabled {
 			switch r.Method {
 			case http.MethodPost, http.MethodPut, http.MethodDelete:
-				token := r.Header.Get(CSRFHeaderName)
-				if err := verifyCSRFToken(token); err != nil {
-					logger.Errorf("CSRF token invalid: %v", err)
+				if err := c.verify(r); err != nil {
+					requestLogger(r).Errorf("CSRF token invalid: %v", err)
 					writeError(w, apiVersion, http.StatusForbidden, err.Error())
 					return
 				}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -24,7 +26,7 @@ const (
 )
 
 func setCSRFParameters(t *testing.T, tokenType string, req *http.Request) {
-	token, err := newCSRFToken()
+	token, err := newCSRFToken(CSRFBinding{}, CSRFMaxAge)
 	require.NoError(t, err)
 	// token check
 	switch tokenType {
@@ -37,7 +39,7 @@ func setCSRFParameters(t *testing.T, tokenType string, req *http.Request) {
 		req.Header.Set("X-CSRF-Token", "YXNkc2Fkcw.YXNkc2Fkcw")
 	case tokenExpired:
 		// set some old unix time
-		expiredToken, err := newCSRFTokenWithTime(time.Unix(1517509381, 10))
+		expiredToken, err := newCSRFTokenWithTime(CSRFBinding{}, time.Unix(1517509381, 10))
 		require.NoError(t, err)
 		req.Header.Set("X-CSRF-Token", expiredToken)
 	case tokenEmpty:
@@ -55,7 +57,7 @@ func TestCSRFWrapper(t *testing.T) {
 			for _, c := range cases {
 				name := fmt.Sprintf("%s %s %s", method, endpoint, c)
 				t.Run(name, func(t *testing.T) {
-					gateway := &MockGatewayer{}
+					gateway := newWalletMockGatewayer()
 
 					req, err := http.NewRequest(method, endpoint, nil)
 					require.NoError(t, err)
@@ -106,7 +108,7 @@ func TestCSRFWrapperConcurrent(t *testing.T) {
 	methods := []string{http.MethodPost, http.MethodPut, http.MethodDelete}
 	cases := []string{tokenInvalid, tokenExpired, tokenEmpty, tokenInvalidSignature}
 
-	gateway := &MockGatewayer{}
+	gateway := newWalletMockGatewayer()
 
 	handler := newServerMux(muxConfig{
 		host:           configuredHost,
@@ -172,7 +174,7 @@ func TestCSRFWrapperConcurrent(t *testing.T) {
 
 func TestCSRF(t *testing.T) {
 	updateWalletLabel := func(csrfToken string) *httptest.ResponseRecorder {
-		gateway := &MockGatewayer{}
+		gateway := newWalletMockGatewayer()
 		gateway.On("UpdateWalletLabel", "fooid", "foolabel").Return(nil)
 
 		endpoint := "/api/v1/wallet/update"
@@ -209,7 +211,7 @@ func TestCSRF(t *testing.T) {
 	require.Equal(t, "403 Forbidden - invalid CSRF token\n", rr.Body.String())
 
 	// Make a request to /csrf to get a token
-	gateway := &MockGatewayer{}
+	gateway := newWalletMockGatewayer()
 	cfg := defaultMuxConfig()
 	cfg.disableCSRF = false
 	handler := newServerMux(cfg, gateway)
@@ -264,3 +266,226 @@ func TestCSRF(t *testing.T) {
 	rr = updateWalletLabel(token)
 	require.Equal(t, http.StatusOK, rr.Code)
 }
+
+// csrfTestClient requests CSRF tokens and makes requests to POST /api/v1/wallet/update with them
+type csrfTestClient struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+func newCSRFTestClient(t *testing.T, cfg muxConfig) *csrfTestClient {
+	gateway := newWalletMockGatewayer()
+	gateway.On("UpdateWalletLabel", "fooid", "foolabel").Return(nil)
+
+	return &csrfTestClient{
+		t:       t,
+		handler: newServerMux(cfg, gateway),
+	}
+}
+
+func (c *csrfTestClient) do(method, endpoint, token string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, endpoint, body)
+	require.NoError(c.t, err)
+
+	if token != "" {
+		req.Header.Set(CSRFHeaderName, token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rr := httptest.NewRecorder()
+	c.handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func (c *csrfTestClient) token(method, endpoint, token string, headers map[string]string) string {
+	rr := c.do(method, endpoint, token, nil, headers)
+	require.Equal(c.t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var msg map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &msg)
+	require.NoError(c.t, err)
+	require.NotEmpty(c.t, msg["csrf_token"])
+
+	return msg["csrf_token"]
+}
+
+func (c *csrfTestClient) getToken(headers map[string]string) string {
+	return c.token(http.MethodGet, "/api/v1/csrf", "", headers)
+}
+
+func (c *csrfTestClient) updateWalletLabel(token string, headers map[string]string) *httptest.ResponseRecorder {
+	v := url.Values{}
+	v.Add("id", "fooid")
+	v.Add("label", "foolabel")
+
+	h := map[string]string{
+		"Content-Type": ContentTypeForm,
+	}
+	for k, v := range headers {
+		h[k] = v
+	}
+
+	return c.do(http.MethodPost, "/api/v1/wallet/update", token, strings.NewReader(v.Encode()), h)
+}
+
+func TestCSRFBinding(t *testing.T) {
+	cfg := defaultMuxConfig()
+	cfg.disableCSRF = false
+	cfg.hostWhitelist = []string{"example.com"}
+
+	origin := "http://" + configuredHost
+	otherOrigin := "http://example.com"
+
+	c := newCSRFTestClient(t, cfg)
+
+	token := c.getToken(map[string]string{
+		"Origin":              origin,
+		CSRFSessionHeaderName: "session1",
+	})
+
+	// The token is valid for the origin and session it was issued to
+	rr := c.updateWalletLabel(token, map[string]string{
+		"Origin":              origin,
+		CSRFSessionHeaderName: "session1",
+	})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Replaying the token from another origin is rejected
+	rr = c.updateWalletLabel(token, map[string]string{
+		"Origin":              otherOrigin,
+		CSRFSessionHeaderName: "session1",
+	})
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Equal(t, fmt.Sprintf("403 Forbidden - %s\n", ErrCSRFBindingMismatch), rr.Body.String())
+
+	// Replaying the token from another session is rejected
+	rr = c.updateWalletLabel(token, map[string]string{
+		"Origin":              origin,
+		CSRFSessionHeaderName: "session2",
+	})
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Equal(t, fmt.Sprintf("403 Forbidden - %s\n", ErrCSRFBindingMismatch), rr.Body.String())
+
+	// Replaying the token without origin and session is rejected
+	rr = c.updateWalletLabel(token, nil)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Equal(t, fmt.Sprintf("403 Forbidden - %s\n", ErrCSRFBindingMismatch), rr.Body.String())
+
+	// A token requested with a Referer is bound to the origin of the Referer,
+	// browsers don't send the Origin header with same-origin GET requests
+	token = c.getToken(map[string]string{
+		"Referer": origin + "/wallet",
+	})
+	rr = c.updateWalletLabel(token, map[string]string{
+		"Origin": origin,
+	})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// A token requested without origin and session is only valid without origin and session
+	token = c.getToken(nil)
+	rr = c.updateWalletLabel(token, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = c.updateWalletLabel(token, map[string]string{
+		"Origin": otherOrigin,
+	})
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Stateless tokens are not bound to the client session
+	cfg.csrfStateless = true
+	c = newCSRFTestClient(t, cfg)
+
+	token = c.getToken(map[string]string{
+		"Origin":              origin,
+		CSRFSessionHeaderName: "session1",
+	})
+	rr = c.updateWalletLabel(token, map[string]string{
+		"Origin":              otherOrigin,
+		CSRFSessionHeaderName: "session2",
+	})
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCSRFRotate(t *testing.T) {
+	cfg := defaultMuxConfig()
+	cfg.disableCSRF = false
+
+	c := newCSRFTestClient(t, cfg)
+
+	// Rotating requires a valid token
+	rr := c.do(http.MethodPost, "/api/v1/csrf/rotate", "", nil, nil)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Equal(t, "403 Forbidden - invalid CSRF token\n", rr.Body.String())
+
+	rr = c.do(http.MethodGet, "/api/v1/csrf/rotate", "", nil, nil)
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	headers := map[string]string{
+		CSRFSessionHeaderName: "session1",
+	}
+
+	oldToken := c.getToken(headers)
+	otherToken := c.getToken(nil)
+
+	// Rotating returns a new token for the client session
+	newToken := c.token(http.MethodPost, "/api/v1/csrf/rotate", oldToken, headers)
+	require.NotEqual(t, oldToken, newToken)
+
+	// Outstanding tokens are invalidated
+	for _, token := range []string{oldToken, otherToken} {
+		rr = c.updateWalletLabel(token, headers)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+		require.Equal(t, fmt.Sprintf("403 Forbidden - %s\n", ErrCSRFInvalidSignature), rr.Body.String())
+	}
+
+	rr = c.updateWalletLabel(newToken, headers)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Tokens requested after the rotation are valid
+	rr = c.updateWalletLabel(c.getToken(nil), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Rotating is not available when CSRF is disabled
+	c = newCSRFTestClient(t, defaultMuxConfig())
+	rr = c.do(http.MethodPost, "/api/v1/csrf/rotate", "", nil, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCSRFTokenLifetime(t *testing.T) {
+	cfg := defaultMuxConfig()
+	cfg.disableCSRF = false
+	cfg.csrfTokenLifetime = time.Hour
+
+	c := newCSRFTestClient(t, cfg)
+
+	start := time.Now()
+	token := c.getToken(nil)
+
+	b, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	require.NoError(t, err)
+
+	var csrfToken CSRFToken
+	err = json.Unmarshal(b, &csrfToken)
+	require.NoError(t, err)
+	require.False(t, csrfToken.ExpiresAt.Before(start.Add(time.Hour)))
+	require.True(t, csrfToken.ExpiresAt.Before(time.Now().Add(time.Hour+time.Second)))
+
+	// The default lifetime is CSRFMaxAge
+	cfg.csrfTokenLifetime = 0
+	c = newCSRFTestClient(t, cfg)
+	token = c.getToken(nil)
+
+	b, err = base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	require.NoError(t, err)
+	err = json.Unmarshal(b, &csrfToken)
+	require.NoError(t, err)
+	require.True(t, csrfToken.ExpiresAt.Before(time.Now().Add(CSRFMaxAge+time.Second)))
+
+	// A token is rejected after its lifetime
+	token, err = newCSRFToken(CSRFBinding{}, time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, verifyCSRFToken(token, &CSRFBinding{}))
+	time.Sleep(5 * time.Millisecond)
+	require.Equal(t, ErrCSRFExpired, verifyCSRFToken(token, &CSRFBinding{}))
+}
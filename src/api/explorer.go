@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/droplet"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 // CoinSupply records the coin supply info
@@ -38,6 +43,22 @@ func newAddrSet(addrs []cipher.Address) map[cipher.Address]struct{} {
 	return s
 }
 
+// unlockedPoolBalance returns the coins held by the confirmed unspent outputs of the unlocked distribution addresses
+func unlockedPoolBalance(unspents []visor.UnspentOutput, unlockedAddrSet map[cipher.Address]struct{}) (uint64, error) {
+	var balance uint64
+	for _, u := range unspents {
+		// check if address is an unlocked distribution address
+		if _, ok := unlockedAddrSet[u.Body.Address]; ok {
+			var err error
+			balance, err = mathutil.AddUint64(balance, u.Body.Coins)
+			if err != nil {
+				return 0, fmt.Errorf("uint64 overflow while adding up unlocked supply coins: %v", err)
+			}
+		}
+	}
+	return balance, nil
+}
+
 // coinSupplyHandler returns coin distribution supply stats
 // Method: GET
 // URI: /api/v1/coinSupply
@@ -61,19 +82,10 @@ func coinSupplyHandler(gateway Gatewayer) http.HandlerFunc {
 		// Search map of unlocked addresses, used to filter unspents
 		unlockedAddrSet := newAddrSet(unlockedAddrs)
 
-		var unlockedSupply uint64
-		// check confirmed unspents only
-		for _, u := range allUnspents.Confirmed {
-			// check if address is an unlocked distribution address
-			if _, ok := unlockedAddrSet[u.Body.Address]; ok {
-				var err error
-				unlockedSupply, err = mathutil.AddUint64(unlockedSupply, u.Body.Coins)
-				if err != nil {
-					err = fmt.Errorf("uint64 overflow while adding up unlocked supply coins: %v", err)
-					wh.Error500(w, err.Error())
-					return
-				}
-			}
+		unlockedSupply, err := unlockedPoolBalance(allUnspents.Confirmed, unlockedAddrSet)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
 		}
 
 		// "total supply" is the number of coins unlocked.
@@ -155,6 +167,217 @@ func coinSupplyHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// UnlockTranche is a group of distribution addresses unlocked at the same time
+type UnlockTranche struct {
+	// Time is the unix time at which the addresses are unlocked
+	Time      uint64   `json:"time"`
+	Addresses []string `json:"addresses"`
+	// UnlockedAddressCount is the total number of unlocked distribution addresses after this tranche
+	UnlockedAddressCount uint64 `json:"unlocked_address_count"`
+	// UnlockedSupply is the total coins of the unlocked distribution addresses after this tranche
+	UnlockedSupply string `json:"unlocked_supply"`
+}
+
+// CoinSupplyProjection is the projected coin supply at a point in time
+type CoinSupplyProjection struct {
+	// At is the unix time of the projection
+	At uint64 `json:"at"`
+	// ScheduleStart is the unix time at which the unlock schedule starts
+	ScheduleStart uint64 `json:"schedule_start"`
+	MaxSupply     string `json:"max_supply"`
+	// TotalSupply is the coins in existence at At, 0 before the genesis block and the max supply afterwards
+	TotalSupply string `json:"total_supply"`
+	// UnlockedSupply is the coins of the distribution addresses unlocked at At
+	UnlockedSupply string `json:"unlocked_supply"`
+	// LockedSupply is the coins of the distribution addresses still locked at At
+	LockedSupply         string `json:"locked_supply"`
+	UnlockedAddressCount uint64 `json:"unlocked_address_count"`
+	// CurrentSupply is the coins distributed from the unlocked distribution addresses as of the head block
+	CurrentSupply string          `json:"current_supply"`
+	Schedule      []UnlockTranche `json:"schedule"`
+}
+
+// distributionUnlockSchedule returns the unlock schedule of the distribution addresses, starting at the unix time start.
+// The first InitialUnlockedCount addresses are unlocked at start, then UnlockAddressRate addresses
+// are unlocked every UnlockTimeInterval seconds, until all addresses are unlocked.
+// If UnlockAddressRate or UnlockTimeInterval is 0, only the initial addresses are ever unlocked.
+func distributionUnlockSchedule(dist params.Distribution, start uint64) ([]UnlockTranche, error) {
+	n := uint64(len(dist.Addresses))
+	unlocked := dist.InitialUnlockedCount
+	if unlocked > n {
+		unlocked = n
+	}
+
+	tranche := func(t, from, to uint64) (UnlockTranche, error) {
+		supply, err := droplet.ToString(to * dist.AddressInitialBalance() * droplet.Multiplier)
+		if err != nil {
+			return UnlockTranche{}, err
+		}
+
+		addrs := make([]string, to-from)
+		copy(addrs, dist.Addresses[from:to])
+
+		return UnlockTranche{
+			Time:                 t,
+			Addresses:            addrs,
+			UnlockedAddressCount: to,
+			UnlockedSupply:       supply,
+		}, nil
+	}
+
+	first, err := tranche(start, 0, unlocked)
+	if err != nil {
+		return nil, err
+	}
+	schedule := []UnlockTranche{first}
+
+	if dist.UnlockAddressRate == 0 || dist.UnlockTimeInterval == 0 {
+		return schedule, nil
+	}
+
+	t := start
+	for unlocked < n {
+		t, err = mathutil.AddUint64(t, dist.UnlockTimeInterval)
+		if err != nil {
+			return nil, err
+		}
+
+		next := unlocked + dist.UnlockAddressRate
+		if next > n || next < unlocked {
+			next = n
+		}
+
+		tr, err := tranche(t, unlocked, next)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, tr)
+
+		unlocked = next
+	}
+
+	return schedule, nil
+}
+
+// unlockedAddressCountAt returns the number of distribution addresses unlocked at the unix time at
+func unlockedAddressCountAt(schedule []UnlockTranche, at uint64) uint64 {
+	var unlocked uint64
+	for _, t := range schedule {
+		if t.Time > at {
+			break
+		}
+		unlocked = t.UnlockedAddressCount
+	}
+	return unlocked
+}
+
+// coinSupplyProjectionHandler returns the coin supply at a point in time, projected from the unlock schedule
+// of the distribution addresses, and the unlock schedule.
+// The projection only depends on the distribution parameters and the genesis timestamp,
+// except for current_supply, which is the current supply as of the head block.
+// Method: GET
+// URI: /api/v1/coinSupply/projection
+// Args:
+//
+//	at: unix time of the projection [optional, defaults to now]
+//	start: unix time at which the unlock schedule starts [optional, defaults to the genesis block timestamp]
+func coinSupplyProjectionHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		at := uint64(time.Now().Unix())
+		if atStr := r.FormValue("at"); atStr != "" {
+			var err error
+			at, err = strconv.ParseUint(atStr, 10, 64)
+			if err != nil {
+				wh.Error400(w, "invalid at value")
+				return
+			}
+		}
+
+		cfg := gateway.VisorConfig()
+		dist := cfg.Distribution
+		genesis := cfg.GenesisTimestamp
+
+		start := genesis
+		if startStr := r.FormValue("start"); startStr != "" {
+			var err error
+			start, err = strconv.ParseUint(startStr, 10, 64)
+			if err != nil {
+				wh.Error400(w, "invalid start value")
+				return
+			}
+
+			if start < genesis {
+				wh.Error400(w, "start must not be before the genesis block timestamp")
+				return
+			}
+		}
+
+		schedule, err := distributionUnlockSchedule(dist, start)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		// No coins exist before the genesis block
+		var totalSupply, unlockedSupply uint64
+		var unlockedCount uint64
+		if at >= genesis {
+			totalSupply = dist.MaxCoinSupply * droplet.Multiplier
+			unlockedCount = unlockedAddressCountAt(schedule, at)
+			unlockedSupply = unlockedCount * dist.AddressInitialBalance() * droplet.Multiplier
+		}
+
+		// The current supply is computed from the currently unlocked addresses, as /api/v1/coinSupply does
+		allUnspents, err := gateway.GetUnspentOutputsSummary(nil)
+		if err != nil {
+			err = fmt.Errorf("gateway.GetUnspentOutputsSummary failed: %v", err)
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		unlockedAddrs := dist.UnlockedAddressesDecoded()
+		unlockedPool, err := unlockedPoolBalance(allUnspents.Confirmed, newAddrSet(unlockedAddrs))
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		currentSupply := uint64(len(unlockedAddrs))*dist.AddressInitialBalance()*droplet.Multiplier - unlockedPool
+
+		projection := CoinSupplyProjection{
+			At:                   at,
+			ScheduleStart:        start,
+			UnlockedAddressCount: unlockedCount,
+			Schedule:             schedule,
+		}
+
+		for _, v := range []struct {
+			coins uint64
+			dst   *string
+		}{
+			{dist.MaxCoinSupply * droplet.Multiplier, &projection.MaxSupply},
+			{totalSupply, &projection.TotalSupply},
+			{unlockedSupply, &projection.UnlockedSupply},
+			{totalSupply - unlockedSupply, &projection.LockedSupply},
+			{currentSupply, &projection.CurrentSupply},
+		} {
+			*v.dst, err = droplet.ToString(v.coins)
+			if err != nil {
+				err = fmt.Errorf("Failed to convert coins to string: %v", err)
+				wh.Error500(w, err.Error())
+				return
+			}
+		}
+
+		wh.SendJSONOr500(logger, w, projection)
+	}
+}
+
 // Richlist contains top address balances
 type Richlist struct {
 	Richlist []readable.RichlistBalance `json:"richlist"`
@@ -164,8 +387,9 @@ type Richlist struct {
 // Method: GET
 // URI: /richlist?n=${number}&include-distribution=${bool}
 // Args:
-//	n [int, number of results to include]
-//  include-distribution [bool, include the distribution addresses in the richlist]
+//
+//		n [int, number of results to include]
+//	 include-distribution [bool, include the distribution addresses in the richlist]
 func richlistHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -240,3 +464,253 @@ func addressCountHandler(gateway Gatewayer) http.HandlerFunc {
 		wh.SendJSONOr500(logger, w, &map[string]uint64{"count": addrCount})
 	}
 }
+
+const (
+	// addressExplorerPath is the path of the address explorer endpoint, followed by the address
+	addressExplorerPath = "/api/v1/explorer/address/"
+
+	defaultAddressExplorerTopOutputs = 10
+	defaultAddressExplorerLimit      = 10
+)
+
+// AddressExplorer is the address page of a block explorer.
+// Sections that were skipped are omitted.
+type AddressExplorer struct {
+	Address  string `json:"address"`
+	HeadSeq  uint64 `json:"head_seq"`
+	HeadTime uint64 `json:"head_time"`
+
+	Balance      *readable.Balance            `json:"balance,omitempty"`
+	Outputs      *AddressExplorerOutputs      `json:"outputs,omitempty"`
+	Pending      *AddressExplorerPending      `json:"pending,omitempty"`
+	Transactions *AddressExplorerTransactions `json:"transactions,omitempty"`
+	// Activity is also omitted if the address has no confirmed transactions
+	Activity *AddressExplorerActivity `json:"activity,omitempty"`
+}
+
+// AddressExplorerOutputs are the confirmed unspent outputs of an address
+type AddressExplorerOutputs struct {
+	Count uint64                  `json:"count"`
+	Top   readable.UnspentOutputs `json:"top"`
+}
+
+// AddressExplorerPending are the outputs of an address involved in unconfirmed transactions
+type AddressExplorerPending struct {
+	Incoming readable.UnspentOutputs `json:"incoming"`
+	Outgoing readable.UnspentOutputs `json:"outgoing"`
+}
+
+// AddressExplorerTransactions are the most recent confirmed transactions of an address
+type AddressExplorerTransactions struct {
+	Total  uint64                       `json:"total"`
+	Recent []AddressExplorerTransaction `json:"recent"`
+}
+
+// AddressExplorerTransaction summarizes a confirmed transaction from the point of view of an address
+type AddressExplorerTransaction struct {
+	Txid     string                     `json:"txid"`
+	Status   readable.TransactionStatus `json:"status"`
+	Time     uint64                     `json:"time"`
+	Received string                     `json:"received"`
+	Sent     string                     `json:"sent"`
+	Inputs   int                        `json:"inputs"`
+	Outputs  int                        `json:"outputs"`
+}
+
+// AddressExplorerActivity is the first and last confirmed transactions involving an address
+type AddressExplorerActivity struct {
+	FirstSeenSeq   uint64 `json:"first_seen_seq"`
+	FirstSeenTime  uint64 `json:"first_seen_time"`
+	LastActiveSeq  uint64 `json:"last_active_seq"`
+	LastActiveTime uint64 `json:"last_active_time"`
+}
+
+func newReadableUnspentOutputs(outs []visor.UnspentOutput) (readable.UnspentOutputs, error) {
+	uxs := make([]visor.UnspentOutput, len(outs))
+	for i, o := range outs {
+		uxs[i] = visor.UnspentOutput{
+			UxOut:           o.UxOut,
+			CalculatedHours: o.CalculatedHours,
+		}
+	}
+	return readable.NewUnspentOutputs(uxs)
+}
+
+// NewAddressExplorer creates an AddressExplorer from a visor.AddressExplorer
+func NewAddressExplorer(ae *visor.AddressExplorer) (*AddressExplorer, error) {
+	r := &AddressExplorer{
+		Address:  ae.Address.String(),
+		HeadSeq:  ae.HeadSeq,
+		HeadTime: ae.HeadTime,
+	}
+
+	if ae.Balance != nil {
+		b := readable.NewBalance(*ae.Balance)
+		r.Balance = &b
+	}
+
+	if ae.Outputs != nil {
+		top, err := newReadableUnspentOutputs(ae.Outputs.Top)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Outputs = &AddressExplorerOutputs{
+			Count: ae.Outputs.Count,
+			Top:   top,
+		}
+	}
+
+	if ae.Pending != nil {
+		incoming, err := newReadableUnspentOutputs(ae.Pending.Incoming)
+		if err != nil {
+			return nil, err
+		}
+
+		outgoing, err := newReadableUnspentOutputs(ae.Pending.Outgoing)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Pending = &AddressExplorerPending{
+			Incoming: incoming,
+			Outgoing: outgoing,
+		}
+	}
+
+	if ae.Transactions != nil {
+		r.Transactions = &AddressExplorerTransactions{
+			Total:  ae.Transactions.Total,
+			Recent: make([]AddressExplorerTransaction, len(ae.Transactions.Recent)),
+		}
+
+		for i, s := range ae.Transactions.Recent {
+			received, err := droplet.ToString(s.Received)
+			if err != nil {
+				return nil, err
+			}
+
+			sent, err := droplet.ToString(s.Sent)
+			if err != nil {
+				return nil, err
+			}
+
+			r.Transactions.Recent[i] = AddressExplorerTransaction{
+				Txid: s.Transaction.Transaction.Hash().Hex(),
+				Status: readable.TransactionStatus{
+					Confirmed:   s.Status.Confirmed,
+					Unconfirmed: !s.Status.Confirmed,
+					Height:      s.Status.Height,
+					BlockSeq:    s.Status.BlockSeq,
+				},
+				Time:     s.Time,
+				Received: received,
+				Sent:     sent,
+				Inputs:   len(s.Transaction.Transaction.In),
+				Outputs:  len(s.Transaction.Transaction.Out),
+			}
+		}
+	}
+
+	if ae.Activity != nil {
+		r.Activity = &AddressExplorerActivity{
+			FirstSeenSeq:   ae.Activity.FirstSeenSeq,
+			FirstSeenTime:  ae.Activity.FirstSeenTime,
+			LastActiveSeq:  ae.Activity.LastActiveSeq,
+			LastActiveTime: ae.Activity.LastActiveTime,
+		}
+	}
+
+	return r, nil
+}
+
+// addressExplorerHandler returns the data of the address page of a block explorer in one call:
+// the balance, the unspent outputs, the pending outputs, the recent transactions and the activity of an address.
+// The sections are read from the same database transaction, and each of them can be skipped.
+// Method: GET
+// URI: /api/v1/explorer/address/{addr}
+// Args:
+//
+//	balance: include the confirmed balance [optional, defaults to true]
+//	outputs: include the unspent output count and the outputs with the most coins [optional, defaults to true]
+//	top_outputs: number of outputs to include [optional, defaults to 10]
+//	pending: include the unconfirmed incoming and outgoing outputs [optional, defaults to true]
+//	transactions: include the total and the most recent confirmed transactions [optional, defaults to true]
+//	limit: number of recent transactions to include [optional, defaults to 10]
+//	activity: include the first seen and last active blocks [optional, defaults to true]
+func addressExplorerHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		addrStr := strings.TrimPrefix(r.URL.Path, addressExplorerPath)
+		if addrStr == "" || strings.Contains(addrStr, "/") {
+			wh.Error404(w, "")
+			return
+		}
+
+		addr, err := cipher.DecodeBase58Address(addrStr)
+		if err != nil {
+			wh.Error400(w, "invalid address")
+			return
+		}
+
+		opts := visor.AddressExplorerOptions{
+			TopOutputs: defaultAddressExplorerTopOutputs,
+			Limit:      defaultAddressExplorerLimit,
+		}
+
+		for _, f := range []struct {
+			name string
+			dst  *bool
+		}{
+			{"balance", &opts.Balance},
+			{"outputs", &opts.Outputs},
+			{"pending", &opts.Pending},
+			{"transactions", &opts.Transactions},
+			{"activity", &opts.Activity},
+		} {
+			*f.dst = true
+			if v := r.FormValue(f.name); v != "" {
+				*f.dst, err = strconv.ParseBool(v)
+				if err != nil {
+					wh.Error400(w, fmt.Sprintf("invalid %s value", f.name))
+					return
+				}
+			}
+		}
+
+		for _, n := range []struct {
+			name string
+			dst  *int
+		}{
+			{"top_outputs", &opts.TopOutputs},
+			{"limit", &opts.Limit},
+		} {
+			if v := r.FormValue(n.name); v != "" {
+				*n.dst, err = strconv.Atoi(v)
+				if err != nil || *n.dst < 0 {
+					wh.Error400(w, fmt.Sprintf("invalid %s value", n.name))
+					return
+				}
+			}
+		}
+
+		ae, err := gateway.GetAddressExplorer(addr, opts)
+		if err != nil {
+			err = fmt.Errorf("gateway.GetAddressExplorer failed: %v", err)
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		rae, err := NewAddressExplorer(ae)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, rae)
+	}
+}
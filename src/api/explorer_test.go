@@ -16,10 +16,12 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/util/droplet"
-	"github.com/skycoin/skycoin/src/visor"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func makeSuccessCoinSupplyResult(t *testing.T, allUnspents readable.UnspentOutputsSummary) *CoinSupply {
@@ -551,3 +553,604 @@ func TestGetAddressCount(t *testing.T) {
 		})
 	}
 }
+
+func TestCoinSupplyProjection(t *testing.T) {
+	addrs := make([]string, 5)
+	for i := range addrs {
+		addrs[i] = testutil.MakeAddress().String()
+	}
+
+	// Each address has 20 coins. 2 addresses are unlocked at genesis, then 2 addresses every 100 seconds
+	dist := params.Distribution{
+		MaxCoinSupply:        100,
+		InitialUnlockedCount: 2,
+		UnlockAddressRate:    2,
+		UnlockTimeInterval:   100,
+		Addresses:            addrs,
+	}
+	dist.MustValidate()
+
+	var genesis uint64 = 1000
+
+	schedule := []UnlockTranche{
+		{
+			Time:                 1000,
+			Addresses:            addrs[:2],
+			UnlockedAddressCount: 2,
+			UnlockedSupply:       "40.000000",
+		},
+		{
+			Time:                 1100,
+			Addresses:            addrs[2:4],
+			UnlockedAddressCount: 4,
+			UnlockedSupply:       "80.000000",
+		},
+		{
+			Time:                 1200,
+			Addresses:            addrs[4:],
+			UnlockedAddressCount: 5,
+			UnlockedSupply:       "100.000000",
+		},
+	}
+
+	// 15 coins are left in the pool of the unlocked addresses, 25 coins were distributed
+	unspents := &visor.UnspentOutputsSummary{
+		Confirmed: []visor.UnspentOutput{
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						Coins:   15e6,
+						Address: cipher.MustDecodeBase58Address(addrs[0]),
+					},
+				},
+			},
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						Coins:   7e6,
+						Address: cipher.MustDecodeBase58Address(addrs[3]),
+					},
+				},
+			},
+		},
+	}
+
+	tt := []struct {
+		name                           string
+		method                         string
+		query                          url.Values
+		status                         int
+		err                            string
+		gatewayGetUnspentOutputsResult *visor.UnspentOutputsSummary
+		gatewayGetUnspentOutputsErr    error
+		result                         *CoinSupplyProjection
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - invalid at",
+			method: http.MethodGet,
+			query: url.Values{
+				"at": []string{"-1"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid at value",
+		},
+		{
+			name:   "400 - invalid start",
+			method: http.MethodGet,
+			query: url.Values{
+				"at":    []string{"1000"},
+				"start": []string{"foo"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid start value",
+		},
+		{
+			name:   "400 - start before genesis",
+			method: http.MethodGet,
+			query: url.Values{
+				"at":    []string{"1000"},
+				"start": []string{"999"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - start must not be before the genesis block timestamp",
+		},
+		{
+			name:   "500 - gatewayGetUnspentOutputsErr",
+			method: http.MethodGet,
+			query: url.Values{
+				"at": []string{"1000"},
+			},
+			status:                      http.StatusInternalServerError,
+			err:                         "500 Internal Server Error - gateway.GetUnspentOutputsSummary failed: gatewayGetUnspentOutputsErr",
+			gatewayGetUnspentOutputsErr: errors.New("gatewayGetUnspentOutputsErr"),
+		},
+		{
+			name:   "200 - before genesis",
+			method: http.MethodGet,
+			query: url.Values{
+				"at": []string{"999"},
+			},
+			status:                         http.StatusOK,
+			gatewayGetUnspentOutputsResult: unspents,
+			result: &CoinSupplyProjection{
+				At:                   999,
+				ScheduleStart:        1000,
+				MaxSupply:            "100.000000",
+				TotalSupply:          "0.000000",
+				UnlockedSupply:       "0.000000",
+				LockedSupply:         "0.000000",
+				UnlockedAddressCount: 0,
+				CurrentSupply:        "25.000000",
+				Schedule:             schedule,
+			},
+		},
+		{
+			name:   "200 - at genesis",
+			method: http.MethodGet,
+			query: url.Values{
+				"at": []string{"1000"},
+			},
+			status:                         http.StatusOK,
+			gatewayGetUnspentOutputsResult: unspents,
+			result: &CoinSupplyProjection{
+				At:                   1000,
+				ScheduleStart:        1000,
+				MaxSupply:            "100.000000",
+				TotalSupply:          "100.000000",
+				UnlockedSupply:       "40.000000",
+				LockedSupply:         "60.000000",
+				UnlockedAddressCount: 2,
+				CurrentSupply:        "25.000000",
+				Schedule:             schedule,
+			},
+		},
+		{
+			name:   "200 - between unlock tranches",
+			method: http.MethodGet,
+			query: url.Values{
+				"at": []string{"1150"},
+			},
+			status:                         http.StatusOK,
+			gatewayGetUnspentOutputsResult: unspents,
+			result: &CoinSupplyProjection{
+				At:                   1150,
+				ScheduleStart:        1000,
+				MaxSupply:            "100.000000",
+				TotalSupply:          "100.000000",
+				UnlockedSupply:       "80.000000",
+				LockedSupply:         "20.000000",
+				UnlockedAddressCount: 4,
+				CurrentSupply:        "25.000000",
+				Schedule:             schedule,
+			},
+		},
+		{
+			name:   "200 - after full unlock",
+			method: http.MethodGet,
+			query: url.Values{
+				"at": []string{"5000"},
+			},
+			status:                         http.StatusOK,
+			gatewayGetUnspentOutputsResult: unspents,
+			result: &CoinSupplyProjection{
+				At:                   5000,
+				ScheduleStart:        1000,
+				MaxSupply:            "100.000000",
+				TotalSupply:          "100.000000",
+				UnlockedSupply:       "100.000000",
+				LockedSupply:         "0.000000",
+				UnlockedAddressCount: 5,
+				CurrentSupply:        "25.000000",
+				Schedule:             schedule,
+			},
+		},
+		{
+			name:   "200 - start",
+			method: http.MethodGet,
+			query: url.Values{
+				"at":    []string{"1150"},
+				"start": []string{"1100"},
+			},
+			status:                         http.StatusOK,
+			gatewayGetUnspentOutputsResult: unspents,
+			result: &CoinSupplyProjection{
+				At:                   1150,
+				ScheduleStart:        1100,
+				MaxSupply:            "100.000000",
+				TotalSupply:          "100.000000",
+				UnlockedSupply:       "40.000000",
+				LockedSupply:         "60.000000",
+				UnlockedAddressCount: 2,
+				CurrentSupply:        "25.000000",
+				Schedule: []UnlockTranche{
+					{
+						Time:                 1100,
+						Addresses:            addrs[:2],
+						UnlockedAddressCount: 2,
+						UnlockedSupply:       "40.000000",
+					},
+					{
+						Time:                 1200,
+						Addresses:            addrs[2:4],
+						UnlockedAddressCount: 4,
+						UnlockedSupply:       "80.000000",
+					},
+					{
+						Time:                 1300,
+						Addresses:            addrs[4:],
+						UnlockedAddressCount: 5,
+						UnlockedSupply:       "100.000000",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v1/coinSupply/projection"
+			gateway := &MockGatewayer{}
+			gateway.On("GetUnspentOutputsSummary", mock.Anything).Return(tc.gatewayGetUnspentOutputsResult, tc.gatewayGetUnspentOutputsErr)
+			gateway.On("VisorConfig").Return(visor.Config{
+				Distribution:     dist,
+				GenesisTimestamp: genesis,
+			})
+
+			if len(tc.query) > 0 {
+				endpoint += "?" + tc.query.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`", strings.TrimSpace(rr.Body.String()), status, tc.err)
+			} else {
+				var msg *CoinSupplyProjection
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.result, msg)
+			}
+		})
+	}
+}
+
+func TestDistributionUnlockSchedule(t *testing.T) {
+	addrs := make([]string, 3)
+	for i := range addrs {
+		addrs[i] = testutil.MakeAddress().String()
+	}
+
+	// No unlock rate, only the initial addresses are unlocked
+	schedule, err := distributionUnlockSchedule(params.Distribution{
+		MaxCoinSupply:        300,
+		InitialUnlockedCount: 1,
+		Addresses:            addrs,
+	}, 10)
+	require.NoError(t, err)
+	require.Equal(t, []UnlockTranche{
+		{
+			Time:                 10,
+			Addresses:            addrs[:1],
+			UnlockedAddressCount: 1,
+			UnlockedSupply:       "100.000000",
+		},
+	}, schedule)
+	require.Equal(t, uint64(0), unlockedAddressCountAt(schedule, 9))
+	require.Equal(t, uint64(1), unlockedAddressCountAt(schedule, 1e9))
+
+	// The last tranche is smaller than the unlock rate
+	schedule, err = distributionUnlockSchedule(params.Distribution{
+		MaxCoinSupply:        300,
+		InitialUnlockedCount: 0,
+		UnlockAddressRate:    2,
+		UnlockTimeInterval:   5,
+		Addresses:            addrs,
+	}, 10)
+	require.NoError(t, err)
+	require.Len(t, schedule, 3)
+	require.Empty(t, schedule[0].Addresses)
+	require.Equal(t, uint64(15), schedule[1].Time)
+	require.Equal(t, addrs[:2], schedule[1].Addresses)
+	require.Equal(t, uint64(20), schedule[2].Time)
+	require.Equal(t, addrs[2:], schedule[2].Addresses)
+	require.Equal(t, "300.000000", schedule[2].UnlockedSupply)
+	require.Equal(t, uint64(2), unlockedAddressCountAt(schedule, 19))
+}
+
+func TestAddressExplorer(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	txn := coin.Transaction{
+		In: []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{Address: addr, Coins: 2e6, Hours: 10},
+			{Address: testutil.MakeAddress(), Coins: 1e6, Hours: 10},
+		},
+	}
+
+	ux := coin.UxOut{
+		Head: coin.UxHead{
+			Time:  100,
+			BkSeq: 3,
+		},
+		Body: coin.UxBody{
+			SrcTransaction: txn.Hash(),
+			Address:        addr,
+			Coins:          2e6,
+			Hours:          10,
+		},
+	}
+
+	ae := &visor.AddressExplorer{
+		Address:  addr,
+		HeadSeq:  5,
+		HeadTime: 200,
+		Balance: &wallet.Balance{
+			Coins: 2e6,
+			Hours: 12,
+		},
+		Outputs: &visor.AddressExplorerOutputs{
+			Count: 3,
+			Top: []visor.UnspentOutput{
+				{UxOut: ux, CalculatedHours: 12},
+			},
+		},
+		Pending: &visor.AddressExplorerPending{
+			Incoming: []visor.UnspentOutput{},
+			Outgoing: []visor.UnspentOutput{
+				{UxOut: ux, CalculatedHours: 12},
+			},
+		},
+		Transactions: &visor.AddressExplorerTransactions{
+			Total: 4,
+			Recent: []visor.AddressTransactionSummary{
+				{
+					Transaction: visor.Transaction{
+						Transaction: txn,
+						Status:      visor.NewConfirmedTransactionStatus(3, 3),
+						Time:        100,
+					},
+					Received: 2e6,
+					Sent:     3e6,
+				},
+			},
+		},
+		Activity: &visor.AddressActivity{
+			FirstSeenSeq:   1,
+			FirstSeenTime:  50,
+			LastActiveSeq:  3,
+			LastActiveTime: 100,
+		},
+	}
+
+	rux := readable.UnspentOutput{
+		Hash:              ux.Hash().Hex(),
+		Time:              100,
+		BkSeq:             3,
+		SourceTransaction: txn.Hash().Hex(),
+		Address:           addr.String(),
+		Coins:             "2.000000",
+		Hours:             10,
+		CalculatedHours:   12,
+	}
+
+	result := &AddressExplorer{
+		Address:  addr.String(),
+		HeadSeq:  5,
+		HeadTime: 200,
+		Balance: &readable.Balance{
+			Coins: 2e6,
+			Hours: 12,
+		},
+		Outputs: &AddressExplorerOutputs{
+			Count: 3,
+			Top:   readable.UnspentOutputs{rux},
+		},
+		Pending: &AddressExplorerPending{
+			Incoming: readable.UnspentOutputs{},
+			Outgoing: readable.UnspentOutputs{rux},
+		},
+		Transactions: &AddressExplorerTransactions{
+			Total: 4,
+			Recent: []AddressExplorerTransaction{
+				{
+					Txid: txn.Hash().Hex(),
+					Status: readable.TransactionStatus{
+						Confirmed: true,
+						Height:    3,
+						BlockSeq:  3,
+					},
+					Time:     100,
+					Received: "2.000000",
+					Sent:     "3.000000",
+					Inputs:   1,
+					Outputs:  2,
+				},
+			},
+		},
+		Activity: &AddressExplorerActivity{
+			FirstSeenSeq:   1,
+			FirstSeenTime:  50,
+			LastActiveSeq:  3,
+			LastActiveTime: 100,
+		},
+	}
+
+	allOpts := visor.AddressExplorerOptions{
+		Balance:      true,
+		Outputs:      true,
+		TopOutputs:   10,
+		Pending:      true,
+		Transactions: true,
+		Limit:        10,
+		Activity:     true,
+	}
+
+	skippedOpts := visor.AddressExplorerOptions{
+		TopOutputs: 1,
+		Limit:      10,
+		Activity:   true,
+	}
+
+	tt := []struct {
+		name       string
+		method     string
+		path       string
+		query      url.Values
+		status     int
+		err        string
+		opts       *visor.AddressExplorerOptions
+		gatewayAE  *visor.AddressExplorer
+		gatewayErr error
+		result     *AddressExplorer
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			path:   addr.String(),
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "404 - no address",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			err:    "404 Not Found",
+		},
+		{
+			name:   "404 - extra path",
+			method: http.MethodGet,
+			path:   addr.String() + "/foo",
+			status: http.StatusNotFound,
+			err:    "404 Not Found",
+		},
+		{
+			name:   "400 - invalid address",
+			method: http.MethodGet,
+			path:   "foo",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid address",
+		},
+		{
+			name:   "400 - invalid flag",
+			method: http.MethodGet,
+			path:   addr.String(),
+			query:  url.Values{"pending": []string{"foo"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid pending value",
+		},
+		{
+			name:   "400 - invalid limit",
+			method: http.MethodGet,
+			path:   addr.String(),
+			query:  url.Values{"limit": []string{"-1"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid limit value",
+		},
+		{
+			name:   "400 - invalid top_outputs",
+			method: http.MethodGet,
+			path:   addr.String(),
+			query:  url.Values{"top_outputs": []string{"x"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid top_outputs value",
+		},
+		{
+			name:       "500 - gateway error",
+			method:     http.MethodGet,
+			path:       addr.String(),
+			status:     http.StatusInternalServerError,
+			err:        "500 Internal Server Error - gateway.GetAddressExplorer failed: gatewayErr",
+			opts:       &allOpts,
+			gatewayErr: errors.New("gatewayErr"),
+		},
+		{
+			name:      "200",
+			method:    http.MethodGet,
+			path:      addr.String(),
+			status:    http.StatusOK,
+			opts:      &allOpts,
+			gatewayAE: ae,
+			result:    result,
+		},
+		{
+			name:   "200 - skipped sections",
+			method: http.MethodGet,
+			path:   addr.String(),
+			query: url.Values{
+				"balance":      []string{"false"},
+				"outputs":      []string{"0"},
+				"pending":      []string{"false"},
+				"transactions": []string{"false"},
+				"top_outputs":  []string{"1"},
+			},
+			status: http.StatusOK,
+			opts:   &skippedOpts,
+			gatewayAE: &visor.AddressExplorer{
+				Address:  addr,
+				HeadSeq:  5,
+				HeadTime: 200,
+			},
+			result: &AddressExplorer{
+				Address:  addr.String(),
+				HeadSeq:  5,
+				HeadTime: 200,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.opts != nil {
+				gateway.On("GetAddressExplorer", addr, *tc.opts).Return(tc.gatewayAE, tc.gatewayErr)
+			}
+
+			endpoint := "/api/v1/explorer/address/" + tc.path
+			if len(tc.query) != 0 {
+				endpoint += "?" + tc.query.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(muxConfig{
+				host:           configuredHost,
+				appLoc:         ".",
+				disableCSP:     true,
+				enabledAPISets: allAPISetsEnabled,
+			}, gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg *AddressExplorer
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.Equal(t, tc.result, msg)
+
+			gateway.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// fieldsParam is the query parameter selecting the fields of a JSON response
+	fieldsParam = "fields"
+	// maxFilterFields is the maximum number of field paths in a fields parameter
+	maxFilterFields = 32
+	// maxFilterDepth is the maximum number of keys in a field path
+	maxFilterDepth = 8
+)
+
+// fieldTree is a set of field paths, by key. A nil subtree selects the whole value of its key.
+type fieldTree map[string]fieldTree
+
+// parseFieldPaths parses a comma separated list of dot separated field paths, e.g. "head.seq,body.txns.txid".
+// Malformed paths, with an empty key, are ignored. A path that is a prefix of another selects the whole value.
+// Returns an error if there are more than maxFilterFields paths or a path has more than maxFilterDepth keys.
+func parseFieldPaths(s string) (fieldTree, error) {
+	var paths [][]string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		keys := strings.Split(p, ".")
+		if len(keys) > maxFilterDepth {
+			return nil, fmt.Errorf("field %q has more than %d keys", p, maxFilterDepth)
+		}
+
+		valid := true
+		for _, k := range keys {
+			if k == "" {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			paths = append(paths, keys)
+		}
+	}
+
+	if len(paths) > maxFilterFields {
+		return nil, fmt.Errorf("more than %d fields", maxFilterFields)
+	}
+
+	tree := fieldTree{}
+	for _, keys := range paths {
+		tree.add(keys)
+	}
+
+	return tree, nil
+}
+
+// add adds the field path keys to t
+func (t fieldTree) add(keys []string) {
+	k := keys[0]
+	sub, ok := t[k]
+	if ok && sub == nil {
+		// A shorter path already selects the whole value
+		return
+	}
+
+	if len(keys) == 1 {
+		t[k] = nil
+		return
+	}
+
+	if !ok {
+		sub = fieldTree{}
+		t[k] = sub
+	}
+	sub.add(keys[1:])
+}
+
+// prune returns the fields of v selected by t, and false if v has none of them.
+// The fields of the objects in an array are selected from each object,
+// the objects without any of them are left empty so that the array keeps its length.
+// An empty array is kept, it has no object without the fields.
+func (t fieldTree) prune(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		pruned := make(map[string]interface{})
+		for k, sub := range t {
+			x, ok := v[k]
+			if !ok {
+				continue
+			}
+
+			if sub == nil {
+				pruned[k] = x
+			} else if x, ok := sub.prune(x); ok {
+				pruned[k] = x
+			}
+		}
+		return pruned, len(pruned) != 0
+
+	case []interface{}:
+		pruned := make([]interface{}, len(v))
+		matched := len(v) == 0
+		for i, x := range v {
+			x, ok := t.prune(x)
+			if !ok {
+				x = map[string]interface{}{}
+			}
+			pruned[i] = x
+			matched = matched || ok
+		}
+		return pruned, matched
+
+	default:
+		// Scalars have no fields
+		return nil, false
+	}
+}
+
+// filterFields returns the JSON document body reduced to the fields selected by t.
+// The fields of v2 responses are selected from their "data" object.
+// A document without any of the fields is reduced to an empty object.
+func filterFields(apiVersion string, body []byte, t fieldTree) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if apiVersion == apiVersion2 {
+		t = fieldTree{
+			"data": t,
+		}
+	}
+
+	pruned, ok := t.prune(v)
+	if !ok {
+		pruned = map[string]interface{}{}
+	}
+
+	return json.MarshalIndent(pruned, "", "    ")
+}
+
+// fieldsResponseWriter buffers a response to filter its fields
+type fieldsResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *fieldsResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *fieldsResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// fieldsHandler reduces the JSON response of a GET request to the fields of its fields parameter,
+// e.g. ?fields=head.seq,head.block_hash,body.txns.txid. The handler is not changed, its response is
+// parsed, pruned and written again. Field paths that don't match the response are ignored.
+// Error responses and responses that are not JSON are written unchanged.
+func fieldsHandler(apiVersion string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields := r.URL.Query().Get(fieldsParam)
+		if r.Method != http.MethodGet || fields == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		t, err := parseFieldPaths(fields)
+		if err != nil {
+			writeError(w, apiVersion, http.StatusBadRequest, fmt.Sprintf("Invalid %s: %v", fieldsParam, err))
+			return
+		}
+
+		fw := &fieldsResponseWriter{
+			ResponseWriter: w,
+		}
+		handler.ServeHTTP(fw, r)
+
+		if fw.status == 0 {
+			fw.status = http.StatusOK
+		}
+
+		body := fw.body.Bytes()
+		if fw.status == http.StatusOK && isContentTypeJSON(w.Header().Get("Content-Type")) {
+			filtered, err := filterFields(apiVersion, body, t)
+			if err != nil {
+				requestLogger(r).WithError(err).Error("filterFields failed")
+			} else {
+				body = filtered
+				w.Header().Del("Content-Length")
+			}
+		}
+
+		w.WriteHeader(fw.status)
+		if _, err := w.Write(body); err != nil {
+			requestLogger(r).WithError(err).Error("http Write failed")
+		}
+	})
+}
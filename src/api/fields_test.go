@@ -0,0 +1,284 @@
+package api
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestParseFieldPaths(t *testing.T) {
+	cases := []struct {
+		name   string
+		fields string
+		tree   fieldTree
+		err    string
+	}{
+		{
+			name:   "nested and top level",
+			fields: "head.seq, head.block_hash,size",
+			tree: fieldTree{
+				"head": {
+					"seq":        nil,
+					"block_hash": nil,
+				},
+				"size": nil,
+			},
+		},
+		{
+			name:   "prefix selects the whole value",
+			fields: "head.seq,head,head.block_hash",
+			tree: fieldTree{
+				"head": nil,
+			},
+		},
+		{
+			name:   "malformed paths are ignored",
+			fields: ",head.,.seq,a..b,body.txns.txid",
+			tree: fieldTree{
+				"body": {
+					"txns": {
+						"txid": nil,
+					},
+				},
+			},
+		},
+		{
+			name:   "too deep",
+			fields: "a.b.c.d.e.f.g.h.i",
+			err:    `field "a.b.c.d.e.f.g.h.i" has more than 8 keys`,
+		},
+		{
+			name:   "too many",
+			fields: strings.Repeat("a,", maxFilterFields) + "b",
+			err:    "more than 32 fields",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := parseFieldPaths(tc.fields)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.tree, tree)
+		})
+	}
+}
+
+func TestFilterFields(t *testing.T) {
+	body := `{
+		"head": {"seq": 18446744073709551615, "block_hash": "abc", "fee": 10},
+		"body": {"txns": [
+			{"txid": "t1", "inputs": ["i1"], "outputs": [{"uxid": "o1", "coins": "1.000000"}, {"uxid": "o2", "coins": "2.000000"}]},
+			{"inputs": []},
+			{"txid": "t3", "outputs": []}
+		]},
+		"size": 100
+	}`
+
+	cases := []struct {
+		name       string
+		apiVersion string
+		body       string
+		fields     string
+		expect     string
+	}{
+		{
+			name:       "nested objects",
+			apiVersion: apiVersion1,
+			body:       body,
+			fields:     "head.seq,head.block_hash,size",
+			expect:     `{"head":{"block_hash":"abc","seq":18446744073709551615},"size":100}`,
+		},
+		{
+			name:       "arrays keep their length",
+			apiVersion: apiVersion1,
+			body:       body,
+			fields:     "body.txns.txid",
+			expect:     `{"body":{"txns":[{"txid":"t1"},{},{"txid":"t3"}]}}`,
+		},
+		{
+			name:       "arrays nested in arrays",
+			apiVersion: apiVersion1,
+			body:       body,
+			fields:     "body.txns.outputs.uxid",
+			expect:     `{"body":{"txns":[{"outputs":[{"uxid":"o1"},{"uxid":"o2"}]},{},{"outputs":[]}]}}`,
+		},
+		{
+			name:       "top level array",
+			apiVersion: apiVersion1,
+			body:       `[{"a": 1, "b": 2}, {"b": 3}]`,
+			fields:     "a",
+			expect:     `[{"a":1},{}]`,
+		},
+		{
+			name:       "unknown fields are ignored",
+			apiVersion: apiVersion1,
+			body:       body,
+			fields:     "head.seq,head.nonexistent,nonexistent.x,size.x",
+			expect:     `{"head":{"seq":18446744073709551615}}`,
+		},
+		{
+			name:       "no field matches",
+			apiVersion: apiVersion1,
+			body:       body,
+			fields:     "nonexistent,head.seq.x",
+			expect:     `{}`,
+		},
+		{
+			name:       "no field matches an array",
+			apiVersion: apiVersion1,
+			body:       `[{"a": 1}]`,
+			fields:     "b",
+			expect:     `{}`,
+		},
+		{
+			name:       "v2 fields are selected from data",
+			apiVersion: apiVersion2,
+			body:       `{"data": {"head_seq": 5, "ok": true}}`,
+			fields:     "ok",
+			expect:     `{"data":{"ok":true}}`,
+		},
+		{
+			name:       "v2 no field matches",
+			apiVersion: apiVersion2,
+			body:       `{"data": {"head_seq": 5}}`,
+			fields:     "data",
+			expect:     `{}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := parseFieldPaths(tc.fields)
+			require.NoError(t, err)
+
+			filtered, err := filterFields(tc.apiVersion, []byte(tc.body), tree)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expect, string(filtered))
+		})
+	}
+
+	_, err := filterFields(apiVersion1, []byte(`{"a":`), fieldTree{"a": nil})
+	require.Error(t, err)
+}
+
+func TestFieldsHandler(t *testing.T) {
+	report := &visor.UnspentHashReport{
+		HeadSeq:   180,
+		Stored:    testSHA256(1),
+		Computed:  testSHA256(1),
+		Expected:  testSHA256(1),
+		Unspents:  100,
+		CheckedAt: time.Unix(1540305209, 0).UTC(),
+	}
+
+	// The commit is long enough for the response to be gzip compressed, the version is not
+	commit := strings.Repeat("a", gziphandler.DefaultMinSize)
+
+	cases := []struct {
+		name       string
+		endpoint   string
+		fields     string
+		status     int
+		compressed bool
+		expect     string
+	}{
+		{
+			name:     "v1",
+			endpoint: "/api/v1/version",
+			fields:   "version,nonexistent",
+			status:   http.StatusOK,
+			expect:   `{"version":"0.1.0"}`,
+		},
+		{
+			name:       "v1 filtered response is compressed",
+			endpoint:   "/api/v1/version",
+			fields:     "commit",
+			status:     http.StatusOK,
+			compressed: true,
+			expect:     `{"commit":"` + commit + `"}`,
+		},
+		{
+			name:       "v1 without fields",
+			endpoint:   "/api/v1/version",
+			status:     http.StatusOK,
+			compressed: true,
+			expect:     `{"version":"0.1.0","commit":"` + commit + `","branch":"develop"}`,
+		},
+		{
+			name:     "v1 no field matches",
+			endpoint: "/api/v1/version",
+			fields:   "nonexistent",
+			status:   http.StatusOK,
+			expect:   `{}`,
+		},
+		{
+			name:     "v2",
+			endpoint: "/api/v2/health/unspent-hash",
+			fields:   "head_seq,ok",
+			status:   http.StatusOK,
+			expect:   `{"data":{"head_seq":180,"ok":true}}`,
+		},
+		{
+			name:     "v2 too many fields",
+			endpoint: "/api/v2/health/unspent-hash",
+			fields:   strings.Repeat("a,", maxFilterFields+1),
+			status:   http.StatusBadRequest,
+			expect:   `{"error":{"message":"Invalid fields: more than 32 fields","code":400}}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("CheckUnspentHash").Return(report, nil)
+
+			endpoint := tc.endpoint
+			if tc.fields != "" {
+				endpoint += "?" + url.Values{"fields": []string{tc.fields}}.Encode()
+			}
+
+			req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			req.Header.Set("Accept-Encoding", "gzip")
+			setCSRFParameters(t, tokenValid, req)
+
+			cfg := defaultMuxConfig()
+			cfg.health.BuildInfo.Version = "0.1.0"
+			cfg.health.BuildInfo.Commit = commit
+			cfg.health.BuildInfo.Branch = "develop"
+
+			rr := httptest.NewRecorder()
+			newServerMux(cfg, gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, ContentTypeJSON, rr.Header().Get("Content-Type"))
+
+			body := rr.Body.Bytes()
+			if tc.compressed {
+				require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+				zr, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+				body, err = ioutil.ReadAll(zr)
+				require.NoError(t, err)
+			} else {
+				require.Empty(t, rr.Header().Get("Content-Encoding"))
+			}
+
+			require.JSONEq(t, tc.expect, string(body))
+		})
+	}
+}
@@ -1,20 +1,28 @@
 package api
 
 import (
+	"context"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/kvstorage"
-	"github.com/skycoin/skycoin/src/transaction"
-	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/visor/historydb"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/daemon/forcerequest"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/daemon/propagation"
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 //go:generate mockery -name Gatewayer -case underscore -inpkg -testonly
 
+var _ Gatewayer = &Gateway{}
+
 // Gateway bundles daemon.Daemon, Visor, wallet.Service and kvstorage.Manager into a single object
 type Gateway struct {
 	*daemon.Daemon
@@ -33,6 +41,12 @@ func NewGateway(d *daemon.Daemon, v *visor.Visor, w *wallet.Service, m *kvstorag
 	}
 }
 
+// WalletAddressReuse returns the addresses of a transaction created for a wallet that were used before,
+// looking up the activity of the addresses in the blockchain
+func (gw *Gateway) WalletAddressReuse(wltID string, txn *coin.Transaction, receivers int) ([]wallet.AddressReuse, error) {
+	return gw.Service.WalletAddressReuse(wltID, txn, receivers, gw.Visor)
+}
+
 // Gatewayer interface for Gateway methods
 type Gatewayer interface {
 	Daemoner
@@ -48,10 +62,23 @@ type Daemoner interface {
 	GetConnections(f func(c daemon.Connection) bool) ([]daemon.Connection, error)
 	DisconnectByGnetID(gnetID uint64) error
 	GetDefaultConnections() []string
+	GetAlwaysConnectPeers() []string
 	GetTrustConnections() []string
 	GetExchgConnection() []string
+	GetPeers() pex.Peers
+	ExportPeers(f pex.BundleFilter, sign bool) (*pex.PeerBundle, error)
+	ImportPeers(b pex.PeerBundle) (*pex.ImportResult, error)
+	GetPeerBans() pex.BanReport
+	UnbanPeer(ip string) error
+	GetBandwidthLimits() gnet.BandwidthLimits
+	SetBandwidthLimits(l gnet.BandwidthLimits)
+	GetPropagation(hash cipher.SHA256) (*propagation.Report, bool)
+	ForceRequestBlocks(req forcerequest.BlocksRequest) ([]string, error)
+	ForceRequestTxns(req forcerequest.TxnsRequest) ([]string, error)
+	StartPublishing(seckey cipher.SecKey) error
+	StopPublishing() error
 	GetBlockchainProgress(headSeq uint64) *daemon.BlockchainProgress
-	InjectBroadcastTransaction(txn coin.Transaction) error
+	InjectBroadcastTransaction(ctx context.Context, txn coin.Transaction) error
 	InjectTransaction(txn coin.Transaction) error
 }
 
@@ -61,6 +88,10 @@ type Visorer interface {
 	StartedAt() time.Time
 	HeadBkSeq() (uint64, bool, error)
 	GetBlockchainMetadata() (*visor.BlockchainMetadata, error)
+	WaitHeadSeqAfter(ctx context.Context, seq uint64) bool
+	SubscribeBlocks(bufferSize int) *visor.BlockSubscription
+	CheckUnspentHash() (*visor.UnspentHashReport, error)
+	LastUnspentHashReport() *visor.UnspentHashReport
 	ResendUnconfirmedTxns() ([]cipher.SHA256, error)
 	GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error)
 	GetSignedBlockByHashVerbose(hash cipher.SHA256) (*coin.SignedBlock, [][]visor.TransactionInput, error)
@@ -74,41 +105,90 @@ type Visorer interface {
 	GetLastBlocksVerbose(num uint64) ([]coin.SignedBlock, [][][]visor.TransactionInput, error)
 	GetUnspentOutputsSummary(filters []visor.OutputsFilter) (*visor.UnspentOutputsSummary, error)
 	GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalancePair, error)
+	GetAddressOutputsSummary(addrs []cipher.Address) ([]visor.AddressOutputsSummary, error)
+	GetAddressExplorer(addr cipher.Address, opts visor.AddressExplorerOptions) (*visor.AddressExplorer, error)
 	VerifyTxnVerbose(txn *coin.Transaction, signed visor.TxnSignedFlag) ([]visor.TransactionInput, bool, error)
+	TestAcceptTransactions(txns []coin.Transaction) ([]visor.TxnAcceptResult, error)
+	PreviewBlock() (*visor.BlockPreview, error)
+	BlockPublisherStatus() visor.BlockPublisherStatus
+	CreateSnapshot() (*visor.Snapshot, error)
+	StorageStats() (*visor.StorageStats, error)
+	StartDBCompaction() error
+	AbortDBCompaction() error
 	AddressCount() (uint64, error)
 	GetUxOutByID(id cipher.SHA256) (*historydb.UxOut, error)
+	GetTxnGraph(uxid cipher.SHA256, opts visor.TxnGraphOptions) (*visor.TxnGraph, error)
 	GetSpentOutputsForAddresses(addr []cipher.Address) ([][]historydb.UxOut, error)
 	GetVerboseTransactionsForAddress(a cipher.Address) ([]visor.Transaction, [][]visor.TransactionInput, error)
 	GetRichlist(includeDistribution bool) (visor.Richlist, error)
 	GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error)
 	GetAllUnconfirmedTransactionsVerbose() ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error)
+	GetUnconfirmedConflicts() (map[cipher.SHA256][]cipher.SHA256, error)
+	GetStuckUnconfirmedTxnHashes() ([]cipher.SHA256, error)
+	GetUnconfirmedTxnAnnounceCounts() (map[cipher.SHA256]uint64, error)
+	AbandonUnconfirmedTransaction(txid cipher.SHA256) error
 	GetTransaction(txid cipher.SHA256) (*visor.Transaction, error)
 	GetTransactionWithInputs(txid cipher.SHA256) (*visor.Transaction, []visor.TransactionInput, error)
 	GetTransactions(flts []visor.TxFilter) ([]visor.Transaction, error)
 	GetTransactionsWithInputs(flts []visor.TxFilter) ([]visor.Transaction, [][]visor.TransactionInput, error)
+	GetAddressTransactionsInBlockRange(addrs []cipher.Address, start, end uint64) ([]visor.Transaction, error)
+	GetAddressTransactionsInBlockRangeWithInputs(addrs []cipher.Address, start, end uint64) ([]visor.Transaction, [][]visor.TransactionInput, error)
 	AddressesActivity(addrs []cipher.Address) ([]bool, error)
 	GetWalletUnconfirmedTransactions(wltID string) ([]visor.UnconfirmedTransaction, error)
 	GetWalletUnconfirmedTransactionsVerbose(wltID string) ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error)
 	GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error)
+	GetWalletBalanceAtHead(wltID string) (*visor.WalletBalance, error)
+	GetWalletBalanceHistory(wltID string) (*visor.BalanceHistory, error)
 	CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
-	WalletCreateTransaction(wltID string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
-	WalletCreateTransactionSigned(wltID string, password []byte, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
-	WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletCreateTransaction(ctx context.Context, wltID string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletCreateTransactionSigned(ctx context.Context, wltID string, password []byte, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletSignTransaction(ctx context.Context, wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletBumpTransaction(wltID string, txn *coin.Transaction, targetFee uint64, changeAddress *cipher.Address) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletSweep(wltID string, password []byte, keys []cipher.SecKey) (*visor.SweepResult, error)
+	WalletMigrate(wltID string, password []byte, opts visor.WalletMigrateOptions) (*visor.WalletMigratePlan, error)
 }
 
 // Walleter interface for wallet.Service methods used by the API
 type Walleter interface {
 	UnloadWallet(wltID string) error
+	UnlockWalletMetadata(wltID string, password []byte) error
+	LockWalletMetadata(wltID string) error
 	EncryptWallet(wltID string, password []byte) (wallet.Wallet, error)
 	DecryptWallet(wltID string, password []byte) (wallet.Wallet, error)
+	EncryptWalletAddress(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error
+	DecryptWalletAddress(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error
+	SignTransactionWithAddressPasswords(wltID string, password []byte, addrPasswords map[cipher.Address][]byte, txn *coin.Transaction, signIndexes []int, uxOuts []coin.UxOut) (*coin.Transaction, error)
 	GetWalletSeed(wltID string, password []byte) (string, string, error)
+	GetWalletAccountXPub(wltID string, account uint32) (*wallet.AccountXPub, error)
 	CreateWallet(wltName string, options wallet.Options, bg wallet.TransactionsFinder) (wallet.Wallet, error)
-	RecoverWallet(wltID, seed, seedPassphrase string, password []byte) (wallet.Wallet, error)
+	StartWalletRecovery(opts wallet.RecoveryOptions, tf wallet.TransactionsFinder) (string, error)
+	WalletRecoveryStatus(jobID string) (*wallet.RecoveryStatus, error)
+	CancelWalletRecovery(jobID string) error
 	NewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error)
+	PreviewNewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error)
+	CommitNewAddresses(wltID string, password []byte, addrs []cipher.Address) ([]cipher.Address, error)
 	GetWallet(wltID string) (wallet.Wallet, error)
 	GetWallets() (wallet.Wallets, error)
+	ListWallets() ([]wallet.WalletHeader, error)
 	UpdateWalletLabel(wltID, label string) error
+	SetWalletReuseChange(wltID string, reuse bool) error
+	WalletStrictPrivacy(wltID string) (bool, error)
+	SetWalletStrictPrivacy(wltID string, strict bool) error
+	WalletAddressReuse(wltID string, txn *coin.Transaction, receivers int) ([]wallet.AddressReuse, error)
+	UpdateAddressLabel(wltID string, addr cipher.Address, label string) error
+	GetWalletAddressesByLabels(wltID string, labels []string) ([]cipher.Address, error)
 	WalletDir() (string, error)
+	ListWalletBackups(wltID string) ([]wallet.Backup, error)
+	WalletFormatStatus() ([]wallet.WalletFormatStatus, error)
+	MigrateWalletFormat(wltID string, password []byte) (*wallet.WalletFormatMigrationResult, error)
+	SetWalletAccessToken(wltID string, password []byte) (string, error)
+	RotateWalletAccessToken(wltID string) (string, error)
+	RemoveWalletAccessToken(wltID string, password []byte) error
+	VerifyWalletAccessToken(wltID, token string) error
+	WalletApprovalPolicy(wltID string) (*wallet.ApprovalPolicy, error)
+	SetWalletApprovalPolicy(wltID string, password, approverPassword, currentApproverPassword []byte, threshold uint64, window time.Duration) error
+	RemoveWalletApprovalPolicy(wltID string, password, approverPassword []byte) error
+	VerifyWalletApproverPassword(wltID string, approverPassword []byte) error
 }
 
 // Storer interface for kvstorage.Manager methods used by the API
@@ -117,4 +197,16 @@ type Storer interface {
 	GetAllStorageValues(storageType kvstorage.Type) (map[string]string, error)
 	AddStorageValue(storageType kvstorage.Type, key, val string) error
 	RemoveStorageValue(storageType kvstorage.Type, key string) error
+	GetWalletTxNote(wltID string, txid cipher.SHA256) (string, error)
+	GetWalletTxNotes(wltID string) (map[cipher.SHA256]string, error)
+	SetWalletTxNote(wltID string, txid cipher.SHA256, note string) error
+	RemoveWalletTxNote(wltID string, txid cipher.SHA256) error
+	Subscribe(addrs []cipher.Address) (string, error)
+	Unsubscribe(id string) error
+	GetSubscriptionAddresses(id string) ([]cipher.Address, error)
+	GetNotifications(id string, after uint64, limit int) (*kvstorage.Notifications, error)
+	AddPendingApproval(wltID string, txn *coin.Transaction, amount uint64, window time.Duration) (*kvstorage.PendingApproval, error)
+	GetPendingApproval(wltID, id string) (*kvstorage.PendingApproval, error)
+	GetPendingApprovals(wltID string) ([]kvstorage.PendingApproval, error)
+	RemovePendingApproval(wltID, id string) (*kvstorage.PendingApproval, error)
 }
@@ -5,10 +5,12 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/skycoin/skycoin/src/daemon"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 // BlockchainMetadata extends visor.BlockchainMetadata to include the time since the last block
@@ -30,6 +32,7 @@ type HealthResponse struct {
 	CSRFEnabled          bool                 `json:"csrf_enabled"`
 	HeaderCheckEnabled   bool                 `json:"header_check_enabled"`
 	CSPEnabled           bool                 `json:"csp_enabled"`
+	APIKeyAuthEnabled    bool                 `json:"api_key_auth_enabled"`
 	WalletAPIEnabled     bool                 `json:"wallet_api_enabled"`
 	GUIEnabled           bool                 `json:"gui_enabled"`
 	BlockPublisher       bool                 `json:"block_publisher"`
@@ -37,6 +40,40 @@ type HealthResponse struct {
 	UnconfirmedVerifyTxn readable.VerifyTxn   `json:"unconfirmed_verify_transaction"`
 	StartedAt            int64                `json:"started_at"`
 	Fiber                readable.FiberConfig `json:"fiber"`
+	UnspentHash          *UnspentHashStatus   `json:"unspent_hash"`
+}
+
+// UnspentHashStatus is the result of checking the unspent pool hash (UxHash) against the blockchain
+type UnspentHashStatus struct {
+	// OK is false if the unspent pool diverged from the blockchain
+	OK bool `json:"ok"`
+	// Error describes the divergence
+	Error     string `json:"error,omitempty"`
+	HeadSeq   uint64 `json:"head_seq"`
+	Expected  string `json:"expected"`
+	Stored    string `json:"stored"`
+	Computed  string `json:"computed"`
+	Unspents  uint64 `json:"unspents"`
+	CheckedAt int64  `json:"checked_at"`
+}
+
+// NewUnspentHashStatus creates an UnspentHashStatus from a visor.UnspentHashReport
+func NewUnspentHashStatus(r visor.UnspentHashReport) UnspentHashStatus {
+	var errMsg string
+	if err := r.Err(); err != nil {
+		errMsg = err.Error()
+	}
+
+	return UnspentHashStatus{
+		OK:        r.OK(),
+		Error:     errMsg,
+		HeadSeq:   r.HeadSeq,
+		Expected:  r.Expected.Hex(),
+		Stored:    r.Stored.Hex(),
+		Computed:  r.Computed.Hex(),
+		Unspents:  r.Unspents,
+		CheckedAt: r.CheckedAt.Unix(),
+	}
 }
 
 func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
@@ -72,6 +109,12 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		return nil, err
 	}
 
+	var unspentHash *UnspentHashStatus
+	if r := gateway.LastUnspentHashReport(); r != nil {
+		s := NewUnspentHashStatus(*r)
+		unspentHash = &s
+	}
+
 	return &HealthResponse{
 		BlockchainMetadata: BlockchainMetadata{
 			BlockchainMetadata: readable.NewBlockchainMetadata(*metadata),
@@ -87,6 +130,7 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		CSRFEnabled:          !c.disableCSRF,
 		HeaderCheckEnabled:   !c.disableHeaderCheck,
 		CSPEnabled:           !c.disableCSP,
+		APIKeyAuthEnabled:    c.apiKey != "",
 		GUIEnabled:           c.enableGUI,
 		BlockPublisher:       c.health.BlockPublisher,
 		WalletAPIEnabled:     walletAPIEnabled,
@@ -94,6 +138,7 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		UnconfirmedVerifyTxn: readable.NewVerifyTxn(gateway.DaemonConfig().UnconfirmedVerifyTxn),
 		Uptime:               wh.FromDuration(time.Since(gateway.StartedAt())),
 		StartedAt:            gateway.StartedAt().Unix(),
+		UnspentHash:          unspentHash,
 	}, nil
 }
 
@@ -116,3 +161,35 @@ func healthHandler(c muxConfig, gateway Gatewayer) http.HandlerFunc {
 		wh.SendJSONOr500(logger, w, health)
 	}
 }
+
+// unspentHashHandler recomputes the unspent pool hash (UxHash) and compares it to the hash expected by the head block.
+// The result is also reported by /api/v1/health afterwards. A diverged unspent pool is repaired by restarting the node with -repair-unspents.
+// URI: /api/v2/health/unspent-hash
+// Method: GET
+// Response: UnspentHashStatus
+func unspentHashHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		report, err := gateway.CheckUnspentHash()
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if report == nil {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "blockchain is empty")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewUnspentHashStatus(*report),
+		})
+	}
+}
@@ -15,11 +15,12 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/useragent"
-	"github.com/skycoin/skycoin/src/visor"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -32,6 +33,7 @@ func TestHealthHandler(t *testing.T) {
 		getConnectionsErr        error
 		cfg                      muxConfig
 		walletAPIEnabled         bool
+		unspentHash              *visor.UnspentHashReport
 	}{
 		{
 			name:   "405 method not allowed",
@@ -80,6 +82,7 @@ func TestHealthHandler(t *testing.T) {
 				disableCSRF: false,
 				disableCSP:  false,
 				enableGUI:   true,
+				apiKey:      "secret",
 				enabledAPISets: map[string]struct{}{
 					EndpointsStatus: struct{}{},
 					EndpointsRead:   struct{}{},
@@ -87,6 +90,22 @@ func TestHealthHandler(t *testing.T) {
 			},
 			walletAPIEnabled: false,
 		},
+
+		{
+			name:             "valid response, unspent pool diverged",
+			method:           http.MethodGet,
+			code:             http.StatusOK,
+			cfg:              defaultMuxConfig(),
+			walletAPIEnabled: true,
+			unspentHash: &visor.UnspentHashReport{
+				HeadSeq:   21175,
+				Stored:    testSHA256(1),
+				Computed:  testSHA256(2),
+				Expected:  testSHA256(1),
+				Unspents:  10,
+				CheckedAt: time.Now().UTC(),
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -185,6 +204,7 @@ func TestHealthHandler(t *testing.T) {
 			startedAt := time.Now().Add(time.Second * -4)
 
 			gateway.On("StartedAt").Return(startedAt)
+			gateway.On("LastUnspentHashReport").Return(tc.unspentHash)
 
 			dc := daemon.DaemonConfig{
 				UnconfirmedVerifyTxn: params.VerifyTxn{
@@ -243,6 +263,7 @@ func TestHealthHandler(t *testing.T) {
 
 			require.Equal(t, !tc.cfg.disableCSRF, r.CSRFEnabled)
 			require.Equal(t, !tc.cfg.disableCSP, r.CSPEnabled)
+			require.Equal(t, tc.cfg.apiKey != "", r.APIKeyAuthEnabled)
 			require.Equal(t, tc.cfg.enableGUI, r.GUIEnabled)
 			require.Equal(t, tc.walletAPIEnabled, r.WalletAPIEnabled)
 
@@ -255,6 +276,116 @@ func TestHealthHandler(t *testing.T) {
 			require.Equal(t, dc.UnconfirmedVerifyTxn.MaxDropletPrecision, r.UnconfirmedVerifyTxn.MaxDropletPrecision)
 			require.True(t, time.Now().Unix() > r.StartedAt)
 
+			if tc.unspentHash == nil {
+				require.Nil(t, r.UnspentHash)
+			} else {
+				require.NotNil(t, r.UnspentHash)
+				require.Equal(t, NewUnspentHashStatus(*tc.unspentHash), *r.UnspentHash)
+			}
+
+		})
+	}
+}
+
+func TestUnspentHashHandler(t *testing.T) {
+	report := &visor.UnspentHashReport{
+		HeadSeq:   180,
+		Stored:    testSHA256(1),
+		Computed:  testSHA256(1),
+		Expected:  testSHA256(1),
+		Unspents:  100,
+		CheckedAt: time.Unix(1540305209, 0).UTC(),
+	}
+
+	mismatch := *report
+	mismatch.Computed = testSHA256(2)
+
+	cases := []struct {
+		name            string
+		method          string
+		status          int
+		err             string
+		report          *visor.UnspentHashReport
+		checkErr        error
+		expectOK        bool
+		expectErrSubstr string
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:     "500 - CheckUnspentHash failed",
+			method:   http.MethodGet,
+			status:   http.StatusInternalServerError,
+			err:      "CheckUnspentHash failed",
+			checkErr: errors.New("CheckUnspentHash failed"),
+		},
+		{
+			name:   "404 - empty blockchain",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			err:    "blockchain is empty",
+		},
+		{
+			name:     "200",
+			method:   http.MethodGet,
+			status:   http.StatusOK,
+			report:   report,
+			expectOK: true,
+		},
+		{
+			name:            "200 - mismatch",
+			method:          http.MethodGet,
+			status:          http.StatusOK,
+			report:          &mismatch,
+			expectErrSubstr: "unspent pool hash does not match the blockchain at head seq 180",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("CheckUnspentHash").Return(tc.report, tc.checkErr)
+
+			req, err := http.NewRequest(tc.method, "/api/v2/health/unspent-hash", nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp HTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			if tc.err != "" {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+
+			b, err := json.Marshal(resp.Data)
+			require.NoError(t, err)
+
+			var status UnspentHashStatus
+			require.NoError(t, json.Unmarshal(b, &status))
+			require.Equal(t, NewUnspentHashStatus(*tc.report), status)
+			require.Equal(t, tc.expectOK, status.OK)
+			if tc.expectErrSubstr != "" {
+				require.Contains(t, status.Error, tc.expectErrSubstr)
+			} else {
+				require.Empty(t, status.Error)
+			}
 		})
 	}
 }
+
+func testSHA256(b byte) cipher.SHA256 {
+	return cipher.SumSHA256([]byte{b})
+}
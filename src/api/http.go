@@ -19,15 +19,29 @@ import (
 	"github.com/rs/cors"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/file"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/readable"
+	phttp "github.com/ness-network/privateness/src/util/http"
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 var (
 	logger = logging.MustGetLogger("api")
+
+	// byteRangeEndpoints serve byte range requests and are not gzip compressed
+	byteRangeEndpoints = map[string]struct{}{
+		"/api/v2/blocks/raw": {},
+	}
+
+	// streamEndpoints stream their responses and compress them themselves, flushing the compressed stream as they write
+	streamEndpoints = map[string]struct{}{
+		"/api/v2/blocks/stream": {},
+	}
 )
 
 const (
@@ -42,6 +56,18 @@ const (
 	defaultWriteTimeout = time.Second * 60
 	defaultIdleTimeout  = time.Second * 120
 
+	// defaultMaxLongPollTimeout is kept below defaultWriteTimeout so that a long-poll response can be written
+	defaultMaxLongPollTimeout = time.Second * 30
+	// defaultMaxStreamDuration is kept below defaultWriteTimeout so that a stream ends cleanly before the write deadline
+	defaultMaxStreamDuration = time.Second * 50
+
+	// defaultMaxBalanceAddresses is the default maximum number of addresses of a /api/v1/balance request
+	defaultMaxBalanceAddresses = 25000
+	// balanceChunkSize is the number of addresses whose balances /api/v1/balance reads at a time
+	balanceChunkSize = 1000
+	// defaultMaxBalanceHistoryEntries is the default maximum number of wallet transactions of a /api/v1/wallet/balance/history request
+	defaultMaxBalanceHistoryEntries = 10000
+
 	// EndpointsRead endpoints with no side-effects and no changes in node state
 	EndpointsRead = "READ"
 	// EndpointsStatus endpoints offer (meta,runtime)data to dashboard and monitoring clients
@@ -52,12 +78,18 @@ const (
 	EndpointsWallet = "WALLET"
 	// EndpointsInsecureWalletSeed endpoints implement wallet interface
 	EndpointsInsecureWalletSeed = "INSECURE_WALLET_SEED"
+	// EndpointsInsecureWalletSweep endpoints that accept raw secret keys to sweep their funds into a wallet
+	EndpointsInsecureWalletSweep = "INSECURE_WALLET_SWEEP"
 	// EndpointsPrometheus endpoints for Go application metrics
 	EndpointsPrometheus = "PROMETHEUS"
 	// EndpointsNetCtrl endpoints for managing network connections
 	EndpointsNetCtrl = "NET_CTRL"
 	// EndpointsStorage endpoints implement interface for key-value storage for arbitrary data
 	EndpointsStorage = "STORAGE"
+	// EndpointsAdmin endpoints for node administration, e.g. database snapshots and forced block requests
+	EndpointsAdmin = "ADMIN"
+	// EndpointsPublisher endpoints that arm and disarm the block publisher role with the blockchain secret key
+	EndpointsPublisher = "PUBLISHER"
 )
 
 // Server exposes an HTTP API
@@ -78,10 +110,28 @@ type Config struct {
 	WriteTimeout       time.Duration
 	IdleTimeout        time.Duration
 	Health             HealthConfig
+	Node               NodeConfig
 	HostWhitelist      []string
 	EnabledAPISets     map[string]struct{}
 	Username           string
 	Password           string
+	// APIKey is the key required in the X-API-Key header by the wallet, storage and admin endpoints, if not empty
+	APIKey string
+	// MaxLongPollTimeout caps how long a long-poll request waits for a change
+	MaxLongPollTimeout time.Duration
+	// MaxStreamDuration caps how long a streamed response stays open, it must be below WriteTimeout
+	MaxStreamDuration time.Duration
+	// MaxBalanceAddresses is the maximum number of addresses of a /api/v1/balance request
+	MaxBalanceAddresses int
+	// MaxBalanceHistoryEntries is the maximum number of wallet transactions in the time range of a /api/v1/wallet/balance/history request
+	MaxBalanceHistoryEntries int
+	// MaxBodySizes are the maximum request body sizes of the endpoints
+	MaxBodySizes BodyLimits
+	// CSRFTokenLifetime is the lifetime of CSRF tokens
+	CSRFTokenLifetime time.Duration
+	// CSRFStateless disables binding CSRF tokens to the client's origin and session.
+	// Deprecated, it will be removed in the next release.
+	CSRFStateless bool
 }
 
 // HealthConfig configuration data exposed in /health
@@ -93,17 +143,27 @@ type HealthConfig struct {
 }
 
 type muxConfig struct {
-	host               string
-	appLoc             string
-	enableGUI          bool
-	disableCSRF        bool
-	disableHeaderCheck bool
-	disableCSP         bool
-	enabledAPISets     map[string]struct{}
-	hostWhitelist      []string
-	username           string
-	password           string
-	health             HealthConfig
+	host                string
+	appLoc              string
+	enableGUI           bool
+	disableCSRF         bool
+	csrfTokenLifetime   time.Duration
+	csrfStateless       bool
+	disableHeaderCheck  bool
+	disableCSP          bool
+	enabledAPISets      map[string]struct{}
+	hostWhitelist       []string
+	username            string
+	password            string
+	apiKey              string
+	health              HealthConfig
+	node                NodeConfig
+	maxLongPollTimeout  time.Duration
+	maxStreamDuration   time.Duration
+	maxBalanceAddresses int
+	maxBodySizes        BodyLimits
+
+	maxBalanceHistoryEntries int
 }
 
 // HTTPResponse represents the http response struct
@@ -116,6 +176,10 @@ type HTTPResponse struct {
 type HTTPError struct {
 	Message string `json:"message"`
 	Code    int    `json:"code"`
+	// Field is the JSON field of an invalid request body, if the error is about a field
+	Field string `json:"field,omitempty"`
+	// Offset is the byte offset of invalid JSON in the request body, if it is known
+	Offset int64 `json:"offset,omitempty"`
 }
 
 // NewHTTPErrorResponse returns an HTTPResponse with the Error field populated
@@ -132,6 +196,13 @@ func NewHTTPErrorResponse(code int, msg string) HTTPResponse {
 	}
 }
 
+// requestLogger returns the package logger, adding the request ID of r to its log entries
+func requestLogger(r *http.Request) *logging.Logger {
+	return &logging.Logger{
+		FieldLogger: plogging.FromContext(r.Context(), logger),
+	}
+}
+
 func writeHTTPResponse(w http.ResponseWriter, resp HTTPResponse) {
 	out, err := json.MarshalIndent(resp, "", "    ")
 	if err != nil {
@@ -172,6 +243,10 @@ func create(host string, c Config, gateway Gatewayer) (*Server, error) {
 		logger.Warning("CSRF check disabled")
 	}
 
+	if c.CSRFStateless {
+		logger.Warning("CSRF tokens are not bound to the client session, this option is deprecated")
+	}
+
 	if c.DisableHeaderCheck {
 		logger.Warning("Header check disabled")
 	}
@@ -185,19 +260,44 @@ func create(host string, c Config, gateway Gatewayer) (*Server, error) {
 	if c.IdleTimeout == 0 {
 		c.IdleTimeout = defaultIdleTimeout
 	}
+	if c.MaxLongPollTimeout == 0 {
+		c.MaxLongPollTimeout = defaultMaxLongPollTimeout
+	}
+	if c.MaxStreamDuration == 0 {
+		c.MaxStreamDuration = defaultMaxStreamDuration
+	}
+	if c.MaxBalanceAddresses == 0 {
+		c.MaxBalanceAddresses = defaultMaxBalanceAddresses
+	}
+	if c.MaxBalanceHistoryEntries == 0 {
+		c.MaxBalanceHistoryEntries = defaultMaxBalanceHistoryEntries
+	}
+	if c.CSRFTokenLifetime == 0 {
+		c.CSRFTokenLifetime = CSRFMaxAge
+	}
 
 	mc := muxConfig{
-		host:               host,
-		appLoc:             appLoc,
-		enableGUI:          c.EnableGUI,
-		disableCSRF:        c.DisableCSRF,
-		disableHeaderCheck: c.DisableHeaderCheck,
-		disableCSP:         c.DisableCSP,
-		health:             c.Health,
-		enabledAPISets:     c.EnabledAPISets,
-		hostWhitelist:      c.HostWhitelist,
-		username:           c.Username,
-		password:           c.Password,
+		host:                host,
+		appLoc:              appLoc,
+		enableGUI:           c.EnableGUI,
+		disableCSRF:         c.DisableCSRF,
+		csrfTokenLifetime:   c.CSRFTokenLifetime,
+		csrfStateless:       c.CSRFStateless,
+		disableHeaderCheck:  c.DisableHeaderCheck,
+		disableCSP:          c.DisableCSP,
+		health:              c.Health,
+		node:                c.Node,
+		enabledAPISets:      c.EnabledAPISets,
+		hostWhitelist:       c.HostWhitelist,
+		username:            c.Username,
+		password:            c.Password,
+		apiKey:              c.APIKey,
+		maxLongPollTimeout:  c.MaxLongPollTimeout,
+		maxStreamDuration:   c.MaxStreamDuration,
+		maxBalanceAddresses: c.MaxBalanceAddresses,
+		maxBodySizes:        c.MaxBodySizes,
+
+		maxBalanceHistoryEntries: c.MaxBalanceHistoryEntries,
 	}
 
 	srvMux := newServerMux(mc, gateway)
@@ -311,10 +411,22 @@ func (s *Server) Shutdown() {
 	<-s.done
 }
 
+// muxPattern returns the http.ServeMux pattern of an endpoint.
+// An endpoint ending with a path parameter, such as /explorer/address/{addr},
+// is registered as the subtree before the parameter, and its handler parses the rest of the path.
+func muxPattern(endpoint string) string {
+	if i := strings.Index(endpoint, "{"); i != -1 {
+		return endpoint[:i]
+	}
+	return endpoint
+}
+
 // newServerMux creates an http.ServeMux with handlers registered
 func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	mux := http.NewServeMux()
 
+	c.maxBodySizes = c.maxBodySizes.withDefaults()
+
 	allowedOrigins := []string{fmt.Sprintf("http://%s", c.host)}
 	for _, s := range c.hostWhitelist {
 		allowedOrigins = append(allowedOrigins, fmt.Sprintf("http://%s", s))
@@ -324,7 +436,8 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		AllowedOrigins:     allowedOrigins,
 		Debug:              false,
 		AllowedMethods:     []string{http.MethodGet, http.MethodPost},
-		AllowedHeaders:     []string{"Origin", "Accept", "Content-Type", "X-Requested-With", CSRFHeaderName},
+		AllowedHeaders:     []string{"Origin", "Accept", "Content-Type", "X-Requested-With", CSRFHeaderName, CSRFSessionHeaderName, APIKeyHeader, phttp.RequestIDHeader},
+		ExposedHeaders:     []string{phttp.RequestIDHeader},
 		AllowCredentials:   false, // credentials are not used, but it would be safe to enable if necessary
 		OptionsPassthrough: false,
 	})
@@ -379,12 +492,14 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	}
 
 	webHandlerWithOptionals := func(apiVersion, endpoint string, handlerFunc http.Handler, checkCSRF, checkHeaders bool) {
-		handler := wh.ElapsedHandler(logger, handlerFunc)
+		// The body size is checked after the CSRF, header and auth checks, and before the handler reads the body
+		handler := bodyLimitHandler(apiVersion, c.maxBodySizes.endpoint(endpoint), handlerFunc)
+		handler = phttp.ElapsedHandler(logger, handler)
 
 		handler = corsHandler.Handler(handler)
 
 		if checkCSRF {
-			handler = CSRFCheck(apiVersion, c.disableCSRF, handler)
+			handler = CSRFCheck(apiVersion, c.disableCSRF, c.csrfStateless, handler)
 		}
 
 		if checkHeaders {
@@ -396,7 +511,20 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		}
 
 		handler = basicAuth(apiVersion, c.username, c.password, "skycoin daemon", handler)
-		handler = gziphandler.GzipHandler(handler)
+
+		// Byte range responses are not compressed, their ranges are offsets of the uncompressed content.
+		// Streams are compressed by their handlers, the gzip handler buffers small writes and ignores flushes.
+		_, isByteRange := byteRangeEndpoints[endpoint]
+		_, isStream := streamEndpoints[endpoint]
+		// The fields of a response are filtered before it is compressed
+		if !isByteRange && !isStream {
+			handler = fieldsHandler(apiVersion, handler)
+			handler = gziphandler.GzipHandler(handler)
+		}
+
+		// Assign the request ID first, so that everything logged for the request includes it
+		handler = phttp.RequestIDHandler(handler)
+
 		mux.Handle(endpoint, handler)
 	}
 
@@ -405,16 +533,30 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		// Explicitly check nil, caller should not pass empty initialized map
 		if methodAPISets != nil {
 			handler = forMethodAPISets(apiVersion, handler, methodAPISets)
+			handler = apiKeyCheck(apiVersion, c.apiKey, methodAPISets, handler)
 		}
 
 		webHandlerWithOptionals(apiVersion, endpoint, handler, true, !c.disableHeaderCheck)
 	}
 
+	// routes records the API endpoints for the OpenAPI spec
+	var routes []apiRoute
+
 	webHandlerV1 := func(endpoint string, handler http.Handler, methodAPISets map[string][]string) {
-		webHandler(apiVersion1, "/api/v1"+endpoint, handler, methodAPISets)
+		routes = append(routes, apiRoute{
+			apiVersion:    apiVersion1,
+			path:          "/api/v1" + endpoint,
+			methodAPISets: methodAPISets,
+		})
+		webHandler(apiVersion1, muxPattern("/api/v1"+endpoint), handler, methodAPISets)
 	}
 
 	webHandlerV2 := func(endpoint string, handler http.Handler, methodAPISets map[string][]string) {
+		routes = append(routes, apiRoute{
+			apiVersion:    apiVersion2,
+			path:          "/api/v2" + endpoint,
+			methodAPISets: methodAPISets,
+		})
 		webHandler(apiVersion2, "/api/v2"+endpoint, handler, methodAPISets)
 	}
 
@@ -447,39 +589,88 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 
 	// get the current CSRF token
 	csrfHandlerV1 := func(endpoint string, handler http.Handler) {
+		routes = append(routes, apiRoute{
+			apiVersion: apiVersion1,
+			path:       "/api/v1" + endpoint,
+		})
 		webHandlerWithOptionals(apiVersion1, "/api/v1"+endpoint, handler, false, !c.disableHeaderCheck)
 	}
-	csrfHandlerV1("/csrf", getCSRFToken(c.disableCSRF)) // csrf is always available, regardless of the API set
+	csrf := csrfConfig{
+		disabled:  c.disableCSRF,
+		lifetime:  c.csrfTokenLifetime,
+		stateless: c.csrfStateless,
+	}
+	csrfHandlerV1("/csrf", getCSRFToken(csrf)) // csrf is always available, regardless of the API set
+	webHandlerV1("/csrf/rotate", rotateCSRFHandler(csrf), map[string][]string{
+		http.MethodPost: []string{EndpointsNetCtrl},
+	})
 
 	// Status endpoints
 	webHandlerV1("/version", versionHandler(c.health.BuildInfo), nil) // version is always available, regardless of the API set
 	webHandlerV1("/health", healthHandler(c, gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
 	})
+	webHandlerV2("/health/unspent-hash", unspentHashHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
+	})
+	webHandlerV1("/node", nodeHandler(c), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
+	})
+	webHandlerV1("/verification-params", verificationParamsHandler(c, gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
+	})
 
-	// Wallet endpoints
-	webHandlerV1("/wallet", walletHandler(gateway), map[string][]string{
+	// Wallet endpoints. The endpoints that operate on one wallet require its access token, if it has one.
+	// /wallets and /wallets/format-status leave out the wallets whose token the request doesn't carry.
+	// These endpoints are deliberately not checked:
+	// - /wallet/create, /wallet/newSeed and /wallet/seed/verify don't use an existing wallet
+	// - /wallet/recover requires the wallet seed, and /wallet/recover/status only reports a job's progress
+	// - /wallets/folderName only returns the wallet directory
+	webHandlerV1("/wallet", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletHandler(gateway)), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
 	webHandlerV1("/wallet/create", walletCreateHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/newAddress", walletNewAddressesHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/newAddress", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletNewAddressesHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/balance", walletBalanceHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/balance", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletBalanceHandler(gateway)), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/balance/history", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletBalanceHistoryHandler(gateway, c.maxBalanceHistoryEntries)), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/transaction", walletCreateTransactionHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/transaction", walletTokenCheck(apiVersion1, gateway, walletIDFromJSON, walletCreateTransactionHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/transaction/sign", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletSignTransactionHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/address/encrypt", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletAddressEncryptHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV2("/wallet/transaction/sign", walletSignTransactionHandler(gateway), map[string][]string{
+	webHandlerV2("/wallet/address/decrypt", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletAddressDecryptHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/transactions", walletTransactionsHandler(gateway), map[string][]string{
+	webHandlerV2("/wallet/transaction/bump", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletBumpTransactionHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/transaction/note", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletTxNoteHandler(gateway)), map[string][]string{
+		http.MethodGet:    []string{EndpointsWallet},
+		http.MethodPost:   []string{EndpointsWallet},
+		http.MethodDelete: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/transaction/detail", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletTransactionDetailHandler(gateway)), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/transactions", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletTransactionsHandler(gateway)), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/update", walletUpdateHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/update", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletUpdateHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/address/label", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletAddressLabelHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
 	webHandlerV1("/wallets", walletsHandler(gateway), map[string][]string{
@@ -488,31 +679,86 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/wallets/folderName", walletFolderHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
+	webHandlerV1("/wallets/backups", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletBackupsHandler(gateway)), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallets/format-status", walletFormatStatusHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallets/migrate", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletFormatMigrateHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
 	webHandlerV1("/wallet/newSeed", newSeedHandler(), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/seed", walletSeedHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/seed", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletSeedHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsInsecureWalletSeed},
+	})
+	webHandlerV1("/wallet/xpub", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletXPubHandler(gateway)), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/derive-child", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletDeriveChildHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsInsecureWalletSeed},
 	})
+	webHandlerV1("/wallet/sweep", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletSweepHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsInsecureWalletSweep},
+	})
+	webHandlerV1("/wallet/migrate", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletMigrateHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
 	webHandlerV2("/wallet/seed/verify", http.HandlerFunc(walletVerifySeedHandler), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
 
-	webHandlerV1("/wallet/unload", walletUnloadHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/unload", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletUnloadHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/encrypt", walletEncryptHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/metadata/unlock", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletMetadataUnlockHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/decrypt", walletDecryptHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/metadata/lock", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletMetadataLockHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
-	webHandlerV2("/wallet/recover", walletRecoverHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/encrypt", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletEncryptHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV1("/wallet/decrypt", walletTokenCheck(apiVersion1, gateway, walletIDFromForm, walletDecryptHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/token", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletTokenHandler(gateway)), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
+	webHandlerV2("/wallet/token/rotate", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletTokenRotateHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/token/remove", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletTokenRemoveHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/approval/policy", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletApprovalPolicyHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/approval/policy/remove", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletApprovalPolicyRemoveHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/approvals", walletTokenCheck(apiVersion2, gateway, walletIDFromForm, walletApprovalsHandler(gateway)), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/approval/approve", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletApprovalApproveHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/approval/cancel", walletTokenCheck(apiVersion2, gateway, walletIDFromJSON, walletApprovalCancelHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/recover", walletRecoverHandler(gateway), map[string][]string{
+		http.MethodPost:   []string{EndpointsWallet},
+		http.MethodDelete: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/recover/status", walletRecoverStatusHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
 
 	// Blockchain interface
-	webHandlerV1("/blockchain/metadata", blockchainMetadataHandler(gateway), map[string][]string{
+	webHandlerV1("/blockchain/metadata", blockchainMetadataHandler(gateway, c.maxLongPollTimeout), map[string][]string{
 		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
 	})
 	webHandlerV1("/blockchain/progress", blockchainProgressHandler(gateway), map[string][]string{
@@ -528,6 +774,19 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/last_blocks", lastBlocksHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
+	webHandlerV2("/block/decode", http.HandlerFunc(decodeBlockHandler), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV2("/block/preview", blockPreviewHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
+	webHandlerV2("/blocks/stream", blocksStreamHandler(gateway, c.maxStreamDuration), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
+	webHandlerV2("/blocks/raw", blocksRawHandler(gateway), map[string][]string{
+		http.MethodGet:  []string{EndpointsRead},
+		http.MethodHead: []string{EndpointsRead},
+	})
 
 	// Network stats endpoints
 	webHandlerV1("/network/connection", connectionHandler(gateway), map[string][]string{
@@ -545,14 +804,46 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/network/connections/exchange", exchgConnectionsHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
 	})
+	webHandlerV1("/network/peers", peersHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
+	})
 
 	// Network admin endpoints
 	webHandlerV1("/network/connection/disconnect", disconnectHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsNetCtrl},
 	})
+	webHandlerV1("/network/peers/export", peersExportHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsNetCtrl},
+	})
+	webHandlerV1("/network/peers/import", peersImportHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsNetCtrl},
+	})
+	webHandlerV1("/network/bandwidth", bandwidthHandler(gateway), map[string][]string{
+		http.MethodGet:  []string{EndpointsRead, EndpointsStatus},
+		http.MethodPost: []string{EndpointsNetCtrl},
+	})
+	webHandlerV1("/network/propagation", propagationHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsStatus},
+	})
+	webHandlerV1("/network/bans", peerBansHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead, EndpointsNetCtrl},
+	})
+	webHandlerV1("/network/unban", unbanHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsNetCtrl},
+	})
+	webHandlerV2("/network/request-blocks", networkRequestBlocksHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsAdmin},
+	})
+	webHandlerV2("/network/request-txns", networkRequestTxnsHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsAdmin},
+	})
 
 	// Transaction related endpoints
 	webHandlerV1("/pendingTxs", pendingTxnsHandler(gateway), map[string][]string{
+		http.MethodGet:    []string{EndpointsRead},
+		http.MethodDelete: []string{EndpointsTransaction},
+	})
+	webHandlerV1("/pendingTxs/conflicts", pendingTxnsConflictsHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
 	webHandlerV1("/transaction", transactionHandler(gateway), map[string][]string{
@@ -565,6 +856,15 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/transaction/verify", verifyTxnHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsRead},
 	})
+	webHandlerV2("/transaction/test-accept", testAcceptTxnsHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV2("/transaction/ancestry", txnGraphHandler(gateway, visor.TxnGraphAncestry), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
+	webHandlerV2("/transaction/descendants", txnGraphHandler(gateway, visor.TxnGraphDescendants), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 	webHandlerV1("/transactions", transactionsHandler(gateway), map[string][]string{
 		http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsRead},
@@ -584,7 +884,11 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsRead},
 	})
-	webHandlerV1("/balance", balanceHandler(gateway), map[string][]string{
+	webHandlerV1("/outputs/summary", outputsSummaryHandler(gateway), map[string][]string{
+		http.MethodGet:  []string{EndpointsRead},
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV1("/balance", balanceHandler(gateway, c.maxBalanceAddresses), map[string][]string{
 		http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsRead},
 	})
@@ -595,6 +899,29 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		http.MethodGet: []string{EndpointsRead},
 	})
 
+	// Database endpoints
+	webHandlerV2("/db/snapshot", dbSnapshotHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsAdmin},
+	})
+	webHandlerV2("/storage/stats", storageStatsHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsStatus, EndpointsAdmin},
+	})
+	webHandlerV2("/storage/compact", storageCompactHandler(gateway), map[string][]string{
+		http.MethodPost:   []string{EndpointsAdmin},
+		http.MethodDelete: []string{EndpointsAdmin},
+	})
+
+	// Block publisher endpoints, they receive the blockchain secret key so they require HTTPS or localhost
+	webHandlerV2("/publisher", secureTransportCheck(apiVersion2, publisherStatusHandler(gateway)), map[string][]string{
+		http.MethodGet: []string{EndpointsPublisher},
+	})
+	webHandlerV2("/publisher/arm", secureTransportCheck(apiVersion2, publisherArmHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsPublisher},
+	})
+	webHandlerV2("/publisher/disarm", secureTransportCheck(apiVersion2, publisherDisarmHandler(gateway)), map[string][]string{
+		http.MethodPost: []string{EndpointsPublisher},
+	})
+
 	// golang process internal metrics for Prometheus
 	webHandlerV2("/metrics", metricsHandler(c, gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsPrometheus},
@@ -609,12 +936,18 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/coinSupply", coinSupplyHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
+	webHandlerV1("/coinSupply/projection", coinSupplyProjectionHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 	webHandlerV1("/richlist", richlistHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
 	webHandlerV1("/addresscount", addressCountHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
+	webHandlerV1("/explorer/address/{addr}", addressExplorerHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 
 	// Storage endpoint
 	webHandlerV2("/data", storageHandler(gateway), map[string][]string{
@@ -623,6 +956,18 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		http.MethodDelete: []string{EndpointsStorage},
 	})
 
+	// Address notifications endpoints
+	webHandlerV2("/notifications/subscriptions", notificationSubscriptionsHandler(gateway), map[string][]string{
+		http.MethodPost:   []string{EndpointsStorage},
+		http.MethodDelete: []string{EndpointsStorage},
+	})
+	webHandlerV2("/notifications", notificationsHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsStorage},
+	})
+
+	// OpenAPI spec of the endpoints registered above
+	webHandlerV1("/spec.json", specHandler(&routes, c.health.BuildInfo), nil) // spec is always available, regardless of the API set
+
 	return mux
 }
 
@@ -15,14 +15,17 @@ import (
 const configuredHost = "127.0.0.1:6420"
 
 var allAPISetsEnabled = map[string]struct{}{
-	EndpointsRead:               struct{}{},
-	EndpointsTransaction:        struct{}{},
-	EndpointsStatus:             struct{}{},
-	EndpointsWallet:             struct{}{},
-	EndpointsInsecureWalletSeed: struct{}{},
-	EndpointsPrometheus:         struct{}{},
-	EndpointsNetCtrl:            struct{}{},
-	EndpointsStorage:            struct{}{},
+	EndpointsRead:                struct{}{},
+	EndpointsTransaction:         struct{}{},
+	EndpointsStatus:              struct{}{},
+	EndpointsWallet:              struct{}{},
+	EndpointsInsecureWalletSeed:  struct{}{},
+	EndpointsInsecureWalletSweep: struct{}{},
+	EndpointsPrometheus:          struct{}{},
+	EndpointsNetCtrl:             struct{}{},
+	EndpointsStorage:             struct{}{},
+	EndpointsAdmin:               struct{}{},
+	EndpointsPublisher:           struct{}{},
 }
 
 func defaultMuxConfig() muxConfig {
@@ -62,6 +65,15 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/coinSupply": []string{
 		http.MethodGet,
 	},
+	"/api/v1/coinSupply/projection": []string{
+		http.MethodGet,
+	},
+	"/api/v1/csrf/rotate": []string{
+		http.MethodPost,
+	},
+	"/api/v1/explorer/address/{addr}": []string{
+		http.MethodGet,
+	},
 	"/api/v1/health": []string{
 		http.MethodGet,
 	},
@@ -71,6 +83,9 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/last_blocks": []string{
 		http.MethodGet,
 	},
+	"/api/v1/verification-params": []string{
+		http.MethodGet,
+	},
 	"/api/v1/version": []string{
 		http.MethodGet,
 	},
@@ -83,6 +98,28 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/network/connections/exchange": []string{
 		http.MethodGet,
 	},
+	"/api/v1/network/peers": []string{
+		http.MethodGet,
+	},
+	"/api/v1/network/peers/export": []string{
+		http.MethodGet,
+	},
+	"/api/v1/network/peers/import": []string{
+		http.MethodPost,
+	},
+	"/api/v1/network/bandwidth": []string{
+		http.MethodGet,
+		http.MethodPost,
+	},
+	"/api/v1/network/propagation": []string{
+		http.MethodGet,
+	},
+	"/api/v1/network/bans": []string{
+		http.MethodGet,
+	},
+	"/api/v1/network/unban": []string{
+		http.MethodPost,
+	},
 	"/api/v1/network/connections/trust": []string{
 		http.MethodGet,
 	},
@@ -92,12 +129,23 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/network/connection/disconnect": []string{
 		http.MethodPost,
 	},
+	"/api/v1/node": []string{
+		http.MethodGet,
+	},
 	"/api/v1/outputs": []string{
 		http.MethodGet,
 		http.MethodPost,
 	},
+	"/api/v1/outputs/summary": []string{
+		http.MethodGet,
+		http.MethodPost,
+	},
 	"/api/v1/pendingTxs": []string{
 		http.MethodGet,
+		http.MethodDelete,
+	},
+	"/api/v1/pendingTxs/conflicts": []string{
+		http.MethodGet,
 	},
 	"/api/v1/rawtx": []string{
 		http.MethodGet,
@@ -108,6 +156,9 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/resendUnconfirmedTxns": []string{
 		http.MethodPost,
 	},
+	"/api/v1/spec.json": []string{
+		http.MethodGet,
+	},
 	"/api/v1/transaction": []string{
 		http.MethodGet,
 	},
@@ -121,48 +172,124 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/wallet": []string{
 		http.MethodGet,
 	},
+	"/api/v1/wallet/address/label": []string{
+		http.MethodPost,
+	},
 	"/api/v1/wallet/balance": []string{
 		http.MethodGet,
 	},
+	"/api/v1/wallet/balance/history": []string{
+		http.MethodGet,
+	},
 	"/api/v1/wallet/create": []string{
 		http.MethodPost,
 	},
+	"/api/v1/wallet/decrypt": []string{
+		http.MethodPost,
+	},
+	"/api/v1/wallet/encrypt": []string{
+		http.MethodPost,
+	},
+	"/api/v1/wallet/migrate": []string{
+		http.MethodPost,
+	},
 	"/api/v1/wallet/newAddress": []string{
 		http.MethodPost,
 	},
 	"/api/v1/wallet/newSeed": []string{
 		http.MethodGet,
 	},
+	"/api/v1/wallet/derive-child": []string{
+		http.MethodPost,
+	},
 	"/api/v1/wallet/seed": []string{
 		http.MethodPost,
 	},
+	"/api/v1/wallet/sweep": []string{
+		http.MethodPost,
+	},
 	"/api/v1/wallet/transaction": []string{
 		http.MethodPost,
 	},
+	"/api/v1/wallet/transaction/detail": []string{
+		http.MethodGet,
+	},
+	"/api/v1/wallet/transaction/note": []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodDelete,
+	},
 	"/api/v1/wallet/transactions": []string{
 		http.MethodGet,
 	},
+	"/api/v1/wallet/metadata/lock": []string{
+		http.MethodPost,
+	},
+	"/api/v1/wallet/metadata/unlock": []string{
+		http.MethodPost,
+	},
 	"/api/v1/wallet/unload": []string{
 		http.MethodPost,
 	},
 	"/api/v1/wallet/update": []string{
 		http.MethodPost,
 	},
+	"/api/v1/wallet/xpub": []string{
+		http.MethodGet,
+	},
 	"/api/v1/wallets": []string{
 		http.MethodGet,
 	},
+	"/api/v1/wallets/backups": []string{
+		http.MethodGet,
+	},
 	"/api/v1/wallets/folderName": []string{
 		http.MethodGet,
 	},
+	"/api/v1/wallets/format-status": []string{
+		http.MethodGet,
+	},
+	"/api/v1/wallets/migrate": []string{
+		http.MethodPost,
+	},
 
+	"/api/v2/block/decode": []string{
+		http.MethodPost,
+	},
+	"/api/v2/block/preview": []string{
+		http.MethodGet,
+	},
+	"/api/v2/blocks/raw": []string{
+		http.MethodGet,
+		http.MethodHead,
+	},
+	"/api/v2/blocks/stream": []string{
+		http.MethodGet,
+	},
+	"/api/v2/health/unspent-hash": []string{
+		http.MethodGet,
+	},
 	"/api/v2/transaction/verify": []string{
 		http.MethodPost,
 	},
+	"/api/v2/transaction/test-accept": []string{
+		http.MethodPost,
+	},
+	"/api/v2/transaction/ancestry": []string{
+		http.MethodGet,
+	},
+	"/api/v2/transaction/descendants": []string{
+		http.MethodGet,
+	},
 	"/api/v2/address/verify": []string{
 		http.MethodPost,
 	},
 	"/api/v2/wallet/recover": []string{
 		http.MethodPost,
+		http.MethodDelete,
+	},
+	"/api/v2/wallet/recover/status": []string{
+		http.MethodGet,
 	},
 	"/api/v2/wallet/seed/verify": []string{
 		http.MethodPost,
@@ -170,6 +297,39 @@ var endpointsMethods = map[string][]string{
 	"/api/v2/wallet/transaction/sign": []string{
 		http.MethodPost,
 	},
+	"/api/v2/wallet/address/encrypt": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/address/decrypt": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/transaction/bump": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/token": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/token/rotate": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/token/remove": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/approval/policy": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/approval/policy/remove": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/approvals": []string{
+		http.MethodGet,
+	},
+	"/api/v2/wallet/approval/approve": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/approval/cancel": []string{
+		http.MethodPost,
+	},
 	"/api/v2/transaction": []string{
 		http.MethodPost,
 	},
@@ -179,6 +339,41 @@ var endpointsMethods = map[string][]string{
 		http.MethodPost,
 		http.MethodDelete,
 	},
+	"/api/v2/db/snapshot": []string{
+		http.MethodPost,
+	},
+	"/api/v2/storage/stats": []string{
+		http.MethodGet,
+	},
+	"/api/v2/storage/compact": []string{
+		http.MethodPost,
+		http.MethodDelete,
+	},
+	"/api/v2/metrics": []string{
+		http.MethodGet,
+	},
+	"/api/v2/network/request-blocks": []string{
+		http.MethodPost,
+	},
+	"/api/v2/network/request-txns": []string{
+		http.MethodPost,
+	},
+	"/api/v2/notifications": []string{
+		http.MethodGet,
+	},
+	"/api/v2/notifications/subscriptions": []string{
+		http.MethodPost,
+		http.MethodDelete,
+	},
+	"/api/v2/publisher": []string{
+		http.MethodGet,
+	},
+	"/api/v2/publisher/arm": []string{
+		http.MethodPost,
+	},
+	"/api/v2/publisher/disarm": []string{
+		http.MethodPost,
+	},
 }
 
 func allEndpoints() []string {
@@ -242,7 +437,7 @@ func TestEnableGUI(t *testing.T) {
 			req, err := http.NewRequest(http.MethodGet, tc.endpoint, nil)
 			require.NoError(t, err)
 
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 
 			rr := httptest.NewRecorder()
 			cfg := defaultMuxConfig()
@@ -305,13 +500,13 @@ func TestAPISetDisabled(t *testing.T) {
 		cfg.disableCSRF = disableCSRF
 		cfg.enabledAPISets = map[string]struct{}{} // disable all API sets
 
-		handler := newServerMux(cfg, &MockGatewayer{})
+		handler := newServerMux(cfg, newWalletMockGatewayer())
 
 		rr := httptest.NewRecorder()
 		handler.ServeHTTP(rr, req)
 
 		switch endpoint {
-		case "/api/v1/csrf", "/api/v1/version": // always enabled
+		case "/api/v1/csrf", "/api/v1/version", "/api/v1/spec.json": // always enabled
 			require.Equal(t, http.StatusOK, rr.Code)
 		default:
 			require.Equal(t, http.StatusForbidden, rr.Code)
@@ -386,7 +581,7 @@ func TestCORS(t *testing.T) {
 					requestHeaders := strings.ToLower(fmt.Sprintf("%s, Content-Type", CSRFHeaderName))
 					req.Header.Set("Access-Control-Request-Headers", requestHeaders)
 
-					handler := newServerMux(cfg, &MockGatewayer{})
+					handler := newServerMux(cfg, newWalletMockGatewayer())
 
 					rr := httptest.NewRecorder()
 					handler.ServeHTTP(rr, req)
@@ -510,7 +705,7 @@ func TestHTTPBasicAuthInvalid(t *testing.T) {
 				cfg.username = tc.username
 				cfg.password = tc.password
 
-				handler := newServerMux(cfg, &MockGatewayer{})
+				handler := newServerMux(cfg, newWalletMockGatewayer())
 
 				rr := httptest.NewRecorder()
 				handler.ServeHTTP(rr, req)
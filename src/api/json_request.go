@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// JSONDecodeError is returned by decodeJSONRequest for an invalid request body.
+// Field is the JSON field of the error, Offset is the byte offset of invalid JSON in the body.
+type JSONDecodeError struct {
+	Message string
+	Field   string
+	Offset  int64
+}
+
+func (e JSONDecodeError) Error() string {
+	return e.Message
+}
+
+// decodeJSONRequest decodes the JSON request body of a v2 endpoint into v.
+// Unlike a plain json.Decoder, it rejects an empty body, fields that v doesn't have
+// and data after the JSON value, so that misspelled fields are reported to the client instead of ignored.
+// JSON errors are returned as a JSONDecodeError.
+func decodeJSONRequest(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return JSONDecodeError{
+			Message: "empty request body",
+		}
+	}
+
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	if err := d.Decode(v); err != nil {
+		return newJSONDecodeError(err)
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		return JSONDecodeError{
+			Message: "invalid JSON: unexpected data after the JSON value",
+			Offset:  d.InputOffset(),
+		}
+	}
+
+	return nil
+}
+
+// newJSONDecodeError converts a json.Decoder error to a JSONDecodeError
+func newJSONDecodeError(err error) JSONDecodeError {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return JSONDecodeError{
+			Message: fmt.Sprintf("invalid JSON at offset %d: %v", e.Offset, e),
+			Offset:  e.Offset,
+		}
+	case *json.UnmarshalTypeError:
+		if e.Field == "" {
+			return JSONDecodeError{
+				Message: fmt.Sprintf("invalid JSON at offset %d: the request body must be a JSON object, not a JSON %s", e.Offset, e.Value),
+				Offset:  e.Offset,
+			}
+		}
+		return JSONDecodeError{
+			Message: fmt.Sprintf("invalid value for field %q at offset %d: expected %s, got JSON %s", e.Field, e.Offset, e.Type, e.Value),
+			Field:   e.Field,
+			Offset:  e.Offset,
+		}
+	}
+
+	switch err {
+	case io.EOF:
+		return JSONDecodeError{
+			Message: "empty request body",
+		}
+	case io.ErrUnexpectedEOF:
+		return JSONDecodeError{
+			Message: "invalid JSON: unexpected end of the request body",
+		}
+	}
+
+	// json.Decoder has no error type for unknown fields, the error is `json: unknown field "<field>"`
+	const unknownFieldPrefix = "json: unknown field "
+	if msg := err.Error(); strings.HasPrefix(msg, unknownFieldPrefix) {
+		field, uerr := strconv.Unquote(strings.TrimPrefix(msg, unknownFieldPrefix))
+		if uerr == nil {
+			return JSONDecodeError{
+				Message: fmt.Sprintf("unknown field %q", field),
+				Field:   field,
+			}
+		}
+	}
+
+	// Errors of the UnmarshalJSON methods of the request's types
+	return JSONDecodeError{
+		Message: err.Error(),
+	}
+}
+
+// NewJSONDecodeErrorResponse returns a 400 HTTPResponse for an error of decodeJSONRequest,
+// with the field or the offset of a JSONDecodeError
+func NewJSONDecodeErrorResponse(err error) HTTPResponse {
+	resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	if e, ok := err.(JSONDecodeError); ok {
+		resp.Error.Field = e.Field
+		resp.Error.Offset = e.Offset
+	}
+	return resp
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSONRequest(t *testing.T) {
+	type request struct {
+		A string `json:"a"`
+		B struct {
+			C uint64 `json:"c"`
+		} `json:"b"`
+	}
+
+	cases := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{
+			name: "valid",
+			body: `{"a": "x", "b": {"c": 1}}`,
+		},
+		{
+			name: "valid with trailing whitespace",
+			body: "{\"a\": \"x\"}\n",
+		},
+		{
+			name: "empty body",
+			err:  JSONDecodeError{Message: "empty request body"},
+		},
+		{
+			name: "whitespace body",
+			body: "  \n",
+			err:  JSONDecodeError{Message: "empty request body"},
+		},
+		{
+			name: "unknown field",
+			body: `{"a": "x", "d": 1}`,
+			err:  JSONDecodeError{Message: `unknown field "d"`, Field: "d"},
+		},
+		{
+			name: "unknown nested field",
+			body: `{"b": {"e": 1}}`,
+			err:  JSONDecodeError{Message: `unknown field "e"`, Field: "e"},
+		},
+		{
+			name: "wrong type",
+			body: `{"a": 1}`,
+			err:  JSONDecodeError{Message: `invalid value for field "a" at offset 7: expected string, got JSON number`, Field: "a", Offset: 7},
+		},
+		{
+			name: "not an object",
+			body: `[1]`,
+			err:  JSONDecodeError{Message: "invalid JSON at offset 1: the request body must be a JSON object, not a JSON array", Offset: 1},
+		},
+		{
+			name: "syntax error",
+			body: `{"a" "x"}`,
+			err:  JSONDecodeError{Message: "invalid JSON at offset 6: invalid character '\"' after object key", Offset: 6},
+		},
+		{
+			name: "truncated",
+			body: `{"a": "x"`,
+			err:  JSONDecodeError{Message: "invalid JSON: unexpected end of the request body"},
+		},
+		{
+			name: "trailing data",
+			body: `{"a": "x"} {}`,
+			err:  JSONDecodeError{Message: "invalid JSON: unexpected data after the JSON value", Offset: 12},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			var v request
+			err = decodeJSONRequest(req, &v)
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	resp := NewJSONDecodeErrorResponse(JSONDecodeError{Message: `unknown field "d"`, Field: "d"})
+	require.Equal(t, HTTPResponse{
+		Error: &HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: `unknown field "d"`,
+			Field:   "d",
+		},
+	}, resp)
+}
+
+func TestV2EndpointsStrictJSON(t *testing.T) {
+	// The v2 POST endpoints with a JSON request body, with a field of the request and a value of the wrong type for it
+	endpoints := []struct {
+		endpoint  string
+		field     string
+		value     string
+		valueType string
+		fieldType string
+	}{
+		{"/api/v2/address/verify", "address", "1", "number", "string"},
+		{"/api/v2/block/decode", "raw", "1", "number", "string"},
+		{"/api/v2/data", "key", "1", "number", "string"},
+		{"/api/v2/network/request-blocks", "start", `"1"`, "string", "uint64"},
+		{"/api/v2/network/request-txns", "peer", "1", "number", "string"},
+		{"/api/v2/notifications/subscriptions", "addresses", `"a"`, "string", "[]string"},
+		{"/api/v2/publisher/arm", "secret_key", "1", "number", "string"},
+		{"/api/v2/transaction", "ignore_unconfirmed", `"true"`, "string", "bool"},
+		{"/api/v2/transaction/test-accept", "rawtxs", `"a"`, "string", "[]string"},
+		{"/api/v2/transaction/verify", "unsigned", "1", "number", "bool"},
+		{"/api/v2/wallet/address/decrypt", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/address/encrypt", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/approval/approve", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/approval/cancel", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/approval/policy", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/approval/policy/remove", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/recover", "seed", "1", "number", "string"},
+		{"/api/v2/wallet/seed/verify", "seed", "1", "number", "string"},
+		{"/api/v2/wallet/token", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/token/remove", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/token/rotate", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/transaction/bump", "wallet_id", "1", "number", "string"},
+		{"/api/v2/wallet/transaction/sign", "wallet_id", "1", "number", "string"},
+	}
+
+	for _, e := range endpoints {
+		wrongType := fmt.Sprintf(`{"%s":%s}`, e.field, e.value)
+
+		cases := []struct {
+			name        string
+			contentType string
+			body        string
+			status      int
+			httpError   *HTTPError
+		}{
+			{
+				name:        "415 - form body",
+				contentType: ContentTypeForm,
+				body:        e.field + "=1",
+				status:      http.StatusUnsupportedMediaType,
+				httpError: &HTTPError{
+					Code:    http.StatusUnsupportedMediaType,
+					Message: "Unsupported Media Type",
+				},
+			},
+			{
+				name:   "400 - empty body",
+				status: http.StatusBadRequest,
+				httpError: &HTTPError{
+					Code:    http.StatusBadRequest,
+					Message: "empty request body",
+				},
+			},
+			{
+				name:   "400 - unknown field",
+				body:   `{"hours_selectoin": {}}`,
+				status: http.StatusBadRequest,
+				httpError: &HTTPError{
+					Code:    http.StatusBadRequest,
+					Message: `unknown field "hours_selectoin"`,
+					Field:   "hours_selectoin",
+				},
+			},
+			{
+				name:   "400 - wrong type",
+				body:   wrongType,
+				status: http.StatusBadRequest,
+				httpError: &HTTPError{
+					Code:    http.StatusBadRequest,
+					Message: fmt.Sprintf("invalid value for field %q at offset %d: expected %s, got JSON %s", e.field, len(wrongType)-1, e.fieldType, e.valueType),
+					Field:   e.field,
+					Offset:  int64(len(wrongType) - 1),
+				},
+			},
+			{
+				name:   "400 - trailing data",
+				body:   `{}{}`,
+				status: http.StatusBadRequest,
+				httpError: &HTTPError{
+					Code:    http.StatusBadRequest,
+					Message: "invalid JSON: unexpected data after the JSON value",
+					Offset:  3,
+				},
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(e.endpoint+" "+tc.name, func(t *testing.T) {
+				// No gateway method is called for an invalid request
+				gateway := &MockGatewayer{}
+
+				req, err := http.NewRequest(http.MethodPost, e.endpoint, strings.NewReader(tc.body))
+				require.NoError(t, err)
+
+				contentType := tc.contentType
+				if contentType == "" {
+					contentType = ContentTypeJSON
+				}
+				req.Header.Set("Content-Type", contentType)
+				// The publisher endpoints require HTTPS or a localhost connection
+				req.RemoteAddr = "127.0.0.1:40000"
+
+				rr := httptest.NewRecorder()
+				newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+				require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+				var resp ReceivedHTTPResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpError, resp.Error)
+			})
+		}
+	}
+}
@@ -98,7 +98,7 @@ func hostCheck(apiVersion, host string, hostWhitelist []string, handler http.Han
 		// NOTE: The "Host" header is not in http.Request.Header, it's put in the http.Request.Host field
 		_, isWhitelisted := hostWhitelistMap[r.Host]
 		if isLocalhost && r.Host != "" && !isWhitelisted {
-			logger.Critical().Errorf("Detected DNS rebind attempt - configured-host=%s header-host=%s", host, r.Host)
+			requestLogger(r).Critical().Errorf("Detected DNS rebind attempt - configured-host=%s header-host=%s", host, r.Host)
 			writeError(w, apiVersion, http.StatusForbidden, "Invalid Host")
 			return
 		}
@@ -142,13 +142,13 @@ func originRefererCheck(apiVersion, host string, hostWhitelist []string, handler
 		if toCheck != "" {
 			u, err := url.Parse(toCheck)
 			if err != nil {
-				logger.Critical().Errorf("Invalid URL in %s header: %s %v", toCheckHeader, toCheck, err)
+				requestLogger(r).Critical().Errorf("Invalid URL in %s header: %s %v", toCheckHeader, toCheck, err)
 				writeError(w, apiVersion, http.StatusForbidden, "Invalid URL in Origin or Referer header")
 				return
 			}
 
 			if _, isWhitelisted := hostWhitelistMap[u.Host]; !isWhitelisted {
-				logger.Critical().Errorf("%s header value %s does not match host and is not whitelisted", toCheckHeader, toCheck)
+				requestLogger(r).Critical().Errorf("%s header value %s does not match host and is not whitelisted", toCheckHeader, toCheck)
 				writeError(w, apiVersion, http.StatusForbidden, "Invalid Origin or Referer")
 				return
 			}
@@ -158,6 +158,27 @@ func originRefererCheck(apiVersion, host string, hostWhitelist []string, handler
 	})
 }
 
+// secureTransportCheck only allows requests received over HTTPS or from a localhost address,
+// for the endpoints that receive secret keys. The remote address of a request forwarded by
+// a reverse proxy is the address of the proxy, so a plain HTTP proxy must not be exposed.
+func secureTransportCheck(apiVersion string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil && !isLocalhostRequest(r) {
+			requestLogger(r).WithField("remoteAddr", r.RemoteAddr).Error("Rejected a request that is neither HTTPS nor from localhost")
+			writeError(w, apiVersion, http.StatusForbidden, "HTTPS or a localhost connection is required")
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// isLocalhostRequest returns true if the request was received from a localhost address
+func isLocalhostRequest(r *http.Request) bool {
+	addr, _, err := iputil.SplitAddr(r.RemoteAddr)
+	return err == nil && iputil.IsLocalhost(addr)
+}
+
 func basicAuth(apiVersion, username, password, realm string, f http.Handler) http.HandlerFunc {
 	needsAuth := username != "" || password != ""
 	usernamePasswordHash := cipher.SumSHA256(append([]byte(username), []byte(password)...))
@@ -196,6 +217,72 @@ func basicAuth(apiVersion, username, password, realm string, f http.Handler) htt
 	}
 }
 
+// APIKeyHeader is the header that carries the API key, if the node requires one
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyAPISets are the API sets whose endpoints require the API key, if one is configured.
+// An endpoint method that is also in an API set not listed here, e.g. READ, stays open.
+var apiKeyAPISets = map[string]struct{}{
+	EndpointsWallet:              {},
+	EndpointsInsecureWalletSeed:  {},
+	EndpointsInsecureWalletSweep: {},
+	EndpointsStorage:             {},
+	EndpointsAdmin:               {},
+	EndpointsPublisher:           {},
+}
+
+// requiresAPIKey returns true if all API sets of an endpoint method require the API key
+func requiresAPIKey(apiSets []string) bool {
+	if len(apiSets) == 0 {
+		return false
+	}
+
+	for _, k := range apiSets {
+		if _, ok := apiKeyAPISets[k]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// apiKeyCheck requires the X-API-Key header to match apiKey for the endpoint methods
+// whose API sets all require the API key. If apiKey is empty, requests that provide
+// an API key to these methods are rejected, like requests that provide a basic auth
+// to a node without basic auth.
+func apiKeyCheck(apiVersion, apiKey string, methodsAPISets map[string][]string, f http.Handler) http.Handler {
+	needsKey := apiKey != ""
+	apiKeyHash := cipher.SumSHA256([]byte(apiKey))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requiresAPIKey(methodsAPISets[r.Method]) {
+			f.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.Header.Get(APIKeyHeader)
+
+		if needsKey {
+			if key == "" {
+				writeError(w, apiVersion, http.StatusUnauthorized, "API key required")
+				return
+			}
+
+			keyHash := cipher.SumSHA256([]byte(key))
+			if subtle.ConstantTimeCompare(keyHash[:], apiKeyHash[:]) != 1 {
+				requestLogger(r).WithField("remoteAddr", r.RemoteAddr).Error("Rejected a request with an invalid API key")
+				writeError(w, apiVersion, http.StatusUnauthorized, "Invalid API key")
+				return
+			}
+		} else if key != "" {
+			writeError(w, apiVersion, http.StatusUnauthorized, "API key is not enabled")
+			return
+		}
+
+		f.ServeHTTP(w, r)
+	})
+}
+
 func writeError(w http.ResponseWriter, apiVersion string, code int, msg string) {
 	switch apiVersion {
 	case apiVersion1:
@@ -1,13 +1,23 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	phttp "github.com/ness-network/privateness/src/util/http"
+	plogging "github.com/ness-network/privateness/src/util/logging"
 )
 
 func TestOriginRefererCheck(t *testing.T) {
@@ -219,12 +229,12 @@ func TestContentSecurityPolicy(t *testing.T) {
 		enableGUI       bool
 	}{
 		{
-			name:      "enable CSP GET /",
-			endpoint:  "/",
-			enableCSP: true,
-			appLoc:    "../gui/static/dist",
+			name:            "enable CSP GET /",
+			endpoint:        "/",
+			enableCSP:       true,
+			appLoc:          "../gui/static/dist",
 			expectCSPHeader: "default-src 'self'; connect-src 'self' https://api.coinpaprika.com https://swaplab.cc https://version.skycoin.com https://downloads.skycoin.com http://127.0.0.1:9510; img-src 'self' 'unsafe-inline' data:; style-src 'self' 'unsafe-inline'; object-src	'none'; form-action 'none'; frame-ancestors 'none'; block-all-mixed-content; base-uri 'self'",
-			enableGUI: true,
+			enableGUI:       true,
 		},
 		{
 			name:            "disable CSP GET /",
@@ -275,6 +285,119 @@ func TestContentSecurityPolicy(t *testing.T) {
 	}
 }
 
+// requestIDLogHook records the messages of the log entries carrying a request ID
+type requestIDLogHook struct {
+	sync.Mutex
+	messages map[string][]string
+}
+
+func (h *requestIDLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *requestIDLogHook) Fire(e *logrus.Entry) error {
+	id, ok := e.Data["request_id"].(string)
+	if !ok {
+		return nil
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	h.messages[id] = append(h.messages[id], e.Message)
+	return nil
+}
+
+func (h *requestIDLogHook) get(id string) []string {
+	h.Lock()
+	defer h.Unlock()
+	return h.messages[id]
+}
+
+func TestRequestID(t *testing.T) {
+	hook := &requestIDLogHook{
+		messages: make(map[string][]string),
+	}
+	logging.AddHook(hook)
+
+	txn := makeTransaction(t)
+	body, err := json.Marshal(InjectTransactionRequest{
+		RawTxn: txn.MustSerializeHex(),
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{
+			name:      "request ID header",
+			requestID: "d1c4b3a2-request",
+		},
+		{
+			name:      "no request ID header",
+			generated: true,
+		},
+		{
+			name:      "request ID header with invalid characters",
+			requestID: "foo bar",
+			generated: true,
+		},
+		{
+			name:      "request ID header too long",
+			requestID: strings.Repeat("a", 129),
+			generated: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ctxRequestID string
+			gateway := &MockGatewayer{}
+			gateway.On("InjectBroadcastTransaction", mock.MatchedBy(func(ctx context.Context) bool {
+				ctxRequestID, _ = plogging.RequestIDFromContext(ctx)
+				return true
+			}), txn).Return(nil)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/injectTransaction", strings.NewReader(string(body)))
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				req.Header.Set(phttp.RequestIDHeader, tc.requestID)
+			}
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			requestID := rr.Header().Get(phttp.RequestIDHeader)
+			if tc.generated {
+				require.Len(t, requestID, 32)
+				require.NotEqual(t, tc.requestID, requestID)
+			} else {
+				require.Equal(t, tc.requestID, requestID)
+			}
+
+			// The request ID is passed on to the gateway and added to the request's log entries
+			require.Equal(t, requestID, ctxRequestID)
+			require.Equal(t, []string{
+				"200 POST /api/v1/injectTransaction elapsed",
+			}, elapsedMessages(hook.get(requestID)))
+		})
+	}
+}
+
+// elapsedMessages replaces the elapsed time at the end of ElapsedHandler log messages
+func elapsedMessages(msgs []string) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m[:strings.LastIndex(m, " ")+1] + "elapsed"
+	}
+	return out
+}
+
 func TestIsContentTypeJSON(t *testing.T) {
 	require.True(t, isContentTypeJSON(ContentTypeJSON))
 	require.True(t, isContentTypeJSON("application/json"))
@@ -282,3 +405,148 @@ func TestIsContentTypeJSON(t *testing.T) {
 	require.False(t, isContentTypeJSON("application/x-www-form-urlencoded"))
 	require.False(t, isContentTypeJSON(ContentTypeForm))
 }
+
+func TestAPIKeyCheck(t *testing.T) {
+	apiKey := "c0ffee"
+
+	endpoints := []struct {
+		group    string
+		method   string
+		endpoint string
+		open     bool
+	}{
+		{
+			group:    "wallet",
+			method:   http.MethodGet,
+			endpoint: "/api/v1/wallets",
+		},
+		{
+			group:    "wallet",
+			method:   http.MethodPost,
+			endpoint: "/api/v2/wallet/recover",
+		},
+		{
+			group:    "insecure wallet seed",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/wallet/seed",
+		},
+		{
+			group:    "storage",
+			method:   http.MethodGet,
+			endpoint: "/api/v2/data",
+		},
+		{
+			group:    "storage",
+			method:   http.MethodDelete,
+			endpoint: "/api/v2/data",
+		},
+		{
+			group:    "admin",
+			method:   http.MethodPost,
+			endpoint: "/api/v2/storage/compact",
+		},
+		{
+			group:    "publisher",
+			method:   http.MethodGet,
+			endpoint: "/api/v2/publisher",
+		},
+		{
+			group:    "read",
+			method:   http.MethodGet,
+			endpoint: "/api/v1/blockchain/metadata",
+			open:     true,
+		},
+		{
+			group:    "status and admin",
+			method:   http.MethodGet,
+			endpoint: "/api/v2/storage/stats",
+			open:     true,
+		},
+		{
+			group:    "transaction and wallet",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/injectTransaction",
+			open:     true,
+		},
+	}
+
+	cases := []struct {
+		name   string
+		apiKey string
+		reqKey string
+		// authorized is true if a request to an endpoint that requires the API key is authorized
+		authorized bool
+		errMsg     string
+	}{
+		{
+			name:       "no API key configured, no key",
+			authorized: true,
+		},
+		{
+			name:       "no API key configured, key provided",
+			reqKey:     apiKey,
+			authorized: false,
+			errMsg:     "API key is not enabled",
+		},
+		{
+			name:       "missing key",
+			apiKey:     apiKey,
+			authorized: false,
+			errMsg:     "API key required",
+		},
+		{
+			name:       "wrong key",
+			apiKey:     apiKey,
+			reqKey:     apiKey + "0",
+			authorized: false,
+			errMsg:     "Invalid API key",
+		},
+		{
+			name:       "correct key",
+			apiKey:     apiKey,
+			reqKey:     apiKey,
+			authorized: true,
+		},
+	}
+
+	for _, e := range endpoints {
+		for _, tc := range cases {
+			name := fmt.Sprintf("%s %s %s %s", e.group, e.method, e.endpoint, tc.name)
+			t.Run(name, func(t *testing.T) {
+				req, err := http.NewRequest(e.method, e.endpoint, nil)
+				require.NoError(t, err)
+
+				isAPIV2 := strings.HasPrefix(e.endpoint, "/api/v2/")
+				if isAPIV2 {
+					req.Header.Set("Content-Type", ContentTypeJSON)
+				}
+				if tc.reqKey != "" {
+					req.Header.Set(APIKeyHeader, tc.reqKey)
+				}
+
+				// Disable all API sets, so that authorized requests are rejected
+				// before they reach the mock gateway
+				cfg := defaultMuxConfig()
+				cfg.apiKey = tc.apiKey
+				cfg.enabledAPISets = map[string]struct{}{}
+
+				handler := newServerMux(cfg, newWalletMockGatewayer())
+
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+
+				if e.open || tc.authorized {
+					require.Equal(t, http.StatusForbidden, rr.Code)
+					return
+				}
+
+				require.Equal(t, http.StatusUnauthorized, rr.Code)
+				if isAPIV2 {
+					require.Equal(t, fmt.Sprintf("{\n    \"error\": {\n        \"message\": \"%s\",\n        \"code\": 401\n    }\n}", tc.errMsg), rr.Body.String())
+				} else {
+					require.Equal(t, "401 Unauthorized - "+tc.errMsg, strings.TrimSpace(rr.Body.String()))
+				}
+			})
+		}
+	}
+}
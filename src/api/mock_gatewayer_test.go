@@ -3,24 +3,34 @@
 package api
 
 import (
+	context "context"
+
 	cipher "github.com/skycoin/skycoin/src/cipher"
 	coin "github.com/skycoin/skycoin/src/coin"
 
-	daemon "github.com/skycoin/skycoin/src/daemon"
+	daemon "github.com/ness-network/privateness/src/daemon"
+
+	forcerequest "github.com/ness-network/privateness/src/daemon/forcerequest"
+
+	gnet "github.com/ness-network/privateness/src/daemon/gnet"
 
 	historydb "github.com/skycoin/skycoin/src/visor/historydb"
 
-	kvstorage "github.com/skycoin/skycoin/src/kvstorage"
+	kvstorage "github.com/ness-network/privateness/src/kvstorage"
 
 	mock "github.com/stretchr/testify/mock"
 
+	pex "github.com/ness-network/privateness/src/daemon/pex"
+
+	propagation "github.com/ness-network/privateness/src/daemon/propagation"
+
 	time "time"
 
-	transaction "github.com/skycoin/skycoin/src/transaction"
+	transaction "github.com/ness-network/privateness/src/transaction"
 
-	visor "github.com/skycoin/skycoin/src/visor"
+	visor "github.com/ness-network/privateness/src/visor"
 
-	wallet "github.com/skycoin/skycoin/src/wallet"
+	wallet "github.com/ness-network/privateness/src/wallet"
 )
 
 // MockGatewayer is an autogenerated mock type for the Gatewayer type
@@ -28,6 +38,57 @@ type MockGatewayer struct {
 	mock.Mock
 }
 
+// AbandonUnconfirmedTransaction provides a mock function with given fields: txid
+func (_m *MockGatewayer) AbandonUnconfirmedTransaction(txid cipher.SHA256) error {
+	ret := _m.Called(txid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(cipher.SHA256) error); ok {
+		r0 = rf(txid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AbortDBCompaction provides a mock function with given fields:
+func (_m *MockGatewayer) AbortDBCompaction() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddPendingApproval provides a mock function with given fields: wltID, txn, amount, window
+func (_m *MockGatewayer) AddPendingApproval(wltID string, txn *coin.Transaction, amount uint64, window time.Duration) (*kvstorage.PendingApproval, error) {
+	ret := _m.Called(wltID, txn, amount, window)
+
+	var r0 *kvstorage.PendingApproval
+	if rf, ok := ret.Get(0).(func(string, *coin.Transaction, uint64, time.Duration) *kvstorage.PendingApproval); ok {
+		r0 = rf(wltID, txn, amount, window)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*kvstorage.PendingApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *coin.Transaction, uint64, time.Duration) error); ok {
+		r1 = rf(wltID, txn, amount, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddStorageValue provides a mock function with given fields: storageType, key, val
 func (_m *MockGatewayer) AddStorageValue(storageType kvstorage.Type, key string, val string) error {
 	ret := _m.Called(storageType, key, val)
@@ -86,6 +147,103 @@ func (_m *MockGatewayer) AddressesActivity(addrs []cipher.Address) ([]bool, erro
 	return r0, r1
 }
 
+// BlockPublisherStatus provides a mock function with given fields:
+func (_m *MockGatewayer) BlockPublisherStatus() visor.BlockPublisherStatus {
+	ret := _m.Called()
+
+	var r0 visor.BlockPublisherStatus
+	if rf, ok := ret.Get(0).(func() visor.BlockPublisherStatus); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(visor.BlockPublisherStatus)
+	}
+
+	return r0
+}
+
+// CancelWalletRecovery provides a mock function with given fields: jobID
+func (_m *MockGatewayer) CancelWalletRecovery(jobID string) error {
+	ret := _m.Called(jobID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(jobID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckUnspentHash provides a mock function with given fields:
+func (_m *MockGatewayer) CheckUnspentHash() (*visor.UnspentHashReport, error) {
+	ret := _m.Called()
+
+	var r0 *visor.UnspentHashReport
+	if rf, ok := ret.Get(0).(func() *visor.UnspentHashReport); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.UnspentHashReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CommitNewAddresses provides a mock function with given fields: wltID, password, addrs
+func (_m *MockGatewayer) CommitNewAddresses(wltID string, password []byte, addrs []cipher.Address) ([]cipher.Address, error) {
+	ret := _m.Called(wltID, password, addrs)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, []byte, []cipher.Address) []cipher.Address); ok {
+		r0 = rf(wltID, password, addrs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, []cipher.Address) error); ok {
+		r1 = rf(wltID, password, addrs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSnapshot provides a mock function with given fields:
+func (_m *MockGatewayer) CreateSnapshot() (*visor.Snapshot, error) {
+	ret := _m.Called()
+
+	var r0 *visor.Snapshot
+	if rf, ok := ret.Get(0).(func() *visor.Snapshot); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.Snapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateTransaction provides a mock function with given fields: p, wp
 func (_m *MockGatewayer) CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
 	ret := _m.Called(p, wp)
@@ -178,6 +336,20 @@ func (_m *MockGatewayer) DecryptWallet(wltID string, password []byte) (wallet.Wa
 	return r0, r1
 }
 
+// DecryptWalletAddress provides a mock function with given fields: wltID, password, addr, addrPassword
+func (_m *MockGatewayer) DecryptWalletAddress(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error {
+	ret := _m.Called(wltID, password, addr, addrPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, cipher.Address, []byte) error); ok {
+		r0 = rf(wltID, password, addr, addrPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DisconnectByGnetID provides a mock function with given fields: gnetID
 func (_m *MockGatewayer) DisconnectByGnetID(gnetID uint64) error {
 	ret := _m.Called(gnetID)
@@ -215,45 +387,36 @@ func (_m *MockGatewayer) EncryptWallet(wltID string, password []byte) (wallet.Wa
 	return r0, r1
 }
 
-// GetAllStorageValues provides a mock function with given fields: storageType
-func (_m *MockGatewayer) GetAllStorageValues(storageType kvstorage.Type) (map[string]string, error) {
-	ret := _m.Called(storageType)
-
-	var r0 map[string]string
-	if rf, ok := ret.Get(0).(func(kvstorage.Type) map[string]string); ok {
-		r0 = rf(storageType)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string]string)
-		}
-	}
+// EncryptWalletAddress provides a mock function with given fields: wltID, password, addr, addrPassword
+func (_m *MockGatewayer) EncryptWalletAddress(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error {
+	ret := _m.Called(wltID, password, addr, addrPassword)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(kvstorage.Type) error); ok {
-		r1 = rf(storageType)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, cipher.Address, []byte) error); ok {
+		r0 = rf(wltID, password, addr, addrPassword)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetAllUnconfirmedTransactions provides a mock function with given fields:
-func (_m *MockGatewayer) GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error) {
-	ret := _m.Called()
+// ExportPeers provides a mock function with given fields: f, sign
+func (_m *MockGatewayer) ExportPeers(f pex.BundleFilter, sign bool) (*pex.PeerBundle, error) {
+	ret := _m.Called(f, sign)
 
-	var r0 []visor.UnconfirmedTransaction
-	if rf, ok := ret.Get(0).(func() []visor.UnconfirmedTransaction); ok {
-		r0 = rf()
+	var r0 *pex.PeerBundle
+	if rf, ok := ret.Get(0).(func(pex.BundleFilter, bool) *pex.PeerBundle); ok {
+		r0 = rf(f, sign)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]visor.UnconfirmedTransaction)
+			r0 = ret.Get(0).(*pex.PeerBundle)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(pex.BundleFilter, bool) error); ok {
+		r1 = rf(f, sign)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -261,54 +424,45 @@ func (_m *MockGatewayer) GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTra
 	return r0, r1
 }
 
-// GetAllUnconfirmedTransactionsVerbose provides a mock function with given fields:
-func (_m *MockGatewayer) GetAllUnconfirmedTransactionsVerbose() ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error) {
-	ret := _m.Called()
+// ForceRequestBlocks provides a mock function with given fields: req
+func (_m *MockGatewayer) ForceRequestBlocks(req forcerequest.BlocksRequest) ([]string, error) {
+	ret := _m.Called(req)
 
-	var r0 []visor.UnconfirmedTransaction
-	if rf, ok := ret.Get(0).(func() []visor.UnconfirmedTransaction); ok {
-		r0 = rf()
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(forcerequest.BlocksRequest) []string); ok {
+		r0 = rf(req)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]visor.UnconfirmedTransaction)
-		}
-	}
-
-	var r1 [][]visor.TransactionInput
-	if rf, ok := ret.Get(1).(func() [][]visor.TransactionInput); ok {
-		r1 = rf()
-	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).([][]visor.TransactionInput)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func() error); ok {
-		r2 = rf()
+	var r1 error
+	if rf, ok := ret.Get(1).(func(forcerequest.BlocksRequest) error); ok {
+		r1 = rf(req)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// GetBalanceOfAddresses provides a mock function with given fields: addrs
-func (_m *MockGatewayer) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalancePair, error) {
-	ret := _m.Called(addrs)
+// ForceRequestTxns provides a mock function with given fields: req
+func (_m *MockGatewayer) ForceRequestTxns(req forcerequest.TxnsRequest) ([]string, error) {
+	ret := _m.Called(req)
 
-	var r0 []wallet.BalancePair
-	if rf, ok := ret.Get(0).(func([]cipher.Address) []wallet.BalancePair); ok {
-		r0 = rf(addrs)
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(forcerequest.TxnsRequest) []string); ok {
+		r0 = rf(req)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]wallet.BalancePair)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]cipher.Address) error); ok {
-		r1 = rf(addrs)
+	if rf, ok := ret.Get(1).(func(forcerequest.TxnsRequest) error); ok {
+		r1 = rf(req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -316,22 +470,22 @@ func (_m *MockGatewayer) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet
 	return r0, r1
 }
 
-// GetBlockchainMetadata provides a mock function with given fields:
-func (_m *MockGatewayer) GetBlockchainMetadata() (*visor.BlockchainMetadata, error) {
-	ret := _m.Called()
+// GetAddressExplorer provides a mock function with given fields: addr, opts
+func (_m *MockGatewayer) GetAddressExplorer(addr cipher.Address, opts visor.AddressExplorerOptions) (*visor.AddressExplorer, error) {
+	ret := _m.Called(addr, opts)
 
-	var r0 *visor.BlockchainMetadata
-	if rf, ok := ret.Get(0).(func() *visor.BlockchainMetadata); ok {
-		r0 = rf()
+	var r0 *visor.AddressExplorer
+	if rf, ok := ret.Get(0).(func(cipher.Address, visor.AddressExplorerOptions) *visor.AddressExplorer); ok {
+		r0 = rf(addr, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*visor.BlockchainMetadata)
+			r0 = ret.Get(0).(*visor.AddressExplorer)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(cipher.Address, visor.AddressExplorerOptions) error); ok {
+		r1 = rf(addr, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -339,38 +493,22 @@ func (_m *MockGatewayer) GetBlockchainMetadata() (*visor.BlockchainMetadata, err
 	return r0, r1
 }
 
-// GetBlockchainProgress provides a mock function with given fields: headSeq
-func (_m *MockGatewayer) GetBlockchainProgress(headSeq uint64) *daemon.BlockchainProgress {
-	ret := _m.Called(headSeq)
-
-	var r0 *daemon.BlockchainProgress
-	if rf, ok := ret.Get(0).(func(uint64) *daemon.BlockchainProgress); ok {
-		r0 = rf(headSeq)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*daemon.BlockchainProgress)
-		}
-	}
-
-	return r0
-}
-
-// GetBlocks provides a mock function with given fields: seqs
-func (_m *MockGatewayer) GetBlocks(seqs []uint64) ([]coin.SignedBlock, error) {
-	ret := _m.Called(seqs)
+// GetAddressOutputsSummary provides a mock function with given fields: addrs
+func (_m *MockGatewayer) GetAddressOutputsSummary(addrs []cipher.Address) ([]visor.AddressOutputsSummary, error) {
+	ret := _m.Called(addrs)
 
-	var r0 []coin.SignedBlock
-	if rf, ok := ret.Get(0).(func([]uint64) []coin.SignedBlock); ok {
-		r0 = rf(seqs)
+	var r0 []visor.AddressOutputsSummary
+	if rf, ok := ret.Get(0).(func([]cipher.Address) []visor.AddressOutputsSummary); ok {
+		r0 = rf(addrs)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]coin.SignedBlock)
+			r0 = ret.Get(0).([]visor.AddressOutputsSummary)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]uint64) error); ok {
-		r1 = rf(seqs)
+	if rf, ok := ret.Get(1).(func([]cipher.Address) error); ok {
+		r1 = rf(addrs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -378,22 +516,22 @@ func (_m *MockGatewayer) GetBlocks(seqs []uint64) ([]coin.SignedBlock, error) {
 	return r0, r1
 }
 
-// GetBlocksInRange provides a mock function with given fields: start, end
-func (_m *MockGatewayer) GetBlocksInRange(start uint64, end uint64) ([]coin.SignedBlock, error) {
-	ret := _m.Called(start, end)
+// GetAddressTransactionsInBlockRange provides a mock function with given fields: addrs, start, end
+func (_m *MockGatewayer) GetAddressTransactionsInBlockRange(addrs []cipher.Address, start uint64, end uint64) ([]visor.Transaction, error) {
+	ret := _m.Called(addrs, start, end)
 
-	var r0 []coin.SignedBlock
-	if rf, ok := ret.Get(0).(func(uint64, uint64) []coin.SignedBlock); ok {
-		r0 = rf(start, end)
+	var r0 []visor.Transaction
+	if rf, ok := ret.Get(0).(func([]cipher.Address, uint64, uint64) []visor.Transaction); ok {
+		r0 = rf(addrs, start, end)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]coin.SignedBlock)
+			r0 = ret.Get(0).([]visor.Transaction)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint64, uint64) error); ok {
-		r1 = rf(start, end)
+	if rf, ok := ret.Get(1).(func([]cipher.Address, uint64, uint64) error); ok {
+		r1 = rf(addrs, start, end)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -401,31 +539,31 @@ func (_m *MockGatewayer) GetBlocksInRange(start uint64, end uint64) ([]coin.Sign
 	return r0, r1
 }
 
-// GetBlocksInRangeVerbose provides a mock function with given fields: start, end
-func (_m *MockGatewayer) GetBlocksInRangeVerbose(start uint64, end uint64) ([]coin.SignedBlock, [][][]visor.TransactionInput, error) {
-	ret := _m.Called(start, end)
+// GetAddressTransactionsInBlockRangeWithInputs provides a mock function with given fields: addrs, start, end
+func (_m *MockGatewayer) GetAddressTransactionsInBlockRangeWithInputs(addrs []cipher.Address, start uint64, end uint64) ([]visor.Transaction, [][]visor.TransactionInput, error) {
+	ret := _m.Called(addrs, start, end)
 
-	var r0 []coin.SignedBlock
-	if rf, ok := ret.Get(0).(func(uint64, uint64) []coin.SignedBlock); ok {
-		r0 = rf(start, end)
+	var r0 []visor.Transaction
+	if rf, ok := ret.Get(0).(func([]cipher.Address, uint64, uint64) []visor.Transaction); ok {
+		r0 = rf(addrs, start, end)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]coin.SignedBlock)
+			r0 = ret.Get(0).([]visor.Transaction)
 		}
 	}
 
-	var r1 [][][]visor.TransactionInput
-	if rf, ok := ret.Get(1).(func(uint64, uint64) [][][]visor.TransactionInput); ok {
-		r1 = rf(start, end)
+	var r1 [][]visor.TransactionInput
+	if rf, ok := ret.Get(1).(func([]cipher.Address, uint64, uint64) [][]visor.TransactionInput); ok {
+		r1 = rf(addrs, start, end)
 	} else {
 		if ret.Get(1) != nil {
-			r1 = ret.Get(1).([][][]visor.TransactionInput)
+			r1 = ret.Get(1).([][]visor.TransactionInput)
 		}
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(uint64, uint64) error); ok {
-		r2 = rf(start, end)
+	if rf, ok := ret.Get(2).(func([]cipher.Address, uint64, uint64) error); ok {
+		r2 = rf(addrs, start, end)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -433,9 +571,257 @@ func (_m *MockGatewayer) GetBlocksInRangeVerbose(start uint64, end uint64) ([]co
 	return r0, r1, r2
 }
 
-// GetBlocksVerbose provides a mock function with given fields: seqs
-func (_m *MockGatewayer) GetBlocksVerbose(seqs []uint64) ([]coin.SignedBlock, [][][]visor.TransactionInput, error) {
-	ret := _m.Called(seqs)
+// GetAllStorageValues provides a mock function with given fields: storageType
+func (_m *MockGatewayer) GetAllStorageValues(storageType kvstorage.Type) (map[string]string, error) {
+	ret := _m.Called(storageType)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(kvstorage.Type) map[string]string); ok {
+		r0 = rf(storageType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(kvstorage.Type) error); ok {
+		r1 = rf(storageType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllUnconfirmedTransactions provides a mock function with given fields:
+func (_m *MockGatewayer) GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error) {
+	ret := _m.Called()
+
+	var r0 []visor.UnconfirmedTransaction
+	if rf, ok := ret.Get(0).(func() []visor.UnconfirmedTransaction); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.UnconfirmedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllUnconfirmedTransactionsVerbose provides a mock function with given fields:
+func (_m *MockGatewayer) GetAllUnconfirmedTransactionsVerbose() ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error) {
+	ret := _m.Called()
+
+	var r0 []visor.UnconfirmedTransaction
+	if rf, ok := ret.Get(0).(func() []visor.UnconfirmedTransaction); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.UnconfirmedTransaction)
+		}
+	}
+
+	var r1 [][]visor.TransactionInput
+	if rf, ok := ret.Get(1).(func() [][]visor.TransactionInput); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([][]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetAlwaysConnectPeers provides a mock function with given fields:
+func (_m *MockGatewayer) GetAlwaysConnectPeers() []string {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// GetBalanceOfAddresses provides a mock function with given fields: addrs
+func (_m *MockGatewayer) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalancePair, error) {
+	ret := _m.Called(addrs)
+
+	var r0 []wallet.BalancePair
+	if rf, ok := ret.Get(0).(func([]cipher.Address) []wallet.BalancePair); ok {
+		r0 = rf(addrs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]wallet.BalancePair)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]cipher.Address) error); ok {
+		r1 = rf(addrs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBandwidthLimits provides a mock function with given fields:
+func (_m *MockGatewayer) GetBandwidthLimits() gnet.BandwidthLimits {
+	ret := _m.Called()
+
+	var r0 gnet.BandwidthLimits
+	if rf, ok := ret.Get(0).(func() gnet.BandwidthLimits); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(gnet.BandwidthLimits)
+	}
+
+	return r0
+}
+
+// GetBlockchainMetadata provides a mock function with given fields:
+func (_m *MockGatewayer) GetBlockchainMetadata() (*visor.BlockchainMetadata, error) {
+	ret := _m.Called()
+
+	var r0 *visor.BlockchainMetadata
+	if rf, ok := ret.Get(0).(func() *visor.BlockchainMetadata); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.BlockchainMetadata)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlockchainProgress provides a mock function with given fields: headSeq
+func (_m *MockGatewayer) GetBlockchainProgress(headSeq uint64) *daemon.BlockchainProgress {
+	ret := _m.Called(headSeq)
+
+	var r0 *daemon.BlockchainProgress
+	if rf, ok := ret.Get(0).(func(uint64) *daemon.BlockchainProgress); ok {
+		r0 = rf(headSeq)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*daemon.BlockchainProgress)
+		}
+	}
+
+	return r0
+}
+
+// GetBlocks provides a mock function with given fields: seqs
+func (_m *MockGatewayer) GetBlocks(seqs []uint64) ([]coin.SignedBlock, error) {
+	ret := _m.Called(seqs)
+
+	var r0 []coin.SignedBlock
+	if rf, ok := ret.Get(0).(func([]uint64) []coin.SignedBlock); ok {
+		r0 = rf(seqs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]coin.SignedBlock)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]uint64) error); ok {
+		r1 = rf(seqs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlocksInRange provides a mock function with given fields: start, end
+func (_m *MockGatewayer) GetBlocksInRange(start uint64, end uint64) ([]coin.SignedBlock, error) {
+	ret := _m.Called(start, end)
+
+	var r0 []coin.SignedBlock
+	if rf, ok := ret.Get(0).(func(uint64, uint64) []coin.SignedBlock); ok {
+		r0 = rf(start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]coin.SignedBlock)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64, uint64) error); ok {
+		r1 = rf(start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlocksInRangeVerbose provides a mock function with given fields: start, end
+func (_m *MockGatewayer) GetBlocksInRangeVerbose(start uint64, end uint64) ([]coin.SignedBlock, [][][]visor.TransactionInput, error) {
+	ret := _m.Called(start, end)
+
+	var r0 []coin.SignedBlock
+	if rf, ok := ret.Get(0).(func(uint64, uint64) []coin.SignedBlock); ok {
+		r0 = rf(start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]coin.SignedBlock)
+		}
+	}
+
+	var r1 [][][]visor.TransactionInput
+	if rf, ok := ret.Get(1).(func(uint64, uint64) [][][]visor.TransactionInput); ok {
+		r1 = rf(start, end)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([][][]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(uint64, uint64) error); ok {
+		r2 = rf(start, end)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetBlocksVerbose provides a mock function with given fields: seqs
+func (_m *MockGatewayer) GetBlocksVerbose(seqs []uint64) ([]coin.SignedBlock, [][][]visor.TransactionInput, error) {
+	ret := _m.Called(seqs)
 
 	var r0 []coin.SignedBlock
 	if rf, ok := ret.Get(0).(func([]uint64) []coin.SignedBlock); ok {
@@ -598,22 +984,22 @@ func (_m *MockGatewayer) GetLastBlocksVerbose(num uint64) ([]coin.SignedBlock, [
 	return r0, r1, r2
 }
 
-// GetRichlist provides a mock function with given fields: includeDistribution
-func (_m *MockGatewayer) GetRichlist(includeDistribution bool) (visor.Richlist, error) {
-	ret := _m.Called(includeDistribution)
+// GetNotifications provides a mock function with given fields: id, after, limit
+func (_m *MockGatewayer) GetNotifications(id string, after uint64, limit int) (*kvstorage.Notifications, error) {
+	ret := _m.Called(id, after, limit)
 
-	var r0 visor.Richlist
-	if rf, ok := ret.Get(0).(func(bool) visor.Richlist); ok {
-		r0 = rf(includeDistribution)
+	var r0 *kvstorage.Notifications
+	if rf, ok := ret.Get(0).(func(string, uint64, int) *kvstorage.Notifications); ok {
+		r0 = rf(id, after, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(visor.Richlist)
+			r0 = ret.Get(0).(*kvstorage.Notifications)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(bool) error); ok {
-		r1 = rf(includeDistribution)
+	if rf, ok := ret.Get(1).(func(string, uint64, int) error); ok {
+		r1 = rf(id, after, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -621,9 +1007,131 @@ func (_m *MockGatewayer) GetRichlist(includeDistribution bool) (visor.Richlist,
 	return r0, r1
 }
 
-// GetSignedBlockByHash provides a mock function with given fields: hash
-func (_m *MockGatewayer) GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error) {
-	ret := _m.Called(hash)
+// GetPeerBans provides a mock function with given fields:
+func (_m *MockGatewayer) GetPeerBans() pex.BanReport {
+	ret := _m.Called()
+
+	var r0 pex.BanReport
+	if rf, ok := ret.Get(0).(func() pex.BanReport); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(pex.BanReport)
+	}
+
+	return r0
+}
+
+// GetPeers provides a mock function with given fields:
+func (_m *MockGatewayer) GetPeers() pex.Peers {
+	ret := _m.Called()
+
+	var r0 pex.Peers
+	if rf, ok := ret.Get(0).(func() pex.Peers); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(pex.Peers)
+		}
+	}
+
+	return r0
+}
+
+// GetPendingApproval provides a mock function with given fields: wltID, id
+func (_m *MockGatewayer) GetPendingApproval(wltID string, id string) (*kvstorage.PendingApproval, error) {
+	ret := _m.Called(wltID, id)
+
+	var r0 *kvstorage.PendingApproval
+	if rf, ok := ret.Get(0).(func(string, string) *kvstorage.PendingApproval); ok {
+		r0 = rf(wltID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*kvstorage.PendingApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(wltID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingApprovals provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetPendingApprovals(wltID string) ([]kvstorage.PendingApproval, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []kvstorage.PendingApproval
+	if rf, ok := ret.Get(0).(func(string) []kvstorage.PendingApproval); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kvstorage.PendingApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPropagation provides a mock function with given fields: hash
+func (_m *MockGatewayer) GetPropagation(hash cipher.SHA256) (*propagation.Report, bool) {
+	ret := _m.Called(hash)
+
+	var r0 *propagation.Report
+	if rf, ok := ret.Get(0).(func(cipher.SHA256) *propagation.Report); ok {
+		r0 = rf(hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*propagation.Report)
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(cipher.SHA256) bool); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// GetRichlist provides a mock function with given fields: includeDistribution
+func (_m *MockGatewayer) GetRichlist(includeDistribution bool) (visor.Richlist, error) {
+	ret := _m.Called(includeDistribution)
+
+	var r0 visor.Richlist
+	if rf, ok := ret.Get(0).(func(bool) visor.Richlist); ok {
+		r0 = rf(includeDistribution)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(visor.Richlist)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(includeDistribution)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSignedBlockByHash provides a mock function with given fields: hash
+func (_m *MockGatewayer) GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error) {
+	ret := _m.Called(hash)
 
 	var r0 *coin.SignedBlock
 	if rf, ok := ret.Get(0).(func(cipher.SHA256) *coin.SignedBlock); ok {
@@ -775,6 +1283,52 @@ func (_m *MockGatewayer) GetStorageValue(storageType kvstorage.Type, key string)
 	return r0, r1
 }
 
+// GetStuckUnconfirmedTxnHashes provides a mock function with given fields:
+func (_m *MockGatewayer) GetStuckUnconfirmedTxnHashes() ([]cipher.SHA256, error) {
+	ret := _m.Called()
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func() []cipher.SHA256); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptionAddresses provides a mock function with given fields: id
+func (_m *MockGatewayer) GetSubscriptionAddresses(id string) ([]cipher.Address, error) {
+	ret := _m.Called(id)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string) []cipher.Address); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransaction provides a mock function with given fields: txid
 func (_m *MockGatewayer) GetTransaction(txid cipher.SHA256) (*visor.Transaction, error) {
 	ret := _m.Called(txid)
@@ -901,6 +1455,52 @@ func (_m *MockGatewayer) GetTrustConnections() []string {
 	return r0
 }
 
+// GetTxnGraph provides a mock function with given fields: uxid, opts
+func (_m *MockGatewayer) GetTxnGraph(uxid cipher.SHA256, opts visor.TxnGraphOptions) (*visor.TxnGraph, error) {
+	ret := _m.Called(uxid, opts)
+
+	var r0 *visor.TxnGraph
+	if rf, ok := ret.Get(0).(func(cipher.SHA256, visor.TxnGraphOptions) *visor.TxnGraph); ok {
+		r0 = rf(uxid, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.TxnGraph)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(cipher.SHA256, visor.TxnGraphOptions) error); ok {
+		r1 = rf(uxid, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUnconfirmedTxnAnnounceCounts provides a mock function with given fields:
+func (_m *MockGatewayer) GetUnconfirmedTxnAnnounceCounts() (map[cipher.SHA256]uint64, error) {
+	ret := _m.Called()
+
+	var r0 map[cipher.SHA256]uint64
+	if rf, ok := ret.Get(0).(func() map[cipher.SHA256]uint64); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.SHA256]uint64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUnspentOutputsSummary provides a mock function with given fields: filters
 func (_m *MockGatewayer) GetUnspentOutputsSummary(filters []visor.OutputsFilter) (*visor.UnspentOutputsSummary, error) {
 	ret := _m.Called(filters)
@@ -924,6 +1524,29 @@ func (_m *MockGatewayer) GetUnspentOutputsSummary(filters []visor.OutputsFilter)
 	return r0, r1
 }
 
+// GetUnconfirmedConflicts provides a mock function with given fields:
+func (_m *MockGatewayer) GetUnconfirmedConflicts() (map[cipher.SHA256][]cipher.SHA256, error) {
+	ret := _m.Called()
+
+	var r0 map[cipher.SHA256][]cipher.SHA256
+	if rf, ok := ret.Get(0).(func() map[cipher.SHA256][]cipher.SHA256); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.SHA256][]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUxOutByID provides a mock function with given fields: id
 func (_m *MockGatewayer) GetUxOutByID(id cipher.SHA256) (*historydb.UxOut, error) {
 	ret := _m.Called(id)
@@ -1002,6 +1625,52 @@ func (_m *MockGatewayer) GetWallet(wltID string) (wallet.Wallet, error) {
 	return r0, r1
 }
 
+// GetWalletAccountXPub provides a mock function with given fields: wltID, account
+func (_m *MockGatewayer) GetWalletAccountXPub(wltID string, account uint32) (*wallet.AccountXPub, error) {
+	ret := _m.Called(wltID, account)
+
+	var r0 *wallet.AccountXPub
+	if rf, ok := ret.Get(0).(func(string, uint32) *wallet.AccountXPub); ok {
+		r0 = rf(wltID, account)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.AccountXPub)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, uint32) error); ok {
+		r1 = rf(wltID, account)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletAddressesByLabels provides a mock function with given fields: wltID, labels
+func (_m *MockGatewayer) GetWalletAddressesByLabels(wltID string, labels []string) ([]cipher.Address, error) {
+	ret := _m.Called(wltID, labels)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, []string) []cipher.Address); ok {
+		r0 = rf(wltID, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(wltID, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWalletBalance provides a mock function with given fields: wltID
 func (_m *MockGatewayer) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error) {
 	ret := _m.Called(wltID)
@@ -1032,6 +1701,52 @@ func (_m *MockGatewayer) GetWalletBalance(wltID string) (wallet.BalancePair, wal
 	return r0, r1, r2
 }
 
+// GetWalletBalanceAtHead provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetWalletBalanceAtHead(wltID string) (*visor.WalletBalance, error) {
+	ret := _m.Called(wltID)
+
+	var r0 *visor.WalletBalance
+	if rf, ok := ret.Get(0).(func(string) *visor.WalletBalance); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.WalletBalance)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletBalanceHistory provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetWalletBalanceHistory(wltID string) (*visor.BalanceHistory, error) {
+	ret := _m.Called(wltID)
+
+	var r0 *visor.BalanceHistory
+	if rf, ok := ret.Get(0).(func(string) *visor.BalanceHistory); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.BalanceHistory)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWalletSeed provides a mock function with given fields: wltID, password
 func (_m *MockGatewayer) GetWalletSeed(wltID string, password []byte) (string, string, error) {
 	ret := _m.Called(wltID, password)
@@ -1060,11 +1775,55 @@ func (_m *MockGatewayer) GetWalletSeed(wltID string, password []byte) (string, s
 	return r0, r1, r2
 }
 
-// GetWalletUnconfirmedTransactions provides a mock function with given fields: wltID
-func (_m *MockGatewayer) GetWalletUnconfirmedTransactions(wltID string) ([]visor.UnconfirmedTransaction, error) {
-	ret := _m.Called(wltID)
+// GetWalletTxNote provides a mock function with given fields: wltID, txid
+func (_m *MockGatewayer) GetWalletTxNote(wltID string, txid cipher.SHA256) (string, error) {
+	ret := _m.Called(wltID, txid)
 
-	var r0 []visor.UnconfirmedTransaction
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, cipher.SHA256) string); ok {
+		r0 = rf(wltID, txid)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, cipher.SHA256) error); ok {
+		r1 = rf(wltID, txid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletTxNotes provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetWalletTxNotes(wltID string) (map[cipher.SHA256]string, error) {
+	ret := _m.Called(wltID)
+
+	var r0 map[cipher.SHA256]string
+	if rf, ok := ret.Get(0).(func(string) map[cipher.SHA256]string); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.SHA256]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletUnconfirmedTransactions provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetWalletUnconfirmedTransactions(wltID string) ([]visor.UnconfirmedTransaction, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []visor.UnconfirmedTransaction
 	if rf, ok := ret.Get(0).(func(string) []visor.UnconfirmedTransaction); ok {
 		r0 = rf(wltID)
 	} else {
@@ -1166,27 +1925,523 @@ func (_m *MockGatewayer) HeadBkSeq() (uint64, bool, error) {
 	return r0, r1, r2
 }
 
-// InjectBroadcastTransaction provides a mock function with given fields: txn
-func (_m *MockGatewayer) InjectBroadcastTransaction(txn coin.Transaction) error {
+// ImportPeers provides a mock function with given fields: b
+func (_m *MockGatewayer) ImportPeers(b pex.PeerBundle) (*pex.ImportResult, error) {
+	ret := _m.Called(b)
+
+	var r0 *pex.ImportResult
+	if rf, ok := ret.Get(0).(func(pex.PeerBundle) *pex.ImportResult); ok {
+		r0 = rf(b)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pex.ImportResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(pex.PeerBundle) error); ok {
+		r1 = rf(b)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InjectBroadcastTransaction provides a mock function with given fields: ctx, txn
+func (_m *MockGatewayer) InjectBroadcastTransaction(ctx context.Context, txn coin.Transaction) error {
+	ret := _m.Called(ctx, txn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, coin.Transaction) error); ok {
+		r0 = rf(ctx, txn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InjectTransaction provides a mock function with given fields: txn
+func (_m *MockGatewayer) InjectTransaction(txn coin.Transaction) error {
 	ret := _m.Called(txn)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(coin.Transaction) error); ok {
-		r0 = rf(txn)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(coin.Transaction) error); ok {
+		r0 = rf(txn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LastUnspentHashReport provides a mock function with given fields:
+func (_m *MockGatewayer) LastUnspentHashReport() *visor.UnspentHashReport {
+	ret := _m.Called()
+
+	var r0 *visor.UnspentHashReport
+	if rf, ok := ret.Get(0).(func() *visor.UnspentHashReport); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.UnspentHashReport)
+		}
+	}
+
+	return r0
+}
+
+// ListWalletBackups provides a mock function with given fields: wltID
+func (_m *MockGatewayer) ListWalletBackups(wltID string) ([]wallet.Backup, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []wallet.Backup
+	if rf, ok := ret.Get(0).(func(string) []wallet.Backup); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]wallet.Backup)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWallets provides a mock function with given fields:
+func (_m *MockGatewayer) ListWallets() ([]wallet.WalletHeader, error) {
+	ret := _m.Called()
+
+	var r0 []wallet.WalletHeader
+	if rf, ok := ret.Get(0).(func() []wallet.WalletHeader); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]wallet.WalletHeader)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockWalletMetadata provides a mock function with given fields: wltID
+func (_m *MockGatewayer) LockWalletMetadata(wltID string) error {
+	ret := _m.Called(wltID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(wltID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MigrateWalletFormat provides a mock function with given fields: wltID, password
+func (_m *MockGatewayer) MigrateWalletFormat(wltID string, password []byte) (*wallet.WalletFormatMigrationResult, error) {
+	ret := _m.Called(wltID, password)
+
+	var r0 *wallet.WalletFormatMigrationResult
+	if rf, ok := ret.Get(0).(func(string, []byte) *wallet.WalletFormatMigrationResult); ok {
+		r0 = rf(wltID, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.WalletFormatMigrationResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte) error); ok {
+		r1 = rf(wltID, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAddresses provides a mock function with given fields: wltID, password, n
+func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error) {
+	ret := _m.Called(wltID, password, n)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, []byte, uint64) []cipher.Address); ok {
+		r0 = rf(wltID, password, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, uint64) error); ok {
+		r1 = rf(wltID, password, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PreviewBlock provides a mock function with given fields:
+func (_m *MockGatewayer) PreviewBlock() (*visor.BlockPreview, error) {
+	ret := _m.Called()
+
+	var r0 *visor.BlockPreview
+	if rf, ok := ret.Get(0).(func() *visor.BlockPreview); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.BlockPreview)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PreviewNewAddresses provides a mock function with given fields: wltID, password, n
+func (_m *MockGatewayer) PreviewNewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error) {
+	ret := _m.Called(wltID, password, n)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, []byte, uint64) []cipher.Address); ok {
+		r0 = rf(wltID, password, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, uint64) error); ok {
+		r1 = rf(wltID, password, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemovePendingApproval provides a mock function with given fields: wltID, id
+func (_m *MockGatewayer) RemovePendingApproval(wltID string, id string) (*kvstorage.PendingApproval, error) {
+	ret := _m.Called(wltID, id)
+
+	var r0 *kvstorage.PendingApproval
+	if rf, ok := ret.Get(0).(func(string, string) *kvstorage.PendingApproval); ok {
+		r0 = rf(wltID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*kvstorage.PendingApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(wltID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveStorageValue provides a mock function with given fields: storageType, key
+func (_m *MockGatewayer) RemoveStorageValue(storageType kvstorage.Type, key string) error {
+	ret := _m.Called(storageType, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(kvstorage.Type, string) error); ok {
+		r0 = rf(storageType, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveWalletAccessToken provides a mock function with given fields: wltID, password
+func (_m *MockGatewayer) RemoveWalletAccessToken(wltID string, password []byte) error {
+	ret := _m.Called(wltID, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(wltID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveWalletApprovalPolicy provides a mock function with given fields: wltID, password, approverPassword
+func (_m *MockGatewayer) RemoveWalletApprovalPolicy(wltID string, password []byte, approverPassword []byte) error {
+	ret := _m.Called(wltID, password, approverPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, []byte) error); ok {
+		r0 = rf(wltID, password, approverPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveWalletTxNote provides a mock function with given fields: wltID, txid
+func (_m *MockGatewayer) RemoveWalletTxNote(wltID string, txid cipher.SHA256) error {
+	ret := _m.Called(wltID, txid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, cipher.SHA256) error); ok {
+		r0 = rf(wltID, txid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendUnconfirmedTxns provides a mock function with given fields:
+func (_m *MockGatewayer) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
+	ret := _m.Called()
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func() []cipher.SHA256); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RotateWalletAccessToken provides a mock function with given fields: wltID
+func (_m *MockGatewayer) RotateWalletAccessToken(wltID string) (string, error) {
+	ret := _m.Called(wltID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(wltID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetBandwidthLimits provides a mock function with given fields: l
+func (_m *MockGatewayer) SetBandwidthLimits(l gnet.BandwidthLimits) {
+	_m.Called(l)
+}
+
+// SetWalletAccessToken provides a mock function with given fields: wltID, password
+func (_m *MockGatewayer) SetWalletAccessToken(wltID string, password []byte) (string, error) {
+	ret := _m.Called(wltID, password)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, []byte) string); ok {
+		r0 = rf(wltID, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte) error); ok {
+		r1 = rf(wltID, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetWalletApprovalPolicy provides a mock function with given fields: wltID, password, approverPassword, currentApproverPassword, threshold, window
+func (_m *MockGatewayer) SetWalletApprovalPolicy(wltID string, password []byte, approverPassword []byte, currentApproverPassword []byte, threshold uint64, window time.Duration) error {
+	ret := _m.Called(wltID, password, approverPassword, currentApproverPassword, threshold, window)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, []byte, []byte, uint64, time.Duration) error); ok {
+		r0 = rf(wltID, password, approverPassword, currentApproverPassword, threshold, window)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetWalletReuseChange provides a mock function with given fields: wltID, reuse
+func (_m *MockGatewayer) SetWalletReuseChange(wltID string, reuse bool) error {
+	ret := _m.Called(wltID, reuse)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, bool) error); ok {
+		r0 = rf(wltID, reuse)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetWalletStrictPrivacy provides a mock function with given fields: wltID, strict
+func (_m *MockGatewayer) SetWalletStrictPrivacy(wltID string, strict bool) error {
+	ret := _m.Called(wltID, strict)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, bool) error); ok {
+		r0 = rf(wltID, strict)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetWalletTxNote provides a mock function with given fields: wltID, txid, note
+func (_m *MockGatewayer) SetWalletTxNote(wltID string, txid cipher.SHA256, note string) error {
+	ret := _m.Called(wltID, txid, note)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, cipher.SHA256, string) error); ok {
+		r0 = rf(wltID, txid, note)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SignTransactionWithAddressPasswords provides a mock function with given fields: wltID, password, addrPasswords, txn, signIndexes, uxOuts
+func (_m *MockGatewayer) SignTransactionWithAddressPasswords(wltID string, password []byte, addrPasswords map[cipher.Address][]byte, txn *coin.Transaction, signIndexes []int, uxOuts []coin.UxOut) (*coin.Transaction, error) {
+	ret := _m.Called(wltID, password, addrPasswords, txn, signIndexes, uxOuts)
+
+	var r0 *coin.Transaction
+	if rf, ok := ret.Get(0).(func(string, []byte, map[cipher.Address][]byte, *coin.Transaction, []int, []coin.UxOut) *coin.Transaction); ok {
+		r0 = rf(wltID, password, addrPasswords, txn, signIndexes, uxOuts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.Transaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, map[cipher.Address][]byte, *coin.Transaction, []int, []coin.UxOut) error); ok {
+		r1 = rf(wltID, password, addrPasswords, txn, signIndexes, uxOuts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartDBCompaction provides a mock function with given fields:
+func (_m *MockGatewayer) StartDBCompaction() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartPublishing provides a mock function with given fields: seckey
+func (_m *MockGatewayer) StartPublishing(seckey cipher.SecKey) error {
+	ret := _m.Called(seckey)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(cipher.SecKey) error); ok {
+		r0 = rf(seckey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartWalletRecovery provides a mock function with given fields: opts, tf
+func (_m *MockGatewayer) StartWalletRecovery(opts wallet.RecoveryOptions, tf wallet.TransactionsFinder) (string, error) {
+	ret := _m.Called(opts, tf)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(wallet.RecoveryOptions, wallet.TransactionsFinder) string); ok {
+		r0 = rf(opts, tf)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(wallet.RecoveryOptions, wallet.TransactionsFinder) error); ok {
+		r1 = rf(opts, tf)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartedAt provides a mock function with given fields:
+func (_m *MockGatewayer) StartedAt() time.Time {
+	ret := _m.Called()
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func() time.Time); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(time.Time)
 	}
 
 	return r0
 }
 
-// InjectTransaction provides a mock function with given fields: txn
-func (_m *MockGatewayer) InjectTransaction(txn coin.Transaction) error {
-	ret := _m.Called(txn)
+// StopPublishing provides a mock function with given fields:
+func (_m *MockGatewayer) StopPublishing() error {
+	ret := _m.Called()
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(coin.Transaction) error); ok {
-		r0 = rf(txn)
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1194,22 +2449,22 @@ func (_m *MockGatewayer) InjectTransaction(txn coin.Transaction) error {
 	return r0
 }
 
-// NewAddresses provides a mock function with given fields: wltID, password, n
-func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error) {
-	ret := _m.Called(wltID, password, n)
+// StorageStats provides a mock function with given fields:
+func (_m *MockGatewayer) StorageStats() (*visor.StorageStats, error) {
+	ret := _m.Called()
 
-	var r0 []cipher.Address
-	if rf, ok := ret.Get(0).(func(string, []byte, uint64) []cipher.Address); ok {
-		r0 = rf(wltID, password, n)
+	var r0 *visor.StorageStats
+	if rf, ok := ret.Get(0).(func() *visor.StorageStats); ok {
+		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]cipher.Address)
+			r0 = ret.Get(0).(*visor.StorageStats)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, []byte, uint64) error); ok {
-		r1 = rf(wltID, password, n)
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1217,22 +2472,20 @@ func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, n uint64) (
 	return r0, r1
 }
 
-// RecoverWallet provides a mock function with given fields: wltID, seed, seedPassphrase, password
-func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, seedPassphrase string, password []byte) (wallet.Wallet, error) {
-	ret := _m.Called(wltID, seed, seedPassphrase, password)
+// Subscribe provides a mock function with given fields: addrs
+func (_m *MockGatewayer) Subscribe(addrs []cipher.Address) (string, error) {
+	ret := _m.Called(addrs)
 
-	var r0 wallet.Wallet
-	if rf, ok := ret.Get(0).(func(string, string, string, []byte) wallet.Wallet); ok {
-		r0 = rf(wltID, seed, seedPassphrase, password)
+	var r0 string
+	if rf, ok := ret.Get(0).(func([]cipher.Address) string); ok {
+		r0 = rf(addrs)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(wallet.Wallet)
-		}
+		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, string, []byte) error); ok {
-		r1 = rf(wltID, seed, seedPassphrase, password)
+	if rf, ok := ret.Get(1).(func([]cipher.Address) error); ok {
+		r1 = rf(addrs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1240,36 +2493,38 @@ func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, seedPassphrase
 	return r0, r1
 }
 
-// RemoveStorageValue provides a mock function with given fields: storageType, key
-func (_m *MockGatewayer) RemoveStorageValue(storageType kvstorage.Type, key string) error {
-	ret := _m.Called(storageType, key)
+// SubscribeBlocks provides a mock function with given fields: bufferSize
+func (_m *MockGatewayer) SubscribeBlocks(bufferSize int) *visor.BlockSubscription {
+	ret := _m.Called(bufferSize)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(kvstorage.Type, string) error); ok {
-		r0 = rf(storageType, key)
+	var r0 *visor.BlockSubscription
+	if rf, ok := ret.Get(0).(func(int) *visor.BlockSubscription); ok {
+		r0 = rf(bufferSize)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.BlockSubscription)
+		}
 	}
 
 	return r0
 }
 
-// ResendUnconfirmedTxns provides a mock function with given fields:
-func (_m *MockGatewayer) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
-	ret := _m.Called()
+// TestAcceptTransactions provides a mock function with given fields: txns
+func (_m *MockGatewayer) TestAcceptTransactions(txns []coin.Transaction) ([]visor.TxnAcceptResult, error) {
+	ret := _m.Called(txns)
 
-	var r0 []cipher.SHA256
-	if rf, ok := ret.Get(0).(func() []cipher.SHA256); ok {
-		r0 = rf()
+	var r0 []visor.TxnAcceptResult
+	if rf, ok := ret.Get(0).(func([]coin.Transaction) []visor.TxnAcceptResult); ok {
+		r0 = rf(txns)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]cipher.SHA256)
+			r0 = ret.Get(0).([]visor.TxnAcceptResult)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func([]coin.Transaction) error); ok {
+		r1 = rf(txns)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1277,15 +2532,15 @@ func (_m *MockGatewayer) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
 	return r0, r1
 }
 
-// StartedAt provides a mock function with given fields:
-func (_m *MockGatewayer) StartedAt() time.Time {
-	ret := _m.Called()
+// UnbanPeer provides a mock function with given fields: ip
+func (_m *MockGatewayer) UnbanPeer(ip string) error {
+	ret := _m.Called(ip)
 
-	var r0 time.Time
-	if rf, ok := ret.Get(0).(func() time.Time); ok {
-		r0 = rf()
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(ip)
 	} else {
-		r0 = ret.Get(0).(time.Time)
+		r0 = ret.Error(0)
 	}
 
 	return r0
@@ -1305,6 +2560,48 @@ func (_m *MockGatewayer) UnloadWallet(wltID string) error {
 	return r0
 }
 
+// UnlockWalletMetadata provides a mock function with given fields: wltID, password
+func (_m *MockGatewayer) UnlockWalletMetadata(wltID string, password []byte) error {
+	ret := _m.Called(wltID, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(wltID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unsubscribe provides a mock function with given fields: id
+func (_m *MockGatewayer) Unsubscribe(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAddressLabel provides a mock function with given fields: wltID, addr, label
+func (_m *MockGatewayer) UpdateAddressLabel(wltID string, addr cipher.Address, label string) error {
+	ret := _m.Called(wltID, addr, label)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, cipher.Address, string) error); ok {
+		r0 = rf(wltID, addr, label)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateWalletLabel provides a mock function with given fields: wltID, label
 func (_m *MockGatewayer) UpdateWalletLabel(wltID string, label string) error {
 	ret := _m.Called(wltID, label)
@@ -1349,6 +2646,34 @@ func (_m *MockGatewayer) VerifyTxnVerbose(txn *coin.Transaction, signed visor.Tx
 	return r0, r1, r2
 }
 
+// VerifyWalletAccessToken provides a mock function with given fields: wltID, token
+func (_m *MockGatewayer) VerifyWalletAccessToken(wltID string, token string) error {
+	ret := _m.Called(wltID, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(wltID, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyWalletApproverPassword provides a mock function with given fields: wltID, approverPassword
+func (_m *MockGatewayer) VerifyWalletApproverPassword(wltID string, approverPassword []byte) error {
+	ret := _m.Called(wltID, approverPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(wltID, approverPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // VisorConfig provides a mock function with given fields:
 func (_m *MockGatewayer) VisorConfig() visor.Config {
 	ret := _m.Called()
@@ -1363,13 +2688,105 @@ func (_m *MockGatewayer) VisorConfig() visor.Config {
 	return r0
 }
 
-// WalletCreateTransaction provides a mock function with given fields: wltID, p, wp
-func (_m *MockGatewayer) WalletCreateTransaction(wltID string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
-	ret := _m.Called(wltID, p, wp)
+// WaitHeadSeqAfter provides a mock function with given fields: ctx, seq
+func (_m *MockGatewayer) WaitHeadSeqAfter(ctx context.Context, seq uint64) bool {
+	ret := _m.Called(ctx, seq)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) bool); ok {
+		r0 = rf(ctx, seq)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// WalletAddressReuse provides a mock function with given fields: wltID, txn, receivers
+func (_m *MockGatewayer) WalletAddressReuse(wltID string, txn *coin.Transaction, receivers int) ([]wallet.AddressReuse, error) {
+	ret := _m.Called(wltID, txn, receivers)
+
+	var r0 []wallet.AddressReuse
+	if rf, ok := ret.Get(0).(func(string, *coin.Transaction, int) []wallet.AddressReuse); ok {
+		r0 = rf(wltID, txn, receivers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]wallet.AddressReuse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *coin.Transaction, int) error); ok {
+		r1 = rf(wltID, txn, receivers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WalletApprovalPolicy provides a mock function with given fields: wltID
+func (_m *MockGatewayer) WalletApprovalPolicy(wltID string) (*wallet.ApprovalPolicy, error) {
+	ret := _m.Called(wltID)
+
+	var r0 *wallet.ApprovalPolicy
+	if rf, ok := ret.Get(0).(func(string) *wallet.ApprovalPolicy); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.ApprovalPolicy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WalletBumpTransaction provides a mock function with given fields: wltID, txn, targetFee, changeAddress
+func (_m *MockGatewayer) WalletBumpTransaction(wltID string, txn *coin.Transaction, targetFee uint64, changeAddress *cipher.Address) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(wltID, txn, targetFee, changeAddress)
+
+	var r0 *coin.Transaction
+	if rf, ok := ret.Get(0).(func(string, *coin.Transaction, uint64, *cipher.Address) *coin.Transaction); ok {
+		r0 = rf(wltID, txn, targetFee, changeAddress)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.Transaction)
+		}
+	}
+
+	var r1 []visor.TransactionInput
+	if rf, ok := ret.Get(1).(func(string, *coin.Transaction, uint64, *cipher.Address) []visor.TransactionInput); ok {
+		r1 = rf(wltID, txn, targetFee, changeAddress)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, *coin.Transaction, uint64, *cipher.Address) error); ok {
+		r2 = rf(wltID, txn, targetFee, changeAddress)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// WalletCreateTransaction provides a mock function with given fields: ctx, wltID, p, wp
+func (_m *MockGatewayer) WalletCreateTransaction(ctx context.Context, wltID string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(ctx, wltID, p, wp)
 
 	var r0 *coin.Transaction
-	if rf, ok := ret.Get(0).(func(string, transaction.Params, visor.CreateTransactionParams) *coin.Transaction); ok {
-		r0 = rf(wltID, p, wp)
+	if rf, ok := ret.Get(0).(func(context.Context, string, transaction.Params, visor.CreateTransactionParams) *coin.Transaction); ok {
+		r0 = rf(ctx, wltID, p, wp)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*coin.Transaction)
@@ -1377,8 +2794,8 @@ func (_m *MockGatewayer) WalletCreateTransaction(wltID string, p transaction.Par
 	}
 
 	var r1 []visor.TransactionInput
-	if rf, ok := ret.Get(1).(func(string, transaction.Params, visor.CreateTransactionParams) []visor.TransactionInput); ok {
-		r1 = rf(wltID, p, wp)
+	if rf, ok := ret.Get(1).(func(context.Context, string, transaction.Params, visor.CreateTransactionParams) []visor.TransactionInput); ok {
+		r1 = rf(ctx, wltID, p, wp)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).([]visor.TransactionInput)
@@ -1386,8 +2803,8 @@ func (_m *MockGatewayer) WalletCreateTransaction(wltID string, p transaction.Par
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string, transaction.Params, visor.CreateTransactionParams) error); ok {
-		r2 = rf(wltID, p, wp)
+	if rf, ok := ret.Get(2).(func(context.Context, string, transaction.Params, visor.CreateTransactionParams) error); ok {
+		r2 = rf(ctx, wltID, p, wp)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -1395,13 +2812,13 @@ func (_m *MockGatewayer) WalletCreateTransaction(wltID string, p transaction.Par
 	return r0, r1, r2
 }
 
-// WalletCreateTransactionSigned provides a mock function with given fields: wltID, password, p, wp
-func (_m *MockGatewayer) WalletCreateTransactionSigned(wltID string, password []byte, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
-	ret := _m.Called(wltID, password, p, wp)
+// WalletCreateTransactionSigned provides a mock function with given fields: ctx, wltID, password, p, wp
+func (_m *MockGatewayer) WalletCreateTransactionSigned(ctx context.Context, wltID string, password []byte, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(ctx, wltID, password, p, wp)
 
 	var r0 *coin.Transaction
-	if rf, ok := ret.Get(0).(func(string, []byte, transaction.Params, visor.CreateTransactionParams) *coin.Transaction); ok {
-		r0 = rf(wltID, password, p, wp)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, transaction.Params, visor.CreateTransactionParams) *coin.Transaction); ok {
+		r0 = rf(ctx, wltID, password, p, wp)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*coin.Transaction)
@@ -1409,8 +2826,8 @@ func (_m *MockGatewayer) WalletCreateTransactionSigned(wltID string, password []
 	}
 
 	var r1 []visor.TransactionInput
-	if rf, ok := ret.Get(1).(func(string, []byte, transaction.Params, visor.CreateTransactionParams) []visor.TransactionInput); ok {
-		r1 = rf(wltID, password, p, wp)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, transaction.Params, visor.CreateTransactionParams) []visor.TransactionInput); ok {
+		r1 = rf(ctx, wltID, password, p, wp)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).([]visor.TransactionInput)
@@ -1418,8 +2835,8 @@ func (_m *MockGatewayer) WalletCreateTransactionSigned(wltID string, password []
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string, []byte, transaction.Params, visor.CreateTransactionParams) error); ok {
-		r2 = rf(wltID, password, p, wp)
+	if rf, ok := ret.Get(2).(func(context.Context, string, []byte, transaction.Params, visor.CreateTransactionParams) error); ok {
+		r2 = rf(ctx, wltID, password, p, wp)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -1448,13 +2865,82 @@ func (_m *MockGatewayer) WalletDir() (string, error) {
 	return r0, r1
 }
 
-// WalletSignTransaction provides a mock function with given fields: wltID, password, txn, signIndexes
-func (_m *MockGatewayer) WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error) {
-	ret := _m.Called(wltID, password, txn, signIndexes)
+// WalletFormatStatus provides a mock function with given fields:
+func (_m *MockGatewayer) WalletFormatStatus() ([]wallet.WalletFormatStatus, error) {
+	ret := _m.Called()
+
+	var r0 []wallet.WalletFormatStatus
+	if rf, ok := ret.Get(0).(func() []wallet.WalletFormatStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]wallet.WalletFormatStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WalletMigrate provides a mock function with given fields: wltID, password, opts
+func (_m *MockGatewayer) WalletMigrate(wltID string, password []byte, opts visor.WalletMigrateOptions) (*visor.WalletMigratePlan, error) {
+	ret := _m.Called(wltID, password, opts)
+
+	var r0 *visor.WalletMigratePlan
+	if rf, ok := ret.Get(0).(func(string, []byte, visor.WalletMigrateOptions) *visor.WalletMigratePlan); ok {
+		r0 = rf(wltID, password, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.WalletMigratePlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, visor.WalletMigrateOptions) error); ok {
+		r1 = rf(wltID, password, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WalletRecoveryStatus provides a mock function with given fields: jobID
+func (_m *MockGatewayer) WalletRecoveryStatus(jobID string) (*wallet.RecoveryStatus, error) {
+	ret := _m.Called(jobID)
+
+	var r0 *wallet.RecoveryStatus
+	if rf, ok := ret.Get(0).(func(string) *wallet.RecoveryStatus); ok {
+		r0 = rf(jobID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.RecoveryStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WalletSignTransaction provides a mock function with given fields: ctx, wltID, password, txn, signIndexes
+func (_m *MockGatewayer) WalletSignTransaction(ctx context.Context, wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(ctx, wltID, password, txn, signIndexes)
 
 	var r0 *coin.Transaction
-	if rf, ok := ret.Get(0).(func(string, []byte, *coin.Transaction, []int) *coin.Transaction); ok {
-		r0 = rf(wltID, password, txn, signIndexes)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, *coin.Transaction, []int) *coin.Transaction); ok {
+		r0 = rf(ctx, wltID, password, txn, signIndexes)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*coin.Transaction)
@@ -1462,8 +2948,8 @@ func (_m *MockGatewayer) WalletSignTransaction(wltID string, password []byte, tx
 	}
 
 	var r1 []visor.TransactionInput
-	if rf, ok := ret.Get(1).(func(string, []byte, *coin.Transaction, []int) []visor.TransactionInput); ok {
-		r1 = rf(wltID, password, txn, signIndexes)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, *coin.Transaction, []int) []visor.TransactionInput); ok {
+		r1 = rf(ctx, wltID, password, txn, signIndexes)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).([]visor.TransactionInput)
@@ -1471,11 +2957,55 @@ func (_m *MockGatewayer) WalletSignTransaction(wltID string, password []byte, tx
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string, []byte, *coin.Transaction, []int) error); ok {
-		r2 = rf(wltID, password, txn, signIndexes)
+	if rf, ok := ret.Get(2).(func(context.Context, string, []byte, *coin.Transaction, []int) error); ok {
+		r2 = rf(ctx, wltID, password, txn, signIndexes)
 	} else {
 		r2 = ret.Error(2)
 	}
 
 	return r0, r1, r2
 }
+
+// WalletStrictPrivacy provides a mock function with given fields: wltID
+func (_m *MockGatewayer) WalletStrictPrivacy(wltID string) (bool, error) {
+	ret := _m.Called(wltID)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(wltID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WalletSweep provides a mock function with given fields: wltID, password, keys
+func (_m *MockGatewayer) WalletSweep(wltID string, password []byte, keys []cipher.SecKey) (*visor.SweepResult, error) {
+	ret := _m.Called(wltID, password, keys)
+
+	var r0 *visor.SweepResult
+	if rf, ok := ret.Get(0).(func(string, []byte, []cipher.SecKey) *visor.SweepResult); ok {
+		r0 = rf(wltID, password, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.SweepResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, []cipher.SecKey) error); ok {
+		r1 = rf(wltID, password, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -3,21 +3,32 @@ package api
 // APIs for network-related information
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/cipher"
 	wh "github.com/skycoin/skycoin/src/util/http"
+	"github.com/skycoin/skycoin/src/util/iputil"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/daemon/forcerequest"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/daemon/propagation"
+	"github.com/ness-network/privateness/src/readable"
 )
 
 // connectionHandler returns a specific connection
 // URI: /api/v1/network/connections
 // Method: GET
 // Args:
+//
 //	addr - An IP:Port string
 func connectionHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -43,20 +54,53 @@ func connectionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wh.SendJSONOr500(logger, w, readable.NewConnection(c))
+		wh.SendJSONOr500(logger, w, NewConnection(c, gateway.GetAlwaysConnectPeers()))
 	}
 }
 
+// Connection a connection's state within the daemon
+type Connection struct {
+	readable.Connection
+	IsAlwaysConnect bool `json:"is_always_connect"`
+}
+
+// NewConnection copies daemon.Connection to a struct with json tags.
+// The connection is flagged if it is a connection to one of the alwaysConnect peers.
+func NewConnection(c *daemon.Connection, alwaysConnect []string) Connection {
+	return Connection{
+		Connection:      readable.NewConnection(c),
+		IsAlwaysConnect: isAlwaysConnect(c, alwaysConnect),
+	}
+}
+
+// isAlwaysConnect returns true if the connection's address or listen address is in alwaysConnect
+func isAlwaysConnect(c *daemon.Connection, alwaysConnect []string) bool {
+	var listenAddr string
+	if c.ListenPort != 0 {
+		if ip, _, err := iputil.SplitAddr(c.Addr); err == nil {
+			listenAddr = fmt.Sprintf("%s:%d", ip, c.ListenPort)
+		}
+	}
+
+	for _, a := range alwaysConnect {
+		if a == c.Addr || a == listenAddr {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Connections wraps []Connection
 type Connections struct {
-	Connections []readable.Connection `json:"connections"`
+	Connections []Connection `json:"connections"`
 }
 
 // NewConnections copies []daemon.Connection to a struct with json tags
-func NewConnections(dconns []daemon.Connection) Connections {
-	conns := make([]readable.Connection, len(dconns))
+func NewConnections(dconns []daemon.Connection, alwaysConnect []string) Connections {
+	conns := make([]Connection, len(dconns))
 	for i, dc := range dconns {
-		conns[i] = readable.NewConnection(&dc)
+		conns[i] = NewConnection(&dc, alwaysConnect)
 	}
 
 	return Connections{
@@ -68,8 +112,9 @@ func NewConnections(dconns []daemon.Connection) Connections {
 // URI: /api/v1/network/connections
 // Method: GET
 // Args:
-//	states: [optional] comma-separated list of connection states ("pending", "connected" or "introduced"). Defaults to "connected,introduced"
-//  direction: [optional] "outgoing" or "incoming". If not provided, both are included.
+//
+//		states: [optional] comma-separated list of connection states ("pending", "connected" or "introduced"). Defaults to "connected,introduced"
+//	 direction: [optional] "outgoing" or "incoming". If not provided, both are included.
 func connectionsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -132,7 +177,7 @@ func connectionsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wh.SendJSONOr500(logger, w, NewConnections(conns))
+		wh.SendJSONOr500(logger, w, NewConnections(conns, gateway.GetAlwaysConnectPeers()))
 	}
 }
 
@@ -189,10 +234,483 @@ func exchgConnectionsHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// NetworkPeer is a known peer and its quality score
+type NetworkPeer struct {
+	Address         string `json:"address"`
+	LastSeen        int64  `json:"last_seen"`
+	Private         bool   `json:"private"`
+	Trusted         bool   `json:"trusted"`
+	HasIncomingPort bool   `json:"has_incoming_port"`
+	Score           int    `json:"score"`
+	CoolingOff      bool   `json:"cooling_off"`
+}
+
+// NetworkPeersResponse is returned by /api/v1/network/peers
+type NetworkPeersResponse struct {
+	Peers []NetworkPeer `json:"peers"`
+}
+
+// NewNetworkPeersResponse creates a NetworkPeersResponse from pex.Peers
+func NewNetworkPeersResponse(peers pex.Peers) NetworkPeersResponse {
+	ps := make([]NetworkPeer, len(peers))
+	for i, p := range peers {
+		ps[i] = NetworkPeer{
+			Address:         p.Addr,
+			LastSeen:        p.LastSeen,
+			Private:         p.Private,
+			Trusted:         p.Trusted,
+			HasIncomingPort: p.HasIncomingPort,
+			Score:           p.Score,
+			CoolingOff:      p.IsCoolingOff(),
+		}
+	}
+
+	return NetworkPeersResponse{
+		Peers: ps,
+	}
+}
+
+// peersHandler returns all known peers with their quality scores, highest score first
+// URI: /api/v1/network/peers
+// Method: GET
+func peersHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		peers := gateway.GetPeers()
+
+		// Sort by score, then by address so that the response is stable
+		sort.SliceStable(peers, func(i, j int) bool {
+			if peers[i].Score == peers[j].Score {
+				return peers[i].Addr < peers[j].Addr
+			}
+			return peers[i].Score > peers[j].Score
+		})
+
+		wh.SendJSONOr500(logger, w, NewNetworkPeersResponse(peers))
+	}
+}
+
+// peersExportHandler returns a bundle of known-good peers, for importing into another node with peersImportHandler.
+// Private peers and peers cooling off after their score dropped are not exported.
+// URI: /api/v1/network/peers/export
+// Method: GET
+// Args:
+//
+//	min_score: minimum quality score of the peers, default 0 [optional]
+//	max_age: exclude peers last seen longer ago than this duration, e.g. "24h" [optional]
+//	limit: maximum number of peers, highest score first [optional]
+//	sign: sign the bundle with the node key [optional]
+func peersExportHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		var f pex.BundleFilter
+
+		if s := r.FormValue("min_score"); s != "" {
+			minScore, err := strconv.Atoi(s)
+			if err != nil {
+				wh.Error400(w, "invalid min_score value")
+				return
+			}
+			f.MinScore = minScore
+		}
+
+		if s := r.FormValue("max_age"); s != "" {
+			maxAge, err := time.ParseDuration(s)
+			if err != nil || maxAge < 0 {
+				wh.Error400(w, "invalid max_age value")
+				return
+			}
+			f.MaxAge = maxAge
+		}
+
+		if s := r.FormValue("limit"); s != "" {
+			limit, err := strconv.ParseUint(s, 10, 64)
+			if err != nil || limit > math.MaxInt32 {
+				wh.Error400(w, "invalid limit value")
+				return
+			}
+			f.Limit = int(limit)
+		}
+
+		var sign bool
+		if s := r.FormValue("sign"); s != "" {
+			var err error
+			sign, err = strconv.ParseBool(s)
+			if err != nil {
+				wh.Error400(w, "invalid sign value")
+				return
+			}
+		}
+
+		b, err := gateway.ExportPeers(f, sign)
+		if err != nil {
+			switch err {
+			case pex.ErrPeerBundleNoKey:
+				wh.Error403(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, b)
+	}
+}
+
+// PeersImportResponse is returned by /api/v1/network/peers/import
+type PeersImportResponse struct {
+	Added        int               `json:"added"`
+	Skipped      int               `json:"skipped"`
+	AddedPeers   []string          `json:"added_peers"`
+	SkippedPeers []pex.SkippedPeer `json:"skipped_peers"`
+}
+
+// NewPeersImportResponse creates a PeersImportResponse from a pex.ImportResult
+func NewPeersImportResponse(r pex.ImportResult) PeersImportResponse {
+	return PeersImportResponse{
+		Added:        len(r.Added),
+		Skipped:      len(r.Skipped),
+		AddedPeers:   r.Added,
+		SkippedPeers: r.Skipped,
+	}
+}
+
+// peersImportHandler adds the peers of a bundle exported by peersExportHandler to the peer list.
+// If trusted peer bundle keys are configured, the bundle must be signed by one of them.
+// Peers that are invalid, already known or cooling off are skipped, as are peers that don't fit in the peer list.
+// URI: /api/v1/network/peers/import
+// Method: POST
+// Args: JSON body, a pex.PeerBundle
+// Response:
+//
+//	200 - ok, returns PeersImportResponse
+//	400 - invalid bundle
+//	403 - the bundle's signature is missing, invalid or not trusted
+func peersImportHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		var b pex.PeerBundle
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
+		result, err := gateway.ImportPeers(b)
+		if err != nil {
+			switch err {
+			case pex.ErrPeerBundleVersion:
+				wh.Error400(w, err.Error())
+			case pex.ErrPeerBundleNotSigned,
+				pex.ErrPeerBundleUntrustedKey,
+				pex.ErrPeerBundleInvalidSignature:
+				wh.Error403(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, NewPeersImportResponse(*result))
+	}
+}
+
+// PeerBanConfig is the configuration of the banning of peers that repeatedly send invalid objects
+type PeerBanConfig struct {
+	Threshold int         `json:"threshold"`
+	Window    wh.Duration `json:"window"`
+	Duration  wh.Duration `json:"duration"`
+}
+
+// PeerBansResponse is returned by /api/v1/network/bans
+type PeerBansResponse struct {
+	Config   PeerBanConfig        `json:"config"`
+	Offenses []pex.OffenseCounter `json:"offenses"`
+	Bans     []pex.Ban            `json:"bans"`
+}
+
+// NewPeerBansResponse creates a PeerBansResponse from a pex.BanReport
+func NewPeerBansResponse(r pex.BanReport) PeerBansResponse {
+	offenses := r.Counters
+	if offenses == nil {
+		offenses = []pex.OffenseCounter{}
+	}
+	bans := r.Bans
+	if bans == nil {
+		bans = []pex.Ban{}
+	}
+
+	return PeerBansResponse{
+		Config: PeerBanConfig{
+			Threshold: r.Config.Threshold,
+			Window:    wh.FromDuration(r.Config.Window),
+			Duration:  wh.FromDuration(r.Config.Duration),
+		},
+		Offenses: offenses,
+		Bans:     bans,
+	}
+}
+
+// peerBansHandler returns the ban thresholds, the offense counters of peer IPs and the banned peer IPs.
+// Peers that repeatedly send transactions violating hard constraints, invalid blocks or malformed messages are banned.
+// URI: /api/v1/network/bans
+// Method: GET
+func peerBansHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, NewPeerBansResponse(gateway.GetPeerBans()))
+	}
+}
+
+// unbanHandler lifts the ban of a peer IP and resets its offenses
+// URI: /api/v1/network/unban
+// Method: POST
+// Args:
+//
+//	ip: the banned IP [required]
+//
+// Response:
+//
+//	200 - ok, returns PeerBansResponse
+//	400 - missing or invalid IP
+//	404 - the IP is not banned
+func unbanHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		ip := r.FormValue("ip")
+		if ip == "" {
+			wh.Error400(w, "ip is required")
+			return
+		}
+
+		if err := gateway.UnbanPeer(ip); err != nil {
+			switch err {
+			case pex.ErrInvalidAddress:
+				wh.Error400(w, "invalid ip")
+			case pex.ErrNotBanned:
+				wh.Error404(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, NewPeerBansResponse(gateway.GetPeerBans()))
+	}
+}
+
+// bytesPerKbps is the number of bytes per second in a kilobit per second
+const bytesPerKbps = 1000 / 8
+
+// BandwidthLimitsResponse is returned by /api/v1/network/bandwidth
+type BandwidthLimitsResponse struct {
+	MaxUploadKbps   uint64 `json:"max_upload_kbps"`
+	MaxDownloadKbps uint64 `json:"max_download_kbps"`
+}
+
+// NewBandwidthLimitsResponse creates a BandwidthLimitsResponse from gnet.BandwidthLimits
+func NewBandwidthLimitsResponse(l gnet.BandwidthLimits) BandwidthLimitsResponse {
+	return BandwidthLimitsResponse{
+		MaxUploadKbps:   l.Upload / bytesPerKbps,
+		MaxDownloadKbps: l.Download / bytesPerKbps,
+	}
+}
+
+// bandwidthHandler returns or changes the bandwidth limits of block transfers
+// URI: /api/v1/network/bandwidth
+// Method: GET, POST
+// Args (POST):
+//
+//	max_upload_kbps: maximum upload rate in kilobits per second, 0 is unlimited [optional]
+//	max_download_kbps: maximum download rate in kilobits per second, 0 is unlimited [optional]
+func bandwidthHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			l := gateway.GetBandwidthLimits()
+
+			upload := r.FormValue("max_upload_kbps")
+			download := r.FormValue("max_download_kbps")
+			if upload == "" && download == "" {
+				wh.Error400(w, "max_upload_kbps or max_download_kbps is required")
+				return
+			}
+
+			if upload != "" {
+				kbps, err := strconv.ParseUint(upload, 10, 64)
+				if err != nil || kbps > math.MaxUint64/bytesPerKbps {
+					wh.Error400(w, "invalid max_upload_kbps value")
+					return
+				}
+				l.Upload = kbps * bytesPerKbps
+			}
+
+			if download != "" {
+				kbps, err := strconv.ParseUint(download, 10, 64)
+				if err != nil || kbps > math.MaxUint64/bytesPerKbps {
+					wh.Error400(w, "invalid max_download_kbps value")
+					return
+				}
+				l.Download = kbps * bytesPerKbps
+			}
+
+			gateway.SetBandwidthLimits(l)
+		default:
+			wh.Error405(w)
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, NewBandwidthLimitsResponse(gateway.GetBandwidthLimits()))
+	}
+}
+
+// PropagationAnnouncement is a peer announcing a transaction or block after it was first seen
+type PropagationAnnouncement struct {
+	Address string `json:"address"`
+	// Time is a unix timestamp in nanoseconds
+	Time      int64   `json:"time"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// PropagationPercentiles are the announcement latencies in milliseconds
+type PropagationPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// PropagationResponse is returned by /api/v1/network/propagation
+type PropagationResponse struct {
+	Hash string `json:"hash"`
+	Kind string `json:"kind"`
+	// Seq is the block sequence, for blocks
+	Seq *uint64 `json:"seq,omitempty"`
+	// FirstSeen is a unix timestamp in nanoseconds
+	FirstSeen int64 `json:"first_seen"`
+	// Origin is the peer the transaction or block was first seen from, empty if it was injected or created locally
+	Origin        string                    `json:"origin"`
+	Peers         int                       `json:"peers"`
+	Announcements []PropagationAnnouncement `json:"announcements"`
+	Percentiles   PropagationPercentiles    `json:"percentiles"`
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// NewPropagationResponse creates a PropagationResponse from a propagation.Report
+func NewPropagationResponse(r *propagation.Report) PropagationResponse {
+	anns := make([]PropagationAnnouncement, len(r.Announcements))
+	for i, a := range r.Announcements {
+		anns[i] = PropagationAnnouncement{
+			Address:   a.Addr,
+			Time:      a.Time.UnixNano(),
+			LatencyMS: durationMS(a.Latency),
+		}
+	}
+
+	rsp := PropagationResponse{
+		Hash:          r.Hash.Hex(),
+		Kind:          string(r.Kind),
+		FirstSeen:     r.FirstSeen.UnixNano(),
+		Origin:        r.Origin,
+		Peers:         len(anns),
+		Announcements: anns,
+		Percentiles: PropagationPercentiles{
+			P50: durationMS(r.Percentiles.P50),
+			P90: durationMS(r.Percentiles.P90),
+			P99: durationMS(r.Percentiles.P99),
+			Max: durationMS(r.Percentiles.Max),
+		},
+	}
+
+	if r.Kind == propagation.KindBlock {
+		seq := r.Seq
+		rsp.Seq = &seq
+	}
+
+	return rsp
+}
+
+// propagationHandler returns how a recently seen transaction or block propagated through the network:
+// when it was first seen, and when each peer announced it afterwards
+// URI: /api/v1/network/propagation
+// Method: GET
+// Args:
+//
+//	txid: transaction ID [required, unless block is given]
+//	block: block hash [required, unless txid is given]
+func propagationHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		txid := r.FormValue("txid")
+		block := r.FormValue("block")
+
+		var name, value string
+		switch {
+		case txid != "" && block != "":
+			wh.Error400(w, "txid and block cannot be combined")
+			return
+		case txid != "":
+			name, value = "txid", txid
+		case block != "":
+			name, value = "block", block
+		default:
+			wh.Error400(w, "txid or block is required")
+			return
+		}
+
+		hash, err := cipher.SHA256FromHex(value)
+		if err != nil {
+			wh.Error400(w, fmt.Sprintf("invalid %s", name))
+			return
+		}
+
+		report, ok := gateway.GetPropagation(hash)
+		if !ok {
+			wh.Error404(w, "")
+			return
+		}
+
+		if (name == "txid") != (report.Kind == propagation.KindTransaction) {
+			wh.Error404(w, "")
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, NewPropagationResponse(report))
+	}
+}
+
 // disconnectHandler disconnects a connection by ID or address
 // URI: /api/v1/network/connection/disconnect
 // Method: POST
 // Args:
+//
 //	id: ID of the connection
 func disconnectHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -226,3 +744,131 @@ func disconnectHandler(gateway Gatewayer) http.HandlerFunc {
 		wh.SendJSONOr500(logger, w, struct{}{})
 	}
 }
+
+// NetworkRequestBlocksRequest is the body of POST /api/v2/network/request-blocks
+type NetworkRequestBlocksRequest struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+	Peer  string `json:"peer,omitempty"`
+}
+
+// NetworkRequestTxnsRequest is the body of POST /api/v2/network/request-txns
+type NetworkRequestTxnsRequest struct {
+	Txids []string `json:"txids"`
+	Peer  string   `json:"peer,omitempty"`
+}
+
+// NetworkForcedRequestResponse is returned by POST /api/v2/network/request-blocks and POST /api/v2/network/request-txns
+type NetworkForcedRequestResponse struct {
+	// Peers are the addresses of the peers the request was sent to
+	Peers []string `json:"peers"`
+}
+
+// Sends a GetBlocksMessage for the blocks from seq start to seq end, inclusive, to a connected peer,
+// or to all the connected peers if peer is omitted. Used to nudge a node stuck without a block.
+// Forced requests are rate limited.
+// Method: POST
+// URI: /api/v2/network/request-blocks
+// Args: JSON body with "start", "end" and the optional "peer" address
+func networkRequestBlocksHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req NetworkRequestBlocksRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		peers, err := gateway.ForceRequestBlocks(forcerequest.BlocksRequest{
+			Start: req.Start,
+			End:   req.End,
+			Addr:  req.Peer,
+		})
+		writeForcedRequestResponse(w, peers, err)
+	}
+}
+
+// Sends a GetTxnsMessage for transactions to a connected peer, or to all the connected peers if peer is omitted.
+// Used to fetch a transaction that a node missed. Forced requests are rate limited.
+// Method: POST
+// URI: /api/v2/network/request-txns
+// Args: JSON body with "txids", a list of at most 256 transaction IDs, and the optional "peer" address
+func networkRequestTxnsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req NetworkRequestTxnsRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txids := make([]cipher.SHA256, len(req.Txids))
+		for i, txid := range req.Txids {
+			h, err := cipher.SHA256FromHex(txid)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid txids[%d]: %v", i, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			txids[i] = h
+		}
+
+		peers, err := gateway.ForceRequestTxns(forcerequest.TxnsRequest{
+			Txids: txids,
+			Addr:  req.Peer,
+		})
+		writeForcedRequestResponse(w, peers, err)
+	}
+}
+
+// writeForcedRequestResponse writes the response of a forced request, mapping the forcerequest errors to status codes
+func writeForcedRequestResponse(w http.ResponseWriter, peers []string, err error) {
+	if err != nil {
+		var resp HTTPResponse
+		switch e := err.(type) {
+		case forcerequest.ErrTooManyBlocks:
+			resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		case forcerequest.ErrRateLimited:
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+			resp = NewHTTPErrorResponse(http.StatusTooManyRequests, err.Error())
+		default:
+			switch err {
+			case forcerequest.ErrGenesisBlock,
+				forcerequest.ErrInvalidRange,
+				forcerequest.ErrNoTxids,
+				forcerequest.ErrTooManyTxids:
+				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			case forcerequest.ErrPeerNotConnected:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+			case forcerequest.ErrNoPeers:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+		}
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if peers == nil {
+		peers = []string{}
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: NetworkForcedRequestResponse{
+			Peers: peers,
+		},
+	})
+}
@@ -13,10 +13,17 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/daemon/pex"
-	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/testutil"
+	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/daemon/forcerequest"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/daemon/propagation"
+	"github.com/ness-network/privateness/src/readable"
 )
 
 func TestConnection(t *testing.T) {
@@ -28,7 +35,8 @@ func TestConnection(t *testing.T) {
 		addr                       string
 		gatewayGetConnectionResult *daemon.Connection
 		gatewayGetConnectionError  error
-		result                     *readable.Connection
+		alwaysConnect              []string
+		result                     *Connection
 	}{
 		{
 			name:   "405",
@@ -71,19 +79,49 @@ func TestConnection(t *testing.T) {
 					Trusted: false,
 				},
 			},
-			result: &readable.Connection{
-				Addr:          "127.0.0.1:6061",
-				GnetID:        1,
-				LastSent:      99999,
-				LastReceived:  1111111,
-				ConnectedAt:   222222,
-				Outgoing:      true,
-				State:         daemon.ConnectionStateIntroduced,
-				Mirror:        6789,
-				ListenPort:    9877,
-				Height:        1234,
-				UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
-				IsTrustedPeer: false,
+			result: &Connection{
+				Connection: readable.Connection{
+					Addr:          "127.0.0.1:6061",
+					GnetID:        1,
+					LastSent:      99999,
+					LastReceived:  1111111,
+					ConnectedAt:   222222,
+					Outgoing:      true,
+					State:         daemon.ConnectionStateIntroduced,
+					Mirror:        6789,
+					ListenPort:    9877,
+					Height:        1234,
+					UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
+					IsTrustedPeer: false,
+				},
+			},
+		},
+
+		{
+			name:   "200 always connect",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			err:    "",
+			addr:   "addr",
+			gatewayGetConnectionResult: &daemon.Connection{
+				Addr: "127.0.0.1:6061",
+				Gnet: daemon.GnetConnectionDetails{
+					ID: 1,
+				},
+				ConnectionDetails: daemon.ConnectionDetails{
+					Outgoing: true,
+					State:    daemon.ConnectionStateConnected,
+				},
+			},
+			alwaysConnect: []string{"127.0.0.2:6061", "127.0.0.1:6061"},
+			result: &Connection{
+				Connection: readable.Connection{
+					Addr:     "127.0.0.1:6061",
+					GnetID:   1,
+					Outgoing: true,
+					State:    daemon.ConnectionStateConnected,
+				},
+				IsAlwaysConnect: true,
 			},
 		},
 
@@ -109,6 +147,7 @@ func TestConnection(t *testing.T) {
 			endpoint := "/api/v1/network/connection"
 			gateway := &MockGatewayer{}
 			gateway.On("GetConnection", tc.addr).Return(tc.gatewayGetConnectionResult, tc.gatewayGetConnectionError)
+			gateway.On("GetAlwaysConnectPeers").Return(tc.alwaysConnect)
 
 			v := url.Values{}
 			if tc.addr != "" {
@@ -131,7 +170,7 @@ func TestConnection(t *testing.T) {
 				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
-				var msg *readable.Connection
+				var msg *Connection
 				err = json.Unmarshal(rr.Body.Bytes(), &msg)
 				require.NoError(t, err)
 				require.Equal(t, tc.result, msg)
@@ -180,38 +219,45 @@ func TestConnections(t *testing.T) {
 		},
 	}
 
-	readIntrOut := readable.Connection{
-		Addr:          "127.0.0.1:6061",
-		GnetID:        1,
-		LastSent:      99999,
-		LastReceived:  1111111,
-		ConnectedAt:   222222,
-		Outgoing:      true,
-		State:         daemon.ConnectionStateIntroduced,
-		Mirror:        9876,
-		ListenPort:    9877,
-		Height:        1234,
-		UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
-		IsTrustedPeer: true,
-	}
-
-	readIntrIn := readable.Connection{
-		Addr:          "127.0.0.2:6062",
-		GnetID:        2,
-		LastSent:      99999,
-		LastReceived:  1111111,
-		ConnectedAt:   222222,
-		Outgoing:      false,
-		State:         daemon.ConnectionStateIntroduced,
-		Mirror:        9877,
-		ListenPort:    9879,
-		Height:        1234,
-		UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
-		IsTrustedPeer: false,
+	readIntrOut := Connection{
+		Connection: readable.Connection{
+			Addr:          "127.0.0.1:6061",
+			GnetID:        1,
+			LastSent:      99999,
+			LastReceived:  1111111,
+			ConnectedAt:   222222,
+			Outgoing:      true,
+			State:         daemon.ConnectionStateIntroduced,
+			Mirror:        9876,
+			ListenPort:    9877,
+			Height:        1234,
+			UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
+			IsTrustedPeer: true,
+		},
+	}
+
+	// The incoming connection is from an always connect peer, matched by its listen address
+	readIntrIn := Connection{
+		Connection: readable.Connection{
+			Addr:          "127.0.0.2:6062",
+			GnetID:        2,
+			LastSent:      99999,
+			LastReceived:  1111111,
+			ConnectedAt:   222222,
+			Outgoing:      false,
+			State:         daemon.ConnectionStateIntroduced,
+			Mirror:        9877,
+			ListenPort:    9879,
+			Height:        1234,
+			UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
+			IsTrustedPeer: false,
+		},
+		IsAlwaysConnect: true,
 	}
 
 	conns := []daemon.Connection{intrOut, intrIn}
-	readConns := []readable.Connection{readIntrOut, readIntrIn}
+	readConns := []Connection{readIntrOut, readIntrIn}
+	alwaysConnect := []string{"127.0.0.2:9879"}
 
 	tt := []struct {
 		name                                 string
@@ -333,6 +379,7 @@ func TestConnections(t *testing.T) {
 			endpoint := "/api/v1/network/connections"
 			gateway := &MockGatewayer{}
 			gateway.On("GetConnections", mock.Anything).Return(tc.gatewayGetSolicitedConnectionsResult, tc.gatewayGetSolicitedConnectionsError)
+			gateway.On("GetAlwaysConnectPeers").Return(alwaysConnect)
 
 			v := url.Values{}
 			if tc.states != "" {
@@ -526,6 +573,338 @@ func TestGetExchgConnection(t *testing.T) {
 	}
 }
 
+func TestNetworkPeers(t *testing.T) {
+	lowScoreAt := time.Now().UTC().Unix()
+
+	tt := []struct {
+		name         string
+		method       string
+		status       int
+		err          string
+		gatewayPeers pex.Peers
+		result       NetworkPeersResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "200 - no peers",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			result: NetworkPeersResponse{
+				Peers: []NetworkPeer{},
+			},
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			gatewayPeers: pex.Peers{
+				{Addr: "44.33.22.11:6000", LastSeen: 100, Score: -60, LowScoreAt: lowScoreAt},
+				{Addr: "11.44.66.88:6000", LastSeen: 200, Score: 10, HasIncomingPort: true},
+				{Addr: "22.33.44.55:6000", LastSeen: 300, Trusted: true},
+				{Addr: "12.34.56.78:6000", LastSeen: 400},
+			},
+			result: NetworkPeersResponse{
+				Peers: []NetworkPeer{
+					{
+						Address:         "11.44.66.88:6000",
+						LastSeen:        200,
+						HasIncomingPort: true,
+						Score:           10,
+					},
+					{
+						Address:  "12.34.56.78:6000",
+						LastSeen: 400,
+					},
+					{
+						Address:  "22.33.44.55:6000",
+						LastSeen: 300,
+						Trusted:  true,
+					},
+					{
+						Address:    "44.33.22.11:6000",
+						LastSeen:   100,
+						Score:      -60,
+						CoolingOff: true,
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v1/network/peers"
+			gateway := &MockGatewayer{}
+			gateway.On("GetPeers").Return(tc.gatewayPeers)
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				var msg NetworkPeersResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.result, msg)
+			}
+		})
+	}
+}
+
+func TestNetworkPeersExport(t *testing.T) {
+	bundle := &pex.PeerBundle{
+		Version: pex.PeerBundleVersion,
+		Created: 1560000000,
+		Peers: []pex.BundlePeer{
+			{Addr: "11.44.66.88:6000", LastSeen: 200, Score: 10},
+		},
+	}
+
+	tt := []struct {
+		name       string
+		method     string
+		query      url.Values
+		status     int
+		err        string
+		filter     pex.BundleFilter
+		sign       bool
+		gatewayErr error
+		result     *pex.PeerBundle
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 invalid min_score",
+			method: http.MethodGet,
+			query: url.Values{
+				"min_score": []string{"foo"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid min_score value",
+		},
+		{
+			name:   "400 invalid max_age",
+			method: http.MethodGet,
+			query: url.Values{
+				"max_age": []string{"-1h"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid max_age value",
+		},
+		{
+			name:   "400 invalid limit",
+			method: http.MethodGet,
+			query: url.Values{
+				"limit": []string{"-1"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid limit value",
+		},
+		{
+			name:   "400 invalid sign",
+			method: http.MethodGet,
+			query: url.Values{
+				"sign": []string{"foo"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid sign value",
+		},
+		{
+			name:   "403 no node key",
+			method: http.MethodGet,
+			query: url.Values{
+				"sign": []string{"true"},
+			},
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden - No key to sign the peer bundle with",
+			sign:       true,
+			gatewayErr: pex.ErrPeerBundleNoKey,
+		},
+		{
+			name:   "200 default filter",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			result: bundle,
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			query: url.Values{
+				"min_score": []string{"-20"},
+				"max_age":   []string{"24h"},
+				"limit":     []string{"10"},
+				"sign":      []string{"1"},
+			},
+			status: http.StatusOK,
+			filter: pex.BundleFilter{
+				MinScore: -20,
+				MaxAge:   24 * time.Hour,
+				Limit:    10,
+			},
+			sign:   true,
+			result: bundle,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v1/network/peers/export"
+			gateway := &MockGatewayer{}
+			gateway.On("ExportPeers", tc.filter, tc.sign).Return(tc.result, tc.gatewayErr)
+
+			if len(tc.query) > 0 {
+				endpoint += "?" + tc.query.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				var msg pex.PeerBundle
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, *tc.result, msg)
+			}
+		})
+	}
+}
+
+func TestNetworkPeersImport(t *testing.T) {
+	bundle := pex.PeerBundle{
+		Version: pex.PeerBundleVersion,
+		Created: 1560000000,
+		Peers: []pex.BundlePeer{
+			{Addr: "11.44.66.88:6000", LastSeen: 200, Score: 10},
+			{Addr: "12.34.56.78:6000", LastSeen: 300},
+		},
+	}
+
+	tt := []struct {
+		name          string
+		method        string
+		body          string
+		status        int
+		err           string
+		gatewayResult *pex.ImportResult
+		gatewayErr    error
+		result        PeersImportResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 invalid json",
+			method: http.MethodPost,
+			body:   "foo",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid character 'o' in literal false (expecting 'a')",
+		},
+		{
+			name:       "400 unsupported version",
+			method:     http.MethodPost,
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - Unsupported peer bundle version",
+			gatewayErr: pex.ErrPeerBundleVersion,
+		},
+		{
+			name:       "403 untrusted key",
+			method:     http.MethodPost,
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden - Peer bundle is not signed by a trusted key",
+			gatewayErr: pex.ErrPeerBundleUntrustedKey,
+		},
+		{
+			name:       "500",
+			method:     http.MethodPost,
+			status:     http.StatusInternalServerError,
+			err:        "500 Internal Server Error - gateway error",
+			gatewayErr: errors.New("gateway error"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			gatewayResult: &pex.ImportResult{
+				Added: []string{"11.44.66.88:6000"},
+				Skipped: []pex.SkippedPeer{
+					{Addr: "12.34.56.78:6000", Reason: pex.ImportSkippedKnown},
+				},
+			},
+			result: PeersImportResponse{
+				Added:      1,
+				Skipped:    1,
+				AddedPeers: []string{"11.44.66.88:6000"},
+				SkippedPeers: []pex.SkippedPeer{
+					{Addr: "12.34.56.78:6000", Reason: pex.ImportSkippedKnown},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v1/network/peers/import"
+			gateway := &MockGatewayer{}
+			gateway.On("ImportPeers", bundle).Return(tc.gatewayResult, tc.gatewayErr)
+
+			body := tc.body
+			if body == "" {
+				b, err := json.Marshal(bundle)
+				require.NoError(t, err)
+				body = string(b)
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				var msg PeersImportResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.result, msg)
+			}
+		})
+	}
+}
+
 func TestDisconnect(t *testing.T) {
 	tt := []struct {
 		name          string
@@ -628,3 +1007,750 @@ func TestDisconnect(t *testing.T) {
 		})
 	}
 }
+
+func TestBandwidth(t *testing.T) {
+	limits := gnet.BandwidthLimits{
+		Upload:   1000 * bytesPerKbps,
+		Download: 0,
+	}
+
+	tt := []struct {
+		name      string
+		method    string
+		form      url.Values
+		status    int
+		err       string
+		setLimits *gnet.BandwidthLimits
+		response  BandwidthLimitsResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+
+		{
+			name:   "200 GET",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			response: BandwidthLimitsResponse{
+				MaxUploadKbps:   1000,
+				MaxDownloadKbps: 0,
+			},
+		},
+
+		{
+			name:   "400 missing limits",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - max_upload_kbps or max_download_kbps is required",
+		},
+
+		{
+			name:   "400 invalid max_upload_kbps",
+			method: http.MethodPost,
+			form: url.Values{
+				"max_upload_kbps": []string{"-1"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid max_upload_kbps value",
+		},
+
+		{
+			name:   "400 invalid max_download_kbps",
+			method: http.MethodPost,
+			form: url.Values{
+				"max_download_kbps": []string{"18446744073709551615"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid max_download_kbps value",
+		},
+
+		{
+			name:   "200 POST download limit",
+			method: http.MethodPost,
+			form: url.Values{
+				"max_download_kbps": []string{"512"},
+			},
+			status: http.StatusOK,
+			setLimits: &gnet.BandwidthLimits{
+				Upload:   1000 * bytesPerKbps,
+				Download: 512 * bytesPerKbps,
+			},
+			response: BandwidthLimitsResponse{
+				MaxUploadKbps:   1000,
+				MaxDownloadKbps: 0,
+			},
+		},
+
+		{
+			name:   "200 POST both limits",
+			method: http.MethodPost,
+			form: url.Values{
+				"max_upload_kbps":   []string{"0"},
+				"max_download_kbps": []string{"64"},
+			},
+			status: http.StatusOK,
+			setLimits: &gnet.BandwidthLimits{
+				Upload:   0,
+				Download: 64 * bytesPerKbps,
+			},
+			response: BandwidthLimitsResponse{
+				MaxUploadKbps:   1000,
+				MaxDownloadKbps: 0,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetBandwidthLimits").Return(limits)
+			if tc.setLimits != nil {
+				gateway.On("SetBandwidthLimits", *tc.setLimits)
+			}
+
+			endpoint := "/api/v1/network/bandwidth"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.form.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+				return
+			}
+
+			var rsp BandwidthLimitsResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, rsp)
+
+			if tc.setLimits != nil {
+				gateway.AssertCalled(t, "SetBandwidthLimits", *tc.setLimits)
+			}
+		})
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	txid := testutil.RandSHA256(t)
+	blockHash := testutil.RandSHA256(t)
+	firstSeen := time.Unix(1540000000, 0).UTC()
+
+	txnReport := &propagation.Report{
+		Hash:      txid,
+		Kind:      propagation.KindTransaction,
+		FirstSeen: firstSeen,
+		Announcements: []propagation.Announcement{
+			{
+				Addr:    "5.6.7.8:6000",
+				Time:    firstSeen.Add(1500 * time.Microsecond),
+				Latency: 1500 * time.Microsecond,
+			},
+			{
+				Addr:    "1.2.3.4:6000",
+				Time:    firstSeen.Add(120 * time.Millisecond),
+				Latency: 120 * time.Millisecond,
+			},
+		},
+		Percentiles: propagation.Percentiles{
+			P50: 1500 * time.Microsecond,
+			P90: 120 * time.Millisecond,
+			P99: 120 * time.Millisecond,
+			Max: 120 * time.Millisecond,
+		},
+	}
+
+	blockReport := &propagation.Report{
+		Hash:      blockHash,
+		Kind:      propagation.KindBlock,
+		Seq:       10,
+		FirstSeen: firstSeen,
+		Origin:    "1.2.3.4:6000",
+	}
+
+	blockSeq := uint64(10)
+
+	tt := []struct {
+		name     string
+		method   string
+		query    url.Values
+		status   int
+		err      string
+		hash     cipher.SHA256
+		report   *propagation.Report
+		response PropagationResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+
+		{
+			name:   "400 missing txid and block",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - txid or block is required",
+		},
+
+		{
+			name:   "400 txid and block",
+			method: http.MethodGet,
+			query: url.Values{
+				"txid":  []string{txid.Hex()},
+				"block": []string{blockHash.Hex()},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - txid and block cannot be combined",
+		},
+
+		{
+			name:   "400 invalid txid",
+			method: http.MethodGet,
+			query: url.Values{
+				"txid": []string{"abc"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid txid",
+		},
+
+		{
+			name:   "404 not tracked",
+			method: http.MethodGet,
+			query: url.Values{
+				"txid": []string{txid.Hex()},
+			},
+			status: http.StatusNotFound,
+			err:    "404 Not Found",
+			hash:   txid,
+		},
+
+		{
+			name:   "404 txid is a block",
+			method: http.MethodGet,
+			query: url.Values{
+				"txid": []string{blockHash.Hex()},
+			},
+			status: http.StatusNotFound,
+			err:    "404 Not Found",
+			hash:   blockHash,
+			report: blockReport,
+		},
+
+		{
+			name:   "200 txid",
+			method: http.MethodGet,
+			query: url.Values{
+				"txid": []string{txid.Hex()},
+			},
+			status: http.StatusOK,
+			hash:   txid,
+			report: txnReport,
+			response: PropagationResponse{
+				Hash:      txid.Hex(),
+				Kind:      "transaction",
+				FirstSeen: firstSeen.UnixNano(),
+				Peers:     2,
+				Announcements: []PropagationAnnouncement{
+					{
+						Address:   "5.6.7.8:6000",
+						Time:      firstSeen.Add(1500 * time.Microsecond).UnixNano(),
+						LatencyMS: 1.5,
+					},
+					{
+						Address:   "1.2.3.4:6000",
+						Time:      firstSeen.Add(120 * time.Millisecond).UnixNano(),
+						LatencyMS: 120,
+					},
+				},
+				Percentiles: PropagationPercentiles{
+					P50: 1.5,
+					P90: 120,
+					P99: 120,
+					Max: 120,
+				},
+			},
+		},
+
+		{
+			name:   "200 block",
+			method: http.MethodGet,
+			query: url.Values{
+				"block": []string{blockHash.Hex()},
+			},
+			status: http.StatusOK,
+			hash:   blockHash,
+			report: blockReport,
+			response: PropagationResponse{
+				Hash:          blockHash.Hex(),
+				Kind:          "block",
+				Seq:           &blockSeq,
+				FirstSeen:     firstSeen.UnixNano(),
+				Origin:        "1.2.3.4:6000",
+				Announcements: []PropagationAnnouncement{},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetPropagation", tc.hash).Return(tc.report, tc.report != nil)
+
+			endpoint := "/api/v1/network/propagation"
+			if len(tc.query) > 0 {
+				endpoint += "?" + tc.query.Encode()
+			}
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+				return
+			}
+
+			var rsp PropagationResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, rsp)
+		})
+	}
+}
+
+func TestPeerBans(t *testing.T) {
+	report := pex.BanReport{
+		Config: pex.BanConfig{
+			Threshold: 20,
+			Window:    time.Hour,
+			Duration:  time.Hour * 24,
+		},
+		Counters: []pex.OffenseCounter{
+			{
+				IP:          "11.22.33.45",
+				InvalidTxns: 2.5,
+				UpdatedAt:   1540000000,
+			},
+		},
+		Bans: []pex.Ban{
+			{
+				IP:        "11.22.33.44",
+				Reason:    "20.5 invalid transactions, 0.0 invalid blocks and 0.0 protocol violations",
+				BannedAt:  1540000000,
+				ExpiresAt: 1540086400,
+			},
+		},
+	}
+
+	expectedResponse := PeerBansResponse{
+		Config: PeerBanConfig{
+			Threshold: 20,
+			Window:    wh.FromDuration(time.Hour),
+			Duration:  wh.FromDuration(time.Hour * 24),
+		},
+		Offenses: report.Counters,
+		Bans:     report.Bans,
+	}
+
+	tt := []struct {
+		name     string
+		method   string
+		endpoint string
+		form     url.Values
+		report   pex.BanReport
+		unbanIP  string
+		unbanErr error
+		status   int
+		err      string
+		response PeerBansResponse
+	}{
+		{
+			name:     "405 bans",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/network/bans",
+			status:   http.StatusMethodNotAllowed,
+			err:      "405 Method Not Allowed",
+		},
+
+		{
+			name:     "200 bans",
+			method:   http.MethodGet,
+			endpoint: "/api/v1/network/bans",
+			report:   report,
+			status:   http.StatusOK,
+			response: expectedResponse,
+		},
+
+		{
+			name:     "200 no bans",
+			method:   http.MethodGet,
+			endpoint: "/api/v1/network/bans",
+			report: pex.BanReport{
+				Config: report.Config,
+			},
+			status: http.StatusOK,
+			response: PeerBansResponse{
+				Config:   expectedResponse.Config,
+				Offenses: []pex.OffenseCounter{},
+				Bans:     []pex.Ban{},
+			},
+		},
+
+		{
+			name:     "405 unban",
+			method:   http.MethodGet,
+			endpoint: "/api/v1/network/unban",
+			status:   http.StatusMethodNotAllowed,
+			err:      "405 Method Not Allowed",
+		},
+
+		{
+			name:     "400 unban missing ip",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/network/unban",
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - ip is required",
+		},
+
+		{
+			name:     "400 unban invalid ip",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/network/unban",
+			form: url.Values{
+				"ip": []string{"foo"},
+			},
+			unbanIP:  "foo",
+			unbanErr: pex.ErrInvalidAddress,
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - invalid ip",
+		},
+
+		{
+			name:     "404 unban not banned",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/network/unban",
+			form: url.Values{
+				"ip": []string{"11.22.33.45"},
+			},
+			unbanIP:  "11.22.33.45",
+			unbanErr: pex.ErrNotBanned,
+			status:   http.StatusNotFound,
+			err:      "404 Not Found - IP is not banned",
+		},
+
+		{
+			name:     "200 unban",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/network/unban",
+			form: url.Values{
+				"ip": []string{"11.22.33.44"},
+			},
+			unbanIP: "11.22.33.44",
+			report: pex.BanReport{
+				Config: report.Config,
+			},
+			status: http.StatusOK,
+			response: PeerBansResponse{
+				Config:   expectedResponse.Config,
+				Offenses: []pex.OffenseCounter{},
+				Bans:     []pex.Ban{},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetPeerBans").Return(tc.report)
+			if tc.unbanIP != "" {
+				gateway.On("UnbanPeer", tc.unbanIP).Return(tc.unbanErr)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.form.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+				return
+			}
+
+			var rsp PeerBansResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, rsp)
+
+			if tc.unbanIP != "" {
+				gateway.AssertCalled(t, "UnbanPeer", tc.unbanIP)
+			}
+		})
+	}
+}
+
+func TestNetworkRequestBlocks(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		body       string
+		req        *forcerequest.BlocksRequest
+		peers      []string
+		gatewayErr error
+		status     int
+		err        string
+		retryAfter string
+		result     *NetworkForcedRequestResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:   "400 - invalid json",
+			method: http.MethodPost,
+			body:   `{"start":"1"}`,
+			status: http.StatusBadRequest,
+			err:    `invalid value for field "start" at offset 12: expected uint64, got JSON string`,
+		},
+		{
+			name:       "400 - genesis block",
+			method:     http.MethodPost,
+			body:       `{"start":0,"end":2}`,
+			req:        &forcerequest.BlocksRequest{Start: 0, End: 2},
+			gatewayErr: forcerequest.ErrGenesisBlock,
+			status:     http.StatusBadRequest,
+			err:        forcerequest.ErrGenesisBlock.Error(),
+		},
+		{
+			name:       "400 - too many blocks",
+			method:     http.MethodPost,
+			body:       `{"start":1,"end":1000}`,
+			req:        &forcerequest.BlocksRequest{Start: 1, End: 1000},
+			gatewayErr: forcerequest.ErrTooManyBlocks{Max: 20},
+			status:     http.StatusBadRequest,
+			err:        "at most 20 blocks can be requested at once",
+		},
+		{
+			name:       "404 - peer not connected",
+			method:     http.MethodPost,
+			body:       `{"start":5,"end":6,"peer":"1.2.3.4:6000"}`,
+			req:        &forcerequest.BlocksRequest{Start: 5, End: 6, Addr: "1.2.3.4:6000"},
+			gatewayErr: forcerequest.ErrPeerNotConnected,
+			status:     http.StatusNotFound,
+			err:        "peer is not connected",
+		},
+		{
+			name:       "429 - rate limited",
+			method:     http.MethodPost,
+			body:       `{"start":5,"end":6}`,
+			req:        &forcerequest.BlocksRequest{Start: 5, End: 6},
+			gatewayErr: forcerequest.ErrRateLimited{RetryAfter: time.Millisecond * 2500},
+			status:     http.StatusTooManyRequests,
+			err:        "requests are forced too often, retry in 2.5s",
+			retryAfter: "3",
+		},
+		{
+			name:       "503 - no peers",
+			method:     http.MethodPost,
+			body:       `{"start":5,"end":6}`,
+			req:        &forcerequest.BlocksRequest{Start: 5, End: 6},
+			gatewayErr: forcerequest.ErrNoPeers,
+			status:     http.StatusServiceUnavailable,
+			err:        "no connected peers to send the request to",
+		},
+		{
+			name:       "500",
+			method:     http.MethodPost,
+			body:       `{"start":5,"end":6}`,
+			req:        &forcerequest.BlocksRequest{Start: 5, End: 6},
+			gatewayErr: errors.New("connection pool is closed"),
+			status:     http.StatusInternalServerError,
+			err:        "connection pool is closed",
+		},
+		{
+			name:   "200 - one peer",
+			method: http.MethodPost,
+			body:   `{"start":5,"end":6,"peer":"1.2.3.4:6000"}`,
+			req:    &forcerequest.BlocksRequest{Start: 5, End: 6, Addr: "1.2.3.4:6000"},
+			peers:  []string{"1.2.3.4:6000"},
+			status: http.StatusOK,
+			result: &NetworkForcedRequestResponse{
+				Peers: []string{"1.2.3.4:6000"},
+			},
+		},
+		{
+			name:   "200 - all peers",
+			method: http.MethodPost,
+			body:   `{"start":5,"end":5}`,
+			req:    &forcerequest.BlocksRequest{Start: 5, End: 5},
+			peers:  []string{"1.2.3.4:6000", "5.6.7.8:6000"},
+			status: http.StatusOK,
+			result: &NetworkForcedRequestResponse{
+				Peers: []string{"1.2.3.4:6000", "5.6.7.8:6000"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("ForceRequestBlocks", *tc.req).Return(tc.peers, tc.gatewayErr)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v2/network/request-blocks", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, tc.retryAfter, rr.Header().Get("Retry-After"))
+
+			var resp struct {
+				Error *HTTPError                    `json:"error"`
+				Data  *NetworkForcedRequestResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.status != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
+
+func TestNetworkRequestTxns(t *testing.T) {
+	txid := testutil.RandSHA256(t)
+
+	cases := []struct {
+		name       string
+		method     string
+		body       string
+		req        *forcerequest.TxnsRequest
+		peers      []string
+		gatewayErr error
+		status     int
+		err        string
+		result     *NetworkForcedRequestResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:   "400 - invalid txid",
+			method: http.MethodPost,
+			body:   `{"txids":["` + txid.Hex() + `","abc"]}`,
+			status: http.StatusBadRequest,
+			err:    "invalid txids[1]: encoding/hex: odd length hex string",
+		},
+		{
+			name:       "400 - no txids",
+			method:     http.MethodPost,
+			body:       `{"txids":[]}`,
+			req:        &forcerequest.TxnsRequest{Txids: []cipher.SHA256{}},
+			gatewayErr: forcerequest.ErrNoTxids,
+			status:     http.StatusBadRequest,
+			err:        "no txids to request",
+		},
+		{
+			name:       "503 - no peers",
+			method:     http.MethodPost,
+			body:       `{"txids":["` + txid.Hex() + `"]}`,
+			req:        &forcerequest.TxnsRequest{Txids: []cipher.SHA256{txid}},
+			gatewayErr: forcerequest.ErrNoPeers,
+			status:     http.StatusServiceUnavailable,
+			err:        "no connected peers to send the request to",
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body:   `{"txids":["` + txid.Hex() + `"],"peer":"1.2.3.4:6000"}`,
+			req:    &forcerequest.TxnsRequest{Txids: []cipher.SHA256{txid}, Addr: "1.2.3.4:6000"},
+			peers:  []string{"1.2.3.4:6000"},
+			status: http.StatusOK,
+			result: &NetworkForcedRequestResponse{
+				Peers: []string{"1.2.3.4:6000"},
+			},
+		},
+		{
+			name:   "200 - no peer sent",
+			method: http.MethodPost,
+			body:   `{"txids":["` + txid.Hex() + `"]}`,
+			req:    &forcerequest.TxnsRequest{Txids: []cipher.SHA256{txid}},
+			status: http.StatusOK,
+			result: &NetworkForcedRequestResponse{
+				Peers: []string{},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("ForceRequestTxns", *tc.req).Return(tc.peers, tc.gatewayErr)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v2/network/request-txns", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp struct {
+				Error *HTTPError                    `json:"error"`
+				Data  *NetworkForcedRequestResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.status != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/util/datadir"
+)
+
+// NodeConfig configuration data exposed in /node
+type NodeConfig struct {
+	// ID is the node's persistent identifier, see datadir.LoadOrCreateNodeID
+	ID string
+	// BuildDate is the date the node was built
+	BuildDate string
+	// ExecutableHash is the SHA256 hash of the node's executable
+	ExecutableHash string
+	// DataDirFingerprint is the fingerprint of the node's data directory, see datadir.Fingerprint
+	DataDirFingerprint string
+	// DataDirLock is the node's lock of its data directory
+	DataDirLock DataDirLocker
+}
+
+// DataDirLocker reports the status of a data directory lock
+type DataDirLocker interface {
+	Status() datadir.LockStatus
+}
+
+// NodeResponse is returned by the /node endpoint
+type NodeResponse struct {
+	ID                 string               `json:"id"`
+	DaemonUserAgent    string               `json:"user_agent"`
+	Version            readable.BuildInfo   `json:"version"`
+	BuildDate          string               `json:"build_date"`
+	ExecutableHash     string               `json:"executable_hash"`
+	CoinName           string               `json:"coin"`
+	EnabledAPISets     []string             `json:"enabled_api_sets"`
+	DataDirFingerprint string               `json:"data_dir_fingerprint"`
+	DataDirLock        *datadir.LockStatus  `json:"data_dir_lock"`
+	Fiber              readable.FiberConfig `json:"fiber"`
+}
+
+// nodeHandler returns the node's identity and build info
+// URI: /api/v1/node
+// Method: GET
+func nodeHandler(c muxConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		userAgent, err := c.health.DaemonUserAgent.Build()
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		apiSets := make([]string, 0, len(c.enabledAPISets))
+		for s := range c.enabledAPISets {
+			apiSets = append(apiSets, s)
+		}
+		sort.Strings(apiSets)
+
+		var lockStatus *datadir.LockStatus
+		if c.node.DataDirLock != nil {
+			s := c.node.DataDirLock.Status()
+			lockStatus = &s
+		}
+
+		wh.SendJSONOr500(logger, w, NodeResponse{
+			ID:                 c.node.ID,
+			DaemonUserAgent:    userAgent,
+			Version:            c.health.BuildInfo,
+			BuildDate:          c.node.BuildDate,
+			ExecutableHash:     c.node.ExecutableHash,
+			CoinName:           c.health.Fiber.Name,
+			EnabledAPISets:     apiSets,
+			DataDirFingerprint: c.node.DataDirFingerprint,
+			DataDirLock:        lockStatus,
+			Fiber:              c.health.Fiber,
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/util/datadir"
+)
+
+type fakeDataDirLock struct {
+	status datadir.LockStatus
+}
+
+func (l fakeDataDirLock) Status() datadir.LockStatus {
+	return l.status
+}
+
+func TestNodeHandler(t *testing.T) {
+	conflict := datadir.LockStatus{
+		Status: datadir.LockStatusConflict,
+		Holder: &datadir.LockInfo{
+			NodeID:   "0123",
+			Hostname: "other-host",
+			PID:      123,
+			Created:  1000,
+		},
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		code   int
+		err    string
+		lock   DataDirLocker
+		expect *datadir.LockStatus
+	}{
+		{
+			name:   "405 method not allowed",
+			method: http.MethodPost,
+			code:   http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "200 no lock",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "200 lock conflict",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			lock:   fakeDataDirLock{conflict},
+			expect: &conflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := defaultMuxConfig()
+			cfg.enabledAPISets = map[string]struct{}{
+				EndpointsStatus: struct{}{},
+				EndpointsWallet: struct{}{},
+				EndpointsRead:   struct{}{},
+			}
+			cfg.health = HealthConfig{
+				BuildInfo: readable.BuildInfo{
+					Version: "1.0.0",
+					Commit:  "abcdef",
+					Branch:  "develop",
+				},
+				Fiber: readable.FiberConfig{
+					Name: "privateness",
+				},
+				DaemonUserAgent: useragent.Data{
+					Coin:    "privateness",
+					Version: "0.27.1",
+				},
+			}
+			cfg.node = NodeConfig{
+				ID:                 "8f0d6bcb3e1f4d3bb7a4c2b5d4e01234",
+				BuildDate:          "2020-01-02T03:04:05Z",
+				ExecutableHash:     "cafe",
+				DataDirFingerprint: "beef",
+				DataDirLock:        tc.lock,
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v1/node", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(cfg, &MockGatewayer{})
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+			if tc.code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var r NodeResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &r)
+			require.NoError(t, err)
+
+			require.Equal(t, NodeResponse{
+				ID:                 cfg.node.ID,
+				DaemonUserAgent:    "privateness:0.27.1",
+				Version:            cfg.health.BuildInfo,
+				BuildDate:          cfg.node.BuildDate,
+				ExecutableHash:     "cafe",
+				CoinName:           "privateness",
+				EnabledAPISets:     []string{EndpointsRead, EndpointsStatus, EndpointsWallet},
+				DataDirFingerprint: "beef",
+				DataDirLock:        tc.expect,
+				Fiber:              cfg.health.Fiber,
+			}, r)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package api
+
+// APIs for address subscriptions and their notification queues
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/droplet"
+
+	"github.com/ness-network/privateness/src/kvstorage"
+)
+
+// SubscriptionRequest is the request data for POST /api/v2/notifications/subscriptions
+type SubscriptionRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// SubscriptionResponse is returned by POST /api/v2/notifications/subscriptions
+type SubscriptionResponse struct {
+	ID        string   `json:"id"`
+	Addresses []string `json:"addresses"`
+}
+
+// NotificationEvent is a transaction of an applied block touching the addresses of a subscription
+type NotificationEvent struct {
+	Cursor    uint64 `json:"cursor"`
+	TxID      string `json:"txid"`
+	BlockSeq  uint64 `json:"seq"`
+	Direction string `json:"direction"`
+	Amount    string `json:"amount"`
+}
+
+// NotificationsResponse is returned by GET /api/v2/notifications
+type NotificationsResponse struct {
+	Events []NotificationEvent `json:"events"`
+	// Next is the cursor to request the following page with
+	Next uint64 `json:"next"`
+	// Dropped is true if events after the requested cursor were dropped from the queue
+	Dropped bool `json:"dropped"`
+}
+
+// NewNotificationsResponse creates a NotificationsResponse
+func NewNotificationsResponse(n *kvstorage.Notifications) (*NotificationsResponse, error) {
+	events := make([]NotificationEvent, len(n.Events))
+	for i, e := range n.Events {
+		amount, err := droplet.ToString(e.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		events[i] = NotificationEvent{
+			Cursor:    e.Cursor,
+			TxID:      e.TxID,
+			BlockSeq:  e.BlockSeq,
+			Direction: e.Direction,
+			Amount:    amount,
+		}
+	}
+
+	return &NotificationsResponse{
+		Events:  events,
+		Next:    n.Next,
+		Dropped: n.Dropped,
+	}, nil
+}
+
+// notificationErrorResponse returns the error response for a notification storage error
+func notificationErrorResponse(err error) HTTPResponse {
+	switch err {
+	case kvstorage.ErrStorageAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "")
+	case kvstorage.ErrNoSuchStorage:
+		return NewHTTPErrorResponse(http.StatusNotFound, "notifications storage is not loaded")
+	case kvstorage.ErrNoSuchSubscription:
+		return NewHTTPErrorResponse(http.StatusNotFound, "")
+	case kvstorage.ErrTooManySubscriptions:
+		return NewHTTPErrorResponse(http.StatusConflict, err.Error())
+	default:
+		switch err.(type) {
+		case kvstorage.Error:
+			return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		default:
+			return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
+}
+
+// Dispatches /notifications/subscriptions endpoint.
+// Subscribes to the transactions of applied blocks touching a set of addresses,
+// or removes a subscription and its queued events.
+// Method: POST, DELETE
+// URI: /api/v2/notifications/subscriptions
+// Args:
+//
+//	addresses: addresses to subscribe to, in the JSON body [required for POST]
+//	id: subscription ID [required for DELETE]
+func notificationSubscriptionsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			subscribeHandler(w, r, gateway)
+		case http.MethodDelete:
+			unsubscribeHandler(w, r, gateway)
+		default:
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+		}
+	}
+}
+
+func subscribeHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	var req SubscriptionRequest
+	if err := decodeJSONRequest(r, &req); err != nil {
+		resp := NewJSONDecodeErrorResponse(err)
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	addrs := make([]cipher.Address, len(req.Addresses))
+	for i, a := range req.Addresses {
+		var err error
+		addrs[i], err = cipher.DecodeBase58Address(a)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address %q: %v", a, err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+	}
+
+	id, err := gateway.Subscribe(addrs)
+	if err != nil {
+		writeHTTPResponse(w, notificationErrorResponse(err))
+		return
+	}
+
+	subAddrs, err := gateway.GetSubscriptionAddresses(id)
+	if err != nil {
+		writeHTTPResponse(w, notificationErrorResponse(err))
+		return
+	}
+
+	resp := SubscriptionResponse{
+		ID:        id,
+		Addresses: make([]string, len(subAddrs)),
+	}
+	for i, a := range subAddrs {
+		resp.Addresses[i] = a.String()
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: resp,
+	})
+}
+
+func unsubscribeHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	id := r.FormValue("id")
+	if id == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if err := gateway.Unsubscribe(id); err != nil {
+		writeHTTPResponse(w, notificationErrorResponse(err))
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{})
+}
+
+// Returns the queued events of a subscription after a cursor.
+// Events are retained until newer events push them out of the queue, so a page can be requested again.
+// Method: GET
+// URI: /api/v2/notifications
+// Args:
+//
+//	subscription: subscription ID [required]
+//	after: return the events after this cursor [optional, defaults to 0]
+//	limit: maximum number of events to return [optional, defaults to and at most 100]
+func notificationsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		id := r.FormValue("subscription")
+		if id == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "subscription is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var after uint64
+		if s := r.FormValue("after"); s != "" {
+			var err error
+			after, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid after value")
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		var limit int
+		if s := r.FormValue("limit"); s != "" {
+			n, err := strconv.ParseUint(s, 10, 64)
+			if err != nil || n == 0 || n > kvstorage.MaxNotificationsPageSize {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", kvstorage.MaxNotificationsPageSize))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			limit = int(n)
+		}
+
+		n, err := gateway.GetNotifications(id, after, limit)
+		if err != nil {
+			writeHTTPResponse(w, notificationErrorResponse(err))
+			return
+		}
+
+		resp, err := NewNotificationsResponse(n)
+		if err != nil {
+			writeHTTPResponse(w, NewHTTPErrorResponse(http.StatusInternalServerError, err.Error()))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: resp,
+		})
+	}
+}
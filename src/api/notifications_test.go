@@ -0,0 +1,388 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/kvstorage"
+)
+
+func TestNotificationSubscriptionsHandler(t *testing.T) {
+	addr1 := testutil.MakeAddress()
+	addr2 := testutil.MakeAddress()
+
+	type subscribeCall struct {
+		addrs []cipher.Address
+		id    string
+		err   error
+	}
+
+	tt := []struct {
+		name            string
+		method          string
+		contentType     string
+		httpBody        string
+		query           string
+		status          int
+		subscribe       *subscribeCall
+		unsubscribeID   string
+		unsubscribeErr  error
+		subAddrs        []cipher.Address
+		httpResponse    HTTPResponse
+		subscriptionRsp *SubscriptionResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
+		},
+		{
+			name:         "400 - missing addresses",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, SubscriptionRequest{}),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required"),
+		},
+		{
+			name:        "400 - invalid address",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{"foo"},
+			}),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid address "foo": Invalid address length`),
+		},
+		{
+			name:        "400 - too many addresses",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{addr1.String()},
+			}),
+			status: http.StatusBadRequest,
+			subscribe: &subscribeCall{
+				addrs: []cipher.Address{addr1},
+				err:   kvstorage.ErrSubscriptionTooManyAddresses,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, kvstorage.ErrSubscriptionTooManyAddresses.Error()),
+		},
+		{
+			name:        "403 - storage api disabled",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{addr1.String()},
+			}),
+			status: http.StatusForbidden,
+			subscribe: &subscribeCall{
+				addrs: []cipher.Address{addr1},
+				err:   kvstorage.ErrStorageAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "404 - storage not loaded",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{addr1.String()},
+			}),
+			status: http.StatusNotFound,
+			subscribe: &subscribeCall{
+				addrs: []cipher.Address{addr1},
+				err:   kvstorage.ErrNoSuchStorage,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "notifications storage is not loaded"),
+		},
+		{
+			name:        "409 - too many subscriptions",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{addr1.String()},
+			}),
+			status: http.StatusConflict,
+			subscribe: &subscribeCall{
+				addrs: []cipher.Address{addr1},
+				err:   kvstorage.ErrTooManySubscriptions,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusConflict, kvstorage.ErrTooManySubscriptions.Error()),
+		},
+		{
+			name:        "500",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{addr1.String()},
+			}),
+			status: http.StatusInternalServerError,
+			subscribe: &subscribeCall{
+				addrs: []cipher.Address{addr1},
+				err:   errors.New("disk full"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "disk full"),
+		},
+		{
+			name:        "200 - subscribe",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, SubscriptionRequest{
+				Addresses: []string{addr1.String(), addr2.String(), addr1.String()},
+			}),
+			status: http.StatusOK,
+			subscribe: &subscribeCall{
+				addrs: []cipher.Address{addr1, addr2, addr1},
+				id:    "0123abcd",
+			},
+			subAddrs: []cipher.Address{addr1, addr2},
+			subscriptionRsp: &SubscriptionResponse{
+				ID:        "0123abcd",
+				Addresses: []string{addr1.String(), addr2.String()},
+			},
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodDelete,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:   "404 - unknown subscription",
+			method: http.MethodDelete,
+			query: url.Values{
+				"id": []string{"0123abcd"},
+			}.Encode(),
+			status:         http.StatusNotFound,
+			unsubscribeID:  "0123abcd",
+			unsubscribeErr: kvstorage.ErrNoSuchSubscription,
+			httpResponse:   NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:   "200 - unsubscribe",
+			method: http.MethodDelete,
+			query: url.Values{
+				"id": []string{"0123abcd"},
+			}.Encode(),
+			status:        http.StatusOK,
+			unsubscribeID: "0123abcd",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.subscribe != nil {
+				gateway.On("Subscribe", tc.subscribe.addrs).Return(tc.subscribe.id, tc.subscribe.err)
+				gateway.On("GetSubscriptionAddresses", tc.subscribe.id).Return(tc.subAddrs, nil)
+			}
+			if tc.unsubscribeID != "" {
+				gateway.On("Unsubscribe", tc.unsubscribeID).Return(tc.unsubscribeErr)
+			}
+
+			endpoint := "/api/v2/notifications/subscriptions"
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.subscriptionRsp == nil {
+				require.Nil(t, rsp.Data)
+				return
+			}
+
+			var subRsp SubscriptionResponse
+			err = json.Unmarshal(rsp.Data, &subRsp)
+			require.NoError(t, err)
+			require.Equal(t, *tc.subscriptionRsp, subRsp)
+
+			gateway.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNotificationsHandler(t *testing.T) {
+	txid := testutil.RandSHA256(t).Hex()
+
+	type getNotificationsCall struct {
+		after  uint64
+		limit  int
+		result *kvstorage.Notifications
+		err    error
+	}
+
+	tt := []struct {
+		name             string
+		method           string
+		query            string
+		status           int
+		getNotifications *getNotificationsCall
+		httpResponse     HTTPResponse
+		notificationsRsp *NotificationsResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing subscription",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "subscription is required"),
+		},
+		{
+			name:   "400 - invalid after",
+			method: http.MethodGet,
+			query: url.Values{
+				"subscription": []string{"0123abcd"},
+				"after":        []string{"-1"},
+			}.Encode(),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid after value"),
+		},
+		{
+			name:   "400 - limit too large",
+			method: http.MethodGet,
+			query: url.Values{
+				"subscription": []string{"0123abcd"},
+				"limit":        []string{"101"},
+			}.Encode(),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "limit must be between 1 and 100"),
+		},
+		{
+			name:   "404 - unknown subscription",
+			method: http.MethodGet,
+			query: url.Values{
+				"subscription": []string{"0123abcd"},
+			}.Encode(),
+			status: http.StatusNotFound,
+			getNotifications: &getNotificationsCall{
+				err: kvstorage.ErrNoSuchSubscription,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			query: url.Values{
+				"subscription": []string{"0123abcd"},
+				"after":        []string{"4"},
+				"limit":        []string{"10"},
+			}.Encode(),
+			status: http.StatusOK,
+			getNotifications: &getNotificationsCall{
+				after: 4,
+				limit: 10,
+				result: &kvstorage.Notifications{
+					Events: []kvstorage.NotificationEvent{
+						{
+							Cursor:    5,
+							TxID:      txid,
+							BlockSeq:  120,
+							Direction: kvstorage.NotificationDirectionOut,
+							Amount:    1500000,
+						},
+					},
+					Next:    5,
+					Dropped: true,
+				},
+			},
+			notificationsRsp: &NotificationsResponse{
+				Events: []NotificationEvent{
+					{
+						Cursor:    5,
+						TxID:      txid,
+						BlockSeq:  120,
+						Direction: "out",
+						Amount:    "1.500000",
+					},
+				},
+				Next:    5,
+				Dropped: true,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.getNotifications != nil {
+				gateway.On("GetNotifications", "0123abcd", tc.getNotifications.after, tc.getNotifications.limit).Return(tc.getNotifications.result, tc.getNotifications.err)
+			}
+
+			endpoint := "/api/v2/notifications"
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.notificationsRsp == nil {
+				require.Nil(t, rsp.Data)
+				return
+			}
+
+			var nRsp NotificationsResponse
+			err = json.Unmarshal(rsp.Data, &nRsp)
+			require.NoError(t, err)
+			require.Equal(t, *tc.notificationsRsp, nRsp)
+		})
+	}
+}
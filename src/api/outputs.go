@@ -4,20 +4,50 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/droplet"
 	wh "github.com/skycoin/skycoin/src/util/http"
-	"github.com/skycoin/skycoin/src/visor"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
-// outputsHandler returns UxOuts filtered by a set of addresses or a set of hashes
+// TransactionOutputStatus is an output created by a transaction, and whether it was spent
+type TransactionOutputStatus struct {
+	UxID    string `json:"uxid"`
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Hours   uint64 `json:"hours"`
+	Spent   bool   `json:"spent"`
+	// SpentTxID and SpentBlockSeq are set if the output was spent
+	SpentTxID     string `json:"spent_txid,omitempty"`
+	SpentBlockSeq uint64 `json:"spent_block_seq,omitempty"`
+}
+
+// TransactionOutputsResponse is returned by /api/v1/outputs when txid is specified
+type TransactionOutputsResponse struct {
+	TxID string `json:"txid"`
+	// Confirmed is false if the transaction is unconfirmed, its outputs are then not created yet
+	Confirmed bool                      `json:"confirmed"`
+	Outputs   []TransactionOutputStatus `json:"outputs"`
+}
+
+// outputsHandler returns UxOuts filtered by a set of addresses or a set of hashes,
+// or the outputs created by a transaction
 // URI: /api/v1/outputs
 // Method: GET, POST
 // Args:
-//    addrs: comma-separated list of addresses
-//    hashes: comma-separated list of uxout hashes
+//
+//	addrs: comma-separated list of addresses
+//	hashes: comma-separated list of uxout hashes
+//	txid: transaction id
+//
 // If neither addrs nor hashes are specificed, return all unspent outputs.
 // If only one filter is specified, then return outputs match the filter.
 // Both filters cannot be specified.
+// If txid is specified, return the outputs created by the transaction and whether they are spent,
+// see transactionOutputsHandler. txid cannot be combined with addrs or hashes.
 func outputsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodPost {
@@ -27,12 +57,23 @@ func outputsHandler(gateway Gatewayer) http.HandlerFunc {
 
 		addrStr := r.FormValue("addrs")
 		hashStr := r.FormValue("hashes")
+		txidStr := r.FormValue("txid")
 
 		if addrStr != "" && hashStr != "" {
 			wh.Error400(w, "addrs and hashes cannot be specified together")
 			return
 		}
 
+		if txidStr != "" {
+			if addrStr != "" || hashStr != "" {
+				wh.Error400(w, "txid cannot be specified together with addrs or hashes")
+				return
+			}
+
+			transactionOutputsHandler(gateway, txidStr)(w, r)
+			return
+		}
+
 		var filters []visor.OutputsFilter
 
 		if addrStr != "" {
@@ -75,3 +116,200 @@ func outputsHandler(gateway Gatewayer) http.HandlerFunc {
 		wh.SendJSONOr500(logger, w, rSummary)
 	}
 }
+
+// transactionOutputsHandler returns the outputs created by a transaction and their spent status.
+// The outputs of an unconfirmed transaction are returned as unspent.
+func transactionOutputsHandler(gateway Gatewayer, txidStr string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		txid, err := cipher.SHA256FromHex(txidStr)
+		if err != nil {
+			wh.Error400(w, "invalid txid")
+			return
+		}
+
+		txn, err := gateway.GetTransaction(txid)
+		if err != nil {
+			err = fmt.Errorf("gateway.GetTransaction failed: %v", err)
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		if txn == nil {
+			wh.Error404(w, "transaction not found")
+			return
+		}
+
+		rlt, err := newTransactionOutputsResponse(gateway, txn)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, rlt)
+	}
+}
+
+func newTransactionOutputsResponse(gateway Gatewayer, txn *visor.Transaction) (*TransactionOutputsResponse, error) {
+	txid := txn.Transaction.Hash()
+
+	// The outputs of the genesis transaction have a null SrcTransaction
+	srcTxn := txid
+	if txn.Status.Confirmed && txn.Status.BlockSeq == 0 {
+		srcTxn = cipher.SHA256{}
+	}
+
+	rlt := &TransactionOutputsResponse{
+		TxID:      txid.Hex(),
+		Confirmed: txn.Status.Confirmed,
+		Outputs:   make([]TransactionOutputStatus, len(txn.Transaction.Out)),
+	}
+
+	for i, o := range txn.Transaction.Out {
+		coins, err := droplet.ToString(o.Coins)
+		if err != nil {
+			return nil, err
+		}
+
+		body := coin.UxBody{
+			SrcTransaction: srcTxn,
+			Address:        o.Address,
+			Coins:          o.Coins,
+			Hours:          o.Hours,
+		}
+		uxID := body.Hash()
+
+		rlt.Outputs[i] = TransactionOutputStatus{
+			UxID:    uxID.Hex(),
+			Address: o.Address.String(),
+			Coins:   coins,
+			Hours:   o.Hours,
+		}
+
+		if !txn.Status.Confirmed {
+			continue
+		}
+
+		ux, err := gateway.GetUxOutByID(uxID)
+		if err != nil {
+			return nil, fmt.Errorf("gateway.GetUxOutByID failed: %v", err)
+		}
+		if ux == nil {
+			return nil, fmt.Errorf("output %s of confirmed transaction %s not found", uxID.Hex(), txid.Hex())
+		}
+
+		if ux.SpentBlockSeq != 0 {
+			rlt.Outputs[i].Spent = true
+			rlt.Outputs[i].SpentTxID = ux.SpentTxnID.Hex()
+			rlt.Outputs[i].SpentBlockSeq = ux.SpentBlockSeq
+		}
+	}
+
+	return rlt, nil
+}
+
+// AddressOutputsSummary is the aggregated confirmed unspent outputs of an address
+type AddressOutputsSummary struct {
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	// Hours are calculated at the head block time
+	Hours         uint64         `json:"hours"`
+	Count         uint64         `json:"count"`
+	LargestOutput *LargestOutput `json:"largest_output"`
+}
+
+// LargestOutput is the unspent output with the most coins owned by an address
+type LargestOutput struct {
+	UxID  string `json:"uxid"`
+	Coins string `json:"coins"`
+}
+
+// OutputsSummaryResponse is returned by /api/v1/outputs/summary
+type OutputsSummaryResponse struct {
+	Addresses []AddressOutputsSummary `json:"addresses"`
+}
+
+// NewOutputsSummaryResponse creates an OutputsSummaryResponse from visor address summaries
+func NewOutputsSummaryResponse(summaries []visor.AddressOutputsSummary) (*OutputsSummaryResponse, error) {
+	addrs := make([]AddressOutputsSummary, len(summaries))
+	for i, s := range summaries {
+		coins, err := droplet.ToString(s.Coins)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs[i] = AddressOutputsSummary{
+			Address: s.Address.String(),
+			Coins:   coins,
+			Hours:   s.Hours,
+			Count:   s.Count,
+		}
+
+		if s.Count == 0 {
+			continue
+		}
+
+		largestCoins, err := droplet.ToString(s.LargestCoins)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs[i].LargestOutput = &LargestOutput{
+			UxID:  s.LargestUxID.Hex(),
+			Coins: largestCoins,
+		}
+	}
+
+	return &OutputsSummaryResponse{
+		Addresses: addrs,
+	}, nil
+}
+
+// outputsSummaryHandler returns per-address totals of confirmed unspent outputs
+// URI: /api/v1/outputs/summary
+// Method: GET, POST
+// Args:
+//
+//	addrs: comma-separated list of addresses [required]
+//
+// Addresses without unspent outputs are included with zero values.
+// The results are returned in the same order as the requested addresses.
+func outputsSummaryHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		addrStr := r.FormValue("addrs")
+		if addrStr == "" {
+			wh.Error400(w, "addrs is required")
+			return
+		}
+
+		addrs, err := parseAddressesFromStr(addrStr)
+		if err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
+		if len(addrs) == 0 {
+			wh.Error400(w, "addrs is required")
+			return
+		}
+
+		summaries, err := gateway.GetAddressOutputsSummary(addrs)
+		if err != nil {
+			err = fmt.Errorf("gateway.GetAddressOutputsSummary failed: %v", err)
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		rlt, err := NewOutputsSummaryResponse(summaries)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, rlt)
+	}
+}
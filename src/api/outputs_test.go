@@ -13,9 +13,13 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/readable"
-	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 func TestGetOutputsHandler(t *testing.T) {
@@ -160,3 +164,333 @@ func TestGetOutputsHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOutputsByTxIDHandler(t *testing.T) {
+	addr1 := testutil.MakeAddress()
+	addr2 := testutil.MakeAddress()
+	spentTxID := testutil.RandSHA256(t)
+
+	txn := coin.Transaction{
+		In: []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{Address: addr1, Coins: 1e6, Hours: 10},
+			{Address: addr2, Coins: 2e6, Hours: 20},
+		},
+	}
+	txid := txn.Hash()
+	uxOuts := coin.CreateUnspents(coin.BlockHeader{BkSeq: 10}, txn)
+
+	genesisTxn := coin.Transaction{
+		Out: []coin.TransactionOutput{
+			{Address: addr1, Coins: 100e6, Hours: 100},
+		},
+	}
+	genesisUxOuts := coin.CreateUnspents(coin.BlockHeader{}, genesisTxn)
+
+	tt := []struct {
+		name       string
+		query      string
+		txn        *visor.Transaction
+		txnErr     error
+		uxOuts     map[cipher.SHA256]*historydb.UxOut
+		status     int
+		err        string
+		rsp        *TransactionOutputsResponse
+		genesisTxn bool
+	}{
+		{
+			name:   "400 - txid with addrs",
+			query:  "txid=" + txid.Hex() + "&addrs=" + addr1.String(),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - txid cannot be specified together with addrs or hashes",
+		},
+		{
+			name:   "400 - txid with hashes",
+			query:  "txid=" + txid.Hex() + "&hashes=" + uxOuts[0].Hash().Hex(),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - txid cannot be specified together with addrs or hashes",
+		},
+		{
+			name:   "400 - invalid txid",
+			query:  "txid=foo",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid txid",
+		},
+		{
+			name:   "404 - transaction not found",
+			query:  "txid=" + txid.Hex(),
+			status: http.StatusNotFound,
+			err:    "404 Not Found - transaction not found",
+		},
+		{
+			name:   "500 - GetTransaction error",
+			query:  "txid=" + txid.Hex(),
+			txnErr: errors.New("failed"),
+			status: http.StatusInternalServerError,
+			err:    "500 Internal Server Error - gateway.GetTransaction failed: failed",
+		},
+		{
+			name:  "200 - unconfirmed",
+			query: "txid=" + txid.Hex(),
+			txn: &visor.Transaction{
+				Transaction: txn,
+			},
+			status: http.StatusOK,
+			rsp: &TransactionOutputsResponse{
+				TxID: txid.Hex(),
+				Outputs: []TransactionOutputStatus{
+					{UxID: uxOuts[0].Hash().Hex(), Address: addr1.String(), Coins: "1.000000", Hours: 10},
+					{UxID: uxOuts[1].Hash().Hex(), Address: addr2.String(), Coins: "2.000000", Hours: 20},
+				},
+			},
+		},
+		{
+			name:  "200 - confirmed",
+			query: "txid=" + txid.Hex(),
+			txn: &visor.Transaction{
+				Transaction: txn,
+				Status:      visor.NewConfirmedTransactionStatus(2, 10),
+			},
+			uxOuts: map[cipher.SHA256]*historydb.UxOut{
+				uxOuts[0].Hash(): {Out: uxOuts[0], SpentTxnID: spentTxID, SpentBlockSeq: 11},
+				uxOuts[1].Hash(): {Out: uxOuts[1]},
+			},
+			status: http.StatusOK,
+			rsp: &TransactionOutputsResponse{
+				TxID:      txid.Hex(),
+				Confirmed: true,
+				Outputs: []TransactionOutputStatus{
+					{
+						UxID:          uxOuts[0].Hash().Hex(),
+						Address:       addr1.String(),
+						Coins:         "1.000000",
+						Hours:         10,
+						Spent:         true,
+						SpentTxID:     spentTxID.Hex(),
+						SpentBlockSeq: 11,
+					},
+					{UxID: uxOuts[1].Hash().Hex(), Address: addr2.String(), Coins: "2.000000", Hours: 20},
+				},
+			},
+		},
+		{
+			name:  "200 - genesis",
+			query: "txid=" + genesisTxn.Hash().Hex(),
+			txn: &visor.Transaction{
+				Transaction: genesisTxn,
+				Status:      visor.NewConfirmedTransactionStatus(11, 0),
+			},
+			uxOuts: map[cipher.SHA256]*historydb.UxOut{
+				genesisUxOuts[0].Hash(): {Out: genesisUxOuts[0]},
+			},
+			status: http.StatusOK,
+			rsp: &TransactionOutputsResponse{
+				TxID:      genesisTxn.Hash().Hex(),
+				Confirmed: true,
+				Outputs: []TransactionOutputStatus{
+					{UxID: genesisUxOuts[0].Hash().Hex(), Address: addr1.String(), Coins: "100.000000", Hours: 100},
+				},
+			},
+			genesisTxn: true,
+		},
+		{
+			name:  "500 - output not found",
+			query: "txid=" + txid.Hex(),
+			txn: &visor.Transaction{
+				Transaction: txn,
+				Status:      visor.NewConfirmedTransactionStatus(2, 10),
+			},
+			uxOuts: map[cipher.SHA256]*historydb.UxOut{},
+			status: http.StatusInternalServerError,
+			err:    "500 Internal Server Error - output " + uxOuts[0].Hash().Hex() + " of confirmed transaction " + txid.Hex() + " not found",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.genesisTxn {
+				gateway.On("GetTransaction", genesisTxn.Hash()).Return(tc.txn, tc.txnErr)
+			} else {
+				gateway.On("GetTransaction", txid).Return(tc.txn, tc.txnErr)
+			}
+			gateway.On("GetUxOutByID", mock.Anything).Return(func(id cipher.SHA256) *historydb.UxOut {
+				return tc.uxOuts[id]
+			}, nil)
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/outputs?"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg TransactionOutputsResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &msg))
+			require.Equal(t, *tc.rsp, msg)
+		})
+	}
+}
+
+func TestGetOutputsSummaryHandler(t *testing.T) {
+	addr1 := testutil.MakeAddress()
+	addr2 := testutil.MakeAddress()
+	uxID := testutil.RandSHA256(t)
+
+	summaries := []visor.AddressOutputsSummary{
+		{
+			Address:      addr1,
+			Coins:        30e6,
+			Hours:        1234,
+			Count:        2,
+			LargestUxID:  uxID,
+			LargestCoins: 20e6,
+		},
+		{
+			Address: addr2,
+		},
+	}
+
+	tt := []struct {
+		name          string
+		method        string
+		addrs         string
+		gatewayAddrs  []cipher.Address
+		gatewayResult []visor.AddressOutputsSummary
+		gatewayErr    error
+		status        int
+		err           string
+		rsp           *OutputsSummaryResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing addrs",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - addrs is required",
+		},
+		{
+			name:   "400 - empty addrs",
+			method: http.MethodGet,
+			addrs:  ",",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - addrs is required",
+		},
+		{
+			name:   "400 - invalid address",
+			method: http.MethodGet,
+			addrs:  "foo",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - address \"foo\" is invalid: Invalid address length",
+		},
+		{
+			name:         "500 - gateway error",
+			method:       http.MethodGet,
+			addrs:        addr1.String(),
+			gatewayAddrs: []cipher.Address{addr1},
+			gatewayErr:   errors.New("failed"),
+			status:       http.StatusInternalServerError,
+			err:          "500 Internal Server Error - gateway.GetAddressOutputsSummary failed: failed",
+		},
+		{
+			name:          "200 - GET",
+			method:        http.MethodGet,
+			addrs:         addr1.String() + "," + addr2.String(),
+			gatewayAddrs:  []cipher.Address{addr1, addr2},
+			gatewayResult: summaries,
+			status:        http.StatusOK,
+			rsp: &OutputsSummaryResponse{
+				Addresses: []AddressOutputsSummary{
+					{
+						Address: addr1.String(),
+						Coins:   "30.000000",
+						Hours:   1234,
+						Count:   2,
+						LargestOutput: &LargestOutput{
+							UxID:  uxID.Hex(),
+							Coins: "20.000000",
+						},
+					},
+					{
+						Address: addr2.String(),
+						Coins:   "0.000000",
+					},
+				},
+			},
+		},
+		{
+			name:          "200 - POST",
+			method:        http.MethodPost,
+			addrs:         addr2.String(),
+			gatewayAddrs:  []cipher.Address{addr2},
+			gatewayResult: summaries[1:],
+			status:        http.StatusOK,
+			rsp: &OutputsSummaryResponse{
+				Addresses: []AddressOutputsSummary{
+					{
+						Address: addr2.String(),
+						Coins:   "0.000000",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetAddressOutputsSummary", tc.gatewayAddrs).Return(tc.gatewayResult, tc.gatewayErr)
+
+			endpoint := "/api/v1/outputs/summary"
+
+			v := url.Values{}
+			if tc.addrs != "" {
+				v.Add("addrs", tc.addrs)
+			}
+
+			var reqBody io.Reader
+			if len(v) > 0 {
+				if tc.method == http.MethodPost {
+					reqBody = strings.NewReader(v.Encode())
+				} else {
+					endpoint += "?" + v.Encode()
+				}
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, reqBody)
+			require.NoError(t, err)
+
+			if tc.method == http.MethodPost {
+				req.Header.Set("Content-Type", ContentTypeForm)
+			}
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				var msg *OutputsSummaryResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.rsp, msg, tc.name)
+			}
+		})
+	}
+}
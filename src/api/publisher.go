@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+// auditLogger records the operator actions that change the role of the node
+var auditLogger = logging.MustGetLogger("audit")
+
+// PublisherStatusResponse is returned by the /api/v2/publisher endpoints
+type PublisherStatusResponse struct {
+	// Armed is true if the node creates and publishes blocks
+	Armed bool `json:"armed"`
+	// Configured is true if the node was started as a block publisher
+	Configured       bool   `json:"configured"`
+	BlockchainPubkey string `json:"blockchain_pubkey"`
+	// ChangedAt is the unix time the role was last armed or disarmed at runtime, 0 if it was never changed
+	ChangedAt int64 `json:"changed_at"`
+}
+
+// NewPublisherStatusResponse creates a PublisherStatusResponse
+func NewPublisherStatusResponse(s visor.BlockPublisherStatus) PublisherStatusResponse {
+	var changedAt int64
+	if !s.ChangedAt.IsZero() {
+		changedAt = s.ChangedAt.Unix()
+	}
+
+	return PublisherStatusResponse{
+		Armed:            s.Armed,
+		Configured:       s.Configured,
+		BlockchainPubkey: s.BlockchainPubkey.Hex(),
+		ChangedAt:        changedAt,
+	}
+}
+
+// PublisherArmRequest is the body of POST /api/v2/publisher/arm
+type PublisherArmRequest struct {
+	// SecretKey is the hex encoded blockchain secret key
+	SecretKey string `json:"secret_key"`
+}
+
+// Returns the block publisher role of the node
+// Method: GET
+// URI: /api/v2/publisher
+// Response: PublisherStatusResponse
+func publisherStatusHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewPublisherStatusResponse(gateway.BlockPublisherStatus()),
+		})
+	}
+}
+
+// Arms the block publisher role, the node creates and publishes blocks signed with the blockchain secret key
+// until it is disarmed. The secret key is only held in memory, it is not written to disk.
+// Method: POST
+// URI: /api/v2/publisher/arm
+// Args: JSON body with the hex encoded "secret_key" of the blockchain pubkey
+// Response: PublisherStatusResponse
+func publisherArmHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PublisherArmRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.SecretKey == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "secret_key is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		// The decoding error is not returned, it could include a part of the secret key
+		seckey, err := cipher.SecKeyFromHex(req.SecretKey)
+		if err != nil {
+			auditLog(r, "publisher.arm", visor.ErrInvalidPublisherKey)
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "Invalid secret_key")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		err = gateway.StartPublishing(seckey)
+		auditLog(r, "publisher.arm", err)
+		if err != nil {
+			writePublisherError(w, err)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewPublisherStatusResponse(gateway.BlockPublisherStatus()),
+		})
+	}
+}
+
+// Disarms the block publisher role and forgets the blockchain secret key.
+// A block being created is published, no block is created afterwards.
+// Method: POST
+// URI: /api/v2/publisher/disarm
+// Response: PublisherStatusResponse
+func publisherDisarmHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		err := gateway.StopPublishing()
+		auditLog(r, "publisher.disarm", err)
+		if err != nil {
+			writePublisherError(w, err)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewPublisherStatusResponse(gateway.BlockPublisherStatus()),
+		})
+	}
+}
+
+// writePublisherError writes the response of a failed arm or disarm request
+func writePublisherError(w http.ResponseWriter, err error) {
+	var resp HTTPResponse
+	switch err {
+	case visor.ErrInvalidPublisherKey:
+		resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	case visor.ErrBlockPublisherArmed, visor.ErrNotBlockPublisher:
+		resp = NewHTTPErrorResponse(http.StatusConflict, err.Error())
+	default:
+		resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+	writeHTTPResponse(w, resp)
+}
+
+// auditLog records an operator action of a request and its outcome in the audit log
+func auditLog(r *http.Request, action string, err error) {
+	user, _, _ := r.BasicAuth()
+
+	l := (&logging.Logger{
+		FieldLogger: plogging.FromContext(r.Context(), auditLogger),
+	}).Critical().WithFields(logrus.Fields{
+		"action":     action,
+		"remoteAddr": r.RemoteAddr,
+		"https":      r.TLS != nil,
+		"user":       user,
+	})
+
+	if err != nil {
+		l.WithError(err).Warning("Operator action failed")
+		return
+	}
+
+	l.Info("Operator action succeeded")
+}
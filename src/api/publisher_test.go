@@ -0,0 +1,217 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestPublisherEndpoints(t *testing.T) {
+	pubkey, seckey := cipher.GenerateKeyPair()
+
+	disarmed := visor.BlockPublisherStatus{
+		BlockchainPubkey: pubkey,
+	}
+	armed := visor.BlockPublisherStatus{
+		Armed:            true,
+		BlockchainPubkey: pubkey,
+		ChangedAt:        time.Unix(1540305209, 0),
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		endpoint   string
+		remoteAddr string
+		tls        bool
+		body       string
+		seckey     *cipher.SecKey
+		startErr   error
+		stop       bool
+		stopErr    error
+		status     visor.BlockPublisherStatus
+		httpStatus int
+		err        string
+		result     *PublisherStatusResponse
+	}{
+		{
+			name:       "status",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/publisher",
+			remoteAddr: "127.0.0.1:43210",
+			status:     disarmed,
+			httpStatus: http.StatusOK,
+			result: &PublisherStatusResponse{
+				BlockchainPubkey: pubkey.Hex(),
+			},
+		},
+		{
+			name:       "403 - status without HTTPS from a remote address",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/publisher",
+			remoteAddr: "1.2.3.4:43210",
+			httpStatus: http.StatusForbidden,
+			err:        "HTTPS or a localhost connection is required",
+		},
+		{
+			name:       "405 - arm",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "127.0.0.1:43210",
+			httpStatus: http.StatusMethodNotAllowed,
+			err:        "Method Not Allowed",
+		},
+		{
+			name:       "403 - arm without HTTPS from a remote address",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "1.2.3.4:43210",
+			body:       `{"secret_key":"` + seckey.Hex() + `"}`,
+			httpStatus: http.StatusForbidden,
+			err:        "HTTPS or a localhost connection is required",
+		},
+		{
+			name:       "400 - arm without secret key",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "127.0.0.1:43210",
+			body:       `{}`,
+			httpStatus: http.StatusBadRequest,
+			err:        "secret_key is required",
+		},
+		{
+			name:       "400 - arm with invalid secret key",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "127.0.0.1:43210",
+			body:       `{"secret_key":"abcd"}`,
+			httpStatus: http.StatusBadRequest,
+			err:        "Invalid secret_key",
+		},
+		{
+			name:       "400 - arm with another secret key",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "127.0.0.1:43210",
+			body:       `{"secret_key":"` + seckey.Hex() + `"}`,
+			seckey:     &seckey,
+			startErr:   visor.ErrInvalidPublisherKey,
+			httpStatus: http.StatusBadRequest,
+			err:        "Secret key is not the blockchain secret key",
+		},
+		{
+			name:       "409 - arm when armed",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "127.0.0.1:43210",
+			body:       `{"secret_key":"` + seckey.Hex() + `"}`,
+			seckey:     &seckey,
+			startErr:   visor.ErrBlockPublisherArmed,
+			httpStatus: http.StatusConflict,
+			err:        "Node is already a block publisher",
+		},
+		{
+			name:       "arm over HTTPS from a remote address",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/arm",
+			remoteAddr: "1.2.3.4:43210",
+			tls:        true,
+			body:       `{"secret_key":"` + seckey.Hex() + `"}`,
+			seckey:     &seckey,
+			status:     armed,
+			httpStatus: http.StatusOK,
+			result: &PublisherStatusResponse{
+				Armed:            true,
+				BlockchainPubkey: pubkey.Hex(),
+				ChangedAt:        1540305209,
+			},
+		},
+		{
+			name:       "409 - disarm when disarmed",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/disarm",
+			remoteAddr: "127.0.0.1:43210",
+			stop:       true,
+			stopErr:    visor.ErrNotBlockPublisher,
+			httpStatus: http.StatusConflict,
+			err:        "Node is not a block publisher",
+		},
+		{
+			name:       "500 - disarm",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/disarm",
+			remoteAddr: "[::1]:43210",
+			stop:       true,
+			stopErr:    errors.New("failed"),
+			httpStatus: http.StatusInternalServerError,
+			err:        "failed",
+		},
+		{
+			name:       "disarm",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/publisher/disarm",
+			remoteAddr: "[::1]:43210",
+			stop:       true,
+			status:     disarmed,
+			httpStatus: http.StatusOK,
+			result: &PublisherStatusResponse{
+				BlockchainPubkey: pubkey.Hex(),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("BlockPublisherStatus").Return(tc.status)
+			if tc.seckey != nil {
+				gateway.On("StartPublishing", *tc.seckey).Return(tc.startErr)
+			}
+			if tc.stop {
+				gateway.On("StopPublishing").Return(tc.stopErr)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.httpStatus, rr.Code)
+
+			var resp struct {
+				Error *HTTPError               `json:"error"`
+				Data  *PublisherStatusResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.httpStatus != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				if tc.httpStatus == http.StatusForbidden {
+					gateway.AssertNotCalled(t, "StartPublishing", seckey)
+				}
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+// DBSnapshotResponse is returned by POST /api/v2/db/snapshot
+type DBSnapshotResponse struct {
+	Path         string                 `json:"path"`
+	ManifestPath string                 `json:"manifest_path"`
+	Manifest     visor.SnapshotManifest `json:"manifest"`
+}
+
+// Creates a consistent snapshot of the database while the node runs, with a manifest
+// holding the head block and the sha256 of the snapshot file. The snapshot is written
+// to the node's snapshot directory. Start a node with -bootstrap-from-snapshot to use it.
+// Method: POST
+// URI: /api/v2/db/snapshot
+func dbSnapshotHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		s, err := gateway.CreateSnapshot()
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrSnapshotEmpty:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: DBSnapshotResponse{
+				Path:         s.Path,
+				ManifestPath: s.ManifestPath,
+				Manifest:     s.Manifest,
+			},
+		})
+	}
+}
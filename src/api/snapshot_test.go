@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestDBSnapshot(t *testing.T) {
+	manifest := visor.SnapshotManifest{
+		HeadSeq:     10,
+		HeadHash:    "bb8a1b2e3f0e0d6bd1f4c4b2fb5c0b4ca7f3c0c24ef1d29a1ba0e7c6d0a5a9f5",
+		GenesisHash: "0551a1e5af999fe8fff529f6f2ab341e1e33db95135eef1b2be44fe6981349f3",
+		Size:        65536,
+		SHA256:      "4f1c1ea3a2e3b7b2b9b0e6d9f5e3c2a1b0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5",
+		CreatedAt:   1600000000,
+	}
+
+	cases := []struct {
+		name        string
+		method      string
+		snapshot    *visor.Snapshot
+		snapshotErr error
+		status      int
+		err         string
+		result      *DBSnapshotResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
+		},
+		{
+			name:        "503 - no blocks",
+			method:      http.MethodPost,
+			snapshotErr: visor.ErrSnapshotEmpty,
+			status:      http.StatusServiceUnavailable,
+			err:         "snapshot has no blocks",
+		},
+		{
+			name:        "500 - snapshot failed",
+			method:      http.MethodPost,
+			snapshotErr: errors.New("disk full"),
+			status:      http.StatusInternalServerError,
+			err:         "disk full",
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			snapshot: &visor.Snapshot{
+				Path:         "/data/snapshots/data-10-20200913T122640Z.db",
+				ManifestPath: "/data/snapshots/data-10-20200913T122640Z.db.manifest.json",
+				Manifest:     manifest,
+			},
+			status: http.StatusOK,
+			result: &DBSnapshotResponse{
+				Path:         "/data/snapshots/data-10-20200913T122640Z.db",
+				ManifestPath: "/data/snapshots/data-10-20200913T122640Z.db.manifest.json",
+				Manifest:     manifest,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("CreateSnapshot").Return(tc.snapshot, tc.snapshotErr)
+
+			req, err := http.NewRequest(tc.method, "/api/v2/db/snapshot", nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp struct {
+				Error *HTTPError          `json:"error"`
+				Data  *DBSnapshotResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.status != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
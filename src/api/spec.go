@@ -0,0 +1,539 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
+)
+
+/*
+The OpenAPI spec served at /api/v1/spec.json is generated from the routes registered in newServerMux,
+so that the paths, methods and API sets can't drift from the code.
+Each method of each route is documented in endpointDocs, with its parameters and the Go types of its
+request and response bodies. The JSON schemas of the bodies are generated from the types by reflection,
+following their json struct tags.
+
+newOpenAPISpec fails if a registered route is not documented, or if a documented route is not registered.
+*/
+
+const (
+	openAPIVersion = "3.0.3"
+
+	paramString  = "string"
+	paramInteger = "integer"
+	paramBoolean = "boolean"
+
+	contentTypeJSON = "application/json"
+	contentTypeForm = "application/x-www-form-urlencoded"
+	contentTypeText = "text/plain"
+
+	contentTypeOctetStream = "application/octet-stream"
+	contentTypeNDJSON      = "application/x-ndjson"
+)
+
+// apiRoute is a route registered in newServerMux
+type apiRoute struct {
+	apiVersion string
+	path       string
+	// methodAPISets is nil for routes that are always enabled
+	methodAPISets map[string][]string
+}
+
+// specParam is a query or form parameter of an endpoint, or a parameter in its path
+type specParam struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+	// Path is set for parameters in the path, such as {addr} in /explorer/address/{addr}
+	Path bool
+}
+
+// param returns an optional specParam
+func param(name, typ, description string) specParam {
+	return specParam{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+	}
+}
+
+// requiredParam returns a required specParam
+func requiredParam(name, typ, description string) specParam {
+	p := param(name, typ, description)
+	p.Required = true
+	return p
+}
+
+// pathParam returns a specParam in the path of the endpoint, which is always required
+func pathParam(name, typ, description string) specParam {
+	p := requiredParam(name, typ, description)
+	p.Path = true
+	return p
+}
+
+// specOneOf is used as an endpointDoc.Response when the response type depends on the parameters
+type specOneOf []interface{}
+
+// endpointDoc documents a method of an endpoint
+type endpointDoc struct {
+	Summary string
+	// Params are the query parameters, or the form parameters of v1 POST requests
+	Params []specParam
+	// Request is a value of the type of the JSON request body
+	Request interface{}
+	// Response is a value of the type of the JSON response body, or a specOneOf.
+	// For v2 endpoints, it is the type of the response's "data" field.
+	Response interface{}
+	// ContentType of the response, if it's not JSON
+	ContentType string
+}
+
+// openAPISpec is an OpenAPI 3 document
+type openAPISpec struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       specInfo                             `json:"info"`
+	Paths      map[string]map[string]*specOperation `json:"paths"`
+	Components specComponents                       `json:"components"`
+}
+
+type specInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type specComponents struct {
+	Schemas map[string]*specSchema `json:"schemas"`
+}
+
+type specOperation struct {
+	Summary     string                   `json:"summary,omitempty"`
+	Parameters  []specParameter          `json:"parameters,omitempty"`
+	RequestBody *specRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*specResponse `json:"responses"`
+	APISets     []string                 `json:"x-api-sets,omitempty"`
+}
+
+type specParameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Required    bool        `json:"required,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Schema      *specSchema `json:"schema"`
+}
+
+type specRequestBody struct {
+	Required bool                     `json:"required,omitempty"`
+	Content  map[string]specMediaType `json:"content"`
+}
+
+type specResponse struct {
+	Description string                   `json:"description"`
+	Content     map[string]specMediaType `json:"content,omitempty"`
+}
+
+type specMediaType struct {
+	Schema *specSchema `json:"schema"`
+}
+
+// specSchema is an OpenAPI schema object
+type specSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*specSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *specSchema            `json:"items,omitempty"`
+	AdditionalProperties *specSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*specSchema          `json:"oneOf,omitempty"`
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	apiPkgPath        = reflect.TypeOf(HTTPResponse{}).PkgPath()
+)
+
+// specSchemas generates schemas from Go types, collecting the schemas of named struct types as components
+type specSchemas struct {
+	schemas map[string]*specSchema
+	types   map[string]reflect.Type
+}
+
+func newSpecSchemas() *specSchemas {
+	return &specSchemas{
+		schemas: make(map[string]*specSchema),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
+// schemaName returns the component name of a named type.
+// Types of this package are named as is, other types are prefixed with their package name.
+func (s *specSchemas) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if t.PkgPath() != apiPkgPath {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+
+	// Disambiguate same-named packages, e.g. readable from this module and from skycoin
+	if other, ok := s.types[name]; ok && other != t {
+		name = strings.Replace(t.PkgPath(), "/", ".", -1) + "." + t.Name()
+	}
+
+	return name
+}
+
+// schemaOf returns the schema of values of type t, as marshaled by encoding/json
+func (s *specSchemas) schemaOf(t reflect.Type) *specSchema {
+	if t == nil {
+		return &specSchema{}
+	}
+
+	if t == timeType {
+		return &specSchema{
+			Type:   "string",
+			Format: "date-time",
+		}
+	}
+
+	// Custom JSON encodings, e.g. for durations, coins and hashes, are strings
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &specSchema{
+			Type: "string",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &specSchema{
+			Type: "boolean",
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &specSchema{
+			Type: "integer",
+		}
+	case reflect.Float32, reflect.Float64:
+		return &specSchema{
+			Type: "number",
+		}
+	case reflect.String:
+		return &specSchema{
+			Type: "string",
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &specSchema{
+				Type:   "string",
+				Format: "byte",
+			}
+		}
+		return &specSchema{
+			Type:  "array",
+			Items: s.schemaOf(t.Elem()),
+		}
+	case reflect.Map:
+		return &specSchema{
+			Type:                 "object",
+			AdditionalProperties: s.schemaOf(t.Elem()),
+		}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+
+		name := s.schemaName(t)
+		if _, ok := s.types[name]; !ok {
+			// Register the type before generating its schema, for recursive types
+			s.types[name] = t
+			s.schemas[name] = s.structSchema(t)
+		}
+
+		return &specSchema{
+			Ref: "#/components/schemas/" + name,
+		}
+	default:
+		// interface{} can be any value
+		return &specSchema{}
+	}
+}
+
+// structSchema returns the object schema of a struct type, with the fields of embedded structs inlined
+func (s *specSchemas) structSchema(t reflect.Type) *specSchema {
+	schema := &specSchema{
+		Type:       "object",
+		Properties: make(map[string]*specSchema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct && !ft.Implements(jsonMarshalerType) {
+				embedded := s.structSchema(ft)
+				for k, v := range embedded.Properties {
+					schema.Properties[k] = v
+				}
+				continue
+			}
+		}
+
+		// Unexported fields are not marshaled
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := s.schemaOf(f.Type)
+		for _, o := range opts[1:] {
+			if o == "string" {
+				fs = &specSchema{
+					Type: "string",
+				}
+			}
+		}
+
+		schema.Properties[name] = fs
+	}
+
+	return schema
+}
+
+// bodySchema returns the schema of a documented request or response body value
+func (s *specSchemas) bodySchema(v interface{}) *specSchema {
+	if oneOf, ok := v.(specOneOf); ok {
+		schema := &specSchema{}
+		for _, x := range oneOf {
+			schema.OneOf = append(schema.OneOf, s.schemaOf(reflect.TypeOf(x)))
+		}
+		return schema
+	}
+
+	return s.schemaOf(reflect.TypeOf(v))
+}
+
+// paramsSchema returns the object schema of form parameters
+func paramsSchema(params []specParam) *specSchema {
+	schema := &specSchema{
+		Type:       "object",
+		Properties: make(map[string]*specSchema, len(params)),
+	}
+
+	for _, p := range params {
+		schema.Properties[p.Name] = &specSchema{
+			Type:        p.Type,
+			Description: p.Description,
+		}
+		if p.Required {
+			schema.Required = append(schema.Required, p.Name)
+		}
+	}
+
+	return schema
+}
+
+// newSpecOperation generates the OpenAPI operation of a documented endpoint method
+func newSpecOperation(r apiRoute, method string, doc endpointDoc, schemas *specSchemas) *specOperation {
+	op := &specOperation{
+		Summary:   doc.Summary,
+		Responses: make(map[string]*specResponse),
+	}
+
+	if r.methodAPISets != nil {
+		op.APISets = r.methodAPISets[method]
+	}
+
+	// v1 POST endpoints read form parameters, other parameters are read from the query string
+	if r.apiVersion == apiVersion1 && method == http.MethodPost && len(doc.Params) != 0 {
+		content := map[string]specMediaType{
+			contentTypeForm: {
+				Schema: paramsSchema(doc.Params),
+			},
+		}
+		if doc.Request != nil {
+			content[contentTypeJSON] = specMediaType{
+				Schema: schemas.bodySchema(doc.Request),
+			}
+		}
+		op.RequestBody = &specRequestBody{
+			Content: content,
+		}
+	} else {
+		for _, p := range doc.Params {
+			in := "query"
+			if p.Path {
+				in = "path"
+			}
+
+			op.Parameters = append(op.Parameters, specParameter{
+				Name:        p.Name,
+				In:          in,
+				Required:    p.Required,
+				Description: p.Description,
+				Schema: &specSchema{
+					Type: p.Type,
+				},
+			})
+		}
+
+		if doc.Request != nil {
+			op.RequestBody = &specRequestBody{
+				Required: true,
+				Content: map[string]specMediaType{
+					contentTypeJSON: {
+						Schema: schemas.bodySchema(doc.Request),
+					},
+				},
+			}
+		}
+	}
+
+	resp := &specResponse{
+		Description: "OK",
+	}
+
+	switch {
+	case doc.ContentType != "":
+		resp.Content = map[string]specMediaType{
+			doc.ContentType: {
+				Schema: &specSchema{
+					Type: "string",
+				},
+			},
+		}
+	case r.apiVersion == apiVersion2:
+		// v2 responses are wrapped in an HTTPResponse
+		envelope := &specSchema{
+			Type: "object",
+			Properties: map[string]*specSchema{
+				"error": schemas.schemaOf(reflect.TypeOf(HTTPError{})),
+			},
+		}
+		if doc.Response != nil {
+			envelope.Properties["data"] = schemas.bodySchema(doc.Response)
+		}
+		resp.Content = map[string]specMediaType{
+			contentTypeJSON: {
+				Schema: envelope,
+			},
+		}
+	case doc.Response != nil:
+		resp.Content = map[string]specMediaType{
+			contentTypeJSON: {
+				Schema: schemas.bodySchema(doc.Response),
+			},
+		}
+	}
+
+	op.Responses["200"] = resp
+
+	return op
+}
+
+// newOpenAPISpec generates the OpenAPI spec of the registered routes
+func newOpenAPISpec(routes []apiRoute, bi readable.BuildInfo) (*openAPISpec, error) {
+	spec := &openAPISpec{
+		OpenAPI: openAPIVersion,
+		Info: specInfo{
+			Title:   "Privateness node REST API",
+			Version: bi.Version,
+		},
+		Paths: make(map[string]map[string]*specOperation, len(routes)),
+	}
+
+	schemas := newSpecSchemas()
+
+	for _, r := range routes {
+		docs, ok := endpointDocs[r.path]
+		if !ok {
+			return nil, fmt.Errorf("endpoint %s is not documented", r.path)
+		}
+
+		methods := make([]string, 0, len(docs))
+		if r.methodAPISets == nil {
+			for m := range docs {
+				methods = append(methods, m)
+			}
+		} else {
+			for m := range r.methodAPISets {
+				methods = append(methods, m)
+			}
+			for m := range docs {
+				if _, ok := r.methodAPISets[m]; !ok {
+					return nil, fmt.Errorf("endpoint %s %s is documented but not registered", m, r.path)
+				}
+			}
+		}
+		sort.Strings(methods)
+
+		ops := make(map[string]*specOperation, len(methods))
+		for _, m := range methods {
+			doc, ok := docs[m]
+			if !ok {
+				return nil, fmt.Errorf("endpoint %s %s is not documented", m, r.path)
+			}
+
+			ops[strings.ToLower(m)] = newSpecOperation(r, m, doc, schemas)
+		}
+
+		spec.Paths[r.path] = ops
+	}
+
+	for p := range endpointDocs {
+		if _, ok := spec.Paths[p]; !ok {
+			return nil, fmt.Errorf("endpoint %s is documented but not registered", p)
+		}
+	}
+
+	spec.Components.Schemas = schemas.schemas
+
+	return spec, nil
+}
+
+// specHandler returns the OpenAPI spec of the API
+// URI: /api/v1/spec.json
+// Method: GET
+func specHandler(routes *[]apiRoute, bi readable.BuildInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		spec, err := newOpenAPISpec(*routes, bi)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, spec)
+	}
+}
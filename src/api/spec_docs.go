@@ -0,0 +1,1052 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ness-network/privateness/src/readable"
+
+	"github.com/ness-network/privateness/src/daemon/pex"
+)
+
+// walletIDParam is the id parameter of the wallet endpoints
+var walletIDParam = requiredParam("id", paramString, "wallet id")
+
+// verboseParam is the verbose parameter of endpoints returning transactions or blocks
+var verboseParam = param("verbose", paramBoolean, "include verbose transaction input data")
+
+// txnGraphParams are the parameters of the transaction graph endpoints
+var txnGraphParams = []specParam{
+	requiredParam("uxid", paramString, "output ID"),
+	param("depth", paramInteger, "maximum number of transactions between the output and the nodes, defaults to 5, at most 50"),
+	param("max_nodes", paramInteger, "maximum number of nodes, defaults to 1000, at most 10000"),
+}
+
+// endpointDocs documents the methods of every registered endpoint, keyed by path and method.
+// newOpenAPISpec fails if the registered routes and endpointDocs differ.
+var endpointDocs = map[string]map[string]endpointDoc{
+	"/api/v1/address_uxouts": {
+		http.MethodGet: {
+			Summary: "Returns the historical, spent outputs associated with an address",
+			Params: []specParam{
+				requiredParam("address", paramString, "address"),
+			},
+			Response: []readable.SpentOutput{},
+		},
+	},
+	"/api/v1/addresscount": {
+		http.MethodGet: {
+			Summary:  "Returns the number of unique addresses that have coins",
+			Response: map[string]uint64{},
+		},
+	},
+	"/api/v1/balance": {
+		http.MethodGet: {
+			Summary: "Returns the confirmed and predicted balance of addresses",
+			Params: []specParam{
+				requiredParam("addrs", paramString, "comma-separated list of addresses"),
+			},
+			Response: BalanceResponse{},
+		},
+		http.MethodPost: {
+			Summary: "Returns the confirmed and predicted balance of addresses. " +
+				"A JSON request body returns the balance of each address",
+			Params: []specParam{
+				requiredParam("addrs", paramString, "comma-separated list of addresses"),
+			},
+			Request:  BalanceRequest{},
+			Response: specOneOf{BalanceResponse{}, BalanceBatchResponse{}},
+		},
+	},
+	"/api/v1/block": {
+		http.MethodGet: {
+			Summary: "Returns a block by hash or seq",
+			Params: []specParam{
+				param("hash", paramString, "block hash, can't be combined with seq"),
+				param("seq", paramInteger, "block seq, can't be combined with hash"),
+				verboseParam,
+				param("raw", paramBoolean, "include the hex-encoded serialized block"),
+			},
+			Response: specOneOf{readable.Block{}, readable.BlockVerbose{}, BlockRaw{}, BlockVerboseRaw{}},
+		},
+	},
+	"/api/v1/blockchain/metadata": {
+		http.MethodGet: {
+			Summary: "Returns the blockchain metadata",
+			Params: []specParam{
+				param("wait_after_seq", paramInteger, "wait until the head block seq is greater than this seq"),
+				param("timeout", paramString, "maximum duration to wait for a new block"),
+			},
+			Response: readable.BlockchainMetadata{},
+		},
+	},
+	"/api/v1/blockchain/progress": {
+		http.MethodGet: {
+			Summary:  "Returns the blockchain sync progress",
+			Response: readable.BlockchainProgress{},
+		},
+	},
+	"/api/v1/blocks": {
+		http.MethodGet: {
+			Summary: "Returns blocks by seq range or by a list of seqs",
+			Params: []specParam{
+				param("start", paramInteger, "first block seq of the range"),
+				param("end", paramInteger, "last block seq of the range"),
+				param("seqs", paramString, "comma-separated list of block seqs, can't be combined with start and end"),
+				verboseParam,
+			},
+			Response: specOneOf{readable.Blocks{}, readable.BlocksVerbose{}},
+		},
+		http.MethodPost: {
+			Summary: "Returns blocks by seq range or by a list of seqs",
+			Params: []specParam{
+				param("start", paramInteger, "first block seq of the range"),
+				param("end", paramInteger, "last block seq of the range"),
+				param("seqs", paramString, "comma-separated list of block seqs, can't be combined with start and end"),
+				verboseParam,
+			},
+			Response: specOneOf{readable.Blocks{}, readable.BlocksVerbose{}},
+		},
+	},
+	"/api/v1/coinSupply": {
+		http.MethodGet: {
+			Summary:  "Returns coin distribution supply stats",
+			Response: CoinSupply{},
+		},
+	},
+	"/api/v1/coinSupply/projection": {
+		http.MethodGet: {
+			Summary: "Returns the coin supply at a point in time, projected from the unlock schedule",
+			Params: []specParam{
+				param("at", paramInteger, "unix time of the projection, defaults to now"),
+				param("start", paramInteger, "unix time at which the unlock schedule starts, defaults to the genesis block timestamp"),
+			},
+			Response: CoinSupplyProjection{},
+		},
+	},
+	"/api/v1/csrf": {
+		http.MethodGet: {
+			Summary:  "Returns a CSRF token for the client session",
+			Response: map[string]string{},
+		},
+	},
+	"/api/v1/csrf/rotate": {
+		http.MethodPost: {
+			Summary:  "Rotates the CSRF secret, invalidating all CSRF tokens, and returns a new token",
+			Response: map[string]string{},
+		},
+	},
+	"/api/v1/explorer/address/{addr}": {
+		http.MethodGet: {
+			Summary: "Returns the balance, outputs, pending outputs, recent transactions and activity of an address in one call",
+			Params: []specParam{
+				pathParam("addr", paramString, "address"),
+				param("balance", paramBoolean, "include the confirmed balance, defaults to true"),
+				param("outputs", paramBoolean, "include the unspent output count and the outputs with the most coins, defaults to true"),
+				param("top_outputs", paramInteger, "number of outputs, defaults to 10"),
+				param("pending", paramBoolean, "include the unconfirmed incoming and outgoing outputs, defaults to true"),
+				param("transactions", paramBoolean, "include the total and the most recent confirmed transactions, defaults to true"),
+				param("limit", paramInteger, "number of recent transactions, defaults to 10"),
+				param("activity", paramBoolean, "include the first seen and last active blocks, defaults to true"),
+			},
+			Response: AddressExplorer{},
+		},
+	},
+	"/api/v1/health": {
+		http.MethodGet: {
+			Summary:  "Returns node health data",
+			Response: HealthResponse{},
+		},
+	},
+	"/api/v1/injectTransaction": {
+		http.MethodPost: {
+			Summary:  "Broadcasts a hex-encoded serialized transaction and returns its ID",
+			Request:  InjectTransactionRequest{},
+			Response: "",
+		},
+	},
+	"/api/v1/last_blocks": {
+		http.MethodGet: {
+			Summary: "Returns the most recent blocks",
+			Params: []specParam{
+				requiredParam("num", paramInteger, "number of blocks"),
+				verboseParam,
+			},
+			Response: specOneOf{readable.Blocks{}, readable.BlocksVerbose{}},
+		},
+	},
+	"/api/v1/network/bandwidth": {
+		http.MethodGet: {
+			Summary:  "Returns the bandwidth limits of block transfers",
+			Response: BandwidthLimitsResponse{},
+		},
+		http.MethodPost: {
+			Summary: "Changes the bandwidth limits of block transfers",
+			Params: []specParam{
+				param("max_upload_kbps", paramInteger, "maximum upload rate in kilobits per second, 0 is unlimited"),
+				param("max_download_kbps", paramInteger, "maximum download rate in kilobits per second, 0 is unlimited"),
+			},
+			Response: BandwidthLimitsResponse{},
+		},
+	},
+	"/api/v1/network/bans": {
+		http.MethodGet: {
+			Summary:  "Returns the ban thresholds, the offense counters of peer IPs and the banned peer IPs",
+			Response: PeerBansResponse{},
+		},
+	},
+	"/api/v1/network/connection": {
+		http.MethodGet: {
+			Summary: "Returns a connection by address",
+			Params: []specParam{
+				requiredParam("addr", paramString, "connection address, ip:port"),
+			},
+			Response: readable.Connection{},
+		},
+	},
+	"/api/v1/network/connection/disconnect": {
+		http.MethodPost: {
+			Summary: "Disconnects a connection by ID",
+			Params: []specParam{
+				requiredParam("id", paramInteger, "connection gnet ID"),
+			},
+			Response: struct{}{},
+		},
+	},
+	"/api/v1/network/connections": {
+		http.MethodGet: {
+			Summary: "Returns the connections",
+			Params: []specParam{
+				param("states", paramString, "comma-separated list of connection states, defaults to connected and introduced"),
+				param("direction", paramString, `"outgoing" or "incoming", defaults to both`),
+			},
+			Response: Connections{},
+		},
+	},
+	"/api/v1/network/connections/exchange": {
+		http.MethodGet: {
+			Summary:  "Returns the peers found through peer exchange",
+			Response: []string{},
+		},
+	},
+	"/api/v1/network/connections/trust": {
+		http.MethodGet: {
+			Summary:  "Returns the trusted peers",
+			Response: []string{},
+		},
+	},
+	"/api/v1/network/defaultConnections": {
+		http.MethodGet: {
+			Summary:  "Returns the default bootstrap peers",
+			Response: []string{},
+		},
+	},
+	"/api/v1/network/peers": {
+		http.MethodGet: {
+			Summary:  "Returns all known peers with their quality scores, highest score first",
+			Response: NetworkPeersResponse{},
+		},
+	},
+	"/api/v1/network/peers/export": {
+		http.MethodGet: {
+			Summary: "Returns a bundle of known-good peers, optionally signed with the node key",
+			Params: []specParam{
+				param("min_score", paramInteger, "minimum quality score of the peers, default 0"),
+				param("max_age", paramString, "exclude peers last seen longer ago than this duration, e.g. 24h"),
+				param("limit", paramInteger, "maximum number of peers, highest score first"),
+				param("sign", paramBoolean, "sign the bundle with the node key"),
+			},
+			Response: pex.PeerBundle{},
+		},
+	},
+	"/api/v1/network/peers/import": {
+		http.MethodPost: {
+			Summary:  "Adds the peers of a peer bundle to the peer list, verifying its signature if trusted keys are configured",
+			Request:  pex.PeerBundle{},
+			Response: PeersImportResponse{},
+		},
+	},
+	"/api/v1/network/propagation": {
+		http.MethodGet: {
+			Summary: "Returns how a recently seen transaction or block propagated through the network",
+			Params: []specParam{
+				param("txid", paramString, "transaction ID, required unless block is given"),
+				param("block", paramString, "block hash, required unless txid is given"),
+			},
+			Response: PropagationResponse{},
+		},
+	},
+	"/api/v1/network/unban": {
+		http.MethodPost: {
+			Summary: "Lifts the ban of a peer IP and resets its offenses",
+			Params: []specParam{
+				requiredParam("ip", paramString, "the banned IP"),
+			},
+			Response: PeerBansResponse{},
+		},
+	},
+	"/api/v1/node": {
+		http.MethodGet: {
+			Summary:  "Returns the node's identity and build info",
+			Response: NodeResponse{},
+		},
+	},
+	"/api/v1/outputs": {
+		http.MethodGet: {
+			Summary: "Returns the unspent outputs of addresses or by hash",
+			Params: []specParam{
+				param("addrs", paramString, "comma-separated list of addresses, can't be combined with hashes"),
+				param("hashes", paramString, "comma-separated list of output hashes, can't be combined with addrs"),
+				param("txid", paramString, "transaction id, returns the outputs created by the transaction and whether they are spent instead, can't be combined with addrs or hashes"),
+			},
+			Response: readable.UnspentOutputsSummary{},
+		},
+		http.MethodPost: {
+			Summary: "Returns the unspent outputs of addresses or by hash",
+			Params: []specParam{
+				param("addrs", paramString, "comma-separated list of addresses, can't be combined with hashes"),
+				param("hashes", paramString, "comma-separated list of output hashes, can't be combined with addrs"),
+				param("txid", paramString, "transaction id, returns the outputs created by the transaction and whether they are spent instead, can't be combined with addrs or hashes"),
+			},
+			Response: readable.UnspentOutputsSummary{},
+		},
+	},
+	"/api/v1/outputs/summary": {
+		http.MethodGet: {
+			Summary: "Returns per-address totals of confirmed unspent outputs",
+			Params: []specParam{
+				requiredParam("addrs", paramString, "comma-separated list of addresses"),
+			},
+			Response: OutputsSummaryResponse{},
+		},
+		http.MethodPost: {
+			Summary: "Returns per-address totals of confirmed unspent outputs",
+			Params: []specParam{
+				requiredParam("addrs", paramString, "comma-separated list of addresses"),
+			},
+			Response: OutputsSummaryResponse{},
+		},
+	},
+	"/api/v1/pendingTxs": {
+		http.MethodGet: {
+			Summary: "Returns the unconfirmed transactions",
+			Params: []specParam{
+				verboseParam,
+				param("stuck", paramBoolean, "only return the transactions that can't be confirmed"),
+			},
+			Response: specOneOf{[]PendingTxn{}, []PendingTxnVerbose{}},
+		},
+		http.MethodDelete: {
+			Summary: "Abandons an unconfirmed transaction",
+			Params: []specParam{
+				requiredParam("txid", paramString, "transaction ID"),
+			},
+			Response: AbandonPendingTxnResponse{},
+		},
+	},
+	"/api/v1/pendingTxs/conflicts": {
+		http.MethodGet: {
+			Summary:  "Returns the groups of unconfirmed transactions that spend the same output",
+			Response: UnconfirmedConflictsResponse{},
+		},
+	},
+	"/api/v1/rawtx": {
+		http.MethodGet: {
+			Summary: "Returns the hex-encoded serialized transaction",
+			Params: []specParam{
+				requiredParam("txid", paramString, "transaction ID"),
+			},
+			Response: "",
+		},
+	},
+	"/api/v1/resendUnconfirmedTxns": {
+		http.MethodPost: {
+			Summary:  "Broadcasts the unconfirmed transactions again",
+			Response: ResendResult{},
+		},
+	},
+	"/api/v1/richlist": {
+		http.MethodGet: {
+			Summary: "Returns the addresses with the most coins",
+			Params: []specParam{
+				param("n", paramInteger, "number of addresses, defaults to 20"),
+				param("include-distribution", paramBoolean, "include the distribution addresses"),
+			},
+			Response: Richlist{},
+		},
+	},
+	"/api/v1/spec.json": {
+		http.MethodGet: {
+			Summary:  "Returns the OpenAPI spec of the API",
+			Response: map[string]interface{}{},
+		},
+	},
+	"/api/v1/transaction": {
+		http.MethodGet: {
+			Summary: "Returns a transaction by ID",
+			Params: []specParam{
+				requiredParam("txid", paramString, "transaction ID"),
+				verboseParam,
+				param("encoded", paramBoolean, "return the hex-encoded serialized transaction"),
+			},
+			Response: specOneOf{
+				readable.TransactionWithStatus{},
+				TransactionWithStatusVerbose{},
+				TransactionEncodedResponse{},
+			},
+		},
+	},
+	"/api/v1/transactions": {
+		http.MethodGet: {
+			Summary: "Returns the transactions of addresses",
+			Params: []specParam{
+				param("addrs", paramString, "comma-separated list of addresses"),
+				param("confirmed", paramBoolean, "only return confirmed or unconfirmed transactions"),
+				param("start_block", paramInteger, "only return confirmed transactions executed in this block or later, requires addrs"),
+				param("end_block", paramInteger, "only return confirmed transactions executed in this block or earlier, requires addrs"),
+				verboseParam,
+			},
+			Response: specOneOf{[]readable.TransactionWithStatus{}, []TransactionWithStatusVerbose{}},
+		},
+		http.MethodPost: {
+			Summary: "Returns the transactions of addresses",
+			Params: []specParam{
+				param("addrs", paramString, "comma-separated list of addresses"),
+				param("confirmed", paramBoolean, "only return confirmed or unconfirmed transactions"),
+				param("start_block", paramInteger, "only return confirmed transactions executed in this block or later, requires addrs"),
+				param("end_block", paramInteger, "only return confirmed transactions executed in this block or earlier, requires addrs"),
+				verboseParam,
+			},
+			Response: specOneOf{[]readable.TransactionWithStatus{}, []TransactionWithStatusVerbose{}},
+		},
+	},
+	"/api/v1/uxout": {
+		http.MethodGet: {
+			Summary: "Returns an output by ID",
+			Params: []specParam{
+				requiredParam("uxid", paramString, "output ID"),
+			},
+			Response: readable.SpentOutput{},
+		},
+	},
+	"/api/v1/verification-params": {
+		http.MethodGet: {
+			Summary:  "Returns the node's transaction verification parameters and limits, for wallets that create transactions without the node",
+			Response: VerificationParamsResponse{},
+		},
+	},
+	"/api/v1/version": {
+		http.MethodGet: {
+			Summary:  "Returns the application version info",
+			Response: readable.BuildInfo{},
+		},
+	},
+	"/api/v1/wallet": {
+		http.MethodGet: {
+			Summary: "Returns a wallet by ID",
+			Params: []specParam{
+				walletIDParam,
+			},
+			Response: WalletResponse{},
+		},
+	},
+	"/api/v1/wallet/address/label": {
+		http.MethodPost: {
+			Summary: "Changes the label of a wallet address",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("address", paramString, "wallet address"),
+				param("label", paramString, "address label, empty to clear it"),
+			},
+			Response: "",
+		},
+	},
+	"/api/v1/wallet/balance": {
+		http.MethodGet: {
+			Summary: "Returns the confirmed and predicted balance of a wallet",
+			Params: []specParam{
+				walletIDParam,
+			},
+			Response: BalanceResponse{},
+		},
+	},
+	"/api/v1/wallet/balance/history": {
+		http.MethodGet: {
+			Summary: "Returns the confirmed balance of a wallet over time, as [timestamp, coins, hours] points",
+			Params: []specParam{
+				walletIDParam,
+				param("granularity", paramString, `"day", "week" or "block", defaults to "day"`),
+				param("from", paramInteger, "unix time of the first point"),
+				param("to", paramInteger, "unix time of the last point"),
+			},
+			Response: WalletBalanceHistoryResponse{},
+		},
+	},
+	"/api/v1/wallet/create": {
+		http.MethodPost: {
+			Summary: "Creates a wallet",
+			Params: []specParam{
+				param("seed", paramString, "wallet seed, required unless bits is set"),
+				param("bits", paramInteger, "generate a mnemonic seed with this entropy size"),
+				param("seed-passphrase", paramString, "seed passphrase of bip44 wallets"),
+				requiredParam("type", paramString, `wallet type, "deterministic", "bip44" or "xpub"`),
+				param("bip44-coin", paramInteger, "BIP44 coin type of bip44 wallets"),
+				param("xpub", paramString, "xpub key of xpub wallets"),
+				requiredParam("label", paramString, "wallet label"),
+				param("scan", paramInteger, "number of addresses to scan ahead for balances"),
+				param("encrypt", paramBoolean, "encrypt the wallet"),
+				param("password", paramString, "wallet password, required if encrypt is set"),
+				param("access-token", paramBoolean, "set an access token for the wallet"),
+			},
+			Response: specOneOf{WalletResponse{}, WalletCreateResponse{}},
+		},
+	},
+	"/api/v1/wallet/decrypt": {
+		http.MethodPost: {
+			Summary: "Decrypts a wallet",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("password", paramString, "wallet password"),
+			},
+			Response: WalletResponse{},
+		},
+	},
+	"/api/v1/wallet/derive-child": {
+		http.MethodPost: {
+			Summary: "Creates a bip44 child wallet from a bip85 mnemonic derived from the seed of a bip44 wallet",
+			Params: []specParam{
+				requiredParam("id", paramString, "parent wallet id"),
+				requiredParam("index", paramInteger, "application index of the child mnemonic"),
+				param("words", paramInteger, "number of words of the child mnemonic, 12, 18 or 24"),
+				requiredParam("label", paramString, "child wallet label"),
+				param("password", paramString, "parent wallet password, required if it is encrypted"),
+				param("scan", paramInteger, "number of addresses to scan ahead for balances"),
+			},
+			Response: WalletDeriveChildResponse{},
+		},
+	},
+	"/api/v1/wallet/encrypt": {
+		http.MethodPost: {
+			Summary: "Encrypts a wallet",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("password", paramString, "wallet password"),
+			},
+			Response: WalletResponse{},
+		},
+	},
+	"/api/v1/wallet/metadata/lock": {
+		http.MethodPost: {
+			Summary: "Locks the metadata of an encrypted wallet",
+			Params: []specParam{
+				walletIDParam,
+			},
+			Response: "",
+		},
+	},
+	"/api/v1/wallet/metadata/unlock": {
+		http.MethodPost: {
+			Summary: "Unlocks the metadata of an encrypted wallet, to change its labels without the password",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("password", paramString, "wallet password"),
+			},
+			Response: "",
+		},
+	},
+	"/api/v1/wallet/newAddress": {
+		http.MethodPost: {
+			Summary: "Generates new wallet addresses",
+			Params: []specParam{
+				walletIDParam,
+				param("num", paramInteger, "number of addresses, defaults to 1"),
+				param("password", paramString, "wallet password, required if it is encrypted"),
+				param("preview", paramBoolean, "return the next addresses without generating them"),
+				param("commit", paramString, "comma separated addresses returned by a preview, generated unless they were generated since the preview, 409 otherwise"),
+			},
+			Response: struct {
+				Addresses []string `json:"addresses"`
+			}{},
+		},
+	},
+	"/api/v1/wallet/newSeed": {
+		http.MethodGet: {
+			Summary: "Generates a bip39 mnemonic seed",
+			Params: []specParam{
+				param("entropy", paramInteger, "entropy bit size, 128 or 256, defaults to 128"),
+			},
+			Response: struct {
+				Seed string `json:"seed"`
+			}{},
+		},
+	},
+	"/api/v1/wallet/migrate": {
+		http.MethodPost: {
+			Summary: "Migrates a deterministic wallet to a bip44 wallet of the same mnemonic, moving its funds",
+			Params: []specParam{
+				walletIDParam,
+				param("to", paramString, `wallet type to migrate to, only "bip44" is supported`),
+				param("bip44-coin", paramInteger, "bip44 coin type of the new wallet, defaults to 8000"),
+				param("label", paramString, "label of the new wallet, defaults to the label of the migrated wallet"),
+				param("scan", paramInteger, "number of addresses of the new wallet to scan for balances, defaults to 10"),
+				param("dry-run", paramBoolean, "plan the migration without creating the wallet or injecting transactions"),
+				param("password", paramString, "wallet password, required if it is encrypted"),
+			},
+			Response: WalletMigrateResponse{},
+		},
+	},
+	"/api/v1/wallet/seed": {
+		http.MethodPost: {
+			Summary: "Returns the seed of an encrypted wallet",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("password", paramString, "wallet password"),
+			},
+			Response: WalletSeedResponse{},
+		},
+	},
+	"/api/v1/wallet/sweep": {
+		http.MethodPost: {
+			Summary: "Sweeps the funds of secret keys to a new address of a wallet",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("secret_keys", paramString, "comma separated hex encoded secret keys"),
+				param("password", paramString, "wallet password, required if it is encrypted"),
+			},
+			Response: WalletSweepResponse{},
+		},
+	},
+	"/api/v1/wallet/transaction": {
+		http.MethodPost: {
+			Summary:  "Creates a transaction from the outputs of a wallet",
+			Request:  WalletCreateTransactionRequest{},
+			Response: CreateTransactionResponse{},
+		},
+	},
+	"/api/v1/wallet/transaction/detail": {
+		http.MethodGet: {
+			Summary: "Returns a transaction with the wallet's note attached to it",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("txid", paramString, "transaction ID"),
+				verboseParam,
+			},
+			Response: specOneOf{WalletTransactionResponse{}, WalletTransactionVerboseResponse{}},
+		},
+	},
+	"/api/v1/wallet/transaction/note": {
+		http.MethodGet: {
+			Summary: "Returns the note of a wallet transaction, or all of the wallet's notes",
+			Params: []specParam{
+				walletIDParam,
+				param("txid", paramString, "transaction ID, all notes are returned if omitted"),
+			},
+			Response: specOneOf{WalletTxNote{}, WalletTxNotesResponse{}},
+		},
+		http.MethodPost: {
+			Summary: "Sets the note of a wallet transaction",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("txid", paramString, "transaction ID"),
+				requiredParam("note", paramString, "the note, at most 256 characters"),
+			},
+			Response: WalletTxNote{},
+		},
+		http.MethodDelete: {
+			Summary: "Removes the note of a wallet transaction",
+			Params: []specParam{
+				walletIDParam,
+				requiredParam("txid", paramString, "transaction ID"),
+			},
+			Response: "",
+		},
+	},
+	"/api/v1/wallet/transactions": {
+		http.MethodGet: {
+			Summary: "Returns the unconfirmed transactions of a wallet",
+			Params: []specParam{
+				walletIDParam,
+				verboseParam,
+			},
+			Response: specOneOf{UnconfirmedTxnsResponse{}, UnconfirmedTxnsVerboseResponse{}},
+		},
+	},
+	"/api/v1/wallet/unload": {
+		http.MethodPost: {
+			Summary: "Unloads a wallet from memory",
+			Params: []specParam{
+				walletIDParam,
+			},
+		},
+	},
+	"/api/v1/wallet/update": {
+		http.MethodPost: {
+			Summary: "Changes the label and options of a wallet",
+			Params: []specParam{
+				walletIDParam,
+				param("label", paramString, "wallet label, required unless reuse_change or strict_privacy is set"),
+				param("reuse_change", paramBoolean, "send change to a spent address, bip44 wallets only"),
+				param("strict_privacy", paramBoolean, "refuse to create transactions that reuse addresses instead of warning about them"),
+			},
+			Response: "",
+		},
+	},
+	"/api/v1/wallet/xpub": {
+		http.MethodGet: {
+			Summary: "Returns the stored account xpub of a bip44 wallet, without its seed",
+			Params: []specParam{
+				walletIDParam,
+				param("account", paramInteger, "bip44 account, only account 0 is stored"),
+			},
+			Response: WalletXPubResponse{},
+		},
+	},
+	"/api/v1/wallets": {
+		http.MethodGet: {
+			Summary: "Returns all wallets",
+			Params: []specParam{
+				param("label", paramString, "only return the wallets with this label"),
+			},
+			Response: []WalletResponse{},
+		},
+	},
+	"/api/v1/wallets/backups": {
+		http.MethodGet: {
+			Summary: "Returns the backups of a wallet file, oldest first",
+			Params: []specParam{
+				walletIDParam,
+			},
+			Response: WalletBackupsResponse{},
+		},
+	},
+	"/api/v1/wallets/folderName": {
+		http.MethodGet: {
+			Summary:  "Returns the wallet directory path",
+			Response: WalletFolder{},
+		},
+	},
+	"/api/v1/wallets/format-status": {
+		http.MethodGet: {
+			Summary:  "Returns the format version of every wallet file and the migrations that would upgrade it to the current format",
+			Response: WalletFormatStatusResponse{},
+		},
+	},
+	"/api/v1/wallets/migrate": {
+		http.MethodPost: {
+			Summary: "Upgrades a wallet file to the current format, backing it up first",
+			Params: []specParam{
+				walletIDParam,
+				param("password", paramString, "wallet password, required if the wallet is encrypted and a migration needs its seed"),
+			},
+			Response: WalletFormatMigrateResponse{},
+		},
+	},
+	"/api/v2/address/verify": {
+		http.MethodPost: {
+			Summary:  "Verifies an address",
+			Request:  VerifyAddressRequest{},
+			Response: VerifyAddressResponse{},
+		},
+	},
+	"/api/v2/block/decode": {
+		http.MethodPost: {
+			Summary:  "Decodes a serialized block without consulting the blockchain",
+			Request:  DecodeBlockRequest{},
+			Response: readable.Block{},
+		},
+	},
+	"/api/v2/blocks/raw": {
+		http.MethodGet: {
+			Summary: "Exports the serialized blocks up to a head seq, supporting byte range requests to resume a download",
+			Params: []specParam{
+				param("head_seq", paramInteger, "last block seq of the export, defaults to the blockchain head"),
+			},
+			ContentType: contentTypeOctetStream,
+		},
+		http.MethodHead: {
+			Summary: "Returns the headers of the raw blocks export, with its Content-Length and X-Head-Seq",
+			Params: []specParam{
+				param("head_seq", paramInteger, "last block seq of the export, defaults to the blockchain head"),
+			},
+			ContentType: contentTypeOctetStream,
+		},
+	},
+	"/api/v2/blocks/stream": {
+		http.MethodGet: {
+			Summary: "Streams verbose blocks from a seq as newline-delimited JSON, gzip compressed if accepted, optionally following new blocks",
+			Params: []specParam{
+				param("since", paramInteger, "seq of the first block, defaults to 0"),
+				param("follow", paramBoolean, "keep the stream open and write the blocks executed after the head"),
+			},
+			ContentType: contentTypeNDJSON,
+		},
+	},
+	"/api/v2/health/unspent-hash": {
+		http.MethodGet: {
+			Summary:  "Recomputes the unspent pool hash and compares it to the hash expected by the head block",
+			Response: UnspentHashStatus{},
+		},
+	},
+	"/api/v2/block/preview": {
+		http.MethodGet: {
+			Summary:  "Returns the block that the block publisher would create from the unconfirmed pool now",
+			Response: BlockPreviewResponse{},
+		},
+	},
+	"/api/v2/data": {
+		http.MethodGet: {
+			Summary: "Returns a value of a key-value storage, or all of its values",
+			Params: []specParam{
+				requiredParam("type", paramString, "storage type"),
+				param("key", paramString, "key, all values are returned if omitted"),
+			},
+			Response: specOneOf{"", map[string]string{}},
+		},
+		http.MethodPost: {
+			Summary: "Adds a value to a key-value storage",
+			Request: StorageRequest{},
+		},
+		http.MethodDelete: {
+			Summary: "Removes a value from a key-value storage",
+			Params: []specParam{
+				requiredParam("type", paramString, "storage type"),
+				requiredParam("key", paramString, "key"),
+			},
+		},
+	},
+	"/api/v2/db/snapshot": {
+		http.MethodPost: {
+			Summary:  "Creates a snapshot of the database with a manifest, for bootstrapping new nodes",
+			Response: DBSnapshotResponse{},
+		},
+	},
+	"/api/v2/storage/stats": {
+		http.MethodGet: {
+			Summary:  "Returns the size of the database file, the number of keys of its buckets and the state of its compaction",
+			Response: StorageStatsResponse{},
+		},
+	},
+	"/api/v2/storage/compact": {
+		http.MethodPost: {
+			Summary:  "Starts a compaction of the database in the background, copying its live buckets into a new database file",
+			Response: StorageStatsResponse{},
+		},
+		http.MethodDelete: {
+			Summary:  "Aborts the running compaction of the database",
+			Response: StorageStatsResponse{},
+		},
+	},
+	"/api/v2/metrics": {
+		http.MethodGet: {
+			Summary:     "Returns metrics in the Prometheus text format",
+			ContentType: contentTypeText,
+		},
+	},
+	"/api/v2/network/request-blocks": {
+		http.MethodPost: {
+			Summary:  "Sends a request for a range of blocks to a connected peer, or to all connected peers",
+			Request:  NetworkRequestBlocksRequest{},
+			Response: NetworkForcedRequestResponse{},
+		},
+	},
+	"/api/v2/network/request-txns": {
+		http.MethodPost: {
+			Summary:  "Sends a request for transactions to a connected peer, or to all connected peers",
+			Request:  NetworkRequestTxnsRequest{},
+			Response: NetworkForcedRequestResponse{},
+		},
+	},
+	"/api/v2/notifications": {
+		http.MethodGet: {
+			Summary: "Returns the queued events of a subscription after a cursor",
+			Params: []specParam{
+				requiredParam("subscription", paramString, "subscription ID"),
+				param("after", paramInteger, "return the events after this cursor, defaults to 0"),
+				param("limit", paramInteger, "maximum number of events, defaults to and at most 100"),
+			},
+			Response: NotificationsResponse{},
+		},
+	},
+	"/api/v2/notifications/subscriptions": {
+		http.MethodPost: {
+			Summary:  "Subscribes to the transactions of applied blocks touching a set of addresses",
+			Request:  SubscriptionRequest{},
+			Response: SubscriptionResponse{},
+		},
+		http.MethodDelete: {
+			Summary: "Removes a subscription and its queued events",
+			Params: []specParam{
+				requiredParam("id", paramString, "subscription ID"),
+			},
+		},
+	},
+	"/api/v2/publisher": {
+		http.MethodGet: {
+			Summary:  "Returns the block publisher role of the node",
+			Response: PublisherStatusResponse{},
+		},
+	},
+	"/api/v2/publisher/arm": {
+		http.MethodPost: {
+			Summary:  "Arms the block publisher role with the blockchain secret key, which is only held in memory. Requires HTTPS or localhost",
+			Request:  PublisherArmRequest{},
+			Response: PublisherStatusResponse{},
+		},
+	},
+	"/api/v2/publisher/disarm": {
+		http.MethodPost: {
+			Summary:  "Disarms the block publisher role and forgets the blockchain secret key. Requires HTTPS or localhost",
+			Response: PublisherStatusResponse{},
+		},
+	},
+	"/api/v2/transaction": {
+		http.MethodPost: {
+			Summary:  "Creates a transaction from outputs or addresses",
+			Request:  CreateTransactionRequest{},
+			Response: CreateTransactionResponse{},
+		},
+	},
+	"/api/v2/transaction/ancestry": {
+		http.MethodGet: {
+			Summary:  "Returns the graph of the outputs a confirmed output was created from, following the inputs of the transactions back",
+			Params:   txnGraphParams,
+			Response: TxnGraphResponse{},
+		},
+	},
+	"/api/v2/transaction/descendants": {
+		http.MethodGet: {
+			Summary:  "Returns the graph of the outputs a spent output was spent into, following the outputs of the transactions forward",
+			Params:   txnGraphParams,
+			Response: TxnGraphResponse{},
+		},
+	},
+	"/api/v2/transaction/test-accept": {
+		http.MethodPost: {
+			Summary:  "Tests whether serialized transactions would be accepted into the unconfirmed pool, without injecting them",
+			Request:  TestAcceptTransactionsRequest{},
+			Response: []TestAcceptResult{},
+		},
+	},
+	"/api/v2/transaction/verify": {
+		http.MethodPost: {
+			Summary:  "Decodes and verifies a serialized transaction",
+			Request:  VerifyTransactionRequest{},
+			Response: VerifyTransactionResponse{},
+		},
+	},
+	"/api/v2/wallet/recover": {
+		http.MethodPost: {
+			Summary:  "Starts recovering a wallet from its seed, scanning addresses in the background",
+			Request:  WalletRecoverRequest{},
+			Response: WalletRecoverResponse{},
+		},
+		http.MethodDelete: {
+			Summary: "Cancels a running wallet recovery",
+			Params: []specParam{
+				requiredParam("job", paramString, "recovery job id"),
+			},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/recover/status": {
+		http.MethodGet: {
+			Summary: "Returns the progress of a wallet recovery",
+			Params: []specParam{
+				requiredParam("job", paramString, "recovery job id"),
+			},
+			Response: WalletRecoveryStatusResponse{},
+		},
+	},
+	"/api/v2/wallet/seed/verify": {
+		http.MethodPost: {
+			Summary:  "Verifies a bip39 mnemonic seed",
+			Request:  VerifySeedRequest{},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/transaction/sign": {
+		http.MethodPost: {
+			Summary:  "Signs an unsigned transaction with the keys of a wallet",
+			Request:  WalletSignTransactionRequest{},
+			Response: CreateTransactionResponse{},
+		},
+	},
+	"/api/v2/wallet/address/encrypt": {
+		http.MethodPost: {
+			Summary:  "Encrypts the secret key of a collection wallet address with its own password",
+			Request:  WalletAddressPasswordRequest{},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/address/decrypt": {
+		http.MethodPost: {
+			Summary:  "Removes the encryption of the secret key of a collection wallet address with its own password",
+			Request:  WalletAddressPasswordRequest{},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/token": {
+		http.MethodPost: {
+			Summary:  "Sets a new access token for a wallet, required by the wallet endpoints for this wallet",
+			Request:  WalletTokenRequest{},
+			Response: WalletTokenResponse{},
+		},
+	},
+	"/api/v2/wallet/token/rotate": {
+		http.MethodPost: {
+			Summary:  "Replaces the access token of a wallet, requires the current token",
+			Request:  WalletTokenRequest{},
+			Response: WalletTokenResponse{},
+		},
+	},
+	"/api/v2/wallet/token/remove": {
+		http.MethodPost: {
+			Summary:  "Removes the access token of a wallet",
+			Request:  WalletTokenRequest{},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/approval/policy": {
+		http.MethodPost: {
+			Summary:  "Sets the approval policy of a wallet, which holds its spends above a threshold until an approver approves them",
+			Request:  WalletApprovalPolicyRequest{},
+			Response: WalletApprovalPolicyResponse{},
+		},
+	},
+	"/api/v2/wallet/approval/policy/remove": {
+		http.MethodPost: {
+			Summary:  "Removes the approval policy of a wallet",
+			Request:  WalletApprovalPolicyRemoveRequest{},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/approvals": {
+		http.MethodGet: {
+			Summary: "Returns the approval policy and the pending approvals of a wallet",
+			Params: []specParam{
+				requiredParam("id", paramString, "wallet id"),
+			},
+			Response: WalletApprovalsResponse{},
+		},
+	},
+	"/api/v2/wallet/approval/approve": {
+		http.MethodPost: {
+			Summary:  "Approves a pending approval of a wallet with the approver password, and optionally broadcasts its transaction",
+			Request:  WalletApprovalRequest{},
+			Response: WalletApprovalApproveResponse{},
+		},
+	},
+	"/api/v2/wallet/approval/cancel": {
+		http.MethodPost: {
+			Summary:  "Cancels a pending approval of a wallet",
+			Request:  WalletApprovalRequest{},
+			Response: struct{}{},
+		},
+	},
+	"/api/v2/wallet/transaction/bump": {
+		http.MethodPost: {
+			Summary:  "Increases the fee of an unsigned transaction by adding an input from a wallet",
+			Request:  WalletBumpTransactionRequest{},
+			Response: CreateTransactionResponse{},
+		},
+	},
+}
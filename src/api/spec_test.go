@@ -0,0 +1,235 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
+)
+
+func TestSpecHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		code   int
+		err    string
+	}{
+		{
+			name:   "405 method not allowed",
+			method: http.MethodPost,
+			code:   http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/api/v1/spec.json", nil)
+			require.NoError(t, err)
+
+			cfg := defaultMuxConfig()
+			cfg.health.BuildInfo = readable.BuildInfo{
+				Version: "0.27.1",
+			}
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(cfg, &MockGatewayer{})
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var spec openAPISpec
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &spec))
+			require.Equal(t, openAPIVersion, spec.OpenAPI)
+			require.Equal(t, "0.27.1", spec.Info.Version)
+
+			// Every registered route is in the spec, with its methods
+			expected := map[string][]string{
+				"/api/v1/csrf": []string{http.MethodGet},
+			}
+			for e, methods := range endpointsMethods {
+				expected[e] = methods
+			}
+
+			require.Equal(t, len(expected), len(spec.Paths))
+			for e, methods := range expected {
+				ops, ok := spec.Paths[e]
+				require.True(t, ok, "%s is not in the spec", e)
+
+				var specMethods []string
+				for m, op := range ops {
+					specMethods = append(specMethods, strings.ToUpper(m))
+					require.NotEmpty(t, op.Summary, "%s %s", m, e)
+					require.NotNil(t, op.Responses["200"], "%s %s", m, e)
+				}
+
+				methods = append([]string{}, methods...)
+				sort.Strings(methods)
+				sort.Strings(specMethods)
+				require.Equal(t, methods, specMethods, e)
+			}
+
+			require.Equal(t, []string{EndpointsRead}, spec.Paths["/api/v1/block"]["get"].APISets)
+			require.Empty(t, spec.Paths["/api/v1/version"]["get"].APISets)
+
+			// Every schema reference resolves
+			var refs []string
+			var collectRefs func(v interface{})
+			collectRefs = func(v interface{}) {
+				switch x := v.(type) {
+				case map[string]interface{}:
+					for k, y := range x {
+						// The spec's own schema has a "$ref" property, which is not a reference
+						if ref, ok := y.(string); ok && k == "$ref" {
+							refs = append(refs, ref)
+						}
+						collectRefs(y)
+					}
+				case []interface{}:
+					for _, y := range x {
+						collectRefs(y)
+					}
+				}
+			}
+
+			var raw interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+			collectRefs(raw)
+			require.NotEmpty(t, refs)
+
+			for _, ref := range refs {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				_, ok := spec.Components.Schemas[name]
+				require.True(t, ok, "unresolved reference %s", ref)
+			}
+		})
+	}
+}
+
+func TestSpecSchemas(t *testing.T) {
+	type embedded struct {
+		A string `json:"a"`
+	}
+
+	type testStruct struct {
+		embedded
+		B          uint64         `json:"b,string"`
+		C          []string       `json:"c,omitempty"`
+		D          map[string]int `json:"d"`
+		E          *HTTPError     `json:"e"`
+		F          []byte         `json:"f"`
+		G          wh.Coins       `json:"g"`
+		H          interface{}    `json:"h"`
+		Ignored    string         `json:"-"`
+		unexported string
+		NoTag      bool
+		Nested     struct{ X int }   `json:"nested"`
+		M          map[string][]bool `json:"m"`
+	}
+
+	s := newSpecSchemas()
+	schema := s.schemaOf(reflect.TypeOf(testStruct{}))
+	require.Equal(t, "#/components/schemas/testStruct", schema.Ref)
+
+	require.Equal(t, &specSchema{
+		Type: "object",
+		Properties: map[string]*specSchema{
+			"a":     {Type: "string"},
+			"b":     {Type: "string"},
+			"c":     {Type: "array", Items: &specSchema{Type: "string"}},
+			"d":     {Type: "object", AdditionalProperties: &specSchema{Type: "integer"}},
+			"e":     {Ref: "#/components/schemas/HTTPError"},
+			"f":     {Type: "string", Format: "byte"},
+			"g":     {Type: "string"},
+			"h":     {},
+			"NoTag": {Type: "boolean"},
+			"nested": {
+				Type: "object",
+				Properties: map[string]*specSchema{
+					"X": {Type: "integer"},
+				},
+			},
+			"m": {
+				Type: "object",
+				AdditionalProperties: &specSchema{
+					Type:  "array",
+					Items: &specSchema{Type: "boolean"},
+				},
+			},
+		},
+	}, s.schemas["testStruct"])
+
+	require.Equal(t, &specSchema{
+		Type: "object",
+		Properties: map[string]*specSchema{
+			"message": {Type: "string"},
+			"code":    {Type: "integer"},
+			"field":   {Type: "string"},
+			"offset":  {Type: "integer"},
+		},
+	}, s.schemas["HTTPError"])
+
+	// Types of other packages are prefixed with their package name
+	schema = s.schemaOf(reflect.TypeOf(readable.BuildInfo{}))
+	require.Equal(t, "#/components/schemas/readable.BuildInfo", schema.Ref)
+
+	schema = s.bodySchema(specOneOf{"", []int{}})
+	require.Equal(t, &specSchema{
+		OneOf: []*specSchema{
+			{Type: "string"},
+			{Type: "array", Items: &specSchema{Type: "integer"}},
+		},
+	}, schema)
+}
+
+func TestNewOpenAPISpecUndocumented(t *testing.T) {
+	_, err := newOpenAPISpec([]apiRoute{
+		{
+			apiVersion: apiVersion1,
+			path:       "/api/v1/undocumented",
+			methodAPISets: map[string][]string{
+				http.MethodGet: []string{EndpointsRead},
+			},
+		},
+	}, readable.BuildInfo{})
+	require.EqualError(t, err, "endpoint /api/v1/undocumented is not documented")
+
+	_, err = newOpenAPISpec([]apiRoute{
+		{
+			apiVersion: apiVersion1,
+			path:       "/api/v1/version",
+			methodAPISets: map[string][]string{
+				http.MethodGet:  []string{EndpointsRead},
+				http.MethodPost: []string{EndpointsRead},
+			},
+		},
+	}, readable.BuildInfo{})
+	require.EqualError(t, err, "endpoint POST /api/v1/version is not documented")
+
+	_, err = newOpenAPISpec([]apiRoute{
+		{
+			apiVersion: apiVersion1,
+			path:       "/api/v1/version",
+		},
+	}, readable.BuildInfo{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is documented but not registered")
+}
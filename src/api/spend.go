@@ -6,26 +6,37 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/shopspring/decimal"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/util/fee"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/mathutil"
-	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/transaction"
+	pfee "github.com/ness-network/privateness/src/util/fee"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 // CreateTransactionResponse is returned by /wallet/transaction
 type CreateTransactionResponse struct {
 	Transaction        CreatedTransaction `json:"transaction"`
 	EncodedTransaction string             `json:"encoded_transaction"`
+	// UsedAddresses lists the addresses whose outputs were spent, set when addresses were selected by label
+	UsedAddresses []string `json:"used_addresses,omitempty"`
+	// Note is the note attached to the transaction in the wallet, set by /wallet/transaction
+	Note string `json:"note,omitempty"`
+	// Warnings describe the previously used addresses the transaction sends coins to, set by /wallet/transaction
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // NewCreateTransactionResponse creates a CreateTransactionResponse
@@ -371,43 +382,42 @@ func (r createTransactionRequest) Validate() error {
 		return errors.New("to is empty")
 	}
 
-	for i, to := range r.To {
-		if to.Address.Null() {
-			return fmt.Errorf("to[%d].address is empty", i)
-		}
-
-		if to.Coins == 0 {
-			return fmt.Errorf("to[%d].coins must not be zero", i)
-		}
-
-		if to.Coins.Value()%params.UserVerifyTxn.MaxDropletDivisor() != 0 {
-			return fmt.Errorf("to[%d].coins has too many decimal places", i)
-		}
-	}
-
-	// Check for duplicate created outputs, a transaction can't have outputs with
-	// the same (address, coins, hours)
+	// A transaction can't have outputs with the same (address, coins, hours).
 	// Auto mode would distribute hours to the outputs and could hypothetically
 	// avoid assigning duplicate hours in many cases, but the complexity for doing
 	// so is very high, so also reject duplicate (address, coins) for auto mode.
-	outputs := make(map[coin.TransactionOutput]struct{}, len(r.To))
-	for _, to := range r.To {
+	to := make([]pcoin.TransactionOutput, len(r.To))
+	for i, o := range r.To {
 		var hours uint64
-		if to.Hours != nil {
-			hours = to.Hours.Value()
+		if o.Hours != nil {
+			hours = o.Hours.Value()
 		}
 
-		txo := coin.TransactionOutput{
-			Address: to.Address.Address,
-			Coins:   to.Coins.Value(),
+		to[i] = pcoin.TransactionOutput{
+			Address: o.Address.Address,
+			Coins:   o.Coins.Value(),
 			Hours:   hours,
 		}
+	}
 
-		if _, ok := outputs[txo]; ok {
-			return errors.New("to contains duplicate values")
+	if err := pcoin.ValidateOutputs(to, params.UserVerifyTxn.MaxDropletPrecision); err != nil {
+		e, ok := err.(pcoin.OutputError)
+		if !ok {
+			return err
 		}
 
-		outputs[txo] = struct{}{}
+		switch {
+		case errors.Is(e.Err, pcoin.ErrNullAddressOutput):
+			return fmt.Errorf("to[%d].address is empty", e.Index)
+		case errors.Is(e.Err, pcoin.ErrZeroCoinOutput):
+			return fmt.Errorf("to[%d].coins must not be zero", e.Index)
+		case errors.Is(e.Err, pcoin.ErrOutputDecimals):
+			return fmt.Errorf("to[%d].coins has too many decimal places", e.Index)
+		case errors.Is(e.Err, pcoin.ErrDuplicateOutput):
+			return errors.New("to contains duplicate values")
+		default:
+			return fmt.Errorf("to[%d]: %v", e.Index, e.Err)
+		}
 	}
 
 	return nil
@@ -488,8 +498,8 @@ func transactionHandlerV2(gateway Gatewayer) http.HandlerFunc {
 		}
 
 		var req createTransactionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
 			writeHTTPResponse(w, resp)
 			return
 		}
@@ -542,9 +552,12 @@ func transactionHandlerV2(gateway Gatewayer) http.HandlerFunc {
 
 // walletCreateTransactionRequest is sent to POST /api/v1/wallet/transaction
 type walletCreateTransactionRequest struct {
-	Unsigned bool   `json:"unsigned"`
-	WalletID string `json:"wallet_id"`
-	Password string `json:"password"`
+	Unsigned      bool     `json:"unsigned"`
+	WalletID      string   `json:"wallet_id"`
+	Password      string   `json:"password"`
+	AddressLabels []string `json:"address_labels,omitempty"`
+	// Note is stored locally with the txid in the wallet's transaction notes. It is never broadcast
+	Note string `json:"note,omitempty"`
 	createTransactionRequest
 }
 
@@ -558,13 +571,107 @@ func (r walletCreateTransactionRequest) Validate() error {
 		return errors.New("password must not be used for unsigned transactions")
 	}
 
+	if len(r.AddressLabels) != 0 && len(r.UxOuts) != 0 {
+		return errors.New("unspents and address_labels cannot be combined")
+	}
+
+	if r.Note != "" {
+		// The txid of an unsigned transaction changes when it is signed
+		if r.Unsigned {
+			return errors.New("note cannot be attached to an unsigned transaction")
+		}
+
+		if err := kvstorage.ValidateWalletTxNote(r.Note); err != nil {
+			return err
+		}
+	}
+
+	for i, l := range r.AddressLabels {
+		if l == "" {
+			return fmt.Errorf("address_labels[%d] is empty", i)
+		}
+	}
+
 	return r.createTransactionRequest.Validate()
 }
 
+// unionAddresses appends the addresses of b that are not in a to a, preserving order
+func unionAddresses(a, b []cipher.Address) []cipher.Address {
+	seen := make(map[cipher.Address]struct{}, len(a)+len(b))
+	out := make([]cipher.Address, 0, len(a)+len(b))
+	for _, addrs := range [][]cipher.Address{a, b} {
+		for _, x := range addrs {
+			if _, ok := seen[x]; ok {
+				continue
+			}
+			seen[x] = struct{}{}
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// inputAddresses returns the unique owner addresses of inputs, in input order
+func inputAddresses(inputs []visor.TransactionInput) []string {
+	seen := make(map[cipher.Address]struct{}, len(inputs))
+	var addrs []string
+	for _, in := range inputs {
+		a := in.UxOut.Body.Address
+		if _, ok := seen[a]; ok {
+			continue
+		}
+		seen[a] = struct{}{}
+		addrs = append(addrs, a.String())
+	}
+	return addrs
+}
+
+// addressReuseWarnings returns the warnings about the addresses reused by a transaction created for a wallet.
+// Returns errAddressReuse if the wallet has strict privacy set and the transaction reuses addresses.
+func addressReuseWarnings(gateway Gatewayer, wltID string, txn *coin.Transaction, receivers int) ([]string, error) {
+	reuse, err := gateway.WalletAddressReuse(wltID, txn, receivers)
+	if err != nil || len(reuse) == 0 {
+		return nil, err
+	}
+
+	warnings := make([]string, len(reuse))
+	for i, r := range reuse {
+		warnings[i] = r.String()
+	}
+
+	strict, err := gateway.WalletStrictPrivacy(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strict {
+		return nil, errAddressReuse{warnings}
+	}
+
+	return warnings, nil
+}
+
+// errAddressReuse is returned when a transaction created for a wallet with strict privacy reuses addresses
+type errAddressReuse struct {
+	warnings []string
+}
+
+func (e errAddressReuse) Error() string {
+	return fmt.Sprintf("wallet has strict privacy set: %s", strings.Join(e.warnings, "; "))
+}
+
 // walletCreateTransactionHandler creates a transaction
 // Method: POST
 // URI: /api/v1/wallet/transaction
 // Args: JSON body
+// If address_labels is set, the wallet addresses carrying any of the labels are
+// added to addresses, and the response includes the addresses actually spent from.
+// The response warns about the previously used addresses the transaction sends coins to:
+// its change address, and its destinations that belong to the wallet. If the wallet has
+// strict privacy set, such a transaction is refused with 400 Bad Request instead.
+// If note is set, it is attached to the signed transaction in the wallet's transaction notes.
+// If the wallet's approval policy requires approval of the signed transaction, it is held
+// and the response is 202 Accepted with the pending approval, see walletApprovalApproveHandler.
 func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -580,23 +687,45 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 		var req walletCreateTransactionRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			logger.WithError(err).Error("Invalid create transaction request")
+			requestLogger(r).WithError(err).Error("Invalid create transaction request")
 			wh.Error400(w, err.Error())
 			return
 		}
 
 		if err := req.Validate(); err != nil {
-			logger.WithError(err).Error("Invalid create transaction request")
+			requestLogger(r).WithError(err).Error("Invalid create transaction request")
 			wh.Error400(w, err.Error())
 			return
 		}
 
+		visorParams := req.VisorParams()
+		if len(req.AddressLabels) != 0 {
+			addrs, err := gateway.GetWalletAddressesByLabels(req.WalletID, req.AddressLabels)
+			if err != nil {
+				switch err {
+				case wallet.ErrWalletAPIDisabled:
+					wh.Error403(w, "")
+				case wallet.ErrWalletNotExist:
+					wh.Error404(w, err.Error())
+				default:
+					switch err.(type) {
+					case wallet.Error:
+						wh.Error400(w, err.Error())
+					default:
+						wh.Error500(w, err.Error())
+					}
+				}
+				return
+			}
+			visorParams.Addresses = unionAddresses(visorParams.Addresses, addrs)
+		}
+
 		var txn *coin.Transaction
 		var inputs []visor.TransactionInput
 		if req.Unsigned {
-			txn, inputs, err = gateway.WalletCreateTransaction(req.WalletID, req.TransactionParams(), req.VisorParams())
+			txn, inputs, err = gateway.WalletCreateTransaction(r.Context(), req.WalletID, req.TransactionParams(), visorParams)
 		} else {
-			txn, inputs, err = gateway.WalletCreateTransactionSigned(req.WalletID, []byte(req.Password), req.TransactionParams(), req.VisorParams())
+			txn, inputs, err = gateway.WalletCreateTransactionSigned(r.Context(), req.WalletID, []byte(req.Password), req.TransactionParams(), visorParams)
 		}
 		if err != nil {
 			switch err.(type) {
@@ -625,6 +754,18 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		warnings, err := addressReuseWarnings(gateway, req.WalletID, txn, len(req.To))
+		if err != nil {
+			requestLogger(r).WithError(err).Error("addressReuseWarnings failed")
+			switch err.(type) {
+			case errAddressReuse:
+				wh.Error400(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
 		txnResp, err := NewCreateTransactionResponse(txn, inputs)
 		if err != nil {
 			err = fmt.Errorf("NewCreateTransactionResponse failed: %v", err)
@@ -632,19 +773,198 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if len(req.AddressLabels) != 0 {
+			txnResp.UsedAddresses = inputAddresses(inputs)
+		}
+		txnResp.Warnings = warnings
+
+		var pending *kvstorage.PendingApproval
+		if !req.Unsigned {
+			pending, err = holdForApproval(gateway, req.WalletID, txn)
+			if err != nil {
+				requestLogger(r).WithError(err).Error("holdForApproval failed")
+				writeWalletApprovalError(w, apiVersion1, err)
+				return
+			}
+		}
+
+		if req.Note != "" {
+			if err := gateway.SetWalletTxNote(req.WalletID, txn.Hash(), req.Note); err != nil {
+				requestLogger(r).WithError(err).Error("SetWalletTxNote failed")
+				writeWalletTxNoteError(w, err)
+				return
+			}
+			txnResp.Note = req.Note
+		}
+
+		if pending != nil {
+			writePendingApproval(w, apiVersion1, pending)
+			return
+		}
+
 		wh.SendJSONOr500(logger, w, txnResp)
 	}
 }
 
+// WalletBumpTransactionRequest is the request body object for /api/v2/wallet/transaction/bump
+type WalletBumpTransactionRequest struct {
+	WalletID           string `json:"wallet_id"`
+	EncodedTransaction string `json:"encoded_transaction"`
+	Fee                string `json:"fee"`
+	ChangeAddress      string `json:"change_address,omitempty"`
+}
+
+// walletBumpTransactionHandler increases the fee of an unsigned transaction by adding an input from the wallet
+// Method: POST
+// URI: /api/v2/wallet/transaction/bump
+// Args: JSON body
+func walletBumpTransactionHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletBumpTransactionRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.EncodedTransaction == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "encoded_transaction is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var targetFee uint64
+		if req.Fee != "" {
+			var err error
+			targetFee, err = strconv.ParseUint(req.Fee, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid fee")
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		var changeAddress *cipher.Address
+		if req.ChangeAddress != "" {
+			addr, err := cipher.DecodeBase58Address(req.ChangeAddress)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid change_address: %v", err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			changeAddress = &addr
+		}
+
+		txn, err := decodeTxn(req.EncodedTransaction)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("Decode transaction failed: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		bumpedTxn, inputs, err := gateway.WalletBumpTransaction(req.WalletID, txn, targetFee, changeAddress)
+		if err != nil {
+			var resp HTTPResponse
+			switch err.(type) {
+			case wallet.Error:
+				switch err {
+				case wallet.ErrWalletNotExist:
+					resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+				case wallet.ErrWalletAPIDisabled:
+					resp = NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				}
+			case transaction.Error,
+				visor.ErrTxnViolatesSoftConstraint,
+				visor.ErrTxnViolatesHardConstraint,
+				visor.ErrTxnViolatesUserConstraint,
+				blockdb.ErrUnspentNotExist:
+				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			default:
+				switch err {
+				case pfee.ErrTxnInsufficientCoinHours:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txnResp, err := NewCreateTransactionResponse(bumpedTxn, inputs)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: txnResp,
+		})
+	}
+}
+
 // WalletSignTransactionRequest is the request body object for /api/v2/wallet/transaction/sign
 type WalletSignTransactionRequest struct {
 	WalletID           string `json:"wallet_id"`
 	Password           string `json:"password"`
 	EncodedTransaction string `json:"encoded_transaction"`
 	SignIndexes        []int  `json:"sign_indexes"`
+	// AddressPasswords are the passwords of the wallet addresses encrypted with their own password, by address
+	AddressPasswords map[string]string `json:"address_passwords,omitempty"`
+}
+
+// signTransactionWithAddressPasswords signs a transaction with a wallet whose addresses may be encrypted
+// with their own password. The outputs spent by the transaction must be unspent.
+func signTransactionWithAddressPasswords(gateway Gatewayer, wltID string, password []byte, addrPasswords map[cipher.Address][]byte,
+	txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error) {
+	uxOuts := make([]coin.UxOut, len(txn.In))
+	for i, in := range txn.In {
+		ux, err := gateway.GetUxOutByID(in)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ux == nil || ux.SpentBlockSeq != 0 {
+			return nil, nil, blockdb.NewErrUnspentNotExist(in.Hex())
+		}
+		uxOuts[i] = ux.Out
+	}
+
+	signedTxn, err := gateway.SignTransactionWithAddressPasswords(wltID, password, addrPasswords, txn, signIndexes, uxOuts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signed := visor.TxnUnsigned
+	if signedTxn.IsFullySigned() {
+		signed = visor.TxnSigned
+	}
+
+	inputs, _, err := gateway.VerifyTxnVerbose(signedTxn, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return signedTxn, inputs, nil
 }
 
 // walletSignTransactionHandler signs an unsigned transaction
+// If the wallet's approval policy requires approval of the signed transaction, it is held
+// and the response is 202 Accepted with the pending approval, see walletApprovalApproveHandler.
 // Method: POST
 // URI: /api/v2/wallet/transaction/sign
 // Args: JSON body
@@ -657,8 +977,8 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 		}
 
 		var req WalletSignTransactionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
 			writeHTTPResponse(w, resp)
 			return
 		}
@@ -709,7 +1029,30 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			signIndexesMap[i] = struct{}{}
 		}
 
-		signedTxn, inputs, err := gateway.WalletSignTransaction(req.WalletID, []byte(req.Password), txn, req.SignIndexes)
+		addrPasswords := make(map[cipher.Address][]byte, len(req.AddressPasswords))
+		for a, p := range req.AddressPasswords {
+			addr, err := cipher.DecodeBase58Address(a)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address %q in address_passwords: %v", a, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			addrPasswords[addr] = []byte(p)
+		}
+
+		defer func() {
+			req.Password = ""
+			req.AddressPasswords = nil
+			addrPasswords = nil
+		}()
+
+		var signedTxn *coin.Transaction
+		var inputs []visor.TransactionInput
+		if len(addrPasswords) != 0 {
+			signedTxn, inputs, err = signTransactionWithAddressPasswords(gateway, req.WalletID, []byte(req.Password), addrPasswords, txn, req.SignIndexes)
+		} else {
+			signedTxn, inputs, err = gateway.WalletSignTransaction(r.Context(), req.WalletID, []byte(req.Password), txn, req.SignIndexes)
+		}
 		if err != nil {
 			var resp HTTPResponse
 			switch err.(type) {
@@ -734,6 +1077,16 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		pending, err := holdForApproval(gateway, req.WalletID, signedTxn)
+		if err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+		if pending != nil {
+			writePendingApproval(w, apiVersion2, pending)
+			return
+		}
+
 		txnResp, err := NewCreateTransactionResponse(signedTxn, inputs)
 		if err != nil {
 			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
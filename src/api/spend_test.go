@@ -11,16 +11,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/util/fee"
-	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 type rawHoursSelection struct {
@@ -41,7 +45,6 @@ type rawCreateTxnRequest struct {
 	HoursSelection rawHoursSelection `json:"hours_selection"`
 	ChangeAddress  string            `json:"change_address,omitempty"`
 	To             []rawReceiver     `json:"to"`
-	Password       string            `json:"password"`
 }
 
 func TestCreateTransaction(t *testing.T) {
@@ -795,13 +798,13 @@ func TestCreateTransaction(t *testing.T) {
 			method:       http.MethodPost,
 			rawBody:      "{ca",
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid character 'c' looking for beginning of object key string"),
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: "invalid JSON at offset 2: invalid character 'c' looking for beginning of object key string", Offset: 2}),
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 
 			// If the rawRequestBody can be deserialized to CreateTransactionRequest, use it to mock gateway.WalletCreateTransaction
 			serializedBody, err := json.Marshal(tc.body)
@@ -1761,7 +1764,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 	for _, tc := range cases {
 		name := fmt.Sprintf("unsigned=%v %s", tc.body.Unsigned, tc.name)
 		t.Run(name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 
 			// If the rawRequestBody can be deserialized to CreateTransactionRequest, use it to mock gateway.WalletCreateTransaction
 			serializedBody, err := json.Marshal(tc.body)
@@ -1770,10 +1773,10 @@ func TestWalletCreateTransaction(t *testing.T) {
 			err = json.Unmarshal(serializedBody, &body)
 			if err == nil {
 				if tc.body.Unsigned {
-					x := gateway.On("WalletCreateTransaction", body.WalletID, body.TransactionParams(), body.VisorParams())
+					x := gateway.On("WalletCreateTransaction", mock.Anything, body.WalletID, body.TransactionParams(), body.VisorParams())
 					x.Return(tc.gatewayCreateTransactionResult, tc.gatewayCreateTransactionInputs, tc.gatewayCreateTransactionErr)
 				} else {
-					x := gateway.On("WalletCreateTransactionSigned", body.WalletID, []byte(body.Password), body.TransactionParams(), body.VisorParams())
+					x := gateway.On("WalletCreateTransactionSigned", mock.Anything, body.WalletID, []byte(body.Password), body.TransactionParams(), body.VisorParams())
 					x.Return(tc.gatewayCreateTransactionResult, tc.gatewayCreateTransactionInputs, tc.gatewayCreateTransactionErr)
 
 				}
@@ -1827,6 +1830,474 @@ func TestWalletCreateTransaction(t *testing.T) {
 	}
 }
 
+func TestWalletCreateTransactionAddressLabels(t *testing.T) {
+	destinationAddress := testutil.MakeAddress()
+	explicitAddress := testutil.MakeAddress()
+	labelAddress := testutil.MakeAddress()
+
+	txn := &coin.Transaction{
+		Length:    100,
+		InnerHash: testutil.RandSHA256(t),
+		In:        []cipher.SHA256{testutil.RandSHA256(t), testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: destinationAddress,
+				Coins:   1e6,
+				Hours:   100,
+			},
+		},
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        labelAddress,
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 100,
+		},
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        labelAddress,
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 100,
+		},
+	}
+
+	createdTxn, err := NewCreatedTransaction(txn, inputs)
+	require.NoError(t, err)
+
+	newBody := func(labels []string, addrs []string, uxouts []string) string {
+		b := map[string]interface{}{
+			"wallet_id": "foo.wlt",
+			"unsigned":  true,
+			"hours_selection": map[string]string{
+				"type": transaction.HoursSelectionTypeManual,
+			},
+			"to": []map[string]string{
+				{
+					"address": destinationAddress.String(),
+					"coins":   "1",
+					"hours":   "10",
+				},
+			},
+			"address_labels": labels,
+		}
+		if addrs != nil {
+			b["addresses"] = addrs
+		}
+		if uxouts != nil {
+			b["unspents"] = uxouts
+		}
+		x, err := json.Marshal(b)
+		require.NoError(t, err)
+		return string(x)
+	}
+
+	cases := []struct {
+		name             string
+		body             string
+		labels           []string
+		labelAddrs       []cipher.Address
+		labelErr         error
+		expectAddrs      []cipher.Address
+		status           int
+		err              string
+		expectedResponse *CreateTransactionResponse
+	}{
+		{
+			name:   "400 - empty label",
+			body:   newBody([]string{"hot", ""}, nil, nil),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - address_labels[1] is empty",
+		},
+		{
+			name:   "400 - labels combined with unspents",
+			body:   newBody([]string{"hot"}, nil, []string{testutil.RandSHA256(t).Hex()}),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - unspents and address_labels cannot be combined",
+		},
+		{
+			name:     "400 - no addresses match labels",
+			body:     newBody([]string{"hot"}, nil, nil),
+			labels:   []string{"hot"},
+			labelErr: wallet.NewError(errors.New("no addresses in wallet match the given labels")),
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - no addresses in wallet match the given labels",
+		},
+		{
+			name:     "404 - wallet not found",
+			body:     newBody([]string{"hot"}, nil, nil),
+			labels:   []string{"hot"},
+			labelErr: wallet.ErrWalletNotExist,
+			status:   http.StatusNotFound,
+			err:      "404 Not Found - wallet doesn't exist",
+		},
+		{
+			name:        "200 - labels only",
+			body:        newBody([]string{"hot"}, nil, nil),
+			labels:      []string{"hot"},
+			labelAddrs:  []cipher.Address{labelAddress},
+			expectAddrs: []cipher.Address{labelAddress},
+			status:      http.StatusOK,
+			expectedResponse: &CreateTransactionResponse{
+				Transaction:        *createdTxn,
+				EncodedTransaction: txn.MustSerializeHex(),
+				UsedAddresses:      []string{labelAddress.String()},
+			},
+		},
+		{
+			name:        "200 - labels union addresses",
+			body:        newBody([]string{"hot", "cold"}, []string{explicitAddress.String(), labelAddress.String()}, nil),
+			labels:      []string{"hot", "cold"},
+			labelAddrs:  []cipher.Address{labelAddress, explicitAddress},
+			expectAddrs: []cipher.Address{explicitAddress, labelAddress},
+			status:      http.StatusOK,
+			expectedResponse: &CreateTransactionResponse{
+				Transaction:        *createdTxn,
+				EncodedTransaction: txn.MustSerializeHex(),
+				UsedAddresses:      []string{labelAddress.String()},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+
+			if tc.labels != nil {
+				gateway.On("GetWalletAddressesByLabels", "foo.wlt", tc.labels).Return(tc.labelAddrs, tc.labelErr)
+			}
+
+			if tc.expectAddrs != nil {
+				var req walletCreateTransactionRequest
+				err := json.Unmarshal([]byte(tc.body), &req)
+				require.NoError(t, err)
+				params := req.VisorParams()
+				params.Addresses = tc.expectAddrs
+				gateway.On("WalletCreateTransaction", mock.Anything, "foo.wlt", req.TransactionParams(), params).Return(txn, inputs, nil)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/transaction", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg CreateTransactionResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.Equal(t, *tc.expectedResponse, msg)
+		})
+	}
+}
+
+func TestWalletCreateTransactionAddressReuse(t *testing.T) {
+	destinationAddress := testutil.MakeAddress()
+	changeAddress := testutil.MakeAddress()
+
+	txn := &coin.Transaction{
+		Length:    100,
+		InnerHash: testutil.RandSHA256(t),
+		In:        []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: destinationAddress,
+				Coins:   1e6,
+				Hours:   10,
+			},
+			{
+				Address: changeAddress,
+				Coins:   1e6,
+				Hours:   90,
+			},
+		},
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        changeAddress,
+					Coins:          2e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 100,
+		},
+	}
+
+	createdTxn, err := NewCreatedTransaction(txn, inputs)
+	require.NoError(t, err)
+
+	body := `{
+		"wallet_id": "foo.wlt",
+		"unsigned": true,
+		"hours_selection": {
+			"type": "manual"
+		},
+		"to": [{
+			"address": "` + destinationAddress.String() + `",
+			"coins": "1",
+			"hours": "10"
+		}]
+	}`
+
+	reuse := []wallet.AddressReuse{
+		{
+			Address: changeAddress,
+			Change:  true,
+		},
+		{
+			Address: destinationAddress,
+		},
+	}
+	warnings := []string{
+		fmt.Sprintf("change address %s has been used before", changeAddress),
+		fmt.Sprintf("destination address %s belongs to the wallet and has been used before", destinationAddress),
+	}
+
+	cases := []struct {
+		name             string
+		reuse            []wallet.AddressReuse
+		reuseErr         error
+		strict           bool
+		strictErr        error
+		status           int
+		err              string
+		expectedResponse *CreateTransactionResponse
+	}{
+		{
+			name:   "200 - no reuse",
+			status: http.StatusOK,
+			expectedResponse: &CreateTransactionResponse{
+				Transaction:        *createdTxn,
+				EncodedTransaction: txn.MustSerializeHex(),
+			},
+		},
+		{
+			name:   "200 - reuse warnings",
+			reuse:  reuse,
+			status: http.StatusOK,
+			expectedResponse: &CreateTransactionResponse{
+				Transaction:        *createdTxn,
+				EncodedTransaction: txn.MustSerializeHex(),
+				Warnings:           warnings,
+			},
+		},
+		{
+			name:   "200 - strict privacy without reuse",
+			strict: true,
+			status: http.StatusOK,
+			expectedResponse: &CreateTransactionResponse{
+				Transaction:        *createdTxn,
+				EncodedTransaction: txn.MustSerializeHex(),
+			},
+		},
+		{
+			name:   "400 - strict privacy",
+			reuse:  reuse,
+			strict: true,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - wallet has strict privacy set: " + strings.Join(warnings, "; "),
+		},
+		{
+			name:     "500 - reuse lookup failed",
+			reuseErr: errors.New("db error"),
+			status:   http.StatusInternalServerError,
+			err:      "500 Internal Server Error - db error",
+		},
+		{
+			name:      "500 - strict privacy lookup failed",
+			reuse:     reuse,
+			strictErr: errors.New("strict error"),
+			status:    http.StatusInternalServerError,
+			err:       "500 Internal Server Error - strict error",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("VerifyWalletAccessToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+			gateway.On("WalletApprovalPolicy", mock.Anything).Return(nil, nil).Maybe()
+			gateway.On("WalletCreateTransaction", mock.Anything, "foo.wlt", mock.Anything, mock.Anything).Return(txn, inputs, nil)
+			gateway.On("WalletAddressReuse", "foo.wlt", txn, 1).Return(tc.reuse, tc.reuseErr)
+			gateway.On("WalletStrictPrivacy", "foo.wlt").Return(tc.strict, tc.strictErr).Maybe()
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/transaction", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if len(tc.reuse) == 0 {
+				gateway.AssertNotCalled(t, "WalletStrictPrivacy", mock.Anything)
+			}
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg CreateTransactionResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.Equal(t, *tc.expectedResponse, msg)
+		})
+	}
+}
+
+func TestWalletCreateTransactionNote(t *testing.T) {
+	destinationAddress := testutil.MakeAddress()
+
+	txn := &coin.Transaction{
+		Length:    100,
+		InnerHash: testutil.RandSHA256(t),
+		In:        []cipher.SHA256{testutil.RandSHA256(t)},
+		Sigs:      []cipher.Sig{testutil.RandSig(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: destinationAddress,
+				Coins:   1e6,
+				Hours:   100,
+			},
+		},
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        testutil.MakeAddress(),
+					Coins:          1e6,
+					Hours:          200,
+				},
+			},
+			CalculatedHours: 200,
+		},
+	}
+
+	createdTxn, err := NewCreatedTransaction(txn, inputs)
+	require.NoError(t, err)
+
+	newBody := func(unsigned bool, note string) string {
+		b := map[string]interface{}{
+			"wallet_id": "foo.wlt",
+			"unsigned":  unsigned,
+			"hours_selection": map[string]string{
+				"type": transaction.HoursSelectionTypeManual,
+			},
+			"to": []map[string]string{
+				{
+					"address": destinationAddress.String(),
+					"coins":   "1",
+					"hours":   "100",
+				},
+			},
+			"note": note,
+		}
+		x, err := json.Marshal(b)
+		require.NoError(t, err)
+		return string(x)
+	}
+
+	cases := []struct {
+		name             string
+		body             string
+		setNoteErr       error
+		status           int
+		err              string
+		expectedResponse *CreateTransactionResponse
+	}{
+		{
+			name:   "400 - note on unsigned transaction",
+			body:   newBody(true, "rent"),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - note cannot be attached to an unsigned transaction",
+		},
+		{
+			name:   "400 - note too long",
+			body:   newBody(false, strings.Repeat("x", kvstorage.MaxWalletTxNoteLength+1)),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - note is longer than 256 characters",
+		},
+		{
+			name:       "403 - storage API disabled",
+			body:       newBody(false, "rent"),
+			setNoteErr: kvstorage.ErrStorageAPIDisabled,
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden - storage api is disabled, notes are unavailable",
+		},
+		{
+			name:   "200",
+			body:   newBody(false, "rent"),
+			status: http.StatusOK,
+			expectedResponse: &CreateTransactionResponse{
+				Transaction:        *createdTxn,
+				EncodedTransaction: txn.MustSerializeHex(),
+				Note:               "rent",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+
+			var req walletCreateTransactionRequest
+			err := json.Unmarshal([]byte(tc.body), &req)
+			require.NoError(t, err)
+			gateway.On("WalletCreateTransactionSigned", mock.Anything, "foo.wlt", []byte(""), req.TransactionParams(), req.VisorParams()).Return(txn, inputs, nil)
+			gateway.On("SetWalletTxNote", "foo.wlt", txn.Hash(), "rent").Return(tc.setNoteErr)
+
+			r, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/transaction", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			r.Header.Add("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, r)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, r)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg CreateTransactionResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.Equal(t, *tc.expectedResponse, msg)
+			gateway.AssertCalled(t, "SetWalletTxNote", "foo.wlt", txn.Hash(), "rent")
+		})
+	}
+}
+
 func newStrPtr(s string) *string {
 	return &s
 }
@@ -2038,7 +2509,7 @@ func TestWalletSignTransaction(t *testing.T) {
 			method:       http.MethodPost,
 			rawBody:      "{ca",
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid character 'c' looking for beginning of object key string"),
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: "invalid JSON at offset 2: invalid character 'c' looking for beginning of object key string", Offset: 2}),
 		},
 
 		{
@@ -2119,7 +2590,7 @@ func TestWalletSignTransaction(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 
 			var txn *coin.Transaction
 			if tc.body != nil {
@@ -2132,7 +2603,7 @@ func TestWalletSignTransaction(t *testing.T) {
 			}
 
 			if tc.body != nil {
-				gateway.On("WalletSignTransaction", tc.body.WalletID, []byte(tc.body.Password), txn, tc.body.SignIndexes).Return(tc.gatewaySignTransactionResult, tc.gatewaySignTransactionInputs, tc.gatewaySignTransactionErr)
+				gateway.On("WalletSignTransaction", mock.Anything, tc.body.WalletID, []byte(tc.body.Password), txn, tc.body.SignIndexes).Return(tc.gatewaySignTransactionResult, tc.gatewaySignTransactionInputs, tc.gatewaySignTransactionErr)
 			}
 
 			endpoint := "/api/v2/wallet/transaction/sign"
@@ -2191,3 +2662,543 @@ func TestWalletSignTransaction(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletSignTransactionAddressPasswords(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	uxOuts := []coin.UxOut{
+		{
+			Head: coin.UxHead{
+				Time:  uint64(time.Now().UTC().Unix()),
+				BkSeq: 9999,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        addr,
+				Coins:          1e6,
+				Hours:          100,
+			},
+		},
+		{
+			Head: coin.UxHead{
+				Time:  uint64(time.Now().UTC().Unix()),
+				BkSeq: 9999,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        testutil.MakeAddress(),
+				Coins:          2e6,
+				Hours:          100,
+			},
+		},
+	}
+
+	signedTxn := coin.Transaction{
+		Length:    100,
+		Type:      0,
+		InnerHash: testutil.RandSHA256(t),
+		Sigs:      []cipher.Sig{testutil.RandSig(t), testutil.RandSig(t)},
+		In:        []cipher.SHA256{uxOuts[0].Hash(), uxOuts[1].Hash()},
+		Out: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   100,
+			},
+		},
+	}
+
+	partiallySignedTxn := signedTxn
+	partiallySignedTxn.Sigs = []cipher.Sig{testutil.RandSig(t), {}}
+
+	txn := signedTxn
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut:           uxOuts[0],
+			CalculatedHours: 200,
+		},
+		{
+			UxOut:           uxOuts[1],
+			CalculatedHours: 200,
+		},
+	}
+
+	signedTxnResp, err := NewCreateTransactionResponse(&signedTxn, inputs)
+	require.NoError(t, err)
+	partiallySignedTxnResp, err := NewCreateTransactionResponse(&partiallySignedTxn, inputs)
+	require.NoError(t, err)
+
+	addrPasswords := map[string]string{
+		addr.String(): "pwd",
+	}
+
+	invalidPasswordErr := wallet.NewError(wallet.AddressPasswordError{
+		Address: addr,
+		Err:     wallet.ErrInvalidPassword,
+	})
+
+	tt := []struct {
+		name         string
+		body         *WalletSignTransactionRequest
+		spentSeq     uint64
+		missingUxOut bool
+		signResult   *coin.Transaction
+		signErr      error
+		verifySigned visor.TxnSignedFlag
+		verifyInputs []visor.TransactionInput
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name: "400 invalid address",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: txn.MustSerializeHex(),
+				AddressPasswords: map[string]string{
+					"foo": "pwd",
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid address "foo" in address_passwords: Invalid address length`),
+		},
+		{
+			name: "400 unspent does not exist",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: txn.MustSerializeHex(),
+				AddressPasswords:   addrPasswords,
+			},
+			missingUxOut: true,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("unspent output of %s does not exist", uxOuts[0].Hash().Hex())),
+		},
+		{
+			name: "400 output spent",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: txn.MustSerializeHex(),
+				AddressPasswords:   addrPasswords,
+			},
+			spentSeq:     10,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("unspent output of %s does not exist", uxOuts[0].Hash().Hex())),
+		},
+		{
+			name: "400 invalid address password",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: txn.MustSerializeHex(),
+				AddressPasswords:   addrPasswords,
+			},
+			signErr:      invalidPasswordErr,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("address %s: invalid password", addr)),
+		},
+		{
+			name: "404 wallet not found",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: txn.MustSerializeHex(),
+				AddressPasswords:   addrPasswords,
+			},
+			signErr:      wallet.ErrWalletNotExist,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name: "200 partially signed",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: txn.MustSerializeHex(),
+				SignIndexes:        []int{0},
+				AddressPasswords:   addrPasswords,
+			},
+			signResult:   &partiallySignedTxn,
+			verifySigned: visor.TxnUnsigned,
+			verifyInputs: inputs,
+			status:       http.StatusOK,
+			httpResponse: HTTPResponse{
+				Data: *partiallySignedTxnResp,
+			},
+		},
+		{
+			name: "200 fully signed",
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				Password:           "wpwd",
+				EncodedTransaction: txn.MustSerializeHex(),
+				AddressPasswords:   addrPasswords,
+			},
+			signResult:   &signedTxn,
+			verifySigned: visor.TxnSigned,
+			verifyInputs: inputs,
+			status:       http.StatusOK,
+			httpResponse: HTTPResponse{
+				Data: *signedTxnResp,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+
+			for _, ux := range uxOuts {
+				if tc.missingUxOut {
+					gateway.On("GetUxOutByID", ux.Hash()).Return(nil, nil)
+				} else {
+					gateway.On("GetUxOutByID", ux.Hash()).Return(&historydb.UxOut{
+						Out:           ux,
+						SpentBlockSeq: tc.spentSeq,
+					}, nil)
+				}
+			}
+
+			gateway.On("SignTransactionWithAddressPasswords", tc.body.WalletID, []byte(tc.body.Password), map[cipher.Address][]byte{
+				addr: []byte("pwd"),
+			}, &txn, tc.body.SignIndexes, uxOuts).Return(tc.signResult, tc.signErr)
+
+			if tc.signResult != nil {
+				gateway.On("VerifyTxnVerbose", tc.signResult, tc.verifySigned).Return(tc.verifyInputs, false, nil)
+			}
+
+			bodyText, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/wallet/transaction/sign", bytes.NewBuffer(bodyText))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.httpResponse.Data == nil {
+				require.Nil(t, rsp.Data)
+				return
+			}
+
+			var cRsp CreateTransactionResponse
+			require.NoError(t, json.Unmarshal(rsp.Data, &cRsp))
+			require.Equal(t, tc.httpResponse.Data.(CreateTransactionResponse), cRsp)
+		})
+	}
+}
+
+func TestWalletBumpTransaction(t *testing.T) {
+	destinationAddress := testutil.MakeAddress()
+	changeAddress := testutil.MakeAddress()
+
+	txn := coin.Transaction{
+		Length:    100,
+		Type:      0,
+		InnerHash: testutil.RandSHA256(t),
+		Sigs:      make([]cipher.Sig, 1),
+		In:        []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: destinationAddress,
+				Coins:   1e6,
+				Hours:   100,
+			},
+		},
+	}
+
+	bumpedTxn := txn
+	bumpedTxn.InnerHash = testutil.RandSHA256(t)
+	bumpedTxn.Sigs = make([]cipher.Sig, 2)
+	bumpedTxn.In = []cipher.SHA256{txn.In[0], testutil.RandSHA256(t)}
+	bumpedTxn.Out = []coin.TransactionOutput{
+		txn.Out[0],
+		{
+			Address: changeAddress,
+			Coins:   1e6,
+			Hours:   50,
+		},
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Head: coin.UxHead{
+					Time:  uint64(time.Now().UTC().Unix()),
+					BkSeq: 9999,
+				},
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        testutil.MakeAddress(),
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 200,
+		},
+		{
+			UxOut: coin.UxOut{
+				Head: coin.UxHead{
+					Time:  uint64(time.Now().UTC().Unix()),
+					BkSeq: 9999,
+				},
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        testutil.MakeAddress(),
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 200,
+		},
+	}
+
+	bumpedTxnResp, err := NewCreateTransactionResponse(&bumpedTxn, inputs)
+	require.NoError(t, err)
+
+	validBody := &WalletBumpTransactionRequest{
+		WalletID:           "foo.wlt",
+		EncodedTransaction: txn.MustSerializeHex(),
+		Fee:                "300",
+	}
+
+	tt := []struct {
+		name                         string
+		method                       string
+		body                         *WalletBumpTransactionRequest
+		rawBody                      string
+		status                       int
+		gatewayCalled                bool
+		gatewayFee                   uint64
+		gatewayChangeAddress         *cipher.Address
+		gatewayBumpTransactionResult *coin.Transaction
+		gatewayBumpTransactionInputs []visor.TransactionInput
+		gatewayBumpTransactionErr    error
+		httpResponse                 HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "400 - invalid json",
+			method:       http.MethodPost,
+			rawBody:      "{ca",
+			status:       http.StatusBadRequest,
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: "invalid JSON at offset 2: invalid character 'c' looking for beginning of object key string", Offset: 2}),
+		},
+
+		{
+			name:   "400 wallet ID required",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			body: &WalletBumpTransactionRequest{
+				EncodedTransaction: validBody.EncodedTransaction,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+
+		{
+			name:   "400 encoded_transaction is required",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			body: &WalletBumpTransactionRequest{
+				WalletID: "foo.wlt",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "encoded_transaction is required"),
+		},
+
+		{
+			name:   "400 invalid fee",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			body: &WalletBumpTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: validBody.EncodedTransaction,
+				Fee:                "-1",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid fee"),
+		},
+
+		{
+			name:   "400 invalid change address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			body: &WalletBumpTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: validBody.EncodedTransaction,
+				ChangeAddress:      "xxx",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid change_address: Invalid address length"),
+		},
+
+		{
+			name:   "400 decode transaction failed",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			body: &WalletBumpTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: "abc",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "Decode transaction failed: encoding/hex: odd length hex string"),
+		},
+
+		{
+			name:                      "404 - wallet not found",
+			method:                    http.MethodPost,
+			body:                      validBody,
+			status:                    http.StatusNotFound,
+			gatewayCalled:             true,
+			gatewayFee:                300,
+			gatewayBumpTransactionErr: wallet.ErrWalletNotExist,
+			httpResponse:              NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+
+		{
+			name:                      "403 - wallet API disabled",
+			method:                    http.MethodPost,
+			body:                      validBody,
+			status:                    http.StatusForbidden,
+			gatewayCalled:             true,
+			gatewayFee:                300,
+			gatewayBumpTransactionErr: wallet.ErrWalletAPIDisabled,
+			httpResponse:              NewHTTPErrorResponse(http.StatusForbidden, "wallet api is disabled"),
+		},
+
+		{
+			name:                      "400 - fee already satisfied",
+			method:                    http.MethodPost,
+			body:                      validBody,
+			status:                    http.StatusBadRequest,
+			gatewayCalled:             true,
+			gatewayFee:                300,
+			gatewayBumpTransactionErr: transaction.ErrFeeSatisfied,
+			httpResponse:              NewHTTPErrorResponse(http.StatusBadRequest, "Transaction already pays the target fee"),
+		},
+
+		{
+			name:                      "400 - signed transaction",
+			method:                    http.MethodPost,
+			body:                      validBody,
+			status:                    http.StatusBadRequest,
+			gatewayCalled:             true,
+			gatewayFee:                300,
+			gatewayBumpTransactionErr: transaction.ErrBumpSignedTransaction,
+			httpResponse:              NewHTTPErrorResponse(http.StatusBadRequest, "Only fully unsigned transactions can be bumped"),
+		},
+
+		{
+			name:                      "400 - violates hard constraint",
+			method:                    http.MethodPost,
+			body:                      validBody,
+			status:                    http.StatusBadRequest,
+			gatewayCalled:             true,
+			gatewayFee:                300,
+			gatewayBumpTransactionErr: visor.NewErrTxnViolatesHardConstraint(errors.New("bad txn")),
+			httpResponse:              NewHTTPErrorResponse(http.StatusBadRequest, "Transaction violates hard constraint: bad txn"),
+		},
+
+		{
+			name:                      "500 - misc error",
+			method:                    http.MethodPost,
+			body:                      validBody,
+			status:                    http.StatusInternalServerError,
+			gatewayCalled:             true,
+			gatewayFee:                300,
+			gatewayBumpTransactionErr: errors.New("unhandled error"),
+			httpResponse:              NewHTTPErrorResponse(http.StatusInternalServerError, "unhandled error"),
+		},
+
+		{
+			name:                         "200",
+			method:                       http.MethodPost,
+			body:                         validBody,
+			status:                       http.StatusOK,
+			gatewayCalled:                true,
+			gatewayFee:                   300,
+			gatewayBumpTransactionResult: &bumpedTxn,
+			gatewayBumpTransactionInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: *bumpedTxnResp,
+			},
+		},
+
+		{
+			name:   "200 - change address, no fee",
+			method: http.MethodPost,
+			body: &WalletBumpTransactionRequest{
+				WalletID:           "foo.wlt",
+				EncodedTransaction: validBody.EncodedTransaction,
+				ChangeAddress:      changeAddress.String(),
+			},
+			status:                       http.StatusOK,
+			gatewayCalled:                true,
+			gatewayChangeAddress:         &changeAddress,
+			gatewayBumpTransactionResult: &bumpedTxn,
+			gatewayBumpTransactionInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: *bumpedTxnResp,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+
+			if tc.gatewayCalled {
+				txnx, err := coin.DeserializeTransactionHex(tc.body.EncodedTransaction)
+				require.NoError(t, err)
+				gateway.On("WalletBumpTransaction", tc.body.WalletID, &txnx, tc.gatewayFee, tc.gatewayChangeAddress).Return(tc.gatewayBumpTransactionResult, tc.gatewayBumpTransactionInputs, tc.gatewayBumpTransactionErr)
+			}
+
+			endpoint := "/api/v2/wallet/transaction/bump"
+
+			bodyText := []byte(tc.rawBody)
+			if len(bodyText) == 0 {
+				var err error
+				bodyText, err = json.Marshal(tc.body)
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, bytes.NewBuffer(bodyText))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var cRsp CreateTransactionResponse
+				err := json.Unmarshal(rsp.Data, &cRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(CreateTransactionResponse), cRsp)
+			}
+
+			gateway.AssertExpectations(t)
+		})
+	}
+}
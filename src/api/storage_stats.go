@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+// StorageStatsResponse is returned by GET /api/v2/storage/stats and the /api/v2/storage/compact endpoints
+type StorageStatsResponse struct {
+	Path string `json:"path"`
+	// Size is the size of the database file, in bytes
+	Size    int64                `json:"size"`
+	Buckets []StorageBucketStats `json:"buckets"`
+	// Compacting is true while the database is compacted
+	Compacting bool `json:"compacting"`
+	// CompactionStartedAt is the unix time the running compaction started, 0 if none is running
+	CompactionStartedAt int64 `json:"compaction_started_at"`
+	// LastCompaction is null if the database was never compacted
+	LastCompaction *StorageCompaction `json:"last_compaction"`
+}
+
+// StorageBucketStats is the number of keys of a database bucket, including the keys of its nested buckets
+type StorageBucketStats struct {
+	Name string `json:"name"`
+	Keys int    `json:"keys"`
+}
+
+// StorageCompaction is the result of a database compaction
+type StorageCompaction struct {
+	StartedAt  int64 `json:"started_at"`
+	FinishedAt int64 `json:"finished_at"`
+	SizeBefore int64 `json:"size_before"`
+	// SizeAfter is 0 if the compaction failed
+	SizeAfter int64 `json:"size_after"`
+	// Error is why the compaction failed or was aborted, empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// NewStorageStatsResponse creates a StorageStatsResponse
+func NewStorageStatsResponse(s visor.StorageStats) StorageStatsResponse {
+	buckets := make([]StorageBucketStats, len(s.Buckets))
+	for i, b := range s.Buckets {
+		buckets[i] = StorageBucketStats{
+			Name: b.Name,
+			Keys: b.Keys,
+		}
+	}
+
+	var lastCompaction *StorageCompaction
+	if c := s.LastCompaction; c != nil {
+		lastCompaction = &StorageCompaction{
+			StartedAt:  unixTimeOrZero(c.StartedAt),
+			FinishedAt: unixTimeOrZero(c.FinishedAt),
+			SizeBefore: c.SizeBefore,
+			SizeAfter:  c.SizeAfter,
+			Error:      c.Error,
+		}
+	}
+
+	return StorageStatsResponse{
+		Path:                s.Path,
+		Size:                s.Size,
+		Buckets:             buckets,
+		Compacting:          s.Compacting,
+		CompactionStartedAt: unixTimeOrZero(s.CompactionStartedAt),
+		LastCompaction:      lastCompaction,
+	}
+}
+
+func unixTimeOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// Returns the size of the database file, the number of keys of its buckets and the state of its compaction
+// Method: GET
+// URI: /api/v2/storage/stats
+// Response: StorageStatsResponse
+func storageStatsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeStorageStats(w, gateway)
+	}
+}
+
+// Starts or aborts a compaction of the database. The database is copied into a new file in the background,
+// which replaces the database file. Database writes wait until the compaction is done.
+// Method: POST, DELETE
+// URI: /api/v2/storage/compact
+// Response: StorageStatsResponse
+func storageCompactHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.Method {
+		case http.MethodPost:
+			err = gateway.StartDBCompaction()
+		case http.MethodDelete:
+			err = gateway.AbortDBCompaction()
+		default:
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrDBCompactionRunning:
+				resp = NewHTTPErrorResponse(http.StatusConflict, err.Error())
+			case visor.ErrNoDBCompaction:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+			case visor.ErrDBReadOnly:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeStorageStats(w, gateway)
+	}
+}
+
+func writeStorageStats(w http.ResponseWriter, gateway Gatewayer) {
+	s, err := gateway.StorageStats()
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: NewStorageStatsResponse(*s),
+	})
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestStorageStats(t *testing.T) {
+	compacting := &visor.StorageStats{
+		Path: "/data/data.db",
+		Size: 1 << 30,
+		Buckets: []visor.BucketStats{
+			{Name: "blocks", Keys: 62431},
+			{Name: "unspent_pool", Keys: 38113},
+		},
+		Compacting:          true,
+		CompactionStartedAt: time.Unix(1600000100, 0),
+		LastCompaction: &visor.DBCompaction{
+			StartedAt:  time.Unix(1600000000, 0),
+			FinishedAt: time.Unix(1600000030, 0),
+			SizeBefore: 1 << 31,
+			SizeAfter:  1 << 30,
+		},
+	}
+	compactingResult := &StorageStatsResponse{
+		Path: "/data/data.db",
+		Size: 1 << 30,
+		Buckets: []StorageBucketStats{
+			{Name: "blocks", Keys: 62431},
+			{Name: "unspent_pool", Keys: 38113},
+		},
+		Compacting:          true,
+		CompactionStartedAt: 1600000100,
+		LastCompaction: &StorageCompaction{
+			StartedAt:  1600000000,
+			FinishedAt: 1600000030,
+			SizeBefore: 1 << 31,
+			SizeAfter:  1 << 30,
+		},
+	}
+	idle := &visor.StorageStats{
+		Path: "/data/data.db",
+		Size: 1 << 20,
+	}
+	idleResult := &StorageStatsResponse{
+		Path:    "/data/data.db",
+		Size:    1 << 20,
+		Buckets: []StorageBucketStats{},
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		endpoint   string
+		stats      *visor.StorageStats
+		statsErr   error
+		start      bool
+		startErr   error
+		abort      bool
+		abortErr   error
+		httpStatus int
+		err        string
+		result     *StorageStatsResponse
+	}{
+		{
+			name:       "405 - stats",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/storage/stats",
+			httpStatus: http.StatusMethodNotAllowed,
+			err:        "Method Not Allowed",
+		},
+		{
+			name:       "500 - stats",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/storage/stats",
+			statsErr:   errors.New("failed"),
+			httpStatus: http.StatusInternalServerError,
+			err:        "failed",
+		},
+		{
+			name:       "stats",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/storage/stats",
+			stats:      idle,
+			httpStatus: http.StatusOK,
+			result:     idleResult,
+		},
+		{
+			name:       "405 - compact",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/storage/compact",
+			httpStatus: http.StatusMethodNotAllowed,
+			err:        "Method Not Allowed",
+		},
+		{
+			name:       "409 - compact while compacting",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/storage/compact",
+			start:      true,
+			startErr:   visor.ErrDBCompactionRunning,
+			httpStatus: http.StatusConflict,
+			err:        "Database compaction is already running",
+		},
+		{
+			name:       "403 - compact read-only database",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/storage/compact",
+			start:      true,
+			startErr:   visor.ErrDBReadOnly,
+			httpStatus: http.StatusForbidden,
+			err:        "Database is read-only",
+		},
+		{
+			name:       "compact",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/storage/compact",
+			start:      true,
+			stats:      compacting,
+			httpStatus: http.StatusOK,
+			result:     compactingResult,
+		},
+		{
+			name:       "404 - abort without compaction",
+			method:     http.MethodDelete,
+			endpoint:   "/api/v2/storage/compact",
+			abort:      true,
+			abortErr:   visor.ErrNoDBCompaction,
+			httpStatus: http.StatusNotFound,
+			err:        "No database compaction is running",
+		},
+		{
+			name:       "abort",
+			method:     http.MethodDelete,
+			endpoint:   "/api/v2/storage/compact",
+			abort:      true,
+			stats:      compacting,
+			httpStatus: http.StatusOK,
+			result:     compactingResult,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("StorageStats").Return(tc.stats, tc.statsErr)
+			if tc.start {
+				gateway.On("StartDBCompaction").Return(tc.startErr)
+			}
+			if tc.abort {
+				gateway.On("AbortDBCompaction").Return(tc.abortErr)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.endpoint, nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.httpStatus, rr.Code)
+
+			var resp struct {
+				Error *HTTPError            `json:"error"`
+				Data  *StorageStatsResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.httpStatus != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
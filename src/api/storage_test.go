@@ -10,7 +10,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"github.com/skycoin/skycoin/src/kvstorage"
+	"github.com/ness-network/privateness/src/kvstorage"
 )
 
 func TestGetAllStorageValuesHandler(t *testing.T) {
@@ -455,7 +455,7 @@ func TestAddStorageValueHandler(t *testing.T) {
 			key:                "",
 			val:                "",
 			addStorageValueErr: kvstorage.ErrUnknownKVStorageType,
-			httpResponse:       NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			httpResponse:       NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
 		},
 		{
 			name:        "400 - missing type",
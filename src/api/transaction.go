@@ -5,28 +5,77 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/mathutil"
-	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/util/timeutil"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
-// pendingTxnsHandler returns pending (unconfirmed) transactions
-// Method: GET
+// PendingTxn is an unconfirmed transaction returned by /api/v1/pendingTxs
+type PendingTxn struct {
+	readable.UnconfirmedTransactions
+	// ConflictsWith lists the other unconfirmed transactions spending any of this transaction's inputs
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+}
+
+// PendingTxnVerbose is a verbose unconfirmed transaction returned by /api/v1/pendingTxs?verbose=1
+type PendingTxnVerbose struct {
+	readable.UnconfirmedTransactionVerbose
+	// ConflictsWith lists the other unconfirmed transactions spending any of this transaction's inputs
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+	// ReceivedAt is when the transaction was last received, its time in the pool is measured from it
+	ReceivedAt time.Time `json:"received_at"`
+	// LastAnnouncedAt is when the transaction was last announced to peers, null if it was never announced
+	LastAnnouncedAt *time.Time `json:"last_announced_at"`
+	// AnnounceCount is the number of times the transaction was announced to peers
+	AnnounceCount uint64 `json:"announce_count"`
+	// IsValidAtLastCheck is whether the transaction was valid when last checked against the blockchain
+	IsValidAtLastCheck bool `json:"is_valid_at_last_check"`
+	// CalculatedHoursEstimated is true for unconfirmed transactions. Their inputs' calculated hours and their fee
+	// are computed at the head block time, instead of the time of the block that spends the inputs.
+	CalculatedHoursEstimated bool `json:"calculated_hours_estimated,omitempty"`
+}
+
+const (
+	// pendingTxnsSortAge sorts pending transactions by their time in the pool, oldest first
+	pendingTxnsSortAge = "age"
+	// pendingTxnsSortFee sorts pending transactions by their fee, highest first
+	pendingTxnsSortFee = "fee"
+)
+
+// pendingTxnsHandler returns pending (unconfirmed) transactions, or abandons a pending transaction
+// Method: GET, DELETE
 // URI: /api/v1/pendingTxs
-// Args:
+// Args (GET):
+//
 //	verbose: [bool] include verbose transaction input data
+//	stuck: [bool] only return locally created transactions that have been pending for longer than the local txn expiry
+//	sort: [string] "age" sorts by time in the pool, oldest first. "fee" sorts by fee, highest first, and requires verbose.
+//	min_age_seconds: [int] only return transactions that have been in the pool for at least this many seconds
+//
+// Args (DELETE):
+//
+//	txid: transaction id [required]. Only locally created transactions can be abandoned.
 func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			abandonPendingTxn(gateway, w, r)
+			return
+		default:
 			wh.Error405(w)
 			return
 		}
@@ -37,6 +86,59 @@ func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		stuck, err := parseBoolFlag(r.FormValue("stuck"))
+		if err != nil {
+			wh.Error400(w, "Invalid value for stuck")
+			return
+		}
+
+		sortBy := r.FormValue("sort")
+		switch sortBy {
+		case "", pendingTxnsSortAge:
+		case pendingTxnsSortFee:
+			if !verbose {
+				wh.Error400(w, "sort=fee requires verbose")
+				return
+			}
+		default:
+			wh.Error400(w, "Invalid value for sort, must be age or fee")
+			return
+		}
+
+		var minAge time.Duration
+		if minAgeStr := r.FormValue("min_age_seconds"); minAgeStr != "" {
+			minAgeSecs, err := strconv.ParseUint(minAgeStr, 10, 64)
+			if err != nil || minAgeSecs > uint64(math.MaxInt64/int64(time.Second)) {
+				wh.Error400(w, "Invalid value for min_age_seconds")
+				return
+			}
+			minAge = time.Duration(minAgeSecs) * time.Second
+		}
+
+		var stuckHashes map[cipher.SHA256]struct{}
+		if stuck {
+			hashes, err := gateway.GetStuckUnconfirmedTxnHashes()
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			stuckHashes = make(map[cipher.SHA256]struct{}, len(hashes))
+			for _, h := range hashes {
+				stuckHashes[h] = struct{}{}
+			}
+		}
+
+		now := time.Now()
+		include := func(txn visor.UnconfirmedTransaction) bool {
+			if stuck {
+				if _, ok := stuckHashes[txn.Transaction.Hash()]; !ok {
+					return false
+				}
+			}
+			return minAge == 0 || now.Sub(timeutil.NanoToTime(txn.Received)) >= minAge
+		}
+
 		if verbose {
 			txns, inputs, err := gateway.GetAllUnconfirmedTransactionsVerbose()
 			if err != nil {
@@ -44,13 +146,70 @@ func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
+			if stuck || minAge != 0 {
+				var includedTxns []visor.UnconfirmedTransaction
+				var includedInputs [][]visor.TransactionInput
+				for i, txn := range txns {
+					if include(txn) {
+						includedTxns = append(includedTxns, txn)
+						includedInputs = append(includedInputs, inputs[i])
+					}
+				}
+				txns = includedTxns
+				inputs = includedInputs
+			}
+
+			conflicts, err := gateway.GetUnconfirmedConflicts()
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+			conflictsWith := newConflictsWith(conflicts)
+
+			announceCounts, err := gateway.GetUnconfirmedTxnAnnounceCounts()
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
 			vb, err := readable.NewUnconfirmedTransactionsVerbose(txns, inputs)
 			if err != nil {
 				wh.Error500(w, err.Error())
 				return
 			}
 
-			wh.SendJSONOr500(logger, w, vb)
+			ret := make([]PendingTxnVerbose, len(vb))
+			for i, txn := range vb {
+				var lastAnnouncedAt *time.Time
+				if !txn.Announced.IsZero() {
+					announced := txn.Announced
+					lastAnnouncedAt = &announced
+				}
+
+				hash := txns[i].Transaction.Hash()
+				ret[i] = PendingTxnVerbose{
+					UnconfirmedTransactionVerbose: txn,
+					ConflictsWith:                 conflictsWith[hash],
+					ReceivedAt:                    txn.Received,
+					LastAnnouncedAt:               lastAnnouncedAt,
+					AnnounceCount:                 announceCounts[hash],
+					IsValidAtLastCheck:            txn.IsValid,
+					CalculatedHoursEstimated:      true,
+				}
+			}
+
+			switch sortBy {
+			case pendingTxnsSortAge:
+				sort.SliceStable(ret, func(i, j int) bool {
+					return ret[i].ReceivedAt.Before(ret[j].ReceivedAt)
+				})
+			case pendingTxnsSortFee:
+				sort.SliceStable(ret, func(i, j int) bool {
+					return ret[i].Transaction.Fee > ret[j].Transaction.Fee
+				})
+			}
+
+			wh.SendJSONOr500(logger, w, ret)
 		} else {
 			txns, err := gateway.GetAllUnconfirmedTransactions()
 			if err != nil {
@@ -58,17 +217,225 @@ func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
-			ret, err := readable.NewUnconfirmedTransactions(txns)
+			if stuck || minAge != 0 {
+				var includedTxns []visor.UnconfirmedTransaction
+				for _, txn := range txns {
+					if include(txn) {
+						includedTxns = append(includedTxns, txn)
+					}
+				}
+				txns = includedTxns
+			}
+
+			conflicts, err := gateway.GetUnconfirmedConflicts()
 			if err != nil {
 				wh.Error500(w, err.Error())
 				return
 			}
+			conflictsWith := newConflictsWith(conflicts)
+
+			rTxns, err := readable.NewUnconfirmedTransactions(txns)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			ret := make([]PendingTxn, len(rTxns))
+			for i, txn := range rTxns {
+				ret[i] = PendingTxn{
+					UnconfirmedTransactions: txn,
+					ConflictsWith:           conflictsWith[txns[i].Transaction.Hash()],
+				}
+			}
+
+			if sortBy == pendingTxnsSortAge {
+				sort.SliceStable(ret, func(i, j int) bool {
+					return ret[i].Received.Before(ret[j].Received)
+				})
+			}
 
 			wh.SendJSONOr500(logger, w, ret)
 		}
 	}
 }
 
+// AbandonPendingTxnResponse is returned by DELETE /api/v1/pendingTxs
+type AbandonPendingTxnResponse struct {
+	TxID    string `json:"txid"`
+	Message string `json:"message"`
+}
+
+// abandonPendingTxn removes a locally created transaction from the unconfirmed pool.
+// Peers that received the transaction are not affected.
+func abandonPendingTxn(gateway Gatewayer, w http.ResponseWriter, r *http.Request) {
+	txidStr := r.FormValue("txid")
+	if txidStr == "" {
+		wh.Error400(w, "txid is empty")
+		return
+	}
+
+	txid, err := cipher.SHA256FromHex(txidStr)
+	if err != nil {
+		wh.Error400(w, "invalid txid")
+		return
+	}
+
+	if err := gateway.AbandonUnconfirmedTransaction(txid); err != nil {
+		switch err {
+		case visor.ErrUnconfirmedTxnNotFound:
+			wh.Error404(w, err.Error())
+		case visor.ErrUnconfirmedTxnNotLocal:
+			wh.Error403(w, err.Error())
+		default:
+			wh.Error500(w, err.Error())
+		}
+		return
+	}
+
+	wh.SendJSONOr500(logger, w, AbandonPendingTxnResponse{
+		TxID:    txid.Hex(),
+		Message: "transaction removed from the local unconfirmed pool only, it is not revoked from peers that received it, which may still relay it and get it confirmed",
+	})
+}
+
+// newConflictsWith maps each conflicting transaction hash to the sorted hex hashes of
+// the other transactions spending any of the same inputs
+func newConflictsWith(conflicts map[cipher.SHA256][]cipher.SHA256) map[cipher.SHA256][]string {
+	others := make(map[cipher.SHA256]map[cipher.SHA256]struct{})
+	for _, hashes := range conflicts {
+		for _, h := range hashes {
+			for _, o := range hashes {
+				if o == h {
+					continue
+				}
+				if others[h] == nil {
+					others[h] = make(map[cipher.SHA256]struct{})
+				}
+				others[h][o] = struct{}{}
+			}
+		}
+	}
+
+	conflictsWith := make(map[cipher.SHA256][]string, len(others))
+	for h, os := range others {
+		hexes := make([]string, 0, len(os))
+		for o := range os {
+			hexes = append(hexes, o.Hex())
+		}
+		sort.Strings(hexes)
+		conflictsWith[h] = hexes
+	}
+
+	return conflictsWith
+}
+
+// UnconfirmedTxnConflict is an unconfirmed transaction in a group of conflicting transactions
+type UnconfirmedTxnConflict struct {
+	TxID     string    `json:"txid"`
+	Fee      uint64    `json:"fee"`
+	Received time.Time `json:"received"`
+}
+
+// UnconfirmedTxnConflictGroup is a set of unconfirmed transactions spending the same output
+type UnconfirmedTxnConflictGroup struct {
+	UxID         string                   `json:"uxid"`
+	Transactions []UnconfirmedTxnConflict `json:"transactions"`
+}
+
+// UnconfirmedConflictsResponse is the response of /api/v1/pendingTxs/conflicts
+type UnconfirmedConflictsResponse struct {
+	Conflicts []UnconfirmedTxnConflictGroup `json:"conflicts"`
+}
+
+// pendingTxnsConflictsHandler returns the groups of unconfirmed transactions that spend the same output.
+// Only one transaction of each group can be confirmed.
+// Groups are sorted by uxid, transactions within a group by the time they were first received.
+// Method: GET
+// URI: /api/v1/pendingTxs/conflicts
+func pendingTxnsConflictsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		conflicts, err := gateway.GetUnconfirmedConflicts()
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		resp := UnconfirmedConflictsResponse{
+			Conflicts: []UnconfirmedTxnConflictGroup{},
+		}
+
+		if len(conflicts) == 0 {
+			wh.SendJSONOr500(logger, w, resp)
+			return
+		}
+
+		txns, inputs, err := gateway.GetAllUnconfirmedTransactionsVerbose()
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		if len(txns) != len(inputs) {
+			wh.Error500(w, "len(txns) != len(inputs)")
+			return
+		}
+
+		pending := make(map[cipher.SHA256]UnconfirmedTxnConflict, len(txns))
+		for i := range txns {
+			rTxn, err := readable.NewUnconfirmedTransactionVerbose(&txns[i], inputs[i])
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			pending[txns[i].Transaction.Hash()] = UnconfirmedTxnConflict{
+				TxID:     rTxn.Transaction.Hash,
+				Fee:      rTxn.Transaction.Fee,
+				Received: rTxn.Received,
+			}
+		}
+
+		for uxid, hashes := range conflicts {
+			group := UnconfirmedTxnConflictGroup{
+				UxID:         uxid.Hex(),
+				Transactions: make([]UnconfirmedTxnConflict, 0, len(hashes)),
+			}
+
+			for _, h := range hashes {
+				// The pool may have changed between the two gateway calls
+				if txn, ok := pending[h]; ok {
+					group.Transactions = append(group.Transactions, txn)
+				}
+			}
+
+			if len(group.Transactions) < 2 {
+				continue
+			}
+
+			sort.Slice(group.Transactions, func(i, j int) bool {
+				a, b := group.Transactions[i], group.Transactions[j]
+				if a.Received.Equal(b.Received) {
+					return a.TxID < b.TxID
+				}
+				return a.Received.Before(b.Received)
+			})
+
+			resp.Conflicts = append(resp.Conflicts, group)
+		}
+
+		sort.Slice(resp.Conflicts, func(i, j int) bool {
+			return resp.Conflicts[i].UxID < resp.Conflicts[j].UxID
+		})
+
+		wh.SendJSONOr500(logger, w, resp)
+	}
+}
+
 // TransactionEncodedResponse represents the data struct of the response to /api/v1/transaction?encoded=1
 type TransactionEncodedResponse struct {
 	Status             readable.TransactionStatus `json:"status"`
@@ -80,9 +447,10 @@ type TransactionEncodedResponse struct {
 // Method: GET
 // URI: /api/v1/transaction
 // Args:
-//	txid: transaction hash
-//	verbose: [bool] include verbose transaction input data
-//  encoded: [bool] return as a raw encoded transaction
+//
+//		txid: transaction hash
+//		verbose: [bool] include verbose transaction input data
+//	 encoded: [bool] return as a raw encoded transaction
 func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -130,7 +498,7 @@ func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
-			rTxn, err := readable.NewTransactionWithStatusVerbose(txn, inputs)
+			rTxn, err := NewTransactionWithStatusVerbose(txn, inputs)
 			if err != nil {
 				wh.Error500(w, err.Error())
 				return
@@ -210,9 +578,34 @@ func NewTransactionsWithStatus(txns []visor.Transaction) (*TransactionsWithStatu
 	}, nil
 }
 
+// TransactionWithStatusVerbose is a verbose transaction with its status
+type TransactionWithStatusVerbose struct {
+	readable.TransactionWithStatusVerbose
+	// CalculatedHoursEstimated is true for unconfirmed transactions. Their inputs' calculated hours and their fee
+	// are computed at the head block time, instead of the time of the block that spends the inputs.
+	CalculatedHoursEstimated bool `json:"calculated_hours_estimated,omitempty"`
+}
+
+// NewTransactionWithStatusVerbose converts a visor.Transaction to a TransactionWithStatusVerbose
+func NewTransactionWithStatusVerbose(txn *visor.Transaction, inputs []visor.TransactionInput) (*TransactionWithStatusVerbose, error) {
+	if txn == nil {
+		return nil, errors.New("NewTransactionWithStatusVerbose: txn is nil")
+	}
+
+	rTxn, err := readable.NewTransactionWithStatusVerbose(txn, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionWithStatusVerbose{
+		TransactionWithStatusVerbose: *rTxn,
+		CalculatedHoursEstimated:     !txn.Status.Confirmed,
+	}, nil
+}
+
 // TransactionsWithStatusVerbose array of transaction results
 type TransactionsWithStatusVerbose struct {
-	Transactions []readable.TransactionWithStatusVerbose `json:"txns"`
+	Transactions []TransactionWithStatusVerbose `json:"txns"`
 }
 
 // Sort sorts transactions chronologically, using txid for tiebreaking
@@ -235,9 +628,9 @@ func NewTransactionsWithStatusVerbose(txns []visor.Transaction, inputs [][]visor
 		return nil, errors.New("NewTransactionsWithStatusVerbose: len(txns) != len(inputs)")
 	}
 
-	txnRlts := make([]readable.TransactionWithStatusVerbose, len(txns))
+	txnRlts := make([]TransactionWithStatusVerbose, len(txns))
 	for i, txn := range txns {
-		rTxn, err := readable.NewTransactionWithStatusVerbose(&txn, inputs[i])
+		rTxn, err := NewTransactionWithStatusVerbose(&txn, inputs[i])
 		if err != nil {
 			return nil, err
 		}
@@ -253,9 +646,14 @@ func NewTransactionsWithStatusVerbose(txns []visor.Transaction, inputs [][]visor
 // Method: GET, POST
 // URI: /api/v1/transactions
 // Args:
-//     addrs: Comma separated addresses [optional, returns all transactions if no address provided]
-//     confirmed: Whether the transactions should be confirmed [optional, must be 0 or 1; if not provided, returns all]
-//	   verbose: [bool] include verbose transaction input data
+//
+//	    addrs: Comma separated addresses [optional, returns all transactions if no address provided]
+//	    confirmed: Whether the transactions should be confirmed [optional, must be 0 or 1; if not provided, returns all]
+//	    start_block: First block seq of the transactions of addrs [optional, requires addrs, default 0]
+//	    end_block: Last block seq of the transactions of addrs [optional, requires addrs, default the head block]
+//		   verbose: [bool] include verbose transaction input data
+//
+// With a block range, only confirmed transactions are returned, ordered by block seq then by their index in the block.
 func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodPost {
@@ -281,8 +679,9 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 
 		// Gets the 'confirmed' parameter value
 		confirmedStr := r.FormValue("confirmed")
+		confirmed := true
 		if confirmedStr != "" {
-			confirmed, err := strconv.ParseBool(confirmedStr)
+			confirmed, err = strconv.ParseBool(confirmedStr)
 			if err != nil {
 				wh.Error400(w, fmt.Sprintf("invalid 'confirmed' value: %v", err))
 				return
@@ -291,6 +690,47 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 			flts = append(flts, visor.NewConfirmedTxFilter(confirmed))
 		}
 
+		// Gets the 'start_block' and 'end_block' parameter values
+		sStart := r.FormValue("start_block")
+		sEnd := r.FormValue("end_block")
+		if sStart != "" || sEnd != "" {
+			if len(addrs) == 0 {
+				wh.Error400(w, "start_block and end_block require addrs")
+				return
+			}
+
+			if !confirmed {
+				wh.Error400(w, "confirmed=false cannot be used with start_block or end_block, a block range only has confirmed transactions")
+				return
+			}
+
+			start := uint64(0)
+			if sStart != "" {
+				start, err = strconv.ParseUint(sStart, 10, 64)
+				if err != nil {
+					wh.Error400(w, fmt.Sprintf("Invalid start_block value %q", sStart))
+					return
+				}
+			}
+
+			end := uint64(math.MaxUint64)
+			if sEnd != "" {
+				end, err = strconv.ParseUint(sEnd, 10, 64)
+				if err != nil {
+					wh.Error400(w, fmt.Sprintf("Invalid end_block value %q", sEnd))
+					return
+				}
+			}
+
+			if start > end {
+				wh.Error400(w, "start_block must not be greater than end_block")
+				return
+			}
+
+			blockRangeTransactions(w, gateway, addrs, start, end, verbose)
+			return
+		}
+
 		if verbose {
 			txns, inputs, err := gateway.GetTransactionsWithInputs(flts)
 			if err != nil {
@@ -327,6 +767,62 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// blockRangeTransactions writes the confirmed transactions of addrs in the blocks from start to end,
+// in the order of the blocks and of the transactions in each block
+func blockRangeTransactions(w http.ResponseWriter, gateway Gatewayer, addrs []cipher.Address, start, end uint64, verbose bool) {
+	if verbose {
+		txns, inputs, err := gateway.GetAddressTransactionsInBlockRangeWithInputs(addrs, start, end)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		vInputs := make([][]visor.TransactionInput, len(inputs))
+		for i, txnInputs := range inputs {
+			vInputs[i] = make([]visor.TransactionInput, len(txnInputs))
+			for j, in := range txnInputs {
+				vInputs[i][j] = visor.TransactionInput(in)
+			}
+		}
+
+		rTxns, err := NewTransactionsWithStatusVerbose(newVisorTransactions(txns), vInputs)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, rTxns.Transactions)
+		return
+	}
+
+	txns, err := gateway.GetAddressTransactionsInBlockRange(addrs, start, end)
+	if err != nil {
+		wh.Error500(w, err.Error())
+		return
+	}
+
+	rTxns, err := NewTransactionsWithStatus(newVisorTransactions(txns))
+	if err != nil {
+		wh.Error500(w, err.Error())
+		return
+	}
+
+	wh.SendJSONOr500(logger, w, rTxns.Transactions)
+}
+
+// newVisorTransactions converts the transactions of the local visor to the transactions of the readable package
+func newVisorTransactions(txns []visor.Transaction) []visor.Transaction {
+	vTxns := make([]visor.Transaction, len(txns))
+	for i, txn := range txns {
+		vTxns[i] = visor.Transaction{
+			Transaction: txn.Transaction,
+			Status:      visor.TransactionStatus(txn.Status),
+			Time:        txn.Time,
+		}
+	}
+	return vTxns
+}
+
 // InjectTransactionRequest is sent to POST /api/v1/injectTransaction
 type InjectTransactionRequest struct {
 	RawTxn      string `json:"rawtx"`
@@ -338,10 +834,11 @@ type InjectTransactionRequest struct {
 // Content-Type: application/json
 // Body: {"rawtx": "<hex encoded transaction>"}
 // Response:
-//      200 - ok, returns the transaction hash in hex as string
-//      400 - bad transaction
-//		500 - other error
-//      503 - network unavailable for broadcasting transaction
+//
+//	     200 - ok, returns the transaction hash in hex as string
+//	     400 - bad transaction
+//			500 - other error
+//	     503 - network unavailable for broadcasting transaction
 func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -379,7 +876,7 @@ func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 		} else {
-			if err := gateway.InjectBroadcastTransaction(txn); err != nil {
+			if err := gateway.InjectBroadcastTransaction(r.Context(), txn); err != nil {
 				switch err.(type) {
 				case visor.ErrTxnViolatesUserConstraint,
 					visor.ErrTxnViolatesHardConstraint,
@@ -420,10 +917,11 @@ func NewResendResult(hashes []cipher.SHA256) ResendResult {
 // Method: POST
 // Broadcasts all unconfirmed transactions from the unconfirmed transaction pool
 // Response:
-//      200 - ok, returns the transaction hashes that were resent
-//      405 - method not POST
-//		500 - other error
-//      503 - network unavailable for broadcasting transaction
+//
+//	     200 - ok, returns the transaction hashes that were resent
+//	     405 - method not POST
+//			500 - other error
+//	     503 - network unavailable for broadcasting transaction
 func resendUnconfirmedTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -448,7 +946,9 @@ func resendUnconfirmedTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 // URI: /api/v1/rawtx
 // Method: GET
 // Args:
+//
 //	txid: transaction ID hash
+//
 // Returns the hex-encoded byte serialization of a transaction.
 // The transaction may be confirmed or unconfirmed.
 func rawTxnHandler(gateway Gatewayer) http.HandlerFunc {
@@ -495,18 +995,28 @@ func rawTxnHandler(gateway Gatewayer) http.HandlerFunc {
 type VerifyTransactionRequest struct {
 	Unsigned           bool   `json:"unsigned"`
 	EncodedTransaction string `json:"encoded_transaction"`
+	Encoding           string `json:"encoding,omitempty"`
+	Evaluate           bool   `json:"evaluate,omitempty"`
 }
 
 // VerifyTransactionResponse the response data struct for /api/v2/transaction/verify
 type VerifyTransactionResponse struct {
-	Unsigned    bool               `json:"unsigned"`
-	Confirmed   bool               `json:"confirmed"`
-	Transaction CreatedTransaction `json:"transaction"`
+	Unsigned    bool                   `json:"unsigned"`
+	Confirmed   bool                   `json:"confirmed"`
+	Transaction CreatedTransaction     `json:"transaction"`
+	Evaluation  *TransactionEvaluation `json:"evaluation,omitempty"`
 }
 
 // Decode and verify an encoded transaction
 // Method: POST
 // URI: /api/v2/transaction/verify
+// Args: JSON body
+//
+//	encoded_transaction [string]: serialized transaction [required]
+//	encoding [string]: "hex" (default) or "base64"
+//	unsigned [bool]: verify the transaction as unsigned
+//	evaluate [bool]: evaluate the transaction against the node's soft constraints.
+//	 Checks that depend on the inputs' coin hours are "unknown" if the inputs are not unspent.
 func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -516,8 +1026,8 @@ func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 		}
 
 		var req VerifyTransactionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
 			writeHTTPResponse(w, resp)
 			return
 		}
@@ -528,7 +1038,7 @@ func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		txn, err := decodeTxn(req.EncodedTransaction)
+		txn, err := decodeTxnEncoding(req.EncodedTransaction, req.Encoding)
 		if err != nil {
 			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("decode transaction failed: %v", err))
 			writeHTTPResponse(w, resp)
@@ -575,6 +1085,16 @@ func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 
 		verifyTxnResp.Transaction = *verboseTxn
 
+		if req.Evaluate {
+			// The inputs of a confirmed transaction are spent, their coin hours are not evaluated
+			unspentInputs := inputs
+			if isTxnConfirmed {
+				unspentInputs = nil
+			}
+			evaluation := evaluateTxn(txn, unspentInputs, gateway.VisorConfig().UnconfirmedVerifyTxn)
+			verifyTxnResp.Evaluation = &evaluation
+		}
+
 		resp.Data = verifyTxnResp
 
 		if isTxnConfirmed && resp.Error == nil {
@@ -588,6 +1108,88 @@ func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// TestAcceptTransactionsRequest is sent to POST /api/v2/transaction/test-accept
+type TestAcceptTransactionsRequest struct {
+	RawTxns []string `json:"rawtxs"`
+}
+
+// TestAcceptResult reports whether a transaction would be accepted by POST /api/v1/injectTransaction
+type TestAcceptResult struct {
+	Txid         string `json:"txid"`
+	Accepted     bool   `json:"accepted"`
+	RejectCode   string `json:"reject_code,omitempty"`
+	RejectReason string `json:"reject_reason,omitempty"`
+	FeeHours     uint64 `json:"fee_hours"`
+	Size         uint32 `json:"size"`
+}
+
+// NewTestAcceptResults creates []TestAcceptResult from []visor.TxnAcceptResult
+func NewTestAcceptResults(results []visor.TxnAcceptResult) []TestAcceptResult {
+	rs := make([]TestAcceptResult, len(results))
+	for i, r := range results {
+		rs[i] = TestAcceptResult{
+			Txid:         r.Txid.Hex(),
+			Accepted:     r.Accepted,
+			RejectCode:   r.RejectCode,
+			RejectReason: r.RejectReason,
+			FeeHours:     r.Fee,
+			Size:         r.Size,
+		}
+	}
+	return rs
+}
+
+// Test whether raw transactions would be accepted into the unconfirmed pool, without injecting them
+// Method: POST
+// URI: /api/v2/transaction/test-accept
+// Args: JSON body with "rawtxs", a list of hex-encoded serialized transactions
+// The transactions are tested in order, as if they were injected one after another.
+// The unconfirmed pool is not modified.
+func testAcceptTxnsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req TestAcceptTransactionsRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.RawTxns) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "rawtxs is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txns := make([]coin.Transaction, len(req.RawTxns))
+		for i, rawTxn := range req.RawTxns {
+			txn, err := decodeTxn(rawTxn)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("decode rawtxs[%d] failed: %v", i, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			txns[i] = *txn
+		}
+
+		results, err := gateway.TestAcceptTransactions(txns)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewTestAcceptResults(results),
+		})
+	}
+}
+
 func decodeTxn(encodedTxn string) (*coin.Transaction, error) {
 	var txn coin.Transaction
 	b, err := hex.DecodeString(encodedTxn)
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/fee"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+const (
+	// TxnEncodingHex hex encoded serialized transaction
+	TxnEncodingHex = "hex"
+	// TxnEncodingBase64 base64 encoded serialized transaction
+	TxnEncodingBase64 = "base64"
+)
+
+const (
+	// ConstraintOK the transaction satisfies the constraint
+	ConstraintOK = "ok"
+	// ConstraintViolated the transaction violates the constraint
+	ConstraintViolated = "violated"
+	// ConstraintUnknown the constraint could not be evaluated, because the transaction's inputs are not unspent
+	ConstraintUnknown = "unknown"
+)
+
+// decodeTxnEncoding decodes a serialized transaction in the given encoding, "hex" if empty
+func decodeTxnEncoding(encodedTxn, encoding string) (*coin.Transaction, error) {
+	var b []byte
+	var err error
+	switch encoding {
+	case "", TxnEncodingHex:
+		b, err = hex.DecodeString(encodedTxn)
+	case TxnEncodingBase64:
+		b, err = base64.StdEncoding.DecodeString(encodedTxn)
+	default:
+		return nil, fmt.Errorf("invalid encoding %q, valid encodings are %q or %q", encoding, TxnEncodingHex, TxnEncodingBase64)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := coin.DeserializeTransaction(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txn, nil
+}
+
+// ConstraintEvaluation is the result of checking a soft constraint
+type ConstraintEvaluation struct {
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func newConstraintEvaluation(err error) ConstraintEvaluation {
+	if err != nil {
+		return ConstraintEvaluation{
+			Result: ConstraintViolated,
+			Reason: err.Error(),
+		}
+	}
+
+	return ConstraintEvaluation{
+		Result: ConstraintOK,
+	}
+}
+
+// TransactionEvaluation is the evaluation of a transaction against the soft constraints
+// applied by the node to unconfirmed transactions
+type TransactionEvaluation struct {
+	// FeeHours is the coin hours burned by the transaction, null if unknown
+	FeeHours *uint64 `json:"fee_hours"`
+	// Size is the size of the serialized transaction
+	Size uint32 `json:"size"`
+
+	BurnFactor          uint32 `json:"burn_factor"`
+	MaxTransactionSize  uint32 `json:"max_transaction_size"`
+	MaxDropletPrecision uint8  `json:"max_decimals"`
+
+	Burn     ConstraintEvaluation `json:"burn_compliance"`
+	Decimals ConstraintEvaluation `json:"decimal_compliance"`
+	MaxSize  ConstraintEvaluation `json:"size_compliance"`
+}
+
+// evaluateTxn checks a transaction against the soft constraints of p.
+// inputs are the transaction's unspent inputs, or nil if they are unknown,
+// in which case the checks that depend on the input coin hours are reported as unknown.
+func evaluateTxn(txn *coin.Transaction, inputs []visor.TransactionInput, p params.VerifyTxn) TransactionEvaluation {
+	e := TransactionEvaluation{
+		BurnFactor:          p.BurnFactor,
+		MaxTransactionSize:  p.MaxTransactionSize,
+		MaxDropletPrecision: p.MaxDropletPrecision,
+		Burn: ConstraintEvaluation{
+			Result: ConstraintUnknown,
+		},
+	}
+
+	size, err := txn.Size()
+	if err != nil {
+		e.MaxSize = newConstraintEvaluation(visor.ErrTxnExceedsMaxBlockSize)
+	} else {
+		e.Size = size
+		if size > p.MaxTransactionSize {
+			e.MaxSize = newConstraintEvaluation(visor.ErrTxnExceedsMaxBlockSize)
+		} else {
+			e.MaxSize = newConstraintEvaluation(nil)
+		}
+	}
+
+	e.Decimals = newConstraintEvaluation(nil)
+	for _, o := range txn.Out {
+		if err := params.DropletPrecisionCheck(p.MaxDropletPrecision, o.Coins); err != nil {
+			e.Decimals = newConstraintEvaluation(err)
+			break
+		}
+	}
+
+	if len(inputs) == 0 || len(inputs) != len(txn.In) {
+		return e
+	}
+
+	feeHours, err := txnFeeHours(txn, inputs)
+	if err != nil {
+		e.Burn = newConstraintEvaluation(err)
+		return e
+	}
+
+	e.FeeHours = &feeHours
+	e.Burn = newConstraintEvaluation(fee.VerifyTransactionFee(txn, feeHours, p.BurnFactor))
+
+	return e
+}
+
+// txnFeeHours returns the difference between the input and output coin hours of a transaction
+func txnFeeHours(txn *coin.Transaction, inputs []visor.TransactionInput) (uint64, error) {
+	var inputHours uint64
+	for _, i := range inputs {
+		var err error
+		inputHours, err = mathutil.AddUint64(inputHours, i.CalculatedHours)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	outputHours, err := txn.OutputHours()
+	if err != nil {
+		return 0, err
+	}
+
+	if inputHours < outputHours {
+		return 0, fee.ErrTxnInsufficientCoinHours
+	}
+
+	return inputHours - outputHours, nil
+}
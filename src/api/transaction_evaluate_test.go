@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/fee"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestDecodeTxnEncoding(t *testing.T) {
+	txnAndInputs := prepareTxnAndInputs(t)
+	b := txnAndInputs.txn.MustSerialize()
+
+	for _, encoding := range []string{"", TxnEncodingHex} {
+		txn, err := decodeTxnEncoding(txnAndInputs.txn.MustSerializeHex(), encoding)
+		require.NoError(t, err)
+		require.Equal(t, txnAndInputs.txn, *txn)
+	}
+
+	txn, err := decodeTxnEncoding(base64.StdEncoding.EncodeToString(b), TxnEncodingBase64)
+	require.NoError(t, err)
+	require.Equal(t, txnAndInputs.txn, *txn)
+
+	_, err = decodeTxnEncoding(base64.StdEncoding.EncodeToString(b), TxnEncodingHex)
+	require.Error(t, err)
+
+	_, err = decodeTxnEncoding(txnAndInputs.txn.MustSerializeHex(), "base58")
+	require.EqualError(t, err, `invalid encoding "base58", valid encodings are "hex" or "base64"`)
+}
+
+func TestEvaluateTxn(t *testing.T) {
+	txnAndInputs := prepareTxnAndInputs(t)
+	txn := txnAndInputs.txn
+	size, err := txn.Size()
+	require.NoError(t, err)
+
+	var inputHours uint64
+	for _, i := range txnAndInputs.inputs {
+		inputHours += i.CalculatedHours
+	}
+	feeHours := inputHours - 100
+
+	p := params.VerifyTxn{
+		BurnFactor:          2,
+		MaxTransactionSize:  size,
+		MaxDropletPrecision: 3,
+	}
+
+	// An output with more decimal places than allowed, the transaction size is unchanged
+	txnDecimals := txn
+	txnDecimals.Out = append([]coin.TransactionOutput{}, txn.Out...)
+	txnDecimals.Out[0].Coins = 1100000
+
+	ok := ConstraintEvaluation{Result: ConstraintOK}
+	unknown := ConstraintEvaluation{Result: ConstraintUnknown}
+
+	cases := []struct {
+		name   string
+		txn    coin.Transaction
+		inputs []visor.TransactionInput
+		p      params.VerifyTxn
+		expect TransactionEvaluation
+	}{
+		{
+			name:   "ok",
+			txn:    txn,
+			inputs: txnAndInputs.inputs,
+			p:      p,
+			expect: TransactionEvaluation{
+				FeeHours: &feeHours,
+				Burn:     ok,
+				Decimals: ok,
+				MaxSize:  ok,
+			},
+		},
+		{
+			name: "inputs unknown",
+			txn:  txn,
+			p:    p,
+			expect: TransactionEvaluation{
+				Burn:     unknown,
+				Decimals: ok,
+				MaxSize:  ok,
+			},
+		},
+		{
+			name:   "inputs count mismatch",
+			txn:    txn,
+			inputs: append(txnAndInputs.inputs, txnAndInputs.inputs...),
+			p:      p,
+			expect: TransactionEvaluation{
+				Burn:     unknown,
+				Decimals: ok,
+				MaxSize:  ok,
+			},
+		},
+		{
+			name:   "violations",
+			txn:    txnDecimals,
+			inputs: txnAndInputs.inputs,
+			p: params.VerifyTxn{
+				BurnFactor:          1,
+				MaxTransactionSize:  size - 1,
+				MaxDropletPrecision: 0,
+			},
+			expect: TransactionEvaluation{
+				FeeHours: &feeHours,
+				Burn: ConstraintEvaluation{
+					Result: ConstraintViolated,
+					Reason: fee.ErrTxnInsufficientFee.Error(),
+				},
+				Decimals: ConstraintEvaluation{
+					Result: ConstraintViolated,
+					Reason: params.ErrInvalidDecimals.Error(),
+				},
+				MaxSize: ConstraintEvaluation{
+					Result: ConstraintViolated,
+					Reason: visor.ErrTxnExceedsMaxBlockSize.Error(),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect.Size = size
+			tc.expect.BurnFactor = tc.p.BurnFactor
+			tc.expect.MaxTransactionSize = tc.p.MaxTransactionSize
+			tc.expect.MaxDropletPrecision = tc.p.MaxDropletPrecision
+
+			e := evaluateTxn(&tc.txn, tc.inputs, tc.p)
+			require.Equal(t, tc.expect, e)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+const (
+	defaultTxnGraphDepth    = 5
+	maxTxnGraphDepth        = 50
+	defaultTxnGraphMaxNodes = 1000
+	maxTxnGraphMaxNodes     = 10000
+)
+
+// TxnGraphResponse is returned by GET /api/v2/transaction/ancestry and GET /api/v2/transaction/descendants
+type TxnGraphResponse struct {
+	Root      string `json:"root"`
+	Direction string `json:"direction"`
+	// Truncated is true if the neighbours of some nodes were left out of the graph
+	Truncated bool                   `json:"truncated"`
+	Nodes     []TxnGraphNodeResponse `json:"nodes"`
+	Edges     []TxnGraphEdgeResponse `json:"edges"`
+}
+
+// TxnGraphNodeResponse is an output of a transaction graph
+type TxnGraphNodeResponse struct {
+	readable.SpentOutput
+	// Depth is the number of transactions between the root output and this output
+	Depth int `json:"depth"`
+	// Truncated is "depth" or "max_nodes" if the neighbours of this output were left out of the graph
+	Truncated string `json:"truncated,omitempty"`
+}
+
+// TxnGraphEdgeResponse links an output to the transaction that created ("created_by") or spent ("spent_by") it
+type TxnGraphEdgeResponse struct {
+	Kind string `json:"kind"`
+	UxID string `json:"uxid"`
+	Txid string `json:"txid"`
+}
+
+// NewTxnGraphResponse creates a TxnGraphResponse
+func NewTxnGraphResponse(g *visor.TxnGraph) TxnGraphResponse {
+	nodes := make([]TxnGraphNodeResponse, len(g.Nodes))
+	for i, n := range g.Nodes {
+		nodes[i] = TxnGraphNodeResponse{
+			SpentOutput: readable.NewSpentOutput(&n.UxOut),
+			Depth:       n.Depth,
+			Truncated:   n.Truncated,
+		}
+	}
+
+	edges := make([]TxnGraphEdgeResponse, len(g.Edges))
+	for i, e := range g.Edges {
+		edges[i] = TxnGraphEdgeResponse{
+			Kind: e.Kind,
+			UxID: e.UxID.Hex(),
+			Txid: e.Txid.Hex(),
+		}
+	}
+
+	return TxnGraphResponse{
+		Root:      g.Root.Hex(),
+		Direction: string(g.Direction),
+		Truncated: g.Truncated,
+		Nodes:     nodes,
+		Edges:     edges,
+	}
+}
+
+// Returns the graph of the outputs reached from a confirmed output by following,
+// for ancestry, the transactions that created the outputs back to the outputs they spent,
+// or, for descendants, the transactions that spent the outputs forward to the outputs they created.
+// The graph is bounded by depth and max_nodes, and the nodes whose neighbours were left out are marked truncated.
+// Method: GET
+// URI: /api/v2/transaction/ancestry, /api/v2/transaction/descendants
+// Args:
+//
+//	uxid: output ID [required]
+//	depth: maximum number of transactions between the output and the nodes [optional, defaults to 5, at most 50]
+//	max_nodes: maximum number of nodes [optional, defaults to 1000, at most 10000]
+//
+// Response: TxnGraphResponse
+func txnGraphHandler(gateway Gatewayer, direction visor.TxnGraphDirection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		uxidStr := r.FormValue("uxid")
+		if uxidStr == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "uxid is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		uxid, err := cipher.SHA256FromHex(uxidStr)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid uxid: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		opts := visor.TxnGraphOptions{
+			Direction: direction,
+			Depth:     defaultTxnGraphDepth,
+			MaxNodes:  defaultTxnGraphMaxNodes,
+		}
+
+		for _, n := range []struct {
+			name string
+			dst  *int
+			min  int
+			max  int
+		}{
+			{"depth", &opts.Depth, 0, maxTxnGraphDepth},
+			{"max_nodes", &opts.MaxNodes, 1, maxTxnGraphMaxNodes},
+		} {
+			if v := r.FormValue(n.name); v != "" {
+				*n.dst, err = strconv.Atoi(v)
+				if err != nil || *n.dst < n.min || *n.dst > n.max {
+					resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid %s value, must be between %d and %d", n.name, n.min, n.max))
+					writeHTTPResponse(w, resp)
+					return
+				}
+			}
+		}
+
+		g, err := gateway.GetTxnGraph(uxid, opts)
+		if err != nil {
+			var resp HTTPResponse
+			switch err.(type) {
+			case historydb.ErrUxOutNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+			case visor.UserError:
+				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewTxnGraphResponse(g),
+		})
+	}
+}
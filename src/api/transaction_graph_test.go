@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestTxnGraphHandler(t *testing.T) {
+	root := historydb.UxOut{
+		Out: coin.UxOut{
+			Head: coin.UxHead{
+				Time:  1000,
+				BkSeq: 3,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        testutil.MakeAddress(),
+				Coins:          2e6,
+				Hours:          20,
+			},
+		},
+	}
+	parent := historydb.UxOut{
+		Out: coin.UxOut{
+			Head: coin.UxHead{
+				Time:  900,
+				BkSeq: 2,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        testutil.MakeAddress(),
+				Coins:          3e6,
+				Hours:          30,
+			},
+		},
+		SpentBlockSeq: 3,
+		SpentTxnID:    root.Out.Body.SrcTransaction,
+	}
+
+	uxid := root.Hash()
+
+	graph := &visor.TxnGraph{
+		Root:      uxid,
+		Direction: visor.TxnGraphAncestry,
+		Nodes: []visor.TxnGraphNode{
+			{
+				UxOut: root,
+			},
+			{
+				UxOut:     parent,
+				Depth:     1,
+				Truncated: visor.TxnGraphTruncatedDepth,
+			},
+		},
+		Edges: []visor.TxnGraphEdge{
+			{
+				Kind: visor.TxnGraphCreatedBy,
+				UxID: uxid,
+				Txid: root.Out.Body.SrcTransaction,
+			},
+			{
+				Kind: visor.TxnGraphSpentBy,
+				UxID: parent.Hash(),
+				Txid: root.Out.Body.SrcTransaction,
+			},
+			{
+				Kind: visor.TxnGraphCreatedBy,
+				UxID: parent.Hash(),
+				Txid: parent.Out.Body.SrcTransaction,
+			},
+		},
+		Truncated: true,
+	}
+
+	graphResult := &TxnGraphResponse{
+		Root:      uxid.Hex(),
+		Direction: "ancestry",
+		Truncated: true,
+		Nodes: []TxnGraphNodeResponse{
+			{
+				SpentOutput: readable.NewSpentOutput(&root),
+			},
+			{
+				SpentOutput: readable.NewSpentOutput(&parent),
+				Depth:       1,
+				Truncated:   "depth",
+			},
+		},
+		Edges: []TxnGraphEdgeResponse{
+			{
+				Kind: "created_by",
+				UxID: uxid.Hex(),
+				Txid: root.Out.Body.SrcTransaction.Hex(),
+			},
+			{
+				Kind: "spent_by",
+				UxID: parent.Hash().Hex(),
+				Txid: root.Out.Body.SrcTransaction.Hex(),
+			},
+			{
+				Kind: "created_by",
+				UxID: parent.Hash().Hex(),
+				Txid: parent.Out.Body.SrcTransaction.Hex(),
+			},
+		},
+	}
+
+	defaultOpts := func(d visor.TxnGraphDirection) *visor.TxnGraphOptions {
+		return &visor.TxnGraphOptions{
+			Direction: d,
+			Depth:     defaultTxnGraphDepth,
+			MaxNodes:  defaultTxnGraphMaxNodes,
+		}
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		endpoint   string
+		query      string
+		opts       *visor.TxnGraphOptions
+		graph      *visor.TxnGraph
+		graphErr   error
+		httpStatus int
+		err        string
+		result     *TxnGraphResponse
+	}{
+		{
+			name:       "405",
+			method:     http.MethodPost,
+			endpoint:   "/api/v2/transaction/ancestry",
+			httpStatus: http.StatusMethodNotAllowed,
+			err:        "Method Not Allowed",
+		},
+		{
+			name:       "400 - missing uxid",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/transaction/ancestry",
+			httpStatus: http.StatusBadRequest,
+			err:        "uxid is required",
+		},
+		{
+			name:       "400 - invalid uxid",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/transaction/ancestry",
+			query:      "uxid=abcd",
+			httpStatus: http.StatusBadRequest,
+			err:        "invalid uxid: Invalid hex length",
+		},
+		{
+			name:       "400 - invalid depth",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/transaction/ancestry",
+			query:      "uxid=" + uxid.Hex() + "&depth=51",
+			httpStatus: http.StatusBadRequest,
+			err:        "invalid depth value, must be between 0 and 50",
+		},
+		{
+			name:       "400 - invalid max_nodes",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/transaction/descendants",
+			query:      "uxid=" + uxid.Hex() + "&max_nodes=0",
+			httpStatus: http.StatusBadRequest,
+			err:        "invalid max_nodes value, must be between 1 and 10000",
+		},
+		{
+			name:       "404 - unknown output",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/transaction/ancestry",
+			query:      "uxid=" + uxid.Hex(),
+			opts:       defaultOpts(visor.TxnGraphAncestry),
+			graphErr:   historydb.NewErrUxOutNotExist(uxid.Hex()),
+			httpStatus: http.StatusNotFound,
+			err:        historydb.NewErrUxOutNotExist(uxid.Hex()).Error(),
+		},
+		{
+			name:       "500 - gateway error",
+			method:     http.MethodGet,
+			endpoint:   "/api/v2/transaction/ancestry",
+			query:      "uxid=" + uxid.Hex(),
+			opts:       defaultOpts(visor.TxnGraphAncestry),
+			graphErr:   errors.New("failed"),
+			httpStatus: http.StatusInternalServerError,
+			err:        "failed",
+		},
+		{
+			name:     "ancestry",
+			method:   http.MethodGet,
+			endpoint: "/api/v2/transaction/ancestry",
+			query:    "uxid=" + uxid.Hex() + "&depth=1&max_nodes=10",
+			opts: &visor.TxnGraphOptions{
+				Direction: visor.TxnGraphAncestry,
+				Depth:     1,
+				MaxNodes:  10,
+			},
+			graph:      graph,
+			httpStatus: http.StatusOK,
+			result:     graphResult,
+		},
+		{
+			name:     "descendants",
+			method:   http.MethodGet,
+			endpoint: "/api/v2/transaction/descendants",
+			query:    "uxid=" + uxid.Hex() + "&depth=0",
+			opts: &visor.TxnGraphOptions{
+				Direction: visor.TxnGraphDescendants,
+				Depth:     0,
+				MaxNodes:  defaultTxnGraphMaxNodes,
+			},
+			graph: &visor.TxnGraph{
+				Root:      uxid,
+				Direction: visor.TxnGraphDescendants,
+				Nodes: []visor.TxnGraphNode{
+					{
+						UxOut: root,
+					},
+				},
+			},
+			httpStatus: http.StatusOK,
+			result: &TxnGraphResponse{
+				Root:      uxid.Hex(),
+				Direction: "descendants",
+				Nodes: []TxnGraphNodeResponse{
+					{
+						SpentOutput: readable.NewSpentOutput(&root),
+					},
+				},
+				Edges: []TxnGraphEdgeResponse{},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.opts != nil {
+				gateway.On("GetTxnGraph", uxid, *tc.opts).Return(tc.graph, tc.graphErr)
+			}
+
+			endpoint := tc.endpoint
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.httpStatus, rr.Code)
+
+			var resp struct {
+				Error *HTTPError        `json:"error"`
+				Data  *TxnGraphResponse `json:"data"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			if tc.httpStatus != http.StatusOK {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tc.err, resp.Error.Message)
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.result, resp.Data)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -19,11 +20,13 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/daemon/gnet"
-	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/visor"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 func createUnconfirmedTxn(t *testing.T) visor.UnconfirmedTransaction {
@@ -97,6 +100,9 @@ func TestGetPendingTxs(t *testing.T) {
 		err                                  string
 		verbose                              bool
 		verboseStr                           string
+		stuck                                string
+		sort                                 string
+		minAge                               string
 		getAllUnconfirmedTxnsResponse        []visor.UnconfirmedTransaction
 		getAllUnconfirmedTxnsErr             error
 		getAllUnconfirmedTxnsVerboseResponse verboseResult
@@ -117,6 +123,41 @@ func TestGetPendingTxs(t *testing.T) {
 			err:        "400 Bad Request - Invalid value for verbose",
 			verboseStr: "foo",
 		},
+		{
+			name:   "400 - bad stuck",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid value for stuck",
+			stuck:  "foo",
+		},
+		{
+			name:   "400 - bad sort",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid value for sort, must be age or fee",
+			sort:   "foo",
+		},
+		{
+			name:   "400 - sort by fee without verbose",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - sort=fee requires verbose",
+			sort:   "fee",
+		},
+		{
+			name:   "400 - bad min_age_seconds",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid value for min_age_seconds",
+			minAge: "-1",
+		},
+		{
+			name:   "400 - min_age_seconds too large",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid value for min_age_seconds",
+			minAge: "18446744073709551615",
+		},
 		{
 			name:   "500 - bad unconfirmedTxn",
 			method: http.MethodGet,
@@ -170,11 +211,22 @@ func TestGetPendingTxs(t *testing.T) {
 			gateway.On("GetAllUnconfirmedTransactions").Return(tc.getAllUnconfirmedTxnsResponse, tc.getAllUnconfirmedTxnsErr)
 			gateway.On("GetAllUnconfirmedTransactionsVerbose").Return(tc.getAllUnconfirmedTxnsVerboseResponse.Transactions,
 				tc.getAllUnconfirmedTxnsVerboseResponse.Inputs, tc.getAllUnconfirmedTxnsVerboseErr)
+			gateway.On("GetUnconfirmedConflicts").Return(nil, nil)
+			gateway.On("GetUnconfirmedTxnAnnounceCounts").Return(nil, nil)
 
 			v := url.Values{}
 			if tc.verboseStr != "" {
 				v.Add("verbose", tc.verboseStr)
 			}
+			if tc.stuck != "" {
+				v.Add("stuck", tc.stuck)
+			}
+			if tc.sort != "" {
+				v.Add("sort", tc.sort)
+			}
+			if tc.minAge != "" {
+				v.Add("min_age_seconds", tc.minAge)
+			}
 			if len(v) > 0 {
 				endpoint += "?" + v.Encode()
 			}
@@ -215,6 +267,395 @@ func TestGetPendingTxs(t *testing.T) {
 	}
 }
 
+func TestGetPendingTxsStuck(t *testing.T) {
+	stuckTxn := createUnconfirmedTxn(t)
+	otherTxn := createUnconfirmedTxn(t)
+
+	t.Run("500 - GetStuckUnconfirmedTxnHashes error", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetStuckUnconfirmedTxnHashes").Return(nil, errors.New("GetStuckUnconfirmedTxnHashes failed"))
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/pendingTxs?stuck=1", nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler := newServerMux(defaultMuxConfig(), gateway)
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, "500 Internal Server Error - GetStuckUnconfirmedTxnHashes failed", strings.TrimSpace(rr.Body.String()))
+	})
+
+	t.Run("200", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetStuckUnconfirmedTxnHashes").Return([]cipher.SHA256{stuckTxn.Transaction.Hash()}, nil)
+		gateway.On("GetAllUnconfirmedTransactions").Return([]visor.UnconfirmedTransaction{otherTxn, stuckTxn}, nil)
+		gateway.On("GetUnconfirmedConflicts").Return(nil, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/pendingTxs?stuck=1", nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler := newServerMux(defaultMuxConfig(), gateway)
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var msg []readable.UnconfirmedTransactions
+		err = json.Unmarshal(rr.Body.Bytes(), &msg)
+		require.NoError(t, err)
+		require.Len(t, msg, 1)
+		require.Equal(t, stuckTxn.Transaction.Hash().Hex(), msg[0].Transaction.Hash)
+	})
+}
+
+func TestGetPendingTxsAgeAndAnnounce(t *testing.T) {
+	now := time.Now().UTC()
+
+	newTxn := func(received time.Time, announced int64, hours uint64) (visor.UnconfirmedTransaction, []visor.TransactionInput) {
+		txn := createUnconfirmedTxn(t)
+		txn.Received = received.UnixNano()
+		txn.Checked = txn.Received
+		txn.Announced = announced
+		txn.IsValid = 1
+
+		inputs := []visor.TransactionInput{
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: testutil.RandSHA256(t),
+						Address:        testutil.MakeAddress(),
+						Coins:          1e6,
+						Hours:          hours,
+					},
+				},
+				CalculatedHours: hours,
+			},
+		}
+		txn.Transaction.In = []cipher.SHA256{inputs[0].UxOut.Hash()}
+
+		return txn, inputs
+	}
+
+	// old was received an hour ago and announced twice, recent was received now and never announced
+	announced := now.Add(-time.Minute)
+	oldTxn, oldInputs := newTxn(now.Add(-time.Hour), announced.UnixNano(), 10)
+	recentTxn, recentInputs := newTxn(now, time.Time{}.UnixNano(), 30)
+	oldHash := oldTxn.Transaction.Hash()
+	recentHash := recentTxn.Transaction.Hash()
+
+	newGateway := func() *MockGatewayer {
+		gateway := &MockGatewayer{}
+		gateway.On("GetAllUnconfirmedTransactions").Return([]visor.UnconfirmedTransaction{recentTxn, oldTxn}, nil)
+		gateway.On("GetAllUnconfirmedTransactionsVerbose").Return([]visor.UnconfirmedTransaction{recentTxn, oldTxn},
+			[][]visor.TransactionInput{recentInputs, oldInputs}, nil)
+		gateway.On("GetUnconfirmedConflicts").Return(nil, nil)
+		gateway.On("GetUnconfirmedTxnAnnounceCounts").Return(map[cipher.SHA256]uint64{oldHash: 2}, nil)
+		return gateway
+	}
+
+	get := func(t *testing.T, gateway *MockGatewayer, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/pendingTxs?"+query, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("verbose fields", func(t *testing.T) {
+		rr := get(t, newGateway(), "verbose=1")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var msg []PendingTxnVerbose
+		err := json.Unmarshal(rr.Body.Bytes(), &msg)
+		require.NoError(t, err)
+		require.Len(t, msg, 2)
+
+		require.Equal(t, recentHash.Hex(), msg[0].Transaction.Hash)
+		require.True(t, msg[0].ReceivedAt.Equal(now))
+		require.Nil(t, msg[0].LastAnnouncedAt)
+		require.Equal(t, uint64(0), msg[0].AnnounceCount)
+		require.True(t, msg[0].IsValidAtLastCheck)
+		require.True(t, msg[0].CalculatedHoursEstimated)
+
+		require.Equal(t, oldHash.Hex(), msg[1].Transaction.Hash)
+		require.True(t, msg[1].ReceivedAt.Equal(now.Add(-time.Hour)))
+		require.NotNil(t, msg[1].LastAnnouncedAt)
+		require.True(t, msg[1].LastAnnouncedAt.Equal(announced))
+		require.Equal(t, uint64(2), msg[1].AnnounceCount)
+
+		// A transaction that was never announced has a null last_announced_at
+		var raw []map[string]interface{}
+		err = json.Unmarshal(rr.Body.Bytes(), &raw)
+		require.NoError(t, err)
+		require.Contains(t, raw[0], "last_announced_at")
+		require.Nil(t, raw[0]["last_announced_at"])
+	})
+
+	t.Run("500 - GetUnconfirmedTxnAnnounceCounts error", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetAllUnconfirmedTransactionsVerbose").Return([]visor.UnconfirmedTransaction{oldTxn},
+			[][]visor.TransactionInput{oldInputs}, nil)
+		gateway.On("GetUnconfirmedConflicts").Return(nil, nil)
+		gateway.On("GetUnconfirmedTxnAnnounceCounts").Return(nil, errors.New("GetUnconfirmedTxnAnnounceCounts failed"))
+
+		rr := get(t, gateway, "verbose=1")
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, "500 Internal Server Error - GetUnconfirmedTxnAnnounceCounts failed", strings.TrimSpace(rr.Body.String()))
+	})
+
+	hashes := func(t *testing.T, rr *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var msg []struct {
+			Transaction struct {
+				Hash string `json:"txid"`
+			} `json:"transaction"`
+		}
+		err := json.Unmarshal(rr.Body.Bytes(), &msg)
+		require.NoError(t, err)
+
+		hashes := make([]string, len(msg))
+		for i, txn := range msg {
+			hashes[i] = txn.Transaction.Hash
+		}
+		return hashes
+	}
+
+	tt := []struct {
+		query  string
+		hashes []string
+	}{
+		{"sort=age", []string{oldHash.Hex(), recentHash.Hex()}},
+		{"verbose=1&sort=age", []string{oldHash.Hex(), recentHash.Hex()}},
+		{"verbose=1&sort=fee", []string{recentHash.Hex(), oldHash.Hex()}},
+		{"min_age_seconds=60", []string{oldHash.Hex()}},
+		{"verbose=1&min_age_seconds=60", []string{oldHash.Hex()}},
+		{"verbose=1&min_age_seconds=7200", []string{}},
+		{"min_age_seconds=0", []string{recentHash.Hex(), oldHash.Hex()}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.query, func(t *testing.T) {
+			require.Equal(t, tc.hashes, hashes(t, get(t, newGateway(), tc.query)))
+		})
+	}
+}
+
+func TestAbandonPendingTxn(t *testing.T) {
+	txid := testutil.RandSHA256(t)
+
+	tt := []struct {
+		name       string
+		txid       string
+		abandonErr error
+		status     int
+		err        string
+	}{
+		{
+			name:   "400 - missing txid",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - txid is empty",
+		},
+		{
+			name:   "400 - invalid txid",
+			txid:   "foo",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid txid",
+		},
+		{
+			name:       "404 - not in pool",
+			txid:       txid.Hex(),
+			abandonErr: visor.ErrUnconfirmedTxnNotFound,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found - " + visor.ErrUnconfirmedTxnNotFound.Error(),
+		},
+		{
+			name:       "403 - peer txn",
+			txid:       txid.Hex(),
+			abandonErr: visor.ErrUnconfirmedTxnNotLocal,
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden - " + visor.ErrUnconfirmedTxnNotLocal.Error(),
+		},
+		{
+			name:       "500 - abandon error",
+			txid:       txid.Hex(),
+			abandonErr: errors.New("AbandonUnconfirmedTransaction failed"),
+			status:     http.StatusInternalServerError,
+			err:        "500 Internal Server Error - AbandonUnconfirmedTransaction failed",
+		},
+		{
+			name:   "200",
+			txid:   txid.Hex(),
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("AbandonUnconfirmedTransaction", txid).Return(tc.abandonErr)
+
+			endpoint := "/api/v1/pendingTxs"
+			if tc.txid != "" {
+				endpoint += "?txid=" + tc.txid
+			}
+
+			req, err := http.NewRequest(http.MethodDelete, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg AbandonPendingTxnResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.Equal(t, txid.Hex(), msg.TxID)
+			require.NotEmpty(t, msg.Message)
+		})
+	}
+}
+
+func TestGetPendingTxsConflicts(t *testing.T) {
+	newTxn := func(in cipher.SHA256, received time.Time, hours uint64) (visor.UnconfirmedTransaction, []visor.TransactionInput) {
+		txn := createUnconfirmedTxn(t)
+		txn.Transaction.In = []cipher.SHA256{in}
+		txn.Received = received.UnixNano()
+		txn.Checked = txn.Received
+
+		inputs := []visor.TransactionInput{
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: testutil.RandSHA256(t),
+						Address:        testutil.MakeAddress(),
+						Coins:          1e6,
+						Hours:          hours,
+					},
+				},
+				CalculatedHours: hours,
+			},
+		}
+
+		return txn, inputs
+	}
+
+	spent := testutil.RandSHA256(t)
+	now := time.Now().UTC()
+	txnA, inputsA := newTxn(spent, now.Add(-time.Minute), 10)
+	txnB, inputsB := newTxn(spent, now, 20)
+	txnC, inputsC := newTxn(testutil.RandSHA256(t), now, 30)
+	hashA := txnA.Transaction.Hash()
+	hashB := txnB.Transaction.Hash()
+
+	txns := []visor.UnconfirmedTransaction{txnB, txnC, txnA}
+	inputs := [][]visor.TransactionInput{inputsB, inputsC, inputsA}
+	conflicts := map[cipher.SHA256][]cipher.SHA256{
+		spent: []cipher.SHA256{hashB, hashA},
+	}
+
+	t.Run("conflicts", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetUnconfirmedConflicts").Return(conflicts, nil)
+		gateway.On("GetAllUnconfirmedTransactionsVerbose").Return(txns, inputs, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/pendingTxs/conflicts", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp UnconfirmedConflictsResponse
+		err = json.Unmarshal(rr.Body.Bytes(), &resp)
+		require.NoError(t, err)
+
+		require.Equal(t, UnconfirmedConflictsResponse{
+			Conflicts: []UnconfirmedTxnConflictGroup{
+				{
+					UxID: spent.Hex(),
+					Transactions: []UnconfirmedTxnConflict{
+						{
+							TxID:     hashA.Hex(),
+							Fee:      10,
+							Received: now.Add(-time.Minute),
+						},
+						{
+							TxID:     hashB.Hex(),
+							Fee:      20,
+							Received: now,
+						},
+					},
+				},
+			},
+		}, resp)
+	})
+
+	t.Run("no conflicts", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetUnconfirmedConflicts").Return(map[cipher.SHA256][]cipher.SHA256{}, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/pendingTxs/conflicts", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"conflicts":[]}`, rr.Body.String())
+	})
+
+	t.Run("500", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetUnconfirmedConflicts").Return(nil, errors.New("GetUnconfirmedConflicts failed"))
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/pendingTxs/conflicts", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, "500 Internal Server Error - GetUnconfirmedConflicts failed", strings.TrimSpace(rr.Body.String()))
+	})
+
+	t.Run("pendingTxs conflicts_with", func(t *testing.T) {
+		gateway := &MockGatewayer{}
+		gateway.On("GetUnconfirmedConflicts").Return(conflicts, nil)
+		gateway.On("GetUnconfirmedTxnAnnounceCounts").Return(nil, nil)
+		gateway.On("GetAllUnconfirmedTransactions").Return(txns, nil)
+		gateway.On("GetAllUnconfirmedTransactionsVerbose").Return(txns, inputs, nil)
+
+		for _, endpoint := range []string{"/api/v1/pendingTxs", "/api/v1/pendingTxs?verbose=1"} {
+			req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp []struct {
+				Transaction struct {
+					Hash string `json:"txid"`
+				} `json:"transaction"`
+				ConflictsWith []string `json:"conflicts_with"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Len(t, resp, 3)
+
+			require.Equal(t, hashB.Hex(), resp[0].Transaction.Hash)
+			require.Equal(t, []string{hashA.Hex()}, resp[0].ConflictsWith)
+			require.Empty(t, resp[1].ConflictsWith)
+			require.Equal(t, hashA.Hex(), resp[2].Transaction.Hash)
+			require.Equal(t, []string{hashB.Hex()}, resp[2].ConflictsWith)
+		}
+	})
+}
+
 func TestGetTransactionByID(t *testing.T) {
 	oddHash := "cafcb"
 	invalidHash := "cabrca"
@@ -486,37 +927,112 @@ func TestGetTransactionByID(t *testing.T) {
 					},
 				},
 			},
-			httpResponse: &readable.TransactionWithStatusVerbose{
-				Status: readable.TransactionStatus{
-					Confirmed: true,
-					BlockSeq:  100,
-					Height:    9,
+			httpResponse: &TransactionWithStatusVerbose{
+				TransactionWithStatusVerbose: readable.TransactionWithStatusVerbose{
+					Status: readable.TransactionStatus{
+						Confirmed: true,
+						BlockSeq:  100,
+						Height:    9,
+					},
+					Transaction: readable.TransactionVerbose{
+						BlockTransactionVerbose: readable.BlockTransactionVerbose{
+							Fee:       2222,
+							Hash:      "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
+							InnerHash: "0000000000000000000000000000000000000000000000000000000000000000",
+							Sigs:      []string{validSig},
+							In: []readable.TransactionInput{
+								{
+									Hash:            "50e8ad459e29a051d969f221f1fb9775e26248e8b443982fef0cfaa117ee6c0c",
+									Coins:           "0.009999",
+									Hours:           1111,
+									CalculatedHours: 3333,
+									Address:         validAddr,
+								},
+							},
+							Out: []readable.TransactionOutput{
+								{
+									Hash:    "87ec4d440fd64bb4c26839d58684e567e499265ca396649c03304b928378720b",
+									Coins:   "0.009999",
+									Hours:   1111,
+									Address: validAddr,
+								},
+							},
+						},
+					},
 				},
-				Transaction: readable.TransactionVerbose{
-					BlockTransactionVerbose: readable.BlockTransactionVerbose{
-						Fee:       2222,
-						Hash:      "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
-						InnerHash: "0000000000000000000000000000000000000000000000000000000000000000",
-						Sigs:      []string{validSig},
-						In: []readable.TransactionInput{
+			},
+		},
+
+		{
+			name:   "200 verbose unconfirmed",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			httpBody: &httpBody{
+				txid:    validHash,
+				verbose: "1",
+			},
+			verbose: true,
+			txid:    testutil.SHA256FromHex(t, validHash),
+			getTransactionResultVerboseReponse: verboseResult{
+				Transaction: &visor.Transaction{
+					Transaction: coin.Transaction{
+						Sigs: []cipher.Sig{validSigRaw},
+						In:   []cipher.SHA256{validHashRaw},
+						Out: []coin.TransactionOutput{
 							{
-								Hash:            "50e8ad459e29a051d969f221f1fb9775e26248e8b443982fef0cfaa117ee6c0c",
-								Coins:           "0.009999",
-								Hours:           1111,
-								CalculatedHours: 3333,
-								Address:         validAddr,
+								Coins:   9999,
+								Hours:   1111,
+								Address: validAddrRaw,
 							},
 						},
-						Out: []readable.TransactionOutput{
-							{
-								Hash:    "87ec4d440fd64bb4c26839d58684e567e499265ca396649c03304b928378720b",
-								Coins:   "0.009999",
+					},
+					Status: visor.TransactionStatus{},
+				},
+				Inputs: []visor.TransactionInput{
+					{
+						UxOut: coin.UxOut{
+							Body: coin.UxBody{
+								Coins:   9999,
 								Hours:   1111,
-								Address: validAddr,
+								Address: validAddrRaw,
+							},
+						},
+						CalculatedHours: 3333,
+					},
+				},
+			},
+			httpResponse: &TransactionWithStatusVerbose{
+				TransactionWithStatusVerbose: readable.TransactionWithStatusVerbose{
+					Status: readable.TransactionStatus{
+						Unconfirmed: true,
+					},
+					Transaction: readable.TransactionVerbose{
+						BlockTransactionVerbose: readable.BlockTransactionVerbose{
+							Fee:       2222,
+							Hash:      "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
+							InnerHash: "0000000000000000000000000000000000000000000000000000000000000000",
+							Sigs:      []string{validSig},
+							In: []readable.TransactionInput{
+								{
+									Hash:            "50e8ad459e29a051d969f221f1fb9775e26248e8b443982fef0cfaa117ee6c0c",
+									Coins:           "0.009999",
+									Hours:           1111,
+									CalculatedHours: 3333,
+									Address:         validAddr,
+								},
+							},
+							Out: []readable.TransactionOutput{
+								{
+									Hash:    "87ec4d440fd64bb4c26839d58684e567e499265ca396649c03304b928378720b",
+									Coins:   "0.009999",
+									Hours:   1111,
+									Address: validAddr,
+								},
 							},
 						},
 					},
 				},
+				CalculatedHoursEstimated: true,
 			},
 		},
 
@@ -603,7 +1119,7 @@ func TestGetTransactionByID(t *testing.T) {
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
 				if tc.verbose {
-					var msg readable.TransactionWithStatusVerbose
+					var msg TransactionWithStatusVerbose
 					err = json.Unmarshal(rr.Body.Bytes(), &msg)
 					require.NoError(t, err)
 					require.Equal(t, tc.httpResponse, &msg, tc.name)
@@ -790,7 +1306,7 @@ func TestInjectTransaction(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			endpoint := "/api/v1/injectTransaction"
 			gateway := &MockGatewayer{}
-			gateway.On("InjectBroadcastTransaction", tc.injectTransactionArg).Return(tc.injectTransactionError)
+			gateway.On("InjectBroadcastTransaction", mock.Anything, tc.injectTransactionArg).Return(tc.injectTransactionError)
 			gateway.On("InjectTransaction", tc.injectTransactionArg).Return(tc.injectTransactionError)
 
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
@@ -1199,7 +1715,7 @@ func TestGetTransactions(t *testing.T) {
 				Transactions: []visor.Transaction{},
 				Inputs:       [][]visor.TransactionInput{},
 			},
-			httpResponse: []readable.TransactionWithStatusVerbose{},
+			httpResponse: []TransactionWithStatusVerbose{},
 		},
 
 		{
@@ -1326,7 +1842,7 @@ func TestGetTransactions(t *testing.T) {
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
 				if tc.verbose {
-					var msg []readable.TransactionWithStatusVerbose
+					var msg []TransactionWithStatusVerbose
 					err = json.Unmarshal(rr.Body.Bytes(), &msg)
 					require.NoError(t, err)
 					require.Equal(t, tc.httpResponse, msg, tc.name)
@@ -1407,6 +1923,8 @@ func TestVerifyTransaction(t *testing.T) {
 	type httpBody struct {
 		Unsigned           bool   `json:"unsigned"`
 		EncodedTransaction string `json:"encoded_transaction"`
+		Encoding           string `json:"encoding,omitempty"`
+		Evaluate           bool   `json:"evaluate,omitempty"`
 	}
 
 	validTxnBody := &httpBody{
@@ -1415,6 +1933,31 @@ func TestVerifyTransaction(t *testing.T) {
 	validTxnBodyJSON, err := json.Marshal(validTxnBody)
 	require.NoError(t, err)
 
+	validTxnBase64BodyJSON, err := json.Marshal(&httpBody{
+		EncodedTransaction: base64.StdEncoding.EncodeToString(txnAndInputs.txn.MustSerialize()),
+		Encoding:           TxnEncodingBase64,
+	})
+	require.NoError(t, err)
+
+	invalidEncodingBodyJSON, err := json.Marshal(&httpBody{
+		EncodedTransaction: txnAndInputs.txn.MustSerializeHex(),
+		Encoding:           "base58",
+	})
+	require.NoError(t, err)
+
+	evaluateTxnBodyJSON, err := json.Marshal(&httpBody{
+		EncodedTransaction: txnAndInputs.txn.MustSerializeHex(),
+		Evaluate:           true,
+	})
+	require.NoError(t, err)
+
+	newEvaluatedTxnResponse := func(inputs, unspentInputs []visor.TransactionInput, isTxnConfirmed bool) VerifyTransactionResponse {
+		rsp := newVerifyTxnResponseJSON(t, &txnAndInputs.txn, inputs, isTxnConfirmed, false)
+		e := evaluateTxn(&txnAndInputs.txn, unspentInputs, params.UserVerifyTxn)
+		rsp.Evaluation = &e
+		return rsp
+	}
+
 	b := &httpBody{
 		EncodedTransaction: hex.EncodeToString(testutil.RandBytes(t, 128)),
 	}
@@ -1475,7 +2018,7 @@ func TestVerifyTransaction(t *testing.T) {
 			method:       http.MethodPost,
 			contentType:  ContentTypeJSON,
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
 		},
 		{
 			name:         "415 - Unsupported Media Type",
@@ -1485,11 +2028,19 @@ func TestVerifyTransaction(t *testing.T) {
 			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
 		},
 		{
-			name:         "400 - encoded_transaction is required",
+			name:         "400 - unknown field",
 			method:       http.MethodPost,
 			contentType:  ContentTypeJSON,
 			status:       http.StatusBadRequest,
 			httpBody:     `{"wrongKey":"wrongValue"}`,
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: `unknown field "wrongKey"`, Field: "wrongKey"}),
+		},
+		{
+			name:         "400 - encoded_transaction is required",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			httpBody:     `{}`,
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "encoded_transaction is required"),
 		},
 		{
@@ -1508,6 +2059,22 @@ func TestVerifyTransaction(t *testing.T) {
 			httpBody:     string(invalidTxnBodyJSON),
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "decode transaction failed: Invalid transaction: Not enough buffer data to deserialize"),
 		},
+		{
+			name:         "400 - invalid encoding",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			httpBody:     string(invalidEncodingBodyJSON),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `decode transaction failed: invalid encoding "base58", valid encodings are "hex" or "base64"`),
+		},
+		{
+			name:         "400 - base64 decode error",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			httpBody:     `{"encoded_transaction":"aab","encoding":"base64"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "decode transaction failed: illegal base64 data at input byte 0"),
+		},
 		{
 			name:                          "422 - txn sends to empty address",
 			method:                        http.MethodPost,
@@ -1611,6 +2178,68 @@ func TestVerifyTransaction(t *testing.T) {
 				Data: newVerifyTxnResponseJSON(t, &txnAndInputs.txn, txnAndInputs.inputs, false, false),
 			},
 		},
+		{
+			name:                          "200 - base64",
+			method:                        http.MethodPost,
+			contentType:                   ContentTypeJSON,
+			status:                        http.StatusOK,
+			httpBody:                      string(validTxnBase64BodyJSON),
+			gatewayVerifyTxnVerboseArg:    txnAndInputs.txn,
+			gatewayVerifyTxnVerboseSigned: visor.TxnSigned,
+			gatewayVerifyTxnVerboseResult: verifyTxnVerboseResult{
+				Uxouts: txnAndInputs.inputs,
+			},
+			httpResponse: HTTPResponse{
+				Data: newVerifyTxnResponseJSON(t, &txnAndInputs.txn, txnAndInputs.inputs, false, false),
+			},
+		},
+		{
+			name:                          "200 - evaluate",
+			method:                        http.MethodPost,
+			contentType:                   ContentTypeJSON,
+			status:                        http.StatusOK,
+			httpBody:                      string(evaluateTxnBodyJSON),
+			gatewayVerifyTxnVerboseArg:    txnAndInputs.txn,
+			gatewayVerifyTxnVerboseSigned: visor.TxnSigned,
+			gatewayVerifyTxnVerboseResult: verifyTxnVerboseResult{
+				Uxouts: txnAndInputs.inputs,
+			},
+			httpResponse: HTTPResponse{
+				Data: newEvaluatedTxnResponse(txnAndInputs.inputs, txnAndInputs.inputs, false),
+			},
+		},
+		{
+			name:                          "200 - evaluate inputs unknown",
+			method:                        http.MethodPost,
+			contentType:                   ContentTypeJSON,
+			status:                        http.StatusOK,
+			httpBody:                      string(evaluateTxnBodyJSON),
+			gatewayVerifyTxnVerboseArg:    txnAndInputs.txn,
+			gatewayVerifyTxnVerboseSigned: visor.TxnSigned,
+			httpResponse: HTTPResponse{
+				Data: newEvaluatedTxnResponse(nil, nil, false),
+			},
+		},
+		{
+			name:                          "422 - evaluate txn is confirmed",
+			method:                        http.MethodPost,
+			contentType:                   ContentTypeJSON,
+			status:                        http.StatusUnprocessableEntity,
+			httpBody:                      string(evaluateTxnBodyJSON),
+			gatewayVerifyTxnVerboseArg:    txnAndInputs.txn,
+			gatewayVerifyTxnVerboseSigned: visor.TxnSigned,
+			gatewayVerifyTxnVerboseResult: verifyTxnVerboseResult{
+				Uxouts:         txnAndInputs.inputs,
+				IsTxnConfirmed: true,
+			},
+			httpResponse: HTTPResponse{
+				Error: &HTTPError{
+					Message: "transaction has been spent",
+					Code:    http.StatusUnprocessableEntity,
+				},
+				Data: newEvaluatedTxnResponse(txnAndInputs.inputs, nil, true),
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -1619,6 +2248,9 @@ func TestVerifyTransaction(t *testing.T) {
 			gateway := &MockGatewayer{}
 			gateway.On("VerifyTxnVerbose", &tc.gatewayVerifyTxnVerboseArg, tc.gatewayVerifyTxnVerboseSigned).Return(tc.gatewayVerifyTxnVerboseResult.Uxouts,
 				tc.gatewayVerifyTxnVerboseResult.IsTxnConfirmed, tc.gatewayVerifyTxnVerboseResult.Err)
+			gateway.On("VisorConfig").Return(visor.Config{
+				UnconfirmedVerifyTxn: params.UserVerifyTxn,
+			})
 
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
 			require.NoError(t, err)
@@ -1661,3 +2293,322 @@ func TestVerifyTransaction(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTransactionsInBlockRange(t *testing.T) {
+	addrsStr := "2konv5no3DZvSMxf2GPVtAfZinfwqCGhfVQ,2PBmUva7J8WFsyWg979cREZkU3z2pkYjNkE"
+	var addrs []cipher.Address
+	for _, item := range strings.Split(addrsStr, ",") {
+		addr, err := cipher.DecodeBase58Address(item)
+		require.NoError(t, err)
+		addrs = append(addrs, addr)
+	}
+
+	// The transactions are in the order of the blocks, which the response keeps even if their times are not ordered
+	var txns []visor.Transaction
+	var inputs [][]visor.TransactionInput
+	for i, seq := range []uint64{5, 7} {
+		ux, s := makeUxOutWithSecret(t)
+		txn := coin.Transaction{}
+		err := txn.PushInput(ux.Hash())
+		require.NoError(t, err)
+		err = txn.PushOutput(makeAddress(), 1e6, 50)
+		require.NoError(t, err)
+		txn.SignInputs([]cipher.SecKey{s})
+		err = txn.UpdateHeader()
+		require.NoError(t, err)
+
+		txns = append(txns, visor.Transaction{
+			Transaction: txn,
+			Status:      visor.NewConfirmedTransactionStatus(8-seq, seq),
+			Time:        200 - uint64(i)*100,
+		})
+		inputs = append(inputs, []visor.TransactionInput{{
+			UxOut:           ux,
+			CalculatedHours: 100,
+		}})
+	}
+
+	rTxns := make([]readable.TransactionWithStatus, len(txns))
+	rTxnsVerbose := make([]TransactionWithStatusVerbose, len(txns))
+	for i, txn := range newVisorTransactions(txns) {
+		rTxn, err := readable.NewTransactionWithStatus(&txn)
+		require.NoError(t, err)
+		rTxns[i] = *rTxn
+
+		rTxnVerbose, err := NewTransactionWithStatusVerbose(&txn, []visor.TransactionInput{visor.TransactionInput(inputs[i][0])})
+		require.NoError(t, err)
+		rTxnsVerbose[i] = *rTxnVerbose
+	}
+
+	cases := []struct {
+		name         string
+		method       string
+		args         url.Values
+		status       int
+		err          string
+		verbose      bool
+		start, end   uint64
+		gatewayErr   error
+		httpResponse interface{}
+	}{
+		{
+			name:   "400 - no addrs",
+			method: http.MethodGet,
+			args:   url.Values{"start_block": {"1"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - start_block and end_block require addrs",
+		},
+		{
+			name:   "400 - unconfirmed",
+			method: http.MethodGet,
+			args:   url.Values{"addrs": {addrsStr}, "end_block": {"1"}, "confirmed": {"false"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - confirmed=false cannot be used with start_block or end_block, a block range only has confirmed transactions",
+		},
+		{
+			name:   "400 - invalid start_block",
+			method: http.MethodGet,
+			args:   url.Values{"addrs": {addrsStr}, "start_block": {"-1"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid start_block value \"-1\"",
+		},
+		{
+			name:   "400 - invalid end_block",
+			method: http.MethodGet,
+			args:   url.Values{"addrs": {addrsStr}, "end_block": {"x"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid end_block value \"x\"",
+		},
+		{
+			name:   "400 - start_block after end_block",
+			method: http.MethodGet,
+			args:   url.Values{"addrs": {addrsStr}, "start_block": {"8"}, "end_block": {"7"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - start_block must not be greater than end_block",
+		},
+		{
+			name:       "500 - gateway error",
+			method:     http.MethodGet,
+			args:       url.Values{"addrs": {addrsStr}, "start_block": {"5"}, "end_block": {"7"}},
+			status:     http.StatusInternalServerError,
+			err:        "500 Internal Server Error - gateway error",
+			start:      5,
+			end:        7,
+			gatewayErr: errors.New("gateway error"),
+		},
+		{
+			name:         "200",
+			method:       http.MethodGet,
+			args:         url.Values{"addrs": {addrsStr}, "start_block": {"5"}, "end_block": {"7"}, "confirmed": {"true"}},
+			status:       http.StatusOK,
+			start:        5,
+			end:          7,
+			httpResponse: rTxns,
+		},
+		{
+			name:         "200 without end_block",
+			method:       http.MethodPost,
+			args:         url.Values{"addrs": {addrsStr}, "start_block": {"5"}},
+			status:       http.StatusOK,
+			start:        5,
+			end:          math.MaxUint64,
+			httpResponse: rTxns,
+		},
+		{
+			name:         "200 verbose without start_block",
+			method:       http.MethodGet,
+			args:         url.Values{"addrs": {addrsStr}, "end_block": {"7"}, "verbose": {"1"}},
+			status:       http.StatusOK,
+			verbose:      true,
+			start:        0,
+			end:          7,
+			httpResponse: rTxnsVerbose,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.verbose {
+				gateway.On("GetAddressTransactionsInBlockRangeWithInputs", addrs, tc.start, tc.end).Return(txns, inputs, tc.gatewayErr)
+			} else {
+				gateway.On("GetAddressTransactionsInBlockRange", addrs, tc.start, tc.end).Return(txns, tc.gatewayErr)
+			}
+
+			endpoint := "/api/v1/transactions"
+			var reqBody io.Reader
+			if tc.method == http.MethodPost {
+				reqBody = strings.NewReader(tc.args.Encode())
+			} else {
+				endpoint += "?" + tc.args.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, reqBody)
+			require.NoError(t, err)
+			if tc.method == http.MethodPost {
+				req.Header.Set("Content-Type", ContentTypeForm)
+			}
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			if tc.verbose {
+				var msg []TransactionWithStatusVerbose
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse, msg)
+			} else {
+				var msg []readable.TransactionWithStatus
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse, msg)
+			}
+		})
+	}
+}
+
+func TestTestAcceptTransactions(t *testing.T) {
+	txn1 := prepareTxnAndInputs(t).txn
+	txn2 := makeTransactionWithEmptyAddressOutput(t).txn
+
+	validBody, err := json.Marshal(TestAcceptTransactionsRequest{
+		RawTxns: []string{txn1.MustSerializeHex(), txn2.MustSerializeHex()},
+	})
+	require.NoError(t, err)
+
+	invalidBody, err := json.Marshal(TestAcceptTransactionsRequest{
+		RawTxns: []string{txn1.MustSerializeHex(), "abcd"},
+	})
+	require.NoError(t, err)
+
+	results := []visor.TxnAcceptResult{
+		{
+			Txid:     txn1.Hash(),
+			Accepted: true,
+			Fee:      150,
+			Size:     220,
+		},
+		{
+			Txid:         txn2.Hash(),
+			RejectCode:   visor.RejectCodeUserConstraint,
+			RejectReason: "Transaction violates user constraint: Transaction output is sent to the null address",
+			Size:         183,
+		},
+	}
+
+	tt := []struct {
+		name         string
+		method       string
+		status       int
+		httpBody     string
+		gatewayArg   []coin.Transaction
+		gatewayRet   []visor.TxnAcceptResult
+		gatewayErr   error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
+		},
+		{
+			name:         "400 - missing rawtxs",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     `{"rawtxs":[]}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "rawtxs is required"),
+		},
+		{
+			name:         "400 - invalid rawtx",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     string(invalidBody),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "decode rawtxs[1] failed: Invalid transaction: Not enough buffer data to deserialize"),
+		},
+		{
+			name:         "500 - gateway error",
+			method:       http.MethodPost,
+			status:       http.StatusInternalServerError,
+			httpBody:     string(validBody),
+			gatewayArg:   []coin.Transaction{txn1, txn2},
+			gatewayErr:   errors.New("TestAcceptTransactions failed"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "TestAcceptTransactions failed"),
+		},
+		{
+			name:       "200",
+			method:     http.MethodPost,
+			status:     http.StatusOK,
+			httpBody:   string(validBody),
+			gatewayArg: []coin.Transaction{txn1, txn2},
+			gatewayRet: results,
+			httpResponse: HTTPResponse{
+				Data: []TestAcceptResult{
+					{
+						Txid:     txn1.Hash().Hex(),
+						Accepted: true,
+						FeeHours: 150,
+						Size:     220,
+					},
+					{
+						Txid:         txn2.Hash().Hex(),
+						RejectCode:   "user-constraint",
+						RejectReason: "Transaction violates user constraint: Transaction output is sent to the null address",
+						Size:         183,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/transaction/test-accept"
+			gateway := &MockGatewayer{}
+			gateway.On("TestAcceptTransactions", tc.gatewayArg).Return(tc.gatewayRet, tc.gatewayErr)
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var results []TestAcceptResult
+				err := json.Unmarshal(rsp.Data, &results)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.([]TestAcceptResult), results)
+			}
+		})
+	}
+}
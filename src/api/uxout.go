@@ -4,14 +4,17 @@ import (
 	"net/http"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
 )
 
 // URI: /api/v1/uxout
 // Method: GET
 // Args:
+//
 //	uxid: unspent output ID hash
+//
 // Returns an unspent output by ID
 func uxOutHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -50,7 +53,9 @@ func uxOutHandler(gateway Gatewayer) http.HandlerFunc {
 // URI: /api/v1/address_uxouts
 // Method: GET
 // Args:
+//
 //	address
+//
 // Returns the historical, spent outputs associated with an address
 func addrUxOutsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
+)
+
+// VerificationParamsResponse is returned by the /verification-params endpoint
+type VerificationParamsResponse struct {
+	// MaxBlockSize is the max size of the transactions of a block, in bytes
+	MaxBlockSize uint32 `json:"max_block_size"`
+	// UserVerifyTxn are the constraints of the transactions created by the node's wallets
+	UserVerifyTxn readable.VerifyTxn `json:"user_verify_transaction"`
+	// UnconfirmedVerifyTxn are the constraints of the transactions accepted into the unconfirmed pool
+	UnconfirmedVerifyTxn readable.VerifyTxn `json:"unconfirmed_verify_transaction"`
+	// BurnFactorSchedule are the changes of the user burn factor at scheduled block times.
+	// The unconfirmed burn factor keeps its ratio to the user burn factor.
+	BurnFactorSchedule []readable.BurnFactorChange `json:"burn_factor_schedule,omitempty"`
+	// CoinTicker is the ticker of the coin
+	CoinTicker string `json:"coin_ticker"`
+	// CoinHoursTicker is the ticker of the coin hours
+	CoinHoursTicker string `json:"coin_hours_ticker"`
+	// DropletExponent is the number of decimal places of a coin, one droplet is 10^-DropletExponent coins
+	DropletExponent uint8 `json:"droplet_exponent"`
+	// MaxRequestBodySizes are the maximum request body sizes of the API endpoints, in bytes
+	MaxRequestBodySizes BodyLimits `json:"max_request_body_sizes"`
+}
+
+// verificationParamsHandler returns the node's transaction verification parameters and limits,
+// for wallets that create transactions without the node
+// URI: /api/v1/verification-params
+// Method: GET
+func verificationParamsHandler(c muxConfig, gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		vc := gateway.VisorConfig()
+
+		wh.SendJSONOr500(logger, w, VerificationParamsResponse{
+			MaxBlockSize:         vc.MaxBlockTransactionsSize,
+			UserVerifyTxn:        readable.NewVerifyTxn(params.UserVerifyTxn),
+			UnconfirmedVerifyTxn: readable.NewVerifyTxn(gateway.DaemonConfig().UnconfirmedVerifyTxn),
+			BurnFactorSchedule:   readable.NewBurnFactorSchedule(vc.BurnFactorSchedule),
+			CoinTicker:           c.health.Fiber.Ticker,
+			CoinHoursTicker:      c.health.Fiber.CoinHoursTicker,
+			DropletExponent:      droplet.Exponent,
+			MaxRequestBodySizes:  c.maxBodySizes,
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/params"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/util/fee"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestVerificationParamsHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		code   int
+		err    string
+	}{
+		{
+			name:   "405 method not allowed",
+			method: http.MethodPost,
+			code:   http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := defaultMuxConfig()
+			cfg.health.Fiber = readable.FiberConfig{
+				Name:            "privateness",
+				Ticker:          "NCH",
+				CoinHoursTicker: "NCH-H",
+			}
+
+			gateway := &MockGatewayer{}
+			gateway.On("VisorConfig").Return(visor.Config{
+				MaxBlockTransactionsSize: 65536,
+				BurnFactorSchedule: []fee.BurnFactorChange{
+					{Time: 1800000000, BurnFactor: 4},
+				},
+			})
+			gateway.On("DaemonConfig").Return(daemon.DaemonConfig{
+				UnconfirmedVerifyTxn: params.VerifyTxn{
+					BurnFactor:          4,
+					MaxTransactionSize:  65536,
+					MaxDropletPrecision: 2,
+				},
+			})
+
+			req, err := http.NewRequest(tc.method, "/api/v1/verification-params", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+			if tc.code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var r VerificationParamsResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &r)
+			require.NoError(t, err)
+
+			require.Equal(t, VerificationParamsResponse{
+				MaxBlockSize:  65536,
+				UserVerifyTxn: readable.NewVerifyTxn(params.UserVerifyTxn),
+				UnconfirmedVerifyTxn: readable.VerifyTxn{
+					BurnFactor:          4,
+					MaxTransactionSize:  65536,
+					MaxDropletPrecision: 2,
+				},
+				BurnFactorSchedule: []readable.BurnFactorChange{
+					{Time: 1800000000, BurnFactor: 4},
+				},
+				CoinTicker:      "NCH",
+				CoinHoursTicker: "NCH-H",
+				DropletExponent: 6,
+				MaxRequestBodySizes: BodyLimits{
+					Wallet:      32 * 1024,
+					Transaction: 2*int64(params.UserVerifyTxn.MaxTransactionSize) + 16*1024,
+					Storage:     1024 * 1024,
+					Default:     2 * 1024 * 1024,
+				},
+			}, r)
+		})
+	}
+}
@@ -3,8 +3,9 @@ package api
 import (
 	"net/http"
 
-	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
 )
 
 // versionHandler returns the application version info
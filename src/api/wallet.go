@@ -9,21 +9,45 @@ import (
 	"sort"
 	"strconv"
 
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip32"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
-	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/cipher/bip85"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 // UnconfirmedTxnsResponse contains unconfirmed transaction data
 type UnconfirmedTxnsResponse struct {
-	Transactions []readable.UnconfirmedTransactions `json:"transactions"`
+	Transactions []WalletUnconfirmedTransaction `json:"transactions"`
 }
 
 // UnconfirmedTxnsVerboseResponse contains verbose unconfirmed transaction data
 type UnconfirmedTxnsVerboseResponse struct {
-	Transactions []readable.UnconfirmedTransactionVerbose `json:"transactions"`
+	Transactions []WalletUnconfirmedTransactionVerbose `json:"transactions"`
+}
+
+// WalletUnconfirmedTransaction is an unconfirmed transaction of a wallet, with the wallet's note,
+// its fee and the ownership of its outputs by the wallet
+type WalletUnconfirmedTransaction struct {
+	readable.UnconfirmedTransactions
+	Transaction WalletReadableTransaction `json:"transaction"`
+	FeeHours    uint64                    `json:"fee_hours"`
+	Note        string                    `json:"note,omitempty"`
+}
+
+// WalletUnconfirmedTransactionVerbose is a verbose unconfirmed transaction of a wallet, with the wallet's note,
+// its fee and the ownership of its inputs and outputs by the wallet
+type WalletUnconfirmedTransactionVerbose struct {
+	readable.UnconfirmedTransactionVerbose
+	Transaction WalletBlockTransactionVerbose `json:"transaction"`
+	FeeHours    uint64                        `json:"fee_hours"`
+	Note        string                        `json:"note,omitempty"`
+	// CalculatedHoursEstimated is always true, the inputs' calculated hours and the fee are computed at the head block time
+	CalculatedHoursEstimated bool `json:"calculated_hours_estimated,omitempty"`
 }
 
 // BalanceResponse address balance summary struct
@@ -32,12 +56,50 @@ type BalanceResponse struct {
 	Addresses readable.AddressBalances `json:"addresses"`
 }
 
+// WalletBalanceResponse is returned by GET /api/v1/wallet/balance
+type WalletBalanceResponse struct {
+	BalanceResponse
+	// HeadSeq is the seq of the head block the balance was computed at
+	HeadSeq uint64 `json:"head_seq"`
+	// Stale is true if a block was executed after HeadSeq while the balance was computed
+	Stale bool `json:"stale"`
+}
+
 // WalletResponse wallet response struct for http apis
 type WalletResponse struct {
-	Meta    readable.WalletMeta    `json:"meta"`
-	Entries []readable.WalletEntry `json:"entries"`
+	Meta    WalletMeta    `json:"meta"`
+	Entries []WalletEntry `json:"entries"`
+}
+
+// WalletEntry is a wallet entry included in a WalletResponse
+type WalletEntry struct {
+	readable.WalletEntry
+	// Path is the bip44 derivation path of the address, m/44'/coin'/account'/change/index,
+	// or its generation index for deterministic wallets
+	Path string `json:"path,omitempty"`
+}
+
+// WalletMeta is the wallet metadata included in a WalletResponse
+type WalletMeta struct {
+	readable.WalletMeta
+	ReuseChange bool `json:"reuse_change,omitempty"`
+	// StrictPrivacy is true if transactions of the wallet that reuse addresses are refused
+	StrictPrivacy bool `json:"strict_privacy,omitempty"`
+	// AccessToken is true if the wallet endpoints require the wallet's access token
+	AccessToken bool `json:"access_token,omitempty"`
+	// Unloaded is true if the wallet is not loaded in memory, in which case its entries are not included
+	Unloaded bool `json:"unloaded,omitempty"`
 }
 
+const (
+	// walletMetaReuseChange is the wallet meta field of the bip44 wallet ReuseChange option, see wallet.Meta.ReuseChange
+	walletMetaReuseChange = "reuseChange"
+	// walletMetaStrictPrivacy is the wallet meta field of the StrictPrivacy option, see wallet.Meta.StrictPrivacy
+	walletMetaStrictPrivacy = "strictPrivacy"
+	// walletMetaAccessToken is the wallet meta field of the access token hash, see wallet.Meta.AccessTokenHash
+	walletMetaAccessToken = "accessToken"
+)
+
 // NewWalletResponse creates WalletResponse struct from wallet.Wallet
 func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 	var wr WalletResponse
@@ -50,22 +112,27 @@ func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 	wr.Meta.CryptoType = w.CryptoType()
 	wr.Meta.Encrypted = w.IsEncrypted()
 	wr.Meta.Timestamp = w.Timestamp()
+	wr.Meta.AccessToken = w.Find(walletMetaAccessToken) != ""
+	wr.Meta.StrictPrivacy, _ = strconv.ParseBool(w.Find(walletMetaStrictPrivacy)) //nolint:errcheck
 
 	switch w.Type() {
 	case wallet.WalletTypeBip44:
 		bip44Coin := w.Bip44Coin()
 		wr.Meta.Bip44Coin = &bip44Coin
+		wr.Meta.ReuseChange, _ = strconv.ParseBool(w.Find(walletMetaReuseChange)) //nolint:errcheck
 	case wallet.WalletTypeXPub:
 		wr.Meta.XPub = w.XPub()
 	}
 
 	entries := w.GetEntries()
-	wr.Entries = make([]readable.WalletEntry, len(entries))
+	wr.Entries = make([]WalletEntry, len(entries))
 
 	for i, e := range entries {
-		wr.Entries[i] = readable.WalletEntry{
-			Address: e.Address.String(),
-			Public:  e.Public.Hex(),
+		wr.Entries[i] = WalletEntry{
+			WalletEntry: readable.WalletEntry{
+				Address: e.Address.String(),
+				Public:  e.Public.Hex(),
+			},
 		}
 
 		switch w.Type() {
@@ -78,21 +145,79 @@ func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 			wr.Entries[i].ChildNumber = &childNumber
 			change := e.Change
 			wr.Entries[i].Change = &change
+			wr.Entries[i].Path = wallet.Bip44Path(w.Bip44Coin(), wallet.Bip44Account, e.Change, e.ChildNumber)
 		case wallet.WalletTypeXPub:
 			childNumber := e.ChildNumber
 			wr.Entries[i].ChildNumber = &childNumber
+		case wallet.WalletTypeDeterministic:
+			wr.Entries[i].Path = strconv.Itoa(i)
 		}
 	}
 
 	return &wr, nil
 }
 
+// NewWalletHeaderResponse creates a WalletResponse from a wallet header, with the wallet's entries if it is loaded
+func NewWalletHeaderResponse(h wallet.WalletHeader) *WalletResponse {
+	var wr WalletResponse
+
+	m := h.Meta
+	wr.Meta.Coin = wallet.CoinType(m.Coin())
+	wr.Meta.Filename = m.Filename()
+	wr.Meta.Label = m.Label()
+	wr.Meta.Type = m.Type()
+	wr.Meta.Version = m.Version()
+	wr.Meta.CryptoType = wallet.CryptoType(m.CryptoType())
+	wr.Meta.Encrypted = m.IsEncrypted()
+	wr.Meta.Timestamp = m.Timestamp()
+	wr.Meta.AccessToken = m.AccessTokenHash() != ""
+	wr.Meta.StrictPrivacy = m.StrictPrivacy()
+	wr.Meta.Unloaded = !h.Loaded
+
+	switch m.Type() {
+	case wallet.WalletTypeBip44:
+		bip44Coin := m.Bip44Coin()
+		wr.Meta.Bip44Coin = &bip44Coin
+		wr.Meta.ReuseChange = m.ReuseChange()
+	case wallet.WalletTypeXPub:
+		wr.Meta.XPub = m.XPub()
+	}
+
+	paths := h.EntryPaths()
+	wr.Entries = make([]WalletEntry, len(h.Entries))
+	for i, e := range h.Entries {
+		wr.Entries[i] = WalletEntry{
+			WalletEntry: readable.WalletEntry{
+				Address: e.Address.String(),
+				Public:  e.Public.Hex(),
+			},
+			Path: paths[i],
+		}
+
+		switch m.Type() {
+		case wallet.WalletTypeBip44:
+			childNumber := e.ChildNumber
+			wr.Entries[i].ChildNumber = &childNumber
+			change := e.Change
+			wr.Entries[i].Change = &change
+		case wallet.WalletTypeXPub:
+			childNumber := e.ChildNumber
+			wr.Entries[i].ChildNumber = &childNumber
+		}
+	}
+
+	return &wr
+}
+
 // Returns the wallet's balance, both confirmed and predicted.  The predicted
 // balance is the confirmed balance minus the pending spends.
+// The balance is computed at the head block head_seq, stale is true if a block was
+// executed while it was computed.
 // URI: /api/v1/wallet/balance
 // Method: GET
 // Args:
-//     id: wallet id [required]
+//
+//	id: wallet id [required]
 func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -105,9 +230,9 @@ func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		walletBalance, addressBalances, err := gateway.GetWalletBalance(wltID)
+		b, err := gateway.GetWalletBalanceAtHead(wltID)
 		if err != nil {
-			logger.Errorf("Get wallet balance failed: %v", err)
+			requestLogger(r).Errorf("Get wallet balance failed: %v", err)
 			switch err {
 			case wallet.ErrWalletNotExist:
 				wh.Error404(w, "")
@@ -119,31 +244,95 @@ func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wh.SendJSONOr500(logger, w, BalanceResponse{
-			BalancePair: readable.NewBalancePair(walletBalance),
-			Addresses:   readable.NewAddressBalances(addressBalances),
+		wh.SendJSONOr500(logger, w, WalletBalanceResponse{
+			BalanceResponse: BalanceResponse{
+				BalancePair: readable.NewBalancePair(b.Balance),
+				Addresses:   readable.NewAddressBalances(b.Addresses),
+			},
+			HeadSeq: b.HeadSeq,
+			Stale:   b.Stale,
 		})
 	}
 }
 
+// BalanceRequest is the JSON body of POST /api/v1/balance
+type BalanceRequest struct {
+	Addresses []string `json:"addrs"`
+}
+
+// AddressBalance is the balance of an address
+type AddressBalance struct {
+	Address string `json:"address"`
+	readable.BalancePair
+}
+
+// BalanceBatchResponse is returned by POST /api/v1/balance when the request has a JSON body.
+// Balances has an entry for each requested address, in the order of the request.
+type BalanceBatchResponse struct {
+	BalanceResponse
+	Balances []AddressBalance `json:"balances"`
+}
+
 // Returns the balance of one or more addresses, both confirmed and predicted.  The predicted
 // balance is the confirmed balance minus the pending spends.
-// URI: /api/v1s/balance
+// The balances are read balanceChunkSize addresses at a time, so that large requests
+// don't hold the unspent pool for the whole request.
+// URI: /api/v1/balance
 // Method: GET, POST
 // Args:
-//     addrs: command separated list of addresses [required]
-func balanceHandler(gateway Gatewayer) http.HandlerFunc {
+//
+//	addrs: command separated list of addresses [required]
+//
+// A POST request with Content-Type: application/json takes a BalanceRequest body instead,
+// and returns a BalanceBatchResponse.
+// Requests with more than maxAddrs addresses are rejected with 413.
+func balanceHandler(gateway Gatewayer, maxAddrs int) http.HandlerFunc {
+	if maxAddrs == 0 {
+		maxAddrs = defaultMaxBalanceAddresses
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodPost {
 			wh.Error405(w)
 			return
 		}
 
-		addrsParam := r.FormValue("addrs")
-		addrs, err := parseAddressesFromStr(addrsParam)
-		if err != nil {
-			wh.Error400(w, err.Error())
-			return
+		batch := r.Method == http.MethodPost && isContentTypeJSON(r.Header.Get("Content-Type"))
+
+		var addrs []cipher.Address
+		if batch {
+			var req BalanceRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				wh.Error400(w, err.Error())
+				return
+			}
+
+			if len(req.Addresses) > maxAddrs {
+				writeTooManyBalanceAddresses(w, maxAddrs)
+				return
+			}
+
+			addrs = make([]cipher.Address, len(req.Addresses))
+			for i, s := range req.Addresses {
+				a, err := cipher.DecodeBase58Address(s)
+				if err != nil {
+					wh.Error400(w, fmt.Sprintf("address %q is invalid: %v", s, err))
+					return
+				}
+				addrs[i] = a
+			}
+		} else {
+			var err error
+			addrs, err = parseAddressesFromStr(r.FormValue("addrs"))
+			if err != nil {
+				wh.Error400(w, err.Error())
+				return
+			}
+
+			if len(addrs) > maxAddrs {
+				writeTooManyBalanceAddresses(w, maxAddrs)
+				return
+			}
 		}
 
 		if len(addrs) == 0 {
@@ -151,7 +340,7 @@ func balanceHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		bals, err := gateway.GetBalanceOfAddresses(addrs)
+		bals, err := getBalanceOfAddressesChunked(gateway, addrs)
 		if err != nil {
 			err = fmt.Errorf("gateway.GetBalanceOfAddresses failed: %v", err)
 			wh.Error500(w, err.Error())
@@ -180,27 +369,73 @@ func balanceHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}
 
-		wh.SendJSONOr500(logger, w, BalanceResponse{
+		resp := BalanceResponse{
 			BalancePair: readable.NewBalancePair(balance),
 			Addresses:   addressBalances,
+		}
+
+		if !batch {
+			wh.SendJSONOr500(logger, w, resp)
+			return
+		}
+
+		balances := make([]AddressBalance, len(addrs))
+		for i, addr := range addrs {
+			balances[i] = AddressBalance{
+				Address:     addr.String(),
+				BalancePair: readable.NewBalancePair(bals[i]),
+			}
+		}
+
+		wh.SendJSONOr500(logger, w, BalanceBatchResponse{
+			BalanceResponse: resp,
+			Balances:        balances,
 		})
 	}
 }
 
+func writeTooManyBalanceAddresses(w http.ResponseWriter, maxAddrs int) {
+	wh.ErrorXXX(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("too many addresses, at most %d addresses are allowed per request", maxAddrs))
+}
+
+// getBalanceOfAddressesChunked returns the balances of addrs, in the same order,
+// reading at most balanceChunkSize addresses per gateway call
+func getBalanceOfAddressesChunked(gateway Gatewayer, addrs []cipher.Address) ([]wallet.BalancePair, error) {
+	bals := make([]wallet.BalancePair, 0, len(addrs))
+	for i := 0; i < len(addrs); i += balanceChunkSize {
+		j := i + balanceChunkSize
+		if j > len(addrs) {
+			j = len(addrs)
+		}
+
+		chunk, err := gateway.GetBalanceOfAddresses(addrs[i:j])
+		if err != nil {
+			return nil, err
+		}
+
+		bals = append(bals, chunk...)
+	}
+
+	return bals, nil
+}
+
 // Loads wallet from seed, will scan ahead N address and
 // load addresses till the last one that have coins.
 // URI: /api/v1/wallet/create
 // Method: POST
 // Args:
-//     seed: wallet seed [required]
-//     seed-passphrase: wallet seed passphrase [optional, bip44 type wallet only]
-//     type: wallet type [required, one of "deterministic", "bip44" or "xpub"]
-//     bip44-coin: BIP44 coin type [optional, defaults to 8000 (skycoin's coin type), only valid if type is "bip44"]
-//     xpub: xpub key [required for xpub wallets]
-//     label: wallet label [required]
-//     scan: the number of addresses to scan ahead for balances [optional, must be > 0]
-//     encrypt: bool value, whether encrypt the wallet [optional]
-//     password: password for encrypting wallet [optional, must be provided if "encrypt" is set]
+//
+//	seed: wallet seed [required, unless bits is set]
+//	bits: generate a mnemonic seed with this entropy size [optional, one of 128, 160, 192, 224, 256; can't be combined with seed]
+//	seed-passphrase: wallet seed passphrase [optional, bip44 type wallet only]
+//	type: wallet type [required, one of "deterministic", "bip44" or "xpub"]
+//	bip44-coin: BIP44 coin type [optional, defaults to 8000 (skycoin's coin type), only valid if type is "bip44"]
+//	xpub: xpub key [required for xpub wallets]
+//	label: wallet label [required]
+//	scan: the number of addresses to scan ahead for balances [optional, must be > 0]
+//	encrypt: bool value, whether encrypt the wallet [optional]
+//	password: password for encrypting wallet [optional, must be provided if "encrypt" is set]
+//	access-token: bool value, whether to set an access token for the wallet, see /api/v2/wallet/token [optional]
 func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -215,6 +450,46 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 		}
 
 		seed := r.FormValue("seed")
+
+		var seedBits int
+		if bitsStr := r.FormValue("bits"); bitsStr != "" {
+			switch walletType {
+			case wallet.WalletTypeDeterministic, wallet.WalletTypeBip44:
+			default:
+				wh.Error400(w, "bits is only valid for deterministic and bip44 type wallets")
+				return
+			}
+
+			if seed != "" {
+				wh.Error400(w, "seed and bits cannot be combined")
+				return
+			}
+
+			var err error
+			seedBits, err = strconv.Atoi(bitsStr)
+			if err != nil {
+				wh.Error400(w, "invalid bits value")
+				return
+			}
+
+			if !isValidSeedBits(seedBits) {
+				wh.Error400(w, "bits must be 128, 160, 192, 224 or 256")
+				return
+			}
+
+			entropy, err := bip39.NewEntropy(seedBits)
+			if err != nil {
+				wh.Error500(w, fmt.Sprintf("bip39.NewEntropy failed: %v", err))
+				return
+			}
+
+			seed, err = bip39.NewMnemonic(entropy)
+			if err != nil {
+				wh.Error500(w, fmt.Sprintf("bip39.NewMnemonic failed: %v", err))
+				return
+			}
+		}
+
 		switch walletType {
 		case wallet.WalletTypeDeterministic, wallet.WalletTypeBip44:
 			if seed == "" {
@@ -271,6 +546,16 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		var accessToken bool
+		if v := r.FormValue("access-token"); v != "" {
+			var err error
+			accessToken, err = strconv.ParseBool(v)
+			if err != nil {
+				wh.Error400(w, fmt.Sprintf("invalid access-token value: %v", err))
+				return
+			}
+		}
+
 		var bip44Coin *bip44.CoinType
 		bip44CoinStr := r.FormValue("bip44-coin")
 		if bip44CoinStr != "" {
@@ -301,6 +586,11 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 			XPub:           r.FormValue("xpub"),
 		}, gateway)
 		if err != nil {
+			if err == wallet.ErrWalletLabelConflict {
+				wh.ErrorXXX(w, http.StatusConflict, err.Error())
+				return
+			}
+
 			switch err.(type) {
 			case wallet.Error:
 				switch err {
@@ -318,22 +608,75 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 
 		}
 
+		var token string
+		if accessToken {
+			var password []byte
+			if encrypt {
+				password = []byte(r.FormValue("password"))
+			}
+
+			token, err = gateway.SetWalletAccessToken(wlt.Filename(), password)
+			if err != nil {
+				wh.Error500(w, fmt.Sprintf("wallet %s was created, but setting its access token failed: %v", wlt.Filename(), err))
+				return
+			}
+		}
+
 		rlt, err := NewWalletResponse(wlt)
 		if err != nil {
 			wh.Error500(w, err.Error())
 			return
 		}
+		rlt.Meta.AccessToken = accessToken
+
+		// Return the generated seed and access token, the caller has no other way to learn them
+		if seedBits != 0 || token != "" {
+			resp := WalletCreateResponse{
+				WalletResponse: rlt,
+				AccessToken:    token,
+			}
+			if seedBits != 0 {
+				resp.Seed = seed
+				resp.SeedBits = seedBits
+			}
+
+			wh.SendJSONOr500(logger, w, resp)
+			return
+		}
+
 		wh.SendJSONOr500(logger, w, rlt)
 	}
 }
 
+// WalletCreateResponse is returned by /api/v1/wallet/create when the seed was generated by the node
+type WalletCreateResponse struct {
+	*WalletResponse
+	Seed     string `json:"seed,omitempty"`
+	SeedBits int    `json:"seed_bits,omitempty"`
+	// AccessToken is the wallet access token, if the access-token option was set
+	AccessToken string `json:"access_token,omitempty"`
+}
+
+// isValidSeedBits returns true if bits is an entropy size supported for bip39 mnemonic generation
+func isValidSeedBits(bits int) bool {
+	switch bits {
+	case 128, 160, 192, 224, 256:
+		return true
+	default:
+		return false
+	}
+}
+
 // Genreates new addresses
 // URI: /api/v1/wallet/newAddress
 // Method: POST
 // Args:
-//     id: wallet id [required]
-//     num: number of address need to create [optional, if not set the default value is 1]
-//     password: wallet password [optional, must be provided if the wallet is encrypted]
+//
+//	id: wallet id [required]
+//	num: number of address need to create [optional, if not set the default value is 1]
+//	password: wallet password [optional, must be provided if the wallet is encrypted]
+//	preview: bool value, return the next addresses without generating them [optional]
+//	commit: comma separated addresses returned by a preview, generated only if no other request generated them since [optional]
 func walletNewAddressesHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -359,16 +702,50 @@ func walletNewAddressesHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}
 
+		preview, err := parseBoolFlag(r.FormValue("preview"))
+		if err != nil {
+			wh.Error400(w, "invalid preview value")
+			return
+		}
+
+		var commit []cipher.Address
+		if commitStr := r.FormValue("commit"); commitStr != "" {
+			if preview {
+				wh.Error400(w, "preview and commit can't be combined")
+				return
+			}
+			if num != "" {
+				wh.Error400(w, "num and commit can't be combined")
+				return
+			}
+
+			commit, err = parseAddressesFromStr(commitStr)
+			if err != nil {
+				wh.Error400(w, fmt.Sprintf("invalid commit value: %v", err))
+				return
+			}
+		}
+
 		password := r.FormValue("password")
 		defer func() {
 			password = ""
 		}()
 
-		addrs, err := gateway.NewAddresses(wltID, []byte(password), n)
+		var addrs []cipher.Address
+		switch {
+		case preview:
+			addrs, err = gateway.PreviewNewAddresses(wltID, []byte(password), n)
+		case commit != nil:
+			addrs, err = gateway.CommitNewAddresses(wltID, []byte(password), commit)
+		default:
+			addrs, err = gateway.NewAddresses(wltID, []byte(password), n)
+		}
 		if err != nil {
 			switch err {
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
+			case wallet.ErrPreviewedAddressesUsed:
+				wh.ErrorXXX(w, http.StatusConflict, err.Error())
 			default:
 				wh.Error400(w, err.Error())
 			}
@@ -387,12 +764,15 @@ func walletNewAddressesHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
-// Update wallet label
+// Update wallet label and options
 // URI: /api/v1/wallet/update
 // Method: POST
 // Args:
-//     id: wallet id [required]
-//     label: the label the wallet will be updated to [required]
+//
+//	id: wallet id [required]
+//	label: the label the wallet will be updated to [required, unless reuse_change or strict_privacy is set]
+//	reuse_change: bool value, whether change is sent to a spent address instead of a new change address [optional, bip44 type wallet only]
+//	strict_privacy: bool value, whether transactions created for the wallet that reuse addresses are refused instead of warned about [optional]
 func walletUpdateHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -407,20 +787,128 @@ func walletUpdateHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		var reuseChange *bool
+		if v := r.FormValue("reuse_change"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				wh.Error400(w, fmt.Sprintf("invalid reuse_change value: %v", err))
+				return
+			}
+			reuseChange = &b
+		}
+
+		var strictPrivacy *bool
+		if v := r.FormValue("strict_privacy"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				wh.Error400(w, fmt.Sprintf("invalid strict_privacy value: %v", err))
+				return
+			}
+			strictPrivacy = &b
+		}
+
 		label := r.FormValue("label")
-		if label == "" {
+		if label == "" && reuseChange == nil && strictPrivacy == nil {
 			wh.Error400(w, "missing label")
 			return
 		}
 
-		if err := gateway.UpdateWalletLabel(wltID, label); err != nil {
-			logger.Errorf("update wallet label failed: %v", err)
+		// The label is updated first, so that a label conflict fails the request before the options are changed
+		if label != "" {
+			if err := gateway.UpdateWalletLabel(wltID, label); err != nil {
+				requestLogger(r).Errorf("update wallet label failed: %v", err)
+				writeWalletUpdateError(w, err)
+				return
+			}
+		}
+
+		if reuseChange != nil {
+			if err := gateway.SetWalletReuseChange(wltID, *reuseChange); err != nil {
+				requestLogger(r).Errorf("set wallet reuse change failed: %v", err)
+				writeWalletUpdateError(w, err)
+				return
+			}
+		}
+
+		if strictPrivacy != nil {
+			if err := gateway.SetWalletStrictPrivacy(wltID, *strictPrivacy); err != nil {
+				requestLogger(r).Errorf("set wallet strict privacy failed: %v", err)
+				writeWalletUpdateError(w, err)
+				return
+			}
+		}
+
+		wh.SendJSONOr500(logger, w, "success")
+	}
+}
+
+// writeWalletUpdateError writes the error response for a wallet update error
+func writeWalletUpdateError(w http.ResponseWriter, err error) {
+	switch err {
+	case wallet.ErrWalletNotExist:
+		wh.Error404(w, "")
+	case wallet.ErrWalletAPIDisabled:
+		wh.Error403(w, "")
+	case wallet.ErrWalletMetadataLocked:
+		wh.Error403(w, err.Error())
+	case wallet.ErrWalletLabelConflict:
+		wh.ErrorXXX(w, http.StatusConflict, err.Error())
+	default:
+		switch err.(type) {
+		case wallet.Error:
+			wh.Error400(w, err.Error())
+		default:
+			wh.Error500(w, err.Error())
+		}
+	}
+}
+
+// Update the label of an address in a wallet
+// URI: /api/v1/wallet/address/label
+// Method: POST
+// Args:
+//
+//	id: wallet id [required]
+//	address: the wallet address to label [required]
+//	label: the new address label, empty to clear it [optional]
+func walletAddressLabelHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		addrStr := r.FormValue("address")
+		if addrStr == "" {
+			wh.Error400(w, "missing address")
+			return
+		}
+
+		addr, err := cipher.DecodeBase58Address(addrStr)
+		if err != nil {
+			wh.Error400(w, fmt.Sprintf("invalid address: %v", err))
+			return
+		}
+
+		if err := gateway.UpdateAddressLabel(wltID, addr, r.FormValue("label")); err != nil {
+			requestLogger(r).Errorf("update address label failed: %v", err)
 
 			switch err {
 			case wallet.ErrWalletNotExist:
 				wh.Error404(w, "")
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
+			case wallet.ErrUnknownAddress,
+				wallet.ErrWalletMetadataTampered:
+				wh.Error400(w, err.Error())
+			case wallet.ErrWalletMetadataLocked:
+				wh.Error403(w, err.Error())
 			default:
 				wh.Error500(w, err.Error())
 			}
@@ -435,7 +923,8 @@ func walletUpdateHandler(gateway Gatewayer) http.HandlerFunc {
 // URI: /api/v1/wallet
 // Method: GET
 // Args:
-//     id: wallet id [required]
+//
+//	id: wallet id [required]
 func walletHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -468,12 +957,14 @@ func walletHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
-// walletTransactionsHandler returns all unconfirmed transactions for all addresses in a given wallet
+// walletTransactionsHandler returns all unconfirmed transactions for all addresses in a given wallet,
+// with their fee and the outputs (and inputs, if verbose) owned by the wallet
 // URI: /api/v1/wallet/transactions
 // Method: GET
 // Args:
-//  id: wallet id [required]
-//  verbose: [bool] include verbose transaction input data
+//
+//	id: wallet id [required]
+//	verbose: [bool] include verbose transaction input data
 func walletTransactionsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -505,51 +996,91 @@ func walletTransactionsHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}
 
-		if verbose {
-			txns, inputs, err := gateway.GetWalletUnconfirmedTransactionsVerbose(wltID)
-			if err != nil {
-				logger.Errorf("get wallet unconfirmed transactions verbose failed: %v", err)
-				handleWalletError(err)
-				return
-			}
+		// The inputs are needed for the fee, also if not verbose
+		txns, inputs, err := gateway.GetWalletUnconfirmedTransactionsVerbose(wltID)
+		if err != nil {
+			requestLogger(r).Errorf("get wallet unconfirmed transactions verbose failed: %v", err)
+			handleWalletError(err)
+			return
+		}
+
+		wlt, err := gateway.GetWallet(wltID)
+		if err != nil {
+			handleWalletError(err)
+			return
+		}
+		addrs := newWalletAddresses(wlt)
+
+		notes, err := getWalletTxNotes(gateway, wltID)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
 
-			vb := make([]readable.UnconfirmedTransactionVerbose, len(txns))
+		if verbose {
+			vb := make([]WalletUnconfirmedTransactionVerbose, len(txns))
 			for i, txn := range txns {
 				v, err := readable.NewUnconfirmedTransactionVerbose(&txn, inputs[i])
 				if err != nil {
 					wh.Error500(w, err.Error())
 					return
 				}
-				vb[i] = *v
+
+				wTxn := addrs.blockTransactionVerbose(v.Transaction)
+				v.Transaction = readable.BlockTransactionVerbose{}
+
+				vb[i] = WalletUnconfirmedTransactionVerbose{
+					UnconfirmedTransactionVerbose: *v,
+					Transaction:                   wTxn,
+					FeeHours:                      wTxn.Fee,
+					Note:                          notes[txn.Transaction.Hash()],
+					CalculatedHoursEstimated:      true,
+				}
 			}
 
 			wh.SendJSONOr500(logger, w, UnconfirmedTxnsVerboseResponse{
 				Transactions: vb,
 			})
 		} else {
-			txns, err := gateway.GetWalletUnconfirmedTransactions(wltID)
-			if err != nil {
-				logger.Errorf("get wallet unconfirmed transactions failed: %v", err)
-				handleWalletError(err)
-				return
-			}
-
 			unconfirmedTxns, err := readable.NewUnconfirmedTransactions(txns)
 			if err != nil {
 				wh.Error500(w, err.Error())
 				return
 			}
 
+			wltTxns := make([]WalletUnconfirmedTransaction, len(txns))
+			for i, txn := range txns {
+				fee, err := transactionFeeHours(txn.Transaction, inputs[i], false)
+				if err != nil {
+					wh.Error500(w, err.Error())
+					return
+				}
+
+				wTxn := addrs.transaction(unconfirmedTxns[i].Transaction)
+				unconfirmedTxns[i].Transaction = readable.Transaction{}
+
+				wltTxns[i] = WalletUnconfirmedTransaction{
+					UnconfirmedTransactions: unconfirmedTxns[i],
+					Transaction:             wTxn,
+					FeeHours:                fee,
+					Note:                    notes[txn.Transaction.Hash()],
+				}
+			}
+
 			wh.SendJSONOr500(logger, w, UnconfirmedTxnsResponse{
-				Transactions: unconfirmedTxns,
+				Transactions: wltTxns,
 			})
 		}
 	}
 }
 
-// Returns all loaded wallets
+// Returns all wallets. Wallets that are not loaded are listed without loading them, with their metadata only.
+// Wallets with an access token are only listed if the request carries their token.
 // URI: /api/v1/wallets
 // Method: GET
+// Args:
+//
+//	label: only return the wallets with this label [optional]
 func walletsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -557,7 +1088,9 @@ func walletsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wlts, err := gateway.GetWallets()
+		label := r.FormValue("label")
+
+		hs, err := gateway.ListWallets()
 		if err != nil {
 			switch err {
 			case wallet.ErrWalletAPIDisabled:
@@ -568,15 +1101,15 @@ func walletsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wrs := make([]*WalletResponse, 0, len(wlts))
-		for _, wlt := range wlts {
-			wr, err := NewWalletResponse(wlt)
-			if err != nil {
-				wh.Error500(w, err.Error())
-				return
+		wrs := make([]*WalletResponse, 0, len(hs))
+		for _, h := range hs {
+			if label != "" && h.Meta.Label() != label {
+				continue
 			}
-
-			wrs = append(wrs, wr)
+			if !walletTokenAllowed(gateway, h.Meta.Filename(), r) {
+				continue
+			}
+			wrs = append(wrs, NewWalletHeaderResponse(h))
 		}
 
 		sort.Slice(wrs, func(i, j int) bool {
@@ -619,11 +1152,74 @@ func walletFolderHandler(s Walleter) http.HandlerFunc {
 	}
 }
 
-// Generates wallet seed
-// URI: /api/v1/wallet/newSeed
+// WalletBackup is a backup of a wallet file
+type WalletBackup struct {
+	Filename string `json:"filename"`
+	Time     int64  `json:"time"`
+	Size     int64  `json:"size"`
+}
+
+// WalletBackupsResponse is returned by GET /api/v1/wallets/backups
+type WalletBackupsResponse struct {
+	WalletID string         `json:"wallet_id"`
+	Backups  []WalletBackup `json:"backups"`
+}
+
+// Returns the backups of a wallet file, oldest first.
+// The wallet does not need to be loaded.
+// URI: /api/v1/wallets/backups
+// Method: GET
+// Args:
+//
+//	id: wallet id [required]
+func walletBackupsHandler(s Walleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		backups, err := s.ListWalletBackups(wltID)
+		if err != nil {
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				wh.Error403(w, "")
+			case wallet.ErrInvalidWalletID:
+				wh.Error400(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		ret := WalletBackupsResponse{
+			WalletID: wltID,
+			Backups:  make([]WalletBackup, len(backups)),
+		}
+		for i, b := range backups {
+			ret.Backups[i] = WalletBackup{
+				Filename: b.Filename,
+				Time:     b.Time.Unix(),
+				Size:     b.Size,
+			}
+		}
+
+		wh.SendJSONOr500(logger, w, ret)
+	}
+}
+
+// Generates wallet seed
+// URI: /api/v1/wallet/newSeed
 // Method: GET
 // Args:
-//     entropy: entropy bitsize [optional, default value of 128 will be used if not set]
+//
+//	entropy: entropy bitsize [optional, default value of 128 will be used if not set]
 func newSeedHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -681,8 +1277,9 @@ type WalletSeedResponse struct {
 // URI: /api/v1/wallet/seed
 // Method: POST
 // Args:
-//     id: wallet id
-//     password: wallet password
+//
+//	id: wallet id
+//	password: wallet password
 func walletSeedHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -727,6 +1324,270 @@ func walletSeedHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// WalletXPubResponse is returned by /api/v1/wallet/xpub
+type WalletXPubResponse struct {
+	Account uint32 `json:"account"`
+	XPub    string `json:"xpub"`
+	// Encrypted is true if the wallet is encrypted
+	Encrypted bool `json:"encrypted"`
+	// Note explains that the xpub of an encrypted wallet is stored unencrypted
+	Note string `json:"note,omitempty"`
+}
+
+// encryptedXPubNote is the WalletXPubResponse note of encrypted wallets
+const encryptedXPubNote = "The xpub of an encrypted wallet is stored unencrypted with its metadata, so that its addresses " +
+	"can be watched without the password. It reveals all of the account's addresses and balances, but no keys."
+
+// Returns the stored account xpub of a bip44 wallet, so that the seed API can stay disabled.
+// The xpub of account 0 is stored when the wallet is created. An encrypted wallet created before
+// the xpub was stored returns 404 until its metadata is unlocked once with the password.
+// URI: /api/v1/wallet/xpub
+// Method: GET
+// Args:
+//
+//	id: wallet id
+//	account: bip44 account [optional, defaults to 0, only account 0 is stored]
+func walletXPubHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		var account uint32
+		if accountStr := r.FormValue("account"); accountStr != "" {
+			n, err := strconv.ParseUint(accountStr, 10, 32)
+			if err != nil || n >= uint64(bip32.FirstHardenedChild) {
+				wh.Error400(w, "invalid account")
+				return
+			}
+			account = uint32(n)
+		}
+
+		x, err := gateway.GetWalletAccountXPub(id, account)
+		if err != nil {
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				wh.Error403(w, "")
+			case wallet.ErrWalletNotExist:
+				wh.Error404(w, "")
+			case wallet.ErrAccountXPubNotStored:
+				wh.Error404(w, err.Error())
+			default:
+				switch err.(type) {
+				case wallet.Error:
+					wh.Error400(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
+			}
+			return
+		}
+
+		resp := WalletXPubResponse{
+			Account:   x.Account,
+			XPub:      x.XPub,
+			Encrypted: x.Encrypted,
+		}
+		if x.Encrypted {
+			resp.Note = encryptedXPubNote
+		}
+
+		wh.SendJSONOr500(logger, w, resp)
+	}
+}
+
+// WalletDeriveChildResponse is returned by /api/v1/wallet/derive-child
+type WalletDeriveChildResponse struct {
+	*WalletResponse
+	// Mnemonic is the seed of the child wallet. It is only returned once, for the user to back up
+	Mnemonic string `json:"mnemonic"`
+	// Path is the bip85 path the mnemonic was derived at
+	Path string `json:"path"`
+}
+
+// Creates a bip44 child wallet from a bip85 mnemonic derived from the seed of a bip44 wallet,
+// so that the parent wallet's seed is a backup of the child wallet.
+// The child wallet is encrypted with the parent wallet's password if the parent wallet is encrypted.
+// Method: POST
+// URI: /api/v1/wallet/derive-child
+// Args:
+//
+//	id: parent bip44 wallet id [required]
+//	index: application index of the child mnemonic, < 2147483648 [required]
+//	words: number of words of the child mnemonic, 12, 18 or 24 [optional, default 12]
+//	label: child wallet label [required]
+//	password: parent wallet password [required if the parent wallet is encrypted]
+//	scan: the number of addresses to scan ahead for balances [optional, default 1]
+func walletDeriveChildHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		indexStr := r.FormValue("index")
+		if indexStr == "" {
+			wh.Error400(w, "missing index")
+			return
+		}
+
+		index, err := strconv.ParseUint(indexStr, 10, 32)
+		if err != nil {
+			wh.Error400(w, "invalid index value")
+			return
+		}
+
+		var words uint64 = 12
+		if wordsStr := r.FormValue("words"); wordsStr != "" {
+			words, err = strconv.ParseUint(wordsStr, 10, 32)
+			if err != nil {
+				wh.Error400(w, "invalid words value")
+				return
+			}
+		}
+
+		label := r.FormValue("label")
+		if label == "" {
+			wh.Error400(w, "missing label")
+			return
+		}
+
+		scanN := uint64(1)
+		if scanNStr := r.FormValue("scan"); scanNStr != "" {
+			scanN, err = strconv.ParseUint(scanNStr, 10, 64)
+			if err != nil {
+				wh.Error400(w, "invalid scan value")
+				return
+			}
+		}
+
+		if scanN == 0 {
+			wh.Error400(w, "scan must be > 0")
+			return
+		}
+
+		password := r.FormValue("password")
+		defer func() {
+			password = ""
+		}()
+
+		parent, err := gateway.GetWallet(id)
+		if err != nil {
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				wh.Error403(w, "")
+			case wallet.ErrWalletNotExist:
+				wh.Error404(w, "")
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		if parent.Type() != wallet.WalletTypeBip44 {
+			wh.Error400(w, fmt.Sprintf("wallet type must be %q", wallet.WalletTypeBip44))
+			return
+		}
+
+		seed, seedPassphrase, err := gateway.GetWalletSeed(id, []byte(password))
+		switch err {
+		case nil:
+		case wallet.ErrWalletNotEncrypted:
+			if len(password) != 0 {
+				wh.Error400(w, err.Error())
+				return
+			}
+			seed = parent.Seed()
+			seedPassphrase = parent.SeedPassphrase()
+		case wallet.ErrMissingPassword,
+			wallet.ErrInvalidPassword:
+			wh.Error400(w, err.Error())
+			return
+		case wallet.ErrWalletAPIDisabled, wallet.ErrSeedAPIDisabled:
+			wh.Error403(w, "")
+			return
+		case wallet.ErrWalletNotExist:
+			wh.Error404(w, "")
+			return
+		default:
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		seedBytes, err := bip39.NewSeed(seed, seedPassphrase)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		mnemonic, err := bip85.NewMnemonicFromSeed(seedBytes, uint32(words), uint32(index))
+		if err != nil {
+			switch err {
+			case bip85.ErrInvalidWords, bip85.ErrInvalidIndex:
+				wh.Error400(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		bip44Coin := parent.Bip44Coin()
+		wlt, err := gateway.CreateWallet("", wallet.Options{
+			Type:      wallet.WalletTypeBip44,
+			Seed:      mnemonic,
+			Label:     label,
+			Encrypt:   parent.IsEncrypted(),
+			Password:  []byte(password),
+			ScanN:     scanN,
+			Bip44Coin: &bip44Coin,
+		}, gateway)
+		if err != nil {
+			if err == wallet.ErrWalletLabelConflict {
+				wh.ErrorXXX(w, http.StatusConflict, err.Error())
+				return
+			}
+
+			switch err.(type) {
+			case wallet.Error:
+				switch err {
+				case wallet.ErrWalletAPIDisabled:
+					wh.Error403(w, "")
+				default:
+					wh.Error400(w, err.Error())
+				}
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		rlt, err := NewWalletResponse(wlt)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, WalletDeriveChildResponse{
+			WalletResponse: rlt,
+			Mnemonic:       mnemonic,
+			Path:           bip85.Bip39Path(uint32(words), uint32(index)),
+		})
+	}
+}
+
 // VerifySeedRequest is the request data for POST /api/v2/wallet/seed/verify
 type VerifySeedRequest struct {
 	Seed string `json:"seed"`
@@ -743,8 +1604,8 @@ func walletVerifySeedHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req VerifySeedRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	if err := decodeJSONRequest(r, &req); err != nil {
+		resp := NewJSONDecodeErrorResponse(err)
 		writeHTTPResponse(w, resp)
 		return
 	}
@@ -764,11 +1625,13 @@ func walletVerifySeedHandler(w http.ResponseWriter, r *http.Request) {
 	writeHTTPResponse(w, HTTPResponse{Data: struct{}{}})
 }
 
-// Unloads wallet from the wallet service
+// Unloads wallet from memory, it is loaded again when it is next used.
+// Fails with 409 if the wallet is in use by another request.
 // URI: /api/v1/wallet/unload
 // Method: POST
 // Args:
-//     id: wallet id
+//
+//	id: wallet id
 func walletUnloadHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -786,6 +1649,8 @@ func walletUnloadHandler(gateway Gatewayer) http.HandlerFunc {
 			switch err {
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
+			case wallet.ErrWalletInUse:
+				wh.ErrorXXX(w, http.StatusConflict, err.Error())
 			default:
 				wh.Error500(w, err.Error())
 			}
@@ -793,12 +1658,95 @@ func walletUnloadHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// Unlocks the metadata of an encrypted wallet with its password, without decrypting its seeds and keys.
+// The wallet and address labels can then be updated without the password, until the metadata
+// is locked again or the wallet is unloaded.
+// Fails with 400 if the metadata was modified without the password.
+// URI: /api/v1/wallet/metadata/unlock
+// Method: POST
+// Args:
+//
+//	id: wallet id
+//	password: wallet password
+func walletMetadataUnlockHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		password := r.FormValue("password")
+		defer func() {
+			password = ""
+		}()
+
+		if err := gateway.UnlockWalletMetadata(id, []byte(password)); err != nil {
+			writeWalletMetadataError(w, err)
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, "success")
+	}
+}
+
+// Locks the metadata of an encrypted wallet, wiping its metadata key from memory
+// URI: /api/v1/wallet/metadata/lock
+// Method: POST
+// Args:
+//
+//	id: wallet id
+func walletMetadataLockHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		if err := gateway.LockWalletMetadata(id); err != nil {
+			writeWalletMetadataError(w, err)
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, "success")
+	}
+}
+
+// writeWalletMetadataError writes the error response for a wallet metadata unlock or lock error
+func writeWalletMetadataError(w http.ResponseWriter, err error) {
+	switch err {
+	case wallet.ErrWalletAPIDisabled:
+		wh.Error403(w, "")
+	case wallet.ErrWalletNotExist:
+		wh.Error404(w, "")
+	default:
+		switch err.(type) {
+		case wallet.Error:
+			wh.Error400(w, err.Error())
+		default:
+			wh.Error500(w, err.Error())
+		}
+	}
+}
+
 // Encrypts wallet
 // URI: /api/v1/wallet/encrypt
 // Method: POST
 // Args:
-//     id: wallet id
-//     password: wallet password
+//
+//	id: wallet id
+//	password: wallet password
 func walletEncryptHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -848,8 +1796,9 @@ func walletEncryptHandler(gateway Gatewayer) http.HandlerFunc {
 // URI: /api/v1/wallet/decrypt
 // Method: POST
 // Args:
-//     id: wallet id
-//     password: wallet password
+//
+//	id: wallet id
+//	password: wallet password
 func walletDecryptHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -900,87 +1849,210 @@ type WalletRecoverRequest struct {
 	Seed           string `json:"seed"`
 	SeedPassphrase string `json:"seed_passphrase"`
 	Password       string `json:"password"`
+	Type           string `json:"type,omitempty"`
+	Label          string `json:"label,omitempty"`
+	ScanN          uint64 `json:"scan_n,omitempty"`
+}
+
+// WalletRecoverResponse is the response data for POST /api/v2/wallet/recover
+type WalletRecoverResponse struct {
+	JobID string `json:"job_id"`
 }
 
 // URI: /api/v2/wallet/recover
-// Method: POST
+// Method: POST, DELETE
 // Args:
-//  id: wallet id
-//  seed: wallet seed
-//  password: [optional] new password
-// Recovers an encrypted wallet by providing the seed.
-// The first address will be generated from seed and compared to the first address
-// of the specified wallet. If they match, the wallet will be regenerated
-// with an optional password.
-// If the wallet is not encrypted, an error is returned.
+//
+//	POST, JSON body:
+//	id: wallet id
+//	seed: wallet seed
+//	seed_passphrase: [optional] seed passphrase
+//	password: [optional] new password
+//	type: [optional] type of a new wallet, bip44 or deterministic, defaults to bip44
+//	label: [optional] label of a new wallet
+//	scan_n: [optional] number of addresses to scan ahead for transaction history
+//	DELETE, query:
+//	job: recovery job id
+//
+// POST starts recovering a wallet from its seed in the background and returns the job id,
+// see GET /api/v2/wallet/recover/status for its progress.
+// If the id is an existing encrypted wallet, the first address will be generated from seed and compared
+// to the first address of the wallet. If they match, the wallet will be regenerated with an optional password.
+// Otherwise a new wallet is created from the seed with the id as filename.
+// The wallet is only changed or created when the recovery completes.
+// Only one recovery runs at a time. Recovery jobs are kept in memory and do not survive a restart.
+// DELETE cancels a running recovery.
 func walletRecoverHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
+		switch r.Method {
+		case http.MethodPost:
+			walletRecoverStart(gateway, w, r)
+		case http.MethodDelete:
+			walletRecoverCancel(gateway, w, r)
+		default:
 			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
 			writeHTTPResponse(w, resp)
-			return
 		}
+	}
+}
 
-		var req WalletRecoverRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
-			writeHTTPResponse(w, resp)
-			return
-		}
+func walletRecoverStart(gateway Gatewayer, w http.ResponseWriter, r *http.Request) {
+	var req WalletRecoverRequest
+	if err := decodeJSONRequest(r, &req); err != nil {
+		resp := NewJSONDecodeErrorResponse(err)
+		writeHTTPResponse(w, resp)
+		return
+	}
 
-		if req.ID == "" {
-			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
-			writeHTTPResponse(w, resp)
-			return
-		}
+	if req.ID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Seed == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "seed is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	var password []byte
+	if req.Password != "" {
+		password = []byte(req.Password)
+	}
+
+	defer func() {
+		req.Seed = ""
+		req.SeedPassphrase = ""
+		req.Password = ""
+		password = nil
+	}()
+
+	jobID, err := gateway.StartWalletRecovery(wallet.RecoveryOptions{
+		WalletID:       req.ID,
+		Seed:           req.Seed,
+		SeedPassphrase: req.SeedPassphrase,
+		Password:       password,
+		Type:           req.Type,
+		Label:          req.Label,
+		ScanN:          req.ScanN,
+	}, gateway)
+	if err != nil {
+		writeHTTPResponse(w, walletRecoveryErrorResponse(err))
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: WalletRecoverResponse{
+			JobID: jobID,
+		},
+	})
+}
 
-		if req.Seed == "" {
-			resp := NewHTTPErrorResponse(http.StatusBadRequest, "seed is required")
+func walletRecoverCancel(gateway Gatewayer, w http.ResponseWriter, r *http.Request) {
+	jobID := r.FormValue("job")
+	if jobID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "job is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if err := gateway.CancelWalletRecovery(jobID); err != nil {
+		writeHTTPResponse(w, walletRecoveryErrorResponse(err))
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{})
+}
+
+// walletRecoveryErrorResponse returns the error response for an error of a wallet recovery
+func walletRecoveryErrorResponse(err error) HTTPResponse {
+	switch err.(type) {
+	case wallet.Error:
+		switch err {
+		case wallet.ErrWalletNotExist,
+			wallet.ErrRecoveryNotExist:
+			return NewHTTPErrorResponse(http.StatusNotFound, "")
+		case wallet.ErrWalletAPIDisabled:
+			return NewHTTPErrorResponse(http.StatusForbidden, "")
+		case wallet.ErrRecoveryInProgress,
+			wallet.ErrRecoveryFinished:
+			return NewHTTPErrorResponse(http.StatusConflict, err.Error())
+		default:
+			return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		}
+	default:
+		return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// WalletRecoveryStatusResponse is the response data for GET /api/v2/wallet/recover/status
+type WalletRecoveryStatusResponse struct {
+	JobID            string `json:"job_id"`
+	WalletID         string `json:"wallet_id"`
+	State            string `json:"state"`
+	ScanN            uint64 `json:"scan_n"`
+	AddressesScanned uint64 `json:"addresses_scanned"`
+	AddressesFound   uint64 `json:"addresses_found"`
+	ChainIndex       uint32 `json:"chain_index"`
+	ChildIndex       uint32 `json:"child_index"`
+	Started          int64  `json:"started"`
+	Finished         int64  `json:"finished,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// NewWalletRecoveryStatusResponse creates a WalletRecoveryStatusResponse from a wallet.RecoveryStatus
+func NewWalletRecoveryStatusResponse(s wallet.RecoveryStatus) WalletRecoveryStatusResponse {
+	rsp := WalletRecoveryStatusResponse{
+		JobID:            s.JobID,
+		WalletID:         s.WalletID,
+		State:            string(s.State),
+		ScanN:            s.ScanN,
+		AddressesScanned: s.AddressesScanned,
+		AddressesFound:   s.AddressesFound,
+		ChainIndex:       s.ChainIndex,
+		ChildIndex:       s.ChildIndex,
+		Started:          s.Started.Unix(),
+		Error:            s.Error,
+	}
+
+	if !s.Finished.IsZero() {
+		rsp.Finished = s.Finished.Unix()
+	}
+
+	return rsp
+}
+
+// URI: /api/v2/wallet/recover/status
+// Method: GET
+// Args:
+//
+//	job: recovery job id
+//
+// Returns the progress of a wallet recovery
+func walletRecoverStatusHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
 			writeHTTPResponse(w, resp)
 			return
 		}
 
-		var password []byte
-		if req.Password != "" {
-			password = []byte(req.Password)
-		}
-
-		defer func() {
-			req.Seed = ""
-			req.SeedPassphrase = ""
-			req.Password = ""
-			password = nil
-		}()
-
-		wlt, err := gateway.RecoverWallet(req.ID, req.Seed, req.SeedPassphrase, password)
-		if err != nil {
-			var resp HTTPResponse
-			switch err.(type) {
-			case wallet.Error:
-				switch err {
-				case wallet.ErrWalletNotExist:
-					resp = NewHTTPErrorResponse(http.StatusNotFound, "")
-				case wallet.ErrWalletAPIDisabled:
-					resp = NewHTTPErrorResponse(http.StatusForbidden, "")
-				default:
-					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
-				}
-			default:
-				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
-			}
+		jobID := r.FormValue("job")
+		if jobID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "job is required")
 			writeHTTPResponse(w, resp)
 			return
 		}
 
-		rlt, err := NewWalletResponse(wlt)
+		status, err := gateway.WalletRecoveryStatus(jobID)
 		if err != nil {
-			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
-			writeHTTPResponse(w, resp)
+			writeHTTPResponse(w, walletRecoveryErrorResponse(err))
 			return
 		}
 
 		writeHTTPResponse(w, HTTPResponse{
-			Data: rlt,
+			Data: NewWalletRecoveryStatusResponse(*status),
 		})
 	}
 }
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletAddressPasswordRequest is the request body of /api/v2/wallet/address/encrypt and /api/v2/wallet/address/decrypt
+type WalletAddressPasswordRequest struct {
+	WalletID        string `json:"wallet_id"`
+	Password        string `json:"password"`
+	Address         string `json:"address"`
+	AddressPassword string `json:"address_password"`
+}
+
+// walletAddressEncryptHandler encrypts the secret key of an address of a collection wallet with its own password.
+// The address password is then required to sign for the address, instead of the wallet password,
+// see the address_passwords of /api/v2/wallet/transaction/sign.
+// Method: POST
+// URI: /api/v2/wallet/address/encrypt
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	password [string]: wallet password, if the wallet is encrypted
+//	address [string]: address [required]
+//	address_password [string]: address password [required]
+func walletAddressEncryptHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletAddressPasswordHandler(gateway.EncryptWalletAddress)
+}
+
+// walletAddressDecryptHandler removes the encryption of the secret key of an address of a collection wallet
+// with its own password. The secret key is protected by the wallet password again, if the wallet is encrypted.
+// Method: POST
+// URI: /api/v2/wallet/address/decrypt
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	password [string]: wallet password, if the wallet is encrypted
+//	address [string]: address [required]
+//	address_password [string]: address password [required]
+func walletAddressDecryptHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletAddressPasswordHandler(gateway.DecryptWalletAddress)
+}
+
+func walletAddressPasswordHandler(f func(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletAddressPasswordRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+			req.AddressPassword = ""
+		}()
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Address == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "address is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addr, err := cipher.DecodeBase58Address(req.Address)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.AddressPassword == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "address_password is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var password []byte
+		if req.Password != "" {
+			password = []byte(req.Password)
+		}
+
+		if err := f(req.WalletID, password, addr, []byte(req.AddressPassword)); err != nil {
+			var resp HTTPResponse
+			switch err.(type) {
+			case wallet.Error:
+				switch err {
+				case wallet.ErrWalletNotExist:
+					resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+				case wallet.ErrWalletAPIDisabled:
+					resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+				default:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				}
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{})
+	}
+}
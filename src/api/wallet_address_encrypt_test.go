@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletAddressPassword(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	validBody := &WalletAddressPasswordRequest{
+		WalletID:        "foo.wlt",
+		Address:         addr.String(),
+		AddressPassword: "pwd",
+	}
+
+	cases := []struct {
+		name         string
+		method       string
+		body         *WalletAddressPasswordRequest
+		rawBody      string
+		gatewayErr   error
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - invalid json",
+			method:       http.MethodPost,
+			rawBody:      "{ca",
+			status:       http.StatusBadRequest,
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: "invalid JSON at offset 2: invalid character 'c' looking for beginning of object key string", Offset: 2}),
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			body: &WalletAddressPasswordRequest{
+				Address:         addr.String(),
+				AddressPassword: "pwd",
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+		{
+			name:   "400 - missing address",
+			method: http.MethodPost,
+			body: &WalletAddressPasswordRequest{
+				WalletID:        "foo.wlt",
+				AddressPassword: "pwd",
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address is required"),
+		},
+		{
+			name:   "400 - invalid address",
+			method: http.MethodPost,
+			body: &WalletAddressPasswordRequest{
+				WalletID:        "foo.wlt",
+				Address:         "foo",
+				AddressPassword: "pwd",
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid address: Invalid address length"),
+		},
+		{
+			name:   "400 - missing address password",
+			method: http.MethodPost,
+			body: &WalletAddressPasswordRequest{
+				WalletID: "foo.wlt",
+				Address:  addr.String(),
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address_password is required"),
+		},
+		{
+			name:         "400 - wallet error",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrEntryEncryptionNotSupported,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `only "collection" wallet entries can be encrypted with their own password`),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			status:       http.StatusForbidden,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet not found",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:         "500 - misc error",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   errors.New("failed"),
+			status:       http.StatusInternalServerError,
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body: &WalletAddressPasswordRequest{
+				WalletID:        "foo.wlt",
+				Password:        "wpwd",
+				Address:         addr.String(),
+				AddressPassword: "pwd",
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, endpoint := range []string{"encrypt", "decrypt"} {
+		for _, tc := range cases {
+			t.Run(endpoint+" "+tc.name, func(t *testing.T) {
+				gateway := newWalletMockGatewayer()
+
+				if tc.body != nil {
+					var password []byte
+					if tc.body.Password != "" {
+						password = []byte(tc.body.Password)
+					}
+
+					method := "EncryptWalletAddress"
+					if endpoint == "decrypt" {
+						method = "DecryptWalletAddress"
+					}
+					gateway.On(method, tc.body.WalletID, password, addr, []byte(tc.body.AddressPassword)).Return(tc.gatewayErr)
+				}
+
+				body := []byte(tc.rawBody)
+				if len(body) == 0 {
+					var err error
+					body, err = json.Marshal(tc.body)
+					require.NoError(t, err)
+				}
+
+				req, err := http.NewRequest(tc.method, "/api/v2/wallet/address/"+endpoint, bytes.NewBuffer(body))
+				require.NoError(t, err)
+				req.Header.Set("Content-Type", ContentTypeJSON)
+				setCSRFParameters(t, tokenValid, req)
+
+				rr := httptest.NewRecorder()
+				newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+				require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+				var rsp ReceivedHTTPResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+				require.Equal(t, tc.httpResponse.Error, rsp.Error)
+				require.Nil(t, rsp.Data)
+			})
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	wh "github.com/skycoin/skycoin/src/util/http"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// walletSpendAmount returns the number of droplets a transaction sends to addresses that are not in a wallet
+func walletSpendAmount(w wallet.Wallet, txn *coin.Transaction) (uint64, error) {
+	walletAddrs, err := w.GetSkycoinAddresses()
+	if err != nil {
+		return 0, err
+	}
+
+	addrs := make(map[cipher.Address]struct{}, len(walletAddrs))
+	for _, a := range walletAddrs {
+		addrs[a] = struct{}{}
+	}
+
+	var amount uint64
+	for _, o := range txn.Out {
+		if _, ok := addrs[o.Address]; ok {
+			continue
+		}
+
+		amount, err = mathutil.AddUint64(amount, o.Coins)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return amount, nil
+}
+
+// holdForApproval holds a signed transaction of a wallet until the wallet's approver approves it,
+// if the wallet's approval policy requires it, and returns the pending approval.
+// Returns nil if the wallet has no approval policy or the transaction does not send more than
+// the policy threshold out of the wallet.
+// The transaction is not released if the wallet approvals storage is unavailable.
+func holdForApproval(gateway Gatewayer, wltID string, txn *coin.Transaction) (*kvstorage.PendingApproval, error) {
+	p, err := gateway.WalletApprovalPolicy(wltID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	w, err := gateway.GetWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := walletSpendAmount(w, txn)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.RequiresApproval(amount) {
+		return nil, nil
+	}
+
+	return gateway.AddPendingApproval(wltID, txn, amount, p.Window)
+}
+
+// walletApprovalErrorResponse returns the error response of the wallet approval errors
+func walletApprovalErrorResponse(err error) HTTPResponse {
+	switch err {
+	case wallet.ErrWalletNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "")
+	case kvstorage.ErrStorageAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "storage api is disabled, approvals are unavailable")
+	case kvstorage.ErrNoSuchStorage:
+		return NewHTTPErrorResponse(http.StatusNotFound, "wallet approvals storage is not loaded")
+	case kvstorage.ErrNoSuchPendingApproval:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	case kvstorage.ErrTooManyPendingApprovals:
+		return NewHTTPErrorResponse(http.StatusConflict, err.Error())
+	default:
+		switch err.(type) {
+		case wallet.Error, kvstorage.Error:
+			return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		default:
+			return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
+}
+
+// writeWalletApprovalError writes the error response of the wallet approval errors
+func writeWalletApprovalError(w http.ResponseWriter, apiVersion string, err error) {
+	resp := walletApprovalErrorResponse(err)
+	writeError(w, apiVersion, resp.Error.Code, resp.Error.Message)
+}
+
+// writePendingApproval writes a 202 Accepted response with a pending approval
+func writePendingApproval(w http.ResponseWriter, apiVersion string, p *kvstorage.PendingApproval) {
+	var v interface{} = p
+	if apiVersion == apiVersion2 {
+		v = HTTPResponse{
+			Data: p,
+		}
+	}
+
+	out, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		wh.Error500(w, "json.MarshalIndent failed")
+		return
+	}
+
+	w.Header().Add("Content-Type", ContentTypeJSON)
+	w.WriteHeader(http.StatusAccepted)
+
+	if _, err := w.Write(out); err != nil {
+		logger.WithError(err).Error("http Write failed")
+	}
+}
+
+// WalletApprovalPolicyRequest is the request body of POST /api/v2/wallet/approval/policy
+type WalletApprovalPolicyRequest struct {
+	WalletID string `json:"wallet_id"`
+	Password string `json:"password,omitempty"`
+	// Threshold is the number of coins above which a spend requires approval
+	Threshold string `json:"threshold"`
+	// Window is how long a spend waits for approval, e.g. "24h"
+	Window                  string `json:"window"`
+	ApproverPassword        string `json:"approver_password"`
+	CurrentApproverPassword string `json:"current_approver_password,omitempty"`
+}
+
+// WalletApprovalPolicyResponse is the approval policy of a wallet
+type WalletApprovalPolicyResponse struct {
+	Threshold string `json:"threshold"`
+	Window    string `json:"window"`
+}
+
+func newWalletApprovalPolicyResponse(p *wallet.ApprovalPolicy) (*WalletApprovalPolicyResponse, error) {
+	threshold, err := droplet.ToString(p.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WalletApprovalPolicyResponse{
+		Threshold: threshold,
+		Window:    p.Window.String(),
+	}, nil
+}
+
+// walletApprovalPolicyHandler sets the approval policy of a wallet, replacing its policy if it has one.
+// Spends created or signed by the wallet API that send more than the threshold out of the wallet
+// are then held until they are approved with the approver password, see walletApprovalApproveHandler.
+// Method: POST
+// URI: /api/v2/wallet/approval/policy
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	password [string]: wallet password, if the wallet is encrypted
+//	threshold [string]: number of coins above which a spend requires approval [required]
+//	window [string]: how long a spend waits for approval, e.g. "24h" [required]
+//	approver_password [string]: approver password, which must differ from the wallet password [required]
+//	current_approver_password [string]: current approver password, if the wallet has an approval policy
+func walletApprovalPolicyHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletApprovalPolicyRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+			req.ApproverPassword = ""
+			req.CurrentApproverPassword = ""
+		}()
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		threshold, err := droplet.FromString(req.Threshold)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid threshold: "+err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		window, err := time.ParseDuration(req.Window)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid window: "+err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := gateway.SetWalletApprovalPolicy(req.WalletID, []byte(req.Password), []byte(req.ApproverPassword),
+			[]byte(req.CurrentApproverPassword), threshold, window); err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		resp, err := newWalletApprovalPolicyResponse(&wallet.ApprovalPolicy{
+			Threshold: threshold,
+			Window:    window,
+		})
+		if err != nil {
+			writeHTTPResponse(w, NewHTTPErrorResponse(http.StatusInternalServerError, err.Error()))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: resp,
+		})
+	}
+}
+
+// WalletApprovalPolicyRemoveRequest is the request body of POST /api/v2/wallet/approval/policy/remove
+type WalletApprovalPolicyRemoveRequest struct {
+	WalletID         string `json:"wallet_id"`
+	Password         string `json:"password,omitempty"`
+	ApproverPassword string `json:"approver_password"`
+}
+
+// walletApprovalPolicyRemoveHandler removes the approval policy of a wallet.
+// Pending approvals can still be approved or cancelled.
+// Method: POST
+// URI: /api/v2/wallet/approval/policy/remove
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	password [string]: wallet password, if the wallet is encrypted
+//	approver_password [string]: approver password [required]
+func walletApprovalPolicyRemoveHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletApprovalPolicyRemoveRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+			req.ApproverPassword = ""
+		}()
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := gateway.RemoveWalletApprovalPolicy(req.WalletID, []byte(req.Password), []byte(req.ApproverPassword)); err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: struct{}{},
+		})
+	}
+}
+
+// WalletApprovalsResponse is the response data of GET /api/v2/wallet/approvals
+type WalletApprovalsResponse struct {
+	// Policy is the approval policy of the wallet, nil if it has none
+	Policy    *WalletApprovalPolicyResponse `json:"policy"`
+	Approvals []kvstorage.PendingApproval   `json:"approvals"`
+}
+
+// walletApprovalsHandler returns the approval policy and the pending approvals of a wallet, oldest first.
+// Expired approvals are not returned.
+// Method: GET
+// URI: /api/v2/wallet/approvals
+// Args:
+//
+//	id: wallet id [required]
+func walletApprovalsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "missing wallet id")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		p, err := gateway.WalletApprovalPolicy(wltID)
+		if err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		approvals, err := gateway.GetPendingApprovals(wltID)
+		if err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		resp := WalletApprovalsResponse{
+			Approvals: approvals,
+		}
+		if p != nil {
+			resp.Policy, err = newWalletApprovalPolicyResponse(p)
+			if err != nil {
+				writeHTTPResponse(w, NewHTTPErrorResponse(http.StatusInternalServerError, err.Error()))
+				return
+			}
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: resp,
+		})
+	}
+}
+
+// WalletApprovalRequest is the request body of POST /api/v2/wallet/approval/approve
+// and POST /api/v2/wallet/approval/cancel
+type WalletApprovalRequest struct {
+	WalletID string `json:"wallet_id"`
+	// ID is the id of the pending approval
+	ID               string `json:"id"`
+	ApproverPassword string `json:"approver_password,omitempty"`
+	Broadcast        bool   `json:"broadcast,omitempty"`
+}
+
+// WalletApprovalApproveResponse is the response data of POST /api/v2/wallet/approval/approve
+type WalletApprovalApproveResponse struct {
+	TxID               string `json:"txid"`
+	EncodedTransaction string `json:"encoded_transaction"`
+	Broadcast          bool   `json:"broadcast"`
+}
+
+// walletApprovalApproveHandler approves a pending approval of a wallet with the approver password,
+// and returns its transaction, broadcasting it if requested. The pending approval is removed.
+// Method: POST
+// URI: /api/v2/wallet/approval/approve
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	id [string]: pending approval id [required]
+//	approver_password [string]: approver password [required]
+//	broadcast [bool]: broadcast the transaction, which must be fully signed
+func walletApprovalApproveHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletApprovalRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.ApproverPassword = ""
+		}()
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := gateway.VerifyWalletApproverPassword(req.WalletID, []byte(req.ApproverPassword)); err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		p, err := gateway.GetPendingApproval(req.WalletID, req.ID)
+		if err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		txn, err := p.Transaction()
+		if err != nil {
+			writeHTTPResponse(w, NewHTTPErrorResponse(http.StatusInternalServerError, err.Error()))
+			return
+		}
+
+		// Broadcast before removing the pending approval, so that it can be approved again if broadcasting fails
+		if req.Broadcast {
+			if !txn.IsFullySigned() {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "transaction is not fully signed and cannot be broadcast")
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			if err := gateway.InjectBroadcastTransaction(r.Context(), *txn); err != nil {
+				var resp HTTPResponse
+				switch err.(type) {
+				case visor.ErrTxnViolatesUserConstraint,
+					visor.ErrTxnViolatesHardConstraint,
+					visor.ErrTxnViolatesSoftConstraint:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				default:
+					if daemon.IsBroadcastFailure(err) {
+						resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+					} else {
+						resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+					}
+				}
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		if _, err := gateway.RemovePendingApproval(req.WalletID, req.ID); err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletApprovalApproveResponse{
+				TxID:               p.TxID,
+				EncodedTransaction: p.EncodedTransaction,
+				Broadcast:          req.Broadcast,
+			},
+		})
+	}
+}
+
+// walletApprovalCancelHandler cancels a pending approval of a wallet. The approver password is not required,
+// since the transaction is only dropped.
+// Method: POST
+// URI: /api/v2/wallet/approval/cancel
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	id [string]: pending approval id [required]
+func walletApprovalCancelHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletApprovalRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if _, err := gateway.RemovePendingApproval(req.WalletID, req.ID); err != nil {
+			writeHTTPResponse(w, walletApprovalErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: struct{}{},
+		})
+	}
+}
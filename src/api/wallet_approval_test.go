@@ -0,0 +1,669 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// newApprovalMockGatewayer returns a MockGatewayer whose wallets have no access token
+// and whose foo.wlt has an approval policy of policy
+func newApprovalMockGatewayer(policy *wallet.ApprovalPolicy) *MockGatewayer {
+	gateway := &MockGatewayer{}
+	gateway.On("VerifyWalletAccessToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+	gateway.On("WalletApprovalPolicy", "foo.wlt").Return(policy, nil).Maybe()
+	gateway.On("WalletAddressReuse", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	return gateway
+}
+
+func makeApprovalWallet(t *testing.T) (wallet.Wallet, cipher.Address) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Type:      wallet.WalletTypeDeterministic,
+		Seed:      "seed",
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+
+	addrs, err := w.GetSkycoinAddresses()
+	require.NoError(t, err)
+	return w, addrs[1]
+}
+
+func TestWalletSpendAmount(t *testing.T) {
+	w, change := makeApprovalWallet(t)
+
+	txn := &coin.Transaction{
+		Out: []coin.TransactionOutput{
+			{Address: testutil.MakeAddress(), Coins: 2e6},
+			{Address: change, Coins: 7e6},
+			{Address: testutil.MakeAddress(), Coins: 1e6},
+		},
+	}
+
+	amount, err := walletSpendAmount(w, txn)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3e6), amount)
+
+	txn.Out = txn.Out[1:2]
+	amount, err = walletSpendAmount(w, txn)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), amount)
+}
+
+func TestWalletSignTransactionApproval(t *testing.T) {
+	w, change := makeApprovalWallet(t)
+
+	signedTxn := coin.Transaction{
+		Sigs: []cipher.Sig{testutil.RandSig(t)},
+		In:   []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{Address: testutil.MakeAddress(), Coins: 2e6, Hours: 10},
+			{Address: change, Coins: 8e6, Hours: 10},
+		},
+	}
+	txn := signedTxn
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        change,
+					Coins:          10e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 100,
+		},
+	}
+
+	pending := &kvstorage.PendingApproval{
+		ID:                 "abc",
+		WalletID:           "foo.wlt",
+		TxID:               signedTxn.Hash().Hex(),
+		EncodedTransaction: signedTxn.MustSerializeHex(),
+		Amount:             2e6,
+		CreatedAt:          100,
+		ExpiresAt:          3700,
+	}
+
+	cases := []struct {
+		name         string
+		policy       *wallet.ApprovalPolicy
+		addErr       error
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name:   "200 - no policy",
+			status: http.StatusOK,
+		},
+		{
+			name:   "200 - below threshold",
+			policy: &wallet.ApprovalPolicy{Threshold: 2e6, Window: time.Hour},
+			status: http.StatusOK,
+		},
+		{
+			name:   "202 - above threshold",
+			policy: &wallet.ApprovalPolicy{Threshold: 2e6 - 1, Window: time.Hour},
+			status: http.StatusAccepted,
+			httpResponse: HTTPResponse{
+				Data: pending,
+			},
+		},
+		{
+			name:         "404 - storage not loaded",
+			policy:       &wallet.ApprovalPolicy{Threshold: 1e6, Window: time.Hour},
+			addErr:       kvstorage.ErrNoSuchStorage,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet approvals storage is not loaded"),
+		},
+		{
+			name:         "409 - too many pending approvals",
+			policy:       &wallet.ApprovalPolicy{Threshold: 1e6, Window: time.Hour},
+			addErr:       kvstorage.ErrTooManyPendingApprovals,
+			status:       http.StatusConflict,
+			httpResponse: NewHTTPErrorResponse(http.StatusConflict, kvstorage.ErrTooManyPendingApprovals.Error()),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newApprovalMockGatewayer(tc.policy)
+			gateway.On("WalletSignTransaction", mock.Anything, "foo.wlt", []byte("pwd"), &txn, []int(nil)).Return(&signedTxn, inputs, nil)
+			gateway.On("GetWallet", "foo.wlt").Return(w, nil)
+			gateway.On("AddPendingApproval", "foo.wlt", &signedTxn, uint64(2e6), time.Hour).Return(pending, tc.addErr)
+
+			body, err := json.Marshal(WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				Password:           "pwd",
+				EncodedTransaction: txn.MustSerializeHex(),
+			})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/wallet/transaction/sign", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			switch tc.status {
+			case http.StatusOK:
+				var data CreateTransactionResponse
+				require.NoError(t, json.Unmarshal(rsp.Data, &data))
+				require.Equal(t, signedTxn.Hash().Hex(), data.Transaction.TxID)
+				gateway.AssertNotCalled(t, "AddPendingApproval", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			case http.StatusAccepted:
+				var data kvstorage.PendingApproval
+				require.NoError(t, json.Unmarshal(rsp.Data, &data))
+				require.Equal(t, *pending, data)
+			}
+		})
+	}
+}
+
+func TestWalletCreateTransactionApproval(t *testing.T) {
+	w, _ := makeApprovalWallet(t)
+
+	txn := coin.Transaction{
+		Sigs: []cipher.Sig{testutil.RandSig(t)},
+		In:   []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{Address: testutil.MakeAddress(), Coins: 5e6, Hours: 10},
+		},
+	}
+
+	pending := &kvstorage.PendingApproval{
+		ID:                 "abc",
+		WalletID:           "foo.wlt",
+		TxID:               txn.Hash().Hex(),
+		EncodedTransaction: txn.MustSerializeHex(),
+		Amount:             5e6,
+		CreatedAt:          100,
+		ExpiresAt:          700,
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        testutil.MakeAddress(),
+					Coins:          5e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 100,
+		},
+	}
+
+	body := `{"hours_selection":{"type":"manual"},"wallet_id":"foo.wlt","to":[{"address":"` + txn.Out[0].Address.String() + `","coins":"5","hours":"10"}]}`
+
+	var req walletCreateTransactionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	gateway := newApprovalMockGatewayer(&wallet.ApprovalPolicy{Threshold: 1e6, Window: 10 * time.Minute})
+	gateway.On("WalletCreateTransactionSigned", mock.Anything, "foo.wlt", []byte(""), req.TransactionParams(), req.VisorParams()).Return(&txn, inputs, nil)
+	gateway.On("GetWallet", "foo.wlt").Return(w, nil)
+	gateway.On("AddPendingApproval", "foo.wlt", &txn, uint64(5e6), 10*time.Minute).Return(pending, nil)
+
+	r, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/transaction", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Add("Content-Type", ContentTypeJSON)
+	setCSRFParameters(t, tokenValid, r)
+
+	rr := httptest.NewRecorder()
+	newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, r)
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+	var data kvstorage.PendingApproval
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &data))
+	require.Equal(t, *pending, data)
+}
+
+func TestWalletApprovalPolicy(t *testing.T) {
+	validBody := &WalletApprovalPolicyRequest{
+		WalletID:         "foo.wlt",
+		Password:         "pwd",
+		Threshold:        "100",
+		Window:           "24h",
+		ApproverPassword: "approver",
+	}
+
+	cases := []struct {
+		name         string
+		method       string
+		body         *WalletApprovalPolicyRequest
+		rawBody      string
+		gatewayErr   error
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - invalid json",
+			method:       http.MethodPost,
+			rawBody:      "{ca",
+			status:       http.StatusBadRequest,
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: "invalid JSON at offset 2: invalid character 'c' looking for beginning of object key string", Offset: 2}),
+		},
+		{
+			name:         "400 - missing wallet id",
+			method:       http.MethodPost,
+			body:         &WalletApprovalPolicyRequest{},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+		{
+			name:   "400 - invalid threshold",
+			method: http.MethodPost,
+			body: &WalletApprovalPolicyRequest{
+				WalletID:  "foo.wlt",
+				Threshold: "x",
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid threshold: can't convert x to decimal"),
+		},
+		{
+			name:   "400 - invalid window",
+			method: http.MethodPost,
+			body: &WalletApprovalPolicyRequest{
+				WalletID:  "foo.wlt",
+				Threshold: "1",
+				Window:    "x",
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid window: time: invalid duration "x"`),
+		},
+		{
+			name:         "400 - same passwords",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrApproverPasswordIsWalletPassword,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "approver password must differ from the wallet password"),
+		},
+		{
+			name:         "404 - wallet not found",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body:   validBody,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("SetWalletApprovalPolicy", "foo.wlt", []byte("pwd"), []byte("approver"), []byte(""), uint64(100e6), 24*time.Hour).Return(tc.gatewayErr)
+
+			body := []byte(tc.rawBody)
+			if len(body) == 0 {
+				var err error
+				body, err = json.Marshal(tc.body)
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v2/wallet/approval/policy", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var data WalletApprovalPolicyResponse
+			require.NoError(t, json.Unmarshal(rsp.Data, &data))
+			require.Equal(t, WalletApprovalPolicyResponse{
+				Threshold: "100.000000",
+				Window:    "24h0m0s",
+			}, data)
+		})
+	}
+}
+
+func TestWalletApprovalPolicyRemove(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		gatewayErr   error
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "400 - invalid approver password",
+			gatewayErr:   wallet.ErrInvalidApproverPassword,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid approver password"),
+		},
+		{
+			name:         "400 - no policy",
+			gatewayErr:   wallet.ErrWalletApprovalPolicyNotSet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet has no approval policy"),
+		},
+		{
+			name:   "200",
+			status: http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("RemoveWalletApprovalPolicy", "foo.wlt", []byte("pwd"), []byte("approver")).Return(tc.gatewayErr)
+
+			body := `{"wallet_id":"foo.wlt","password":"pwd","approver_password":"approver"}`
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/wallet/approval/policy/remove", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+		})
+	}
+}
+
+func TestWalletApprovals(t *testing.T) {
+	approvals := []kvstorage.PendingApproval{
+		{
+			ID:        "abc",
+			WalletID:  "foo.wlt",
+			Amount:    5e6,
+			CreatedAt: 100,
+			ExpiresAt: 200,
+		},
+	}
+
+	cases := []struct {
+		name         string
+		query        string
+		policy       *wallet.ApprovalPolicy
+		approvalsErr error
+		status       int
+		httpResponse HTTPResponse
+		expected     WalletApprovalsResponse
+	}{
+		{
+			name:         "400 - missing wallet id",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "missing wallet id"),
+		},
+		{
+			name:         "403 - storage api disabled",
+			query:        "?id=foo.wlt",
+			approvalsErr: kvstorage.ErrStorageAPIDisabled,
+			status:       http.StatusForbidden,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, "storage api is disabled, approvals are unavailable"),
+		},
+		{
+			name:   "200 - no policy",
+			query:  "?id=foo.wlt",
+			status: http.StatusOK,
+			expected: WalletApprovalsResponse{
+				Approvals: approvals,
+			},
+		},
+		{
+			name:   "200",
+			query:  "?id=foo.wlt",
+			policy: &wallet.ApprovalPolicy{Threshold: 1e6, Window: time.Hour},
+			status: http.StatusOK,
+			expected: WalletApprovalsResponse{
+				Policy: &WalletApprovalPolicyResponse{
+					Threshold: "1.000000",
+					Window:    "1h0m0s",
+				},
+				Approvals: approvals,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newApprovalMockGatewayer(tc.policy)
+			gateway.On("GetPendingApprovals", "foo.wlt").Return(approvals, tc.approvalsErr)
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v2/wallet/approvals"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var data WalletApprovalsResponse
+			require.NoError(t, json.Unmarshal(rsp.Data, &data))
+			require.Equal(t, tc.expected, data)
+		})
+	}
+}
+
+func TestWalletApprovalApprove(t *testing.T) {
+	signedTxn := coin.Transaction{
+		Sigs: []cipher.Sig{testutil.RandSig(t)},
+		In:   []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{Address: testutil.MakeAddress(), Coins: 5e6, Hours: 10},
+		},
+	}
+	unsignedTxn := signedTxn
+	unsignedTxn.Sigs = make([]cipher.Sig, 1)
+
+	newPending := func(txn coin.Transaction) *kvstorage.PendingApproval {
+		return &kvstorage.PendingApproval{
+			ID:                 "abc",
+			WalletID:           "foo.wlt",
+			TxID:               txn.Hash().Hex(),
+			EncodedTransaction: txn.MustSerializeHex(),
+			Amount:             5e6,
+		}
+	}
+
+	cases := []struct {
+		name         string
+		body         string
+		verifyErr    error
+		pending      *kvstorage.PendingApproval
+		pendingErr   error
+		injectErr    error
+		status       int
+		httpResponse HTTPResponse
+		injected     bool
+	}{
+		{
+			name:         "400 - missing id",
+			body:         `{"wallet_id":"foo.wlt"}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - invalid approver password",
+			body:         `{"wallet_id":"foo.wlt","id":"abc","approver_password":"approver"}`,
+			verifyErr:    wallet.ErrInvalidApproverPassword,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid approver password"),
+		},
+		{
+			name:         "404 - no such pending approval",
+			body:         `{"wallet_id":"foo.wlt","id":"abc","approver_password":"approver"}`,
+			pendingErr:   kvstorage.ErrNoSuchPendingApproval,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "pending approval does not exist"),
+		},
+		{
+			name:         "400 - broadcast unsigned transaction",
+			body:         `{"wallet_id":"foo.wlt","id":"abc","approver_password":"approver","broadcast":true}`,
+			pending:      newPending(unsignedTxn),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "transaction is not fully signed and cannot be broadcast"),
+		},
+		{
+			name:         "500 - broadcast failed",
+			body:         `{"wallet_id":"foo.wlt","id":"abc","approver_password":"approver","broadcast":true}`,
+			pending:      newPending(signedTxn),
+			injectErr:    errors.New("failed"),
+			status:       http.StatusInternalServerError,
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+			injected:     true,
+		},
+		{
+			name:    "200",
+			body:    `{"wallet_id":"foo.wlt","id":"abc","approver_password":"approver"}`,
+			pending: newPending(unsignedTxn),
+			status:  http.StatusOK,
+		},
+		{
+			name:     "200 - broadcast",
+			body:     `{"wallet_id":"foo.wlt","id":"abc","approver_password":"approver","broadcast":true}`,
+			pending:  newPending(signedTxn),
+			status:   http.StatusOK,
+			injected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("VerifyWalletApproverPassword", "foo.wlt", []byte("approver")).Return(tc.verifyErr)
+			gateway.On("GetPendingApproval", "foo.wlt", "abc").Return(tc.pending, tc.pendingErr)
+			gateway.On("InjectBroadcastTransaction", mock.Anything, signedTxn).Return(tc.injectErr)
+			gateway.On("RemovePendingApproval", "foo.wlt", "abc").Return(tc.pending, nil)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/wallet/approval/approve", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.injected {
+				gateway.AssertCalled(t, "InjectBroadcastTransaction", mock.Anything, signedTxn)
+			} else {
+				gateway.AssertNotCalled(t, "InjectBroadcastTransaction", mock.Anything, mock.Anything)
+			}
+
+			if tc.status != http.StatusOK {
+				gateway.AssertNotCalled(t, "RemovePendingApproval", mock.Anything, mock.Anything)
+				return
+			}
+
+			var data WalletApprovalApproveResponse
+			require.NoError(t, json.Unmarshal(rsp.Data, &data))
+			require.Equal(t, WalletApprovalApproveResponse{
+				TxID:               tc.pending.TxID,
+				EncodedTransaction: tc.pending.EncodedTransaction,
+				Broadcast:          tc.injected,
+			}, data)
+			gateway.AssertCalled(t, "RemovePendingApproval", "foo.wlt", "abc")
+		})
+	}
+}
+
+func TestWalletApprovalCancel(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		body         string
+		removeErr    error
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "400 - missing wallet id",
+			body:         `{"id":"abc"}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+		{
+			name:         "404 - no such pending approval",
+			body:         `{"wallet_id":"foo.wlt","id":"abc"}`,
+			removeErr:    kvstorage.ErrNoSuchPendingApproval,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "pending approval does not exist"),
+		},
+		{
+			name:   "200",
+			body:   `{"wallet_id":"foo.wlt","id":"abc"}`,
+			status: http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("RemovePendingApproval", "foo.wlt", "abc").Return(nil, tc.removeErr)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/wallet/approval/cancel", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+		})
+	}
+}
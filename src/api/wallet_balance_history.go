@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletBalanceHistoryResponse is returned by GET /api/v1/wallet/balance/history
+type WalletBalanceHistoryResponse struct {
+	// HeadSeq is the seq of the head block the history was computed at
+	HeadSeq     uint64 `json:"head_seq"`
+	Granularity string `json:"granularity"`
+	// Points are [timestamp, coins, hours], with the coins in droplets
+	Points [][3]uint64 `json:"points"`
+}
+
+// NewWalletBalanceHistoryResponse creates a WalletBalanceHistoryResponse
+func NewWalletBalanceHistoryResponse(headSeq uint64, g visor.BalanceHistoryGranularity, points []visor.BalanceHistoryPoint) WalletBalanceHistoryResponse {
+	r := WalletBalanceHistoryResponse{
+		HeadSeq:     headSeq,
+		Granularity: string(g),
+		Points:      make([][3]uint64, len(points)),
+	}
+
+	for i, p := range points {
+		r.Points[i] = [3]uint64{p.Time, p.Coins, p.Hours}
+	}
+
+	return r
+}
+
+// Returns the confirmed balance of a wallet over time, computed from the transactions of its addresses.
+// A point has the balance after the last block touching the wallet's addresses in its period,
+// its timestamp is the time of the block with the "block" granularity, or the start of the UTC day or week.
+// Requests with more than maxEntries wallet transactions in the time range are rejected with 413.
+// URI: /api/v1/wallet/balance/history
+// Method: GET
+// Args:
+//
+//	id: wallet id [required]
+//	granularity: "day", "week" or "block" [optional, defaults to "day"]
+//	from: unix time of the first point [optional]
+//	to: unix time of the last point [optional]
+func walletBalanceHistoryHandler(gateway Gatewayer, maxEntries int) http.HandlerFunc {
+	if maxEntries == 0 {
+		maxEntries = defaultMaxBalanceHistoryEntries
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		g := visor.BalanceHistoryDay
+		if s := r.FormValue("granularity"); s != "" {
+			var err error
+			g, err = visor.BalanceHistoryGranularityFromString(s)
+			if err != nil {
+				wh.Error400(w, "Invalid granularity, must be day, week or block")
+				return
+			}
+		}
+
+		var from, to uint64
+		if s := r.FormValue("from"); s != "" {
+			var err error
+			from, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				wh.Error400(w, "Invalid from value")
+				return
+			}
+		}
+		if s := r.FormValue("to"); s != "" {
+			var err error
+			to, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				wh.Error400(w, "Invalid to value")
+				return
+			}
+			if to < from {
+				wh.Error400(w, "from must not be after to")
+				return
+			}
+		}
+
+		h, err := gateway.GetWalletBalanceHistory(wltID)
+		if err != nil {
+			requestLogger(r).Errorf("Get wallet balance history failed: %v", err)
+			switch err {
+			case wallet.ErrWalletNotExist:
+				wh.Error404(w, "")
+			case wallet.ErrWalletAPIDisabled:
+				wh.Error403(w, "")
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		points := visor.FilterBalanceHistory(h.Points, from, to)
+		if n := visor.CountBalanceHistoryTxns(points); n > maxEntries {
+			wh.ErrorXXX(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the wallet has %d history entries in the time range, at most %d are allowed per request, narrow the time range with from and to", n, maxEntries))
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, NewWalletBalanceHistoryResponse(h.HeadSeq, g, visor.GroupBalanceHistory(points, g)))
+	}
+}
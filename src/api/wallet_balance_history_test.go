@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+func TestWalletBalanceHistoryHandler(t *testing.T) {
+	// Tuesday 2018-10-23 14:33:29 UTC
+	tue := uint64(1540305209)
+
+	history := &visor.BalanceHistory{
+		HeadSeq: 20,
+		Points: []visor.BalanceHistoryPoint{
+			{Time: tue, BkSeq: 1, Coins: 1e6, Hours: 10, Txns: 1},
+			{Time: tue + 3600, BkSeq: 2, Coins: 2e6, Hours: 20, Txns: 2},
+			{Time: tue + 86400*6, BkSeq: 3, Coins: 3e6, Hours: 30, Txns: 1},
+		},
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		query      url.Values
+		maxEntries int
+		gateway    bool
+		history    *visor.BalanceHistory
+		gatewayErr error
+		status     int
+		err        string
+		result     *WalletBalanceHistoryResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - invalid granularity",
+			method: http.MethodGet,
+			query:  url.Values{"id": {"foo"}, "granularity": {"month"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid granularity, must be day, week or block",
+		},
+		{
+			name:   "400 - invalid from",
+			method: http.MethodGet,
+			query:  url.Values{"id": {"foo"}, "from": {"-1"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid from value",
+		},
+		{
+			name:   "400 - invalid to",
+			method: http.MethodGet,
+			query:  url.Values{"id": {"foo"}, "to": {"x"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - Invalid to value",
+		},
+		{
+			name:   "400 - from after to",
+			method: http.MethodGet,
+			query:  url.Values{"id": {"foo"}, "from": {"10"}, "to": {"9"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - from must not be after to",
+		},
+		{
+			name:       "404",
+			method:     http.MethodGet,
+			query:      url.Values{"id": {"foo"}},
+			gateway:    true,
+			gatewayErr: wallet.ErrWalletNotExist,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+		},
+		{
+			name:       "500",
+			method:     http.MethodGet,
+			query:      url.Values{"id": {"foo"}},
+			gateway:    true,
+			gatewayErr: errors.New("failed"),
+			status:     http.StatusInternalServerError,
+			err:        "500 Internal Server Error - failed",
+		},
+		{
+			name:       "413 - too many entries",
+			method:     http.MethodGet,
+			query:      url.Values{"id": {"foo"}},
+			maxEntries: 3,
+			gateway:    true,
+			history:    history,
+			status:     http.StatusRequestEntityTooLarge,
+			err:        "413 Request Entity Too Large - the wallet has 4 history entries in the time range, at most 3 are allowed per request, narrow the time range with from and to",
+		},
+		{
+			name:       "200 - narrower time range",
+			method:     http.MethodGet,
+			query:      url.Values{"id": {"foo"}, "granularity": {"block"}, "from": {"1540305210"}},
+			maxEntries: 3,
+			gateway:    true,
+			history:    history,
+			status:     http.StatusOK,
+			result: &WalletBalanceHistoryResponse{
+				HeadSeq:     20,
+				Granularity: "block",
+				Points: [][3]uint64{
+					{tue + 3600, 2e6, 20},
+					{tue + 86400*6, 3e6, 30},
+				},
+			},
+		},
+		{
+			name:    "200 - day",
+			method:  http.MethodGet,
+			query:   url.Values{"id": {"foo"}},
+			gateway: true,
+			history: history,
+			status:  http.StatusOK,
+			result: &WalletBalanceHistoryResponse{
+				HeadSeq:     20,
+				Granularity: "day",
+				Points: [][3]uint64{
+					{1540252800, 2e6, 20},
+					{1540771200, 3e6, 30},
+				},
+			},
+		},
+		{
+			name:    "200 - week",
+			method:  http.MethodGet,
+			query:   url.Values{"id": {"foo"}, "granularity": {"week"}, "to": {"1540400000"}},
+			gateway: true,
+			history: history,
+			status:  http.StatusOK,
+			result: &WalletBalanceHistoryResponse{
+				HeadSeq:     20,
+				Granularity: "week",
+				Points: [][3]uint64{
+					{1540166400, 2e6, 20},
+				},
+			},
+		},
+		{
+			name:    "200 - no history",
+			method:  http.MethodGet,
+			query:   url.Values{"id": {"foo"}},
+			gateway: true,
+			history: &visor.BalanceHistory{
+				HeadSeq: 20,
+			},
+			status: http.StatusOK,
+			result: &WalletBalanceHistoryResponse{
+				HeadSeq:     20,
+				Granularity: "day",
+				Points:      [][3]uint64{},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			if tc.gateway {
+				gateway.On("GetWalletBalanceHistory", "foo").Return(tc.history, tc.gatewayErr)
+			}
+
+			endpoint := "/api/v1/wallet/balance/history"
+			if len(tc.query) > 0 {
+				endpoint += "?" + tc.query.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			cfg := defaultMuxConfig()
+			cfg.maxBalanceHistoryEntries = tc.maxEntries
+
+			rr := httptest.NewRecorder()
+			newServerMux(cfg, gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp WalletBalanceHistoryResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, tc.result, &resp)
+		})
+	}
+}
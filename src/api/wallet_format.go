@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletFormatStatusResponse is returned by GET /api/v1/wallets/format-status
+type WalletFormatStatusResponse struct {
+	// CurrentVersion is the version of wallet files in the current format
+	CurrentVersion string               `json:"current_version"`
+	Wallets        []WalletFormatStatus `json:"wallets"`
+}
+
+// WalletFormatStatus is the format of a wallet file and the migrations that would upgrade it
+type WalletFormatStatus struct {
+	WalletID  string `json:"wallet_id"`
+	Version   string `json:"version"`
+	Encrypted bool   `json:"encrypted"`
+	// NeedsUnlock is true if a migration needs the wallet password
+	NeedsUnlock bool                    `json:"needs_unlock"`
+	Migrations  []WalletFormatMigration `json:"migrations"`
+	// Error is why the wallet file could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// WalletFormatMigration is a migration that would run on a wallet file
+type WalletFormatMigration struct {
+	Name string `json:"name"`
+	// NeedsUnlock is true if the wallet is encrypted and the migration needs its seed
+	NeedsUnlock bool `json:"needs_unlock"`
+}
+
+// NewWalletFormatStatusResponse creates a WalletFormatStatusResponse
+func NewWalletFormatStatusResponse(ss []wallet.WalletFormatStatus) WalletFormatStatusResponse {
+	r := WalletFormatStatusResponse{
+		CurrentVersion: wallet.Version,
+		Wallets:        make([]WalletFormatStatus, len(ss)),
+	}
+
+	for i, s := range ss {
+		ms := make([]WalletFormatMigration, len(s.Migrations))
+		for j, m := range s.Migrations {
+			ms[j] = WalletFormatMigration{
+				Name:        string(m.Migration),
+				NeedsUnlock: m.NeedsUnlock,
+			}
+		}
+
+		r.Wallets[i] = WalletFormatStatus{
+			WalletID:    s.WalletID,
+			Version:     s.Version,
+			Encrypted:   s.Encrypted,
+			NeedsUnlock: s.NeedsUnlock(),
+			Migrations:  ms,
+			Error:       s.Error,
+		}
+	}
+
+	return r
+}
+
+// WalletFormatMigrateResponse is returned by POST /api/v1/wallets/migrate
+type WalletFormatMigrateResponse struct {
+	WalletID string `json:"wallet_id"`
+	// Version is the version of the wallet file after the migrations
+	Version string `json:"version"`
+	// Migrated are the names of the migrations that were run
+	Migrated []string `json:"migrated"`
+	// Backup is the filename of the backup made before the migrations, in the backups directory of the wallet directory
+	Backup string `json:"backup,omitempty"`
+}
+
+// Returns the format version of every wallet file, the current version,
+// and the migrations that would upgrade each wallet file to the current format.
+// A migration that needs the password of an encrypted wallet is marked needs_unlock.
+// Wallets with an access token are only listed if the request carries their token.
+// URI: /api/v1/wallets/format-status
+// Method: GET
+func walletFormatStatusHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		ss, err := gateway.WalletFormatStatus()
+		if err != nil {
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				wh.Error403(w, "")
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		allowed := ss[:0]
+		for _, s := range ss {
+			if walletTokenAllowed(gateway, s.WalletID, r) {
+				allowed = append(allowed, s)
+			}
+		}
+
+		wh.SendJSONOr500(logger, w, NewWalletFormatStatusResponse(allowed))
+	}
+}
+
+// Runs the migrations that upgrade a wallet file to the current format, backing up the wallet file first.
+// The password is required if the wallet is encrypted and a migration needs its seed.
+// URI: /api/v1/wallets/migrate
+// Method: POST
+// Args:
+//
+//	id: wallet id [required]
+//	password: wallet password [optional]
+func walletFormatMigrateHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		password := r.FormValue("password")
+		defer func() {
+			password = ""
+		}()
+
+		res, err := gateway.MigrateWalletFormat(id, []byte(password))
+		if err != nil {
+			writeWalletMetadataError(w, err)
+			return
+		}
+
+		migrated := make([]string, len(res.Migrated))
+		for i, m := range res.Migrated {
+			migrated[i] = string(m)
+		}
+
+		wh.SendJSONOr500(logger, w, WalletFormatMigrateResponse{
+			WalletID: res.WalletID,
+			Version:  res.Version,
+			Migrated: migrated,
+			Backup:   res.Backup,
+		})
+	}
+}
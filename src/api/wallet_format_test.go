@@ -0,0 +1,257 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletFormatStatusHandler(t *testing.T) {
+	statuses := []wallet.WalletFormatStatus{
+		{
+			WalletID: "current.wlt",
+			Version:  wallet.Version,
+		},
+		{
+			WalletID:  "old.wlt",
+			Version:   "0.2",
+			Encrypted: true,
+			Migrations: []wallet.WalletFormatMigration{
+				{Migration: wallet.MigrationMetadataKey, NeedsUnlock: true},
+				{Migration: wallet.MigrationVersion},
+			},
+		},
+		{
+			WalletID: "broken.wlt",
+			Error:    "invalid wallet",
+		},
+	}
+
+	tt := []struct {
+		name      string
+		method    string
+		status    int
+		err       string
+		statuses  []wallet.WalletFormatStatus
+		statusErr error
+		response  WalletFormatStatusResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:      "403 - wallet API disabled",
+			method:    http.MethodGet,
+			status:    http.StatusForbidden,
+			err:       "403 Forbidden",
+			statusErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:      "500 - other error",
+			method:    http.MethodGet,
+			status:    http.StatusInternalServerError,
+			err:       "500 Internal Server Error - permission denied",
+			statusErr: errors.New("permission denied"),
+		},
+		{
+			name:     "200",
+			method:   http.MethodGet,
+			status:   http.StatusOK,
+			statuses: statuses,
+			response: WalletFormatStatusResponse{
+				CurrentVersion: wallet.Version,
+				Wallets: []WalletFormatStatus{
+					{
+						WalletID:   "current.wlt",
+						Version:    wallet.Version,
+						Migrations: []WalletFormatMigration{},
+					},
+					{
+						WalletID:    "old.wlt",
+						Version:     "0.2",
+						Encrypted:   true,
+						NeedsUnlock: true,
+						Migrations: []WalletFormatMigration{
+							{Name: "metadata-key", NeedsUnlock: true},
+							{Name: "version"},
+						},
+					},
+					{
+						WalletID:   "broken.wlt",
+						Migrations: []WalletFormatMigration{},
+						Error:      "invalid wallet",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("WalletFormatStatus").Return(tc.statuses, tc.statusErr)
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallets/format-status", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp WalletFormatStatusResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, resp)
+		})
+	}
+}
+
+func TestWalletFormatMigrateHandler(t *testing.T) {
+	tt := []struct {
+		name       string
+		method     string
+		body       url.Values
+		status     int
+		err        string
+		migrateRes *wallet.WalletFormatMigrationResult
+		migrateErr error
+		response   WalletFormatMigrateResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			body:   url.Values{},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - needs password",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - the migrations of the encrypted wallet need the wallet password",
+			migrateErr: wallet.ErrWalletMigrationNeedsPassword,
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":       {"foo.wlt"},
+				"password": {"wrong"},
+			},
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - invalid password",
+			migrateErr: wallet.ErrInvalidPassword,
+		},
+		{
+			name:   "403 - wallet API disabled",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden",
+			migrateErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:   "404 - wallet not found",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+			migrateErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":       {"foo.wlt"},
+				"password": {"pwd"},
+			},
+			status: http.StatusOK,
+			migrateRes: &wallet.WalletFormatMigrationResult{
+				WalletID: "foo.wlt",
+				Version:  wallet.Version,
+				Migrated: []wallet.WalletMigration{wallet.MigrationMetadataKey, wallet.MigrationVersion},
+				Backup:   "foo.1550000000000000000.wlt",
+			},
+			response: WalletFormatMigrateResponse{
+				WalletID: "foo.wlt",
+				Version:  wallet.Version,
+				Migrated: []string{"metadata-key", "version"},
+				Backup:   "foo.1550000000000000000.wlt",
+			},
+		},
+		{
+			name:   "200 - nothing to migrate",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status: http.StatusOK,
+			migrateRes: &wallet.WalletFormatMigrationResult{
+				WalletID: "foo.wlt",
+				Version:  wallet.Version,
+				Migrated: []wallet.WalletMigration{},
+			},
+			response: WalletFormatMigrateResponse{
+				WalletID: "foo.wlt",
+				Version:  wallet.Version,
+				Migrated: []string{},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("MigrateWalletFormat", "foo.wlt", []byte(tc.body.Get("password"))).Return(tc.migrateRes, tc.migrateErr)
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallets/migrate", strings.NewReader(tc.body.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp WalletFormatMigrateResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, resp)
+		})
+	}
+}
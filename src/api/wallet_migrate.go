@@ -0,0 +1,201 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// defaultWalletMigrateScanN is the default number of addresses of the new wallet scanned for balances
+const defaultWalletMigrateScanN = 10
+
+// WalletMigrateResponse is returned by /api/v1/wallet/migrate
+type WalletMigrateResponse struct {
+	DryRun bool `json:"dry_run"`
+	// Wallet is the ID of the migrated wallet, which is kept
+	Wallet string `json:"wallet"`
+	// NewWallet is the ID of the new bip44 wallet, empty in a dry run unless an earlier migration created it
+	NewWallet          string           `json:"new_wallet"`
+	Bip44Coin          bip44.CoinType   `json:"bip44_coin"`
+	NewWalletAddresses []string         `json:"new_wallet_addresses"`
+	NewWalletBalance   readable.Balance `json:"new_wallet_balance"`
+	// Transactions move the confirmed funds of the migrated wallet to the first addresses of the new wallet
+	Transactions []WalletMigrateTransaction `json:"transactions"`
+	// Warnings describe the funds of the migrated wallet that were not moved
+	Warnings []string `json:"warnings"`
+}
+
+// WalletMigrateTransaction is a transaction of a wallet migration
+type WalletMigrateTransaction struct {
+	Txid string `json:"txid"`
+	// Address is the new wallet address that receives the funds
+	Address string `json:"address"`
+	Fee     uint64 `json:"fee,string"`
+	// Injected is true if the transaction was injected and broadcast
+	Injected bool `json:"injected"`
+	// Error is the reason the transaction could not be injected
+	Error       string             `json:"error,omitempty"`
+	Transaction CreatedTransaction `json:"transaction"`
+}
+
+// Migrates a deterministic wallet to a bip44 wallet created from the same mnemonic.
+// The bip44 wallet is scanned for balances, then the confirmed funds of the deterministic wallet are sent to the
+// first addresses of the bip44 wallet in as few transactions as the transaction constraints allow.
+// The deterministic wallet is not deleted. Funds that can't be moved, e.g. outputs spent by pending transactions,
+// are reported in the warnings, and can be moved by migrating again later, which reuses the bip44 wallet.
+// Method: POST
+// URI: /api/v1/wallet/migrate
+// Args:
+//
+//	id: wallet id [required]
+//	to: wallet type to migrate to [optional, only "bip44" is supported]
+//	bip44-coin: BIP44 coin type of the new wallet [optional, defaults to 8000 (skycoin's coin type)]
+//	label: label of the new wallet [optional, defaults to the label of the migrated wallet]
+//	scan: the number of addresses of the new wallet to scan for balances [optional, defaults to 10]
+//	dry-run: plan the migration without creating the wallet or injecting transactions [optional]
+//	password: wallet password [optional, must be provided if the wallet is encrypted]
+func walletMigrateHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		opts := visor.WalletMigrateOptions{
+			To:    r.FormValue("to"),
+			Label: r.FormValue("label"),
+			ScanN: defaultWalletMigrateScanN,
+		}
+
+		if v := r.FormValue("bip44-coin"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				wh.Error400(w, "invalid bip44-coin value")
+				return
+			}
+
+			c := bip44.CoinType(n)
+			opts.Bip44Coin = &c
+		}
+
+		if v := r.FormValue("scan"); v != "" {
+			var err error
+			opts.ScanN, err = strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				wh.Error400(w, "invalid scan value")
+				return
+			}
+		}
+
+		if v := r.FormValue("dry-run"); v != "" {
+			var err error
+			opts.DryRun, err = strconv.ParseBool(v)
+			if err != nil {
+				wh.Error400(w, fmt.Sprintf("invalid dry-run value: %v", err))
+				return
+			}
+		}
+
+		password := r.FormValue("password")
+		defer func() {
+			password = ""
+		}()
+
+		plan, err := gateway.WalletMigrate(id, []byte(password), opts)
+		if err != nil {
+			switch err.(type) {
+			case visor.UserError,
+				visor.ErrTxnViolatesUserConstraint,
+				visor.ErrTxnViolatesHardConstraint,
+				visor.ErrTxnViolatesSoftConstraint:
+				wh.Error400(w, err.Error())
+			default:
+				switch err {
+				case wallet.ErrWalletAPIDisabled:
+					wh.Error403(w, "")
+				case wallet.ErrWalletNotExist:
+					wh.Error404(w, "")
+				case wallet.ErrMissingPassword,
+					wallet.ErrInvalidPassword,
+					wallet.ErrWalletNotEncrypted:
+					wh.Error400(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
+			}
+			return
+		}
+
+		resp := WalletMigrateResponse{
+			DryRun:             opts.DryRun,
+			Wallet:             plan.Wallet,
+			NewWallet:          plan.NewWallet,
+			Bip44Coin:          plan.Bip44Coin,
+			NewWalletAddresses: make([]string, len(plan.NewWalletAddresses)),
+			NewWalletBalance:   readable.Balance(plan.NewWalletBalance),
+			Transactions:       make([]WalletMigrateTransaction, len(plan.Transactions)),
+			Warnings:           plan.Warnings,
+		}
+
+		if resp.Warnings == nil {
+			resp.Warnings = []string{}
+		}
+
+		for i, a := range plan.NewWalletAddresses {
+			resp.NewWalletAddresses[i] = a.String()
+		}
+
+		// The transactions spend distinct outputs, a transaction that fails to inject does not prevent the others
+		var failed int
+		for i, t := range plan.Transactions {
+			inputs := make([]visor.TransactionInput, len(t.Inputs))
+			for j, in := range t.Inputs {
+				inputs[j] = visor.TransactionInput(in)
+			}
+
+			txn, err := NewCreatedTransaction(t.Transaction, inputs)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			mt := WalletMigrateTransaction{
+				Txid:        t.Transaction.Hash().Hex(),
+				Address:     t.Address.String(),
+				Fee:         t.Fee,
+				Transaction: *txn,
+			}
+
+			if !opts.DryRun {
+				if err := gateway.InjectBroadcastTransaction(r.Context(), *t.Transaction); err != nil {
+					requestLogger(r).WithError(err).WithField("txid", mt.Txid).Error("walletMigrateHandler: InjectBroadcastTransaction failed")
+					mt.Error = err.Error()
+					failed++
+				} else {
+					mt.Injected = true
+				}
+			}
+
+			resp.Transactions[i] = mt
+		}
+
+		if failed != 0 {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf("%d transactions could not be injected and their funds were not moved", failed))
+		}
+
+		wh.SendJSONOr500(logger, w, resp)
+	}
+}
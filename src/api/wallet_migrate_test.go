@@ -0,0 +1,285 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletMigrateHandler(t *testing.T) {
+	dests := []cipher.Address{testutil.MakeAddress(), testutil.MakeAddress()}
+
+	makeMigrateTxn := func(dest cipher.Address) visor.WalletMigrateTransaction {
+		ux := coin.UxOut{
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        testutil.MakeAddress(),
+				Coins:          1e6,
+				Hours:          10,
+			},
+		}
+
+		var txn coin.Transaction
+		err := txn.PushInput(ux.Hash())
+		require.NoError(t, err)
+		err = txn.PushOutput(dest, 1e6, 5)
+		require.NoError(t, err)
+		txn.Sigs = make([]cipher.Sig, 1)
+		err = txn.UpdateHeader()
+		require.NoError(t, err)
+
+		return visor.WalletMigrateTransaction{
+			Transaction: &txn,
+			Inputs: []visor.TransactionInput{
+				{
+					UxOut:           ux,
+					CalculatedHours: 10,
+				},
+			},
+			Address: dest,
+			Fee:     5,
+		}
+	}
+
+	migrateTxns := []visor.WalletMigrateTransaction{
+		makeMigrateTxn(dests[0]),
+		makeMigrateTxn(dests[1]),
+	}
+
+	plan := &visor.WalletMigratePlan{
+		Wallet:             "foo.wlt",
+		NewWallet:          "bar.wlt",
+		Bip44Coin:          bip44.CoinTypeSkycoin,
+		NewWalletAddresses: dests,
+		NewWalletBalance: wallet.Balance{
+			Coins: 2e6,
+			Hours: 20,
+		},
+		Transactions: migrateTxns,
+		Warnings:     []string{"1 outputs are spent by pending transactions and were not moved"},
+	}
+
+	responseTxns := make([]WalletMigrateTransaction, len(migrateTxns))
+	for i, mt := range migrateTxns {
+		createdTxn, err := NewCreatedTransaction(mt.Transaction, []visor.TransactionInput{visor.TransactionInput(mt.Inputs[0])})
+		require.NoError(t, err)
+
+		responseTxns[i] = WalletMigrateTransaction{
+			Txid:        mt.Transaction.Hash().Hex(),
+			Address:     mt.Address.String(),
+			Fee:         mt.Fee,
+			Transaction: *createdTxn,
+		}
+	}
+
+	dryRunResponse := WalletMigrateResponse{
+		DryRun:             true,
+		Wallet:             "foo.wlt",
+		NewWallet:          "bar.wlt",
+		Bip44Coin:          bip44.CoinTypeSkycoin,
+		NewWalletAddresses: []string{dests[0].String(), dests[1].String()},
+		NewWalletBalance: readable.Balance{
+			Coins: 2e6,
+			Hours: 20,
+		},
+		Transactions: responseTxns,
+		Warnings:     plan.Warnings,
+	}
+
+	injectedTxns := make([]WalletMigrateTransaction, len(responseTxns))
+	copy(injectedTxns, responseTxns)
+	injectedTxns[0].Injected = true
+	injectedTxns[1].Error = daemon.ErrNetworkingDisabled.Error()
+
+	migrateResponse := dryRunResponse
+	migrateResponse.DryRun = false
+	migrateResponse.Transactions = injectedTxns
+	migrateResponse.Warnings = append(plan.Warnings[:len(plan.Warnings):len(plan.Warnings)], "1 transactions could not be injected and their funds were not moved")
+
+	coinType := bip44.CoinType(1)
+
+	tt := []struct {
+		name          string
+		method        string
+		body          url.Values
+		status        int
+		err           string
+		migrateOpts   visor.WalletMigrateOptions
+		migrateResult *visor.WalletMigratePlan
+		migrateErr    error
+		response      WalletMigrateResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			body:   url.Values{},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - invalid bip44-coin",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":         {"foo.wlt"},
+				"bip44-coin": {"-1"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid bip44-coin value",
+		},
+		{
+			name:   "400 - invalid scan",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":   {"foo.wlt"},
+				"scan": {"foo"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid scan value",
+		},
+		{
+			name:   "400 - invalid dry-run",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":      {"foo.wlt"},
+				"dry-run": {"foo"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid dry-run value: strconv.ParseBool: parsing \"foo\": invalid syntax",
+		},
+		{
+			name:   "400 - not a deterministic wallet",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - Only \"deterministic\" wallets can be migrated",
+			migrateOpts: visor.WalletMigrateOptions{ScanN: defaultWalletMigrateScanN},
+			migrateErr:  visor.ErrMigrateNotDeterministic,
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":       {"foo.wlt"},
+				"password": {"foo"},
+			},
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - invalid password",
+			migrateOpts: visor.WalletMigrateOptions{ScanN: defaultWalletMigrateScanN},
+			migrateErr:  wallet.ErrInvalidPassword,
+		},
+		{
+			name:   "404 - wallet does not exist",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status:      http.StatusNotFound,
+			err:         "404 Not Found",
+			migrateOpts: visor.WalletMigrateOptions{ScanN: defaultWalletMigrateScanN},
+			migrateErr:  wallet.ErrWalletNotExist,
+		},
+		{
+			name:   "500",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status:      http.StatusInternalServerError,
+			err:         "500 Internal Server Error - foo",
+			migrateOpts: visor.WalletMigrateOptions{ScanN: defaultWalletMigrateScanN},
+			migrateErr:  errors.New("foo"),
+		},
+		{
+			name:   "200 - dry run",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":         {"foo.wlt"},
+				"to":         {"bip44"},
+				"bip44-coin": {"1"},
+				"label":      {"bar"},
+				"scan":       {"5"},
+				"dry-run":    {"true"},
+				"password":   {"pwd"},
+			},
+			status: http.StatusOK,
+			migrateOpts: visor.WalletMigrateOptions{
+				To:        "bip44",
+				Bip44Coin: &coinType,
+				Label:     "bar",
+				ScanN:     5,
+				DryRun:    true,
+			},
+			migrateResult: plan,
+			response:      dryRunResponse,
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":       {"foo.wlt"},
+				"password": {"pwd"},
+			},
+			status:        http.StatusOK,
+			migrateOpts:   visor.WalletMigrateOptions{ScanN: defaultWalletMigrateScanN},
+			migrateResult: plan,
+			response:      migrateResponse,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("WalletMigrate", "foo.wlt", []byte(tc.body.Get("password")), tc.migrateOpts).Return(tc.migrateResult, tc.migrateErr)
+			gateway.On("InjectBroadcastTransaction", mock.Anything, *migrateTxns[0].Transaction).Return(nil)
+			gateway.On("InjectBroadcastTransaction", mock.Anything, *migrateTxns[1].Transaction).Return(daemon.ErrNetworkingDisabled)
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallet/migrate", strings.NewReader(tc.body.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp WalletMigrateResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, resp)
+
+			if tc.migrateOpts.DryRun {
+				gateway.AssertNotCalled(t, "InjectBroadcastTransaction", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+// Classification of transaction inputs and outputs as owned by a wallet
+
+import (
+	"github.com/skycoin/skycoin/src/coin"
+
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletTransactionOutput is a transaction output, with whether its address belongs to the wallet
+type WalletTransactionOutput struct {
+	readable.TransactionOutput
+	Owned bool `json:"owned"`
+}
+
+// WalletTransactionInput is a verbose transaction input, with whether its owner belongs to the wallet
+type WalletTransactionInput struct {
+	readable.TransactionInput
+	Owned bool `json:"owned"`
+}
+
+// WalletReadableTransaction is a transaction with the ownership of its outputs by a wallet.
+// The inputs are hashes, their ownership is only resolved in the verbose transaction.
+type WalletReadableTransaction struct {
+	readable.Transaction
+	Out []WalletTransactionOutput `json:"outputs"`
+}
+
+// WalletBlockTransactionVerbose is a verbose transaction with the ownership of its inputs and outputs by a wallet
+type WalletBlockTransactionVerbose struct {
+	readable.BlockTransactionVerbose
+	In  []WalletTransactionInput  `json:"inputs"`
+	Out []WalletTransactionOutput `json:"outputs"`
+}
+
+// WalletTransactionVerbose is a verbose transaction with its status, with the ownership of its inputs and outputs by a wallet
+type WalletTransactionVerbose struct {
+	readable.TransactionVerbose
+	In  []WalletTransactionInput  `json:"inputs"`
+	Out []WalletTransactionOutput `json:"outputs"`
+}
+
+// walletAddresses is the set of a wallet's addresses, which own the inputs and outputs sent from and to them
+type walletAddresses map[string]struct{}
+
+func newWalletAddresses(w wallet.Wallet) walletAddresses {
+	addrs := w.GetAddresses()
+	wa := make(walletAddresses, len(addrs))
+	for _, a := range addrs {
+		wa[a.String()] = struct{}{}
+	}
+	return wa
+}
+
+func (wa walletAddresses) owns(addr string) bool {
+	_, ok := wa[addr]
+	return ok
+}
+
+func (wa walletAddresses) outputs(out []readable.TransactionOutput) []WalletTransactionOutput {
+	wOut := make([]WalletTransactionOutput, len(out))
+	for i, o := range out {
+		wOut[i] = WalletTransactionOutput{
+			TransactionOutput: o,
+			Owned:             wa.owns(o.Address),
+		}
+	}
+	return wOut
+}
+
+func (wa walletAddresses) inputs(in []readable.TransactionInput) []WalletTransactionInput {
+	wIn := make([]WalletTransactionInput, len(in))
+	for i, o := range in {
+		wIn[i] = WalletTransactionInput{
+			TransactionInput: o,
+			Owned:            wa.owns(o.Address),
+		}
+	}
+	return wIn
+}
+
+// The annotated inputs and outputs replace the transaction's, which are cleared
+// so that a decoded response equals the encoded one
+
+func (wa walletAddresses) transaction(txn readable.Transaction) WalletReadableTransaction {
+	out := wa.outputs(txn.Out)
+	txn.Out = nil
+	return WalletReadableTransaction{
+		Transaction: txn,
+		Out:         out,
+	}
+}
+
+func (wa walletAddresses) blockTransactionVerbose(txn readable.BlockTransactionVerbose) WalletBlockTransactionVerbose {
+	in := wa.inputs(txn.In)
+	out := wa.outputs(txn.Out)
+	txn.In = nil
+	txn.Out = nil
+	return WalletBlockTransactionVerbose{
+		BlockTransactionVerbose: txn,
+		In:                      in,
+		Out:                     out,
+	}
+}
+
+func (wa walletAddresses) transactionVerbose(txn readable.TransactionVerbose) WalletTransactionVerbose {
+	in := wa.inputs(txn.In)
+	out := wa.outputs(txn.Out)
+	txn.In = nil
+	txn.Out = nil
+	return WalletTransactionVerbose{
+		TransactionVerbose: txn,
+		In:                 in,
+		Out:                out,
+	}
+}
+
+// transactionFeeHours returns the coin hour fee of a transaction, calculated from its inputs as for a verbose transaction
+func transactionFeeHours(txn coin.Transaction, inputs []visor.TransactionInput, isGenesis bool) (uint64, error) {
+	v, err := readable.NewBlockTransactionVerbose(txn, inputs, isGenesis)
+	if err != nil {
+		return 0, err
+	}
+	return v.Fee, nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletTransactionsHandlerOwnership(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Type:      wallet.WalletTypeDeterministic,
+		Seed:      "seed",
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+	addrs, err := w.GetSkycoinAddresses()
+	require.NoError(t, err)
+
+	external := testutil.MakeAddress()
+
+	// The first transaction spends an output of the wallet, sending coins to an external address
+	// and the change to the wallet. The second transaction receives coins from an external address.
+	inputs := [][]visor.TransactionInput{
+		{
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: testutil.RandSHA256(t),
+						Address:        addrs[0],
+						Coins:          1e6,
+						Hours:          100,
+					},
+				},
+				CalculatedHours: 100,
+			},
+		},
+		{
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: testutil.RandSHA256(t),
+						Address:        external,
+						Coins:          2e6,
+						Hours:          40,
+					},
+				},
+				CalculatedHours: 40,
+			},
+		},
+	}
+	txns := []visor.UnconfirmedTransaction{
+		{
+			Transaction: coin.Transaction{
+				In: []cipher.SHA256{inputs[0][0].UxOut.Hash()},
+				Out: []coin.TransactionOutput{
+					{Address: external, Coins: 4e5, Hours: 20},
+					{Address: addrs[1], Coins: 6e5, Hours: 30},
+				},
+			},
+		},
+		{
+			Transaction: coin.Transaction{
+				In: []cipher.SHA256{inputs[1][0].UxOut.Hash()},
+				Out: []coin.TransactionOutput{
+					{Address: addrs[0], Coins: 2e6, Hours: 10},
+				},
+			},
+		},
+	}
+
+	newRequest := func(verbose bool) *http.Request {
+		endpoint := "/api/v1/wallet/transactions?id=foo.wlt"
+		if verbose {
+			endpoint += "&verbose=1"
+		}
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		require.NoError(t, err)
+		setCSRFParameters(t, tokenValid, req)
+		return req
+	}
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		gateway := newWalletMockGatewayer()
+		gateway.On("GetWalletUnconfirmedTransactionsVerbose", "foo.wlt").Return(txns, inputs, nil)
+		gateway.On("GetWallet", "foo.wlt").Return(w, nil)
+		gateway.On("GetWalletTxNotes", "foo.wlt").Return(map[cipher.SHA256]string{}, nil)
+
+		rr := httptest.NewRecorder()
+		handler := newServerMux(defaultMuxConfig(), gateway)
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr
+	}
+
+	t.Run("not verbose", func(t *testing.T) {
+		rr := serve(newRequest(false))
+
+		var msg UnconfirmedTxnsResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &msg)
+		require.NoError(t, err)
+		require.Len(t, msg.Transactions, 2)
+
+		require.Equal(t, uint64(50), msg.Transactions[0].FeeHours)
+		require.Len(t, msg.Transactions[0].Transaction.Out, 2)
+		require.Equal(t, external.String(), msg.Transactions[0].Transaction.Out[0].Address)
+		require.False(t, msg.Transactions[0].Transaction.Out[0].Owned)
+		require.Equal(t, addrs[1].String(), msg.Transactions[0].Transaction.Out[1].Address)
+		require.True(t, msg.Transactions[0].Transaction.Out[1].Owned)
+
+		require.Equal(t, uint64(30), msg.Transactions[1].FeeHours)
+		require.Len(t, msg.Transactions[1].Transaction.Out, 1)
+		require.True(t, msg.Transactions[1].Transaction.Out[0].Owned)
+	})
+
+	t.Run("verbose", func(t *testing.T) {
+		rr := serve(newRequest(true))
+
+		var msg UnconfirmedTxnsVerboseResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &msg)
+		require.NoError(t, err)
+		require.Len(t, msg.Transactions, 2)
+
+		require.Equal(t, uint64(50), msg.Transactions[0].FeeHours)
+		require.Equal(t, uint64(50), msg.Transactions[0].Transaction.Fee)
+		require.Len(t, msg.Transactions[0].Transaction.In, 1)
+		require.Equal(t, addrs[0].String(), msg.Transactions[0].Transaction.In[0].Address)
+		require.True(t, msg.Transactions[0].Transaction.In[0].Owned)
+		require.Len(t, msg.Transactions[0].Transaction.Out, 2)
+		require.False(t, msg.Transactions[0].Transaction.Out[0].Owned)
+		require.True(t, msg.Transactions[0].Transaction.Out[1].Owned)
+
+		require.Equal(t, uint64(30), msg.Transactions[1].FeeHours)
+		require.Len(t, msg.Transactions[1].Transaction.In, 1)
+		require.Equal(t, external.String(), msg.Transactions[1].Transaction.In[0].Address)
+		require.False(t, msg.Transactions[1].Transaction.In[0].Owned)
+		require.True(t, msg.Transactions[1].Transaction.Out[0].Owned)
+	})
+
+	t.Run("wallet doesn't exist", func(t *testing.T) {
+		gateway := newWalletMockGatewayer()
+		gateway.On("GetWalletUnconfirmedTransactionsVerbose", "foo.wlt").Return(txns, inputs, nil)
+		gateway.On("GetWallet", "foo.wlt").Return(nil, wallet.ErrWalletNotExist)
+
+		rr := httptest.NewRecorder()
+		handler := newServerMux(defaultMuxConfig(), gateway)
+		handler.ServeHTTP(rr, newRequest(false))
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
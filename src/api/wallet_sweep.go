@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletSweepResponse is returned by /api/v1/wallet/sweep
+type WalletSweepResponse struct {
+	Txid string `json:"txid"`
+	// Address is the new wallet address that received the funds
+	Address     string             `json:"address"`
+	Transaction CreatedTransaction `json:"transaction"`
+	// Warnings describe the funds of the secret keys that were not swept
+	Warnings []string `json:"warnings"`
+}
+
+// Sweeps the confirmed funds of the addresses of secret keys, e.g. the keys of paper wallets,
+// to a new address of a wallet. The entire balance is sent, minus the coin hours fee.
+// Outputs spent or received by pending transactions are not swept, and are reported in the warnings.
+// The secret keys are only used to sign the transaction, they are not stored or logged.
+// Method: POST
+// URI: /api/v1/wallet/sweep
+// Args:
+//
+//	id: wallet id [required]
+//	secret_keys: comma separated hex encoded secret keys [required]
+//	password: wallet password [optional, must be provided if the wallet is encrypted]
+func walletSweepHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		id := r.FormValue("id")
+		if id == "" {
+			wh.Error400(w, "missing wallet id")
+			return
+		}
+
+		keysStr := splitCommaString(r.FormValue("secret_keys"))
+		if len(keysStr) == 0 {
+			wh.Error400(w, "missing secret_keys")
+			return
+		}
+
+		keys := make([]cipher.SecKey, len(keysStr))
+		defer func() {
+			for i := range keys {
+				keys[i] = cipher.SecKey{}
+			}
+		}()
+
+		for i, s := range keysStr {
+			var err error
+			keys[i], err = cipher.SecKeyFromHex(s)
+			if err != nil {
+				// The key is not echoed in the error
+				wh.Error400(w, fmt.Sprintf("invalid secret key at index %d", i))
+				return
+			}
+		}
+
+		password := r.FormValue("password")
+		defer func() {
+			password = ""
+		}()
+
+		result, err := gateway.WalletSweep(id, []byte(password), keys)
+		if err != nil {
+			switch err.(type) {
+			case visor.UserError,
+				visor.ErrTxnViolatesUserConstraint,
+				visor.ErrTxnViolatesHardConstraint,
+				visor.ErrTxnViolatesSoftConstraint:
+				wh.Error400(w, err.Error())
+			default:
+				switch err {
+				case wallet.ErrWalletAPIDisabled:
+					wh.Error403(w, "")
+				case wallet.ErrWalletNotExist:
+					wh.Error404(w, "")
+				case wallet.ErrMissingPassword,
+					wallet.ErrInvalidPassword:
+					wh.Error400(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
+			}
+			return
+		}
+
+		if err := gateway.InjectBroadcastTransaction(r.Context(), *result.Transaction); err != nil {
+			switch err.(type) {
+			case visor.ErrTxnViolatesUserConstraint,
+				visor.ErrTxnViolatesHardConstraint,
+				visor.ErrTxnViolatesSoftConstraint:
+				wh.Error400(w, err.Error())
+			default:
+				if daemon.IsBroadcastFailure(err) {
+					wh.Error503(w, err.Error())
+				} else {
+					wh.Error500(w, err.Error())
+				}
+			}
+			return
+		}
+
+		inputs := make([]visor.TransactionInput, len(result.Inputs))
+		for i, in := range result.Inputs {
+			inputs[i] = visor.TransactionInput(in)
+		}
+
+		txn, err := NewCreatedTransaction(result.Transaction, inputs)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		warnings := result.Warnings
+		if warnings == nil {
+			warnings = []string{}
+		}
+
+		wh.SendJSONOr500(logger, w, WalletSweepResponse{
+			Txid:        result.Transaction.Hash().Hex(),
+			Address:     result.Address.String(),
+			Transaction: *txn,
+			Warnings:    warnings,
+		})
+	}
+}
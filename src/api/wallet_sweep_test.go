@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletSweepHandler(t *testing.T) {
+	_, key1 := cipher.GenerateKeyPair()
+	_, key2 := cipher.GenerateKeyPair()
+	keys := []cipher.SecKey{key1, key2}
+	keysStr := key1.Hex() + "," + key2.Hex()
+
+	ux := coin.UxOut{
+		Body: coin.UxBody{
+			SrcTransaction: testutil.RandSHA256(t),
+			Address:        cipher.MustAddressFromSecKey(key1),
+			Coins:          1e6,
+			Hours:          10,
+		},
+	}
+	dest := testutil.MakeAddress()
+
+	var txn coin.Transaction
+	err := txn.PushInput(ux.Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(dest, 1e6, 5)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{key1})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	result := &visor.SweepResult{
+		Transaction: &txn,
+		Inputs: []visor.TransactionInput{
+			{
+				UxOut:           ux,
+				CalculatedHours: 10,
+			},
+		},
+		Address: dest,
+	}
+
+	warningResult := *result
+	warningResult.Warnings = []string{"1 outputs are spent by pending transactions and were not swept"}
+
+	createdTxn, err := NewCreatedTransaction(&txn, []visor.TransactionInput{{UxOut: ux, CalculatedHours: 10}})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name        string
+		method      string
+		body        url.Values
+		status      int
+		err         string
+		sweepResult *visor.SweepResult
+		sweepErr    error
+		injectErr   error
+		response    WalletSweepResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			body: url.Values{
+				"secret_keys": {keysStr},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - missing secret keys",
+			method: http.MethodPost,
+			body: url.Values{
+				"id": {"foo.wlt"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing secret_keys",
+		},
+		{
+			name:   "400 - invalid secret key",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {key1.Hex() + ",abcd"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid secret key at index 1",
+		},
+		{
+			name:   "400 - no funds",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - The addresses of the secret keys have no unspent outputs",
+			sweepErr: visor.ErrSweepNoFunds,
+		},
+		{
+			name:   "400 - dust",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - The outputs of the secret keys are dust: their coin hours can't pay the transaction fee or their coins can't be sent",
+			sweepErr: visor.ErrSweepDustOnly,
+		},
+		{
+			name:   "400 - unconfirmed",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - The outputs of the secret keys are all spent or received by pending transactions, sweep after they are confirmed",
+			sweepErr: visor.ErrSweepUnconfirmedOnly,
+		},
+		{
+			name:   "400 - missing password",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - missing password",
+			sweepErr: wallet.ErrMissingPassword,
+		},
+		{
+			name:   "403 - wallet api disabled",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusForbidden,
+			err:      "403 Forbidden",
+			sweepErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:   "404 - wallet not found",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusNotFound,
+			err:      "404 Not Found",
+			sweepErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:   "500 - sweep failed",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:   http.StatusInternalServerError,
+			err:      "500 Internal Server Error - failed",
+			sweepErr: errors.New("failed"),
+		},
+		{
+			name:   "503 - broadcast failed",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:      http.StatusServiceUnavailable,
+			err:         "503 Service Unavailable - Networking is disabled",
+			sweepResult: result,
+			injectErr:   daemon.ErrNetworkingDisabled,
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+			},
+			status:      http.StatusOK,
+			sweepResult: result,
+			response: WalletSweepResponse{
+				Txid:        txn.Hash().Hex(),
+				Address:     dest.String(),
+				Transaction: *createdTxn,
+				Warnings:    []string{},
+			},
+		},
+		{
+			name:   "200 - warnings",
+			method: http.MethodPost,
+			body: url.Values{
+				"id":          {"foo.wlt"},
+				"secret_keys": {keysStr},
+				"password":    {"pwd"},
+			},
+			status:      http.StatusOK,
+			sweepResult: &warningResult,
+			response: WalletSweepResponse{
+				Txid:        txn.Hash().Hex(),
+				Address:     dest.String(),
+				Transaction: *createdTxn,
+				Warnings:    warningResult.Warnings,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("WalletSweep", "foo.wlt", []byte(tc.body.Get("password")), keys).Return(tc.sweepResult, tc.sweepErr)
+			gateway.On("InjectBroadcastTransaction", mock.Anything, txn).Return(tc.injectErr)
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallet/sweep", strings.NewReader(tc.body.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp WalletSweepResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, resp)
+		})
+	}
+}
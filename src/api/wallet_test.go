@@ -10,18 +10,23 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"encoding/json"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/visor"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/cipher/bip85"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func TestGetBalanceHandler(t *testing.T) {
@@ -162,7 +167,7 @@ func TestGetBalanceHandler(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			endpoint := "/api/v1/balance"
 			gateway.On("GetBalanceOfAddresses", tc.getBalanceOfAddrsArg).Return(tc.getBalanceOfAddrsResponse, tc.getBalanceOfAddrsError)
 
@@ -209,6 +214,109 @@ func TestGetBalanceHandler(t *testing.T) {
 	}
 }
 
+func TestBalanceHandlerBatch(t *testing.T) {
+	addrs := make([]cipher.Address, balanceChunkSize*2+500)
+	addrStrs := make([]string, len(addrs))
+	bals := make([]wallet.BalancePair, len(addrs))
+	for i := range addrs {
+		copy(addrs[i].Key[:], testutil.RandBytes(t, len(addrs[i].Key)))
+		addrStrs[i] = addrs[i].String()
+		bals[i] = wallet.BalancePair{
+			Confirmed: wallet.Balance{Coins: uint64(i) * 1e6, Hours: uint64(i)},
+			Predicted: wallet.Balance{Coins: uint64(i) * 1e6, Hours: uint64(i)},
+		}
+	}
+
+	doRequest := func(t *testing.T, cfg muxConfig, gateway *MockGatewayer, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/balance", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", ContentTypeJSON)
+
+		rr := httptest.NewRecorder()
+		handler := newServerMux(cfg, gateway)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("200 - chunked, in request order", func(t *testing.T) {
+		index := make(map[cipher.Address]int, len(addrs))
+		for i, a := range addrs {
+			index[a] = i
+		}
+
+		var chunks []int
+		gateway := newWalletMockGatewayer()
+		gateway.On("GetBalanceOfAddresses", mock.Anything).Return(func(chunk []cipher.Address) []wallet.BalancePair {
+			chunks = append(chunks, len(chunk))
+			ret := make([]wallet.BalancePair, len(chunk))
+			for i, a := range chunk {
+				ret[i] = bals[index[a]]
+			}
+			return ret
+		}, nil)
+
+		body, err := json.Marshal(BalanceRequest{
+			Addresses: addrStrs,
+		})
+		require.NoError(t, err)
+
+		rr := doRequest(t, defaultMuxConfig(), gateway, string(body))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, []int{balanceChunkSize, balanceChunkSize, 500}, chunks)
+
+		var msg BalanceBatchResponse
+		err = json.Unmarshal(rr.Body.Bytes(), &msg)
+		require.NoError(t, err)
+
+		require.Len(t, msg.Balances, len(addrs))
+		require.Len(t, msg.Addresses, len(addrs))
+		for i, b := range msg.Balances {
+			require.Equal(t, addrStrs[i], b.Address)
+			require.Equal(t, readable.NewBalancePair(bals[i]), b.BalancePair)
+			require.Equal(t, b.BalancePair, msg.Addresses[addrStrs[i]])
+		}
+	})
+
+	t.Run("413 - too many addresses", func(t *testing.T) {
+		cfg := defaultMuxConfig()
+		cfg.maxBalanceAddresses = 2
+
+		body, err := json.Marshal(BalanceRequest{
+			Addresses: addrStrs[:3],
+		})
+		require.NoError(t, err)
+
+		rr := doRequest(t, cfg, newWalletMockGatewayer(), string(body))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		require.Equal(t, "413 Request Entity Too Large - too many addresses, at most 2 addresses are allowed per request", strings.TrimSpace(rr.Body.String()))
+
+		// The limit also applies to form requests
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/balance?addrs="+strings.Join(addrStrs[:3], ","), nil)
+		require.NoError(t, err)
+		rr = httptest.NewRecorder()
+		newServerMux(cfg, newWalletMockGatewayer()).ServeHTTP(rr, req)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("400 - invalid body", func(t *testing.T) {
+		rr := doRequest(t, defaultMuxConfig(), newWalletMockGatewayer(), `{"addrs":`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Equal(t, "400 Bad Request - unexpected EOF", strings.TrimSpace(rr.Body.String()))
+	})
+
+	t.Run("400 - invalid address", func(t *testing.T) {
+		rr := doRequest(t, defaultMuxConfig(), newWalletMockGatewayer(), `{"addrs":["invalidAddr"]}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Equal(t, "400 Bad Request - address \"invalidAddr\" is invalid: Invalid base58 character", strings.TrimSpace(rr.Body.String()))
+	})
+
+	t.Run("400 - no addresses", func(t *testing.T) {
+		rr := doRequest(t, defaultMuxConfig(), newWalletMockGatewayer(), `{"addrs":[]}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Equal(t, "400 Bad Request - addrs is required", strings.TrimSpace(rr.Body.String()))
+	})
+}
+
 func TestWalletGet(t *testing.T) {
 	entries, resEntries := makeEntries([]byte("seed"), 5)
 	type httpBody struct {
@@ -294,7 +402,7 @@ func TestWalletGet(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			gateway.On("GetWallet", tc.walletID).Return(tc.gatewayGetWalletResultFunc, tc.gatewayGetWalletErr)
 
 			v := url.Values{}
@@ -348,11 +456,6 @@ func TestWalletBalanceHandler(t *testing.T) {
 		Coins    string
 	}
 
-	type balanceResult struct {
-		BalancePair wallet.BalancePair
-		Addresses   wallet.AddressBalances
-	}
-
 	tt := []struct {
 		name                          string
 		method                        string
@@ -360,9 +463,9 @@ func TestWalletBalanceHandler(t *testing.T) {
 		status                        int
 		err                           string
 		walletID                      string
-		gatewayGetWalletBalanceResult balanceResult
+		gatewayGetWalletBalanceResult *visor.WalletBalance
 		gatewayBalanceErr             error
-		result                        *readable.BalancePair
+		result                        *WalletBalanceResponse
 	}{
 		{
 			name:     "405",
@@ -384,15 +487,10 @@ func TestWalletBalanceHandler(t *testing.T) {
 			body: &httpBody{
 				WalletID: "notFoundId",
 			},
-			status:                        http.StatusNotFound,
-			err:                           "404 Not Found",
-			walletID:                      "notFoundId",
-			gatewayGetWalletBalanceResult: balanceResult{},
-			gatewayBalanceErr:             wallet.ErrWalletNotExist,
-			result: &readable.BalancePair{
-				Confirmed: readable.Balance{Coins: 0, Hours: 0},
-				Predicted: readable.Balance{Coins: 0, Hours: 0},
-			},
+			status:            http.StatusNotFound,
+			err:               "404 Not Found",
+			walletID:          "notFoundId",
+			gatewayBalanceErr: wallet.ErrWalletNotExist,
 		},
 		{
 			name:   "500 - gw other error",
@@ -400,15 +498,10 @@ func TestWalletBalanceHandler(t *testing.T) {
 			body: &httpBody{
 				WalletID: "someId",
 			},
-			status:                        http.StatusInternalServerError,
-			err:                           "500 Internal Server Error - gatewayBalanceError",
-			walletID:                      "someId",
-			gatewayGetWalletBalanceResult: balanceResult{},
-			gatewayBalanceErr:             errors.New("gatewayBalanceError"),
-			result: &readable.BalancePair{
-				Confirmed: readable.Balance{Coins: 0, Hours: 0},
-				Predicted: readable.Balance{Coins: 0, Hours: 0},
-			},
+			status:            http.StatusInternalServerError,
+			err:               "500 Internal Server Error - gatewayBalanceError",
+			walletID:          "someId",
+			gatewayBalanceErr: errors.New("gatewayBalanceError"),
 		},
 		{
 			name:   "403 - Forbidden - wallet API disabled",
@@ -416,11 +509,10 @@ func TestWalletBalanceHandler(t *testing.T) {
 			body: &httpBody{
 				WalletID: "foo",
 			},
-			status:                        http.StatusForbidden,
-			err:                           "403 Forbidden",
-			walletID:                      "foo",
-			gatewayGetWalletBalanceResult: balanceResult{},
-			gatewayBalanceErr:             wallet.ErrWalletAPIDisabled,
+			status:            http.StatusForbidden,
+			err:               "403 Forbidden",
+			walletID:          "foo",
+			gatewayBalanceErr: wallet.ErrWalletAPIDisabled,
 		},
 		{
 			name:   "200 - OK",
@@ -431,15 +523,61 @@ func TestWalletBalanceHandler(t *testing.T) {
 			status:   http.StatusOK,
 			err:      "",
 			walletID: "foo",
-			result:   &readable.BalancePair{},
+			gatewayGetWalletBalanceResult: &visor.WalletBalance{
+				Balance: wallet.BalancePair{
+					Confirmed: wallet.Balance{Coins: 21e6, Hours: 100},
+					Predicted: wallet.Balance{Coins: 20e6, Hours: 90},
+				},
+				Addresses: wallet.AddressBalances{
+					"2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv": wallet.BalancePair{
+						Confirmed: wallet.Balance{Coins: 21e6, Hours: 100},
+						Predicted: wallet.Balance{Coins: 20e6, Hours: 90},
+					},
+				},
+				HeadSeq: 12,
+			},
+			result: &WalletBalanceResponse{
+				BalanceResponse: BalanceResponse{
+					BalancePair: readable.BalancePair{
+						Confirmed: readable.Balance{Coins: 21e6, Hours: 100},
+						Predicted: readable.Balance{Coins: 20e6, Hours: 90},
+					},
+					Addresses: readable.AddressBalances{
+						"2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv": readable.BalancePair{
+							Confirmed: readable.Balance{Coins: 21e6, Hours: 100},
+							Predicted: readable.Balance{Coins: 20e6, Hours: 90},
+						},
+					},
+				},
+				HeadSeq: 12,
+			},
+		},
+		{
+			name:   "200 - stale",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			status:   http.StatusOK,
+			walletID: "foo",
+			gatewayGetWalletBalanceResult: &visor.WalletBalance{
+				HeadSeq: 12,
+				Stale:   true,
+			},
+			result: &WalletBalanceResponse{
+				BalanceResponse: BalanceResponse{
+					Addresses: readable.AddressBalances{},
+				},
+				HeadSeq: 12,
+				Stale:   true,
+			},
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
-			gateway.On("GetWalletBalance", tc.walletID).Return(tc.gatewayGetWalletBalanceResult.BalancePair,
-				tc.gatewayGetWalletBalanceResult.Addresses, tc.gatewayBalanceErr)
+			gateway := newWalletMockGatewayer()
+			gateway.On("GetWalletBalanceAtHead", tc.walletID).Return(tc.gatewayGetWalletBalanceResult, tc.gatewayBalanceErr)
 
 			endpoint := "/api/v1/wallet/balance"
 
@@ -475,7 +613,7 @@ func TestWalletBalanceHandler(t *testing.T) {
 				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
-				var msg readable.BalancePair
+				var msg WalletBalanceResponse
 				err = json.Unmarshal(rr.Body.Bytes(), &msg)
 				require.NoError(t, err)
 				require.Equal(t, tc.result, &msg, tc.name)
@@ -538,6 +676,32 @@ func TestUpdateWalletLabelHandler(t *testing.T) {
 			label:                       "label",
 			gatewayUpdateWalletLabelErr: wallet.ErrWalletNotExist,
 		},
+		{
+			name:   "403 - gateway.UpdateWalletLabel ErrWalletMetadataLocked",
+			method: http.MethodPost,
+			body: &httpBody{
+				WalletID: "foo",
+				Label:    "label",
+			},
+			status:                      http.StatusForbidden,
+			err:                         "403 Forbidden - wallet metadata is locked",
+			walletID:                    "foo",
+			label:                       "label",
+			gatewayUpdateWalletLabelErr: wallet.ErrWalletMetadataLocked,
+		},
+		{
+			name:   "409 - gateway.UpdateWalletLabel ErrWalletLabelConflict",
+			method: http.MethodPost,
+			body: &httpBody{
+				WalletID: "foo",
+				Label:    "label",
+			},
+			status:                      http.StatusConflict,
+			err:                         "409 Conflict - another wallet already has this label",
+			walletID:                    "foo",
+			label:                       "label",
+			gatewayUpdateWalletLabelErr: wallet.ErrWalletLabelConflict,
+		},
 		{
 			name:   "500 - gateway.UpdateWalletLabel error",
 			method: http.MethodPost,
@@ -582,7 +746,7 @@ func TestUpdateWalletLabelHandler(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			gateway.On("UpdateWalletLabel", tc.walletID, tc.label).Return(tc.gatewayUpdateWalletLabelErr)
 
 			endpoint := "/api/v1/wallet/update"
@@ -624,6 +788,300 @@ func TestUpdateWalletLabelHandler(t *testing.T) {
 	}
 }
 
+func TestUpdateWalletReuseChangeHandler(t *testing.T) {
+	tt := []struct {
+		name                     string
+		label                    string
+		reuseChange              string
+		status                   int
+		err                      string
+		setReuseChange           bool
+		gatewaySetReuseChangeErr error
+		updateLabel              bool
+		gatewayUpdateLabelErr    error
+	}{
+		{
+			name:        "400 - invalid reuse_change",
+			reuseChange: "foo",
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - invalid reuse_change value: strconv.ParseBool: parsing \"foo\": invalid syntax",
+		},
+		{
+			name:                     "400 - not a bip44 wallet",
+			reuseChange:              "true",
+			status:                   http.StatusBadRequest,
+			err:                      "400 Bad Request - reuseChange is only used for \"bip44\" wallets",
+			setReuseChange:           true,
+			gatewaySetReuseChangeErr: wallet.NewError(errors.New("reuseChange is only used for \"bip44\" wallets")),
+		},
+		{
+			name:                     "404 - wallet not found",
+			reuseChange:              "true",
+			status:                   http.StatusNotFound,
+			err:                      "404 Not Found",
+			setReuseChange:           true,
+			gatewaySetReuseChangeErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:           "200 - reuse_change only",
+			reuseChange:    "true",
+			status:         http.StatusOK,
+			setReuseChange: true,
+		},
+		{
+			name:           "200 - reuse_change and label",
+			label:          "label",
+			reuseChange:    "false",
+			status:         http.StatusOK,
+			setReuseChange: true,
+			updateLabel:    true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			reuse, _ := strconv.ParseBool(tc.reuseChange) //nolint:errcheck
+
+			gateway := newWalletMockGatewayer()
+			gateway.On("SetWalletReuseChange", "foo", reuse).Return(tc.gatewaySetReuseChangeErr)
+			gateway.On("UpdateWalletLabel", "foo", tc.label).Return(tc.gatewayUpdateLabelErr)
+
+			v := url.Values{}
+			v.Add("id", "foo")
+			v.Add("reuse_change", tc.reuseChange)
+			if tc.label != "" {
+				v.Add("label", tc.label)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/update", strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				require.Equal(t, "\"success\"", rr.Body.String())
+			}
+
+			if tc.setReuseChange {
+				gateway.AssertCalled(t, "SetWalletReuseChange", "foo", reuse)
+			} else {
+				gateway.AssertNotCalled(t, "SetWalletReuseChange", "foo", reuse)
+			}
+			if tc.updateLabel {
+				gateway.AssertCalled(t, "UpdateWalletLabel", "foo", tc.label)
+			} else {
+				gateway.AssertNotCalled(t, "UpdateWalletLabel", "foo", tc.label)
+			}
+		})
+	}
+}
+
+func TestUpdateWalletStrictPrivacyHandler(t *testing.T) {
+	tt := []struct {
+		name                       string
+		strictPrivacy              string
+		status                     int
+		err                        string
+		setStrictPrivacy           bool
+		gatewaySetStrictPrivacyErr error
+	}{
+		{
+			name:          "400 - invalid strict_privacy",
+			strictPrivacy: "foo",
+			status:        http.StatusBadRequest,
+			err:           "400 Bad Request - invalid strict_privacy value: strconv.ParseBool: parsing \"foo\": invalid syntax",
+		},
+		{
+			name:                       "403 - wallet api disabled",
+			strictPrivacy:              "true",
+			status:                     http.StatusForbidden,
+			err:                        "403 Forbidden",
+			setStrictPrivacy:           true,
+			gatewaySetStrictPrivacyErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:                       "404 - wallet not found",
+			strictPrivacy:              "true",
+			status:                     http.StatusNotFound,
+			err:                        "404 Not Found",
+			setStrictPrivacy:           true,
+			gatewaySetStrictPrivacyErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:             "200 - strict_privacy true",
+			strictPrivacy:    "true",
+			status:           http.StatusOK,
+			setStrictPrivacy: true,
+		},
+		{
+			name:             "200 - strict_privacy false",
+			strictPrivacy:    "false",
+			status:           http.StatusOK,
+			setStrictPrivacy: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			strict, _ := strconv.ParseBool(tc.strictPrivacy) //nolint:errcheck
+
+			gateway := newWalletMockGatewayer()
+			gateway.On("SetWalletStrictPrivacy", "foo", strict).Return(tc.gatewaySetStrictPrivacyErr)
+
+			v := url.Values{}
+			v.Add("id", "foo")
+			v.Add("strict_privacy", tc.strictPrivacy)
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/update", strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				require.Equal(t, "\"success\"", rr.Body.String())
+			}
+
+			if tc.setStrictPrivacy {
+				gateway.AssertCalled(t, "SetWalletStrictPrivacy", "foo", strict)
+			} else {
+				gateway.AssertNotCalled(t, "SetWalletStrictPrivacy", "foo", strict)
+			}
+			gateway.AssertNotCalled(t, "UpdateWalletLabel", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWalletAddressLabelHandler(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	tt := []struct {
+		name       string
+		method     string
+		form       url.Values
+		status     int
+		err        string
+		walletID   string
+		label      string
+		mockLabel  bool
+		gatewayErr error
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			form:   url.Values{},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - missing address",
+			method: http.MethodPost,
+			form:   url.Values{"id": {"foo"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing address",
+		},
+		{
+			name:   "400 - invalid address",
+			method: http.MethodPost,
+			form:   url.Values{"id": {"foo"}, "address": {"bad"}},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid address: Invalid address length",
+		},
+		{
+			name:       "400 - unknown address",
+			method:     http.MethodPost,
+			form:       url.Values{"id": {"foo"}, "address": {addr.String()}, "label": {"hot"}},
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - address not found in wallet",
+			walletID:   "foo",
+			label:      "hot",
+			mockLabel:  true,
+			gatewayErr: wallet.ErrUnknownAddress,
+		},
+		{
+			name:       "404 - wallet not found",
+			method:     http.MethodPost,
+			form:       url.Values{"id": {"foo"}, "address": {addr.String()}, "label": {"hot"}},
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+			walletID:   "foo",
+			label:      "hot",
+			mockLabel:  true,
+			gatewayErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:       "403 - wallet metadata locked",
+			method:     http.MethodPost,
+			form:       url.Values{"id": {"foo"}, "address": {addr.String()}, "label": {"hot"}},
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden - wallet metadata is locked",
+			walletID:   "foo",
+			label:      "hot",
+			mockLabel:  true,
+			gatewayErr: wallet.ErrWalletMetadataLocked,
+		},
+		{
+			name:      "200 OK - clear label",
+			method:    http.MethodPost,
+			form:      url.Values{"id": {"foo"}, "address": {addr.String()}},
+			status:    http.StatusOK,
+			walletID:  "foo",
+			mockLabel: true,
+		},
+		{
+			name:      "200 OK",
+			method:    http.MethodPost,
+			form:      url.Values{"id": {"foo"}, "address": {addr.String()}, "label": {"hot"}},
+			status:    http.StatusOK,
+			walletID:  "foo",
+			label:     "hot",
+			mockLabel: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			if tc.mockLabel {
+				gateway.On("UpdateAddressLabel", tc.walletID, addr, tc.label).Return(tc.gatewayErr)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallet/address/label", strings.NewReader(tc.form.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				require.Equal(t, "\"success\"", rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestWalletTransactionsHandler(t *testing.T) {
 	type httpBody struct {
 		walletID string
@@ -770,8 +1228,8 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			walletID:                              "foo",
 			gatewayGetWalletUnconfirmedTxnsResult: make([]visor.UnconfirmedTransaction, 1),
 			responseBody: UnconfirmedTxnsResponse{
-				Transactions: []readable.UnconfirmedTransactions{
-					*unconfirmedTxn,
+				Transactions: []WalletUnconfirmedTransaction{
+					{UnconfirmedTransactions: *unconfirmedTxn},
 				},
 			},
 		},
@@ -788,17 +1246,18 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			walletID: "foo",
 			gatewayGetWalletUnconfirmedTxnsVerboseResult: make([]readable.UnconfirmedTransactionVerbose, 1),
 			responseBody: UnconfirmedTxnsVerboseResponse{
-				Transactions: []readable.UnconfirmedTransactionVerbose{
-					*unconfirmedTxnVerbose,
+				Transactions: []WalletUnconfirmedTransactionVerbose{
+					{UnconfirmedTransactionVerbose: *unconfirmedTxnVerbose},
 				},
 			},
 		},
 	}
 
 	for _, tc := range tt {
-		gateway := &MockGatewayer{}
+		gateway := newWalletMockGatewayer()
 		gateway.On("GetWalletUnconfirmedTransactions", tc.walletID).Return(tc.gatewayGetWalletUnconfirmedTxnsResult, tc.gatewayGetWalletUnconfirmedTxnsErr)
 		gateway.On("GetWalletUnconfirmedTransactionsVerbose", tc.walletID).Return(tc.gatewayGetWalletUnconfirmedTxnsVerboseResult, tc.gatewayGetWalletUnconfirmedTxnsVerboseErr)
+		gateway.On("GetWalletTxNotes", tc.walletID).Return(nil, nil)
 
 		endpoint := "/api/v1/wallet/transactions"
 
@@ -842,6 +1301,7 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			require.IsType(t, msg, tc.responseBody)
 			require.Len(t, msg.Transactions, 1)
 			require.Equal(t, msg.Transactions[0].Transaction, tc.responseBody.(UnconfirmedTxnsVerboseResponse).Transactions[0].Transaction)
+			require.True(t, msg.Transactions[0].CalculatedHoursEstimated)
 		} else {
 			var msg UnconfirmedTxnsResponse
 			err = json.Unmarshal(rr.Body.Bytes(), &msg)
@@ -1083,8 +1543,10 @@ func TestWalletCreateHandler(t *testing.T) {
 				}
 			},
 			responseBody: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename: "filename",
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename: "filename",
+					},
 				},
 				Entries: responseEntries[:],
 			},
@@ -1119,8 +1581,10 @@ func TestWalletCreateHandler(t *testing.T) {
 				}
 			},
 			responseBody: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename: "filename",
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename: "filename",
+					},
 				},
 				Entries: responseEntries[:],
 			},
@@ -1153,8 +1617,10 @@ func TestWalletCreateHandler(t *testing.T) {
 				}
 			},
 			responseBody: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename: "filename",
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename: "filename",
+					},
 				},
 				Entries: responseEntries[:],
 			},
@@ -1187,10 +1653,12 @@ func TestWalletCreateHandler(t *testing.T) {
 				}
 			},
 			responseBody: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename: "filename",
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename: "filename",
+					},
 				},
-				Entries: []readable.WalletEntry{},
+				Entries: []WalletEntry{},
 			},
 			csrfDisabled: true,
 		},
@@ -1227,12 +1695,14 @@ func TestWalletCreateHandler(t *testing.T) {
 				}
 			},
 			responseBody: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "filename",
-					Label:     "bar",
-					Encrypted: true,
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename:  "filename",
+						Label:     "bar",
+						Encrypted: true,
+					},
 				},
-				Entries: []readable.WalletEntry{},
+				Entries: []WalletEntry{},
 			},
 		},
 		{
@@ -1251,7 +1721,7 @@ func TestWalletCreateHandler(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			if tc.options.ScanN == 0 {
 				tc.options.ScanN = 1
 			}
@@ -1331,50 +1801,161 @@ func TestWalletCreateHandler(t *testing.T) {
 	}
 }
 
-func TestWalletNewSeed(t *testing.T) {
-	type httpBody struct {
-		Entropy string
-	}
+func TestWalletCreateHandlerBits(t *testing.T) {
 	tt := []struct {
-		name      string
-		method    string
-		body      *httpBody
-		status    int
-		err       string
-		entropy   string
-		resultLen int
+		name       string
+		walletType string
+		seed       string
+		bits       string
+		status     int
+		err        string
+		words      int
 	}{
 		{
-			name:   "405",
-			method: http.MethodPut,
-			status: http.StatusMethodNotAllowed,
-			err:    "405 Method Not Allowed",
+			name:       "400 - invalid bits",
+			walletType: wallet.WalletTypeDeterministic,
+			bits:       "foo",
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - invalid bits value",
 		},
 		{
-			name:   "400 - invalid entropy type",
-			method: http.MethodGet,
-			body: &httpBody{
-				Entropy: "xx",
-			},
-			status:  http.StatusBadRequest,
-			err:     "400 Bad Request - invalid entropy",
-			entropy: "xx",
+			name:       "400 - bits not allowed",
+			walletType: wallet.WalletTypeDeterministic,
+			bits:       "100",
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - bits must be 128, 160, 192, 224 or 256",
 		},
 		{
-			name:   "400 - `wrong entropy length` error",
-			method: http.MethodGet,
-			body: &httpBody{
-				Entropy: "200",
-			},
-			status:  http.StatusBadRequest,
-			err:     "400 Bad Request - entropy length must be 128 or 256",
-			entropy: "200",
+			name:       "400 - bits and seed",
+			walletType: wallet.WalletTypeBip44,
+			seed:       "foo",
+			bits:       "128",
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - seed and bits cannot be combined",
 		},
 		{
-			name:      "200 - OK with no entropy",
-			method:    http.MethodGet,
-			body:      &httpBody{},
-			status:    http.StatusOK,
+			name:       "400 - bits with collection wallet",
+			walletType: wallet.WalletTypeCollection,
+			bits:       "128",
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - bits is only valid for deterministic and bip44 type wallets",
+		},
+		{
+			name:       "200 - deterministic 128 bits",
+			walletType: wallet.WalletTypeDeterministic,
+			bits:       "128",
+			status:     http.StatusOK,
+			words:      12,
+		},
+		{
+			name:       "200 - bip44 192 bits",
+			walletType: wallet.WalletTypeBip44,
+			bits:       "192",
+			status:     http.StatusOK,
+			words:      18,
+		},
+		{
+			name:       "200 - bip44 256 bits",
+			walletType: wallet.WalletTypeBip44,
+			bits:       "256",
+			status:     http.StatusOK,
+			words:      24,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+
+			var createdOpts wallet.Options
+			gateway.On("CreateWallet", "", mock.Anything, gateway).Return(func(wltName string, opts wallet.Options, tf wallet.TransactionsFinder) wallet.Wallet {
+				createdOpts = opts
+				opts.ScanN = 0
+				w, err := wallet.NewWallet("foo.wlt", opts)
+				require.NoError(t, err)
+				return w
+			}, nil)
+
+			v := url.Values{}
+			v.Add("type", tc.walletType)
+			v.Add("label", "foo")
+			v.Add("bits", tc.bits)
+			if tc.seed != "" {
+				v.Add("seed", tc.seed)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/create", strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg WalletCreateResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.bits, strconv.Itoa(msg.SeedBits))
+			require.Len(t, strings.Fields(msg.Seed), tc.words)
+			require.NoError(t, bip39.ValidateMnemonic(msg.Seed))
+			require.Equal(t, createdOpts.Seed, msg.Seed)
+			require.Len(t, msg.Entries, 1)
+		})
+	}
+}
+
+func TestWalletNewSeed(t *testing.T) {
+	type httpBody struct {
+		Entropy string
+	}
+	tt := []struct {
+		name      string
+		method    string
+		body      *httpBody
+		status    int
+		err       string
+		entropy   string
+		resultLen int
+	}{
+		{
+			name:   "405",
+			method: http.MethodPut,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - invalid entropy type",
+			method: http.MethodGet,
+			body: &httpBody{
+				Entropy: "xx",
+			},
+			status:  http.StatusBadRequest,
+			err:     "400 Bad Request - invalid entropy",
+			entropy: "xx",
+		},
+		{
+			name:   "400 - `wrong entropy length` error",
+			method: http.MethodGet,
+			body: &httpBody{
+				Entropy: "200",
+			},
+			status:  http.StatusBadRequest,
+			err:     "400 Bad Request - entropy length must be 128 or 256",
+			entropy: "200",
+		},
+		{
+			name:      "200 - OK with no entropy",
+			method:    http.MethodGet,
+			body:      &httpBody{},
+			status:    http.StatusOK,
 			entropy:   "128",
 			resultLen: 12,
 		},
@@ -1403,7 +1984,7 @@ func TestWalletNewSeed(t *testing.T) {
 	// Loop over each test case
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 
 			endpoint := "/api/v1/wallet/newSeed"
 
@@ -1483,7 +2064,7 @@ func TestVerifySeed(t *testing.T) {
 			method:       http.MethodPost,
 			contentType:  ContentTypeJSON,
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
 		},
 		{
 			name:         "400 - Missing Seed",
@@ -1527,7 +2108,7 @@ func TestVerifySeed(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			endpoint := "/api/v2/wallet/seed/verify"
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
 			require.NoError(t, err)
@@ -1695,7 +2276,7 @@ func TestGetWalletSeed(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			gateway.On("GetWalletSeed", tc.wltID, []byte(tc.password)).Return(tc.gatewayReturnArgs...)
 
 			endpoint := "/api/v1/wallet/seed"
@@ -1736,11 +2317,348 @@ func TestGetWalletSeed(t *testing.T) {
 	}
 }
 
+func TestWalletDeriveChildHandler(t *testing.T) {
+	seed := "dizzy cigar grant ramp inmate uniform gold success able payment faith practice"
+	bip44Coin := bip44.CoinTypeSkycoin
+
+	newParent := func(encrypt bool) wallet.Wallet {
+		opts := wallet.Options{
+			Type:           wallet.WalletTypeBip44,
+			Seed:           seed,
+			SeedPassphrase: "passphrase",
+			Bip44Coin:      &bip44Coin,
+		}
+		if encrypt {
+			opts.Encrypt = true
+			opts.Password = []byte("pwd")
+			opts.CryptoType = wallet.CryptoTypeSha256Xor
+		}
+		w, err := wallet.NewWallet("parent.wlt", opts)
+		require.NoError(t, err)
+		return w
+	}
+
+	collection, err := wallet.NewWallet("collection.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	childMnemonic := func(words, index uint32) string {
+		s, err := bip39.NewSeed(seed, "passphrase")
+		require.NoError(t, err)
+		m, err := bip85.NewMnemonicFromSeed(s, words, index)
+		require.NoError(t, err)
+		return m
+	}
+
+	tt := []struct {
+		name             string
+		method           string
+		form             url.Values
+		status           int
+		err              string
+		parent           wallet.Wallet
+		getWalletErr     error
+		getWalletSeedErr error
+		createWalletErr  error
+		options          *wallet.Options
+		mnemonic         string
+		path             string
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing id",
+			method: http.MethodPost,
+			form:   url.Values{},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - missing index",
+			method: http.MethodPost,
+			form: url.Values{
+				"id": []string{"parent.wlt"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing index",
+		},
+		{
+			name:   "400 - invalid index",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"-1"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid index value",
+		},
+		{
+			name:   "400 - invalid words",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"0"},
+				"words": []string{"x"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid words value",
+		},
+		{
+			name:   "400 - missing label",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"0"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing label",
+		},
+		{
+			name:   "400 - scan 0",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"0"},
+				"label": []string{"child"},
+				"scan":  []string{"0"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - scan must be > 0",
+		},
+		{
+			name:   "404 - wallet not found",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"0"},
+				"label": []string{"child"},
+			},
+			status:       http.StatusNotFound,
+			err:          "404 Not Found",
+			getWalletErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:   "400 - not a bip44 wallet",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"0"},
+				"label": []string{"child"},
+			},
+			status: http.StatusBadRequest,
+			err:    `400 Bad Request - wallet type must be "bip44"`,
+			parent: collection,
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"0"},
+				"label":    []string{"child"},
+				"password": []string{"wrong"},
+			},
+			status:           http.StatusBadRequest,
+			err:              "400 Bad Request - invalid password",
+			parent:           newParent(true),
+			getWalletSeedErr: wallet.ErrInvalidPassword,
+		},
+		{
+			name:   "403 - seed api disabled",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"0"},
+				"label":    []string{"child"},
+				"password": []string{"pwd"},
+			},
+			status:           http.StatusForbidden,
+			err:              "403 Forbidden",
+			parent:           newParent(true),
+			getWalletSeedErr: wallet.ErrSeedAPIDisabled,
+		},
+		{
+			name:   "400 - password for unencrypted wallet",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"0"},
+				"label":    []string{"child"},
+				"password": []string{"pwd"},
+			},
+			status:           http.StatusBadRequest,
+			err:              "400 Bad Request - wallet is not encrypted",
+			parent:           newParent(false),
+			getWalletSeedErr: wallet.ErrWalletNotEncrypted,
+		},
+		{
+			name:   "400 - unsupported words",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"0"},
+				"words":    []string{"15"},
+				"label":    []string{"child"},
+				"password": []string{"pwd"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - bip85 mnemonic must have 12, 18 or 24 words",
+			parent: newParent(true),
+		},
+		{
+			name:   "400 - index too large",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"2147483648"},
+				"label":    []string{"child"},
+				"password": []string{"pwd"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - bip85 index must be < 0x80000000",
+			parent: newParent(true),
+		},
+		{
+			name:   "400 - child already exists",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"0"},
+				"label":    []string{"child"},
+				"password": []string{"pwd"},
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - a wallet already exists with this seed",
+			parent: newParent(true),
+			options: &wallet.Options{
+				Type:      wallet.WalletTypeBip44,
+				Seed:      childMnemonic(12, 0),
+				Label:     "child",
+				Encrypt:   true,
+				Password:  []byte("pwd"),
+				ScanN:     1,
+				Bip44Coin: &bip44Coin,
+			},
+			createWalletErr: wallet.ErrSeedUsed,
+		},
+		{
+			name:   "200 - encrypted parent",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":       []string{"parent.wlt"},
+				"index":    []string{"3"},
+				"words":    []string{"24"},
+				"label":    []string{"child"},
+				"password": []string{"pwd"},
+				"scan":     []string{"5"},
+			},
+			status: http.StatusOK,
+			parent: newParent(true),
+			options: &wallet.Options{
+				Type:      wallet.WalletTypeBip44,
+				Seed:      childMnemonic(24, 3),
+				Label:     "child",
+				Encrypt:   true,
+				Password:  []byte("pwd"),
+				ScanN:     5,
+				Bip44Coin: &bip44Coin,
+			},
+			mnemonic: childMnemonic(24, 3),
+			path:     "m/83696968'/39'/0'/24'/3'",
+		},
+		{
+			name:   "200 - unencrypted parent",
+			method: http.MethodPost,
+			form: url.Values{
+				"id":    []string{"parent.wlt"},
+				"index": []string{"0"},
+				"label": []string{"child"},
+			},
+			status:           http.StatusOK,
+			parent:           newParent(false),
+			getWalletSeedErr: wallet.ErrWalletNotEncrypted,
+			options: &wallet.Options{
+				Type:      wallet.WalletTypeBip44,
+				Seed:      childMnemonic(12, 0),
+				Label:     "child",
+				Password:  []byte{},
+				ScanN:     1,
+				Bip44Coin: &bip44Coin,
+			},
+			mnemonic: childMnemonic(12, 0),
+			path:     "m/83696968'/39'/0'/12'/0'",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("GetWallet", "parent.wlt").Return(tc.parent, tc.getWalletErr)
+
+			password := tc.form.Get("password")
+			if tc.getWalletSeedErr != nil {
+				gateway.On("GetWalletSeed", "parent.wlt", []byte(password)).Return("", "", tc.getWalletSeedErr)
+			} else {
+				gateway.On("GetWalletSeed", "parent.wlt", []byte(password)).Return(seed, "passphrase", nil)
+			}
+
+			if tc.options != nil {
+				gateway.On("CreateWallet", "", *tc.options, gateway).Return(func(wltName string, opts wallet.Options, tf wallet.TransactionsFinder) wallet.Wallet {
+					if tc.createWalletErr != nil {
+						return nil
+					}
+					opts.ScanN = 0
+					opts.CryptoType = wallet.CryptoTypeSha256Xor
+					w, err := wallet.NewWallet("child.wlt", opts)
+					require.NoError(t, err)
+					return w
+				}, tc.createWalletErr)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallet/derive-child", strings.NewReader(tc.form.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var rsp WalletDeriveChildResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.mnemonic, rsp.Mnemonic)
+			require.Equal(t, tc.path, rsp.Path)
+			require.Equal(t, wallet.WalletTypeBip44, rsp.Meta.Type)
+			require.Equal(t, tc.options.Encrypt, rsp.Meta.Encrypted)
+			require.Len(t, rsp.Entries, 1)
+
+			// The wallet response does not include the seed
+			require.Equal(t, 1, strings.Count(rr.Body.String(), rsp.Mnemonic))
+
+			gateway.AssertCalled(t, "CreateWallet", "", *tc.options, gateway)
+		})
+	}
+}
+
 func TestWalletNewAddressesHandler(t *testing.T) {
 	type httpBody struct {
 		ID       string
 		Num      string
 		Password string
+		Preview  string
+		Commit   string
 	}
 	type Addresses struct {
 		Address []string `json:"addresses"`
@@ -1769,6 +2687,8 @@ func TestWalletNewAddressesHandler(t *testing.T) {
 		password                  string
 		gatewayNewAddressesResult []cipher.Address
 		gatewayNewAddressesErr    error
+		preview                   bool
+		commit                    []cipher.Address
 		responseBody              Addresses
 		csrfDisabled              bool
 	}{
@@ -1900,35 +2820,131 @@ func TestWalletNewAddressesHandler(t *testing.T) {
 			responseBody:              responseEmptyAddresses,
 		},
 		{
-			name:   "200 - OK - CSRF disabled",
+			name:   "400 - invalid preview value",
 			method: http.MethodPost,
 			body: &httpBody{
-				ID:  "foo",
-				Num: "1",
+				ID:      "foo",
+				Preview: "bar",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid preview value",
+		},
+		{
+			name:   "400 - preview and commit",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:      "foo",
+				Preview: "1",
+				Commit:  addrs[0].String(),
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - preview and commit can't be combined",
+		},
+		{
+			name:   "400 - num and commit",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:     "foo",
+				Num:    "1",
+				Commit: addrs[0].String(),
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - num and commit can't be combined",
+		},
+		{
+			name:   "400 - invalid commit value",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:     "foo",
+				Commit: "bar",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid commit value: address \"bar\" is invalid: Invalid address length",
+		},
+		{
+			name:   "200 - OK preview",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:      "foo",
+				Num:     "3",
+				Preview: "1",
 			},
 			status:                    http.StatusOK,
 			walletID:                  "foo",
-			n:                         1,
+			n:                         3,
+			preview:                   true,
 			gatewayNewAddressesResult: addrs,
 			responseBody:              responseAddresses,
-			csrfDisabled:              true,
 		},
-	}
-
-	for _, tc := range tt {
-		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
-			gateway.On("NewAddresses", tc.walletID, []byte(tc.password), tc.n).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
-
-			endpoint := "/api/v1/wallet/newAddress"
-
-			v := url.Values{}
-			if tc.body != nil {
-				if tc.body.ID != "" {
-					v.Add("id", tc.body.ID)
-				}
-				if tc.body.Num != "" {
-					v.Add("num", tc.body.Num)
+		{
+			name:   "200 - OK commit",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:     "foo",
+				Commit: strings.Join(responseAddresses.Address, ","),
+			},
+			status:                    http.StatusOK,
+			walletID:                  "foo",
+			commit:                    addrs,
+			gatewayNewAddressesResult: addrs,
+			responseBody:              responseAddresses,
+		},
+		{
+			name:   "409 - previewed addresses used",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:     "foo",
+				Commit: strings.Join(responseAddresses.Address, ","),
+			},
+			status:                 http.StatusConflict,
+			err:                    "409 Conflict - the previewed addresses are no longer the next addresses of the wallet, they were generated since the preview",
+			walletID:               "foo",
+			commit:                 addrs,
+			gatewayNewAddressesErr: wallet.ErrPreviewedAddressesUsed,
+		},
+		{
+			name:   "200 - OK - CSRF disabled",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:  "foo",
+				Num: "1",
+			},
+			status:                    http.StatusOK,
+			walletID:                  "foo",
+			n:                         1,
+			gatewayNewAddressesResult: addrs,
+			responseBody:              responseAddresses,
+			csrfDisabled:              true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			switch {
+			case tc.preview:
+				gateway.On("PreviewNewAddresses", tc.walletID, []byte(tc.password), tc.n).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
+			case tc.commit != nil:
+				gateway.On("CommitNewAddresses", tc.walletID, []byte(tc.password), tc.commit).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
+			default:
+				gateway.On("NewAddresses", tc.walletID, []byte(tc.password), tc.n).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
+			}
+
+			endpoint := "/api/v1/wallet/newAddress"
+
+			v := url.Values{}
+			if tc.body != nil {
+				if tc.body.ID != "" {
+					v.Add("id", tc.body.ID)
+				}
+				if tc.body.Num != "" {
+					v.Add("num", tc.body.Num)
+				}
+				if tc.body.Preview != "" {
+					v.Add("preview", tc.body.Preview)
+				}
+				if tc.body.Commit != "" {
+					v.Add("commit", tc.body.Commit)
 				}
 			}
 
@@ -2001,7 +3017,7 @@ func TestGetWalletFolderHandler(t *testing.T) {
 	}
 
 	for _, tc := range tt {
-		gateway := &MockGatewayer{}
+		gateway := newWalletMockGatewayer()
 		gateway.On("WalletDir").Return(tc.getWalletDirResponse, tc.getWalletDirErr)
 
 		endpoint := "/api/v1/wallets/folderName"
@@ -2035,11 +3051,150 @@ func TestGetWalletFolderHandler(t *testing.T) {
 	}
 }
 
+func TestWalletBackupsHandler(t *testing.T) {
+	backups := []wallet.Backup{
+		{
+			WalletID: "foo.wlt",
+			Filename: "foo.1540000000000000000.wlt",
+			Time:     time.Unix(0, 1540000000000000000),
+			Size:     1234,
+		},
+		{
+			WalletID: "foo.wlt",
+			Filename: "foo.1550000000000000000.wlt",
+			Time:     time.Unix(0, 1550000000000000000),
+			Size:     2345,
+		},
+	}
+
+	tt := []struct {
+		name                      string
+		method                    string
+		status                    int
+		err                       string
+		wltID                     string
+		listWalletBackupsResponse []wallet.Backup
+		listWalletBackupsErr      error
+		httpResponse              WalletBackupsResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:                 "400 - invalid wallet id",
+			method:               http.MethodGet,
+			status:               http.StatusBadRequest,
+			err:                  "400 Bad Request - invalid wallet id",
+			wltID:                "../foo.wlt",
+			listWalletBackupsErr: wallet.ErrInvalidWalletID,
+		},
+		{
+			name:                 "403 - wallet API disabled",
+			method:               http.MethodGet,
+			status:               http.StatusForbidden,
+			err:                  "403 Forbidden",
+			wltID:                "foo.wlt",
+			listWalletBackupsErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:                 "500 - other error",
+			method:               http.MethodGet,
+			status:               http.StatusInternalServerError,
+			err:                  "500 Internal Server Error - permission denied",
+			wltID:                "foo.wlt",
+			listWalletBackupsErr: errors.New("permission denied"),
+		},
+		{
+			name:                      "200 - no backups",
+			method:                    http.MethodGet,
+			status:                    http.StatusOK,
+			wltID:                     "foo.wlt",
+			listWalletBackupsResponse: []wallet.Backup{},
+			httpResponse: WalletBackupsResponse{
+				WalletID: "foo.wlt",
+				Backups:  []WalletBackup{},
+			},
+		},
+		{
+			name:                      "200",
+			method:                    http.MethodGet,
+			status:                    http.StatusOK,
+			wltID:                     "foo.wlt",
+			listWalletBackupsResponse: backups,
+			httpResponse: WalletBackupsResponse{
+				WalletID: "foo.wlt",
+				Backups: []WalletBackup{
+					{
+						Filename: "foo.1540000000000000000.wlt",
+						Time:     1540000000,
+						Size:     1234,
+					},
+					{
+						Filename: "foo.1550000000000000000.wlt",
+						Time:     1550000000,
+						Size:     2345,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("ListWalletBackups", tc.wltID).Return(tc.listWalletBackupsResponse, tc.listWalletBackupsErr)
+
+			v := url.Values{}
+			if tc.wltID != "" {
+				v.Add("id", tc.wltID)
+			}
+
+			endpoint := "/api/v1/wallets/backups?" + v.Encode()
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				var msg WalletBackupsResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse, msg)
+			}
+		})
+	}
+}
+
 func TestGetWallets(t *testing.T) {
 	var pubkeys []cipher.PubKey
 	var seckeys []cipher.SecKey
 	var addrs []cipher.Address
 
+	bip44CoinSky := bip44.CoinTypeSkycoin
+
 	for i := 0; i < 4; i++ {
 		pubkey, seckey := cipher.GenerateKeyPair()
 		addr := cipher.AddressFromPubKey(pubkey)
@@ -2049,13 +3204,14 @@ func TestGetWallets(t *testing.T) {
 	}
 
 	cases := []struct {
-		name               string
-		method             string
-		status             int
-		err                string
-		getWalletsResponse wallet.Wallets
-		getWalletsErr      error
-		httpResponse       []*WalletResponse
+		name                string
+		method              string
+		status              int
+		err                 string
+		listWalletsResponse []wallet.WalletHeader
+		listWalletsErr      error
+		label               string
+		httpResponse        []*WalletResponse
 	}{
 		{
 			name:   "405",
@@ -2064,32 +3220,91 @@ func TestGetWallets(t *testing.T) {
 			err:    "405 Method Not Allowed",
 		},
 		{
-			name:          "403 - wallet API disabled",
-			method:        http.MethodGet,
-			status:        http.StatusForbidden,
-			err:           "403 Forbidden",
-			getWalletsErr: wallet.ErrWalletAPIDisabled,
+			name:           "403 - wallet API disabled",
+			method:         http.MethodGet,
+			status:         http.StatusForbidden,
+			err:            "403 Forbidden",
+			listWalletsErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:                "200 no wallets",
+			method:              http.MethodGet,
+			status:              http.StatusOK,
+			listWalletsResponse: nil,
+			httpResponse:        []*WalletResponse{},
 		},
 		{
-			name:               "200 no wallets",
-			method:             http.MethodGet,
-			status:             http.StatusOK,
-			getWalletsResponse: nil,
-			httpResponse:       []*WalletResponse{},
+			name:                "200 no wallets 2",
+			method:              http.MethodGet,
+			status:              http.StatusOK,
+			listWalletsResponse: []wallet.WalletHeader{},
+			httpResponse:        []*WalletResponse{},
 		},
 		{
-			name:               "200 no wallets 2",
-			method:             http.MethodGet,
-			status:             http.StatusOK,
-			getWalletsResponse: wallet.Wallets{},
-			httpResponse:       []*WalletResponse{},
+			name:   "200 label",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			label:  "savings",
+			listWalletsResponse: []wallet.WalletHeader{
+				{
+					Meta: wallet.Meta{
+						"filename": "foofilename",
+						"label":    "savings",
+						"type":     "deterministic",
+						"tm":       "345678",
+					},
+				},
+				{
+					Meta: wallet.Meta{
+						"filename": "foofilename2",
+						"label":    "spending",
+						"type":     "deterministic",
+						"tm":       "123456",
+					},
+				},
+				{
+					Meta: wallet.Meta{
+						"filename": "foofilename3",
+						"label":    "savings",
+						"type":     "deterministic",
+						"tm":       "234567",
+					},
+				},
+			},
+			httpResponse: []*WalletResponse{
+				{
+					Meta: WalletMeta{
+						WalletMeta: readable.WalletMeta{
+							Filename:  "foofilename3",
+							Label:     "savings",
+							Type:      "deterministic",
+							Timestamp: 234567,
+						},
+						Unloaded: true,
+					},
+					Entries: []WalletEntry{},
+				},
+				{
+					Meta: WalletMeta{
+						WalletMeta: readable.WalletMeta{
+							Filename:  "foofilename",
+							Label:     "savings",
+							Type:      "deterministic",
+							Timestamp: 345678,
+						},
+						Unloaded: true,
+					},
+					Entries: []WalletEntry{},
+				},
+			},
 		},
 		{
 			name:   "200",
 			method: http.MethodGet,
 			status: http.StatusOK,
-			getWalletsResponse: wallet.Wallets{
-				"foofilename": &wallet.DeterministicWallet{
+			listWalletsResponse: []wallet.WalletHeader{
+				{
+					Loaded: true,
 					Meta: wallet.Meta{
 						"foo":        "bar",
 						"seed":       "fooseed",
@@ -2103,7 +3318,7 @@ func TestGetWallets(t *testing.T) {
 						"tm":         "345678",
 						"encrypted":  "true",
 					},
-					Entries: []wallet.Entry{
+					Entries: wallet.Entries{
 						{
 							Address: addrs[0],
 							Public:  pubkeys[0],
@@ -2111,7 +3326,8 @@ func TestGetWallets(t *testing.T) {
 						},
 					},
 				},
-				"foofilename2": &wallet.DeterministicWallet{
+				{
+					Loaded: true,
 					Meta: wallet.Meta{
 						"foo":        "bar2",
 						"seed":       "fooseed2",
@@ -2125,7 +3341,7 @@ func TestGetWallets(t *testing.T) {
 						"tm":         "123456",
 						"encrypted":  "false",
 					},
-					Entries: []wallet.Entry{
+					Entries: wallet.Entries{
 						{
 							Address: addrs[1],
 							Public:  pubkeys[1],
@@ -2133,7 +3349,8 @@ func TestGetWallets(t *testing.T) {
 						},
 					},
 				},
-				"foofilename3": &wallet.DeterministicWallet{
+				{
+					Loaded: true,
 					Meta: wallet.Meta{
 						"foo":        "bar3",
 						"seed":       "fooseed3",
@@ -2147,7 +3364,7 @@ func TestGetWallets(t *testing.T) {
 						"tm":         "234567",
 						"encrypted":  "true",
 					},
-					Entries: []wallet.Entry{
+					Entries: wallet.Entries{
 						{
 							Address: addrs[2],
 							Public:  pubkeys[2],
@@ -2160,75 +3377,124 @@ func TestGetWallets(t *testing.T) {
 						},
 					},
 				},
+				{
+					Meta: wallet.Meta{
+						"coin":        "foocoin",
+						"filename":    "foofilename4",
+						"label":       "foolabel4",
+						"type":        "bip44",
+						"version":     "fooversion",
+						"cryptoType":  "foocryptotype",
+						"tm":          "456789",
+						"encrypted":   "true",
+						"bip44Coin":   "8000",
+						"reuseChange": "true",
+					},
+				},
 			},
 			httpResponse: []*WalletResponse{
 				{
-					Meta: readable.WalletMeta{
-						Coin:       "foocoin",
-						Filename:   "foofilename2",
-						Label:      "foolabel2",
-						Type:       "footype",
-						Version:    "fooversion",
-						CryptoType: "foocryptotype",
-						Timestamp:  123456,
-						Encrypted:  false,
+					Meta: WalletMeta{
+						WalletMeta: readable.WalletMeta{
+							Coin:       "foocoin",
+							Filename:   "foofilename2",
+							Label:      "foolabel2",
+							Type:       "footype",
+							Version:    "fooversion",
+							CryptoType: "foocryptotype",
+							Timestamp:  123456,
+							Encrypted:  false,
+						},
 					},
-					Entries: []readable.WalletEntry{
+					Entries: []WalletEntry{
 						{
-							Address: addrs[1].String(),
-							Public:  pubkeys[1].Hex(),
+							WalletEntry: readable.WalletEntry{
+								Address: addrs[1].String(),
+								Public:  pubkeys[1].Hex(),
+							},
 						},
 					},
 				},
 				{
-					Meta: readable.WalletMeta{
-						Coin:       "foocoin",
-						Filename:   "foofilename3",
-						Label:      "foolabel3",
-						Type:       "footype",
-						Version:    "fooversion",
-						CryptoType: "foocryptotype",
-						Timestamp:  234567,
-						Encrypted:  true,
+					Meta: WalletMeta{
+						WalletMeta: readable.WalletMeta{
+							Coin:       "foocoin",
+							Filename:   "foofilename3",
+							Label:      "foolabel3",
+							Type:       "footype",
+							Version:    "fooversion",
+							CryptoType: "foocryptotype",
+							Timestamp:  234567,
+							Encrypted:  true,
+						},
 					},
-					Entries: []readable.WalletEntry{
+					Entries: []WalletEntry{
 						{
-							Address: addrs[2].String(),
-							Public:  pubkeys[2].Hex(),
+							WalletEntry: readable.WalletEntry{
+								Address: addrs[2].String(),
+								Public:  pubkeys[2].Hex(),
+							},
 						},
 						{
-							Address: addrs[3].String(),
-							Public:  pubkeys[3].Hex(),
+							WalletEntry: readable.WalletEntry{
+								Address: addrs[3].String(),
+								Public:  pubkeys[3].Hex(),
+							},
 						},
 					},
 				},
 				{
-					Meta: readable.WalletMeta{
-						Coin:       "foocoin",
-						Filename:   "foofilename",
-						Label:      "foolabel",
-						Type:       "footype",
-						Version:    "fooversion",
-						CryptoType: "foocryptotype",
-						Timestamp:  345678,
-						Encrypted:  true,
+					Meta: WalletMeta{
+						WalletMeta: readable.WalletMeta{
+							Coin:       "foocoin",
+							Filename:   "foofilename",
+							Label:      "foolabel",
+							Type:       "footype",
+							Version:    "fooversion",
+							CryptoType: "foocryptotype",
+							Timestamp:  345678,
+							Encrypted:  true,
+						},
 					},
-					Entries: []readable.WalletEntry{
+					Entries: []WalletEntry{
 						{
-							Address: addrs[0].String(),
-							Public:  pubkeys[0].Hex(),
+							WalletEntry: readable.WalletEntry{
+								Address: addrs[0].String(),
+								Public:  pubkeys[0].Hex(),
+							},
+						},
+					},
+				},
+				{
+					Meta: WalletMeta{
+						WalletMeta: readable.WalletMeta{
+							Coin:       "foocoin",
+							Filename:   "foofilename4",
+							Label:      "foolabel4",
+							Type:       "bip44",
+							Version:    "fooversion",
+							CryptoType: "foocryptotype",
+							Timestamp:  456789,
+							Encrypted:  true,
+							Bip44Coin:  &bip44CoinSky,
 						},
+						ReuseChange: true,
+						Unloaded:    true,
 					},
+					Entries: []WalletEntry{},
 				},
 			},
 		},
 	}
 
 	for _, tc := range cases {
-		gateway := &MockGatewayer{}
-		gateway.On("GetWallets").Return(tc.getWalletsResponse, tc.getWalletsErr)
+		gateway := newWalletMockGatewayer()
+		gateway.On("ListWallets").Return(tc.listWalletsResponse, tc.listWalletsErr)
 
 		endpoint := "/api/v1/wallets"
+		if tc.label != "" {
+			endpoint += "?label=" + url.QueryEscape(tc.label)
+		}
 
 		req, err := http.NewRequest(tc.method, endpoint, nil)
 		require.NoError(t, err)
@@ -2255,91 +3521,395 @@ func TestGetWallets(t *testing.T) {
 			var msg []*WalletResponse
 			err := json.Unmarshal(rr.Body.Bytes(), &msg)
 			require.NoError(t, err)
-			require.NotNil(t, msg)
-			require.Equal(t, tc.httpResponse, msg, tc.name)
-		}
+			require.NotNil(t, msg)
+			require.Equal(t, tc.httpResponse, msg, tc.name)
+		}
+	}
+}
+
+func TestWalletUnloadHandler(t *testing.T) {
+	tt := []struct {
+		name            string
+		method          string
+		status          int
+		err             string
+		walletID        string
+		unloadWalletErr error
+		csrfDisabled    bool
+	}{
+		{
+			name:     "405",
+			method:   http.MethodGet,
+			status:   http.StatusMethodNotAllowed,
+			err:      "405 Method Not Allowed",
+			walletID: "wallet.wlt",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:            "403 - Forbidden - wallet API disabled",
+			method:          http.MethodPost,
+			status:          http.StatusForbidden,
+			err:             "403 Forbidden",
+			walletID:        "wallet.wlt",
+			unloadWalletErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:            "409 - wallet in use",
+			method:          http.MethodPost,
+			status:          http.StatusConflict,
+			err:             "409 Conflict - wallet is in use",
+			walletID:        "wallet.wlt",
+			unloadWalletErr: wallet.ErrWalletInUse,
+		},
+		{
+			name:     "200 - ok",
+			method:   http.MethodPost,
+			status:   http.StatusOK,
+			walletID: "wallet.wlt",
+		},
+		{
+			name:         "200 - ok, csrf disabled",
+			method:       http.MethodPost,
+			status:       http.StatusOK,
+			walletID:     "wallet.wlt",
+			csrfDisabled: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("UnloadWallet", tc.walletID).Return(tc.unloadWalletErr)
+
+			endpoint := "/api/v1/wallet/unload"
+			v := url.Values{}
+			v.Add("id", tc.walletID)
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			if tc.csrfDisabled {
+				setCSRFParameters(t, tokenInvalid, req)
+			} else {
+				setCSRFParameters(t, tokenValid, req)
+			}
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = tc.csrfDisabled
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			}
+		})
+	}
+}
+
+func TestWalletXPubHandler(t *testing.T) {
+	xpub := "xpub6BvenwtHLSGqw9v1GiwRe2uuDZW93Gi9H61Lqo9AaegvdcmysqLGYBmn1eku88NFb1nKJgp11vrvcEi8kxvpoZBNwxemDRWPustZzbUgUZt"
+
+	tt := []struct {
+		name        string
+		method      string
+		query       string
+		account     uint32
+		mock        bool
+		xpub        *wallet.AccountXPub
+		gatewayErr  error
+		status      int
+		err         string
+		expectedRsp WalletXPubResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			query:  "id=foo.wlt",
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - invalid account",
+			method: http.MethodGet,
+			query:  "id=foo.wlt&account=x",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid account",
+		},
+		{
+			name:   "400 - hardened account",
+			method: http.MethodGet,
+			query:  "id=foo.wlt&account=2147483648",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid account",
+		},
+		{
+			name:       "400 - not bip44",
+			method:     http.MethodGet,
+			query:      "id=foo.wlt",
+			mock:       true,
+			gatewayErr: wallet.NewError(errors.New(`account xpubs are only stored in "bip44" wallets`)),
+			status:     http.StatusBadRequest,
+			err:        `400 Bad Request - account xpubs are only stored in "bip44" wallets`,
+		},
+		{
+			name:       "403 - wallet API disabled",
+			method:     http.MethodGet,
+			query:      "id=foo.wlt",
+			mock:       true,
+			gatewayErr: wallet.ErrWalletAPIDisabled,
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden",
+		},
+		{
+			name:       "404 - wallet not found",
+			method:     http.MethodGet,
+			query:      "id=foo.wlt",
+			mock:       true,
+			gatewayErr: wallet.ErrWalletNotExist,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+		},
+		{
+			name:       "404 - xpub not stored",
+			method:     http.MethodGet,
+			query:      "id=foo.wlt",
+			mock:       true,
+			gatewayErr: wallet.ErrAccountXPubNotStored,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found - " + wallet.ErrAccountXPubNotStored.Error(),
+		},
+		{
+			name:       "500",
+			method:     http.MethodGet,
+			query:      "id=foo.wlt",
+			mock:       true,
+			gatewayErr: errors.New("GetWalletAccountXPub failed"),
+			status:     http.StatusInternalServerError,
+			err:        "500 Internal Server Error - GetWalletAccountXPub failed",
+		},
+		{
+			name:   "200 - unencrypted",
+			method: http.MethodGet,
+			query:  "id=foo.wlt",
+			mock:   true,
+			xpub: &wallet.AccountXPub{
+				XPub: xpub,
+			},
+			status: http.StatusOK,
+			expectedRsp: WalletXPubResponse{
+				XPub: xpub,
+			},
+		},
+		{
+			name:    "200 - encrypted",
+			method:  http.MethodGet,
+			query:   "id=foo.wlt&account=1",
+			account: 1,
+			mock:    true,
+			xpub: &wallet.AccountXPub{
+				Account:   1,
+				XPub:      xpub,
+				Encrypted: true,
+			},
+			status: http.StatusOK,
+			expectedRsp: WalletXPubResponse{
+				Account:   1,
+				XPub:      xpub,
+				Encrypted: true,
+				Note:      encryptedXPubNote,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			if tc.mock {
+				gateway.On("GetWalletAccountXPub", "foo.wlt", tc.account).Return(tc.xpub, tc.gatewayErr)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallet/xpub?"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var rsp WalletXPubResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRsp, rsp)
+			gateway.AssertExpectations(t)
+		})
 	}
 }
 
-func TestWalletUnloadHandler(t *testing.T) {
+func TestWalletMetadataHandlers(t *testing.T) {
 	tt := []struct {
-		name            string
-		method          string
-		status          int
-		err             string
-		walletID        string
-		unloadWalletErr error
-		csrfDisabled    bool
+		name        string
+		method      string
+		endpoint    string
+		form        url.Values
+		mock        bool
+		status      int
+		err         string
+		gatewayErr  error
+		expectedArg []byte
 	}{
 		{
 			name:     "405",
 			method:   http.MethodGet,
+			endpoint: "/api/v1/wallet/metadata/unlock",
 			status:   http.StatusMethodNotAllowed,
 			err:      "405 Method Not Allowed",
-			walletID: "wallet.wlt",
 		},
 		{
-			name:   "400 - missing wallet id",
-			method: http.MethodPost,
-			status: http.StatusBadRequest,
-			err:    "400 Bad Request - missing wallet id",
+			name:     "400 - missing wallet id",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/wallet/metadata/unlock",
+			form:     url.Values{},
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - missing wallet id",
 		},
 		{
-			name:            "403 - Forbidden - wallet API disabled",
-			method:          http.MethodPost,
-			status:          http.StatusForbidden,
-			err:             "403 Forbidden",
-			walletID:        "wallet.wlt",
-			unloadWalletErr: wallet.ErrWalletAPIDisabled,
+			name:        "400 - invalid password",
+			method:      http.MethodPost,
+			endpoint:    "/api/v1/wallet/metadata/unlock",
+			form:        url.Values{"id": {"foo.wlt"}, "password": {"wrong"}},
+			mock:        true,
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - invalid password",
+			gatewayErr:  wallet.ErrInvalidPassword,
+			expectedArg: []byte("wrong"),
 		},
 		{
-			name:     "200 - ok",
+			name:        "400 - metadata tampered",
+			method:      http.MethodPost,
+			endpoint:    "/api/v1/wallet/metadata/unlock",
+			form:        url.Values{"id": {"foo.wlt"}, "password": {"pwd"}},
+			mock:        true,
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - wallet metadata was modified without the wallet password",
+			gatewayErr:  wallet.ErrWalletMetadataTampered,
+			expectedArg: []byte("pwd"),
+		},
+		{
+			name:        "403 - wallet API disabled",
+			method:      http.MethodPost,
+			endpoint:    "/api/v1/wallet/metadata/unlock",
+			form:        url.Values{"id": {"foo.wlt"}, "password": {"pwd"}},
+			mock:        true,
+			status:      http.StatusForbidden,
+			err:         "403 Forbidden",
+			gatewayErr:  wallet.ErrWalletAPIDisabled,
+			expectedArg: []byte("pwd"),
+		},
+		{
+			name:        "404 - wallet not found",
+			method:      http.MethodPost,
+			endpoint:    "/api/v1/wallet/metadata/unlock",
+			form:        url.Values{"id": {"foo.wlt"}, "password": {"pwd"}},
+			mock:        true,
+			status:      http.StatusNotFound,
+			err:         "404 Not Found",
+			gatewayErr:  wallet.ErrWalletNotExist,
+			expectedArg: []byte("pwd"),
+		},
+		{
+			name:        "200 - unlock",
+			method:      http.MethodPost,
+			endpoint:    "/api/v1/wallet/metadata/unlock",
+			form:        url.Values{"id": {"foo.wlt"}, "password": {"pwd"}},
+			mock:        true,
+			status:      http.StatusOK,
+			expectedArg: []byte("pwd"),
+		},
+		{
+			name:     "405 - lock",
+			method:   http.MethodGet,
+			endpoint: "/api/v1/wallet/metadata/lock",
+			status:   http.StatusMethodNotAllowed,
+			err:      "405 Method Not Allowed",
+		},
+		{
+			name:     "400 - lock missing wallet id",
 			method:   http.MethodPost,
-			status:   http.StatusOK,
-			walletID: "wallet.wlt",
+			endpoint: "/api/v1/wallet/metadata/lock",
+			form:     url.Values{},
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - missing wallet id",
 		},
 		{
-			name:         "200 - ok, csrf disabled",
-			method:       http.MethodPost,
-			status:       http.StatusOK,
-			walletID:     "wallet.wlt",
-			csrfDisabled: true,
+			name:       "404 - lock wallet not found",
+			method:     http.MethodPost,
+			endpoint:   "/api/v1/wallet/metadata/lock",
+			form:       url.Values{"id": {"foo.wlt"}},
+			mock:       true,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+			gatewayErr: wallet.ErrWalletNotExist,
+		},
+		{
+			name:     "200 - lock",
+			method:   http.MethodPost,
+			endpoint: "/api/v1/wallet/metadata/lock",
+			form:     url.Values{"id": {"foo.wlt"}},
+			mock:     true,
+			status:   http.StatusOK,
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
-			gateway.On("UnloadWallet", tc.walletID).Return(tc.unloadWalletErr)
-
-			endpoint := "/api/v1/wallet/unload"
-			v := url.Values{}
-			v.Add("id", tc.walletID)
+			gateway := newWalletMockGatewayer()
+			if tc.mock {
+				if tc.endpoint == "/api/v1/wallet/metadata/lock" {
+					gateway.On("LockWalletMetadata", "foo.wlt").Return(tc.gatewayErr)
+				} else {
+					gateway.On("UnlockWalletMetadata", "foo.wlt", tc.expectedArg).Return(tc.gatewayErr)
+				}
+			}
 
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			req, err := http.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.form.Encode()))
 			require.NoError(t, err)
 			req.Header.Add("Content-Type", ContentTypeForm)
-
-			if tc.csrfDisabled {
-				setCSRFParameters(t, tokenInvalid, req)
-			} else {
-				setCSRFParameters(t, tokenValid, req)
-			}
+			setCSRFParameters(t, tokenValid, req)
 
 			rr := httptest.NewRecorder()
-
-			cfg := defaultMuxConfig()
-			cfg.disableCSRF = tc.csrfDisabled
-
-			handler := newServerMux(cfg, gateway)
+			handler := newServerMux(defaultMuxConfig(), gateway)
 			handler.ServeHTTP(rr, req)
 
-			status := rr.Code
-			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`", status, tc.status)
-
-			if status != http.StatusOK {
-				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
-					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			} else {
+				require.Equal(t, "\"success\"", rr.Body.String())
 			}
 		})
 	}
@@ -2380,9 +3950,11 @@ func TestEncryptWallet(t *testing.T) {
 			},
 			status: http.StatusOK,
 			expectWallet: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "wallet.wlt",
-					Encrypted: true,
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename:  "wallet.wlt",
+						Encrypted: true,
+					},
 				},
 				Entries: responseEntries,
 			},
@@ -2461,7 +4033,7 @@ func TestEncryptWallet(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			gateway.On("EncryptWallet", tc.wltID, []byte(tc.password)).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
 
 			endpoint := "/api/v1/wallet/encrypt"
@@ -2536,9 +4108,11 @@ func TestDecryptWallet(t *testing.T) {
 			},
 			status: http.StatusOK,
 			expectWallet: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "wallet",
-					Encrypted: false,
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename:  "wallet",
+						Encrypted: false,
+					},
 				},
 				Entries: responseEntries,
 			},
@@ -2562,9 +4136,11 @@ func TestDecryptWallet(t *testing.T) {
 			},
 			status: http.StatusOK,
 			expectWallet: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "wallet",
-					Encrypted: false,
+				Meta: WalletMeta{
+					WalletMeta: readable.WalletMeta{
+						Filename:  "wallet",
+						Encrypted: false,
+					},
 				},
 				Entries: responseEntries,
 			},
@@ -2644,7 +4220,7 @@ func TestDecryptWallet(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
+			gateway := newWalletMockGatewayer()
 			gateway.On("DecryptWallet", tc.wltID, []byte(tc.password)).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
 
 			endpoint := "/api/v1/wallet/decrypt"
@@ -2683,12 +4259,11 @@ func TestDecryptWallet(t *testing.T) {
 }
 
 // makeEntries derives N wallet address entries from given seed
-// Returns set of wallet.Entry and wallet.ReadableEntry, the readable
-// entries' secrets are removed.
-func makeEntries(seed []byte, n int) ([]wallet.Entry, []readable.WalletEntry) { //nolint:unparam
+// Returns set of wallet.Entry and the WalletEntry of each in a response.
+func makeEntries(seed []byte, n int) ([]wallet.Entry, []WalletEntry) { //nolint:unparam
 	seckeys := cipher.MustGenerateDeterministicKeyPairs(seed, n)
 	var entries []wallet.Entry
-	var responseEntries []readable.WalletEntry
+	var responseEntries []WalletEntry
 	for i, seckey := range seckeys {
 		pubkey := cipher.MustPubKeyFromSecKey(seckey)
 		entries = append(entries, wallet.Entry{
@@ -2696,9 +4271,11 @@ func makeEntries(seed []byte, n int) ([]wallet.Entry, []readable.WalletEntry) {
 			Public:  pubkey,
 			Secret:  seckey,
 		})
-		responseEntries = append(responseEntries, readable.WalletEntry{
-			Address: entries[i].Address.String(),
-			Public:  entries[i].Public.Hex(),
+		responseEntries = append(responseEntries, WalletEntry{
+			WalletEntry: readable.WalletEntry{
+				Address: entries[i].Address.String(),
+				Public:  entries[i].Public.Hex(),
+			},
 		})
 	}
 	return entries, responseEntries
@@ -2711,45 +4288,18 @@ func cloneEntries(es []wallet.Entry) []wallet.Entry {
 }
 
 func TestWalletRecover(t *testing.T) {
-	type gatewayReturnPair struct {
-		w   wallet.Wallet
-		err error
-	}
-
-	okWalletUnencrypted, err := wallet.NewWallet("foo", wallet.Options{
-		Type:      wallet.WalletTypeDeterministic,
-		Coin:      wallet.CoinTypeSkycoin,
-		Label:     "foolabel",
-		Seed:      "fooseed",
-		GenerateN: 10,
-	})
-	require.NoError(t, err)
-	okWalletUnencryptedResponse, err := NewWalletResponse(okWalletUnencrypted)
-	require.NoError(t, err)
-
-	okWalletEncrypted, err := wallet.NewWallet("foo", wallet.Options{
-		Type:       wallet.WalletTypeDeterministic,
-		Coin:       wallet.CoinTypeSkycoin,
-		Label:      "foolabel",
-		Seed:       "fooseed",
-		Encrypt:    true,
-		Password:   []byte("foopassword"),
-		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
-		GenerateN:  10,
-	})
-	require.NoError(t, err)
-	okWalletEncryptedResponse, err := NewWalletResponse(okWalletEncrypted)
-	require.NoError(t, err)
-
 	cases := []struct {
 		name          string
 		method        string
 		status        int
 		contentType   string
 		req           *WalletRecoverRequest
+		query         string
 		httpBody      string
 		httpResponse  HTTPResponse
-		gatewayReturn gatewayReturnPair
+		gatewayJobID  string
+		gatewayCancel bool
+		gatewayErr    error
 	}{
 		{
 			name:         "method not allowed",
@@ -2773,7 +4323,7 @@ func TestWalletRecover(t *testing.T) {
 			status:       http.StatusBadRequest,
 			contentType:  ContentTypeJSON,
 			httpBody:     "",
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "empty request body"),
 		},
 		{
 			name:        "id missing",
@@ -2804,9 +4354,7 @@ func TestWalletRecover(t *testing.T) {
 				ID:   "foo",
 				Seed: "fooseed",
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotEncrypted,
-			},
+			gatewayErr:   wallet.ErrWalletNotEncrypted,
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletNotEncrypted.Error()),
 		},
 		{
@@ -2818,24 +4366,32 @@ func TestWalletRecover(t *testing.T) {
 				ID:   "foo",
 				Seed: "fooseed",
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletRecoverSeedWrong,
-			},
+			gatewayErr:   wallet.ErrWalletRecoverSeedWrong,
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletRecoverSeedWrong.Error()),
 		},
 		{
-			name:        "wallet does not exist",
+			name:        "invalid wallet filename",
 			method:      http.MethodPost,
-			status:      http.StatusNotFound,
+			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
 			req: &WalletRecoverRequest{
 				ID:   "foo",
 				Seed: "fooseed",
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotExist,
+			gatewayErr:   wallet.ErrInvalidWalletFilename,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet filename must be a file name with the .wlt extension"),
+		},
+		{
+			name:        "recovery in progress",
+			method:      http.MethodPost,
+			status:      http.StatusConflict,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+			gatewayErr:   wallet.ErrRecoveryInProgress,
+			httpResponse: NewHTTPErrorResponse(http.StatusConflict, "a wallet recovery is already in progress"),
 		},
 		{
 			name:        "wallet api disabled",
@@ -2846,9 +4402,7 @@ func TestWalletRecover(t *testing.T) {
 				ID:   "foo",
 				Seed: "fooseed",
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletAPIDisabled,
-			},
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
 			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
 		},
 		{
@@ -2860,9 +4414,7 @@ func TestWalletRecover(t *testing.T) {
 				ID:   "foo",
 				Seed: "fooseed",
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: errors.New("wallet error"),
-			},
+			gatewayErr:   errors.New("wallet error"),
 			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
 		},
 		{
@@ -2874,58 +4426,89 @@ func TestWalletRecover(t *testing.T) {
 				ID:   "foo",
 				Seed: "fooseed",
 			},
-			gatewayReturn: gatewayReturnPair{
-				w: okWalletUnencrypted,
-			},
+			gatewayJobID: "a1b2",
 			httpResponse: HTTPResponse{
-				Data: *okWalletUnencryptedResponse,
+				Data: WalletRecoverResponse{
+					JobID: "a1b2",
+				},
 			},
 		},
 		{
-			name:        "ok, seed passphrase, no password",
+			name:        "ok, new wallet, password, scan",
 			method:      http.MethodPost,
 			status:      http.StatusOK,
 			contentType: ContentTypeJSON,
 			req: &WalletRecoverRequest{
-				ID:             "foo",
+				ID:             "foo.wlt",
 				Seed:           "fooseed",
 				SeedPassphrase: "fooseedpassphrase",
+				Password:       "foopassword",
+				Type:           wallet.WalletTypeBip44,
+				Label:          "foo",
+				ScanN:          1000,
 			},
-			gatewayReturn: gatewayReturnPair{
-				w: okWalletUnencrypted,
-			},
+			gatewayJobID: "a1b2",
 			httpResponse: HTTPResponse{
-				Data: *okWalletUnencryptedResponse,
+				Data: WalletRecoverResponse{
+					JobID: "a1b2",
+				},
 			},
 		},
 		{
-			name:        "ok, password",
-			method:      http.MethodPost,
-			status:      http.StatusOK,
-			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:       "foo",
-				Seed:     "fooseed",
-				Password: "foopassword",
-			},
-			gatewayReturn: gatewayReturnPair{
-				w: okWalletEncrypted,
-			},
-			httpResponse: HTTPResponse{
-				Data: *okWalletEncryptedResponse,
-			},
+			name:         "cancel, job missing",
+			method:       http.MethodDelete,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "job is required"),
+		},
+		{
+			name:          "cancel, job not found",
+			method:        http.MethodDelete,
+			status:        http.StatusNotFound,
+			query:         "a1b2",
+			gatewayCancel: true,
+			gatewayErr:    wallet.ErrRecoveryNotExist,
+			httpResponse:  NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:          "cancel, job finished",
+			method:        http.MethodDelete,
+			status:        http.StatusConflict,
+			query:         "a1b2",
+			gatewayCancel: true,
+			gatewayErr:    wallet.ErrRecoveryFinished,
+			httpResponse:  NewHTTPErrorResponse(http.StatusConflict, "wallet recovery job has finished"),
+		},
+		{
+			name:          "cancel, ok",
+			method:        http.MethodDelete,
+			status:        http.StatusOK,
+			query:         "a1b2",
+			gatewayCancel: true,
+			httpResponse:  HTTPResponse{},
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
-			if tc.req != nil {
+			gateway := newWalletMockGatewayer()
+			if tc.req != nil && tc.req.ID != "" && tc.req.Seed != "" {
 				var password []byte
 				if tc.req.Password != "" {
 					password = []byte(tc.req.Password)
 				}
-				gateway.On("RecoverWallet", tc.req.ID, tc.req.Seed, tc.req.SeedPassphrase, password).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+				gateway.On("StartWalletRecovery", wallet.RecoveryOptions{
+					WalletID:       tc.req.ID,
+					Seed:           tc.req.Seed,
+					SeedPassphrase: tc.req.SeedPassphrase,
+					Password:       password,
+					Type:           tc.req.Type,
+					Label:          tc.req.Label,
+					ScanN:          tc.req.ScanN,
+				}, gateway).Return(tc.gatewayJobID, tc.gatewayErr)
+			}
+
+			if tc.gatewayCancel {
+				gateway.On("CancelWalletRecovery", tc.query).Return(tc.gatewayErr)
 			}
 
 			if tc.httpBody == "" && tc.req != nil {
@@ -2933,6 +4516,10 @@ func TestWalletRecover(t *testing.T) {
 			}
 
 			endpoint := "/api/v2/wallet/recover"
+			if tc.query != "" {
+				endpoint += "?job=" + tc.query
+			}
+
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
 			require.NoError(t, err)
 
@@ -2967,12 +4554,194 @@ func TestWalletRecover(t *testing.T) {
 			} else {
 				require.NotNil(t, tc.httpResponse.Data)
 
-				var wltRsp WalletResponse
-				err := json.Unmarshal(rsp.Data, &wltRsp)
+				var recoverRsp WalletRecoverResponse
+				err := json.Unmarshal(rsp.Data, &recoverRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletRecoverResponse), recoverRsp)
+			}
+
+			gateway.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWalletRecoverStatus(t *testing.T) {
+	started := time.Unix(1560000000, 0).UTC()
+
+	running := wallet.RecoveryStatus{
+		JobID:            "a1b2",
+		WalletID:         "foo.wlt",
+		State:            wallet.RecoveryStateRunning,
+		ScanN:            1000,
+		AddressesScanned: 1200,
+		AddressesFound:   12,
+		ChainIndex:       1,
+		ChildIndex:       200,
+		Started:          started,
+	}
+
+	failed := running
+	failed.State = wallet.RecoveryStateFailed
+	failed.Finished = started.Add(time.Minute)
+	failed.Error = "disk full"
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		query         string
+		gatewayStatus *wallet.RecoveryStatus
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodPost,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "job missing",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "job is required"),
+		},
+		{
+			name:         "job not found",
+			method:       http.MethodGet,
+			status:       http.StatusNotFound,
+			query:        "a1b2",
+			gatewayErr:   wallet.ErrRecoveryNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:          "running",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			query:         "a1b2",
+			gatewayStatus: &running,
+			httpResponse: HTTPResponse{
+				Data: WalletRecoveryStatusResponse{
+					JobID:            "a1b2",
+					WalletID:         "foo.wlt",
+					State:            "running",
+					ScanN:            1000,
+					AddressesScanned: 1200,
+					AddressesFound:   12,
+					ChainIndex:       1,
+					ChildIndex:       200,
+					Started:          1560000000,
+				},
+			},
+		},
+		{
+			name:          "failed",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			query:         "a1b2",
+			gatewayStatus: &failed,
+			httpResponse: HTTPResponse{
+				Data: WalletRecoveryStatusResponse{
+					JobID:            "a1b2",
+					WalletID:         "foo.wlt",
+					State:            "failed",
+					ScanN:            1000,
+					AddressesScanned: 1200,
+					AddressesFound:   12,
+					ChainIndex:       1,
+					ChildIndex:       200,
+					Started:          1560000000,
+					Finished:         1560000060,
+					Error:            "disk full",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			if tc.query != "" {
+				gateway.On("WalletRecoveryStatus", tc.query).Return(tc.gatewayStatus, tc.gatewayErr)
+			}
+
+			endpoint := "/api/v2/wallet/recover/status"
+			if tc.query != "" {
+				endpoint += "?job=" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var statusRsp WalletRecoveryStatusResponse
+				err := json.Unmarshal(rsp.Data, &statusRsp)
 				require.NoError(t, err)
 
-				require.Equal(t, tc.httpResponse.Data.(WalletResponse), wltRsp)
+				require.Equal(t, tc.httpResponse.Data.(WalletRecoveryStatusResponse), statusRsp)
 			}
+
+			gateway.AssertExpectations(t)
 		})
 	}
 }
+
+func TestNewWalletResponsePaths(t *testing.T) {
+	w, err := wallet.NewWallet("bip44.wlt", wallet.Options{
+		Seed:      bip39.MustNewDefaultMnemonic(),
+		Type:      wallet.WalletTypeBip44,
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+	_, err = w.(*wallet.Bip44Wallet).GenerateChangeEntry()
+	require.NoError(t, err)
+
+	wr, err := NewWalletResponse(w)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, e := range wr.Entries {
+		paths = append(paths, e.Path)
+	}
+	require.Equal(t, []string{
+		"m/44'/8000'/0'/0/0",
+		"m/44'/8000'/0'/0/1",
+		"m/44'/8000'/0'/1/0",
+	}, paths)
+
+	w, err = wallet.NewWallet("det.wlt", wallet.Options{
+		Seed:      "seed",
+		Type:      wallet.WalletTypeDeterministic,
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+
+	wr, err = NewWalletResponse(w)
+	require.NoError(t, err)
+	require.Equal(t, "0", wr.Entries[0].Path)
+	require.Equal(t, "1", wr.Entries[1].Path)
+
+	d, err := json.Marshal(wr.Entries[1])
+	require.NoError(t, err)
+	require.Contains(t, string(d), `"path":"1"`)
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletTokenHeader is the header that carries a wallet access token when the Authorization header
+// is used for the HTTP basic auth of the API
+const WalletTokenHeader = "X-Wallet-Token"
+
+// walletIDFunc returns the id of the wallet a request is for, or an empty string if it is unknown
+type walletIDFunc func(r *http.Request) string
+
+// walletIDFromForm returns the "id" query or form value, used by the v1 wallet endpoints
+func walletIDFromForm(r *http.Request) string {
+	return r.FormValue("id")
+}
+
+// walletIDFromJSON returns the "wallet_id" of a JSON request body, which is kept for the handler
+func walletIDFromJSON(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	r.Body.Close() //nolint:errcheck
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return ""
+	}
+
+	// Invalid bodies are reported by the handler
+	var req struct {
+		WalletID string `json:"wallet_id"`
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		return ""
+	}
+
+	return req.WalletID
+}
+
+// walletAccessToken returns the wallet access token of a request, sent as "Authorization: Bearer <token>"
+// or in the X-Wallet-Token header
+func walletAccessToken(r *http.Request) string {
+	if token := r.Header.Get(WalletTokenHeader); token != "" {
+		return token
+	}
+
+	const prefix = "Bearer "
+	if auth := r.Header.Get("Authorization"); len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return auth[len(prefix):]
+	}
+
+	return ""
+}
+
+// walletTokenCheck requires the access token of the request's wallet, if the wallet has one.
+// Returns 401 Unauthorized if the token is missing or wrong.
+// Requests whose wallet is unknown or doesn't exist are passed to the handler, which reports them.
+func walletTokenCheck(apiVersion string, gateway Walleter, walletID walletIDFunc, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wltID := walletID(r)
+		if wltID == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		switch err := gateway.VerifyWalletAccessToken(wltID, walletAccessToken(r)); err {
+		case wallet.ErrWalletAccessTokenRequired, wallet.ErrWalletAccessTokenInvalid:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="wallet"`)
+			writeError(w, apiVersion, http.StatusUnauthorized, err.Error())
+		default:
+			handler.ServeHTTP(w, r)
+		}
+	})
+}
+
+// walletTokenAllowed returns true if the wallet has no access token or the request carries its token.
+// Used by the endpoints that list wallets, which leave out the wallets the request can't access.
+func walletTokenAllowed(gateway Walleter, wltID string, r *http.Request) bool {
+	return gateway.VerifyWalletAccessToken(wltID, walletAccessToken(r)) == nil
+}
+
+// WalletTokenRequest is the request body of the /api/v2/wallet/token endpoints
+type WalletTokenRequest struct {
+	WalletID string `json:"wallet_id"`
+	Password string `json:"password,omitempty"`
+}
+
+// WalletTokenResponse is the response data of POST /api/v2/wallet/token and POST /api/v2/wallet/token/rotate
+type WalletTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// walletTokenHandler sets a new access token for a wallet, replacing its token if it has one.
+// The token is then required by the wallet endpoints for this wallet, see walletTokenCheck.
+// The token is only returned by this request, the wallet stores its hash.
+// Method: POST
+// URI: /api/v2/wallet/token
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	password [string]: wallet password, if the wallet is encrypted
+func walletTokenHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletTokenRequestHandler(func(req WalletTokenRequest, password []byte) (interface{}, error) {
+		token, err := gateway.SetWalletAccessToken(req.WalletID, password)
+		if err != nil {
+			return nil, err
+		}
+		return WalletTokenResponse{Token: token}, nil
+	})
+}
+
+// walletTokenRotateHandler replaces the access token of a wallet. The current token is required,
+// the wallet password is not.
+// Method: POST
+// URI: /api/v2/wallet/token/rotate
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+func walletTokenRotateHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletTokenRequestHandler(func(req WalletTokenRequest, _ []byte) (interface{}, error) {
+		token, err := gateway.RotateWalletAccessToken(req.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		return WalletTokenResponse{Token: token}, nil
+	})
+}
+
+// walletTokenRemoveHandler removes the access token of a wallet
+// Method: POST
+// URI: /api/v2/wallet/token/remove
+// Args: JSON body
+//
+//	wallet_id [string]: wallet id [required]
+//	password [string]: wallet password, if the wallet is encrypted
+func walletTokenRemoveHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletTokenRequestHandler(func(req WalletTokenRequest, password []byte) (interface{}, error) {
+		return struct{}{}, gateway.RemoveWalletAccessToken(req.WalletID, password)
+	})
+}
+
+func walletTokenRequestHandler(f func(req WalletTokenRequest, password []byte) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletTokenRequest
+		if err := decodeJSONRequest(r, &req); err != nil {
+			resp := NewJSONDecodeErrorResponse(err)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var password []byte
+		if req.Password != "" {
+			password = []byte(req.Password)
+		}
+
+		data, err := f(req, password)
+		if err != nil {
+			var resp HTTPResponse
+			switch err.(type) {
+			case wallet.Error:
+				switch err {
+				case wallet.ErrWalletNotExist:
+					resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+				case wallet.ErrWalletAPIDisabled:
+					resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+				default:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				}
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: data,
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// newWalletMockGatewayer returns a MockGatewayer whose wallets have no access token
+func newWalletMockGatewayer() *MockGatewayer {
+	gateway := &MockGatewayer{}
+	gateway.On("VerifyWalletAccessToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+	gateway.On("WalletApprovalPolicy", mock.Anything).Return(nil, nil).Maybe()
+	gateway.On("WalletAddressReuse", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	return gateway
+}
+
+func TestWalletAccessToken(t *testing.T) {
+	newRequest := func(header, value string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		return req
+	}
+
+	require.Equal(t, "", walletAccessToken(newRequest("", "")))
+	require.Equal(t, "foo", walletAccessToken(newRequest(WalletTokenHeader, "foo")))
+	require.Equal(t, "foo", walletAccessToken(newRequest("Authorization", "Bearer foo")))
+	require.Equal(t, "foo", walletAccessToken(newRequest("Authorization", "bearer foo")))
+	require.Equal(t, "", walletAccessToken(newRequest("Authorization", "Basic Zm9vOmJhcg==")))
+	require.Equal(t, "", walletAccessToken(newRequest("Authorization", "Bearer ")))
+
+	// X-Wallet-Token takes precedence, the Authorization header may carry basic auth credentials
+	req := newRequest(WalletTokenHeader, "foo")
+	req.Header.Set("Authorization", "Bearer bar")
+	require.Equal(t, "foo", walletAccessToken(req))
+}
+
+func TestWalletTokenCheck(t *testing.T) {
+	cases := []struct {
+		name      string
+		header    string
+		token     string
+		verifyErr error
+		status    int
+		err       string
+	}{
+		{
+			name:      "401 - token required",
+			verifyErr: wallet.ErrWalletAccessTokenRequired,
+			status:    http.StatusUnauthorized,
+			err:       "401 Unauthorized - wallet access token required",
+		},
+		{
+			name:      "401 - invalid token",
+			header:    "Authorization",
+			token:     "Bearer bar",
+			verifyErr: wallet.ErrWalletAccessTokenInvalid,
+			status:    http.StatusUnauthorized,
+			err:       "401 Unauthorized - invalid wallet access token",
+		},
+		{
+			name:   "200 - bearer token",
+			header: "Authorization",
+			token:  "Bearer foo",
+			status: http.StatusOK,
+		},
+		{
+			name:   "200 - wallet token header",
+			header: WalletTokenHeader,
+			token:  "foo",
+			status: http.StatusOK,
+		},
+		{
+			name:      "403 - reported by the handler",
+			verifyErr: wallet.ErrWalletAPIDisabled,
+			status:    http.StatusForbidden,
+			err:       "403 Forbidden",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			token := ""
+			if tc.token != "" {
+				token = "foo"
+				if tc.verifyErr != nil {
+					token = "bar"
+				}
+			}
+			gateway.On("VerifyWalletAccessToken", "foo.wlt", token).Return(tc.verifyErr)
+			if tc.verifyErr == nil {
+				gateway.On("UnloadWallet", "foo.wlt").Return(nil)
+			} else {
+				gateway.On("UnloadWallet", "foo.wlt").Return(wallet.ErrWalletAPIDisabled).Maybe()
+			}
+
+			v := url.Values{}
+			v.Add("id", "foo.wlt")
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/unload", strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeForm)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.token)
+			}
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if tc.status == http.StatusUnauthorized {
+				require.Equal(t, `Bearer realm="wallet"`, rr.Header().Get("WWW-Authenticate"))
+			}
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+			}
+
+			gateway.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWalletTokenCheckJSON(t *testing.T) {
+	addr := testutil.MakeAddress()
+	body, err := json.Marshal(WalletAddressPasswordRequest{
+		WalletID:        "foo.wlt",
+		Address:         addr.String(),
+		AddressPassword: "pwd",
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name      string
+		verifyErr error
+		status    int
+		err       string
+	}{
+		{
+			name:      "401",
+			verifyErr: wallet.ErrWalletAccessTokenInvalid,
+			status:    http.StatusUnauthorized,
+			err:       "invalid wallet access token",
+		},
+		{
+			name:   "200",
+			status: http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("VerifyWalletAccessToken", "foo.wlt", "foo").Return(tc.verifyErr)
+			if tc.verifyErr == nil {
+				// The handler reads the request body after the token check
+				gateway.On("EncryptWalletAddress", "foo.wlt", []byte(nil), addr, []byte("pwd")).Return(nil)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/wallet/address/encrypt", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+			req.Header.Set("Authorization", "Bearer foo")
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			if tc.err != "" {
+				require.NotNil(t, rsp.Error)
+				require.Equal(t, tc.err, rsp.Error.Message)
+			} else {
+				require.Nil(t, rsp.Error)
+			}
+
+			gateway.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWalletToken(t *testing.T) {
+	validBody := &WalletTokenRequest{
+		WalletID: "foo.wlt",
+		Password: "pwd",
+	}
+
+	cases := []struct {
+		name         string
+		method       string
+		body         *WalletTokenRequest
+		rawBody      string
+		gatewayErr   error
+		status       int
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - invalid json",
+			method:       http.MethodPost,
+			rawBody:      "{ca",
+			status:       http.StatusBadRequest,
+			httpResponse: NewJSONDecodeErrorResponse(JSONDecodeError{Message: "invalid JSON at offset 2: invalid character 'c' looking for beginning of object key string", Offset: 2}),
+		},
+		{
+			name:         "400 - missing wallet id",
+			method:       http.MethodPost,
+			body:         &WalletTokenRequest{},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+		{
+			name:         "400 - wallet error",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrInvalidPassword,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			status:       http.StatusForbidden,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet not found",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:         "500 - misc error",
+			method:       http.MethodPost,
+			body:         validBody,
+			gatewayErr:   errors.New("failed"),
+			status:       http.StatusInternalServerError,
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			body:   validBody,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, endpoint := range []string{"", "/rotate", "/remove"} {
+		for _, tc := range cases {
+			t.Run(endpoint+" "+tc.name, func(t *testing.T) {
+				gateway := newWalletMockGatewayer()
+
+				if tc.body != nil && tc.body.WalletID != "" {
+					password := []byte(tc.body.Password)
+					switch endpoint {
+					case "":
+						gateway.On("SetWalletAccessToken", tc.body.WalletID, password).Return("token", tc.gatewayErr)
+					case "/rotate":
+						gateway.On("RotateWalletAccessToken", tc.body.WalletID).Return("token", tc.gatewayErr)
+					case "/remove":
+						gateway.On("RemoveWalletAccessToken", tc.body.WalletID, password).Return(tc.gatewayErr)
+					}
+				}
+
+				body := []byte(tc.rawBody)
+				if len(body) == 0 {
+					var err error
+					body, err = json.Marshal(tc.body)
+					require.NoError(t, err)
+				}
+
+				req, err := http.NewRequest(tc.method, "/api/v2/wallet/token"+endpoint, bytes.NewBuffer(body))
+				require.NoError(t, err)
+				req.Header.Set("Content-Type", ContentTypeJSON)
+				setCSRFParameters(t, tokenValid, req)
+
+				rr := httptest.NewRecorder()
+				newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+				require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+				var rsp ReceivedHTTPResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+				require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+				if tc.status != http.StatusOK {
+					return
+				}
+
+				if endpoint == "/remove" {
+					require.Equal(t, "{}", string(rsp.Data))
+					return
+				}
+
+				var data WalletTokenResponse
+				require.NoError(t, json.Unmarshal(rsp.Data, &data))
+				require.Equal(t, "token", data.Token)
+			})
+		}
+	}
+}
+
+func TestWalletCreateHandlerAccessToken(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		accessToken string
+		encrypt     bool
+		tokenErr    error
+		status      int
+		err         string
+	}{
+		{
+			name:        "400 - invalid access-token",
+			accessToken: "foo",
+			status:      http.StatusBadRequest,
+			err:         `400 Bad Request - invalid access-token value: strconv.ParseBool: parsing "foo": invalid syntax`,
+		},
+		{
+			name:        "500 - set token failed",
+			accessToken: "true",
+			tokenErr:    errors.New("failed"),
+			status:      http.StatusInternalServerError,
+			err:         "500 Internal Server Error - wallet foo.wlt was created, but setting its access token failed: failed",
+		},
+		{
+			name:        "200",
+			accessToken: "true",
+			status:      http.StatusOK,
+		},
+		{
+			name:        "200 - encrypted",
+			accessToken: "true",
+			encrypt:     true,
+			status:      http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+
+			gateway.On("CreateWallet", "", mock.Anything, gateway).Return(func(wltName string, opts wallet.Options, tf wallet.TransactionsFinder) wallet.Wallet {
+				opts.ScanN = 0
+				w, err := wallet.NewWallet("foo.wlt", opts)
+				require.NoError(t, err)
+				return w
+			}, nil).Maybe()
+
+			var password []byte
+			if tc.encrypt {
+				password = []byte("pwd")
+			}
+			gateway.On("SetWalletAccessToken", "foo.wlt", password).Return("token", tc.tokenErr).Maybe()
+
+			v := url.Values{}
+			v.Add("type", wallet.WalletTypeDeterministic)
+			v.Add("seed", "foo")
+			v.Add("label", "foo")
+			v.Add("access-token", tc.accessToken)
+			if tc.encrypt {
+				v.Add("encrypt", "true")
+				v.Add("password", "pwd")
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/wallet/create", strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeForm)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var msg WalletCreateResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &msg))
+			require.Equal(t, "token", msg.AccessToken)
+			require.True(t, msg.Meta.AccessToken)
+			// A seed provided by the caller is not returned
+			require.Empty(t, msg.Seed)
+			require.Zero(t, msg.SeedBits)
+		})
+	}
+}
+
+func TestWalletsAccessTokenFilter(t *testing.T) {
+	headers := []wallet.WalletHeader{
+		{
+			Meta: wallet.Meta{
+				"filename": "open.wlt",
+				"type":     "deterministic",
+				"tm":       "1",
+			},
+		},
+		{
+			Meta: wallet.Meta{
+				"filename": "foo.wlt",
+				"type":     "deterministic",
+				"tm":       "2",
+			},
+		},
+		{
+			Meta: wallet.Meta{
+				"filename": "bar.wlt",
+				"type":     "deterministic",
+				"tm":       "3",
+			},
+		},
+	}
+
+	statuses := []wallet.WalletFormatStatus{
+		{WalletID: "bar.wlt", Version: wallet.Version},
+		{WalletID: "foo.wlt", Version: wallet.Version},
+		{WalletID: "open.wlt", Version: wallet.Version},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		expect []string
+	}{
+		{
+			name:   "no token",
+			expect: []string{"open.wlt"},
+		},
+		{
+			name:   "foo token",
+			token:  "foo",
+			expect: []string{"open.wlt", "foo.wlt"},
+		},
+		{
+			name:   "wrong token",
+			token:  "baz",
+			expect: []string{"open.wlt"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// foo.wlt and bar.wlt have an access token, open.wlt doesn't
+			gateway := &MockGatewayer{}
+			gateway.On("ListWallets").Return(headers, nil)
+			gateway.On("WalletFormatStatus").Return(append([]wallet.WalletFormatStatus{}, statuses...), nil)
+			gateway.On("VerifyWalletAccessToken", "open.wlt", tc.token).Return(nil)
+			for _, id := range []string{"foo.wlt", "bar.wlt"} {
+				var err error
+				switch tc.token {
+				case "":
+					err = wallet.ErrWalletAccessTokenRequired
+				case strings.TrimSuffix(id, ".wlt"):
+				default:
+					err = wallet.ErrWalletAccessTokenInvalid
+				}
+				gateway.On("VerifyWalletAccessToken", id, tc.token).Return(err)
+			}
+
+			get := func(endpoint string) []byte {
+				req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+				require.NoError(t, err)
+				if tc.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tc.token)
+				}
+
+				rr := httptest.NewRecorder()
+				newServerMux(defaultMuxConfig(), gateway).ServeHTTP(rr, req)
+				require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+				return rr.Body.Bytes()
+			}
+
+			var wrs []WalletResponse
+			require.NoError(t, json.Unmarshal(get("/api/v1/wallets"), &wrs))
+			ids := make([]string, len(wrs))
+			for i, wr := range wrs {
+				ids[i] = wr.Meta.Filename
+			}
+			require.Equal(t, tc.expect, ids)
+
+			var fs WalletFormatStatusResponse
+			require.NoError(t, json.Unmarshal(get("/api/v1/wallets/format-status"), &fs))
+			ids = ids[:0]
+			for _, s := range fs.Wallets {
+				ids = append(ids, s.WalletID)
+			}
+			require.ElementsMatch(t, tc.expect, ids)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package api
+
+// APIs for notes attached to wallet transactions
+
+import (
+	"net/http"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	wh "github.com/skycoin/skycoin/src/util/http"
+
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// WalletTxNote is a note attached to a transaction of a wallet
+type WalletTxNote struct {
+	WalletID string `json:"wallet_id"`
+	TxID     string `json:"txid"`
+	Note     string `json:"note"`
+}
+
+// WalletTxNotesResponse is returned by GET /api/v1/wallet/transaction/note when no txid is given
+type WalletTxNotesResponse struct {
+	WalletID string            `json:"wallet_id"`
+	Notes    map[string]string `json:"notes"`
+}
+
+// WalletTransactionResponse is a transaction queried through a wallet, with the wallet's note,
+// its fee and the ownership of its outputs by the wallet
+type WalletTransactionResponse struct {
+	*readable.TransactionWithStatus
+	Transaction WalletReadableTransaction `json:"txn"`
+	FeeHours    uint64                    `json:"fee_hours"`
+	Note        string                    `json:"note,omitempty"`
+}
+
+// WalletTransactionVerboseResponse is a verbose transaction queried through a wallet, with the wallet's note,
+// its fee and the ownership of its inputs and outputs by the wallet
+type WalletTransactionVerboseResponse struct {
+	*readable.TransactionWithStatusVerbose
+	Transaction WalletTransactionVerbose `json:"txn"`
+	FeeHours    uint64                   `json:"fee_hours"`
+	Note        string                   `json:"note,omitempty"`
+	// CalculatedHoursEstimated is true for unconfirmed transactions. Their inputs' calculated hours and their fee
+	// are computed at the head block time, instead of the time of the block that spends the inputs.
+	CalculatedHoursEstimated bool `json:"calculated_hours_estimated,omitempty"`
+}
+
+// writeWalletError writes the error response for a wallet lookup error
+func writeWalletError(w http.ResponseWriter, err error) {
+	switch err {
+	case wallet.ErrWalletNotExist:
+		wh.Error404(w, "")
+	case wallet.ErrWalletAPIDisabled:
+		wh.Error403(w, "")
+	default:
+		wh.Error500(w, err.Error())
+	}
+}
+
+// writeWalletTxNoteError writes the error response for a wallet transaction note storage error
+func writeWalletTxNoteError(w http.ResponseWriter, err error) {
+	switch err {
+	case kvstorage.ErrStorageAPIDisabled:
+		wh.Error403(w, "storage api is disabled, notes are unavailable")
+	case kvstorage.ErrNoSuchStorage:
+		wh.Error404(w, "wallet transaction notes storage is not loaded")
+	case kvstorage.ErrNoSuchKey:
+		wh.Error404(w, "")
+	default:
+		switch err.(type) {
+		case kvstorage.Error:
+			wh.Error400(w, err.Error())
+		default:
+			wh.Error500(w, err.Error())
+		}
+	}
+}
+
+// getWalletTxNotes returns the notes of a wallet's transactions.
+// No notes are returned if the notes storage is disabled or not loaded.
+func getWalletTxNotes(gateway Gatewayer, wltID string) (map[cipher.SHA256]string, error) {
+	notes, err := gateway.GetWalletTxNotes(wltID)
+	switch err {
+	case nil:
+		return notes, nil
+	case kvstorage.ErrStorageAPIDisabled, kvstorage.ErrNoSuchStorage:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// parseWalletTxNoteArgs parses the wallet id and optional txid of a wallet transaction note request.
+// The wallet must exist and is returned. Returns false if an error response was written.
+func parseWalletTxNoteArgs(w http.ResponseWriter, gateway Gatewayer, wltID, txidStr string, requireTxID bool) (wallet.Wallet, cipher.SHA256, bool) {
+	if wltID == "" {
+		wh.Error400(w, "missing wallet id")
+		return nil, cipher.SHA256{}, false
+	}
+
+	var txid cipher.SHA256
+	if txidStr == "" {
+		if requireTxID {
+			wh.Error400(w, "missing txid")
+			return nil, cipher.SHA256{}, false
+		}
+	} else {
+		var err error
+		txid, err = cipher.SHA256FromHex(txidStr)
+		if err != nil {
+			wh.Error400(w, "invalid txid")
+			return nil, cipher.SHA256{}, false
+		}
+	}
+
+	wlt, err := gateway.GetWallet(wltID)
+	if err != nil {
+		writeWalletError(w, err)
+		return nil, cipher.SHA256{}, false
+	}
+
+	return wlt, txid, true
+}
+
+// Dispatches /wallet/transaction/note endpoint.
+// Notes are stored locally and are never broadcast.
+// URI: /api/v1/wallet/transaction/note
+// Method: GET, POST, DELETE
+// Args:
+//
+//	id: wallet id [required]
+//	txid: transaction id [required for POST and DELETE. If omitted for GET, all of the wallet's notes are returned]
+//	note: the note, at most 256 characters [required for POST]
+func walletTxNoteHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodPost, http.MethodDelete:
+		default:
+			wh.Error405(w)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		txidStr := r.FormValue("txid")
+		_, txid, ok := parseWalletTxNoteArgs(w, gateway, wltID, txidStr, r.Method != http.MethodGet)
+		if !ok {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if txidStr == "" {
+				notes, err := gateway.GetWalletTxNotes(wltID)
+				if err != nil {
+					writeWalletTxNoteError(w, err)
+					return
+				}
+
+				resp := WalletTxNotesResponse{
+					WalletID: wltID,
+					Notes:    make(map[string]string, len(notes)),
+				}
+				for h, n := range notes {
+					resp.Notes[h.Hex()] = n
+				}
+
+				wh.SendJSONOr500(logger, w, resp)
+				return
+			}
+
+			note, err := gateway.GetWalletTxNote(wltID, txid)
+			if err != nil {
+				writeWalletTxNoteError(w, err)
+				return
+			}
+
+			wh.SendJSONOr500(logger, w, WalletTxNote{
+				WalletID: wltID,
+				TxID:     txid.Hex(),
+				Note:     note,
+			})
+
+		case http.MethodPost:
+			note := r.FormValue("note")
+			if err := gateway.SetWalletTxNote(wltID, txid, note); err != nil {
+				writeWalletTxNoteError(w, err)
+				return
+			}
+
+			wh.SendJSONOr500(logger, w, WalletTxNote{
+				WalletID: wltID,
+				TxID:     txid.Hex(),
+				Note:     note,
+			})
+
+		case http.MethodDelete:
+			if err := gateway.RemoveWalletTxNote(wltID, txid); err != nil {
+				writeWalletTxNoteError(w, err)
+				return
+			}
+
+			wh.SendJSONOr500(logger, w, "success")
+		}
+	}
+}
+
+// Returns a transaction with the wallet's note attached to it,
+// with its fee and the outputs (and inputs, if verbose) owned by the wallet
+// URI: /api/v1/wallet/transaction/detail
+// Method: GET
+// Args:
+//
+//	id: wallet id [required]
+//	txid: transaction id [required]
+//	verbose: [bool] include verbose transaction input data
+func walletTransactionDetailHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		verbose, err := parseBoolFlag(r.FormValue("verbose"))
+		if err != nil {
+			wh.Error400(w, "Invalid value for verbose")
+			return
+		}
+
+		wltID := r.FormValue("id")
+		wlt, txid, ok := parseWalletTxNoteArgs(w, gateway, wltID, r.FormValue("txid"), true)
+		if !ok {
+			return
+		}
+
+		note, err := gateway.GetWalletTxNote(wltID, txid)
+		switch err {
+		case nil:
+		case kvstorage.ErrNoSuchKey, kvstorage.ErrStorageAPIDisabled, kvstorage.ErrNoSuchStorage:
+			note = ""
+		default:
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		// The inputs are needed for the fee, also if not verbose
+		txn, inputs, err := gateway.GetTransactionWithInputs(txid)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+		if txn == nil {
+			wh.Error404(w, "")
+			return
+		}
+
+		addrs := newWalletAddresses(wlt)
+
+		if verbose {
+			rTxn, err := readable.NewTransactionWithStatusVerbose(txn, inputs)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			wTxn := addrs.transactionVerbose(rTxn.Transaction)
+			rTxn.Transaction = readable.TransactionVerbose{}
+
+			wh.SendJSONOr500(logger, w, WalletTransactionVerboseResponse{
+				TransactionWithStatusVerbose: rTxn,
+				Transaction:                  wTxn,
+				FeeHours:                     wTxn.Fee,
+				Note:                         note,
+				CalculatedHoursEstimated:     !txn.Status.Confirmed,
+			})
+			return
+		}
+
+		isGenesis := txn.Status.BlockSeq == 0 && txn.Status.Confirmed
+		fee, err := transactionFeeHours(txn.Transaction, inputs, isGenesis)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		rTxn, err := readable.NewTransactionWithStatus(txn)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		wTxn := addrs.transaction(rTxn.Transaction)
+		rTxn.Transaction = readable.Transaction{}
+
+		wh.SendJSONOr500(logger, w, WalletTransactionResponse{
+			TransactionWithStatus: rTxn,
+			Transaction:           wTxn,
+			FeeHours:              fee,
+			Note:                  note,
+		})
+	}
+}
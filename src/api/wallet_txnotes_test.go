@@ -0,0 +1,539 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletTxNoteHandler(t *testing.T) {
+	txid := testutil.RandSHA256(t)
+	txid2 := testutil.RandSHA256(t)
+
+	tt := []struct {
+		name         string
+		method       string
+		wltID        string
+		txid         string
+		note         string
+		getWalletErr error
+		getNote      string
+		getNoteErr   error
+		getNotes     map[cipher.SHA256]string
+		getNotesErr  error
+		setNoteErr   error
+		removeErr    error
+		status       int
+		err          string
+		httpResponse interface{}
+	}{
+		{
+			name:   "405",
+			method: http.MethodPut,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:   "400 - missing txid",
+			method: http.MethodPost,
+			wltID:  "foo.wlt",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing txid",
+		},
+		{
+			name:   "400 - invalid txid",
+			method: http.MethodGet,
+			wltID:  "foo.wlt",
+			txid:   "foo",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid txid",
+		},
+		{
+			name:         "403 - wallet API disabled",
+			method:       http.MethodGet,
+			wltID:        "foo.wlt",
+			txid:         txid.Hex(),
+			getWalletErr: wallet.ErrWalletAPIDisabled,
+			status:       http.StatusForbidden,
+			err:          "403 Forbidden",
+		},
+		{
+			name:         "404 - wallet doesn't exist",
+			method:       http.MethodDelete,
+			wltID:        "foo.wlt",
+			txid:         txid.Hex(),
+			getWalletErr: wallet.ErrWalletNotExist,
+			status:       http.StatusNotFound,
+			err:          "404 Not Found",
+		},
+		{
+			name:       "404 - no note",
+			method:     http.MethodGet,
+			wltID:      "foo.wlt",
+			txid:       txid.Hex(),
+			getNoteErr: kvstorage.ErrNoSuchKey,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+		},
+		{
+			name:       "403 - storage API disabled",
+			method:     http.MethodGet,
+			wltID:      "foo.wlt",
+			txid:       txid.Hex(),
+			getNoteErr: kvstorage.ErrStorageAPIDisabled,
+			status:     http.StatusForbidden,
+			err:        "403 Forbidden - storage api is disabled, notes are unavailable",
+		},
+		{
+			name:    "200 - get note",
+			method:  http.MethodGet,
+			wltID:   "foo.wlt",
+			txid:    txid.Hex(),
+			getNote: "rent",
+			status:  http.StatusOK,
+			httpResponse: WalletTxNote{
+				WalletID: "foo.wlt",
+				TxID:     txid.Hex(),
+				Note:     "rent",
+			},
+		},
+		{
+			name:   "200 - get all notes",
+			method: http.MethodGet,
+			wltID:  "foo.wlt",
+			getNotes: map[cipher.SHA256]string{
+				txid:  "rent",
+				txid2: "refund",
+			},
+			status: http.StatusOK,
+			httpResponse: WalletTxNotesResponse{
+				WalletID: "foo.wlt",
+				Notes: map[string]string{
+					txid.Hex():  "rent",
+					txid2.Hex(): "refund",
+				},
+			},
+		},
+		{
+			name:        "500 - get all notes",
+			method:      http.MethodGet,
+			wltID:       "foo.wlt",
+			getNotesErr: errors.New("disk full"),
+			status:      http.StatusInternalServerError,
+			err:         "500 Internal Server Error - disk full",
+		},
+		{
+			name:       "400 - note too long",
+			method:     http.MethodPost,
+			wltID:      "foo.wlt",
+			txid:       txid.Hex(),
+			note:       "x",
+			setNoteErr: kvstorage.ErrWalletTxNoteTooLong,
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - note is longer than 256 characters",
+		},
+		{
+			name:   "200 - set note",
+			method: http.MethodPost,
+			wltID:  "foo.wlt",
+			txid:   txid.Hex(),
+			note:   "rent",
+			status: http.StatusOK,
+			httpResponse: WalletTxNote{
+				WalletID: "foo.wlt",
+				TxID:     txid.Hex(),
+				Note:     "rent",
+			},
+		},
+		{
+			name:      "404 - delete missing note",
+			method:    http.MethodDelete,
+			wltID:     "foo.wlt",
+			txid:      txid.Hex(),
+			removeErr: kvstorage.ErrNoSuchKey,
+			status:    http.StatusNotFound,
+			err:       "404 Not Found",
+		},
+		{
+			name:         "200 - delete note",
+			method:       http.MethodDelete,
+			wltID:        "foo.wlt",
+			txid:         txid.Hex(),
+			status:       http.StatusOK,
+			httpResponse: "success",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			gateway.On("GetWallet", tc.wltID).Return(nil, tc.getWalletErr)
+			gateway.On("GetWalletTxNote", tc.wltID, txid).Return(tc.getNote, tc.getNoteErr)
+			gateway.On("GetWalletTxNotes", tc.wltID).Return(tc.getNotes, tc.getNotesErr)
+			gateway.On("SetWalletTxNote", tc.wltID, txid, tc.note).Return(tc.setNoteErr)
+			gateway.On("RemoveWalletTxNote", tc.wltID, txid).Return(tc.removeErr)
+
+			v := url.Values{}
+			if tc.wltID != "" {
+				v.Add("id", tc.wltID)
+			}
+			if tc.txid != "" {
+				v.Add("txid", tc.txid)
+			}
+			if tc.note != "" {
+				v.Add("note", tc.note)
+			}
+
+			var req *http.Request
+			var err error
+			if tc.method == http.MethodPost {
+				req, err = http.NewRequest(tc.method, "/api/v1/wallet/transaction/note", strings.NewReader(v.Encode()))
+				require.NoError(t, err)
+				req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req, err = http.NewRequest(tc.method, "/api/v1/wallet/transaction/note?"+v.Encode(), nil)
+				require.NoError(t, err)
+			}
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			switch expect := tc.httpResponse.(type) {
+			case WalletTxNote:
+				var msg WalletTxNote
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, expect, msg)
+			case WalletTxNotesResponse:
+				var msg WalletTxNotesResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, expect, msg)
+			case string:
+				var msg string
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, expect, msg)
+			default:
+				t.Fatalf("unexpected response type %T", tc.httpResponse)
+			}
+		})
+	}
+}
+
+func TestWalletTransactionDetailHandler(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Type:      wallet.WalletTypeDeterministic,
+		Seed:      "seed",
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+	addrs, err := w.GetSkycoinAddresses()
+	require.NoError(t, err)
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        addrs[0],
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 100,
+		},
+	}
+	txn := &visor.Transaction{
+		Transaction: coin.Transaction{
+			In: []cipher.SHA256{inputs[0].UxOut.Hash()},
+			Out: []coin.TransactionOutput{
+				{Address: testutil.MakeAddress(), Coins: 4e5, Hours: 20},
+				{Address: addrs[1], Coins: 6e5, Hours: 30},
+			},
+		},
+		Status: visor.TransactionStatus{
+			Confirmed: true,
+			Height:    10,
+			BlockSeq:  9,
+		},
+		Time: 1540000000,
+	}
+	txid := txn.Transaction.Hash()
+
+	rTxn, err := readable.NewTransactionWithStatus(txn)
+	require.NoError(t, err)
+	rTxnVerbose, err := readable.NewTransactionWithStatusVerbose(txn, inputs)
+	require.NoError(t, err)
+
+	// The outputs are sent to an external address and to the wallet's change address,
+	// the input is spent from the wallet
+	wTxn := WalletReadableTransaction{
+		Transaction: rTxn.Transaction,
+		Out: []WalletTransactionOutput{
+			{TransactionOutput: rTxn.Transaction.Out[0], Owned: false},
+			{TransactionOutput: rTxn.Transaction.Out[1], Owned: true},
+		},
+	}
+	wTxn.Transaction.Out = nil
+	rTxn.Transaction = readable.Transaction{}
+
+	wTxnVerbose := WalletTransactionVerbose{
+		TransactionVerbose: rTxnVerbose.Transaction,
+		In: []WalletTransactionInput{
+			{TransactionInput: rTxnVerbose.Transaction.In[0], Owned: true},
+		},
+		Out: []WalletTransactionOutput{
+			{TransactionOutput: rTxnVerbose.Transaction.Out[0], Owned: false},
+			{TransactionOutput: rTxnVerbose.Transaction.Out[1], Owned: true},
+		},
+	}
+	wTxnVerbose.TransactionVerbose.In = nil
+	wTxnVerbose.TransactionVerbose.Out = nil
+	rTxnVerbose.Transaction = readable.TransactionVerbose{}
+
+	tt := []struct {
+		name            string
+		method          string
+		wltID           string
+		txid            string
+		verbose         bool
+		getWalletErr    error
+		getNote         string
+		getNoteErr      error
+		getTxn          *visor.Transaction
+		status          int
+		err             string
+		response        *WalletTransactionResponse
+		responseVerbose *WalletTransactionVerboseResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - missing txid",
+			method: http.MethodGet,
+			wltID:  "foo.wlt",
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing txid",
+		},
+		{
+			name:         "404 - wallet doesn't exist",
+			method:       http.MethodGet,
+			wltID:        "foo.wlt",
+			txid:         txid.Hex(),
+			getWalletErr: wallet.ErrWalletNotExist,
+			status:       http.StatusNotFound,
+			err:          "404 Not Found",
+		},
+		{
+			name:       "404 - transaction doesn't exist",
+			method:     http.MethodGet,
+			wltID:      "foo.wlt",
+			txid:       txid.Hex(),
+			getNoteErr: kvstorage.ErrNoSuchKey,
+			status:     http.StatusNotFound,
+			err:        "404 Not Found",
+		},
+		{
+			name:       "200 - no note",
+			method:     http.MethodGet,
+			wltID:      "foo.wlt",
+			txid:       txid.Hex(),
+			getNoteErr: kvstorage.ErrNoSuchKey,
+			getTxn:     txn,
+			status:     http.StatusOK,
+			response: &WalletTransactionResponse{
+				TransactionWithStatus: rTxn,
+				Transaction:           wTxn,
+				FeeHours:              50,
+			},
+		},
+		{
+			name:       "200 - storage API disabled",
+			method:     http.MethodGet,
+			wltID:      "foo.wlt",
+			txid:       txid.Hex(),
+			getNoteErr: kvstorage.ErrStorageAPIDisabled,
+			getTxn:     txn,
+			status:     http.StatusOK,
+			response: &WalletTransactionResponse{
+				TransactionWithStatus: rTxn,
+				Transaction:           wTxn,
+				FeeHours:              50,
+			},
+		},
+		{
+			name:    "200 - note",
+			method:  http.MethodGet,
+			wltID:   "foo.wlt",
+			txid:    txid.Hex(),
+			getNote: "rent",
+			getTxn:  txn,
+			status:  http.StatusOK,
+			response: &WalletTransactionResponse{
+				TransactionWithStatus: rTxn,
+				Transaction:           wTxn,
+				FeeHours:              50,
+				Note:                  "rent",
+			},
+		},
+		{
+			name:    "200 - note verbose",
+			method:  http.MethodGet,
+			wltID:   "foo.wlt",
+			txid:    txid.Hex(),
+			verbose: true,
+			getNote: "rent",
+			getTxn:  txn,
+			status:  http.StatusOK,
+			responseVerbose: &WalletTransactionVerboseResponse{
+				TransactionWithStatusVerbose: rTxnVerbose,
+				Transaction:                  wTxnVerbose,
+				FeeHours:                     50,
+				Note:                         "rent",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newWalletMockGatewayer()
+			if tc.getWalletErr != nil {
+				gateway.On("GetWallet", tc.wltID).Return(nil, tc.getWalletErr)
+			} else {
+				gateway.On("GetWallet", tc.wltID).Return(w, nil)
+			}
+			gateway.On("GetWalletTxNote", tc.wltID, txid).Return(tc.getNote, tc.getNoteErr)
+			gateway.On("GetTransactionWithInputs", txid).Return(tc.getTxn, inputs, nil)
+
+			v := url.Values{}
+			v.Add("id", tc.wltID)
+			if tc.txid != "" {
+				v.Add("txid", tc.txid)
+			}
+			if tc.verbose {
+				v.Add("verbose", "1")
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v1/wallet/transaction/detail?"+v.Encode(), nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if rr.Code != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			if tc.verbose {
+				var msg WalletTransactionVerboseResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, *tc.responseVerbose, msg)
+			} else {
+				var msg WalletTransactionResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				require.Equal(t, *tc.response, msg)
+			}
+		})
+	}
+}
+
+func TestWalletTransactionsHandlerNotes(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Type:      wallet.WalletTypeDeterministic,
+		Seed:      "seed",
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+
+	inputs := [][]visor.TransactionInput{
+		{{UxOut: coin.UxOut{Body: coin.UxBody{SrcTransaction: testutil.RandSHA256(t)}}}},
+		{{UxOut: coin.UxOut{Body: coin.UxBody{SrcTransaction: testutil.RandSHA256(t)}}}},
+	}
+	txns := []visor.UnconfirmedTransaction{
+		{Transaction: coin.Transaction{In: []cipher.SHA256{inputs[0][0].UxOut.Hash()}}},
+		{Transaction: coin.Transaction{In: []cipher.SHA256{inputs[1][0].UxOut.Hash()}}},
+	}
+
+	gateway := newWalletMockGatewayer()
+	gateway.On("GetWalletUnconfirmedTransactionsVerbose", "foo.wlt").Return(txns, inputs, nil)
+	gateway.On("GetWallet", "foo.wlt").Return(w, nil)
+	gateway.On("GetWalletTxNotes", "foo.wlt").Return(map[cipher.SHA256]string{
+		txns[1].Transaction.Hash(): "rent",
+	}, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/wallet/transactions?id=foo.wlt", nil)
+	require.NoError(t, err)
+	setCSRFParameters(t, tokenValid, req)
+
+	rr := httptest.NewRecorder()
+	handler := newServerMux(defaultMuxConfig(), gateway)
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var msg UnconfirmedTxnsResponse
+	err = json.Unmarshal(rr.Body.Bytes(), &msg)
+	require.NoError(t, err)
+	require.Len(t, msg.Transactions, 2)
+	require.Equal(t, txns[0].Transaction.Hash().Hex(), msg.Transactions[0].Transaction.Hash)
+	require.Empty(t, msg.Transactions[0].Note)
+	require.Equal(t, txns[1].Transaction.Hash().Hex(), msg.Transactions[1].Transaction.Hash)
+	require.Equal(t, "rent", msg.Transactions[1].Note)
+
+	// The transactions are listed without notes if the notes storage is unavailable
+	gateway = newWalletMockGatewayer()
+	gateway.On("GetWalletUnconfirmedTransactionsVerbose", "foo.wlt").Return(txns, inputs, nil)
+	gateway.On("GetWallet", "foo.wlt").Return(w, nil)
+	gateway.On("GetWalletTxNotes", "foo.wlt").Return(nil, kvstorage.ErrStorageAPIDisabled)
+
+	rr = httptest.NewRecorder()
+	handler = newServerMux(defaultMuxConfig(), gateway)
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var msg2 UnconfirmedTxnsResponse
+	err = json.Unmarshal(rr.Body.Bytes(), &msg2)
+	require.NoError(t, err)
+	require.Len(t, msg2.Transactions, 2)
+	require.Empty(t, msg2.Transactions[1].Note)
+}
@@ -0,0 +1,139 @@
+package cipher
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AddressFormat is an encoding of a public key's address
+type AddressFormat string
+
+const (
+	// AddressFormatSkycoin is the base58 Address, with the version byte after the key
+	AddressFormatSkycoin AddressFormat = "skycoin"
+	// AddressFormatBitcoin is the base58 mainnet P2PKH BitcoinAddress, with the version byte before the key
+	AddressFormatBitcoin AddressFormat = "bitcoin"
+	// AddressFormatHash160 is the hex encoded ripemd160(sha256(pubkey)), the key of a BitcoinAddress
+	AddressFormatHash160 AddressFormat = "hash160"
+	// AddressFormatPubKey is the hex encoded compressed public key
+	AddressFormatPubKey AddressFormat = "pubkey"
+)
+
+// ErrUnknownAddressFormat is returned by ParseAddressVariant for an unknown AddressFormat
+var ErrUnknownAddressFormat = errors.New("Unknown address format")
+
+// AddressVariantSet are the encodings of the address of a public key.
+// The key of Address is ripemd160(sha256(sha256(pubkey))) while the key of BitcoinAddress is
+// ripemd160(sha256(pubkey)), so Address and BitcoinAddress can only be converted with the public key.
+// Fields that are unknown are null.
+type AddressVariantSet struct {
+	PubKey         PubKey
+	Address        Address
+	BitcoinAddress BitcoinAddress
+	// Hash160 is ripemd160(sha256(pubkey)), the key of BitcoinAddress
+	Hash160 Ripemd160
+}
+
+// AddressVariants returns the Skycoin address, Bitcoin address and hash160 of a public key
+func AddressVariants(pub PubKey) AddressVariantSet {
+	btcAddr := BitcoinAddressFromPubKey(pub)
+	return AddressVariantSet{
+		PubKey:         pub,
+		Address:        AddressFromPubKey(pub),
+		BitcoinAddress: btcAddr,
+		Hash160:        btcAddr.Key,
+	}
+}
+
+// ParseAddressVariant decodes s in the given format and returns the encodings that can be derived from it.
+// If format is empty, the format is detected: 66 hex characters are a public key, 40 hex characters a hash160,
+// otherwise s is decoded as a Skycoin address, then as a Bitcoin address.
+// Errors name the format that was attempted.
+func ParseAddressVariant(s string, format AddressFormat) (AddressVariantSet, AddressFormat, error) {
+	if format == "" {
+		format = detectAddressFormat(s)
+		if format == "" {
+			v, format, err := parseBase58AddressVariant(s)
+			return v, format, err
+		}
+	}
+
+	switch format {
+	case AddressFormatPubKey:
+		pub, err := PubKeyFromHex(s)
+		if err != nil {
+			return AddressVariantSet{}, format, fmt.Errorf("invalid %s: %v", format, err)
+		}
+		return AddressVariants(pub), format, nil
+
+	case AddressFormatHash160:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return AddressVariantSet{}, format, fmt.Errorf("invalid %s: %v", format, err)
+		}
+		h, err := Ripemd160FromBytes(b)
+		if err != nil {
+			return AddressVariantSet{}, format, fmt.Errorf("invalid %s: %v", format, err)
+		}
+		return AddressVariantSet{
+			BitcoinAddress: BitcoinAddress{
+				Key: h,
+			},
+			Hash160: h,
+		}, format, nil
+
+	case AddressFormatSkycoin:
+		addr, err := DecodeBase58Address(s)
+		if err != nil {
+			return AddressVariantSet{}, format, fmt.Errorf("invalid %s address: %v", format, err)
+		}
+		return AddressVariantSet{
+			Address: addr,
+		}, format, nil
+
+	case AddressFormatBitcoin:
+		addr, err := DecodeBase58BitcoinAddress(s)
+		if err != nil {
+			return AddressVariantSet{}, format, fmt.Errorf("invalid %s address: %v", format, err)
+		}
+		return AddressVariantSet{
+			BitcoinAddress: addr,
+			Hash160:        addr.Key,
+		}, format, nil
+
+	default:
+		return AddressVariantSet{}, format, ErrUnknownAddressFormat
+	}
+}
+
+// detectAddressFormat returns the hex encoded format of s by its length, or "" if s is not hex encoded
+func detectAddressFormat(s string) AddressFormat {
+	if _, err := hex.DecodeString(s); err != nil {
+		return ""
+	}
+
+	switch len(s) {
+	case len(PubKey{}) * 2:
+		return AddressFormatPubKey
+	case len(Ripemd160{}) * 2:
+		return AddressFormatHash160
+	default:
+		return ""
+	}
+}
+
+// parseBase58AddressVariant decodes s as a Skycoin address, then as a Bitcoin address
+func parseBase58AddressVariant(s string) (AddressVariantSet, AddressFormat, error) {
+	var errs []string
+	for _, f := range []AddressFormat{AddressFormatSkycoin, AddressFormatBitcoin} {
+		v, _, err := ParseAddressVariant(s, f)
+		if err == nil {
+			return v, f, nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	return AddressVariantSet{}, "", fmt.Errorf("not a public key, hash160, skycoin or bitcoin address: %s", strings.Join(errs, "; "))
+}
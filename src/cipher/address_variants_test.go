@@ -0,0 +1,158 @@
+package cipher
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/base58"
+)
+
+func TestAddressVariants(t *testing.T) {
+	pub := MustPubKeyFromHex("034f355bdcb7cc0af728ef3cceb9615d90684bb5b2ca5f859ab0f0b704075871aa")
+
+	v := AddressVariants(pub)
+	require.Equal(t, pub, v.PubKey)
+	require.Equal(t, AddressFromPubKey(pub), v.Address)
+	require.Equal(t, "1Q1pE5vPGEEMqRcVRMbtBK842Y6Pzo6nK9", v.BitcoinAddress.String())
+	require.Equal(t, v.BitcoinAddress.Key, v.Hash160)
+	require.NotEqual(t, v.Address.Key, v.Hash160)
+
+	// The Bitcoin address and hash160 round trip
+	btcAddr, err := DecodeBase58BitcoinAddress(v.BitcoinAddress.String())
+	require.NoError(t, err)
+	require.Equal(t, v.BitcoinAddress, btcAddr)
+	require.NoError(t, btcAddr.Verify(pub))
+	require.Equal(t, v.BitcoinAddress, BitcoinAddress{Key: v.Hash160})
+}
+
+func TestParseAddressVariant(t *testing.T) {
+	pub := MustPubKeyFromHex("034f355bdcb7cc0af728ef3cceb9615d90684bb5b2ca5f859ab0f0b704075871aa")
+	v := AddressVariants(pub)
+
+	mustHexDecode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	// Change the last character of the base58 addresses to break their checksums
+	badChecksum := func(s string) string {
+		last := "2"
+		if s[len(s)-1] == '2' {
+			last = "3"
+		}
+		return s[:len(s)-1] + last
+	}
+
+	cases := []struct {
+		name   string
+		s      string
+		format AddressFormat
+		parsed AddressFormat
+		v      AddressVariantSet
+		err    string
+	}{
+		{
+			name:   "pubkey",
+			s:      pub.Hex(),
+			parsed: AddressFormatPubKey,
+			v:      v,
+		},
+		{
+			name:   "hash160",
+			s:      "fc7250a211c9bc7a4f2e8d5b3e5fd3d6bd32a0e2",
+			parsed: AddressFormatHash160,
+			v: AddressVariantSet{
+				BitcoinAddress: BitcoinAddress{Key: MustRipemd160FromBytes(mustHexDecode("fc7250a211c9bc7a4f2e8d5b3e5fd3d6bd32a0e2"))},
+				Hash160:        MustRipemd160FromBytes(mustHexDecode("fc7250a211c9bc7a4f2e8d5b3e5fd3d6bd32a0e2")),
+			},
+		},
+		{
+			name:   "hash160 of pubkey",
+			s:      hex.EncodeToString(v.Hash160[:]),
+			parsed: AddressFormatHash160,
+			v: AddressVariantSet{
+				BitcoinAddress: v.BitcoinAddress,
+				Hash160:        v.Hash160,
+			},
+		},
+		{
+			name:   "skycoin address",
+			s:      v.Address.String(),
+			parsed: AddressFormatSkycoin,
+			v: AddressVariantSet{
+				Address: v.Address,
+			},
+		},
+		{
+			name:   "bitcoin address",
+			s:      v.BitcoinAddress.String(),
+			parsed: AddressFormatBitcoin,
+			v: AddressVariantSet{
+				BitcoinAddress: v.BitcoinAddress,
+				Hash160:        v.Hash160,
+			},
+		},
+		{
+			name:   "bitcoin address, explicit format",
+			s:      v.BitcoinAddress.String(),
+			format: AddressFormatBitcoin,
+			parsed: AddressFormatBitcoin,
+			v: AddressVariantSet{
+				BitcoinAddress: v.BitcoinAddress,
+				Hash160:        v.Hash160,
+			},
+		},
+		{
+			name:   "skycoin address as bitcoin address",
+			s:      v.Address.String(),
+			format: AddressFormatBitcoin,
+			parsed: AddressFormatBitcoin,
+			err:    "invalid bitcoin address: Invalid checksum",
+		},
+		{
+			name:   "skycoin address, bad checksum",
+			s:      badChecksum(v.Address.String()),
+			format: AddressFormatSkycoin,
+			parsed: AddressFormatSkycoin,
+			err:    "invalid skycoin address: Invalid checksum",
+		},
+		{
+			name: "undetected, bad checksum",
+			s:    badChecksum(v.BitcoinAddress.String()),
+			err:  "not a public key, hash160, skycoin or bitcoin address: invalid skycoin address: Invalid checksum; invalid bitcoin address: Invalid checksum",
+		},
+		{
+			name:   "invalid pubkey",
+			s:      "02" + hex.EncodeToString(make([]byte, 32)),
+			parsed: AddressFormatPubKey,
+			err:    "invalid pubkey: Invalid public key",
+		},
+		{
+			name:   "unknown format",
+			s:      v.Address.String(),
+			format: "foo",
+			parsed: "foo",
+			err:    ErrUnknownAddressFormat.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, format, err := ParseAddressVariant(tc.s, tc.format)
+			require.Equal(t, tc.parsed, format)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.v, parsed)
+		})
+	}
+
+	// A base58 string that is not 25 bytes long
+	_, _, err := ParseAddressVariant(string(base58.Encode([]byte("foo"))), "")
+	require.EqualError(t, err, "not a public key, hash160, skycoin or bitcoin address: invalid skycoin address: Invalid address length; invalid bitcoin address: Invalid address length")
+}
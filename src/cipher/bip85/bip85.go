@@ -0,0 +1,106 @@
+/*
+Package bip85 implements deterministic entropy from bip32 keychains, https://github.com/bitcoin/bips/blob/master/bip-0085.mediawiki
+
+Only the bip39 application is implemented, which derives child mnemonics from a master key,
+so that one backup of the master seed covers the wallets created from the child mnemonics.
+*/
+package bip85
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+)
+
+// Bip85's bip32 path: m / 83696968' / app_no' / ...
+// The bip39 application path is: m / 83696968' / 39' / language' / words' / index'
+
+const (
+	// Purpose is the purpose level of a bip85 path
+	Purpose uint32 = 83696968
+	// AppBip39 is the application number of bip39 mnemonics
+	AppBip39 uint32 = 39
+	// LanguageEnglish is the language number of the english bip39 word list
+	LanguageEnglish uint32 = 0
+)
+
+var (
+	// ErrInvalidWords the number of mnemonic words is not 12, 18 or 24
+	ErrInvalidWords = errors.New("bip85 mnemonic must have 12, 18 or 24 words")
+	// ErrInvalidIndex the child index is >= 0x80000000
+	ErrInvalidIndex = errors.New("bip85 index must be < 0x80000000")
+
+	// entropyHMACKey is the HMAC key used to derive entropy from a derived private key
+	entropyHMACKey = []byte("bip-entropy-from-k")
+
+	// mnemonicEntropyLengths maps the number of mnemonic words to the length of their entropy in bytes
+	mnemonicEntropyLengths = map[uint32]int{
+		12: 16,
+		18: 24,
+		24: 32,
+	}
+)
+
+// DeriveEntropy derives 64 bytes of entropy from a master key, at a bip85 path
+func DeriveEntropy(master *bip32.PrivateKey, path string) ([]byte, error) {
+	p, err := bip32.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the master node, master is already the master key
+	nodes := p.Elements
+	if len(nodes) != 0 && nodes[0].Master {
+		nodes = nodes[1:]
+	}
+
+	k, err := master.DeriveSubpath(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, entropyHMACKey)
+	if _, err := mac.Write(k.Key); err != nil {
+		return nil, err
+	}
+
+	return mac.Sum(nil), nil
+}
+
+// Bip39Path returns the bip85 path of an english child mnemonic
+func Bip39Path(words, index uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d'/%d'", Purpose, AppBip39, LanguageEnglish, words, index)
+}
+
+// NewMnemonic derives an english child mnemonic of 12, 18 or 24 words from a master key
+func NewMnemonic(master *bip32.PrivateKey, words, index uint32) (string, error) {
+	n, ok := mnemonicEntropyLengths[words]
+	if !ok {
+		return "", ErrInvalidWords
+	}
+
+	if index >= bip32.FirstHardenedChild {
+		return "", ErrInvalidIndex
+	}
+
+	entropy, err := DeriveEntropy(master, Bip39Path(words, index))
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy[:n])
+}
+
+// NewMnemonicFromSeed derives an english child mnemonic of 12, 18 or 24 words from a bip39 seed
+func NewMnemonicFromSeed(seed []byte, words, index uint32) (string, error) {
+	master, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return "", err
+	}
+
+	return NewMnemonic(master, words, index)
+}
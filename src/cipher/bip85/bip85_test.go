@@ -0,0 +1,113 @@
+package bip85
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+)
+
+// Test vectors from https://github.com/bitcoin/bips/blob/master/bip-0085.mediawiki
+const testMasterKey = "xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb"
+
+func mustTestMasterKey(t *testing.T) *bip32.PrivateKey {
+	k, err := bip32.DeserializeEncodedPrivateKey(testMasterKey)
+	require.NoError(t, err)
+	return k
+}
+
+func TestDeriveEntropy(t *testing.T) {
+	cases := []struct {
+		path    string
+		entropy string
+	}{
+		{
+			path:    "m/83696968'/0'/0'",
+			entropy: "efecfbccffea313214232d29e71563d941229afb4338c21f9517c41aaa0d16f00b83d2a09ef747e7a64e8e2bd5a14869e693da66ce94ac2da570ab7ee48618f7",
+		},
+		{
+			path:    "m/83696968'/0'/1'",
+			entropy: "70c6e3e8ebee8dc4c0dbba66076819bb8c09672527c4277ca8729532ad711872218f826919f6b67218adde99018a6df9095ab2b58d803b5b93ec9802085a690e",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			entropy, err := DeriveEntropy(mustTestMasterKey(t), tc.path)
+			require.NoError(t, err)
+			require.Equal(t, tc.entropy, hex.EncodeToString(entropy))
+		})
+	}
+
+	_, err := DeriveEntropy(mustTestMasterKey(t), "83696968'/0'/0'")
+	require.Equal(t, bip32.ErrPathNoMaster, err)
+}
+
+func TestNewMnemonic(t *testing.T) {
+	cases := []struct {
+		words    uint32
+		path     string
+		mnemonic string
+	}{
+		{
+			words:    12,
+			path:     "m/83696968'/39'/0'/12'/0'",
+			mnemonic: "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose",
+		},
+		{
+			words:    18,
+			path:     "m/83696968'/39'/0'/18'/0'",
+			mnemonic: "near account window bike charge season chef number sketch tomorrow excuse sniff circle vital hockey outdoor supply token",
+		},
+		{
+			words:    24,
+			path:     "m/83696968'/39'/0'/24'/0'",
+			mnemonic: "puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce twin tonight reason outdoor destroy simple truth cigar social volcano",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			require.Equal(t, tc.path, Bip39Path(tc.words, 0))
+
+			mnemonic, err := NewMnemonic(mustTestMasterKey(t), tc.words, 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.mnemonic, mnemonic)
+		})
+	}
+
+	// Each index derives a different mnemonic
+	m0, err := NewMnemonic(mustTestMasterKey(t), 12, 0)
+	require.NoError(t, err)
+	m1, err := NewMnemonic(mustTestMasterKey(t), 12, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, m0, m1)
+	require.NoError(t, bip39.ValidateMnemonic(m1))
+
+	_, err = NewMnemonic(mustTestMasterKey(t), 15, 0)
+	require.Equal(t, ErrInvalidWords, err)
+
+	_, err = NewMnemonic(mustTestMasterKey(t), 12, bip32.FirstHardenedChild)
+	require.Equal(t, ErrInvalidIndex, err)
+}
+
+func TestNewMnemonicFromSeed(t *testing.T) {
+	seed, err := bip39.NewSeed("dizzy cigar grant ramp inmate uniform gold success able payment faith practice", "")
+	require.NoError(t, err)
+
+	master, err := bip32.NewMasterKey(seed)
+	require.NoError(t, err)
+
+	expected, err := NewMnemonic(master, 24, 7)
+	require.NoError(t, err)
+
+	mnemonic, err := NewMnemonicFromSeed(seed, 24, 7)
+	require.NoError(t, err)
+	require.Equal(t, expected, mnemonic)
+
+	_, err = NewMnemonicFromSeed(make([]byte, 3), 24, 7)
+	require.Equal(t, bip32.ErrInvalidSeedLength, err)
+}
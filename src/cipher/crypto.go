@@ -16,7 +16,9 @@ are used by any user of this package, regardless of their gopath.
 package cipher
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -25,7 +27,8 @@ import (
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher/ripemd160"
-	secp256k1 "github.com/skycoin/skycoin/src/cipher/secp256k1-go"
+
+	secp256k1 "github.com/ness-network/privateness/src/cipher/secp256k1-go"
 )
 
 var (
@@ -82,6 +85,8 @@ var (
 	ErrPubKeyFromSecKeyMismatch = errors.New("impossible error TestSecKey, pubkey does not match recovered pubkey")
 	// ErrEmptySeed Seed input is empty
 	ErrEmptySeed = errors.New("Seed input is empty")
+	// ErrEmptyDomain Domain input is empty
+	ErrEmptyDomain = errors.New("Domain input is empty")
 )
 
 // PubKey public key
@@ -159,7 +164,7 @@ func MustPubKeyFromSecKey(seckey SecKey) PubKey {
 
 // PubKeyFromSig recovers the public key from a signed hash
 func PubKeyFromSig(sig Sig, hash SHA256) (PubKey, error) {
-	rawPubKey := secp256k1.RecoverPubkey(hash[:], sig[:])
+	rawPubKey := backend.recoverPubkey(hash[:], sig[:])
 	if rawPubKey == nil {
 		return PubKey{}, ErrInvalidSigPubKeyRecovery
 	}
@@ -371,7 +376,7 @@ func SignHash(hash SHA256, sec SecKey) (Sig, error) {
 		return Sig{}, ErrNullSignHash
 	}
 
-	s := secp256k1.Sign(hash[:], sec[:])
+	s := backend.sign(hash[:], sec[:])
 
 	sig, err := NewSig(s)
 	if err != nil {
@@ -413,7 +418,7 @@ func MustSignHash(hash SHA256, sec SecKey) Sig {
 // - fail if recovered address does not match PubKey hash
 // - verify that signature is valid for hash for PubKey
 func VerifyAddressSignedHash(address Address, sig Sig, hash SHA256) error {
-	rawPubKey := secp256k1.RecoverPubkey(hash[:], sig[:])
+	rawPubKey := backend.recoverPubkey(hash[:], sig[:])
 	if rawPubKey == nil {
 		return ErrInvalidSigPubKeyRecovery
 	}
@@ -427,7 +432,7 @@ func VerifyAddressSignedHash(address Address, sig Sig, hash SHA256) error {
 		return ErrInvalidAddressForSig
 	}
 
-	if secp256k1.VerifySignature(hash[:], sig[:], rawPubKey[:]) != 1 {
+	if backend.verifySignature(hash[:], sig[:], rawPubKey[:]) != 1 {
 		return ErrInvalidHashForSig
 	}
 
@@ -445,7 +450,7 @@ func VerifyPubKeySignedHash(pubkey PubKey, sig Sig, hash SHA256) error {
 	}
 	if secp256k1.VerifyPubkey(pubkey[:]) != 1 {
 		if DebugLevel2 {
-			if secp256k1.VerifySignature(hash[:], sig[:], pubkey[:]) == 1 {
+			if backend.verifySignature(hash[:], sig[:], pubkey[:]) == 1 {
 				log.Panic("VerifyPubKeySignedHash warning, invalid pubkey is valid for signature")
 			}
 		}
@@ -454,7 +459,7 @@ func VerifyPubKeySignedHash(pubkey PubKey, sig Sig, hash SHA256) error {
 	if secp256k1.VerifySignatureValidity(sig[:]) != 1 {
 		return ErrInvalidSigValidity
 	}
-	if secp256k1.VerifySignature(hash[:], sig[:], pubkey[:]) != 1 {
+	if backend.verifySignature(hash[:], sig[:], pubkey[:]) != 1 {
 		return ErrInvalidSigForMessage
 	}
 	return nil
@@ -464,11 +469,11 @@ func VerifyPubKeySignedHash(pubkey PubKey, sig Sig, hash SHA256) error {
 // It does not check that the signature signed the hash.
 // The original public key or address is required to verify that the signature signed the hash.
 func VerifySignatureRecoverPubKey(sig Sig, hash SHA256) error {
-	rawPubKey := secp256k1.RecoverPubkey(hash[:], sig[:])
+	rawPubKey := backend.recoverPubkey(hash[:], sig[:])
 	if rawPubKey == nil {
 		return ErrInvalidSigPubKeyRecovery
 	}
-	if secp256k1.VerifySignature(hash[:], sig[:], rawPubKey) != 1 {
+	if backend.verifySignature(hash[:], sig[:], rawPubKey) != 1 {
 		// This should always pass; the recovered pubkey should always be valid
 		return ErrInvalidHashForSig
 	}
@@ -542,6 +547,46 @@ func MustGenerateDeterministicKeyPair(seed []byte) (PubKey, SecKey) {
 	return p, s
 }
 
+// seededKeyPairHMACKey is the HMAC key of GenerateDeterministicKeyPairSeeded
+const seededKeyPairHMACKey = "privateness/cipher/seeded-keypair/v1"
+
+// GenerateDeterministicKeyPairSeeded generates a stable key pair from seed, separated by domain.
+// The key pair is generated by GenerateDeterministicKeyPair from
+//
+//	HMAC-SHA256(key="privateness/cipher/seeded-keypair/v1", len(domain) || domain || seed)
+//
+// where len(domain) is the 4 byte big endian length of domain. The same seed gives unrelated
+// key pairs in different domains, and a key pair different from GenerateDeterministicKeyPair(seed).
+// It is meant for test fixtures, whose keys must not change between runs. The seeds of fixtures
+// are not secret, so it must not be used for real wallets; only test code may call it.
+func GenerateDeterministicKeyPairSeeded(domain string, seed []byte) (PubKey, SecKey, error) {
+	if domain == "" {
+		return PubKey{}, SecKey{}, ErrEmptyDomain
+	}
+	if len(seed) == 0 {
+		return PubKey{}, SecKey{}, ErrEmptySeed
+	}
+
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(domain)))
+
+	mac := hmac.New(sha256.New, []byte(seededKeyPairHMACKey))
+	mac.Write(n[:])           //nolint:errcheck
+	mac.Write([]byte(domain)) //nolint:errcheck
+	mac.Write(seed)           //nolint:errcheck
+
+	return GenerateDeterministicKeyPair(mac.Sum(nil))
+}
+
+// MustGenerateDeterministicKeyPairSeeded generates a stable key pair from seed, separated by domain, panics on error
+func MustGenerateDeterministicKeyPairSeeded(domain string, seed []byte) (PubKey, SecKey) {
+	p, s, err := GenerateDeterministicKeyPairSeeded(domain, seed)
+	if err != nil {
+		log.Panic(err)
+	}
+	return p, s
+}
+
 // DeterministicKeyPairIterator takes SHA256 value, returns a new
 // SHA256 value and publickey and private key. Apply multiple times
 // feeding the SHA256 value back into generate sequence of keys
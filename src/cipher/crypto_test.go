@@ -5,6 +5,10 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -567,6 +571,78 @@ func TestGenerateDeterministicKeyPair(t *testing.T) {
 	})
 }
 
+func TestGenerateDeterministicKeyPairSeeded(t *testing.T) {
+	// The key pairs are pinned, test fixtures depend on them
+	p, s := MustGenerateDeterministicKeyPairSeeded("test", []byte("seed"))
+	require.Equal(t, "02d35736ec91029f1e2efa0a31924d8489bdf37c8b9640d3b93783e50da4d53b0f", p.Hex())
+	require.Equal(t, "ad49ddfa2c917fa0b6da1bdf2474597c5a4206a6fb4f1ad5fb46df955bab3784", s.Hex())
+	require.Equal(t, p, MustPubKeyFromSecKey(s))
+
+	p2, s2 := MustGenerateDeterministicKeyPairSeeded("test", []byte("seed"))
+	require.Equal(t, p, p2)
+	require.Equal(t, s, s2)
+
+	// Domains separate the key pairs of a seed
+	p2, _ = MustGenerateDeterministicKeyPairSeeded("other", []byte("seed"))
+	require.Equal(t, "02c62011b7257b03e88057056b8bf449ed6db91b232d34de4668585b9e079e58e0", p2.Hex())
+
+	// The domain length is part of the input, moving bytes between the domain and the seed changes the key pair
+	p2, _ = MustGenerateDeterministicKeyPairSeeded("tests", []byte("eed"))
+	require.NotEqual(t, p, p2)
+
+	// The key pair is not the key pair of the seed alone
+	p2, _ = MustGenerateDeterministicKeyPair([]byte("seed"))
+	require.NotEqual(t, p, p2)
+
+	_, _, err := GenerateDeterministicKeyPairSeeded("", []byte("seed"))
+	require.Equal(t, ErrEmptyDomain, err)
+	_, _, err = GenerateDeterministicKeyPairSeeded("test", nil)
+	require.Equal(t, ErrEmptySeed, err)
+
+	require.Panics(t, func() {
+		MustGenerateDeterministicKeyPairSeeded("test", nil)
+	})
+}
+
+func TestGenerateDeterministicKeyPairSeededTestOnly(t *testing.T) {
+	// Seeded key pairs are for test fixtures. Outside of this package, only test files
+	// and the testutil package may generate them, directly or with testutil.KeyPair.
+	names := []string{"DeterministicKeyPairSeeded", "testutil.KeyPair("}
+	pkgDir := filepath.Join("..", "cipher")
+
+	for _, dir := range []string{"..", filepath.Join("..", "..", "cmd")} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				if info.Name() == "testutil" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") || filepath.Dir(path) == pkgDir {
+				return nil
+			}
+
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range names {
+				if bytes.Contains(b, []byte(name)) {
+					t.Errorf("%s uses %s, seeded key pairs must only be used by tests", path, name)
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+}
+
 func TestGenerateDeterministicKeyPairs(t *testing.T) {
 	seed := randBytes(t, 32)
 	keys, err := GenerateDeterministicKeyPairs(seed, 4)
@@ -0,0 +1,101 @@
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"log"
+
+	secp "github.com/skycoin/skycoin/src/cipher/secp256k1-go/secp256k1-go2"
+)
+
+// rfc6979 generates the deterministic ECDSA signing nonces of RFC6979 section 3.2,
+// using HMAC-SHA256. The nonces are the same as the ones generated by
+// libsecp256k1's secp256k1_nonce_function_rfc6979 without extra entropy.
+type rfc6979 struct {
+	k     []byte
+	v     []byte
+	retry bool
+}
+
+// newRFC6979 initializes the nonce generator for a 32 byte secret key and message hash
+func newRFC6979(seckey, msg []byte) *rfc6979 {
+	if len(seckey) != 32 {
+		log.Panic("newRFC6979, seckey must be 32 bytes")
+	}
+	if len(msg) != 32 {
+		log.Panic("newRFC6979, message must be 32 bytes")
+	}
+
+	// bits2octets(h1): the message hash reduced modulo the curve order
+	var m secp.Number
+	m.SetBytes(msg)
+	m.Mod(&m.Int, &secp.TheCurve.Order.Int)
+	msgMod := make([]byte, 32)
+	b := m.Bytes()
+	copy(msgMod[32-len(b):], b)
+
+	r := &rfc6979{
+		k: make([]byte, 32),
+		v: make([]byte, 32),
+	}
+	for i := range r.v {
+		r.v[i] = 0x01
+	}
+
+	data := make([]byte, 0, 64)
+	data = append(data, seckey...)
+	data = append(data, msgMod...)
+
+	r.k = r.mac(r.v, []byte{0x00}, data)
+	r.v = r.mac(r.v)
+	r.k = r.mac(r.v, []byte{0x01}, data)
+	r.v = r.mac(r.v)
+
+	return r
+}
+
+func (r *rfc6979) mac(data ...[]byte) []byte {
+	var h hash.Hash = hmac.New(sha256.New, r.k)
+	for _, d := range data {
+		h.Write(d) //nolint:errcheck
+	}
+	return h.Sum(nil)
+}
+
+// next returns the next 32 byte nonce candidate. Candidates are not checked against the curve order.
+func (r *rfc6979) next() []byte {
+	if r.retry {
+		r.k = r.mac(r.v, []byte{0x00})
+		r.v = r.mac(r.v)
+	}
+	r.retry = true
+
+	r.v = r.mac(r.v)
+
+	out := make([]byte, 32)
+	copy(out, r.v)
+	return out
+}
+
+// nextNonce returns the next nonce candidate that satisfies 0 < k < n, where n is the order of the curve
+func (r *rfc6979) nextNonce() secp.Number {
+	for {
+		var n secp.Number
+		n.SetBytes(r.next())
+		if n.Sign() != 0 && n.Cmp(&secp.TheCurve.Order.Int) < 0 {
+			return n
+		}
+	}
+}
+
+// RFC6979Nonce returns the deterministic nonce that Sign uses to sign msg with seckey.
+// It is exposed so that the nonce generation can be checked against the RFC6979 test vectors,
+// it should not be used for anything else.
+func RFC6979Nonce(msg, seckey []byte) []byte {
+	n := newRFC6979(seckey, msg).nextNonce()
+	out := make([]byte, 32)
+	b := n.Bytes()
+	copy(out[32-len(b):], b)
+	return out
+}
@@ -0,0 +1,112 @@
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// RFC6979 test vectors for secp256k1 with SHA256, as used by libsecp256k1 and bitcoin libraries
+var rfc6979Vectors = []struct {
+	seckey string
+	msg    string
+	nonce  string
+	sig    string
+}{
+	{
+		seckey: "0000000000000000000000000000000000000000000000000000000000000001",
+		msg:    "Satoshi Nakamoto",
+		nonce:  "8f8a276c19f4149656b280621e358cce24f5f52542772691ee69063b74f15d15",
+		sig:    "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d82442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+	},
+	{
+		seckey: "0000000000000000000000000000000000000000000000000000000000000001",
+		msg:    "All those moments will be lost in time, like tears in rain. Time to die...",
+		nonce:  "38aa22d72376b4dbc472e06c3ba403ee0a394da63fc58d88686c611aba98d6b3",
+		sig:    "8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc21",
+	},
+	{
+		seckey: "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+		msg:    "Satoshi Nakamoto",
+		nonce:  "33a19b60e25fb6f4435af53a3d42d493644827367e6453928554f43e49aa6f90",
+		sig:    "fd567d121db66e382991534ada77a6bd3106f0a1098c231e47993447cd6af2d06b39cd0eb1bc8603e159ef5c20a5c8ad685a45b06ce9bebed3f153d10d93bed5",
+	},
+	{
+		seckey: "f8b8af8ce3c7cca5e300d33939540c10d45ce001b8f252bfbc57ba0342904181",
+		msg:    "Alan Turing",
+		nonce:  "525a82b70e67874398067543fd84c83d30c175fdc45fdeee082fe13b1d7cfdf1",
+		sig:    "7063ae83e7f62bbb171798131b4a0564b956930092b33b07b395615d9ec7e15c58dfcc1e00a35e1572f366ffe34ba0fc47db1e7189759b9fb233c5b05ab388ea",
+	},
+}
+
+func TestRFC6979Vectors(t *testing.T) {
+	for _, tc := range rfc6979Vectors {
+		t.Run(tc.msg, func(t *testing.T) {
+			seckey, err := hex.DecodeString(tc.seckey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := SumSHA256([]byte(tc.msg))
+
+			nonce := RFC6979Nonce(msg, seckey)
+			if hex.EncodeToString(nonce) != tc.nonce {
+				t.Fatalf("nonce mismatch: %x != %s", nonce, tc.nonce)
+			}
+
+			sig := Sign(msg, seckey)
+			if hex.EncodeToString(sig[:64]) != tc.sig {
+				t.Fatalf("signature mismatch: %x != %s", sig[:64], tc.sig)
+			}
+
+			pubkey := PubkeyFromSeckey(seckey)
+			if VerifySignature(msg, sig, pubkey) != 1 {
+				t.Fatal("signature is not valid")
+			}
+		})
+	}
+}
+
+func TestSignDeterministic(t *testing.T) {
+	for i := 0; i < 64; i++ {
+		_, seckey := GenerateKeyPair()
+		msg := RandByte(32)
+
+		sig1 := Sign(msg, seckey)
+		sig2 := Sign(msg, seckey)
+		if !bytes.Equal(sig1, sig2) {
+			t.Fatal("signatures of the same msg and seckey differ")
+		}
+
+		// A different msg or seckey gives a different nonce
+		msg2 := RandByte(32)
+		if bytes.Equal(RFC6979Nonce(msg, seckey), RFC6979Nonce(msg2, seckey)) {
+			t.Fatal("nonces of different messages are equal")
+		}
+		_, seckey2 := GenerateKeyPair()
+		if bytes.Equal(RFC6979Nonce(msg, seckey), RFC6979Nonce(msg, seckey2)) {
+			t.Fatal("nonces of different seckeys are equal")
+		}
+	}
+}
+
+func TestRFC6979Retry(t *testing.T) {
+	// Each candidate after the first is derived from the updated HMAC state,
+	// so retries never return the same candidate
+	seckey := RandByte(32)
+	msg := RandByte(32)
+	r := newRFC6979(seckey, msg)
+	seen := make(map[string]struct{})
+	for i := 0; i < 16; i++ {
+		c := hex.EncodeToString(r.next())
+		if _, ok := seen[c]; ok {
+			t.Fatal("repeated nonce candidate")
+		}
+		seen[c] = struct{}{}
+	}
+
+	// The first candidate is the nonce
+	r = newRFC6979(seckey, msg)
+	if !bytes.Equal(r.next(), RFC6979Nonce(msg, seckey)) {
+		t.Fatal("first candidate is not the nonce")
+	}
+}
@@ -219,24 +219,9 @@ func DeterministicKeyPairIterator(seedIn []byte) ([]byte, []byte, []byte) {
 	return seed1, pubkey, seckey
 }
 
-func newRandomNonceNumber() secp.Number {
-	nonce := RandByte(32)
-	var n secp.Number
-	n.SetBytes(nonce)
-	return n
-}
-
-// newSigningNonce creates a nonce for signing. This is the `k` parameter in
-// ECDSA signing. `k` must be 0 < k < n, where `n` is the order of the curve
-func newSigningNonce() secp.Number {
-	nonce := newRandomNonceNumber()
-	for nonce.Sign() == 0 || nonce.Cmp(&secp.TheCurve.Order.Int) >= 0 {
-		nonce = newRandomNonceNumber()
-	}
-	return nonce
-}
-
-// Sign sign hash, returns a compact recoverable signature
+// Sign sign hash, returns a compact recoverable signature.
+// The signing nonce is generated deterministically with RFC6979, so signing the same
+// msg with the same seckey always returns the same signature.
 func Sign(msg []byte, seckey []byte) []byte {
 	if len(seckey) != 32 {
 		log.Panic("Sign, Invalid seckey length")
@@ -251,7 +236,6 @@ func Sign(msg []byte, seckey []byte) []byte {
 		log.Panic("Sign, message must be 32 bytes")
 	}
 
-	nonce := newSigningNonce()
 	sig := make([]byte, 65)
 	var recid int // recovery byte, used to recover pubkey from sig
 
@@ -267,10 +251,14 @@ func Sign(msg []byte, seckey []byte) []byte {
 		log.Panic("Sign: message is 0")
 	}
 
-	ret := cSig.Sign(&seckey1, &msg1, &nonce, &recid)
-
-	if ret != 1 {
-		log.Panic("Secp25k1-go, Sign, signature operation failed")
+	// The nonce is derived deterministically from the seckey and msg (RFC6979).
+	// If the nonce produces an invalid signature, the next nonce is tried.
+	nonces := newRFC6979(seckey, msg)
+	for {
+		nonce := nonces.nextNonce()
+		if cSig.Sign(&seckey1, &msg1, &nonce, &recid) == 1 {
+			break
+		}
 	}
 
 	sigBytes := cSig.Bytes()
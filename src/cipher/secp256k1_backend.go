@@ -0,0 +1,46 @@
+package cipher
+
+import (
+	secp256k1 "github.com/ness-network/privateness/src/cipher/secp256k1-go"
+)
+
+// secp256k1Backend implements the secp256k1 operations used by SignHash, PubKeyFromSig
+// and the signature verification functions.
+//
+// The backend is selected at build time. The pure Go backend is the default.
+// Building with the cgo_secp256k1 tag selects the libsecp256k1 backend, which requires cgo
+// and libsecp256k1 built with the recovery module.
+//
+// All backends must produce identical results. Signatures use RFC6979 nonces,
+// so signing the same hash with the same key gives the same signature with every backend.
+type secp256k1Backend interface {
+	// name identifies the backend
+	name() string
+	// sign signs a 32 byte msg with a valid 32 byte seckey, returning a 65 byte compact recoverable signature.
+	// Panics if signing fails.
+	sign(msg, seckey []byte) []byte
+	// recoverPubkey recovers the 33 byte compressed pubkey from a 65 byte signature of msg.
+	// Returns nil if the pubkey can't be recovered.
+	recoverPubkey(msg, sig []byte) []byte
+	// verifySignature verifies that the 65 byte sig signed msg with pubkey. Returns 1 on success.
+	verifySignature(msg, sig, pubkey []byte) int
+}
+
+// pureGoBackend is the secp256k1Backend implemented in Go by the secp256k1-go package
+type pureGoBackend struct{}
+
+func (pureGoBackend) name() string {
+	return "pure-go"
+}
+
+func (pureGoBackend) sign(msg, seckey []byte) []byte {
+	return secp256k1.Sign(msg, seckey)
+}
+
+func (pureGoBackend) recoverPubkey(msg, sig []byte) []byte {
+	return secp256k1.RecoverPubkey(msg, sig)
+}
+
+func (pureGoBackend) verifySignature(msg, sig, pubkey []byte) int {
+	return secp256k1.VerifySignature(msg, sig, pubkey)
+}
@@ -0,0 +1,143 @@
+// +build cgo_secp256k1
+
+package cipher
+
+/*
+#cgo LDFLAGS: -lsecp256k1
+#include <secp256k1.h>
+#include <secp256k1_recovery.h>
+
+static secp256k1_context* newContext(void) {
+	return secp256k1_context_create(SECP256K1_CONTEXT_SIGN | SECP256K1_CONTEXT_VERIFY);
+}
+
+// signRecoverable signs msg32 with seckey using RFC6979 nonces and writes
+// the 64 byte compact signature followed by the recovery id to sig65
+static int signRecoverable(const secp256k1_context* ctx, unsigned char* sig65, const unsigned char* msg32, const unsigned char* seckey) {
+	secp256k1_ecdsa_recoverable_signature sig;
+	int recid;
+
+	if (!secp256k1_ecdsa_sign_recoverable(ctx, &sig, msg32, seckey, secp256k1_nonce_function_rfc6979, NULL)) {
+		return 0;
+	}
+	if (!secp256k1_ecdsa_recoverable_signature_serialize_compact(ctx, sig65, &recid, &sig)) {
+		return 0;
+	}
+	sig65[64] = (unsigned char)recid;
+	return 1;
+}
+
+// recoverCompressed recovers the pubkey of a 65 byte compact signature of msg32
+// and writes it to pubkey33 in compressed form
+static int recoverCompressed(const secp256k1_context* ctx, unsigned char* pubkey33, const unsigned char* msg32, const unsigned char* sig65) {
+	secp256k1_ecdsa_recoverable_signature sig;
+	secp256k1_pubkey pubkey;
+	size_t outlen = 33;
+
+	if (!secp256k1_ecdsa_recoverable_signature_parse_compact(ctx, &sig, sig65, sig65[64])) {
+		return 0;
+	}
+	if (!secp256k1_ecdsa_recover(ctx, &pubkey, &sig, msg32)) {
+		return 0;
+	}
+	if (!secp256k1_ec_pubkey_serialize(ctx, pubkey33, &outlen, &pubkey, SECP256K1_EC_COMPRESSED)) {
+		return 0;
+	}
+	return outlen == 33;
+}
+*/
+import "C"
+
+import (
+	"bytes"
+	"log"
+	"unsafe"
+)
+
+// backend is the secp256k1 backend selected at build time
+var backend secp256k1Backend = newCgoBackend()
+
+// cgoBackend is the secp256k1Backend implemented by the libsecp256k1 C library
+type cgoBackend struct {
+	ctx *C.secp256k1_context
+}
+
+func newCgoBackend() cgoBackend {
+	ctx := C.newContext()
+	if ctx == nil {
+		log.Panic("secp256k1_context_create failed")
+	}
+	return cgoBackend{
+		ctx: ctx,
+	}
+}
+
+func (cgoBackend) name() string {
+	return "cgo-secp256k1"
+}
+
+func (b cgoBackend) sign(msg, seckey []byte) []byte {
+	if len(seckey) != 32 {
+		log.Panic("Sign, Invalid seckey length")
+	}
+	if len(msg) != 32 {
+		log.Panic("Sign, message must be 32 bytes")
+	}
+
+	sig := make([]byte, 65)
+	if C.signRecoverable(b.ctx, cBytes(sig), cBytes(msg), cBytes(seckey)) != 1 {
+		log.Panic("cgo-secp256k1, Sign, signature operation failed")
+	}
+	return sig
+}
+
+// recoverPubkey has the same semantics as the pure Go backend, which only uses
+// the lowest two bits of the recovery id. verifySignature rejects recovery ids >= 4.
+func (b cgoBackend) recoverPubkey(msg, sig []byte) []byte {
+	if len(sig) != 65 {
+		log.Panic("sig length must be 65 bytes")
+	}
+	if len(msg) != 32 {
+		return nil
+	}
+
+	sig2 := make([]byte, 65)
+	copy(sig2, sig)
+	sig2[64] &= 3
+
+	pubkey := make([]byte, 33)
+	if C.recoverCompressed(b.ctx, cBytes(pubkey), cBytes(msg), cBytes(sig2)) != 1 {
+		return nil
+	}
+	return pubkey
+}
+
+// verifySignature has the same semantics as the pure Go backend: the signature must
+// have a low S value and a valid recovery id, and the pubkey recovered from it must match pubkey.
+func (b cgoBackend) verifySignature(msg, sig, pubkey []byte) int {
+	if len(sig) != 65 {
+		log.Panic("VerifySignature, invalid signature length")
+	}
+	if len(pubkey) != 33 {
+		log.Panic("VerifySignature, invalid pubkey length")
+	}
+	if len(msg) == 0 {
+		return 0
+	}
+	if (sig[32] >> 7) == 1 {
+		return 0
+	}
+	if sig[64] >= 4 {
+		return 0
+	}
+
+	pubkey2 := b.recoverPubkey(msg, sig)
+	if pubkey2 == nil || !bytes.Equal(pubkey, pubkey2) {
+		return 0
+	}
+	return 1
+}
+
+func cBytes(b []byte) *C.uchar {
+	return (*C.uchar)(unsafe.Pointer(&b[0]))
+}
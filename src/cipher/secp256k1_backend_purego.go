@@ -0,0 +1,6 @@
+// +build !cgo_secp256k1
+
+package cipher
+
+// backend is the secp256k1 backend selected at build time
+var backend secp256k1Backend = pureGoBackend{}
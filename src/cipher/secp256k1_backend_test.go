@@ -0,0 +1,142 @@
+package cipher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBackends returns the pure Go backend and the backend selected at build time, if different
+func testBackends() []secp256k1Backend {
+	backends := []secp256k1Backend{pureGoBackend{}}
+	if backend.name() != backends[0].name() {
+		backends = append(backends, backend)
+	}
+	return backends
+}
+
+func TestSecp256k1BackendConsistency(t *testing.T) {
+	n := 2000
+	if testing.Short() {
+		n = 200
+	}
+
+	backends := testBackends()
+	t.Logf("Testing backends: %s", backendNames(backends))
+
+	for i := 0; i < n; i++ {
+		pk, sk := GenerateKeyPair()
+		hash := SumSHA256(randBytes(t, 32))
+
+		var sigs [][]byte
+		for _, b := range backends {
+			sig := b.sign(hash[:], sk[:])
+			require.Len(t, sig, 65)
+
+			// Signatures are deterministic
+			require.Equal(t, sig, b.sign(hash[:], sk[:]), b.name())
+			sigs = append(sigs, sig)
+		}
+
+		// Identical (key, hash) inputs give identical signatures with every backend
+		for j := 1; j < len(sigs); j++ {
+			require.Equal(t, sigs[0], sigs[j], "%s and %s signatures differ", backends[0].name(), backends[j].name())
+		}
+
+		// Every backend recovers the pubkey and verifies the signatures of every backend
+		for _, b := range backends {
+			for _, sig := range sigs {
+				require.Equal(t, pk[:], b.recoverPubkey(hash[:], sig), b.name())
+				require.Equal(t, 1, b.verifySignature(hash[:], sig, pk[:]), b.name())
+			}
+
+			// A signature of a different hash does not verify
+			hash2 := SumSHA256(hash[:])
+			require.Equal(t, 0, b.verifySignature(hash2[:], sigs[0], pk[:]), b.name())
+
+			// A signature with a high S value does not verify
+			badSig := append([]byte{}, sigs[0]...)
+			badSig[32] |= 0x80
+			require.Equal(t, 0, b.verifySignature(hash[:], badSig, pk[:]), b.name())
+
+			// A signature with an invalid recovery id does not verify.
+			// Pubkey recovery only uses the lowest two bits of the recovery id.
+			badSig = append([]byte{}, sigs[0]...)
+			badSig[64] += 4
+			require.Equal(t, pk[:], b.recoverPubkey(hash[:], badSig), b.name())
+			require.Equal(t, 0, b.verifySignature(hash[:], badSig, pk[:]), b.name())
+
+			// A signature with an invalid R value can't be recovered
+			badSig = append([]byte{}, sigs[0]...)
+			for k := 0; k < 32; k++ {
+				badSig[k] = 0xff
+			}
+			require.Nil(t, b.recoverPubkey(hash[:], badSig), b.name())
+			require.Equal(t, 0, b.verifySignature(hash[:], badSig, pk[:]), b.name())
+		}
+	}
+}
+
+func TestSignHashDeterministic(t *testing.T) {
+	_, sk := GenerateKeyPair()
+	hash := SumSHA256(randBytes(t, 32))
+
+	sig1 := MustSignHash(hash, sk)
+	sig2 := MustSignHash(hash, sk)
+	require.Equal(t, sig1, sig2)
+
+	sig3 := MustSignHash(SumSHA256(hash[:]), sk)
+	require.NotEqual(t, sig1, sig3)
+}
+
+func backendNames(backends []secp256k1Backend) []string {
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.name()
+	}
+	return names
+}
+
+func BenchmarkSecp256k1BackendSign(b *testing.B) {
+	_, sk := GenerateKeyPair()
+	hash := SumSHA256(RandByte(32))
+
+	for _, backend := range testBackends() {
+		b.Run(backend.name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				backend.sign(hash[:], sk[:])
+			}
+		})
+	}
+}
+
+func BenchmarkSecp256k1BackendRecoverPubkey(b *testing.B) {
+	_, sk := GenerateKeyPair()
+	hash := SumSHA256(RandByte(32))
+	sig := MustSignHash(hash, sk)
+
+	for _, backend := range testBackends() {
+		b.Run(backend.name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				backend.recoverPubkey(hash[:], sig[:])
+			}
+		})
+	}
+}
+
+func BenchmarkSecp256k1BackendVerifySignature(b *testing.B) {
+	pk, sk := GenerateKeyPair()
+	hash := SumSHA256(RandByte(32))
+	sig := MustSignHash(hash, sk)
+
+	for _, backend := range testBackends() {
+		b.Run(backend.name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				backend.verifySignature(hash[:], sig[:], pk[:])
+			}
+		})
+	}
+}
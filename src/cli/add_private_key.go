@@ -6,8 +6,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 func addPrivateKeyCmd() *cobra.Command {
@@ -34,11 +34,10 @@ func addPrivateKeyCmd() *cobra.Command {
 			walletFile := args[0]
 			skStr := args[1]
 
-			password, err := c.Flags().GetString("password")
+			pr, err := passwordReaderFromFlags(c)
 			if err != nil {
 				return err
 			}
-			pr := NewPasswordReader([]byte(password))
 
 			err = AddPrivateKeyToFile(walletFile, skStr, pr)
 
@@ -55,7 +54,7 @@ func addPrivateKeyCmd() *cobra.Command {
 		},
 	}
 
-	addPrivateKeyCmd.Flags().StringP("password", "p", "", "wallet password")
+	addPasswordFlags(addPrivateKeyCmd, "wallet password")
 
 	return addPrivateKeyCmd
 }
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"encoding/hex"
+
+	"github.com/spf13/cobra"
+
+	pcipher "github.com/ness-network/privateness/src/cipher"
+)
+
+// AddressConvertResult is the output of the addressConvert command.
+// Encodings that cannot be derived from the input are omitted.
+type AddressConvertResult struct {
+	Format         string `json:"format"`
+	PubKey         string `json:"pubkey,omitempty"`
+	SkycoinAddress string `json:"skycoin_address,omitempty"`
+	BitcoinAddress string `json:"bitcoin_address,omitempty"`
+	Hash160        string `json:"hash160,omitempty"`
+}
+
+func addressConvertCmd() *cobra.Command {
+	addressConvertCmd := &cobra.Command{
+		Short: "Convert an address between the Skycoin and Bitcoin encodings",
+		Use:   "addressConvert [pubkey, hash160, skycoin or bitcoin address]",
+		Long: `Prints the Skycoin address, Bitcoin address and hash160 of the argument.
+    The argument is a hex encoded public key, a hex encoded hash160,
+    a Skycoin address or a Bitcoin address. The format is detected unless --format is set.
+
+    A Skycoin address is derived from ripemd160(sha256(sha256(pubkey))) and a Bitcoin
+    address from ripemd160(sha256(pubkey)), so a Skycoin address can only be converted
+    to a Bitcoin address, and vice versa, from the public key.
+    A Bitcoin address and its hash160 convert to each other.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			format, err := c.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			v, f, err := pcipher.ParseAddressVariant(args[0], pcipher.AddressFormat(format))
+			if err != nil {
+				return err
+			}
+
+			return printJSON(newAddressConvertResult(v, f))
+		},
+	}
+
+	addressConvertCmd.Flags().StringP("format", "f", "", "Input format (\"pubkey\", \"hash160\", \"skycoin\", \"bitcoin\"). Detected if not set.")
+
+	return addressConvertCmd
+}
+
+func newAddressConvertResult(v pcipher.AddressVariantSet, f pcipher.AddressFormat) AddressConvertResult {
+	r := AddressConvertResult{
+		Format: string(f),
+	}
+
+	if v.PubKey != (pcipher.PubKey{}) {
+		r.PubKey = v.PubKey.Hex()
+	}
+	if !v.Address.Null() {
+		r.SkycoinAddress = v.Address.String()
+	}
+	if !v.BitcoinAddress.Null() {
+		r.BitcoinAddress = v.BitcoinAddress.String()
+	}
+	if v.Hash160 != (pcipher.Ripemd160{}) {
+		r.Hash160 = hex.EncodeToString(v.Hash160[:])
+	}
+
+	return r
+}
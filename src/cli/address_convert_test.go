@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pcipher "github.com/ness-network/privateness/src/cipher"
+)
+
+func TestNewAddressConvertResult(t *testing.T) {
+	pub := pcipher.MustPubKeyFromHex("034f355bdcb7cc0af728ef3cceb9615d90684bb5b2ca5f859ab0f0b704075871aa")
+	v := pcipher.AddressVariants(pub)
+
+	r := newAddressConvertResult(v, pcipher.AddressFormatPubKey)
+	require.Equal(t, AddressConvertResult{
+		Format:         "pubkey",
+		PubKey:         pub.Hex(),
+		SkycoinAddress: pcipher.AddressFromPubKey(pub).String(),
+		BitcoinAddress: "1Q1pE5vPGEEMqRcVRMbtBK842Y6Pzo6nK9",
+		Hash160:        "fc7250a211deddc70ee5a2738de5f07817351cef",
+	}, r)
+
+	// A bitcoin address converts to its hash160 only
+	v, f, err := pcipher.ParseAddressVariant(r.BitcoinAddress, "")
+	require.NoError(t, err)
+	require.Equal(t, AddressConvertResult{
+		Format:         "bitcoin",
+		BitcoinAddress: r.BitcoinAddress,
+		Hash160:        r.Hash160,
+	}, newAddressConvertResult(v, f))
+
+	// And back
+	v, f, err = pcipher.ParseAddressVariant(r.Hash160, "")
+	require.NoError(t, err)
+	require.Equal(t, AddressConvertResult{
+		Format:         "hash160",
+		BitcoinAddress: r.BitcoinAddress,
+		Hash160:        r.Hash160,
+	}, newAddressConvertResult(v, f))
+
+	v, f, err = pcipher.ParseAddressVariant(r.SkycoinAddress, "")
+	require.NoError(t, err)
+	require.Equal(t, AddressConvertResult{
+		Format:         "skycoin",
+		SkycoinAddress: r.SkycoinAddress,
+	}, newAddressConvertResult(v, f))
+}
@@ -9,9 +9,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 func addressGenCmd() *cobra.Command {
@@ -75,8 +75,12 @@ func addressGenCmd() *cobra.Command {
 					return errors.New("Encrypt flag requires -mode to be json")
 				}
 
-				var err error
-				password, err = PasswordFromTerm{}.Password()
+				pr, err := passwordReaderFromFlags(c)
+				if err != nil {
+					return err
+				}
+
+				password, err = pr.Password()
 				if err != nil {
 					return err
 				}
@@ -139,6 +143,7 @@ func addressGenCmd() *cobra.Command {
 	addressGenCmd.Flags().BoolP("hide-secrets", "i", false, "Hide the secret key and seed from the output when printing a JSON wallet file")
 	addressGenCmd.Flags().StringP("mode", "m", "wallet", "Output mode. Options are wallet (prints a full JSON wallet), addresses (prints addresses in plain text), secrets (prints secret keys in plain text)")
 	addressGenCmd.Flags().BoolP("encrypt", "x", false, "Encrypt the wallet when printing a JSON wallet")
+	addPasswordFlags(addressGenCmd, "Password of the encrypted wallet")
 
 	return addressGenCmd
 }
@@ -1,26 +1,49 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
+// AddressCountResult is the output of the addressCount command
+type AddressCountResult struct {
+	Count uint64 `json:"count"`
+}
+
 func addresscountCmd() *cobra.Command {
-	return &cobra.Command{
-		Short:                 "Get the count of addresses with unspent outputs (coins)",
-		Long:                  "Returns the count of all addresses that currently have unspent outputs (coins) associated with them.",
-		Use:                   "addresscount",
-		Args:                  cobra.NoArgs,
-		DisableFlagsInUseLine: true,
-		SilenceUsage:          true,
-		RunE:                  getAddresscount,
+	addresscountCmd := &cobra.Command{
+		Short:        "Get the count of addresses with unspent outputs (coins)",
+		Long:         "Returns the count of all addresses that currently have unspent outputs (coins) associated with them.",
+		Use:          "addressCount",
+		Aliases:      []string{"addresscount"},
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         getAddresscount,
 	}
+
+	addresscountCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return addresscountCmd
 }
 
-func getAddresscount(_ *cobra.Command, _ []string) error {
+func getAddresscount(c *cobra.Command, _ []string) error {
+	jsonOutput, err := c.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+
 	addresscount, err := apiClient.AddressCount()
 	if err != nil {
 		return err
 	}
 
-	return printJSON(addresscount)
+	if jsonOutput {
+		return printJSON(AddressCountResult{
+			Count: addresscount,
+		})
+	}
+
+	fmt.Printf("Addresses with unspent outputs: %d\n", addresscount)
+	return nil
 }
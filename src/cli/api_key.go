@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"net/http"
+)
+
+// apiKeyHeader is the header of the API key required by the node's wallet, storage and admin endpoints
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyTransport is an http.RoundTripper that adds the node's API key to requests
+type apiKeyTransport struct {
+	apiKey    string
+	transport http.RoundTripper
+}
+
+func newAPIKeyTransport(apiKey string, transport http.RoundTripper) *apiKeyTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &apiKeyTransport{
+		apiKey:    apiKey,
+		transport: transport,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set(apiKeyHeader, t.apiKey)
+
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyTransport(t *testing.T) {
+	var header http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	}))
+	defer s.Close()
+
+	c, err := newAPIClient(s.URL, 0)
+	require.NoError(t, err)
+	c.SetAuth("user", "pass")
+	c.HTTPClient.Transport = newWalletTokenTransport("foo", c.HTTPClient.Transport)
+	c.HTTPClient.Transport = newAPIKeyTransport("bar", c.HTTPClient.Transport)
+
+	var obj struct{}
+	require.NoError(t, c.Get("/api/v1/wallet?id=foo.wlt", &obj))
+	require.Equal(t, "bar", header.Get(apiKeyHeader))
+	require.Equal(t, "Basic dXNlcjpwYXNz", header.Get("Authorization"))
+	require.Equal(t, "foo", header.Get(walletTokenHeader))
+}
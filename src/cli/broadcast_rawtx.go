@@ -1,29 +1,216 @@
 package cli
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	"github.com/skycoin/skycoin/src/util/mathutil"
 )
 
 func broadcastTxCmd() *cobra.Command {
-	return &cobra.Command{
-		Short:                 "Broadcast a raw transaction to the network",
-		Use:                   "broadcastTransaction [raw transaction]",
-		Args:                  cobra.ExactArgs(1),
-		DisableFlagsInUseLine: true,
-		SilenceUsage:          true,
-		RunE: func(_ *cobra.Command, args []string) error {
-			rawtx := args[0]
+	broadcastTxCmd := &cobra.Command{
+		Short: "Broadcast a raw transaction to the network",
+		Use:   "broadcastTransaction [raw transaction]",
+		Long: fmt.Sprintf(`Broadcast a signed raw transaction to the network.
 
-			txid, err := apiClient.InjectEncodedTransaction(rawtx)
+    The raw transaction is read from the argument, from the file of --file,
+    or from stdin if the argument is "-". Whitespace and newlines are ignored.
+
+    Before it is sent to the node, the transaction is checked locally: it must
+    deserialize, its inner hash must match its inputs and outputs, it must have
+    a signature per input, and it must not exceed the max block size.
+
+    A summary of the transaction is printed to stderr, and the broadcast must be
+    confirmed unless --yes is set. --yes is required when reading from stdin.
+    The transaction id is printed to stdout.
+
+    Exit codes, in addition to the exit codes of all commands:
+      %d: the transaction failed the local checks`, ExitCodeInvalidTransaction),
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			file, err := c.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			yes, err := c.Flags().GetBool("yes")
+			if err != nil {
+				return err
+			}
+
+			fromStdin := len(args) == 1 && args[0] == "-"
+			if fromStdin && !yes {
+				return errors.New("--yes is required when reading the transaction from stdin")
+			}
+
+			rawtx, err := readRawTransaction(args, file, os.Stdin)
 			if err != nil {
 				return err
 			}
 
+			txn, err := checkRawTransaction(rawtx)
+			if err != nil {
+				return ExitError{
+					error: err,
+					Code:  ExitCodeInvalidTransaction,
+				}
+			}
+
+			if err := printTransactionSummary(os.Stderr, txn); err != nil {
+				return err
+			}
+
+			if !yes {
+				ok, err := confirm(os.Stdin, os.Stderr, "Broadcast this transaction?")
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errors.New("broadcast cancelled")
+				}
+			}
+
+			txid, err := apiClient.InjectEncodedTransaction(rawtx)
+			if err != nil {
+				return injectTransactionError(err)
+			}
+
 			fmt.Println(txid)
 			return nil
 		},
 	}
 
+	broadcastTxCmd.Flags().StringP("file", "f", "", "Read the raw transaction from a file")
+	broadcastTxCmd.Flags().BoolP("yes", "y", false, "Broadcast without asking for confirmation")
+
+	return broadcastTxCmd
+}
+
+// readRawTransaction reads the hex encoded transaction from the args, from file, or from stdin if the arg is "-",
+// and removes its whitespace
+func readRawTransaction(args []string, file string, stdin io.Reader) (string, error) {
+	var raw string
+	switch {
+	case len(args) == 1 && file != "":
+		return "", errors.New("the raw transaction and --file cannot be combined")
+	case len(args) == 1 && args[0] == "-":
+		b, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("read stdin failed: %v", err)
+		}
+		raw = string(b)
+	case len(args) == 1:
+		raw = args[0]
+	case file != "":
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		raw = string(b)
+	default:
+		return "", errors.New("missing raw transaction")
+	}
+
+	raw = strings.Join(strings.Fields(raw), "")
+	if raw == "" {
+		return "", errors.New("raw transaction is empty")
+	}
+
+	return raw, nil
+}
+
+// checkRawTransaction deserializes a hex encoded transaction and checks that it can be broadcast:
+// its inner hash is valid, it has a signature per input and it fits in a block
+func checkRawTransaction(rawtx string) (*coin.Transaction, error) {
+	txn, err := coin.DeserializeTransactionHex(rawtx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %v", err)
+	}
+
+	if txn.InnerHash != txn.HashInner() {
+		return nil, errors.New("invalid raw transaction: inner hash does not match the inputs and outputs")
+	}
+
+	if len(txn.Sigs) != len(txn.In) {
+		return nil, fmt.Errorf("invalid raw transaction: %d signatures for %d inputs", len(txn.Sigs), len(txn.In))
+	}
+
+	for i, sig := range txn.Sigs {
+		if sig.Null() {
+			return nil, fmt.Errorf("invalid raw transaction: input %d is not signed", i)
+		}
+	}
+
+	size, err := txn.Size()
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %v", err)
+	}
+
+	if size > params.UserVerifyTxn.MaxTransactionSize {
+		return nil, fmt.Errorf("invalid raw transaction: size %d exceeds the max block size %d", size, params.UserVerifyTxn.MaxTransactionSize)
+	}
+
+	return &txn, nil
+}
+
+// printTransactionSummary prints the id, inputs and outputs of a transaction, with the total coins and hours of its outputs
+func printTransactionSummary(out io.Writer, txn *coin.Transaction) error {
+	var coins, hours uint64
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Transaction:\t%s\n", txn.Hash().Hex())
+	fmt.Fprintf(w, "Inputs:\t%d\n", len(txn.In))
+	fmt.Fprintln(w, "Outputs:")
+	for _, o := range txn.Out {
+		c, err := droplet.ToString(o.Coins)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  %s\t%s coins\t%d hours\n", o.Address, c, o.Hours)
+
+		coins, err = mathutil.AddUint64(coins, o.Coins)
+		if err != nil {
+			return errors.New("output coins overflow")
+		}
+		hours, err = mathutil.AddUint64(hours, o.Hours)
+		if err != nil {
+			return errors.New("output hours overflow")
+		}
+	}
+
+	c, err := droplet.ToString(coins)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Total:\t%s coins\t%d hours\n", c, hours)
+
+	return w.Flush()
+}
+
+// confirm asks a yes/no question and returns true if the answer is "y" or "yes"
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
 }
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+)
+
+const testRawTxn = "dc00000000247bd0f0a1cf39fa51ea3eca044e4d9cbb28fff5376e90e2eb008c9fe0af384301000000cf5869cb1b21da4da98bdb5dca57b1fd5a6fcbefd37d4f1eb332b21233f92cd62e00d8e2f1c8545142eaeed8fada1158dd0e552d3be55f18dd60d7e85407ef4f000100000005e524872c838de517592c9a495d758b8ab2ec32d3e4d3fb131023a424386634020000000007445b5d6fbbb1a7d70bef941fb5da234a10fcae40420f00000000000100000000000000008001532c3a705e7e62bb0bb80630ecc21a87ec090024f400000000009805000000000000"
+
+func TestReadRawTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "broadcast")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Split over lines, as a wrapped hex dump would be
+	file := filepath.Join(dir, "txn.hex")
+	err = ioutil.WriteFile(file, []byte(testRawTxn[:100]+"\n"+testRawTxn[100:]+"\n"), 0600)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		args  []string
+		file  string
+		stdin string
+		err   string
+	}{
+		{
+			name: "arg",
+			args: []string{" " + testRawTxn + "\n"},
+		},
+		{
+			name: "file",
+			file: file,
+		},
+		{
+			name:  "stdin",
+			args:  []string{"-"},
+			stdin: "\t" + testRawTxn[:10] + " " + testRawTxn[10:] + "\r\n",
+		},
+		{
+			name: "arg and file",
+			args: []string{testRawTxn},
+			file: file,
+			err:  "the raw transaction and --file cannot be combined",
+		},
+		{
+			name: "missing",
+			err:  "missing raw transaction",
+		},
+		{
+			name:  "empty stdin",
+			args:  []string{"-"},
+			stdin: " \n",
+			err:   "raw transaction is empty",
+		},
+		{
+			name: "missing file",
+			file: filepath.Join(dir, "missing.hex"),
+			err:  "no such file or directory",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := readRawTransaction(tc.args, tc.file, strings.NewReader(tc.stdin))
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testRawTxn, raw)
+		})
+	}
+}
+
+func TestCheckRawTransaction(t *testing.T) {
+	txn, err := checkRawTransaction(testRawTxn)
+	require.NoError(t, err)
+	require.Len(t, txn.Out, 2)
+
+	valid, err := coin.DeserializeTransactionHex(testRawTxn)
+	require.NoError(t, err)
+
+	encode := func(txn coin.Transaction) string {
+		raw, err := txn.SerializeHex()
+		require.NoError(t, err)
+		return raw
+	}
+
+	badInnerHash := valid
+	badInnerHash.Out = append([]coin.TransactionOutput{}, valid.Out...)
+	badInnerHash.Out[0].Coins++
+
+	missingSig := valid
+	missingSig.Sigs = nil
+
+	nullSig := valid
+	nullSig.Sigs = []cipher.Sig{{}}
+
+	tooLarge := valid
+	tooLarge.Out = append([]coin.TransactionOutput{}, valid.Out...)
+	// Each output is 37 bytes
+	for i := 0; i < int(params.UserVerifyTxn.MaxTransactionSize)/37; i++ {
+		tooLarge.Out = append(tooLarge.Out, valid.Out[0])
+	}
+	tooLarge.InnerHash = tooLarge.HashInner()
+
+	cases := []struct {
+		name  string
+		rawtx string
+		err   string
+	}{
+		{
+			name:  "not hex",
+			rawtx: "xyz",
+			err:   "invalid raw transaction: encoding/hex: invalid byte",
+		},
+		{
+			name:  "truncated",
+			rawtx: testRawTxn[:len(testRawTxn)-2],
+			err:   "invalid raw transaction",
+		},
+		{
+			name:  "inner hash",
+			rawtx: encode(badInnerHash),
+			err:   "invalid raw transaction: inner hash does not match the inputs and outputs",
+		},
+		{
+			name:  "missing signature",
+			rawtx: encode(missingSig),
+			err:   "invalid raw transaction: 0 signatures for 1 inputs",
+		},
+		{
+			name:  "null signature",
+			rawtx: encode(nullSig),
+			err:   "invalid raw transaction: input 0 is not signed",
+		},
+		{
+			name:  "too large",
+			rawtx: encode(tooLarge),
+			err:   "exceeds the max block size",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := checkRawTransaction(tc.rawtx)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestPrintTransactionSummary(t *testing.T) {
+	txn, err := checkRawTransaction(testRawTxn)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = printTransactionSummary(&buf, txn)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 6)
+	require.Equal(t, []string{"Transaction:", txn.Hash().Hex()}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"Inputs:", "1"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{txn.Out[0].Address.String(), "1.000000", "coins", "1", "hours"}, strings.Fields(lines[3]))
+	require.Equal(t, []string{txn.Out[1].Address.String(), "16.000000", "coins", "1432", "hours"}, strings.Fields(lines[4]))
+	require.Equal(t, []string{"Total:", "17.000000", "coins", "1433", "hours"}, strings.Fields(lines[5]))
+}
+
+func TestConfirm(t *testing.T) {
+	for answer, ok := range map[string]bool{
+		"y\n":    true,
+		"YES\n":  true,
+		" yes ":  true,
+		"n\n":    false,
+		"\n":     false,
+		"":       false,
+		"yess\n": false,
+	} {
+		var out bytes.Buffer
+		got, err := confirm(strings.NewReader(answer), &out, "Broadcast?")
+		require.NoError(t, err)
+		require.Equal(t, ok, got, answer)
+		require.Equal(t, "Broadcast? [y/N]: ", out.String())
+	}
+}
@@ -6,10 +6,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/droplet"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 // Balance represents an coin and hours balance
@@ -24,6 +24,7 @@ type AddressBalances struct {
 	Spendable Balance `json:"spendable"`
 	Expected  Balance `json:"expected"`
 	Address   string  `json:"address"`
+	Label     string  `json:"label,omitempty"`
 }
 
 // BalanceResult represents an set of addresses' balances
@@ -34,14 +35,14 @@ type BalanceResult struct {
 	Addresses []AddressBalances `json:"addresses"`
 }
 
-func walletBalanceCmd() *cobra.Command {
-	return &cobra.Command{
-		Short:                 "Check the balance of a wallet",
-		Use:                   "walletBalance [wallet]",
-		Args:                  cobra.ExactArgs(1),
-		DisableFlagsInUseLine: true,
-		RunE:                  checkWltBalance,
-	}
+// WalletBalanceResult represents the balances of a wallet's addresses,
+// with the coins and hours moving in and out of the wallet in unconfirmed transactions
+type WalletBalanceResult struct {
+	BalanceResult
+	// PendingIncoming is the balance of the outputs created by unconfirmed transactions
+	PendingIncoming Balance `json:"pending_incoming"`
+	// PendingOutgoing is the balance of the outputs spent by unconfirmed transactions
+	PendingOutgoing Balance `json:"pending_outgoing"`
 }
 
 func addressBalanceCmd() *cobra.Command {
@@ -57,21 +58,6 @@ func addressBalanceCmd() *cobra.Command {
 	}
 }
 
-func checkWltBalance(c *cobra.Command, args []string) error {
-	w := args[0]
-	balRlt, err := CheckWalletBalance(apiClient, w)
-	switch err.(type) {
-	case nil:
-	case WalletLoadError:
-		printHelp(c)
-		return err
-	default:
-		return err
-	}
-
-	return printJSON(balRlt)
-}
-
 func addrBalance(_ *cobra.Command, args []string) error {
 	numArgs := len(args)
 
@@ -96,7 +82,7 @@ func addrBalance(_ *cobra.Command, args []string) error {
 // PUBLIC
 
 // CheckWalletBalance returns the total and individual balances of addresses in a wallet file
-func CheckWalletBalance(c GetOutputser, walletFile string) (*BalanceResult, error) {
+func CheckWalletBalance(c GetOutputser, walletFile string) (*WalletBalanceResult, error) {
 	wlt, err := wallet.Load(walletFile)
 	if err != nil {
 		return nil, WalletLoadError{err}
@@ -108,7 +94,90 @@ func CheckWalletBalance(c GetOutputser, walletFile string) (*BalanceResult, erro
 		addrs = append(addrs, a.String())
 	}
 
-	return GetBalanceOfAddresses(c, addrs)
+	outs, err := c.OutputsForAddresses(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	for _, e := range wlt.GetEntries() {
+		if e.Label != "" {
+			labels[e.Address.String()] = e.Label
+		}
+	}
+
+	return getWalletBalance(outs, addrs, labels)
+}
+
+func getWalletBalance(outs *readable.UnspentOutputsSummary, addrs []string, labels map[string]string) (*WalletBalanceResult, error) {
+	balRlt, err := getBalanceOfAddresses(outs, addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, a := range balRlt.Addresses {
+		balRlt.Addresses[i].Label = labels[a.Address]
+	}
+
+	incoming, err := sumOutputs(outs.IncomingOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	outgoing, err := sumOutputs(outs.OutgoingOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	wltBalRlt := &WalletBalanceResult{
+		BalanceResult: *balRlt,
+	}
+
+	wltBalRlt.PendingIncoming, err = newBalance(incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	wltBalRlt.PendingOutgoing, err = newBalance(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	return wltBalRlt, nil
+}
+
+// sumOutputs returns the total coins and calculated hours of outs
+func sumOutputs(outs readable.UnspentOutputs) (wallet.Balance, error) {
+	var total wallet.Balance
+	for _, o := range outs {
+		amt, err := droplet.FromString(o.Coins)
+		if err != nil {
+			return wallet.Balance{}, fmt.Errorf("droplet.FromString failed: %v", err)
+		}
+
+		total, err = total.Add(wallet.Balance{
+			Coins: amt,
+			Hours: o.CalculatedHours,
+		})
+		if err != nil {
+			return wallet.Balance{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// newBalance converts a wallet.Balance to a Balance
+func newBalance(b wallet.Balance) (Balance, error) {
+	coins, err := droplet.ToString(b.Coins)
+	if err != nil {
+		return Balance{}, err
+	}
+
+	return Balance{
+		Coins: coins,
+		Hours: strconv.FormatUint(b.Hours, 10),
+	}, nil
 }
 
 // GetBalanceOfAddresses returns the total and individual balances of a set of addresses
@@ -185,18 +254,6 @@ func getBalanceOfAddresses(outs *readable.UnspentOutputsSummary, addrs []string)
 		addrBalances[o.Address] = b
 	}
 
-	toBalance := func(b wallet.Balance) (Balance, error) {
-		coins, err := droplet.ToString(b.Coins)
-		if err != nil {
-			return Balance{}, err
-		}
-
-		return Balance{
-			Coins: coins,
-			Hours: strconv.FormatUint(b.Hours, 10),
-		}, nil
-	}
-
 	var totalConfirmed, totalSpendable, totalExpected wallet.Balance
 	balRlt := &BalanceResult{
 		Addresses: make([]AddressBalances, len(addrs)),
@@ -223,34 +280,34 @@ func getBalanceOfAddresses(outs *readable.UnspentOutputsSummary, addrs []string)
 			return nil, err
 		}
 
-		balRlt.Addresses[i].Confirmed, err = toBalance(b.confirmed)
+		balRlt.Addresses[i].Confirmed, err = newBalance(b.confirmed)
 		if err != nil {
 			return nil, err
 		}
 
-		balRlt.Addresses[i].Spendable, err = toBalance(b.spendable)
+		balRlt.Addresses[i].Spendable, err = newBalance(b.spendable)
 		if err != nil {
 			return nil, err
 		}
 
-		balRlt.Addresses[i].Expected, err = toBalance(b.expected)
+		balRlt.Addresses[i].Expected, err = newBalance(b.expected)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	var err error
-	balRlt.Confirmed, err = toBalance(totalConfirmed)
+	balRlt.Confirmed, err = newBalance(totalConfirmed)
 	if err != nil {
 		return nil, err
 	}
 
-	balRlt.Spendable, err = toBalance(totalSpendable)
+	balRlt.Spendable, err = newBalance(totalSpendable)
 	if err != nil {
 		return nil, err
 	}
 
-	balRlt.Expected, err = toBalance(totalExpected)
+	balRlt.Expected, err = newBalance(totalExpected)
 	if err != nil {
 		return nil, err
 	}
@@ -11,9 +11,9 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"net/url"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"os"
 
@@ -21,9 +21,9 @@ import (
 	"github.com/spf13/pflag"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/api"
 	"github.com/skycoin/skycoin/src/util/file"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 var (
@@ -41,11 +41,30 @@ const (
 var (
 	envVarsHelp = fmt.Sprintf(`ENVIRONMENT VARIABLES:
     RPC_ADDR: Address of RPC node. Must be in scheme://host format. Default "%s"
+              A comma-separated list of addresses fails over between the nodes.
+    RPC_MAX_BLOCK_AGE: With multiple RPC_ADDR nodes, skip nodes whose last block is older than this duration, e.g. "1h".
     RPC_USER: Username for RPC API, if enabled in the RPC.
     RPC_PASS: Password for RPC API, if enabled in the RPC.
+    RPC_API_KEY: API key of the node's wallet, storage and admin endpoints, if the node requires one.
+    WALLET_TOKEN: Access token of the node's wallet the command operates on, if the wallet has one.
+    WALLET_PASSWORD: Wallet password, if no --password, --password-stdin or --password-file option is given.
     COIN: Name of the coin. Default "%s"
     DATA_DIR: Directory where everything is stored. Default "%s"`, defaultRPCAddress, defaultCoin, defaultDataDir)
 
+	exitCodesHelp = fmt.Sprintf(`EXIT CODES:
+    %d: success
+    %d: any error without a specific exit code
+    %d: invalid command, arguments or flags
+    %d: the node can't be reached or failed to handle the request
+    %d: the wallet doesn't exist
+    %d: the wallet password is wrong
+    %d: the balance is not sufficient
+    %d: the node rejected the transaction
+    Failed commands with a --json flag write a JSON error object to stderr when the flag is set:
+    {"error": {"code": <exit code>, "kind": <failure class>, "message": <error message>}}`,
+		ExitCodeOK, ExitCodeError, ExitCodeUsage, ExitCodeNode, ExitCodeWalletNotFound,
+		ExitCodeWrongPassword, ExitCodeInsufficientBalance, ExitCodeTransactionRejected)
+
 	helpTemplate = fmt.Sprintf(`USAGE:{{if .Runnable}}
   {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
   {{.CommandPath}} [command] [flags] [arguments...]{{end}}{{with (or .Long .Short)}}
@@ -71,7 +90,9 @@ Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
 Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
 
 %s
-`, envVarsHelp)
+
+%s
+`, envVarsHelp, exitCodesHelp)
 
 	// ErrWalletName is returned if the wallet file name is invalid
 	ErrWalletName = fmt.Errorf("error wallet file name, must have %s extension", walletExt)
@@ -89,11 +110,19 @@ var (
 
 // Config cli's configuration struct
 type Config struct {
-	DataDir     string `json:"data_directory"`
-	Coin        string `json:"coin"`
+	DataDir string `json:"data_directory"`
+	Coin    string `json:"coin"`
+	// RPCAddress is the address of the node, or a comma-separated list of node addresses
 	RPCAddress  string `json:"rpc_address"`
 	RPCUsername string `json:"-"`
 	RPCPassword string `json:"-"`
+	// RPCAPIKey is the API key sent to the node's wallet, storage and admin API endpoints
+	RPCAPIKey string `json:"-"`
+	// RPCMaxBlockAge is the age of the last block above which a node is considered syncing,
+	// when failing over between multiple nodes. 0 disables the check.
+	RPCMaxBlockAge time.Duration `json:"-"`
+	// WalletToken is the access token sent to the node's wallet API endpoints
+	WalletToken string `json:"-"`
 }
 
 // LoadConfig loads config from environment, prior to parsing CLI flags
@@ -110,12 +139,23 @@ func LoadConfig() (Config, error) {
 		rpcAddr = defaultRPCAddress
 	}
 
-	if _, err := url.Parse(rpcAddr); err != nil {
-		return Config{}, errors.New("RPC_ADDR must be in scheme://host format")
+	if _, err := parseRPCAddresses(rpcAddr); err != nil {
+		return Config{}, err
+	}
+
+	var maxBlockAge time.Duration
+	if v := os.Getenv("RPC_MAX_BLOCK_AGE"); v != "" {
+		var err error
+		maxBlockAge, err = time.ParseDuration(v)
+		if err != nil || maxBlockAge < 0 {
+			return Config{}, errors.New("RPC_MAX_BLOCK_AGE must be a positive duration")
+		}
 	}
 
 	rpcUser := os.Getenv("RPC_USER")
 	rpcPass := os.Getenv("RPC_PASS")
+	rpcAPIKey := os.Getenv("RPC_API_KEY")
+	walletToken := os.Getenv("WALLET_TOKEN")
 
 	home := file.UserHome()
 
@@ -133,11 +173,14 @@ func LoadConfig() (Config, error) {
 	}
 
 	return Config{
-		DataDir:     dataDir,
-		Coin:        coin,
-		RPCAddress:  rpcAddr,
-		RPCUsername: rpcUser,
-		RPCPassword: rpcPass,
+		DataDir:        dataDir,
+		Coin:           coin,
+		RPCAddress:     rpcAddr,
+		RPCUsername:    rpcUser,
+		RPCPassword:    rpcPass,
+		RPCAPIKey:      rpcAPIKey,
+		RPCMaxBlockAge: maxBlockAge,
+		WalletToken:    walletToken,
 	}, nil
 }
 
@@ -165,60 +208,92 @@ func resolveDBPath(cfg Config, db string) (string, error) {
 
 // NewCLI creates a cli instance
 func NewCLI(cfg Config) (*cobra.Command, error) {
-	apiClient = api.NewClient(cfg.RPCAddress)
-	apiClient.SetAuth(cfg.RPCUsername, cfg.RPCPassword)
-
-	cliConfig = cfg
+	if err := setAPIClient(cfg); err != nil {
+		return nil, err
+	}
 
 	skyCLI := &cobra.Command{
 		Short: fmt.Sprintf("The %s command line interface", cfg.Coin),
 		Use:   fmt.Sprintf("%s-cli", cfg.Coin),
+		// The errors are written by Execute
+		SilenceErrors: true,
+		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			node, err := c.Flags().GetString("node")
+			if err != nil {
+				return err
+			}
+			if node == "" {
+				return nil
+			}
+
+			cfg := cliConfig
+			cfg.RPCAddress = node
+			return setAPIClient(cfg)
+		},
 	}
 
+	skyCLI.PersistentFlags().String("node", "", "Address of RPC node, or a comma-separated list of node addresses to fail over between. Overrides RPC_ADDR")
+
 	commands := []*cobra.Command{
-		addPrivateKeyCmd(),
+		withWalletLabel(addPrivateKeyCmd(), walletFileFromLabel),
 		addressBalanceCmd(),
 		addressGenCmd(),
+		addressConvertCmd(),
 		fiberAddressGenCmd(),
 		addressOutputsCmd(),
 		blocksCmd(),
 		broadcastTxCmd(),
 		checkDBCmd(),
 		checkDBEncodingCmd(),
-		createRawTxnCmd(),
-		createRawTxnV2Cmd(),
-		signTxnCmd(),
+		createSnapshotCmd(),
+		verifySnapshotCmd(),
+		withWalletLabel(createRawTxnCmd(), walletFileFromLabel),
+		withWalletLabel(createRawTxnV2Cmd(), walletFileFromLabel),
+		withWalletLabel(signTxnCmd(), walletFileFromLabel),
 		decodeRawTxnCmd(),
 		encodeJSONTxnCmd(),
-		decryptWalletCmd(),
-		encryptWalletCmd(),
+		exportTransactionQRCmd(),
+		importTransactionQRCmd(),
+		withWalletLabel(decryptWalletCmd(), walletFileFromLabel),
+		withWalletLabel(encryptWalletCmd(), walletFileFromLabel),
 		lastBlocksCmd(),
-		listAddressesCmd(),
+		withWalletLabel(listAddressesCmd(), walletFileFromLabel),
 		listWalletsCmd(),
-		sendCmd(),
+		withWalletLabel(sendCmd(), walletFileFromLabel),
 		showConfigCmd(),
-		showSeedCmd(),
+		withWalletLabel(showSeedCmd(), walletFileFromLabel),
 		statusCmd(),
 		transactionCmd(),
 		verifyTransactionCmd(),
 		verifyAddressCmd(),
 		versionCmd(),
 		walletCreateCmd(),
-		walletAddAddressesCmd(),
-		walletKeyExportCmd(),
-		walletBalanceCmd(),
-		walletHisCmd(),
-		walletOutputsCmd(),
+		withWalletLabel(walletAddAddressesCmd(), walletFileFromLabel),
+		withWalletLabel(walletImportAddressesCmd(), walletFileFromLabel),
+		withWalletLabel(walletRestoreBackupCmd(), walletFileFromLabel),
+		walletBackupVerifyCmd(),
+		withWalletLabel(walletMigrateCmd(), walletIDFromLabel),
+		withWalletLabel(walletKeyExportCmd(), walletFileFromLabel),
+		withWalletLabel(walletEncryptAddressCmd(), walletFileFromLabel),
+		withWalletLabel(walletDecryptAddressCmd(), walletFileFromLabel),
+		withWalletLabel(walletBalanceCmd(), walletFileFromLabel),
+		withWalletLabel(walletHisCmd(), walletFileFromLabel),
+		withWalletLabel(walletOutputsCmd(), walletFileFromLabel),
 		richlistCmd(),
 		addressTransactionsCmd(),
 		pendingTransactionsCmd(),
 		addresscountCmd(),
 		distributeGenesisCmd(),
+		doctorCmd(),
+		peersExportCmd(),
+		peersImportCmd(),
+		watchTransactionCmd(),
 	}
 
 	skyCLI.Version = Version
 	skyCLI.SuggestionsMinimumDistance = 1
 	skyCLI.AddCommand(commands...)
+	wrapUsageErrors(skyCLI)
 
 	skyCLI.SetHelpTemplate(helpTemplate)
 	skyCLI.SetUsageTemplate(helpTemplate)
@@ -227,6 +302,25 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 	return skyCLI, nil
 }
 
+// setAPIClient sets the api client and the config used by the commands
+func setAPIClient(cfg Config) error {
+	c, err := newAPIClient(cfg.RPCAddress, cfg.RPCMaxBlockAge)
+	if err != nil {
+		return err
+	}
+	c.SetAuth(cfg.RPCUsername, cfg.RPCPassword)
+	if cfg.WalletToken != "" {
+		c.HTTPClient.Transport = newWalletTokenTransport(cfg.WalletToken, c.HTTPClient.Transport)
+	}
+	if cfg.RPCAPIKey != "" {
+		c.HTTPClient.Transport = newAPIKeyTransport(cfg.RPCAPIKey, c.HTTPClient.Transport)
+	}
+
+	apiClient = c
+	cliConfig = cfg
+	return nil
+}
+
 func printHelp(c *cobra.Command) {
 	c.Printf("See '%s %s --help'\n", c.Parent().Name(), c.Name())
 }
@@ -252,6 +346,10 @@ func printJSON(obj interface{}) error {
 
 // readPasswordFromTerminal promotes user to enter password and read it.
 func readPasswordFromTerminal() ([]byte, error) {
+	if !terminal.IsTerminal(int(syscall.Stdin)) { //nolint:unconvert
+		return nil, fmt.Errorf("stdin is not a terminal, can't prompt for a password. Use --password-stdin, --password-file or %s", WalletPasswordEnvVar)
+	}
+
 	// Promotes to enter the wallet password
 	fmt.Fprint(os.Stdout, "enter password:")
 	bp, err := terminal.ReadPassword(int(syscall.Stdin)) //nolint:unconvert
@@ -310,11 +408,17 @@ func (p PasswordFromTerm) Password() ([]byte, error) {
 }
 
 // NewPasswordReader creats a PasswordReader instance,
-// reads password from the input bytes first, if it's empty, then read from terminal.
+// reads password from the input bytes first, if it's empty, then from the WALLET_PASSWORD
+// environment variable, if that's not set, then read from terminal.
+// Use NewPasswordReaderFromOptions to also read from stdin or a file.
 func NewPasswordReader(p []byte) PasswordReader {
 	if len(p) != 0 {
 		return PasswordFromBytes(p)
 	}
 
+	if v := os.Getenv(WalletPasswordEnvVar); v != "" {
+		return PasswordFromEnv(v)
+	}
+
 	return PasswordFromTerm{}
 }
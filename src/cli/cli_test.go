@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -60,6 +61,42 @@ func TestLoadConfig(t *testing.T) {
 		testutil.RequireError(t, err, "RPC_ADDR must be in scheme://host format")
 	})
 
+	t.Run("set RPC_ADDR list", func(t *testing.T) {
+		val := "http://111.22.33.44:5555,https://node.example.com"
+		os.Setenv("RPC_ADDR", val)
+		defer os.Unsetenv("RPC_ADDR")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		require.Equal(t, cfg.RPCAddress, val)
+	})
+
+	t.Run("set RPC_ADDR list invalid", func(t *testing.T) {
+		val := "http://111.22.33.44:5555,111.22.33.44:5556"
+		os.Setenv("RPC_ADDR", val)
+		defer os.Unsetenv("RPC_ADDR")
+
+		_, err := LoadConfig()
+		testutil.RequireError(t, err, "RPC_ADDR must be in scheme://host format")
+	})
+
+	t.Run("set RPC_MAX_BLOCK_AGE", func(t *testing.T) {
+		os.Setenv("RPC_MAX_BLOCK_AGE", "90m")
+		defer os.Unsetenv("RPC_MAX_BLOCK_AGE")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		require.Equal(t, 90*time.Minute, cfg.RPCMaxBlockAge)
+	})
+
+	t.Run("set RPC_MAX_BLOCK_AGE invalid", func(t *testing.T) {
+		os.Setenv("RPC_MAX_BLOCK_AGE", "90")
+		defer os.Unsetenv("RPC_MAX_BLOCK_AGE")
+
+		_, err := LoadConfig()
+		testutil.RequireError(t, err, "RPC_MAX_BLOCK_AGE must be a positive duration")
+	})
+
 	t.Run("set DATA_DIR", func(t *testing.T) {
 		val := "/home/foo/"
 		os.Setenv("DATA_DIR", val)
@@ -6,10 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	pcoin "github.com/ness-network/privateness/src/coin"
+	ptransaction "github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/util/fee"
+	pvisor "github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/api"
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
@@ -17,10 +23,8 @@ import (
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/util/droplet"
-	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/mathutil"
 	"github.com/skycoin/skycoin/src/visor"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 var (
@@ -61,7 +65,7 @@ func createRawTxnCmd() *cobra.Command {
 				return err
 			}
 
-			txn, err := createRawTxnCmdHandler(c, args)
+			txn, err := createRawTxnCmdHandler(c, args, nil)
 			switch err.(type) {
 			case nil:
 			case WalletLoadError:
@@ -91,11 +95,13 @@ func createRawTxnCmd() *cobra.Command {
 	}
 
 	createRawTxnCmd.Flags().StringP("from-address", "a", "", "From address in wallet")
+	createRawTxnCmd.Flags().StringSlice("from-label", nil, "Spend from the wallet addresses with any of these labels, combined with --from-address")
 	createRawTxnCmd.Flags().StringP("change-address", "c", "", `Specify the change address.
-Defaults to one of the spending addresses (deterministic wallets) or to a new change address (bip44 wallets).`)
+Defaults to one of the spending addresses (deterministic wallets and bip44 wallets that reuse change addresses)
+or to a new change chain address (bip44 wallets).`)
 	createRawTxnCmd.Flags().StringP("many", "m", "", `use JSON string to set multiple receive addresses and coins,
 example: -m '[{"addr":"$addr1", "coins": "10.2"}, {"addr":"$addr2", "coins": "20"}]'`)
-	createRawTxnCmd.Flags().StringP("password", "p", "", "Wallet password")
+	addPasswordFlags(createRawTxnCmd, "Wallet password")
 	createRawTxnCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 	createRawTxnCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
 
@@ -112,6 +118,11 @@ func createRawTxnV2Cmd() *cobra.Command {
 
     The [to address] and [amount] arguments can be replaced with the --csv option.,
 
+    The node warns when the transaction sends coins to previously used addresses:
+    its change address, or destination addresses of the wallet. The warnings are
+    printed to stderr. If the wallet has strict privacy set, the node refuses to
+    create the transaction instead.
+
     Use caution when using the "-p" command. If you have command history enabled
     your wallet encryption password can be recovered from the history log. If you
     do not include the "-p" option you will be prompted to enter your password
@@ -129,8 +140,8 @@ func createRawTxnV2Cmd() *cobra.Command {
 				return err
 			}
 
-			rsp, err := apiClient.WalletCreateTransaction(*req)
-			if err != nil {
+			var rsp walletCreateTransactionResponse
+			if err := apiClient.PostJSON("/api/v1/wallet/transaction", req, &rsp); err != nil {
 				return err
 			}
 
@@ -138,6 +149,11 @@ func createRawTxnV2Cmd() *cobra.Command {
 				return printJSON(rsp)
 			}
 
+			// The warnings go to stderr, leaving the transaction alone on stdout
+			for _, w := range rsp.Warnings {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+			}
+
 			fmt.Println(rsp.EncodedTransaction)
 
 			return nil
@@ -145,10 +161,12 @@ func createRawTxnV2Cmd() *cobra.Command {
 	}
 
 	createRawTxnCmd.Flags().StringP("from-address", "a", "", "From address in wallet")
+	createRawTxnCmd.Flags().StringSlice("from-label", nil, "Spend from the wallet addresses with any of these labels, combined with --from-address")
 	createRawTxnCmd.Flags().StringP("change-address", "c", "", `Specify the change address.
-	Defaults to one of the spending addresses (deterministic wallets) or to a new change address (bip44 wallets).`)
+	Defaults to one of the spending addresses (deterministic wallets and bip44 wallets that reuse change addresses)
+or to a new change chain address (bip44 wallets).`)
 	createRawTxnCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
-	createRawTxnCmd.Flags().StringP("password", "p", "", "Wallet password")
+	addPasswordFlags(createRawTxnCmd, "Wallet password")
 	createRawTxnCmd.Flags().BoolP("unsign", "", false, "Do not sign the transaction")
 	createRawTxnCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 
@@ -160,6 +178,13 @@ func createRawTxnV2Cmd() *cobra.Command {
 	return createRawTxnCmd
 }
 
+// walletCreateTransactionResponse is returned by POST /api/v1/wallet/transaction.
+// Warnings describe the previously used addresses the transaction sends coins to.
+type walletCreateTransactionResponse struct {
+	api.CreateTransactionResponse
+	Warnings []string `json:"warnings,omitempty"`
+}
+
 func makeWalletCreateTxnRequest(c *cobra.Command, args []string) (*api.WalletCreateTransactionRequest, error) {
 	unsign, err := c.Flags().GetBool("unsign")
 	if err != nil {
@@ -180,7 +205,19 @@ func makeWalletCreateTxnRequest(c *cobra.Command, args []string) (*api.WalletCre
 	var addrs []string
 	if wltAddr.Address != "" {
 		addrs = append(addrs, wltAddr.Address)
-	} else {
+	}
+
+	if len(wltAddr.Labels) != 0 {
+		labelAddrs, err := wallet.AddressesWithLabels(w, wltAddr.Labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range labelAddrs {
+			if addr.String() != wltAddr.Address {
+				addrs = append(addrs, addr.String())
+			}
+		}
+	} else if wltAddr.Address == "" {
 		for _, addr := range w.GetAddresses() {
 			addrs = append(addrs, addr.String())
 		}
@@ -211,15 +248,12 @@ func makeWalletCreateTxnRequest(c *cobra.Command, args []string) (*api.WalletCre
 }
 
 func getPassword(c *cobra.Command) ([]byte, error) {
-	p, err := c.Flags().GetString("password")
+	pr, err := passwordReaderFromFlags(c)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		p = ""
-	}()
 
-	return NewPasswordReader([]byte(p)).Password()
+	return pr.Password()
 }
 
 func makeCreateTransactionRequest(c *cobra.Command, args []string, fromAddrs []string) (*api.CreateTransactionRequest, error) {
@@ -315,6 +349,7 @@ func getHoursSelection(c *cobra.Command) (*api.HoursSelection, error) {
 type walletAddress struct {
 	Wallet  string
 	Address string
+	Labels  []string
 }
 
 func fromWalletOrAddress(c *cobra.Command, walletFile string) (walletAddress, error) {
@@ -323,8 +358,23 @@ func fromWalletOrAddress(c *cobra.Command, walletFile string) (walletAddress, er
 		return walletAddress{}, err
 	}
 
+	var labels []string
+	if c.Flags().Lookup("from-label") != nil {
+		labels, err = c.Flags().GetStringSlice("from-label")
+		if err != nil {
+			return walletAddress{}, err
+		}
+	}
+
+	for _, l := range labels {
+		if l == "" {
+			return walletAddress{}, errors.New("--from-label must not be empty")
+		}
+	}
+
 	wltAddr := walletAddress{
 		Wallet: walletFile,
+		Labels: labels,
 	}
 
 	wltAddr.Address = address
@@ -352,6 +402,11 @@ func getChangeAddress(wltAddr walletAddress, chgAddr string) (string, error) {
 				return "", WalletLoadError{err}
 			}
 
+			if usesChangeChain(wlt) {
+				// the next change chain address is generated when the transaction is created
+				return "", nil
+			}
+
 			if wlt.EntriesLen() > 0 {
 				chgAddr = wlt.GetEntryAt(0).Address.String()
 			} else {
@@ -430,12 +485,14 @@ func openCSV(csvFile string) ([][]string, error) {
 func parseSendAmountsFromCSV(fields [][]string) ([]SendAmount, error) {
 	var sends []SendAmount
 	var errs []error
+	var outs []pcoin.TransactionOutput
 	for i, f := range fields {
 		addr := f[0]
 
 		addr = strings.TrimSpace(addr)
 
-		if _, err := cipher.DecodeBase58Address(addr); err != nil {
+		a, err := cipher.DecodeBase58Address(addr)
+		if err != nil {
 			err = fmt.Errorf("[row %d] Invalid address %s: %v", i, addr, err)
 			errs = append(errs, err)
 			continue
@@ -448,6 +505,11 @@ func parseSendAmountsFromCSV(fields [][]string) ([]SendAmount, error) {
 			continue
 		}
 
+		outs = append(outs, pcoin.TransactionOutput{
+			Address: a,
+			Coins:   coins,
+		})
+
 		sends = append(sends, SendAmount{
 			Addr:  addr,
 			Coins: coins,
@@ -465,30 +527,41 @@ func parseSendAmountsFromCSV(fields [][]string) ([]SendAmount, error) {
 		return nil, errors.New(errMsg)
 	}
 
+	if err := validateCSVOutputs(outs); err != nil {
+		return nil, err
+	}
+
 	return sends, nil
 }
 
 func parseReceiversFromCSV(fields [][]string) ([]api.Receiver, error) {
 	var sends []api.Receiver
 	var errs []error
+	var outs []pcoin.TransactionOutput
 	for i, f := range fields {
 		addr := f[0]
 
 		addr = strings.TrimSpace(addr)
 
-		if _, err := cipher.DecodeBase58Address(addr); err != nil {
+		a, err := cipher.DecodeBase58Address(addr)
+		if err != nil {
 			err = fmt.Errorf("[row %d] Invalid address %s: %v", i, addr, err)
 			errs = append(errs, err)
 			continue
 		}
 
-		_, err := droplet.FromString(f[1])
+		coins, err := droplet.FromString(f[1])
 		if err != nil {
 			err = fmt.Errorf("[row %d] Invalid amount %s: %v", i, f[1], err)
 			errs = append(errs, err)
 			continue
 		}
 
+		outs = append(outs, pcoin.TransactionOutput{
+			Address: a,
+			Coins:   coins,
+		})
+
 		sends = append(sends, api.Receiver{
 			Address: addr,
 			Coins:   f[1],
@@ -506,9 +579,24 @@ func parseReceiversFromCSV(fields [][]string) ([]api.Receiver, error) {
 		return nil, errors.New(errMsg)
 	}
 
+	if err := validateCSVOutputs(outs); err != nil {
+		return nil, err
+	}
+
 	return sends, nil
 }
 
+// validateCSVOutputs checks the outputs of the rows of a CSV file like the node checks the destinations of a transaction
+func validateCSVOutputs(outs []pcoin.TransactionOutput) error {
+	if err := pcoin.ValidateOutputs(outs, params.UserVerifyTxn.MaxDropletPrecision); err != nil {
+		if e, ok := err.(pcoin.OutputError); ok {
+			return fmt.Errorf("[row %d] %v", e.Index, e.Err)
+		}
+		return err
+	}
+	return nil
+}
+
 func parseSendAmountsFromJSON(m string) ([]SendAmount, error) {
 	sas := []sendAmountJSON{}
 
@@ -547,6 +635,7 @@ func getAmount(args []string) (uint64, error) {
 type createRawTxnArgs struct {
 	WalletID      string
 	Address       string
+	Labels        []string
 	ChangeAddress string
 	SendAmounts   []SendAmount
 	Password      PasswordReader
@@ -575,22 +664,24 @@ func parseCreateRawTxnArgs(c *cobra.Command, args []string) (*createRawTxnArgs,
 		return nil, err
 	}
 
-	password, err := c.Flags().GetString("password")
+	pr, err := passwordReaderFromFlags(c)
 	if err != nil {
 		return nil, err
 	}
-	pr := NewPasswordReader([]byte(password))
 
 	return &createRawTxnArgs{
 		WalletID:      wltAddr.Wallet,
 		Address:       wltAddr.Address,
+		Labels:        wltAddr.Labels,
 		ChangeAddress: chgAddr,
 		SendAmounts:   toAddrs,
 		Password:      pr,
 	}, nil
 }
 
-func createRawTxnCmdHandler(c *cobra.Command, args []string) (*coin.Transaction, error) {
+// createRawTxnCmdHandler creates a transaction from the command's arguments.
+// If review is not nil, it is called with the review of the transaction before the transaction is signed.
+func createRawTxnCmdHandler(c *cobra.Command, args []string, review TxnReviewer) (*coin.Transaction, error) {
 	parsedArgs, err := parseCreateRawTxnArgs(c, args)
 	if err != nil {
 		return nil, err
@@ -600,15 +691,21 @@ func createRawTxnCmdHandler(c *cobra.Command, args []string) (*coin.Transaction,
 	// There's too many distribution parameters to put them in command line, but we could read them from a file.
 	// We could also have multiple hardcoded known distribution parameters for fiber coins, in the source,
 	// but this wouldn't work for new fiber coins that hadn't been hardcoded yet.
+	if len(parsedArgs.Labels) != 0 {
+		return CreateRawTxnFromLabels(apiClient, parsedArgs.Labels, parsedArgs.Address,
+			parsedArgs.WalletID, parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
+			parsedArgs.Password, params.MainNetDistribution, review)
+	}
+
 	if parsedArgs.Address == "" {
 		return CreateRawTxnFromWallet(apiClient, parsedArgs.WalletID,
 			parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
-			parsedArgs.Password, params.MainNetDistribution)
+			parsedArgs.Password, params.MainNetDistribution, review)
 	}
 
 	return CreateRawTxnFromAddress(apiClient, parsedArgs.Address,
 		parsedArgs.WalletID, parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
-		parsedArgs.Password, params.MainNetDistribution)
+		parsedArgs.Password, params.MainNetDistribution, review)
 }
 
 func validateSendAmounts(toAddrs []SendAmount) error {
@@ -634,47 +731,22 @@ func validateSendAmounts(toAddrs []SendAmount) error {
 // PUBLIC
 
 // CreateRawTxnFromWallet creates a transaction from any address or combination of addresses in a wallet
-func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
-	// check change address
-	cAddr, err := cipher.DecodeBase58Address(chgAddr)
-	if err != nil {
-		return nil, ErrAddress
-	}
-
-	// check if the change address is in wallet.
+// If chgAddr is empty, the change is sent to the next change chain address of a bip44 wallet, which is saved to the wallet.
+// If review is not nil, it reviews the transaction before it is signed.
+func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution, review TxnReviewer) (*coin.Transaction, error) {
 	wlt, err := wallet.Load(walletFile)
 	if err != nil {
 		return nil, err
 	}
 
-	_, ok := wlt.GetEntry(cAddr)
-	if !ok {
-		return nil, fmt.Errorf("change address %v is not in wallet", chgAddr)
-	}
-
-	switch pr.(type) {
-	case nil:
-		if wlt.IsEncrypted() {
-			return nil, wallet.ErrWalletEncrypted
-		}
-	case PasswordFromBytes:
-		p, err := pr.Password()
-		if err != nil {
-			return nil, err
-		}
-
-		if !wlt.IsEncrypted() && len(p) != 0 {
-			return nil, wallet.ErrWalletNotEncrypted
-		}
+	// check if the change address is in wallet.
+	if err := checkChangeAddress(wlt, chgAddr); err != nil {
+		return nil, err
 	}
 
-	var password []byte
-	if wlt.IsEncrypted() {
-		var err error
-		password, err = pr.Password()
-		if err != nil {
-			return nil, err
-		}
+	password, err := readWalletPassword(wlt, pr)
+	if err != nil {
+		return nil, err
 	}
 
 	// get all address in the wallet
@@ -684,11 +756,13 @@ func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs
 		addrStrArray[i] = a.String()
 	}
 
-	return CreateRawTxn(c, wlt, addrStrArray, chgAddr, toAddrs, password, distParams)
+	return createRawTxnWithChange(c, wlt, walletFile, addrStrArray, chgAddr, toAddrs, password, distParams, review)
 }
 
-// CreateRawTxnFromAddress creates a transaction from a specific address in a wallet
-func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
+// CreateRawTxnFromAddress creates a transaction from a specific address in a wallet.
+// If chgAddr is empty, the change is sent to the next change chain address of a bip44 wallet, which is saved to the wallet.
+// If review is not nil, it reviews the transaction before it is signed.
+func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution, review TxnReviewer) (*coin.Transaction, error) {
 	// check if the address is in the default wallet.
 	wlt, err := wallet.Load(walletFile)
 	if err != nil {
@@ -706,16 +780,145 @@ func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, t
 	}
 
 	// validate change address
+	if err := checkChangeAddress(wlt, chgAddr); err != nil {
+		return nil, err
+	}
+
+	password, err := readWalletPassword(wlt, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return createRawTxnWithChange(c, wlt, walletFile, []string{addr}, chgAddr, toAddrs, password, distParams, review)
+}
+
+// CreateRawTxnFromLabels creates a transaction from the wallet addresses whose label matches any of labels.
+// If addr is not empty, it is spent from as well. If review is not nil, it reviews the transaction before it is signed.
+func CreateRawTxnFromLabels(c GetOutputser, labels []string, addr, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution, review TxnReviewer) (*coin.Transaction, error) {
+	wlt, err := wallet.Load(walletFile)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := wallet.AddressesWithLabels(wlt, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if addr != "" {
+		srcAddr, err := cipher.DecodeBase58Address(addr)
+		if err != nil {
+			return nil, ErrAddress
+		}
+
+		if _, ok := wlt.GetEntry(srcAddr); !ok {
+			return nil, fmt.Errorf("%v address is not in wallet", addr)
+		}
+
+		found := false
+		for _, a := range addrs {
+			if a == srcAddr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addrs = append([]cipher.Address{srcAddr}, addrs...)
+		}
+	}
+
+	// validate change address
+	if err := checkChangeAddress(wlt, chgAddr); err != nil {
+		return nil, err
+	}
+
+	password, err := readWalletPassword(wlt, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	addrStrArray := make([]string, len(addrs))
+	for i, a := range addrs {
+		addrStrArray[i] = a.String()
+	}
+
+	return createRawTxnWithChange(c, wlt, walletFile, addrStrArray, chgAddr, toAddrs, password, distParams, review)
+}
+
+// usesChangeChain returns true if change is sent to a new change chain address of the wallet by default
+func usesChangeChain(wlt wallet.Wallet) bool {
+	return wlt.Type() == wallet.WalletTypeBip44 && !wlt.ReuseChange()
+}
+
+// checkChangeAddress checks that the change address is in the wallet.
+// The change address may be empty for wallets that send change to a new change chain address.
+func checkChangeAddress(wlt wallet.Wallet, chgAddr string) error {
+	if chgAddr == "" && usesChangeChain(wlt) {
+		return nil
+	}
+
 	cAddr, err := cipher.DecodeBase58Address(chgAddr)
 	if err != nil {
-		return nil, ErrAddress
+		return ErrAddress
+	}
+
+	if _, ok := wlt.GetEntry(cAddr); !ok {
+		return fmt.Errorf("change address %v is not in wallet", chgAddr)
+	}
+
+	return nil
+}
+
+// createRawTxnWithChange creates a transaction with CreateRawTxn. If chgAddr is empty, the next change chain
+// address of the bip44 wallet is generated for the change, and the wallet is saved once the transaction is created.
+func createRawTxnWithChange(c GetOutputser, wlt wallet.Wallet, walletFile string, inAddrs []string, chgAddr string, toAddrs []SendAmount, password []byte, distParams params.Distribution, review TxnReviewer) (*coin.Transaction, error) {
+	if chgAddr != "" {
+		return CreateRawTxn(c, wlt, inAddrs, chgAddr, toAddrs, password, distParams, review)
 	}
 
-	_, ok = wlt.GetEntry(cAddr)
+	bw, ok := wlt.(*wallet.Bip44Wallet)
 	if !ok {
-		return nil, fmt.Errorf("change address %v is not in wallet", chgAddr)
+		return nil, ErrAddress
 	}
 
+	generateChangeEntry := func(w wallet.Wallet) error {
+		e, err := w.(*wallet.Bip44Wallet).GenerateChangeEntry()
+		if err != nil {
+			return err
+		}
+		chgAddr = e.Address.String()
+		return nil
+	}
+
+	var err error
+	if bw.IsEncrypted() {
+		err = wallet.GuardUpdate(bw, password, generateChangeEntry)
+	} else {
+		err = generateChangeEntry(bw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := CreateRawTxn(c, wlt, inAddrs, chgAddr, toAddrs, password, distParams, review)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(walletFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wallet.Save(wlt, dir); err != nil {
+		return nil, WalletSaveError{err}
+	}
+
+	return txn, nil
+}
+
+// readWalletPassword checks pr against the wallet's encryption state and returns the password, if the wallet is encrypted
+func readWalletPassword(wlt wallet.Wallet, pr PasswordReader) ([]byte, error) {
 	switch pr.(type) {
 	case nil:
 		if wlt.IsEncrypted() {
@@ -732,16 +935,11 @@ func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, t
 		}
 	}
 
-	var password []byte
-	if wlt.IsEncrypted() {
-		var err error
-		password, err = pr.Password()
-		if err != nil {
-			return nil, err
-		}
+	if !wlt.IsEncrypted() {
+		return nil, nil
 	}
 
-	return CreateRawTxn(c, wlt, []string{addr}, chgAddr, toAddrs, password, distParams)
+	return pr.Password()
 }
 
 // GetOutputser implements unspent output querying
@@ -749,24 +947,37 @@ type GetOutputser interface {
 	OutputsForAddresses([]string) (*readable.UnspentOutputsSummary, error)
 }
 
-// CreateRawTxn creates a transaction from a set of addresses contained in a loaded wallet.Wallet
-func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr string, toAddrs []SendAmount, password []byte, distParams params.Distribution) (*coin.Transaction, error) {
+// CreateRawTxn creates a transaction from a set of addresses contained in a loaded wallet.Wallet.
+// If review is not nil, it is called with the review of the unsigned transaction,
+// and the transaction is only signed if it returns nil.
+func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr string, toAddrs []SendAmount, password []byte, distParams params.Distribution, review TxnReviewer) (*coin.Transaction, error) {
 	if err := validateSendAmounts(toAddrs); err != nil {
 		return nil, err
 	}
 
+	// The node's constraints, instead of the constants of this build, in case the node is of a fiber chain
+	verifyTxn, err := userVerifyTxn(c)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get unspent outputs of those addresses
 	outputs, err := c.OutputsForAddresses(inAddrs)
 	if err != nil {
 		return nil, err
 	}
 
+	burnPolicy, err := userBurnPolicy(c, verifyTxn)
+	if err != nil {
+		return nil, err
+	}
+
 	inUxs, err := outputs.SpendableOutputs().ToUxArray()
 	if err != nil {
 		return nil, err
 	}
 
-	txn, err := createRawTxn(outputs, wlt, chgAddr, toAddrs, password)
+	txn, err := createRawTxn(outputs, wlt, chgAddr, toAddrs, password, burnPolicy, review)
 	if err != nil {
 		return nil, err
 	}
@@ -786,7 +997,7 @@ func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr s
 		return nil, err
 	}
 
-	if err := visor.VerifySingleTxnSoftConstraints(*txn, head.Time, inUxsFiltered, distParams, params.UserVerifyTxn); err != nil {
+	if err := pvisor.VerifySingleTxnSoftConstraintsWithPolicy(*txn, head.Time, inUxsFiltered, distParams, verifyTxn, burnPolicy); err != nil {
 		return nil, err
 	}
 	if err := visor.VerifySingleTxnHardConstraints(*txn, head, inUxsFiltered, visor.TxnSigned); err != nil {
@@ -799,7 +1010,7 @@ func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr s
 	return txn, nil
 }
 
-func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt wallet.Wallet, chgAddr string, toAddrs []SendAmount, password []byte) (*coin.Transaction, error) {
+func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt wallet.Wallet, chgAddr string, toAddrs []SendAmount, password []byte, burnPolicy fee.BurnPolicy, review TxnReviewer) (*coin.Transaction, error) {
 	// Calculate total required coins
 	var totalCoins uint64
 	for _, arg := range toAddrs {
@@ -815,18 +1026,39 @@ func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt wallet.Wallet, chg
 		return nil, err
 	}
 
-	txOuts, err := makeChangeOut(spendOutputs, chgAddr, toAddrs)
+	txOuts, err := makeChangeOut(spendOutputs, chgAddr, toAddrs, uxouts.Head.Time, burnPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := newUnsignedTransaction(spendOutputs, txOuts)
 	if err != nil {
 		return nil, err
 	}
 
+	// The review is made from the transaction that is signed, so that what is reviewed is what is sent
+	if review != nil {
+		r, err := newTxnReview(txn, spendOutputs, wlt, chgAddr, toAddrs)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := review(r); err != nil {
+			return nil, err
+		}
+	}
+
 	f := func(w wallet.Wallet) (*coin.Transaction, error) {
 		keys, err := getKeys(w, spendOutputs)
 		if err != nil {
 			return nil, err
 		}
 
-		return NewTransaction(spendOutputs, keys, txOuts)
+		if err := signTransaction(txn, spendOutputs, keys); err != nil {
+			return nil, err
+		}
+
+		return txn, nil
 	}
 
 	makeTxn := func() (*coin.Transaction, error) {
@@ -886,7 +1118,9 @@ func chooseSpends(uxouts *readable.UnspentOutputsSummary, coins uint64) ([]trans
 	return outs, nil
 }
 
-func makeChangeOut(outs []transaction.UxBalance, chgAddr string, toAddrs []SendAmount) ([]coin.TransactionOutput, error) {
+// makeChangeOut distributes the hours of outs to toAddrs and the change address,
+// burning the fee required by burnPolicy for a blockchain head with time headTime
+func makeChangeOut(outs []transaction.UxBalance, chgAddr string, toAddrs []SendAmount, headTime uint64, burnPolicy fee.BurnPolicy) ([]coin.TransactionOutput, error) {
 	var totalInCoins, totalInHours, totalOutCoins uint64
 
 	for _, o := range outs {
@@ -911,9 +1145,9 @@ func makeChangeOut(outs []transaction.UxBalance, chgAddr string, toAddrs []SendA
 
 	haveChange := changeAmount > 0
 	nAddrs := uint64(len(toAddrs))
-	changeHours, addrHours, totalOutHours := transaction.DistributeSpendHours(totalInHours, nAddrs, haveChange)
+	changeHours, addrHours, totalOutHours := ptransaction.DistributeSpendHoursWithPolicy(totalInHours, nAddrs, haveChange, headTime, burnPolicy)
 
-	if err := fee.VerifyTransactionFeeForHours(totalOutHours, totalInHours-totalOutHours, params.UserVerifyTxn.BurnFactor); err != nil {
+	if err := fee.VerifyTransactionFeeForHoursWithPolicy(totalOutHours, totalInHours-totalOutHours, headTime, burnPolicy); err != nil {
 		return nil, err
 	}
 
@@ -971,7 +1205,22 @@ func getKeys(wlt wallet.Wallet, outs []transaction.UxBalance) ([]cipher.SecKey,
 }
 
 // NewTransaction creates a transaction. The transaction should be validated against hard and soft constraints before transmission.
+// keys[i] must be the key of the address owning utxos[i], otherwise pcoin.SigningKeyErrors is returned.
 func NewTransaction(utxos []transaction.UxBalance, keys []cipher.SecKey, outs []coin.TransactionOutput) (*coin.Transaction, error) {
+	txn, err := newUnsignedTransaction(utxos, outs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := signTransaction(txn, utxos, keys); err != nil {
+		return nil, err
+	}
+
+	return txn, nil
+}
+
+// newUnsignedTransaction creates a transaction spending utxos to outs, which is signed by signTransaction
+func newUnsignedTransaction(utxos []transaction.UxBalance, outs []coin.TransactionOutput) (*coin.Transaction, error) {
 	txn := coin.Transaction{}
 	for _, u := range utxos {
 		if err := txn.PushInput(u.Hash); err != nil {
@@ -985,12 +1234,33 @@ func NewTransaction(utxos []transaction.UxBalance, keys []cipher.SecKey, outs []
 		}
 	}
 
-	txn.SignInputs(keys)
+	return &txn, nil
+}
 
-	err := txn.UpdateHeader()
-	if err != nil {
-		return nil, err
+// signTransaction signs the inputs of a transaction created by newUnsignedTransaction from utxos.
+// keys[i] must be the key of the address owning utxos[i], otherwise pcoin.SigningKeyErrors is returned.
+func signTransaction(txn *coin.Transaction, utxos []transaction.UxBalance, keys []cipher.SecKey) error {
+	owners := make([]cipher.Address, len(utxos))
+	for i, u := range utxos {
+		owners[i] = u.Address
+	}
+	if err := pcoin.CheckSigningKeys(keys, owners); err != nil {
+		return err
 	}
 
-	return &txn, nil
+	// Sign with pcoin so that the signature nonces are deterministic
+	out := make([]pcoin.TransactionOutput, len(txn.Out))
+	for i, o := range txn.Out {
+		out[i] = pcoin.TransactionOutput(o)
+	}
+	ptxn := pcoin.Transaction{
+		Sigs: txn.Sigs,
+		In:   txn.In,
+		Out:  out,
+	}
+	ptxn.SignInputs(keys)
+	txn.InnerHash = ptxn.InnerHash
+	txn.Sigs = ptxn.Sigs
+
+	return txn.UpdateHeader()
 }
@@ -2,15 +2,27 @@ package cli
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/transaction"
-	"github.com/skycoin/skycoin/src/util/fee"
+	"github.com/skycoin/skycoin/src/visor"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/util/fee"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func TestMakeChangeOut(t *testing.T) {
@@ -41,7 +53,7 @@ func TestMakeChangeOut(t *testing.T) {
 	_, err := cipher.DecodeBase58Address(chgAddr)
 	require.NoError(t, err)
 
-	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt)
+	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
 	require.NoError(t, err)
 	require.NotEmpty(t, txOuts)
 
@@ -91,7 +103,7 @@ func TestMakeChangeOut(t *testing.T) {
 	_, err = cipher.DecodeBase58Address(chgAddr)
 	require.NoError(t, err)
 
-	txOuts, err = makeChangeOut(uxOuts, chgAddr, spendAmt)
+	txOuts, err = makeChangeOut(uxOuts, chgAddr, spendAmt, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
 	require.NoError(t, err)
 	require.NotEmpty(t, txOuts)
 
@@ -140,7 +152,7 @@ func TestMakeChangeOutMinOneCoinHourSend(t *testing.T) {
 	_, err := cipher.DecodeBase58Address(chgAddr)
 	require.NoError(t, err)
 
-	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt)
+	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
 	require.NoError(t, err)
 	require.NotEmpty(t, txOuts)
 
@@ -187,7 +199,7 @@ func TestMakeChangeOutCoinHourCap(t *testing.T) {
 	_, err := cipher.DecodeBase58Address(chgAddr)
 	require.NoError(t, err)
 
-	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt)
+	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
 	require.NoError(t, err)
 	require.NotEmpty(t, txOuts)
 
@@ -236,7 +248,7 @@ func TestMakeChangeOutOneCoinHour(t *testing.T) {
 	_, err := cipher.DecodeBase58Address(chgAddr)
 	require.NoError(t, err)
 
-	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt)
+	txOuts, err := makeChangeOut(uxOuts, chgAddr, spendAmt, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
 	require.NoError(t, err)
 	require.NotEmpty(t, txOuts)
 
@@ -284,7 +296,7 @@ func TestMakeChangeOutInsufficientCoinHours(t *testing.T) {
 	_, err := cipher.DecodeBase58Address(chgAddr)
 	require.NoError(t, err)
 
-	_, err = makeChangeOut(uxOuts, chgAddr, spendAmt)
+	_, err = makeChangeOut(uxOuts, chgAddr, spendAmt, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
 	testutil.RequireError(t, err, fee.ErrTxnNoFee.Error())
 }
 
@@ -543,8 +555,8 @@ func TestParseSendAmountsFromCSV(t *testing.T) {
 			fields: [][]string{
 				{"2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP", "123"},
 				{"2UDzBKnxZf4d9pdrBJAqbtoeH641RFLYKxd", "123.456"},
-				{"8LbGZ9Z9r7ELNKyrQmAbhLhLvrmLJjfotm", "123.456789"},
-				{"7KU683yzoPE9rVuuFRQMZVhGwBBtwqTKT2", "0"},
+				{"8LbGZ9Z9r7ELNKyrQmAbhLhLvrmLJjfotm", "0.001"},
+				{"2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP", "124"},
 			},
 			amts: []SendAmount{
 				{
@@ -557,15 +569,50 @@ func TestParseSendAmountsFromCSV(t *testing.T) {
 				},
 				{
 					Addr:  "8LbGZ9Z9r7ELNKyrQmAbhLhLvrmLJjfotm",
-					Coins: 123456789,
+					Coins: 1e3,
 				},
 				{
-					Addr:  "7KU683yzoPE9rVuuFRQMZVhGwBBtwqTKT2",
-					Coins: 0,
+					Addr:  "2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP",
+					Coins: 124e6,
 				},
 			},
 		},
 
+		{
+			name: "zero coins",
+			fields: [][]string{
+				{"2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP", "123"},
+				{"7KU683yzoPE9rVuuFRQMZVhGwBBtwqTKT2", "0"},
+			},
+			err: errors.New("[row 1] Zero coin output"),
+		},
+
+		{
+			name: "too many decimal places for a transaction",
+			fields: [][]string{
+				{"8LbGZ9Z9r7ELNKyrQmAbhLhLvrmLJjfotm", "123.456789"},
+			},
+			err: errors.New("[row 0] Output coins have too many decimal places"),
+		},
+
+		{
+			name: "null address",
+			fields: [][]string{
+				{"111111111111111111111691FSP", "1"},
+			},
+			err: errors.New("[row 0] Null address output"),
+		},
+
+		{
+			name: "duplicate row",
+			fields: [][]string{
+				{"2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP", "123"},
+				{"2UDzBKnxZf4d9pdrBJAqbtoeH641RFLYKxd", "123"},
+				{"2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP", "123.000"},
+			},
+			err: errors.New("[row 2] Duplicate output, same as output 0"),
+		},
+
 		{
 			name: "invalid coins value",
 			fields: [][]string{
@@ -598,3 +645,195 @@ func TestParseSendAmountsFromCSV(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTransactionChecksKeys(t *testing.T) {
+	p, s := cipher.GenerateKeyPair()
+	p2, s2 := cipher.GenerateKeyPair()
+
+	utxos := []transaction.UxBalance{
+		{
+			Hash:    testutil.RandSHA256(t),
+			Address: cipher.AddressFromPubKey(p),
+			Coins:   1e6,
+			Hours:   10,
+		},
+		{
+			Hash:    testutil.RandSHA256(t),
+			Address: cipher.AddressFromPubKey(p2),
+			Coins:   1e6,
+			Hours:   10,
+		},
+	}
+	outs := []coin.TransactionOutput{
+		{
+			Address: testutil.MakeAddress(),
+			Coins:   2e6,
+			Hours:   5,
+		},
+	}
+
+	_, err := NewTransaction(utxos, []cipher.SecKey{s2, s}, outs)
+	require.Error(t, err)
+	keyErrs, ok := err.(pcoin.SigningKeyErrors)
+	require.True(t, ok)
+	require.Len(t, keyErrs, 2)
+	require.Equal(t, 0, keyErrs[0].Index)
+	require.Equal(t, 1, keyErrs[1].Index)
+
+	txn, err := NewTransaction(utxos, []cipher.SecKey{s, s2}, outs)
+	require.NoError(t, err)
+	require.True(t, txn.IsFullySigned())
+}
+
+type fakeOutputser struct {
+	outputs *readable.UnspentOutputsSummary
+}
+
+func (f fakeOutputser) OutputsForAddresses(addrs []string) (*readable.UnspentOutputsSummary, error) {
+	return f.outputs, nil
+}
+
+func TestCreateRawTxnFromWalletChange(t *testing.T) {
+	headTime := uint64(time.Now().UTC().Unix())
+
+	cases := []struct {
+		name        string
+		encrypt     bool
+		reuseChange bool
+	}{
+		{
+			name: "change chain",
+		},
+		{
+			name:    "change chain encrypted",
+			encrypt: true,
+		},
+		{
+			name:        "reuse change",
+			reuseChange: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "create-raw-txn")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			opts := wallet.Options{
+				Seed:        bip39.MustNewDefaultMnemonic(),
+				Label:       "label",
+				Type:        wallet.WalletTypeBip44,
+				ReuseChange: tc.reuseChange,
+			}
+			if tc.encrypt {
+				opts.Encrypt = true
+				opts.Password = []byte("pwd")
+				opts.CryptoType = wallet.CryptoTypeSha256Xor
+			}
+
+			w, err := wallet.NewWallet("test.wlt", opts)
+			require.NoError(t, err)
+			addrs, err := w.GetSkycoinAddresses()
+			require.NoError(t, err)
+			require.Len(t, addrs, 1)
+			require.NoError(t, wallet.Save(w, dir))
+
+			ux := coin.UxOut{
+				Head: coin.UxHead{
+					Time:  headTime,
+					BkSeq: 1,
+				},
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        addrs[0],
+					Coins:          10e6,
+					Hours:          100,
+				},
+			}
+			out, err := readable.NewUnspentOutput(visor.UnspentOutput{
+				UxOut:           ux,
+				CalculatedHours: 100,
+			})
+			require.NoError(t, err)
+
+			c := fakeOutputser{
+				outputs: &readable.UnspentOutputsSummary{
+					Head: readable.NewBlockHeader(coin.BlockHeader{
+						Time:  headTime,
+						BkSeq: 1,
+					}),
+					HeadOutputs: readable.UnspentOutputs{out},
+				},
+			}
+
+			walletFile := filepath.Join(dir, "test.wlt")
+			chgAddr, err := getChangeAddress(walletAddress{Wallet: walletFile}, "")
+			require.NoError(t, err)
+			if tc.reuseChange {
+				require.Equal(t, addrs[0].String(), chgAddr)
+			} else {
+				require.Empty(t, chgAddr)
+			}
+
+			var review *TxnReview
+			reviewer := func(r *TxnReview) error {
+				review = r
+				return nil
+			}
+
+			toAddr := testutil.MakeAddress()
+			txn, err := CreateRawTxnFromWallet(c, walletFile, chgAddr, []SendAmount{
+				{
+					Addr:  toAddr.String(),
+					Coins: 1e6,
+				},
+			}, PasswordFromBytes(opts.Password), params.MainNetDistribution, reviewer)
+			require.NoError(t, err)
+			require.Len(t, txn.Out, 2)
+
+			// The review is made from the transaction that was signed
+			require.NotNil(t, review)
+			require.Equal(t, []TxnReviewInput{
+				{
+					Hash:    ux.Hash(),
+					Address: addrs[0],
+					Coins:   10e6,
+					Hours:   100,
+				},
+			}, review.Inputs)
+			require.Equal(t, []TxnReviewOutput{
+				{
+					Address: toAddr,
+					Coins:   1e6,
+					Hours:   txn.Out[0].Hours,
+				},
+				{
+					Address: txn.Out[1].Address,
+					Coins:   9e6,
+					Hours:   txn.Out[1].Hours,
+					Change:  true,
+				},
+			}, review.Outputs)
+			require.Equal(t, uint64(100), review.InputHours)
+			require.Equal(t, 100-txn.Out[0].Hours-txn.Out[1].Hours, review.FeeHours)
+			require.False(t, review.HasUnexpectedOutputs())
+
+			w, err = wallet.Load(walletFile)
+			require.NoError(t, err)
+			bw := w.(*wallet.Bip44Wallet)
+			require.Equal(t, tc.encrypt, bw.IsEncrypted())
+
+			if tc.reuseChange {
+				require.Empty(t, bw.ChangeEntries)
+				require.Equal(t, addrs[0], txn.Out[1].Address)
+				return
+			}
+
+			// The change chain address is saved to the wallet
+			require.Len(t, bw.ChangeEntries, 1)
+			require.Equal(t, bip44.ChangeChainIndex, bw.ChangeEntries[0].Change)
+			require.Equal(t, bw.ChangeEntries[0].SkycoinAddress(), txn.Out[1].Address)
+		})
+	}
+}
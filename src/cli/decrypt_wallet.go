@@ -5,7 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func decryptWalletCmd() *cobra.Command {
@@ -23,9 +23,12 @@ func decryptWalletCmd() *cobra.Command {
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
 			w := args[0]
-			pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+			pr, err := passwordReaderFromFlags(c)
+			if err != nil {
+				return err
+			}
 
-			_, err := decryptWallet(w, pr)
+			_, err = decryptWallet(w, pr)
 			switch err.(type) {
 			case nil:
 			case WalletLoadError:
@@ -39,7 +42,7 @@ func decryptWalletCmd() *cobra.Command {
 		},
 	}
 
-	decryptWalletCmd.Flags().StringP("password", "p", "", "wallet password")
+	addPasswordFlags(decryptWalletCmd, "wallet password")
 
 	return decryptWalletCmd
 }
@@ -64,11 +64,11 @@ func distributeGenesisHandler(c *cobra.Command, args []string) error {
 	// node elsewhere.
 	if health.BlockPublisher {
 		if _, err := apiClient.InjectTransactionNoBroadcast(txn); err != nil {
-			return err
+			return injectTransactionError(err)
 		}
 	} else {
 		if _, err := apiClient.InjectTransaction(txn); err != nil {
-			return err
+			return injectTransactionError(err)
 		}
 	}
 
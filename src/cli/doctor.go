@@ -0,0 +1,506 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/api"
+)
+
+// Status of a doctor check
+const (
+	// DoctorOK the check passed
+	DoctorOK = "ok"
+	// DoctorWarning the check found a problem that doesn't prevent the CLI from working
+	DoctorWarning = "warning"
+	// DoctorFail the check found a problem that prevents the CLI from working
+	DoctorFail = "fail"
+	// DoctorSkipped the check could not run because an earlier check failed
+	DoctorSkipped = "skipped"
+)
+
+const (
+	defaultDoctorMaxBlocksBehind = 10
+	defaultDoctorMaxClockSkew    = time.Minute
+)
+
+// doctorRequiredAPISets are the API sets used by the CLI's node commands
+var doctorRequiredAPISets = []string{"READ", "STATUS", "TXN"}
+
+// DoctorCheck is the result of a check of the doctor command
+type DoctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Hint is how to fix the problem found by the check
+	Hint string `json:"hint,omitempty"`
+}
+
+// DoctorResult is printed by the doctor command
+type DoctorResult struct {
+	Checks   []DoctorCheck `json:"checks"`
+	Failed   int           `json:"failed"`
+	Warnings int           `json:"warnings"`
+}
+
+// doctor runs the checks of the doctor command
+type doctor struct {
+	client     *api.Client
+	rpcAddress string
+	walletDir  string
+	// cliVersion is the version of the CLI, compared to the node's version
+	cliVersion      string
+	maxBlocksBehind uint64
+	maxClockSkew    time.Duration
+	now             func() time.Time
+
+	// health is the node's health, nil if the node is not reachable
+	health *api.HealthResponse
+}
+
+// doctorChecks are the checks of the doctor command, in the order they are run.
+// The node must be checked first, the other node checks are skipped if it is not reachable.
+var doctorChecks = []func(d *doctor) DoctorCheck{
+	(*doctor).checkNode,
+	(*doctor).checkVersion,
+	(*doctor).checkCSRF,
+	(*doctor).checkAPISets,
+	(*doctor).checkSync,
+	(*doctor).checkClock,
+	(*doctor).checkWallets,
+}
+
+func doctorCmd() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose common setup problems",
+		Long: `Runs a series of checks of the CLI's setup and of the node it uses,
+    and prints how to fix the problems found:
+
+    node: the node at RPC_ADDR is reachable
+    version: the node's version is compatible with the CLI's version
+    csrf: CSRF protection is enabled and a token can be obtained
+    api_sets: the API sets used by the CLI are enabled
+    sync: the node is synced within --max-blocks-behind blocks of its peers
+    clock: the system clock is within --max-clock-skew of the node's clock
+    wallets: the wallet directory $DATA_DIR/wallets is readable and its wallets can be loaded
+
+    The command fails if any check fails. Warnings don't make it fail.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, _ []string) error {
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			maxBlocksBehind, err := c.Flags().GetUint64("max-blocks-behind")
+			if err != nil {
+				return err
+			}
+
+			maxClockSkew, err := c.Flags().GetDuration("max-clock-skew")
+			if err != nil {
+				return err
+			}
+
+			d := &doctor{
+				client:          apiClient,
+				rpcAddress:      apiClient.Addr,
+				walletDir:       filepath.Join(cliConfig.DataDir, "wallets"),
+				cliVersion:      Version,
+				maxBlocksBehind: maxBlocksBehind,
+				maxClockSkew:    maxClockSkew,
+				now:             time.Now,
+			}
+
+			result := d.run()
+
+			if jsonOutput {
+				if err := printJSON(result); err != nil {
+					return err
+				}
+			} else {
+				printDoctorResult(result)
+			}
+
+			if result.Failed > 0 {
+				return fmt.Errorf("%d of %d checks failed", result.Failed, len(result.Checks))
+			}
+
+			return nil
+		},
+	}
+
+	doctorCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format")
+	doctorCmd.Flags().Uint64("max-blocks-behind", defaultDoctorMaxBlocksBehind, "Number of blocks the node can be behind its peers")
+	doctorCmd.Flags().Duration("max-clock-skew", defaultDoctorMaxClockSkew, "Difference between the system clock and the node's clock that is tolerated")
+
+	return doctorCmd
+}
+
+// run runs the checks
+func (d *doctor) run() DoctorResult {
+	result := DoctorResult{
+		Checks: make([]DoctorCheck, 0, len(doctorChecks)),
+	}
+
+	for _, check := range doctorChecks {
+		r := check(d)
+		switch r.Status {
+		case DoctorFail:
+			result.Failed++
+		case DoctorWarning:
+			result.Warnings++
+		}
+		result.Checks = append(result.Checks, r)
+	}
+
+	return result
+}
+
+func printDoctorResult(result DoctorResult) {
+	for _, c := range result.Checks {
+		fmt.Printf("%-9s %s: %s\n", "["+c.Status+"]", c.Name, c.Message)
+		if c.Hint != "" {
+			fmt.Printf("%-9s hint: %s\n", "", c.Hint)
+		}
+	}
+
+	fmt.Printf("\n%d checks, %d failed, %d warnings\n", len(result.Checks), result.Failed, result.Warnings)
+}
+
+// skipped returns the result of a node check when the node is not reachable
+func skipped(name string) DoctorCheck {
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorSkipped,
+		Message: "the node is not reachable",
+	}
+}
+
+// checkNode checks that the node is reachable, and gets its health for the other node checks
+func (d *doctor) checkNode() DoctorCheck {
+	const name = "node"
+
+	health, err := d.client.Health()
+	if err != nil {
+		check := DoctorCheck{
+			Name:    name,
+			Status:  DoctorFail,
+			Message: fmt.Sprintf("the node at %s is not reachable: %v", d.rpcAddress, err),
+			Hint:    "start the node, or set RPC_ADDR to the address of its web interface, e.g. http://127.0.0.1:6460",
+		}
+
+		if e, ok := err.(api.ClientError); ok {
+			switch e.StatusCode {
+			case http.StatusUnauthorized:
+				check.Message = fmt.Sprintf("the node at %s requires authentication: %s", d.rpcAddress, e.Message)
+				check.Hint = "set RPC_USER and RPC_PASS to the node's -web-interface-username and -web-interface-password"
+			case http.StatusForbidden:
+				check.Message = fmt.Sprintf("the node at %s refused the request: %s", d.rpcAddress, e.Message)
+				check.Hint = "enable the STATUS API set with the node's -enable-api-sets option"
+			default:
+				check.Message = fmt.Sprintf("the node at %s failed to report its health: %s", d.rpcAddress, e.Message)
+				check.Hint = "check the node's logs"
+			}
+		}
+
+		return check
+	}
+
+	d.health = health
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: fmt.Sprintf("the node at %s is reachable, %d connections", d.rpcAddress, health.OpenConnections),
+	}
+}
+
+// checkVersion checks that the major and minor versions of the node and the CLI are the same
+func (d *doctor) checkVersion() DoctorCheck {
+	const name = "version"
+	if d.health == nil {
+		return skipped(name)
+	}
+
+	nodeVersion := d.health.Version.Version
+	hint := fmt.Sprintf("use the CLI released with the node's version %s", nodeVersion)
+
+	nv, err := semver.Make(nodeVersion)
+	if err != nil {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the node's version %q is not a valid version", nodeVersion),
+			Hint:    hint,
+		}
+	}
+
+	cv, err := semver.Make(d.cliVersion)
+	if err != nil {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the CLI's version %q is not a valid version", d.cliVersion),
+			Hint:    hint,
+		}
+	}
+
+	if nv.Major != cv.Major || nv.Minor != cv.Minor {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the node's version %s differs from the CLI's version %s", nv, cv),
+			Hint:    hint,
+		}
+	}
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: fmt.Sprintf("the node's version %s is compatible with the CLI's version %s", nv, cv),
+	}
+}
+
+// checkCSRF checks that CSRF protection is enabled and that a CSRF token can be obtained
+func (d *doctor) checkCSRF() DoctorCheck {
+	const name = "csrf"
+	if d.health == nil {
+		return skipped(name)
+	}
+
+	if !d.health.CSRFEnabled {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: "CSRF protection is disabled, web pages opened on this machine can make requests to the node",
+			Hint:    "don't start the node with -disable-csrf",
+		}
+	}
+
+	token, err := d.client.CSRF()
+	if err == nil && token == "" {
+		err = errors.New("empty token")
+	}
+	if err != nil {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorFail,
+			Message: fmt.Sprintf("CSRF protection is enabled but a token could not be obtained: %v", err),
+			Hint:    "check that nothing between the CLI and the node blocks /api/v1/csrf, and check the node's logs",
+		}
+	}
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: "CSRF protection is enabled and a token was obtained",
+	}
+}
+
+// checkAPISets checks that the API sets used by the CLI are enabled
+func (d *doctor) checkAPISets() DoctorCheck {
+	const name = "api_sets"
+	if d.health == nil {
+		return skipped(name)
+	}
+
+	var node struct {
+		EnabledAPISets []string `json:"enabled_api_sets"`
+	}
+	if err := d.client.Get("/api/v1/node", &node); err != nil {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the node did not report its enabled API sets: %v", err),
+			Hint:    "the node may be older than the CLI, see the version check",
+		}
+	}
+
+	enabled := make(map[string]struct{}, len(node.EnabledAPISets))
+	for _, s := range node.EnabledAPISets {
+		enabled[s] = struct{}{}
+	}
+
+	var missing []string
+	for _, s := range doctorRequiredAPISets {
+		if _, ok := enabled[s]; !ok {
+			missing = append(missing, s)
+		}
+	}
+
+	sets := append([]string{}, node.EnabledAPISets...)
+	sort.Strings(sets)
+
+	if len(missing) != 0 {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the API sets %s are disabled, enabled API sets: %s", strings.Join(missing, ", "), strings.Join(sets, ", ")),
+			Hint:    fmt.Sprintf("start the node with -enable-api-sets=%s", strings.Join(append(sets, missing...), ",")),
+		}
+	}
+
+	message := fmt.Sprintf("enabled API sets: %s", strings.Join(sets, ", "))
+	if !d.health.WalletAPIEnabled {
+		message += ". The WALLET API set is disabled, the commands using the node's wallets won't work"
+	}
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: message,
+	}
+}
+
+// checkSync checks that the node is synced with its peers
+func (d *doctor) checkSync() DoctorCheck {
+	const name = "sync"
+	if d.health == nil {
+		return skipped(name)
+	}
+
+	progress, err := d.client.BlockchainProgress()
+	if err != nil {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the node did not report its sync progress: %v", err),
+			Hint:    "enable the READ API set with the node's -enable-api-sets option",
+		}
+	}
+
+	if len(progress.Peers) == 0 {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the node is at height %d and has no peers to sync with", progress.Current),
+			Hint:    "check the node's network connection, and that it is not started with -disable-networking",
+		}
+	}
+
+	if progress.Highest > progress.Current && progress.Highest-progress.Current > d.maxBlocksBehind {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the node is at height %d, %d blocks behind its peers", progress.Current, progress.Highest-progress.Current),
+			Hint:    "wait for the node to sync, balances and transactions are out of date until it is",
+		}
+	}
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: fmt.Sprintf("the node is synced at height %d", progress.Current),
+	}
+}
+
+// checkClock checks that the system clock agrees with the node's clock.
+// The node's time is the time of its head block plus the time since the head block it reports.
+func (d *doctor) checkClock() DoctorCheck {
+	const name = "clock"
+	if d.health == nil {
+		return skipped(name)
+	}
+
+	headTime := time.Unix(int64(d.health.BlockchainMetadata.Head.Time), 0)
+	nodeTime := headTime.Add(d.health.BlockchainMetadata.TimeSinceLastBlock.Duration)
+	skew := d.now().Sub(nodeTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	// The head block time has a resolution of a second
+	skew = skew.Truncate(time.Second)
+
+	if skew > d.maxClockSkew {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorWarning,
+			Message: fmt.Sprintf("the system clock differs from the node's clock by %s", skew),
+			Hint:    "synchronize the clocks of both machines with NTP, transaction and block times are compared to the current time",
+		}
+	}
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: fmt.Sprintf("the system clock is within %s of the node's clock", d.maxClockSkew),
+	}
+}
+
+// checkWallets checks that the wallet directory is readable and that its wallets can be loaded
+func (d *doctor) checkWallets() DoctorCheck {
+	const name = "wallets"
+
+	entries, err := ioutil.ReadDir(d.walletDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DoctorCheck{
+				Name:    name,
+				Status:  DoctorWarning,
+				Message: fmt.Sprintf("the wallet directory %s doesn't exist", d.walletDir),
+				Hint:    "create a wallet with walletCreate, or set DATA_DIR to the directory containing the wallets directory",
+			}
+		}
+
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorFail,
+			Message: fmt.Sprintf("the wallet directory %s is not readable: %v", d.walletDir, err),
+			Hint:    "check the permissions of the wallet directory",
+		}
+	}
+
+	var loaded, encrypted int
+	var invalid []string
+	for _, e := range entries {
+		if !e.Mode().IsRegular() || !strings.HasSuffix(e.Name(), walletExt) {
+			continue
+		}
+
+		w, err := wallet.Load(filepath.Join(d.walletDir, e.Name()))
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s (%v)", e.Name(), err))
+			continue
+		}
+
+		loaded++
+		if w.IsEncrypted() {
+			encrypted++
+		}
+	}
+
+	if len(invalid) != 0 {
+		return DoctorCheck{
+			Name:    name,
+			Status:  DoctorFail,
+			Message: fmt.Sprintf("%d wallets in %s can't be loaded: %s", len(invalid), d.walletDir, strings.Join(invalid, ", ")),
+			Hint:    "restore the wallets from a backup with walletRestoreBackup, or move them out of the wallet directory",
+		}
+	}
+
+	message := fmt.Sprintf("%d wallets in %s can be loaded", loaded, d.walletDir)
+	if encrypted != 0 {
+		message += fmt.Sprintf(", %d of them encrypted, needing their password to spend", encrypted)
+	}
+
+	return DoctorCheck{
+		Name:    name,
+		Status:  DoctorOK,
+		Message: message,
+	}
+}
@@ -0,0 +1,433 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/readable"
+)
+
+// doctorNode is a fake node for the doctor checks
+type doctorNode struct {
+	healthStatus   int
+	health         api.HealthResponse
+	csrfStatus     int
+	csrfToken      string
+	nodeStatus     int
+	enabledAPISets []string
+	progressStatus int
+	progress       readable.BlockchainProgress
+}
+
+func newDoctorNode(now time.Time) *doctorNode {
+	n := &doctorNode{
+		healthStatus:   http.StatusOK,
+		csrfStatus:     http.StatusOK,
+		csrfToken:      "token",
+		nodeStatus:     http.StatusOK,
+		enabledAPISets: []string{"READ", "STATUS", "TXN", "WALLET"},
+		progressStatus: http.StatusOK,
+		progress: readable.BlockchainProgress{
+			Current: 100,
+			Highest: 100,
+			Peers: []readable.PeerBlockchainHeight{
+				{Address: "127.0.0.1:6000", Height: 100},
+			},
+		},
+	}
+
+	n.health.Version.Version = Version
+	n.health.CSRFEnabled = true
+	n.health.WalletAPIEnabled = true
+	n.health.OpenConnections = 1
+	n.health.BlockchainMetadata.Head.Time = uint64(now.Add(-time.Minute).Unix())
+	n.health.BlockchainMetadata.TimeSinceLastBlock.Duration = time.Minute
+
+	return n
+}
+
+func (n *doctorNode) serve(t *testing.T) *httptest.Server {
+	writeJSON := func(w http.ResponseWriter, status int, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			fmt.Fprint(w, http.StatusText(status))
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(obj))
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/health":
+			writeJSON(w, n.healthStatus, n.health)
+		case "/api/v1/csrf":
+			writeJSON(w, n.csrfStatus, map[string]string{"csrf_token": n.csrfToken})
+		case "/api/v1/node":
+			writeJSON(w, n.nodeStatus, map[string][]string{"enabled_api_sets": n.enabledAPISets})
+		case "/api/v1/blockchain/progress":
+			writeJSON(w, n.progressStatus, n.progress)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestDoctor(addr, walletDir string, now time.Time) *doctor {
+	return &doctor{
+		client:          api.NewClient(addr),
+		rpcAddress:      addr,
+		walletDir:       walletDir,
+		cliVersion:      Version,
+		maxBlocksBehind: defaultDoctorMaxBlocksBehind,
+		maxClockSkew:    defaultDoctorMaxClockSkew,
+		now: func() time.Time {
+			return now
+		},
+	}
+}
+
+// runDoctorCheck runs the node check, then the check named name, and returns its result
+func runDoctorCheck(t *testing.T, d *doctor, name string) DoctorCheck {
+	result := d.run()
+	for _, c := range result.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("check %s not found", name)
+	return DoctorCheck{}
+}
+
+func TestDoctor(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	dir, err := ioutil.TempDir("", "doctor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	n := newDoctorNode(now)
+	s := n.serve(t)
+	defer s.Close()
+
+	d := newTestDoctor(s.URL, dir, now)
+	result := d.run()
+
+	require.Equal(t, 0, result.Failed)
+	require.Equal(t, 0, result.Warnings)
+	require.Len(t, result.Checks, len(doctorChecks))
+	for _, c := range result.Checks {
+		require.Equal(t, DoctorOK, c.Status, "%s: %s", c.Name, c.Message)
+		require.Empty(t, c.Hint)
+	}
+}
+
+func TestDoctorCheckNode(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	cases := []struct {
+		name         string
+		healthStatus int
+		status       string
+		hint         string
+	}{
+		{"ok", http.StatusOK, DoctorOK, ""},
+		{"unauthorized", http.StatusUnauthorized, DoctorFail, "set RPC_USER and RPC_PASS to the node's -web-interface-username and -web-interface-password"},
+		{"forbidden", http.StatusForbidden, DoctorFail, "enable the STATUS API set with the node's -enable-api-sets option"},
+		{"error", http.StatusInternalServerError, DoctorFail, "check the node's logs"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newDoctorNode(now)
+			n.healthStatus = tc.healthStatus
+			s := n.serve(t)
+			defer s.Close()
+
+			d := newTestDoctor(s.URL, "", now)
+			c := d.checkNode()
+			require.Equal(t, "node", c.Name)
+			require.Equal(t, tc.status, c.Status, c.Message)
+			require.Equal(t, tc.hint, c.Hint)
+			require.Equal(t, tc.status == DoctorOK, d.health != nil)
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		d := newTestDoctor(deadNodeAddr(t), "", now)
+		result := d.run()
+
+		require.Equal(t, DoctorFail, result.Checks[0].Status)
+		require.Contains(t, result.Checks[0].Hint, "RPC_ADDR")
+
+		// The other node checks are skipped, the wallet check still runs
+		for _, c := range result.Checks[1:] {
+			if c.Name == "wallets" {
+				require.NotEqual(t, DoctorSkipped, c.Status)
+				continue
+			}
+			require.Equal(t, DoctorSkipped, c.Status, c.Name)
+		}
+		require.Equal(t, 1, result.Failed)
+	})
+}
+
+func TestDoctorCheckVersion(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	cases := []struct {
+		name        string
+		nodeVersion string
+		cliVersion  string
+		status      string
+	}{
+		{"same", "0.27.1", "0.27.1", DoctorOK},
+		{"patch differs", "0.27.0", "0.27.1", DoctorOK},
+		{"prerelease", "0.27.1-rc1", "0.27.1", DoctorOK},
+		{"minor differs", "0.26.0", "0.27.1", DoctorWarning},
+		{"major differs", "1.27.1", "0.27.1", DoctorWarning},
+		{"invalid node version", "dev", "0.27.1", DoctorWarning},
+		{"invalid cli version", "0.27.1", "", DoctorWarning},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newDoctorNode(now)
+			n.health.Version.Version = tc.nodeVersion
+			s := n.serve(t)
+			defer s.Close()
+
+			d := newTestDoctor(s.URL, "", now)
+			d.cliVersion = tc.cliVersion
+			c := runDoctorCheck(t, d, "version")
+			require.Equal(t, tc.status, c.Status, c.Message)
+			if tc.status != DoctorOK {
+				require.Contains(t, c.Hint, tc.nodeVersion)
+			}
+		})
+	}
+}
+
+func TestDoctorCheckCSRF(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	cases := []struct {
+		name        string
+		csrfEnabled bool
+		csrfStatus  int
+		csrfToken   string
+		status      string
+	}{
+		{"ok", true, http.StatusOK, "token", DoctorOK},
+		{"disabled", false, http.StatusNotFound, "", DoctorWarning},
+		{"no token", true, http.StatusNotFound, "", DoctorFail},
+		{"empty token", true, http.StatusOK, "", DoctorFail},
+		{"error", true, http.StatusInternalServerError, "", DoctorFail},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newDoctorNode(now)
+			n.health.CSRFEnabled = tc.csrfEnabled
+			n.csrfStatus = tc.csrfStatus
+			n.csrfToken = tc.csrfToken
+			s := n.serve(t)
+			defer s.Close()
+
+			c := runDoctorCheck(t, newTestDoctor(s.URL, "", now), "csrf")
+			require.Equal(t, tc.status, c.Status, c.Message)
+			if tc.status == DoctorWarning {
+				require.Equal(t, "don't start the node with -disable-csrf", c.Hint)
+			}
+		})
+	}
+}
+
+func TestDoctorCheckAPISets(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	cases := []struct {
+		name      string
+		status    int
+		sets      []string
+		walletAPI bool
+		result    string
+		hint      string
+		message   string
+	}{
+		{
+			name:      "ok",
+			status:    http.StatusOK,
+			sets:      []string{"TXN", "READ", "STATUS", "WALLET"},
+			walletAPI: true,
+			result:    DoctorOK,
+			message:   "enabled API sets: READ, STATUS, TXN, WALLET",
+		},
+		{
+			name:    "wallet disabled",
+			status:  http.StatusOK,
+			sets:    []string{"READ", "STATUS", "TXN"},
+			result:  DoctorOK,
+			message: "enabled API sets: READ, STATUS, TXN. The WALLET API set is disabled, the commands using the node's wallets won't work",
+		},
+		{
+			name:    "missing",
+			status:  http.StatusOK,
+			sets:    []string{"STATUS", "READ"},
+			result:  DoctorWarning,
+			hint:    "start the node with -enable-api-sets=READ,STATUS,TXN",
+			message: "the API sets TXN are disabled, enabled API sets: READ, STATUS",
+		},
+		{
+			name:   "not reported",
+			status: http.StatusNotFound,
+			result: DoctorWarning,
+			hint:   "the node may be older than the CLI, see the version check",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newDoctorNode(now)
+			n.nodeStatus = tc.status
+			n.enabledAPISets = tc.sets
+			n.health.WalletAPIEnabled = tc.walletAPI
+			s := n.serve(t)
+			defer s.Close()
+
+			c := runDoctorCheck(t, newTestDoctor(s.URL, "", now), "api_sets")
+			require.Equal(t, tc.result, c.Status, c.Message)
+			require.Equal(t, tc.hint, c.Hint)
+			if tc.message != "" {
+				require.Equal(t, tc.message, c.Message)
+			}
+		})
+	}
+}
+
+func TestDoctorCheckSync(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	peers := []readable.PeerBlockchainHeight{
+		{Address: "127.0.0.1:6000", Height: 200},
+	}
+
+	cases := []struct {
+		name     string
+		status   int
+		progress readable.BlockchainProgress
+		result   string
+	}{
+		{"synced", http.StatusOK, readable.BlockchainProgress{Current: 200, Highest: 200, Peers: peers}, DoctorOK},
+		{"within max", http.StatusOK, readable.BlockchainProgress{Current: 190, Highest: 200, Peers: peers}, DoctorOK},
+		{"ahead", http.StatusOK, readable.BlockchainProgress{Current: 200, Highest: 190, Peers: peers}, DoctorOK},
+		{"behind", http.StatusOK, readable.BlockchainProgress{Current: 189, Highest: 200, Peers: peers}, DoctorWarning},
+		{"no peers", http.StatusOK, readable.BlockchainProgress{Current: 200, Highest: 200}, DoctorWarning},
+		{"error", http.StatusForbidden, readable.BlockchainProgress{}, DoctorWarning},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newDoctorNode(now)
+			n.progressStatus = tc.status
+			n.progress = tc.progress
+			s := n.serve(t)
+			defer s.Close()
+
+			c := runDoctorCheck(t, newTestDoctor(s.URL, "", now), "sync")
+			require.Equal(t, tc.result, c.Status, c.Message)
+			if tc.result == DoctorWarning {
+				require.NotEmpty(t, c.Hint)
+			}
+		})
+	}
+}
+
+func TestDoctorCheckClock(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	cases := []struct {
+		name   string
+		skew   time.Duration
+		result string
+	}{
+		{"same", 0, DoctorOK},
+		{"within max ahead", time.Minute, DoctorOK},
+		{"within max behind", -time.Minute, DoctorOK},
+		{"ahead", time.Minute + time.Second, DoctorWarning},
+		{"behind", -time.Hour, DoctorWarning},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newDoctorNode(now)
+			s := n.serve(t)
+			defer s.Close()
+
+			c := runDoctorCheck(t, newTestDoctor(s.URL, "", now.Add(tc.skew)), "clock")
+			require.Equal(t, tc.result, c.Status, c.Message)
+			if tc.result == DoctorWarning {
+				require.NotEmpty(t, c.Hint)
+			}
+		})
+	}
+}
+
+func TestDoctorCheckWallets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctor-wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := newTestDoctor("", filepath.Join(dir, "missing"), time.Now())
+	c := d.checkWallets()
+	require.Equal(t, DoctorWarning, c.Status, c.Message)
+	require.Contains(t, c.Hint, "DATA_DIR")
+
+	d.walletDir = dir
+	c = d.checkWallets()
+	require.Equal(t, DoctorOK, c.Status, c.Message)
+	require.Equal(t, fmt.Sprintf("0 wallets in %s can be loaded", dir), c.Message)
+
+	w, err := wallet.NewWallet("plain.wlt", wallet.Options{
+		Type:      wallet.WalletTypeDeterministic,
+		Seed:      "seed",
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, wallet.Save(w, dir))
+
+	w, err = wallet.NewWallet("encrypted.wlt", wallet.Options{
+		Type:       wallet.WalletTypeDeterministic,
+		Seed:       "seed",
+		GenerateN:  1,
+		Encrypt:    true,
+		Password:   []byte("pwd"),
+		CryptoType: wallet.CryptoTypeSha256Xor,
+	})
+	require.NoError(t, err)
+	require.NoError(t, wallet.Save(w, dir))
+
+	// Files without the wallet extension are ignored
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0600))
+
+	c = d.checkWallets()
+	require.Equal(t, DoctorOK, c.Status, c.Message)
+	require.Equal(t, fmt.Sprintf("2 wallets in %s can be loaded, 1 of them encrypted, needing their password to spend", dir), c.Message)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "corrupt.wlt"), []byte("{"), 0600))
+
+	c = d.checkWallets()
+	require.Equal(t, DoctorFail, c.Status, c.Message)
+	require.Contains(t, c.Message, "corrupt.wlt")
+	require.Contains(t, c.Hint, "walletRestoreBackup")
+}
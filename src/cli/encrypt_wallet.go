@@ -5,7 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func encryptWalletCmd() *cobra.Command {
@@ -29,7 +29,10 @@ func encryptWalletCmd() *cobra.Command {
 				return err
 			}
 
-			pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+			pr, err := passwordReaderFromFlags(c)
+			if err != nil {
+				return err
+			}
 
 			_, err = encryptWallet(w, pr, cryptoType)
 			switch err.(type) {
@@ -45,7 +48,7 @@ func encryptWalletCmd() *cobra.Command {
 		},
 	}
 
-	encryptWalletCmd.Flags().StringP("password", "p", "", "wallet password")
+	addPasswordFlags(encryptWalletCmd, "wallet password")
 	encryptWalletCmd.Flags().StringP("crypto-type", "x", "scrypt-chacha20poly1305", "The crypto type for wallet encryption, can be scrypt-chacha20poly1305 or sha256-xor")
 	return encryptWalletCmd
 }
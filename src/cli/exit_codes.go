@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	ptransaction "github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/transaction"
+)
+
+// Exit codes of the CLI. The codes of a failure class are the same for all commands.
+const (
+	// ExitCodeOK is returned if the command succeeded
+	ExitCodeOK = 0
+	// ExitCodeError is returned for an error that has no specific exit code
+	ExitCodeError = 1
+	// ExitCodeUsage is returned if the command, its arguments or its flags are invalid
+	ExitCodeUsage = 2
+	// ExitCodeNode is returned if the node can't be reached or failed to handle the request
+	ExitCodeNode = 3
+	// ExitCodeWalletNotFound is returned if the wallet file or the node's wallet doesn't exist
+	ExitCodeWalletNotFound = 4
+	// ExitCodeWrongPassword is returned if the wallet can't be decrypted with the password
+	ExitCodeWrongPassword = 5
+	// ExitCodeInsufficientBalance is returned if the balance is not sufficient for a spend
+	ExitCodeInsufficientBalance = 6
+	// ExitCodeTransactionRejected is returned if the node rejects a transaction
+	ExitCodeTransactionRejected = 7
+	// ExitCodeInvalidTransaction is returned if a raw transaction fails the local sanity checks
+	ExitCodeInvalidTransaction = 8
+	// ExitCodeWalletCorrupt is returned if a wallet file can't be loaded or is inconsistent with its seed
+	ExitCodeWalletCorrupt = 9
+	// ExitCodeAddressMismatch is returned if an expected address is not one of the wallet's first addresses
+	ExitCodeAddressMismatch = 10
+)
+
+// exitCodeKinds are the names of the exit codes, used in JSON error objects
+var exitCodeKinds = map[int]string{
+	ExitCodeError:               "error",
+	ExitCodeUsage:               "usage",
+	ExitCodeNode:                "node",
+	ExitCodeWalletNotFound:      "wallet_not_found",
+	ExitCodeWrongPassword:       "wrong_password",
+	ExitCodeInsufficientBalance: "insufficient_balance",
+	ExitCodeTransactionRejected: "transaction_rejected",
+	ExitCodeInvalidTransaction:  "invalid_transaction",
+	ExitCodeWalletCorrupt:       "wallet_corrupt",
+	ExitCodeAddressMismatch:     "address_mismatch",
+}
+
+// ExitError is an error that makes the CLI exit with a specific exit code
+type ExitError struct {
+	error
+	Code int
+}
+
+// ExitCode returns the exit code of the CLI for an error returned by a command:
+// 0 for nil, the code of an ExitError, the code of the error's failure class,
+// and ExitCodeError if the error has no failure class
+func ExitCode(err error) int {
+	switch e := err.(type) {
+	case nil:
+		return ExitCodeOK
+	case ExitError:
+		return e.Code
+	case WalletLoadError:
+		if isNotExistMessage(e.error.Error()) {
+			return ExitCodeWalletNotFound
+		}
+		return ExitCode(e.error)
+	case api.ClientError:
+		return clientErrorExitCode(e)
+	case *url.Error, net.Error:
+		return ExitCodeNode
+	}
+
+	switch err {
+	case wallet.ErrWalletNotExist:
+		return ExitCodeWalletNotFound
+	case wallet.ErrInvalidPassword:
+		return ExitCodeWrongPassword
+	case transaction.ErrInsufficientBalance,
+		ptransaction.ErrInsufficientBalance,
+		ErrTemporaryInsufficientBalance:
+		return ExitCodeInsufficientBalance
+	case ErrMultiplePasswordSources:
+		return ExitCodeUsage
+	}
+
+	// cobra returns untyped errors for unknown commands
+	if strings.HasPrefix(err.Error(), "unknown command ") {
+		return ExitCodeUsage
+	}
+
+	return ExitCodeError
+}
+
+// clientErrorExitCode returns the exit code of an error response of the node.
+// The wallet errors are recognized by their message, which is the same for all wallet endpoints.
+func clientErrorExitCode(e api.ClientError) int {
+	switch {
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ExitCodeNode
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ExitCodeNode
+	case strings.Contains(e.Message, wallet.ErrWalletNotExist.Error()):
+		return ExitCodeWalletNotFound
+	case strings.Contains(e.Message, wallet.ErrInvalidPassword.Error()):
+		return ExitCodeWrongPassword
+	case strings.Contains(e.Message, ptransaction.ErrInsufficientBalance.Error()):
+		return ExitCodeInsufficientBalance
+	default:
+		return ExitCodeError
+	}
+}
+
+// injectTransactionError makes the rejection of an injected transaction by the node an ExitError.
+// The node responds 400 if the transaction is invalid, and 503 if it can't be broadcast.
+func injectTransactionError(err error) error {
+	if e, ok := err.(api.ClientError); ok && e.StatusCode == http.StatusBadRequest {
+		return ExitError{
+			error: err,
+			Code:  ExitCodeTransactionRejected,
+		}
+	}
+	return err
+}
+
+// isNotExistMessage returns true for the error messages of missing wallets,
+// e.g. wallet.ErrWalletNotExist and the error of wallet.Load for a missing file
+func isNotExistMessage(msg string) bool {
+	return strings.HasSuffix(msg, "doesn't exist")
+}
+
+// usageError wraps an error of the command line arguments or flags
+func usageError(c *cobra.Command, err error) error {
+	if err == nil {
+		return nil
+	}
+	return ExitError{
+		error: err,
+		Code:  ExitCodeUsage,
+	}
+}
+
+// wrapUsageErrors makes the argument and flag errors of c and its subcommands usage errors
+func wrapUsageErrors(c *cobra.Command) {
+	c.SetFlagErrorFunc(usageError)
+
+	var wrap func(c *cobra.Command)
+	wrap = func(c *cobra.Command) {
+		if args := c.Args; args != nil {
+			c.Args = func(c *cobra.Command, a []string) error {
+				return usageError(c, args(c, a))
+			}
+		}
+		for _, sc := range c.Commands() {
+			wrap(sc)
+		}
+	}
+	wrap(c)
+}
+
+// jsonError is the error object written to stderr by a failed command run with --json
+type jsonError struct {
+	Error jsonErrorDetail `json:"error"`
+}
+
+type jsonErrorDetail struct {
+	Code    int    `json:"code"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Execute runs the command of the CLI's arguments and returns its exit code.
+// The error of a failed command is written to stderr. If the command's --json flag is set,
+// the error is written as a JSON object with the exit code, the name of its failure class and the message.
+func Execute(skyCLI *cobra.Command, stderr io.Writer) int {
+	c, err := skyCLI.ExecuteC()
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	code := ExitCode(err)
+
+	if c != nil && jsonFlag(c) {
+		kind, ok := exitCodeKinds[code]
+		if !ok {
+			kind = exitCodeKinds[ExitCodeError]
+		}
+
+		d, jsonErr := json.Marshal(jsonError{
+			Error: jsonErrorDetail{
+				Code:    code,
+				Kind:    kind,
+				Message: err.Error(),
+			},
+		})
+		if jsonErr == nil {
+			fmt.Fprintln(stderr, string(d))
+			return code
+		}
+	}
+
+	fmt.Fprintln(stderr, "Error:", err)
+	if code == ExitCodeUsage && c != nil {
+		fmt.Fprintf(stderr, "Run '%s --help' for usage.\n", c.CommandPath())
+	}
+
+	return code
+}
+
+// jsonFlag returns true if the command has a --json flag that is set
+func jsonFlag(c *cobra.Command) bool {
+	f := c.Flags().Lookup("json")
+	return f != nil && f.Value.Type() == "bool" && f.Value.String() == "true"
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ptransaction "github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/transaction"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"nil", nil, ExitCodeOK},
+		{"other", errors.New("failed"), ExitCodeError},
+		{"exit error", ExitError{error: errors.New("failed"), Code: 3}, 3},
+		{"unknown command", errors.New(`unknown command "foo" for "privateness-cli"`), ExitCodeUsage},
+		{"multiple passwords", ErrMultiplePasswordSources, ExitCodeUsage},
+		{"connection", &url.Error{Op: "Get", URL: "http://127.0.0.1:1", Err: errors.New("connection refused")}, ExitCodeNode},
+		{"node 500", api.NewClientError("500 Internal Server Error", http.StatusInternalServerError, "500 Internal Server Error"), ExitCodeNode},
+		{"node 503", api.NewClientError("503 Service Unavailable", http.StatusServiceUnavailable, "503 Service Unavailable"), ExitCodeNode},
+		{"node 401", api.NewClientError("401 Unauthorized", http.StatusUnauthorized, "401 Unauthorized"), ExitCodeNode},
+		{"node 404", api.NewClientError("404 Not Found", http.StatusNotFound, "404 Not Found"), ExitCodeError},
+		{"wallet not exist", wallet.ErrWalletNotExist, ExitCodeWalletNotFound},
+		{"wallet file not exist", WalletLoadError{fmt.Errorf("wallet %q doesn't exist", "foo.wlt")}, ExitCodeWalletNotFound},
+		{"wallet load", WalletLoadError{errors.New("invalid wallet")}, ExitCodeError},
+		{"wallet load password", WalletLoadError{wallet.ErrInvalidPassword}, ExitCodeWrongPassword},
+		{"node wallet not exist", api.NewClientError("400 Bad Request", http.StatusBadRequest, "400 Bad Request - wallet doesn't exist"), ExitCodeWalletNotFound},
+		{"wrong password", wallet.ErrInvalidPassword, ExitCodeWrongPassword},
+		{"node wrong password", api.NewClientError("400 Bad Request", http.StatusBadRequest, "400 Bad Request - invalid password"), ExitCodeWrongPassword},
+		{"insufficient balance", transaction.ErrInsufficientBalance, ExitCodeInsufficientBalance},
+		{"insufficient balance local", ptransaction.ErrInsufficientBalance, ExitCodeInsufficientBalance},
+		{"temporary insufficient balance", ErrTemporaryInsufficientBalance, ExitCodeInsufficientBalance},
+		{"node insufficient balance", api.NewClientError("400 Bad Request", http.StatusBadRequest, "balance is not sufficient"), ExitCodeInsufficientBalance},
+		{"transaction rejected", injectTransactionError(api.NewClientError("400 Bad Request", http.StatusBadRequest, "400 Bad Request - Transaction violates hard constraint")), ExitCodeTransactionRejected},
+		{"transaction not broadcast", injectTransactionError(api.NewClientError("503 Service Unavailable", http.StatusServiceUnavailable, "503 Service Unavailable")), ExitCodeNode},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.code, ExitCode(tc.err))
+		})
+	}
+}
+
+func TestExecuteExitCodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exit-codes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := wallet.NewWallet("encrypted.wlt", wallet.Options{
+		Type:       wallet.WalletTypeDeterministic,
+		Seed:       "seed",
+		GenerateN:  1,
+		Encrypt:    true,
+		Password:   []byte("pwd"),
+		CryptoType: wallet.CryptoTypeSha256Xor,
+	})
+	require.NoError(t, err)
+	require.NoError(t, wallet.Save(w, dir))
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/injectTransaction":
+			http.Error(w, "400 Bad Request - Transaction violates hard constraint", http.StatusBadRequest)
+		case "/api/v1/health":
+			http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer node.Close()
+
+	cases := []struct {
+		name    string
+		rpcAddr string
+		args    []string
+		code    int
+		json    bool
+	}{
+		{
+			name: "unknown command",
+			args: []string{"foo"},
+			code: ExitCodeUsage,
+		},
+		{
+			name: "missing argument",
+			args: []string{"decryptWallet"},
+			code: ExitCodeUsage,
+		},
+		{
+			name: "unknown flag",
+			args: []string{"status", "--foo"},
+			code: ExitCodeUsage,
+		},
+		{
+			name:    "node unreachable",
+			rpcAddr: deadNodeAddr(t),
+			args:    []string{"status"},
+			code:    ExitCodeNode,
+		},
+		{
+			name: "node error",
+			args: []string{"status"},
+			code: ExitCodeNode,
+		},
+		{
+			name: "wallet not found",
+			args: []string{"walletBalance", filepath.Join(dir, "missing.wlt")},
+			code: ExitCodeWalletNotFound,
+		},
+		{
+			name: "wallet not found json",
+			args: []string{"walletBalance", filepath.Join(dir, "missing.wlt"), "--json"},
+			code: ExitCodeWalletNotFound,
+			json: true,
+		},
+		{
+			name: "wrong password",
+			args: []string{"decryptWallet", filepath.Join(dir, "encrypted.wlt"), "-p", "wrong"},
+			code: ExitCodeWrongPassword,
+		},
+		{
+			name: "invalid transaction",
+			args: []string{"broadcastTransaction", "--yes", "00"},
+			code: ExitCodeInvalidTransaction,
+		},
+		{
+			name: "transaction rejected",
+			args: []string{"broadcastTransaction", "--yes", testRawTxn},
+			code: ExitCodeTransactionRejected,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rpcAddr := tc.rpcAddr
+			if rpcAddr == "" {
+				rpcAddr = node.URL
+			}
+
+			skyCLI, err := NewCLI(Config{
+				Coin:       "privateness",
+				RPCAddress: rpcAddr,
+			})
+			require.NoError(t, err)
+			skyCLI.SetArgs(tc.args)
+			skyCLI.SetOutput(ioutil.Discard)
+
+			var stderr bytes.Buffer
+			code := Execute(skyCLI, &stderr)
+			require.Equal(t, tc.code, code, stderr.String())
+
+			if !tc.json {
+				require.Contains(t, stderr.String(), "Error: ")
+				return
+			}
+
+			var e jsonError
+			require.NoError(t, json.Unmarshal(stderr.Bytes(), &e))
+			require.Equal(t, tc.code, e.Error.Code)
+			require.Equal(t, exitCodeKinds[tc.code], e.Error.Kind)
+			require.NotEmpty(t, e.Error.Message)
+		})
+	}
+}
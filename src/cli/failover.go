@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/api"
+)
+
+const (
+	csrfHeader = "X-CSRF-Token"
+)
+
+// parseRPCAddresses parses a comma-separated list of node addresses
+func parseRPCAddresses(addrs string) ([]string, error) {
+	var ret []string
+	for _, a := range strings.Split(addrs, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+
+		u, err := url.Parse(a)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("RPC_ADDR must be in scheme://host format")
+		}
+
+		ret = append(ret, strings.TrimRight(a, "/")+"/")
+	}
+
+	if len(ret) == 0 {
+		return nil, errors.New("RPC_ADDR must be in scheme://host format")
+	}
+
+	return ret, nil
+}
+
+// newAPIClient creates the api client for a comma-separated list of node addresses.
+// With more than one node, requests fail over between the nodes, see nodeFailover.
+func newAPIClient(addrs string, maxBlockAge time.Duration) (*api.Client, error) {
+	nodes, err := parseRPCAddresses(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := api.NewClient(nodes[0])
+	if len(nodes) > 1 {
+		c.HTTPClient.Transport = newNodeFailover(nodes, c.HTTPClient.Transport, maxBlockAge)
+	}
+
+	return c, nil
+}
+
+// nodeFailover is an http.RoundTripper that sends each request to the first usable node of a list.
+//
+// A node is usable if it accepts connections and its /api/v1/health endpoint reports it healthy
+// and not syncing (see checkHealth). The first usable node is remembered and used for all
+// following requests, until a request to it fails.
+//
+// A request that failed on a node is only sent to the next node if it can't have been applied
+// by the failed node. GET and HEAD requests are retried after any transport error.
+// Other requests, which may modify the node's state, are only retried if the connection to the
+// node could not be established.
+//
+// The api client builds request URLs from the first node's address, which nodeFailover
+// rewrites to the address of the node the request is sent to.
+type nodeFailover struct {
+	nodes       []string
+	transport   http.RoundTripper
+	maxBlockAge time.Duration
+
+	sync.Mutex
+	// current is the index of the last node a request succeeded on, -1 if no request succeeded yet
+	current int
+}
+
+func newNodeFailover(nodes []string, transport http.RoundTripper, maxBlockAge time.Duration) *nodeFailover {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &nodeFailover{
+		nodes:       nodes,
+		transport:   transport,
+		maxBlockAge: maxBlockAge,
+		current:     -1,
+	}
+}
+
+// Node returns the address of the node requests are currently sent to, if any request succeeded yet
+func (f *nodeFailover) Node() string {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.current == -1 {
+		return ""
+	}
+	return f.nodes[f.current]
+}
+
+// RoundTrip implements http.RoundTripper
+func (f *nodeFailover) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.Lock()
+	start := f.current
+	f.Unlock()
+
+	known := start != -1
+	if !known {
+		start = 0
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	var errs []string
+	for i := start; i < len(f.nodes); i++ {
+		node := f.nodes[i]
+
+		// The remembered node is not checked again, any other node is checked before using it
+		if !known || i != start {
+			if err := f.checkHealth(req, node); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", node, err))
+				continue
+			}
+		}
+
+		nodeReq, err := f.nodeRequest(req, node, i != start)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := f.transport.RoundTrip(nodeReq)
+		if err == nil {
+			f.Lock()
+			f.current = i
+			f.Unlock()
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %v", node, err))
+
+		if !idempotent && !isConnectError(err) {
+			// The node may have applied the request, it must not be sent to another node
+			return nil, err
+		}
+
+		if req.Body != nil && req.GetBody == nil {
+			// The request body can't be sent again
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("no usable node: %s", strings.Join(errs, "; "))
+}
+
+// nodeRequest copies req for sending to node.
+// If the request was redirected from the node its CSRF token was obtained from, a new token is obtained.
+func (f *nodeFailover) nodeRequest(req *http.Request, node string, redirected bool) (*http.Request, error) {
+	nodeReq := req.Clone(req.Context())
+
+	u, err := url.Parse(node + strings.TrimPrefix(req.URL.String(), f.nodes[0]))
+	if err != nil {
+		return nil, err
+	}
+	nodeReq.URL = u
+	nodeReq.Host = ""
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		nodeReq.Body = body
+	}
+
+	if redirected && req.Header.Get(csrfHeader) != "" {
+		token, err := f.csrfToken(req, node)
+		if err != nil {
+			return nil, err
+		}
+		nodeReq.Header.Set(csrfHeader, token)
+	}
+
+	return nodeReq, nil
+}
+
+// get makes a GET request to node with the authentication of req
+func (f *nodeFailover) get(req *http.Request, node, endpoint string, obj interface{}) error {
+	getReq, err := http.NewRequest(http.MethodGet, node+endpoint, nil)
+	if err != nil {
+		return err
+	}
+	getReq = getReq.WithContext(req.Context())
+
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		getReq.Header.Set("Authorization", auth)
+	}
+
+	resp, err := f.transport.RoundTrip(getReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return api.NewClientError(resp.Status, resp.StatusCode, string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(obj)
+}
+
+// checkHealth returns an error if node is unreachable, unhealthy or syncing.
+// A node is considered syncing if it has no peer connections, or if maxBlockAge is set and
+// its last block is older than maxBlockAge.
+func (f *nodeFailover) checkHealth(req *http.Request, node string) error {
+	var health api.HealthResponse
+	if err := f.get(req, node, "api/v1/health", &health); err != nil {
+		return fmt.Errorf("health check failed: %v", err)
+	}
+
+	if health.OpenConnections == 0 {
+		return errors.New("node is syncing: no peer connections")
+	}
+
+	if f.maxBlockAge > 0 && health.BlockchainMetadata.TimeSinceLastBlock.Duration > f.maxBlockAge {
+		return fmt.Errorf("node is syncing: last block is %s old", health.BlockchainMetadata.TimeSinceLastBlock.Duration)
+	}
+
+	return nil
+}
+
+// csrfToken obtains a CSRF token from node
+func (f *nodeFailover) csrfToken(req *http.Request, node string) (string, error) {
+	var m map[string]string
+	if err := f.get(req, node, "api/v1/csrf", &m); err != nil {
+		return "", fmt.Errorf("get CSRF token failed: %v", err)
+	}
+
+	token, ok := m["csrf_token"]
+	if !ok {
+		return "", errors.New("csrf_token not found in response")
+	}
+
+	return token, nil
+}
+
+// isConnectError returns true if err happened while connecting to the node,
+// before any part of the request was sent
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/api"
+)
+
+type testNode struct {
+	*httptest.Server
+	name            string
+	healthStatus    int
+	openConnections int
+	lastBlockAge    time.Duration
+	healthChecks    int32
+	requests        int32
+	csrfToken       string
+	// receivedCSRF is the CSRF token of the last POST request received
+	receivedCSRF atomic.Value
+	// dropPost closes the connection when a POST request is received, after reading it
+	dropPost bool
+}
+
+func newTestNode(t *testing.T, name string) *testNode {
+	n := &testNode{
+		name:            name,
+		healthStatus:    http.StatusOK,
+		openConnections: 1,
+		csrfToken:       "csrf-" + name,
+	}
+
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/health":
+			atomic.AddInt32(&n.healthChecks, 1)
+			w.WriteHeader(n.healthStatus)
+			fmt.Fprintf(w, `{"open_connections":%d,"blockchain":{"time_since_last_block":"%s"}}`, n.openConnections, n.lastBlockAge)
+		case "/api/v1/csrf":
+			fmt.Fprintf(w, `{"csrf_token":"%s"}`, n.csrfToken)
+		case "/api/v1/node":
+			atomic.AddInt32(&n.requests, 1)
+			if r.Method == http.MethodPost {
+				n.receivedCSRF.Store(r.Header.Get(csrfHeader))
+				if n.dropPost {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					require.NoError(t, err)
+					conn.Close()
+					return
+				}
+			}
+			fmt.Fprintf(w, `{"node":"%s"}`, n.name)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return n
+}
+
+// deadNodeAddr returns the address of a node that refuses connections
+func deadNodeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return "http://" + addr
+}
+
+func nodeList(addrs ...string) string {
+	return strings.Join(addrs, ",")
+}
+
+type nodeResponse struct {
+	Node string `json:"node"`
+}
+
+func getNode(c *api.Client) (string, error) {
+	var r nodeResponse
+	err := c.Get("/api/v1/node", &r)
+	return r.Node, err
+}
+
+func postNode(c *api.Client) (string, error) {
+	var r nodeResponse
+	err := c.PostJSON("/api/v1/node", struct{}{}, &r)
+	return r.Node, err
+}
+
+func TestParseRPCAddresses(t *testing.T) {
+	addrs, err := parseRPCAddresses("http://127.0.0.1:6420")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://127.0.0.1:6420/"}, addrs)
+
+	addrs, err = parseRPCAddresses(" http://127.0.0.1:6420/, https://node.example.com ,")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://127.0.0.1:6420/", "https://node.example.com/"}, addrs)
+
+	for _, v := range []string{"", ",", "127.0.0.1:6420", "http://127.0.0.1:6420,127.0.0.1:6421"} {
+		_, err := parseRPCAddresses(v)
+		require.Error(t, err, v)
+		require.Equal(t, "RPC_ADDR must be in scheme://host format", err.Error())
+	}
+}
+
+func TestNodeFailoverSingleNode(t *testing.T) {
+	a := newTestNode(t, "a")
+	defer a.Close()
+	a.healthStatus = http.StatusInternalServerError
+
+	c, err := newAPIClient(a.URL, 0)
+	require.NoError(t, err)
+
+	// A single node is used as is, without health checks
+	node, err := getNode(c)
+	require.NoError(t, err)
+	require.Equal(t, "a", node)
+	require.Equal(t, int32(0), atomic.LoadInt32(&a.healthChecks))
+}
+
+func TestNodeFailoverSkipsUnusableNodes(t *testing.T) {
+	unhealthy := newTestNode(t, "unhealthy")
+	defer unhealthy.Close()
+	unhealthy.healthStatus = http.StatusServiceUnavailable
+
+	noPeers := newTestNode(t, "noPeers")
+	defer noPeers.Close()
+	noPeers.openConnections = 0
+
+	stale := newTestNode(t, "stale")
+	defer stale.Close()
+	stale.lastBlockAge = 3 * time.Hour
+
+	good := newTestNode(t, "good")
+	defer good.Close()
+	good.lastBlockAge = time.Minute
+
+	c, err := newAPIClient(nodeList(deadNodeAddr(t), unhealthy.URL, noPeers.URL, stale.URL, good.URL), time.Hour)
+	require.NoError(t, err)
+
+	node, err := getNode(c)
+	require.NoError(t, err)
+	require.Equal(t, "good", node)
+
+	for _, n := range []*testNode{unhealthy, noPeers, stale} {
+		require.Equal(t, int32(1), atomic.LoadInt32(&n.healthChecks), n.name)
+		require.Equal(t, int32(0), atomic.LoadInt32(&n.requests), n.name)
+	}
+
+	// The good node is remembered and not checked again
+	node, err = getNode(c)
+	require.NoError(t, err)
+	require.Equal(t, "good", node)
+	require.Equal(t, int32(1), atomic.LoadInt32(&good.healthChecks))
+	require.Equal(t, int32(2), atomic.LoadInt32(&good.requests))
+	require.Equal(t, good.URL+"/", c.HTTPClient.Transport.(*nodeFailover).Node())
+}
+
+func TestNodeFailoverNoUsableNode(t *testing.T) {
+	unhealthy := newTestNode(t, "unhealthy")
+	defer unhealthy.Close()
+	unhealthy.healthStatus = http.StatusInternalServerError
+
+	c, err := newAPIClient(nodeList(deadNodeAddr(t), unhealthy.URL), 0)
+	require.NoError(t, err)
+
+	_, err = getNode(c)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no usable node")
+	require.Contains(t, err.Error(), unhealthy.URL)
+}
+
+func TestNodeFailoverMutatingRequests(t *testing.T) {
+	t.Run("connection failure is retried", func(t *testing.T) {
+		a := newTestNode(t, "a")
+		b := newTestNode(t, "b")
+		defer b.Close()
+
+		c, err := newAPIClient(nodeList(a.URL, b.URL), 0)
+		require.NoError(t, err)
+
+		node, err := getNode(c)
+		require.NoError(t, err)
+		require.Equal(t, "a", node)
+
+		// The remembered node goes down. The request was never sent to it, so it is sent to
+		// the next node, with a CSRF token from that node
+		a.Close()
+
+		node, err = postNode(c)
+		require.NoError(t, err)
+		require.Equal(t, "b", node)
+		require.Equal(t, "csrf-b", b.receivedCSRF.Load())
+	})
+
+	t.Run("failure after sending is not retried", func(t *testing.T) {
+		a := newTestNode(t, "a")
+		defer a.Close()
+		a.dropPost = true
+		b := newTestNode(t, "b")
+		defer b.Close()
+
+		c, err := newAPIClient(nodeList(a.URL, b.URL), 0)
+		require.NoError(t, err)
+
+		_, err = postNode(c)
+		require.Error(t, err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&a.requests))
+		require.Equal(t, int32(0), atomic.LoadInt32(&b.requests))
+	})
+}
@@ -8,8 +8,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 func walletAddAddressesCmd() *cobra.Command {
@@ -35,7 +35,7 @@ func walletAddAddressesCmd() *cobra.Command {
 	}
 
 	walletAddAddressesCmd.Flags().Uint64P("num", "n", 1, "Number of addresses to generate")
-	walletAddAddressesCmd.Flags().StringP("password", "p", "", "wallet password")
+	addPasswordFlags(walletAddAddressesCmd, "wallet password")
 	walletAddAddressesCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format")
 
 	return walletAddAddressesCmd
@@ -59,7 +59,11 @@ func generateAddrs(c *cobra.Command, args []string) error {
 
 	w := args[0]
 
-	pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+	pr, err := passwordReaderFromFlags(c)
+	if err != nil {
+		return err
+	}
+
 	addrs, err := GenerateAddressesInFile(w, num, pr)
 
 	switch err.(type) {
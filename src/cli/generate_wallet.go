@@ -4,16 +4,18 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
 	secp256k1 "github.com/skycoin/skycoin/src/cipher/secp256k1-go"
-	"github.com/skycoin/skycoin/src/wallet"
 )
 
 const (
@@ -33,14 +35,23 @@ func walletCreateCmd() *cobra.Command {
     from the history log. If you do not include the "-p" option you will
     be prompted to enter your password after you enter your command.
 
-    All results are returned in JSON format in addition to being written to the specified filename.`,
+    Use "--words" to choose the mnemonic length. The entropy size is derived
+    from the word count (12 words = 128 bits, 24 words = 256 bits).
+
+    Use "--entropy-file" to mix additional entropy from a file into the generated
+    mnemonic. The file contents are hashed and XORed with the random entropy;
+    they never replace it.
+
+    All results are returned in JSON format in addition to being written to the specified filename.
+    The strength of a generated seed is printed to stderr.`,
 		SilenceUsage: true,
 		RunE:         generateWalletHandler,
 	}
 
 	walletCreateCmd.Flags().BoolP("random", "r", false, "A random alpha numeric seed will be generated.")
 	walletCreateCmd.Flags().BoolP("mnemonic", "m", false, "A mnemonic seed consisting of 12 dictionary words will be generated")
-	walletCreateCmd.Flags().Uint64P("wordcount", "w", 12, "Number of seed words to use for mnemonic. Must be 12, 15, 18, 21 or 24")
+	walletCreateCmd.Flags().Uint64P("wordcount", "w", 12, "Number of seed words to use for mnemonic. Must be 12, 15, 18, 21 or 24. Alias: --words")
+	walletCreateCmd.Flags().StringP("entropy-file", "", "", "File whose contents are mixed into the generated mnemonic entropy")
 	walletCreateCmd.Flags().StringP("seed", "s", "", "Your seed")
 	walletCreateCmd.Flags().StringP("seed-passphrase", "", "", "Seed passphrase (bip44 wallets only)")
 	walletCreateCmd.Flags().Uint32P("bip44-coin", "", uint32(bip44.CoinTypeSkycoin), "BIP44 coin type")
@@ -50,9 +61,16 @@ func walletCreateCmd() *cobra.Command {
 	walletCreateCmd.Flags().StringP("type", "t", wallet.WalletTypeDeterministic, "Wallet type. Types are \"collection\", \"deterministic\", \"bip44\" or \"xpub\"")
 	walletCreateCmd.Flags().BoolP("encrypt", "e", false, "Create encrypted wallet.")
 	walletCreateCmd.Flags().StringP("crypto-type", "x", string(wallet.DefaultCryptoType), "The crypto type for wallet encryption, can be scrypt-chacha20poly1305 or sha256-xor")
-	walletCreateCmd.Flags().StringP("password", "p", "", "Wallet password")
+	addPasswordFlags(walletCreateCmd, "Wallet password")
 	walletCreateCmd.Flags().StringP("xpub", "", "", "xpub key for \"xpub\" type wallets")
 
+	walletCreateCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "words" {
+			name = "wordcount"
+		}
+		return pflag.NormalizedName(name)
+	})
+
 	return walletCreateCmd
 }
 
@@ -99,6 +117,19 @@ func generateWalletHandler(c *cobra.Command, args []string) error {
 		return errors.New("-m must also be set when using -wordcount")
 	}
 
+	entropyBits, err := wordCountToEntropy(wordCount)
+	if err != nil {
+		return err
+	}
+
+	var extraEntropy []byte
+	if entropyFile := c.Flag("entropy-file").Value.String(); entropyFile != "" {
+		extraEntropy, err = readEntropyFile(entropyFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	encrypt, err := c.Flags().GetBool("encrypt")
 	if err != nil {
 		return err
@@ -137,24 +168,29 @@ func generateWalletHandler(c *cobra.Command, args []string) error {
 		return err
 	}
 
+	if extraEntropy != nil && (s != "" || random) {
+		return errors.New("--entropy-file can only be used when generating a mnemonic")
+	}
+
 	var sd string
+	var seedBits int
 	switch walletType {
 	case wallet.WalletTypeBip44:
 		var err error
-		sd, err = parseBip44WalletSeedOptions(s, random, mnemonic, wordCount)
+		sd, err = parseBip44WalletSeedOptions(s, random, mnemonic, wordCount, extraEntropy)
 		if err != nil {
 			return err
 		}
 
 	case wallet.WalletTypeDeterministic:
 		var err error
-		sd, err = parseDeterministicWalletSeedOptions(s, random, mnemonic, wordCount)
+		sd, err = parseDeterministicWalletSeedOptions(s, random, mnemonic, wordCount, extraEntropy)
 		if err != nil {
 			return err
 		}
 
 	case wallet.WalletTypeCollection:
-		if s != "" || random || mnemonic {
+		if s != "" || random || mnemonic || extraEntropy != nil {
 			return fmt.Errorf("%q type wallets do not use seeds", walletType)
 		}
 		if c.Flags().Changed("num") {
@@ -163,7 +199,7 @@ func generateWalletHandler(c *cobra.Command, args []string) error {
 		num = 0
 
 	case wallet.WalletTypeXPub:
-		if s != "" || random || mnemonic {
+		if s != "" || random || mnemonic || extraEntropy != nil {
 			return fmt.Errorf("%q type wallets do not use seeds", walletType)
 		}
 
@@ -171,6 +207,14 @@ func generateWalletHandler(c *cobra.Command, args []string) error {
 		return fmt.Errorf("unhandled wallet type %q", walletType)
 	}
 
+	if sd != "" && s == "" {
+		if random {
+			seedBits = 256
+		} else {
+			seedBits = entropyBits
+		}
+	}
+
 	seedPassphrase, err := c.Flags().GetString("seed-passphrase")
 	if err != nil {
 		return err
@@ -181,7 +225,11 @@ func generateWalletHandler(c *cobra.Command, args []string) error {
 		return err
 	}
 
-	pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+	pr, err := passwordReaderFromFlags(c)
+	if err != nil {
+		return err
+	}
+
 	switch pr.(type) {
 	case PasswordFromBytes:
 		p, err := pr.Password()
@@ -226,9 +274,25 @@ func generateWalletHandler(c *cobra.Command, args []string) error {
 		return err
 	}
 
+	if seedBits != 0 {
+		fmt.Fprintf(os.Stderr, "Seed strength: %d bits\n", seedBits)
+	}
+
 	return printJSON(wlt.ToReadable())
 }
 
+// readEntropyFile reads user-supplied entropy from a file
+func readEntropyFile(filename string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read entropy file failed: %v", err)
+	}
+	if len(b) == 0 {
+		return nil, errors.New("entropy file is empty")
+	}
+	return b, nil
+}
+
 // wordCountToEntropy maps a mnemonic word count to its entropy size in bits
 func wordCountToEntropy(wc uint64) (int, error) {
 	switch wc {
@@ -247,7 +311,22 @@ func wordCountToEntropy(wc uint64) (int, error) {
 	}
 }
 
-func newMnemomic(wc uint64) (string, error) {
+// mixEntropy XORs the SHA256 hash of extra into e. The random entropy is never
+// replaced, so the result is at least as strong as e alone.
+func mixEntropy(e, extra []byte) []byte {
+	if len(extra) == 0 {
+		return e
+	}
+
+	h := cipher.SumSHA256(extra)
+	mixed := make([]byte, len(e))
+	for i := range e {
+		mixed[i] = e[i] ^ h[i%len(h)]
+	}
+	return mixed
+}
+
+func newMnemomic(wc uint64, extraEntropy []byte) (string, error) {
 	entropySize, err := wordCountToEntropy(wc)
 	if err != nil {
 		return "", err
@@ -256,10 +335,10 @@ func newMnemomic(wc uint64) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return bip39.NewMnemonic(e)
+	return bip39.NewMnemonic(mixEntropy(e, extraEntropy))
 }
 
-func parseBip44WalletSeedOptions(s string, r, m bool, wc uint64) (string, error) {
+func parseBip44WalletSeedOptions(s string, r, m bool, wc uint64, extraEntropy []byte) (string, error) {
 	if s != "" && (r || m) {
 		return "", errors.New("-r and -m can't be used with -s")
 	}
@@ -270,7 +349,7 @@ func parseBip44WalletSeedOptions(s string, r, m bool, wc uint64) (string, error)
 
 	if m || s == "" {
 		var err error
-		s, err = newMnemomic(wc)
+		s, err = newMnemomic(wc, extraEntropy)
 		if err != nil {
 			return "", err
 		}
@@ -283,7 +362,7 @@ func parseBip44WalletSeedOptions(s string, r, m bool, wc uint64) (string, error)
 	return s, nil
 }
 
-func parseDeterministicWalletSeedOptions(s string, r, m bool, wc uint64) (string, error) {
+func parseDeterministicWalletSeedOptions(s string, r, m bool, wc uint64, extraEntropy []byte) (string, error) {
 	if s != "" {
 		// 111, 101, 110
 		if r || m {
@@ -304,7 +383,7 @@ func parseDeterministicWalletSeedOptions(s string, r, m bool, wc uint64) (string
 	}
 
 	// 001, 000
-	return newMnemomic(wc)
+	return newMnemomic(wc, extraEntropy)
 }
 
 // PUBLIC
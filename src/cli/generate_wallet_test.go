@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+)
+
+func TestWordCountToEntropy(t *testing.T) {
+	cases := map[uint64]int{
+		12: 128,
+		15: 160,
+		18: 192,
+		21: 224,
+		24: 256,
+	}
+	for wc, bits := range cases {
+		n, err := wordCountToEntropy(wc)
+		require.NoError(t, err)
+		require.Equal(t, bits, n)
+
+		m, err := newMnemomic(wc, nil)
+		require.NoError(t, err)
+		require.Len(t, strings.Fields(m), int(wc))
+	}
+
+	for _, wc := range []uint64{0, 1, 11, 13, 16, 25, 48} {
+		_, err := wordCountToEntropy(wc)
+		require.Error(t, err)
+
+		_, err = newMnemomic(wc, nil)
+		require.Error(t, err)
+	}
+}
+
+func TestMixEntropy(t *testing.T) {
+	e := bytes.Repeat([]byte{0xAA}, 32)
+
+	// No extra entropy leaves the input unchanged
+	require.Equal(t, e, mixEntropy(e, nil))
+
+	mixed := mixEntropy(e, []byte("dice rolls"))
+	require.Len(t, mixed, len(e))
+	require.NotEqual(t, e, mixed)
+	// The input slice must not be modified
+	require.Equal(t, bytes.Repeat([]byte{0xAA}, 32), e)
+
+	// Mixing is deterministic for the same inputs
+	require.Equal(t, mixed, mixEntropy(e, []byte("dice rolls")))
+
+	// Shorter entropy is mixed with a truncated hash
+	short := mixEntropy(e[:16], []byte("dice rolls"))
+	require.Equal(t, mixed[:16], short)
+
+	// Extra entropy never replaces the random entropy
+	m1, err := newMnemomic(24, []byte("dice rolls"))
+	require.NoError(t, err)
+	m2, err := newMnemomic(24, []byte("dice rolls"))
+	require.NoError(t, err)
+	require.NotEqual(t, m1, m2)
+}
+
+func TestReadEntropyFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "entropy")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	require.NoError(t, f.Close())
+
+	_, err = readEntropyFile(f.Name())
+	require.EqualError(t, err, "entropy file is empty")
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("123456"), 0600))
+	b, err := readEntropyFile(f.Name())
+	require.NoError(t, err)
+	require.Equal(t, []byte("123456"), b)
+
+	_, err = readEntropyFile(f.Name() + ".missing")
+	require.Error(t, err)
+}
+
+func TestMnemonic24WordsRecover(t *testing.T) {
+	seed, err := parseBip44WalletSeedOptions("", false, true, 24, []byte("extra entropy"))
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(seed), 24)
+
+	e, err := bip39.EntropyFromMnemonic(seed)
+	require.NoError(t, err)
+	require.Len(t, e, 32)
+
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := wallet.NewService(wallet.Config{
+		WalletDir:       dir,
+		CryptoType:      wallet.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err := s.CreateWallet("t.wlt", wallet.Options{
+		Type:       wallet.WalletTypeBip44,
+		Seed:       seed,
+		Label:      "t",
+		GenerateN:  3,
+		Encrypt:    true,
+		Password:   []byte("pwd"),
+		CryptoType: wallet.CryptoTypeSha256Xor,
+	}, nil)
+	require.NoError(t, err)
+
+	w2, err := s.RecoverWallet("t.wlt", seed, "", nil)
+	require.NoError(t, err)
+	require.False(t, w2.IsEncrypted())
+	require.Equal(t, w.Fingerprint(), w2.Fingerprint())
+	require.Equal(t, w.GetAddresses(), w2.GetAddresses())
+}
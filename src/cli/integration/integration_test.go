@@ -1369,7 +1369,7 @@ func TestStableWalletBalance(t *testing.T) {
 	fn, clean := createUnencryptedWallet(t)
 	defer clean()
 
-	output, err := execCommandCombinedOutput("walletBalance", fn)
+	output, err := execCommandCombinedOutput("walletBalance", fn, "-j")
 	require.NoError(t, err, output)
 
 	var wltBalance cli.BalanceResult
@@ -1387,7 +1387,7 @@ func TestLiveWalletBalance(t *testing.T) {
 
 	fn := requireWalletEnv(t)
 
-	output, err := execCommandCombinedOutput("walletBalance", fn)
+	output, err := execCommandCombinedOutput("walletBalance", fn, "-j")
 	require.NoError(t, err)
 
 	var wltBalance cli.BalanceResult
@@ -2573,7 +2573,7 @@ func TestLiveCreateAndBroadcastRawTransaction(t *testing.T) {
 			}
 
 			// Broadcast transaction
-			output, err = execCommandCombinedOutput("broadcastTransaction", string(output))
+			output, err = execCommandCombinedOutput("broadcastTransaction", "--yes", string(output))
 			require.NoError(t, err, string(output))
 
 			txid := string(bytes.TrimRight(output, "\n"))
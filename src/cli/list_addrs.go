@@ -5,7 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func listAddressesCmd() *cobra.Command {
@@ -26,13 +26,35 @@ func listAddresses(_ *cobra.Command, args []string) error {
 	}
 
 	addrs := wlt.GetAddresses()
+	paths := wallet.EntryPaths(wlt)
+
+	entries := make([]AddressEntry, len(addrs))
+	for i, a := range addrs {
+		entries[i] = AddressEntry{
+			Address: a.String(),
+			Path:    paths[i],
+		}
+	}
 
-	s, err := FormatAddressesAsJSON(addrs)
+	d, err := formatJSON(struct {
+		Addresses []string       `json:"addresses"`
+		Entries   []AddressEntry `json:"entries"`
+	}{
+		Addresses: AddressesToStrings(addrs),
+		Entries:   entries,
+	})
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(s)
+	fmt.Println(string(d))
 
 	return nil
 }
+
+// AddressEntry is an address of a wallet listed by listAddresses
+type AddressEntry struct {
+	Address string `json:"address"`
+	// Path is the bip44 derivation path of the address, or its generation index for deterministic wallets
+	Path string `json:"path,omitempty"`
+}
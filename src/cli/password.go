@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// WalletPasswordEnvVar is the environment variable a wallet password is read from,
+// if no password option is given
+const WalletPasswordEnvVar = "WALLET_PASSWORD"
+
+var (
+	// ErrMultiplePasswordSources is returned if more than one password option is given
+	ErrMultiplePasswordSources = errors.New("only one of --password, --password-stdin and --password-file can be used")
+
+	// passwordStdin is where --password-stdin reads the password from
+	passwordStdin io.Reader = os.Stdin
+)
+
+// PasswordFromEnv is a password read from the WALLET_PASSWORD environment variable.
+// Unlike PasswordFromBytes, it is not an error to have it set for a wallet that is not encrypted,
+// the environment applies to every command, so it is only read if a password is needed.
+type PasswordFromEnv []byte
+
+// Password implements the PasswordReader's Password method
+func (p PasswordFromEnv) Password() ([]byte, error) {
+	return []byte(p), nil
+}
+
+// PasswordOptions are the sources of a wallet password given on the command line
+type PasswordOptions struct {
+	// Password is the value of --password
+	Password []byte
+	// Stdin reads the password from stdin, until EOF
+	Stdin bool
+	// File is a file to read the password from, which must not be readable by all users
+	File string
+}
+
+// NewPasswordReaderFromOptions creates a PasswordReader from the password options.
+// The sources, in order of precedence, are:
+//  1. --password, --password-stdin or --password-file, only one of which can be given
+//  2. the WALLET_PASSWORD environment variable
+//  3. an interactive prompt on the terminal
+// The password read from stdin or a file has its trailing newline removed.
+func NewPasswordReaderFromOptions(opts PasswordOptions) (PasswordReader, error) {
+	n := 0
+	if len(opts.Password) != 0 {
+		n++
+	}
+	if opts.Stdin {
+		n++
+	}
+	if opts.File != "" {
+		n++
+	}
+	if n > 1 {
+		return nil, ErrMultiplePasswordSources
+	}
+
+	switch {
+	case opts.Stdin:
+		p, err := ioutil.ReadAll(passwordStdin)
+		if err != nil {
+			return nil, fmt.Errorf("read password from stdin failed: %v", err)
+		}
+		return PasswordFromBytes(trimTrailingNewline(p)), nil
+	case opts.File != "":
+		p, err := readPasswordFile(opts.File)
+		if err != nil {
+			return nil, err
+		}
+		return PasswordFromBytes(p), nil
+	default:
+		return NewPasswordReader(opts.Password), nil
+	}
+}
+
+// readPasswordFile reads a password from a file, refusing files that can be read by all users
+func readPasswordFile(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Windows does not have unix permission bits, os.FileInfo reports all files as readable by all users
+	if runtime.GOOS != "windows" {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+
+		if fi.Mode().Perm()&0004 != 0 {
+			return nil, fmt.Errorf("password file %s is readable by all users, restrict its permissions, e.g. chmod 600", filename)
+		}
+	}
+
+	p, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return trimTrailingNewline(p), nil
+}
+
+// trimTrailingNewline removes one trailing "\n" or "\r\n"
+func trimTrailingNewline(p []byte) []byte {
+	p = bytes.TrimSuffix(p, []byte("\n"))
+	return bytes.TrimSuffix(p, []byte("\r"))
+}
+
+// addPasswordFlags adds the wallet password options -p/--password, --password-stdin and --password-file to a command
+func addPasswordFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringP("password", "p", "", usage+". Visible in process listings, prefer --password-stdin, --password-file or "+WalletPasswordEnvVar)
+	cmd.Flags().Bool("password-stdin", false, "Read the wallet password from stdin")
+	cmd.Flags().String("password-file", "", "Read the wallet password from a file, which must not be readable by all users")
+}
+
+// passwordReaderFromFlags creates a PasswordReader from the options added by addPasswordFlags
+func passwordReaderFromFlags(c *cobra.Command) (PasswordReader, error) {
+	password, err := c.Flags().GetString("password")
+	if err != nil {
+		return nil, err
+	}
+
+	stdin, err := c.Flags().GetBool("password-stdin")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := c.Flags().GetString("password-file")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPasswordReaderFromOptions(PasswordOptions{
+		Password: []byte(password),
+		Stdin:    stdin,
+		File:     file,
+	})
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPasswordReaderFromOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "password")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, ioutil.WriteFile(passwordFile, []byte("filepwd\r\n"), 0600))
+
+	worldReadableFile := filepath.Join(dir, "world-readable")
+	require.NoError(t, ioutil.WriteFile(worldReadableFile, []byte("filepwd"), 0644))
+	require.NoError(t, os.Chmod(worldReadableFile, 0644))
+
+	cases := []struct {
+		name     string
+		opts     PasswordOptions
+		stdin    string
+		env      string
+		expect   PasswordReader
+		err      error
+		errMatch string
+	}{
+		{
+			name:   "password",
+			opts:   PasswordOptions{Password: []byte("pwd")},
+			env:    "envpwd",
+			expect: PasswordFromBytes("pwd"),
+		},
+		{
+			name:   "stdin",
+			opts:   PasswordOptions{Stdin: true},
+			stdin:  "stdin pwd\n",
+			env:    "envpwd",
+			expect: PasswordFromBytes("stdin pwd"),
+		},
+		{
+			name:   "stdin only one trailing newline is removed",
+			opts:   PasswordOptions{Stdin: true},
+			stdin:  "pwd\n\n",
+			expect: PasswordFromBytes("pwd\n"),
+		},
+		{
+			name:   "file",
+			opts:   PasswordOptions{File: passwordFile},
+			env:    "envpwd",
+			expect: PasswordFromBytes("filepwd"),
+		},
+		{
+			name:     "file does not exist",
+			opts:     PasswordOptions{File: filepath.Join(dir, "foo")},
+			errMatch: "no such file or directory",
+		},
+		{
+			name:   "env",
+			env:    "envpwd",
+			expect: PasswordFromEnv("envpwd"),
+		},
+		{
+			name:   "terminal",
+			expect: PasswordFromTerm{},
+		},
+		{
+			name: "password and stdin",
+			opts: PasswordOptions{Password: []byte("pwd"), Stdin: true},
+			err:  ErrMultiplePasswordSources,
+		},
+		{
+			name: "password and file",
+			opts: PasswordOptions{Password: []byte("pwd"), File: passwordFile},
+			err:  ErrMultiplePasswordSources,
+		},
+		{
+			name: "stdin and file",
+			opts: PasswordOptions{Stdin: true, File: passwordFile},
+			err:  ErrMultiplePasswordSources,
+		},
+	}
+
+	if runtime.GOOS != "windows" {
+		cases = append(cases, struct {
+			name     string
+			opts     PasswordOptions
+			stdin    string
+			env      string
+			expect   PasswordReader
+			err      error
+			errMatch string
+		}{
+			name:     "file is readable by all users",
+			opts:     PasswordOptions{File: worldReadableFile},
+			errMatch: "is readable by all users",
+		})
+	}
+
+	defer func(r *os.File) {
+		passwordStdin = r
+	}(os.Stdin)
+	defer os.Unsetenv(WalletPasswordEnvVar)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			passwordStdin = strings.NewReader(tc.stdin)
+			if tc.env != "" {
+				require.NoError(t, os.Setenv(WalletPasswordEnvVar, tc.env))
+			} else {
+				require.NoError(t, os.Unsetenv(WalletPasswordEnvVar))
+			}
+
+			pr, err := NewPasswordReaderFromOptions(tc.opts)
+			switch {
+			case tc.err != nil:
+				require.Equal(t, tc.err, err)
+				return
+			case tc.errMatch != "":
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errMatch)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, pr)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/daemon/pex"
+)
+
+func peersExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "Export a bundle of known-good peers",
+		Use:   "peersExport",
+		Long: `Export a bundle of known-good peers from the node, to bootstrap another node with peersImport.
+    Private peers and peers cooling off after their score dropped are not exported.
+
+    With --sign, the bundle is signed with the node key.`,
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  peersExportHandler,
+	}
+
+	cmd.Flags().Int("min-score", 0, "Minimum quality score of the peers")
+	cmd.Flags().Duration("max-age", 0, "Exclude peers last seen longer ago than this duration, e.g. 24h. 0 for no limit")
+	cmd.Flags().Int("limit", 0, "Maximum number of peers, highest score first. 0 for no limit")
+	cmd.Flags().Bool("sign", false, "Sign the bundle with the node key")
+	cmd.Flags().StringP("output", "o", "", "Write the bundle to this file instead of stdout")
+
+	return cmd
+}
+
+func peersExportHandler(c *cobra.Command, _ []string) error {
+	minScore, err := c.Flags().GetInt("min-score")
+	if err != nil {
+		return err
+	}
+
+	maxAge, err := c.Flags().GetDuration("max-age")
+	if err != nil {
+		return err
+	}
+	if maxAge < 0 {
+		return errors.New("invalid --max-age value")
+	}
+
+	limit, err := c.Flags().GetInt("limit")
+	if err != nil {
+		return err
+	}
+	if limit < 0 {
+		return errors.New("invalid --limit value")
+	}
+
+	sign, err := c.Flags().GetBool("sign")
+	if err != nil {
+		return err
+	}
+
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	v := url.Values{}
+	v.Add("min_score", fmt.Sprint(minScore))
+	if maxAge > 0 {
+		v.Add("max_age", maxAge.String())
+	}
+	if limit > 0 {
+		v.Add("limit", fmt.Sprint(limit))
+	}
+	v.Add("sign", fmt.Sprint(sign))
+
+	var b pex.PeerBundle
+	if err := apiClient.Get("/api/v1/network/peers/export?"+v.Encode(), &b); err != nil {
+		return err
+	}
+
+	if output == "" {
+		return printJSON(b)
+	}
+
+	d, err := formatJSON(b)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(output, append(d, '\n'), 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d peers to %s\n", len(b.Peers), output)
+	return nil
+}
+
+func peersImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Import a bundle of peers",
+		Use:   "peersImport [file path or -]",
+		Long: `Import a peer bundle exported by peersExport into the node's peer list.
+    If the node is started with -trusted-peer-bundle-keys, the bundle must be signed by one of those keys.
+
+    Peers that are invalid, already known or cooling off are skipped,
+    as are peers that don't fit in the peer list.`,
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  peersImportHandler,
+	}
+}
+
+func peersImportHandler(_ *cobra.Command, args []string) error {
+	b, err := readPeerBundle(args[0])
+	if err != nil {
+		return err
+	}
+
+	var rsp json.RawMessage
+	if err := apiClient.PostJSON("/api/v1/network/peers/import", b, &rsp); err != nil {
+		return err
+	}
+
+	return printJSON(rsp)
+}
+
+// readPeerBundle reads a peer bundle from a file, or from stdin if the path is "-"
+func readPeerBundle(path string) (*pex.PeerBundle, error) {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		f, err = os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open file failed %s: %v", path, err)
+		}
+		defer f.Close()
+	}
+
+	var b pex.PeerBundle
+	if err := json.NewDecoder(f).Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid peer bundle: %v", err)
+	}
+
+	if b.Version != pex.PeerBundleVersion {
+		return nil, pex.ErrPeerBundleVersion
+	}
+
+	return &b, nil
+}
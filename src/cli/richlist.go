@@ -1,58 +1,212 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"os"
 	"strconv"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/droplet"
 )
 
+// Distribution address states of a richlist entry
+const (
+	distributionLocked   = "locked"
+	distributionUnlocked = "unlocked"
+)
+
+// RichlistEntry is a richlist balance annotated with its distribution address state and share of the supply
+type RichlistEntry struct {
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Locked  bool   `json:"locked"`
+	// Distribution is "locked" or "unlocked" for the distribution addresses, empty for the others
+	Distribution string `json:"distribution,omitempty"`
+	// SupplyPercent is the percentage of the circulating supply, with 2 decimal places
+	SupplyPercent string `json:"supply_percent"`
+}
+
+// RichlistResult is the output of the richlist command
+type RichlistResult struct {
+	// CurrentSupply is the circulating supply, the coins distributed beyond the distribution addresses
+	CurrentSupply string          `json:"current_supply"`
+	Richlist      []RichlistEntry `json:"richlist"`
+}
+
 func richlistCmd() *cobra.Command {
-	return &cobra.Command{
-		Short:                 "Get skycoin richlist",
-		Long:                  "Returns top N address (default 20) balances (based on unspent outputs). Optionally include distribution addresses (exluded by default).",
-		Use:                   "richlist [top N addresses (20 default)] [include distribution addresses (false default)]",
-		Args:                  cobra.MaximumNArgs(2),
-		DisableFlagsInUseLine: true,
-		SilenceUsage:          true,
-		RunE:                  getRichlist,
+	richlistCmd := &cobra.Command{
+		Short: "Get skycoin richlist",
+		Long: `Returns the top N address (default 20) balances (based on unspent outputs).
+    Optionally include distribution addresses (excluded by default).
+
+    Distribution addresses are annotated as locked or unlocked, and each address
+    has its percentage of the circulating supply, as returned by /api/v1/coinSupply.
+
+    The positional arguments [top N addresses] [include distribution addresses]
+    are deprecated, use -n and --include-distribution instead.`,
+		Use:          "richlist",
+		Args:         cobra.MaximumNArgs(2),
+		SilenceUsage: true,
+		RunE:         getRichlist,
 	}
-}
 
-func getRichlist(_ *cobra.Command, args []string) error {
-	// default values
-	num := "20"
-	dist := "false"
+	richlistCmd.Flags().IntP("num", "n", 20, "Number of addresses to return, 0 for all addresses")
+	richlistCmd.Flags().Bool("include-distribution", false, "Include the distribution addresses")
+	richlistCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return richlistCmd
+}
 
-	switch len(args) {
-	case 1:
-		num = args[0]
-	case 2:
-		num = args[0]
-		dist = args[1]
+func getRichlist(c *cobra.Command, args []string) error {
+	n, err := c.Flags().GetInt("num")
+	if err != nil {
+		return err
 	}
 
-	n, err := strconv.Atoi(num)
+	includeDist, err := c.Flags().GetBool("include-distribution")
 	if err != nil {
-		return fmt.Errorf("invalid number of addresses, %s", err)
+		return err
 	}
 
-	d, err := strconv.ParseBool(dist)
+	jsonOutput, err := c.Flags().GetBool("json")
 	if err != nil {
-		return fmt.Errorf("invalid (bool) flag for include distribution addresses, %s", err)
+		return err
 	}
 
-	params := &api.RichlistParams{
+	if len(args) > 0 {
+		n, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of addresses, %s", err)
+		}
+	}
+	if len(args) > 1 {
+		includeDist, err = strconv.ParseBool(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid (bool) flag for include distribution addresses, %s", err)
+		}
+	}
+
+	if n < 0 {
+		return errors.New("invalid number of addresses, must be >= 0")
+	}
+
+	richlist, err := apiClient.Richlist(&api.RichlistParams{
 		N:                   n,
-		IncludeDistribution: d,
+		IncludeDistribution: includeDist,
+	})
+	if err != nil {
+		return err
+	}
+
+	supply, err := apiClient.CoinSupply()
+	if err != nil {
+		return err
+	}
+
+	r, err := newRichlistResult(richlist, supply, params.MainNetDistribution)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(r)
+	}
+
+	return writeRichlist(os.Stdout, r, params.UserVerifyTxn.MaxDropletPrecision)
+}
+
+// newRichlistResult annotates the richlist with the distribution address state of the entries
+// and their percentage of the circulating supply
+func newRichlistResult(richlist *api.Richlist, supply *api.CoinSupply, dist params.Distribution) (*RichlistResult, error) {
+	currentSupply, err := droplet.FromString(supply.CurrentSupply)
+	if err != nil {
+		return nil, fmt.Errorf("invalid current supply %q: %v", supply.CurrentSupply, err)
+	}
+
+	distribution := make(map[string]string, len(dist.Addresses))
+	for _, a := range dist.UnlockedAddresses() {
+		distribution[a] = distributionUnlocked
+	}
+	for _, a := range dist.LockedAddresses() {
+		distribution[a] = distributionLocked
+	}
+
+	r := &RichlistResult{
+		CurrentSupply: supply.CurrentSupply,
+		Richlist:      make([]RichlistEntry, len(richlist.Richlist)),
+	}
+
+	for i, b := range richlist.Richlist {
+		coins, err := droplet.FromString(b.Coins)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coins %q of address %s: %v", b.Coins, b.Address, err)
+		}
+
+		r.Richlist[i] = RichlistEntry{
+			Address:       b.Address,
+			Coins:         b.Coins,
+			Locked:        b.Locked,
+			Distribution:  distribution[b.Address],
+			SupplyPercent: supplyPercent(coins, currentSupply),
+		}
+	}
+
+	return r, nil
+}
+
+// supplyPercent returns coins as a percentage of supply, rounded to 2 decimal places.
+// The ratio is exact, so that the percentages don't drift with float rounding.
+// It is empty if the supply is zero.
+func supplyPercent(coins, supply uint64) string {
+	if supply == 0 {
+		return ""
+	}
+
+	p := new(big.Rat).SetFrac(new(big.Int).SetUint64(coins), new(big.Int).SetUint64(supply))
+	p.Mul(p, big.NewRat(100, 1))
+	return p.FloatString(2)
+}
+
+// writeRichlist writes the richlist as a text table, with coins formatted with at least decimals decimal places
+func writeRichlist(out io.Writer, r *RichlistResult, decimals uint8) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "RANK\tADDRESS\tCOINS\tSUPPLY %\tDISTRIBUTION")
+	for i, e := range r.Richlist {
+		coins, err := formatCoins(e.Coins, decimals)
+		if err != nil {
+			return err
+		}
+
+		percent := "-"
+		if e.SupplyPercent != "" {
+			percent = e.SupplyPercent + "%"
+		}
+
+		distribution := "-"
+		if e.Distribution != "" {
+			distribution = e.Distribution
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, e.Address, coins, percent, distribution)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
 	}
 
-	richlist, err := apiClient.Richlist(params)
+	currentSupply, err := formatCoins(r.CurrentSupply, decimals)
 	if err != nil {
 		return err
 	}
 
-	return printJSON(richlist)
+	_, err = fmt.Fprintf(out, "\nCirculating supply: %s\n", currentSupply)
+	return err
 }
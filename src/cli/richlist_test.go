@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/readable"
+)
+
+func TestSupplyPercent(t *testing.T) {
+	cases := []struct {
+		coins, supply uint64
+		expect        string
+	}{
+		{0, 100, "0.00"},
+		{1, 3, "33.33"},
+		{2, 3, "66.67"},
+		{1, 8, "12.50"},
+		{100, 100, "100.00"},
+		{250, 100, "250.00"},
+		// One droplet of the max uint64 supply
+		{1, 18446744073709551615, "0.00"},
+		// Exact at a magnitude where float64 loses droplets
+		{9007199254740993, 18014398509481986, "50.00"},
+		{1, 0, ""},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.expect, supplyPercent(tc.coins, tc.supply), "supplyPercent(%d, %d)", tc.coins, tc.supply)
+	}
+}
+
+func TestRichlist(t *testing.T) {
+	addrs := []string{
+		"2iNNt6fm9LszSWe51693BeyNUKX34pPaLx8",
+		"2fGi2jhvp6ppHg3DecguZgzqvpJj2Gd4KHW",
+		"2jNwfvZNUoRLiFzJtmnevSF6TKPfSehvrc1",
+		"2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv",
+	}
+
+	dist := params.Distribution{
+		MaxCoinSupply:        3e6,
+		InitialUnlockedCount: 1,
+		Addresses:            addrs[:3],
+	}
+
+	richlist := &api.Richlist{
+		Richlist: []readable.RichlistBalance{
+			{Address: addrs[1], Coins: "1000000.000000", Locked: true},
+			{Address: addrs[0], Coins: "700000.000000"},
+			{Address: addrs[3], Coins: "100000.000001"},
+		},
+	}
+
+	supply := &api.CoinSupply{
+		CurrentSupply: "300000.000000",
+	}
+
+	r, err := newRichlistResult(richlist, supply, dist)
+	require.NoError(t, err)
+	require.Equal(t, &RichlistResult{
+		CurrentSupply: "300000.000000",
+		Richlist: []RichlistEntry{
+			{Address: addrs[1], Coins: "1000000.000000", Locked: true, Distribution: "locked", SupplyPercent: "333.33"},
+			{Address: addrs[0], Coins: "700000.000000", Distribution: "unlocked", SupplyPercent: "233.33"},
+			{Address: addrs[3], Coins: "100000.000001", SupplyPercent: "33.33"},
+		},
+	}, r)
+
+	var buf bytes.Buffer
+	err = writeRichlist(&buf, r, 3)
+	require.NoError(t, err)
+	require.Equal(t, `RANK  ADDRESS                              COINS          SUPPLY %  DISTRIBUTION
+1     2fGi2jhvp6ppHg3DecguZgzqvpJj2Gd4KHW  1000000.000    333.33%   locked
+2     2iNNt6fm9LszSWe51693BeyNUKX34pPaLx8  700000.000     233.33%   unlocked
+3     2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv  100000.000001  33.33%    -
+
+Circulating supply: 300000.000
+`, buf.String())
+
+	_, err = newRichlistResult(richlist, &api.CoinSupply{CurrentSupply: "x"}, dist)
+	require.Error(t, err)
+
+	richlist.Richlist[0].Coins = "1.0000001"
+	_, err = newRichlistResult(richlist, supply, dist)
+	require.Error(t, err)
+}
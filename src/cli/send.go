@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +21,12 @@ func sendCmd() *cobra.Command {
 
     If you are sending from a wallet without specifying an address,
     the transaction will use one or more of the addresses within the wallet.
+    Use --from-label to restrict spending to the addresses with a given label.
+
+    Before the transaction is signed, its inputs, outputs, fee and the percentage
+    of the input hours it burns are printed to stderr, and sending must be confirmed
+    by typing "yes", unless --yes is set. Outputs to addresses that are neither in
+    the wallet nor destinations are flagged. --yes is required with --password-stdin.
 
     Use caution when using the “-p” command. If you have command history enabled
     your wallet encryption password can be recovered from the history log.
@@ -26,15 +34,33 @@ func sendCmd() *cobra.Command {
     after you enter your command.`,
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
-			rawTxn, err := createRawTxnCmdHandler(c, args)
+			yes, err := c.Flags().GetBool("yes")
+			if err != nil {
+				return err
+			}
+
+			// The password is read from stdin, which can't be used to confirm the review afterwards
+			passwordStdin, err := c.Flags().GetBool("password-stdin")
 			if err != nil {
+				return err
+			}
+			if passwordStdin && !yes {
+				return errors.New("--yes is required with --password-stdin")
+			}
+
+			rawTxn, err := createRawTxnCmdHandler(c, args, reviewTxnInteractively(os.Stdin, os.Stderr, yes))
+			switch err {
+			case nil:
+			case ErrSendCancelled:
+				return err
+			default:
 				printHelp(c)
 				return err
 			}
 
 			txid, err := apiClient.InjectTransaction(rawTxn)
 			if err != nil {
-				return err
+				return injectTransactionError(err)
 			}
 
 			jsonOutput, err := c.Flags().GetBool("json")
@@ -55,13 +81,16 @@ func sendCmd() *cobra.Command {
 	}
 
 	sendCmd.Flags().StringP("from-address", "a", "", "From address in wallet")
+	sendCmd.Flags().StringSlice("from-label", nil, "Spend from the wallet addresses with any of these labels, combined with --from-address")
 	sendCmd.Flags().StringP("change-address", "c", "", `Specify the change address.
-Defaults to one of the spending addresses (deterministic wallets) or to a new change address (bip44 wallets).`)
+Defaults to one of the spending addresses (deterministic wallets and bip44 wallets that reuse change addresses)
+or to a new change chain address (bip44 wallets).`)
 	sendCmd.Flags().StringP("many", "m", "", `use JSON string to set multiple receive addresses and coins,
 example: -m '[{"addr":"$addr1", "coins": "10.2"}, {"addr":"$addr2", "coins": "20"}]'`)
-	sendCmd.Flags().StringP("password", "p", "", "Wallet password")
+	addPasswordFlags(sendCmd, "Wallet password")
 	sendCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 	sendCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
+	sendCmd.Flags().BoolP("yes", "y", false, "Sign and send without asking for confirmation, the review is still printed")
 
 	return sendCmd
 }
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/transaction"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+)
+
+// ErrSendCancelled is returned if the review of a transaction is not confirmed
+var ErrSendCancelled = errors.New("send cancelled, the transaction was not signed")
+
+// TxnReviewer reviews a transaction before it is signed. The transaction is not signed if it returns an error.
+type TxnReviewer func(review *TxnReview) error
+
+// TxnReview is the review of an unsigned transaction, made from the transaction that is then signed
+type TxnReview struct {
+	Inputs      []TxnReviewInput
+	Outputs     []TxnReviewOutput
+	InputHours  uint64
+	OutputHours uint64
+	// FeeHours are the hours burned by the transaction
+	FeeHours uint64
+}
+
+// TxnReviewInput is an output spent by the transaction
+type TxnReviewInput struct {
+	Hash    cipher.SHA256
+	Address cipher.Address
+	Coins   uint64
+	// Hours are the hours of the output at the head block time
+	Hours uint64
+}
+
+// TxnReviewOutput is an output created by the transaction
+type TxnReviewOutput struct {
+	Address cipher.Address
+	Coins   uint64
+	Hours   uint64
+	// Change is true if the output is the change of the transaction
+	Change bool
+	// Unexpected is true if the address is neither in the wallet nor a destination,
+	// which would be a bug misrouting the change
+	Unexpected bool
+}
+
+// BurnPercent returns the percentage of the input hours burned as fee
+func (r *TxnReview) BurnPercent() float64 {
+	if r.InputHours == 0 {
+		return 0
+	}
+	return float64(r.FeeHours) * 100 / float64(r.InputHours)
+}
+
+// HasUnexpectedOutputs returns true if an output goes to an address that is neither in the wallet nor a destination
+func (r *TxnReview) HasUnexpectedOutputs() bool {
+	for _, o := range r.Outputs {
+		if o.Unexpected {
+			return true
+		}
+	}
+	return false
+}
+
+// newTxnReview makes the review of the unsigned transaction txn, spending spends to the destinations toAddrs
+// and the change address chgAddr of wallet wlt
+func newTxnReview(txn *coin.Transaction, spends []transaction.UxBalance, wlt wallet.Wallet, chgAddr string, toAddrs []SendAmount) (*TxnReview, error) {
+	var r TxnReview
+
+	spent := make(map[cipher.SHA256]transaction.UxBalance, len(spends))
+	for _, s := range spends {
+		spent[s.Hash] = s
+	}
+
+	for _, h := range txn.In {
+		s, ok := spent[h]
+		if !ok {
+			return nil, fmt.Errorf("transaction input %s is not a chosen unspent output", h.Hex())
+		}
+
+		r.Inputs = append(r.Inputs, TxnReviewInput{
+			Hash:    h,
+			Address: s.Address,
+			Coins:   s.Coins,
+			Hours:   s.Hours,
+		})
+
+		var err error
+		r.InputHours, err = mathutil.AddUint64(r.InputHours, s.Hours)
+		if err != nil {
+			return nil, errors.New("input hours overflow")
+		}
+	}
+
+	destinations := make(map[string]struct{}, len(toAddrs))
+	for _, to := range toAddrs {
+		destinations[to.Addr] = struct{}{}
+	}
+
+	for i, o := range txn.Out {
+		addr := o.Address.String()
+		_, isDestination := destinations[addr]
+		_, inWallet := wlt.GetEntry(o.Address)
+
+		// The destination outputs come first, in the order of toAddrs, followed by the change output
+		isDestinationOutput := i < len(toAddrs) && toAddrs[i].Addr == addr && toAddrs[i].Coins == o.Coins
+
+		r.Outputs = append(r.Outputs, TxnReviewOutput{
+			Address:    o.Address,
+			Coins:      o.Coins,
+			Hours:      o.Hours,
+			Change:     !isDestinationOutput && addr == chgAddr,
+			Unexpected: !inWallet && !isDestination,
+		})
+
+		var err error
+		r.OutputHours, err = mathutil.AddUint64(r.OutputHours, o.Hours)
+		if err != nil {
+			return nil, errors.New("output hours overflow")
+		}
+	}
+
+	if r.OutputHours > r.InputHours {
+		return nil, fmt.Errorf("transaction outputs have %d hours, more than the %d hours of its inputs", r.OutputHours, r.InputHours)
+	}
+	r.FeeHours = r.InputHours - r.OutputHours
+
+	return &r, nil
+}
+
+// printTxnReview prints the inputs and outputs of a transaction review, flagging the change and unexpected outputs,
+// with the fee and the percentage of the input hours it burns
+func printTxnReview(out io.Writer, r *TxnReview) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Inputs:")
+	fmt.Fprintln(w, "  ADDRESS\tCOINS\tHOURS\t")
+	for _, in := range r.Inputs {
+		c, err := droplet.ToString(in.Coins)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%d\t\n", in.Address, c, in.Hours)
+	}
+
+	fmt.Fprintln(w, "Outputs:")
+	fmt.Fprintln(w, "  ADDRESS\tCOINS\tHOURS\t")
+	for _, o := range r.Outputs {
+		c, err := droplet.ToString(o.Coins)
+		if err != nil {
+			return err
+		}
+
+		var flags []string
+		if o.Change {
+			flags = append(flags, "change")
+		}
+		if o.Unexpected {
+			flags = append(flags, "WARNING: not in the wallet and not a destination")
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%s\n", o.Address, c, o.Hours, strings.Join(flags, ", "))
+	}
+
+	fmt.Fprintf(w, "Fee:\t%d hours, %.2f%% of the input hours burned\n", r.FeeHours, r.BurnPercent())
+
+	return w.Flush()
+}
+
+// confirmTyped asks a question that must be answered by typing answer, and returns true if it was
+func confirmTyped(in io.Reader, out io.Writer, question, answer string) (bool, error) {
+	fmt.Fprintf(out, "%s Type %q to continue: ", question, answer)
+
+	typed, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	return strings.TrimSpace(typed) == answer, nil
+}
+
+// reviewTxnInteractively returns a TxnReviewer printing the review to out,
+// which asks for a typed "yes" on in unless yes is true
+func reviewTxnInteractively(in io.Reader, out io.Writer, yes bool) TxnReviewer {
+	return func(r *TxnReview) error {
+		if err := printTxnReview(out, r); err != nil {
+			return err
+		}
+
+		if yes {
+			return nil
+		}
+
+		question := "Sign and send this transaction?"
+		if r.HasUnexpectedOutputs() {
+			question = "Some outputs go to addresses that are not in the wallet and not destinations. Sign and send this transaction anyway?"
+		}
+
+		ok, err := confirmTyped(in, out, question, "yes")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrSendCancelled
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/transaction"
+
+	"github.com/ness-network/privateness/src/util/fee"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func makeReviewWallet(t *testing.T) (wallet.Wallet, []transaction.UxBalance) {
+	w, err := wallet.NewWallet("test.wlt", wallet.Options{
+		Seed:      "seed",
+		Type:      wallet.WalletTypeDeterministic,
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+
+	addrs, err := w.GetSkycoinAddresses()
+	require.NoError(t, err)
+
+	spends := []transaction.UxBalance{
+		{
+			Hash:    testutil.RandSHA256(t),
+			Address: addrs[0],
+			Coins:   10e6,
+			Hours:   100,
+		},
+		{
+			Hash:    testutil.RandSHA256(t),
+			Address: addrs[1],
+			Coins:   5e6,
+			Hours:   60,
+		},
+	}
+
+	return w, spends
+}
+
+func TestNewTxnReview(t *testing.T) {
+	w, spends := makeReviewWallet(t)
+	walletAddr := spends[0].Address
+	chgAddr := spends[1].Address
+	toAddr := testutil.MakeAddress()
+	strayAddr := testutil.MakeAddress()
+
+	cases := []struct {
+		name       string
+		toAddrs    []SendAmount
+		outs       []coin.TransactionOutput
+		chgAddr    string
+		change     []bool
+		unexpected []bool
+		fee        uint64
+		err        string
+	}{
+		{
+			name:    "destination and change",
+			toAddrs: []SendAmount{{Addr: toAddr.String(), Coins: 1e6}},
+			outs: []coin.TransactionOutput{
+				{Address: toAddr, Coins: 1e6, Hours: 20},
+				{Address: chgAddr, Coins: 14e6, Hours: 60},
+			},
+			chgAddr:    chgAddr.String(),
+			change:     []bool{false, true},
+			unexpected: []bool{false, false},
+			fee:        80,
+		},
+		{
+			name:    "change address is also a destination",
+			toAddrs: []SendAmount{{Addr: chgAddr.String(), Coins: 1e6}},
+			outs: []coin.TransactionOutput{
+				{Address: chgAddr, Coins: 1e6, Hours: 20},
+				{Address: chgAddr, Coins: 14e6, Hours: 60},
+			},
+			chgAddr:    chgAddr.String(),
+			change:     []bool{false, true},
+			unexpected: []bool{false, false},
+			fee:        80,
+		},
+		{
+			name:    "change misrouted outside of the wallet",
+			toAddrs: []SendAmount{{Addr: toAddr.String(), Coins: 1e6}},
+			outs: []coin.TransactionOutput{
+				{Address: toAddr, Coins: 1e6, Hours: 20},
+				{Address: strayAddr, Coins: 14e6, Hours: 60},
+			},
+			chgAddr:    chgAddr.String(),
+			change:     []bool{false, false},
+			unexpected: []bool{false, true},
+			fee:        80,
+		},
+		{
+			name:    "output to a wallet address that is not a destination",
+			toAddrs: []SendAmount{{Addr: toAddr.String(), Coins: 15e6}},
+			outs: []coin.TransactionOutput{
+				{Address: toAddr, Coins: 15e6, Hours: 0},
+				{Address: walletAddr, Coins: 0, Hours: 10},
+			},
+			chgAddr:    chgAddr.String(),
+			change:     []bool{false, false},
+			unexpected: []bool{false, false},
+			fee:        150,
+		},
+		{
+			name:    "outputs have more hours than inputs",
+			toAddrs: []SendAmount{{Addr: toAddr.String(), Coins: 15e6}},
+			outs: []coin.TransactionOutput{
+				{Address: toAddr, Coins: 15e6, Hours: 161},
+			},
+			chgAddr: chgAddr.String(),
+			err:     "transaction outputs have 161 hours, more than the 160 hours of its inputs",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txn, err := newUnsignedTransaction(spends, tc.outs)
+			require.NoError(t, err)
+
+			r, err := newTxnReview(txn, spends, w, tc.chgAddr, tc.toAddrs)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Len(t, r.Inputs, 2)
+			for i, in := range r.Inputs {
+				require.Equal(t, TxnReviewInput{
+					Hash:    spends[i].Hash,
+					Address: spends[i].Address,
+					Coins:   spends[i].Coins,
+					Hours:   spends[i].Hours,
+				}, in)
+			}
+
+			require.Len(t, r.Outputs, len(tc.outs))
+			var unexpected bool
+			for i, o := range r.Outputs {
+				require.Equal(t, tc.outs[i].Address, o.Address)
+				require.Equal(t, tc.outs[i].Coins, o.Coins)
+				require.Equal(t, tc.outs[i].Hours, o.Hours)
+				require.Equal(t, tc.change[i], o.Change, "output %d", i)
+				require.Equal(t, tc.unexpected[i], o.Unexpected, "output %d", i)
+				unexpected = unexpected || o.Unexpected
+			}
+
+			require.Equal(t, uint64(160), r.InputHours)
+			require.Equal(t, tc.fee, r.FeeHours)
+			require.Equal(t, unexpected, r.HasUnexpectedOutputs())
+		})
+	}
+
+	// Inputs must be chosen outputs
+	txn, err := newUnsignedTransaction(spends, nil)
+	require.NoError(t, err)
+	_, err = newTxnReview(txn, spends[:1], w, chgAddr.String(), nil)
+	require.EqualError(t, err, "transaction input "+spends[1].Hash.Hex()+" is not a chosen unspent output")
+}
+
+func TestPrintTxnReview(t *testing.T) {
+	w, spends := makeReviewWallet(t)
+	toAddr := testutil.MakeAddress()
+	strayAddr := testutil.MakeAddress()
+
+	txn, err := newUnsignedTransaction(spends, []coin.TransactionOutput{
+		{Address: toAddr, Coins: 1e6, Hours: 20},
+		{Address: spends[1].Address, Coins: 13e6, Hours: 60},
+		{Address: strayAddr, Coins: 1e6, Hours: 0},
+	})
+	require.NoError(t, err)
+
+	r, err := newTxnReview(txn, spends, w, spends[1].Address.String(), []SendAmount{{Addr: toAddr.String(), Coins: 1e6}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, printTxnReview(&buf, r))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 10)
+	require.Equal(t, "Inputs:", lines[0])
+	require.Equal(t, []string{"ADDRESS", "COINS", "HOURS"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{spends[0].Address.String(), "10.000000", "100"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{spends[1].Address.String(), "5.000000", "60"}, strings.Fields(lines[3]))
+	require.Equal(t, "Outputs:", lines[4])
+	require.Equal(t, []string{toAddr.String(), "1.000000", "20"}, strings.Fields(lines[6]))
+	require.Equal(t, []string{spends[1].Address.String(), "13.000000", "60", "change"}, strings.Fields(lines[7]))
+	require.Equal(t, strayAddr.String()+" 1.000000 0 WARNING: not in the wallet and not a destination", strings.Join(strings.Fields(lines[8]), " "))
+	require.Equal(t, "Fee: 80 hours, 50.00% of the input hours burned", strings.Join(strings.Fields(lines[9]), " "))
+}
+
+func TestConfirmTyped(t *testing.T) {
+	for answer, ok := range map[string]bool{
+		"yes\n":  true,
+		" yes ":  true,
+		"y\n":    false,
+		"YES\n":  false,
+		"\n":     false,
+		"":       false,
+		"yess\n": false,
+	} {
+		var out bytes.Buffer
+		got, err := confirmTyped(strings.NewReader(answer), &out, "Send?", "yes")
+		require.NoError(t, err)
+		require.Equal(t, ok, got, answer)
+		require.Equal(t, `Send? Type "yes" to continue: `, out.String())
+	}
+}
+
+func TestCreateRawTxnReview(t *testing.T) {
+	w, spends := makeReviewWallet(t)
+	toAddr := testutil.MakeAddress()
+
+	var outs readable.UnspentOutputs
+	for _, s := range spends {
+		outs = append(outs, readable.UnspentOutput{
+			Hash:              s.Hash.Hex(),
+			SourceTransaction: testutil.RandSHA256(t).Hex(),
+			Address:           s.Address.String(),
+			Coins:             "10.000000",
+			Hours:             s.Hours,
+			CalculatedHours:   s.Hours,
+		})
+	}
+	uxouts := &readable.UnspentOutputsSummary{
+		HeadOutputs: outs[:1],
+	}
+	toAddrs := []SendAmount{{Addr: toAddr.String(), Coins: 1e6}}
+	policy := fee.NewConstantBurnPolicy(2)
+
+	// A cancelled review doesn't sign the transaction
+	var review *TxnReview
+	txn, err := createRawTxn(uxouts, w, spends[0].Address.String(), toAddrs, nil, policy, func(r *TxnReview) error {
+		review = r
+		return ErrSendCancelled
+	})
+	require.Equal(t, ErrSendCancelled, err)
+	require.Nil(t, txn)
+	require.NotNil(t, review)
+
+	// The interactive review needs a typed "yes"
+	var out bytes.Buffer
+	_, err = createRawTxn(uxouts, w, spends[0].Address.String(), toAddrs, nil, policy, reviewTxnInteractively(strings.NewReader("y\n"), &out, false))
+	require.Equal(t, ErrSendCancelled, err)
+	require.True(t, strings.HasSuffix(out.String(), `Sign and send this transaction? Type "yes" to continue: `))
+
+	out.Reset()
+	txn, err = createRawTxn(uxouts, w, spends[0].Address.String(), toAddrs, nil, policy, reviewTxnInteractively(strings.NewReader("yes\n"), &out, false))
+	require.NoError(t, err)
+	require.Len(t, txn.Sigs, 1)
+	require.Contains(t, out.String(), "Fee:")
+
+	// --yes prints the review without asking
+	out.Reset()
+	txn, err = createRawTxn(uxouts, w, spends[0].Address.String(), toAddrs, nil, policy, reviewTxnInteractively(strings.NewReader(""), &out, true))
+	require.NoError(t, err)
+	require.Len(t, txn.Sigs, 1)
+	require.NotContains(t, out.String(), "Type")
+
+	// The reviewer's error is returned
+	_, err = createRawTxn(uxouts, w, spends[0].Address.String(), toAddrs, nil, policy, func(r *TxnReview) error {
+		return errors.New("review failed")
+	})
+	require.EqualError(t, err, "review failed")
+}
@@ -5,7 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func showSeedCmd() *cobra.Command {
@@ -23,7 +23,7 @@ func showSeedCmd() *cobra.Command {
 		RunE: func(c *cobra.Command, args []string) error {
 			w := args[0]
 
-			password, err := c.Flags().GetString("password")
+			pr, err := passwordReaderFromFlags(c)
 			if err != nil {
 				return err
 			}
@@ -33,7 +33,6 @@ func showSeedCmd() *cobra.Command {
 				return err
 			}
 
-			pr := NewPasswordReader([]byte(password))
 			seed, seedPassphrase, err := getSeed(w, pr)
 			switch err.(type) {
 			case nil:
@@ -64,7 +63,7 @@ func showSeedCmd() *cobra.Command {
 		},
 	}
 
-	showSeedCmd.Flags().StringP("password", "p", "", "Wallet password")
+	addPasswordFlags(showSeedCmd, "Wallet password")
 	showSeedCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 
 	return showSeedCmd
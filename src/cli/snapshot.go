@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+
+	pvisor "github.com/ness-network/privateness/src/visor"
+)
+
+func createSnapshotCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Create a snapshot of the node's database",
+		Use:   "createSnapshot",
+		Long: `Create a consistent snapshot of the node's database while the node runs.
+    The snapshot is written to the node's snapshot directory, with a manifest holding
+    the head block and the sha256 of the snapshot file.
+
+    Copy the snapshot and its manifest to a new node and start it with
+    -bootstrap-from-snapshot to skip syncing the blocks of the snapshot from peers.
+
+    The node must have the ADMIN API set enabled.`,
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			var rsp json.RawMessage
+			if _, err := apiClient.PostJSONV2("/api/v2/db/snapshot", nil, &rsp); err != nil {
+				return err
+			}
+
+			return printJSON(rsp)
+		},
+	}
+}
+
+func verifySnapshotCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Verify a database snapshot against its manifest",
+		Use:   "verifySnapshot [snapshot path]",
+		Long: `Verify the size and sha256 of a database snapshot against its manifest,
+    which is read from the snapshot path with "` + pvisor.SnapshotManifestSuffix + `" appended.
+    Prints the manifest if the snapshot is valid.`,
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			m, err := pvisor.VerifySnapshot(args[0])
+			if err != nil {
+				return err
+			}
+
+			return printJSON(m)
+		},
+	}
+}
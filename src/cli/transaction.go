@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"text/tabwriter"
+	"time"
 
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/wallet"
 	"github.com/skycoin/skycoin/src/util/droplet"
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/skycoin/skycoin/src/util/mathutil"
 
 	"github.com/skycoin/skycoin/src/api"
 	"github.com/skycoin/skycoin/src/cipher"
@@ -55,13 +62,28 @@ func transactionCmd() *cobra.Command {
 }
 
 func decodeRawTxnCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Short:                 "Decode raw transaction",
 		Use:                   "decodeRawTransaction [raw transaction]",
 		DisableFlagsInUseLine: true,
 		SilenceUsage:          true,
 		Args:                  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(c *cobra.Command, args []string) error {
+			explain, err := c.Flags().GetBool("explain")
+			if err != nil {
+				return err
+			}
+
+			if explain {
+				ptxn, err := pcoin.DeserializeTransactionHex(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid raw transaction: %v", err)
+				}
+
+				fmt.Print(explainTransactionEncoding(ptxn))
+				return nil
+			}
+
 			txn, err := coin.DeserializeTransactionHex(args[0])
 			if err != nil {
 				return fmt.Errorf("invalid raw transaction: %v", err)
@@ -78,6 +100,26 @@ func decodeRawTxnCmd() *cobra.Command {
 			return printJSON(rTxn)
 		},
 	}
+
+	cmd.Flags().Bool("explain", false, "Print an annotated hex dump of the raw transaction, with the byte offset and length of each field")
+
+	return cmd
+}
+
+// explainTransactionEncoding returns the serialization of a transaction as an annotated hex dump,
+// with a line per field, see pcoin.ExplainTransactionEncoding
+func explainTransactionEncoding(txn pcoin.Transaction) string {
+	b := txn.MustSerialize()
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OFFSET\tLENGTH\tFIELD\tHEX")
+	for _, s := range pcoin.ExplainTransactionEncoding(txn) {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", s.Offset, s.Length, s.Name, hex.EncodeToString(b[s.Offset:s.Offset+s.Length]))
+	}
+	w.Flush() //nolint:errcheck
+
+	return buf.String()
 }
 
 func encodeJSONTxnCmd() *cobra.Command {
@@ -240,16 +282,63 @@ func recomputeHashes(rTxn *readable.Transaction) error {
 	return nil
 }
 
+// Directions of an address transaction, relative to the queried addresses
+const (
+	// DirectionIn the transaction spends no output of the addresses
+	DirectionIn = "in"
+	// DirectionOut the transaction spends outputs of the addresses and sends coins to other addresses
+	DirectionOut = "out"
+	// DirectionSelf the transaction spends outputs of the addresses and sends all coins to the addresses
+	DirectionSelf = "self"
+	// DirectionUnknown the owner of an input of the transaction could not be resolved
+	DirectionUnknown = "unknown"
+)
+
+// AddressTransaction is a transaction of the addressTransactions command,
+// with its direction and the coins it moved relative to the queried addresses
+type AddressTransaction struct {
+	readable.TransactionWithStatusVerbose
+	Direction string `json:"direction"`
+	// Coins are the coins received by the addresses for "in" and "unknown" transactions,
+	// sent to other addresses for "out" transactions, and sent back to the addresses for "self" transactions
+	Coins string `json:"coins"`
+}
+
+// addressTxnsFilter filters the transactions of the addressTransactions command
+type addressTxnsFilter struct {
+	// direction is the direction of the transactions, empty for any direction
+	direction string
+	minCoins  uint64
+	// after and before are unix times, 0 for no bound
+	after  uint64
+	before uint64
+	// limit is the maximum number of transactions, newest first, 0 for no limit
+	limit int
+}
+
 func addressTransactionsCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Short: "Show detail for transaction associated with one or more specified addresses",
 		Use:   "addressTransactions [address list]",
 		Long: `Display transactions for specific addresses, separate multiple addresses with a space,
-        example: addressTransactions addr1 addr2 addr3`,
+        example: addressTransactions addr1 addr2 addr3
+
+    Each transaction is labeled with its direction relative to the addresses:
+    "in" if it spends no output of the addresses, "out" if it sends coins to other addresses,
+    "self" if it sends all coins back to the addresses, and "unknown" if the owner of an input
+    could not be resolved. Filters are applied after fetching the transactions.`,
 		DisableFlagsInUseLine: true,
 		SilenceUsage:          true,
 		RunE:                  getAddressTransactionsCmd,
 	}
+
+	cmd.Flags().String("direction", "", "Only show transactions of this direction: in, out or self")
+	cmd.Flags().String("min-coins", "", "Only show transactions moving at least this many coins")
+	cmd.Flags().String("after", "", "Only show transactions after this time, a unix timestamp or RFC3339 time")
+	cmd.Flags().String("before", "", "Only show transactions before this time, a unix timestamp or RFC3339 time")
+	cmd.Flags().Int("limit", 0, "Show at most this many transactions, newest first")
+
+	return cmd
 }
 
 func getAddressTransactionsCmd(c *cobra.Command, args []string) error {
@@ -264,16 +353,199 @@ func getAddressTransactionsCmd(c *cobra.Command, args []string) error {
 	}
 
 	// If one or more addresses have been provided, request their transactions - otherwise report an error
-	if len(addrs) > 0 {
-		outputs, err := apiClient.TransactionsVerbose(addrs)
+	if len(addrs) == 0 {
+		return fmt.Errorf("at least one address must be specified. Example: %s addr1 addr2 addr3", c.Name())
+	}
+
+	f, err := parseAddressTxnsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	// The verbose transactions include the owner of the inputs, needed to classify their direction
+	txns, err := apiClient.TransactionsVerbose(addrs)
+	if err != nil {
+		return err
+	}
+
+	filtered, err := filterAddressTransactions(txns, addrs, f)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(filtered)
+}
+
+func parseAddressTxnsFilter(c *cobra.Command) (addressTxnsFilter, error) {
+	var f addressTxnsFilter
+
+	direction, err := c.Flags().GetString("direction")
+	if err != nil {
+		return f, err
+	}
+	switch direction {
+	case "", DirectionIn, DirectionOut, DirectionSelf:
+		f.direction = direction
+	default:
+		return f, fmt.Errorf("invalid direction %q, must be in, out or self", direction)
+	}
+
+	minCoins, err := c.Flags().GetString("min-coins")
+	if err != nil {
+		return f, err
+	}
+	if minCoins != "" {
+		f.minCoins, err = droplet.FromString(minCoins)
 		if err != nil {
-			return err
+			return f, fmt.Errorf("invalid min-coins: %v", err)
+		}
+	}
+
+	for _, v := range []struct {
+		flag string
+		dst  *uint64
+	}{
+		{"after", &f.after},
+		{"before", &f.before},
+	} {
+		s, err := c.Flags().GetString(v.flag)
+		if err != nil {
+			return f, err
+		}
+		if s == "" {
+			continue
 		}
 
-		return printJSON(outputs)
+		*v.dst, err = parseTimestamp(s)
+		if err != nil {
+			return f, fmt.Errorf("invalid %s: %v", v.flag, err)
+		}
 	}
 
-	return fmt.Errorf("at least one address must be specified. Example: %s addr1 addr2 addr3", c.Name())
+	f.limit, err = c.Flags().GetInt("limit")
+	if err != nil {
+		return f, err
+	}
+	if f.limit < 0 {
+		return f, errors.New("limit must not be negative")
+	}
+
+	return f, nil
+}
+
+// parseTimestamp parses a unix timestamp or a RFC3339 time
+func parseTimestamp(s string) (uint64, error) {
+	if ts, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return ts, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, errors.New("must be a unix timestamp or RFC3339 time")
+	}
+
+	if t.Unix() < 0 {
+		return 0, errors.New("must not be before 1970")
+	}
+
+	return uint64(t.Unix()), nil
+}
+
+// addressTransactionDirection returns the direction of a transaction relative to a set of addresses,
+// and the coins it moved, see AddressTransaction
+func addressTransactionDirection(txn readable.TransactionVerbose, addrs map[string]struct{}) (string, uint64, error) {
+	unknown := false
+	spends := false
+	for _, in := range txn.In {
+		if in.Address == "" {
+			unknown = true
+			continue
+		}
+		if _, ok := addrs[in.Address]; ok {
+			spends = true
+		}
+	}
+
+	var received, sent uint64
+	for _, o := range txn.Out {
+		coins, err := droplet.FromString(o.Coins)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if _, ok := addrs[o.Address]; ok {
+			received, err = mathutil.AddUint64(received, coins)
+		} else {
+			sent, err = mathutil.AddUint64(sent, coins)
+		}
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	switch {
+	case unknown:
+		return DirectionUnknown, received, nil
+	case !spends:
+		return DirectionIn, received, nil
+	case sent > 0:
+		return DirectionOut, sent, nil
+	default:
+		return DirectionSelf, received, nil
+	}
+}
+
+// filterAddressTransactions labels the transactions of addresses with their direction and applies the filter.
+// If the filter has a limit, the transactions are ordered newest first.
+func filterAddressTransactions(txns []readable.TransactionWithStatusVerbose, addrs []string, f addressTxnsFilter) ([]AddressTransaction, error) {
+	addrSet := make(map[string]struct{}, len(addrs))
+	for _, a := range addrs {
+		addrSet[a] = struct{}{}
+	}
+
+	filtered := make([]AddressTransaction, 0, len(txns))
+	for _, txn := range txns {
+		direction, coins, err := addressTransactionDirection(txn.Transaction, addrSet)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %v", txn.Transaction.Hash, err)
+		}
+
+		if f.direction != "" && direction != f.direction {
+			continue
+		}
+		if coins < f.minCoins {
+			continue
+		}
+		if f.after != 0 && txn.Time <= f.after {
+			continue
+		}
+		if f.before != 0 && txn.Time >= f.before {
+			continue
+		}
+
+		coinsStr, err := droplet.ToString(coins)
+		if err != nil {
+			return nil, err
+		}
+
+		filtered = append(filtered, AddressTransaction{
+			TransactionWithStatusVerbose: txn,
+			Direction:                    direction,
+			Coins:                        coinsStr,
+		})
+	}
+
+	if f.limit > 0 {
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return filtered[i].Time > filtered[j].Time
+		})
+
+		if len(filtered) > f.limit {
+			filtered = filtered[:f.limit]
+		}
+	}
+
+	return filtered, nil
 }
 
 func verifyTransactionCmd() *cobra.Command {
@@ -365,21 +637,45 @@ func signTxnCmd() *cobra.Command {
 				return fmt.Errorf("Transaction already signed")
 			}
 
+			addrPasswordValues, err := c.Flags().GetStringArray("address-password")
+			if err != nil {
+				return err
+			}
+
+			addrPasswords, err := parseAddressPasswords(addrPasswordValues, readAddressPassword)
+			if err != nil {
+				return err
+			}
+
+			pr, err := passwordReaderFromFlags(c)
+			if err != nil {
+				return err
+			}
+
 			// Check if wallet is encrypted
-			req := api.WalletSignTransactionRequest{
-				WalletID:           id,
-				EncodedTransaction: rawTxn,
+			req := walletSignTransactionRequest{
+				WalletSignTransactionRequest: api.WalletSignTransactionRequest{
+					WalletID:           id,
+					EncodedTransaction: rawTxn,
+				},
+				AddressPasswords: addrPasswords,
 			}
 
+			defer func() {
+				req.AddressPasswords = nil
+				addrPasswords = nil
+			}()
+
 			// Load wallet to check if the wallet is encrypted
 			w, err := wallet.Load(id)
 			if err != nil {
 				return err
 			}
 
-			// Read wallet password from terminal if it is encrypted
-			if w.IsEncrypted() {
-				v, err := readPasswordFromTerminal()
+			// Read wallet password if it is encrypted, unless the wallet
+			// password is not needed because the addresses have their own passwords
+			if w.IsEncrypted() && (len(addrPasswords) == 0 || walletNeedsPassword(w)) {
+				v, err := pr.Password()
 				if err != nil {
 					return err
 				}
@@ -392,8 +688,8 @@ func signTxnCmd() *cobra.Command {
 			}
 
 			// Send transaction signing request
-			signedTxn, err := apiClient.WalletSignTransaction(req)
-			if err != nil {
+			var signedTxn api.CreateTransactionResponse
+			if _, err := apiClient.PostJSONV2("/api/v2/wallet/transaction/sign", req, &signedTxn); err != nil {
 				return err
 			}
 
@@ -401,5 +697,18 @@ func signTxnCmd() *cobra.Command {
 		},
 	}
 
+	signTxnCmd.Flags().StringArrayP("address-password", "a", nil,
+		`Password of a wallet address encrypted with its own password, as ADDRESS=PASSWORD.
+	If only the ADDRESS is given, the password is read from the terminal.
+	Repeat the option for each encrypted address whose outputs are spent.`)
+	addPasswordFlags(signTxnCmd, "Wallet password, if the wallet is encrypted")
+
 	return signTxnCmd
 }
+
+// walletSignTransactionRequest is the request body of /api/v2/wallet/transaction/sign,
+// with the passwords of the wallet addresses encrypted with their own password
+type walletSignTransactionRequest struct {
+	api.WalletSignTransactionRequest
+	AddressPasswords map[string]string `json:"address_passwords,omitempty"`
+}
@@ -0,0 +1,595 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/util/qr"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/droplet"
+)
+
+/*
+Transactions are moved to and from air-gapped machines as a sequence of QR codes.
+
+The payload is the JSON of a qrTransaction: the hex encoded transaction, whether it is signed,
+and optionally the outputs spent by its inputs, so that they can be reviewed before signing.
+The payload is split into chunks, each encoded in a QR code as the text
+
+    NESSQR:1:<index>/<total>:<checksum>:<data>
+
+where 1 is the format version, index is the 1-based index of the chunk, total is the number of chunks,
+checksum is the hex encoded first 4 bytes of the SHA256 of the payload, and data is the chunk of the payload.
+The chunks can be scanned in any order and repeatedly. The checksum identifies the chunks of a payload,
+and verifies the reassembled payload.
+*/
+
+const (
+	qrChunkPrefix  = "NESSQR"
+	qrChunkVersion = 1
+	// defaultQRChunkSize is the default number of payload bytes per QR code, small enough to be scanned reliably
+	defaultQRChunkSize = 400
+	// maxQRChunkSize is the max number of payload bytes per QR code, which fits in a version 40 QR code at level H
+	maxQRChunkSize = 1200
+)
+
+// qrTransaction is the payload of the QR codes of a transaction
+type qrTransaction struct {
+	// Transaction is the hex encoded transaction
+	Transaction string `json:"transaction"`
+	// Signed is true if all the inputs of the transaction are signed
+	Signed bool `json:"signed"`
+	// Inputs are the outputs spent by the transaction's inputs, in order, if they were exported
+	Inputs []qrTransactionInput `json:"inputs,omitempty"`
+}
+
+// qrTransactionInput is an output spent by a transaction input
+type qrTransactionInput struct {
+	Hash    string `json:"uxid"`
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Hours   uint64 `json:"hours"`
+}
+
+// qrChunk is a chunk of a QR payload
+type qrChunk struct {
+	Index    int
+	Total    int
+	Checksum string
+	Data     string
+}
+
+func (c qrChunk) String() string {
+	return fmt.Sprintf("%s:%d:%d/%d:%s:%s", qrChunkPrefix, qrChunkVersion, c.Index, c.Total, c.Checksum, c.Data)
+}
+
+func qrPayloadChecksum(payload []byte) string {
+	h := cipher.SumSHA256(payload)
+	return hex.EncodeToString(h[:4])
+}
+
+// splitQRPayload splits a payload into chunks of up to size bytes
+func splitQRPayload(payload []byte, size int) ([]qrChunk, error) {
+	if size <= 0 {
+		return nil, errors.New("chunk size must be positive")
+	}
+	if len(payload) == 0 {
+		return nil, errors.New("payload is empty")
+	}
+
+	checksum := qrPayloadChecksum(payload)
+	total := (len(payload) + size - 1) / size
+
+	chunks := make([]qrChunk, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * size
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		chunks = append(chunks, qrChunk{
+			Index:    i + 1,
+			Total:    total,
+			Checksum: checksum,
+			Data:     string(payload[i*size : end]),
+		})
+	}
+
+	return chunks, nil
+}
+
+// parseQRChunk parses the text of a chunk
+func parseQRChunk(s string) (qrChunk, error) {
+	pts := strings.SplitN(s, ":", 5)
+	if len(pts) != 5 || pts[0] != qrChunkPrefix {
+		return qrChunk{}, errors.New("not a transaction QR code")
+	}
+
+	if pts[1] != strconv.Itoa(qrChunkVersion) {
+		return qrChunk{}, fmt.Errorf("unsupported transaction QR code version %q", pts[1])
+	}
+
+	pos := strings.Split(pts[2], "/")
+	if len(pos) != 2 {
+		return qrChunk{}, fmt.Errorf("invalid chunk position %q", pts[2])
+	}
+
+	index, err := strconv.Atoi(pos[0])
+	if err != nil {
+		return qrChunk{}, fmt.Errorf("invalid chunk index %q", pos[0])
+	}
+	total, err := strconv.Atoi(pos[1])
+	if err != nil {
+		return qrChunk{}, fmt.Errorf("invalid chunk total %q", pos[1])
+	}
+	if total < 1 || index < 1 || index > total {
+		return qrChunk{}, fmt.Errorf("invalid chunk position %q", pts[2])
+	}
+
+	if len(pts[3]) != 8 {
+		return qrChunk{}, fmt.Errorf("invalid chunk checksum %q", pts[3])
+	}
+	if _, err := hex.DecodeString(pts[3]); err != nil {
+		return qrChunk{}, fmt.Errorf("invalid chunk checksum %q", pts[3])
+	}
+
+	if pts[4] == "" {
+		return qrChunk{}, errors.New("chunk data is empty")
+	}
+
+	return qrChunk{
+		Index:    index,
+		Total:    total,
+		Checksum: pts[3],
+		Data:     pts[4],
+	}, nil
+}
+
+// assembleQRChunks reassembles a payload from the texts of its chunks, in any order.
+// A chunk can be repeated, but all the chunks must be of the same payload and none can be missing.
+func assembleQRChunks(texts []string) ([]byte, error) {
+	var total int
+	var checksum string
+	chunks := make(map[int]string)
+
+	for _, s := range texts {
+		c, err := parseQRChunk(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if checksum == "" {
+			checksum = c.Checksum
+			total = c.Total
+		} else if c.Checksum != checksum || c.Total != total {
+			return nil, fmt.Errorf("chunk %d/%d %s is not a chunk of transaction QR code %s with %d chunks", c.Index, c.Total, c.Checksum, checksum, total)
+		}
+
+		if data, ok := chunks[c.Index]; ok && data != c.Data {
+			return nil, fmt.Errorf("chunk %d/%d is repeated with different data", c.Index, c.Total)
+		}
+		chunks[c.Index] = c.Data
+	}
+
+	if checksum == "" {
+		return nil, errors.New("no transaction QR code chunks")
+	}
+
+	var missing []string
+	var payload strings.Builder
+	for i := 1; i <= total; i++ {
+		data, ok := chunks[i]
+		if !ok {
+			missing = append(missing, strconv.Itoa(i))
+			continue
+		}
+		payload.WriteString(data)
+	}
+
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("missing chunks %s of %d", strings.Join(missing, ", "), total)
+	}
+
+	b := []byte(payload.String())
+	if qrPayloadChecksum(b) != checksum {
+		return nil, errors.New("reassembled payload does not match the checksum")
+	}
+
+	return b, nil
+}
+
+// newQRTransaction creates the payload of a transaction.
+// If inputs is not nil, it must have the output spent by each input of the transaction.
+func newQRTransaction(txn *coin.Transaction, inputs []qrTransactionInput) (*qrTransaction, error) {
+	b, err := txn.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	if inputs != nil && len(inputs) != len(txn.In) {
+		return nil, fmt.Errorf("%d inputs metadata for %d inputs", len(inputs), len(txn.In))
+	}
+
+	signed := len(txn.Sigs) == len(txn.In)
+	for _, sig := range txn.Sigs {
+		if sig.Null() {
+			signed = false
+		}
+	}
+
+	return &qrTransaction{
+		Transaction: hex.EncodeToString(b),
+		Signed:      signed,
+		Inputs:      inputs,
+	}, nil
+}
+
+// decodeQRTransaction decodes a payload and checks its transaction and inputs metadata
+func decodeQRTransaction(payload []byte) (*qrTransaction, *coin.Transaction, error) {
+	var qt qrTransaction
+	if err := json.Unmarshal(payload, &qt); err != nil {
+		return nil, nil, fmt.Errorf("invalid transaction QR code payload: %v", err)
+	}
+
+	txn, err := coin.DeserializeTransactionHex(qt.Transaction)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+
+	if txn.InnerHash != txn.HashInner() {
+		return nil, nil, errors.New("invalid transaction: inner hash does not match the inputs and outputs")
+	}
+
+	if len(qt.Inputs) != 0 {
+		if len(qt.Inputs) != len(txn.In) {
+			return nil, nil, fmt.Errorf("%d inputs metadata for %d inputs", len(qt.Inputs), len(txn.In))
+		}
+		for i, in := range qt.Inputs {
+			if in.Hash != txn.In[i].Hex() {
+				return nil, nil, fmt.Errorf("inputs metadata %d is for %s, not for input %s", i, in.Hash, txn.In[i].Hex())
+			}
+		}
+	}
+
+	return &qt, &txn, nil
+}
+
+// getQRTransactionInputs gets the outputs spent by the inputs of a transaction from the node
+func getQRTransactionInputs(txn *coin.Transaction) ([]qrTransactionInput, error) {
+	inputs := make([]qrTransactionInput, len(txn.In))
+	for i, h := range txn.In {
+		ux, err := apiClient.UxOut(h.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("get input %s failed: %v", h.Hex(), err)
+		}
+
+		coins, err := droplet.ToString(ux.Coins)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs[i] = qrTransactionInput{
+			Hash:    ux.Uxid,
+			Address: ux.OwnerAddress,
+			Coins:   coins,
+			Hours:   ux.Hours,
+		}
+	}
+	return inputs, nil
+}
+
+func parseQRLevel(s string) (qr.Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qr.L, nil
+	case "M":
+		return qr.M, nil
+	case "Q":
+		return qr.Q, nil
+	case "H":
+		return qr.H, nil
+	default:
+		return 0, fmt.Errorf("invalid error correction level %q, must be L, M, Q or H", s)
+	}
+}
+
+// writeQRChunks writes the chunks as text, one per line, as ASCII QR codes, or as PNG files in pngDir
+func writeQRChunks(out io.Writer, chunks []qrChunk, level qr.Level, text, invert bool, pngDir string, scale int) error {
+	for _, ch := range chunks {
+		s := ch.String()
+
+		if text {
+			fmt.Fprintln(out, s)
+			continue
+		}
+
+		code, err := qr.Encode([]byte(s), level)
+		if err != nil {
+			return fmt.Errorf("encode chunk %d/%d failed: %v", ch.Index, ch.Total, err)
+		}
+
+		if pngDir == "" {
+			fmt.Fprintf(out, "Chunk %d/%d\n%s\n", ch.Index, ch.Total, code.ASCII(invert))
+			continue
+		}
+
+		fn := filepath.Join(pngDir, fmt.Sprintf("chunk-%03d-of-%03d.png", ch.Index, ch.Total))
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+
+		if err := png.Encode(f, code.Image(scale)); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(out, fn)
+	}
+
+	return nil
+}
+
+// readQRChunkTexts reads the chunk texts from files, one per non-empty line. The file "-" is stdin.
+func readQRChunkTexts(files []string, stdin io.Reader) ([]string, error) {
+	var texts []string
+	read := func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if s := strings.TrimSpace(scanner.Text()); s != "" {
+				texts = append(texts, s)
+			}
+		}
+		return scanner.Err()
+	}
+
+	for _, fn := range files {
+		if fn == "-" {
+			if err := read(stdin); err != nil {
+				return nil, fmt.Errorf("read stdin failed: %v", err)
+			}
+			continue
+		}
+
+		f, err := os.Open(fn)
+		if err != nil {
+			return nil, err
+		}
+		err = read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %v", fn, err)
+		}
+	}
+
+	return texts, nil
+}
+
+func exportTransactionQRCmd() *cobra.Command {
+	exportTransactionQRCmd := &cobra.Command{
+		Short: "Export a transaction as QR codes for an air-gapped machine",
+		Use:   "exportTransactionQR [raw transaction]",
+		Long: `Export an unsigned or signed raw transaction as a sequence of QR codes,
+    to move it to or from an air-gapped machine.
+
+    The raw transaction is read from the argument, from the file of --file,
+    or from stdin if the argument is "-". Whitespace and newlines are ignored.
+
+    The outputs spent by the transaction's inputs are fetched from the node and
+    exported with the transaction, so that they can be reviewed before signing.
+    Use --no-inputs on a machine without a node, e.g. to export the signed transaction.
+
+    The transaction is split into chunks of --chunk-size bytes, each encoded in a QR code.
+    The QR codes are printed as text, or saved as PNG files in --png-dir.
+    Use --text to print the text of each QR code instead.
+
+    The scanned texts of the QR codes are reassembled by importTransactionQR.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			file, err := c.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			noInputs, err := c.Flags().GetBool("no-inputs")
+			if err != nil {
+				return err
+			}
+
+			chunkSize, err := c.Flags().GetInt("chunk-size")
+			if err != nil {
+				return err
+			}
+			if chunkSize < 1 || chunkSize > maxQRChunkSize {
+				return fmt.Errorf("--chunk-size must be between 1 and %d", maxQRChunkSize)
+			}
+
+			levelFlag, err := c.Flags().GetString("level")
+			if err != nil {
+				return err
+			}
+			level, err := parseQRLevel(levelFlag)
+			if err != nil {
+				return err
+			}
+
+			text, err := c.Flags().GetBool("text")
+			if err != nil {
+				return err
+			}
+
+			invert, err := c.Flags().GetBool("invert")
+			if err != nil {
+				return err
+			}
+
+			pngDir, err := c.Flags().GetString("png-dir")
+			if err != nil {
+				return err
+			}
+
+			scale, err := c.Flags().GetInt("scale")
+			if err != nil {
+				return err
+			}
+			if scale < 1 {
+				return errors.New("--scale must be positive")
+			}
+
+			if text && pngDir != "" {
+				return errors.New("--text and --png-dir cannot be combined")
+			}
+
+			rawtx, err := readRawTransaction(args, file, os.Stdin)
+			if err != nil {
+				return err
+			}
+
+			txn, err := coin.DeserializeTransactionHex(rawtx)
+			if err != nil {
+				return fmt.Errorf("invalid raw transaction: %v", err)
+			}
+
+			var inputs []qrTransactionInput
+			if !noInputs {
+				inputs, err = getQRTransactionInputs(&txn)
+				if err != nil {
+					return err
+				}
+			}
+
+			qt, err := newQRTransaction(&txn, inputs)
+			if err != nil {
+				return err
+			}
+
+			payload, err := json.Marshal(qt)
+			if err != nil {
+				return ErrJSONMarshal
+			}
+
+			chunks, err := splitQRPayload(payload, chunkSize)
+			if err != nil {
+				return err
+			}
+
+			return writeQRChunks(os.Stdout, chunks, level, text, invert, pngDir, scale)
+		},
+	}
+
+	exportTransactionQRCmd.Flags().StringP("file", "f", "", "Read the raw transaction from a file")
+	exportTransactionQRCmd.Flags().Bool("no-inputs", false, "Do not fetch and export the outputs spent by the inputs")
+	exportTransactionQRCmd.Flags().Int("chunk-size", defaultQRChunkSize, "Number of bytes of the transaction per QR code")
+	exportTransactionQRCmd.Flags().String("level", "M", "Error correction level of the QR codes: L, M, Q or H")
+	exportTransactionQRCmd.Flags().Bool("text", false, "Print the text of the QR codes, one per line")
+	exportTransactionQRCmd.Flags().Bool("invert", false, "Invert the colors of the printed QR codes, for a terminal with a dark background")
+	exportTransactionQRCmd.Flags().String("png-dir", "", "Save the QR codes as PNG files in this directory")
+	exportTransactionQRCmd.Flags().Int("scale", 4, "Pixels per module of the PNG files")
+
+	return exportTransactionQRCmd
+}
+
+func importTransactionQRCmd() *cobra.Command {
+	importTransactionQRCmd := &cobra.Command{
+		Short: "Import a transaction from the scanned texts of its QR codes",
+		Use:   "importTransactionQR [file...]",
+		Long: `Reassemble a transaction exported by exportTransactionQR from the scanned texts of its QR codes.
+
+    The files contain the texts of the QR codes, one per line, in any order. Repeated QR codes
+    are ignored. The file "-" is stdin.
+
+    A summary of the transaction and of the outputs spent by its inputs, if they were exported,
+    is printed to stderr. The raw transaction is printed to stdout, and can be signed with
+    signTransaction or broadcast with broadcastTransaction.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			texts, err := readQRChunkTexts(args, os.Stdin)
+			if err != nil {
+				return err
+			}
+
+			payload, err := assembleQRChunks(texts)
+			if err != nil {
+				return err
+			}
+
+			qt, txn, err := decodeQRTransaction(payload)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(qt)
+			}
+
+			if err := printQRTransactionSummary(os.Stderr, qt, txn); err != nil {
+				return err
+			}
+
+			fmt.Println(qt.Transaction)
+			return nil
+		},
+	}
+
+	importTransactionQRCmd.Flags().BoolP("json", "j", false, "Print the transaction, whether it is signed and the spent outputs as JSON")
+
+	return importTransactionQRCmd
+}
+
+// printQRTransactionSummary prints the summary of a transaction and of the outputs spent by its inputs
+func printQRTransactionSummary(out io.Writer, qt *qrTransaction, txn *coin.Transaction) error {
+	if err := printTransactionSummary(out, txn); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Signed: %v\n", qt.Signed)
+
+	if len(qt.Inputs) == 0 {
+		return nil
+	}
+
+	// Summarize the spent coins by address
+	spent := make(map[string][]string)
+	for _, in := range qt.Inputs {
+		spent[in.Address] = append(spent[in.Address], fmt.Sprintf("%s coins %d hours", in.Coins, in.Hours))
+	}
+
+	addrs := make([]string, 0, len(spent))
+	for a := range spent {
+		addrs = append(addrs, a)
+	}
+	sort.Strings(addrs)
+
+	fmt.Fprintln(out, "Spent outputs:")
+	for _, a := range addrs {
+		for _, s := range spent[a] {
+			fmt.Fprintf(out, "  %s  %s\n", a, s)
+		}
+	}
+
+	return nil
+}
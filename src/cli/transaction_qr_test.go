@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/util/qr"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+func TestQRChunks(t *testing.T) {
+	payload := []byte(`{"transaction":"` + testRawTxn + `","signed":true}`)
+
+	chunks, err := splitQRPayload(payload, 100)
+	require.NoError(t, err)
+	require.Len(t, chunks, 5)
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		require.Equal(t, i+1, c.Index)
+		require.Equal(t, len(chunks), c.Total)
+		require.True(t, strings.HasPrefix(c.String(), "NESSQR:1:"))
+
+		parsed, err := parseQRChunk(c.String())
+		require.NoError(t, err)
+		require.Equal(t, c, parsed)
+
+		texts[i] = c.String()
+	}
+
+	// In order
+	b, err := assembleQRChunks(texts)
+	require.NoError(t, err)
+	require.Equal(t, payload, b)
+
+	// Out of order and duplicated
+	shuffled := append([]string{}, texts...)
+	shuffled = append(shuffled, texts[1], texts[0])
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	b, err = assembleQRChunks(shuffled)
+	require.NoError(t, err)
+	require.Equal(t, payload, b)
+
+	// Missing chunks
+	_, err = assembleQRChunks(texts[1:2])
+	require.EqualError(t, err, "missing chunks 1, 3, 4, 5 of 5")
+
+	// A chunk of another payload
+	other, err := splitQRPayload([]byte(strings.Repeat("x", 400)), 100)
+	require.NoError(t, err)
+	_, err = assembleQRChunks(append(texts[:1:1], other[1].String()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not a chunk of transaction QR code")
+
+	// A repeated chunk with different data
+	c := chunks[0]
+	c.Data = strings.ToUpper(c.Data)
+	_, err = assembleQRChunks(append(append([]string{}, texts...), c.String()))
+	require.EqualError(t, err, "chunk 1/5 is repeated with different data")
+
+	// Data not matching the checksum
+	corrupt := append([]string{}, texts...)
+	corrupt[0] = c.String()
+	_, err = assembleQRChunks(corrupt)
+	require.EqualError(t, err, "reassembled payload does not match the checksum")
+
+	_, err = assembleQRChunks(nil)
+	require.EqualError(t, err, "no transaction QR code chunks")
+
+	_, err = splitQRPayload(nil, 100)
+	require.EqualError(t, err, "payload is empty")
+}
+
+func TestParseQRChunk(t *testing.T) {
+	cases := []struct {
+		name string
+		s    string
+		c    qrChunk
+		err  string
+	}{
+		{
+			name: "valid",
+			s:    "NESSQR:1:2/3:0a1b2c3d:data:with:colons",
+			c: qrChunk{
+				Index:    2,
+				Total:    3,
+				Checksum: "0a1b2c3d",
+				Data:     "data:with:colons",
+			},
+		},
+		{
+			name: "other QR code",
+			s:    "bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			err:  "not a transaction QR code",
+		},
+		{
+			name: "unsupported version",
+			s:    "NESSQR:2:1/1:0a1b2c3d:data",
+			err:  `unsupported transaction QR code version "2"`,
+		},
+		{
+			name: "index out of range",
+			s:    "NESSQR:1:4/3:0a1b2c3d:data",
+			err:  `invalid chunk position "4/3"`,
+		},
+		{
+			name: "zero index",
+			s:    "NESSQR:1:0/3:0a1b2c3d:data",
+			err:  `invalid chunk position "0/3"`,
+		},
+		{
+			name: "invalid total",
+			s:    "NESSQR:1:1/x:0a1b2c3d:data",
+			err:  `invalid chunk total "x"`,
+		},
+		{
+			name: "invalid checksum",
+			s:    "NESSQR:1:1/1:0a1b2c3g:data",
+			err:  `invalid chunk checksum "0a1b2c3g"`,
+		},
+		{
+			name: "empty data",
+			s:    "NESSQR:1:1/1:0a1b2c3d:",
+			err:  "chunk data is empty",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseQRChunk(tc.s)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.c, c)
+		})
+	}
+}
+
+func TestQRTransaction(t *testing.T) {
+	txn, err := coin.DeserializeTransactionHex(testRawTxn)
+	require.NoError(t, err)
+
+	inputs := []qrTransactionInput{{
+		Hash:    txn.In[0].Hex(),
+		Address: "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv",
+		Coins:   "1000",
+		Hours:   100,
+	}}
+
+	qt, err := newQRTransaction(&txn, inputs)
+	require.NoError(t, err)
+	require.Equal(t, testRawTxn, qt.Transaction)
+	require.True(t, qt.Signed)
+
+	payload, err := json.Marshal(qt)
+	require.NoError(t, err)
+
+	decoded, decodedTxn, err := decodeQRTransaction(payload)
+	require.NoError(t, err)
+	require.Equal(t, qt, decoded)
+	require.Equal(t, txn.Hash(), decodedTxn.Hash())
+
+	// Unsigned
+	unsigned := txn
+	unsigned.Sigs = make([]cipher.Sig, len(txn.In))
+	qt, err = newQRTransaction(&unsigned, nil)
+	require.NoError(t, err)
+	require.False(t, qt.Signed)
+	require.Empty(t, qt.Inputs)
+
+	_, err = newQRTransaction(&txn, append(inputs, inputs[0]))
+	require.EqualError(t, err, "2 inputs metadata for 1 inputs")
+
+	// Inputs metadata that does not match the inputs
+	qt.Inputs = []qrTransactionInput{{Hash: testRawTxn[:64]}}
+	payload, err = json.Marshal(qt)
+	require.NoError(t, err)
+	_, _, err = decodeQRTransaction(payload)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "inputs metadata 0 is for")
+
+	_, _, err = decodeQRTransaction([]byte(`{"transaction":"00"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid transaction")
+}
+
+func TestWriteQRChunks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txqr")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chunks, err := splitQRPayload([]byte(strings.Repeat("ab", 300)), 250)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+
+	// Text, which can be read back by importTransactionQR
+	var buf bytes.Buffer
+	require.NoError(t, writeQRChunks(&buf, chunks, qr.M, true, false, "", 4))
+	fn := filepath.Join(dir, "scanned.txt")
+	require.NoError(t, ioutil.WriteFile(fn, buf.Bytes(), 0600))
+
+	texts, err := readQRChunkTexts([]string{fn}, nil)
+	require.NoError(t, err)
+	require.Len(t, texts, 3)
+	b, err := assembleQRChunks(texts)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("ab", 300), string(b))
+
+	// ASCII
+	buf.Reset()
+	require.NoError(t, writeQRChunks(&buf, chunks, qr.M, false, false, "", 4))
+	require.Contains(t, buf.String(), "Chunk 1/3\n")
+	require.Contains(t, buf.String(), "Chunk 3/3\n")
+	require.Contains(t, buf.String(), "██")
+
+	// PNG
+	buf.Reset()
+	require.NoError(t, writeQRChunks(&buf, chunks, qr.M, false, false, dir, 2))
+	for _, name := range []string{"chunk-001-of-003.png", "chunk-002-of-003.png", "chunk-003-of-003.png"} {
+		require.Contains(t, buf.String(), name)
+		_, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+	}
+}
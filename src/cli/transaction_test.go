@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/readable"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+)
+
+func TestExplainTransactionEncoding(t *testing.T) {
+	raw := "dc00000000247bd0f0a1cf39fa51ea3eca044e4d9cbb28fff5376e90e2eb008c9fe0af384301000000cf5869cb1b21da4da98bdb5dca57b1fd5a6fcbefd37d4f1eb332b21233f92cd62e00d8e2f1c8545142eaeed8fada1158dd0e552d3be55f18dd60d7e85407ef4f000100000005e524872c838de517592c9a495d758b8ab2ec32d3e4d3fb131023a424386634020000000007445b5d6fbbb1a7d70bef941fb5da234a10fcae40420f00000000000100000000000000008001532c3a705e7e62bb0bb80630ecc21a87ec090024f400000000009805000000000000"
+
+	txn, err := pcoin.DeserializeTransactionHex(raw)
+	require.NoError(t, err)
+
+	out := explainTransactionEncoding(txn)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 15)
+
+	require.Equal(t, []string{"OFFSET", "LENGTH", "FIELD", "HEX"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"0", "4", "Length", "dc000000"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"4", "1", "Type", "00"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"142", "4", "len(Out)", "02000000"}, strings.Fields(lines[8]))
+	require.Equal(t, []string{"204", "8", "Out[1].Coins", "0024f40000000000"}, strings.Fields(lines[13]))
+	require.Equal(t, []string{"212", "8", "Out[1].Hours", "9805000000000000"}, strings.Fields(lines[14]))
+
+	// The hex column of every line is the raw transaction
+	var hexDump string
+	for _, l := range lines[1:] {
+		f := strings.Fields(l)
+		hexDump += f[len(f)-1]
+	}
+	require.Equal(t, raw, hexDump)
+}
+
+func makeAddressTxn(txid string, t uint64, ins []string, outs map[string]string) readable.TransactionWithStatusVerbose {
+	var txn readable.TransactionWithStatusVerbose
+	txn.Time = t
+	txn.Transaction.Hash = txid
+	for _, a := range ins {
+		txn.Transaction.In = append(txn.Transaction.In, readable.TransactionInput{
+			Address: a,
+		})
+	}
+	for a, coins := range outs {
+		txn.Transaction.Out = append(txn.Transaction.Out, readable.TransactionOutput{
+			Address: a,
+			Coins:   coins,
+		})
+	}
+	return txn
+}
+
+func TestFilterAddressTransactions(t *testing.T) {
+	const (
+		addr1 = "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv"
+		addr2 = "R6aHqKWSQfvpdo2fGSrq4F1RYXkBWR9HHJ"
+		other = "tWPDM36ex9zLjJw1aPMfYTVPbYgkL2Xp9V"
+	)
+
+	txns := []readable.TransactionWithStatusVerbose{
+		// Received from another address
+		makeAddressTxn("in", 100, []string{other}, map[string]string{addr1: "10.000000", other: "5.000000"}),
+		// Sent to another address, with change
+		makeAddressTxn("out", 200, []string{addr1}, map[string]string{other: "3.000000", addr1: "6.000000"}),
+		// Moved between the addresses
+		makeAddressTxn("self", 300, []string{addr1, addr2}, map[string]string{addr2: "6.000000"}),
+		// An input owner could not be resolved
+		makeAddressTxn("unknown", 400, []string{addr1, ""}, map[string]string{addr2: "1.000000", other: "2.000000"}),
+		// The genesis transaction has no inputs
+		makeAddressTxn("genesis", 50, nil, map[string]string{addr2: "100.000000"}),
+	}
+
+	addrs := []string{addr1, addr2}
+
+	type result struct {
+		txid      string
+		direction string
+		coins     string
+	}
+
+	cases := []struct {
+		name   string
+		filter addressTxnsFilter
+		result []result
+	}{
+		{
+			name: "no filter",
+			result: []result{
+				{"in", DirectionIn, "10.000000"},
+				{"out", DirectionOut, "3.000000"},
+				{"self", DirectionSelf, "6.000000"},
+				{"unknown", DirectionUnknown, "1.000000"},
+				{"genesis", DirectionIn, "100.000000"},
+			},
+		},
+		{
+			name: "direction in",
+			filter: addressTxnsFilter{
+				direction: DirectionIn,
+			},
+			result: []result{
+				{"in", DirectionIn, "10.000000"},
+				{"genesis", DirectionIn, "100.000000"},
+			},
+		},
+		{
+			name: "direction out",
+			filter: addressTxnsFilter{
+				direction: DirectionOut,
+			},
+			result: []result{
+				{"out", DirectionOut, "3.000000"},
+			},
+		},
+		{
+			name: "min coins",
+			filter: addressTxnsFilter{
+				minCoins: 6e6,
+			},
+			result: []result{
+				{"in", DirectionIn, "10.000000"},
+				{"self", DirectionSelf, "6.000000"},
+				{"genesis", DirectionIn, "100.000000"},
+			},
+		},
+		{
+			name: "after and before",
+			filter: addressTxnsFilter{
+				after:  100,
+				before: 400,
+			},
+			result: []result{
+				{"out", DirectionOut, "3.000000"},
+				{"self", DirectionSelf, "6.000000"},
+			},
+		},
+		{
+			name: "limit newest first",
+			filter: addressTxnsFilter{
+				limit: 3,
+			},
+			result: []result{
+				{"unknown", DirectionUnknown, "1.000000"},
+				{"self", DirectionSelf, "6.000000"},
+				{"out", DirectionOut, "3.000000"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, err := filterAddressTransactions(txns, addrs, tc.filter)
+			require.NoError(t, err)
+
+			res := make([]result, len(filtered))
+			for i, txn := range filtered {
+				res[i] = result{txn.Transaction.Hash, txn.Direction, txn.Coins}
+			}
+			require.Equal(t, tc.result, res)
+		})
+	}
+
+	bad := []readable.TransactionWithStatusVerbose{
+		makeAddressTxn("bad", 100, []string{other}, map[string]string{addr1: "x"}),
+	}
+	_, err := filterAddressTransactions(bad, addrs, addressTxnsFilter{})
+	require.Error(t, err)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	ts, err := parseTimestamp("1523180676")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1523180676), ts)
+
+	ts, err = parseTimestamp("2018-04-08T09:44:36Z")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1523180676), ts)
+
+	_, err = parseTimestamp("yesterday")
+	require.Error(t, err)
+
+	_, err = parseTimestamp("1969-01-01T00:00:00Z")
+	require.Error(t, err)
+}
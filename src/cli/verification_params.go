@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/util/droplet"
+
+	"github.com/ness-network/privateness/src/util/fee"
+)
+
+// VerificationParams is the response of GET /api/v1/verification-params,
+// the node's transaction verification parameters and limits
+type VerificationParams struct {
+	MaxBlockSize         uint32             `json:"max_block_size"`
+	UserVerifyTxn        readable.VerifyTxn `json:"user_verify_transaction"`
+	UnconfirmedVerifyTxn readable.VerifyTxn `json:"unconfirmed_verify_transaction"`
+	BurnFactorSchedule   []BurnFactorChange `json:"burn_factor_schedule"`
+	CoinTicker           string             `json:"coin_ticker"`
+	CoinHoursTicker      string             `json:"coin_hours_ticker"`
+	DropletExponent      uint8              `json:"droplet_exponent"`
+}
+
+// BurnFactorChange is a change of the node's user burn factor for blockchain heads
+// with a time greater than or equal to Time
+type BurnFactorChange struct {
+	Time       uint64 `json:"time"`
+	BurnFactor uint32 `json:"burn_factor"`
+}
+
+// defaultVerificationParams returns the verification parameters of this build,
+// used with nodes that don't have the verification-params endpoint
+func defaultVerificationParams() *VerificationParams {
+	return &VerificationParams{
+		MaxBlockSize:         params.UserVerifyTxn.MaxTransactionSize,
+		UserVerifyTxn:        readable.NewVerifyTxn(params.UserVerifyTxn),
+		UnconfirmedVerifyTxn: readable.NewVerifyTxn(params.UserVerifyTxn),
+		DropletExponent:      droplet.Exponent,
+	}
+}
+
+// apiGetter makes GET requests to the node's API, implemented by api.Client
+type apiGetter interface {
+	Get(endpoint string, obj interface{}) error
+}
+
+// verificationParamsCache fetches the node's verification parameters once
+type verificationParamsCache struct {
+	sync.Mutex
+	params *VerificationParams
+}
+
+// nodeVerificationParams caches the verification parameters of the node for an invocation of the CLI
+var nodeVerificationParams verificationParamsCache
+
+// get returns the node's verification parameters, fetching them on the first call.
+// Returns the default parameters if the node doesn't have the verification-params endpoint.
+func (c *verificationParamsCache) get(g apiGetter) (*VerificationParams, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.params != nil {
+		return c.params, nil
+	}
+
+	var p VerificationParams
+	if err := g.Get("/api/v1/verification-params", &p); err != nil {
+		if e, ok := err.(api.ClientError); !ok || e.StatusCode != http.StatusNotFound {
+			return nil, err
+		}
+		p = *defaultVerificationParams()
+	}
+
+	c.params = &p
+	return c.params, nil
+}
+
+// userVerifyTxn returns the constraints the node applies to user-created transactions.
+// The parameters of this build are returned if c can't make requests to the node.
+func userVerifyTxn(c interface{}) (params.VerifyTxn, error) {
+	g, ok := c.(apiGetter)
+	if !ok {
+		return params.UserVerifyTxn, nil
+	}
+
+	p, err := nodeVerificationParams.get(g)
+	if err != nil {
+		return params.VerifyTxn{}, err
+	}
+
+	v := params.VerifyTxn{
+		BurnFactor:          p.UserVerifyTxn.BurnFactor,
+		MaxTransactionSize:  p.UserVerifyTxn.MaxTransactionSize,
+		MaxDropletPrecision: p.UserVerifyTxn.MaxDropletPrecision,
+	}
+
+	if err := v.Validate(); err != nil {
+		return params.VerifyTxn{}, fmt.Errorf("invalid verification parameters of the node: %v", err)
+	}
+
+	return v, nil
+}
+
+// userBurnPolicy returns the policy of the fee the node requires of user-created transactions,
+// the burn factor of v changed at the block times of the node's burn factor schedule.
+// v.BurnFactor applies at all times if c can't make requests to the node.
+func userBurnPolicy(c interface{}, v params.VerifyTxn) (fee.BurnPolicy, error) {
+	g, ok := c.(apiGetter)
+	if !ok {
+		return fee.NewConstantBurnPolicy(v.BurnFactor), nil
+	}
+
+	p, err := nodeVerificationParams.get(g)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.BurnFactorSchedule) == 0 {
+		return fee.NewConstantBurnPolicy(v.BurnFactor), nil
+	}
+
+	schedule := make([]fee.BurnFactorChange, len(p.BurnFactorSchedule))
+	for i, c := range p.BurnFactorSchedule {
+		schedule[i] = fee.BurnFactorChange{
+			Time:       c.Time,
+			BurnFactor: c.BurnFactor,
+		}
+	}
+
+	policy, err := fee.NewScheduledBurnPolicy(v.BurnFactor, schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid burn factor schedule of the node: %v", err)
+	}
+
+	return policy, nil
+}
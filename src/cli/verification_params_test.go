@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/params"
+
+	"github.com/ness-network/privateness/src/util/fee"
+)
+
+// fakeAPIGetter returns a JSON response or an error, and counts the requests
+type fakeAPIGetter struct {
+	rsp      string
+	err      error
+	requests int
+}
+
+func (g *fakeAPIGetter) Get(endpoint string, obj interface{}) error {
+	g.requests++
+	if endpoint != "/api/v1/verification-params" {
+		return errors.New("unexpected endpoint")
+	}
+	if g.err != nil {
+		return g.err
+	}
+	return json.Unmarshal([]byte(g.rsp), obj)
+}
+
+func TestVerificationParamsCache(t *testing.T) {
+	fiberRsp := `{
+		"max_block_size": 65536,
+		"user_verify_transaction": {"burn_factor": 4, "max_transaction_size": 65536, "max_decimals": 2},
+		"unconfirmed_verify_transaction": {"burn_factor": 4, "max_transaction_size": 65536, "max_decimals": 2},
+		"coin_ticker": "FIB",
+		"coin_hours_ticker": "FH",
+		"droplet_exponent": 6
+	}`
+
+	t.Run("fetched once", func(t *testing.T) {
+		var c verificationParamsCache
+		g := &fakeAPIGetter{rsp: fiberRsp}
+
+		for i := 0; i < 3; i++ {
+			p, err := c.get(g)
+			require.NoError(t, err)
+			require.Equal(t, uint32(65536), p.MaxBlockSize)
+			require.Equal(t, uint32(4), p.UserVerifyTxn.BurnFactor)
+			require.Equal(t, uint8(2), p.UserVerifyTxn.MaxDropletPrecision)
+			require.Equal(t, "FIB", p.CoinTicker)
+		}
+		require.Equal(t, 1, g.requests)
+	})
+
+	t.Run("node without the endpoint", func(t *testing.T) {
+		var c verificationParamsCache
+		g := &fakeAPIGetter{err: api.NewClientError("404 Not Found", http.StatusNotFound, "404 Not Found")}
+
+		p, err := c.get(g)
+		require.NoError(t, err)
+		require.Equal(t, defaultVerificationParams(), p)
+
+		_, err = c.get(g)
+		require.NoError(t, err)
+		require.Equal(t, 1, g.requests)
+	})
+
+	t.Run("error is not cached", func(t *testing.T) {
+		var c verificationParamsCache
+		g := &fakeAPIGetter{err: api.NewClientError("503 Service Unavailable", http.StatusServiceUnavailable, "503 Service Unavailable")}
+
+		_, err := c.get(g)
+		require.Error(t, err)
+
+		g.err = nil
+		g.rsp = fiberRsp
+		p, err := c.get(g)
+		require.NoError(t, err)
+		require.Equal(t, "FIB", p.CoinTicker)
+		require.Equal(t, 2, g.requests)
+	})
+}
+
+func TestUserVerifyTxn(t *testing.T) {
+	defer func() {
+		nodeVerificationParams = verificationParamsCache{}
+	}()
+
+	// Clients that can't make requests to the node get the parameters of this build
+	v, err := userVerifyTxn(struct{}{})
+	require.NoError(t, err)
+	require.Equal(t, params.UserVerifyTxn, v)
+
+	nodeVerificationParams = verificationParamsCache{}
+	v, err = userVerifyTxn(&fakeAPIGetter{
+		rsp: `{"user_verify_transaction": {"burn_factor": 4, "max_transaction_size": 65536, "max_decimals": 2}}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, params.VerifyTxn{
+		BurnFactor:          4,
+		MaxTransactionSize:  65536,
+		MaxDropletPrecision: 2,
+	}, v)
+
+	nodeVerificationParams = verificationParamsCache{}
+	_, err = userVerifyTxn(&fakeAPIGetter{
+		rsp: `{"user_verify_transaction": {"burn_factor": 1, "max_transaction_size": 65536, "max_decimals": 2}}`,
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid verification parameters of the node")
+}
+
+func TestUserBurnPolicy(t *testing.T) {
+	defer func() {
+		nodeVerificationParams = verificationParamsCache{}
+	}()
+
+	v := params.VerifyTxn{
+		BurnFactor:          4,
+		MaxTransactionSize:  65536,
+		MaxDropletPrecision: 2,
+	}
+
+	// Clients that can't make requests to the node get a constant burn factor
+	p, err := userBurnPolicy(struct{}{}, v)
+	require.NoError(t, err)
+	require.Equal(t, fee.NewConstantBurnPolicy(4), p)
+
+	nodeVerificationParams = verificationParamsCache{}
+	p, err = userBurnPolicy(&fakeAPIGetter{
+		rsp: `{"user_verify_transaction": {"burn_factor": 4}}`,
+	}, v)
+	require.NoError(t, err)
+	require.Equal(t, fee.NewConstantBurnPolicy(4), p)
+
+	// The node's burn factor schedule applies
+	nodeVerificationParams = verificationParamsCache{}
+	p, err = userBurnPolicy(&fakeAPIGetter{
+		rsp: `{"user_verify_transaction": {"burn_factor": 4}, "burn_factor_schedule": [{"time": 100, "burn_factor": 2}]}`,
+	}, v)
+	require.NoError(t, err)
+	require.Equal(t, uint64(25), p.RequiredFee(100, 99))
+	require.Equal(t, uint64(50), p.RequiredFee(100, 100))
+
+	nodeVerificationParams = verificationParamsCache{}
+	_, err = userBurnPolicy(&fakeAPIGetter{
+		rsp: `{"user_verify_transaction": {"burn_factor": 4}, "burn_factor_schedule": [{"time": 100, "burn_factor": 1}]}`,
+	}, v)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid burn factor schedule of the node")
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func walletEncryptAddressCmd() *cobra.Command {
+	walletEncryptAddressCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "walletEncryptAddress [wallet] [address]",
+		Short: "Encrypt the secret key of a collection wallet address with its own password",
+		Long: `Encrypt the secret key of an address of a collection wallet with its own password.
+    The address password is then required to sign for the address, instead of the
+    wallet password, see the --address-password option of signTransaction.
+    The wallet password, if the wallet is encrypted, only protects the other addresses.
+
+    Use caution when using the "-p" and "-a" options. If you have command history enabled
+    your passwords can be recovered from the history log. If you do not include the
+    options you will be prompted to enter your passwords after you enter your command.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			return walletAddressPasswordHandler(c, args, func(w wallet.Wallet, addr cipher.Address, addrPassword []byte) error {
+				cryptoType, err := wallet.CryptoTypeFromString(c.Flag("crypto-type").Value.String())
+				if err != nil {
+					return err
+				}
+				return wallet.EncryptEntry(w, addr, addrPassword, cryptoType)
+			})
+		},
+	}
+
+	addPasswordFlags(walletEncryptAddressCmd, "wallet password, if the wallet is encrypted")
+	walletEncryptAddressCmd.Flags().StringP("address-password", "a", "", "address password")
+	walletEncryptAddressCmd.Flags().StringP("crypto-type", "x", "scrypt-chacha20poly1305", "The crypto type for address encryption, can be scrypt-chacha20poly1305 or sha256-xor")
+	return walletEncryptAddressCmd
+}
+
+func walletDecryptAddressCmd() *cobra.Command {
+	walletDecryptAddressCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "walletDecryptAddress [wallet] [address]",
+		Short: "Remove the encryption of a collection wallet address with its own password",
+		Long: `Remove the encryption of the secret key of an address of a collection wallet
+    with its own password. The secret key is protected by the wallet password again,
+    if the wallet is encrypted.
+
+    Use caution when using the "-p" and "-a" options. If you have command history enabled
+    your passwords can be recovered from the history log. If you do not include the
+    options you will be prompted to enter your passwords after you enter your command.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			return walletAddressPasswordHandler(c, args, func(w wallet.Wallet, addr cipher.Address, addrPassword []byte) error {
+				return wallet.DecryptEntry(w, addr, addrPassword)
+			})
+		},
+	}
+
+	addPasswordFlags(walletDecryptAddressCmd, "wallet password, if the wallet is encrypted")
+	walletDecryptAddressCmd.Flags().StringP("address-password", "a", "", "address password")
+	return walletDecryptAddressCmd
+}
+
+func walletAddressPasswordHandler(c *cobra.Command, args []string, f func(wallet.Wallet, cipher.Address, []byte) error) error {
+	addr, err := cipher.DecodeBase58Address(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid address: %v", err)
+	}
+
+	w, err := wallet.Load(args[0])
+	if err != nil {
+		printHelp(c)
+		return WalletLoadError{err}
+	}
+
+	var pr PasswordReader
+	if w.IsEncrypted() {
+		pr, err = passwordReaderFromFlags(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	addrPassword := []byte(c.Flag("address-password").Value.String())
+	if len(addrPassword) == 0 {
+		addrPassword, err = readAddressPassword(addr.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := updateWalletAddress(w, pr, func(w wallet.Wallet) error {
+		return f(w, addr, addrPassword)
+	}); err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(args[0]))
+	if err != nil {
+		return err
+	}
+
+	if err := wallet.Save(w, dir); err != nil {
+		return WalletLoadError{err}
+	}
+
+	return nil
+}
+
+// updateWalletAddress applies f to the wallet, decrypting it with the password of pr if pr is not nil
+func updateWalletAddress(w wallet.Wallet, pr PasswordReader, f func(wallet.Wallet) error) error {
+	if pr == nil {
+		return f(w)
+	}
+
+	password, err := pr.Password()
+	if err != nil {
+		return err
+	}
+
+	return wallet.GuardUpdate(w, password, f)
+}
+
+// readAddressPassword reads the password of an address from the terminal
+func readAddressPassword(addr string) ([]byte, error) {
+	fmt.Fprintf(os.Stdout, "password of address %s, ", addr)
+	return readPasswordFromTerminal()
+}
+
+// parseAddressPasswords parses the values of the --address-password option of signTransaction.
+// A value is either ADDRESS=PASSWORD, or ADDRESS, then the password is read with readPassword.
+func parseAddressPasswords(values []string, readPassword func(addr string) ([]byte, error)) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	passwords := make(map[string]string, len(values))
+	for _, v := range values {
+		addr := v
+		var password string
+		if i := strings.Index(v, "="); i >= 0 {
+			addr, password = v[:i], v[i+1:]
+		}
+
+		if _, err := cipher.DecodeBase58Address(addr); err != nil {
+			return nil, fmt.Errorf("invalid address %q: %v", addr, err)
+		}
+
+		if _, ok := passwords[addr]; ok {
+			return nil, fmt.Errorf("duplicate address %s", addr)
+		}
+
+		if password == "" {
+			p, err := readPassword(addr)
+			if err != nil {
+				return nil, err
+			}
+			password = string(p)
+		}
+
+		passwords[addr] = password
+	}
+
+	return passwords, nil
+}
+
+// walletNeedsPassword returns true if the wallet password is needed to sign for any
+// of the wallet's addresses, which is not the case if the wallet is not encrypted or
+// the secret keys of all its addresses are encrypted with their own password
+func walletNeedsPassword(w wallet.Wallet) bool {
+	if !w.IsEncrypted() {
+		return false
+	}
+
+	for _, e := range w.GetEntries() {
+		if !e.IsWatchOnly() && !e.IsSecretEncrypted() {
+			return true
+		}
+	}
+
+	return false
+}
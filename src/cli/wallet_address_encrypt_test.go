@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestParseAddressPasswords(t *testing.T) {
+	a1 := testutil.MakeAddress().String()
+	a2 := testutil.MakeAddress().String()
+
+	var prompted []string
+	readPassword := func(addr string) ([]byte, error) {
+		prompted = append(prompted, addr)
+		return []byte("prompted"), nil
+	}
+
+	passwords, err := parseAddressPasswords(nil, readPassword)
+	require.NoError(t, err)
+	require.Nil(t, passwords)
+
+	passwords, err = parseAddressPasswords([]string{a1 + "=pwd=1", a2}, readPassword)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		a1: "pwd=1",
+		a2: "prompted",
+	}, passwords)
+	require.Equal(t, []string{a2}, prompted)
+
+	_, err = parseAddressPasswords([]string{a1 + "=", a1 + "=pwd"}, readPassword)
+	require.EqualError(t, err, "duplicate address "+a1)
+
+	_, err = parseAddressPasswords([]string{"foo=pwd"}, readPassword)
+	require.EqualError(t, err, `invalid address "foo": Invalid address length`)
+
+	_, err = parseAddressPasswords([]string{a1}, func(string) ([]byte, error) {
+		return nil, errors.New("no terminal")
+	})
+	require.EqualError(t, err, "no terminal")
+}
+
+func TestWalletNeedsPassword(t *testing.T) {
+	w, err := wallet.NewWallet("t.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	p, s := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(p)
+	require.NoError(t, w.(*wallet.CollectionWallet).AddEntry(wallet.Entry{
+		Address: addr,
+		Public:  p,
+		Secret:  s,
+	}))
+	require.NoError(t, w.(*wallet.CollectionWallet).AddWatchAddress(testutil.MakeAddress()))
+	require.False(t, walletNeedsPassword(w))
+
+	ct := wallet.CryptoTypeScryptChacha20poly1305Insecure
+	require.NoError(t, wallet.Lock(w, []byte("wpwd"), ct))
+	require.True(t, walletNeedsPassword(w))
+
+	pr := PasswordFromBytes("wpwd")
+	require.NoError(t, updateWalletAddress(w, pr, func(w wallet.Wallet) error {
+		return wallet.EncryptEntry(w, addr, []byte("pwd"), ct)
+	}))
+	require.True(t, w.IsEncrypted())
+	require.False(t, walletNeedsPassword(w))
+}
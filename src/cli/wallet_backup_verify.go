@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func walletBackupVerifyCmd() *cobra.Command {
+	walletBackupVerifyCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "walletBackupVerify [file]",
+		Short: "Verify a wallet file without importing it",
+		Long: fmt.Sprintf(`Verify that a wallet file is intact and holds the expected addresses,
+    without loading it in a node.
+
+    The file structure is validated, and the public key of each entry is checked
+    against its address. Encrypted wallets are decrypted with the password, and
+    the secret key of each entry is checked against its public key.
+
+    The first addresses of deterministic, bip44 and xpub wallets are derived
+    again from the seed or xpub and compared to the addresses in the file.
+    For bip44 wallets, the addresses of the external chain are compared, and
+    the xpub recorded in the file, if any, is compared to the seed's account 0 xpub,
+    and every address, including change addresses, is derived again at its bip44 path.
+    Encrypted wallets are only derived again if they are decrypted.
+
+    Each --expect-address must be one of the first addresses checked.
+
+    Exit codes, in addition to the exit codes of all commands:
+      %d: the wallet file is corrupt or inconsistent with its seed
+      %d: an expected address was not found
+
+    Use caution when using the "-p" command. If you have command history enabled
+    your wallet encryption password can be recovered from the history log. If you
+    do not include the "-p" option you will be prompted to enter your password
+    after you enter your command.`, ExitCodeWalletCorrupt, ExitCodeAddressMismatch),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			noDecrypt, err := c.Flags().GetBool("no-decrypt")
+			if err != nil {
+				return err
+			}
+
+			num, err := c.Flags().GetUint64("num")
+			if err != nil {
+				return err
+			}
+			if num == 0 {
+				return fmt.Errorf("num must be > 0")
+			}
+
+			expected, err := c.Flags().GetStringSlice("expect-address")
+			if err != nil {
+				return err
+			}
+
+			expectedAddrs := make([]cipher.Address, len(expected))
+			for i, a := range expected {
+				expectedAddrs[i], err = cipher.DecodeBase58Address(a)
+				if err != nil {
+					return fmt.Errorf("invalid expected address %q: %v", a, err)
+				}
+			}
+
+			var pr PasswordReader
+			if !noDecrypt {
+				pr, err = passwordReaderFromFlags(c)
+				if err != nil {
+					return err
+				}
+			}
+
+			result, err := verifyWalletBackup(args[0], pr, num, expectedAddrs)
+			if err != nil {
+				return err
+			}
+
+			return printJSON(result)
+		},
+	}
+
+	addPasswordFlags(walletBackupVerifyCmd, "Wallet password")
+	walletBackupVerifyCmd.Flags().Bool("no-decrypt", false, "Do not decrypt an encrypted wallet. Its secret keys are not checked and its addresses are not derived again")
+	walletBackupVerifyCmd.Flags().Uint64P("num", "n", 10, "Number of addresses to derive again and compare")
+	walletBackupVerifyCmd.Flags().StringSlice("expect-address", nil, "Address expected in the first addresses of the wallet, can be repeated")
+
+	return walletBackupVerifyCmd
+}
+
+// WalletBackupVerifyResult is the output of walletBackupVerify
+type WalletBackupVerifyResult struct {
+	Filename  string `json:"filename"`
+	Type      string `json:"type"`
+	Encrypted bool   `json:"encrypted"`
+	Decrypted bool   `json:"decrypted"`
+	Entries   int    `json:"entries"`
+	// Derived is true if the first addresses were derived again from the seed or xpub
+	Derived bool `json:"derived"`
+	// Addresses are the first addresses of the wallet that were checked
+	Addresses []string `json:"addresses"`
+}
+
+// verifyWalletBackup verifies a wallet file. Encrypted wallets are decrypted with the password of pr,
+// unless pr is nil. Up to num addresses are derived again and compared, and each of expected must be
+// one of them. Returns an ExitError for a corrupt wallet, a wrong password or a missing expected address.
+func verifyWalletBackup(filename string, pr PasswordReader, num uint64, expected []cipher.Address) (*WalletBackupVerifyResult, error) {
+	if _, err := os.Stat(filename); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ExitError{
+				error: err,
+				Code:  ExitCodeWalletNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	corrupt := func(err error) error {
+		return ExitError{
+			error: fmt.Errorf("wallet %s is corrupt: %v", filename, err),
+			Code:  ExitCodeWalletCorrupt,
+		}
+	}
+
+	w, err := wallet.Load(filename)
+	if err != nil {
+		return nil, corrupt(err)
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, corrupt(err)
+	}
+
+	for i, e := range w.GetEntries() {
+		if e.IsWatchOnly() {
+			continue
+		}
+		if err := e.VerifyPublic(); err != nil {
+			return nil, corrupt(fmt.Errorf("entry %d: %v", i, err))
+		}
+	}
+
+	result := &WalletBackupVerifyResult{
+		Filename:  filename,
+		Type:      w.Type(),
+		Encrypted: w.IsEncrypted(),
+		Entries:   w.EntriesLen(),
+	}
+
+	// dw is the wallet with its secrets, nil if they are not available
+	var dw wallet.Wallet
+	switch {
+	case !w.IsEncrypted():
+		dw = w
+	case pr != nil:
+		password, err := pr.Password()
+		if err != nil {
+			return nil, err
+		}
+
+		dw, err = wallet.Unlock(w, password)
+		switch err {
+		case nil:
+			defer dw.Erase()
+			result.Decrypted = true
+		case wallet.ErrInvalidPassword:
+			return nil, ExitError{
+				error: err,
+				Code:  ExitCodeWrongPassword,
+			}
+		case wallet.ErrMissingPassword:
+			return nil, err
+		default:
+			return nil, corrupt(err)
+		}
+	}
+
+	if dw != nil {
+		for i, e := range dw.GetEntries() {
+			if e.IsWatchOnly() {
+				continue
+			}
+			if err := e.Verify(); err != nil {
+				return nil, corrupt(fmt.Errorf("entry %d: %v", i, err))
+			}
+		}
+	}
+
+	stored := walletFirstAddresses(w, num)
+
+	derived, err := deriveWalletAddresses(w, dw, num)
+	if err != nil {
+		return nil, corrupt(err)
+	}
+
+	addrs := stored
+	if derived != nil {
+		result.Derived = true
+		for i, a := range stored {
+			if i < len(derived) && a != derived[i] {
+				return nil, corrupt(fmt.Errorf("address %d is %s but the seed derives %s", i, a, derived[i]))
+			}
+		}
+		addrs = derived
+	}
+
+	if dw != nil && w.Type() == wallet.WalletTypeBip44 && w.XPub() != "" {
+		xpub, err := bip44AccountXPub(dw)
+		if err != nil {
+			return nil, corrupt(err)
+		}
+		if xpub != w.XPub() {
+			return nil, corrupt(fmt.Errorf("xpub %s does not match the seed's account 0 xpub %s", w.XPub(), xpub))
+		}
+	}
+
+	if bw, ok := dw.(*wallet.Bip44Wallet); ok {
+		mismatches, err := bw.VerifyEntryPaths()
+		if err != nil {
+			return nil, corrupt(err)
+		}
+		if len(mismatches) != 0 {
+			msgs := make([]string, len(mismatches))
+			for i, m := range mismatches {
+				msgs[i] = m.String()
+			}
+			return nil, corrupt(errors.New(strings.Join(msgs, "; ")))
+		}
+	}
+
+	result.Addresses = make([]string, len(addrs))
+	found := make(map[cipher.Address]struct{}, len(addrs))
+	for i, a := range addrs {
+		result.Addresses[i] = a.String()
+		found[a] = struct{}{}
+	}
+
+	var missing []string
+	for _, a := range expected {
+		if _, ok := found[a]; !ok {
+			missing = append(missing, a.String())
+		}
+	}
+	if len(missing) != 0 {
+		return nil, ExitError{
+			error: fmt.Errorf("expected addresses not found in the first %d addresses: %s", len(addrs), strings.Join(missing, ", ")),
+			Code:  ExitCodeAddressMismatch,
+		}
+	}
+
+	return result, nil
+}
+
+// walletFirstAddresses returns up to num addresses stored in a wallet, in order.
+// For bip44 wallets, the addresses of the external chain are returned.
+func walletFirstAddresses(w wallet.Wallet, num uint64) []cipher.Address {
+	var entries wallet.Entries
+	if bw, ok := w.(*wallet.Bip44Wallet); ok {
+		entries = bw.ExternalEntries
+	} else {
+		entries = w.GetEntries()
+	}
+
+	if uint64(len(entries)) > num {
+		entries = entries[:num]
+	}
+
+	addrs := make([]cipher.Address, len(entries))
+	for i, e := range entries {
+		addrs[i] = e.SkycoinAddress()
+	}
+	return addrs
+}
+
+// deriveWalletAddresses derives the first num addresses of a wallet again, from its seed or xpub.
+// dw is the wallet with its secrets, nil if they are not available.
+// Returns nil if the addresses can't be derived: for collection wallets, and for encrypted wallets without dw.
+func deriveWalletAddresses(w, dw wallet.Wallet, num uint64) ([]cipher.Address, error) {
+	opts := wallet.Options{
+		Type:      w.Type(),
+		Coin:      w.Coin(),
+		GenerateN: num,
+	}
+
+	switch w.Type() {
+	case wallet.WalletTypeDeterministic, wallet.WalletTypeBip44:
+		if dw == nil {
+			return nil, nil
+		}
+		opts.Seed = dw.Seed()
+		if w.Type() == wallet.WalletTypeBip44 {
+			opts.SeedPassphrase = dw.SeedPassphrase()
+			bip44Coin := w.Bip44Coin()
+			opts.Bip44Coin = &bip44Coin
+		}
+	case wallet.WalletTypeXPub:
+		opts.XPub = w.XPub()
+	default:
+		return nil, nil
+	}
+
+	nw, err := wallet.NewWallet(w.Filename(), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer nw.Erase()
+
+	return walletFirstAddresses(nw, num), nil
+}
+
+// bip44AccountXPub returns the account 0 xpub of a bip44 wallet with its seed
+func bip44AccountXPub(w wallet.Wallet) (string, error) {
+	bw, ok := w.(*wallet.Bip44Wallet)
+	if !ok {
+		return "", fmt.Errorf("wallet type is %q, not %q", w.Type(), wallet.WalletTypeBip44)
+	}
+
+	return bw.AccountXPub()
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+// editWalletFile applies f to the JSON of a wallet file
+func editWalletFile(t *testing.T, filename string, f func(w map[string]interface{})) {
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	var w map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &w))
+
+	f(w)
+
+	b, err = json.Marshal(w)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filename, b, 0600))
+}
+
+func requireExitCode(t *testing.T, code int, err error) {
+	require.Error(t, err)
+	require.Equal(t, code, ExitCode(err), err.Error())
+}
+
+func TestVerifyWalletBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet-backup-verify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	newWallet := func(name string, opts wallet.Options) (string, wallet.Wallet) {
+		w, err := wallet.NewWallet(name, opts)
+		require.NoError(t, err)
+		require.NoError(t, wallet.Save(w, dir))
+		return filepath.Join(dir, name), w
+	}
+
+	password := []byte("pwd")
+	seed := bip39.MustNewDefaultMnemonic()
+
+	t.Run("deterministic", func(t *testing.T) {
+		fn, w := newWallet("det.wlt", wallet.Options{
+			Type:      wallet.WalletTypeDeterministic,
+			Seed:      "seed",
+			GenerateN: 3,
+		})
+		addrs, err := w.GetSkycoinAddresses()
+		require.NoError(t, err)
+
+		r, err := verifyWalletBackup(fn, nil, 10, []cipher.Address{addrs[2]})
+		require.NoError(t, err)
+		require.Equal(t, &WalletBackupVerifyResult{
+			Filename:  fn,
+			Type:      wallet.WalletTypeDeterministic,
+			Entries:   3,
+			Derived:   true,
+			Addresses: derivedAddressStrings(t, wallet.WalletTypeDeterministic, "seed", 10),
+		}, r)
+
+		// The expected address must be in the first num addresses
+		_, err = verifyWalletBackup(fn, nil, 2, []cipher.Address{addrs[2]})
+		requireExitCode(t, ExitCodeAddressMismatch, err)
+
+		_, err = verifyWalletBackup(fn, nil, 10, []cipher.Address{testutil.MakeAddress()})
+		requireExitCode(t, ExitCodeAddressMismatch, err)
+
+		// An entry replaced with a valid key pair that does not derive from the seed
+		pk, sk := cipher.GenerateKeyPair()
+		editWalletFile(t, fn, func(w map[string]interface{}) {
+			e := w["entries"].([]interface{})[1].(map[string]interface{})
+			e["address"] = cipher.AddressFromPubKey(pk).String()
+			e["public_key"] = pk.Hex()
+			e["secret_key"] = sk.Hex()
+		})
+		_, err = verifyWalletBackup(fn, nil, 10, nil)
+		requireExitCode(t, ExitCodeWalletCorrupt, err)
+
+		// An address that does not match its public key
+		editWalletFile(t, fn, func(w map[string]interface{}) {
+			e := w["entries"].([]interface{})[1].(map[string]interface{})
+			e["address"] = addrs[0].String()
+		})
+		_, err = verifyWalletBackup(fn, nil, 10, nil)
+		requireExitCode(t, ExitCodeWalletCorrupt, err)
+	})
+
+	t.Run("encrypted bip44", func(t *testing.T) {
+		fn, w := newWallet("bip44.wlt", wallet.Options{
+			Type:           wallet.WalletTypeBip44,
+			Seed:           seed,
+			SeedPassphrase: "passphrase",
+			GenerateN:      2,
+			Encrypt:        true,
+			Password:       password,
+			CryptoType:     wallet.CryptoTypeSha256Xor,
+		})
+		addrs, err := w.GetSkycoinAddresses()
+		require.NoError(t, err)
+
+		_, err = verifyWalletBackup(fn, PasswordFromBytes("wrong"), 5, nil)
+		requireExitCode(t, ExitCodeWrongPassword, err)
+
+		r, err := verifyWalletBackup(fn, PasswordFromBytes(password), 5, []cipher.Address{addrs[1]})
+		require.NoError(t, err)
+		require.True(t, r.Encrypted)
+		require.True(t, r.Decrypted)
+		require.True(t, r.Derived)
+		require.Len(t, r.Addresses, 5)
+		require.Equal(t, addrs[0].String(), r.Addresses[0])
+
+		// Without decrypting, only the stored addresses are checked
+		r, err = verifyWalletBackup(fn, nil, 5, []cipher.Address{addrs[1]})
+		require.NoError(t, err)
+		require.False(t, r.Decrypted)
+		require.False(t, r.Derived)
+		require.Equal(t, []string{addrs[0].String(), addrs[1].String()}, r.Addresses)
+
+		// The recorded xpub must match the seed
+		dw, err := wallet.Unlock(w, password)
+		require.NoError(t, err)
+		xpub, err := bip44AccountXPub(dw)
+		require.NoError(t, err)
+
+		editWalletFile(t, fn, func(w map[string]interface{}) {
+			w["meta"].(map[string]interface{})["xpub"] = xpub
+		})
+		_, err = verifyWalletBackup(fn, PasswordFromBytes(password), 5, nil)
+		require.NoError(t, err)
+
+		otherXPub, err := bip44AccountXPub(mustBip44Wallet(t, bip39.MustNewDefaultMnemonic()))
+		require.NoError(t, err)
+		editWalletFile(t, fn, func(w map[string]interface{}) {
+			w["meta"].(map[string]interface{})["xpub"] = otherXPub
+		})
+		_, err = verifyWalletBackup(fn, PasswordFromBytes(password), 5, nil)
+		requireExitCode(t, ExitCodeWalletCorrupt, err)
+	})
+
+	t.Run("bip44 paths", func(t *testing.T) {
+		fn, w := newWallet("bip44-paths.wlt", wallet.Options{
+			Type:      wallet.WalletTypeBip44,
+			Seed:      seed,
+			GenerateN: 2,
+		})
+		_, err := w.(*wallet.Bip44Wallet).GenerateChangeEntry()
+		require.NoError(t, err)
+		require.NoError(t, wallet.Save(w, dir))
+
+		_, err = verifyWalletBackup(fn, nil, 5, nil)
+		require.NoError(t, err)
+
+		// Every address, including change addresses, must be derived at its bip44 path
+		editWalletFile(t, fn, func(w map[string]interface{}) {
+			e := w["entries"].([]interface{})[2].(map[string]interface{})
+			e["child_number"] = 5
+			delete(e, "path")
+		})
+		_, err = verifyWalletBackup(fn, nil, 5, nil)
+		requireExitCode(t, ExitCodeWalletCorrupt, err)
+		require.Contains(t, err.Error(), "is not the address")
+		require.Contains(t, err.Error(), "derived at m/44'/8000'/0'/1/5")
+	})
+
+	t.Run("collection", func(t *testing.T) {
+		fn, _ := newWallet("collection.wlt", wallet.Options{
+			Type: wallet.WalletTypeCollection,
+		})
+
+		r, err := verifyWalletBackup(fn, nil, 5, nil)
+		require.NoError(t, err)
+		require.False(t, r.Derived)
+		require.Empty(t, r.Addresses)
+	})
+
+	t.Run("corrupt file", func(t *testing.T) {
+		fn := filepath.Join(dir, "truncated.wlt")
+		require.NoError(t, ioutil.WriteFile(fn, []byte(`{"meta":{"type":"deterministic"`), 0600))
+
+		_, err := verifyWalletBackup(fn, nil, 5, nil)
+		requireExitCode(t, ExitCodeWalletCorrupt, err)
+
+		// A missing file is not a corrupt wallet
+		_, err = verifyWalletBackup(filepath.Join(dir, "missing.wlt"), nil, 5, nil)
+		requireExitCode(t, ExitCodeWalletNotFound, err)
+	})
+}
+
+func derivedAddressStrings(t *testing.T, walletType, seed string, n uint64) []string {
+	w, err := wallet.NewWallet("derived.wlt", wallet.Options{
+		Type:      walletType,
+		Seed:      seed,
+		GenerateN: n,
+	})
+	require.NoError(t, err)
+
+	addrs, err := w.GetSkycoinAddresses()
+	require.NoError(t, err)
+
+	s := make([]string, len(addrs))
+	for i, a := range addrs {
+		s[i] = a.String()
+	}
+	return s
+}
+
+func mustBip44Wallet(t *testing.T, seed string) wallet.Wallet {
+	w, err := wallet.NewWallet("other.wlt", wallet.Options{
+		Type: wallet.WalletTypeBip44,
+		Seed: seed,
+	})
+	require.NoError(t, err)
+	return w
+}
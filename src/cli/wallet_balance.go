@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/droplet"
+)
+
+// clearScreen moves the cursor to the top left corner of the terminal and clears it
+const clearScreen = "\033[H\033[2J"
+
+func walletBalanceCmd() *cobra.Command {
+	walletBalanceCmd := &cobra.Command{
+		Short: "Check the balance of a wallet",
+		Use:   "walletBalance [wallet]",
+		Long: `Check the balance of a wallet.
+
+    Prints the confirmed, spendable and predicted coins and coin hours of the wallet,
+    and the coins and coin hours received and spent by unconfirmed transactions.
+    The predicted balance is the spendable balance plus the pending incoming balance.
+
+    Use --verbose to list the balance of each address, with its label, sorted by balance.
+    Use --watch to refresh the balance every N seconds, until interrupted.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         checkWltBalance,
+	}
+
+	walletBalanceCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+	walletBalanceCmd.Flags().BoolP("verbose", "v", false, "List the balance of each address")
+	walletBalanceCmd.Flags().IntP("watch", "w", 0, "Refresh the balance every N seconds, until interrupted. 0 to print it once")
+
+	return walletBalanceCmd
+}
+
+func checkWltBalance(c *cobra.Command, args []string) error {
+	w := args[0]
+
+	jsonOutput, err := c.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+
+	verbose, err := c.Flags().GetBool("verbose")
+	if err != nil {
+		return err
+	}
+
+	watch, err := c.Flags().GetInt("watch")
+	if err != nil {
+		return err
+	}
+	if watch < 0 {
+		return errors.New("watch must be >= 0")
+	}
+
+	printBalance := func() error {
+		balRlt, err := CheckWalletBalance(apiClient, w)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(balRlt)
+		}
+
+		return writeWalletBalance(os.Stdout, balRlt, verbose, params.UserVerifyTxn.MaxDropletPrecision)
+	}
+
+	if watch == 0 {
+		err := printBalance()
+		if _, ok := err.(WalletLoadError); ok {
+			printHelp(c)
+		}
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	interval := time.Duration(watch) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fmt.Print(clearScreen)
+		fmt.Printf("Every %v, updated at %s\n\n", interval, time.Now().Format(time.RFC3339))
+
+		// Keep watching if the node can't be reached, the error is shown until the next refresh
+		if err := printBalance(); err != nil {
+			if _, ok := err.(WalletLoadError); ok {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, err)
+		}
+
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeWalletBalance writes the balance of a wallet as text tables, with coins formatted
+// with at least decimals decimal places. If verbose is true, the balance of each address is listed,
+// sorted by confirmed then predicted coins, descending.
+func writeWalletBalance(out io.Writer, bal *WalletBalanceResult, verbose bool, decimals uint8) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "\tCOINS\tHOURS")
+	for _, r := range []struct {
+		name string
+		b    Balance
+	}{
+		{"Confirmed", bal.Confirmed},
+		{"Spendable", bal.Spendable},
+		{"Predicted", bal.Expected},
+		{"Pending incoming", bal.PendingIncoming},
+		{"Pending outgoing", bal.PendingOutgoing},
+	} {
+		coins, err := formatCoins(r.b.Coins, decimals)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.name, coins, r.b.Hours)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !verbose {
+		return nil
+	}
+
+	type addressRow struct {
+		AddressBalances
+		confirmed, expected uint64
+	}
+
+	rows := make([]addressRow, len(bal.Addresses))
+	for i, a := range bal.Addresses {
+		confirmed, err := droplet.FromString(a.Confirmed.Coins)
+		if err != nil {
+			return err
+		}
+
+		expected, err := droplet.FromString(a.Expected.Coins)
+		if err != nil {
+			return err
+		}
+
+		rows[i] = addressRow{
+			AddressBalances: a,
+			confirmed:       confirmed,
+			expected:        expected,
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].confirmed != rows[j].confirmed {
+			return rows[i].confirmed > rows[j].confirmed
+		}
+		return rows[i].expected > rows[j].expected
+	})
+
+	fmt.Fprintln(out)
+
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tLABEL\tCOINS\tHOURS\tPREDICTED COINS\tPREDICTED HOURS")
+	for _, r := range rows {
+		coins, err := formatCoins(r.Confirmed.Coins, decimals)
+		if err != nil {
+			return err
+		}
+
+		expectedCoins, err := formatCoins(r.Expected.Coins, decimals)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Address, r.Label, coins, r.Confirmed.Hours, expectedCoins, r.Expected.Hours)
+	}
+
+	return w.Flush()
+}
+
+// formatCoins formats a coin amount with at least decimals decimal places,
+// and more only if the amount has more significant decimal places.
+// For example, with 3 decimals, "1.500000" becomes "1.500" and "1.000001" is unchanged.
+func formatCoins(coins string, decimals uint8) (string, error) {
+	amt, err := droplet.FromString(coins)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := droplet.ToString(amt)
+	if err != nil {
+		return "", err
+	}
+
+	point := strings.IndexByte(s, '.')
+	end := len(s)
+	for end > point+1+int(decimals) && s[end-1] == '0' {
+		end--
+	}
+
+	if end == point+1 {
+		end = point
+	}
+
+	return s[:end], nil
+}
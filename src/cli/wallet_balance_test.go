@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestFormatCoins(t *testing.T) {
+	cases := []struct {
+		coins    string
+		decimals uint8
+		expect   string
+	}{
+		{"0", 3, "0.000"},
+		{"1.5", 3, "1.500"},
+		{"1.000001", 3, "1.000001"},
+		{"1.0001", 3, "1.0001"},
+		{"100", 0, "100"},
+		{"100.1", 0, "100.1"},
+		{"123.456789", 6, "123.456789"},
+		{"10", 6, "10.000000"},
+	}
+
+	for _, tc := range cases {
+		s, err := formatCoins(tc.coins, tc.decimals)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, s, "formatCoins(%q, %d)", tc.coins, tc.decimals)
+	}
+
+	_, err := formatCoins("1.0000001", 3)
+	require.Error(t, err)
+}
+
+func TestGetWalletBalance(t *testing.T) {
+	addrs := []string{
+		testutil.MakeAddress().String(),
+		testutil.MakeAddress().String(),
+	}
+
+	hashes := make([]string, 3)
+	for i := range hashes {
+		hashes[i] = testutil.RandSHA256(t).Hex()
+	}
+
+	outs := readable.UnspentOutputsSummary{
+		HeadOutputs: readable.UnspentOutputs{
+			{
+				Hash:            hashes[0],
+				Address:         addrs[0],
+				Coins:           "10.000000",
+				CalculatedHours: 100,
+			},
+			{
+				Hash:            hashes[1],
+				Address:         addrs[1],
+				Coins:           "2.500000",
+				CalculatedHours: 20,
+			},
+		},
+		OutgoingOutputs: readable.UnspentOutputs{
+			{
+				Hash:            hashes[1],
+				Address:         addrs[1],
+				Coins:           "2.500000",
+				CalculatedHours: 20,
+			},
+		},
+		IncomingOutputs: readable.UnspentOutputs{
+			{
+				Hash:            hashes[2],
+				Address:         addrs[1],
+				Coins:           "1.000000",
+				CalculatedHours: 5,
+			},
+		},
+	}
+
+	bal, err := getWalletBalance(&outs, addrs, map[string]string{
+		addrs[1]: "savings",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, &WalletBalanceResult{
+		BalanceResult: BalanceResult{
+			Confirmed: Balance{Coins: "12.500000", Hours: "120"},
+			Spendable: Balance{Coins: "10.000000", Hours: "100"},
+			Expected:  Balance{Coins: "11.000000", Hours: "105"},
+			Addresses: []AddressBalances{
+				{
+					Confirmed: Balance{Coins: "10.000000", Hours: "100"},
+					Spendable: Balance{Coins: "10.000000", Hours: "100"},
+					Expected:  Balance{Coins: "10.000000", Hours: "100"},
+					Address:   addrs[0],
+				},
+				{
+					Confirmed: Balance{Coins: "2.500000", Hours: "20"},
+					Spendable: Balance{Coins: "0.000000", Hours: "0"},
+					Expected:  Balance{Coins: "1.000000", Hours: "5"},
+					Address:   addrs[1],
+					Label:     "savings",
+				},
+			},
+		},
+		PendingIncoming: Balance{Coins: "1.000000", Hours: "5"},
+		PendingOutgoing: Balance{Coins: "2.500000", Hours: "20"},
+	}, bal)
+}
+
+func TestWriteWalletBalance(t *testing.T) {
+	bal := &WalletBalanceResult{
+		BalanceResult: BalanceResult{
+			Confirmed: Balance{Coins: "12.500000", Hours: "120"},
+			Spendable: Balance{Coins: "10.000000", Hours: "100"},
+			Expected:  Balance{Coins: "11.000000", Hours: "105"},
+			Addresses: []AddressBalances{
+				{
+					Confirmed: Balance{Coins: "0.000000", Hours: "0"},
+					Expected:  Balance{Coins: "0.000000", Hours: "0"},
+					Address:   "a",
+				},
+				{
+					Confirmed: Balance{Coins: "2.500000", Hours: "20"},
+					Expected:  Balance{Coins: "1.000000", Hours: "5"},
+					Address:   "b",
+					Label:     "savings",
+				},
+				{
+					Confirmed: Balance{Coins: "0.000000", Hours: "0"},
+					Expected:  Balance{Coins: "0.000001", Hours: "0"},
+					Address:   "c",
+				},
+				{
+					Confirmed: Balance{Coins: "10.000000", Hours: "100"},
+					Expected:  Balance{Coins: "10.000000", Hours: "100"},
+					Address:   "d",
+				},
+			},
+		},
+		PendingIncoming: Balance{Coins: "1.000000", Hours: "5"},
+		PendingOutgoing: Balance{Coins: "2.500000", Hours: "20"},
+	}
+
+	summary := `                  COINS   HOURS
+Confirmed         12.500  120
+Spendable         10.000  100
+Predicted         11.000  105
+Pending incoming  1.000   5
+Pending outgoing  2.500   20
+`
+
+	var buf bytes.Buffer
+	require.NoError(t, writeWalletBalance(&buf, bal, false, 3))
+	require.Equal(t, summary, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeWalletBalance(&buf, bal, true, 3))
+	require.Equal(t, summary+`
+ADDRESS  LABEL    COINS   HOURS  PREDICTED COINS  PREDICTED HOURS
+d                 10.000  100    10.000           100
+b        savings  2.500   20     1.000            5
+c                 0.000   0      0.000001         0
+a                 0.000   0      0.000            0
+`, buf.String())
+}
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func walletImportAddressesCmd() *cobra.Command {
+	walletImportAddressesCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "walletImportAddresses [wallet]",
+		Short: "Import watch addresses into a collection wallet from a file",
+		Long: `Import watch addresses into a "collection" type wallet from a file.
+
+    The file has one address per line. Blank lines are ignored, and anything
+    following a "#" on a line is a comment.
+
+    Watch addresses have no keys. The wallet tracks their balance and history,
+    but can't sign transactions spending from them.
+
+    Addresses that are already in the wallet, or repeated in the file, are skipped.
+    If any address is invalid, the wallet is not modified, unless --skip-invalid is set.
+    The wallet is saved once, after all addresses are added.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			walletFile := args[0]
+
+			addrsFile, err := c.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+			if addrsFile == "" {
+				return errors.New("--file is required")
+			}
+
+			create, err := c.Flags().GetBool("create")
+			if err != nil {
+				return err
+			}
+
+			skipInvalid, err := c.Flags().GetBool("skip-invalid")
+			if err != nil {
+				return err
+			}
+
+			res, err := ImportAddressesToFile(walletFile, addrsFile, create, skipInvalid)
+			if res != nil {
+				printImportAddressesResult(res)
+			}
+
+			switch err.(type) {
+			case nil:
+				return nil
+			case WalletLoadError:
+				printHelp(c)
+				return err
+			default:
+				return err
+			}
+		},
+	}
+
+	walletImportAddressesCmd.Flags().StringP("file", "f", "", "File with one address per line")
+	walletImportAddressesCmd.Flags().Bool("create", false, "Create a collection wallet if the wallet file does not exist")
+	walletImportAddressesCmd.Flags().Bool("skip-invalid", false, "Import the valid addresses even if some addresses are invalid")
+
+	return walletImportAddressesCmd
+}
+
+// ImportAddressLine is a line of an addresses file that was not imported
+type ImportAddressLine struct {
+	Line    int
+	Address string
+	Reason  string
+}
+
+// ImportAddressesResult summarizes an import of watch addresses
+type ImportAddressesResult struct {
+	Added   []string
+	Skipped []ImportAddressLine
+	Invalid []ImportAddressLine
+}
+
+func printImportAddressesResult(res *ImportAddressesResult) {
+	for _, l := range res.Skipped {
+		fmt.Printf("warning: line %d: skipped %s: %s\n", l.Line, l.Address, l.Reason)
+	}
+
+	for _, l := range res.Invalid {
+		fmt.Printf("line %d: invalid address %q: %s\n", l.Line, l.Address, l.Reason)
+	}
+
+	fmt.Printf("added: %d, skipped: %d, invalid: %d\n", len(res.Added), len(res.Skipped), len(res.Invalid))
+}
+
+type addressLine struct {
+	line    int
+	address string
+}
+
+// readAddressLines reads one address per line, ignoring blank lines and "#" comments
+func readAddressLines(r io.Reader) ([]addressLine, error) {
+	var lines []addressLine
+
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+
+		s := scanner.Text()
+		if i := strings.Index(s, "#"); i != -1 {
+			s = s[:i]
+		}
+
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		lines = append(lines, addressLine{
+			line:    n,
+			address: s,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func decodeAddress(coinType wallet.CoinType, s string) (cipher.Addresser, error) {
+	switch coinType {
+	case wallet.CoinTypeBitcoin:
+		return cipher.DecodeBase58BitcoinAddress(s)
+	default:
+		return cipher.DecodeBase58Address(s)
+	}
+}
+
+// ImportAddresses adds the addresses read from r to a collection wallet as watch-only entries.
+// Caller should save the wallet afterwards
+func ImportAddresses(wlt *wallet.CollectionWallet, r io.Reader) (*ImportAddressesResult, error) {
+	lines, err := readAddressLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{}, wlt.EntriesLen())
+	for _, a := range wlt.GetAddresses() {
+		existing[a.String()] = struct{}{}
+	}
+
+	res := &ImportAddressesResult{}
+	seen := make(map[string]int, len(lines))
+	for _, l := range lines {
+		a, err := decodeAddress(wlt.Coin(), l.address)
+		if err != nil {
+			res.Invalid = append(res.Invalid, ImportAddressLine{
+				Line:    l.line,
+				Address: l.address,
+				Reason:  err.Error(),
+			})
+			continue
+		}
+
+		if line, ok := seen[a.String()]; ok {
+			res.Skipped = append(res.Skipped, ImportAddressLine{
+				Line:    l.line,
+				Address: l.address,
+				Reason:  fmt.Sprintf("duplicate of line %d", line),
+			})
+			continue
+		}
+		seen[a.String()] = l.line
+
+		if _, ok := existing[a.String()]; ok {
+			res.Skipped = append(res.Skipped, ImportAddressLine{
+				Line:    l.line,
+				Address: l.address,
+				Reason:  "already in wallet",
+			})
+			continue
+		}
+
+		if err := wlt.AddWatchAddress(a); err != nil {
+			return nil, err
+		}
+
+		res.Added = append(res.Added, a.String())
+	}
+
+	return res, nil
+}
+
+// ImportAddressesToFile imports watch addresses from addrsFile into a collection wallet based on filename.
+// If create is true and the wallet does not exist, a collection wallet is created.
+// If any address is invalid, the wallet is not saved unless skipInvalid is true.
+// The result is returned with errors caused by invalid addresses.
+func ImportAddressesToFile(walletFile, addrsFile string, create, skipInvalid bool) (*ImportAddressesResult, error) {
+	var wlt wallet.Wallet
+	_, err := os.Stat(walletFile)
+	switch {
+	case err == nil:
+		wlt, err = wallet.Load(walletFile)
+		if err != nil {
+			return nil, WalletLoadError{err}
+		}
+	case os.IsNotExist(err) && create:
+		wlt, err = wallet.NewWallet(filepath.Base(walletFile), wallet.Options{
+			Type:  wallet.WalletTypeCollection,
+			Coin:  wallet.CoinTypeSkycoin,
+			Label: strings.TrimSuffix(filepath.Base(walletFile), walletExt),
+		})
+		if err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err):
+		return nil, WalletLoadError{fmt.Errorf("wallet %q doesn't exist. Use --create to create it", walletFile)}
+	default:
+		return nil, err
+	}
+
+	if wlt.Type() != wallet.WalletTypeCollection {
+		return nil, fmt.Errorf("only %q type wallets can have watch addresses imported", wallet.WalletTypeCollection)
+	}
+
+	f, err := os.Open(addrsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res, err := ImportAddresses(wlt.(*wallet.CollectionWallet), f)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Invalid) > 0 && !skipInvalid {
+		res.Added = nil
+		return res, fmt.Errorf("%d invalid addresses, the wallet was not modified. Use --skip-invalid to import the valid addresses", len(res.Invalid))
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(walletFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wallet.Save(wlt, dir); err != nil {
+		return nil, WalletSaveError{err}
+	}
+
+	return res, nil
+}
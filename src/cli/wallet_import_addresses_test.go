@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/wallet"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestImportAddressesToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	addrs := []string{
+		testutil.MakeAddress().String(),
+		testutil.MakeAddress().String(),
+		testutil.MakeAddress().String(),
+	}
+
+	addrsFile := filepath.Join(dir, "addrs.txt")
+	writeAddrs := func(lines ...string) {
+		err := ioutil.WriteFile(addrsFile, []byte(strings.Join(lines, "\n")), 0600)
+		require.NoError(t, err)
+	}
+
+	walletFile := filepath.Join(dir, "watch.wlt")
+
+	writeAddrs(
+		"# watch addresses",
+		addrs[0],
+		"",
+		fmt.Sprintf("  %s  # cold storage", addrs[1]),
+		addrs[0],
+	)
+
+	// The wallet must exist unless --create is set
+	_, err = ImportAddressesToFile(walletFile, addrsFile, false, false)
+	require.IsType(t, WalletLoadError{}, err)
+
+	res, err := ImportAddressesToFile(walletFile, addrsFile, true, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{addrs[0], addrs[1]}, res.Added)
+	require.Equal(t, []ImportAddressLine{
+		{Line: 5, Address: addrs[0], Reason: "duplicate of line 2"},
+	}, res.Skipped)
+	require.Empty(t, res.Invalid)
+
+	w, err := wallet.Load(walletFile)
+	require.NoError(t, err)
+	require.Equal(t, wallet.WalletTypeCollection, w.Type())
+	require.Equal(t, "watch", w.Label())
+	require.Equal(t, 2, w.EntriesLen())
+	require.True(t, w.GetEntryAt(0).IsWatchOnly())
+
+	// Invalid addresses abort the import without modifying the wallet
+	writeAddrs(
+		addrs[1],
+		"notanaddress",
+		addrs[2],
+	)
+
+	res, err = ImportAddressesToFile(walletFile, addrsFile, false, false)
+	require.Error(t, err)
+	require.Empty(t, res.Added)
+	require.Equal(t, []ImportAddressLine{
+		{Line: 1, Address: addrs[1], Reason: "already in wallet"},
+	}, res.Skipped)
+	require.Len(t, res.Invalid, 1)
+	require.Equal(t, 2, res.Invalid[0].Line)
+
+	w, err = wallet.Load(walletFile)
+	require.NoError(t, err)
+	require.Equal(t, 2, w.EntriesLen())
+
+	// --skip-invalid imports the valid addresses
+	res, err = ImportAddressesToFile(walletFile, addrsFile, false, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{addrs[2]}, res.Added)
+	require.Len(t, res.Invalid, 1)
+
+	w, err = wallet.Load(walletFile)
+	require.NoError(t, err)
+	require.Equal(t, 3, w.EntriesLen())
+
+	// Only collection wallets can import watch addresses
+	detWallet, err := wallet.NewWallet("det.wlt", wallet.Options{
+		Type:  wallet.WalletTypeDeterministic,
+		Seed:  "seed",
+		Label: "det",
+	})
+	require.NoError(t, err)
+	require.NoError(t, wallet.Save(detWallet, dir))
+
+	_, err = ImportAddressesToFile(filepath.Join(dir, "det.wlt"), addrsFile, false, true)
+	require.EqualError(t, err, `only "collection" type wallets can have watch addresses imported`)
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/api"
+)
+
+// walletLabelPrefix prefixes a wallet label given instead of a wallet id or file, e.g. label:savings
+const walletLabelPrefix = "label:"
+
+// walletIDFromLabel returns the id of the node's wallet with the label.
+// It fails if no wallet has the label, or if several wallets have it, listing them.
+func walletIDFromLabel(c *api.Client, label string) (string, error) {
+	if label == "" {
+		return "", ExitError{
+			error: fmt.Errorf("missing wallet label after %q", walletLabelPrefix),
+			Code:  ExitCodeUsage,
+		}
+	}
+
+	v := url.Values{}
+	v.Add("label", label)
+
+	var wrs []api.WalletResponse
+	if err := c.Get("/api/v1/wallets?"+v.Encode(), &wrs); err != nil {
+		return "", err
+	}
+
+	ids := make([]string, 0, len(wrs))
+	for _, w := range wrs {
+		// Older nodes ignore the label parameter and return all wallets
+		if w.Meta.Label == label {
+			ids = append(ids, w.Meta.Filename)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", ExitError{
+			error: fmt.Errorf("no wallet of the node has the label %q", label),
+			Code:  ExitCodeWalletNotFound,
+		}
+	case 1:
+		return ids[0], nil
+	default:
+		sort.Strings(ids)
+		return "", ExitError{
+			error: fmt.Errorf("wallet label %q is ambiguous, it matches the wallets %s", label, strings.Join(ids, ", ")),
+			Code:  ExitCodeUsage,
+		}
+	}
+}
+
+// walletFileFromLabel returns the file of the node's wallet with the label, in the node's wallet directory
+func walletFileFromLabel(c *api.Client, label string) (string, error) {
+	id, err := walletIDFromLabel(c, label)
+	if err != nil {
+		return "", err
+	}
+
+	folder, err := c.WalletFolderName()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(folder.Address, id), nil
+}
+
+// withWalletLabel makes the wallet argument of a command, its first argument, accept label:NAME.
+// The label is resolved with resolve, walletIDFromLabel or walletFileFromLabel, before the command runs,
+// so that all wallet commands resolve labels the same way.
+func withWalletLabel(cmd *cobra.Command, resolve func(*api.Client, string) (string, error)) *cobra.Command {
+	runE := cmd.RunE
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		if len(args) > 0 && strings.HasPrefix(args[0], walletLabelPrefix) {
+			w, err := resolve(apiClient, strings.TrimPrefix(args[0], walletLabelPrefix))
+			if err != nil {
+				return err
+			}
+
+			args = append([]string{w}, args[1:]...)
+		}
+
+		return runE(c, args)
+	}
+
+	return cmd
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/readable"
+)
+
+func TestWalletLabelResolution(t *testing.T) {
+	wallets := map[string]string{
+		"2017_05_a1b2.wlt": "savings",
+		"2018_01_c3d4.wlt": "shared",
+		"2019_07_e5f6.wlt": "shared",
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp interface{}
+		switch r.URL.Path {
+		case "/api/v1/wallets":
+			wrs := []api.WalletResponse{}
+			for id, label := range wallets {
+				if label == r.URL.Query().Get("label") {
+					wrs = append(wrs, api.WalletResponse{
+						Meta: readable.WalletMeta{
+							Filename: id,
+							Label:    label,
+						},
+					})
+				}
+			}
+			resp = wrs
+		case "/api/v1/wallets/folderName":
+			resp = api.WalletFolder{
+				Address: "/home/user/.skycoin/wallets",
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck
+	}))
+	defer s.Close()
+
+	c, err := newAPIClient(s.URL, 0)
+	require.NoError(t, err)
+
+	id, err := walletIDFromLabel(c, "savings")
+	require.NoError(t, err)
+	require.Equal(t, "2017_05_a1b2.wlt", id)
+
+	f, err := walletFileFromLabel(c, "savings")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("/home/user/.skycoin/wallets", "2017_05_a1b2.wlt"), f)
+
+	_, err = walletIDFromLabel(c, "missing")
+	require.EqualError(t, err, `no wallet of the node has the label "missing"`)
+	require.Equal(t, ExitCodeWalletNotFound, ExitCode(err))
+
+	_, err = walletIDFromLabel(c, "shared")
+	require.EqualError(t, err, `wallet label "shared" is ambiguous, it matches the wallets 2018_01_c3d4.wlt, 2019_07_e5f6.wlt`)
+	require.Equal(t, ExitCodeUsage, ExitCode(err))
+
+	_, err = walletIDFromLabel(c, "")
+	require.Equal(t, ExitCodeUsage, ExitCode(err))
+
+	// The wallet argument of a wrapped command is resolved before the command runs
+	defer func(c *api.Client) {
+		apiClient = c
+	}(apiClient)
+	apiClient = c
+
+	var got []string
+	cmd := withWalletLabel(&cobra.Command{
+		RunE: func(_ *cobra.Command, args []string) error {
+			got = args
+			return nil
+		},
+	}, walletIDFromLabel)
+
+	require.NoError(t, cmd.RunE(cmd, []string{"label:savings", "foo"}))
+	require.Equal(t, []string{"2017_05_a1b2.wlt", "foo"}, got)
+
+	require.NoError(t, cmd.RunE(cmd, []string{"2018_01_c3d4.wlt"}))
+	require.Equal(t, []string{"2018_01_c3d4.wlt"}, got)
+
+	require.Error(t, cmd.RunE(cmd, []string{"label:shared"}))
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func walletMigrateCmd() *cobra.Command {
+	walletMigrateCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "walletMigrate [id]",
+		Short: "Migrate a deterministic wallet of the node to a bip44 wallet",
+		Long: `Migrate a deterministic wallet of the node to a bip44 wallet created from the same mnemonic.
+    The bip44 wallet is scanned for balances, then the confirmed funds of the deterministic
+    wallet are sent to the first addresses of the bip44 wallet, in as few transactions as the
+    transaction constraints allow.
+
+    The deterministic wallet is never deleted. Funds that could not be moved, e.g. outputs
+    spent by pending transactions, are reported in the warnings. Run the command again later
+    to move them, it reuses the bip44 wallet.
+
+    With --dry-run, the transactions are planned but the bip44 wallet is not created
+    and no transaction is signed or broadcast.
+
+    Use caution when using the "-p" command. If you have command
+    history enabled your wallet encryption password can be recovered
+    from the history log. If you do not include the "-p" option you will
+    be prompted to enter your password after you enter your command.`,
+		SilenceUsage: true,
+		RunE:         walletMigrateHandler,
+	}
+
+	walletMigrateCmd.Flags().String("to", wallet.WalletTypeBip44, "Wallet type to migrate to, only \"bip44\" is supported")
+	walletMigrateCmd.Flags().Uint32("bip44-coin", 0, "BIP44 coin type of the new wallet (default 8000, skycoin's coin type)")
+	walletMigrateCmd.Flags().String("label", "", "Label of the new wallet (default the label of the migrated wallet)")
+	walletMigrateCmd.Flags().Uint64("scan", 10, "Number of addresses of the new wallet to scan for balances")
+	walletMigrateCmd.Flags().Bool("dry-run", false, "Plan the migration without creating the wallet or broadcasting transactions")
+	addPasswordFlags(walletMigrateCmd, "Wallet password")
+
+	return walletMigrateCmd
+}
+
+func walletMigrateHandler(c *cobra.Command, args []string) error {
+	id := args[0]
+
+	to, err := c.Flags().GetString("to")
+	if err != nil {
+		return err
+	}
+
+	label, err := c.Flags().GetString("label")
+	if err != nil {
+		return err
+	}
+
+	scanN, err := c.Flags().GetUint64("scan")
+	if err != nil {
+		return err
+	}
+
+	dryRun, err := c.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("to", to)
+	v.Add("label", label)
+	v.Add("scan", fmt.Sprint(scanN))
+	v.Add("dry-run", fmt.Sprint(dryRun))
+
+	if c.Flags().Changed("bip44-coin") {
+		coinType, err := c.Flags().GetUint32("bip44-coin")
+		if err != nil {
+			return err
+		}
+		v.Add("bip44-coin", fmt.Sprint(coinType))
+	}
+
+	// The password is only read if the node's wallet is encrypted, to avoid an unnecessary prompt
+	wlt, err := apiClient.Wallet(id)
+	if err != nil {
+		return err
+	}
+
+	if wlt.Meta.Encrypted {
+		pr, err := passwordReaderFromFlags(c)
+		if err != nil {
+			return err
+		}
+
+		password, err := pr.Password()
+		if err != nil {
+			return err
+		}
+		v.Add("password", string(password))
+	}
+
+	var rsp json.RawMessage
+	if err := apiClient.PostForm("/api/v1/wallet/migrate", strings.NewReader(v.Encode()), &rsp); err != nil {
+		return err
+	}
+
+	return printJSON(rsp)
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func walletRestoreBackupCmd() *cobra.Command {
+	walletRestoreBackupCmd := &cobra.Command{
+		Args:  cobra.RangeArgs(1, 2),
+		Use:   "walletRestoreBackup [wallet] [backup]",
+		Short: "List or restore the automatic backups of a wallet",
+		Long: fmt.Sprintf(`List or restore the automatic backups of a wallet.
+
+    The node backs up a wallet file to the "%s" directory in the wallet
+    directory before overwriting it. Backups are copies of the wallet file,
+    so backups of an encrypted wallet are encrypted.
+
+    With only the wallet argument, the wallet's backups are listed, oldest first.
+    With a backup filename, the wallet file is replaced with the backup.
+    The wallet file being replaced is backed up first.
+
+    Stop the node before restoring a backup of a wallet the node has loaded,
+    otherwise the node will overwrite the restored wallet file.`, wallet.BackupsDir),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			walletFile := args[0]
+			dir, err := filepath.Abs(filepath.Dir(walletFile))
+			if err != nil {
+				return err
+			}
+			wltID := filepath.Base(walletFile)
+
+			if len(args) == 1 {
+				backups, err := wallet.ListBackups(dir, wltID)
+				if err != nil {
+					return err
+				}
+
+				return printJSON(newWalletBackupsResult(wltID, backups))
+			}
+
+			maxBackups, err := c.Flags().GetInt("max-backups")
+			if err != nil {
+				return err
+			}
+
+			if err := wallet.RestoreBackup(dir, wltID, args[1], maxBackups); err != nil {
+				return err
+			}
+
+			fmt.Printf("restored %s from %s\n", walletFile, args[1])
+			return nil
+		},
+	}
+
+	walletRestoreBackupCmd.Flags().Int("max-backups", wallet.DefaultMaxBackups, "Number of backups to keep when backing up the replaced wallet file")
+
+	return walletRestoreBackupCmd
+}
+
+// WalletBackup is a backup of a wallet file
+type WalletBackup struct {
+	Filename string `json:"filename"`
+	Time     int64  `json:"time"`
+	Size     int64  `json:"size"`
+}
+
+// WalletBackupsResult is the output of walletRestoreBackup when listing backups
+type WalletBackupsResult struct {
+	WalletID string         `json:"wallet_id"`
+	Backups  []WalletBackup `json:"backups"`
+}
+
+func newWalletBackupsResult(wltID string, backups []wallet.Backup) WalletBackupsResult {
+	ret := WalletBackupsResult{
+		WalletID: wltID,
+		Backups:  make([]WalletBackup, len(backups)),
+	}
+	for i, b := range backups {
+		ret.Backups[i] = WalletBackup{
+			Filename: b.Filename,
+			Time:     b.Time.Unix(),
+			Size:     b.Size,
+		}
+	}
+	return ret
+}
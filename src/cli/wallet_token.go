@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"net/http"
+)
+
+// walletTokenHeader is the header of a wallet access token, when the Authorization header
+// is used for the HTTP basic auth of the API
+const walletTokenHeader = "X-Wallet-Token"
+
+// walletTokenTransport is an http.RoundTripper that adds a wallet access token to requests.
+// The token is sent as "Authorization: Bearer <token>", or in the X-Wallet-Token header
+// if the request has basic auth credentials.
+type walletTokenTransport struct {
+	token     string
+	transport http.RoundTripper
+}
+
+func newWalletTokenTransport(token string, transport http.RoundTripper) *walletTokenTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &walletTokenTransport{
+		token:     token,
+		transport: transport,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *walletTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	if req.Header.Get("Authorization") != "" {
+		req.Header.Set(walletTokenHeader, t.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletTokenTransport(t *testing.T) {
+	var header http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	}))
+	defer s.Close()
+
+	for _, tc := range []struct {
+		name          string
+		username      string
+		authorization string
+		walletToken   string
+	}{
+		{
+			name:          "bearer",
+			authorization: "Bearer foo",
+		},
+		{
+			name:          "basic auth",
+			username:      "user",
+			authorization: "Basic dXNlcjpwYXNz",
+			walletToken:   "foo",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := newAPIClient(s.URL, 0)
+			require.NoError(t, err)
+			if tc.username != "" {
+				c.SetAuth(tc.username, "pass")
+			}
+			c.HTTPClient.Transport = newWalletTokenTransport("foo", c.HTTPClient.Transport)
+
+			var obj struct{}
+			require.NoError(t, c.Get("/api/v1/wallet?id=foo.wlt", &obj))
+			require.Equal(t, tc.authorization, header.Get("Authorization"))
+			require.Equal(t, tc.walletToken, header.Get(walletTokenHeader))
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const defaultWatchTransactionInterval = time.Second * 2
+
+// Events of the transaction watched by watchTransaction
+const (
+	// txnWatchEventNotFound is emitted once if the node doesn't know the transaction when the watch starts
+	txnWatchEventNotFound = "not_found"
+	// txnWatchEventPending is emitted when the transaction is seen in the unconfirmed pool
+	txnWatchEventPending = "pending"
+	// txnWatchEventAnnounced is emitted when the node announced the transaction to peers again
+	txnWatchEventAnnounced = "announced"
+	// txnWatchEventInvalid is emitted when the transaction is not valid at the last check of the pool
+	txnWatchEventInvalid = "invalid"
+	// txnWatchEventValid is emitted when an invalid transaction is valid again at the last check of the pool
+	txnWatchEventValid = "valid"
+	// txnWatchEventConfirmed is emitted when the transaction is in a block. It is a terminal event
+	txnWatchEventConfirmed = "confirmed"
+	// txnWatchEventEvicted is emitted when the transaction left the pool without being confirmed. It is a terminal event
+	txnWatchEventEvicted = "evicted"
+	// txnWatchEventTimeout is emitted when --timeout expires before a terminal event
+	txnWatchEventTimeout = "timeout"
+)
+
+func watchTransactionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "Follow a transaction until it is confirmed or evicted",
+		Use:   "watchTransaction [transaction id]",
+		Long: `Follow a transaction across the node's unconfirmed pool and the blockchain,
+    and print its status transitions with timestamps: seen in the pool, announced to peers,
+    invalid at the last check, confirmed in a block, or evicted from the pool.
+
+    The command exits when the transaction is confirmed or evicted, or when --timeout expires.
+    A transaction that is already confirmed is reported right away.
+
+    With --json, each event is printed as a JSON object on its own line.`,
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  watchTransactionHandler,
+	}
+
+	cmd.Flags().BoolP("json", "j", false, "Print the events as JSON objects, one per line")
+	cmd.Flags().Duration("interval", defaultWatchTransactionInterval, "Interval between the status checks")
+	cmd.Flags().Duration("timeout", 0, "Stop watching after this duration, e.g. 10m. 0 for no limit")
+
+	return cmd
+}
+
+func watchTransactionHandler(c *cobra.Command, args []string) error {
+	txid := args[0]
+	if _, err := cipher.SHA256FromHex(txid); err != nil {
+		return usageError(c, fmt.Errorf("invalid txid: %v", err))
+	}
+
+	jsonOutput, err := c.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+
+	interval, err := c.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return usageError(c, errors.New("invalid --interval value"))
+	}
+
+	timeout, err := c.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+	if timeout < 0 {
+		return usageError(c, errors.New("invalid --timeout value"))
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	w := newTxnWatcher(txid)
+
+	for {
+		obs, err := observeTransaction(apiClient, txid)
+		switch {
+		case err == nil:
+			for _, e := range w.update(time.Now(), obs) {
+				if err := writeTxnWatchEvent(os.Stdout, e, jsonOutput); err != nil {
+					return err
+				}
+			}
+		case isTransientNodeError(err):
+			// Keep watching if the node can't be reached for a moment
+			fmt.Fprintln(os.Stderr, err)
+		default:
+			return err
+		}
+
+		if w.done {
+			return w.err
+		}
+
+		select {
+		case <-interrupt:
+			return nil
+		case <-expired:
+			e := w.timeout(time.Now(), timeout)
+			if err := writeTxnWatchEvent(os.Stdout, e, jsonOutput); err != nil {
+				return err
+			}
+			return w.err
+		case <-ticker.C:
+		}
+	}
+}
+
+// txnObservation is the status of a transaction at one check
+type txnObservation struct {
+	// Found is false if the node doesn't know the transaction
+	Found     bool
+	Confirmed bool
+	BlockSeq  uint64
+	BlockHash string
+	// AnnounceCount, Valid and ConflictsWith are set for unconfirmed transactions
+	AnnounceCount uint64
+	Valid         bool
+	ConflictsWith []string
+}
+
+// watchPendingTxn holds the fields of /api/v1/pendingTxs?verbose=1 used by watchTransaction
+type watchPendingTxn struct {
+	Transaction struct {
+		Hash string `json:"txid"`
+	} `json:"transaction"`
+	ConflictsWith      []string `json:"conflicts_with"`
+	AnnounceCount      uint64   `json:"announce_count"`
+	IsValidAtLastCheck bool     `json:"is_valid_at_last_check"`
+}
+
+// errTxnObservationRace is returned if an unconfirmed transaction left the pool between two requests.
+// The transaction is checked again at the next interval.
+var errTxnObservationRace = errors.New("transaction left the unconfirmed pool while it was checked")
+
+// observeTransaction returns the status of a transaction from the node
+func observeTransaction(c *api.Client, txid string) (txnObservation, error) {
+	txn, err := c.Transaction(txid)
+	if err != nil {
+		if e, ok := err.(api.ClientError); ok && e.StatusCode == http.StatusNotFound {
+			return txnObservation{}, nil
+		}
+		return txnObservation{}, err
+	}
+
+	if txn.Status.Confirmed {
+		b, err := c.BlockBySeq(txn.Status.BlockSeq)
+		if err != nil {
+			return txnObservation{}, err
+		}
+
+		return txnObservation{
+			Found:     true,
+			Confirmed: true,
+			BlockSeq:  b.Head.BkSeq,
+			BlockHash: b.Head.Hash,
+		}, nil
+	}
+
+	var pending []watchPendingTxn
+	if err := c.Get("/api/v1/pendingTxs?verbose=1", &pending); err != nil {
+		return txnObservation{}, err
+	}
+
+	for _, p := range pending {
+		if p.Transaction.Hash == txid {
+			return txnObservation{
+				Found:         true,
+				AnnounceCount: p.AnnounceCount,
+				Valid:         p.IsValidAtLastCheck,
+				ConflictsWith: p.ConflictsWith,
+			}, nil
+		}
+	}
+
+	return txnObservation{}, errTxnObservationRace
+}
+
+// isTransientNodeError returns true for the errors after which watchTransaction keeps watching
+func isTransientNodeError(err error) bool {
+	switch e := err.(type) {
+	case api.ClientError:
+		return e.StatusCode >= http.StatusInternalServerError
+	case *url.Error, net.Error:
+		return true
+	}
+	return err == errTxnObservationRace
+}
+
+// txnWatchEvent is a status transition of a watched transaction
+type txnWatchEvent struct {
+	Time          time.Time `json:"time"`
+	Event         string    `json:"event"`
+	Txid          string    `json:"txid"`
+	AnnounceCount *uint64   `json:"announce_count,omitempty"`
+	BlockSeq      *uint64   `json:"block_seq,omitempty"`
+	BlockHash     string    `json:"block_hash,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// txnWatcher turns the observations of a transaction into status transitions
+type txnWatcher struct {
+	txid          string
+	notFound      bool
+	pending       bool
+	announceCount uint64
+	invalid       bool
+	conflictsWith []string
+	// done is true after a terminal event, err is the error the command returns then
+	done bool
+	err  error
+}
+
+func newTxnWatcher(txid string) *txnWatcher {
+	return &txnWatcher{
+		txid: txid,
+	}
+}
+
+// update returns the events of the transition from the last observation to obs
+func (w *txnWatcher) update(now time.Time, obs txnObservation) []txnWatchEvent {
+	if w.done {
+		return nil
+	}
+
+	newEvent := func(event string) txnWatchEvent {
+		return txnWatchEvent{
+			Time:  now,
+			Event: event,
+			Txid:  w.txid,
+		}
+	}
+
+	switch {
+	case obs.Confirmed:
+		e := newEvent(txnWatchEventConfirmed)
+		e.BlockSeq = &obs.BlockSeq
+		e.BlockHash = obs.BlockHash
+		w.done = true
+		return []txnWatchEvent{e}
+
+	case !obs.Found && w.pending:
+		e := newEvent(txnWatchEventEvicted)
+		e.Reason = w.evictionReason()
+		w.done = true
+		w.err = ExitError{
+			error: fmt.Errorf("transaction %s was evicted: %s", w.txid, e.Reason),
+			Code:  ExitCodeTransactionRejected,
+		}
+		return []txnWatchEvent{e}
+
+	case !obs.Found:
+		if w.notFound {
+			return nil
+		}
+		w.notFound = true
+		return []txnWatchEvent{newEvent(txnWatchEventNotFound)}
+	}
+
+	var events []txnWatchEvent
+
+	count := obs.AnnounceCount
+	switch {
+	case !w.pending:
+		e := newEvent(txnWatchEventPending)
+		e.AnnounceCount = &count
+		events = append(events, e)
+	case count > w.announceCount:
+		e := newEvent(txnWatchEventAnnounced)
+		e.AnnounceCount = &count
+		events = append(events, e)
+	}
+	w.pending = true
+	w.announceCount = count
+
+	switch {
+	case !obs.Valid && !w.invalid:
+		e := newEvent(txnWatchEventInvalid)
+		e.Reason = invalidReason(obs.ConflictsWith)
+		events = append(events, e)
+	case obs.Valid && w.invalid:
+		events = append(events, newEvent(txnWatchEventValid))
+	}
+	w.invalid = !obs.Valid
+	w.conflictsWith = obs.ConflictsWith
+
+	return events
+}
+
+// timeout returns the event of an expired --timeout and stops the watch
+func (w *txnWatcher) timeout(now time.Time, d time.Duration) txnWatchEvent {
+	w.done = true
+	w.err = fmt.Errorf("transaction %s was neither confirmed nor evicted after %s", w.txid, d)
+	return txnWatchEvent{
+		Time:   now,
+		Event:  txnWatchEventTimeout,
+		Txid:   w.txid,
+		Reason: fmt.Sprintf("not confirmed after %s", d),
+	}
+}
+
+// evictionReason describes why the transaction left the pool, as far as the last observation tells.
+// The node doesn't record why it removed a transaction.
+func (w *txnWatcher) evictionReason() string {
+	reason := "removed from the unconfirmed pool without being confirmed"
+	if w.invalid {
+		reason += ", " + invalidReason(w.conflictsWith)
+	}
+	return reason
+}
+
+func invalidReason(conflictsWith []string) string {
+	if len(conflictsWith) == 0 {
+		return "not valid against the blockchain at the last check"
+	}
+	return fmt.Sprintf("not valid against the blockchain at the last check, conflicts with %s", strings.Join(conflictsWith, ", "))
+}
+
+// writeTxnWatchEvent writes an event as a timestamped line, or as a JSON object on one line if jsonOutput is true
+func writeTxnWatchEvent(out io.Writer, e txnWatchEvent, jsonOutput bool) error {
+	if jsonOutput {
+		d, err := json.Marshal(e)
+		if err != nil {
+			return ErrJSONMarshal
+		}
+		_, err = fmt.Fprintln(out, string(d))
+		return err
+	}
+
+	var msg string
+	switch e.Event {
+	case txnWatchEventNotFound:
+		msg = "not known to the node, waiting for it"
+	case txnWatchEventPending:
+		msg = fmt.Sprintf("seen in the unconfirmed pool, announced %d times", *e.AnnounceCount)
+	case txnWatchEventAnnounced:
+		msg = fmt.Sprintf("announced to peers, %d times", *e.AnnounceCount)
+	case txnWatchEventInvalid:
+		msg = e.Reason
+	case txnWatchEventValid:
+		msg = "valid again at the last check"
+	case txnWatchEventConfirmed:
+		msg = fmt.Sprintf("confirmed in block %d (%s)", *e.BlockSeq, e.BlockHash)
+	case txnWatchEventEvicted, txnWatchEventTimeout:
+		msg = e.Reason
+	}
+
+	_, err := fmt.Fprintf(out, "%s %-9s %s\n", e.Time.Format(time.RFC3339), e.Event, msg)
+	return err
+}
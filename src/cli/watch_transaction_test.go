@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxnWatcher(t *testing.T) {
+	txid := "824d421a25f81aa7565d042a54b3e1e8fdc58bed4eefe8f8a90748da6d77d135"
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := func(es []txnWatchEvent) []string {
+		var names []string
+		for _, e := range es {
+			names = append(names, e.Event)
+		}
+		return names
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		w := newTxnWatcher(txid)
+
+		// Not known yet, reported once
+		require.Equal(t, []string{txnWatchEventNotFound}, events(w.update(now, txnObservation{})))
+		require.Empty(t, w.update(now, txnObservation{}))
+
+		es := w.update(now, txnObservation{Found: true, Valid: true})
+		require.Equal(t, []string{txnWatchEventPending}, events(es))
+		require.Equal(t, uint64(0), *es[0].AnnounceCount)
+
+		// Nothing changed
+		require.Empty(t, w.update(now, txnObservation{Found: true, Valid: true}))
+
+		es = w.update(now, txnObservation{Found: true, Valid: true, AnnounceCount: 2})
+		require.Equal(t, []string{txnWatchEventAnnounced}, events(es))
+		require.Equal(t, uint64(2), *es[0].AnnounceCount)
+
+		es = w.update(now, txnObservation{Found: true, Valid: false, AnnounceCount: 2, ConflictsWith: []string{"abc"}})
+		require.Equal(t, []string{txnWatchEventInvalid}, events(es))
+		require.Contains(t, es[0].Reason, "conflicts with abc")
+
+		require.Equal(t, []string{txnWatchEventValid}, events(w.update(now, txnObservation{Found: true, Valid: true, AnnounceCount: 2})))
+
+		es = w.update(now, txnObservation{Found: true, Confirmed: true, BlockSeq: 12, BlockHash: "def"})
+		require.Equal(t, []string{txnWatchEventConfirmed}, events(es))
+		require.Equal(t, uint64(12), *es[0].BlockSeq)
+		require.Equal(t, "def", es[0].BlockHash)
+		require.True(t, w.done)
+		require.NoError(t, w.err)
+
+		require.Empty(t, w.update(now, txnObservation{}))
+	})
+
+	t.Run("already confirmed", func(t *testing.T) {
+		w := newTxnWatcher(txid)
+		es := w.update(now, txnObservation{Found: true, Confirmed: true, BlockSeq: 0, BlockHash: "def"})
+		require.Equal(t, []string{txnWatchEventConfirmed}, events(es))
+		require.Equal(t, uint64(0), *es[0].BlockSeq)
+		require.True(t, w.done)
+		require.NoError(t, w.err)
+	})
+
+	t.Run("evicted", func(t *testing.T) {
+		w := newTxnWatcher(txid)
+		require.Equal(t, []string{txnWatchEventPending, txnWatchEventInvalid}, events(w.update(now, txnObservation{Found: true})))
+
+		es := w.update(now, txnObservation{})
+		require.Equal(t, []string{txnWatchEventEvicted}, events(es))
+		require.Equal(t, "removed from the unconfirmed pool without being confirmed, not valid against the blockchain at the last check", es[0].Reason)
+		require.True(t, w.done)
+		require.Equal(t, ExitCodeTransactionRejected, ExitCode(w.err))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		w := newTxnWatcher(txid)
+		require.Equal(t, []string{txnWatchEventNotFound}, events(w.update(now, txnObservation{})))
+
+		e := w.timeout(now, time.Minute)
+		require.Equal(t, txnWatchEventTimeout, e.Event)
+		require.True(t, w.done)
+		require.Error(t, w.err)
+		require.Equal(t, ExitCodeError, ExitCode(w.err))
+	})
+}
+
+func TestWriteTxnWatchEvent(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := uint64(12)
+	e := txnWatchEvent{
+		Time:      now,
+		Event:     txnWatchEventConfirmed,
+		Txid:      "824d421a25f81aa7565d042a54b3e1e8fdc58bed4eefe8f8a90748da6d77d135",
+		BlockSeq:  &seq,
+		BlockHash: "def",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeTxnWatchEvent(&buf, e, false))
+	require.Equal(t, "2020-01-01T00:00:00Z confirmed confirmed in block 12 (def)\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeTxnWatchEvent(&buf, e, true))
+	require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	var obj map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &obj))
+	require.Equal(t, map[string]interface{}{
+		"time":       "2020-01-01T00:00:00Z",
+		"event":      "confirmed",
+		"txid":       e.Txid,
+		"block_seq":  float64(12),
+		"block_hash": "def",
+	}, obj)
+}
@@ -6,10 +6,14 @@ This package should not have any dependencies except for go stdlib and cipher.
 package coin
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/mathutil"
 )
 
 //go:generate skyencoder -struct BlockHeader -unexported
@@ -106,6 +110,100 @@ func NewGenesisBlock(genesisAddr cipher.Address, genesisCoins, timestamp uint64)
 	return b, nil
 }
 
+// NewGenesisBlockMulti creates a genesis block whose transaction has multiple outputs,
+// for chains that distribute the initial coin supply at genesis.
+// The outputs must have coins and no coin hours, see VerifyGenesisOutputs.
+func NewGenesisBlockMulti(outputs []TransactionOutput, timestamp uint64) (*Block, error) {
+	if _, err := genesisOutputsCoins(outputs); err != nil {
+		return nil, err
+	}
+
+	txn := Transaction{}
+	for _, o := range outputs {
+		if err := txn.PushOutput(o.Address, o.Coins, o.Hours); err != nil {
+			return nil, err
+		}
+	}
+
+	body := BlockBody{Transactions: Transactions{txn}}
+	head := BlockHeader{
+		Time:     timestamp,
+		BodyHash: body.Hash(),
+		PrevHash: cipher.SHA256{},
+		BkSeq:    0,
+		Version:  0,
+		Fee:      0,
+		UxHash:   cipher.SHA256{},
+	}
+
+	return &Block{
+		Head: head,
+		Body: body,
+	}, nil
+}
+
+// VerifyGenesisOutputs verifies the outputs of a multi-output genesis block.
+// The outputs' coins must sum to coinVolume, the declared coin supply of the genesis block.
+// The outputs must have no coin hours, the coin hours of the distributed coins accumulate from the genesis time.
+// An address can't receive two outputs with the same coins, the outputs would have the same hash.
+func VerifyGenesisOutputs(outputs []TransactionOutput, coinVolume uint64) error {
+	coins, err := genesisOutputsCoins(outputs)
+	if err != nil {
+		return err
+	}
+
+	if coins != coinVolume {
+		return fmt.Errorf("genesis outputs coins %d do not match the genesis coin volume %d", coins, coinVolume)
+	}
+
+	return nil
+}
+
+// genesisOutputsCoins verifies the outputs of a multi-output genesis block and returns their total coins
+func genesisOutputsCoins(outputs []TransactionOutput) (uint64, error) {
+	if len(outputs) == 0 {
+		return 0, errors.New("genesis block has no outputs")
+	}
+
+	if len(outputs) > math.MaxUint16 {
+		return 0, fmt.Errorf("genesis block has too many outputs, the max is %d", math.MaxUint16)
+	}
+
+	type outputKey struct {
+		address cipher.Address
+		coins   uint64
+	}
+	seen := make(map[outputKey]struct{}, len(outputs))
+
+	var coins uint64
+	for i, o := range outputs {
+		if o.Coins == 0 {
+			return 0, fmt.Errorf("genesis output %d has zero coins", i)
+		}
+
+		if o.Hours != 0 {
+			return 0, fmt.Errorf("genesis output %d has coin hours, genesis outputs must have zero coin hours", i)
+		}
+
+		k := outputKey{
+			address: o.Address,
+			coins:   o.Coins,
+		}
+		if _, ok := seen[k]; ok {
+			return 0, fmt.Errorf("genesis output %d duplicates an output to %s with %d coins", i, o.Address, o.Coins)
+		}
+		seen[k] = struct{}{}
+
+		var err error
+		coins, err = mathutil.AddUint64(coins, o.Coins)
+		if err != nil {
+			return 0, errors.New("genesis outputs coins overflow")
+		}
+	}
+
+	return coins, nil
+}
+
 // HashHeader return hash of block head.
 func (b Block) HashHeader() cipher.SHA256 {
 	return b.Head.Hash()
@@ -126,6 +224,56 @@ func (b Block) Size() (uint32, error) {
 	return b.Body.Size()
 }
 
+// Serialize serializes the block to bytes, in the same encoding used to store it in the blockchain database.
+// Serialization can fail if the block has too many elements in its arrays
+func (b Block) Serialize() ([]byte, error) {
+	head, err := encodeBlockHeader(&b.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := encodeBlockBody(&b.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(head, body...), nil
+}
+
+// SerializeHex serializes the block to a hex string.
+// Serialization can fail if the block has too many elements in its arrays
+func (b Block) SerializeHex() (string, error) {
+	buf, err := b.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// DeserializeBlock deserializes a block. All bytes of buf must be consumed.
+func DeserializeBlock(buf []byte) (Block, error) {
+	var b Block
+	n, err := decodeBlockHeader(buf, &b.Head)
+	if err != nil {
+		return Block{}, fmt.Errorf("Invalid block header: %v", err)
+	}
+
+	if err := decodeBlockBodyExact(buf[n:], &b.Body); err != nil {
+		return Block{}, fmt.Errorf("Invalid block body: %v", err)
+	}
+
+	return b, nil
+}
+
+// DeserializeBlockHex deserializes a block hex string
+func DeserializeBlockHex(s string) (Block, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return Block{}, err
+	}
+	return DeserializeBlock(buf)
+}
+
 // NewBlockHeader creates block header
 func NewBlockHeader(prev BlockHeader, uxHash cipher.SHA256, currentTime, fee uint64, body BlockBody) BlockHeader {
 	if currentTime <= prev.Time {
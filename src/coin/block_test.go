@@ -3,12 +3,14 @@ package coin
 import (
 	"errors"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 	"github.com/skycoin/skycoin/src/testutil"
 )
 
@@ -108,6 +110,35 @@ func TestBlockBodyHash(t *testing.T) {
 	require.Equal(t, b.Body.Hash(), cipher.Merkle(hashes))
 }
 
+func TestBlockSerialize(t *testing.T) {
+	b := makeNewBlock(t, testutil.RandSHA256(t))
+	addTransactionToBlock(t, b)
+
+	buf, err := b.Serialize()
+	require.NoError(t, err)
+	require.Equal(t, encoder.Serialize(*b), buf)
+
+	b2, err := DeserializeBlock(buf)
+	require.NoError(t, err)
+	require.Equal(t, *b, b2)
+	require.Equal(t, b.HashHeader(), b2.HashHeader())
+
+	h, err := b.SerializeHex()
+	require.NoError(t, err)
+	b3, err := DeserializeBlockHex(h)
+	require.NoError(t, err)
+	require.Equal(t, *b, b3)
+
+	_, err = DeserializeBlock(append(buf, 0))
+	require.Error(t, err)
+
+	_, err = DeserializeBlock(buf[:len(buf)-1])
+	require.Error(t, err)
+
+	_, err = DeserializeBlockHex("zz")
+	require.Error(t, err)
+}
+
 func TestNewGenesisBlock(t *testing.T) {
 	gb, err := NewGenesisBlock(genAddress, _genCoins, _genTime)
 	require.NoError(t, err)
@@ -130,6 +161,135 @@ func TestNewGenesisBlock(t *testing.T) {
 	require.Equal(t, _genCoins, txn.Out[0].Hours)
 }
 
+func genesisTestOutputs(n int) []TransactionOutput {
+	outputs := make([]TransactionOutput, n)
+	for i := range outputs {
+		p, _ := cipher.MustGenerateDeterministicKeyPair([]byte(fmt.Sprintf("genesis-%d", i)))
+		outputs[i] = TransactionOutput{
+			Address: cipher.AddressFromPubKey(p),
+			Coins:   uint64(i+1) * 1e6,
+		}
+	}
+	return outputs
+}
+
+func TestNewGenesisBlockMulti(t *testing.T) {
+	outputs := genesisTestOutputs(3)
+
+	gb, err := NewGenesisBlockMulti(outputs, _genTime)
+	require.NoError(t, err)
+
+	require.Equal(t, cipher.SHA256{}, gb.Head.PrevHash)
+	require.Equal(t, _genTime, gb.Head.Time)
+	require.Equal(t, uint64(0), gb.Head.BkSeq)
+	require.Equal(t, uint32(0), gb.Head.Version)
+	require.Equal(t, uint64(0), gb.Head.Fee)
+	require.Equal(t, cipher.SHA256{}, gb.Head.UxHash)
+	require.Equal(t, gb.Body.Hash(), gb.Head.BodyHash)
+
+	require.Equal(t, 1, len(gb.Body.Transactions))
+	txn := gb.Body.Transactions[0]
+	require.Len(t, txn.In, 0)
+	require.Len(t, txn.Sigs, 0)
+	require.Equal(t, outputs, txn.Out)
+
+	// The hashes of the genesis block must not change for a fixed set of outputs
+	require.Equal(t, "e48899f0dc34143e35d88baa5841f28a00f60f770732212874a7958e88adb1d7", gb.HashHeader().Hex())
+	require.Equal(t, "9a68cab32623f7d4321b2d04b7a2e829a9455d1f602dee6f479d183fdb9c38e3", gb.Head.BodyHash.Hex())
+
+	// A single output genesis block with zero hours is not the same block as NewGenesisBlock's
+	gb1, err := NewGenesisBlockMulti(outputs[:1], _genTime)
+	require.NoError(t, err)
+	gb2, err := NewGenesisBlock(outputs[0].Address, outputs[0].Coins, _genTime)
+	require.NoError(t, err)
+	require.NotEqual(t, gb1.HashHeader(), gb2.HashHeader())
+}
+
+func TestVerifyGenesisOutputs(t *testing.T) {
+	outputs := genesisTestOutputs(3)
+
+	withHours := genesisTestOutputs(3)
+	withHours[1].Hours = 1
+
+	zeroCoins := genesisTestOutputs(3)
+	zeroCoins[2].Coins = 0
+
+	overflow := genesisTestOutputs(2)
+	overflow[1].Coins = math.MaxUint64
+
+	duplicate := append(genesisTestOutputs(2), genesisTestOutputs(1)...)
+
+	// The same address can receive outputs with different coins
+	sameAddress := genesisTestOutputs(2)
+	sameAddress[1].Address = sameAddress[0].Address
+
+	cases := []struct {
+		name       string
+		outputs    []TransactionOutput
+		coinVolume uint64
+		err        error
+	}{
+		{
+			name:       "ok",
+			outputs:    outputs,
+			coinVolume: 6e6,
+		},
+		{
+			name:       "same address",
+			outputs:    sameAddress,
+			coinVolume: 3e6,
+		},
+		{
+			name:       "coin volume mismatch",
+			outputs:    outputs,
+			coinVolume: 7e6,
+			err:        errors.New("genesis outputs coins 6000000 do not match the genesis coin volume 7000000"),
+		},
+		{
+			name: "no outputs",
+			err:  errors.New("genesis block has no outputs"),
+		},
+		{
+			name:       "coin hours",
+			outputs:    withHours,
+			coinVolume: 6e6,
+			err:        errors.New("genesis output 1 has coin hours, genesis outputs must have zero coin hours"),
+		},
+		{
+			name:       "zero coins",
+			outputs:    zeroCoins,
+			coinVolume: 3e6,
+			err:        errors.New("genesis output 2 has zero coins"),
+		},
+		{
+			name:       "coins overflow",
+			outputs:    overflow,
+			coinVolume: 1e6,
+			err:        errors.New("genesis outputs coins overflow"),
+		},
+		{
+			name:       "duplicate output",
+			outputs:    duplicate,
+			coinVolume: 4e6,
+			err:        fmt.Errorf("genesis output 2 duplicates an output to %s with 1000000 coins", duplicate[0].Address),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyGenesisOutputs(tc.outputs, tc.coinVolume)
+			require.Equal(t, tc.err, err)
+
+			_, err = NewGenesisBlockMulti(tc.outputs, _genTime)
+			if tc.err == nil || tc.name == "coin volume mismatch" {
+				require.NoError(t, err)
+			} else {
+				require.Equal(t, tc.err, err)
+			}
+		})
+	}
+}
+
 func TestCreateUnspent(t *testing.T) {
 	txn := Transaction{}
 	err := txn.PushOutput(genAddress, 11e6, 255)
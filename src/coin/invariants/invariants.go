@@ -0,0 +1,303 @@
+/*
+Package invariants checks the invariants of the coin arithmetic of blocks.
+
+The checks take a block, the block it follows and snapshots of the unspent output pool
+before and after the block is applied:
+
+  - coins are never created or destroyed, except by the genesis block
+  - the hours of the outputs of a transaction never exceed the hours its inputs earned by the earning schedule
+  - the unspent pool after the block is the pool before it, less the spent outputs, plus the created outputs,
+    and the unspent pool hash in the block header matches the pool before the block
+  - serialization round trips preserve the hashes of the block and of its transactions
+
+They operate on the coin types used by the visor, and are used by tests and by the visor's paranoid mode.
+*/
+package invariants
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+)
+
+// Invariants checked by this package
+const (
+	// Coins are never created or destroyed, except by the genesis block
+	Coins = "coins"
+	// Hours never increase beyond the earning schedule
+	Hours = "hours"
+	// The unspent pool matches the block applied to it
+	Unspents = "unspents"
+	// Serialization round trips preserve hashes
+	Serialization = "serialization"
+)
+
+// Error is returned when a block violates an invariant
+type Error struct {
+	Invariant string
+	BkSeq     uint64
+	Reason    string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("block %d violates the %s invariant: %s", e.BkSeq, e.Invariant, e.Reason)
+}
+
+func newError(invariant string, b *coin.Block, format string, args ...interface{}) error {
+	return Error{
+		Invariant: invariant,
+		BkSeq:     b.Head.BkSeq,
+		Reason:    fmt.Sprintf(format, args...),
+	}
+}
+
+// CheckBlock checks all the invariants of block b, applied after block prev to the unspent pool before,
+// resulting in the unspent pool after. prev is nil for the genesis block.
+func CheckBlock(prev, b *coin.Block, before, after coin.UxArray) error {
+	if err := CheckSerialization(b); err != nil {
+		return err
+	}
+
+	if err := CheckUnspents(b, before, after); err != nil {
+		return err
+	}
+
+	if err := CheckCoins(b, before, after); err != nil {
+		return err
+	}
+
+	return CheckHours(prev, b, before)
+}
+
+// CheckCoins checks that block b does not create or destroy coins.
+// The genesis block creates the coins of its outputs, in an empty unspent pool.
+func CheckCoins(b *coin.Block, before, after coin.UxArray) error {
+	coinsBefore, err := before.Coins()
+	if err != nil {
+		return newError(Coins, b, "unspent pool before the block: %v", err)
+	}
+
+	coinsAfter, err := after.Coins()
+	if err != nil {
+		return newError(Coins, b, "unspent pool after the block: %v", err)
+	}
+
+	if b.Head.BkSeq == 0 {
+		if len(before) != 0 {
+			return newError(Coins, b, "genesis block applied to a pool of %d unspent outputs", len(before))
+		}
+
+		var created uint64
+		for _, txn := range b.Body.Transactions {
+			for _, o := range txn.Out {
+				created, err = mathutil.AddUint64(created, o.Coins)
+				if err != nil {
+					return newError(Coins, b, "genesis output coins overflow")
+				}
+			}
+		}
+
+		if coinsAfter != created {
+			return newError(Coins, b, "genesis block creates %d coins but the unspent pool holds %d", created, coinsAfter)
+		}
+
+		return nil
+	}
+
+	if coinsBefore != coinsAfter {
+		return newError(Coins, b, "unspent pool holds %d coins before the block and %d after", coinsBefore, coinsAfter)
+	}
+
+	byHash := uxOutsByHash(before)
+	for _, txn := range b.Body.Transactions {
+		var in, out uint64
+		for _, h := range txn.In {
+			ux, ok := byHash[h]
+			if !ok {
+				return newError(Coins, b, "transaction %s spends %s, which is not in the unspent pool", txn.Hash().Hex(), h.Hex())
+			}
+
+			in, err = mathutil.AddUint64(in, ux.Body.Coins)
+			if err != nil {
+				return newError(Coins, b, "transaction %s input coins overflow", txn.Hash().Hex())
+			}
+		}
+
+		for _, o := range txn.Out {
+			out, err = mathutil.AddUint64(out, o.Coins)
+			if err != nil {
+				return newError(Coins, b, "transaction %s output coins overflow", txn.Hash().Hex())
+			}
+		}
+
+		if in != out {
+			return newError(Coins, b, "transaction %s spends %d coins but creates %d", txn.Hash().Hex(), in, out)
+		}
+	}
+
+	return nil
+}
+
+// CheckHours checks that the transactions of block b, applied after block prev, do not create more hours
+// than their inputs earned by the time of prev, the head the transactions are verified against.
+// prev is nil for the genesis block, whose output hours are set by the genesis rules.
+func CheckHours(prev, b *coin.Block, before coin.UxArray) error {
+	if prev == nil {
+		if b.Head.BkSeq != 0 {
+			return newError(Hours, b, "no previous block for a block that is not the genesis block")
+		}
+		return nil
+	}
+
+	byHash := uxOutsByHash(before)
+	for _, txn := range b.Body.Transactions {
+		var in uint64
+		for _, h := range txn.In {
+			ux, ok := byHash[h]
+			if !ok {
+				return newError(Hours, b, "transaction %s spends %s, which is not in the unspent pool", txn.Hash().Hex(), h.Hex())
+			}
+
+			hours, err := ux.CoinHours(prev.Head.Time)
+			if err != nil {
+				// Same exception as coin.VerifyTransactionHoursSpending, for an output spent by block 13277
+				if err != coin.ErrAddEarnedCoinHoursAdditionOverflow {
+					return newError(Hours, b, "transaction %s input %s: %v", txn.Hash().Hex(), h.Hex(), err)
+				}
+				hours = 0
+			}
+
+			in, err = mathutil.AddUint64(in, hours)
+			if err != nil {
+				return newError(Hours, b, "transaction %s input hours overflow", txn.Hash().Hex())
+			}
+		}
+
+		// The output hours are added without overflow checks, as in coin.VerifyTransactionHoursSpending,
+		// because existing blocks have overflowing output hours
+		var out uint64
+		for _, o := range txn.Out {
+			out += o.Hours
+		}
+
+		if out > in {
+			return newError(Hours, b, "transaction %s creates %d hours but its inputs earned %d", txn.Hash().Hex(), out, in)
+		}
+	}
+
+	return nil
+}
+
+// CheckUnspents checks that the unspent pool after block b is the pool before it,
+// less the outputs spent by b, plus the outputs created by b,
+// and that the unspent pool hash in the header of b matches the pool before it.
+func CheckUnspents(b *coin.Block, before, after coin.UxArray) error {
+	if b.Head.BkSeq != 0 {
+		if h := UxHash(before); h != b.Head.UxHash {
+			return newError(Unspents, b, "header unspent pool hash %s does not match the pool hash %s", b.Head.UxHash.Hex(), h.Hex())
+		}
+	}
+
+	if before.HasDupes() {
+		return newError(Unspents, b, "duplicate outputs in the unspent pool before the block")
+	}
+
+	expect := before.Set()
+	for _, txn := range b.Body.Transactions {
+		for _, h := range txn.In {
+			if _, ok := expect[h]; !ok {
+				return newError(Unspents, b, "transaction %s spends %s, which is not unspent", txn.Hash().Hex(), h.Hex())
+			}
+			delete(expect, h)
+		}
+	}
+
+	for _, txn := range b.Body.Transactions {
+		for _, ux := range coin.CreateUnspents(b.Head, txn) {
+			h := ux.Hash()
+			if _, ok := expect[h]; ok {
+				return newError(Unspents, b, "transaction %s creates %s, which already exists", txn.Hash().Hex(), h.Hex())
+			}
+			expect[h] = struct{}{}
+		}
+	}
+
+	if after.HasDupes() {
+		return newError(Unspents, b, "duplicate outputs in the unspent pool after the block")
+	}
+
+	if len(after) != len(expect) {
+		return newError(Unspents, b, "unspent pool holds %d outputs after the block, expected %d", len(after), len(expect))
+	}
+
+	for _, ux := range after {
+		if _, ok := expect[ux.Hash()]; !ok {
+			return newError(Unspents, b, "unexpected output %s in the unspent pool after the block", ux.Hash().Hex())
+		}
+	}
+
+	return nil
+}
+
+// CheckSerialization checks that serializing and deserializing block b and its transactions preserves their hashes
+func CheckSerialization(b *coin.Block) error {
+	buf := encoder.Serialize(*b)
+
+	var b2 coin.Block
+	if err := encoder.DeserializeRawExact(buf, &b2); err != nil {
+		return newError(Serialization, b, "deserialize block: %v", err)
+	}
+
+	if b.HashHeader() != b2.HashHeader() {
+		return newError(Serialization, b, "header hash changed from %s to %s", b.HashHeader().Hex(), b2.HashHeader().Hex())
+	}
+
+	if b.Body.Hash() != b2.Body.Hash() {
+		return newError(Serialization, b, "body hash changed from %s to %s", b.Body.Hash().Hex(), b2.Body.Hash().Hex())
+	}
+
+	if !bytes.Equal(buf, encoder.Serialize(b2)) {
+		return newError(Serialization, b, "serialization of the deserialized block differs")
+	}
+
+	for i := range b.Body.Transactions {
+		txn := &b.Body.Transactions[i]
+
+		txnBuf, err := txn.Serialize()
+		if err != nil {
+			return newError(Serialization, b, "serialize transaction %s: %v", txn.Hash().Hex(), err)
+		}
+
+		txn2, err := coin.DeserializeTransaction(txnBuf)
+		if err != nil {
+			return newError(Serialization, b, "deserialize transaction %s: %v", txn.Hash().Hex(), err)
+		}
+
+		if txn.Hash() != txn2.Hash() {
+			return newError(Serialization, b, "transaction hash changed from %s to %s", txn.Hash().Hex(), txn2.Hash().Hex())
+		}
+	}
+
+	return nil
+}
+
+func uxOutsByHash(uxs coin.UxArray) map[cipher.SHA256]coin.UxOut {
+	m := make(map[cipher.SHA256]coin.UxOut, len(uxs))
+	for _, ux := range uxs {
+		m[ux.Hash()] = ux
+	}
+	return m
+}
+
+// UxHash returns the unspent pool hash of the unspent outputs, the xor of their snapshot hashes
+func UxHash(uxs coin.UxArray) cipher.SHA256 {
+	var h cipher.SHA256
+	for i := range uxs {
+		h = h.Xor(uxs[i].SnapshotHash())
+	}
+	return h
+}
@@ -0,0 +1,255 @@
+package invariants
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+const (
+	genesisCoins uint64 = 1000e6
+	genesisTime  uint64 = 1000
+)
+
+// chain generates random but valid blocks, keeping the unspent pool they apply to
+type chain struct {
+	t    *testing.T
+	rnd  *rand.Rand
+	head *coin.Block
+	pool coin.UxArray
+}
+
+func newChain(t *testing.T, seed int64) *chain {
+	c := &chain{
+		t:   t,
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+
+	gb, err := coin.NewGenesisBlock(c.address(), genesisCoins, genesisTime)
+	require.NoError(t, err)
+
+	c.head = gb
+	c.pool = coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	return c
+}
+
+func (c *chain) address() cipher.Address {
+	var a cipher.Address
+	c.rnd.Read(a.Key[:])
+	return a
+}
+
+// split splits n into k random parts of at least min each, n must be >= k*min
+func (c *chain) split(n, min uint64, k int) []uint64 {
+	parts := make([]uint64, k)
+	rest := n - uint64(k)*min
+	for i := 0; i < k-1; i++ {
+		p := uint64(c.rnd.Int63n(int64(rest) + 1))
+		parts[i] = min + p
+		rest -= p
+	}
+	parts[k-1] = min + rest
+	return parts
+}
+
+// next generates the next block, spending random outputs of the pool in up to 3 transactions.
+// Returns the previous head, the block and the unspent pool before and after it.
+func (c *chain) next() (*coin.Block, *coin.Block, coin.UxArray, coin.UxArray) {
+	prev := c.head
+	before := clone(c.pool)
+
+	spend := c.rnd.Perm(len(c.pool))
+	spend = spend[:1+c.rnd.Intn(len(spend))]
+
+	ntxns := 1 + c.rnd.Intn(3)
+	if ntxns > len(spend) {
+		ntxns = len(spend)
+	}
+
+	fees := make(map[cipher.SHA256]uint64)
+	var txns coin.Transactions
+	for i := 0; i < ntxns; i++ {
+		var txn coin.Transaction
+		var coins, hours uint64
+		for j := i; j < len(spend); j += ntxns {
+			ux := c.pool[spend[j]]
+			require.NoError(c.t, txn.PushInput(ux.Hash()))
+			coins += ux.Body.Coins
+
+			h, err := ux.CoinHours(prev.Head.Time)
+			require.NoError(c.t, err)
+			hours += h
+		}
+
+		nout := 1 + c.rnd.Intn(4)
+		if uint64(nout) > coins {
+			nout = int(coins)
+		}
+
+		spentHours := uint64(c.rnd.Int63n(int64(hours) + 1))
+		outHours := c.split(spentHours, 0, nout)
+		for k, oc := range c.split(coins, 1, nout) {
+			require.NoError(c.t, txn.PushOutput(c.address(), oc, outHours[k]))
+		}
+		require.NoError(c.t, txn.UpdateHeader())
+
+		fees[txn.Hash()] = hours - spentHours
+		txns = append(txns, txn)
+	}
+
+	feeCalc := func(txn *coin.Transaction) (uint64, error) {
+		return fees[txn.Hash()], nil
+	}
+
+	when := prev.Head.Time + 1 + uint64(c.rnd.Int63n(48*3600))
+	b, err := coin.NewBlock(*prev, when, UxHash(c.pool), txns, feeCalc)
+	require.NoError(c.t, err)
+
+	spent := make(map[cipher.SHA256]struct{})
+	for _, txn := range txns {
+		for _, h := range txn.In {
+			spent[h] = struct{}{}
+		}
+	}
+
+	var after coin.UxArray
+	for _, ux := range c.pool {
+		if _, ok := spent[ux.Hash()]; !ok {
+			after = append(after, ux)
+		}
+	}
+	for _, txn := range txns {
+		after = append(after, coin.CreateUnspents(b.Head, txn)...)
+	}
+
+	c.head = b
+	c.pool = after
+	return prev, b, before, clone(after)
+}
+
+func clone(uxs coin.UxArray) coin.UxArray {
+	return append(coin.UxArray{}, uxs...)
+}
+
+func requireViolation(t *testing.T, invariant string, err error) {
+	require.Error(t, err)
+	e, ok := err.(Error)
+	require.True(t, ok, "unexpected error type %T: %v", err, err)
+	require.Equal(t, invariant, e.Invariant, err.Error())
+}
+
+func TestCheckBlockGenesis(t *testing.T) {
+	c := newChain(t, 1)
+	require.NoError(t, CheckBlock(nil, c.head, nil, c.pool))
+
+	// The genesis block creates coins in an empty pool only
+	requireViolation(t, Coins, CheckCoins(c.head, c.pool, c.pool))
+
+	// The genesis outputs are in the pool after it
+	requireViolation(t, Unspents, CheckUnspents(c.head, nil, nil))
+
+	// Only the genesis block has no previous block
+	_, b, before, _ := c.next()
+	requireViolation(t, Hours, CheckHours(nil, b, before))
+}
+
+func TestCheckBlockRandomChains(t *testing.T) {
+	// Random valid blocks satisfy the invariants
+	valid := func(seed int64) bool {
+		c := newChain(t, seed)
+		for i := 0; i < 20; i++ {
+			prev, b, before, after := c.next()
+			if err := CheckBlock(prev, b, before, after); err != nil {
+				t.Logf("seed=%d: %v", seed, err)
+				return false
+			}
+		}
+		return true
+	}
+
+	require.NoError(t, quick.Check(valid, &quick.Config{MaxCount: 25}))
+}
+
+func TestCheckBlockRandomViolations(t *testing.T) {
+	// Random valid blocks with one value changed violate an invariant
+	violated := func(seed int64) bool {
+		c := newChain(t, seed)
+		for i := 0; i < 5; i++ {
+			c.next()
+		}
+		prev, b, before, after := c.next()
+		rnd := rand.New(rand.NewSource(seed))
+
+		// A coin appears in the pool
+		inflated := clone(after)
+		inflated[rnd.Intn(len(inflated))].Body.Coins++
+		requireViolation(t, Coins, CheckCoins(b, before, inflated))
+		requireViolation(t, Unspents, CheckBlock(prev, b, before, inflated))
+
+		// An output disappears from the pool
+		lost := clone(after)
+		k := rnd.Intn(len(lost))
+		lost = append(lost[:k], lost[k+1:]...)
+		requireViolation(t, Unspents, CheckUnspents(b, before, lost))
+
+		// A spent output stays in the pool
+		spent := uxOutsByHash(before)[b.Body.Transactions[0].In[0]]
+		requireViolation(t, Unspents, CheckUnspents(b, before, append(clone(after), spent)))
+
+		// The header hash does not match the pool before the block
+		requireViolation(t, Unspents, CheckUnspents(b, after, after))
+
+		// A transaction creates more hours than its inputs earned
+		txn := &b.Body.Transactions[rnd.Intn(len(b.Body.Transactions))]
+		var earned uint64
+		for _, h := range txn.In {
+			ux := uxOutsByHash(before)[h]
+			hours, err := ux.CoinHours(prev.Head.Time)
+			require.NoError(t, err)
+			earned += hours
+		}
+		txn.Out[0].Hours = earned + 1
+		requireViolation(t, Hours, CheckHours(prev, b, before))
+
+		// A transaction creates coins
+		txn.Out[0].Hours = 0
+		txn.Out[0].Coins++
+		requireViolation(t, Coins, CheckCoins(b, before, after))
+
+		return true
+	}
+
+	require.NoError(t, quick.Check(violated, &quick.Config{MaxCount: 25}))
+}
+
+func TestCheckSerialization(t *testing.T) {
+	c := newChain(t, 2)
+	require.NoError(t, CheckSerialization(c.head))
+	for i := 0; i < 10; i++ {
+		_, b, _, _ := c.next()
+		require.NoError(t, CheckSerialization(b))
+	}
+}
+
+func TestUxHash(t *testing.T) {
+	c := newChain(t, 3)
+	for i := 0; i < 5; i++ {
+		c.next()
+	}
+
+	require.Equal(t, cipher.SHA256{}, UxHash(nil))
+
+	// The hash does not depend on the order of the outputs
+	h := UxHash(c.pool)
+	reversed := clone(c.pool)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	require.Equal(t, h, UxHash(reversed))
+	require.NotEqual(t, h, UxHash(c.pool[1:]))
+}
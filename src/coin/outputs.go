@@ -180,9 +180,46 @@ func (ua UxArray) Set() UxHashSet {
 	return m
 }
 
-// Sort sorts UxArray
+// Clone returns a copy of the UxArray that does not share its backing array.
+// Returns nil if ua is nil.
+func (ua UxArray) Clone() UxArray {
+	if ua == nil {
+		return nil
+	}
+
+	// UxOut has no reference fields, copying the elements is a deep copy
+	uxa := make(UxArray, len(ua))
+	copy(uxa, ua)
+	return uxa
+}
+
+// Sort sorts the UxArray in place by hash.
+// The sort is stable, outputs with the same hash keep their order.
+// Sort modifies the backing array, which is shared with the slices it was made from; sort a Clone to keep them unchanged.
 func (ua UxArray) Sort() {
-	sort.Sort(ua)
+	sort.Stable(uxArrayHashSorter{
+		uxa:    ua,
+		hashes: ua.Hashes(),
+	})
+}
+
+// uxArrayHashSorter sorts a UxArray by the precomputed hashes of its outputs
+type uxArrayHashSorter struct {
+	uxa    UxArray
+	hashes []cipher.SHA256
+}
+
+func (s uxArrayHashSorter) Len() int {
+	return len(s.uxa)
+}
+
+func (s uxArrayHashSorter) Less(i, j int) bool {
+	return bytes.Compare(s.hashes[i][:], s.hashes[j][:]) < 0
+}
+
+func (s uxArrayHashSorter) Swap(i, j int) {
+	s.uxa[i], s.uxa[j] = s.uxa[j], s.uxa[i]
+	s.hashes[i], s.hashes[j] = s.hashes[j], s.hashes[i]
 }
 
 // Len returns length of UxArray
@@ -279,7 +316,7 @@ func (auo AddressUxOuts) Sub(other AddressUxOuts) AddressUxOuts {
 				ox[a] = ouxs
 			}
 		} else {
-			ox[a] = uxs
+			ox[a] = uxs.Clone()
 		}
 	}
 	return ox
@@ -289,14 +326,14 @@ func (auo AddressUxOuts) Sub(other AddressUxOuts) AddressUxOuts {
 func (auo AddressUxOuts) Add(other AddressUxOuts) AddressUxOuts {
 	ox := make(AddressUxOuts, len(auo))
 	for a, o := range auo {
-		ox[a] = o
+		ox[a] = o.Clone()
 	}
 
 	for a, uxs := range other {
 		if suxs, ok := ox[a]; ok {
 			ox[a] = suxs.Add(uxs)
 		} else {
-			ox[a] = uxs
+			ox[a] = uxs.Clone()
 		}
 	}
 	return ox
@@ -315,13 +352,15 @@ func (ua UxArray) Sub(other UxArray) UxArray {
 	return uxa
 }
 
-// Add returns a new UxArray with merged elements
+// Add returns a new UxArray with merged elements.
+// The backing array of ua is not modified.
 func (ua UxArray) Add(other UxArray) UxArray {
+	uxa := ua.Clone()
 	m := ua.Set()
 	for i := range other {
 		if _, ok := m[other[i].Hash()]; !ok {
-			ua = append(ua, other[i])
+			uxa = append(uxa, other[i])
 		}
 	}
-	return ua
+	return uxa
 }
@@ -3,6 +3,7 @@ package coin
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math"
 	"sort"
 	"strings"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/testutil"
+
+	ptestutil "github.com/ness-network/privateness/src/testutil"
 )
 
 func makeUxBody(t *testing.T) UxBody {
@@ -35,6 +38,18 @@ func makeUxBodyWithSecret(t *testing.T) (UxBody, cipher.SecKey) {
 	}, s
 }
 
+// makeStableUxBodyWithSecret creates a UxBody owned by the i-th stable test key pair.
+// The same i always creates the same UxBody, for golden tests.
+func makeStableUxBodyWithSecret(i int) (UxBody, cipher.SecKey) {
+	p, s := ptestutil.KeyPair(i)
+	return UxBody{
+		SrcTransaction: cipher.SumSHA256([]byte(fmt.Sprintf("src transaction %d", i))),
+		Address:        cipher.AddressFromPubKey(p),
+		Coins:          1e6,
+		Hours:          100,
+	}, s
+}
+
 func makeUxOutWithSecret(t *testing.T) (UxOut, cipher.SecKey) {
 	body, sec := makeUxBodyWithSecret(t)
 	return UxOut{
@@ -46,6 +61,18 @@ func makeUxOutWithSecret(t *testing.T) (UxOut, cipher.SecKey) {
 	}, sec
 }
 
+// makeStableUxOutWithSecret creates a UxOut owned by the i-th stable test key pair, like makeStableUxBodyWithSecret
+func makeStableUxOutWithSecret(i int) (UxOut, cipher.SecKey) {
+	body, sec := makeStableUxBodyWithSecret(i)
+	return UxOut{
+		Head: UxHead{
+			Time:  100,
+			BkSeq: 2,
+		},
+		Body: body,
+	}, sec
+}
+
 func TestUxBodyHash(t *testing.T) {
 	uxb := makeUxBody(t)
 	h := uxb.Hash()
@@ -273,6 +300,82 @@ func TestUxArrayRemoveDupes(t *testing.T) {
 	assert.Equal(t, uxb[2], uxa[3])
 }
 
+func TestUxArrayClone(t *testing.T) {
+	require.Nil(t, UxArray(nil).Clone())
+	require.Equal(t, UxArray{}, UxArray{}.Clone())
+
+	uxa := makeUxArray(t, 4)
+	orig := make(UxArray, len(uxa))
+	copy(orig, uxa)
+
+	uxb := uxa.Clone()
+	require.Equal(t, uxa, uxb)
+
+	// Mutating the original after Clone does not change the clone
+	uxa[0].Body.Coins++
+	uxa[1].Head.BkSeq++
+	uxa[2] = makeUxOut(t)
+	uxa.Sort()
+	require.Equal(t, orig, uxb)
+
+	// Mutating the clone does not change the original
+	uxa = orig.Clone()
+	uxb[3].Body.Hours++
+	require.Equal(t, orig, uxa)
+
+	// Appending to a clone does not write to the spare capacity of the original
+	uxc := make(UxArray, 2, 4)
+	copy(uxc, orig[:2])
+	uxd := append(uxc.Clone(), orig[2])
+	uxe := append(uxc, orig[3])
+	require.Equal(t, orig[2], uxd[2])
+	require.Equal(t, orig[3], uxe[2])
+}
+
+func TestUxArraySortStable(t *testing.T) {
+	uxa := makeUxArray(t, 8)
+
+	// Outputs with the same hash and different heads keep their order
+	uxa[5] = uxa[1]
+	uxa[5].Head.BkSeq = 5
+	uxa[7] = uxa[1]
+	uxa[7].Head.BkSeq = 7
+
+	uxb := uxa.Clone()
+	uxb.Sort()
+	require.True(t, manualUxArrayIsSorted(uxb))
+
+	var seqs []uint64
+	for _, ux := range uxb {
+		if ux.Hash() == uxa[1].Hash() {
+			seqs = append(seqs, ux.Head.BkSeq)
+		}
+	}
+	require.Equal(t, []uint64{2, 5, 7}, seqs)
+
+	// Sorting a sorted array does not change it
+	uxc := uxb.Clone()
+	uxc.Sort()
+	require.Equal(t, uxb, uxc)
+}
+
+func TestUxArrayAddAliasing(t *testing.T) {
+	uxs := makeUxArray(t, 4)
+
+	// ua has spare capacity, which Add must not write to
+	ua := make(UxArray, 2, 4)
+	copy(ua, uxs[:2])
+	alias := ua[:3]
+	alias[2] = uxs[3]
+
+	uxb := ua.Add(UxArray{uxs[2]})
+	require.Equal(t, UxArray{uxs[0], uxs[1], uxs[2]}, uxb)
+	require.Equal(t, uxs[3], alias[2])
+
+	uxb[0].Body.Coins++
+	require.Equal(t, uxs[0], ua[0])
+}
+
 func TestUxArraySub(t *testing.T) {
 	uxa := makeUxArray(t, 4)
 	uxb := makeUxArray(t, 4)
@@ -491,6 +594,23 @@ func TestAddressUxOutsAdd(t *testing.T) {
 	assert.Equal(t, len(up2[uxs[2].Body.Address]), 1)
 }
 
+func TestAddressUxOutsAddAliasing(t *testing.T) {
+	uxs := makeUxArray(t, 3)
+	up := AddressUxOuts{
+		uxs[0].Body.Address: UxArray{uxs[0]},
+	}
+	up2 := AddressUxOuts{
+		uxs[1].Body.Address: UxArray{uxs[1]},
+	}
+
+	up3 := up.Add(up2)
+	up3[uxs[0].Body.Address][0] = uxs[2]
+	up3[uxs[1].Body.Address][0] = uxs[2]
+
+	require.Equal(t, UxArray{uxs[0]}, up[uxs[0].Body.Address])
+	require.Equal(t, UxArray{uxs[1]}, up2[uxs[1].Body.Address])
+}
+
 func TestAddressUxOutsFlatten(t *testing.T) {
 	up := make(AddressUxOuts)
 	uxs := makeUxArray(t, 3)
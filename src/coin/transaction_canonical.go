@@ -0,0 +1,190 @@
+package coin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+/*
+The canonical JSON form of a transaction lets systems that can't use the binary encoder
+check transaction hashes. The transaction hash is computed from the binary encoding,
+which is rebuilt from the canonical JSON form as follows:
+
+	{"in":[<input>,...],"inner_hash":<inner hash>,"length":<length>,"out":[<output>,...],"sigs":[<sig>,...],"type":<type>}
+
+with each output as
+
+	{"address":<address>,"coins":<coins>,"hours":<hours>}
+
+- Object keys are in the order above, which is byte order, and no whitespace is used
+- "length" and "type" are JSON integers
+- "coins" and "hours" are strings of the decimal uint64 value, without leading zeros, because they may exceed the precision of JSON numbers
+- "inner_hash", "in" and "sigs" are lowercase hex strings of the 32 byte hashes and 65 byte signatures
+- "address" is the base58 encoded address
+- Empty arrays are written as []
+
+The binary encoding of a transaction is, with integers in little endian:
+
+	length uint32 | type uint8 | inner_hash [32]byte
+	| len(sigs) uint32 | sigs [65]byte...
+	| len(in) uint32 | in [32]byte...
+	| len(out) uint32 | (address version uint8 | address key [20]byte | coins uint64 | hours uint64)...
+
+and the transaction hash is the sha256 of the binary encoding.
+This form is only for external systems, the binary encoding is used everywhere else.
+*/
+
+// ErrNonCanonicalJSON is returned by TransactionFromCanonicalJSON if the JSON is not in the canonical form
+var ErrNonCanonicalJSON = errors.New("transaction JSON is not in the canonical form")
+
+// ErrCanonicalJSONHashMismatch is returned by TransactionFromCanonicalJSON if the hash of the transaction is not the expected hash
+type ErrCanonicalJSONHashMismatch struct {
+	Hash         cipher.SHA256
+	ExpectedHash cipher.SHA256
+}
+
+func (e ErrCanonicalJSONHashMismatch) Error() string {
+	return fmt.Sprintf("transaction hash %s does not match the expected hash %s", e.Hash.Hex(), e.ExpectedHash.Hex())
+}
+
+// canonicalTransaction is the canonical JSON form of a Transaction.
+// The fields must stay sorted by their JSON name.
+type canonicalTransaction struct {
+	In        []string                     `json:"in"`
+	InnerHash string                       `json:"inner_hash"`
+	Length    uint32                       `json:"length"`
+	Out       []canonicalTransactionOutput `json:"out"`
+	Sigs      []string                     `json:"sigs"`
+	Type      uint8                        `json:"type"`
+}
+
+// canonicalTransactionOutput is the canonical JSON form of a TransactionOutput.
+// The fields must stay sorted by their JSON name.
+type canonicalTransactionOutput struct {
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Hours   string `json:"hours"`
+}
+
+// CanonicalJSON returns the canonical JSON form of the transaction, see TransactionFromCanonicalJSON
+func (txn *Transaction) CanonicalJSON() ([]byte, error) {
+	c := canonicalTransaction{
+		In:        make([]string, len(txn.In)),
+		InnerHash: txn.InnerHash.Hex(),
+		Length:    txn.Length,
+		Out:       make([]canonicalTransactionOutput, len(txn.Out)),
+		Sigs:      make([]string, len(txn.Sigs)),
+		Type:      txn.Type,
+	}
+
+	for i, h := range txn.In {
+		c.In[i] = h.Hex()
+	}
+
+	for i, o := range txn.Out {
+		c.Out[i] = canonicalTransactionOutput{
+			Address: o.Address.String(),
+			Coins:   strconv.FormatUint(o.Coins, 10),
+			Hours:   strconv.FormatUint(o.Hours, 10),
+		}
+	}
+
+	for i, s := range txn.Sigs {
+		c.Sigs[i] = s.Hex()
+	}
+
+	// The hex and base58 strings need no escaping, so the output of json.Marshal is canonical
+	return json.Marshal(c)
+}
+
+// TransactionFromCanonicalJSON reconstructs a transaction from its canonical JSON form
+// and checks that its hash is expectedHash.
+// Returns ErrNonCanonicalJSON if b is valid but not in the canonical form, so that a hash
+// always has one canonical JSON form.
+func TransactionFromCanonicalJSON(b []byte, expectedHash cipher.SHA256) (Transaction, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+
+	var c canonicalTransaction
+	if err := d.Decode(&c); err != nil {
+		return Transaction{}, fmt.Errorf("invalid transaction JSON: %v", err)
+	}
+
+	txn := Transaction{
+		Length: c.Length,
+		Type:   c.Type,
+		In:     make([]cipher.SHA256, len(c.In)),
+		Out:    make([]TransactionOutput, len(c.Out)),
+		Sigs:   make([]cipher.Sig, len(c.Sigs)),
+	}
+
+	var err error
+	txn.InnerHash, err = cipher.SHA256FromHex(c.InnerHash)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid inner_hash: %v", err)
+	}
+
+	for i, h := range c.In {
+		txn.In[i], err = cipher.SHA256FromHex(h)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid in[%d]: %v", i, err)
+		}
+	}
+
+	for i, o := range c.Out {
+		addr, err := cipher.DecodeBase58Address(o.Address)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid out[%d].address: %v", i, err)
+		}
+
+		coins, err := strconv.ParseUint(o.Coins, 10, 64)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid out[%d].coins: %v", i, err)
+		}
+
+		hours, err := strconv.ParseUint(o.Hours, 10, 64)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid out[%d].hours: %v", i, err)
+		}
+
+		txn.Out[i] = TransactionOutput{
+			Address: addr,
+			Coins:   coins,
+			Hours:   hours,
+		}
+	}
+
+	for i, s := range c.Sigs {
+		txn.Sigs[i], err = cipher.SigFromHex(s)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid sigs[%d]: %v", i, err)
+		}
+	}
+
+	// Reject non-canonical forms, such as reordered keys, whitespace, uppercase hex or leading zeros
+	canonical, err := txn.CanonicalJSON()
+	if err != nil {
+		return Transaction{}, err
+	}
+	if !bytes.Equal(canonical, b) {
+		return Transaction{}, ErrNonCanonicalJSON
+	}
+
+	_, h, err := txn.SizeHash()
+	if err != nil {
+		return Transaction{}, err
+	}
+	if h != expectedHash {
+		return Transaction{}, ErrCanonicalJSONHashMismatch{
+			Hash:         h,
+			ExpectedHash: expectedHash,
+		}
+	}
+
+	return txn, nil
+}
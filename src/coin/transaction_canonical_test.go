@@ -0,0 +1,178 @@
+package coin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+const canonicalJSONVectorsFile = "canonical-json-vectors.json"
+
+// canonicalJSONVector is a test vector for other implementations of the canonical JSON form
+type canonicalJSONVector struct {
+	// CanonicalJSON is the canonical JSON form of the transaction
+	CanonicalJSON string `json:"canonical_json"`
+	// Serialized is the hex encoded binary encoding of the transaction
+	Serialized string `json:"serialized"`
+	// Hash is the transaction hash, the sha256 of the binary encoding
+	Hash string `json:"hash"`
+}
+
+func makeCanonicalJSONVectorTransactions(t *testing.T) []Transaction {
+	txns := []Transaction{{}}
+
+	for _, n := range []struct {
+		in, out int
+	}{
+		{1, 1},
+		{1, 2},
+		{2, 3},
+		{3, 1},
+		{5, 5},
+	} {
+		uxs := make([]UxOut, n.in)
+		secs := make([]cipher.SecKey, n.in)
+		for i := range uxs {
+			uxs[i], secs[i] = makeUxOutWithSecret(t)
+		}
+
+		var txn Transaction
+		for _, ux := range uxs {
+			require.NoError(t, txn.PushInput(ux.Hash()))
+		}
+		for i := 0; i < n.out; i++ {
+			require.NoError(t, txn.PushOutput(makeAddress(), uint64(i+1)*1e6, uint64(i)*10))
+		}
+		txn.SignInputs(secs)
+		require.NoError(t, txn.UpdateHeader())
+		txns = append(txns, txn)
+	}
+
+	// Amounts that exceed the precision of JSON numbers, and an unsigned input
+	var txn Transaction
+	require.NoError(t, txn.PushInput(testutil.RandSHA256(t)))
+	require.NoError(t, txn.PushOutput(makeAddress(), math.MaxUint64, math.MaxUint64-1))
+	require.NoError(t, txn.PushOutput(makeAddress(), 1<<53+1, 0))
+	txn.Sigs = make([]cipher.Sig, 1)
+	require.NoError(t, txn.UpdateHeader())
+	txns = append(txns, txn)
+
+	return txns
+}
+
+func TestTransactionCanonicalJSONVectors(t *testing.T) {
+	fn := filepath.Join("testdata", canonicalJSONVectorsFile)
+
+	update := false
+	if update {
+		var vectors []canonicalJSONVector
+		for _, txn := range makeCanonicalJSONVectorTransactions(t) {
+			b, err := txn.CanonicalJSON()
+			require.NoError(t, err)
+			vectors = append(vectors, canonicalJSONVector{
+				CanonicalJSON: string(b),
+				Serialized:    txn.MustSerializeHex(),
+				Hash:          txn.Hash().Hex(),
+			})
+		}
+
+		b, err := json.MarshalIndent(vectors, "", "    ")
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(fn, append(b, '\n'), 0644))
+	}
+
+	b, err := ioutil.ReadFile(fn)
+	require.NoError(t, err)
+
+	var vectors []canonicalJSONVector
+	require.NoError(t, json.Unmarshal(b, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for i, v := range vectors {
+		h := cipher.MustSHA256FromHex(v.Hash)
+
+		txn, err := TransactionFromCanonicalJSON([]byte(v.CanonicalJSON), h)
+		require.NoError(t, err, "vector %d", i)
+		require.Equal(t, v.Serialized, txn.MustSerializeHex(), "vector %d", i)
+		require.Equal(t, h, txn.Hash(), "vector %d", i)
+
+		b, err := txn.CanonicalJSON()
+		require.NoError(t, err)
+		require.Equal(t, v.CanonicalJSON, string(b), "vector %d", i)
+	}
+}
+
+func TestTransactionCanonicalJSON(t *testing.T) {
+	txn := makeTransaction(t)
+	txn.Out[0].Coins = math.MaxUint64
+
+	b, err := txn.CanonicalJSON()
+	require.NoError(t, err)
+	require.Equal(t, `{"in":["`+txn.In[0].Hex()+`"],"inner_hash":"`+txn.InnerHash.Hex()+`","length":`+
+		strconv.FormatUint(uint64(txn.Length), 10)+`,"out":[{"address":"`+txn.Out[0].Address.String()+`","coins":"18446744073709551615","hours":"50"},`+
+		`{"address":"`+txn.Out[1].Address.String()+`","coins":"5000000","hours":"50"}],"sigs":["`+txn.Sigs[0].Hex()+`"],"type":0}`, string(b))
+
+	txn2, err := TransactionFromCanonicalJSON(b, txn.Hash())
+	require.NoError(t, err)
+	require.Equal(t, txn, txn2)
+
+	s := string(b)
+	for _, tc := range []struct {
+		name string
+		json string
+		err  error
+	}{
+		{
+			name: "whitespace",
+			json: s + "\n",
+			err:  ErrNonCanonicalJSON,
+		},
+		{
+			name: "uppercase hex",
+			json: strings.Replace(s, txn.InnerHash.Hex(), strings.ToUpper(txn.InnerHash.Hex()), 1),
+			err:  ErrNonCanonicalJSON,
+		},
+		{
+			name: "leading zeros",
+			json: strings.Replace(s, `"hours":"50"`, `"hours":"050"`, 1),
+			err:  ErrNonCanonicalJSON,
+		},
+		{
+			name: "reordered keys",
+			json: `{"type":0,` + strings.TrimSuffix(s[1:], `,"type":0}`) + "}",
+			err:  ErrNonCanonicalJSON,
+		},
+		{
+			name: "null array",
+			json: strings.Replace(s, `"sigs":["`+txn.Sigs[0].Hex()+`"]`, `"sigs":null`, 1),
+			err:  ErrNonCanonicalJSON,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := TransactionFromCanonicalJSON([]byte(tc.json), txn.Hash())
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	_, err = TransactionFromCanonicalJSON([]byte(strings.Replace(s, `"type":0`, `"type":0,"extra":1`, 1)), txn.Hash())
+	testutil.RequireError(t, err, `invalid transaction JSON: json: unknown field "extra"`)
+
+	_, err = TransactionFromCanonicalJSON([]byte(strings.Replace(s, `"coins":"5000000"`, `"coins":"-1"`, 1)), txn.Hash())
+	testutil.RequireError(t, err, `invalid out[1].coins: strconv.ParseUint: parsing "-1": invalid syntax`)
+
+	expectedHash := testutil.RandSHA256(t)
+	_, err = TransactionFromCanonicalJSON(b, expectedHash)
+	require.Equal(t, ErrCanonicalJSONHashMismatch{
+		Hash:         txn.Hash(),
+		ExpectedHash: expectedHash,
+	}, err)
+}
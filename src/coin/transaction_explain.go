@@ -0,0 +1,120 @@
+package coin
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// FieldSpan is the location of a field in the serialization of a transaction
+type FieldSpan struct {
+	// Name is the path of the field in the Transaction struct, e.g. "InnerHash", "Sigs[0]" or "Out[1].Coins".
+	// The length prefix of an array is named "len(Sigs)", "len(In)" or "len(Out)".
+	Name   string
+	Offset int
+	Length int
+}
+
+// ExplainTransactionEncoding returns the location of each field in the serialization of txn, in encoding order.
+// The spans are contiguous and cover the whole serialization.
+func ExplainTransactionEncoding(txn Transaction) []FieldSpan {
+	spans := make([]FieldSpan, 0, 6+len(txn.Sigs)+len(txn.In)+3*len(txn.Out))
+	offset := 0
+	add := func(name string, length int) {
+		spans = append(spans, FieldSpan{
+			Name:   name,
+			Offset: offset,
+			Length: length,
+		})
+		offset += length
+	}
+
+	add("Length", 4)
+	add("Type", 1)
+	add("InnerHash", len(txn.InnerHash))
+
+	add("len(Sigs)", 4)
+	for i := range txn.Sigs {
+		add(fmt.Sprintf("Sigs[%d]", i), len(txn.Sigs[i]))
+	}
+
+	add("len(In)", 4)
+	for i := range txn.In {
+		add(fmt.Sprintf("In[%d]", i), len(txn.In[i]))
+	}
+
+	add("len(Out)", 4)
+	for i := range txn.Out {
+		add(fmt.Sprintf("Out[%d].Address", i), 1+len(txn.Out[i].Address.Key))
+		add(fmt.Sprintf("Out[%d].Coins", i), 8)
+		add(fmt.Sprintf("Out[%d].Hours", i), 8)
+	}
+
+	return spans
+}
+
+// FieldDiff is a field whose encoding differs between two transactions
+type FieldDiff struct {
+	Name string
+	// A and B are the encoded field in each transaction, nil if the transaction does not have the field
+	A []byte
+	B []byte
+}
+
+// DiffTransactions returns the fields whose encoding differs between two transactions,
+// in the encoding order of a followed by the fields only found in b.
+// Panics if a transaction can't be serialized, see Transaction.Serialize.
+func DiffTransactions(a, b Transaction) []FieldDiff {
+	aFields := encodedFields(a)
+	bFields := encodedFields(b)
+
+	bByName := make(map[string][]byte, len(bFields))
+	for _, f := range bFields {
+		bByName[f.Name] = f.A
+	}
+
+	var diffs []FieldDiff
+	for _, f := range aFields {
+		bf, ok := bByName[f.Name]
+		if ok && bytes.Equal(f.A, bf) {
+			continue
+		}
+
+		diffs = append(diffs, FieldDiff{
+			Name: f.Name,
+			A:    f.A,
+			B:    bf,
+		})
+	}
+
+	aNames := make(map[string]struct{}, len(aFields))
+	for _, f := range aFields {
+		aNames[f.Name] = struct{}{}
+	}
+
+	for _, f := range bFields {
+		if _, ok := aNames[f.Name]; !ok {
+			diffs = append(diffs, FieldDiff{
+				Name: f.Name,
+				B:    f.A,
+			})
+		}
+	}
+
+	return diffs
+}
+
+// encodedFields returns each field of a transaction with its encoded bytes in FieldDiff.A
+func encodedFields(txn Transaction) []FieldDiff {
+	b := txn.MustSerialize()
+	spans := ExplainTransactionEncoding(txn)
+
+	fields := make([]FieldDiff, len(spans))
+	for i, s := range spans {
+		fields[i] = FieldDiff{
+			Name: s.Name,
+			A:    b[s.Offset : s.Offset+s.Length],
+		}
+	}
+
+	return fields
+}
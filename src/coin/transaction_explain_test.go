@@ -0,0 +1,133 @@
+package coin
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestExplainTransactionEncoding(t *testing.T) {
+	txn, _ := makeTransactionMultipleInputs(t, 2)
+	require.Len(t, txn.Sigs, 2)
+	require.Len(t, txn.In, 2)
+	require.Len(t, txn.Out, 2)
+
+	spans := ExplainTransactionEncoding(txn)
+
+	// The offsets are pinned, so that a change to the encoding is caught
+	require.Equal(t, []FieldSpan{
+		{Name: "Length", Offset: 0, Length: 4},
+		{Name: "Type", Offset: 4, Length: 1},
+		{Name: "InnerHash", Offset: 5, Length: 32},
+		{Name: "len(Sigs)", Offset: 37, Length: 4},
+		{Name: "Sigs[0]", Offset: 41, Length: 65},
+		{Name: "Sigs[1]", Offset: 106, Length: 65},
+		{Name: "len(In)", Offset: 171, Length: 4},
+		{Name: "In[0]", Offset: 175, Length: 32},
+		{Name: "In[1]", Offset: 207, Length: 32},
+		{Name: "len(Out)", Offset: 239, Length: 4},
+		{Name: "Out[0].Address", Offset: 243, Length: 21},
+		{Name: "Out[0].Coins", Offset: 264, Length: 8},
+		{Name: "Out[0].Hours", Offset: 272, Length: 8},
+		{Name: "Out[1].Address", Offset: 280, Length: 21},
+		{Name: "Out[1].Coins", Offset: 301, Length: 8},
+		{Name: "Out[1].Hours", Offset: 309, Length: 8},
+	}, spans)
+
+	// The spans cover the serialization and hold the encoded fields
+	b := txn.MustSerialize()
+	last := spans[len(spans)-1]
+	require.Equal(t, len(b), last.Offset+last.Length)
+	require.Equal(t, int(encodeSizeTransaction(&txn)), len(b))
+
+	field := func(name string) []byte {
+		for _, s := range spans {
+			if s.Name == name {
+				return b[s.Offset : s.Offset+s.Length]
+			}
+		}
+		t.Fatalf("field %s not found", name)
+		return nil
+	}
+
+	require.Equal(t, txn.Length, binary.LittleEndian.Uint32(field("Length")))
+	require.Equal(t, []byte{txn.Type}, field("Type"))
+	require.Equal(t, txn.InnerHash[:], field("InnerHash"))
+	require.Equal(t, uint32(2), binary.LittleEndian.Uint32(field("len(Sigs)")))
+	require.Equal(t, txn.Sigs[1][:], field("Sigs[1]"))
+	require.Equal(t, uint32(2), binary.LittleEndian.Uint32(field("len(In)")))
+	require.Equal(t, txn.In[1][:], field("In[1]"))
+	require.Equal(t, uint32(2), binary.LittleEndian.Uint32(field("len(Out)")))
+	require.Equal(t, append([]byte{txn.Out[1].Address.Version}, txn.Out[1].Address.Key[:]...), field("Out[1].Address"))
+	require.Equal(t, txn.Out[1].Coins, binary.LittleEndian.Uint64(field("Out[1].Coins")))
+	require.Equal(t, txn.Out[1].Hours, binary.LittleEndian.Uint64(field("Out[1].Hours")))
+
+	// Empty transaction
+	require.Equal(t, []FieldSpan{
+		{Name: "Length", Offset: 0, Length: 4},
+		{Name: "Type", Offset: 4, Length: 1},
+		{Name: "InnerHash", Offset: 5, Length: 32},
+		{Name: "len(Sigs)", Offset: 37, Length: 4},
+		{Name: "len(In)", Offset: 41, Length: 4},
+		{Name: "len(Out)", Offset: 45, Length: 4},
+	}, ExplainTransactionEncoding(Transaction{}))
+}
+
+func TestDiffTransactions(t *testing.T) {
+	txn, _ := makeTransactionMultipleInputs(t, 2)
+
+	require.Empty(t, DiffTransactions(txn, txn))
+
+	// Changed output hours
+	txn2 := copyTransaction(txn)
+	txn2.Out[1].Hours++
+	require.Equal(t, []FieldDiff{
+		{
+			Name: "Out[1].Hours",
+			A:    txn.MustSerialize()[309:317],
+			B:    txn2.MustSerialize()[309:317],
+		},
+	}, DiffTransactions(txn, txn2))
+
+	// Added output, after updating the header
+	txn3 := copyTransaction(txn)
+	require.NoError(t, txn3.PushOutput(makeAddress(), 1e6, 1))
+	require.NoError(t, txn3.UpdateHeader())
+
+	diffs := DiffTransactions(txn, txn3)
+	var names []string
+	for _, d := range diffs {
+		names = append(names, d.Name)
+	}
+	require.Equal(t, []string{
+		"Length",
+		"InnerHash",
+		"len(Out)",
+		"Out[2].Address",
+		"Out[2].Coins",
+		"Out[2].Hours",
+	}, names)
+
+	last := diffs[len(diffs)-1]
+	require.Nil(t, last.A)
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, 1)
+	require.Equal(t, b, last.B)
+
+	// Removed fields are reported with a nil B
+	diffs = DiffTransactions(txn3, txn)
+	require.Equal(t, "Out[2].Hours", diffs[len(diffs)-1].Name)
+	require.Nil(t, diffs[len(diffs)-1].B)
+
+	// A changed signature
+	txn4 := copyTransaction(txn)
+	txn4.Sigs[0] = cipher.Sig{}
+	diffs = DiffTransactions(txn, txn4)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "Sigs[0]", diffs[0].Name)
+	require.Equal(t, txn.Sigs[0][:], diffs[0].A)
+	require.Equal(t, make([]byte, 65), diffs[0].B)
+}
@@ -0,0 +1,113 @@
+package coin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+)
+
+var (
+	// ErrNoOutputs is returned by ValidateOutputs if there are no outputs
+	ErrNoOutputs = errors.New("No outputs")
+	// ErrTooManyOutputs is returned by ValidateOutputs if there are more outputs than a transaction can have
+	ErrTooManyOutputs = errors.New("Too many outputs")
+	// ErrNullAddressOutput is returned by ValidateOutputs for an output to the null address
+	ErrNullAddressOutput = errors.New("Null address output")
+	// ErrZeroCoinOutput is returned by ValidateOutputs for an output of zero coins
+	ErrZeroCoinOutput = errors.New("Zero coin output")
+	// ErrOutputDecimals is returned by ValidateOutputs for an output with coins of too many decimal places
+	ErrOutputDecimals = errors.New("Output coins have too many decimal places")
+	// ErrOutputCoinsOverflow is returned by ValidateOutputs if the sum of the output coins overflows
+	ErrOutputCoinsOverflow = errors.New("Output coins overflow")
+	// ErrDuplicateOutput is returned by ValidateOutputs for an output with the address, coins and hours of a previous output
+	ErrDuplicateOutput = errors.New("Duplicate output")
+)
+
+// OutputError is returned by ValidateOutputs for the invalid output at Index
+type OutputError struct {
+	Index int
+	Err   error
+}
+
+func (e OutputError) Error() string {
+	return fmt.Sprintf("output %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the output
+func (e OutputError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateOutputs checks a list of destinations before a transaction is built with them.
+// The outputs must not be to the null address or of zero coins, their coins must not have
+// more than maxDecimals decimal places and no output may have the address, coins and hours
+// of another output, since a transaction can't create the same output twice.
+// The first invalid output is returned as an OutputError.
+// maxDecimals must be <= droplet.Exponent.
+func ValidateOutputs(outs []TransactionOutput, maxDecimals uint8) error {
+	if len(outs) == 0 {
+		return ErrNoOutputs
+	}
+	if len(outs) > math.MaxUint16 {
+		return ErrTooManyOutputs
+	}
+
+	var coins uint64
+	seen := make(map[TransactionOutput]int, len(outs))
+	for i, o := range outs {
+		var err error
+		switch {
+		case o.Address.Null():
+			err = ErrNullAddressOutput
+		case o.Coins == 0:
+			err = ErrZeroCoinOutput
+		case params.DropletPrecisionCheck(maxDecimals, o.Coins) != nil:
+			err = ErrOutputDecimals
+		}
+		if err != nil {
+			return OutputError{
+				Index: i,
+				Err:   err,
+			}
+		}
+
+		if j, ok := seen[o]; ok {
+			return OutputError{
+				Index: i,
+				Err:   fmt.Errorf("%w, same as output %d", ErrDuplicateOutput, j),
+			}
+		}
+		seen[o] = i
+
+		coins, err = mathutil.AddUint64(coins, o.Coins)
+		if err != nil {
+			return OutputError{
+				Index: i,
+				Err:   ErrOutputCoinsOverflow,
+			}
+		}
+	}
+
+	return nil
+}
+
+// DedupOutputs returns the outputs without the outputs that have the address, coins and hours
+// of a previous output, in their original order. The indexes of the removed outputs are returned too.
+func DedupOutputs(outs []TransactionOutput) ([]TransactionOutput, []int) {
+	var removed []int
+	deduped := make([]TransactionOutput, 0, len(outs))
+	seen := make(map[TransactionOutput]struct{}, len(outs))
+	for i, o := range outs {
+		if _, ok := seen[o]; ok {
+			removed = append(removed, i)
+			continue
+		}
+		seen[o] = struct{}{}
+		deduped = append(deduped, o)
+	}
+
+	return deduped, removed
+}
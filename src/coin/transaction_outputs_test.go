@@ -0,0 +1,134 @@
+package coin
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestValidateOutputs(t *testing.T) {
+	addr1 := makeAddress()
+	addr2 := makeAddress()
+
+	cases := []struct {
+		name        string
+		outs        []TransactionOutput
+		maxDecimals uint8
+		err         error
+	}{
+		{
+			name: "no outputs",
+			err:  ErrNoOutputs,
+		},
+		{
+			name: "too many outputs",
+			outs: make([]TransactionOutput, math.MaxUint16+1),
+			err:  ErrTooManyOutputs,
+		},
+		{
+			name: "valid",
+			outs: []TransactionOutput{
+				{Address: addr1, Coins: 1e6, Hours: 10},
+				{Address: addr1, Coins: 1e6, Hours: 11},
+				{Address: addr1, Coins: 2e6, Hours: 10},
+				{Address: addr2, Coins: 1e6, Hours: 10},
+			},
+			maxDecimals: 0,
+		},
+		{
+			name: "null address",
+			outs: []TransactionOutput{
+				{Address: addr1, Coins: 1e6},
+				{Address: cipher.Address{}, Coins: 1e6},
+			},
+			maxDecimals: 3,
+			err:         OutputError{Index: 1, Err: ErrNullAddressOutput},
+		},
+		{
+			name: "zero coins",
+			outs: []TransactionOutput{
+				{Address: addr1, Coins: 0, Hours: 10},
+			},
+			maxDecimals: 3,
+			err:         OutputError{Index: 0, Err: ErrZeroCoinOutput},
+		},
+		{
+			name: "too many decimals",
+			outs: []TransactionOutput{
+				{Address: addr1, Coins: 1e6},
+				{Address: addr2, Coins: 1e6},
+				{Address: addr2, Coins: 1001},
+			},
+			maxDecimals: 3,
+			err:         OutputError{Index: 2, Err: ErrOutputDecimals},
+		},
+		{
+			name: "max decimals",
+			outs: []TransactionOutput{
+				{Address: addr1, Coins: 1},
+			},
+			maxDecimals: 6,
+		},
+		{
+			name: "coins overflow",
+			outs: []TransactionOutput{
+				{Address: addr1, Coins: math.MaxUint64 - 1e6 + 1},
+				{Address: addr2, Coins: 1e6},
+			},
+			maxDecimals: 6,
+			err:         OutputError{Index: 1, Err: ErrOutputCoinsOverflow},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateOutputs(tc.outs, tc.maxDecimals)
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := ValidateOutputs([]TransactionOutput{
+			{Address: addr1, Coins: 1e6, Hours: 10},
+			{Address: addr2, Coins: 1e6, Hours: 10},
+			{Address: addr1, Coins: 1e6, Hours: 10},
+		}, 3)
+		require.Error(t, err)
+		require.Equal(t, "output 2: Duplicate output, same as output 0", err.Error())
+		require.True(t, errors.Is(err, ErrDuplicateOutput))
+
+		var oe OutputError
+		require.True(t, errors.As(err, &oe))
+		require.Equal(t, 2, oe.Index)
+	})
+}
+
+func TestDedupOutputs(t *testing.T) {
+	addr1 := makeAddress()
+	addr2 := makeAddress()
+
+	outs := []TransactionOutput{
+		{Address: addr1, Coins: 1e6, Hours: 10},
+		{Address: addr2, Coins: 1e6, Hours: 10},
+		{Address: addr1, Coins: 1e6, Hours: 10},
+		{Address: addr1, Coins: 1e6, Hours: 11},
+		{Address: addr2, Coins: 1e6, Hours: 10},
+	}
+
+	deduped, removed := DedupOutputs(outs)
+	require.Equal(t, []TransactionOutput{outs[0], outs[1], outs[3]}, deduped)
+	require.Equal(t, []int{2, 4}, removed)
+	require.NoError(t, ValidateOutputs(deduped, 3))
+
+	deduped, removed = DedupOutputs(outs[:2])
+	require.Equal(t, outs[:2], deduped)
+	require.Empty(t, removed)
+
+	deduped, removed = DedupOutputs(nil)
+	require.Empty(t, deduped)
+	require.Empty(t, removed)
+}
@@ -8,9 +8,13 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	pcipher "github.com/ness-network/privateness/src/cipher"
 )
 
 var (
@@ -321,7 +325,7 @@ func (txn *Transaction) SignInput(key cipher.SecKey, index int) error {
 	}
 
 	h := cipher.AddSHA256(txn.InnerHash, txn.In[index])
-	txn.Sigs[index] = cipher.MustSignHash(h, key)
+	txn.Sigs[index] = signHash(h, key)
 
 	return nil
 }
@@ -346,11 +350,132 @@ func (txn *Transaction) SignInputs(keys []cipher.SecKey) {
 	sigs := make([]cipher.Sig, len(txn.In))
 	for i, k := range keys {
 		h := cipher.AddSHA256(txn.InnerHash, txn.In[i]) // hash to sign
-		sigs[i] = cipher.MustSignHash(h, k)
+		sigs[i] = signHash(h, k)
 	}
 	txn.Sigs = sigs
 }
 
+// signHash signs hash with the secp256k1 backend of the local cipher package,
+// whose nonces are derived from the key and hash with RFC6979
+func signHash(hash cipher.SHA256, key cipher.SecKey) cipher.Sig {
+	return cipher.Sig(pcipher.MustSignHash(pcipher.SHA256(hash), pcipher.SecKey(key)))
+}
+
+// SigningKeyError is an error with the key provided to sign the transaction input at Index
+type SigningKeyError struct {
+	Index int
+	Err   error
+}
+
+func (e SigningKeyError) Error() string {
+	return fmt.Sprintf("input %d: %v", e.Index, e.Err)
+}
+
+// SigningKeyErrors is returned by SignInputsChecked and CheckSigningKeys when some of the
+// keys do not own the inputs they are provided for. It has an entry per invalid key.
+type SigningKeyErrors []SigningKeyError
+
+func (e SigningKeyErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid signing keys: %s", strings.Join(msgs, "; "))
+}
+
+// CheckSigningKeys checks that keys[i] is the secret key of owners[i], the address owning the
+// transaction input at index i. If any key does not match, SigningKeyErrors is returned.
+func CheckSigningKeys(keys []cipher.SecKey, owners []cipher.Address) error {
+	if len(keys) != len(owners) {
+		return fmt.Errorf("%d keys provided for %d inputs", len(keys), len(owners))
+	}
+
+	ownerIndexes := make(map[cipher.Address][]int, len(owners))
+	for i, a := range owners {
+		ownerIndexes[a] = append(ownerIndexes[a], i)
+	}
+
+	var errs SigningKeyErrors
+	for i, k := range keys {
+		addr, err := cipher.AddressFromSecKey(k)
+		if err != nil {
+			errs = append(errs, SigningKeyError{
+				Index: i,
+				Err:   fmt.Errorf("invalid key: %v", err),
+			})
+			continue
+		}
+
+		if addr == owners[i] {
+			continue
+		}
+
+		if x, ok := ownerIndexes[addr]; ok {
+			errs = append(errs, SigningKeyError{
+				Index: i,
+				Err:   fmt.Errorf("key of address %s belongs to input %s, not to this input owned by %s", addr, joinInts(x), owners[i]),
+			})
+		} else {
+			errs = append(errs, SigningKeyError{
+				Index: i,
+				Err:   fmt.Errorf("key of address %s does not own any input, the input is owned by %s", addr, owners[i]),
+			})
+		}
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func joinInts(x []int) string {
+	s := make([]string, len(x))
+	for i, v := range x {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
+
+// SignInputsChecked signs all inputs in the transaction, like SignInputs.
+// uxIn are the unspent outputs spent by the transaction's inputs, in the same order.
+// Before signing, each key is checked to be the key of the address owning the corresponding input.
+// Errors are returned instead of panicking, and the transaction is not modified if an error is returned.
+// If keys do not match the inputs, SigningKeyErrors is returned.
+func (txn *Transaction) SignInputsChecked(keys []cipher.SecKey, uxIn UxArray) error {
+	if len(keys) == 0 {
+		return errors.New("No keys")
+	}
+	if len(keys) > math.MaxUint16 {
+		return errors.New("Too many keys")
+	}
+	if len(keys) != len(txn.In) {
+		return errors.New("Invalid number of keys")
+	}
+	if len(uxIn) != len(txn.In) {
+		return errors.New("Number of uxIn does not match number of inputs")
+	}
+	if len(txn.Sigs) > 0 && txn.hasNonNullSignature() {
+		return errors.New("Transaction has been signed")
+	}
+
+	owners := make([]cipher.Address, len(uxIn))
+	for i, ux := range uxIn {
+		if ux.Hash() != txn.In[i] {
+			return fmt.Errorf("uxIn[%d] is not the output spent by input %d", i, i)
+		}
+		owners[i] = ux.Body.Address
+	}
+
+	if err := CheckSigningKeys(keys, owners); err != nil {
+		return err
+	}
+
+	txn.SignInputs(keys)
+	return nil
+}
+
 // Size returns the encoded byte size of the transaction
 func (txn *Transaction) Size() (uint32, error) {
 	buf, err := txn.Serialize()
@@ -631,6 +756,61 @@ func (txns Transactions) TruncateBytesTo(size uint32) (Transactions, error) {
 	return txns, nil
 }
 
+// TransactionError is returned by Transactions.Validate for the invalid transaction at Index
+type TransactionError struct {
+	Index int
+	Hash  cipher.SHA256
+	Err   error
+}
+
+func (e TransactionError) Error() string {
+	return fmt.Sprintf("transaction %d %s: %v", e.Index, e.Hash.Hex(), e.Err)
+}
+
+// Validate checks that the transactions can be included together in a block.
+// Each transaction must pass Verify(), no transaction may appear twice and
+// no unspent output may be spent by more than one transaction.
+// Validate cannot check if the outputs being spent exist, see Transaction.Verify.
+// The first invalid transaction is returned as a TransactionError.
+func (txns Transactions) Validate() error {
+	hashes := make(map[cipher.SHA256]int, len(txns))
+	spent := make(map[cipher.SHA256]int)
+	for i := range txns {
+		h := txns[i].Hash()
+
+		if err := txns[i].Verify(); err != nil {
+			return TransactionError{
+				Index: i,
+				Hash:  h,
+				Err:   err,
+			}
+		}
+
+		if j, ok := hashes[h]; ok {
+			return TransactionError{
+				Index: i,
+				Hash:  h,
+				Err:   fmt.Errorf("Duplicate of transaction %d", j),
+			}
+		}
+		hashes[h] = i
+
+		// Duplicate inputs within a transaction are rejected by Verify
+		for _, in := range txns[i].In {
+			if j, ok := spent[in]; ok {
+				return TransactionError{
+					Index: i,
+					Hash:  h,
+					Err:   fmt.Errorf("Output %s is already spent by transaction %d", in.Hex(), j),
+				}
+			}
+			spent[in] = i
+		}
+	}
+
+	return nil
+}
+
 // SortableTransactions allows sorting transactions by fee & hash
 type SortableTransactions struct {
 	Transactions Transactions
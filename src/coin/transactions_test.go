@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"math"
 	"sort"
 	"strings"
@@ -16,6 +17,8 @@ import (
 	"github.com/skycoin/skycoin/src/testutil"
 	_require "github.com/skycoin/skycoin/src/testutil/require"
 	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	ptestutil "github.com/ness-network/privateness/src/testutil"
 )
 
 func makeTransactionFromUxOuts(t *testing.T, uxs []UxOut, secs []cipher.SecKey) Transaction {
@@ -73,6 +76,12 @@ func makeAddress() cipher.Address {
 	return cipher.AddressFromPubKey(p)
 }
 
+// makeStableAddress returns the address of the i-th stable test key pair
+func makeStableAddress(i int) cipher.Address {
+	p, _ := ptestutil.KeyPair(i)
+	return cipher.AddressFromPubKey(p)
+}
+
 func copyTransaction(txn Transaction) Transaction {
 	txo := Transaction{}
 	txo.Length = txn.Length
@@ -416,6 +425,166 @@ func TestTransactionSignInputs(t *testing.T) {
 	require.Error(t, cipher.VerifyAddressSignedHash(a2, txn.Sigs[0], h))
 }
 
+func TestTransactionSignInputsDeterministic(t *testing.T) {
+	ux, s := makeUxOutWithSecret(t)
+	ux2, s2 := makeUxOutWithSecret(t)
+
+	newTxn := func() *Transaction {
+		txn := &Transaction{}
+		err := txn.PushInput(ux.Hash())
+		require.NoError(t, err)
+		err = txn.PushInput(ux2.Hash())
+		require.NoError(t, err)
+		err = txn.PushOutput(makeAddress(), 40, 80)
+		require.NoError(t, err)
+		return txn
+	}
+
+	txn := newTxn()
+	txn2 := &Transaction{}
+	*txn2 = *txn
+	txn2.In = append([]cipher.SHA256{}, txn.In...)
+	txn2.Out = append([]TransactionOutput{}, txn.Out...)
+
+	// The nonces are derived from the key and hash, so signing twice gives the same signatures
+	txn.SignInputs([]cipher.SecKey{s, s2})
+	txn2.SignInputs([]cipher.SecKey{s, s2})
+	require.Equal(t, txn.MustSerialize(), txn2.MustSerialize())
+
+	// SignInput gives the same signatures as SignInputs
+	txn3 := &Transaction{}
+	*txn3 = *txn2
+	txn3.Sigs = nil
+	require.NoError(t, txn3.SignInput(s, 0))
+	require.NoError(t, txn3.SignInput(s2, 1))
+	require.Equal(t, txn.Sigs, txn3.Sigs)
+
+	// A different transaction gives different signatures
+	txn4 := newTxn()
+	err := txn4.PushOutput(makeAddress(), 1, 1)
+	require.NoError(t, err)
+	txn4.SignInputs([]cipher.SecKey{s, s2})
+	require.NotEqual(t, txn.Sigs[0], txn4.Sigs[0])
+}
+
+func TestTransactionSignInputsChecked(t *testing.T) {
+	ux, s := makeUxOutWithSecret(t)
+	ux2, s2 := makeUxOutWithSecret(t)
+	_, other := cipher.GenerateKeyPair()
+	uxIn := UxArray{ux, ux2}
+
+	newTxn := func() *Transaction {
+		txn := &Transaction{}
+		err := txn.PushInput(ux.Hash())
+		require.NoError(t, err)
+		err = txn.PushInput(ux2.Hash())
+		require.NoError(t, err)
+		err = txn.PushOutput(makeAddress(), 40, 80)
+		require.NoError(t, err)
+		return txn
+	}
+
+	addr := ux.Body.Address
+	addr2 := ux2.Body.Address
+	otherAddr := cipher.MustAddressFromSecKey(other)
+
+	tt := []struct {
+		name string
+		keys []cipher.SecKey
+		uxIn UxArray
+		err  error
+	}{
+		{
+			name: "no keys",
+			uxIn: uxIn,
+			err:  errors.New("No keys"),
+		},
+		{
+			name: "not enough keys",
+			keys: []cipher.SecKey{s},
+			uxIn: uxIn,
+			err:  errors.New("Invalid number of keys"),
+		},
+		{
+			name: "not enough uxIn",
+			keys: []cipher.SecKey{s, s2},
+			uxIn: uxIn[:1],
+			err:  errors.New("Number of uxIn does not match number of inputs"),
+		},
+		{
+			name: "uxIn wrong order",
+			keys: []cipher.SecKey{s, s2},
+			uxIn: UxArray{ux2, ux},
+			err:  errors.New("uxIn[0] is not the output spent by input 0"),
+		},
+		{
+			name: "keys wrong order",
+			keys: []cipher.SecKey{s2, s},
+			uxIn: uxIn,
+			err: SigningKeyErrors{
+				{
+					Index: 0,
+					Err:   fmt.Errorf("key of address %s belongs to input 1, not to this input owned by %s", addr2, addr),
+				},
+				{
+					Index: 1,
+					Err:   fmt.Errorf("key of address %s belongs to input 0, not to this input owned by %s", addr, addr2),
+				},
+			},
+		},
+		{
+			name: "key of unrelated address",
+			keys: []cipher.SecKey{s, other},
+			uxIn: uxIn,
+			err: SigningKeyErrors{
+				{
+					Index: 1,
+					Err:   fmt.Errorf("key of address %s does not own any input, the input is owned by %s", otherAddr, addr2),
+				},
+			},
+		},
+		{
+			name: "invalid key",
+			keys: []cipher.SecKey{{}, s2},
+			uxIn: uxIn,
+			err: SigningKeyErrors{
+				{
+					Index: 0,
+					Err:   errors.New("invalid key: Attempt to load null seckey, unsafe"),
+				},
+			},
+		},
+		{
+			name: "valid",
+			keys: []cipher.SecKey{s, s2},
+			uxIn: uxIn,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			txn := newTxn()
+			err := txn.SignInputsChecked(tc.keys, tc.uxIn)
+			require.Equal(t, tc.err, err)
+
+			if tc.err != nil {
+				require.Empty(t, txn.Sigs)
+				return
+			}
+
+			require.Len(t, txn.Sigs, 2)
+			require.NoError(t, cipher.VerifyAddressSignedHash(addr, txn.Sigs[0], cipher.AddSHA256(txn.InnerHash, txn.In[0])))
+			require.NoError(t, cipher.VerifyAddressSignedHash(addr2, txn.Sigs[1], cipher.AddSHA256(txn.InnerHash, txn.In[1])))
+		})
+	}
+
+	// An already signed transaction is not signed again
+	txn := newTxn()
+	txn.SignInputs([]cipher.SecKey{s, s2})
+	err := txn.SignInputsChecked([]cipher.SecKey{s, s2}, uxIn)
+	require.Equal(t, errors.New("Transaction has been signed"), err)
+}
+
 func TestTransactionHash(t *testing.T) {
 	txn := makeTransaction(t)
 	h := txn.Hash()
@@ -558,6 +727,74 @@ func TestTransactionsHashes(t *testing.T) {
 	}
 }
 
+func TestTransactionsValidate(t *testing.T) {
+	ux, s := makeUxOutWithSecret(t)
+	txn := makeTransactionFromUxOut(t, ux, s)
+	// A distinct transaction spending the same output
+	doubleSpend := makeTransactionFromUxOut(t, ux, s)
+	require.NotEqual(t, txn.Hash(), doubleSpend.Hash())
+
+	invalid := makeTransaction(t)
+	invalid.Length++
+
+	other := makeTransaction(t)
+
+	cases := []struct {
+		name string
+		txns Transactions
+		err  error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "valid",
+			txns: Transactions{txn, other},
+		},
+		{
+			name: "invalid transaction",
+			txns: Transactions{txn, invalid},
+			err: TransactionError{
+				Index: 1,
+				Hash:  invalid.Hash(),
+				Err:   errors.New("Incorrect transaction length"),
+			},
+		},
+		{
+			name: "duplicate transaction",
+			txns: Transactions{txn, other, txn},
+			err: TransactionError{
+				Index: 2,
+				Hash:  txn.Hash(),
+				Err:   errors.New("Duplicate of transaction 0"),
+			},
+		},
+		{
+			name: "double spend",
+			txns: Transactions{other, txn, doubleSpend},
+			err: TransactionError{
+				Index: 2,
+				Hash:  doubleSpend.Hash(),
+				Err:   fmt.Errorf("Output %s is already spent by transaction 1", ux.Hash().Hex()),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.txns.Validate()
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	err := TransactionError{
+		Index: 2,
+		Hash:  txn.Hash(),
+		Err:   errors.New("Duplicate of transaction 0"),
+	}
+	require.Equal(t, fmt.Sprintf("transaction 2 %s: Duplicate of transaction 0", txn.Hash().Hex()), err.Error())
+}
+
 func TestTransactionsTruncateBytesTo(t *testing.T) {
 	txns := makeTransactions(t, 10)
 	var trunc uint32
@@ -1157,3 +1394,23 @@ func TestTransactionSignedUnsigned(t *testing.T) {
 	require.True(t, txn.IsFullyUnsigned())
 	require.False(t, txn.hasNullSignature())
 }
+
+func TestTransactionGolden(t *testing.T) {
+	// The fixtures are made from stable test key pairs, so their bytes are the same in every run
+	ux, _ := makeStableUxOutWithSecret(0)
+	require.Equal(t, "87916a9c4d74be628abd4c88e18e7ca24da35daa3a3dc78e6a81205bd271c634", ux.Hash().Hex())
+
+	txn := Transaction{}
+	err := txn.PushInput(ux.Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(makeStableAddress(1), 1e6, 50)
+	require.NoError(t, err)
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	require.Equal(t, "da1490fec3b650e5a58098b6bc1595cdf6b09907825513692b55f1a37b508187", txn.InnerHash.Hex())
+
+	b, err := txn.Serialize()
+	require.NoError(t, err)
+	require.Equal(t, "7600000000da1490fec3b650e5a58098b6bc1595cdf6b09907825513692b55f1a37b508187000000000100000087916a9c4d74be628abd4c88e18e7ca24da35daa3a3dc78e6a81205bd271c6340100000000e11c0e268b11452a635fd960305753a87c16362140420f00000000003200000000000000", hex.EncodeToString(b))
+}
@@ -0,0 +1,227 @@
+package daemon
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ness-network/privateness/src/daemon/pex"
+)
+
+var promAlwaysConnectDrops = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "always_connect_peer_drops_total",
+		Help: "Number of times the connection to an always connect peer was lost",
+	}, []string{"addr"})
+
+func init() {
+	prometheus.MustRegister(promAlwaysConnectDrops)
+}
+
+// alwaysConnectPeer is the redial state of an always connect peer
+type alwaysConnectPeer struct {
+	// Time of the next connection attempt
+	nextAttempt time.Time
+	// Delay added after the next failed connection attempt
+	backoff time.Duration
+}
+
+// alwaysConnectPeers tracks the peers listed in DaemonConfig.AlwaysConnect.
+// A peer that can't be reached is redialed with an exponential backoff,
+// which is reset once a connection to the peer completes the introduction.
+type alwaysConnectPeers struct {
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	peers      map[string]*alwaysConnectPeer
+	sync.Mutex
+}
+
+// newAlwaysConnectPeers creates alwaysConnectPeers
+func newAlwaysConnectPeers(addrs []string, minBackoff, maxBackoff time.Duration) *alwaysConnectPeers {
+	peers := make(map[string]*alwaysConnectPeer, len(addrs))
+	for _, a := range addrs {
+		peers[a] = &alwaysConnectPeer{
+			backoff: minBackoff,
+		}
+	}
+
+	return &alwaysConnectPeers{
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		peers:      peers,
+	}
+}
+
+// has returns true if addr is an always connect peer
+func (p *alwaysConnectPeers) has(addr string) bool {
+	p.Lock()
+	defer p.Unlock()
+	_, ok := p.peers[addr]
+	return ok
+}
+
+// due returns the always connect peers whose next connection attempt is due, sorted by address
+func (p *alwaysConnectPeers) due(now time.Time) []string {
+	p.Lock()
+	defer p.Unlock()
+
+	var addrs []string
+	for a, peer := range p.peers {
+		if !now.Before(peer.nextAttempt) {
+			addrs = append(addrs, a)
+		}
+	}
+
+	sort.Strings(addrs)
+	return addrs
+}
+
+// attempted records a connection attempt to the peer at addr.
+// The next attempt is delayed by the current backoff, and the backoff doubles, up to maxBackoff.
+func (p *alwaysConnectPeers) attempted(addr string, now time.Time) {
+	p.Lock()
+	defer p.Unlock()
+
+	peer, ok := p.peers[addr]
+	if !ok {
+		return
+	}
+
+	peer.nextAttempt = now.Add(peer.backoff)
+	peer.backoff *= 2
+	if peer.backoff > p.maxBackoff {
+		peer.backoff = p.maxBackoff
+	}
+}
+
+// introduced resets the backoff of the peer at addr after a successful connection
+func (p *alwaysConnectPeers) introduced(addr string) {
+	p.Lock()
+	defer p.Unlock()
+
+	peer, ok := p.peers[addr]
+	if !ok {
+		return
+	}
+
+	peer.backoff = p.minBackoff
+	peer.nextAttempt = time.Time{}
+}
+
+// dropped records the loss of the connection to the peer at addr.
+// A peer that had introduced is redialed at once, otherwise the pending backoff applies.
+func (p *alwaysConnectPeers) dropped(addr string, wasIntroduced bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	peer, ok := p.peers[addr]
+	if !ok {
+		return
+	}
+
+	if wasIntroduced {
+		peer.nextAttempt = time.Time{}
+	}
+}
+
+// alwaysConnectAddr returns the always connect peer address of a connection, if any.
+// Outgoing connections use the peer's address, incoming connections are matched by their listen address.
+func (dm *Daemon) alwaysConnectAddr(c *connection) (string, bool) {
+	if dm.alwaysConnect.has(c.Addr) {
+		return c.Addr, true
+	}
+
+	if listenAddr := c.ListenAddr(); listenAddr != "" && dm.alwaysConnect.has(listenAddr) {
+		return listenAddr, true
+	}
+
+	return "", false
+}
+
+// isAlwaysConnectPeer returns true if addr is the address of an always connect peer,
+// or of a connection to an always connect peer
+func (dm *Daemon) isAlwaysConnectPeer(addr string) bool {
+	if dm.alwaysConnect.has(addr) {
+		return true
+	}
+
+	c := dm.connections.get(addr)
+	if c == nil {
+		return false
+	}
+
+	_, ok := dm.alwaysConnectAddr(c)
+	return ok
+}
+
+// alwaysConnectLen returns the number of outgoing and total connections to always connect peers.
+// These connections are exempt from the connection limits.
+func (dm *Daemon) alwaysConnectLen() (outgoing, total int) {
+	for _, c := range dm.connections.all() {
+		c := c
+		if _, ok := dm.alwaysConnectAddr(&c); !ok {
+			continue
+		}
+
+		total++
+		if c.Outgoing {
+			outgoing++
+		}
+	}
+
+	return outgoing, total
+}
+
+// connectToAlwaysConnectPeers dials the always connect peers that are not connected and whose backoff has elapsed
+func (dm *Daemon) connectToAlwaysConnectPeers() {
+	if dm.config.DisableOutgoingConnections {
+		return
+	}
+
+	now := time.Now()
+	for _, addr := range dm.alwaysConnect.due(now) {
+		if dm.connections.get(addr) != nil || len(dm.connections.getByListenAddr(addr)) != 0 {
+			continue
+		}
+
+		// The peer must be in the peerlist to complete the introduction of an outgoing connection.
+		// It may have been removed from the peerlist after a disconnect.
+		if err := dm.pex.AddPeer(addr); err != nil {
+			logger.WithError(err).WithField("addr", addr).Error("Add always connect peer to the peerlist failed")
+		}
+
+		dm.alwaysConnect.attempted(addr, now)
+
+		logger.WithField("addr", addr).Debug("Connecting to always connect peer")
+		if err := dm.connectToPeer(pex.Peer{Addr: addr}); err != nil {
+			logger.WithError(err).WithField("addr", addr).Warning("connect to always connect peer failed")
+		}
+	}
+}
+
+// onAlwaysConnectDisconnect logs and counts the loss of a connection to an always connect peer,
+// and schedules its redial. It must be called before the connection is removed.
+func (dm *Daemon) onAlwaysConnectDisconnect(e DisconnectEvent) {
+	c := dm.connections.get(e.Addr)
+	if c == nil || c.gnetID != e.GnetID {
+		return
+	}
+
+	addr, ok := dm.alwaysConnectAddr(c)
+	if !ok {
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"addr":   addr,
+		"reason": e.Reason,
+		"gnetID": e.GnetID,
+	}).Warning("Lost connection to always connect peer")
+
+	promAlwaysConnectDrops.WithLabelValues(addr).Inc()
+
+	dm.alwaysConnect.dropped(addr, c.HasIntroduced())
+}
@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/util/useragent"
+)
+
+func TestAlwaysConnectPeersBackoff(t *testing.T) {
+	addr := "1.2.3.4:6000"
+	p := newAlwaysConnectPeers([]string{addr, "1.2.3.5:6000"}, time.Second, time.Second*5)
+
+	require.True(t, p.has(addr))
+	require.False(t, p.has("1.2.3.6:6000"))
+
+	now := time.Now()
+	require.Equal(t, []string{addr, "1.2.3.5:6000"}, p.due(now))
+
+	// Each attempt delays the next one by the backoff, which doubles up to the maximum
+	expectedBackoffs := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}
+	for _, d := range expectedBackoffs {
+		p.attempted(addr, now)
+		require.Equal(t, []string{"1.2.3.5:6000"}, p.due(now.Add(d-time.Millisecond)))
+		require.Equal(t, []string{addr, "1.2.3.5:6000"}, p.due(now.Add(d)))
+		now = now.Add(d)
+	}
+
+	// A drop before the introduction keeps the pending backoff
+	p.attempted(addr, now)
+	p.dropped(addr, false)
+	require.Equal(t, []string{"1.2.3.5:6000"}, p.due(now))
+
+	// A drop after the introduction redials at once, with the backoff reset
+	p.introduced(addr)
+	p.attempted(addr, now)
+	p.dropped(addr, true)
+	require.Equal(t, []string{addr, "1.2.3.5:6000"}, p.due(now))
+	p.attempted(addr, now)
+	require.Equal(t, []string{"1.2.3.5:6000"}, p.due(now.Add(time.Second*2-time.Millisecond)))
+	require.Equal(t, []string{addr, "1.2.3.5:6000"}, p.due(now.Add(time.Second*2)))
+
+	// Unknown addresses are ignored
+	p.attempted("1.2.3.6:6000", now)
+	p.introduced("1.2.3.6:6000")
+	p.dropped("1.2.3.6:6000", true)
+}
+
+// testPeer is a peer that accepts connections but never speaks, which can be killed and restored
+type testPeer struct {
+	t        *testing.T
+	addr     string
+	listener net.Listener
+	conns    []net.Conn
+	wg       sync.WaitGroup
+	sync.Mutex
+}
+
+func newTestPeer(t *testing.T) *testPeer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &testPeer{
+		t:    t,
+		addr: l.Addr().String(),
+	}
+	p.serve(l)
+	return p
+}
+
+func (p *testPeer) serve(l net.Listener) {
+	p.listener = l
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			p.Lock()
+			p.conns = append(p.conns, c)
+			p.Unlock()
+		}
+	}()
+}
+
+// kill closes the listener and all connections
+func (p *testPeer) kill() {
+	require.NoError(p.t, p.listener.Close())
+	p.wg.Wait()
+
+	p.Lock()
+	defer p.Unlock()
+	for _, c := range p.conns {
+		require.NoError(p.t, c.Close())
+	}
+	p.conns = nil
+}
+
+// restore listens again on the same address
+func (p *testPeer) restore() {
+	l, err := net.Listen("tcp", p.addr)
+	require.NoError(p.t, err)
+	p.serve(l)
+}
+
+// alwaysConnectHarness runs the parts of the daemon run loop that manage always connect peers
+type alwaysConnectHarness struct {
+	dm   *Daemon
+	dir  string
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newAlwaysConnectHarness(t *testing.T, alwaysConnect []string, configure ...func(*Config)) *alwaysConnectHarness {
+	dir, err := ioutil.TempDir("", "always-connect")
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.Daemon.Address = "127.0.0.1"
+	cfg.Daemon.Port = 0
+	cfg.Daemon.LocalhostOnly = true
+	cfg.Daemon.DisableIncomingConnections = true
+	cfg.Daemon.UserAgent = useragent.Data{
+		Coin:    "skycoin",
+		Version: "0.25.0",
+	}
+	// No outgoing slots are available, the always connect peers are exempt from the limits
+	cfg.Daemon.MaxOutgoingConnections = 0
+	cfg.Daemon.AlwaysConnect = alwaysConnect
+	cfg.Daemon.AlwaysConnectRate = time.Millisecond * 10
+	cfg.Daemon.AlwaysConnectMinBackoff = time.Millisecond * 50
+	cfg.Daemon.AlwaysConnectMaxBackoff = time.Millisecond * 200
+	cfg.Pex.DataDirectory = dir
+
+	for _, f := range configure {
+		f(&cfg)
+	}
+
+	dm, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	h := &alwaysConnectHarness{
+		dm:   dm,
+		dir:  dir,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	poolDone := make(chan struct{})
+	go func() {
+		defer close(poolDone)
+		if err := dm.pool.RunOffline(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	go func() {
+		defer close(h.done)
+		defer func() {
+			dm.pool.Shutdown()
+			<-poolDone
+		}()
+
+		ticker := time.NewTicker(dm.config.AlwaysConnectRate)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.quit:
+				return
+			case <-ticker.C:
+				dm.connectToAlwaysConnectPeers()
+			case e := <-dm.events:
+				dm.handleEvent(e)
+			case r := <-dm.pool.Pool.SendResults:
+				dm.handleMessageSendResult(r)
+			}
+		}
+	}()
+
+	return h
+}
+
+func (h *alwaysConnectHarness) shutdown() {
+	close(h.quit)
+	<-h.done
+	os.RemoveAll(h.dir) //nolint:errcheck
+}
+
+func waitForCondition(t *testing.T, msg string, f func() bool) {
+	deadline := time.Now().Add(time.Second * 10)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", msg)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func alwaysConnectDrops(t *testing.T, addr string) float64 {
+	var m dto.Metric
+	require.NoError(t, promAlwaysConnectDrops.WithLabelValues(addr).Write(&m))
+	return m.GetCounter().GetValue()
+}
+
+func TestAlwaysConnectReconnect(t *testing.T) {
+	peer := newTestPeer(t)
+	h := newAlwaysConnectHarness(t, []string{peer.addr})
+	defer h.shutdown()
+
+	isConnected := func() bool {
+		c := h.dm.connections.get(peer.addr)
+		return c != nil && c.State == ConnectionStateConnected
+	}
+
+	waitForCondition(t, "the always connect peer to connect", isConnected)
+	require.True(t, h.dm.isAlwaysConnectPeer(peer.addr))
+	require.Equal(t, []string{peer.addr}, h.dm.GetAlwaysConnectPeers())
+
+	drops := alwaysConnectDrops(t, peer.addr)
+
+	// Kill the peer, the connection is dropped and the redials fail
+	peer.kill()
+	waitForCondition(t, "the always connect peer to disconnect", func() bool {
+		return alwaysConnectDrops(t, peer.addr) == drops+1
+	})
+
+	time.Sleep(h.dm.config.AlwaysConnectMaxBackoff)
+	require.False(t, isConnected())
+
+	// Restore the peer, the daemon reconnects
+	peer.restore()
+	defer peer.kill()
+	waitForCondition(t, "the always connect peer to reconnect", isConnected)
+}
@@ -5,30 +5,38 @@ import (
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
+
+	pvisor "github.com/ness-network/privateness/src/visor"
 )
 
+// announcedTxnsCache collects the announcements of transactions sent to peers,
+// until they are flushed to the unconfirmed transaction pool
 type announcedTxnsCache struct {
 	sync.Mutex
-	cache map[cipher.SHA256]int64
+	cache map[cipher.SHA256]pvisor.TxnAnnouncement
 }
 
 func newAnnouncedTxnsCache() *announcedTxnsCache {
 	return &announcedTxnsCache{
-		cache: make(map[cipher.SHA256]int64),
+		cache: make(map[cipher.SHA256]pvisor.TxnAnnouncement),
 	}
 }
 
+// add records that the txns were sent to a peer, counting the peers they were sent to since the last flush
 func (c *announcedTxnsCache) add(txns []cipher.SHA256) {
 	c.Lock()
 	defer c.Unlock()
 
 	t := time.Now().UTC().UnixNano()
 	for _, txn := range txns {
-		c.cache[txn] = t
+		a := c.cache[txn]
+		a.Time = t
+		a.Peers++
+		c.cache[txn] = a
 	}
 }
 
-func (c *announcedTxnsCache) flush() map[cipher.SHA256]int64 {
+func (c *announcedTxnsCache) flush() map[cipher.SHA256]pvisor.TxnAnnouncement {
 	c.Lock()
 	defer c.Unlock()
 
@@ -38,7 +46,7 @@ func (c *announcedTxnsCache) flush() map[cipher.SHA256]int64 {
 
 	cache := c.cache
 
-	c.cache = make(map[cipher.SHA256]int64)
+	c.cache = make(map[cipher.SHA256]pvisor.TxnAnnouncement)
 
 	return cache
 }
@@ -65,6 +65,35 @@ type ConnectionDetails struct {
 	UserAgent            useragent.Data
 	UnconfirmedVerifyTxn params.VerifyTxn
 	GenesisHash          cipher.SHA256
+	// MinProtocolVersion is the lowest protocol version supported by the peer, 0 if the peer did not advertise it.
+	// ProtocolVersion is the highest.
+	MinProtocolVersion int32
+	// NegotiatedProtocolVersion is the protocol version of the connection
+	NegotiatedProtocolVersion int32
+	// Features are the optional protocol features advertised by the peer
+	Features ProtocolFeatures
+	// NegotiatedFeatures are the optional protocol features supported by both peers
+	NegotiatedFeatures ProtocolFeatures
+	// RTT is the round trip time of the last answered keepalive ping, 0 if no keepalive ping was answered
+	RTT time.Duration
+	// MissedPongs is the number of consecutive keepalive pings that were not answered in time
+	MissedPongs int
+	// SlowMessageHandlers is the number of the peer's messages whose processing exceeded its deadline
+	SlowMessageHandlers int
+
+	keepalive keepaliveState
+}
+
+// SupportsProtocolVersion returns true if the connection's negotiated protocol version is at least version.
+// It is false until the connection has introduced.
+func (c ConnectionDetails) SupportsProtocolVersion(version int32) bool {
+	return c.HasIntroduced() && c.NegotiatedProtocolVersion >= version
+}
+
+// HasProtocolFeature returns true if both peers support the optional protocol features f.
+// It is false until the connection has introduced.
+func (c ConnectionDetails) HasProtocolFeature(f ProtocolFeatures) bool {
+	return c.HasIntroduced() && c.NegotiatedFeatures.Has(f)
 }
 
 // HasIntroduced returns true if the connection has introduced
@@ -296,6 +325,10 @@ func (c *Connections) introduced(addr string, gnetID uint64, m *IntroductionMess
 	conn.State = ConnectionStateIntroduced
 	conn.Mirror = m.Mirror
 	conn.ProtocolVersion = m.ProtocolVersion
+	conn.MinProtocolVersion = m.MinProtocolVersion
+	conn.NegotiatedProtocolVersion = m.NegotiatedProtocolVersion
+	conn.Features = m.Features
+	conn.NegotiatedFeatures = m.NegotiatedFeatures
 	conn.ListenPort = listenPort
 	conn.UserAgent = m.UserAgent
 	conn.UnconfirmedVerifyTxn = m.UnconfirmedVerifyTxn
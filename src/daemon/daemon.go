@@ -4,6 +4,7 @@ Package daemon controls the networking layer of the skycoin daemon
 package daemon
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -17,16 +18,20 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon/gnet"
-	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/elapse"
 	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/iputil"
 	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/util/useragent"
-	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/daemon/forcerequest"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/daemon/propagation"
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 var (
@@ -116,6 +121,30 @@ func (cfg *Config) preprocess() (Config, error) {
 		config.Daemon.MaxPendingConnections = config.Daemon.MaxOutgoingConnections
 	}
 
+	for _, a := range config.Daemon.AlwaysConnect {
+		if _, _, err := iputil.SplitAddr(a); err != nil {
+			return Config{}, fmt.Errorf("Invalid AlwaysConnect address %q: %v", a, err)
+		}
+	}
+
+	if len(config.Daemon.AlwaysConnect) != 0 && (config.Daemon.AlwaysConnectMinBackoff <= 0 || config.Daemon.AlwaysConnectMaxBackoff < config.Daemon.AlwaysConnectMinBackoff) {
+		return Config{}, errors.New("AlwaysConnectMinBackoff must be > 0 and <= AlwaysConnectMaxBackoff")
+	}
+
+	if config.Daemon.ProtocolFeatures.Has(ProtocolFeatureKeepalive) && (config.Daemon.KeepaliveInterval <= 0 || config.Daemon.KeepaliveTimeout <= 0 || config.Daemon.KeepaliveMaxMissedPongs <= 0) {
+		return Config{}, errors.New("KeepaliveInterval, KeepaliveTimeout and KeepaliveMaxMissedPongs must be > 0")
+	}
+
+	if config.Daemon.MessageHandlerTimeout < 0 || config.Daemon.MaxSlowMessageHandlers < 0 {
+		return Config{}, errors.New("MessageHandlerTimeout and MaxSlowMessageHandlers must be >= 0")
+	}
+	for prefix, timeout := range config.Daemon.MessageHandlerTimeouts {
+		if timeout < 0 {
+			return Config{}, fmt.Errorf("MessageHandlerTimeouts[%s] must be >= 0", prefix[:])
+		}
+	}
+
+	config.Pool.AlwaysConnect = config.Daemon.AlwaysConnect
 	config.Pool.MaxConnections = config.Daemon.MaxConnections
 	config.Pool.MaxOutgoingConnections = config.Daemon.MaxOutgoingConnections
 	config.Pool.MaxIncomingMessageLength = int(config.Daemon.MaxIncomingMessageLength)
@@ -153,10 +182,12 @@ func maxSizeGiveBlocksMessage(maxBlockSize uint32) uint64 {
 
 // DaemonConfig configuration for the Daemon
 type DaemonConfig struct { //nolint:golint
-	// Protocol version. TODO -- manage version better
+	// Highest supported protocol version. Connections use the highest version supported by both peers
 	ProtocolVersion int32
 	// Minimum accepted protocol version
 	MinProtocolVersion int32
+	// Optional protocol features supported by this node, see ProtocolFeatures
+	ProtocolFeatures ProtocolFeatures
 	// IP Address to serve on. Leave empty for automatic assignment
 	Address string
 	// BlockchainPubkey blockchain pubkey string
@@ -197,6 +228,19 @@ type DaemonConfig struct { //nolint:golint
 	LocalhostOnly bool
 	// Log ping and pong messages
 	LogPings bool
+	// How long a connection that supports keepalives must be idle before a keepalive ping is sent
+	KeepaliveInterval time.Duration
+	// How long to wait for the reply to a keepalive ping before it is considered missed
+	KeepaliveTimeout time.Duration
+	// Number of consecutive missed keepalive pings after which the peer is disconnected
+	KeepaliveMaxMissedPongs int
+	// Deadline for processing a message received from a peer. Set to 0 to disable message deadlines
+	MessageHandlerTimeout time.Duration
+	// Per message type deadlines, overriding MessageHandlerTimeout
+	MessageHandlerTimeouts map[gnet.MessagePrefix]time.Duration
+	// Number of message deadline violations tolerated before the peer is disconnected.
+	// Set to 0 to never disconnect peers for slow messages
+	MaxSlowMessageHandlers int
 	// How often to request blocks from peers
 	BlocksRequestRate time.Duration
 	// How often to announce our blocks to peers
@@ -215,6 +259,15 @@ type DaemonConfig struct { //nolint:golint
 	UnconfirmedRemoveInvalidRate time.Duration
 	// Default "trusted" peers
 	DefaultConnections []string
+	// Peers that are always kept connected, redialed with backoff whenever the connection drops.
+	// They are exempt from the connection limits
+	AlwaysConnect []string
+	// How often to check for always connect peers that need to be redialed
+	AlwaysConnectRate time.Duration
+	// Initial delay between connection attempts to an unreachable always connect peer
+	AlwaysConnectMinBackoff time.Duration
+	// Maximum delay between connection attempts to an unreachable always connect peer
+	AlwaysConnectMaxBackoff time.Duration
 	// User agent (sent in introduction messages)
 	UserAgent useragent.Data
 	userAgent string // parsed from UserAgent in preprocess()
@@ -228,6 +281,19 @@ type DaemonConfig struct { //nolint:golint
 	MaxOutgoingMessageLength uint64
 	// Maximum total size of transactions in a block
 	MaxBlockTransactionsSize uint32
+	// How long to wait on shutdown for disconnect notices to be written to peers
+	ShutdownDrainTimeout time.Duration
+	// Number of transactions and blocks whose propagation is tracked
+	PropagationTrackerSize int
+	// Secret key that signs exported peer bundles, exports can't be signed if it is null
+	NodeKey cipher.SecKey
+	// Public keys trusted to sign imported peer bundles. If empty, unsigned bundles are accepted
+	TrustedPeerBundleKeys []cipher.PubKey
+	// Minimum interval between the block and transaction requests forced by an operator, 0 for no limit
+	ForceRequestInterval time.Duration
+	// Minimum interval between announcements of an unconfirmed transaction to newly introduced peers.
+	// Transactions announced more recently, including before a restart, are not announced again.
+	TxnReannounceInterval time.Duration
 }
 
 // NewDaemonConfig creates daemon config
@@ -235,11 +301,15 @@ func NewDaemonConfig() DaemonConfig {
 	return DaemonConfig{
 		ProtocolVersion:              2,
 		MinProtocolVersion:           2,
+		ProtocolFeatures:             ProtocolFeatureKeepalive,
 		Address:                      "",
 		Port:                         6677,
 		OutgoingRate:                 time.Second * 5,
 		OutgoingTrustedRate:          time.Millisecond * 100,
 		PrivateRate:                  time.Second * 5,
+		AlwaysConnectRate:            time.Second,
+		AlwaysConnectMinBackoff:      time.Second,
+		AlwaysConnectMaxBackoff:      time.Minute * 2,
 		MaxConnections:               128,
 		MaxOutgoingConnections:       8,
 		MaxPendingConnections:        8,
@@ -252,6 +322,11 @@ func NewDaemonConfig() DaemonConfig {
 		DisableIncomingConnections:   false,
 		LocalhostOnly:                false,
 		LogPings:                     true,
+		KeepaliveInterval:            time.Second * 30,
+		KeepaliveTimeout:             time.Second * 10,
+		KeepaliveMaxMissedPongs:      2,
+		MessageHandlerTimeout:        time.Minute,
+		MaxSlowMessageHandlers:       5,
 		BlocksRequestRate:            time.Second * 60,
 		BlocksAnnounceRate:           time.Second * 60,
 		GetBlocksRequestCount:        20,
@@ -265,6 +340,10 @@ func NewDaemonConfig() DaemonConfig {
 		MaxOutgoingMessageLength:     256 * 1024,
 		MaxIncomingMessageLength:     1024 * 1024,
 		MaxBlockTransactionsSize:     32768,
+		ShutdownDrainTimeout:         time.Second * 5,
+		PropagationTrackerSize:       propagation.DefaultSize,
+		ForceRequestInterval:         forcerequest.DefaultInterval,
+		TxnReannounceInterval:        time.Minute * 10,
 	}
 }
 
@@ -279,6 +358,7 @@ type daemoner interface {
 	disconnectNow(addr string, r gnet.DisconnectReason) error
 	addPeers(addrs []string) int
 	recordPeerHeight(addr string, gnetID, height uint64)
+	scorePeer(addr string, ev pex.ScoreEvent)
 	getSignedBlocksSince(seq, count uint64) ([]coin.SignedBlock, error)
 	headBkSeq() (uint64, bool, error)
 	executeSignedBlock(b coin.SignedBlock) error
@@ -291,6 +371,10 @@ type daemoner interface {
 	recordMessageEvent(m asyncMessage, c *gnet.MessageContext) error
 	connectionIntroduced(addr string, gnetID uint64, m *IntroductionMessage) (*connection, error)
 	sendRandomPeers(addr string) error
+	recordTxnAnnouncements(addr string, txns []cipher.SHA256)
+	recordBlockAnnouncement(addr string, seq uint64)
+	recordBlockSeen(addr string, b coin.SignedBlock)
+	recordKeepalivePong(addr string, gnetID, nonce uint64)
 }
 
 // Daemon stateful properties of the daemon
@@ -308,6 +392,14 @@ type Daemon struct {
 	announcedTxns *announcedTxnsCache
 	// Cache of connection metadata
 	connections *Connections
+	// Redial state of the always connect peers
+	alwaysConnect *alwaysConnectPeers
+	// Propagation of recently seen transactions and blocks
+	propagation *propagation.Tracker
+	// Rate limit of the requests forced by an operator
+	forceRequests *forcerequest.Limiter
+	// Signals the block creation loop that the block publisher role was armed or disarmed
+	publisherChanged chan struct{}
 	// connect, disconnect, message, error events channel
 	events chan interface{}
 	// quit channel
@@ -336,9 +428,14 @@ func New(config Config, v *visor.Visor) (*Daemon, error) {
 
 		announcedTxns: newAnnouncedTxnsCache(),
 		connections:   NewConnections(),
+		alwaysConnect: newAlwaysConnectPeers(config.Daemon.AlwaysConnect, config.Daemon.AlwaysConnectMinBackoff, config.Daemon.AlwaysConnectMaxBackoff),
+		propagation:   propagation.NewTracker(config.Daemon.PropagationTrackerSize),
+		forceRequests: forcerequest.NewLimiter(config.Daemon.ForceRequestInterval),
 		events:        make(chan interface{}, config.Pool.EventChannelSize),
 		quit:          make(chan struct{}),
 		done:          make(chan struct{}),
+
+		publisherChanged: make(chan struct{}, 1),
 	}
 
 	d.pool, err = NewPool(config.Pool, d)
@@ -382,6 +479,10 @@ type messageEvent struct {
 func (dm *Daemon) Shutdown() {
 	defer logger.Info("Daemon shutdown complete")
 
+	if !dm.config.DisableNetworking {
+		dm.drainConnections()
+	}
+
 	// close daemon run loop first to avoid creating new connection after
 	// the connection pool is shutdown.
 	logger.Info("Stopping the daemon run loop")
@@ -396,15 +497,37 @@ func (dm *Daemon) Shutdown() {
 	<-dm.done
 }
 
+// drainConnections stops accepting incoming connections, then sends a disconnect notice to all peers
+// and waits for the notices to be written, for at most ShutdownDrainTimeout.
+// The connections are closed afterwards by the pool shutdown.
+func (dm *Daemon) drainConnections() {
+	logger.Info("Stopping the connection listener")
+	dm.pool.Pool.StopListening()
+
+	conns := dm.connections.all()
+	logger.WithField("peers", len(conns)).Info("Sending disconnect notices")
+	for _, c := range conns {
+		if err := dm.Disconnect(c.Addr, ErrDisconnectNodeShutdown); err != nil {
+			logger.WithError(err).WithField("addr", c.Addr).Warning("Send disconnect notice failed")
+		}
+	}
+
+	if !dm.pool.Pool.WaitForWrites(dm.config.ShutdownDrainTimeout) {
+		logger.WithField("timeout", dm.config.ShutdownDrainTimeout).Warning("Disconnect notices were not all written within the drain timeout")
+	}
+}
+
 // Run main loop for peer/connection management
 func (dm *Daemon) Run() error {
 	defer logger.Info("Daemon closed")
 	defer close(dm.done)
 
-	logger.Infof("Daemon UserAgent is %s", dm.config.userAgent)
-	logger.Infof("Daemon unconfirmed BurnFactor is %d", dm.config.UnconfirmedVerifyTxn.BurnFactor)
-	logger.Infof("Daemon unconfirmed MaxTransactionSize is %d", dm.config.UnconfirmedVerifyTxn.MaxTransactionSize)
-	logger.Infof("Daemon unconfirmed MaxDropletPrecision is %d", dm.config.UnconfirmedVerifyTxn.MaxDropletPrecision)
+	logger.WithFields(logrus.Fields{
+		"userAgent":           dm.config.userAgent,
+		"burnFactor":          dm.config.UnconfirmedVerifyTxn.BurnFactor,
+		"maxTransactionSize":  dm.config.UnconfirmedVerifyTxn.MaxTransactionSize,
+		"maxDropletPrecision": dm.config.UnconfirmedVerifyTxn.MaxDropletPrecision,
+	}).Info("Daemon started with the unconfirmed transaction verification parameters")
 
 	errC := make(chan error, 5)
 	var wg sync.WaitGroup
@@ -436,9 +559,12 @@ func (dm *Daemon) Run() error {
 
 	blockInterval := time.Duration(dm.config.BlockCreationInterval)
 	blockCreationTicker := time.NewTicker(time.Second * blockInterval)
-	if !dm.visor.Config.IsBlockPublisher {
+	if !dm.visor.IsBlockPublisher() {
 		blockCreationTicker.Stop()
 	}
+	defer func() {
+		blockCreationTicker.Stop()
+	}()
 
 	unconfirmedRefreshTicker := time.NewTicker(dm.config.UnconfirmedRefreshRate)
 	defer unconfirmedRefreshTicker.Stop()
@@ -464,6 +590,8 @@ func (dm *Daemon) Run() error {
 
 	privateConnectionsTicker := time.NewTicker(dm.config.PrivateRate)
 	defer privateConnectionsTicker.Stop()
+	alwaysConnectTicker := time.NewTicker(dm.config.AlwaysConnectRate)
+	defer alwaysConnectTicker.Stop()
 	cullInvalidTicker := time.NewTicker(dm.config.CullInvalidRate)
 	defer cullInvalidTicker.Stop()
 	outgoingConnectionsTicker := time.NewTicker(dm.config.OutgoingRate)
@@ -571,7 +699,8 @@ loop:
 			// Sends pings as needed
 			elapser.Register("idleCheckTicker")
 			if !dm.config.DisableNetworking {
-				dm.pool.sendPings()
+				dm.pool.sendPings(dm.hasKeepalive)
+				dm.sendKeepalivePings()
 			}
 
 		case <-outgoingConnectionsTicker.C:
@@ -605,6 +734,13 @@ loop:
 				dm.makePrivateConnections()
 			}
 
+		case <-alwaysConnectTicker.C:
+			// Redial the always connect peers that are not connected
+			elapser.Register("alwaysConnectTicker")
+			if !dm.config.DisableOutgoingConnections {
+				dm.connectToAlwaysConnectPeers()
+			}
+
 		case r := <-dm.events:
 			elapser.Register("dm.event")
 			if dm.config.DisableNetworking {
@@ -624,9 +760,13 @@ loop:
 		case <-blockCreationTicker.C:
 			// Create blocks, if block publisher
 			elapser.Register("blockCreationTicker.C")
-			if dm.visor.Config.IsBlockPublisher {
+			if dm.visor.IsBlockPublisher() {
 				sb, err := dm.createAndPublishBlock()
 				if err != nil {
+					// The role was disarmed after the tick
+					if err == visor.ErrNotBlockPublisher {
+						continue
+					}
 					logger.WithError(err).Error("Failed to create and publish block")
 					continue
 				}
@@ -640,6 +780,14 @@ loop:
 				}).Info("Created and published a new block")
 			}
 
+		case <-dm.publisherChanged:
+			// Restart the block creation interval when the role is armed, stop it when the role is disarmed
+			elapser.Register("publisherChanged")
+			blockCreationTicker.Stop()
+			if dm.visor.IsBlockPublisher() {
+				blockCreationTicker = time.NewTicker(time.Second * blockInterval)
+			}
+
 		case <-unconfirmedRefreshTicker.C:
 			elapser.Register("unconfirmedRefreshTicker")
 			// Get the transactions that turn to valid
@@ -662,7 +810,7 @@ loop:
 				continue
 			}
 			if len(removedTxns) > 0 {
-				logger.Infof("Remove %d txns from pool that began violating hard constraints", len(removedTxns))
+				logger.WithField("removed", len(removedTxns)).Info("Removed txns from pool that began violating hard constraints")
 			}
 
 		case <-blocksRequestTicker.C:
@@ -710,12 +858,16 @@ func (dm *Daemon) connectToPeer(p pex.Peer) error {
 		return errors.New("Not localhost")
 	}
 
+	if dm.pex.IsBanned(a) {
+		return errors.New("Peer IP is banned")
+	}
+
 	if c := dm.connections.get(p.Addr); c != nil {
 		return errors.New("Already connected to this peer")
 	}
 
 	cnt := dm.connections.IPCount(a)
-	if !dm.config.LocalhostOnly && cnt != 0 {
+	if !dm.config.LocalhostOnly && cnt != 0 && !dm.alwaysConnect.has(p.Addr) {
 		return errors.New("Already connected to a peer with this base IP")
 	}
 
@@ -807,18 +959,23 @@ func (dm *Daemon) connectToRandomPeer() {
 	if dm.config.DisableOutgoingConnections {
 		return
 	}
-	if dm.connections.OutgoingLen() >= dm.config.MaxOutgoingConnections {
+
+	// Connections to always connect peers don't count towards the limits
+	alwaysConnectOutgoing, alwaysConnectTotal := dm.alwaysConnectLen()
+	outgoingLen := dm.connections.OutgoingLen() - alwaysConnectOutgoing
+
+	if outgoingLen >= dm.config.MaxOutgoingConnections {
 		return
 	}
 	if dm.connections.PendingLen() >= dm.config.MaxPendingConnections {
 		return
 	}
-	if dm.connections.Len() >= dm.config.MaxConnections {
+	if dm.connections.Len()-alwaysConnectTotal >= dm.config.MaxConnections {
 		return
 	}
 
 	// Make a connection to a random (public) peer
-	peers := dm.pex.RandomPublic(dm.config.MaxOutgoingConnections - dm.connections.OutgoingLen())
+	peers := dm.pex.RandomPublic(dm.config.MaxOutgoingConnections - outgoingLen)
 	for _, p := range peers {
 		if err := dm.connectToPeer(p); err != nil {
 			logger.WithError(err).WithField("addr", p.Addr).Warning("connectToPeer failed")
@@ -924,7 +1081,7 @@ func (dm *Daemon) onMessageEvent(e messageEvent) {
 		}
 	}
 
-	e.Message.process(dm)
+	dm.processMessageEvent(e)
 }
 
 func (dm *Daemon) onConnectEvent(e ConnectEvent) {
@@ -951,7 +1108,15 @@ func (dm *Daemon) onConnectEvent(e ConnectEvent) {
 		logger.Critical().WithFields(fields).Warning("Connection.Outgoing does not match ConnectEvent.Solicited state")
 	}
 
-	if dm.ipCountMaxed(e.Addr) {
+	if dm.pex.IsBanned(e.Addr) && !dm.alwaysConnect.has(e.Addr) {
+		logger.WithFields(fields).Info("Peer IP is banned, disconnecting")
+		if err := dm.Disconnect(e.Addr, ErrDisconnectIsBlacklisted); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Disconnect")
+		}
+		return
+	}
+
+	if dm.ipCountMaxed(e.Addr) && !dm.alwaysConnect.has(e.Addr) {
 		logger.WithFields(fields).Info("Max connections for this IP address reached, disconnecting")
 		if err := dm.Disconnect(e.Addr, ErrDisconnectIPLimitReached); err != nil {
 			logger.WithError(err).WithFields(fields).Error("Disconnect")
@@ -969,6 +1134,8 @@ func (dm *Daemon) onConnectEvent(e ConnectEvent) {
 		dm.config.userAgent,
 		dm.config.UnconfirmedVerifyTxn,
 		dm.config.GenesisHash,
+		dm.config.MinProtocolVersion,
+		dm.config.ProtocolFeatures,
 	)); err != nil {
 		logger.WithFields(fields).WithError(err).Error("Send IntroductionMessage failed")
 		return
@@ -983,6 +1150,13 @@ func (dm *Daemon) onDisconnectEvent(e DisconnectEvent) {
 	}
 	logger.WithFields(fields).Info("onDisconnectEvent")
 
+	// Score the peer before removing the connection, the connection is needed to find the peer's listen address
+	if ev, ok := DisconnectReasonToScoreEvent(e.Reason); ok {
+		dm.scorePeer(e.Addr, ev)
+	}
+
+	dm.onAlwaysConnectDisconnect(e)
+
 	if err := dm.connections.remove(e.Addr, e.GnetID); err != nil {
 		logger.WithError(err).WithFields(fields).Error("connections.Remove failed")
 		return
@@ -994,7 +1168,7 @@ func (dm *Daemon) onDisconnectEvent(e DisconnectEvent) {
 		ErrDisconnectBlockchainPubkeyNotMatched,
 		ErrDisconnectInvalidExtraData,
 		ErrDisconnectInvalidUserAgent:
-		if !dm.isTrustedPeer(e.Addr) {
+		if !dm.isTrustedPeer(e.Addr) && !dm.alwaysConnect.has(e.Addr) {
 			dm.pex.RemovePeer(e.Addr)
 		}
 	case ErrDisconnectNoIntroduction,
@@ -1161,6 +1335,8 @@ func (dm *Daemon) createAndPublishBlock() (*coin.SignedBlock, error) {
 		return nil, err
 	}
 
+	dm.recordBlockSeen("", sb)
+
 	err = dm.broadcastBlock(sb)
 
 	return &sb, err
@@ -1207,7 +1383,10 @@ func (dm *Daemon) BroadcastTransaction(txn coin.Transaction) ([]uint64, error) {
 		return nil, err
 	}
 
-	logger.Debugf("BroadcastTransaction to %d conns", len(ids))
+	logger.WithFields(logrus.Fields{
+		"txid":  txn.Hash().Hex(),
+		"conns": len(ids),
+	}).Debug("BroadcastTransaction")
 
 	return ids, nil
 }
@@ -1226,7 +1405,11 @@ func (dm *Daemon) BroadcastUserTransaction(txn coin.Transaction, head *coin.Sign
 		return err
 	}
 
-	logger.Debugf("BroadcastUserTransaction transaction propagated by %d/%d conns", accepts, len(ids))
+	logger.WithFields(logrus.Fields{
+		"txid":    txn.Hash().Hex(),
+		"accepts": accepts,
+		"conns":   len(ids),
+	}).Debug("BroadcastUserTransaction transaction propagated")
 
 	return nil
 }
@@ -1407,6 +1590,10 @@ func (dm *Daemon) connectionIntroduced(addr string, gnetID uint64, m *Introducti
 
 	dm.pex.ResetRetryTimes(listenAddr)
 
+	if addr, ok := dm.alwaysConnectAddr(c); ok {
+		dm.alwaysConnect.introduced(addr)
+	}
+
 	return c, nil
 }
 
@@ -1423,14 +1610,16 @@ func (dm *Daemon) sendRandomPeers(addr string) error {
 	return dm.sendMessage(addr, m)
 }
 
-// announceAllValidTxns broadcasts valid unconfirmed transactions
+// announceAllValidTxns broadcasts the valid unconfirmed transactions that were not announced
+// in the last DaemonConfig.TxnReannounceInterval. It is called when a peer is introduced,
+// so the transactions are not announced again to all peers each time a peer connects.
 func (dm *Daemon) announceAllValidTxns() error {
 	if dm.config.DisableNetworking {
 		return ErrNetworkingDisabled
 	}
 
-	// Get valid unconfirmed transaction hashes
-	hashes, err := dm.visor.GetAllValidUnconfirmedTxHashes()
+	// Get valid unconfirmed transaction hashes not announced recently
+	hashes, err := dm.visor.GetValidUnconfirmedTxHashesToAnnounce(time.Now(), dm.config.TxnReannounceInterval)
 	if err != nil {
 		return err
 	}
@@ -1500,6 +1689,11 @@ func (dm *Daemon) broadcastMessage(msg gnet.Message) ([]uint64, error) {
 		return nil, ErrNetworkingDisabled
 	}
 
+	return dm.pool.Pool.BroadcastMessage(msg, dm.introducedAddrs())
+}
+
+// introducedAddrs returns the addresses of the introduced connections
+func (dm *Daemon) introducedAddrs() []string {
 	conns := dm.connections.all()
 	var addrs []string
 	for _, c := range conns {
@@ -1507,8 +1701,7 @@ func (dm *Daemon) broadcastMessage(msg gnet.Message) ([]uint64, error) {
 			addrs = append(addrs, c.Addr)
 		}
 	}
-
-	return dm.pool.Pool.BroadcastMessage(msg, addrs)
+	return addrs
 }
 
 // disconnectNow disconnects from a peer immediately without sending a DisconnectMessage. Any pending messages
@@ -1534,6 +1727,51 @@ func (dm *Daemon) recordPeerHeight(addr string, gnetID, height uint64) {
 	}
 }
 
+// scorePeer applies a score event to the pex peer of a connection.
+// Incoming connections are scored by their listen address, since that is the address stored in pex.
+// Offenses count toward banning the peer's IP, unless the peer is trusted or always connected.
+func (dm *Daemon) scorePeer(addr string, ev pex.ScoreEvent) {
+	peerAddr := addr
+	if c := dm.connections.get(addr); c != nil && c.ListenAddr() != "" {
+		peerAddr = c.ListenAddr()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"addr":     addr,
+		"peerAddr": peerAddr,
+		"event":    ev.String(),
+	}).Debug("scorePeer")
+
+	dm.pex.AdjustScore(peerAddr, ev)
+
+	if !ev.IsOffense() || dm.isTrustedPeer(peerAddr) || dm.alwaysConnect.has(peerAddr) || dm.alwaysConnect.has(addr) {
+		return
+	}
+
+	b, err := dm.pex.RecordOffense(addr, ev)
+	if err != nil {
+		logger.WithError(err).WithField("addr", addr).Error("pex.RecordOffense failed")
+		return
+	}
+	if b != nil {
+		dm.disconnectIP(b.IP, ErrDisconnectIsBlacklisted)
+	}
+}
+
+// disconnectIP disconnects all connections of an IP
+func (dm *Daemon) disconnectIP(ip string, r gnet.DisconnectReason) {
+	for _, c := range dm.connections.all() {
+		a, _, err := iputil.SplitAddr(c.Addr)
+		if err != nil || a != ip {
+			continue
+		}
+
+		if err := dm.Disconnect(c.Addr, r); err != nil {
+			logger.WithError(err).WithField("addr", c.Addr).Debug("Disconnect")
+		}
+	}
+}
+
 // getSignedBlocksSince returns N signed blocks since given seq
 func (dm *Daemon) getSignedBlocksSince(seq, count uint64) ([]coin.SignedBlock, error) {
 	return dm.visor.GetSignedBlocksSince(seq, count)
@@ -1568,6 +1806,21 @@ func (dm *Daemon) injectTransaction(txn coin.Transaction) (bool, *visor.ErrTxnVi
 	return dm.visor.InjectForeignTransaction(txn)
 }
 
+// recordTxnAnnouncements records that the peer at addr announced or sent transactions
+func (dm *Daemon) recordTxnAnnouncements(addr string, txns []cipher.SHA256) {
+	dm.propagation.AnnouncedTxns(txns, addr)
+}
+
+// recordBlockAnnouncement records that the peer at addr announced its head block sequence
+func (dm *Daemon) recordBlockAnnouncement(addr string, seq uint64) {
+	dm.propagation.AnnouncedBlocks(seq, addr)
+}
+
+// recordBlockSeen records that a block was executed, received from the peer at addr or created locally if addr is empty
+func (dm *Daemon) recordBlockSeen(addr string, b coin.SignedBlock) {
+	dm.propagation.SeenBlock(b.HashHeader(), b.Seq(), addr)
+}
+
 /* Connection management API */
 
 // Connection a connection's state within the daemon
@@ -1692,6 +1945,13 @@ func (dm *Daemon) DisconnectByGnetID(gnetID uint64) error {
 	return dm.Disconnect(c.Addr, ErrDisconnectRequestedByOperator)
 }
 
+// GetAlwaysConnectPeers returns the addresses of the always connect peers
+func (dm *Daemon) GetAlwaysConnectPeers() []string {
+	addrs := make([]string, len(dm.config.AlwaysConnect))
+	copy(addrs, dm.config.AlwaysConnect)
+	return addrs
+}
+
 // GetTrustConnections returns all trusted connections
 func (dm *Daemon) GetTrustConnections() []string {
 	return dm.pex.Trusted().ToAddrs()
@@ -1702,6 +1962,62 @@ func (dm *Daemon) GetExchgConnection() []string {
 	return dm.pex.RandomExchangeable(0).ToAddrs()
 }
 
+// GetPeers returns all known peers, sorted by quality score
+func (dm *Daemon) GetPeers() pex.Peers {
+	return dm.pex.All()
+}
+
+// GetPeerBans returns the ban config, the offense counters and the banned IPs of peers
+func (dm *Daemon) GetPeerBans() pex.BanReport {
+	return dm.pex.BanReport()
+}
+
+// UnbanPeer lifts the ban of a peer IP.
+// Returns pex.ErrNotBanned if the IP is not banned.
+func (dm *Daemon) UnbanPeer(ip string) error {
+	return dm.pex.Unban(ip)
+}
+
+// ExportPeers returns a bundle of the known-good peers that pass the filter, signed with the node key if sign is true
+func (dm *Daemon) ExportPeers(f pex.BundleFilter, sign bool) (*pex.PeerBundle, error) {
+	b := pex.NewPeerBundle(dm.pex.All(), f, time.Now())
+
+	if sign {
+		if err := b.Sign(dm.config.NodeKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// ImportPeers verifies a peer bundle against the trusted peer bundle keys and adds its peers to the peer list
+func (dm *Daemon) ImportPeers(b pex.PeerBundle) (*pex.ImportResult, error) {
+	if err := b.Verify(dm.config.TrustedPeerBundleKeys); err != nil {
+		return nil, err
+	}
+
+	r := dm.pex.ImportPeers(b.Peers)
+
+	logger.WithFields(logrus.Fields{
+		"added":   len(r.Added),
+		"skipped": len(r.Skipped),
+		"pubkey":  b.PubKey,
+	}).Info("Imported peer bundle")
+
+	return &r, nil
+}
+
+// GetBandwidthLimits returns the bandwidth limits of block transfers
+func (dm *Daemon) GetBandwidthLimits() gnet.BandwidthLimits {
+	return dm.pool.Pool.BandwidthLimits()
+}
+
+// SetBandwidthLimits changes the bandwidth limits of block transfers
+func (dm *Daemon) SetBandwidthLimits(l gnet.BandwidthLimits) {
+	dm.pool.Pool.SetBandwidthLimits(l)
+}
+
 /* Peer Blockchain Status API */
 
 // BlockchainProgress is the current blockchain syncing status
@@ -1770,7 +2086,10 @@ func (dm *Daemon) GetBlockchainProgress(headSeq uint64) *BlockchainProgress {
 // This method is to be used by user-initiated transaction injections.
 // For transactions received over the network, use daemon.injectTransaction and check the result to
 // decide on repropagation.
-func (dm *Daemon) InjectBroadcastTransaction(txn coin.Transaction) error {
+// The log entries include the request ID carried by ctx, if any.
+func (dm *Daemon) InjectBroadcastTransaction(ctx context.Context, txn coin.Transaction) error {
+	logger := plogging.FromContext(ctx, logger).WithField("txid", txn.Hash().Hex())
+
 	return dm.visor.WithUpdateTx("daemon.InjectBroadcastTransaction", func(tx *dbutil.Tx) error {
 		_, head, inputs, err := dm.visor.InjectUserTransactionTx(tx, txn)
 		if err != nil {
@@ -1778,11 +2097,15 @@ func (dm *Daemon) InjectBroadcastTransaction(txn coin.Transaction) error {
 			return err
 		}
 
+		dm.propagation.SeenTxn(txn.Hash(), "")
+
 		if err := dm.BroadcastUserTransaction(txn, head, inputs); err != nil {
 			logger.WithError(err).Error("BroadcastUserTransaction failed")
 			return err
 		}
 
+		logger.Info("Injected and broadcast user transaction")
+
 		return nil
 	})
 }
@@ -1793,6 +2116,16 @@ func (dm *Daemon) InjectBroadcastTransaction(txn coin.Transaction) error {
 // For transactions received over the network, use daemon.injectTransaction and check the result to
 // decide on repropagation.
 func (dm *Daemon) InjectTransaction(txn coin.Transaction) error {
-	_, _, _, err := dm.visor.InjectUserTransaction(txn)
-	return err
+	if _, _, _, err := dm.visor.InjectUserTransaction(txn); err != nil {
+		return err
+	}
+
+	dm.propagation.SeenTxn(txn.Hash(), "")
+	return nil
+}
+
+// GetPropagation returns the propagation of a recently seen transaction or block,
+// or false if it is not tracked
+func (dm *Daemon) GetPropagation(hash cipher.SHA256) (*propagation.Report, bool) {
+	return dm.propagation.Report(hash)
 }
@@ -11,7 +11,8 @@ import (
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/useragent"
-	"github.com/skycoin/skycoin/src/visor"
+
+	"github.com/ness-network/privateness/src/visor"
 )
 
 func TestDivideHashes(t *testing.T) {
@@ -3,7 +3,10 @@ package daemon
 import (
 	"errors"
 
-	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+
+	"github.com/ness-network/privateness/src/daemon/wire"
 )
 
 var (
@@ -47,46 +50,74 @@ var (
 	ErrDisconnectInvalidMaxTransactionSize gnet.DisconnectReason = errors.New("Invalid max transaction size in introduction message")
 	// ErrDisconnectInvalidMaxDropletPrecision invalid max droplet precision in introduction message
 	ErrDisconnectInvalidMaxDropletPrecision gnet.DisconnectReason = errors.New("Invalid max droplet precision in introduction message")
+	// ErrDisconnectNodeShutdown the node is shutting down
+	ErrDisconnectNodeShutdown gnet.DisconnectReason = errors.New("Node is shutting down")
+	// ErrDisconnectKeepaliveTimeout the peer did not reply to keepalive pings
+	ErrDisconnectKeepaliveTimeout gnet.DisconnectReason = errors.New("Keepalive pings were not answered")
+	// ErrDisconnectSlowMessageHandler processing the peer's messages exceeded the message deadlines too many times
+	ErrDisconnectSlowMessageHandler gnet.DisconnectReason = errors.New("Message handler deadline exceeded too many times")
 
 	// ErrDisconnectUnknownReason used when mapping an unknown reason code to an error. Is not sent over the network.
 	ErrDisconnectUnknownReason gnet.DisconnectReason = errors.New("Unknown DisconnectReason")
 
 	disconnectReasonCodes = map[gnet.DisconnectReason]uint16{
-		ErrDisconnectUnknownReason: 0,
-
-		ErrDisconnectVersionNotSupported:           1,
-		ErrDisconnectIntroductionTimeout:           2,
-		ErrDisconnectIsBlacklisted:                 3,
-		ErrDisconnectSelf:                          4,
-		ErrDisconnectConnectedTwice:                5,
-		ErrDisconnectIdle:                          6,
-		ErrDisconnectNoIntroduction:                7,
-		ErrDisconnectIPLimitReached:                8,
-		ErrDisconnectUnexpectedError:               9,
-		ErrDisconnectMaxOutgoingConnectionsReached: 10,
-		ErrDisconnectBlockchainPubkeyNotMatched:    11,
-		ErrDisconnectInvalidExtraData:              12,
-		ErrDisconnectReceivedDisconnect:            13,
-		ErrDisconnectInvalidUserAgent:              14,
-		ErrDisconnectRequestedByOperator:           15,
-		ErrDisconnectPeerlistFull:                  16,
-		ErrDisconnectInvalidBurnFactor:             17,
-		ErrDisconnectInvalidMaxTransactionSize:     18,
-		ErrDisconnectInvalidMaxDropletPrecision:    19,
+		ErrDisconnectUnknownReason: wire.DisconnectUnknownReason,
+
+		ErrDisconnectVersionNotSupported:           wire.DisconnectVersionNotSupported,
+		ErrDisconnectIntroductionTimeout:           wire.DisconnectIntroductionTimeout,
+		ErrDisconnectIsBlacklisted:                 wire.DisconnectIsBlacklisted,
+		ErrDisconnectSelf:                          wire.DisconnectSelf,
+		ErrDisconnectConnectedTwice:                wire.DisconnectConnectedTwice,
+		ErrDisconnectIdle:                          wire.DisconnectIdle,
+		ErrDisconnectNoIntroduction:                wire.DisconnectNoIntroduction,
+		ErrDisconnectIPLimitReached:                wire.DisconnectIPLimitReached,
+		ErrDisconnectUnexpectedError:               wire.DisconnectUnexpectedError,
+		ErrDisconnectMaxOutgoingConnectionsReached: wire.DisconnectMaxOutgoingConnectionsReached,
+		ErrDisconnectBlockchainPubkeyNotMatched:    wire.DisconnectBlockchainPubkeyNotMatched,
+		ErrDisconnectInvalidExtraData:              wire.DisconnectInvalidExtraData,
+		ErrDisconnectReceivedDisconnect:            wire.DisconnectReceivedDisconnect,
+		ErrDisconnectInvalidUserAgent:              wire.DisconnectInvalidUserAgent,
+		ErrDisconnectRequestedByOperator:           wire.DisconnectRequestedByOperator,
+		ErrDisconnectPeerlistFull:                  wire.DisconnectPeerlistFull,
+		ErrDisconnectInvalidBurnFactor:             wire.DisconnectInvalidBurnFactor,
+		ErrDisconnectInvalidMaxTransactionSize:     wire.DisconnectInvalidMaxTransactionSize,
+		ErrDisconnectInvalidMaxDropletPrecision:    wire.DisconnectInvalidMaxDropletPrecision,
+		ErrDisconnectNodeShutdown:                  wire.DisconnectNodeShutdown,
+		ErrDisconnectKeepaliveTimeout:              wire.DisconnectKeepaliveTimeout,
+		ErrDisconnectSlowMessageHandler:            wire.DisconnectSlowMessageHandler,
 
 		// gnet codes are registered here, but they are not sent in a DISC
 		// message by gnet. Only daemon sends a DISC packet.
 		// If gnet chooses to disconnect it will not send a DISC packet.
-		gnet.ErrDisconnectSetReadDeadlineFailed:  1001,
-		gnet.ErrDisconnectInvalidMessageLength:   1002,
-		gnet.ErrDisconnectMalformedMessage:       1003,
-		gnet.ErrDisconnectUnknownMessage:         1004,
-		gnet.ErrDisconnectShutdown:               1005,
-		gnet.ErrDisconnectMessageDecodeUnderflow: 1006,
-		gnet.ErrDisconnectTruncatedMessageID:     1007,
+		gnet.ErrDisconnectSetReadDeadlineFailed:  wire.DisconnectSetReadDeadlineFailed,
+		gnet.ErrDisconnectInvalidMessageLength:   wire.DisconnectInvalidMessageLength,
+		gnet.ErrDisconnectMalformedMessage:       wire.DisconnectMalformedMessage,
+		gnet.ErrDisconnectUnknownMessage:         wire.DisconnectUnknownMessage,
+		gnet.ErrDisconnectShutdown:               wire.DisconnectShutdown,
+		gnet.ErrDisconnectMessageDecodeUnderflow: wire.DisconnectMessageDecodeUnderflow,
+		gnet.ErrDisconnectTruncatedMessageID:     wire.DisconnectTruncatedMessageID,
 	}
 
 	disconnectCodeReasons map[uint16]gnet.DisconnectReason
+
+	// disconnectReasonScoreEvents maps disconnect reasons caused by the remote peer to a pex.ScoreEvent
+	disconnectReasonScoreEvents = map[gnet.DisconnectReason]pex.ScoreEvent{
+		ErrDisconnectVersionNotSupported:        pex.ScoreEventHandshakeFailure,
+		ErrDisconnectIntroductionTimeout:        pex.ScoreEventHandshakeFailure,
+		ErrDisconnectNoIntroduction:             pex.ScoreEventHandshakeFailure,
+		ErrDisconnectBlockchainPubkeyNotMatched: pex.ScoreEventHandshakeFailure,
+		ErrDisconnectInvalidExtraData:           pex.ScoreEventHandshakeFailure,
+		ErrDisconnectInvalidUserAgent:           pex.ScoreEventHandshakeFailure,
+		ErrDisconnectInvalidBurnFactor:          pex.ScoreEventHandshakeFailure,
+		ErrDisconnectInvalidMaxTransactionSize:  pex.ScoreEventHandshakeFailure,
+		ErrDisconnectInvalidMaxDropletPrecision: pex.ScoreEventHandshakeFailure,
+
+		gnet.ErrDisconnectInvalidMessageLength:   pex.ScoreEventProtocolViolation,
+		gnet.ErrDisconnectMalformedMessage:       pex.ScoreEventProtocolViolation,
+		gnet.ErrDisconnectUnknownMessage:         pex.ScoreEventProtocolViolation,
+		gnet.ErrDisconnectMessageDecodeUnderflow: pex.ScoreEventProtocolViolation,
+		gnet.ErrDisconnectTruncatedMessageID:     pex.ScoreEventProtocolViolation,
+	}
 )
 
 func init() {
@@ -102,6 +133,13 @@ func DisconnectReasonToCode(r gnet.DisconnectReason) uint16 {
 	return disconnectReasonCodes[r]
 }
 
+// DisconnectReasonToScoreEvent maps a gnet.DisconnectReason to the pex.ScoreEvent it causes.
+// Returns false if the disconnect reason does not affect the peer's score.
+func DisconnectReasonToScoreEvent(r gnet.DisconnectReason) (pex.ScoreEvent, bool) {
+	ev, ok := disconnectReasonScoreEvents[r]
+	return ev, ok
+}
+
 // DisconnectCodeToReason maps a disconnect code to a gnet.DisconnectReason
 func DisconnectCodeToReason(c uint16) gnet.DisconnectReason {
 	r, ok := disconnectCodeReasons[c]
@@ -6,7 +6,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
 )
 
 func TestDisconnectReasonCode(t *testing.T) {
@@ -27,3 +28,27 @@ func TestDisconnectReasonCode(t *testing.T) {
 	r = DisconnectCodeToReason(999)
 	require.Equal(t, ErrDisconnectUnknownReason, r)
 }
+
+func TestDisconnectReasonToScoreEvent(t *testing.T) {
+	ev, ok := DisconnectReasonToScoreEvent(ErrDisconnectIntroductionTimeout)
+	require.True(t, ok)
+	require.Equal(t, pex.ScoreEventHandshakeFailure, ev)
+
+	ev, ok = DisconnectReasonToScoreEvent(gnet.ErrDisconnectMalformedMessage)
+	require.True(t, ok)
+	require.Equal(t, pex.ScoreEventProtocolViolation, ev)
+
+	// Disconnects not caused by the remote peer do not affect its score
+	for _, r := range []gnet.DisconnectReason{
+		ErrDisconnectIdle,
+		ErrDisconnectRequestedByOperator,
+		ErrDisconnectMaxOutgoingConnectionsReached,
+		ErrDisconnectNodeShutdown,
+		ErrDisconnectKeepaliveTimeout,
+		gnet.ErrDisconnectShutdown,
+		gnet.DisconnectReason(errors.New("foo")),
+	} {
+		_, ok := DisconnectReasonToScoreEvent(r)
+		require.False(t, ok)
+	}
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ness-network/privateness/src/daemon/gnet"
+
+	"github.com/ness-network/privateness/src/daemon/forcerequest"
+)
+
+// ForceRequestBlocks sends a GetBlocksMessage for the blocks of req to the peer of req, or to all introduced peers.
+// Returns the addresses of the peers the message was sent to.
+// The requests forced by an operator are limited to one per DaemonConfig.ForceRequestInterval.
+func (dm *Daemon) ForceRequestBlocks(req forcerequest.BlocksRequest) ([]string, error) {
+	if err := req.Validate(dm.config.MaxGetBlocksResponseCount); err != nil {
+		return nil, err
+	}
+
+	m := newForcedGetBlocksMessage(req)
+	return dm.sendForcedRequest(req.Addr, m, logrus.Fields{
+		"start": req.Start,
+		"end":   req.End,
+	})
+}
+
+// ForceRequestTxns sends a GetTxnsMessage for the transactions of req to the peer of req, or to all introduced peers.
+// Returns the addresses of the peers the message was sent to.
+// The requests forced by an operator are limited to one per DaemonConfig.ForceRequestInterval.
+func (dm *Daemon) ForceRequestTxns(req forcerequest.TxnsRequest) ([]string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m, err := newForcedGetTxnsMessage(req, dm.config.MaxOutgoingMessageLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return dm.sendForcedRequest(req.Addr, m, logrus.Fields{
+		"txns": len(req.Txids),
+	})
+}
+
+// newForcedGetBlocksMessage creates the GetBlocksMessage of a forced blocks request
+func newForcedGetBlocksMessage(req forcerequest.BlocksRequest) *GetBlocksMessage {
+	return NewGetBlocksMessage(req.LastBlock(), req.Count())
+}
+
+// newForcedGetTxnsMessage creates the GetTxnsMessage of a forced transactions request.
+// Unlike NewGetTxnsMessage, the txids are not truncated to fit in maxMsgLength, an error is returned instead.
+func newForcedGetTxnsMessage(req forcerequest.TxnsRequest, maxMsgLength uint64) (*GetTxnsMessage, error) {
+	m := NewGetTxnsMessage(req.Txids, maxMsgLength)
+	if len(m.Transactions) != len(req.Txids) {
+		return nil, forcerequest.ErrTooManyTxids
+	}
+	return m, nil
+}
+
+// sendForcedRequest sends a forced request message to the introduced peer addr, or to all introduced peers if addr is empty,
+// and returns the addresses of the peers it was sent to
+func (dm *Daemon) sendForcedRequest(addr string, m gnet.Message, fields logrus.Fields) ([]string, error) {
+	if dm.config.DisableNetworking {
+		return nil, forcerequest.ErrNoPeers
+	}
+
+	addrs := dm.introducedAddrs()
+	if addr != "" {
+		c := dm.connections.get(addr)
+		if c == nil || !c.HasIntroduced() {
+			return nil, forcerequest.ErrPeerNotConnected
+		}
+		addrs = []string{addr}
+	}
+
+	if len(addrs) == 0 {
+		return nil, forcerequest.ErrNoPeers
+	}
+
+	// Only the requests that can be sent count towards the limit
+	if err := dm.forceRequests.Allow(); err != nil {
+		return nil, err
+	}
+
+	fields["msgType"] = fmt.Sprintf("%T", m)
+	logger.WithFields(fields).WithField("addrs", addrs).Info("Sending a request forced by the operator")
+
+	if addr != "" {
+		if err := dm.sendMessage(addr, m); err != nil {
+			return nil, err
+		}
+		return addrs, nil
+	}
+
+	ids, err := dm.pool.Pool.BroadcastMessage(m, addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if c := dm.connections.getByGnetID(id); c != nil {
+			sent = append(sent, c.Addr)
+		}
+	}
+
+	return sent, nil
+}
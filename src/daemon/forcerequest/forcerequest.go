@@ -0,0 +1,147 @@
+/*
+Package forcerequest validates and rate limits the requests for blocks and transactions
+that an operator forces a node to send to its peers.
+
+The node normally requests the blocks after its head block and the transactions announced to it.
+A node stuck without a block, or missing a transaction, can be nudged by forcing a request for them,
+to one peer or to all the connected peers.
+*/
+package forcerequest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// DefaultInterval is the default minimum interval between forced requests
+	DefaultInterval = time.Second * 5
+	// MaxTxids is the maximum number of transactions in a request, the limit of a GetTxnsMessage
+	MaxTxids = 256
+)
+
+var (
+	// ErrNoPeers is returned if there is no connected peer to send a request to, or networking is disabled
+	ErrNoPeers = errors.New("no connected peers to send the request to")
+	// ErrPeerNotConnected is returned if the peer of a request is not a connected peer
+	ErrPeerNotConnected = errors.New("peer is not connected")
+	// ErrNoTxids is returned if a transactions request has no transactions
+	ErrNoTxids = errors.New("no txids to request")
+	// ErrTooManyTxids is returned if a transactions request has more than MaxTxids transactions
+	ErrTooManyTxids = fmt.Errorf("at most %d txids can be requested at once", MaxTxids)
+	// ErrGenesisBlock is returned if a blocks request starts at the genesis block, which is never requested
+	ErrGenesisBlock = errors.New("the genesis block can't be requested, the range must start at seq 1 or later")
+	// ErrInvalidRange is returned if a blocks request ends before it starts
+	ErrInvalidRange = errors.New("end seq must not be less than start seq")
+)
+
+// ErrTooManyBlocks is returned if a blocks request has more blocks than a peer responds with
+type ErrTooManyBlocks struct {
+	Max uint64
+}
+
+func (e ErrTooManyBlocks) Error() string {
+	return fmt.Sprintf("at most %d blocks can be requested at once", e.Max)
+}
+
+// ErrRateLimited is returned if a request is forced before the minimum interval since the previous one elapsed
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e ErrRateLimited) Error() string {
+	return fmt.Sprintf("requests are forced too often, retry in %v", e.RetryAfter)
+}
+
+// BlocksRequest requests the blocks from seq Start to seq End, inclusive
+type BlocksRequest struct {
+	Start uint64
+	End   uint64
+	// Addr is the address of the peer to request the blocks from, all connected peers if empty
+	Addr string
+}
+
+// Validate checks that the request asks for at most max blocks, after the genesis block
+func (r BlocksRequest) Validate(max uint64) error {
+	if r.Start == 0 {
+		return ErrGenesisBlock
+	}
+
+	if r.End < r.Start {
+		return ErrInvalidRange
+	}
+
+	if r.End-r.Start >= max {
+		return ErrTooManyBlocks{Max: max}
+	}
+
+	return nil
+}
+
+// LastBlock returns the seq of the last block the requester has, as in a GetBlocksMessage,
+// for the peer to respond with the blocks after it
+func (r BlocksRequest) LastBlock() uint64 {
+	return r.Start - 1
+}
+
+// Count returns the number of blocks requested
+func (r BlocksRequest) Count() uint64 {
+	return r.End - r.Start + 1
+}
+
+// TxnsRequest requests transactions by txid
+type TxnsRequest struct {
+	Txids []cipher.SHA256
+	// Addr is the address of the peer to request the transactions from, all connected peers if empty
+	Addr string
+}
+
+// Validate checks that the request asks for 1 to MaxTxids transactions
+func (r TxnsRequest) Validate() error {
+	switch {
+	case len(r.Txids) == 0:
+		return ErrNoTxids
+	case len(r.Txids) > MaxTxids:
+		return ErrTooManyTxids
+	default:
+		return nil
+	}
+}
+
+// Limiter limits forced requests to one per interval, for all kinds of requests and all peers
+type Limiter struct {
+	sync.Mutex
+	interval time.Duration
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewLimiter creates a Limiter. An interval of 0 disables the limit.
+func NewLimiter(interval time.Duration) *Limiter {
+	return &Limiter{
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Allow records a forced request, or returns ErrRateLimited if the previous one was made less than an interval ago
+func (l *Limiter) Allow() error {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		if elapsed := now.Sub(l.last); elapsed < l.interval {
+			return ErrRateLimited{
+				RetryAfter: l.interval - elapsed,
+			}
+		}
+	}
+
+	l.last = now
+	return nil
+}
@@ -0,0 +1,81 @@
+package forcerequest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestBlocksRequestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		req  BlocksRequest
+		err  error
+	}{
+		{
+			name: "genesis block",
+			req:  BlocksRequest{Start: 0, End: 5},
+			err:  ErrGenesisBlock,
+		},
+		{
+			name: "end before start",
+			req:  BlocksRequest{Start: 5, End: 4},
+			err:  ErrInvalidRange,
+		},
+		{
+			name: "too many blocks",
+			req:  BlocksRequest{Start: 1, End: 21},
+			err:  ErrTooManyBlocks{Max: 20},
+		},
+		{
+			name: "max blocks",
+			req:  BlocksRequest{Start: 1, End: 20},
+		},
+		{
+			name: "one block",
+			req:  BlocksRequest{Start: 7, End: 7},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.err, tc.req.Validate(20))
+		})
+	}
+
+	req := BlocksRequest{Start: 7, End: 9}
+	require.Equal(t, uint64(6), req.LastBlock())
+	require.Equal(t, uint64(3), req.Count())
+}
+
+func TestTxnsRequestValidate(t *testing.T) {
+	require.Equal(t, ErrNoTxids, TxnsRequest{}.Validate())
+	require.NoError(t, TxnsRequest{Txids: make([]cipher.SHA256, MaxTxids)}.Validate())
+	require.Equal(t, ErrTooManyTxids, TxnsRequest{Txids: make([]cipher.SHA256, MaxTxids+1)}.Validate())
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := NewLimiter(time.Second * 5)
+	l.now = func() time.Time {
+		return now
+	}
+
+	require.NoError(t, l.Allow())
+
+	now = now.Add(time.Second * 2)
+	require.Equal(t, ErrRateLimited{RetryAfter: time.Second * 3}, l.Allow())
+
+	// A refused request doesn't delay the next one
+	now = now.Add(time.Second * 3)
+	require.NoError(t, l.Allow())
+	require.Equal(t, ErrRateLimited{RetryAfter: time.Second * 5}, l.Allow())
+
+	// An interval of 0 disables the limit
+	l = NewLimiter(0)
+	require.NoError(t, l.Allow())
+	require.NoError(t, l.Allow())
+}
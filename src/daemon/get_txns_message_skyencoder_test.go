@@ -13,8 +13,8 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
-	"github.com/ness-network/privateness/src/cipher/encoder"
 	"github.com/skycoin/encodertest"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
 func newEmptyGetTxnsMessageForEncodeTest() *GetTxnsMessage {
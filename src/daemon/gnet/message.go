@@ -1,6 +1,7 @@
 package gnet
 
 import (
+	"context"
 	"reflect"
 )
 
@@ -48,14 +49,17 @@ type Message interface {
 type MessageContext struct {
 	ConnID uint64 // connection message was received from
 	Addr   string
+	// Context carries the deadline for processing the message, set by the daemon before it processes the message.
+	// Long running message processing should abort once it is done.
+	Context context.Context
 }
 
 // NewMessageContext creates MessageContext
 func NewMessageContext(conn *Connection) *MessageContext {
 	if conn.Conn != nil {
-		return &MessageContext{ConnID: conn.ID, Addr: conn.Addr()}
+		return &MessageContext{ConnID: conn.ID, Addr: conn.Addr(), Context: context.Background()}
 	}
-	return &MessageContext{ConnID: conn.ID}
+	return &MessageContext{ConnID: conn.ID, Context: context.Background()}
 }
 
 // MessageIDMap maps message types to their ids
@@ -0,0 +1,446 @@
+// +build netsim
+
+package gnet
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+/*
+Network simulation, for testing the protocol on bad networks.
+
+Builds with the netsim tag can set the latency, jitter, bandwidth and packet loss of
+a pool's connections with SetNetworkConditions. Builds without the tag don't have it,
+the connections are used as they are.
+
+The conditions apply to the messages sent and received by the pool. To simulate a link
+between two pools, set the conditions of one of them, setting both compounds them.
+*/
+
+const (
+	// netsimQueueSize is the number of messages of a connection that can be in flight in each direction
+	netsimQueueSize = 1024
+)
+
+var (
+	// errNetsimClosed is returned by a closed simulated connection
+	errNetsimClosed = errors.New("use of closed simulated connection")
+)
+
+// LinkConditions are the simulated conditions of one direction of a connection
+type LinkConditions struct {
+	// Latency is the delay of each message
+	Latency time.Duration
+	// Jitter is the maximum random delay added to Latency. Messages are still delivered in order.
+	Jitter time.Duration
+	// Bandwidth in bytes per second. Set to 0 for no limit.
+	Bandwidth uint64
+	// DropRate is the probability in [0, 1] that a message is lost
+	DropRate float64
+	// RetransmitDelay is the delay added to lost messages, which are then delivered like TCP
+	// retransmits lost segments. Set to 0 to drop lost messages, which is harsher than a real network.
+	RetransmitDelay time.Duration
+}
+
+// NetworkConditions are the simulated conditions of a pool's connections
+type NetworkConditions struct {
+	// Outgoing applies to the messages sent by the pool
+	Outgoing LinkConditions
+	// Incoming applies to the messages received by the pool
+	Incoming LinkConditions
+	// Seed of the random jitter and loss
+	Seed int64
+}
+
+// netsim holds the simulated network conditions of a pool
+type netsim struct {
+	sync.Mutex
+	conditions NetworkConditions
+	rand       *rand.Rand
+}
+
+// SetNetworkConditions sets the simulated network conditions of the connections made or accepted afterwards.
+// The zero NetworkConditions disables the simulation. Only available in builds with the netsim tag.
+func (pool *ConnectionPool) SetNetworkConditions(c NetworkConditions) {
+	pool.netsim.Lock()
+	defer pool.netsim.Unlock()
+
+	pool.netsim.conditions = c
+	pool.netsim.rand = rand.New(rand.NewSource(c.Seed))
+}
+
+// simulate wraps conn in a simulated connection if network conditions are set
+func (pool *ConnectionPool) simulate(conn net.Conn) net.Conn {
+	pool.netsim.Lock()
+	defer pool.netsim.Unlock()
+
+	if pool.netsim.conditions == (NetworkConditions{}) {
+		return conn
+	}
+
+	// Each link has its own source, seeded in the order the connections are made
+	return newSimConn(conn,
+		newSimLink(pool.netsim.conditions.Outgoing, pool.netsim.rand.Int63()),
+		newSimLink(pool.netsim.conditions.Incoming, pool.netsim.rand.Int63()),
+		pool.Config.MaxIncomingMessageLength)
+}
+
+// simLink schedules the delivery of the messages of one direction of a connection
+type simLink struct {
+	sync.Mutex
+	LinkConditions
+	rand *rand.Rand
+	// free is when the link is done transmitting the previous message
+	free time.Time
+	// last is the delivery time of the previous message
+	last time.Time
+}
+
+func newSimLink(c LinkConditions, seed int64) *simLink {
+	return &simLink{
+		LinkConditions: c,
+		rand:           rand.New(rand.NewSource(seed)),
+	}
+}
+
+// schedule returns when a message of n bytes sent at now is delivered, or false if it is lost
+func (l *simLink) schedule(n int, now time.Time) (time.Time, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	// The message is transmitted once the previous message is, lost messages use bandwidth too
+	start := now
+	if l.free.After(start) {
+		start = l.free
+	}
+	if l.Bandwidth != 0 {
+		start = start.Add(time.Duration(uint64(n) * uint64(time.Second) / l.Bandwidth))
+	}
+	l.free = start
+
+	at := start.Add(l.Latency)
+	if l.Jitter > 0 {
+		at = at.Add(time.Duration(l.rand.Int63n(int64(l.Jitter) + 1)))
+	}
+
+	if l.DropRate > 0 && l.rand.Float64() < l.DropRate {
+		if l.RetransmitDelay == 0 {
+			return time.Time{}, false
+		}
+		at = at.Add(l.RetransmitDelay)
+	}
+
+	// Messages are delivered in order, a delayed message holds up the ones behind it
+	if at.Before(l.last) {
+		at = l.last
+	}
+	l.last = at
+
+	return at, true
+}
+
+// simPacket is a message in flight
+type simPacket struct {
+	data []byte
+	at   time.Time
+	err  error
+}
+
+// simConn is a net.Conn whose messages are delayed, throttled and dropped by simulated links.
+// Writes are queued and return immediately, the reads return the messages once they are delivered.
+// gnet writes one message per Write, the messages read are framed by their length prefix.
+type simConn struct {
+	net.Conn
+	out    *simLink
+	in     *simLink
+	maxLen int
+
+	outC  chan simPacket
+	inC   chan simPacket
+	readC chan simPacket
+
+	// pending is the rest of the message being read, readErr ends the reads once it is read
+	pending []byte
+	readErr error
+
+	lock          sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	writeErr      error
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newSimConn(conn net.Conn, out, in *simLink, maxLen int) *simConn {
+	c := &simConn{
+		Conn:   conn,
+		out:    out,
+		in:     in,
+		maxLen: maxLen,
+		outC:   make(chan simPacket, netsimQueueSize),
+		inC:    make(chan simPacket, netsimQueueSize),
+		readC:  make(chan simPacket),
+		closed: make(chan struct{}),
+	}
+
+	go c.deliver(c.outC, c.writePacket)
+	go c.deliver(c.inC, c.readPacket)
+	go c.readMessages()
+
+	return c
+}
+
+// deliver passes each packet of packets to f at its delivery time,
+// until packets is closed, f fails or the connection is closed
+func (c *simConn) deliver(packets <-chan simPacket, f func(simPacket) bool) {
+	for p := range packets {
+		if d := time.Until(p.at); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-t.C:
+			case <-c.closed:
+				t.Stop()
+				return
+			}
+		}
+
+		if !f(p) {
+			return
+		}
+	}
+}
+
+// writePacket writes a delivered outgoing message to the connection
+func (c *simConn) writePacket(p simPacket) bool {
+	if _, err := c.Conn.Write(p.data); err != nil {
+		c.lock.Lock()
+		c.writeErr = err
+		c.lock.Unlock()
+
+		c.Close()
+		return false
+	}
+	return true
+}
+
+// readPacket passes a delivered incoming message to Read
+func (c *simConn) readPacket(p simPacket) bool {
+	select {
+	case c.readC <- p:
+		return p.err == nil
+	case <-c.closed:
+		return false
+	}
+}
+
+// readMessages reads the incoming messages and schedules their delivery.
+// If a message has an invalid length, the rest of the data is passed on as it is read,
+// for the pool to disconnect the peer.
+func (c *simConn) readMessages() {
+	defer close(c.inC)
+
+	queue := func(p simPacket) bool {
+		select {
+		case c.inC <- p:
+			return true
+		case <-c.closed:
+			return false
+		}
+	}
+
+	raw := false
+	for {
+		var data []byte
+		var err error
+		if raw {
+			buf := make([]byte, 1024)
+			var n int
+			n, err = c.Conn.Read(buf)
+			data = buf[:n]
+		} else {
+			data, raw, err = readMessage(c.Conn, c.maxLen)
+		}
+
+		if err != nil {
+			c.in.Lock()
+			last := c.in.last
+			c.in.Unlock()
+
+			queue(simPacket{
+				data: data,
+				at:   last,
+				err:  err,
+			})
+			return
+		}
+
+		at, ok := time.Now(), true
+		if !raw {
+			at, ok = c.in.schedule(len(data), at)
+		}
+		if !ok {
+			continue
+		}
+
+		if !queue(simPacket{
+			data: data,
+			at:   at,
+		}) {
+			return
+		}
+	}
+}
+
+// readMessage reads a message with its length prefix. If the length is invalid, only the prefix is returned.
+func readMessage(r io.Reader, maxLen int) ([]byte, bool, error) {
+	prefix := make([]byte, messageLengthPrefixSize)
+	if n, err := io.ReadFull(r, prefix); err != nil {
+		return prefix[:n], false, err
+	}
+
+	length, _, err := encoder.DeserializeUint32(prefix)
+	if err != nil {
+		return prefix, true, nil
+	}
+
+	if int(length) < messagePrefixLength || int(length) > maxLen {
+		return prefix, true, nil
+	}
+
+	data := make([]byte, messageLengthPrefixSize+int(length))
+	copy(data, prefix)
+	n, err := io.ReadFull(r, data[messageLengthPrefixSize:])
+	return data[:messageLengthPrefixSize+n], false, err
+}
+
+// Read reads the delivered incoming messages
+func (c *simConn) Read(b []byte) (int, error) {
+	if len(c.pending) == 0 {
+		if c.readErr != nil {
+			return 0, c.readErr
+		}
+
+		c.lock.Lock()
+		deadline := c.readDeadline
+		c.lock.Unlock()
+
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			t := time.NewTimer(time.Until(deadline))
+			defer t.Stop()
+			timeout = t.C
+		}
+
+		select {
+		case p := <-c.readC:
+			c.pending = p.data
+			c.readErr = p.err
+		case <-c.closed:
+			return 0, errNetsimClosed
+		case <-timeout:
+			return 0, netsimTimeoutError{}
+		}
+	}
+
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	if n == 0 && c.readErr != nil {
+		return 0, c.readErr
+	}
+	return n, nil
+}
+
+// Write queues an outgoing message. Lost messages are reported as written.
+func (c *simConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, errNetsimClosed
+	default:
+	}
+
+	c.lock.Lock()
+	deadline := c.writeDeadline
+	err := c.writeErr
+	c.lock.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	at, ok := c.out.schedule(len(b), time.Now())
+	if !ok {
+		return len(b), nil
+	}
+
+	data := make([]byte, len(b))
+	copy(data, b)
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		t := time.NewTimer(time.Until(deadline))
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case c.outC <- simPacket{
+		data: data,
+		at:   at,
+	}:
+		return len(b), nil
+	case <-c.closed:
+		return 0, errNetsimClosed
+	case <-timeout:
+		return 0, netsimTimeoutError{}
+	}
+}
+
+// SetDeadline sets the read and write deadlines
+func (c *simConn) SetDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readDeadline = t
+	c.writeDeadline = t
+	return nil
+}
+
+// SetReadDeadline sets the deadline of Read
+func (c *simConn) SetReadDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of Write. A queued message is written when it is delivered, without a deadline.
+func (c *simConn) SetWriteDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// Close closes the connection, dropping the messages in flight
+func (c *simConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.closeErr = c.Conn.Close()
+	})
+	return c.closeErr
+}
+
+// netsimTimeoutError is returned by a simulated connection when a deadline is exceeded
+type netsimTimeoutError struct{}
+
+func (netsimTimeoutError) Error() string   { return "simulated connection i/o timeout" }
+func (netsimTimeoutError) Timeout() bool   { return true }
+func (netsimTimeoutError) Temporary() bool { return true }
@@ -0,0 +1,13 @@
+// +build !netsim
+
+package gnet
+
+import "net"
+
+// netsim holds the simulated network conditions of a pool in builds with the netsim tag
+type netsim struct{}
+
+// simulate returns conn. Network simulation is compiled out of builds without the netsim tag.
+func (pool *ConnectionPool) simulate(conn net.Conn) net.Conn {
+	return conn
+}
@@ -0,0 +1,516 @@
+// +build netsim
+
+package gnet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+func TestSimLinkSchedule(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	// Latency and bandwidth
+	l := newSimLink(LinkConditions{
+		Latency:   100 * time.Millisecond,
+		Bandwidth: 1000,
+	}, 1)
+	at, ok := l.schedule(100, now)
+	require.True(t, ok)
+	require.Equal(t, now.Add(200*time.Millisecond), at)
+
+	// The second message waits for the first to be transmitted
+	at, ok = l.schedule(100, now)
+	require.True(t, ok)
+	require.Equal(t, now.Add(300*time.Millisecond), at)
+
+	// The link is idle again
+	at, ok = l.schedule(100, now.Add(time.Second))
+	require.True(t, ok)
+	require.Equal(t, now.Add(1200*time.Millisecond), at)
+
+	// Jitter doesn't reorder messages
+	l = newSimLink(LinkConditions{
+		Latency: 100 * time.Millisecond,
+		Jitter:  50 * time.Millisecond,
+	}, 1)
+	var last time.Time
+	for i := 0; i < 1000; i++ {
+		sent := now.Add(time.Duration(i) * time.Millisecond)
+		at, ok := l.schedule(10, sent)
+		require.True(t, ok)
+		require.False(t, at.Before(last))
+		require.False(t, at.Before(sent.Add(100*time.Millisecond)))
+		if at.After(sent.Add(150 * time.Millisecond)) {
+			require.Equal(t, last, at)
+		}
+		last = at
+	}
+
+	// Lost messages
+	l = newSimLink(LinkConditions{
+		DropRate: 0.1,
+	}, 1)
+	lost := 0
+	for i := 0; i < 10000; i++ {
+		if _, ok := l.schedule(10, now); !ok {
+			lost++
+		}
+	}
+	require.InDelta(t, 1000, lost, 150)
+
+	// Retransmitted messages
+	l = newSimLink(LinkConditions{
+		DropRate:        1,
+		RetransmitDelay: time.Second,
+	}, 1)
+	at, ok = l.schedule(10, now)
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Second), at)
+}
+
+func TestSimConn(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+
+	c := newSimConn(a, newSimLink(LinkConditions{
+		Latency: 100 * time.Millisecond,
+	}, 1), newSimLink(LinkConditions{
+		Latency: 200 * time.Millisecond,
+	}, 2), 1024)
+	defer c.Close()
+
+	msg := []byte{5, 0, 0, 0, 'T', 'E', 'S', 'T', 1}
+
+	// Outgoing messages are delayed
+	start := time.Now()
+	n, err := c.Write(msg)
+	require.NoError(t, err)
+	require.Equal(t, len(msg), n)
+
+	buf := make([]byte, len(msg))
+	_, err = b.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, buf)
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	// Incoming messages are delayed, the read deadline is honored
+	start = time.Now()
+	go func() {
+		_, err := b.Write(msg)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = c.Read(buf)
+	require.Equal(t, netsimTimeoutError{}, err)
+
+	require.NoError(t, c.SetReadDeadline(time.Time{}))
+	buf = make([]byte, 2)
+	var read []byte
+	for len(read) < len(msg) {
+		n, err := c.Read(buf)
+		require.NoError(t, err)
+		read = append(read, buf[:n]...)
+	}
+	require.Equal(t, msg, read)
+	require.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	// The read error is returned after the messages
+	go func() {
+		_, err := b.Write(msg)
+		require.NoError(t, err)
+		require.NoError(t, b.Close())
+	}()
+
+	buf = make([]byte, 100)
+	n, err = c.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, buf[:n])
+	_, err = c.Read(buf)
+	require.Error(t, err)
+
+	require.NoError(t, c.Close())
+	_, err = c.Write(msg)
+	require.Equal(t, errNetsimClosed, err)
+}
+
+func TestSimulateDisabled(t *testing.T) {
+	p, err := NewConnectionPool(newTestConfig(), nil)
+	require.NoError(t, err)
+
+	conn, other := net.Pipe()
+	defer other.Close()
+	require.Equal(t, conn, p.simulate(conn))
+
+	p.SetNetworkConditions(NetworkConditions{
+		Outgoing: LinkConditions{
+			Latency: time.Second,
+		},
+	})
+	c := p.simulate(conn)
+	_, ok := c.(*simConn)
+	require.True(t, ok)
+	require.NoError(t, c.Close())
+
+	p.SetNetworkConditions(NetworkConditions{})
+	require.Equal(t, conn, p.simulate(conn))
+}
+
+/* Scenarios */
+
+// simNode is a pool running a minimal sync and announce protocol, the state of its message handlers
+type simNode struct {
+	sync.Mutex
+	pool   *ConnectionPool
+	blocks []uint64
+	txns   map[uint64]struct{}
+	q      chan struct{}
+}
+
+// newSimNode starts a pool listening on an arbitrary port
+func newSimNode(t *testing.T, c NetworkConditions) *simNode {
+	cfg := newTestConfig()
+	cfg.Port = 0
+	cfg.ReadTimeout = 0
+	cfg.WriteTimeout = 0
+
+	n := &simNode{
+		txns: make(map[uint64]struct{}),
+		q:    make(chan struct{}),
+	}
+
+	p, err := NewConnectionPool(cfg, n)
+	require.NoError(t, err)
+	p.SetNetworkConditions(c)
+	n.pool = p
+
+	go func() {
+		defer close(n.q)
+		require.NoError(t, p.Run())
+	}()
+
+	return n
+}
+
+func (n *simNode) addr(t *testing.T) string {
+	for i := 0; i < 100; i++ {
+		n.pool.listenerLock.Lock()
+		ln := n.pool.listener
+		n.pool.listenerLock.Unlock()
+		if ln != nil {
+			return ln.Addr().String()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("pool is not listening")
+	return ""
+}
+
+func (n *simNode) shutdown() {
+	n.pool.Shutdown()
+	<-n.q
+}
+
+func (n *simNode) height() uint64 {
+	n.Lock()
+	defer n.Unlock()
+	return uint64(len(n.blocks))
+}
+
+func (n *simNode) hasTxn(txn uint64) bool {
+	n.Lock()
+	defer n.Unlock()
+	_, ok := n.txns[txn]
+	return ok
+}
+
+// announce announces the node's transactions to its peers
+func (n *simNode) announce() error {
+	n.Lock()
+	txns := make([]uint64, 0, len(n.txns))
+	for txn := range n.txns {
+		txns = append(txns, txn)
+	}
+	n.Unlock()
+
+	conns, err := n.pool.GetConnections()
+	if err != nil {
+		return err
+	}
+	addrs := make([]string, len(conns))
+	for i, c := range conns {
+		addrs[i] = c.Addr()
+	}
+
+	_, err = n.pool.BroadcastMessage(&simAnnounceTxnsMessage{
+		Txns: txns,
+	}, addrs)
+	return err
+}
+
+func waitForConnections(t *testing.T, n *simNode, count int) {
+	for i := 0; i < 500; i++ {
+		if size, err := n.pool.Size(); err == nil && size >= count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("pool has less than %d connections", count)
+}
+
+func registerSimMessages() {
+	resetHandler()
+	EraseMessages()
+	RegisterMessage(simGetBlocksPrefix, simGetBlocksMessage{})
+	RegisterMessage(simGiveBlocksPrefix, simGiveBlocksMessage{})
+	RegisterMessage(simAnnounceTxnsPrefix, simAnnounceTxnsMessage{})
+	VerifyMessages()
+}
+
+func TestNetsimSync(t *testing.T) {
+	registerSimMessages()
+
+	link := LinkConditions{
+		Latency:  300 * time.Millisecond,
+		Jitter:   50 * time.Millisecond,
+		DropRate: 0.01,
+	}
+
+	a := newSimNode(t, NetworkConditions{})
+	defer a.shutdown()
+	for i := uint64(0); i < 100; i++ {
+		a.blocks = append(a.blocks, i*i+7)
+	}
+
+	b := newSimNode(t, NetworkConditions{
+		Outgoing: link,
+		Incoming: link,
+		Seed:     1,
+	})
+	defer b.shutdown()
+
+	aAddr := a.addr(t)
+	b.addr(t)
+	require.NoError(t, b.pool.Connect(aAddr))
+	waitForConnections(t, b, 1)
+
+	// b requests the blocks it is missing until it has a's chain, the lost requests and responses are requested again
+	timeout := time.After(30 * time.Second)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for b.height() < a.height() {
+		select {
+		case <-timeout:
+			t.Fatalf("sync did not converge, height %d of %d", b.height(), a.height())
+		case <-ticker.C:
+			require.NoError(t, b.pool.SendMessage(aAddr, &simGetBlocksMessage{
+				From: b.height(),
+			}))
+		}
+	}
+
+	a.Lock()
+	b.Lock()
+	require.Equal(t, a.blocks, b.blocks)
+	b.Unlock()
+	a.Unlock()
+}
+
+func TestNetsimTxnPropagation(t *testing.T) {
+	registerSimMessages()
+
+	// Nodes in a ring, every message sent has a 5% chance of being lost
+	nodes := make([]*simNode, 5)
+	for i := range nodes {
+		nodes[i] = newSimNode(t, NetworkConditions{
+			Outgoing: LinkConditions{
+				Latency:  20 * time.Millisecond,
+				Jitter:   10 * time.Millisecond,
+				DropRate: 0.05,
+			},
+			Seed: int64(i),
+		})
+		defer nodes[i].shutdown()
+	}
+
+	for i, n := range nodes {
+		next := nodes[(i+1)%len(nodes)]
+		require.NoError(t, n.pool.Connect(next.addr(t)))
+	}
+	for _, n := range nodes {
+		waitForConnections(t, n, 2)
+	}
+
+	const txn = 42
+	nodes[0].txns[txn] = struct{}{}
+
+	// Every node announces the transactions it knows once per round.
+	// Without loss, the transaction reaches all nodes in 2 rounds.
+	const maxRounds = 10
+	round := 0
+	for ; round < maxRounds; round++ {
+		reached := 0
+		for _, n := range nodes {
+			if n.hasTxn(txn) {
+				reached++
+			}
+		}
+		if reached == len(nodes) {
+			break
+		}
+
+		for _, n := range nodes {
+			require.NoError(t, n.announce())
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	require.True(t, round < maxRounds, "transaction did not reach all nodes in %d announce rounds", maxRounds)
+}
+
+var (
+	simGetBlocksPrefix    = MessagePrefix{'S', 'G', 'E', 'T'}
+	simGiveBlocksPrefix   = MessagePrefix{'S', 'G', 'I', 'V'}
+	simAnnounceTxnsPrefix = MessagePrefix{'S', 'A', 'N', 'N'}
+
+	errNotSimNode = errors.New("message state is not a *simNode")
+)
+
+// simMaxBlocks is the maximum number of blocks in a simGiveBlocksMessage
+const simMaxBlocks = 20
+
+// simGetBlocksMessage requests the blocks from a height
+type simGetBlocksMessage struct {
+	From uint64
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *simGetBlocksMessage) EncodeSize() uint64 {
+	return uint64(encoder.Size(m))
+}
+
+// Encode implements gnet.Serializer
+func (m *simGetBlocksMessage) Encode(buf []byte) error {
+	copy(buf, encoder.Serialize(m))
+	return nil
+}
+
+// Decode implements gnet.Serializer
+func (m *simGetBlocksMessage) Decode(buf []byte) (uint64, error) {
+	return encoder.DeserializeRaw(buf, m)
+}
+
+func (m *simGetBlocksMessage) Handle(context *MessageContext, x interface{}) error {
+	n, ok := x.(*simNode)
+	if !ok {
+		return errNotSimNode
+	}
+
+	n.Lock()
+	var blocks []uint64
+	if m.From < uint64(len(n.blocks)) {
+		blocks = n.blocks[m.From:]
+		if len(blocks) > simMaxBlocks {
+			blocks = blocks[:simMaxBlocks]
+		}
+		blocks = append([]uint64{}, blocks...)
+	}
+	n.Unlock()
+
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	return n.pool.SendMessage(context.Addr, &simGiveBlocksMessage{
+		From:   m.From,
+		Blocks: blocks,
+	})
+}
+
+// simGiveBlocksMessage sends the blocks from a height
+type simGiveBlocksMessage struct {
+	From   uint64
+	Blocks []uint64
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *simGiveBlocksMessage) EncodeSize() uint64 {
+	return uint64(encoder.Size(m))
+}
+
+// Encode implements gnet.Serializer
+func (m *simGiveBlocksMessage) Encode(buf []byte) error {
+	copy(buf, encoder.Serialize(m))
+	return nil
+}
+
+// Decode implements gnet.Serializer
+func (m *simGiveBlocksMessage) Decode(buf []byte) (uint64, error) {
+	return encoder.DeserializeRaw(buf, m)
+}
+
+func (m *simGiveBlocksMessage) Handle(context *MessageContext, x interface{}) error {
+	n, ok := x.(*simNode)
+	if !ok {
+		return errNotSimNode
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	// Responses to earlier requests overlap the blocks already added
+	height := uint64(len(n.blocks))
+	if m.From > height {
+		return fmt.Errorf("blocks from %d are not contiguous with height %d", m.From, height)
+	}
+	if end := m.From + uint64(len(m.Blocks)); end > height {
+		n.blocks = append(n.blocks, m.Blocks[height-m.From:]...)
+	}
+
+	return nil
+}
+
+// simAnnounceTxnsMessage announces transactions
+type simAnnounceTxnsMessage struct {
+	Txns []uint64
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *simAnnounceTxnsMessage) EncodeSize() uint64 {
+	return uint64(encoder.Size(m))
+}
+
+// Encode implements gnet.Serializer
+func (m *simAnnounceTxnsMessage) Encode(buf []byte) error {
+	copy(buf, encoder.Serialize(m))
+	return nil
+}
+
+// Decode implements gnet.Serializer
+func (m *simAnnounceTxnsMessage) Decode(buf []byte) (uint64, error) {
+	return encoder.DeserializeRaw(buf, m)
+}
+
+func (m *simAnnounceTxnsMessage) Handle(context *MessageContext, x interface{}) error {
+	n, ok := x.(*simNode)
+	if !ok {
+		return errNotSimNode
+	}
+
+	n.Lock()
+	defer n.Unlock()
+	for _, txn := range m.Txns {
+		n.txns[txn] = struct{}{}
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"io"
@@ -31,6 +32,7 @@ const (
 	readLoopDurationThreshold       = 10 * time.Second
 	sendInMsgChanDurationThreshold  = 5 * time.Second
 	sendLoopDurationThreshold       = 500 * time.Millisecond
+	writeQueuePollInterval          = 10 * time.Millisecond
 )
 
 var (
@@ -122,6 +124,14 @@ type Config struct {
 	// Individual connections' send queue size.  This should be increased
 	// if send volume per connection is high, so as not to block
 	ConnectionWriteQueueSize int
+	// Maximum rate in bytes per second of throttled messages sent to all connections.
+	// Set to 0 for no limit. Can be changed at runtime with SetBandwidthLimits.
+	MaxUploadRate uint64
+	// Maximum rate in bytes per second of throttled messages received from all connections.
+	// Set to 0 for no limit. Can be changed at runtime with SetBandwidthLimits.
+	MaxDownloadRate uint64
+	// Message types subject to MaxUploadRate and MaxDownloadRate
+	ThrottledMessages map[MessagePrefix]struct{}
 	// Triggered on client disconnect
 	DisconnectCallback DisconnectCallback
 	// Triggered on client connect
@@ -134,6 +144,12 @@ type Config struct {
 	DefaultConnections []string
 	// Default connections map
 	defaultConnections map[string]struct{}
+	// Peers that are always kept connected. Outgoing connections to them
+	// are not limited by MaxOutgoingConnections or MaxDefaultPeerOutgoingConnections,
+	// and do not count towards MaxConnections
+	AlwaysConnect []string
+	// Always connect peers map
+	alwaysConnect map[string]struct{}
 }
 
 // NewConfig returns a Config with defaults set
@@ -150,10 +166,12 @@ func NewConfig() Config {
 		WriteTimeout:                      time.Second * 30,
 		SendResultsSize:                   2048,
 		ConnectionWriteQueueSize:          128,
+		ThrottledMessages:                 make(map[MessagePrefix]struct{}),
 		DisconnectCallback:                nil,
 		ConnectCallback:                   nil,
 		DebugPrint:                        false,
 		defaultConnections:                make(map[string]struct{}),
+		alwaysConnect:                     make(map[string]struct{}),
 	}
 }
 
@@ -179,6 +197,9 @@ type Connection struct {
 	// Message send queue.
 	WriteQueue chan Message
 	Solicited  bool
+	// Number of throttled messages taken from WriteQueue that wait for upload bandwidth.
+	// Accessed atomically.
+	throttledWrites int32
 }
 
 // NewConnection creates a new Connection tied to a ConnectionPool
@@ -236,13 +257,23 @@ type ConnectionPool struct {
 	defaultOutgoingConnections map[string]struct{}
 	// connected outgoing connections
 	outgoingConnections map[string]struct{}
+	// connected always connect peer connections, exempt from the connection limits
+	alwaysConnectConnections map[string]struct{}
 	// User-defined state to be passed into message handlers
 	messageState interface{}
+	// Bandwidth limits of throttled messages
+	uploadLimiter   *RateLimiter
+	downloadLimiter *RateLimiter
+	// Simulated network conditions, empty in builds without the netsim tag
+	netsim netsim
 	// Connection ID counter
 	connID uint64
 	// Listening connection
 	listener     net.Listener
 	listenerLock sync.Mutex
+	// closed by StopListening
+	listenerStopped     chan struct{}
+	listenerStoppedOnce sync.Once
 	// operations channel
 	reqC chan strand.Request
 	// quit channel
@@ -260,6 +291,10 @@ func NewConnectionPool(c Config, state interface{}) (*ConnectionPool, error) {
 		c.defaultConnections[p] = struct{}{}
 	}
 
+	for _, p := range c.AlwaysConnect {
+		c.alwaysConnect[p] = struct{}{}
+	}
+
 	if c.MaxConnections < c.MaxOutgoingConnections+c.MaxDefaultPeerOutgoingConnections {
 		return nil, errors.New("MaxConnections must be >= MaxOutgoingConnections + MaxDefaultPeerOutgoingConnections")
 	}
@@ -270,12 +305,16 @@ func NewConnectionPool(c Config, state interface{}) (*ConnectionPool, error) {
 		addresses:                  make(map[string]*Connection),
 		defaultOutgoingConnections: make(map[string]struct{}),
 		outgoingConnections:        make(map[string]struct{}),
+		alwaysConnectConnections:   make(map[string]struct{}),
 		SendResults:                make(chan SendResult, c.SendResultsSize),
 		messageState:               state,
+		uploadLimiter:              NewRateLimiter(c.MaxUploadRate),
+		downloadLimiter:            NewRateLimiter(c.MaxDownloadRate),
 		quit:                       make(chan struct{}),
 		done:                       make(chan struct{}),
 		strandDone:                 make(chan struct{}),
 		reqC:                       make(chan strand.Request),
+		listenerStopped:            make(chan struct{}),
 	}, nil
 }
 
@@ -302,7 +341,15 @@ func (pool *ConnectionPool) Run() error {
 	}
 
 	pool.listenerLock.Lock()
-	pool.listener = ln
+	select {
+	case <-pool.listenerStopped:
+		// StopListening was called before the listener was set
+		if err := ln.Close(); err != nil {
+			logger.WithError(err).Warning("ln.Close error")
+		}
+	default:
+		pool.listener = ln
+	}
 	pool.listenerLock.Unlock()
 
 loop:
@@ -314,6 +361,10 @@ loop:
 			select {
 			case <-pool.quit:
 				break loop
+			case <-pool.listenerStopped:
+				// No more connections will be accepted, wait for shutdown
+				<-pool.quit
+				break loop
 			default:
 				// without the default case the select will block.
 				logger.Error(err.Error())
@@ -400,6 +451,63 @@ func (pool *ConnectionPool) Shutdown() {
 	<-pool.done
 }
 
+// StopListening closes the listener so that no new incoming connections are accepted.
+// Existing connections are not affected. Used to drain connections before Shutdown.
+func (pool *ConnectionPool) StopListening() {
+	pool.listenerStoppedOnce.Do(func() {
+		logger.Info("ConnectionPool.StopListening closing the listener")
+
+		pool.listenerLock.Lock()
+		defer pool.listenerLock.Unlock()
+
+		close(pool.listenerStopped)
+
+		if pool.listener != nil {
+			if err := pool.listener.Close(); err != nil {
+				logger.WithError(err).Warning("pool.listener.Close error")
+			}
+		}
+		pool.listener = nil
+	})
+}
+
+// WaitForWrites waits until the write queues of all connections are empty, including throttled messages
+// waiting for upload bandwidth, or until timeout passes.
+// Returns false if the timeout passed first or the pool was shut down.
+func (pool *ConnectionPool) WaitForWrites(timeout time.Duration) bool {
+	ticker := time.NewTicker(writeQueuePollInterval)
+	defer ticker.Stop()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		empty := false
+		if err := pool.strand("WaitForWrites", func() error {
+			empty = true
+			for _, c := range pool.pool {
+				if len(c.WriteQueue) != 0 || atomic.LoadInt32(&c.throttledWrites) != 0 {
+					empty = false
+					break
+				}
+			}
+			return nil
+		}); err != nil {
+			return false
+		}
+
+		if empty {
+			return true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
 // strand ensures all read and write action of pool's member variable are in one thread
 func (pool *ConnectionPool) strand(name string, f func() error) error {
 	name = fmt.Sprintf("daemon.gnet.ConnectionPool.%s", name)
@@ -420,6 +528,10 @@ func (pool *ConnectionPool) canConnect(a string, solicited bool) error {
 	}
 
 	if solicited {
+		if _, ok := pool.Config.alwaysConnect[a]; ok {
+			return nil
+		}
+
 		if _, ok := pool.Config.defaultConnections[a]; ok && pool.isMaxOutgoingDefaultConnectionsReached() {
 			return ErrMaxOutgoingDefaultConnectionsReached
 		} else if pool.isMaxOutgoingConnectionsReached() {
@@ -441,7 +553,10 @@ func (pool *ConnectionPool) newConnection(conn net.Conn, solicited bool) (*Conne
 		return nil, err
 	}
 
-	if solicited {
+	if _, ok := pool.Config.alwaysConnect[a]; ok && solicited {
+		pool.alwaysConnectConnections[a] = struct{}{}
+		logger.WithField("addr", a).Debug("Outgoing always connect connection")
+	} else if solicited {
 		pool.outgoingConnections[a] = struct{}{}
 
 		if _, ok := pool.Config.defaultConnections[a]; ok {
@@ -467,6 +582,7 @@ func (pool *ConnectionPool) newConnection(conn net.Conn, solicited bool) (*Conne
 
 // Creates a Connection and begins its read and write loop
 func (pool *ConnectionPool) handleConnection(conn net.Conn, solicited bool) error {
+	conn = pool.simulate(conn)
 	defer logger.WithField("addr", conn.RemoteAddr()).Debug("Connection closed")
 	addr := conn.RemoteAddr().String()
 
@@ -632,6 +748,16 @@ func (pool *ConnectionPool) readLoop(conn *Connection, msgChan chan []byte, qc c
 			return err
 		}
 		for _, d := range datas {
+			// Throttled messages are passed on once there is download bandwidth for them.
+			// The connection is not read from meanwhile, which slows down the sender.
+			var prefix MessagePrefix
+			copy(prefix[:], d)
+			if pool.isThrottled(prefix) {
+				if !pool.waitFor(pool.downloadLimiter.Reserve(messageLengthPrefixSize+len(d)), qc) {
+					return nil
+				}
+			}
+
 			// use select to avoid the goroutine leak,
 			// because if msgChan has no receiver this goroutine will leak
 			select {
@@ -652,43 +778,74 @@ func (pool *ConnectionPool) sendLoop(conn *Connection, timeout time.Duration, ma
 	elapser := elapse.NewElapser(sendLoopDurationThreshold, logger)
 	defer elapser.CheckForDone()
 
+	// Throttled messages waiting for upload bandwidth. Other messages are sent while they wait,
+	// so that throttled messages don't hold up transaction relay and pings.
+	var throttled []Message
+	var throttledReady <-chan time.Time
+	defer atomic.StoreInt32(&conn.throttledWrites, 0)
+
 	for {
 		elapser.CheckForDone()
+
+		if throttledReady == nil && len(throttled) != 0 {
+			throttledReady = time.After(pool.uploadLimiter.Reserve(encodedSize(throttled[0])))
+		}
+
+		// Stop taking messages from the write queue while it is backed up by throttled messages
+		writeQueue := conn.WriteQueue
+		if len(throttled) >= cap(conn.WriteQueue) {
+			writeQueue = nil
+		}
+
+		var m Message
 		select {
 		case <-pool.quit:
 			return nil
 		case <-qc:
 			return nil
-		case m := <-conn.WriteQueue:
-			elapser.Register(fmt.Sprintf("conn.WriteQueue address=%s", conn.Addr()))
+		case <-throttledReady:
+			throttledReady = nil
+			m = throttled[0]
+			throttled[0] = nil
+			throttled = throttled[1:]
+			atomic.AddInt32(&conn.throttledWrites, -1)
+		case m = <-writeQueue:
 			if m == nil {
 				continue
 			}
 
-			err := sendMessage(conn.Conn, m, timeout, maxMsgLength)
-
-			// Update last sent before writing to SendResult,
-			// this allows a write to SendResult to be used as a sync marker,
-			// since no further action in this block will happen after the write.
-			if err == nil {
-				if err := pool.updateLastSent(conn.Addr(), Now()); err != nil {
-					logger.WithField("addr", conn.Addr()).WithError(err).Warning("updateLastSent failed")
-				}
+			if pool.isThrottled(MessageIDMap[reflect.TypeOf(m).Elem()]) && (len(throttled) != 0 || pool.uploadLimiter.Rate() != 0) {
+				throttled = append(throttled, m)
+				atomic.AddInt32(&conn.throttledWrites, 1)
+				continue
 			}
+		}
 
-			sr := newSendResult(conn.Addr(), m, err)
-			select {
-			case <-qc:
-				return nil
-			case pool.SendResults <- sr:
-			default:
-				logger.WithField("addr", conn.Addr()).Warning("SendResults queue full")
-			}
+		elapser.Register(fmt.Sprintf("conn.WriteQueue address=%s", conn.Addr()))
 
-			if err != nil {
-				return err
+		err := sendMessage(conn.Conn, m, timeout, maxMsgLength)
+
+		// Update last sent before writing to SendResult,
+		// this allows a write to SendResult to be used as a sync marker,
+		// since no further action in this block will happen after the write.
+		if err == nil {
+			if err := pool.updateLastSent(conn.Addr(), Now()); err != nil {
+				logger.WithField("addr", conn.Addr()).WithError(err).Warning("updateLastSent failed")
 			}
 		}
+
+		sr := newSendResult(conn.Addr(), m, err)
+		select {
+		case <-qc:
+			return nil
+		case pool.SendResults <- sr:
+		default:
+			logger.WithField("addr", conn.Addr()).Warning("SendResults queue full")
+		}
+
+		if err != nil {
+			return err
+		}
 	}
 }
 
@@ -740,8 +897,9 @@ func decodeData(buf *bytes.Buffer, maxMsgLength int) ([][]byte, error) {
 			return [][]byte{}, ErrDisconnectInvalidMessageLength
 		}
 
+		// Wait for the rest of the message, keeping the messages decoded so far
 		if buf.Len()-messageLengthPrefixSize < length {
-			return [][]byte{}, nil
+			return dataArray, nil
 		}
 
 		buf.Next(messageLengthPrefixSize) // strip the length prefix
@@ -763,7 +921,7 @@ func (pool *ConnectionPool) isConnExist(addr string) bool {
 }
 
 func (pool *ConnectionPool) isMaxIncomingConnectionsReached() bool {
-	return len(pool.pool) >= (pool.Config.MaxConnections - pool.Config.MaxOutgoingConnections - pool.Config.MaxDefaultPeerOutgoingConnections)
+	return len(pool.pool)-len(pool.alwaysConnectConnections) >= (pool.Config.MaxConnections - pool.Config.MaxOutgoingConnections - pool.Config.MaxDefaultPeerOutgoingConnections)
 }
 
 func (pool *ConnectionPool) isMaxOutgoingConnectionsReached() bool {
@@ -886,6 +1044,7 @@ func (pool *ConnectionPool) disconnect(addr string, r DisconnectReason) *Connect
 	delete(pool.addresses, addr)
 	delete(pool.defaultOutgoingConnections, addr)
 	delete(pool.outgoingConnections, addr)
+	delete(pool.alwaysConnectConnections, addr)
 	if err := conn.Close(); err != nil {
 		logger.WithError(err).WithFields(fields).Error("conn.Close")
 	}
@@ -220,6 +220,67 @@ func TestStopListen(t *testing.T) {
 	require.Nil(t, p.listener)
 }
 
+func TestStopListening(t *testing.T) {
+	resetHandler()
+	EraseMessages()
+	RegisterMessage(BytePrefix, ByteMessage{})
+	VerifyMessages()
+
+	cfg := newTestConfig()
+	cfg.WriteTimeout = time.Second
+	p, err := NewConnectionPool(cfg, nil)
+	require.NoError(t, err)
+
+	cc := make(chan *Connection, 1)
+	p.Config.ConnectCallback = func(addr string, id uint64, solicited bool) {
+		cc <- p.pool[id]
+	}
+
+	q := make(chan struct{})
+	go func() {
+		defer close(q)
+		err := p.Run()
+		require.NoError(t, err)
+	}()
+	wait()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	c := <-cc
+
+	p.StopListening()
+	// Calling it twice is safe
+	p.StopListening()
+	wait()
+
+	// New connections are refused
+	_, err = net.Dial("tcp", addr)
+	require.Error(t, err)
+
+	// Existing connections are unaffected
+	err = p.strand("", func() error {
+		require.Len(t, p.pool, 1)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = p.SendMessage(c.Addr(), NewByteMessage(88))
+	require.NoError(t, err)
+	require.True(t, p.WaitForWrites(time.Second))
+
+	// The message was written to the connection
+	b := make([]byte, 1)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(b)
+	require.NoError(t, err)
+
+	p.Shutdown()
+	<-q
+
+	require.Nil(t, p.listener)
+	require.False(t, p.WaitForWrites(time.Second))
+}
+
 func TestHandleConnection(t *testing.T) {
 	cfg := newTestConfig()
 
@@ -349,6 +410,41 @@ func TestConnect(t *testing.T) {
 	require.Error(t, connectErr)
 }
 
+func TestCanConnectAlwaysConnect(t *testing.T) {
+	defaultAddr := "127.0.0.1:6001"
+	alwaysAddr := "127.0.0.1:6002"
+
+	cfg := newTestConfig()
+	cfg.MaxConnections = 3
+	cfg.MaxOutgoingConnections = 1
+	cfg.MaxDefaultPeerOutgoingConnections = 1
+	cfg.DefaultConnections = []string{defaultAddr}
+	cfg.AlwaysConnect = []string{alwaysAddr}
+
+	p, err := NewConnectionPool(cfg, nil)
+	require.NoError(t, err)
+
+	// Fill the outgoing and default outgoing connection slots
+	p.outgoingConnections["127.0.0.1:6003"] = struct{}{}
+	p.defaultOutgoingConnections["127.0.0.1:6004"] = struct{}{}
+
+	require.Equal(t, ErrMaxOutgoingConnectionsReached, p.canConnect("127.0.0.1:6005", true))
+	require.Equal(t, ErrMaxOutgoingDefaultConnectionsReached, p.canConnect(defaultAddr, true))
+	require.NoError(t, p.canConnect(alwaysAddr, true))
+
+	// Always connect connections do not count towards the incoming connections limit
+	p.pool[1] = &Connection{ID: 1}
+	p.alwaysConnectConnections[alwaysAddr] = struct{}{}
+	require.NoError(t, p.canConnect("127.0.0.1:6006", false))
+
+	p.pool[2] = &Connection{ID: 2}
+	require.Equal(t, ErrMaxIncomingConnectionsReached, p.canConnect("127.0.0.1:6006", false))
+
+	// An existing connection is still rejected
+	p.addresses[alwaysAddr] = p.pool[1]
+	require.Equal(t, ErrConnectionExists, p.canConnect(alwaysAddr, true))
+}
+
 func TestConnectNoTimeout(t *testing.T) {
 	cfg := newTestConfig()
 	cfg.DialTimeout = 0
@@ -616,6 +712,32 @@ func TestConnectionReadLoopSetReadDeadlineFailed(t *testing.T) {
 	<-q
 }
 
+func TestDecodeDataPartialMessage(t *testing.T) {
+	EraseMessages()
+	RegisterMessage(BytePrefix, ByteMessage{})
+	VerifyMessages()
+
+	m, err := EncodeMessage(NewByteMessage(7))
+	require.NoError(t, err)
+
+	// A complete message followed by the start of another message
+	buf := &bytes.Buffer{}
+	buf.Write(m)
+	buf.Write(m[:len(m)-1])
+
+	datas, err := decodeData(buf, 1024)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{m[messageLengthPrefixSize:]}, datas)
+	require.Equal(t, len(m)-1, buf.Len())
+
+	// The rest of the message arrives
+	buf.Write(m[len(m)-1:])
+	datas, err = decodeData(buf, 1024)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{m[messageLengthPrefixSize:]}, datas)
+	require.Equal(t, 0, buf.Len())
+}
+
 func TestConnectionReadLoopInvalidMessageLength(t *testing.T) {
 	cfg := newTestConfig()
 	cfg.MaxIncomingMessageLength = 1
@@ -0,0 +1,135 @@
+package gnet
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the number of bytes transferred per second.
+// The bucket holds up to one second of transfer. A rate of 0 disables the limit.
+type RateLimiter struct {
+	sync.Mutex
+	rate   uint64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter with a full bucket
+func NewRateLimiter(rate uint64) *RateLimiter {
+	return &RateLimiter{
+		rate:   rate,
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// Rate returns the rate limit in bytes per second
+func (r *RateLimiter) Rate() uint64 {
+	r.Lock()
+	defer r.Unlock()
+	return r.rate
+}
+
+// SetRate changes the rate limit. Bytes already reserved are not affected.
+func (r *RateLimiter) SetRate(rate uint64) {
+	r.Lock()
+	defer r.Unlock()
+	r.setRate(rate, time.Now())
+}
+
+func (r *RateLimiter) setRate(rate uint64, now time.Time) {
+	if r.rate == 0 {
+		r.tokens = float64(rate)
+	} else {
+		r.refill(now)
+		if r.tokens > float64(rate) {
+			r.tokens = float64(rate)
+		}
+	}
+
+	r.rate = rate
+	r.last = now
+}
+
+// Reserve takes n bytes from the bucket and returns how long to wait before transferring them.
+// Transfers larger than the bucket put it in debt, so that the rate holds over time.
+func (r *RateLimiter) Reserve(n int) time.Duration {
+	r.Lock()
+	defer r.Unlock()
+	return r.reserve(n, time.Now())
+}
+
+func (r *RateLimiter) reserve(n int, now time.Time) time.Duration {
+	if r.rate == 0 {
+		return 0
+	}
+
+	r.refill(now)
+	r.tokens -= float64(n)
+	if r.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-r.tokens / float64(r.rate) * float64(time.Second))
+}
+
+func (r *RateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens += elapsed.Seconds() * float64(r.rate)
+		if r.tokens > float64(r.rate) {
+			r.tokens = float64(r.rate)
+		}
+	}
+	r.last = now
+}
+
+// BandwidthLimits are the upload and download rate limits of throttled messages in bytes per second,
+// shared by all connections. A limit of 0 is unlimited.
+type BandwidthLimits struct {
+	Upload   uint64
+	Download uint64
+}
+
+// BandwidthLimits returns the current bandwidth limits
+func (pool *ConnectionPool) BandwidthLimits() BandwidthLimits {
+	return BandwidthLimits{
+		Upload:   pool.uploadLimiter.Rate(),
+		Download: pool.downloadLimiter.Rate(),
+	}
+}
+
+// SetBandwidthLimits changes the bandwidth limits of a running pool
+func (pool *ConnectionPool) SetBandwidthLimits(l BandwidthLimits) {
+	pool.uploadLimiter.SetRate(l.Upload)
+	pool.downloadLimiter.SetRate(l.Download)
+}
+
+// isThrottled returns true if a message type is subject to the bandwidth limits
+func (pool *ConnectionPool) isThrottled(prefix MessagePrefix) bool {
+	_, ok := pool.Config.ThrottledMessages[prefix]
+	return ok
+}
+
+// encodedSize returns the number of bytes a message takes on the wire
+func encodedSize(m Message) int {
+	return messageLengthPrefixSize + messagePrefixLength + int(m.EncodeSize())
+}
+
+// waitFor blocks for d, returning false if the connection or pool quits first
+func (pool *ConnectionPool) waitFor(d time.Duration, qc chan struct{}) bool {
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-qc:
+		return false
+	case <-pool.quit:
+		return false
+	}
+}
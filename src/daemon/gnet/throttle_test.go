@@ -0,0 +1,272 @@
+package gnet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+type BulkMessage struct {
+	Data []byte
+}
+
+var BulkPrefix = MessagePrefix{'B', 'U', 'L', 'K'}
+
+// EncodeSize implements gnet.Serializer
+func (bm *BulkMessage) EncodeSize() uint64 {
+	return uint64(encoder.Size(bm))
+}
+
+// Encode implements gnet.Serializer
+func (bm *BulkMessage) Encode(buf []byte) error {
+	buf2 := encoder.Serialize(bm)
+	if len(buf) < len(buf2) {
+		return errors.New("Not enough buffer data to encode")
+	}
+	copy(buf[:], buf2[:])
+	return nil
+}
+
+// Decode implements gnet.Serializer
+func (bm *BulkMessage) Decode(buf []byte) (uint64, error) {
+	return encoder.DeserializeRaw(buf, bm)
+}
+
+func (bm *BulkMessage) Handle(c *MessageContext, x interface{}) error {
+	x.(chan time.Time) <- time.Now()
+	return nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+
+	// Unlimited
+	r := NewRateLimiter(0)
+	require.Equal(t, time.Duration(0), r.reserve(1e9, now))
+
+	// The bucket starts full
+	r = NewRateLimiter(1000)
+	r.last = now
+	require.Equal(t, time.Duration(0), r.reserve(600, now))
+	require.Equal(t, time.Duration(0), r.reserve(400, now))
+
+	// An empty bucket makes the caller wait
+	require.Equal(t, time.Millisecond*500, r.reserve(500, now))
+
+	// The bucket is refilled over time, paying off the debt first
+	now = now.Add(time.Second)
+	require.Equal(t, time.Duration(0), r.reserve(500, now))
+	require.Equal(t, time.Millisecond*100, r.reserve(100, now))
+
+	// The bucket holds at most one second of transfer
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Duration(0), r.reserve(1000, now))
+	require.Equal(t, time.Second*2, r.reserve(2000, now))
+
+	// Lowering the rate keeps the debt
+	now = now.Add(time.Second * 2)
+	r.setRate(100, now)
+	require.Equal(t, time.Second, r.reserve(100, now))
+
+	// Disabling and enabling the limit starts with a full bucket
+	r.setRate(0, now)
+	require.Equal(t, time.Duration(0), r.reserve(1e9, now))
+	r.setRate(100, now)
+	require.Equal(t, time.Duration(0), r.reserve(100, now))
+	require.Equal(t, time.Second, r.reserve(100, now))
+}
+
+// throttleTestPools runs a listening pool with the server config and a connected offline pool with the client config
+func throttleTestPools(t *testing.T, serverCfg, clientCfg Config, received chan time.Time) (server, client *ConnectionPool, cleanup func()) {
+	server, err := NewConnectionPool(serverCfg, received)
+	require.NoError(t, err)
+
+	sq := make(chan struct{})
+	go func() {
+		defer close(sq)
+		err := server.Run()
+		require.NoError(t, err)
+	}()
+	wait()
+
+	client, err = NewConnectionPool(clientCfg, received)
+	require.NoError(t, err)
+
+	connected := make(chan struct{})
+	client.Config.ConnectCallback = func(addr string, id uint64, solicited bool) {
+		close(connected)
+	}
+
+	cq := make(chan struct{})
+	go func() {
+		defer close(cq)
+		err := client.RunOffline()
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, client.Connect(addr))
+	<-connected
+
+	return server, client, func() {
+		client.Shutdown()
+		<-cq
+		server.Shutdown()
+		<-sq
+	}
+}
+
+func TestPoolBandwidthThroughput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("slow test")
+	}
+
+	const (
+		rate     = 100000
+		msgCount = 30
+		msgSize  = 10000
+	)
+
+	cases := []struct {
+		name     string
+		upload   bool
+		download bool
+	}{
+		{
+			name:   "upload",
+			upload: true,
+		},
+		{
+			name:     "download",
+			download: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wait()
+			resetHandler()
+			EraseMessages()
+			RegisterMessage(BulkPrefix, BulkMessage{})
+			VerifyMessages()
+
+			serverCfg := newTestConfig()
+			clientCfg := newTestConfig()
+			clientCfg.ConnectionWriteQueueSize = msgCount
+			clientCfg.MaxOutgoingMessageLength = msgSize * 2
+			serverCfg.MaxIncomingMessageLength = msgSize * 2
+			serverCfg.ThrottledMessages[BulkPrefix] = struct{}{}
+			clientCfg.ThrottledMessages[BulkPrefix] = struct{}{}
+			if tc.upload {
+				clientCfg.MaxUploadRate = rate
+			}
+			if tc.download {
+				serverCfg.MaxDownloadRate = rate
+			}
+
+			received := make(chan time.Time, msgCount)
+			_, client, cleanup := throttleTestPools(t, serverCfg, clientCfg, received)
+			defer cleanup()
+
+			m := &BulkMessage{
+				Data: make([]byte, msgSize),
+			}
+
+			start := time.Now()
+			for i := 0; i < msgCount; i++ {
+				require.NoError(t, client.SendMessage(addr, m))
+			}
+
+			var last time.Time
+			for i := 0; i < msgCount; i++ {
+				select {
+				case last = <-received:
+				case <-time.After(time.Second * 10):
+					t.Fatalf("received %d of %d messages", i, msgCount)
+				}
+			}
+
+			// The bucket starts full with one second of transfer
+			expected := time.Duration(float64(msgCount*encodedSize(m)-rate) / rate * float64(time.Second))
+			elapsed := last.Sub(start)
+			require.True(t, elapsed >= expected*8/10 && elapsed <= expected*12/10,
+				"elapsed %s, expected %s", elapsed, expected)
+		})
+	}
+}
+
+func TestPoolBandwidthUnthrottledMessages(t *testing.T) {
+	wait()
+	resetHandler()
+	EraseMessages()
+	RegisterMessage(BulkPrefix, BulkMessage{})
+	RegisterMessage(BytePrefix, ByteMessage{})
+	VerifyMessages()
+
+	const msgSize = 10000
+
+	serverCfg := newTestConfig()
+	serverCfg.MaxIncomingMessageLength = msgSize * 2
+	clientCfg := newTestConfig()
+	clientCfg.MaxOutgoingMessageLength = msgSize * 2
+	clientCfg.MaxUploadRate = msgSize
+	clientCfg.ThrottledMessages[BulkPrefix] = struct{}{}
+
+	received := make(chan time.Time, 8)
+	_, client, cleanup := throttleTestPools(t, serverCfg, clientCfg, received)
+	defer cleanup()
+
+	// The first message empties the bucket, the next ones wait a second each
+	bulk := &BulkMessage{
+		Data: make([]byte, msgSize),
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, client.SendMessage(addr, bulk))
+	}
+	require.NoError(t, client.SendMessage(addr, NewByteMessage(1)))
+
+	// The unthrottled message is sent without waiting for the throttled messages
+	var bulkSent int
+	for sent := false; !sent; {
+		select {
+		case sr := <-client.SendResults:
+			require.NoError(t, sr.Error)
+			switch sr.Message.(type) {
+			case *BulkMessage:
+				bulkSent++
+			case *ByteMessage:
+				sent = true
+			}
+		case <-time.After(time.Millisecond * 500):
+			t.Fatal("unthrottled message was not sent")
+		}
+	}
+	require.True(t, bulkSent <= 1)
+
+	// WaitForWrites includes the throttled messages
+	require.False(t, client.WaitForWrites(time.Millisecond*100))
+	require.True(t, client.WaitForWrites(time.Second*5))
+
+	// Lifting the limit at runtime
+	client.SetBandwidthLimits(BandwidthLimits{})
+	require.Equal(t, BandwidthLimits{}, client.BandwidthLimits())
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, client.SendMessage(addr, bulk))
+	}
+	require.True(t, client.WaitForWrites(time.Second))
+	require.True(t, time.Since(start) < time.Millisecond*500)
+
+	client.SetBandwidthLimits(BandwidthLimits{
+		Upload:   10,
+		Download: 20,
+	})
+	require.Equal(t, BandwidthLimits{
+		Upload:   10,
+		Download: 20,
+	}, client.BandwidthLimits())
+}
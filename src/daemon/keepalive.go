@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// keepaliveState is the keepalive ping outstanding on a connection
+type keepaliveState struct {
+	// Nonce of the last keepalive ping sent
+	nonce uint64
+	// Time that the last keepalive ping was sent
+	sentAt time.Time
+	// Whether the last keepalive ping is waiting for a reply
+	pending bool
+}
+
+// keepaliveAction is the action to take on a connection after a keepalive check
+type keepaliveAction int
+
+const (
+	// keepaliveNone nothing needs to be sent
+	keepaliveNone keepaliveAction = iota
+	// keepalivePing a keepalive ping must be sent
+	keepalivePing
+	// keepaliveDisconnect the peer missed too many keepalive pings and must be disconnected
+	keepaliveDisconnect
+)
+
+// checkKeepalive updates the keepalive state of a connection at time now, given the time a message was last
+// received from the peer, and returns the action to take.
+// A ping that is not answered within timeout counts as missed, unless the peer sent something else after it.
+// A ping is sent once nothing was received from the peer for interval, the nonce of the ping is recorded
+// before returning keepalivePing.
+func (c *ConnectionDetails) checkKeepalive(now, lastReceived time.Time, interval, timeout time.Duration, maxMissed int) keepaliveAction {
+	if c.keepalive.pending {
+		if now.Sub(c.keepalive.sentAt) < timeout {
+			return keepaliveNone
+		}
+
+		c.keepalive.pending = false
+		if lastReceived.After(c.keepalive.sentAt) {
+			c.MissedPongs = 0
+		} else {
+			c.MissedPongs++
+			if c.MissedPongs >= maxMissed {
+				return keepaliveDisconnect
+			}
+		}
+	}
+
+	if now.Sub(lastReceived) < interval {
+		return keepaliveNone
+	}
+
+	c.keepalive.nonce++
+	c.keepalive.sentAt = now
+	c.keepalive.pending = true
+
+	return keepalivePing
+}
+
+// keepalivePong records the reply to a keepalive ping received at time now.
+// Returns false if nonce is not the nonce of the ping waiting for a reply, e.g. because the reply is late.
+func (c *ConnectionDetails) keepalivePong(now time.Time, nonce uint64) bool {
+	if !c.keepalive.pending || nonce != c.keepalive.nonce {
+		return false
+	}
+
+	c.keepalive.pending = false
+	c.RTT = now.Sub(c.keepalive.sentAt)
+	c.MissedPongs = 0
+
+	return true
+}
+
+// checkKeepalive runs ConnectionDetails.checkKeepalive on a connection.
+// Returns the action to take and the nonce of the ping to send.
+func (c *Connections) checkKeepalive(addr string, gnetID uint64, now, lastReceived time.Time, dc DaemonConfig) (keepaliveAction, uint64, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	var action keepaliveAction
+	var nonce uint64
+	err := c.modify(addr, gnetID, func(c *ConnectionDetails) {
+		action = c.checkKeepalive(now, lastReceived, dc.KeepaliveInterval, dc.KeepaliveTimeout, dc.KeepaliveMaxMissedPongs)
+		nonce = c.keepalive.nonce
+	})
+
+	return action, nonce, err
+}
+
+// keepalivePong runs ConnectionDetails.keepalivePong on a connection
+func (c *Connections) keepalivePong(addr string, gnetID uint64, now time.Time, nonce uint64) (bool, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	var ok bool
+	err := c.modify(addr, gnetID, func(c *ConnectionDetails) {
+		ok = c.keepalivePong(now, nonce)
+	})
+
+	return ok, err
+}
+
+// hasKeepalive returns true if the connection at addr negotiated ProtocolFeatureKeepalive
+func (dm *Daemon) hasKeepalive(addr string) bool {
+	c := dm.connections.get(addr)
+	return c != nil && c.HasProtocolFeature(ProtocolFeatureKeepalive)
+}
+
+// sendKeepalivePings sends keepalive pings to the idle connections that negotiated ProtocolFeatureKeepalive,
+// and disconnects the peers that missed KeepaliveMaxMissedPongs consecutive pings.
+// Other connections are kept alive with PingMessage by Pool.sendPings.
+func (dm *Daemon) sendKeepalivePings() {
+	gconns, err := dm.pool.Pool.GetConnections()
+	if err != nil {
+		logger.WithError(err).Error("sendKeepalivePings failed")
+		return
+	}
+
+	lastReceived := make(map[string]time.Time, len(gconns))
+	for _, gc := range gconns {
+		lastReceived[gc.Addr()] = gc.LastReceived
+	}
+
+	now := time.Now().UTC()
+	for _, c := range dm.connections.all() {
+		if !c.HasProtocolFeature(ProtocolFeatureKeepalive) {
+			continue
+		}
+
+		t, ok := lastReceived[c.Addr]
+		if !ok {
+			continue
+		}
+
+		fields := logrus.Fields{
+			"addr":   c.Addr,
+			"gnetID": c.gnetID,
+		}
+
+		action, nonce, err := dm.connections.checkKeepalive(c.Addr, c.gnetID, now, t, dm.config)
+		if err != nil {
+			logger.WithError(err).WithFields(fields).Error("connections.checkKeepalive failed")
+			continue
+		}
+
+		switch action {
+		case keepalivePing:
+			if dm.config.LogPings {
+				logger.WithFields(fields).Debug("Sending keepalive ping")
+			}
+			if err := dm.sendMessage(c.Addr, NewKeepalivePingMessage(nonce)); err != nil {
+				logger.WithError(err).WithFields(fields).Error("Send KeepalivePingMessage failed")
+			}
+		case keepaliveDisconnect:
+			logger.WithFields(fields).Info("Peer did not answer keepalive pings, disconnecting")
+			if err := dm.Disconnect(c.Addr, ErrDisconnectKeepaliveTimeout); err != nil {
+				logger.WithError(err).WithFields(fields).Error("Disconnect")
+			}
+		}
+	}
+}
+
+// recordKeepalivePong records the reply of the peer at addr to a keepalive ping
+func (dm *Daemon) recordKeepalivePong(addr string, gnetID, nonce uint64) {
+	now := time.Now().UTC()
+	ok, err := dm.connections.keepalivePong(addr, gnetID, now, nonce)
+	if err != nil {
+		logger.WithError(err).WithField("addr", addr).Error("connections.keepalivePong failed")
+		return
+	}
+
+	if !ok && dm.config.LogPings {
+		logger.WithFields(logrus.Fields{
+			"addr":   addr,
+			"gnetID": gnetID,
+			"nonce":  nonce,
+		}).Debug("Ignoring keepalive pong with unexpected nonce")
+	}
+}
@@ -0,0 +1,78 @@
+// Code generated by github.com/skycoin/skyencoder. DO NOT EDIT.
+
+package daemon
+
+import "github.com/skycoin/skycoin/src/cipher/encoder"
+
+// encodeSizeKeepalivePingMessage computes the size of an encoded object of type KeepalivePingMessage
+func encodeSizeKeepalivePingMessage(obj *KeepalivePingMessage) uint64 {
+	i0 := uint64(0)
+
+	// obj.Nonce
+	i0 += 8
+
+	return i0
+}
+
+// encodeKeepalivePingMessage encodes an object of type KeepalivePingMessage to a buffer allocated to the exact size
+// required to encode the object.
+func encodeKeepalivePingMessage(obj *KeepalivePingMessage) ([]byte, error) {
+	n := encodeSizeKeepalivePingMessage(obj)
+	buf := make([]byte, n)
+
+	if err := encodeKeepalivePingMessageToBuffer(buf, obj); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// encodeKeepalivePingMessageToBuffer encodes an object of type KeepalivePingMessage to a []byte buffer.
+// The buffer must be large enough to encode the object, otherwise an error is returned.
+func encodeKeepalivePingMessageToBuffer(buf []byte, obj *KeepalivePingMessage) error {
+	if uint64(len(buf)) < encodeSizeKeepalivePingMessage(obj) {
+		return encoder.ErrBufferUnderflow
+	}
+
+	e := &encoder.Encoder{
+		Buffer: buf[:],
+	}
+
+	// obj.Nonce
+	e.Uint64(obj.Nonce)
+
+	return nil
+}
+
+// decodeKeepalivePingMessage decodes an object of type KeepalivePingMessage from a buffer.
+// Returns the number of bytes used from the buffer to decode the object.
+// If the buffer not long enough to decode the object, returns encoder.ErrBufferUnderflow.
+func decodeKeepalivePingMessage(buf []byte, obj *KeepalivePingMessage) (uint64, error) {
+	d := &encoder.Decoder{
+		Buffer: buf[:],
+	}
+
+	{
+		// obj.Nonce
+		i, err := d.Uint64()
+		if err != nil {
+			return 0, err
+		}
+		obj.Nonce = i
+	}
+
+	return uint64(len(buf) - len(d.Buffer)), nil
+}
+
+// decodeKeepalivePingMessageExact decodes an object of type KeepalivePingMessage from a buffer.
+// If the buffer not long enough to decode the object, returns encoder.ErrBufferUnderflow.
+// If the buffer is longer than required to decode the object, returns encoder.ErrRemainingBytes.
+func decodeKeepalivePingMessageExact(buf []byte, obj *KeepalivePingMessage) error {
+	if n, err := decodeKeepalivePingMessage(buf, obj); err != nil {
+		return err
+	} else if n != uint64(len(buf)) {
+		return encoder.ErrRemainingBytes
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// Code generated by github.com/skycoin/skyencoder. DO NOT EDIT.
+
+package daemon
+
+import "github.com/skycoin/skycoin/src/cipher/encoder"
+
+// encodeSizeKeepalivePongMessage computes the size of an encoded object of type KeepalivePongMessage
+func encodeSizeKeepalivePongMessage(obj *KeepalivePongMessage) uint64 {
+	i0 := uint64(0)
+
+	// obj.Nonce
+	i0 += 8
+
+	return i0
+}
+
+// encodeKeepalivePongMessage encodes an object of type KeepalivePongMessage to a buffer allocated to the exact size
+// required to encode the object.
+func encodeKeepalivePongMessage(obj *KeepalivePongMessage) ([]byte, error) {
+	n := encodeSizeKeepalivePongMessage(obj)
+	buf := make([]byte, n)
+
+	if err := encodeKeepalivePongMessageToBuffer(buf, obj); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// encodeKeepalivePongMessageToBuffer encodes an object of type KeepalivePongMessage to a []byte buffer.
+// The buffer must be large enough to encode the object, otherwise an error is returned.
+func encodeKeepalivePongMessageToBuffer(buf []byte, obj *KeepalivePongMessage) error {
+	if uint64(len(buf)) < encodeSizeKeepalivePongMessage(obj) {
+		return encoder.ErrBufferUnderflow
+	}
+
+	e := &encoder.Encoder{
+		Buffer: buf[:],
+	}
+
+	// obj.Nonce
+	e.Uint64(obj.Nonce)
+
+	return nil
+}
+
+// decodeKeepalivePongMessage decodes an object of type KeepalivePongMessage from a buffer.
+// Returns the number of bytes used from the buffer to decode the object.
+// If the buffer not long enough to decode the object, returns encoder.ErrBufferUnderflow.
+func decodeKeepalivePongMessage(buf []byte, obj *KeepalivePongMessage) (uint64, error) {
+	d := &encoder.Decoder{
+		Buffer: buf[:],
+	}
+
+	{
+		// obj.Nonce
+		i, err := d.Uint64()
+		if err != nil {
+			return 0, err
+		}
+		obj.Nonce = i
+	}
+
+	return uint64(len(buf) - len(d.Buffer)), nil
+}
+
+// decodeKeepalivePongMessageExact decodes an object of type KeepalivePongMessage from a buffer.
+// If the buffer not long enough to decode the object, returns encoder.ErrBufferUnderflow.
+// If the buffer is longer than required to decode the object, returns encoder.ErrRemainingBytes.
+func decodeKeepalivePongMessageExact(buf []byte, obj *KeepalivePongMessage) error {
+	if n, err := decodeKeepalivePongMessage(buf, obj); err != nil {
+		return err
+	} else if n != uint64(len(buf)) {
+		return encoder.ErrRemainingBytes
+	}
+
+	return nil
+}
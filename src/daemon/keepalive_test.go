@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckKeepalive(t *testing.T) {
+	interval := time.Second * 30
+	timeout := time.Second * 10
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var c ConnectionDetails
+
+	// Not idle long enough
+	require.Equal(t, keepaliveNone, c.checkKeepalive(start.Add(time.Second*29), start, interval, timeout, 2))
+
+	// Idle, a ping is sent
+	sentAt := start.Add(time.Second * 30)
+	require.Equal(t, keepalivePing, c.checkKeepalive(sentAt, start, interval, timeout, 2))
+	require.Equal(t, uint64(1), c.keepalive.nonce)
+	require.True(t, c.keepalive.pending)
+
+	// Waiting for the pong
+	require.Equal(t, keepaliveNone, c.checkKeepalive(sentAt.Add(time.Second*9), start, interval, timeout, 2))
+
+	// A pong with another nonce is ignored
+	require.False(t, c.keepalivePong(sentAt.Add(time.Second), 2))
+	require.True(t, c.keepalive.pending)
+
+	// The pong records the round trip time
+	require.True(t, c.keepalivePong(sentAt.Add(time.Millisecond*150), 1))
+	require.False(t, c.keepalive.pending)
+	require.Equal(t, time.Millisecond*150, c.RTT)
+	require.Equal(t, 0, c.MissedPongs)
+
+	// A second pong with the same nonce is ignored
+	require.False(t, c.keepalivePong(sentAt.Add(time.Second), 1))
+	require.Equal(t, time.Millisecond*150, c.RTT)
+
+	// The pong was received, the next ping is sent after another interval
+	lastReceived := sentAt.Add(time.Millisecond * 150)
+	require.Equal(t, keepaliveNone, c.checkKeepalive(lastReceived.Add(time.Second*29), lastReceived, interval, timeout, 2))
+
+	sentAt = lastReceived.Add(interval)
+	require.Equal(t, keepalivePing, c.checkKeepalive(sentAt, lastReceived, interval, timeout, 2))
+	require.Equal(t, uint64(2), c.keepalive.nonce)
+
+	// The ping is missed, another ping is sent right away since the connection is still idle
+	sentAt = sentAt.Add(timeout)
+	require.Equal(t, keepalivePing, c.checkKeepalive(sentAt, lastReceived, interval, timeout, 2))
+	require.Equal(t, 1, c.MissedPongs)
+	require.Equal(t, uint64(3), c.keepalive.nonce)
+
+	// The peer sent something else after the ping, so the ping is not missed
+	lastReceived = sentAt.Add(time.Second)
+	require.Equal(t, keepaliveNone, c.checkKeepalive(sentAt.Add(timeout), lastReceived, interval, timeout, 2))
+	require.Equal(t, 0, c.MissedPongs)
+	require.False(t, c.keepalive.pending)
+
+	// Two pings are missed in a row, the peer is disconnected
+	sentAt = lastReceived.Add(interval)
+	require.Equal(t, keepalivePing, c.checkKeepalive(sentAt, lastReceived, interval, timeout, 2))
+	sentAt = sentAt.Add(timeout)
+	require.Equal(t, keepalivePing, c.checkKeepalive(sentAt, lastReceived, interval, timeout, 2))
+	require.Equal(t, 1, c.MissedPongs)
+	require.Equal(t, keepaliveDisconnect, c.checkKeepalive(sentAt.Add(timeout), lastReceived, interval, timeout, 2))
+	require.Equal(t, 2, c.MissedPongs)
+}
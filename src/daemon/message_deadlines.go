@@ -0,0 +1,143 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ness-network/privateness/src/daemon/gnet"
+)
+
+var promMessageHandlerDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "daemon_message_handler_duration_seconds",
+		Help:    "Time spent processing messages received from peers, by message type",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+	}, []string{"message"})
+
+func init() {
+	prometheus.MustRegister(promMessageHandlerDuration)
+}
+
+// messageHandlerTimeout returns the processing deadline configured for a message type
+func (c DaemonConfig) messageHandlerTimeout(prefix gnet.MessagePrefix) time.Duration {
+	if t, ok := c.MessageHandlerTimeouts[prefix]; ok {
+		return t
+	}
+	return c.MessageHandlerTimeout
+}
+
+// ParseMessageHandlerTimeouts parses a comma separated list of message ID=duration pairs, e.g. "GIVB=30s,GIVT=10s"
+func ParseMessageHandlerTimeouts(s string) (map[gnet.MessagePrefix]time.Duration, error) {
+	timeouts := make(map[gnet.MessagePrefix]time.Duration)
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return timeouts, nil
+	}
+
+	known := make(map[string]struct{})
+	for _, mc := range getMessageConfigs() {
+		known[strings.TrimRight(string(mc.Prefix[:]), "\x00")] = struct{}{}
+	}
+
+	for _, p := range strings.Split(s, ",") {
+		pts := strings.Split(p, "=")
+		if len(pts) != 2 {
+			return nil, fmt.Errorf("invalid message deadline %q, must be ID=duration", p)
+		}
+
+		id := strings.TrimSpace(pts[0])
+		if _, ok := known[id]; !ok {
+			return nil, fmt.Errorf("invalid message deadline %q, unknown message ID %q", p, id)
+		}
+
+		prefix := gnet.MessagePrefixFromString(id)
+		if _, ok := timeouts[prefix]; ok {
+			return nil, fmt.Errorf("duplicate message deadline %q", id)
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(pts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid message deadline %q: %v", p, err)
+		}
+		if timeout < 0 {
+			return nil, fmt.Errorf("invalid message deadline %q, must be >= 0", p)
+		}
+
+		timeouts[prefix] = timeout
+	}
+
+	return timeouts, nil
+}
+
+// incrSlowMessageHandlers increments the slow message counter of a connection and returns the new value
+func (c *Connections) incrSlowMessageHandlers(addr string, gnetID uint64) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	var n int
+	err := c.modify(addr, gnetID, func(c *ConnectionDetails) {
+		c.SlowMessageHandlers++
+		n = c.SlowMessageHandlers
+	})
+
+	return n, err
+}
+
+// processMessageEvent processes a message under the deadline configured for its type.
+// The deadline is available to the message's process() in its gnet.MessageContext.
+// Messages that take longer are logged, and the peer is disconnected once MaxSlowMessageHandlers
+// of its messages exceeded their deadline.
+func (dm *Daemon) processMessageEvent(e messageEvent) {
+	prefix := gnet.MessageIDMap[reflect.TypeOf(e.Message).Elem()]
+	timeout := dm.config.messageHandlerTimeout(prefix)
+
+	ctx := context.Background()
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	e.Context.Context = ctx
+
+	start := time.Now()
+	e.Message.process(dm)
+	elapsed := time.Since(start)
+
+	name := strings.TrimRight(string(prefix[:]), "\x00")
+	promMessageHandlerDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+
+	if timeout == 0 || elapsed <= timeout {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":    e.Context.Addr,
+		"gnetID":  e.Context.ConnID,
+		"msgID":   name,
+		"elapsed": elapsed,
+		"timeout": timeout,
+	}
+
+	// The connection may have been removed while the message was processed
+	n, err := dm.connections.incrSlowMessageHandlers(e.Context.Addr, e.Context.ConnID)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Message processing exceeded its deadline")
+		return
+	}
+
+	fields["slowHandlers"] = n
+	logger.WithFields(fields).Warning("Message processing exceeded its deadline")
+
+	if dm.config.MaxSlowMessageHandlers != 0 && n >= dm.config.MaxSlowMessageHandlers {
+		if err := dm.Disconnect(e.Context.Addr, ErrDisconnectSlowMessageHandler); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Disconnect")
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/wire"
+)
+
+const slowMessagePrefix = "SLOW"
+
+// slowMessage is processed until its deadline is exceeded
+type slowMessage struct {
+	c *gnet.MessageContext `enc:"-"`
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *slowMessage) EncodeSize() uint64 {
+	return 0
+}
+
+// Encode implements gnet.Serializer
+func (m *slowMessage) Encode(buf []byte) error {
+	return nil
+}
+
+// Decode implements gnet.Serializer
+func (m *slowMessage) Decode(buf []byte) (uint64, error) {
+	return 0, nil
+}
+
+// Handle implements the Messager interface
+func (m *slowMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+func (m *slowMessage) process(d daemoner) {
+	<-m.c.Context.Done()
+	time.Sleep(time.Millisecond * 5)
+}
+
+func TestParseMessageHandlerTimeouts(t *testing.T) {
+	cases := []struct {
+		name   string
+		s      string
+		expect map[gnet.MessagePrefix]time.Duration
+		err    string
+	}{
+		{
+			name:   "empty",
+			s:      " ",
+			expect: map[gnet.MessagePrefix]time.Duration{},
+		},
+		{
+			name: "valid",
+			s:    "GIVB=2m, GIVT=30s,PING=0",
+			expect: map[gnet.MessagePrefix]time.Duration{
+				gnet.MessagePrefixFromString("GIVB"): time.Minute * 2,
+				gnet.MessagePrefixFromString("GIVT"): time.Second * 30,
+				gnet.MessagePrefixFromString("PING"): 0,
+			},
+		},
+		{
+			name: "missing duration",
+			s:    "GIVB",
+			err:  `invalid message deadline "GIVB", must be ID=duration`,
+		},
+		{
+			name: "unknown message",
+			s:    "GIVB=1s,FOOB=1s",
+			err:  `invalid message deadline "FOOB=1s", unknown message ID "FOOB"`,
+		},
+		{
+			name: "duplicate",
+			s:    "GIVB=1s,GIVB=2s",
+			err:  `duplicate message deadline "GIVB"`,
+		},
+		{
+			name: "invalid duration",
+			s:    "GIVB=1",
+			err:  `invalid message deadline "GIVB=1": time: missing unit in duration "1"`,
+		},
+		{
+			name: "negative duration",
+			s:    "GIVB=-1s",
+			err:  `invalid message deadline "GIVB=-1s", must be >= 0`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeouts, err := ParseMessageHandlerTimeouts(tc.s)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, timeouts)
+		})
+	}
+}
+
+func TestDaemonConfigMessageHandlerTimeout(t *testing.T) {
+	c := NewDaemonConfig()
+	c.MessageHandlerTimeouts = map[gnet.MessagePrefix]time.Duration{
+		gnet.MessagePrefixFromString("GIVB"): time.Second,
+		gnet.MessagePrefixFromString("PING"): 0,
+	}
+
+	require.Equal(t, time.Second, c.messageHandlerTimeout(gnet.MessagePrefixFromString("GIVB")))
+	require.Equal(t, time.Duration(0), c.messageHandlerTimeout(gnet.MessagePrefixFromString("PING")))
+	require.Equal(t, time.Minute, c.messageHandlerTimeout(gnet.MessagePrefixFromString("GIVT")))
+}
+
+func messageHandlerDurations(t *testing.T, msgID string) uint64 {
+	var m dto.Metric
+	require.NoError(t, promMessageHandlerDuration.WithLabelValues(msgID).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+// readMessage reads messages sent by the daemon until one with the given prefix arrives, and returns its body
+func readMessage(t *testing.T, conn net.Conn, prefix string) []byte {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second*10)))
+	for {
+		var size [4]byte
+		_, err := io.ReadFull(conn, size[:])
+		require.NoError(t, err)
+
+		msg := make([]byte, binary.LittleEndian.Uint32(size[:]))
+		_, err = io.ReadFull(conn, msg)
+		require.NoError(t, err)
+
+		if string(msg[:4]) == prefix {
+			return msg[4:]
+		}
+	}
+}
+
+func TestProcessMessageEventDeadline(t *testing.T) {
+	gnet.EraseMessages()
+	defer gnet.EraseMessages()
+	gnet.RegisterMessage(gnet.MessagePrefixFromString(slowMessagePrefix), slowMessage{})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	conns := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			conns <- c
+		}
+	}()
+
+	addr := l.Addr().String()
+	h := newAlwaysConnectHarness(t, []string{addr}, func(cfg *Config) {
+		cfg.Daemon.MessageHandlerTimeout = time.Second * 10
+		cfg.Daemon.MessageHandlerTimeouts = map[gnet.MessagePrefix]time.Duration{
+			gnet.MessagePrefixFromString(slowMessagePrefix): time.Millisecond * 20,
+		}
+		cfg.Daemon.MaxSlowMessageHandlers = 2
+	})
+	defer h.shutdown()
+
+	var conn net.Conn
+	select {
+	case conn = <-conns:
+	case <-time.After(time.Second * 10):
+		t.Fatal("Timed out waiting for the daemon to connect")
+	}
+	defer conn.Close()
+
+	var gnetID uint64
+	waitForCondition(t, "the peer to connect", func() bool {
+		c := h.dm.connections.get(addr)
+		if c == nil || c.State != ConnectionStateConnected {
+			return false
+		}
+		gnetID = c.gnetID
+		return true
+	})
+
+	// Skip the introduction, which is not what is tested here
+	_, portStr, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	require.NoError(t, err)
+	_, err = h.dm.connections.introduced(addr, gnetID, &IntroductionMessage{
+		Mirror:     h.dm.config.Mirror + 1,
+		ListenPort: uint16(port),
+	})
+	require.NoError(t, err)
+
+	send := func(m gnet.Message) {
+		b, err := gnet.EncodeMessage(m)
+		require.NoError(t, err)
+		_, err = conn.Write(b)
+		require.NoError(t, err)
+	}
+
+	slowHandlers := func() int {
+		c := h.dm.connections.get(addr)
+		if c == nil {
+			return -1
+		}
+		return c.SlowMessageHandlers
+	}
+
+	// A message processed within its deadline is not counted
+	pings := messageHandlerDurations(t, "PING")
+	send(&PingMessage{})
+	readMessage(t, conn, "PONG")
+	waitForCondition(t, "the ping to be observed", func() bool {
+		return messageHandlerDurations(t, "PING") == pings+1
+	})
+	require.Equal(t, 0, slowHandlers())
+
+	// The slow message only returns once the deadline set by the daemon has passed
+	slows := messageHandlerDurations(t, slowMessagePrefix)
+	send(&slowMessage{})
+	waitForCondition(t, "the slow message to be counted", func() bool {
+		return slowHandlers() == 1
+	})
+	require.Equal(t, slows+1, messageHandlerDurations(t, slowMessagePrefix))
+
+	// The peer is disconnected once MaxSlowMessageHandlers messages were slow
+	send(&slowMessage{})
+	var disc DisconnectMessage
+	_, err = disc.Decode(readMessage(t, conn, "DISC"))
+	require.NoError(t, err)
+	require.Equal(t, uint16(wire.DisconnectSlowMessageHandler), disc.ReasonCode)
+}
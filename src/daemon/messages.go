@@ -12,11 +12,14 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon/gnet"
-	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/iputil"
 	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/daemon/wire"
+	"github.com/ness-network/privateness/src/visor"
 )
 
 // Message represent a packet to be serialized over the network by
@@ -53,6 +56,8 @@ func NewMessageConfig(prefix string, m interface{}) MessageConfig {
 //go:generate skyencoder -unexported -struct GiveTxnsMessage
 //go:generate skyencoder -unexported -struct AnnounceTxnsMessage
 //go:generate skyencoder -unexported -struct DisconnectMessage
+//go:generate skyencoder -unexported -struct KeepalivePingMessage
+//go:generate skyencoder -unexported -struct KeepalivePongMessage
 //go:generate skyencoder -unexported -struct IPAddr
 //go:generate skyencoder -unexported -output-path . -package daemon -struct SignedBlock github.com/skycoin/skycoin/src/coin
 //go:generate skyencoder -unexported -output-path . -package daemon -struct Transaction github.com/skycoin/skycoin/src/coin
@@ -60,18 +65,20 @@ func NewMessageConfig(prefix string, m interface{}) MessageConfig {
 // Creates and populates the message configs
 func getMessageConfigs() []MessageConfig {
 	return []MessageConfig{
-		NewMessageConfig("INTR", IntroductionMessage{}),
-		NewMessageConfig("GETP", GetPeersMessage{}),
-		NewMessageConfig("GIVP", GivePeersMessage{}),
-		NewMessageConfig("PING", PingMessage{}),
-		NewMessageConfig("PONG", PongMessage{}),
-		NewMessageConfig("GETB", GetBlocksMessage{}),
-		NewMessageConfig("GIVB", GiveBlocksMessage{}),
-		NewMessageConfig("ANNB", AnnounceBlocksMessage{}),
-		NewMessageConfig("GETT", GetTxnsMessage{}),
-		NewMessageConfig("GIVT", GiveTxnsMessage{}),
-		NewMessageConfig("ANNT", AnnounceTxnsMessage{}),
-		NewMessageConfig("DISC", DisconnectMessage{}),
+		NewMessageConfig(wire.IDIntroduction, IntroductionMessage{}),
+		NewMessageConfig(wire.IDGetPeers, GetPeersMessage{}),
+		NewMessageConfig(wire.IDGivePeers, GivePeersMessage{}),
+		NewMessageConfig(wire.IDPing, PingMessage{}),
+		NewMessageConfig(wire.IDPong, PongMessage{}),
+		NewMessageConfig(wire.IDGetBlocks, GetBlocksMessage{}),
+		NewMessageConfig(wire.IDGiveBlocks, GiveBlocksMessage{}),
+		NewMessageConfig(wire.IDAnnounceBlocks, AnnounceBlocksMessage{}),
+		NewMessageConfig(wire.IDGetTxns, GetTxnsMessage{}),
+		NewMessageConfig(wire.IDGiveTxns, GiveTxnsMessage{}),
+		NewMessageConfig(wire.IDAnnounceTxns, AnnounceTxnsMessage{}),
+		NewMessageConfig(wire.IDDisconnect, DisconnectMessage{}),
+		NewMessageConfig(wire.IDKeepalivePing, KeepalivePingMessage{}),
+		NewMessageConfig(wire.IDKeepalivePong, KeepalivePongMessage{}),
 	}
 }
 
@@ -329,12 +336,20 @@ type IntroductionMessage struct {
 	UserAgent            useragent.Data       `enc:"-"`
 	UnconfirmedVerifyTxn params.VerifyTxn     `enc:"-"`
 	GenesisHash          cipher.SHA256        `enc:"-"`
+	// MinProtocolVersion is the lowest protocol version supported by the peer, 0 if it was not advertised
+	MinProtocolVersion int32 `enc:"-"`
+	// Features are the optional protocol features supported by the peer
+	Features ProtocolFeatures `enc:"-"`
+	// NegotiatedProtocolVersion is the protocol version of the connection, set by Verify
+	NegotiatedProtocolVersion int32 `enc:"-"`
+	// NegotiatedFeatures are the optional protocol features supported by both peers, set by Verify
+	NegotiatedFeatures ProtocolFeatures `enc:"-"`
 
 	// Mirror is a random value generated on client startup that is used to identify self-connections
 	Mirror uint32
 	// ListenPort is the port that this client is listening on
 	ListenPort uint16
-	// Protocol version
+	// Protocol version, the highest protocol version supported by the peer
 	ProtocolVersion int32
 
 	// Extra is extra bytes added to the struct to accommodate multiple versions of this packet.
@@ -348,16 +363,32 @@ type IntroductionMessage struct {
 	// MaxDropletPrecision uint8 // maximum number of decimal places for announced txns
 	// UserAgent           string `enc:",maxlen=256"`
 	// GenesisHash         cipher.SHA256 // genesis block hash
+	// MinProtocolVersion  int32 // lowest supported protocol version
+	// Features            uint64 // optional protocol features, see ProtocolFeatures
 	Extra []byte `enc:",omitempty"`
 }
 
-// NewIntroductionMessage creates introduction message
-func NewIntroductionMessage(mirror uint32, version int32, port uint16, pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256) *IntroductionMessage {
+// NewIntroductionMessage creates introduction message.
+// The peer is told that protocol versions minVersion to version are supported, with the optional features.
+func NewIntroductionMessage(mirror uint32, version int32, port uint16, pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256, minVersion int32, features ProtocolFeatures) *IntroductionMessage {
+	if minVersion > version {
+		logger.WithFields(logrus.Fields{
+			"minProtocolVersion": minVersion,
+			"protocolVersion":    version,
+		}).Panic("min protocol version exceeds protocol version")
+	}
+
+	extra := newIntroductionMessageExtra(pubkey, userAgent, verifyParams, genesisHash)
+	extra = append(extra, encoder.Serialize(wire.IntroductionProtocolExtra{
+		MinProtocolVersion: minVersion,
+		Features:           uint64(features),
+	})...)
+
 	return &IntroductionMessage{
 		Mirror:          mirror,
 		ProtocolVersion: version,
 		ListenPort:      port,
-		Extra:           newIntroductionMessageExtra(pubkey, userAgent, verifyParams, genesisHash),
+		Extra:           extra,
 	}
 }
 
@@ -377,20 +408,7 @@ func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyP
 		logger.Panic(err)
 	}
 
-	userAgentSerialized := encoder.SerializeString(userAgent)
-	verifyParamsSerialized := encoder.Serialize(verifyParams)
-
-	extra := make([]byte, len(pubkey)+len(userAgentSerialized)+len(verifyParamsSerialized)+len(genesisHash))
-
-	copy(extra[:len(pubkey)], pubkey[:])
-	i := len(pubkey)
-	copy(extra[i:], verifyParamsSerialized)
-	i += len(verifyParamsSerialized)
-	copy(extra[i:], userAgentSerialized)
-	i += len(userAgentSerialized)
-	copy(extra[i:i+len(genesisHash)], genesisHash[:])
-
-	return extra
+	return wire.EncodeIntroductionExtra(pubkey, userAgent, verifyParams, genesisHash)
 }
 
 // EncodeSize implements gnet.Serializer
@@ -491,7 +509,8 @@ func (intro *IntroductionMessage) Verify(dc DaemonConfig, logFields logrus.Field
 		return ErrDisconnectSelf
 	}
 
-	// Disconnect if peer version is not within the supported range
+	// Disconnect if peer version is not within the supported range.
+	// The peer's lowest supported version is checked once the extra data is parsed
 	if intro.ProtocolVersion < dc.MinProtocolVersion {
 		logger.WithFields(logFields).WithFields(logrus.Fields{
 			"protocolVersion":    intro.ProtocolVersion,
@@ -579,6 +598,56 @@ func (intro *IntroductionMessage) Verify(dc DaemonConfig, logFields logrus.Field
 		return ErrDisconnectInvalidExtraData
 	}
 	copy(intro.GenesisHash[:], intro.Extra[i:])
+	if remainingLen > 0 {
+		i += len(intro.GenesisHash)
+	}
+
+	// The supported protocol version range and features follow the genesis hash.
+	// Any data after them is ignored, for later versions of this message
+	remainingLen = extraLen - i
+	if remainingLen > 0 {
+		if remainingLen < wire.IntroductionProtocolExtraLen {
+			logger.WithFields(logFields).Warning("Extra data protocol version range could not be deserialized: not enough data")
+			return ErrDisconnectInvalidExtraData
+		}
+
+		var p wire.IntroductionProtocolExtra
+		if err := encoder.DeserializeRawExact(intro.Extra[i:i+wire.IntroductionProtocolExtraLen], &p); err != nil {
+			// This should not occur due to the previous length check
+			logger.Critical().WithError(err).WithFields(logFields).Warning("Protocol version range could not be deserialized")
+			return ErrDisconnectInvalidExtraData
+		}
+
+		if p.MinProtocolVersion > intro.ProtocolVersion {
+			logger.WithFields(logFields).WithFields(logrus.Fields{
+				"protocolVersion":    intro.ProtocolVersion,
+				"minProtocolVersion": p.MinProtocolVersion,
+			}).Warning("Peer min protocol version exceeds its protocol version")
+			return ErrDisconnectInvalidExtraData
+		}
+
+		intro.MinProtocolVersion = p.MinProtocolVersion
+		intro.Features = ProtocolFeatures(p.Features)
+	}
+
+	version, ok := negotiateProtocolVersion(dc.MinProtocolVersion, dc.ProtocolVersion, intro.MinProtocolVersion, intro.ProtocolVersion)
+	if !ok {
+		logger.WithFields(logFields).WithFields(logrus.Fields{
+			"protocolVersion":       intro.ProtocolVersion,
+			"minProtocolVersion":    intro.MinProtocolVersion,
+			"ourProtocolVersion":    dc.ProtocolVersion,
+			"ourMinProtocolVersion": dc.MinProtocolVersion,
+		}).Info("No protocol version supported by both peers")
+		return ErrDisconnectVersionNotSupported
+	}
+
+	intro.NegotiatedProtocolVersion = version
+	intro.NegotiatedFeatures = intro.Features & dc.ProtocolFeatures
+
+	logger.WithFields(logFields).WithFields(logrus.Fields{
+		"protocolVersion": version,
+		"features":        intro.NegotiatedFeatures.String(),
+	}).Debug("Negotiated protocol version")
 
 	return nil
 }
@@ -656,6 +725,98 @@ func (pong *PongMessage) Handle(mc *gnet.MessageContext, daemon interface{}) err
 	return nil
 }
 
+// KeepalivePingMessage is sent on an idle connection that negotiated ProtocolFeatureKeepalive.
+// A KeepalivePongMessage with the same nonce is sent in reply.
+type KeepalivePingMessage struct {
+	Nonce uint64
+	c     *gnet.MessageContext `enc:"-"`
+}
+
+// NewKeepalivePingMessage creates a KeepalivePingMessage
+func NewKeepalivePingMessage(nonce uint64) *KeepalivePingMessage {
+	return &KeepalivePingMessage{
+		Nonce: nonce,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (ping *KeepalivePingMessage) EncodeSize() uint64 {
+	return encodeSizeKeepalivePingMessage(ping)
+}
+
+// Encode implements gnet.Serializer
+func (ping *KeepalivePingMessage) Encode(buf []byte) error {
+	return encodeKeepalivePingMessageToBuffer(buf, ping)
+}
+
+// Decode implements gnet.Serializer
+func (ping *KeepalivePingMessage) Decode(buf []byte) (uint64, error) {
+	return decodeKeepalivePingMessage(buf, ping)
+}
+
+// Handle implements the Messager interface
+func (ping *KeepalivePingMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	ping.c = mc
+	return daemon.(daemoner).recordMessageEvent(ping, mc)
+}
+
+// process sends a KeepalivePongMessage to the sender of KeepalivePingMessage
+func (ping *KeepalivePingMessage) process(d daemoner) {
+	fields := logrus.Fields{
+		"addr":   ping.c.Addr,
+		"gnetID": ping.c.ConnID,
+	}
+
+	if d.DaemonConfig().LogPings {
+		logger.WithFields(fields).Debug("Replying to keepalive ping")
+	}
+	if err := d.sendMessage(ping.c.Addr, NewKeepalivePongMessage(ping.Nonce)); err != nil {
+		logger.WithFields(fields).WithError(err).Error("Send KeepalivePongMessage failed")
+	}
+}
+
+// KeepalivePongMessage is sent in reply to a KeepalivePingMessage, with the nonce of the ping
+type KeepalivePongMessage struct {
+	Nonce uint64
+}
+
+// NewKeepalivePongMessage creates a KeepalivePongMessage
+func NewKeepalivePongMessage(nonce uint64) *KeepalivePongMessage {
+	return &KeepalivePongMessage{
+		Nonce: nonce,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (pong *KeepalivePongMessage) EncodeSize() uint64 {
+	return encodeSizeKeepalivePongMessage(pong)
+}
+
+// Encode implements gnet.Serializer
+func (pong *KeepalivePongMessage) Encode(buf []byte) error {
+	return encodeKeepalivePongMessageToBuffer(buf, pong)
+}
+
+// Decode implements gnet.Serializer
+func (pong *KeepalivePongMessage) Decode(buf []byte) (uint64, error) {
+	return decodeKeepalivePongMessage(buf, pong)
+}
+
+// Handle handles message.
+// The pong is recorded here instead of in the daemon's run loop,
+// so that the round trip time doesn't include the time spent waiting for the run loop
+func (pong *KeepalivePongMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	d := daemon.(daemoner)
+	if d.DaemonConfig().LogPings {
+		logger.WithFields(logrus.Fields{
+			"addr":   mc.Addr,
+			"gnetID": mc.ConnID,
+		}).Debug("Received keepalive pong")
+	}
+	d.recordKeepalivePong(mc.Addr, mc.ConnID, pong.Nonce)
+	return nil
+}
+
 // DisconnectMessage sent to a peer before disconnecting, indicating the reason for disconnect
 type DisconnectMessage struct {
 	c      *gnet.MessageContext  `enc:"-"`
@@ -705,7 +866,7 @@ func (dm *DisconnectMessage) process(d daemoner) {
 		"gnetID": dm.c.ConnID,
 		"code":   dm.ReasonCode,
 		"reason": DisconnectCodeToReason(dm.ReasonCode),
-	}).Infof("DisconnectMessage received")
+	}).Info("DisconnectMessage received")
 
 	if err := d.disconnectNow(dm.c.Addr, ErrDisconnectReceivedDisconnect); err != nil {
 		logger.WithError(err).WithField("addr", dm.c.Addr).Warning("disconnectNow")
@@ -784,11 +945,18 @@ func (gbm *GetBlocksMessage) process(d daemoner) {
 		return
 	}
 
-	logger.WithFields(fields).Debugf("GetBlocksMessage: replying with %d blocks after block %d", len(blocks), gbm.LastBlock)
+	logger.WithFields(fields).WithFields(logrus.Fields{
+		"blocks":    len(blocks),
+		"lastBlock": gbm.LastBlock,
+	}).Debug("GetBlocksMessage: replying with blocks")
 
 	m := NewGiveBlocksMessage(blocks, dc.MaxOutgoingMessageLength)
 	if len(m.Blocks) != len(blocks) {
-		logger.WithField("startBlockSeq", blocks[0].Head.BkSeq).WithFields(fields).Warningf("NewGiveBlocksMessage truncated %d blocks to %d blocks", len(blocks), len(m.Blocks))
+		logger.WithFields(fields).WithFields(logrus.Fields{
+			"startBlockSeq": blocks[0].Head.BkSeq,
+			"blocks":        len(blocks),
+			"truncated":     len(m.Blocks),
+		}).Warning("NewGiveBlocksMessage truncated blocks")
 	}
 
 	if err := d.sendMessage(gbm.c.Addr, m); err != nil {
@@ -908,10 +1076,21 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 
 		err := d.executeSignedBlock(b)
 		if err == nil {
-			logger.Critical().WithField("seq", b.Block.Head.BkSeq).Info("Added new block")
+			logger.Critical().WithFields(logrus.Fields{
+				"seq":  b.Block.Head.BkSeq,
+				"hash": b.HashHeader().Hex(),
+				"txns": len(b.Block.Body.Transactions),
+				"addr": m.c.Addr,
+			}).Info("Added new block")
+			d.recordBlockSeen(m.c.Addr, b)
 			processed++
 		} else {
 			logger.Critical().WithError(err).WithField("seq", b.Block.Head.BkSeq).Error("Failed to execute received block")
+			// Only penalize the peer if this was the next block in the chain,
+			// otherwise it may have been sent out of order
+			if b.Seq() == maxSeq+uint64(processed)+1 {
+				d.scorePeer(m.c.Addr, pex.ScoreEventInvalidBlock)
+			}
 			// Blocks must be received in order, so if one fails its assumed
 			// the rest are failing
 			break
@@ -921,6 +1100,8 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	d.scorePeer(m.c.Addr, pex.ScoreEventBlocksAccepted)
+
 	headBkSeq, ok, err := d.headBkSeq()
 	if err != nil {
 		logger.WithError(err).Error("d.headBkSeq failed")
@@ -934,7 +1115,10 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 	if headBkSeq < maxSeq {
 		logger.Critical().Warning("HeadBkSeq decreased after executing blocks")
 	} else if headBkSeq-maxSeq != uint64(processed) {
-		logger.Critical().Warning("HeadBkSeq increased by %d but we processed %s blocks", headBkSeq-maxSeq, processed)
+		logger.Critical().WithFields(logrus.Fields{
+			"increase":  headBkSeq - maxSeq,
+			"processed": processed,
+		}).Warning("HeadBkSeq increase does not match the number of processed blocks")
 	}
 
 	// Announce our new blocks to peers
@@ -996,6 +1180,8 @@ func (abm *AnnounceBlocksMessage) process(d daemoner) {
 		"gnetID": abm.c.ConnID,
 	}
 
+	d.recordBlockAnnouncement(abm.c.Addr, abm.MaxBkSeq)
+
 	headBkSeq, ok, err := d.headBkSeq()
 	if err != nil {
 		logger.WithError(err).Error("AnnounceBlocksMessage d.headBkSeq failed")
@@ -1132,6 +1318,8 @@ func (atm *AnnounceTxnsMessage) process(d daemoner) {
 		"gnetID": atm.c.ConnID,
 	}
 
+	d.recordTxnAnnouncements(atm.c.Addr, atm.Transactions)
+
 	unknown, err := d.filterKnownUnconfirmed(atm.Transactions)
 	if err != nil {
 		logger.WithError(err).Error("AnnounceTxnsMessage d.filterKnownUnconfirmed failed")
@@ -1144,7 +1332,10 @@ func (atm *AnnounceTxnsMessage) process(d daemoner) {
 
 	m := NewGetTxnsMessage(unknown, dc.MaxOutgoingMessageLength)
 	if len(m.Transactions) != len(unknown) {
-		logger.Warningf("NewGetTxnsMessage truncated %d hashes to %d hashes", len(unknown), len(m.Transactions))
+		logger.WithFields(fields).WithFields(logrus.Fields{
+			"hashes":    len(unknown),
+			"truncated": len(m.Transactions),
+		}).Warning("NewGetTxnsMessage truncated hashes")
 	}
 
 	if err := d.sendMessage(atm.c.Addr, m); err != nil {
@@ -1253,7 +1444,10 @@ func (gtm *GetTxnsMessage) process(d daemoner) {
 	// Reply to sender with GiveTxnsMessage
 	m := NewGiveTxnsMessage(known, dc.MaxOutgoingMessageLength)
 	if len(m.Transactions) != len(known) {
-		logger.Warningf("NewGiveTxnsMessage truncated %d hashes to %d hashes", len(known), len(m.Transactions))
+		logger.WithFields(fields).WithFields(logrus.Fields{
+			"hashes":    len(known),
+			"truncated": len(m.Transactions),
+		}).Warning("NewGiveTxnsMessage truncated hashes")
 	}
 
 	if err := d.sendMessage(gtm.c.Addr, m); err != nil {
@@ -1351,6 +1545,12 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		return
 	}
 
+	received := make([]cipher.SHA256, len(gtm.Transactions))
+	for i, txn := range gtm.Transactions {
+		received[i] = txn.Hash()
+	}
+	d.recordTxnAnnouncements(gtm.c.Addr, received)
+
 	hashes := make([]cipher.SHA256, 0, len(gtm.Transactions))
 	// Update unconfirmed pool with these transactions
 	for _, txn := range gtm.Transactions {
@@ -1360,6 +1560,9 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		known, softErr, err := d.injectTransaction(txn)
 		if err != nil {
 			logger.WithError(err).WithField("txid", txn.Hash().Hex()).Warning("Failed to record transaction")
+			if _, ok := err.(visor.ErrTxnViolatesHardConstraint); ok {
+				d.scorePeer(gtm.c.Addr, pex.ScoreEventInvalidTxn)
+			}
 			continue
 		} else if softErr != nil {
 			logger.WithError(softErr).WithField("txid", txn.Hash().Hex()).Warning("Transaction soft violation")
@@ -1379,12 +1582,18 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 	// Announce these transactions to peers
 	m := NewAnnounceTxnsMessage(hashes, dc.MaxOutgoingMessageLength)
 	if len(m.Transactions) != len(hashes) {
-		logger.Warningf("NewAnnounceTxnsMessage truncated %d hashes to %d hashes", len(hashes), len(m.Transactions))
+		logger.WithFields(logrus.Fields{
+			"hashes":    len(hashes),
+			"truncated": len(m.Transactions),
+		}).Warning("NewAnnounceTxnsMessage truncated hashes")
 	}
 
 	if ids, err := d.broadcastMessage(m); err != nil {
 		logger.WithError(err).Warning("Broadcast AnnounceTxnsMessage failed")
 	} else {
-		logger.Debugf("Announced %d transactions to %d peers", len(hashes), len(ids))
+		logger.WithFields(logrus.Fields{
+			"txns":  len(hashes),
+			"peers": len(ids),
+		}).Debug("Announced transactions")
 	}
 }
@@ -12,11 +12,13 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon/gnet"
-	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/daemon/wire"
 )
 
 func TestIntroductionMessage(t *testing.T) {
@@ -34,6 +36,18 @@ func TestIntroductionMessage(t *testing.T) {
 	}, genesisHash)
 	invalidGenesisHashExtra = invalidGenesisHashExtra[:len(invalidGenesisHashExtra)-2]
 
+	extraWithProtocol := func(minVersion int32, features ProtocolFeatures) []byte {
+		extra := newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", params.VerifyTxn{
+			BurnFactor:          4,
+			MaxTransactionSize:  32768,
+			MaxDropletPrecision: 3,
+		}, genesisHash)
+		return append(extra, encoder.Serialize(wire.IntroductionProtocolExtra{
+			MinProtocolVersion: minVersion,
+			Features:           uint64(features),
+		})...)
+	}
+
 	type daemonMockValue struct {
 		protocolVersion          uint32
 		minProtocolVersion       uint32
@@ -57,6 +71,10 @@ func TestIntroductionMessage(t *testing.T) {
 		userAgent            useragent.Data
 		unconfirmedVerifyTxn params.VerifyTxn
 		intro                *IntroductionMessage
+		// Expected protocol negotiation of introduced connections
+		minProtocolVersion        int32
+		negotiatedProtocolVersion int32
+		features                  ProtocolFeatures
 	}{
 		{
 			name: "INTR message without extra bytes",
@@ -183,11 +201,93 @@ func TestIntroductionMessage(t *testing.T) {
 				Mirror:          10001,
 				ListenPort:      6000,
 				ProtocolVersion: 1,
-				Extra: append(newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", params.VerifyTxn{
-					BurnFactor:          4,
-					MaxTransactionSize:  32768,
-					MaxDropletPrecision: 3,
-				}, genesisHash), []byte("additional data")...),
+				Extra:           append(extraWithProtocol(1, 0), []byte("additional data")...),
+			},
+			minProtocolVersion: 1,
+		},
+		{
+			name: "INTR message with protocol version range and features",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:             10000,
+				protocolVersion:    3,
+				minProtocolVersion: 2,
+				pubkey:             pubkey,
+				connectionIntroduced: &connection{
+					Addr: "121.121.121.121:6000",
+					ConnectionDetails: ConnectionDetails{
+						ListenPort: 6000,
+					},
+				},
+			},
+			userAgent: useragent.Data{
+				Coin:    "skycoin",
+				Version: "0.26.0",
+			},
+			unconfirmedVerifyTxn: params.VerifyTxn{
+				BurnFactor:          4,
+				MaxTransactionSize:  32768,
+				MaxDropletPrecision: 3,
+			},
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 5,
+				Extra:           extraWithProtocol(3, 1|1<<2),
+			},
+			minProtocolVersion:        3,
+			negotiatedProtocolVersion: 3,
+			features:                  1 | 1<<2,
+		},
+		{
+			name: "INTR message with protocol version range above ours",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:             10000,
+				protocolVersion:    3,
+				minProtocolVersion: 2,
+				pubkey:             pubkey,
+				disconnectReason:   ErrDisconnectVersionNotSupported,
+			},
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 5,
+				Extra:           extraWithProtocol(4, 0),
+			},
+		},
+		{
+			name: "INTR message with min protocol version above its protocol version",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:             10000,
+				protocolVersion:    3,
+				minProtocolVersion: 2,
+				pubkey:             pubkey,
+				disconnectReason:   ErrDisconnectInvalidExtraData,
+			},
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 3,
+				Extra:           extraWithProtocol(4, 0),
+			},
+		},
+		{
+			name: "INTR message with truncated protocol version range",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:             10000,
+				protocolVersion:    3,
+				minProtocolVersion: 2,
+				pubkey:             pubkey,
+				disconnectReason:   ErrDisconnectInvalidExtraData,
+			},
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 3,
+				Extra:           extraWithProtocol(2, 0)[:len(extraWithProtocol(2, 0))-4],
 			},
 		},
 		{
@@ -532,6 +632,12 @@ func TestIntroductionMessage(t *testing.T) {
 			} else {
 				d.AssertNotCalled(t, "Disconnect", mock.Anything, mock.Anything)
 				require.Equal(t, genesisHash, tc.intro.GenesisHash)
+				require.Equal(t, tc.minProtocolVersion, tc.intro.MinProtocolVersion)
+				require.Equal(t, tc.features, tc.intro.Features)
+				require.Equal(t, ProtocolFeatures(0), tc.intro.NegotiatedFeatures)
+				if tc.negotiatedProtocolVersion != 0 {
+					require.Equal(t, tc.negotiatedProtocolVersion, tc.intro.NegotiatedProtocolVersion)
+				}
 			}
 		})
 	}
@@ -602,6 +708,20 @@ func TestMessageEncodeDecode(t *testing.T) {
 			obj:        &PongMessage{},
 			msg:        &PongMessage{},
 		},
+		{
+			goldenFile: "keepalive-ping-msg.golden",
+			obj:        &KeepalivePingMessage{},
+			msg: &KeepalivePingMessage{
+				Nonce: 12345678,
+			},
+		},
+		{
+			goldenFile: "keepalive-pong-msg.golden",
+			obj:        &KeepalivePongMessage{},
+			msg: &KeepalivePongMessage{
+				Nonce: 12345678,
+			},
+		},
 		{
 			goldenFile: "get-blocks-msg.golden",
 			obj:        &GetBlocksMessage{},
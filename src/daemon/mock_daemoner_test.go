@@ -6,13 +6,13 @@ import (
 	cipher "github.com/skycoin/skycoin/src/cipher"
 	coin "github.com/skycoin/skycoin/src/coin"
 
-	gnet "github.com/skycoin/skycoin/src/daemon/gnet"
+	gnet "github.com/ness-network/privateness/src/daemon/gnet"
 
 	mock "github.com/stretchr/testify/mock"
 
-	pex "github.com/skycoin/skycoin/src/daemon/pex"
+	pex "github.com/ness-network/privateness/src/daemon/pex"
 
-	visor "github.com/skycoin/skycoin/src/visor"
+	visor "github.com/ness-network/privateness/src/visor"
 )
 
 // mockDaemoner is an autogenerated mock type for the daemoner type
@@ -291,6 +291,21 @@ func (_m *mockDaemoner) pexConfig() pex.Config {
 	return r0
 }
 
+// recordBlockAnnouncement provides a mock function with given fields: addr, seq
+func (_m *mockDaemoner) recordBlockAnnouncement(addr string, seq uint64) {
+	_m.Called(addr, seq)
+}
+
+// recordBlockSeen provides a mock function with given fields: addr, b
+func (_m *mockDaemoner) recordBlockSeen(addr string, b coin.SignedBlock) {
+	_m.Called(addr, b)
+}
+
+// recordKeepalivePong provides a mock function with given fields: addr, gnetID, nonce
+func (_m *mockDaemoner) recordKeepalivePong(addr string, gnetID uint64, nonce uint64) {
+	_m.Called(addr, gnetID, nonce)
+}
+
 // recordMessageEvent provides a mock function with given fields: m, c
 func (_m *mockDaemoner) recordMessageEvent(m asyncMessage, c *gnet.MessageContext) error {
 	ret := _m.Called(m, c)
@@ -310,6 +325,11 @@ func (_m *mockDaemoner) recordPeerHeight(addr string, gnetID uint64, height uint
 	_m.Called(addr, gnetID, height)
 }
 
+// recordTxnAnnouncements provides a mock function with given fields: addr, txns
+func (_m *mockDaemoner) recordTxnAnnouncements(addr string, txns []cipher.SHA256) {
+	_m.Called(addr, txns)
+}
+
 // requestBlocksFromAddr provides a mock function with given fields: addr
 func (_m *mockDaemoner) requestBlocksFromAddr(addr string) error {
 	ret := _m.Called(addr)
@@ -324,6 +344,11 @@ func (_m *mockDaemoner) requestBlocksFromAddr(addr string) error {
 	return r0
 }
 
+// scorePeer provides a mock function with given fields: addr, ev
+func (_m *mockDaemoner) scorePeer(addr string, ev pex.ScoreEvent) {
+	_m.Called(addr, ev)
+}
+
 // sendMessage provides a mock function with given fields: addr, msg
 func (_m *mockDaemoner) sendMessage(addr string, msg gnet.Message) error {
 	ret := _m.Called(addr, msg)
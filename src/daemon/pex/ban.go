@@ -0,0 +1,450 @@
+package pex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/util/file"
+)
+
+/*
+Peers that repeatedly send invalid objects are banned by IP.
+
+Each offense, i.e. a transaction that violates hard constraints, an invalid block or a malformed message,
+is counted for the IP of the peer that sent it. Transactions that only violate soft constraints are not offenses.
+The counters decay by half every BanConfig.Window, so that only recent offenses count.
+When the offenses of an IP exceed BanConfig.Threshold, the IP is banned for BanConfig.Duration:
+its peers are removed from the peer list and can't be added again until the ban expires or is lifted.
+The bans are persisted with the peer list.
+*/
+
+// BanCacheFilename filename for disk-cached bans
+const BanCacheFilename = "bans.json"
+
+// minOffenses is the decayed number of offenses below which an offense counter is dropped
+const minOffenses = 0.01
+
+var (
+	// ErrNotBanned is returned when lifting the ban of an IP that is not banned
+	ErrNotBanned = errors.New("IP is not banned")
+)
+
+// BanConfig configures the banning of peers that repeatedly send invalid objects
+type BanConfig struct {
+	// Threshold is the number of offenses that must be exceeded for an IP to be banned. 0 disables banning
+	Threshold int `json:"threshold"`
+	// Window is the half-life of the offense counters
+	Window time.Duration `json:"window"`
+	// Duration is how long an IP is banned for
+	Duration time.Duration `json:"duration"`
+}
+
+// NewBanConfig returns the default BanConfig
+func NewBanConfig() BanConfig {
+	return BanConfig{
+		Threshold: 20,
+		Window:    time.Hour,
+		Duration:  time.Hour * 24,
+	}
+}
+
+// Verify checks that the BanConfig is valid
+func (c BanConfig) Verify() error {
+	if c.Threshold < 0 {
+		return errors.New("ban threshold must not be negative")
+	}
+	if c.Threshold == 0 {
+		return nil
+	}
+	if c.Window <= 0 {
+		return errors.New("ban window must be positive")
+	}
+	if c.Duration <= 0 {
+		return errors.New("ban duration must be positive")
+	}
+	return nil
+}
+
+// IsOffense returns true if the event counts toward banning the peer's IP
+func (ev ScoreEvent) IsOffense() bool {
+	switch ev {
+	case ScoreEventInvalidTxn, ScoreEventInvalidBlock, ScoreEventProtocolViolation:
+		return true
+	default:
+		return false
+	}
+}
+
+// OffenseCounter counts the recent offenses of an IP. The counts decay over time.
+type OffenseCounter struct {
+	IP                 string  `json:"ip"`
+	InvalidTxns        float64 `json:"invalid_txns"`
+	InvalidBlocks      float64 `json:"invalid_blocks"`
+	ProtocolViolations float64 `json:"protocol_violations"`
+	// UpdatedAt is the unix timestamp when the counts were last decayed
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// Total returns the number of offenses
+func (c OffenseCounter) Total() float64 {
+	return c.InvalidTxns + c.InvalidBlocks + c.ProtocolViolations
+}
+
+// decay decays the counts from UpdatedAt to now, halving them every window
+func (c *OffenseCounter) decay(now time.Time, window time.Duration) {
+	elapsed := now.Sub(time.Unix(c.UpdatedAt, 0))
+	c.UpdatedAt = now.Unix()
+	if elapsed <= 0 || window <= 0 {
+		return
+	}
+
+	f := math.Pow(0.5, float64(elapsed)/float64(window))
+	c.InvalidTxns *= f
+	c.InvalidBlocks *= f
+	c.ProtocolViolations *= f
+}
+
+func (c *OffenseCounter) add(ev ScoreEvent) {
+	switch ev {
+	case ScoreEventInvalidTxn:
+		c.InvalidTxns++
+	case ScoreEventInvalidBlock:
+		c.InvalidBlocks++
+	case ScoreEventProtocolViolation:
+		c.ProtocolViolations++
+	}
+}
+
+// Ban is a banned IP
+type Ban struct {
+	IP     string `json:"ip"`
+	Reason string `json:"reason"`
+	// BannedAt is the unix timestamp of the ban
+	BannedAt int64 `json:"banned_at"`
+	// ExpiresAt is the unix timestamp when the ban expires
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// expired returns true if the ban has expired at now
+func (b Ban) expired(now time.Time) bool {
+	return now.Unix() >= b.ExpiresAt
+}
+
+// BanReport is the state of the peer bans
+type BanReport struct {
+	Config   BanConfig
+	Counters []OffenseCounter
+	Bans     []Ban
+}
+
+// banlist tracks the offense counters and bans of IPs
+type banlist struct {
+	config   BanConfig
+	counters map[string]*OffenseCounter
+	bans     map[string]Ban
+}
+
+func newBanlist(cfg BanConfig) banlist {
+	return banlist{
+		config:   cfg,
+		counters: make(map[string]*OffenseCounter),
+		bans:     make(map[string]Ban),
+	}
+}
+
+// recordOffense counts an offense of an IP.
+// Returns the new ban if the offense banned the IP.
+func (bl *banlist) recordOffense(ip string, ev ScoreEvent, now time.Time) *Ban {
+	if !ev.IsOffense() {
+		return nil
+	}
+
+	if b, ok := bl.bans[ip]; ok && !b.expired(now) {
+		return nil
+	}
+
+	c, ok := bl.counters[ip]
+	if !ok {
+		c = &OffenseCounter{
+			IP:        ip,
+			UpdatedAt: now.Unix(),
+		}
+		bl.counters[ip] = c
+	}
+
+	c.decay(now, bl.config.Window)
+	c.add(ev)
+
+	if bl.config.Threshold == 0 || c.Total() <= float64(bl.config.Threshold) {
+		return nil
+	}
+
+	b := Ban{
+		IP:        ip,
+		Reason:    fmt.Sprintf("%.1f invalid transactions, %.1f invalid blocks and %.1f protocol violations", c.InvalidTxns, c.InvalidBlocks, c.ProtocolViolations),
+		BannedAt:  now.Unix(),
+		ExpiresAt: now.Add(bl.config.Duration).Unix(),
+	}
+	bl.bans[ip] = b
+	delete(bl.counters, ip)
+
+	return &b
+}
+
+// isBanned returns true if the IP has a ban that has not expired at now
+func (bl *banlist) isBanned(ip string, now time.Time) bool {
+	b, ok := bl.bans[ip]
+	return ok && !b.expired(now)
+}
+
+// unban lifts the ban of an IP and resets its offenses
+func (bl *banlist) unban(ip string, now time.Time) error {
+	if !bl.isBanned(ip, now) {
+		return ErrNotBanned
+	}
+	delete(bl.bans, ip)
+	delete(bl.counters, ip)
+	return nil
+}
+
+// clearExpired removes the expired bans and the counters whose offenses have decayed away
+func (bl *banlist) clearExpired(now time.Time) {
+	for ip, b := range bl.bans {
+		if b.expired(now) {
+			logger.WithField("ip", ip).Info("Peer ban expired")
+			delete(bl.bans, ip)
+		}
+	}
+
+	for ip, c := range bl.counters {
+		c.decay(now, bl.config.Window)
+		if c.Total() < minOffenses {
+			delete(bl.counters, ip)
+		}
+	}
+}
+
+// report returns the decayed counters and the active bans at now, sorted by IP
+func (bl *banlist) report(now time.Time) BanReport {
+	r := BanReport{
+		Config:   bl.config,
+		Counters: make([]OffenseCounter, 0, len(bl.counters)),
+		Bans:     make([]Ban, 0, len(bl.bans)),
+	}
+
+	for _, c := range bl.counters {
+		c.decay(now, bl.config.Window)
+		r.Counters = append(r.Counters, *c)
+	}
+
+	for _, b := range bl.bans {
+		if !b.expired(now) {
+			r.Bans = append(r.Bans, b)
+		}
+	}
+
+	sort.Slice(r.Counters, func(i, j int) bool {
+		return r.Counters[i].IP < r.Counters[j].IP
+	})
+	sort.Slice(r.Bans, func(i, j int) bool {
+		return r.Bans[i].IP < r.Bans[j].IP
+	})
+
+	return r
+}
+
+// setBans sets the bans loaded from disk, skipping the expired bans
+func (bl *banlist) setBans(bans []Ban, now time.Time) {
+	for _, b := range bans {
+		if b.expired(now) {
+			continue
+		}
+		bl.bans[b.IP] = b
+	}
+}
+
+// save persists the active bans
+func (bl *banlist) save(fn string, now time.Time) error {
+	bans := make([]Ban, 0, len(bl.bans))
+	for _, b := range bl.bans {
+		if !b.expired(now) {
+			bans = append(bans, b)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].IP < bans[j].IP
+	})
+
+	if err := file.SaveJSON(fn, bans, 0600); err != nil {
+		return fmt.Errorf("save bans failed: %s", err)
+	}
+	return nil
+}
+
+// loadCachedBansFile loads bans from the cached bans.json file
+func loadCachedBansFile(path string) ([]Ban, error) {
+	var bans []Ban
+	err := file.LoadJSON(path, &bans)
+
+	if os.IsNotExist(err) {
+		logger.WithField("path", path).Info("File does not exist")
+		return nil, nil
+	} else if err == io.EOF {
+		logger.WithField("path", path).Error("Corrupt or empty file")
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.WithField("path", path).WithError(err).Error("Failed to load bans file")
+		return nil, err
+	}
+
+	valid := bans[:0]
+	for _, b := range bans {
+		if net.ParseIP(b.IP) == nil {
+			logger.WithField("ip", b.IP).Error("Invalid IP in bans JSON file")
+			continue
+		}
+		valid = append(valid, b)
+	}
+
+	return valid, nil
+}
+
+// addrIP returns the IP of an ip:port address or of an IP
+func addrIP(addr string) (string, error) {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", ErrInvalidAddress
+	}
+
+	return ip.String(), nil
+}
+
+// RecordOffense counts an offense of a peer toward banning its IP.
+// If the offense bans the IP, the peers of the IP are removed from the peer list and the ban is returned.
+// Events that are not offenses are ignored.
+func (px *Pex) RecordOffense(addr string, ev ScoreEvent) (*Ban, error) {
+	ip, err := addrIP(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	px.Lock()
+	defer px.Unlock()
+
+	now := time.Now().UTC()
+	b := px.bans.recordOffense(ip, ev, now)
+	if b == nil {
+		return nil, nil
+	}
+
+	logger.WithFields(logrus.Fields{
+		"ip":        ip,
+		"reason":    b.Reason,
+		"expiresAt": b.ExpiresAt,
+	}).Warning("Banned peer IP")
+
+	px.removeIPPeers(ip)
+
+	if err := px.saveBans(now); err != nil {
+		logger.WithError(err).Error("Save bans failed")
+	}
+
+	return b, nil
+}
+
+// IsBanned returns true if the IP of an ip:port address, or an IP, is banned
+func (px *Pex) IsBanned(addr string) bool {
+	ip, err := addrIP(addr)
+	if err != nil {
+		return false
+	}
+
+	px.RLock()
+	defer px.RUnlock()
+	return px.bans.isBanned(ip, time.Now().UTC())
+}
+
+// Unban lifts the ban of an IP and resets its offenses.
+// Returns ErrNotBanned if the IP is not banned.
+func (px *Pex) Unban(ip string) error {
+	cleanIP, err := addrIP(ip)
+	if err != nil {
+		return err
+	}
+
+	px.Lock()
+	defer px.Unlock()
+
+	now := time.Now().UTC()
+	if err := px.bans.unban(cleanIP, now); err != nil {
+		return err
+	}
+
+	logger.WithField("ip", cleanIP).Info("Unbanned peer IP")
+
+	return px.saveBans(now)
+}
+
+// BanReport returns the ban config, the offense counters and the active bans
+func (px *Pex) BanReport() BanReport {
+	// The counters are decayed in place
+	px.Lock()
+	defer px.Unlock()
+	return px.bans.report(time.Now().UTC())
+}
+
+// removeIPPeers removes the untrusted peers of an IP from the peer list
+func (px *Pex) removeIPPeers(ip string) {
+	for addr, p := range px.peerlist.peers {
+		if p.Trusted {
+			continue
+		}
+		if pip, err := addrIP(addr); err == nil && pip == ip {
+			px.peerlist.removePeer(addr)
+		}
+	}
+}
+
+func (px *Pex) isBannedAddr(addr string) bool {
+	ip, err := addrIP(addr)
+	if err != nil {
+		return false
+	}
+	return px.bans.isBanned(ip, time.Now().UTC())
+}
+
+func (px *Pex) loadBans() error {
+	px.Lock()
+	defer px.Unlock()
+
+	fp := filepath.Join(px.Config.DataDirectory, BanCacheFilename)
+	bans, err := loadCachedBansFile(fp)
+	if err != nil {
+		return err
+	}
+
+	px.bans.setBans(bans, time.Now().UTC())
+	return nil
+}
+
+func (px *Pex) saveBans(now time.Time) error {
+	fn := filepath.Join(px.Config.DataDirectory, BanCacheFilename)
+	return px.bans.save(fn, now)
+}
@@ -0,0 +1,213 @@
+package pex
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBanConfigVerify(t *testing.T) {
+	require.NoError(t, NewBanConfig().Verify())
+	require.NoError(t, BanConfig{}.Verify())
+	require.EqualError(t, BanConfig{Threshold: -1}.Verify(), "ban threshold must not be negative")
+	require.EqualError(t, BanConfig{Threshold: 1, Duration: time.Hour}.Verify(), "ban window must be positive")
+	require.EqualError(t, BanConfig{Threshold: 1, Window: time.Hour}.Verify(), "ban duration must be positive")
+}
+
+func TestBanlistRecordOffense(t *testing.T) {
+	cfg := BanConfig{
+		Threshold: 3,
+		Window:    time.Hour,
+		Duration:  time.Hour * 24,
+	}
+	now := time.Unix(1540000000, 0)
+	ip := "11.22.33.44"
+
+	bl := newBanlist(cfg)
+
+	// Events that are not offenses are not counted
+	require.Nil(t, bl.recordOffense(ip, ScoreEventHandshakeFailure, now))
+	require.Nil(t, bl.recordOffense(ip, ScoreEventBlocksAccepted, now))
+	require.Empty(t, bl.counters)
+
+	require.Nil(t, bl.recordOffense(ip, ScoreEventInvalidTxn, now))
+	require.Nil(t, bl.recordOffense(ip, ScoreEventInvalidBlock, now))
+	require.Nil(t, bl.recordOffense(ip, ScoreEventProtocolViolation, now))
+	require.False(t, bl.isBanned(ip, now))
+
+	// The offenses decay by half every window
+	r := bl.report(now.Add(cfg.Window))
+	require.Len(t, r.Counters, 1)
+	require.Equal(t, OffenseCounter{
+		IP:                 ip,
+		InvalidTxns:        0.5,
+		InvalidBlocks:      0.5,
+		ProtocolViolations: 0.5,
+		UpdatedAt:          now.Add(cfg.Window).Unix(),
+	}, r.Counters[0])
+	require.Empty(t, r.Bans)
+
+	// Decayed offenses don't ban
+	require.Nil(t, bl.recordOffense(ip, ScoreEventInvalidTxn, now.Add(cfg.Window)))
+	require.False(t, bl.isBanned(ip, now.Add(cfg.Window)))
+
+	// Exceeding the threshold bans
+	b := bl.recordOffense(ip, ScoreEventInvalidTxn, now.Add(cfg.Window))
+	require.NotNil(t, b)
+	require.Equal(t, Ban{
+		IP:        ip,
+		Reason:    "2.5 invalid transactions, 0.5 invalid blocks and 0.5 protocol violations",
+		BannedAt:  now.Add(cfg.Window).Unix(),
+		ExpiresAt: now.Add(cfg.Window + cfg.Duration).Unix(),
+	}, *b)
+	require.True(t, bl.isBanned(ip, now.Add(cfg.Window)))
+	require.Empty(t, bl.counters)
+
+	// Offenses of a banned IP are not counted
+	require.Nil(t, bl.recordOffense(ip, ScoreEventInvalidBlock, now.Add(cfg.Window)))
+	require.Empty(t, bl.counters)
+
+	// Other IPs are not banned
+	require.False(t, bl.isBanned("11.22.33.45", now.Add(cfg.Window)))
+
+	// The ban expires
+	expiry := now.Add(cfg.Window + cfg.Duration)
+	require.True(t, bl.isBanned(ip, expiry.Add(-time.Second)))
+	require.False(t, bl.isBanned(ip, expiry))
+
+	r = bl.report(expiry)
+	require.Empty(t, r.Bans)
+
+	bl.clearExpired(expiry)
+	require.Empty(t, bl.bans)
+}
+
+func TestBanlistThresholdDisabled(t *testing.T) {
+	bl := newBanlist(BanConfig{})
+	now := time.Unix(1540000000, 0)
+
+	for i := 0; i < 100; i++ {
+		require.Nil(t, bl.recordOffense("11.22.33.44", ScoreEventInvalidBlock, now))
+	}
+
+	// The offenses are counted but don't ban
+	require.Equal(t, float64(100), bl.counters["11.22.33.44"].Total())
+	require.False(t, bl.isBanned("11.22.33.44", now))
+}
+
+func TestBanlistClearExpired(t *testing.T) {
+	cfg := BanConfig{
+		Threshold: 10,
+		Window:    time.Minute,
+		Duration:  time.Hour,
+	}
+	now := time.Unix(1540000000, 0)
+
+	bl := newBanlist(cfg)
+	require.Nil(t, bl.recordOffense("11.22.33.44", ScoreEventInvalidTxn, now))
+	require.Nil(t, bl.recordOffense("11.22.33.45", ScoreEventInvalidTxn, now.Add(time.Minute*6)))
+
+	// The first counter decayed below minOffenses after 7 windows
+	bl.clearExpired(now.Add(time.Minute * 7))
+	require.Len(t, bl.counters, 1)
+	require.Contains(t, bl.counters, "11.22.33.45")
+}
+
+func TestBanlistUnban(t *testing.T) {
+	cfg := BanConfig{
+		Threshold: 1,
+		Window:    time.Hour,
+		Duration:  time.Hour,
+	}
+	now := time.Unix(1540000000, 0)
+
+	bl := newBanlist(cfg)
+	require.Equal(t, ErrNotBanned, bl.unban("11.22.33.44", now))
+
+	require.Nil(t, bl.recordOffense("11.22.33.44", ScoreEventInvalidBlock, now))
+	require.NotNil(t, bl.recordOffense("11.22.33.44", ScoreEventInvalidBlock, now))
+	require.True(t, bl.isBanned("11.22.33.44", now))
+
+	require.NoError(t, bl.unban("11.22.33.44", now))
+	require.False(t, bl.isBanned("11.22.33.44", now))
+	require.Equal(t, ErrNotBanned, bl.unban("11.22.33.44", now))
+
+	// The offenses were reset
+	require.Nil(t, bl.recordOffense("11.22.33.44", ScoreEventInvalidBlock, now))
+}
+
+func TestPexBans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pexbans")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	cfg.DefaultConnections = []string{"11.22.33.55:6000"}
+	cfg.Ban = BanConfig{
+		Threshold: 1,
+		Window:    time.Hour,
+		Duration:  time.Hour,
+	}
+
+	px, err := New(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, px.AddPeer("11.22.33.44:6000"))
+	require.NoError(t, px.AddPeer("11.22.33.44:7000"))
+	require.NoError(t, px.AddPeer("11.22.33.45:6000"))
+
+	_, err = px.RecordOffense("invalid", ScoreEventInvalidTxn)
+	require.Equal(t, ErrInvalidAddress, err)
+
+	b, err := px.RecordOffense("11.22.33.44:6000", ScoreEventInvalidTxn)
+	require.NoError(t, err)
+	require.Nil(t, b)
+
+	b, err = px.RecordOffense("11.22.33.44:7000", ScoreEventInvalidBlock)
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	require.Equal(t, "11.22.33.44", b.IP)
+
+	// The peers of the banned IP are removed and can't be added again
+	require.True(t, px.IsBanned("11.22.33.44:6000"))
+	require.True(t, px.IsBanned("11.22.33.44"))
+	require.False(t, px.IsBanned("11.22.33.45:6000"))
+	require.ElementsMatch(t, []string{"11.22.33.45:6000", "11.22.33.55:6000"}, px.All().ToAddrs())
+
+	require.Equal(t, ErrBlacklistedAddress, px.AddPeer("11.22.33.44:6000"))
+	require.Equal(t, 0, px.AddPeers([]string{"11.22.33.44:8000"}))
+
+	r := px.ImportPeers([]BundlePeer{{Addr: "11.22.33.44:8000"}})
+	require.Empty(t, r.Added)
+	require.Equal(t, []SkippedPeer{{Addr: "11.22.33.44:8000", Reason: ImportSkippedBanned}}, r.Skipped)
+
+	report := px.BanReport()
+	require.Equal(t, cfg.Ban, report.Config)
+	require.Empty(t, report.Counters)
+	require.Equal(t, []Ban{*b}, report.Bans)
+
+	// The bans are persisted
+	bans, err := loadCachedBansFile(filepath.Join(dir, BanCacheFilename))
+	require.NoError(t, err)
+	require.Equal(t, []Ban{*b}, bans)
+
+	px2, err := New(cfg)
+	require.NoError(t, err)
+	require.True(t, px2.IsBanned("11.22.33.44"))
+
+	// Unban
+	require.Equal(t, ErrInvalidAddress, px2.Unban("foo"))
+	require.Equal(t, ErrNotBanned, px2.Unban("11.22.33.45"))
+	require.NoError(t, px2.Unban("11.22.33.44"))
+	require.False(t, px2.IsBanned("11.22.33.44"))
+	require.NoError(t, px2.AddPeer("11.22.33.44:6000"))
+
+	bans, err = loadCachedBansFile(filepath.Join(dir, BanCacheFilename))
+	require.NoError(t, err)
+	require.Empty(t, bans)
+}
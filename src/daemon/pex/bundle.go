@@ -0,0 +1,289 @@
+package pex
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+/*
+Peer bundles carry peer lists between nodes without a network connection between them,
+e.g. to bootstrap a node in an air-gapped environment.
+
+A bundle lists known-good peers: public peers that are not cooling off, filtered by score and
+last seen time. It can be signed with a node key, in which case importing nodes that are
+configured with trusted keys only accept it if it is signed by one of them.
+*/
+
+// PeerBundleVersion is the version of the peer bundle format
+const PeerBundleVersion = 1
+
+var (
+	// ErrPeerBundleNoKey is returned when signing a peer bundle without a key
+	ErrPeerBundleNoKey = errors.New("No key to sign the peer bundle with")
+	// ErrPeerBundleVersion is returned when verifying a peer bundle of an unknown version
+	ErrPeerBundleVersion = errors.New("Unsupported peer bundle version")
+	// ErrPeerBundleNotSigned is returned when verifying an unsigned peer bundle against trusted keys
+	ErrPeerBundleNotSigned = errors.New("Peer bundle is not signed")
+	// ErrPeerBundleUntrustedKey is returned when verifying a peer bundle signed by a key that is not trusted
+	ErrPeerBundleUntrustedKey = errors.New("Peer bundle is not signed by a trusted key")
+	// ErrPeerBundleInvalidSignature is returned when verifying a peer bundle whose signature does not match its content
+	ErrPeerBundleInvalidSignature = errors.New("Peer bundle signature is invalid")
+)
+
+// Reasons an imported peer is skipped
+const (
+	// ImportSkippedInvalid the peer address is invalid
+	ImportSkippedInvalid = "invalid address"
+	// ImportSkippedKnown the peer is already in the peer list
+	ImportSkippedKnown = "already known"
+	// ImportSkippedCoolingOff the peer is in the peer list and is cooling off after its score dropped
+	ImportSkippedCoolingOff = "cooling off"
+	// ImportSkippedDuplicate the peer is listed more than once in the bundle
+	ImportSkippedDuplicate = "duplicate"
+	// ImportSkippedFull the peer list is full
+	ImportSkippedFull = "peer list full"
+	// ImportSkippedBanned the peer's IP is banned
+	ImportSkippedBanned = "banned"
+)
+
+// BundlePeer is a peer of a PeerBundle
+type BundlePeer struct {
+	Addr     string `json:"address"`
+	LastSeen int64  `json:"last_seen"`
+	Score    int    `json:"score"`
+}
+
+// PeerBundle is a portable list of peers
+type PeerBundle struct {
+	Version int          `json:"version"`
+	Created int64        `json:"created"`
+	Peers   []BundlePeer `json:"peers"`
+	// PubKey and Sig are set if the bundle is signed
+	PubKey string `json:"pubkey,omitempty"`
+	Sig    string `json:"sig,omitempty"`
+}
+
+// BundleFilter selects the peers of a PeerBundle
+type BundleFilter struct {
+	// MinScore is the minimum quality score of the peers
+	MinScore int
+	// MaxAge excludes peers that were last seen longer ago than this, 0 for no limit
+	MaxAge time.Duration
+	// Limit is the maximum number of peers, highest score first, 0 for no limit
+	Limit int
+}
+
+// NewPeerBundle creates an unsigned bundle of the public peers that pass the filter and are not cooling off,
+// highest score first
+func NewPeerBundle(peers Peers, f BundleFilter, now time.Time) *PeerBundle {
+	bps := make([]BundlePeer, 0, len(peers))
+	for _, p := range peers {
+		if p.Private || p.IsCoolingOff() || p.Score < f.MinScore {
+			continue
+		}
+
+		if f.MaxAge > 0 && now.Sub(time.Unix(p.LastSeen, 0)) > f.MaxAge {
+			continue
+		}
+
+		bps = append(bps, BundlePeer{
+			Addr:     p.Addr,
+			LastSeen: p.LastSeen,
+			Score:    p.Score,
+		})
+	}
+
+	// Sort by score, then by address so that the bundle is stable
+	sort.Slice(bps, func(i, j int) bool {
+		if bps[i].Score == bps[j].Score {
+			return bps[i].Addr < bps[j].Addr
+		}
+		return bps[i].Score > bps[j].Score
+	})
+
+	if f.Limit > 0 && len(bps) > f.Limit {
+		bps = bps[:f.Limit]
+	}
+
+	return &PeerBundle{
+		Version: PeerBundleVersion,
+		Created: now.UTC().Unix(),
+		Peers:   bps,
+	}
+}
+
+// Hash returns the hash of the bundle's content, which is signed by Sign
+func (b PeerBundle) Hash() (cipher.SHA256, error) {
+	content, err := json.Marshal(struct {
+		Version int          `json:"version"`
+		Created int64        `json:"created"`
+		Peers   []BundlePeer `json:"peers"`
+	}{
+		Version: b.Version,
+		Created: b.Created,
+		Peers:   b.Peers,
+	})
+	if err != nil {
+		return cipher.SHA256{}, err
+	}
+
+	return cipher.SumSHA256(content), nil
+}
+
+// Sign signs the bundle with a secret key
+func (b *PeerBundle) Sign(seckey cipher.SecKey) error {
+	if seckey == (cipher.SecKey{}) {
+		return ErrPeerBundleNoKey
+	}
+
+	pubkey, err := cipher.PubKeyFromSecKey(seckey)
+	if err != nil {
+		return err
+	}
+
+	h, err := b.Hash()
+	if err != nil {
+		return err
+	}
+
+	sig, err := cipher.SignHash(h, seckey)
+	if err != nil {
+		return err
+	}
+
+	b.PubKey = pubkey.Hex()
+	b.Sig = sig.Hex()
+	return nil
+}
+
+// Verify checks the bundle's version and signature.
+// If trusted keys are given, the bundle must be signed by one of them.
+// Otherwise, the signature is only checked if the bundle is signed.
+func (b PeerBundle) Verify(trusted []cipher.PubKey) error {
+	if b.Version != PeerBundleVersion {
+		return ErrPeerBundleVersion
+	}
+
+	if b.Sig == "" && b.PubKey == "" {
+		if len(trusted) != 0 {
+			return ErrPeerBundleNotSigned
+		}
+		return nil
+	}
+
+	pubkey, err := cipher.PubKeyFromHex(b.PubKey)
+	if err != nil {
+		return ErrPeerBundleInvalidSignature
+	}
+
+	sig, err := cipher.SigFromHex(b.Sig)
+	if err != nil {
+		return ErrPeerBundleInvalidSignature
+	}
+
+	if len(trusted) != 0 {
+		ok := false
+		for _, k := range trusted {
+			if k == pubkey {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return ErrPeerBundleUntrustedKey
+		}
+	}
+
+	h, err := b.Hash()
+	if err != nil {
+		return err
+	}
+
+	if err := cipher.VerifyPubKeySignedHash(pubkey, sig, h); err != nil {
+		return ErrPeerBundleInvalidSignature
+	}
+
+	return nil
+}
+
+// SkippedPeer is a peer that was not imported, and why
+type SkippedPeer struct {
+	Addr   string `json:"address"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult reports the peers added and skipped by ImportPeers
+type ImportResult struct {
+	Added   []string      `json:"added"`
+	Skipped []SkippedPeer `json:"skipped"`
+}
+
+// ImportPeers adds the peers of a bundle to the peer list, as untrusted public peers.
+// Peers with invalid addresses and peers that are already known are skipped,
+// and peers are only added while the peer list is not full.
+// The last seen time of a peer is kept from the bundle, unless it is in the future.
+func (px *Pex) ImportPeers(peers []BundlePeer) ImportResult {
+	px.Lock()
+	defer px.Unlock()
+
+	r := ImportResult{
+		Added:   []string{},
+		Skipped: []SkippedPeer{},
+	}
+
+	skip := func(addr, reason string) {
+		r.Skipped = append(r.Skipped, SkippedPeer{
+			Addr:   addr,
+			Reason: reason,
+		})
+	}
+
+	now := time.Now().UTC().Unix()
+	seen := make(map[string]struct{}, len(peers))
+
+	for _, bp := range peers {
+		addr, err := validateAddress(bp.Addr, px.Config.AllowLocalhost)
+		if err != nil {
+			skip(bp.Addr, ImportSkippedInvalid)
+			continue
+		}
+
+		if _, ok := seen[addr]; ok {
+			skip(addr, ImportSkippedDuplicate)
+			continue
+		}
+		seen[addr] = struct{}{}
+
+		if px.isBannedAddr(addr) {
+			skip(addr, ImportSkippedBanned)
+			continue
+		}
+
+		if p, ok := px.peerlist.getPeer(addr); ok {
+			if p.IsCoolingOff() {
+				skip(addr, ImportSkippedCoolingOff)
+			} else {
+				skip(addr, ImportSkippedKnown)
+			}
+			continue
+		}
+
+		if px.isFull() {
+			skip(addr, ImportSkippedFull)
+			continue
+		}
+
+		px.peerlist.addPeer(addr)
+		if bp.LastSeen > 0 && bp.LastSeen < now {
+			px.peerlist.peers[addr].LastSeen = bp.LastSeen
+		}
+
+		r.Added = append(r.Added, addr)
+	}
+
+	return r
+}
@@ -0,0 +1,213 @@
+package pex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestNewPeerBundle(t *testing.T) {
+	now := time.Now().UTC()
+	recent := now.Add(-time.Hour).Unix()
+	old := now.Add(-48 * time.Hour).Unix()
+
+	peers := Peers{
+		{Addr: "1.1.1.1:6000", LastSeen: recent, Score: 10},
+		{Addr: "2.2.2.2:6000", LastSeen: old, Score: 50},
+		{Addr: "3.3.3.3:6000", LastSeen: recent, Score: -10},
+		{Addr: "4.4.4.4:6000", LastSeen: recent, Score: 50, Private: true},
+		{Addr: "5.5.5.5:6000", LastSeen: recent, Score: LowPeerScoreThreshold - 1, LowScoreAt: now.Unix()},
+		{Addr: "6.6.6.6:6000", LastSeen: recent, Score: 10},
+	}
+
+	cases := []struct {
+		name   string
+		filter BundleFilter
+		addrs  []string
+	}{
+		{
+			name:   "no filter",
+			filter: BundleFilter{MinScore: MinPeerScore},
+			addrs:  []string{"2.2.2.2:6000", "1.1.1.1:6000", "6.6.6.6:6000", "3.3.3.3:6000"},
+		},
+		{
+			name:   "min score",
+			filter: BundleFilter{MinScore: 0},
+			addrs:  []string{"2.2.2.2:6000", "1.1.1.1:6000", "6.6.6.6:6000"},
+		},
+		{
+			name:   "max age",
+			filter: BundleFilter{MinScore: 0, MaxAge: 24 * time.Hour},
+			addrs:  []string{"1.1.1.1:6000", "6.6.6.6:6000"},
+		},
+		{
+			name:   "limit",
+			filter: BundleFilter{MinScore: 0, Limit: 2},
+			addrs:  []string{"2.2.2.2:6000", "1.1.1.1:6000"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewPeerBundle(peers, tc.filter, now)
+			require.Equal(t, PeerBundleVersion, b.Version)
+			require.Equal(t, now.Unix(), b.Created)
+			require.Empty(t, b.PubKey)
+			require.Empty(t, b.Sig)
+
+			addrs := make([]string, len(b.Peers))
+			for i, p := range b.Peers {
+				addrs[i] = p.Addr
+			}
+			require.Equal(t, tc.addrs, addrs)
+		})
+	}
+}
+
+func TestPeerBundleVerify(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	otherPk, otherSk := cipher.GenerateKeyPair()
+
+	newBundle := func() PeerBundle {
+		return PeerBundle{
+			Version: PeerBundleVersion,
+			Created: 1560000000,
+			Peers: []BundlePeer{
+				{Addr: "1.1.1.1:6000", LastSeen: 1550000000, Score: 10},
+			},
+		}
+	}
+
+	signed := newBundle()
+	require.NoError(t, signed.Sign(sk))
+	require.Equal(t, pk.Hex(), signed.PubKey)
+
+	unsigned := newBundle()
+	require.Equal(t, ErrPeerBundleNoKey, unsigned.Sign(cipher.SecKey{}))
+
+	signedByOther := newBundle()
+	require.NoError(t, signedByOther.Sign(otherSk))
+
+	tampered := signed
+	tampered.Peers = []BundlePeer{
+		{Addr: "2.2.2.2:6000", LastSeen: 1550000000, Score: 10},
+	}
+
+	badVersion := newBundle()
+	badVersion.Version = 2
+
+	badSig := signed
+	badSig.Sig = "foo"
+
+	cases := []struct {
+		name    string
+		bundle  PeerBundle
+		trusted []cipher.PubKey
+		err     error
+	}{
+		{
+			name:   "unsigned, no trusted keys",
+			bundle: newBundle(),
+		},
+		{
+			name:    "unsigned, trusted keys",
+			bundle:  newBundle(),
+			trusted: []cipher.PubKey{pk},
+			err:     ErrPeerBundleNotSigned,
+		},
+		{
+			name:   "signed, no trusted keys",
+			bundle: signed,
+		},
+		{
+			name:    "signed by a trusted key",
+			bundle:  signed,
+			trusted: []cipher.PubKey{otherPk, pk},
+		},
+		{
+			name:    "signed by an untrusted key",
+			bundle:  signedByOther,
+			trusted: []cipher.PubKey{pk},
+			err:     ErrPeerBundleUntrustedKey,
+		},
+		{
+			name:   "tampered, no trusted keys",
+			bundle: tampered,
+			err:    ErrPeerBundleInvalidSignature,
+		},
+		{
+			name:    "tampered, trusted keys",
+			bundle:  tampered,
+			trusted: []cipher.PubKey{pk},
+			err:     ErrPeerBundleInvalidSignature,
+		},
+		{
+			name:   "invalid signature",
+			bundle: badSig,
+			err:    ErrPeerBundleInvalidSignature,
+		},
+		{
+			name:   "unsupported version",
+			bundle: badVersion,
+			err:    ErrPeerBundleVersion,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.bundle.Verify(tc.trusted)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestPexImportPeers(t *testing.T) {
+	now := time.Now().UTC()
+
+	pex := &Pex{
+		peerlist: newPeerlist(),
+		Config: Config{
+			Max: 4,
+		},
+	}
+
+	pex.peerlist.setPeers([]Peer{
+		{Addr: "1.1.1.1:6000", LastSeen: now.Unix()},
+		{Addr: "2.2.2.2:6000", LastSeen: now.Unix(), Score: LowPeerScoreThreshold - 1, LowScoreAt: now.Unix()},
+	})
+
+	r := pex.ImportPeers([]BundlePeer{
+		{Addr: "1.1.1.1:6000"},
+		{Addr: "2.2.2.2:6000"},
+		{Addr: "127.0.0.1:6000"},
+		{Addr: "3.3.3.3:6000", LastSeen: now.Add(-time.Hour).Unix()},
+		{Addr: "3.3.3.3:6000"},
+		{Addr: "4.4.4.4:6000", LastSeen: now.Add(time.Hour).Unix()},
+		{Addr: "5.5.5.5:6000"},
+	})
+
+	require.Equal(t, []string{"3.3.3.3:6000", "4.4.4.4:6000"}, r.Added)
+	require.Equal(t, []SkippedPeer{
+		{Addr: "1.1.1.1:6000", Reason: ImportSkippedKnown},
+		{Addr: "2.2.2.2:6000", Reason: ImportSkippedCoolingOff},
+		{Addr: "127.0.0.1:6000", Reason: ImportSkippedInvalid},
+		{Addr: "3.3.3.3:6000", Reason: ImportSkippedDuplicate},
+		{Addr: "5.5.5.5:6000", Reason: ImportSkippedFull},
+	}, r.Skipped)
+
+	require.Equal(t, 4, pex.peerlist.len())
+
+	// The last seen time is kept from the bundle, unless it is in the future
+	p, ok := pex.peerlist.getPeer("3.3.3.3:6000")
+	require.True(t, ok)
+	require.Equal(t, now.Add(-time.Hour).Unix(), p.LastSeen)
+
+	p, ok = pex.peerlist.getPeer("4.4.4.4:6000")
+	require.True(t, ok)
+	require.True(t, p.LastSeen <= time.Now().UTC().Unix())
+	require.False(t, p.Trusted)
+	require.False(t, p.Private)
+}
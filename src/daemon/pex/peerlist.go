@@ -2,15 +2,15 @@ package pex
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"math/rand"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/skycoin/skycoin/src/util/file"
 	"github.com/skycoin/skycoin/src/util/useragent"
 )
 
@@ -26,6 +26,14 @@ func (ps Peers) ToAddrs() []string {
 	return addrs
 }
 
+// sortByScore sorts peers by score in descending order.
+// Peers with equal scores keep their relative order.
+func (ps Peers) sortByScore() {
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].Score > ps[j].Score
+	})
+}
+
 // peerlist is a map of addresses to *PeerStates
 type peerlist struct {
 	peers map[string]*Peer
@@ -40,20 +48,21 @@ func newPeerlist() peerlist {
 // Filter peers filter
 type Filter func(peer Peer) bool
 
-// loadCachedPeersFile loads peers from the cached peers.json file
+// loadCachedPeersFile loads peers from the cached peers.json file.
+// If the file is corrupt, the peers are loaded from its most recent valid backup.
+// Returns nil peers if the file does not exist, or it is corrupt and has no valid backup.
 func loadCachedPeersFile(path string) (map[string]*Peer, error) {
-	peersJSON := make(map[string]PeerJSON)
-	err := file.LoadJSON(path, &peersJSON)
+	peersJSON, err := readPeersFile(path)
 
 	if os.IsNotExist(err) {
 		logger.WithField("path", path).Info("File does not exist")
 		return nil, nil
-	} else if err == io.EOF {
-		logger.WithField("path", path).Error("Corrupt or empty file")
-		return nil, nil
-	}
-
-	if err != nil {
+	} else if errors.Is(err, ErrPeersFileCorrupt) {
+		peersJSON = recoverPeersFile(path, err)
+		if peersJSON == nil {
+			return nil, nil
+		}
+	} else if err != nil {
 		logger.WithField("path", path).WithError(err).Error("Failed to load peers file")
 		return nil, err
 	}
@@ -265,6 +274,7 @@ func (pl *peerlist) clearOld(timeAgo time.Duration) {
 
 // Returns n random peers, or all of the peers, whichever is lower.
 // If count is 0, all of the peers are returned, shuffled.
+// Higher scored peers are preferred, peers with equal scores are chosen randomly.
 func (pl *peerlist) random(count int, flts []Filter) Peers {
 	keys := pl.getCanTryPeers(flts).ToAddrs()
 	if len(keys) == 0 {
@@ -276,16 +286,17 @@ func (pl *peerlist) random(count int, flts []Filter) Peers {
 		max = len(keys)
 	}
 
-	ps := make(Peers, max)
+	ps := make(Peers, len(keys))
 	perm := rand.Perm(len(keys))
-	for i, j := range perm[:max] {
+	for i, j := range perm {
 		ps[i] = *pl.peers[keys[j]]
 	}
-	return ps
+	ps.sortByScore()
+
+	return ps[:max]
 }
 
-// save saves known peers to disk as a newline delimited list of addresses to
-// <dir><PeerCacheFilename>
+// save saves known peers to the peers file fn, see savePeersFile
 func (pl *peerlist) save(fn string) error {
 	// filter the peers that has retrytime > MaxPeerRetryTimes
 	peers := make(map[string]PeerJSON)
@@ -295,7 +306,7 @@ func (pl *peerlist) save(fn string) error {
 		}
 	}
 
-	if err := file.SaveJSON(fn, peers, 0600); err != nil {
+	if err := savePeersFile(fn, peers); err != nil {
 		return fmt.Errorf("save peer list failed: %s", err)
 	}
 	return nil
@@ -317,6 +328,13 @@ func (pl *peerlist) resetRetryTimes(addr string) {
 	}
 }
 
+// adjustScore applies a score event to a peer
+func (pl *peerlist) adjustScore(addr string, ev ScoreEvent) {
+	if p, ok := pl.peers[addr]; ok {
+		p.AdjustScore(ev)
+	}
+}
+
 // resetAllRetryTimes reset all peers' retry times
 func (pl *peerlist) resetAllRetryTimes() {
 	logger.Info("Reset all peer's retry times")
@@ -358,6 +376,14 @@ type PeerJSON struct {
 	HasIncomePort   *bool `json:"HasIncomePort,omitempty"` // Whether this peer has incoming port [DEPRECATED]
 	HasIncomingPort *bool // Whether this peer has incoming port
 	UserAgent       useragent.Data
+	Score           int   // Quality score
+	LowScoreAt      int64 // Unix timestamp when the score was last lowered below LowPeerScoreThreshold
+	// Number of failed connection attempts, so that the backoff survives a restart
+	RetryTimes int `json:",omitempty"`
+	// Optional fields without a dedicated field, e.g. the DNS seed the peer was learned from.
+	// Keys under Extra are kept when the peers file is saved again, even if this version doesn't use them.
+	// Unknown top level fields are dropped, so fields that older versions must preserve belong in Extra
+	Extra map[string]json.RawMessage `json:",omitempty"`
 }
 
 // newPeerJSON returns a PeerJSON from a Peer
@@ -369,6 +395,10 @@ func newPeerJSON(p Peer) PeerJSON {
 		Trusted:         p.Trusted,
 		HasIncomingPort: &p.HasIncomingPort,
 		UserAgent:       p.UserAgent,
+		Score:           p.Score,
+		LowScoreAt:      p.LowScoreAt,
+		RetryTimes:      p.RetryTimes,
+		Extra:           p.Extra,
 	}
 }
 
@@ -413,5 +443,9 @@ func newPeerFromJSON(p PeerJSON) (*Peer, error) {
 		Trusted:         p.Trusted,
 		HasIncomingPort: hasIncomingPort,
 		UserAgent:       p.UserAgent,
+		Score:           p.Score,
+		LowScoreAt:      p.LowScoreAt,
+		RetryTimes:      p.RetryTimes,
+		Extra:           p.Extra,
 	}, nil
 }
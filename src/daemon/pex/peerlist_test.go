@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -601,12 +602,16 @@ func peersEqualWithSeenAllowedDiff(t *testing.T, expected Peer, actual Peer) {
 }
 
 // preparePeerlistFile makes peers.json in temporary dir,
+// removing the dir removes the backups of the file too
 func preparePeerlistFile(t *testing.T) (string, func()) {
-	f, err := ioutil.TempFile("", PeerCacheFilename)
+	dir, err := ioutil.TempDir("", "peerlist")
 	require.NoError(t, err)
 
-	return f.Name(), func() {
-		os.Remove(f.Name())
+	fn := filepath.Join(dir, PeerCacheFilename)
+	require.NoError(t, ioutil.WriteFile(fn, nil, 0600))
+
+	return fn, func() {
+		os.RemoveAll(dir)
 	}
 }
 
@@ -0,0 +1,256 @@
+package pex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+/*
+The peers file is a JSON object with the format version and the peers by address,
+followed by a footer line with the SHA256 checksum of everything before it:
+
+	{
+	    "version": 2,
+	    "peers": {
+	        "1.2.3.4:6000": {...}
+	    }
+	}
+	sha256:<hex checksum>
+
+Version 1 files, a JSON object of the peers by address without a footer, are still read,
+and are written in the current format the next time the peers are saved.
+
+The file is written to a temporary file that is renamed over it, and the previous file is kept
+as <file>.1, up to <file>.<PeerCacheBackups>. A file that fails to parse or whose checksum
+doesn't match is moved to <file>.corrupt.<id> and the peers are loaded from the most recent valid backup.
+A file written in a newer format is not overwritten, loading it fails.
+
+New optional peer fields are added to PeerJSON with omitempty, or to PeerJSON.Extra, without changing the version.
+*/
+
+const (
+	// PeersFileVersion is the version of the peers file format
+	PeersFileVersion = 2
+
+	peersFileChecksumPrefix = "sha256:"
+)
+
+var (
+	// ErrPeersFileCorrupt is returned when a peers file can't be parsed or its checksum doesn't match
+	ErrPeersFileCorrupt = errors.New("peers file is corrupt")
+	// ErrPeersFileVersion is returned when a peers file was written in a newer format
+	ErrPeersFileVersion = errors.New("peers file version is not supported")
+)
+
+// peersFile is the body of the peers file
+type peersFile struct {
+	Version int                 `json:"version"`
+	Peers   map[string]PeerJSON `json:"peers"`
+}
+
+// encodePeersFile encodes peers in the current peers file format
+func encodePeersFile(peers map[string]PeerJSON) ([]byte, error) {
+	body, err := json.MarshalIndent(peersFile{
+		Version: PeersFileVersion,
+		Peers:   peers,
+	}, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	body = append(body, '\n')
+	footer := fmt.Sprintf("%s%s\n", peersFileChecksumPrefix, cipher.SumSHA256(body).Hex())
+	return append(body, footer...), nil
+}
+
+// decodePeersFile decodes a peers file in the current or the version 1 format, returning its version.
+// Errors wrap ErrPeersFileCorrupt or ErrPeersFileVersion.
+func decodePeersFile(data []byte) (map[string]PeerJSON, int, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, 0, fmt.Errorf("%w: empty file", ErrPeersFileCorrupt)
+	}
+
+	i := bytes.LastIndex(data, []byte("\n"+peersFileChecksumPrefix))
+	if i == -1 {
+		// Version 1 files have no footer, the JSON must be complete
+		var peers map[string]PeerJSON
+		if err := decodePeersJSON(data, &peers); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrPeersFileCorrupt, err)
+		}
+		return peers, 1, nil
+	}
+
+	body := data[:i+1]
+	checksum := string(bytes.TrimSpace(data[i+1+len(peersFileChecksumPrefix):]))
+	if checksum != cipher.SumSHA256(body).Hex() {
+		return nil, 0, fmt.Errorf("%w: checksum mismatch", ErrPeersFileCorrupt)
+	}
+
+	var pf peersFile
+	if err := decodePeersJSON(body, &pf); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrPeersFileCorrupt, err)
+	}
+
+	switch {
+	case pf.Version > PeersFileVersion:
+		return nil, pf.Version, fmt.Errorf("%w: version %d is newer than %d", ErrPeersFileVersion, pf.Version, PeersFileVersion)
+	case pf.Version < 2:
+		return nil, pf.Version, fmt.Errorf("%w: invalid version %d", ErrPeersFileCorrupt, pf.Version)
+	}
+
+	return pf.Peers, pf.Version, nil
+}
+
+// decodePeersJSON decodes JSON with numbers kept as json.Number, as PeerJSON.LastSeen expects
+func decodePeersJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// readPeersFile reads and decodes a peers file
+func readPeersFile(path string) (map[string]PeerJSON, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, version, err := decodePeersFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < PeersFileVersion {
+		logger.WithField("path", path).Infof("Peers file version %d is migrated to version %d on the next save", version, PeersFileVersion)
+	}
+
+	return peers, nil
+}
+
+// recoverPeersFile moves a corrupt peers file aside and reads the most recent valid backup.
+// Returns nil peers if there is no valid backup.
+func recoverPeersFile(path string, loadErr error) map[string]PeerJSON {
+	logger.Critical().WithError(loadErr).WithField("path", path).Error("Peers file is corrupt, falling back on its backups")
+
+	if corruptPath, err := moveCorruptPeersFile(path); err != nil {
+		logger.Critical().WithError(err).WithField("path", path).Error("Failed to move the corrupt peers file aside")
+	} else {
+		logger.Critical().WithField("path", corruptPath).Error("Moved the corrupt peers file aside")
+	}
+
+	for i := 1; i <= PeerCacheBackups; i++ {
+		bp := peersFileBackupPath(path, i)
+		peers, err := readPeersFile(bp)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			logger.Critical().WithError(err).WithField("path", bp).Error("Peers file backup failed to load")
+			continue
+		}
+
+		logger.Critical().WithField("path", bp).Warningf("Recovered %d peers from the peers file backup", len(peers))
+		return peers
+	}
+
+	logger.Critical().WithField("path", path).Error("No valid peers file backup, starting without cached peers")
+	return nil
+}
+
+// moveCorruptPeersFile renames a peers file to <path>.corrupt.<first 8 hex characters of its SHA256>
+func moveCorruptPeersFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	corruptPath := fmt.Sprintf("%s.corrupt.%s", path, cipher.SumSHA256(data).Hex()[:8])
+	if err := os.Rename(path, corruptPath); err != nil {
+		return "", err
+	}
+
+	return corruptPath, nil
+}
+
+// savePeersFile writes peers to the peers file atomically, keeping the previous file as a backup
+func savePeersFile(path string, peers map[string]PeerJSON) error {
+	data, err := encodePeersFile(peers)
+	if err != nil {
+		return err
+	}
+
+	if err := rotatePeersFileBackups(path); err != nil {
+		return fmt.Errorf("rotate peers file backups failed: %v", err)
+	}
+
+	return writeFileAtomic(path, data, 0600)
+}
+
+// peersFileBackupPath returns the path of the nth most recent backup of the peers file
+func peersFileBackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// rotatePeersFileBackups shifts the backups of the peers file and copies the peers file to the first backup.
+// A peers file that fails to decode is not backed up, so that it does not displace a valid backup.
+func rotatePeersFileBackups(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if _, _, err := decodePeersFile(data); err != nil {
+		logger.WithError(err).WithField("path", path).Warning("Not backing up a peers file that fails to load")
+		return nil
+	}
+
+	for i := PeerCacheBackups - 1; i >= 1; i-- {
+		err := os.Rename(peersFileBackupPath(path, i), peersFileBackupPath(path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return writeFileAtomic(peersFileBackupPath(path, 1), data, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// syncs it and renames it to path, so that path is never left partially written
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	if err := func() error {
+		if _, err := f.Write(data); err != nil {
+			f.Close() //nolint:errcheck
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close() //nolint:errcheck
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Chmod(tmpPath, mode); err != nil {
+			return err
+		}
+		return os.Rename(tmpPath, path)
+	}(); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package pex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func testPeersJSON(addrs ...string) map[string]PeerJSON {
+	peers := make(map[string]PeerJSON, len(addrs))
+	for _, a := range addrs {
+		peers[a] = newPeerJSON(Peer{
+			Addr:     a,
+			LastSeen: 1506235338,
+		})
+	}
+	return peers
+}
+
+// withChecksum appends a valid checksum footer to a peers file body
+func withChecksum(body string) []byte {
+	return []byte(fmt.Sprintf("%s%s%s\n", body, peersFileChecksumPrefix, cipher.SumSHA256([]byte(body)).Hex()))
+}
+
+func TestEncodeDecodePeersFile(t *testing.T) {
+	peers := testPeersJSON(testPeers[0], testPeers[1])
+	p := peers[testPeers[0]]
+	p.Score = -20
+	p.RetryTimes = 3
+	p.Extra = map[string]json.RawMessage{
+		"Origin": json.RawMessage(`"dns-seed"`),
+	}
+	peers[testPeers[0]] = p
+
+	data, err := encodePeersFile(peers)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "{\n    \"version\": 2,"))
+
+	decoded, version, err := decodePeersFile(data)
+	require.NoError(t, err)
+	require.Equal(t, PeersFileVersion, version)
+	require.Len(t, decoded, 2)
+
+	peer, err := newPeerFromJSON(decoded[testPeers[0]])
+	require.NoError(t, err)
+	require.Equal(t, &Peer{
+		Addr:            testPeers[0],
+		LastSeen:        1506235338,
+		Score:           -20,
+		RetryTimes:      3,
+		HasIncomingPort: false,
+		Extra: map[string]json.RawMessage{
+			"Origin": json.RawMessage(`"dns-seed"`),
+		},
+	}, peer)
+
+	// Optional fields are omitted when unset
+	require.Equal(t, 1, strings.Count(string(data), "RetryTimes"))
+	require.Equal(t, 1, strings.Count(string(data), "Extra"))
+}
+
+func TestDecodePeersFile(t *testing.T) {
+	valid, err := encodePeersFile(testPeersJSON(testPeers[0]))
+	require.NoError(t, err)
+
+	legacy, err := json.Marshal(map[string]*Peer{
+		testPeers[0]: NewPeer(testPeers[0]),
+	})
+	require.NoError(t, err)
+
+	tampered := []byte(strings.Replace(string(valid), testPeers[0], testPeers[1], -1))
+
+	tt := []struct {
+		name    string
+		data    []byte
+		version int
+		peers   int
+		err     error
+	}{
+		{
+			name:    "current format",
+			data:    valid,
+			version: PeersFileVersion,
+			peers:   1,
+		},
+		{
+			name:    "version 1",
+			data:    legacy,
+			version: 1,
+			peers:   1,
+		},
+		{
+			name: "empty",
+			data: []byte("\n"),
+			err:  ErrPeersFileCorrupt,
+		},
+		{
+			name: "truncated",
+			data: valid[:len(valid)/2],
+			err:  ErrPeersFileCorrupt,
+		},
+		{
+			name: "truncated footer",
+			data: valid[:len(valid)-10],
+			err:  ErrPeersFileCorrupt,
+		},
+		{
+			name: "truncated version 1",
+			data: legacy[:len(legacy)-1],
+			err:  ErrPeersFileCorrupt,
+		},
+		{
+			name: "checksum mismatch",
+			data: tampered,
+			err:  ErrPeersFileCorrupt,
+		},
+		{
+			name: "missing version",
+			data: withChecksum("{\"peers\": {}}\n"),
+			err:  ErrPeersFileCorrupt,
+		},
+		{
+			name:    "newer version",
+			data:    withChecksum("{\"version\": 3, \"peers\": {}}\n"),
+			version: 3,
+			err:     ErrPeersFileVersion,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			peers, version, err := decodePeersFile(tc.data)
+			if tc.err != nil {
+				require.Error(t, err)
+				require.True(t, errors.Is(err, tc.err), err.Error())
+				require.Equal(t, tc.version, version)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.version, version)
+			require.Len(t, peers, tc.peers)
+		})
+	}
+}
+
+func TestSavePeersFileBackups(t *testing.T) {
+	fn, removeFile := preparePeerlistFile(t)
+	defer removeFile()
+	require.NoError(t, os.Remove(fn))
+
+	// Save once more than the number of backups kept
+	for i := 0; i <= PeerCacheBackups+1; i++ {
+		require.NoError(t, savePeersFile(fn, testPeersJSON(testPeers[:i]...)))
+	}
+
+	peers, err := readPeersFile(fn)
+	require.NoError(t, err)
+	require.Len(t, peers, PeerCacheBackups+1)
+
+	for i := 1; i <= PeerCacheBackups; i++ {
+		peers, err := readPeersFile(peersFileBackupPath(fn, i))
+		require.NoError(t, err)
+		require.Len(t, peers, PeerCacheBackups+1-i)
+	}
+
+	_, err = os.Stat(peersFileBackupPath(fn, PeerCacheBackups+1))
+	require.True(t, os.IsNotExist(err))
+
+	// No temporary files are left
+	files, err := ioutil.ReadDir(filepath.Dir(fn))
+	require.NoError(t, err)
+	require.Len(t, files, PeerCacheBackups+1)
+
+	// A corrupt peers file does not displace the backups
+	require.NoError(t, ioutil.WriteFile(fn, []byte("{"), 0600))
+	require.NoError(t, savePeersFile(fn, testPeersJSON()))
+
+	peers, err = readPeersFile(peersFileBackupPath(fn, 1))
+	require.NoError(t, err)
+	require.Len(t, peers, PeerCacheBackups)
+}
+
+func TestLoadCachedPeersFileRecovery(t *testing.T) {
+	fn, removeFile := preparePeerlistFile(t)
+	defer removeFile()
+
+	for i := 1; i <= PeerCacheBackups; i++ {
+		require.NoError(t, savePeersFile(fn, testPeersJSON(testPeers[:i]...)))
+	}
+
+	truncate := func(fn string) {
+		data, err := ioutil.ReadFile(fn)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(fn, data[:len(data)/2], 0600))
+	}
+
+	// The most recent backup is loaded if the peers file is corrupt
+	truncate(fn)
+	peers, err := loadCachedPeersFile(fn)
+	require.NoError(t, err)
+	require.Len(t, peers, PeerCacheBackups-1)
+
+	// The corrupt peers file was moved aside
+	_, err = os.Stat(fn)
+	require.True(t, os.IsNotExist(err))
+	corrupt, err := filepath.Glob(fn + ".corrupt.*")
+	require.NoError(t, err)
+	require.Len(t, corrupt, 1)
+
+	// Corrupt backups are skipped
+	require.NoError(t, savePeersFile(fn, testPeersJSON(testPeers...)))
+	truncate(fn)
+	truncate(peersFileBackupPath(fn, 1))
+	peers, err = loadCachedPeersFile(fn)
+	require.NoError(t, err)
+	require.Len(t, peers, PeerCacheBackups-2)
+
+	// Without a valid backup, no peers are loaded
+	require.NoError(t, ioutil.WriteFile(fn, nil, 0600))
+	for i := 1; i <= PeerCacheBackups; i++ {
+		os.Remove(peersFileBackupPath(fn, i))
+	}
+	peers, err = loadCachedPeersFile(fn)
+	require.NoError(t, err)
+	require.Nil(t, peers)
+
+	// A peers file in a newer format is not replaced
+	require.NoError(t, ioutil.WriteFile(fn, withChecksum("{\"version\": 3, \"peers\": {}}\n"), 0600))
+	_, err = loadCachedPeersFile(fn)
+	require.True(t, errors.Is(err, ErrPeersFileVersion))
+	_, err = os.Stat(fn)
+	require.NoError(t, err)
+}
+
+func TestPexMigratePeersFile(t *testing.T) {
+	dir, _ := preparePeerlistDir(t)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, PeerCacheFilename)
+	persistPeers(t, fn, testPeers[:2])
+	legacy, err := ioutil.ReadFile(fn)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	pex, err := New(cfg)
+	require.NoError(t, err)
+	require.Len(t, pex.All(), 2)
+
+	// The peers file is saved in the current format, and the version 1 file is kept as a backup
+	data, err := ioutil.ReadFile(fn)
+	require.NoError(t, err)
+	peers, version, err := decodePeersFile(data)
+	require.NoError(t, err)
+	require.Equal(t, PeersFileVersion, version)
+	require.Len(t, peers, 2)
+
+	backup, err := ioutil.ReadFile(peersFileBackupPath(fn, 1))
+	require.NoError(t, err)
+	require.Equal(t, legacy, backup)
+
+	// A corrupt peers file is recovered from the backup when pex starts
+	require.NoError(t, ioutil.WriteFile(fn, data[:len(data)-20], 0600))
+	pex, err = New(cfg)
+	require.NoError(t, err)
+	require.Len(t, pex.All(), 2)
+}
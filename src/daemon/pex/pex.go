@@ -2,6 +2,7 @@
 package pex
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -36,6 +37,8 @@ const (
 	DefaultPeerListURL = "https://downloads.skycoin.com/blockchain/peers.txt"
 	// PeerCacheFilename filename for disk-cached peers
 	PeerCacheFilename = "peers.json"
+	// PeerCacheBackups is the number of previous versions of the peers file kept as backups
+	PeerCacheBackups = 3
 	// oldPeerCacheFilename previous filename for disk-cached peers. The cache loader will fall back onto this filename if it can't load peers.json
 	oldPeerCacheFilename = "peers.txt"
 	// MaxPeerRetryTimes is the maximum number of times to retry a peer
@@ -104,7 +107,11 @@ type Peer struct {
 	Trusted         bool           // Whether this peer is trusted
 	HasIncomingPort bool           // Whether this peer has accessible public port
 	UserAgent       useragent.Data // Peer's last reported user agent
+	Score           int            // Quality score, adjusted by ScoreEvents
+	LowScoreAt      int64          // Unix timestamp when the score was last lowered below LowPeerScoreThreshold
 	RetryTimes      int            `json:"-"` // records the retry times
+	// Optional peers file fields without a dedicated field, see PeerJSON.Extra
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // NewPeer returns a *Peer initialized by an address string of the form ip:port
@@ -137,8 +144,13 @@ func (peer *Peer) ResetRetryTimes() {
 	peer.RetryTimes = 0
 }
 
-// CanTry returns whether this peer is tryable base on the exponential backoff algorithm
+// CanTry returns whether this peer is tryable base on the exponential backoff algorithm.
+// Peers with a low score are not tryable until their cooloff period has elapsed.
 func (peer *Peer) CanTry() bool {
+	if peer.IsCoolingOff() {
+		return false
+	}
+
 	// Exponential backoff
 	mod := (math.Exp2(float64(peer.RetryTimes)) - 1) * 5
 	if mod == 0 {
@@ -168,7 +180,7 @@ type Config struct {
 	CullRate time.Duration
 	// clear old peers on this interval
 	ClearOldRate time.Duration
-	// How often to clear expired blacklist entries
+	// How often to clear expired bans and decayed offense counters
 	UpdateBlacklistRate time.Duration
 	// How often to request peers via PEX
 	RequestRate time.Duration
@@ -190,6 +202,8 @@ type Config struct {
 	CustomPeersFile string
 	// Default "trusted" connections
 	DefaultConnections []string
+	// Banning of peers that repeatedly send invalid objects
+	Ban BanConfig
 }
 
 // NewConfig creates default pex config.
@@ -210,6 +224,7 @@ func NewConfig() Config {
 		PeerListURL:         DefaultPeerListURL,
 		DisableTrustedPeers: false,
 		CustomPeersFile:     "",
+		Ban:                 NewBanConfig(),
 	}
 }
 
@@ -218,9 +233,11 @@ type Pex struct {
 	sync.RWMutex
 	// All known peers
 	peerlist peerlist
-	Config   Config
-	quit     chan struct{}
-	done     chan struct{}
+	// Offense counters and bans of IPs
+	bans   banlist
+	Config Config
+	quit   chan struct{}
+	done   chan struct{}
 }
 
 // New creates pex
@@ -228,6 +245,7 @@ func New(cfg Config) (*Pex, error) {
 	pex := &Pex{
 		Config:   cfg,
 		peerlist: newPeerlist(),
+		bans:     newBanlist(cfg.Ban),
 		quit:     make(chan struct{}),
 		done:     make(chan struct{}),
 	}
@@ -238,6 +256,12 @@ func New(cfg Config) (*Pex, error) {
 		return nil, err
 	}
 
+	// Load bans from disk
+	if err := pex.loadBans(); err != nil {
+		logger.Critical().WithError(err).Error("pex.loadBans failed")
+		return nil, err
+	}
+
 	// Unset trusted status from any existing peers, regenerate
 	// them from the DefaultConnections
 	pex.setAllUntrusted()
@@ -300,6 +324,7 @@ func (px *Pex) Run() error {
 	}()
 
 	clearOldTicker := time.NewTicker(px.Config.ClearOldRate)
+	updateBlacklistTicker := time.NewTicker(px.Config.UpdateBlacklistRate)
 
 	for {
 		select {
@@ -312,6 +337,13 @@ func (px *Pex) Run() error {
 					px.peerlist.clearOld(px.Config.Expiration)
 				}()
 			}
+		case <-updateBlacklistTicker.C:
+			// Remove expired bans and offense counters that have decayed away
+			func() {
+				px.Lock()
+				defer px.Unlock()
+				px.bans.clearExpired(time.Now().UTC())
+			}()
 		case <-px.quit:
 			return nil
 		}
@@ -414,13 +446,17 @@ func (px *Pex) loadCustom(fn string) error {
 	return nil
 }
 
-// SavePeers persists the peerlist
+// SavePeers persists the peerlist and the bans
 func (px *Pex) save() error {
 	px.Lock()
 	defer px.Unlock()
 
 	fn := filepath.Join(px.Config.DataDirectory, PeerCacheFilename)
-	return px.peerlist.save(fn)
+	if err := px.peerlist.save(fn); err != nil {
+		return err
+	}
+
+	return px.saveBans(time.Now().UTC())
 }
 
 // AddPeer adds a peer to the peer list, given an address. If the peer list is
@@ -436,6 +472,10 @@ func (px *Pex) AddPeer(addr string) error {
 		return ErrInvalidAddress
 	}
 
+	if px.isBannedAddr(cleanAddr) {
+		return ErrBlacklistedAddress
+	}
+
 	if px.peerlist.hasPeer(cleanAddr) {
 		px.peerlist.seen(cleanAddr)
 		return nil
@@ -482,6 +522,10 @@ func (px *Pex) AddPeers(addrs []string) int {
 			logger.WithField("addr", addr).WithError(err).Info("Add peers sees an invalid address")
 			continue
 		}
+		if px.isBannedAddr(a) {
+			logger.WithField("addr", a).Debug("Add peers sees a banned address")
+			continue
+		}
 		validAddrs = append(validAddrs, a)
 	}
 	addrs = validAddrs
@@ -644,6 +688,22 @@ func (px *Pex) ResetAllRetryTimes() {
 	px.peerlist.resetAllRetryTimes()
 }
 
+// AdjustScore applies a score event to a peer
+func (px *Pex) AdjustScore(addr string, ev ScoreEvent) {
+	px.Lock()
+	defer px.Unlock()
+	px.peerlist.adjustScore(addr, ev)
+}
+
+// All returns all known peers, sorted by score in descending order
+func (px *Pex) All() Peers {
+	px.RLock()
+	defer px.RUnlock()
+	ps := px.peerlist.getPeers(nil)
+	ps.sortByScore()
+	return ps
+}
+
 // IsFull returns whether the peer list is full
 func (px *Pex) IsFull() bool {
 	px.RLock()
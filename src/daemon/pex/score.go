@@ -0,0 +1,104 @@
+package pex
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxPeerScore is the highest score a peer can reach
+	MaxPeerScore = 100
+	// MinPeerScore is the lowest score a peer can reach
+	MinPeerScore = -100
+	// LowPeerScoreThreshold is the score below which a peer is only retried after LowPeerScoreCooloff
+	LowPeerScoreThreshold = -50
+	// LowPeerScoreCooloff is how long to wait before retrying a peer whose score dropped below LowPeerScoreThreshold
+	LowPeerScoreCooloff = time.Hour * 12
+)
+
+// ScoreEvent is an event that changes a peer's quality score
+type ScoreEvent int
+
+const (
+	// ScoreEventProtocolViolation the peer sent a malformed or unknown message
+	ScoreEventProtocolViolation ScoreEvent = iota + 1
+	// ScoreEventInvalidBlock the peer sent a block that failed to execute
+	ScoreEventInvalidBlock
+	// ScoreEventInvalidTxn the peer sent a transaction that violates hard constraints
+	ScoreEventInvalidTxn
+	// ScoreEventHandshakeFailure the introduction with the peer failed
+	ScoreEventHandshakeFailure
+	// ScoreEventBlocksAccepted the peer sent blocks that were added to the blockchain
+	ScoreEventBlocksAccepted
+)
+
+var scoreEventDeltas = map[ScoreEvent]int{
+	ScoreEventProtocolViolation: -10,
+	ScoreEventInvalidBlock:      -25,
+	ScoreEventInvalidTxn:        -5,
+	ScoreEventHandshakeFailure:  -10,
+	ScoreEventBlocksAccepted:    2,
+}
+
+var scoreEventNames = map[ScoreEvent]string{
+	ScoreEventProtocolViolation: "protocol_violation",
+	ScoreEventInvalidBlock:      "invalid_block",
+	ScoreEventInvalidTxn:        "invalid_txn",
+	ScoreEventHandshakeFailure:  "handshake_failure",
+	ScoreEventBlocksAccepted:    "blocks_accepted",
+}
+
+// String returns the name of the score event
+func (ev ScoreEvent) String() string {
+	if s, ok := scoreEventNames[ev]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// Delta returns the score change caused by the event
+func (ev ScoreEvent) Delta() int {
+	return scoreEventDeltas[ev]
+}
+
+// AdjustScore applies a score event to the peer.
+// The score is clamped to [MinPeerScore, MaxPeerScore].
+// Each time the score is lowered below LowPeerScoreThreshold, the cooloff period restarts.
+func (peer *Peer) AdjustScore(ev ScoreEvent) {
+	delta := ev.Delta()
+
+	score := peer.Score + delta
+	if score > MaxPeerScore {
+		score = MaxPeerScore
+	} else if score < MinPeerScore {
+		score = MinPeerScore
+	}
+	peer.Score = score
+
+	if peer.Score < LowPeerScoreThreshold {
+		if delta < 0 {
+			peer.LowScoreAt = time.Now().UTC().Unix()
+		}
+	} else {
+		peer.LowScoreAt = 0
+	}
+
+	logger.WithFields(logrus.Fields{
+		"addr":  peer.Addr,
+		"event": ev.String(),
+		"score": peer.Score,
+	}).Debug("Adjust peer score")
+}
+
+// IsCoolingOff returns true if the peer's score is below LowPeerScoreThreshold
+// and LowPeerScoreCooloff has not elapsed since it was last lowered.
+// Trusted peers never cool off.
+func (peer *Peer) IsCoolingOff() bool {
+	if peer.Trusted || peer.Score >= LowPeerScoreThreshold {
+		return false
+	}
+
+	lowScoreAt := time.Unix(peer.LowScoreAt, 0)
+	return time.Now().UTC().Sub(lowScoreAt) < LowPeerScoreCooloff
+}
@@ -0,0 +1,178 @@
+package pex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerAdjustScore(t *testing.T) {
+	repeat := func(ev ScoreEvent, n int) []ScoreEvent {
+		evs := make([]ScoreEvent, n)
+		for i := range evs {
+			evs[i] = ev
+		}
+		return evs
+	}
+
+	cases := []struct {
+		name       string
+		trusted    bool
+		events     []ScoreEvent
+		score      int
+		lowScore   bool
+		coolingOff bool
+	}{
+		{
+			name:  "no events",
+			score: 0,
+		},
+		{
+			name:   "blocks accepted",
+			events: repeat(ScoreEventBlocksAccepted, 3),
+			score:  6,
+		},
+		{
+			name:   "score capped at max",
+			events: repeat(ScoreEventBlocksAccepted, 100),
+			score:  MaxPeerScore,
+		},
+		{
+			name:       "score capped at min",
+			events:     repeat(ScoreEventInvalidBlock, 10),
+			score:      MinPeerScore,
+			lowScore:   true,
+			coolingOff: true,
+		},
+		{
+			name: "mixed events above threshold",
+			events: []ScoreEvent{
+				ScoreEventHandshakeFailure,
+				ScoreEventBlocksAccepted,
+				ScoreEventInvalidTxn,
+				ScoreEventProtocolViolation,
+			},
+			score: -23,
+		},
+		{
+			name: "drops below threshold",
+			events: []ScoreEvent{
+				ScoreEventInvalidBlock,
+				ScoreEventInvalidBlock,
+				ScoreEventInvalidTxn,
+			},
+			score:      -55,
+			lowScore:   true,
+			coolingOff: true,
+		},
+		{
+			name: "recovers above threshold",
+			events: append([]ScoreEvent{
+				ScoreEventInvalidBlock,
+				ScoreEventInvalidBlock,
+				ScoreEventInvalidTxn,
+			}, repeat(ScoreEventBlocksAccepted, 3)...),
+			score: -49,
+		},
+		{
+			name: "accepted blocks below threshold do not end cooloff",
+			events: []ScoreEvent{
+				ScoreEventInvalidBlock,
+				ScoreEventInvalidBlock,
+				ScoreEventInvalidBlock,
+				ScoreEventBlocksAccepted,
+			},
+			score:      -73,
+			lowScore:   true,
+			coolingOff: true,
+		},
+		{
+			name:     "trusted peers do not cool off",
+			trusted:  true,
+			events:   repeat(ScoreEventProtocolViolation, 6),
+			score:    -60,
+			lowScore: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPeer(testPeers[0])
+			p.Trusted = tc.trusted
+
+			for _, ev := range tc.events {
+				p.AdjustScore(ev)
+			}
+
+			require.Equal(t, tc.score, p.Score)
+			require.Equal(t, tc.lowScore, p.LowScoreAt != 0)
+			require.Equal(t, tc.coolingOff, p.IsCoolingOff())
+			if tc.coolingOff {
+				require.False(t, p.CanTry())
+			}
+		})
+	}
+}
+
+func TestPeerCooloffExpires(t *testing.T) {
+	p := NewPeer(testPeers[0])
+	for i := 0; i < 3; i++ {
+		p.AdjustScore(ScoreEventInvalidBlock)
+	}
+	require.True(t, p.IsCoolingOff())
+	require.False(t, p.CanTry())
+
+	p.LowScoreAt = time.Now().UTC().Add(-LowPeerScoreCooloff - time.Minute).Unix()
+	require.False(t, p.IsCoolingOff())
+	require.True(t, p.CanTry())
+
+	// Another negative event restarts the cooloff
+	p.AdjustScore(ScoreEventInvalidTxn)
+	require.True(t, p.IsCoolingOff())
+}
+
+func TestPeerlistRandomPrefersScore(t *testing.T) {
+	pl := newPeerlist()
+	pl.setPeers([]Peer{
+		{Addr: testPeers[0], Score: -10},
+		{Addr: testPeers[1], Score: 20},
+		{Addr: testPeers[2], Score: 5},
+		{Addr: testPeers[3], Score: -60, LowScoreAt: time.Now().UTC().Unix()},
+	})
+
+	for i := 0; i < 10; i++ {
+		ps := pl.random(2, nil)
+		require.Equal(t, []string{testPeers[1], testPeers[2]}, ps.ToAddrs())
+	}
+
+	// Peers that are cooling off are excluded
+	ps := pl.random(0, nil)
+	require.Equal(t, []string{testPeers[1], testPeers[2], testPeers[0]}, ps.ToAddrs())
+}
+
+func TestPeerlistSaveScore(t *testing.T) {
+	lowScoreAt := time.Now().UTC().Unix()
+	pl := newPeerlist()
+	pl.setPeers([]Peer{
+		{Addr: testPeers[0], Score: 12},
+		{Addr: testPeers[1], Score: -70, LowScoreAt: lowScoreAt},
+	})
+
+	pl.adjustScore(testPeers[0], ScoreEventBlocksAccepted)
+	// Unknown peers are ignored
+	pl.adjustScore(testPeers[2], ScoreEventInvalidBlock)
+	require.False(t, pl.hasPeer(testPeers[2]))
+
+	f, removeFile := preparePeerlistFile(t)
+	defer removeFile()
+	require.NoError(t, pl.save(f))
+
+	psMap, err := loadCachedPeersFile(f)
+	require.NoError(t, err)
+	require.Len(t, psMap, 2)
+	require.Equal(t, 14, psMap[testPeers[0]].Score)
+	require.Equal(t, int64(0), psMap[testPeers[0]].LowScoreAt)
+	require.Equal(t, -70, psMap[testPeers[1]].Score)
+	require.Equal(t, lowScoreAt, psMap[testPeers[1]].LowScoreAt)
+}
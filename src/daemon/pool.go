@@ -3,7 +3,7 @@ package daemon
 import (
 	"time"
 
-	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/daemon/gnet"
 )
 
 // PoolConfig pool config
@@ -30,10 +30,16 @@ type PoolConfig struct {
 	MaxDefaultPeerOutgoingConnections int
 	// Default "trusted" peers
 	DefaultConnections []string
+	// Peers that are always kept connected, exempt from the connection limits
+	AlwaysConnect []string
 	// Maximum length of incoming messages in bytes
 	MaxIncomingMessageLength int
 	// Maximum length of outgoing messages in bytes
 	MaxOutgoingMessageLength int
+	// Maximum upload rate of block transfers in bytes per second, 0 is unlimited
+	MaxUploadRate uint64
+	// Maximum download rate of block transfers in bytes per second, 0 is unlimited
+	MaxDownloadRate uint64
 	// These should be assigned by the controlling daemon
 	address string
 	port    int
@@ -59,6 +65,12 @@ func NewPoolConfig() PoolConfig {
 	}
 }
 
+// blockTransferMessages are the messages subject to the bandwidth limits.
+// Other messages, such as transaction relay and pings, are never throttled.
+var blockTransferMessages = []gnet.MessagePrefix{
+	gnet.MessagePrefixFromString("GIVB"),
+}
+
 // Pool maintains config and pool
 type Pool struct {
 	Config PoolConfig
@@ -78,8 +90,14 @@ func NewPool(cfg PoolConfig, d *Daemon) (*Pool, error) {
 	gnetCfg.MaxOutgoingConnections = cfg.MaxOutgoingConnections
 	gnetCfg.MaxDefaultPeerOutgoingConnections = cfg.MaxDefaultPeerOutgoingConnections
 	gnetCfg.DefaultConnections = cfg.DefaultConnections
+	gnetCfg.AlwaysConnect = cfg.AlwaysConnect
 	gnetCfg.MaxIncomingMessageLength = cfg.MaxIncomingMessageLength
 	gnetCfg.MaxOutgoingMessageLength = cfg.MaxOutgoingMessageLength
+	gnetCfg.MaxUploadRate = cfg.MaxUploadRate
+	gnetCfg.MaxDownloadRate = cfg.MaxDownloadRate
+	for _, prefix := range blockTransferMessages {
+		gnetCfg.ThrottledMessages[prefix] = struct{}{}
+	}
 
 	pool, err := gnet.NewConnectionPool(gnetCfg, d)
 	if err != nil {
@@ -102,7 +120,7 @@ func (pool *Pool) Shutdown() {
 
 // Run starts listening on the configured Port
 func (pool *Pool) Run() error {
-	logger.Infof("daemon.Pool listening on port %d", pool.Config.port)
+	logger.WithField("port", pool.Config.port).Info("daemon.Pool listening")
 	return pool.Pool.Run()
 }
 
@@ -111,10 +129,25 @@ func (pool *Pool) RunOffline() error {
 	return pool.Pool.RunOffline()
 }
 
-// sendPings send a ping if our last message sent was over pingRate ago
-func (pool *Pool) sendPings() {
-	if err := pool.Pool.SendPings(pool.Config.PingRate, &PingMessage{}); err != nil {
+// sendPings send a ping if our last message sent was over pingRate ago.
+// Connections for which skip returns true are not pinged
+func (pool *Pool) sendPings(skip func(addr string) bool) {
+	conns, err := pool.Pool.GetConnections()
+	if err != nil {
 		logger.WithError(err).Error("sendPings failed")
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, c := range conns {
+		addr := c.Addr()
+		if !c.LastSent.Add(pool.Config.PingRate).Before(now) || skip(addr) {
+			continue
+		}
+
+		if err := pool.Pool.SendMessage(addr, &PingMessage{}); err != nil {
+			logger.WithError(err).WithField("addr", addr).Error("sendPings failed")
+		}
 	}
 }
 
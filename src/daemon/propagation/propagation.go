@@ -0,0 +1,259 @@
+/*
+Package propagation measures how long transactions and blocks take to propagate through the network.
+
+A Tracker records when a transaction or block was first seen, and when each peer announced it afterwards.
+The records are kept in a bounded ring, the oldest record is dropped when the ring is full.
+*/
+package propagation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// Kind is the kind of object tracked
+type Kind string
+
+const (
+	// KindTransaction a transaction, keyed by txid
+	KindTransaction Kind = "transaction"
+	// KindBlock a block, keyed by block hash
+	KindBlock Kind = "block"
+
+	// DefaultSize is the default number of records kept by a Tracker
+	DefaultSize = 1000
+)
+
+// Announcement is a peer announcing an object after it was first seen
+type Announcement struct {
+	Addr    string
+	Time    time.Time
+	Latency time.Duration
+}
+
+// Percentiles are the announcement latencies of a tracked object
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Report is the propagation of a tracked object
+type Report struct {
+	Hash cipher.SHA256
+	Kind Kind
+	// Seq is the block sequence, for blocks
+	Seq       uint64
+	FirstSeen time.Time
+	// Origin is the address of the peer the object was first seen from, empty if it was created or injected locally
+	Origin string
+	// Announcements are ordered by latency
+	Announcements []Announcement
+	Percentiles   Percentiles
+}
+
+type record struct {
+	kind          Kind
+	seq           uint64
+	firstSeen     time.Time
+	origin        string
+	announcements map[string]time.Time
+}
+
+// Tracker records the propagation of transactions and blocks
+type Tracker struct {
+	sync.Mutex
+	size    int
+	records map[cipher.SHA256]*record
+	// ring holds the tracked hashes in insertion order, next is the position of the oldest once full
+	ring []cipher.SHA256
+	next int
+	// blocks maps the sequence of tracked blocks to their hash
+	blocks map[uint64]cipher.SHA256
+	now    func() time.Time
+}
+
+// NewTracker creates a Tracker keeping at most size records. If size is 0, DefaultSize is used.
+func NewTracker(size int) *Tracker {
+	if size <= 0 {
+		size = DefaultSize
+	}
+
+	return &Tracker{
+		size:    size,
+		records: make(map[cipher.SHA256]*record, size),
+		ring:    make([]cipher.SHA256, 0, size),
+		blocks:  make(map[uint64]cipher.SHA256),
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+// Size returns the maximum number of records kept
+func (t *Tracker) Size() int {
+	return t.size
+}
+
+// SeenTxn records that a transaction was seen, from the peer at addr or locally if addr is empty.
+// Nothing is recorded if the transaction is already tracked.
+func (t *Tracker) SeenTxn(txid cipher.SHA256, addr string) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.add(txid, &record{
+		kind:   KindTransaction,
+		origin: addr,
+	})
+}
+
+// SeenBlock records that a block was seen, from the peer at addr or locally if addr is empty.
+// Nothing is recorded if the block is already tracked.
+func (t *Tracker) SeenBlock(hash cipher.SHA256, seq uint64, addr string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.add(hash, &record{
+		kind:   KindBlock,
+		seq:    seq,
+		origin: addr,
+	}) {
+		t.blocks[seq] = hash
+	}
+}
+
+// add tracks a new record, dropping the oldest if the ring is full. Returns false if hash is already tracked.
+func (t *Tracker) add(hash cipher.SHA256, r *record) bool {
+	if _, ok := t.records[hash]; ok {
+		return false
+	}
+
+	if len(t.ring) < t.size {
+		t.ring = append(t.ring, hash)
+	} else {
+		t.remove(t.ring[t.next])
+		t.ring[t.next] = hash
+		t.next = (t.next + 1) % t.size
+	}
+
+	r.firstSeen = t.now()
+	r.announcements = make(map[string]time.Time)
+	t.records[hash] = r
+	return true
+}
+
+func (t *Tracker) remove(hash cipher.SHA256) {
+	r, ok := t.records[hash]
+	if !ok {
+		return
+	}
+
+	if r.kind == KindBlock && t.blocks[r.seq] == hash {
+		delete(t.blocks, r.seq)
+	}
+	delete(t.records, hash)
+}
+
+// AnnouncedTxns records that the peer at addr announced transactions.
+// Transactions that are not tracked yet are first seen from that peer.
+func (t *Tracker) AnnouncedTxns(txids []cipher.SHA256, addr string) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, txid := range txids {
+		if !t.add(txid, &record{
+			kind:   KindTransaction,
+			origin: addr,
+		}) {
+			t.announced(t.records[txid], addr)
+		}
+	}
+}
+
+// AnnouncedBlocks records that the peer at addr announced its head block sequence.
+// The peer has every tracked block up to that sequence.
+func (t *Tracker) AnnouncedBlocks(maxSeq uint64, addr string) {
+	t.Lock()
+	defer t.Unlock()
+
+	for seq, hash := range t.blocks {
+		if seq <= maxSeq {
+			t.announced(t.records[hash], addr)
+		}
+	}
+}
+
+// announced records the first announcement of a record by a peer other than its origin
+func (t *Tracker) announced(r *record, addr string) {
+	if addr == r.origin {
+		return
+	}
+	if _, ok := r.announcements[addr]; ok {
+		return
+	}
+	r.announcements[addr] = t.now()
+}
+
+// Report returns the propagation of a tracked transaction or block, or false if hash is not tracked
+func (t *Tracker) Report(hash cipher.SHA256) (*Report, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	r, ok := t.records[hash]
+	if !ok {
+		return nil, false
+	}
+
+	anns := make([]Announcement, 0, len(r.announcements))
+	for addr, at := range r.announcements {
+		anns = append(anns, Announcement{
+			Addr:    addr,
+			Time:    at,
+			Latency: at.Sub(r.firstSeen),
+		})
+	}
+
+	sort.Slice(anns, func(i, j int) bool {
+		if anns[i].Latency == anns[j].Latency {
+			return anns[i].Addr < anns[j].Addr
+		}
+		return anns[i].Latency < anns[j].Latency
+	})
+
+	return &Report{
+		Hash:          hash,
+		Kind:          r.kind,
+		Seq:           r.seq,
+		FirstSeen:     r.firstSeen,
+		Origin:        r.origin,
+		Announcements: anns,
+		Percentiles:   newPercentiles(anns),
+	}, true
+}
+
+// newPercentiles computes the nearest-rank percentiles of announcements ordered by latency
+func newPercentiles(anns []Announcement) Percentiles {
+	if len(anns) == 0 {
+		return Percentiles{}
+	}
+
+	rank := func(p int) time.Duration {
+		// ceil(p/100 * n), 1-based
+		i := (p*len(anns) + 99) / 100
+		if i < 1 {
+			i = 1
+		}
+		return anns[i-1].Latency
+	}
+
+	return Percentiles{
+		P50: rank(50),
+		P90: rank(90),
+		P99: rank(99),
+		Max: anns[len(anns)-1].Latency,
+	}
+}
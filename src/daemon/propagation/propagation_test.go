@@ -0,0 +1,154 @@
+package propagation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// fakeClock returns a clock that advances by one second on each call
+func fakeClock(start time.Time) func() time.Time {
+	t := start.Add(-time.Second)
+	return func() time.Time {
+		t = t.Add(time.Second)
+		return t
+	}
+}
+
+func TestTrackerTxn(t *testing.T) {
+	start := time.Unix(1500000000, 0).UTC()
+	tr := NewTracker(10)
+	tr.now = fakeClock(start)
+
+	txid := cipher.SumSHA256([]byte("txn"))
+
+	_, ok := tr.Report(txid)
+	require.False(t, ok)
+
+	tr.SeenTxn(txid, "")
+	// Seen again is ignored
+	tr.SeenTxn(txid, "1.1.1.1:6000")
+
+	tr.AnnouncedTxns([]cipher.SHA256{txid}, "2.2.2.2:6000")
+	tr.AnnouncedTxns([]cipher.SHA256{txid}, "3.3.3.3:6000")
+	// A repeated announcement keeps the first time
+	tr.AnnouncedTxns([]cipher.SHA256{txid}, "2.2.2.2:6000")
+
+	r, ok := tr.Report(txid)
+	require.True(t, ok)
+	require.Equal(t, &Report{
+		Hash:      txid,
+		Kind:      KindTransaction,
+		FirstSeen: start,
+		Announcements: []Announcement{
+			{
+				Addr:    "2.2.2.2:6000",
+				Time:    start.Add(time.Second),
+				Latency: time.Second,
+			},
+			{
+				Addr:    "3.3.3.3:6000",
+				Time:    start.Add(2 * time.Second),
+				Latency: 2 * time.Second,
+			},
+		},
+		Percentiles: Percentiles{
+			P50: time.Second,
+			P90: 2 * time.Second,
+			P99: 2 * time.Second,
+			Max: 2 * time.Second,
+		},
+	}, r)
+
+	// A transaction first announced by a peer is seen from it, and its origin's announcements are ignored
+	other := cipher.SumSHA256([]byte("other"))
+	tr.AnnouncedTxns([]cipher.SHA256{other}, "2.2.2.2:6000")
+	tr.AnnouncedTxns([]cipher.SHA256{other}, "2.2.2.2:6000")
+	r, ok = tr.Report(other)
+	require.True(t, ok)
+	require.Equal(t, "2.2.2.2:6000", r.Origin)
+	require.Empty(t, r.Announcements)
+	require.Equal(t, Percentiles{}, r.Percentiles)
+}
+
+func TestTrackerBlock(t *testing.T) {
+	start := time.Unix(1500000000, 0).UTC()
+	tr := NewTracker(10)
+	tr.now = fakeClock(start)
+
+	b1 := cipher.SumSHA256([]byte("b1"))
+	b2 := cipher.SumSHA256([]byte("b2"))
+
+	tr.SeenBlock(b1, 1, "1.1.1.1:6000")
+	tr.SeenBlock(b2, 2, "")
+
+	// The peer has block 1 but not block 2
+	tr.AnnouncedBlocks(1, "2.2.2.2:6000")
+	// The origin of block 1 announces both
+	tr.AnnouncedBlocks(2, "1.1.1.1:6000")
+
+	r, ok := tr.Report(b1)
+	require.True(t, ok)
+	require.Equal(t, KindBlock, r.Kind)
+	require.Equal(t, uint64(1), r.Seq)
+	require.Equal(t, "1.1.1.1:6000", r.Origin)
+	require.Len(t, r.Announcements, 1)
+	require.Equal(t, "2.2.2.2:6000", r.Announcements[0].Addr)
+	require.Equal(t, 2*time.Second, r.Announcements[0].Latency)
+
+	r, ok = tr.Report(b2)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), r.Seq)
+	require.Len(t, r.Announcements, 1)
+	require.Equal(t, "1.1.1.1:6000", r.Announcements[0].Addr)
+	require.Equal(t, 2*time.Second, r.Announcements[0].Latency)
+}
+
+func TestTrackerRing(t *testing.T) {
+	tr := NewTracker(3)
+
+	hashes := make([]cipher.SHA256, 5)
+	for i := range hashes {
+		hashes[i] = cipher.SumSHA256([]byte{byte(i)})
+	}
+
+	tr.SeenBlock(hashes[0], 0, "")
+	for _, h := range hashes[1:] {
+		tr.SeenTxn(h, "")
+	}
+
+	for i, h := range hashes {
+		_, ok := tr.Report(h)
+		require.Equal(t, i >= 2, ok, "hash %d", i)
+	}
+
+	// The dropped block is no longer announced
+	require.Empty(t, tr.blocks)
+	require.Len(t, tr.records, 3)
+
+	require.Equal(t, DefaultSize, NewTracker(0).Size())
+}
+
+func TestNewPercentiles(t *testing.T) {
+	anns := make([]Announcement, 200)
+	for i := range anns {
+		anns[i].Latency = time.Duration(i+1) * time.Millisecond
+	}
+
+	require.Equal(t, Percentiles{
+		P50: 100 * time.Millisecond,
+		P90: 180 * time.Millisecond,
+		P99: 198 * time.Millisecond,
+		Max: 200 * time.Millisecond,
+	}, newPercentiles(anns))
+
+	require.Equal(t, Percentiles{
+		P50: time.Millisecond,
+		P90: time.Millisecond,
+		P99: time.Millisecond,
+		Max: time.Millisecond,
+	}, newPercentiles(anns[:1]))
+}
@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Peers negotiate the protocol version of a connection in their IntroductionMessage.
+Each peer advertises the range of protocol versions it supports, ProtocolVersion being
+the highest and the MinProtocolVersion of the introduction extra data the lowest,
+and the connection operates at the highest version in both ranges.
+Peers with no version in common disconnect with ErrDisconnectVersionNotSupported.
+
+Peers that don't advertise a minimum version are assumed to support any version up to
+their ProtocolVersion, since they check our version themselves.
+
+Behavior that depends on the protocol version must check ConnectionDetails.SupportsProtocolVersion,
+so that a node keeps talking to peers of older versions, instead of comparing versions for equality.
+
+Optional capabilities that are independent of the protocol version are advertised as ProtocolFeatures bits,
+and are used on a connection only if both peers support them, see ConnectionDetails.HasProtocolFeature.
+*/
+
+// ProtocolFeatures is a set of optional protocol capabilities, one bit per capability.
+// Bits are assigned as capabilities are added and are never reused.
+type ProtocolFeatures uint64
+
+const (
+	// ProtocolFeatureKeepalive peers ping idle connections with KeepalivePingMessage
+	// and measure the round trip time, instead of sending PingMessage
+	ProtocolFeatureKeepalive ProtocolFeatures = 1 << 0
+)
+
+// Has returns true if fs has all the features of f
+func (fs ProtocolFeatures) Has(f ProtocolFeatures) bool {
+	return fs&f == f
+}
+
+// String returns the set bits of fs, e.g. "0,3"
+func (fs ProtocolFeatures) String() string {
+	var bits []string
+	for i := uint(0); i < 64; i++ {
+		if fs&(1<<i) != 0 {
+			bits = append(bits, fmt.Sprint(i))
+		}
+	}
+	return strings.Join(bits, ",")
+}
+
+// negotiateProtocolVersion returns the highest protocol version in both the [minVersion, maxVersion]
+// and [peerMinVersion, peerMaxVersion] ranges. Returns false if the ranges don't overlap.
+func negotiateProtocolVersion(minVersion, maxVersion, peerMinVersion, peerMaxVersion int32) (int32, bool) {
+	v := maxVersion
+	if peerMaxVersion < v {
+		v = peerMaxVersion
+	}
+
+	if v < minVersion || v < peerMinVersion {
+		return 0, false
+	}
+
+	return v, true
+}
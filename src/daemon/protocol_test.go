@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	cases := []struct {
+		name                           string
+		minVersion, maxVersion         int32
+		peerMinVersion, peerMaxVersion int32
+		version                        int32
+		ok                             bool
+	}{
+		{
+			name:           "same range",
+			minVersion:     2,
+			maxVersion:     3,
+			peerMinVersion: 2,
+			peerMaxVersion: 3,
+			version:        3,
+			ok:             true,
+		},
+		{
+			name:           "peer is newer",
+			minVersion:     2,
+			maxVersion:     3,
+			peerMinVersion: 3,
+			peerMaxVersion: 5,
+			version:        3,
+			ok:             true,
+		},
+		{
+			name:           "peer is older",
+			minVersion:     2,
+			maxVersion:     5,
+			peerMinVersion: 1,
+			peerMaxVersion: 2,
+			version:        2,
+			ok:             true,
+		},
+		{
+			name:           "peer did not advertise a min version",
+			minVersion:     2,
+			maxVersion:     3,
+			peerMaxVersion: 4,
+			version:        3,
+			ok:             true,
+		},
+		{
+			name:           "peer is too old",
+			minVersion:     3,
+			maxVersion:     4,
+			peerMinVersion: 1,
+			peerMaxVersion: 2,
+		},
+		{
+			name:           "peer is too new",
+			minVersion:     1,
+			maxVersion:     2,
+			peerMinVersion: 3,
+			peerMaxVersion: 4,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, ok := negotiateProtocolVersion(tc.minVersion, tc.maxVersion, tc.peerMinVersion, tc.peerMaxVersion)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.version, version)
+		})
+	}
+}
+
+func TestProtocolFeatures(t *testing.T) {
+	fs := ProtocolFeatures(1 | 1<<3)
+	require.True(t, fs.Has(1))
+	require.True(t, fs.Has(1<<3))
+	require.True(t, fs.Has(1|1<<3))
+	require.True(t, fs.Has(0))
+	require.False(t, fs.Has(1<<1))
+	require.False(t, fs.Has(1|1<<1))
+	require.Equal(t, "0,3", fs.String())
+	require.Equal(t, "", ProtocolFeatures(0).String())
+	require.Equal(t, "63", ProtocolFeatures(1<<63).String())
+}
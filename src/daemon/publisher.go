@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// StartPublishing arms the block publisher role with the blockchain secret key and starts the block creation loop.
+// The secret key is only held in memory, it is forgotten when the role is disarmed or the node stops.
+func (dm *Daemon) StartPublishing(seckey cipher.SecKey) error {
+	if err := dm.visor.ArmBlockPublisher(seckey); err != nil {
+		return err
+	}
+
+	dm.signalPublisherChanged()
+	return nil
+}
+
+// StopPublishing disarms the block publisher role and stops the block creation loop.
+// A block being created is executed and published before the role is disarmed, no block is created afterwards.
+func (dm *Daemon) StopPublishing() error {
+	if err := dm.visor.DisarmBlockPublisher(); err != nil {
+		return err
+	}
+
+	dm.signalPublisherChanged()
+	return nil
+}
+
+// signalPublisherChanged signals the block creation loop that the block publisher role changed,
+// without blocking if a signal is already pending
+func (dm *Daemon) signalPublisherChanged() {
+	select {
+	case dm.publisherChanged <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,83 @@
+package wire
+
+import "fmt"
+
+// Disconnect reason codes.
+// The codes of gnet errors, 1001 and above, are not sent in Disconnect messages,
+// gnet closes the connection without sending a Disconnect message.
+const (
+	DisconnectUnknownReason                 uint16 = 0
+	DisconnectVersionNotSupported           uint16 = 1
+	DisconnectIntroductionTimeout           uint16 = 2
+	DisconnectIsBlacklisted                 uint16 = 3
+	DisconnectSelf                          uint16 = 4
+	DisconnectConnectedTwice                uint16 = 5
+	DisconnectIdle                          uint16 = 6
+	DisconnectNoIntroduction                uint16 = 7
+	DisconnectIPLimitReached                uint16 = 8
+	DisconnectUnexpectedError               uint16 = 9
+	DisconnectMaxOutgoingConnectionsReached uint16 = 10
+	DisconnectBlockchainPubkeyNotMatched    uint16 = 11
+	DisconnectInvalidExtraData              uint16 = 12
+	DisconnectReceivedDisconnect            uint16 = 13
+	DisconnectInvalidUserAgent              uint16 = 14
+	DisconnectRequestedByOperator           uint16 = 15
+	DisconnectPeerlistFull                  uint16 = 16
+	DisconnectInvalidBurnFactor             uint16 = 17
+	DisconnectInvalidMaxTransactionSize     uint16 = 18
+	DisconnectInvalidMaxDropletPrecision    uint16 = 19
+	DisconnectNodeShutdown                  uint16 = 20
+	DisconnectKeepaliveTimeout              uint16 = 21
+	DisconnectSlowMessageHandler            uint16 = 22
+
+	DisconnectSetReadDeadlineFailed  uint16 = 1001
+	DisconnectInvalidMessageLength   uint16 = 1002
+	DisconnectMalformedMessage       uint16 = 1003
+	DisconnectUnknownMessage         uint16 = 1004
+	DisconnectShutdown               uint16 = 1005
+	DisconnectMessageDecodeUnderflow uint16 = 1006
+	DisconnectTruncatedMessageID     uint16 = 1007
+)
+
+var disconnectCodeNames = map[uint16]string{
+	DisconnectUnknownReason:                 "UnknownReason",
+	DisconnectVersionNotSupported:           "VersionNotSupported",
+	DisconnectIntroductionTimeout:           "IntroductionTimeout",
+	DisconnectIsBlacklisted:                 "IsBlacklisted",
+	DisconnectSelf:                          "Self",
+	DisconnectConnectedTwice:                "ConnectedTwice",
+	DisconnectIdle:                          "Idle",
+	DisconnectNoIntroduction:                "NoIntroduction",
+	DisconnectIPLimitReached:                "IPLimitReached",
+	DisconnectUnexpectedError:               "UnexpectedError",
+	DisconnectMaxOutgoingConnectionsReached: "MaxOutgoingConnectionsReached",
+	DisconnectBlockchainPubkeyNotMatched:    "BlockchainPubkeyNotMatched",
+	DisconnectInvalidExtraData:              "InvalidExtraData",
+	DisconnectReceivedDisconnect:            "ReceivedDisconnect",
+	DisconnectInvalidUserAgent:              "InvalidUserAgent",
+	DisconnectRequestedByOperator:           "RequestedByOperator",
+	DisconnectPeerlistFull:                  "PeerlistFull",
+	DisconnectInvalidBurnFactor:             "InvalidBurnFactor",
+	DisconnectInvalidMaxTransactionSize:     "InvalidMaxTransactionSize",
+	DisconnectInvalidMaxDropletPrecision:    "InvalidMaxDropletPrecision",
+	DisconnectNodeShutdown:                  "NodeShutdown",
+	DisconnectKeepaliveTimeout:              "KeepaliveTimeout",
+	DisconnectSlowMessageHandler:            "SlowMessageHandler",
+
+	DisconnectSetReadDeadlineFailed:  "SetReadDeadlineFailed",
+	DisconnectInvalidMessageLength:   "InvalidMessageLength",
+	DisconnectMalformedMessage:       "MalformedMessage",
+	DisconnectUnknownMessage:         "UnknownMessage",
+	DisconnectShutdown:               "Shutdown",
+	DisconnectMessageDecodeUnderflow: "MessageDecodeUnderflow",
+	DisconnectTruncatedMessageID:     "TruncatedMessageID",
+}
+
+// DisconnectCodeName returns the name of a disconnect reason code, e.g. "NoIntroduction (7)"
+func DisconnectCodeName(code uint16) string {
+	name, ok := disconnectCodeNames[code]
+	if !ok {
+		name = "Unknown"
+	}
+	return fmt.Sprintf("%s (%d)", name, code)
+}
@@ -0,0 +1,109 @@
+package wire
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/params"
+)
+
+const (
+	// verifyTxnLen is the encoded length of params.VerifyTxn
+	verifyTxnLen = 9
+	// MaxUserAgentLen is the maximum length of the user agent in the introduction extra data
+	MaxUserAgentLen = 256
+	// IntroductionProtocolExtraLen is the encoded length of IntroductionProtocolExtra
+	IntroductionProtocolExtraLen = 12
+)
+
+var (
+	// ErrIntroductionExtraTooShort the introduction extra data ends in the middle of a field
+	ErrIntroductionExtraTooShort = errors.New("Introduction extra data is too short")
+	// ErrIntroductionExtraUserAgent the user agent of the introduction extra data can't be decoded
+	ErrIntroductionExtraUserAgent = errors.New("Introduction extra data user agent is invalid")
+)
+
+// IntroductionProtocolExtra is the part of the introduction extra data that follows the genesis hash,
+// the lowest protocol version and the optional protocol features supported by the peer
+type IntroductionProtocolExtra struct {
+	MinProtocolVersion int32
+	Features           uint64
+}
+
+// IntroductionExtra is the decoded Introduction.Extra.
+// Its fields are encoded in order, peers of older versions omit the trailing fields.
+type IntroductionExtra struct {
+	Pubkey      cipher.PubKey
+	VerifyTxn   params.VerifyTxn
+	UserAgent   string
+	GenesisHash cipher.SHA256
+	// HasProtocolExtra is true if Protocol was sent
+	HasProtocolExtra bool
+	Protocol         IntroductionProtocolExtra
+}
+
+// EncodeIntroductionExtra encodes the introduction extra data up to the genesis hash.
+// The values are not validated.
+func EncodeIntroductionExtra(pubkey cipher.PubKey, userAgent string, verifyTxn params.VerifyTxn, genesisHash cipher.SHA256) []byte {
+	userAgentSerialized := encoder.SerializeString(userAgent)
+	verifyTxnSerialized := encoder.Serialize(verifyTxn)
+
+	extra := make([]byte, 0, len(pubkey)+len(verifyTxnSerialized)+len(userAgentSerialized)+len(genesisHash))
+	extra = append(extra, pubkey[:]...)
+	extra = append(extra, verifyTxnSerialized...)
+	extra = append(extra, userAgentSerialized...)
+	return append(extra, genesisHash[:]...)
+}
+
+// Encode encodes the introduction extra data, including the protocol extra if HasProtocolExtra is set
+func (e IntroductionExtra) Encode() []byte {
+	extra := EncodeIntroductionExtra(e.Pubkey, e.UserAgent, e.VerifyTxn, e.GenesisHash)
+	if e.HasProtocolExtra {
+		extra = append(extra, encoder.Serialize(e.Protocol)...)
+	}
+	return extra
+}
+
+// DecodeIntroductionExtra decodes the introduction extra data sent by a peer since v0.26.0,
+// which must include the genesis hash. Data after the protocol extra is ignored.
+// The values are not validated.
+func DecodeIntroductionExtra(extra []byte) (*IntroductionExtra, error) {
+	var e IntroductionExtra
+
+	if len(extra) < len(e.Pubkey)+verifyTxnLen {
+		return nil, ErrIntroductionExtraTooShort
+	}
+	i := copy(e.Pubkey[:], extra)
+
+	if err := encoder.DeserializeRawExact(extra[i:i+verifyTxnLen], &e.VerifyTxn); err != nil {
+		return nil, err
+	}
+	i += verifyTxnLen
+
+	userAgent, n, err := encoder.DeserializeString(extra[i:], MaxUserAgentLen)
+	if err != nil {
+		return nil, ErrIntroductionExtraUserAgent
+	}
+	e.UserAgent = userAgent
+	i += int(n)
+
+	if len(extra)-i < len(e.GenesisHash) {
+		return nil, ErrIntroductionExtraTooShort
+	}
+	i += copy(e.GenesisHash[:], extra[i:])
+
+	if i == len(extra) {
+		return &e, nil
+	}
+
+	if len(extra)-i < IntroductionProtocolExtraLen {
+		return nil, ErrIntroductionExtraTooShort
+	}
+	if err := encoder.DeserializeRawExact(extra[i:i+IntroductionProtocolExtraLen], &e.Protocol); err != nil {
+		return nil, err
+	}
+	e.HasProtocolExtra = true
+
+	return &e, nil
+}
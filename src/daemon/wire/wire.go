@@ -0,0 +1,186 @@
+/*
+Package wire implements the framing and encoding of the peer messages of the daemon.
+
+It does not depend on the daemon, gnet or pex, so that tools can speak the peer protocol
+without running a daemon. The daemon encodes its messages identically to the messages of this package.
+
+A frame is a uint32 little-endian length, followed by the 4 byte message ID and the encoded message.
+The length covers the message ID and the encoded message.
+*/
+package wire
+
+import (
+	"errors"
+	"io"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// Message IDs
+const (
+	IDIntroduction   = "INTR"
+	IDGetPeers       = "GETP"
+	IDGivePeers      = "GIVP"
+	IDPing           = "PING"
+	IDPong           = "PONG"
+	IDGetBlocks      = "GETB"
+	IDGiveBlocks     = "GIVB"
+	IDAnnounceBlocks = "ANNB"
+	IDGetTxns        = "GETT"
+	IDGiveTxns       = "GIVT"
+	IDAnnounceTxns   = "ANNT"
+	IDDisconnect     = "DISC"
+	IDKeepalivePing  = "KPIN"
+	IDKeepalivePong  = "KPON"
+)
+
+const (
+	// IDLen is the length of a message ID
+	IDLen = 4
+	// LengthPrefixLen is the length of the length prefix of a frame
+	LengthPrefixLen = 4
+	// DefaultMaxMessageLength is the default maximum length of a received frame, excluding the length prefix
+	DefaultMaxMessageLength = 1024 * 1024
+
+	// MaxGivePeers is the maximum number of peers in a GivePeers message
+	MaxGivePeers = 512
+	// MaxGiveBlocks is the maximum number of blocks in a GiveBlocks message
+	MaxGiveBlocks = 128
+	// MaxTxnHashes is the maximum number of hashes in AnnounceTxns and GetTxns messages
+	MaxTxnHashes = 256
+)
+
+var (
+	// ErrInvalidID the message ID is not IDLen bytes long
+	ErrInvalidID = errors.New("Message ID must be 4 bytes long")
+	// ErrInvalidLength the length prefix of a frame is less than IDLen or exceeds the max message length
+	ErrInvalidLength = errors.New("Invalid message length")
+)
+
+// EncodeFrame frames the encoded message body with the message ID
+func EncodeFrame(id string, body []byte) ([]byte, error) {
+	if len(id) != IDLen {
+		return nil, ErrInvalidID
+	}
+
+	b := make([]byte, 0, LengthPrefixLen+IDLen+len(body))
+	b = append(b, encoder.SerializeUint32(uint32(IDLen+len(body)))...)
+	b = append(b, id...)
+	return append(b, body...), nil
+}
+
+// Encode frames a message. msg is nil for messages without fields, e.g. Ping
+func Encode(id string, msg interface{}) ([]byte, error) {
+	var body []byte
+	if msg != nil {
+		body = encoder.Serialize(msg)
+	}
+	return EncodeFrame(id, body)
+}
+
+// ReadFrame reads a frame from r, returning the message ID and the encoded message body.
+// Returns ErrInvalidLength if the length prefix is less than IDLen or greater than maxLen.
+func ReadFrame(r io.Reader, maxLen int) (string, []byte, error) {
+	prefix := make([]byte, LengthPrefixLen)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return "", nil, err
+	}
+
+	length, _, err := encoder.DeserializeUint32(prefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if length < IDLen || uint64(length) > uint64(maxLen) {
+		return "", nil, ErrInvalidLength
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", nil, err
+	}
+
+	return string(data[:IDLen]), data[IDLen:], nil
+}
+
+// Decode decodes an encoded message body into msg, which must be a pointer.
+// All of body must be consumed.
+func Decode(body []byte, msg interface{}) error {
+	return encoder.DeserializeRawExact(body, msg)
+}
+
+// Introduction is sent on first connect by both parties
+type Introduction struct {
+	// Mirror is a random value used to identify self-connections
+	Mirror uint32
+	// ListenPort is the port that the peer is listening on
+	ListenPort uint16
+	// ProtocolVersion is the highest protocol version supported by the peer
+	ProtocolVersion int32
+	// Extra is the encoded IntroductionExtra
+	Extra []byte `enc:",omitempty"`
+}
+
+// IPAddr is an IPv4 address and port of a peer in a GivePeers message
+type IPAddr struct {
+	IP   uint32
+	Port uint16
+}
+
+// GivePeers sends peers to a peer
+type GivePeers struct {
+	Peers []IPAddr `enc:",maxlen=512"`
+}
+
+// GetBlocks requests the blocks after LastBlock
+type GetBlocks struct {
+	LastBlock       uint64
+	RequestedBlocks uint64
+}
+
+// GiveBlocks sends blocks to a peer
+type GiveBlocks struct {
+	Blocks []coin.SignedBlock `enc:",maxlen=128"`
+}
+
+// AnnounceBlocks tells a peer the sequence of our head block
+type AnnounceBlocks struct {
+	MaxBkSeq uint64
+}
+
+// GetTxns requests transactions by hash
+type GetTxns struct {
+	Transactions []cipher.SHA256 `enc:",maxlen=256"`
+}
+
+// GiveTxns sends transactions to a peer
+type GiveTxns struct {
+	Transactions []coin.Transaction `enc:",maxlen=256"`
+}
+
+// AnnounceTxns tells a peer the hashes of our unconfirmed transactions
+type AnnounceTxns struct {
+	Transactions []cipher.SHA256 `enc:",maxlen=256"`
+}
+
+// KeepalivePing is sent on an idle connection to check that the peer is alive, if both peers support keepalives
+type KeepalivePing struct {
+	Nonce uint64
+}
+
+// KeepalivePong is sent in reply to a KeepalivePing, with the nonce of the ping
+type KeepalivePong struct {
+	Nonce uint64
+}
+
+// Disconnect is sent before closing the connection, with the reason code
+type Disconnect struct {
+	ReasonCode uint16
+	// Reserved for future use
+	Reserved []byte
+}
@@ -0,0 +1,214 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/params"
+)
+
+var (
+	introPubKey      = cipher.MustPubKeyFromHex("03cd7dfcd8c3452d1bb5d9d9e34dd95d6848cb9f66c2aad127b60578f4be7498f2")
+	introGenesisHash = cipher.MustSHA256FromHex("9afa0004c0ae04fae7c48e3bc0a324c51100de9508ae6048ebdb6652dc94f0e2")
+	introVerifyTxn   = params.VerifyTxn{
+		BurnFactor:          2,
+		MaxTransactionSize:  32768,
+		MaxDropletPrecision: 3,
+	}
+)
+
+// The messages of this package must be encoded like the daemon's messages, which are recorded in the daemon's golden files
+func TestDaemonGoldenFiles(t *testing.T) {
+	cases := []struct {
+		goldenFile string
+		obj        interface{}
+		msg        interface{}
+	}{
+		{
+			goldenFile: "intro-msg.golden",
+			obj:        &Introduction{},
+			msg: &Introduction{
+				Mirror:          99998888,
+				ListenPort:      8888,
+				ProtocolVersion: 12341234,
+			},
+		},
+		{
+			goldenFile: "intro-msg-extra.golden",
+			obj:        &Introduction{},
+			msg: &Introduction{
+				Mirror:          99998888,
+				ListenPort:      8888,
+				ProtocolVersion: 12341234,
+				Extra:           EncodeIntroductionExtra(introPubKey, "skycoin:0.26.0(foo)", introVerifyTxn, introGenesisHash),
+			},
+		},
+		{
+			goldenFile: "give-peers-msg.golden",
+			obj:        &GivePeers{},
+			msg: &GivePeers{
+				Peers: []IPAddr{
+					{
+						IP:   12345678,
+						Port: 1234,
+					},
+					{
+						IP:   87654321,
+						Port: 4321,
+					},
+				},
+			},
+		},
+		{
+			goldenFile: "get-blocks-msg.golden",
+			obj:        &GetBlocks{},
+			msg: &GetBlocks{
+				LastBlock:       999988887777,
+				RequestedBlocks: 888899997777,
+			},
+		},
+		{
+			goldenFile: "announce-blocks-msg.golden",
+			obj:        &AnnounceBlocks{},
+		},
+		{
+			goldenFile: "keepalive-ping-msg.golden",
+			obj:        &KeepalivePing{},
+			msg: &KeepalivePing{
+				Nonce: 12345678,
+			},
+		},
+		{
+			goldenFile: "keepalive-pong-msg.golden",
+			obj:        &KeepalivePong{},
+			msg: &KeepalivePong{
+				Nonce: 12345678,
+			},
+		},
+		{
+			goldenFile: "announce-txns-msg.golden",
+			obj:        &AnnounceTxns{},
+		},
+		{
+			goldenFile: "get-txns-msg.golden",
+			obj:        &GetTxns{},
+		},
+		{
+			goldenFile: "give-blocks-msg.golden",
+			obj:        &GiveBlocks{},
+		},
+		{
+			goldenFile: "give-txns-msg.golden",
+			obj:        &GiveTxns{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.goldenFile, func(t *testing.T) {
+			d, err := ioutil.ReadFile(filepath.Join("../testdata", tc.goldenFile))
+			require.NoError(t, err)
+
+			err = Decode(d, tc.obj)
+			require.NoError(t, err)
+
+			if tc.msg != nil {
+				require.Equal(t, tc.msg, tc.obj)
+			}
+
+			require.Equal(t, d, encoder.Serialize(tc.obj))
+		})
+	}
+}
+
+func TestEncodeReadFrame(t *testing.T) {
+	msg := &GetBlocks{
+		LastBlock:       10,
+		RequestedBlocks: 20,
+	}
+
+	b, err := Encode(IDGetBlocks, msg)
+	require.NoError(t, err)
+	require.Len(t, b, LengthPrefixLen+IDLen+16)
+
+	ping, err := Encode(IDPing, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{4, 0, 0, 0, 'P', 'I', 'N', 'G'}, ping)
+
+	r := bytes.NewReader(append(b, ping...))
+
+	id, body, err := ReadFrame(r, DefaultMaxMessageLength)
+	require.NoError(t, err)
+	require.Equal(t, IDGetBlocks, id)
+	var msg2 GetBlocks
+	err = Decode(body, &msg2)
+	require.NoError(t, err)
+	require.Equal(t, *msg, msg2)
+
+	id, body, err = ReadFrame(r, DefaultMaxMessageLength)
+	require.NoError(t, err)
+	require.Equal(t, IDPing, id)
+	require.Empty(t, body)
+
+	_, _, err = ReadFrame(r, DefaultMaxMessageLength)
+	require.Equal(t, io.EOF, err)
+
+	_, err = EncodeFrame("FOO", nil)
+	require.Equal(t, ErrInvalidID, err)
+
+	// The length must cover the message ID
+	_, _, err = ReadFrame(bytes.NewReader([]byte{3, 0, 0, 0, 'P', 'I', 'N'}), DefaultMaxMessageLength)
+	require.Equal(t, ErrInvalidLength, err)
+
+	// The length must not exceed maxLen
+	_, _, err = ReadFrame(bytes.NewReader(b), LengthPrefixLen+15)
+	require.Equal(t, ErrInvalidLength, err)
+
+	// The frame is truncated
+	_, _, err = ReadFrame(bytes.NewReader(b[:len(b)-1]), DefaultMaxMessageLength)
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestIntroductionExtra(t *testing.T) {
+	e := IntroductionExtra{
+		Pubkey:           introPubKey,
+		VerifyTxn:        introVerifyTxn,
+		UserAgent:        "skycoin:0.26.0(foo)",
+		GenesisHash:      introGenesisHash,
+		HasProtocolExtra: true,
+		Protocol: IntroductionProtocolExtra{
+			MinProtocolVersion: 2,
+			Features:           math.MaxUint64,
+		},
+	}
+
+	extra := e.Encode()
+	e2, err := DecodeIntroductionExtra(extra)
+	require.NoError(t, err)
+	require.Equal(t, e, *e2)
+
+	// Data after the protocol extra is ignored
+	e2, err = DecodeIntroductionExtra(append(extra, 1, 2, 3))
+	require.NoError(t, err)
+	require.Equal(t, e, *e2)
+
+	// The protocol extra is optional
+	e.HasProtocolExtra = false
+	e.Protocol = IntroductionProtocolExtra{}
+	e2, err = DecodeIntroductionExtra(e.Encode())
+	require.NoError(t, err)
+	require.Equal(t, e, *e2)
+
+	base := len(e.Encode())
+	for _, n := range []int{0, 32, 33 + 8, base - 1, base + 1, base + IntroductionProtocolExtraLen - 1} {
+		_, err := DecodeIntroductionExtra(extra[:n])
+		require.Error(t, err, "len %d", n)
+	}
+}
@@ -5,11 +5,16 @@ package fiber
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
+
+	"github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 // Config records fiber coin parameters
@@ -38,6 +43,9 @@ type NodeConfig struct {
 	GenesisTimestamp uint64 `mapstructure:"genesis_timestamp"`
 	// GenesisCoinVolume is the total number of coins in the genesis block
 	GenesisCoinVolume uint64 `mapstructure:"genesis_coin_volume"`
+	// GenesisOutputs are the outputs of a multi-output genesis block, which distributes GenesisCoinVolume at genesis.
+	// If set, the genesis block has no output to GenesisAddressStr
+	GenesisOutputs []GenesisOutput `mapstructure:"genesis_outputs"`
 	// DefaultConnections are the default "trusted" connections a node will try to connect to for bootstrapping
 	DefaultConnections []string `mapstructure:"default_connections"`
 	// PeerlistURL is a URL pointing to a newline-separated list of ip:ports that are used for bootstrapping (but they are not "trusted")
@@ -57,6 +65,9 @@ type NodeConfig struct {
 	CreateBlockMaxDropletPrecision uint8 `mapstructure:"create_block_max_decimals"`
 	// MaxBlockTransactionsSize is the maximum total size of transactions in a block when publishing a block
 	MaxBlockTransactionsSize uint32 `mapstructure:"max_block_transactions_size"`
+	// BurnFactorSchedule changes the user burn factor at scheduled block times.
+	// The unconfirmed and create block burn factors keep their ratio to the user burn factor.
+	BurnFactorSchedule []BurnFactorChange `mapstructure:"burn_factor_schedule"`
 
 	// DisplayName is the display name of the coin in the wallet e.g. Skycoin
 	DisplayName string `mapstructure:"display_name"`
@@ -80,6 +91,92 @@ type NodeConfig struct {
 	DataDirectory string
 }
 
+// GenesisOutput is an output of a multi-output genesis block
+type GenesisOutput struct {
+	// Address is the skycoin address that receives the output
+	Address string `mapstructure:"address"`
+	// Coins are the coins of the output, in droplets
+	Coins uint64 `mapstructure:"coins"`
+}
+
+// GenesisTransactionOutputs returns the GenesisOutputs as transaction outputs, with zero coin hours.
+// The outputs are verified with coin.VerifyGenesisOutputs against GenesisCoinVolume.
+// Returns nil if the genesis block has a single output.
+func (c NodeConfig) GenesisTransactionOutputs() ([]coin.TransactionOutput, error) {
+	if len(c.GenesisOutputs) == 0 {
+		return nil, nil
+	}
+
+	outputs := make([]coin.TransactionOutput, len(c.GenesisOutputs))
+	for i, o := range c.GenesisOutputs {
+		addr, err := cipher.DecodeBase58Address(o.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis output %d address %q: %v", i, o.Address, err)
+		}
+
+		outputs[i] = coin.TransactionOutput{
+			Address: addr,
+			Coins:   o.Coins,
+		}
+	}
+
+	if err := coin.VerifyGenesisOutputs(outputs, c.GenesisCoinVolume); err != nil {
+		return nil, err
+	}
+
+	return outputs, nil
+}
+
+// NewGenesisBlock creates the genesis block, with the GenesisOutputs if set,
+// otherwise with a single output of GenesisCoinVolume to GenesisAddressStr
+func (c NodeConfig) NewGenesisBlock() (*coin.Block, error) {
+	outputs, err := c.GenesisTransactionOutputs()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(outputs) != 0 {
+		return coin.NewGenesisBlockMulti(outputs, c.GenesisTimestamp)
+	}
+
+	addr, err := cipher.DecodeBase58Address(c.GenesisAddressStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis address %q: %v", c.GenesisAddressStr, err)
+	}
+
+	return coin.NewGenesisBlock(addr, c.GenesisCoinVolume, c.GenesisTimestamp)
+}
+
+// BurnFactorChange is a burn factor that applies to transactions verified against
+// a blockchain head with a time greater than or equal to Time
+type BurnFactorChange struct {
+	// Time is the block time at which the burn factor changes
+	Time uint64 `mapstructure:"time"`
+	// BurnFactor is the burn factor that applies from Time
+	BurnFactor uint32 `mapstructure:"burn_factor"`
+}
+
+// BurnFactorChanges returns the BurnFactorSchedule as fee.BurnFactorChanges, sorted by time
+func (c NodeConfig) BurnFactorChanges() []fee.BurnFactorChange {
+	if len(c.BurnFactorSchedule) == 0 {
+		return nil
+	}
+
+	changes := make([]fee.BurnFactorChange, len(c.BurnFactorSchedule))
+	for i, s := range c.BurnFactorSchedule {
+		changes[i] = fee.BurnFactorChange{
+			Time:       s.Time,
+			BurnFactor: s.BurnFactor,
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Time < changes[j].Time
+	})
+
+	return changes
+}
+
 // ParamsConfig are the parameters used to generate params/params.go.
 // These parameters are exposed in an importable package `params` because they
 // may need to be imported by libraries that would not know the node's configured CLI options.
@@ -5,7 +5,11 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
+
+	"github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 // TODO(therealssj): write better tests
@@ -46,6 +50,10 @@ func TestNewConfig(t *testing.T) {
 			ExplorerURL:                    "https://explorer.testcoin.com",
 			VersionURL:                     "https://version.testcoin.com/testcoin/version.txt",
 			Bip44Coin:                      bip44.CoinTypeSkycoin,
+			BurnFactorSchedule: []BurnFactorChange{
+				{Time: 1600000000, BurnFactor: 4},
+				{Time: 1500000000, BurnFactor: 5},
+			},
 		},
 		Params: ParamsConfig{
 			MaxCoinSupply:           1e8,
@@ -57,4 +65,54 @@ func TestNewConfig(t *testing.T) {
 			UserMaxDropletPrecision: 2,
 		},
 	}, coinConfig)
+
+	require.Equal(t, []fee.BurnFactorChange{
+		{Time: 1500000000, BurnFactor: 5},
+		{Time: 1600000000, BurnFactor: 4},
+	}, coinConfig.Node.BurnFactorChanges())
+}
+
+func TestNodeConfigNewGenesisBlock(t *testing.T) {
+	coinConfig, err := NewConfig("test.fiber.toml", "./testdata")
+	require.NoError(t, err)
+
+	outputs, err := coinConfig.Node.GenesisTransactionOutputs()
+	require.NoError(t, err)
+	require.Nil(t, outputs)
+
+	gb, err := coinConfig.Node.NewGenesisBlock()
+	require.NoError(t, err)
+	expected, err := coin.NewGenesisBlock(cipher.MustDecodeBase58Address("2jBbGxZRGoQG1mqhPBnXnLTxK6oxsTf8os6"), 100e12, 1426562704)
+	require.NoError(t, err)
+	require.Equal(t, expected, gb)
+
+	coinConfig, err = NewConfig("test-multi-genesis.fiber.toml", "./testdata")
+	require.NoError(t, err)
+	require.Equal(t, []GenesisOutput{
+		{Address: "2jBbGxZRGoQG1mqhPBnXnLTxK6oxsTf8os6", Coins: 60e12},
+		{Address: "24GJTLPMoz61sV4J4qg1n14x5qqDwXqyJJy", Coins: 40e12},
+	}, coinConfig.Node.GenesisOutputs)
+
+	outputs, err = coinConfig.Node.GenesisTransactionOutputs()
+	require.NoError(t, err)
+	require.Equal(t, []coin.TransactionOutput{
+		{Address: cipher.MustDecodeBase58Address("2jBbGxZRGoQG1mqhPBnXnLTxK6oxsTf8os6"), Coins: 60e12},
+		{Address: cipher.MustDecodeBase58Address("24GJTLPMoz61sV4J4qg1n14x5qqDwXqyJJy"), Coins: 40e12},
+	}, outputs)
+
+	gb, err = coinConfig.Node.NewGenesisBlock()
+	require.NoError(t, err)
+	expected, err = coin.NewGenesisBlockMulti(outputs, 1426562704)
+	require.NoError(t, err)
+	require.Equal(t, expected, gb)
+
+	// The outputs must distribute the genesis coin volume
+	coinConfig.Node.GenesisCoinVolume = 200e12
+	_, err = coinConfig.Node.NewGenesisBlock()
+	require.EqualError(t, err, "genesis outputs coins 100000000000000 do not match the genesis coin volume 200000000000000")
+
+	coinConfig.Node.GenesisCoinVolume = 100e12
+	coinConfig.Node.GenesisOutputs[1].Address = "foo"
+	_, err = coinConfig.Node.NewGenesisBlock()
+	require.EqualError(t, err, `invalid genesis output 1 address "foo": Invalid address length`)
 }
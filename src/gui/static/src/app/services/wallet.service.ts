@@ -362,10 +362,28 @@ export class WalletService {
       params['password'] = password;
     }
 
-    return this.apiService.post('wallet/recover', params, {}, true).do(w => {
-      wallet.encrypted = w.data.meta.encrypted;
-      this.updateWallet(w.data);
-    });
+    return this.apiService.post('wallet/recover', params, {}, true)
+      .flatMap(response => this.waitForRecovery(response.data.job_id))
+      .flatMap(() => this.apiService.get('wallet', { id: wallet.filename }))
+      .do(w => {
+        wallet.encrypted = w.meta.encrypted;
+        this.updateWallet(w);
+      });
+  }
+
+  private waitForRecovery(jobId: string): Observable<any> {
+    return Observable.timer(0, 1000)
+      .flatMap(() => this.apiService.get('wallet/recover/status', { job: jobId }, {}, true))
+      .map(response => response.data)
+      .filter(status => status.state !== 'running')
+      .first()
+      .map(status => {
+        if (status.state !== 'completed') {
+          throw new Error(status.error ? status.error : status.state);
+        }
+
+        return status;
+      });
   }
 
   getWalletSeed(wallet: Wallet, password: string): Observable<string> {
@@ -20,6 +20,15 @@ const (
 	TypeTxIDNotes Type = "txid"
 	// TypeGeneral is a type of storage for general user data
 	TypeGeneral Type = "client"
+	// TypeWalletTxNotes is a type of storage containing notes on wallet transactions,
+	// scoped to the wallet
+	TypeWalletTxNotes Type = "wallet_txnotes"
+	// TypeNotifications is a type of storage containing address subscriptions
+	// and their queues of transaction notifications
+	TypeNotifications Type = "notifications"
+	// TypeWalletApprovals is a type of storage containing the wallet transactions
+	// pending approval by the wallet's approver
+	TypeWalletApprovals Type = "wallet_approvals"
 )
 
 const storageFileExtension = ".json"
@@ -43,6 +52,11 @@ type Manager struct {
 	config   Config
 	storages map[Type]*kvStorage
 	sync.Mutex
+
+	// notificationsLock serializes the updates of notification subscriptions
+	notificationsLock sync.Mutex
+	// approvalsLock serializes the updates of pending approvals
+	approvalsLock sync.Mutex
 }
 
 // NewManager constructs new manager according to the config
@@ -239,7 +253,7 @@ func (m *Manager) getStorageFilePath(storageType Type) string {
 // isStorageTypeValid validates the given `storageType` against the predefined available types
 func isStorageTypeValid(storageType Type) bool {
 	switch storageType {
-	case TypeTxIDNotes, TypeGeneral:
+	case TypeTxIDNotes, TypeGeneral, TypeWalletTxNotes, TypeNotifications, TypeWalletApprovals:
 		return true
 	}
 
@@ -0,0 +1,315 @@
+package kvstorage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+const (
+	// MaxNotificationSubscriptions is the maximum number of address subscriptions
+	MaxNotificationSubscriptions = 100
+	// MaxNotificationSubscriptionAddresses is the maximum number of addresses in a subscription
+	MaxNotificationSubscriptionAddresses = 1000
+	// NotificationQueueSize is the number of events retained per subscription.
+	// The oldest events are dropped once a queue is full.
+	NotificationQueueSize = 1000
+	// MaxNotificationsPageSize is the maximum number of events returned by GetNotifications
+	MaxNotificationsPageSize = 100
+
+	// NotificationDirectionIn is the direction of a transaction that did not spend a subscribed address' outputs
+	NotificationDirectionIn = "in"
+	// NotificationDirectionOut is the direction of a transaction that spent a subscribed address' outputs
+	NotificationDirectionOut = "out"
+
+	subscriptionIDLen = 16
+)
+
+var (
+	// ErrNoSuchSubscription is returned if a notification subscription does not exist
+	ErrNoSuchSubscription = NewError(errors.New("subscription does not exist"))
+	// ErrSubscriptionNoAddresses is returned when subscribing to no addresses
+	ErrSubscriptionNoAddresses = NewError(errors.New("subscription has no addresses"))
+	// ErrSubscriptionTooManyAddresses is returned when subscribing to too many addresses
+	ErrSubscriptionTooManyAddresses = NewError(fmt.Errorf("subscription has more than %d addresses", MaxNotificationSubscriptionAddresses))
+	// ErrTooManySubscriptions is returned when subscribing while the maximum number of subscriptions exist
+	ErrTooManySubscriptions = NewError(fmt.Errorf("more than %d subscriptions", MaxNotificationSubscriptions))
+)
+
+// NotificationEvent is a transaction of an applied block touching the addresses of a subscription
+type NotificationEvent struct {
+	// Cursor is the position of the event in its subscription's queue, starting at 1
+	Cursor uint64 `json:"cursor"`
+	TxID   string `json:"txid"`
+	// BlockSeq is the seq of the block that applied the transaction
+	BlockSeq uint64 `json:"seq"`
+	// Direction is "out" if the transaction spent outputs of the subscribed addresses, "in" otherwise
+	Direction string `json:"direction"`
+	// Amount is the number of droplets received by the subscribed addresses for "in",
+	// or spent and not sent back to them for "out"
+	Amount uint64 `json:"amount"`
+}
+
+// Notifications is a page of a subscription's events
+type Notifications struct {
+	Events []NotificationEvent
+	// Next is the cursor to request the following page with
+	Next uint64
+	// Dropped is true if events after the requested cursor were dropped from the queue
+	Dropped bool
+}
+
+// subscription is the stored state of a notification subscription
+type subscription struct {
+	Addresses  []string            `json:"addresses"`
+	NextCursor uint64              `json:"next_cursor"`
+	Events     []NotificationEvent `json:"events"`
+}
+
+// Subscribe creates a subscription to the transactions of applied blocks touching addrs, and returns its ID.
+// Returns `ErrSubscriptionNoAddresses`, `ErrSubscriptionTooManyAddresses`, `ErrTooManySubscriptions`
+// and the errors of AddStorageValue
+func (m *Manager) Subscribe(addrs []cipher.Address) (string, error) {
+	if len(addrs) == 0 {
+		return "", ErrSubscriptionNoAddresses
+	}
+
+	sub := subscription{
+		NextCursor: 1,
+		Events:     []NotificationEvent{},
+	}
+
+	seen := make(map[cipher.Address]struct{}, len(addrs))
+	for _, a := range addrs {
+		if _, ok := seen[a]; ok {
+			continue
+		}
+		seen[a] = struct{}{}
+		sub.Addresses = append(sub.Addresses, a.String())
+	}
+
+	if len(sub.Addresses) > MaxNotificationSubscriptionAddresses {
+		return "", ErrSubscriptionTooManyAddresses
+	}
+
+	m.notificationsLock.Lock()
+	defer m.notificationsLock.Unlock()
+
+	all, err := m.GetAllStorageValues(TypeNotifications)
+	if err != nil {
+		return "", err
+	}
+	if len(all) >= MaxNotificationSubscriptions {
+		return "", ErrTooManySubscriptions
+	}
+
+	id := hex.EncodeToString(cipher.RandByte(subscriptionIDLen))
+	if err := m.setSubscription(id, sub); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Unsubscribe removes a subscription and its queued events.
+// Returns `ErrNoSuchSubscription` and the errors of RemoveStorageValue
+func (m *Manager) Unsubscribe(id string) error {
+	m.notificationsLock.Lock()
+	defer m.notificationsLock.Unlock()
+
+	err := m.RemoveStorageValue(TypeNotifications, id)
+	if err == ErrNoSuchKey {
+		return ErrNoSuchSubscription
+	}
+	return err
+}
+
+// GetSubscriptionAddresses returns the addresses of a subscription.
+// Returns `ErrNoSuchSubscription` and the errors of GetStorageValue
+func (m *Manager) GetSubscriptionAddresses(id string) ([]cipher.Address, error) {
+	sub, err := m.getSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]cipher.Address, len(sub.Addresses))
+	for i, a := range sub.Addresses {
+		addrs[i], err = cipher.DecodeBase58Address(a)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s has an invalid address: %v", id, err)
+		}
+	}
+
+	return addrs, nil
+}
+
+// GetNotifications returns the events of a subscription after the cursor, at most limit events,
+// MaxNotificationsPageSize if limit is 0. Events are kept until they are dropped from the queue
+// by newer events, so the same page can be requested again.
+// Returns `ErrNoSuchSubscription` and the errors of GetStorageValue
+func (m *Manager) GetNotifications(id string, after uint64, limit int) (*Notifications, error) {
+	if limit <= 0 || limit > MaxNotificationsPageSize {
+		limit = MaxNotificationsPageSize
+	}
+
+	sub, err := m.getSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifications{
+		Events: []NotificationEvent{},
+		Next:   after,
+	}
+
+	if len(sub.Events) > 0 && sub.Events[0].Cursor > after+1 {
+		n.Dropped = true
+	}
+
+	for _, e := range sub.Events {
+		if e.Cursor <= after {
+			continue
+		}
+		if len(n.Events) == limit {
+			break
+		}
+		n.Events = append(n.Events, e)
+		n.Next = e.Cursor
+	}
+
+	return n, nil
+}
+
+// AppendBlockNotifications queues an event in each subscription for each transaction of b
+// touching its addresses. inputs are the outputs spent by each transaction of b, in order.
+// Nothing is done if the storage API is disabled or the notifications storage is not loaded.
+func (m *Manager) AppendBlockNotifications(b coin.SignedBlock, inputs [][]coin.UxOut) error {
+	txns := b.Block.Body.Transactions
+	if len(inputs) != len(txns) {
+		return fmt.Errorf("AppendBlockNotifications: block %d has %d transactions but %d inputs", b.Seq(), len(txns), len(inputs))
+	}
+
+	m.notificationsLock.Lock()
+	defer m.notificationsLock.Unlock()
+
+	all, err := m.GetAllStorageValues(TypeNotifications)
+	switch err {
+	case nil:
+	case ErrStorageAPIDisabled, ErrNoSuchStorage:
+		return nil
+	default:
+		return err
+	}
+
+	for id, v := range all {
+		var sub subscription
+		if err := json.Unmarshal([]byte(v), &sub); err != nil {
+			logger.WithError(err).Warningf("Invalid notification subscription %q", id)
+			continue
+		}
+
+		addrs := make(map[string]struct{}, len(sub.Addresses))
+		for _, a := range sub.Addresses {
+			addrs[a] = struct{}{}
+		}
+
+		changed := false
+		for i, txn := range txns {
+			e, ok := notificationEvent(addrs, txn, inputs[i])
+			if !ok {
+				continue
+			}
+
+			e.BlockSeq = b.Seq()
+			e.Cursor = sub.NextCursor
+			sub.NextCursor++
+			sub.Events = append(sub.Events, e)
+			changed = true
+		}
+
+		if !changed {
+			continue
+		}
+
+		if len(sub.Events) > NotificationQueueSize {
+			sub.Events = sub.Events[len(sub.Events)-NotificationQueueSize:]
+		}
+
+		if err := m.setSubscription(id, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notificationEvent returns the event of a transaction for a set of addresses,
+// and false if the transaction does not touch the addresses
+func notificationEvent(addrs map[string]struct{}, txn coin.Transaction, inputs []coin.UxOut) (NotificationEvent, bool) {
+	var spent, received uint64
+	touched := false
+
+	for _, ux := range inputs {
+		if _, ok := addrs[ux.Body.Address.String()]; ok {
+			spent += ux.Body.Coins
+			touched = true
+		}
+	}
+
+	for _, o := range txn.Out {
+		if _, ok := addrs[o.Address.String()]; ok {
+			received += o.Coins
+			touched = true
+		}
+	}
+
+	if !touched {
+		return NotificationEvent{}, false
+	}
+
+	e := NotificationEvent{
+		TxID: txn.Hash().Hex(),
+	}
+
+	if spent > 0 {
+		e.Direction = NotificationDirectionOut
+		if spent > received {
+			e.Amount = spent - received
+		}
+	} else {
+		e.Direction = NotificationDirectionIn
+		e.Amount = received
+	}
+
+	return e, true
+}
+
+func (m *Manager) getSubscription(id string) (*subscription, error) {
+	v, err := m.GetStorageValue(TypeNotifications, id)
+	if err != nil {
+		if err == ErrNoSuchKey {
+			return nil, ErrNoSuchSubscription
+		}
+		return nil, err
+	}
+
+	var sub subscription
+	if err := json.Unmarshal([]byte(v), &sub); err != nil {
+		return nil, fmt.Errorf("invalid notification subscription %q: %v", id, err)
+	}
+
+	return &sub, nil
+}
+
+func (m *Manager) setSubscription(id string, sub subscription) error {
+	v, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+
+	return m.AddStorageValue(TypeNotifications, id, string(v))
+}
@@ -0,0 +1,200 @@
+package kvstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func makeNotificationBlock(seq uint64, txns ...coin.Transaction) coin.SignedBlock {
+	var b coin.SignedBlock
+	b.Block.Head.BkSeq = seq
+	b.Block.Body.Transactions = txns
+	return b
+}
+
+func makeNotificationTxn(outs ...coin.TransactionOutput) coin.Transaction {
+	return coin.Transaction{
+		In:  []cipher.SHA256{cipher.SumSHA256(cipher.RandByte(32))},
+		Out: outs,
+	}
+}
+
+func makeInput(addr cipher.Address, coins uint64) coin.UxOut {
+	return coin.UxOut{
+		Body: coin.UxBody{
+			Address: addr,
+			Coins:   coins,
+		},
+	}
+}
+
+func TestManagerNotifications(t *testing.T) {
+	tmpDir, cleanup := setupTmpDir(t)
+	defer cleanup()
+
+	m, err := NewManager(Config{
+		StorageDir:       tmpDir,
+		EnabledStorages:  []Type{TypeNotifications},
+		EnableStorageAPI: true,
+	})
+	require.NoError(t, err)
+
+	a := testutil.MakeAddress()
+	b := testutil.MakeAddress()
+	c := testutil.MakeAddress()
+
+	_, err = m.Subscribe(nil)
+	require.Equal(t, ErrSubscriptionNoAddresses, err)
+
+	tooMany := make([]cipher.Address, MaxNotificationSubscriptionAddresses+1)
+	for i := range tooMany {
+		tooMany[i].Key[0] = byte(i)
+		tooMany[i].Key[1] = byte(i >> 8)
+	}
+	_, err = m.Subscribe(tooMany)
+	require.Equal(t, ErrSubscriptionTooManyAddresses, err)
+
+	// Duplicate addresses are removed
+	id, err := m.Subscribe([]cipher.Address{a, b, a})
+	require.NoError(t, err)
+	require.Len(t, id, subscriptionIDLen*2)
+
+	addrs, err := m.GetSubscriptionAddresses(id)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.Address{a, b}, addrs)
+
+	idC, err := m.Subscribe([]cipher.Address{c})
+	require.NoError(t, err)
+
+	_, err = m.GetNotifications("unknown", 0, 0)
+	require.Equal(t, ErrNoSuchSubscription, err)
+
+	n, err := m.GetNotifications(id, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, &Notifications{
+		Events: []NotificationEvent{},
+	}, n)
+
+	// a receives coins, then sends some to c with change to b, then c receives coins from elsewhere
+	txn1 := makeNotificationTxn(coin.TransactionOutput{Address: a, Coins: 5e6})
+	txn2 := makeNotificationTxn(
+		coin.TransactionOutput{Address: c, Coins: 2e6},
+		coin.TransactionOutput{Address: b, Coins: 3e6},
+	)
+	txn3 := makeNotificationTxn(coin.TransactionOutput{Address: c, Coins: 1e6})
+	other := testutil.MakeAddress()
+
+	require.NoError(t, m.AppendBlockNotifications(makeNotificationBlock(10, txn1, txn3), [][]coin.UxOut{
+		{makeInput(other, 5e6)},
+		{makeInput(other, 1e6)},
+	}))
+	require.NoError(t, m.AppendBlockNotifications(makeNotificationBlock(11, txn2), [][]coin.UxOut{
+		{makeInput(a, 5e6)},
+	}))
+
+	// The inputs must match the transactions
+	require.Error(t, m.AppendBlockNotifications(makeNotificationBlock(12, txn1), nil))
+
+	n, err = m.GetNotifications(id, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, &Notifications{
+		Events: []NotificationEvent{
+			{
+				Cursor:    1,
+				TxID:      txn1.Hash().Hex(),
+				BlockSeq:  10,
+				Direction: NotificationDirectionIn,
+				Amount:    5e6,
+			},
+			{
+				Cursor:    2,
+				TxID:      txn2.Hash().Hex(),
+				BlockSeq:  11,
+				Direction: NotificationDirectionOut,
+				Amount:    2e6,
+			},
+		},
+		Next: 2,
+	}, n)
+
+	n, err = m.GetNotifications(idC, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, n.Events, 2)
+	require.Equal(t, txn3.Hash().Hex(), n.Events[0].TxID)
+	require.Equal(t, NotificationDirectionIn, n.Events[1].Direction)
+	require.Equal(t, uint64(2e6), n.Events[1].Amount)
+
+	// Pagination with the cursor
+	n, err = m.GetNotifications(id, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, n.Events, 1)
+	require.Equal(t, uint64(1), n.Next)
+
+	n, err = m.GetNotifications(id, n.Next, 1)
+	require.NoError(t, err)
+	require.Len(t, n.Events, 1)
+	require.Equal(t, uint64(2), n.Next)
+
+	n, err = m.GetNotifications(id, n.Next, 1)
+	require.NoError(t, err)
+	require.Empty(t, n.Events)
+	require.Equal(t, uint64(2), n.Next)
+	require.False(t, n.Dropped)
+
+	// The queue keeps the newest events
+	txns := make([]coin.Transaction, NotificationQueueSize)
+	inputs := make([][]coin.UxOut, NotificationQueueSize)
+	for i := range txns {
+		txns[i] = makeNotificationTxn(coin.TransactionOutput{Address: b, Coins: 1e6})
+		inputs[i] = []coin.UxOut{makeInput(other, 1e6)}
+	}
+	require.NoError(t, m.AppendBlockNotifications(makeNotificationBlock(12, txns...), inputs))
+
+	n, err = m.GetNotifications(id, 0, 0)
+	require.NoError(t, err)
+	require.True(t, n.Dropped)
+	require.Len(t, n.Events, MaxNotificationsPageSize)
+	require.Equal(t, uint64(3), n.Events[0].Cursor)
+
+	n, err = m.GetNotifications(id, NotificationQueueSize, 0)
+	require.NoError(t, err)
+	require.False(t, n.Dropped)
+	require.Len(t, n.Events, 2)
+	require.Equal(t, uint64(NotificationQueueSize+2), n.Next)
+
+	// Subscriptions are persisted
+	m2, err := NewManager(Config{
+		StorageDir:       tmpDir,
+		EnabledStorages:  []Type{TypeNotifications},
+		EnableStorageAPI: true,
+	})
+	require.NoError(t, err)
+
+	n2, err := m2.GetNotifications(id, NotificationQueueSize, 0)
+	require.NoError(t, err)
+	require.Equal(t, n, n2)
+
+	require.NoError(t, m.Unsubscribe(id))
+	require.Equal(t, ErrNoSuchSubscription, m.Unsubscribe(id))
+	_, err = m.GetNotifications(id, 0, 0)
+	require.Equal(t, ErrNoSuchSubscription, err)
+
+	// The number of subscriptions is limited
+	for i := 1; i < MaxNotificationSubscriptions; i++ {
+		_, err := m.Subscribe([]cipher.Address{a})
+		require.NoError(t, err)
+	}
+	_, err = m.Subscribe([]cipher.Address{a})
+	require.Equal(t, ErrTooManySubscriptions, err)
+
+	// Blocks are ignored if the storage is not loaded
+	require.NoError(t, m.UnloadStorage(TypeNotifications))
+	require.NoError(t, m.AppendBlockNotifications(makeNotificationBlock(5000, txn1), [][]coin.UxOut{{}}))
+	_, err = m.Subscribe([]cipher.Address{a})
+	require.Equal(t, ErrNoSuchStorage, err)
+}
@@ -0,0 +1,210 @@
+package kvstorage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+const (
+	// MaxPendingApprovals is the maximum number of transactions pending approval per wallet
+	MaxPendingApprovals = 100
+
+	pendingApprovalIDLen = 16
+)
+
+var (
+	// ErrNoSuchPendingApproval is returned if a pending approval does not exist, has expired
+	// or belongs to another wallet
+	ErrNoSuchPendingApproval = NewError(errors.New("pending approval does not exist"))
+	// ErrTooManyPendingApprovals is returned when adding a pending approval to a wallet
+	// that has the maximum number of pending approvals
+	ErrTooManyPendingApprovals = NewError(fmt.Errorf("wallet has %d pending approvals", MaxPendingApprovals))
+)
+
+// PendingApproval is a wallet transaction held until the wallet's approver approves it
+type PendingApproval struct {
+	ID       string `json:"id"`
+	WalletID string `json:"wallet_id"`
+	TxID     string `json:"txid"`
+	// EncodedTransaction is the hex encoded transaction
+	EncodedTransaction string `json:"encoded_transaction"`
+	// Amount is the number of droplets the transaction sends out of the wallet
+	Amount    uint64 `json:"amount"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// Transaction returns the decoded transaction of the pending approval
+func (p PendingApproval) Transaction() (*coin.Transaction, error) {
+	txn, err := coin.DeserializeTransactionHex(p.EncodedTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("pending approval %s has an invalid transaction: %v", p.ID, err)
+	}
+	return &txn, nil
+}
+
+// expired returns true if the pending approval expired at t
+func (p PendingApproval) expired(t time.Time) bool {
+	return t.Unix() >= p.ExpiresAt
+}
+
+// AddPendingApproval holds a transaction of the wallet wltID for approval, until window has passed,
+// and returns the pending approval. amount is the number of droplets the transaction sends out of the wallet.
+// Returns `ErrTooManyPendingApprovals` and the errors of AddStorageValue
+func (m *Manager) AddPendingApproval(wltID string, txn *coin.Transaction, amount uint64, window time.Duration) (*PendingApproval, error) {
+	if wltID == "" {
+		return nil, errors.New("wallet id is empty")
+	}
+
+	encoded, err := txn.SerializeHex()
+	if err != nil {
+		return nil, err
+	}
+
+	m.approvalsLock.Lock()
+	defer m.approvalsLock.Unlock()
+
+	pending, err := m.getPendingApprovals(wltID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) >= MaxPendingApprovals {
+		return nil, ErrTooManyPendingApprovals
+	}
+
+	now := time.Now()
+	p := PendingApproval{
+		ID:                 hex.EncodeToString(cipher.RandByte(pendingApprovalIDLen)),
+		WalletID:           wltID,
+		TxID:               txn.Hash().Hex(),
+		EncodedTransaction: encoded,
+		Amount:             amount,
+		CreatedAt:          now.Unix(),
+		ExpiresAt:          now.Add(window).Unix(),
+	}
+
+	if err := m.setPendingApproval(p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// GetPendingApproval returns a pending approval of the wallet wltID.
+// Returns `ErrNoSuchPendingApproval` and the errors of GetStorageValue
+func (m *Manager) GetPendingApproval(wltID, id string) (*PendingApproval, error) {
+	m.approvalsLock.Lock()
+	defer m.approvalsLock.Unlock()
+
+	return m.getPendingApproval(wltID, id)
+}
+
+// GetPendingApprovals returns the pending approvals of the wallet wltID, oldest first.
+// Expired approvals are removed.
+// Returns the errors of GetAllStorageValues
+func (m *Manager) GetPendingApprovals(wltID string) ([]PendingApproval, error) {
+	m.approvalsLock.Lock()
+	defer m.approvalsLock.Unlock()
+
+	return m.getPendingApprovals(wltID)
+}
+
+// RemovePendingApproval removes a pending approval of the wallet wltID and returns it,
+// so that it is approved or cancelled only once.
+// Returns `ErrNoSuchPendingApproval` and the errors of RemoveStorageValue
+func (m *Manager) RemovePendingApproval(wltID, id string) (*PendingApproval, error) {
+	m.approvalsLock.Lock()
+	defer m.approvalsLock.Unlock()
+
+	p, err := m.getPendingApproval(wltID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.RemoveStorageValue(TypeWalletApprovals, id); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (m *Manager) getPendingApproval(wltID, id string) (*PendingApproval, error) {
+	v, err := m.GetStorageValue(TypeWalletApprovals, id)
+	if err != nil {
+		if err == ErrNoSuchKey {
+			return nil, ErrNoSuchPendingApproval
+		}
+		return nil, err
+	}
+
+	var p PendingApproval
+	if err := json.Unmarshal([]byte(v), &p); err != nil {
+		return nil, fmt.Errorf("invalid pending approval %q: %v", id, err)
+	}
+
+	if p.WalletID != wltID {
+		return nil, ErrNoSuchPendingApproval
+	}
+
+	if p.expired(time.Now()) {
+		if err := m.RemoveStorageValue(TypeWalletApprovals, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoSuchPendingApproval
+	}
+
+	return &p, nil
+}
+
+func (m *Manager) getPendingApprovals(wltID string) ([]PendingApproval, error) {
+	all, err := m.GetAllStorageValues(TypeWalletApprovals)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pending := []PendingApproval{}
+	for id, v := range all {
+		var p PendingApproval
+		if err := json.Unmarshal([]byte(v), &p); err != nil {
+			logger.WithError(err).Warningf("Invalid pending approval %q", id)
+			continue
+		}
+
+		if p.expired(now) {
+			if err := m.RemoveStorageValue(TypeWalletApprovals, id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.WalletID == wltID {
+			pending = append(pending, p)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].CreatedAt == pending[j].CreatedAt {
+			return pending[i].ID < pending[j].ID
+		}
+		return pending[i].CreatedAt < pending[j].CreatedAt
+	})
+
+	return pending, nil
+}
+
+func (m *Manager) setPendingApproval(p PendingApproval) error {
+	v, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return m.AddStorageValue(TypeWalletApprovals, p.ID, string(v))
+}
@@ -0,0 +1,117 @@
+package kvstorage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestManagerPendingApprovals(t *testing.T) {
+	tmpDir, cleanup := setupTmpDir(t)
+	defer cleanup()
+
+	m, err := NewManager(Config{
+		StorageDir:       tmpDir,
+		EnabledStorages:  []Type{TypeWalletApprovals},
+		EnableStorageAPI: true,
+	})
+	require.NoError(t, err)
+
+	txn := makeNotificationTxn(coin.TransactionOutput{
+		Address: testutil.MakeAddress(),
+		Coins:   5e6,
+		Hours:   10,
+	})
+
+	pending, err := m.GetPendingApprovals("a.wlt")
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	p1, err := m.AddPendingApproval("a.wlt", &txn, 5e6, time.Hour)
+	require.NoError(t, err)
+	require.NotEmpty(t, p1.ID)
+	require.Equal(t, "a.wlt", p1.WalletID)
+	require.Equal(t, txn.Hash().Hex(), p1.TxID)
+	require.Equal(t, uint64(5e6), p1.Amount)
+	require.Equal(t, p1.CreatedAt+3600, p1.ExpiresAt)
+
+	decoded, err := p1.Transaction()
+	require.NoError(t, err)
+	require.Equal(t, txn, *decoded)
+
+	p2, err := m.AddPendingApproval("b.wlt", &txn, 1, time.Hour)
+	require.NoError(t, err)
+
+	p, err := m.GetPendingApproval("a.wlt", p1.ID)
+	require.NoError(t, err)
+	require.Equal(t, p1, p)
+
+	// Pending approvals are scoped to their wallet
+	_, err = m.GetPendingApproval("b.wlt", p1.ID)
+	require.Equal(t, ErrNoSuchPendingApproval, err)
+	_, err = m.RemovePendingApproval("b.wlt", p1.ID)
+	require.Equal(t, ErrNoSuchPendingApproval, err)
+
+	pending, err = m.GetPendingApprovals("a.wlt")
+	require.NoError(t, err)
+	require.Equal(t, []PendingApproval{*p1}, pending)
+
+	// Expired approvals are removed
+	expired := *p1
+	expired.ID = "expired"
+	expired.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	require.NoError(t, m.setPendingApproval(expired))
+	_, err = m.GetPendingApproval("a.wlt", "expired")
+	require.Equal(t, ErrNoSuchPendingApproval, err)
+	_, err = m.GetStorageValue(TypeWalletApprovals, "expired")
+	require.Equal(t, ErrNoSuchKey, err)
+
+	require.NoError(t, m.setPendingApproval(expired))
+	pending, err = m.GetPendingApprovals("a.wlt")
+	require.NoError(t, err)
+	require.Equal(t, []PendingApproval{*p1}, pending)
+	_, err = m.GetStorageValue(TypeWalletApprovals, "expired")
+	require.Equal(t, ErrNoSuchKey, err)
+
+	// Approvals are removed once
+	p, err = m.RemovePendingApproval("a.wlt", p1.ID)
+	require.NoError(t, err)
+	require.Equal(t, p1, p)
+	_, err = m.RemovePendingApproval("a.wlt", p1.ID)
+	require.Equal(t, ErrNoSuchPendingApproval, err)
+
+	pending, err = m.GetPendingApprovals("b.wlt")
+	require.NoError(t, err)
+	require.Equal(t, []PendingApproval{*p2}, pending)
+
+	// The number of pending approvals per wallet is limited
+	for i := 1; i < MaxPendingApprovals; i++ {
+		_, err = m.AddPendingApproval("b.wlt", &txn, 1, time.Hour)
+		require.NoError(t, err)
+	}
+	_, err = m.AddPendingApproval("b.wlt", &txn, 1, time.Hour)
+	require.Equal(t, ErrTooManyPendingApprovals, err)
+	_, err = m.AddPendingApproval("a.wlt", &txn, 1, time.Hour)
+	require.NoError(t, err)
+}
+
+func TestManagerPendingApprovalsNotLoaded(t *testing.T) {
+	tmpDir, cleanup := setupTmpDir(t)
+	defer cleanup()
+
+	m, err := NewManager(Config{
+		StorageDir:       tmpDir,
+		EnableStorageAPI: true,
+	})
+	require.NoError(t, err)
+
+	txn := makeNotificationTxn()
+	_, err = m.AddPendingApproval("a.wlt", &txn, 1, time.Hour)
+	require.Equal(t, ErrNoSuchStorage, err)
+	_, err = m.GetPendingApprovals("a.wlt")
+	require.Equal(t, ErrNoSuchStorage, err)
+}
@@ -0,0 +1,115 @@
+package kvstorage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// MaxWalletTxNoteLength is the maximum number of characters in a wallet transaction note
+	MaxWalletTxNoteLength = 256
+
+	walletTxNoteKeySep = ":"
+)
+
+var (
+	// ErrWalletTxNoteEmpty is returned when trying to set an empty wallet transaction note
+	ErrWalletTxNoteEmpty = NewError(errors.New("note is empty"))
+	// ErrWalletTxNoteTooLong is returned when trying to set a wallet transaction note that is too long
+	ErrWalletTxNoteTooLong = NewError(fmt.Errorf("note is longer than %d characters", MaxWalletTxNoteLength))
+	// ErrWalletTxNoteInvalidWalletID is returned if the wallet ID of a wallet transaction note is invalid
+	ErrWalletTxNoteInvalidWalletID = NewError(errors.New("invalid wallet id"))
+)
+
+// walletTxNoteKey returns the storage key of the note of txid in the wallet wltID
+func walletTxNoteKey(wltID string, txid cipher.SHA256) string {
+	return wltID + walletTxNoteKeySep + txid.Hex()
+}
+
+// validateWalletTxNoteWalletID checks that a wallet ID can be used in a note key
+func validateWalletTxNoteWalletID(wltID string) error {
+	if wltID == "" || strings.Contains(wltID, walletTxNoteKeySep) {
+		return ErrWalletTxNoteInvalidWalletID
+	}
+	return nil
+}
+
+// ValidateWalletTxNote checks that a note can be attached to a wallet transaction
+func ValidateWalletTxNote(note string) error {
+	if note == "" {
+		return ErrWalletTxNoteEmpty
+	}
+	if utf8.RuneCountInString(note) > MaxWalletTxNoteLength {
+		return ErrWalletTxNoteTooLong
+	}
+	return nil
+}
+
+// GetWalletTxNote returns the note of txid in the wallet wltID.
+// Returns `ErrNoSuchKey` if the transaction has no note, and the errors of GetStorageValue
+func (m *Manager) GetWalletTxNote(wltID string, txid cipher.SHA256) (string, error) {
+	if err := validateWalletTxNoteWalletID(wltID); err != nil {
+		return "", err
+	}
+
+	return m.GetStorageValue(TypeWalletTxNotes, walletTxNoteKey(wltID, txid))
+}
+
+// GetWalletTxNotes returns the notes of the transactions in the wallet wltID, keyed by txid.
+// Returns the errors of GetAllStorageValues
+func (m *Manager) GetWalletTxNotes(wltID string) (map[cipher.SHA256]string, error) {
+	if err := validateWalletTxNoteWalletID(wltID); err != nil {
+		return nil, err
+	}
+
+	all, err := m.GetAllStorageValues(TypeWalletTxNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := wltID + walletTxNoteKeySep
+	notes := make(map[cipher.SHA256]string)
+	for k, v := range all {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		txid, err := cipher.SHA256FromHex(strings.TrimPrefix(k, prefix))
+		if err != nil {
+			logger.WithError(err).Warningf("Invalid wallet transaction note key %q", k)
+			continue
+		}
+
+		notes[txid] = v
+	}
+
+	return notes, nil
+}
+
+// SetWalletTxNote attaches a note to txid in the wallet wltID, replacing any existing note.
+// Returns `ErrWalletTxNoteEmpty`, `ErrWalletTxNoteTooLong` and the errors of AddStorageValue
+func (m *Manager) SetWalletTxNote(wltID string, txid cipher.SHA256, note string) error {
+	if err := validateWalletTxNoteWalletID(wltID); err != nil {
+		return err
+	}
+
+	if err := ValidateWalletTxNote(note); err != nil {
+		return err
+	}
+
+	return m.AddStorageValue(TypeWalletTxNotes, walletTxNoteKey(wltID, txid), note)
+}
+
+// RemoveWalletTxNote removes the note of txid in the wallet wltID.
+// Returns `ErrNoSuchKey` if the transaction has no note, and the errors of RemoveStorageValue
+func (m *Manager) RemoveWalletTxNote(wltID string, txid cipher.SHA256) error {
+	if err := validateWalletTxNoteWalletID(wltID); err != nil {
+		return err
+	}
+
+	return m.RemoveStorageValue(TypeWalletTxNotes, walletTxNoteKey(wltID, txid))
+}
@@ -0,0 +1,93 @@
+package kvstorage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestManagerWalletTxNotes(t *testing.T) {
+	tmpDir, cleanup := setupTmpDir(t)
+	defer cleanup()
+
+	m, err := NewManager(Config{
+		StorageDir:       tmpDir,
+		EnabledStorages:  []Type{TypeWalletTxNotes},
+		EnableStorageAPI: true,
+	})
+	require.NoError(t, err)
+
+	txid1 := testutil.RandSHA256(t)
+	txid2 := testutil.RandSHA256(t)
+
+	_, err = m.GetWalletTxNote("a.wlt", txid1)
+	require.Equal(t, ErrNoSuchKey, err)
+
+	notes, err := m.GetWalletTxNotes("a.wlt")
+	require.NoError(t, err)
+	require.Empty(t, notes)
+
+	// Invalid notes are rejected
+	require.Equal(t, ErrWalletTxNoteEmpty, m.SetWalletTxNote("a.wlt", txid1, ""))
+	require.Equal(t, ErrWalletTxNoteTooLong, m.SetWalletTxNote("a.wlt", txid1, strings.Repeat("a", MaxWalletTxNoteLength+1)))
+
+	// The length is counted in characters, not bytes
+	longNote := strings.Repeat("é", MaxWalletTxNoteLength)
+	require.NoError(t, m.SetWalletTxNote("a.wlt", txid1, longNote))
+
+	// Invalid wallet IDs are rejected
+	require.Equal(t, ErrWalletTxNoteInvalidWalletID, m.SetWalletTxNote("", txid1, "x"))
+	require.Equal(t, ErrWalletTxNoteInvalidWalletID, m.SetWalletTxNote("a:b.wlt", txid1, "x"))
+
+	// Notes are scoped to the wallet
+	require.NoError(t, m.SetWalletTxNote("a.wlt", txid2, "rent"))
+	require.NoError(t, m.SetWalletTxNote("b.wlt", txid1, "refund"))
+
+	note, err := m.GetWalletTxNote("a.wlt", txid1)
+	require.NoError(t, err)
+	require.Equal(t, longNote, note)
+
+	note, err = m.GetWalletTxNote("b.wlt", txid1)
+	require.NoError(t, err)
+	require.Equal(t, "refund", note)
+
+	notes, err = m.GetWalletTxNotes("a.wlt")
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	require.Equal(t, longNote, notes[txid1])
+	require.Equal(t, "rent", notes[txid2])
+
+	// Setting a note replaces the existing note
+	require.NoError(t, m.SetWalletTxNote("a.wlt", txid2, "rent for may"))
+	note, err = m.GetWalletTxNote("a.wlt", txid2)
+	require.NoError(t, err)
+	require.Equal(t, "rent for may", note)
+
+	require.NoError(t, m.RemoveWalletTxNote("a.wlt", txid1))
+	require.Equal(t, ErrNoSuchKey, m.RemoveWalletTxNote("a.wlt", txid1))
+
+	notes, err = m.GetWalletTxNotes("a.wlt")
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+
+	// Notes are persisted
+	m2, err := NewManager(Config{
+		StorageDir:       tmpDir,
+		EnabledStorages:  []Type{TypeWalletTxNotes},
+		EnableStorageAPI: true,
+	})
+	require.NoError(t, err)
+
+	notes, err = m2.GetWalletTxNotes("b.wlt")
+	require.NoError(t, err)
+	require.Equal(t, map[cipher.SHA256]string{txid1: "refund"}, notes)
+
+	// The storage API must be enabled
+	m.config.EnableStorageAPI = false
+	_, err = m.GetWalletTxNotes("a.wlt")
+	require.Equal(t, ErrStorageAPIDisabled, err)
+}
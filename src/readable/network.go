@@ -1,9 +1,11 @@
 package readable
 
 import (
-	"github.com/skycoin/skycoin/src/daemon"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/useragent"
+
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 // Connection a connection's state within the daemon
@@ -71,3 +73,26 @@ func NewVerifyTxn(p params.VerifyTxn) VerifyTxn {
 		MaxDropletPrecision: p.MaxDropletPrecision,
 	}
 }
+
+// BurnFactorChange is a change of the burn factor for blockchain heads with a time greater than or equal to Time
+type BurnFactorChange struct {
+	Time       uint64 `json:"time"`
+	BurnFactor uint32 `json:"burn_factor"`
+}
+
+// NewBurnFactorSchedule converts []fee.BurnFactorChange to []BurnFactorChange
+func NewBurnFactorSchedule(schedule []fee.BurnFactorChange) []BurnFactorChange {
+	if len(schedule) == 0 {
+		return nil
+	}
+
+	changes := make([]BurnFactorChange, len(schedule))
+	for i, c := range schedule {
+		changes[i] = BurnFactorChange{
+			Time:       c.Time,
+			BurnFactor: c.BurnFactor,
+		}
+	}
+
+	return changes
+}
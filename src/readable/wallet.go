@@ -2,7 +2,8 @@ package readable
 
 import (
 	"github.com/skycoin/skycoin/src/cipher/bip44"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 // Balance has coins and hours
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
@@ -11,26 +12,39 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/fiber"
-	"github.com/skycoin/skycoin/src/kvstorage"
 
 	"log"
 
-	"github.com/skycoin/skycoin/src/api"
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/util/file"
+	"github.com/skycoin/skycoin/src/util/iputil"
 	"github.com/skycoin/skycoin/src/util/useragent"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/api"
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	ppex "github.com/ness-network/privateness/src/daemon/pex"
+	"github.com/ness-network/privateness/src/fiber"
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/util/fee"
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 var (
 	help = false
 )
 
+// bytesPerKbps is the number of bytes per second in a kilobit per second
+const bytesPerKbps = 1000 / 8
+
 // Config records skycoin node and build config
 type Config struct {
 	Node  NodeConfig
@@ -58,6 +72,10 @@ type NodeConfig struct {
 	EnableGUI bool
 	// Disable CSRF check in the wallet API
 	DisableCSRF bool
+	// Lifetime of CSRF tokens
+	CSRFTokenLifetime time.Duration
+	// Don't bind CSRF tokens to the client's origin and session. Deprecated, will be removed in the next release
+	CSRFStateless bool
 	// Disable Host, Origin and Referer header check in the wallet API
 	DisableHeaderCheck bool
 	// Disable CSP disable content-security-policy in http response
@@ -93,8 +111,43 @@ type NodeConfig struct {
 	MaxOutgoingMessageLength int
 	// MaxIncomingMessageLength maximum size of incoming messages
 	MaxIncomingMessageLength int
+	// MaxUploadKbps is the maximum upload rate of block transfers in kilobits per second, 0 is unlimited
+	MaxUploadKbps uint64
+	// MaxDownloadKbps is the maximum download rate of block transfers in kilobits per second, 0 is unlimited
+	MaxDownloadKbps uint64
+	// PropagationTrackerSize is the number of recently seen transactions and blocks whose propagation is tracked
+	PropagationTrackerSize int
+	// TxnReannounceInterval is the minimum interval between announcements of an unconfirmed transaction to newly introduced peers
+	TxnReannounceInterval time.Duration
+	// KeepaliveInterval is how long a connection must be idle before a keepalive ping is sent, to peers that support keepalives
+	KeepaliveInterval time.Duration
+	// KeepaliveTimeout is how long to wait for the reply to a keepalive ping
+	KeepaliveTimeout time.Duration
+	// KeepaliveMaxMissedPongs is the number of consecutive unanswered keepalive pings after which a peer is disconnected
+	KeepaliveMaxMissedPongs int
+	// MessageHandlerTimeout is the deadline for processing a message received from a peer, 0 disables the deadlines
+	MessageHandlerTimeout time.Duration
+	// MessageHandlerTimeouts is a comma separated list of message ID=duration pairs overriding MessageHandlerTimeout
+	MessageHandlerTimeouts string
+	messageHandlerTimeouts map[gnet.MessagePrefix]time.Duration
+	// MaxSlowMessageHandlers is the number of message deadline violations after which a peer is disconnected, 0 never disconnects
+	MaxSlowMessageHandlers int
 	// PeerlistSize represents the maximum number of peers that the pex would maintain
 	PeerlistSize int
+	// TrustedPeerBundleKeys is a comma separated list of public keys trusted to sign imported peer bundles.
+	// If empty, unsigned peer bundles are accepted
+	TrustedPeerBundleKeys string
+	// BanThreshold is the number of recent invalid transactions, invalid blocks and malformed messages
+	// received from an IP that must be exceeded for the IP to be banned. 0 disables banning
+	BanThreshold int
+	// BanWindow is the half-life of the counts of invalid objects received from an IP
+	BanWindow time.Duration
+	// BanDuration is how long an IP is banned for
+	BanDuration time.Duration
+	// AlwaysConnect is a comma separated list of ip:port peers that are always kept connected.
+	// They are redialed whenever the connection drops and are exempt from the connection limits
+	AlwaysConnect string
+	alwaysConnect []string
 	// Wallet Address Version
 	// AddressVersion string
 	// Remote web interface
@@ -114,6 +167,10 @@ type NodeConfig struct {
 	WebInterfacePassword string
 	// Allow web interface auth without HTTPS
 	WebInterfacePlaintextAuth bool
+	// API key required by the wallet, storage and admin API endpoints
+	APIKey string
+	// File containing the API key, an alternative to APIKey that keeps the key out of the process arguments
+	APIKeyFile string
 
 	// Launch System Default Browser after client startup
 	LaunchBrowser bool
@@ -128,6 +185,9 @@ type NodeConfig struct {
 	HTTPWriteTimeout time.Duration
 	HTTPIdleTimeout  time.Duration
 
+	// Maximum time to wait for a graceful shutdown. 0 waits indefinitely
+	ShutdownTimeout time.Duration
+
 	// Remark to include in user agent sent in the wire protocol introduction
 	UserAgentRemark string
 	userAgent       useragent.Data
@@ -136,6 +196,11 @@ type NodeConfig struct {
 	ColorLog bool
 	// This is the value registered with flag, it is converted to LogLevel after parsing
 	LogLevel string
+	// Log format, "text" or "json"
+	LogFormat string
+	// Comma separated subsystem=level pairs overriding LogLevel for the subsystems, e.g. "gnet=debug,visor=info"
+	LogLevelSubsystem  string
+	logLevelSubsystems map[string]logrus.Level
 	// Disable "Reply to ping", "Received pong" log messages
 	DisablePingPong bool
 
@@ -143,6 +208,16 @@ type NodeConfig struct {
 	VerifyDB bool
 	// Reset the database if integrity checks fail, and continue running
 	ResetCorruptDB bool
+	// Rebuild the unspent pool from the blockchain, replacing the unspent pool if the rebuilt pool matches the block headers
+	RepairUnspents bool
+	// Create the database from a snapshot, see visor.CreateSnapshot. The database is verified after it is created.
+	BootstrapFromSnapshot string
+	// Log a breakdown of the stages of applying a block if it takes longer than this many milliseconds. 0 disables the logging
+	TraceSlowBlocksMs uint64
+	// Check the coin invariants of every executed block, refusing blocks that violate them. Slow, for debugging
+	Paranoid bool
+	// How often the database is compacted, see visor.Visor.CompactDB. 0 disables the scheduled compactions
+	DBCompactionInterval time.Duration
 
 	// Transaction verification parameters for unconfirmed transactions
 	UnconfirmedVerifyTxn params.VerifyTxn
@@ -150,6 +225,8 @@ type NodeConfig struct {
 	CreateBlockVerifyTxn params.VerifyTxn
 	// Maximum total size of transactions in a block
 	MaxBlockTransactionsSize uint32
+	// Changes of the user burn factor at scheduled block times, see visor.NewBurnPolicy
+	BurnFactorSchedule []fee.BurnFactorChange
 
 	unconfirmedBurnFactor          uint64
 	maxUnconfirmedTransactionSize  uint64
@@ -164,6 +241,10 @@ type NodeConfig struct {
 	WalletDirectory string
 	// Wallet crypto type
 	WalletCryptoType string
+	// Fail creating a wallet or changing its label if another wallet has the label
+	RequireUniqueWalletLabels bool
+	// Only upgrade wallet files to the current format with POST /api/v1/wallets/migrate, not when they are loaded
+	ExplicitWalletMigrations bool
 
 	// Key-value storage
 	// Default to ${DataDirectory}/data
@@ -200,14 +281,19 @@ type NodeConfig struct {
 	GenesisTimestamp    uint64
 	GenesisCoinVolume   uint64
 	DefaultConnections  []string
+	// Outputs of a multi-output genesis block, instead of the single output to GenesisAddressStr
+	GenesisOutputs []fiber.GenesisOutput
 
 	genesisSignature cipher.Sig
 	genesisAddress   cipher.Address
+	genesisOutputs   []coin.TransactionOutput
 	genesisHash      cipher.SHA256
 
 	blockchainPubkey cipher.PubKey
 	blockchainSeckey cipher.SecKey
 
+	trustedPeerBundleKeys []cipher.PubKey
+
 	Fiber readable.FiberConfig
 }
 
@@ -219,6 +305,7 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		GenesisAddressStr:   node.GenesisAddressStr,
 		GenesisCoinVolume:   node.GenesisCoinVolume,
 		GenesisTimestamp:    node.GenesisTimestamp,
+		GenesisOutputs:      node.GenesisOutputs,
 		BlockchainPubkeyStr: node.BlockchainPubkeyStr,
 		BlockchainSeckeyStr: node.BlockchainSeckeyStr,
 		DefaultConnections:  node.DefaultConnections,
@@ -234,6 +321,10 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		EnableGUI: false,
 		// Disable CSRF check in the wallet API
 		DisableCSRF: false,
+		// Lifetime of CSRF tokens
+		CSRFTokenLifetime: time.Second * 30,
+		// Bind CSRF tokens to the client's origin and session
+		CSRFStateless: false,
 		// Disable Host, Origin and Referer header check in the wallet API
 		DisableHeaderCheck: false,
 		// DisableCSP disable content-security-policy in http response
@@ -258,6 +349,22 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		MaxOutgoingMessageLength: 256 * 1024,
 		MaxIncomingMessageLength: 1024 * 1024,
 		PeerlistSize:             65535,
+		// Ban peers that send more than 20 recent invalid objects for a day
+		BanThreshold: 20,
+		BanWindow:    time.Hour,
+		BanDuration:  time.Hour * 24,
+		// Number of recently seen transactions and blocks whose propagation is tracked
+		PropagationTrackerSize: 1000,
+		// Don't announce an unconfirmed transaction to newly introduced peers more than once per 10 minutes
+		TxnReannounceInterval: time.Minute * 10,
+		// Ping peers that support keepalives after 30 seconds of silence,
+		// and disconnect them after two unanswered pings
+		KeepaliveInterval:       time.Second * 30,
+		KeepaliveTimeout:        time.Second * 10,
+		KeepaliveMaxMissedPongs: 2,
+		// Process each message within a minute, and disconnect peers after five slow messages
+		MessageHandlerTimeout:  time.Minute,
+		MaxSlowMessageHandlers: 5,
 		// Wallet Address Version
 		// AddressVersion: "test",
 		// Remote web interface
@@ -282,6 +389,7 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		// Logging
 		ColorLog:        true,
 		LogLevel:        "INFO",
+		LogFormat:       plogging.FormatText,
 		LogToFile:       false,
 		DisablePingPong: false,
 
@@ -300,6 +408,7 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 			MaxDropletPrecision: node.CreateBlockMaxDropletPrecision,
 		},
 		MaxBlockTransactionsSize: node.MaxBlockTransactionsSize,
+		BurnFactorSchedule:       node.BurnFactorChanges(),
 
 		// Wallets
 		WalletDirectory:  "",
@@ -318,6 +427,8 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		HTTPWriteTimeout: time.Second * 60,
 		HTTPIdleTimeout:  time.Second * 120,
 
+		ShutdownTimeout: time.Second * 30,
+
 		RunBlockPublisher: false,
 
 		// Enable cpu profiling
@@ -363,12 +474,27 @@ func (c *Config) postProcess() error {
 		panicIfError(err, "Invalid Address")
 	}
 
+	genesisOutputs, err := fiber.NodeConfig{
+		GenesisCoinVolume: c.Node.GenesisCoinVolume,
+		GenesisOutputs:    c.Node.GenesisOutputs,
+	}.GenesisTransactionOutputs()
+	panicIfError(err, "Invalid genesis outputs")
+
 	// Compute genesis block hash
-	gb, err := coin.NewGenesisBlock(c.Node.genesisAddress, c.Node.GenesisCoinVolume, c.Node.GenesisTimestamp)
-	if err != nil {
+	if len(genesisOutputs) != 0 {
+		gb, err := pcoin.NewGenesisBlockMulti(genesisOutputs, c.Node.GenesisTimestamp)
+		panicIfError(err, "Create genesis hash failed")
+		c.Node.genesisHash = gb.HashHeader()
+
+		c.Node.genesisOutputs = make([]coin.TransactionOutput, len(genesisOutputs))
+		for i, o := range genesisOutputs {
+			c.Node.genesisOutputs[i] = coin.TransactionOutput(o)
+		}
+	} else {
+		gb, err := coin.NewGenesisBlock(c.Node.genesisAddress, c.Node.GenesisCoinVolume, c.Node.GenesisTimestamp)
 		panicIfError(err, "Create genesis hash failed")
+		c.Node.genesisHash = gb.HashHeader()
 	}
-	c.Node.genesisHash = gb.HashHeader()
 
 	if c.Node.BlockchainPubkeyStr != "" {
 		c.Node.blockchainPubkey, err = cipher.PubKeyFromHex(c.Node.BlockchainPubkeyStr)
@@ -423,6 +549,13 @@ func (c *Config) postProcess() error {
 		c.Node.DBPath = replaceHome(c.Node.DBPath, home)
 	}
 
+	if c.Node.BootstrapFromSnapshot != "" {
+		if c.Node.DBReadOnly {
+			return errors.New("-bootstrap-from-snapshot can't be used with -db-read-only")
+		}
+		c.Node.BootstrapFromSnapshot = replaceHome(c.Node.BootstrapFromSnapshot, home)
+	}
+
 	userAgentData := useragent.Data{
 		Coin:    c.Node.CoinName,
 		Version: c.Build.Version,
@@ -462,6 +595,64 @@ func (c *Config) postProcess() error {
 		c.Node.hostWhitelist = strings.Split(c.Node.HostWhitelist, ",")
 	}
 
+	switch c.Node.LogFormat {
+	case plogging.FormatText, plogging.FormatJSON:
+	default:
+		return fmt.Errorf("Invalid -log-format %q, must be %s or %s", c.Node.LogFormat, plogging.FormatText, plogging.FormatJSON)
+	}
+
+	c.Node.logLevelSubsystems, err = plogging.ParseSubsystemLevels(c.Node.LogLevelSubsystem)
+	if err != nil {
+		return fmt.Errorf("Invalid -log-level-subsystem: %v", err)
+	}
+
+	c.Node.messageHandlerTimeouts, err = daemon.ParseMessageHandlerTimeouts(c.Node.MessageHandlerTimeouts)
+	if err != nil {
+		return fmt.Errorf("Invalid -message-handler-timeouts: %v", err)
+	}
+
+	if c.Node.TrustedPeerBundleKeys != "" {
+		for _, k := range strings.Split(c.Node.TrustedPeerBundleKeys, ",") {
+			pk, err := cipher.PubKeyFromHex(strings.TrimSpace(k))
+			if err != nil {
+				return fmt.Errorf("Invalid -trusted-peer-bundle-keys public key %q: %v", k, err)
+			}
+			c.Node.trustedPeerBundleKeys = append(c.Node.trustedPeerBundleKeys, pk)
+		}
+	}
+
+	if err := c.Node.banConfig().Verify(); err != nil {
+		return fmt.Errorf("Invalid -ban-threshold, -ban-window or -ban-duration: %v", err)
+	}
+
+	if c.Node.AlwaysConnect != "" {
+		for _, a := range strings.Split(c.Node.AlwaysConnect, ",") {
+			a = strings.TrimSpace(a)
+			ip, _, err := iputil.SplitAddr(a)
+			if err != nil {
+				return fmt.Errorf("Invalid -always-connect address %q: %v", a, err)
+			}
+			if c.Node.LocalhostOnly && !iputil.IsLocalhost(ip) {
+				return fmt.Errorf("Invalid -always-connect address %q: only localhost peers are allowed with -localhost-only", a)
+			}
+			c.Node.alwaysConnect = append(c.Node.alwaysConnect, a)
+		}
+	}
+
+	if c.Node.APIKeyFile != "" {
+		if c.Node.APIKey != "" {
+			return errors.New("-api-key and -api-key-file cannot be combined")
+		}
+		key, err := ioutil.ReadFile(c.Node.APIKeyFile)
+		if err != nil {
+			return fmt.Errorf("Invalid -api-key-file: %v", err)
+		}
+		c.Node.APIKey = strings.TrimSpace(string(key))
+		if c.Node.APIKey == "" {
+			return fmt.Errorf("Invalid -api-key-file: %s is empty", c.Node.APIKeyFile)
+		}
+	}
+
 	httpAuthEnabled := c.Node.WebInterfaceUsername != "" || c.Node.WebInterfacePassword != ""
 	if httpAuthEnabled && !c.Node.WebInterfaceHTTPS && !c.Node.WebInterfacePlaintextAuth {
 		return errors.New("Web interface auth enabled but HTTPS is not enabled. Use -web-interface-plaintext-auth=true if this is desired")
@@ -488,6 +679,13 @@ func (c *Config) postProcess() error {
 		return errors.New("-burn-factor-create-block exceeds MaxUint32")
 	}
 
+	if c.Node.MaxUploadKbps > math.MaxUint64/bytesPerKbps {
+		return errors.New("-max-upload-kbps is too large")
+	}
+	if c.Node.MaxDownloadKbps > math.MaxUint64/bytesPerKbps {
+		return errors.New("-max-download-kbps is too large")
+	}
+
 	if c.Node.unconfirmedMaxDropletPrecision > math.MaxUint8 {
 		return errors.New("-max-decimals-unconfirmed exceeds MaxUint8")
 	}
@@ -629,6 +827,15 @@ func validateAPISets(opt string, apiSets []string) error {
 	return nil
 }
 
+// banConfig returns the configuration of the banning of peers that repeatedly send invalid objects
+func (c *NodeConfig) banConfig() ppex.BanConfig {
+	return ppex.BanConfig{
+		Threshold: c.BanThreshold,
+		Window:    c.BanWindow,
+		Duration:  c.BanDuration,
+	}
+}
+
 // RegisterFlags binds CLI flags to config values
 func (c *NodeConfig) RegisterFlags() {
 	flag.BoolVar(&help, "help", false, "Show help")
@@ -640,6 +847,8 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.BoolVar(&c.DisableNetworking, "disable-networking", c.DisableNetworking, "Disable all network activity")
 	flag.BoolVar(&c.EnableGUI, "enable-gui", c.EnableGUI, "Enable GUI")
 	flag.BoolVar(&c.DisableCSRF, "disable-csrf", c.DisableCSRF, "disable CSRF check")
+	flag.DurationVar(&c.CSRFTokenLifetime, "csrf-token-lifetime", c.CSRFTokenLifetime, "lifetime of CSRF tokens")
+	flag.BoolVar(&c.CSRFStateless, "csrf-stateless", c.CSRFStateless, "don't bind CSRF tokens to the client's origin and session. Deprecated, will be removed in the next release")
 	flag.BoolVar(&c.DisableHeaderCheck, "disable-header-check", c.DisableHeaderCheck, "disables the host, origin and referer header checks.")
 	flag.BoolVar(&c.DisableCSP, "disable-csp", c.DisableCSP, "disable content-security-policy in http response")
 	flag.StringVar(&c.Address, "address", c.Address, "IP Address to run application on. Leave empty to default to a public interface")
@@ -670,16 +879,21 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.StringVar(&c.WebInterfaceUsername, "web-interface-username", c.WebInterfaceUsername, "username for the web interface")
 	flag.StringVar(&c.WebInterfacePassword, "web-interface-password", c.WebInterfacePassword, "password for the web interface")
 	flag.BoolVar(&c.WebInterfacePlaintextAuth, "web-interface-plaintext-auth", c.WebInterfacePlaintextAuth, "allow web interface auth without https")
+	flag.StringVar(&c.APIKey, "api-key", c.APIKey, "require this API key in the X-API-Key header of the wallet, storage and admin API endpoints. The read-only endpoints don't require it")
+	flag.StringVar(&c.APIKeyFile, "api-key-file", c.APIKeyFile, "read the API key of -api-key from this file")
 
 	flag.BoolVar(&c.LaunchBrowser, "launch-browser", c.LaunchBrowser, "launch system default webbrowser at client startup")
 	flag.StringVar(&c.DataDirectory, "data-dir", c.DataDirectory, "directory to store app data (defaults to ~/.skycoin)")
 	flag.StringVar(&c.DBPath, "db-path", c.DBPath, "path of database file (defaults to ~/.skycoin/data.db)")
 	flag.BoolVar(&c.DBReadOnly, "db-read-only", c.DBReadOnly, "open bolt db read-only")
+	flag.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "Maximum time to wait for a graceful shutdown before exiting. 0 waits indefinitely")
 	flag.BoolVar(&c.ProfileCPU, "profile-cpu", c.ProfileCPU, "enable cpu profiling")
 	flag.StringVar(&c.ProfileCPUFile, "profile-cpu-file", c.ProfileCPUFile, "where to write the cpu profile file")
 	flag.BoolVar(&c.HTTPProf, "http-prof", c.HTTPProf, "run the HTTP profiling interface")
 	flag.StringVar(&c.HTTPProfHost, "http-prof-host", c.HTTPProfHost, "hostname to bind the HTTP profiling interface to")
 	flag.StringVar(&c.LogLevel, "log-level", c.LogLevel, "Choices are: debug, info, warn, error, fatal, panic")
+	flag.StringVar(&c.LogFormat, "log-format", c.LogFormat, "Log format, text or json. The json format writes one JSON object per line")
+	flag.StringVar(&c.LogLevelSubsystem, "log-level-subsystem", c.LogLevelSubsystem, "Comma separated subsystem=level pairs overriding -log-level for the subsystems, e.g. gnet=debug,visor=info. The subsystems include daemon, visor, gnet, pex, api and wallet")
 	flag.BoolVar(&c.ColorLog, "color-log", c.ColorLog, "Add terminal colors to log output")
 	flag.BoolVar(&c.DisablePingPong, "no-ping-log", c.DisablePingPong, `disable "reply to ping" and "received pong" debug log messages`)
 	flag.BoolVar(&c.LogToFile, "logtofile", c.LogToFile, "log to file")
@@ -687,6 +901,11 @@ func (c *NodeConfig) RegisterFlags() {
 
 	flag.BoolVar(&c.VerifyDB, "verify-db", c.VerifyDB, "check the database for corruption")
 	flag.BoolVar(&c.ResetCorruptDB, "reset-corrupt-db", c.ResetCorruptDB, "reset the database if corrupted, and continue running instead of exiting")
+	flag.BoolVar(&c.RepairUnspents, "repair-unspents", c.RepairUnspents, "rebuild the unspent pool from the blockchain without verifying signatures again, for an unspent pool hash that does not match the blockchain. An interrupted repair resumes on the next start with this flag")
+	flag.StringVar(&c.BootstrapFromSnapshot, "bootstrap-from-snapshot", c.BootstrapFromSnapshot, "create the database from this database snapshot, checked against the manifest next to it, then verify it and sync the remaining blocks from peers. The database must not exist")
+	flag.Uint64Var(&c.TraceSlowBlocksMs, "trace-slow-blocks-ms", c.TraceSlowBlocksMs, "log a breakdown of the stages of applying a block that takes longer than this many milliseconds. 0 disables the logging")
+	flag.DurationVar(&c.DBCompactionInterval, "db-compaction-interval", c.DBCompactionInterval, "compact the database this often, copying its live data into a new database file that replaces it. Database writes wait during a compaction. 0 disables the scheduled compactions, the database can be compacted with POST /api/v2/storage/compact")
+	flag.BoolVar(&c.Paranoid, "paranoid", c.Paranoid, "check that every executed block conserves coins, does not create hours and matches the unspent pool, refusing blocks that do not. Reads the whole unspent pool twice per block, for debugging")
 
 	flag.BoolVar(&c.DisableDefaultPeers, "disable-default-peers", c.DisableDefaultPeers, "disable the hardcoded default peers")
 	flag.StringVar(&c.CustomPeersFile, "custom-peers-file", c.CustomPeersFile, "load custom peers from a newline separate list of ip:port in a file. Note that this is different from the peers.json file in the data directory")
@@ -715,11 +934,28 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.IntVar(&c.MaxOutgoingConnections, "max-outgoing-connections", c.MaxOutgoingConnections, "Maximum number of outgoing connections allowed")
 	flag.IntVar(&c.MaxDefaultPeerOutgoingConnections, "max-default-peer-outgoing-connections", c.MaxDefaultPeerOutgoingConnections, "The maximum default peer outgoing connections allowed")
 	flag.IntVar(&c.PeerlistSize, "peerlist-size", c.PeerlistSize, "Max number of peers to track in peerlist")
+	flag.StringVar(&c.TrustedPeerBundleKeys, "trusted-peer-bundle-keys", c.TrustedPeerBundleKeys, "Comma separated list of public keys trusted to sign imported peer bundles. If empty, unsigned peer bundles are accepted")
+	flag.IntVar(&c.BanThreshold, "ban-threshold", c.BanThreshold, "Ban the IP of a peer that sends more than this number of recent invalid transactions, invalid blocks and malformed messages. 0 disables banning")
+	flag.DurationVar(&c.BanWindow, "ban-window", c.BanWindow, "Half-life of the counts of invalid objects received from a peer IP")
+	flag.DurationVar(&c.BanDuration, "ban-duration", c.BanDuration, "How long to ban a peer IP for")
+	flag.StringVar(&c.AlwaysConnect, "always-connect", c.AlwaysConnect, "Comma separated list of ip:port peers that are always kept connected. They are redialed whenever the connection drops and are exempt from the connection limits")
 	flag.DurationVar(&c.OutgoingConnectionsRate, "connection-rate", c.OutgoingConnectionsRate, "How often to make an outgoing connection")
 	flag.IntVar(&c.MaxOutgoingMessageLength, "max-out-msg-len", c.MaxOutgoingMessageLength, "Maximum length of outgoing wire messages")
 	flag.IntVar(&c.MaxIncomingMessageLength, "max-in-msg-len", c.MaxIncomingMessageLength, "Maximum length of incoming wire messages")
+	flag.Uint64Var(&c.MaxUploadKbps, "max-upload-kbps", c.MaxUploadKbps, "Maximum upload rate of block transfers in kilobits per second. 0 is unlimited")
+	flag.Uint64Var(&c.MaxDownloadKbps, "max-download-kbps", c.MaxDownloadKbps, "Maximum download rate of block transfers in kilobits per second. 0 is unlimited")
+	flag.IntVar(&c.PropagationTrackerSize, "propagation-tracker-size", c.PropagationTrackerSize, "Number of recently seen transactions and blocks whose propagation is tracked")
+	flag.DurationVar(&c.TxnReannounceInterval, "txn-reannounce-interval", c.TxnReannounceInterval, "Minimum interval between announcements of an unconfirmed transaction to newly introduced peers")
+	flag.DurationVar(&c.KeepaliveInterval, "keepalive-interval", c.KeepaliveInterval, "How long a connection must be idle before a keepalive ping is sent, to peers that support keepalives")
+	flag.DurationVar(&c.KeepaliveTimeout, "keepalive-timeout", c.KeepaliveTimeout, "How long to wait for the reply to a keepalive ping")
+	flag.IntVar(&c.KeepaliveMaxMissedPongs, "keepalive-max-missed", c.KeepaliveMaxMissedPongs, "Number of consecutive unanswered keepalive pings after which a peer is disconnected")
+	flag.DurationVar(&c.MessageHandlerTimeout, "message-handler-timeout", c.MessageHandlerTimeout, "Deadline for processing a message received from a peer. 0 disables the deadlines")
+	flag.StringVar(&c.MessageHandlerTimeouts, "message-handler-timeouts", c.MessageHandlerTimeouts, "Comma separated message ID=duration pairs overriding -message-handler-timeout for the message types, e.g. GIVB=2m,GIVT=30s")
+	flag.IntVar(&c.MaxSlowMessageHandlers, "max-slow-message-handlers", c.MaxSlowMessageHandlers, "Number of messages exceeding their processing deadline after which a peer is disconnected. 0 never disconnects peers for slow messages")
 	flag.BoolVar(&c.LocalhostOnly, "localhost-only", c.LocalhostOnly, "Run on localhost and only connect to localhost peers")
 	flag.StringVar(&c.WalletCryptoType, "wallet-crypto-type", c.WalletCryptoType, "wallet crypto type. Can be sha256-xor or scrypt-chacha20poly1305")
+	flag.BoolVar(&c.RequireUniqueWalletLabels, "require-unique-wallet-labels", c.RequireUniqueWalletLabels, "Fail creating a wallet or changing its label if another wallet has the label")
+	flag.BoolVar(&c.ExplicitWalletMigrations, "explicit-wallet-migrations", c.ExplicitWalletMigrations, "Only upgrade wallet files to the current format with POST /api/v1/wallets/migrate, not when they are loaded")
 	flag.BoolVar(&c.Version, "version", false, "show node version")
 }
 
@@ -0,0 +1,64 @@
+package skycoin
+
+import (
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/util/apputil"
+	"github.com/ness-network/privateness/src/util/datadir"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+// lockDataDirectory loads the node's persistent identifier and writes the lockfile to the data directory.
+// A conflicting lockfile is logged, but does not stop the node, since a lockfile of a node that
+// crashed on another host sharing the data directory can't be told apart from one that is in use.
+func (c *Coin) lockDataDirectory() (*datadir.Lock, error) {
+	nodeID, err := datadir.LoadOrCreateNodeID(c.config.Node.DataDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Infof("Node ID: %s", nodeID)
+
+	lock, err := datadir.AcquireLock(c.config.Node.DataDirectory, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s := lock.Status(); s.Status == datadir.LockStatusConflict {
+		c.logger.Critical().Warningf("The data directory %s may be in use by another node: node_id=%s hostname=%s pid=%d",
+			c.config.Node.DataDirectory, s.Previous.NodeID, s.Previous.Hostname, s.Previous.PID)
+	}
+
+	if hash, err := apputil.ExecutableHash(); err != nil {
+		c.logger.WithError(err).Warning("apputil.ExecutableHash failed")
+	} else {
+		c.logger.Infof("Executable hash: %s", hash)
+	}
+
+	return lock, nil
+}
+
+// dataDirFingerprint returns the fingerprint of the data directory, see datadir.Fingerprint.
+// The DB UID is created if the DB has none, unless the DB is read-only.
+func dataDirFingerprint(db *dbutil.DB, v *visor.Visor) (string, error) {
+	var uid string
+	var err error
+	if db.IsReadOnly() {
+		uid, err = visor.GetDBUID(db)
+	} else {
+		uid, err = visor.GetOrCreateDBUID(db)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	genesis, err := v.GetSignedBlockBySeq(0)
+	if err != nil {
+		return "", err
+	}
+	if genesis == nil {
+		return "", nil
+	}
+
+	return datadir.Fingerprint(genesis.HashHeader(), uid), nil
+}
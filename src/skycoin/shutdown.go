@@ -0,0 +1,137 @@
+package skycoin
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	"github.com/ness-network/privateness/src/visor"
+)
+
+var (
+	// errShutdownTimeout is returned by runShutdown if the shutdown steps did not finish in time
+	errShutdownTimeout = errors.New("shutdown timed out")
+)
+
+// shutdownStep is a step of the node shutdown sequence
+type shutdownStep struct {
+	name string
+	run  func() error
+}
+
+// runShutdown runs the shutdown steps in order. A failed step is logged and the
+// remaining steps still run. If the steps have not finished after timeout,
+// errShutdownTimeout is returned without waiting for the remaining steps.
+// A timeout of 0 waits for all steps.
+func runShutdown(logger *logging.Logger, steps []shutdownStep, timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, s := range steps {
+			logger.Infof("Shutdown: %s", s.name)
+			if err := s.run(); err != nil {
+				logger.WithError(err).Errorf("Shutdown: %s failed", s.name)
+			}
+		}
+	}()
+
+	if timeout == 0 {
+		<-done
+		return nil
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-t.C:
+		return errShutdownTimeout
+	}
+}
+
+// unconfirmedTxnPool is the interface of the visor used to save and reload the unconfirmed transaction pool
+type unconfirmedTxnPool interface {
+	GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error)
+	InjectForeignTransaction(coin.Transaction) (bool, *visor.ErrTxnViolatesSoftConstraint, error)
+	GetUnconfirmedTxnAnnouncements() (map[cipher.SHA256]visor.TxnAnnouncement, error)
+	RestoreUnconfirmedTxnAnnouncements(map[cipher.SHA256]visor.TxnAnnouncement) (int, error)
+}
+
+// saveUnconfirmedTxns writes the unconfirmed transaction pool to filename,
+// with the announcement state of the transactions
+func saveUnconfirmedTxns(pool unconfirmedTxnPool, filename string) error {
+	txns, err := pool.GetAllUnconfirmedTransactions()
+	if err != nil {
+		return err
+	}
+
+	announcements, err := pool.GetUnconfirmedTxnAnnouncements()
+	if err != nil {
+		return err
+	}
+
+	return visor.SaveUnconfirmedTxnsFile(filename, txns, announcements)
+}
+
+// loadUnconfirmedTxns injects the transactions saved to filename by saveUnconfirmedTxns
+// into the unconfirmed transaction pool, then removes the file.
+// Each transaction is verified against the current blockchain head. Transactions that can't be
+// decoded or that violate hard constraints are dropped. Transactions that only violate soft constraints
+// are added to the pool marked invalid, like transactions received from peers.
+// The announcement state of the transactions is restored, so that the transactions announced
+// shortly before the shutdown are not announced again to every peer that connects.
+// Returns the number of transactions added to the pool.
+func loadUnconfirmedTxns(logger *logging.Logger, pool unconfirmedTxnPool, filename string) (int, error) {
+	f, err := visor.LoadUnconfirmedTxnsFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	if f == nil {
+		return 0, nil
+	}
+
+	n := 0
+	announcements := make(map[cipher.SHA256]visor.TxnAnnouncement)
+	for _, e := range f.Transactions {
+		txn, err := e.Transaction()
+		if err != nil {
+			logger.WithError(err).WithField("txid", e.Hash).Warning("Dropping saved unconfirmed transaction, decoding failed")
+			continue
+		}
+
+		known, softErr, err := pool.InjectForeignTransaction(txn)
+		if err != nil {
+			logger.WithError(err).WithField("txid", e.Hash).Warning("Dropping saved unconfirmed transaction, verification failed")
+			continue
+		}
+
+		if softErr != nil {
+			logger.WithError(softErr).WithField("txid", e.Hash).Warning("Saved unconfirmed transaction violates soft constraints, marked invalid")
+		}
+
+		if !known {
+			n++
+		}
+
+		if a := e.Announcement(); a != nil {
+			announcements[txn.Hash()] = *a
+		}
+	}
+
+	logger.Infof("Reloaded %d of %d saved unconfirmed transactions", n, len(f.Transactions))
+
+	restored, err := pool.RestoreUnconfirmedTxnAnnouncements(announcements)
+	if err != nil {
+		logger.WithError(err).Warning("Restoring the announcements of the saved unconfirmed transactions failed")
+	} else if restored > 0 {
+		logger.Infof("Restored the announcements of %d saved unconfirmed transactions", restored)
+	}
+
+	return n, os.Remove(filename)
+}
@@ -0,0 +1,351 @@
+package skycoin
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/util/file"
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	"github.com/ness-network/privateness/src/daemon/gnet"
+	"github.com/ness-network/privateness/src/fiber"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/visor"
+)
+
+var testLogger = logging.MustGetLogger("skycoin_test")
+
+// fakeUnconfirmedTxnPool is an in-memory unconfirmedTxnPool.
+// Transactions in hardErrs or softErrs fail verification with that error.
+type fakeUnconfirmedTxnPool struct {
+	txns          []visor.UnconfirmedTransaction
+	hardErrs      map[cipher.SHA256]error
+	softErrs      map[cipher.SHA256]error
+	announcements map[cipher.SHA256]visor.TxnAnnouncement
+}
+
+func (p *fakeUnconfirmedTxnPool) GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error) {
+	return p.txns, nil
+}
+
+func (p *fakeUnconfirmedTxnPool) InjectForeignTransaction(txn coin.Transaction) (bool, *visor.ErrTxnViolatesSoftConstraint, error) {
+	h := txn.Hash()
+	if err := p.hardErrs[h]; err != nil {
+		return false, nil, visor.NewErrTxnViolatesHardConstraint(err)
+	}
+
+	for _, t := range p.txns {
+		if t.Transaction.Hash() == h {
+			return true, nil, nil
+		}
+	}
+
+	ut := visor.NewUnconfirmedTransaction(txn)
+	ut.IsValid = 1
+
+	var softErr *visor.ErrTxnViolatesSoftConstraint
+	if err := p.softErrs[h]; err != nil {
+		e := visor.NewErrTxnViolatesSoftConstraint(err).(visor.ErrTxnViolatesSoftConstraint)
+		softErr = &e
+		ut.IsValid = 0
+	}
+
+	p.txns = append(p.txns, ut)
+	return false, softErr, nil
+}
+
+func (p *fakeUnconfirmedTxnPool) GetUnconfirmedTxnAnnouncements() (map[cipher.SHA256]visor.TxnAnnouncement, error) {
+	return p.announcements, nil
+}
+
+func (p *fakeUnconfirmedTxnPool) RestoreUnconfirmedTxnAnnouncements(announcements map[cipher.SHA256]visor.TxnAnnouncement) (int, error) {
+	if p.announcements == nil {
+		p.announcements = make(map[cipher.SHA256]visor.TxnAnnouncement)
+	}
+
+	n := 0
+	for h, a := range announcements {
+		for _, t := range p.txns {
+			if t.Transaction.Hash() == h && a.Time > p.announcements[h].Time {
+				p.announcements[h] = a
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func makeShutdownTestTxn(t *testing.T) coin.Transaction {
+	txn := coin.Transaction{}
+	err := txn.PushInput(testutil.RandSHA256(t))
+	require.NoError(t, err)
+	err = txn.PushOutput(testutil.MakeAddress(), 1e6, 10)
+	require.NoError(t, err)
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+	return txn
+}
+
+func TestRunShutdown(t *testing.T) {
+	var order []string
+	step := func(name string, err error) shutdownStep {
+		return shutdownStep{
+			name: name,
+			run: func() error {
+				order = append(order, name)
+				return err
+			},
+		}
+	}
+
+	// All steps run in order, a failed step doesn't stop the sequence
+	err := runShutdown(testLogger, []shutdownStep{
+		step("a", nil),
+		step("b", errors.New("b failed")),
+		step("c", nil),
+	}, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, order)
+
+	// A stuck step times out the shutdown, later steps don't run
+	block := make(chan struct{})
+	defer close(block)
+	var mu sync.Mutex
+	ran := false
+	err = runShutdown(testLogger, []shutdownStep{
+		{
+			name: "stuck",
+			run: func() error {
+				<-block
+				return nil
+			},
+		},
+		{
+			name: "after",
+			run: func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				ran = true
+				return nil
+			},
+		},
+	}, time.Millisecond*50)
+	require.Equal(t, errShutdownTimeout, err)
+	mu.Lock()
+	require.False(t, ran)
+	mu.Unlock()
+}
+
+func TestSaveLoadUnconfirmedTxns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unconfirmed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, visor.UnconfirmedTxnsFilename)
+
+	// Nothing is loaded if no pool was saved
+	empty := &fakeUnconfirmedTxnPool{}
+	n, err := loadUnconfirmedTxns(testLogger, empty, filename)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	require.Empty(t, empty.txns)
+
+	txns := make([]coin.Transaction, 4)
+	for i := range txns {
+		txns[i] = makeShutdownTestTxn(t)
+	}
+
+	pool := &fakeUnconfirmedTxnPool{}
+	for _, txn := range txns {
+		_, _, err := pool.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	// txns[1] and txns[3] were announced before the shutdown
+	pool.announcements = map[cipher.SHA256]visor.TxnAnnouncement{
+		txns[1].Hash(): {Time: 100, Count: 1, Peers: 2},
+		txns[3].Hash(): {Time: 200, Count: 3, Peers: 4},
+	}
+
+	err = saveUnconfirmedTxns(pool, filename)
+	require.NoError(t, err)
+
+	// The restarted node's pool already has txns[0], txns[1] was confirmed
+	// or double spent while the node was stopped and txns[2] violates soft constraints
+	restarted := &fakeUnconfirmedTxnPool{
+		txns: []visor.UnconfirmedTransaction{pool.txns[0]},
+		hardErrs: map[cipher.SHA256]error{
+			txns[1].Hash(): errors.New("unspent output does not exist"),
+		},
+		softErrs: map[cipher.SHA256]error{
+			txns[2].Hash(): errors.New("transaction has zero coinhour fee"),
+		},
+	}
+
+	n, err = loadUnconfirmedTxns(testLogger, restarted, filename)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	require.Len(t, restarted.txns, 3)
+	require.Equal(t, txns[0].Hash(), restarted.txns[0].Transaction.Hash())
+	require.Equal(t, txns[2].Hash(), restarted.txns[1].Transaction.Hash())
+	require.Equal(t, int8(0), restarted.txns[1].IsValid)
+	require.Equal(t, txns[3].Hash(), restarted.txns[2].Transaction.Hash())
+	require.Equal(t, int8(1), restarted.txns[2].IsValid)
+
+	// The announcements of the transactions in the pool are restored
+	require.Equal(t, map[cipher.SHA256]visor.TxnAnnouncement{
+		txns[3].Hash(): {Time: 200, Count: 3, Peers: 4},
+	}, restarted.announcements)
+
+	// The file is removed after it is loaded
+	_, err = os.Stat(filename)
+	require.True(t, os.IsNotExist(err))
+
+	// A corrupt file is an error
+	err = ioutil.WriteFile(filename, []byte("{"), 0600)
+	require.NoError(t, err)
+	_, err = loadUnconfirmedTxns(testLogger, restarted, filename)
+	require.Error(t, err)
+}
+
+func newShutdownTestCoin(t *testing.T, dataDir string) *Coin {
+	nodeConfig := NewNodeConfig("", fiber.NodeConfig{
+		CoinName:            "privateness",
+		GenesisSignatureStr: "05d4045854103f8a8938bb701cc4101c38942a180ba02d328d6f880bf37b387c47e95813d061f94bdf5d894bfebf17f933c5fc92fc9d010480765257c3d19d9b00",
+		GenesisAddressStr:   "24GJTLPMoz61sV4J4qg1n14x5qqDwXqyJJy",
+		GenesisCoinVolume:   200e12,
+		GenesisTimestamp:    1426562704,
+		BlockchainPubkeyStr: "02933015bd2fa1e0a885c05fb08eb7c647bf8c3188ed5120b51d0d09ccaf525036",
+		Port:                6660,
+		WebInterfacePort:    6420,
+		DataDirectory:       dataDir,
+
+		UnconfirmedBurnFactor:          10,
+		UnconfirmedMaxTransactionSize:  32768,
+		UnconfirmedMaxDropletPrecision: 3,
+		CreateBlockBurnFactor:          10,
+		CreateBlockMaxTransactionSize:  32768,
+		CreateBlockMaxDropletPrecision: 3,
+		MaxBlockTransactionsSize:       32768,
+		Bip44Coin:                      8000,
+	})
+	// These are set from the defaults when the flags are registered
+	nodeConfig.maxUnconfirmedTransactionSize = uint64(nodeConfig.UnconfirmedVerifyTxn.MaxTransactionSize)
+	nodeConfig.unconfirmedBurnFactor = uint64(nodeConfig.UnconfirmedVerifyTxn.BurnFactor)
+	nodeConfig.unconfirmedMaxDropletPrecision = uint64(nodeConfig.UnconfirmedVerifyTxn.MaxDropletPrecision)
+	nodeConfig.createBlockBurnFactor = uint64(nodeConfig.CreateBlockVerifyTxn.BurnFactor)
+	nodeConfig.createBlockMaxTransactionSize = uint64(nodeConfig.CreateBlockVerifyTxn.MaxTransactionSize)
+	nodeConfig.createBlockMaxDropletPrecision = uint64(nodeConfig.CreateBlockVerifyTxn.MaxDropletPrecision)
+	nodeConfig.maxBlockSize = uint64(nodeConfig.MaxBlockTransactionsSize)
+
+	nodeConfig.DisableNetworking = true
+	nodeConfig.WebInterface = false
+	nodeConfig.DownloadPeerList = false
+	nodeConfig.ShutdownTimeout = time.Second * 10
+
+	c := NewCoin(Config{
+		Node: nodeConfig,
+		Build: readable.BuildInfo{
+			Version: "0.27.1",
+		},
+	}, testLogger)
+
+	err := c.ParseConfig()
+	require.NoError(t, err)
+
+	return c
+}
+
+// runUntilSIGTERM runs the node in-process and sends SIGTERM to the process once ready returns true.
+// ready must only return true after the node has installed its signal handler.
+func runUntilSIGTERM(t *testing.T, c *Coin, ready func() bool) {
+	// daemon.New registers the wire protocol messages, which can only be registered once
+	gnet.EraseMessages()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- c.Run()
+	}()
+
+	for !ready() {
+
+		select {
+		case err := <-errC:
+			t.Fatalf("Run returned before SIGTERM: %v", err)
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	err := syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	require.NoError(t, err)
+
+	select {
+	case err := <-errC:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 30):
+		t.Fatal("Run did not return after SIGTERM")
+	}
+}
+
+func TestCoinRunGracefulShutdown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't support SIGTERM")
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "shutdown")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, visor.UnconfirmedTxnsFilename)
+
+	// Don't let a SIGTERM that arrives while no node is running terminate the test
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGTERM)
+	defer signal.Stop(sigchan)
+
+	// The unconfirmed pool is saved on shutdown.
+	// The database is opened after the signal handler is installed.
+	c := newShutdownTestCoin(t, dir)
+	runUntilSIGTERM(t, c, func() bool {
+		_, err := os.Stat(c.config.Node.DBPath)
+		return err == nil
+	})
+
+	f, err := visor.LoadUnconfirmedTxnsFile(filename)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	require.Empty(t, f.Transactions)
+
+	// Add a transaction spending an output that doesn't exist, it is dropped on reload
+	e, err := visor.NewUnconfirmedTxnsFileEntry(makeShutdownTestTxn(t), time.Now().UnixNano())
+	require.NoError(t, err)
+	f.Transactions = append(f.Transactions, e)
+	err = file.SaveJSON(filename, f, 0600)
+	require.NoError(t, err)
+
+	// The saved pool is reloaded and removed on startup, then saved again on shutdown
+	runUntilSIGTERM(t, newShutdownTestCoin(t, dir), func() bool {
+		_, err := os.Stat(filename)
+		return os.IsNotExist(err)
+	})
+
+	f, err = visor.LoadUnconfirmedTxnsFile(filename)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	require.Empty(t, f.Transactions)
+}
@@ -6,6 +6,7 @@ package skycoin
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -17,22 +18,26 @@ import (
 	"time"
 
 	"github.com/blang/semver"
+	"github.com/sirupsen/logrus"
 	"github.com/toqueteos/webbrowser"
 
-	"github.com/skycoin/skycoin/src/api"
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/daemon"
-	"github.com/skycoin/skycoin/src/kvstorage"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/readable"
-	"github.com/skycoin/skycoin/src/util/apputil"
 	"github.com/skycoin/skycoin/src/util/certutil"
 	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/util/logging"
-	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/api"
+	"github.com/ness-network/privateness/src/daemon"
+	"github.com/ness-network/privateness/src/kvstorage"
+	"github.com/ness-network/privateness/src/readable"
+	"github.com/ness-network/privateness/src/util/apputil"
+	"github.com/ness-network/privateness/src/util/datadir"
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/visor"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 var (
@@ -58,6 +63,7 @@ func (c *Coin) Run() error {
 	var s *kvstorage.Manager
 	var gw *api.Gateway
 	var webInterface *api.Server
+	var dataDirLock *datadir.Lock
 	var retErr error
 	errC := make(chan error, 10)
 
@@ -73,7 +79,13 @@ func (c *Coin) Run() error {
 		return err
 	}
 
-	logging.SetLevel(logLevel)
+	logLevels := plogging.SubsystemLevels{
+		Default:    logLevel,
+		Subsystems: c.config.Node.logLevelSubsystems,
+	}
+
+	// The logger's level filters the entries before they are written, so it is the most verbose subsystem level
+	logging.SetLevel(logLevels.Max())
 
 	if c.config.Node.ColorLog {
 		logging.EnableColors()
@@ -81,10 +93,16 @@ func (c *Coin) Run() error {
 		logging.DisableColors()
 	}
 
+	if hook := c.newLogHook(os.Stdout, logLevels, c.config.Node.ColorLog); hook != nil {
+		// The hook replaces the logger's output
+		logging.SetOutputTo(ioutil.Discard)
+		logging.AddHook(hook)
+	}
+
 	var logFile *os.File
 	if c.config.Node.LogToFile {
 		var err error
-		logFile, err = c.initLogFile()
+		logFile, err = c.initLogFile(logLevels)
 		if err != nil {
 			c.logger.Error(err)
 			return err
@@ -124,8 +142,8 @@ func (c *Coin) Run() error {
 
 	quit := make(chan struct{})
 
-	// Catch SIGINT (CTRL-C) (closes the quit channel)
-	go apputil.CatchInterrupt(quit)
+	// Catch SIGINT (CTRL-C) and SIGTERM (closes the quit channel)
+	apputil.CatchInterruptAsync(quit)
 
 	// Catch SIGUSR1 (prints runtime stack to stdout)
 	go apputil.CatchDebug()
@@ -146,6 +164,17 @@ func (c *Coin) Run() error {
 	vconf := c.ConfigureVisor()
 	sconf := c.ConfigureStorage()
 
+	if c.config.Node.BootstrapFromSnapshot != "" {
+		c.logger.Infof("Bootstrapping database %s from snapshot %s", c.config.Node.DBPath, c.config.Node.BootstrapFromSnapshot)
+		m, err := visor.BootstrapFromSnapshot(c.config.Node.BootstrapFromSnapshot, c.config.Node.DBPath, c.config.Node.genesisHash)
+		if err != nil {
+			err = fmt.Errorf("Bootstrap from snapshot failed: %v", err)
+			c.logger.Error(err)
+			return err
+		}
+		c.logger.Infof("Bootstrapped database from snapshot at head seq %d, head hash %s", m.HeadSeq, m.HeadHash)
+	}
+
 	// Open the database
 	c.logger.Infof("Opening database %s", c.config.Node.DBPath)
 	db, err = visor.OpenDB(c.config.Node.DBPath, c.config.Node.DBReadOnly)
@@ -180,7 +209,8 @@ func (c *Coin) Run() error {
 	}
 
 	// Verify the DB if the version detection says to, or if it was requested on the command line
-	if shouldVerifyDB(appVersion, dbVersion) || c.config.Node.VerifyDB {
+	// A database bootstrapped from a snapshot is always verified
+	if shouldVerifyDB(appVersion, dbVersion) || c.config.Node.VerifyDB || c.config.Node.BootstrapFromSnapshot != "" {
 		if c.config.Node.ResetCorruptDB {
 			// Check the database integrity and recreate it if necessary
 			c.logger.Info("Checking database and resetting if corrupted")
@@ -205,6 +235,31 @@ func (c *Coin) Run() error {
 		}
 	}
 
+	if c.config.Node.RepairUnspents {
+		if db.IsReadOnly() {
+			err = errors.New("the unspent pool cannot be repaired in a read-only database")
+			c.logger.WithError(err).Error()
+			retErr = err
+			goto earlyShutdown
+		}
+
+		c.logger.Info("Repairing the unspent pool")
+		if _, err := visor.RepairUnspents(db, quit); err != nil {
+			if err != visor.ErrRepairStopped {
+				c.logger.WithError(err).Error("visor.RepairUnspents failed")
+				retErr = err
+			}
+			goto earlyShutdown
+		}
+	} else if r, err := visor.CheckUnspentHash(db); err != nil {
+		c.logger.WithError(err).Error("visor.CheckUnspentHash failed")
+	} else if r != nil {
+		// A diverged unspent pool is reported but does not stop the node
+		if err := r.Err(); err != nil {
+			c.logger.Critical().WithError(err).Error("Unspent pool diverged from the blockchain")
+		}
+	}
+
 	// Update the DB version
 	if !db.IsReadOnly() {
 		if err := visor.SetDBVersion(db, *appVersion); err != nil {
@@ -214,6 +269,14 @@ func (c *Coin) Run() error {
 		}
 	}
 
+	// Identify the node and lock the data directory
+	dataDirLock, err = c.lockDataDirectory()
+	if err != nil {
+		c.logger.WithError(err).Error("c.lockDataDirectory failed")
+		retErr = err
+		goto earlyShutdown
+	}
+
 	c.logger.Infof("Coinhour burn factor for user transactions is %d", params.UserVerifyTxn.BurnFactor)
 	c.logger.Infof("Max transaction size for user transactions is %d", params.UserVerifyTxn.MaxTransactionSize)
 	c.logger.Infof("Max decimals for user transactions is %d", params.UserVerifyTxn.MaxDropletPrecision)
@@ -272,6 +335,19 @@ func (c *Coin) Run() error {
 		goto earlyShutdown
 	}
 
+	if fingerprint, err := dataDirFingerprint(db, v); err != nil {
+		c.logger.WithError(err).Error("dataDirFingerprint failed")
+	} else {
+		c.logger.Infof("Data directory fingerprint: %s", fingerprint)
+	}
+
+	if !c.config.Node.DBReadOnly {
+		c.logger.Info("Reloading saved unconfirmed transactions")
+		if _, err := loadUnconfirmedTxns(c.logger, v, c.unconfirmedTxnsFile()); err != nil {
+			c.logger.WithError(err).Error("loadUnconfirmedTxns failed")
+		}
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -283,6 +359,12 @@ func (c *Coin) Run() error {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v.RunDBCompactions(quit)
+	}()
+
 	if c.config.Node.WebInterface {
 		cancelLaunchBrowser := make(chan struct{})
 
@@ -323,17 +405,13 @@ func (c *Coin) Run() error {
 
 	c.logger.Info("Shutting down...")
 
-	if webInterface != nil {
-		c.logger.Info("Closing web interface")
-		webInterface.Shutdown()
+	if err := runShutdown(c.logger, c.shutdownSteps(webInterface, d, v, &wg), c.config.Node.ShutdownTimeout); err != nil {
+		c.logger.WithError(err).Errorf("Shutdown did not finish within %s, exiting without closing the database", c.config.Node.ShutdownTimeout)
+		retErr = err
+		// A shutdown step may still be using the database, and closing it would block
+		db = nil
 	}
 
-	c.logger.Info("Closing daemon")
-	d.Shutdown()
-
-	c.logger.Info("Waiting for goroutines to finish")
-	wg.Wait()
-
 earlyShutdown:
 	if db != nil {
 		c.logger.Info("Closing database")
@@ -342,6 +420,12 @@ earlyShutdown:
 		}
 	}
 
+	if dataDirLock != nil {
+		if err := dataDirLock.Release(); err != nil {
+			c.logger.WithError(err).Error("Failed to release the data directory lock")
+		}
+	}
+
 	c.logger.Info("Goodbye")
 
 	if logFile != nil {
@@ -353,6 +437,57 @@ earlyShutdown:
 	return retErr
 }
 
+// shutdownSteps returns the steps of the shutdown sequence, run after the quit signal.
+// The web interface and daemon are stopped first, so that the daemon disconnects from its peers
+// and no more transactions or blocks are received, then in-flight block writes are waited for
+// before the unconfirmed transaction pool is saved.
+func (c *Coin) shutdownSteps(webInterface *api.Server, d *daemon.Daemon, v *visor.Visor, wg *sync.WaitGroup) []shutdownStep {
+	var steps []shutdownStep
+
+	if webInterface != nil {
+		steps = append(steps, shutdownStep{
+			name: "closing web interface",
+			run: func() error {
+				webInterface.Shutdown()
+				return nil
+			},
+		})
+	}
+
+	steps = append(steps, []shutdownStep{
+		{
+			name: "closing daemon",
+			run: func() error {
+				d.Shutdown()
+				return nil
+			},
+		},
+		{
+			name: "waiting for goroutines to finish",
+			run: func() error {
+				wg.Wait()
+				return nil
+			},
+		},
+	}...)
+
+	if !c.config.Node.DBReadOnly {
+		steps = append(steps, shutdownStep{
+			name: "saving unconfirmed transactions",
+			run: func() error {
+				return saveUnconfirmedTxns(v, c.unconfirmedTxnsFile())
+			},
+		})
+	}
+
+	return steps
+}
+
+// unconfirmedTxnsFile returns the path of the file the unconfirmed transaction pool is saved to on shutdown
+func (c *Coin) unconfirmedTxnsFile() string {
+	return filepath.Join(c.config.Node.DataDirectory, visor.UnconfirmedTxnsFilename)
+}
+
 // NewCoin returns a new fiber coin instance
 func NewCoin(config Config, logger *logging.Logger) *Coin {
 	return &Coin{
@@ -361,7 +496,7 @@ func NewCoin(config Config, logger *logging.Logger) *Coin {
 	}
 }
 
-func (c *Coin) initLogFile() (*os.File, error) {
+func (c *Coin) initLogFile(logLevels plogging.SubsystemLevels) (*os.File, error) {
 	logDir := filepath.Join(c.config.Node.DataDirectory, "logs")
 	if err := createDirIfNotExist(logDir); err != nil {
 		c.logger.WithError(err).Errorf("createDirIfNotExist(%s) failed", logDir)
@@ -378,12 +513,33 @@ func (c *Coin) initLogFile() (*os.File, error) {
 		return nil, err
 	}
 
-	hook := logging.NewWriteHook(f)
+	var hook logrus.Hook = logging.NewWriteHook(f)
+	if h := c.newLogHook(f, logLevels, false); h != nil {
+		hook = h
+	}
 	logging.AddHook(hook)
 
 	return f, nil
 }
 
+// newLogHook returns a hook writing the log entries to w in the configured log format,
+// filtered by the subsystem log levels.
+// Returns nil if the logger's text output is used, i.e. the format is text and there are no subsystem log levels.
+func (c *Coin) newLogHook(w io.Writer, logLevels plogging.SubsystemLevels, colors bool) logrus.Hook {
+	var formatter logrus.Formatter
+	switch c.config.Node.LogFormat {
+	case plogging.FormatJSON:
+		formatter = &plogging.JSONFormatter{}
+	default:
+		if len(logLevels.Subsystems) == 0 {
+			return nil
+		}
+		formatter = plogging.NewTextFormatter(w, colors)
+	}
+
+	return plogging.NewLevelWriteHook(w, formatter, logLevels)
+}
+
 // ConfigureVisor sets the visor config values
 func (c *Coin) ConfigureVisor() visor.Config {
 	vc := visor.NewConfig()
@@ -399,11 +555,17 @@ func (c *Coin) ConfigureVisor() visor.Config {
 	vc.UnconfirmedVerifyTxn = c.config.Node.UnconfirmedVerifyTxn
 	vc.CreateBlockVerifyTxn = c.config.Node.CreateBlockVerifyTxn
 	vc.MaxBlockTransactionsSize = c.config.Node.MaxBlockTransactionsSize
+	vc.BurnFactorSchedule = c.config.Node.BurnFactorSchedule
 
 	vc.GenesisAddress = c.config.Node.genesisAddress
 	vc.GenesisSignature = c.config.Node.genesisSignature
 	vc.GenesisTimestamp = c.config.Node.GenesisTimestamp
 	vc.GenesisCoinVolume = c.config.Node.GenesisCoinVolume
+	vc.GenesisOutputs = c.config.Node.genesisOutputs
+
+	vc.DBCompactionInterval = c.config.Node.DBCompactionInterval
+	vc.Paranoid = c.config.Node.Paranoid
+	vc.TraceSlowBlocks = time.Duration(c.config.Node.TraceSlowBlocksMs) * time.Millisecond
 
 	return vc
 }
@@ -423,6 +585,8 @@ func (c *Coin) ConfigureWallet() wallet.Config {
 	}
 
 	wc.CryptoType = cryptoType
+	wc.RequireUniqueLabels = c.config.Node.RequireUniqueWalletLabels
+	wc.ExplicitFormatMigrations = c.config.Node.ExplicitWalletMigrations
 
 	bc := c.config.Node.Fiber.Bip44Coin
 	wc.Bip44Coin = &bc
@@ -449,6 +613,8 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Pool.MaxDefaultPeerOutgoingConnections = c.config.Node.MaxDefaultPeerOutgoingConnections
 	dc.Pool.MaxIncomingMessageLength = c.config.Node.MaxIncomingMessageLength
 	dc.Pool.MaxOutgoingMessageLength = c.config.Node.MaxOutgoingMessageLength
+	dc.Pool.MaxUploadRate = c.config.Node.MaxUploadKbps * bytesPerKbps
+	dc.Pool.MaxDownloadRate = c.config.Node.MaxDownloadKbps * bytesPerKbps
 
 	dc.Pex.DataDirectory = c.config.Node.DataDirectory
 	dc.Pex.Disabled = c.config.Node.DisablePEX
@@ -478,6 +644,14 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Daemon.GenesisHash = c.config.Node.genesisHash
 	dc.Daemon.UserAgent = c.config.Node.userAgent
 	dc.Daemon.UnconfirmedVerifyTxn = c.config.Node.UnconfirmedVerifyTxn
+	dc.Daemon.TxnReannounceInterval = c.config.Node.TxnReannounceInterval
+	dc.Daemon.KeepaliveInterval = c.config.Node.KeepaliveInterval
+	dc.Daemon.KeepaliveTimeout = c.config.Node.KeepaliveTimeout
+	dc.Daemon.KeepaliveMaxMissedPongs = c.config.Node.KeepaliveMaxMissedPongs
+	dc.Daemon.MessageHandlerTimeout = c.config.Node.MessageHandlerTimeout
+	dc.Daemon.MessageHandlerTimeouts = c.config.Node.messageHandlerTimeouts
+	dc.Daemon.MaxSlowMessageHandlers = c.config.Node.MaxSlowMessageHandlers
+	dc.Daemon.PropagationTrackerSize = c.config.Node.PropagationTrackerSize
 
 	if c.config.Node.OutgoingConnectionsRate == 0 {
 		c.config.Node.OutgoingConnectionsRate = time.Millisecond
@@ -491,6 +665,8 @@ func (c *Coin) createGUI(gw *api.Gateway, host string) (*api.Server, error) {
 	config := api.Config{
 		StaticDir:          c.config.Node.GUIDirectory,
 		DisableCSRF:        c.config.Node.DisableCSRF,
+		CSRFTokenLifetime:  c.config.Node.CSRFTokenLifetime,
+		CSRFStateless:      c.config.Node.CSRFStateless,
 		DisableHeaderCheck: c.config.Node.DisableHeaderCheck,
 		DisableCSP:         c.config.Node.DisableCSP,
 		EnableGUI:          c.config.Node.EnableGUI,
@@ -511,6 +687,7 @@ func (c *Coin) createGUI(gw *api.Gateway, host string) (*api.Server, error) {
 		},
 		Username: c.config.Node.WebInterfaceUsername,
 		Password: c.config.Node.WebInterfacePassword,
+		APIKey:   c.config.Node.APIKey,
 	}
 
 	var s *api.Server
@@ -5,6 +5,7 @@ package testutil
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -17,8 +18,13 @@ import (
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	pcipher "github.com/ness-network/privateness/src/cipher"
 )
 
+// keyPairDomain is the domain of the seeded key pairs of KeyPair
+const keyPairDomain = "testutil.KeyPair"
+
 // PrepareDB creates and opens a temporary test DB and returns it with a cleanup callback
 func PrepareDB(t *testing.T) (*dbutil.DB, func()) {
 	f, err := ioutil.TempFile("", "testdb")
@@ -59,6 +65,16 @@ func MakeAddress() cipher.Address {
 	return cipher.AddressFromPubKey(p)
 }
 
+// KeyPair returns the i-th stable test key pair. The same i always returns the same key pair,
+// so that golden tests can pin the serialized bytes of fixtures. Only tests may use it.
+func KeyPair(i int) (cipher.PubKey, cipher.SecKey) {
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(i))
+
+	p, s := pcipher.MustGenerateDeterministicKeyPairSeeded(keyPairDomain, seed[:])
+	return cipher.MustNewPubKey(p[:]), cipher.MustNewSecKey(s[:])
+}
+
 // MakePubKey creates a cipher.PubKey
 func MakePubKey() cipher.PubKey {
 	p, _ := cipher.GenerateKeyPair()
@@ -0,0 +1,178 @@
+package transaction
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	"github.com/ness-network/privateness/src/util/fee"
+)
+
+var (
+	// ErrBumpSignedTransaction is returned if Bump is called with a transaction that has signatures
+	ErrBumpSignedTransaction = NewError(errors.New("Only fully unsigned transactions can be bumped"))
+	// ErrFeeSatisfied is returned if Bump is called with a transaction that already pays the target fee
+	ErrFeeSatisfied = NewError(errors.New("Transaction already pays the target fee"))
+	// ErrNullBumpChangeAddress BumpParams.ChangeAddress must not be the null address
+	ErrNullBumpChangeAddress = NewError(errors.New("ChangeAddress must not be the null address"))
+)
+
+// BumpParams defines control parameters for bumping the fee of a transaction
+type BumpParams struct {
+	// Fee is the target fee in coin hours. The bumped transaction's fee is at least Fee,
+	// and at least the fee required by BurnPolicy.
+	Fee uint64
+	// ChangeAddress receives the coins of the added input, and the hours not needed for the fee.
+	// If the transaction has an output to ChangeAddress, the last such output is adjusted,
+	// otherwise a change output is added.
+	ChangeAddress cipher.Address
+	// BurnPolicy determines the fee required for the transaction's input hours.
+	// If nil, the burn factor of params.UserVerifyTxn applies.
+	BurnPolicy fee.BurnPolicy
+}
+
+// burnPolicy returns the BurnPolicy that applies to the BumpParams
+func (p BumpParams) burnPolicy() fee.BurnPolicy {
+	if p.BurnPolicy == nil {
+		return fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor)
+	}
+	return p.BurnPolicy
+}
+
+// Bump increases the fee of an unsigned transaction created by Create, by adding one input from unspents.
+// inputs are the UxBalances of the transaction's inputs, in order, and unspents are the outputs
+// that may be added, e.g. the remaining unspent outputs of the wallet.
+// The added input is the one with the fewest hours that satisfies the target fee; its coins and the hours
+// not needed for the fee are added to the change output. The inputs are then sorted as Create sorts its spends,
+// coins highest, hours lowest, with the hash as a tiebreaker.
+// Returns the new unsigned transaction and the UxBalances of its inputs.
+func Bump(txn coin.Transaction, inputs, unspents []UxBalance, p BumpParams, headTime uint64) (*coin.Transaction, []UxBalance, error) {
+	if p.ChangeAddress.Null() {
+		return nil, nil, ErrNullBumpChangeAddress
+	}
+
+	if !txn.IsFullyUnsigned() {
+		return nil, nil, ErrBumpSignedTransaction
+	}
+
+	if len(inputs) != len(txn.In) {
+		return nil, nil, errors.New("Number of UxBalance inputs does not match number of transaction inputs")
+	}
+
+	var inputHours uint64
+	for i, in := range inputs {
+		if in.Hash != txn.In[i] {
+			return nil, nil, errors.New("Transaction input hash does not match UxBalance inputs hash")
+		}
+
+		var err error
+		inputHours, err = mathutil.AddUint64(inputHours, in.Hours)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	outputHours, err := txn.OutputHours()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if inputHours < outputHours {
+		return nil, nil, fee.ErrTxnInsufficientCoinHours
+	}
+
+	burnPolicy := p.burnPolicy()
+	targetFee := func(hours uint64) uint64 {
+		f := burnPolicy.RequiredFee(hours, headTime)
+		if p.Fee > f {
+			return p.Fee
+		}
+		return f
+	}
+
+	if inputHours-outputHours >= targetFee(inputHours) {
+		return nil, nil, ErrFeeSatisfied
+	}
+
+	// Choose the unspent output with the fewest hours that satisfies the target fee
+	candidates := uxBalancesSub(unspents, inputs)
+	sortSpendsHoursLowToHigh(candidates)
+
+	var extra *UxBalance
+	var extraHours uint64
+	for i, c := range candidates {
+		newInputHours, err := mathutil.AddUint64(inputHours, c.Hours)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		f := targetFee(newInputHours)
+		if newInputHours-outputHours >= f {
+			extra = &candidates[i]
+			extraHours = newInputHours - outputHours - f
+			break
+		}
+	}
+
+	if extra == nil {
+		return nil, nil, ErrInsufficientHours
+	}
+
+	bumped := coin.Transaction{
+		Out: append([]coin.TransactionOutput{}, txn.Out...),
+	}
+
+	changeIdx := -1
+	for i, o := range bumped.Out {
+		if o.Address == p.ChangeAddress {
+			changeIdx = i
+		}
+	}
+
+	if changeIdx == -1 {
+		if err := bumped.PushOutput(p.ChangeAddress, extra.Coins, extraHours); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		change := &bumped.Out[changeIdx]
+		change.Coins, err = mathutil.AddUint64(change.Coins, extra.Coins)
+		if err != nil {
+			return nil, nil, err
+		}
+		change.Hours, err = mathutil.AddUint64(change.Hours, extraHours)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	newInputs := append(append([]UxBalance{}, inputs...), *extra)
+	sortSpendsCoinsHighToLow(newInputs)
+
+	for _, in := range newInputs {
+		if err := bumped.PushInput(in.Hash); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	bumped.Sigs = make([]cipher.Sig, len(bumped.In))
+
+	if err := bumped.UpdateHeader(); err != nil {
+		logger.Critical().WithError(err).Error("txn.UpdateHeader failed")
+		return nil, nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"txid":       txn.Hash().Hex(),
+		"bumpedTxid": bumped.Hash().Hex(),
+		"extraInput": extra.Hash.Hex(),
+		"extraHours": extraHours,
+		"targetFee":  p.Fee,
+	}).Info("Bumped transaction fee")
+
+	return &bumped, newInputs, nil
+}
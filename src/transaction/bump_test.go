@@ -0,0 +1,209 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+
+	"github.com/ness-network/privateness/src/util/fee"
+)
+
+func TestBump(t *testing.T) {
+	headTime := uint64(time.Now().UTC().Unix())
+	_, secKeys := cipher.MustGenerateDeterministicKeyPairsSeed([]byte("seed"), 1)
+
+	makeUxBalance := func(coins, hours uint64) UxBalance {
+		ux := makeUxOut(t, secKeys[0], coins, hours)
+		ux.Head.Time = headTime
+		b, err := NewUxBalance(headTime, ux)
+		require.NoError(t, err)
+		return b
+	}
+
+	input := makeUxBalance(2e6, 100)
+	fewHours := makeUxBalance(1e6, 10)
+	someHours := makeUxBalance(1e6, 200)
+	manyHours := makeUxBalance(3e6, 1000)
+	unspents := []UxBalance{input, manyHours, fewHours, someHours}
+
+	toAddr := testutil.MakeAddress()
+	changeAddr := testutil.MakeAddress()
+
+	makeTxn := func(toHours uint64, withChange bool) coin.Transaction {
+		var txn coin.Transaction
+		require.NoError(t, txn.PushInput(input.Hash))
+		if withChange {
+			require.NoError(t, txn.PushOutput(toAddr, 1e6, toHours))
+			require.NoError(t, txn.PushOutput(changeAddr, 1e6, 0))
+		} else {
+			require.NoError(t, txn.PushOutput(toAddr, 2e6, toHours))
+		}
+		txn.Sigs = make([]cipher.Sig, len(txn.In))
+		require.NoError(t, txn.UpdateHeader())
+		return txn
+	}
+
+	signedTxn := makeTxn(60, true)
+	signedTxn.Sigs[0] = cipher.MustSigFromHex("1932a0aa4c5c0bb0c4b6d4d5ebd4d4dd29cfe8cda6d4f1fbd5ba66e5e64fe6bd7ac0ef7ce7b7c8d3e4f3d0a7c41acf4d8d1c1b3a3a4f4ff31cd1fe2ac4ec1c6c00")
+
+	policy := fee.NewConstantBurnPolicy(2)
+
+	cases := []struct {
+		name        string
+		txn         coin.Transaction
+		inputs      []UxBalance
+		unspents    []UxBalance
+		p           BumpParams
+		err         error
+		extra       UxBalance
+		changeIdx   int
+		changeCoins uint64
+		changeHours uint64
+	}{
+		{
+			name:     "null change address",
+			txn:      makeTxn(60, true),
+			inputs:   []UxBalance{input},
+			unspents: unspents,
+			p: BumpParams{
+				BurnPolicy: policy,
+			},
+			err: ErrNullBumpChangeAddress,
+		},
+		{
+			name:     "signed transaction",
+			txn:      signedTxn,
+			inputs:   []UxBalance{input},
+			unspents: unspents,
+			p: BumpParams{
+				ChangeAddress: changeAddr,
+				BurnPolicy:    policy,
+			},
+			err: ErrBumpSignedTransaction,
+		},
+		{
+			name:     "fee already satisfied",
+			txn:      makeTxn(50, true),
+			inputs:   []UxBalance{input},
+			unspents: unspents,
+			p: BumpParams{
+				ChangeAddress: changeAddr,
+				BurnPolicy:    policy,
+			},
+			err: ErrFeeSatisfied,
+		},
+		{
+			name:     "insufficient hours",
+			txn:      makeTxn(60, true),
+			inputs:   []UxBalance{input},
+			unspents: []UxBalance{input, fewHours},
+			p: BumpParams{
+				ChangeAddress: changeAddr,
+				BurnPolicy:    policy,
+			},
+			err: ErrInsufficientHours,
+		},
+		{
+			name:     "required fee, adjusts change output",
+			txn:      makeTxn(60, true),
+			inputs:   []UxBalance{input},
+			unspents: unspents,
+			p: BumpParams{
+				ChangeAddress: changeAddr,
+				BurnPolicy:    policy,
+			},
+			extra:       someHours,
+			changeIdx:   1,
+			changeCoins: 2e6,
+			// 300 input hours, 150 fee, 60 to toAddr
+			changeHours: 90,
+		},
+		{
+			name:     "target fee, adds change output",
+			txn:      makeTxn(60, false),
+			inputs:   []UxBalance{input},
+			unspents: unspents,
+			p: BumpParams{
+				Fee:           200,
+				ChangeAddress: changeAddr,
+				BurnPolicy:    policy,
+			},
+			extra:       someHours,
+			changeIdx:   1,
+			changeCoins: 1e6,
+			// 300 input hours, 200 fee, 60 to toAddr
+			changeHours: 40,
+		},
+		{
+			name:     "target fee above the hours of an input",
+			txn:      makeTxn(60, true),
+			inputs:   []UxBalance{input},
+			unspents: unspents,
+			p: BumpParams{
+				Fee:           500,
+				ChangeAddress: changeAddr,
+				BurnPolicy:    policy,
+			},
+			extra:       manyHours,
+			changeIdx:   1,
+			changeCoins: 4e6,
+			// 1100 input hours, 550 fee, 60 to toAddr
+			changeHours: 490,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bumped, inputs, err := Bump(tc.txn, tc.inputs, tc.unspents, tc.p, headTime)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.True(t, bumped.IsFullyUnsigned())
+			require.Len(t, bumped.In, len(tc.txn.In)+1)
+			require.Len(t, inputs, len(bumped.In))
+			require.NoError(t, bumped.VerifyUnsigned())
+
+			// Inputs are sorted coins highest first
+			for i, in := range inputs {
+				require.Equal(t, in.Hash, bumped.In[i])
+				if i > 0 {
+					require.True(t, inputs[i-1].Coins >= in.Coins)
+				}
+			}
+			require.Contains(t, bumped.In, tc.extra.Hash)
+
+			// The other outputs are unchanged
+			require.Equal(t, tc.txn.Out[0], bumped.Out[0])
+
+			change := bumped.Out[tc.changeIdx]
+			require.Equal(t, changeAddr, change.Address)
+			require.Equal(t, tc.changeCoins, change.Coins)
+			require.Equal(t, tc.changeHours, change.Hours)
+
+			var inputHours uint64
+			for _, in := range inputs {
+				inputHours += in.Hours
+			}
+			outputHours, err := bumped.OutputHours()
+			require.NoError(t, err)
+			txnFee := inputHours - outputHours
+			require.True(t, txnFee >= tc.p.Fee)
+			require.True(t, txnFee >= policy.RequiredFee(inputHours, headTime))
+		})
+	}
+
+	// Mismatched inputs
+	_, _, err := Bump(makeTxn(60, true), []UxBalance{fewHours}, unspents, BumpParams{
+		ChangeAddress: changeAddr,
+		BurnPolicy:    policy,
+	}, headTime)
+	require.EqualError(t, err, "Transaction input hash does not match UxBalance inputs hash")
+}
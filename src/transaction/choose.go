@@ -8,7 +8,8 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/util/fee"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 var (
@@ -183,6 +184,13 @@ func cmpUxBalanceByUxID(a, b UxBalance) bool {
 // It then chooses uxouts with zero coinhours, ordered by sortStrategy
 // It then chooses remaining uxouts with nonzero coinhours, ordered by sortStrategy
 func ChooseSpends(uxa []UxBalance, coins, hours uint64, sortStrategy func([]UxBalance)) ([]UxBalance, error) {
+	return chooseSpends(uxa, coins, hours, sortStrategy, func(hours uint64) uint64 {
+		return fee.RequiredFee(hours, params.UserVerifyTxn.BurnFactor)
+	})
+}
+
+// chooseSpends is ChooseSpends, with the fee required for an amount of input hours given by requiredFee
+func chooseSpends(uxa []UxBalance, coins, hours uint64, sortStrategy func([]UxBalance), requiredFee func(uint64) uint64) ([]UxBalance, error) {
 	if coins == 0 {
 		return nil, ErrZeroSpend
 	}
@@ -234,7 +242,7 @@ func ChooseSpends(uxa []UxBalance, coins, hours uint64, sortStrategy func([]UxBa
 	haveCoins += firstNonzero.Coins
 	haveHours += firstNonzero.Hours
 
-	if haveCoins >= coins && fee.RemainingHours(haveHours, params.UserVerifyTxn.BurnFactor) >= hours {
+	if haveCoins >= coins && haveHours-requiredFee(haveHours) >= hours {
 		return spending, nil
 	}
 
@@ -252,7 +260,7 @@ func ChooseSpends(uxa []UxBalance, coins, hours uint64, sortStrategy func([]UxBa
 		}
 	}
 
-	if haveCoins >= coins && fee.RemainingHours(haveHours, params.UserVerifyTxn.BurnFactor) >= hours {
+	if haveCoins >= coins && haveHours-requiredFee(haveHours) >= hours {
 		return spending, nil
 	}
 
@@ -265,7 +273,7 @@ func ChooseSpends(uxa []UxBalance, coins, hours uint64, sortStrategy func([]UxBa
 		haveCoins += ux.Coins
 		haveHours += ux.Hours
 
-		if haveCoins >= coins && fee.RemainingHours(haveHours, params.UserVerifyTxn.BurnFactor) >= hours {
+		if haveCoins >= coins && haveHours-requiredFee(haveHours) >= hours {
 			return spending, nil
 		}
 	}
@@ -11,7 +11,8 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/util/fee"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 func TestSortSpendsCoinsLowToHigh(t *testing.T) {
@@ -16,10 +16,10 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 var (
@@ -91,7 +91,12 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 	// Use the MinimizeUxOuts strategy, to use least possible uxouts
 	// this will allow more frequent spending
 	// we don't need to check whether we have sufficient balance beforehand as ChooseSpends already checks that
-	spends, err := ChooseSpendsMinimizeUxOuts(uxb, totalOutCoins, requestedHours)
+	burnPolicy := p.burnPolicy()
+	requiredFee := func(hours uint64) uint64 {
+		return burnPolicy.RequiredFee(hours, headTime)
+	}
+
+	spends, err := chooseSpends(uxb, totalOutCoins, requestedHours, sortSpendsCoinsHighToLow, requiredFee)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -116,7 +121,7 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 		}
 	}
 
-	feeHours := fee.RequiredFee(totalInputHours, params.UserVerifyTxn.BurnFactor)
+	feeHours := requiredFee(totalInputHours)
 	if feeHours == 0 {
 		// feeHours can only be 0 if totalInputHours is 0, and if totalInputHours was 0
 		// then ChooseSpendsMinimizeUxOuts should have already returned an error
@@ -240,7 +245,7 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 			}
 
 			// Calculate the new fee for this new amount of hours
-			newFee := fee.RequiredFee(newTotalHours, params.UserVerifyTxn.BurnFactor)
+			newFee := requiredFee(newTotalHours)
 			if newFee < feeHours {
 				err := errors.New("updated fee after adding extra input for change is unexpectedly less than it was initially")
 				logger.WithError(err).Error()
@@ -377,7 +382,7 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 		inputs[i] = uxBalance
 	}
 
-	if err := verifyCreatedUnignedInvariants(p, txn, inputs); err != nil {
+	if err := verifyCreatedUnignedInvariants(p, txn, inputs, headTime); err != nil {
 		logger.Critical().WithError(err).Error("CreateTransaction created transaction that violates invariants, aborting")
 		return nil, nil, fmt.Errorf("Created transaction that violates invariants, this is a bug: %v", err)
 	}
@@ -385,12 +390,12 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 	return txn, inputs, nil
 }
 
-func verifyCreatedUnignedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance) error {
+func verifyCreatedUnignedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance, headTime uint64) error {
 	if !txn.IsFullyUnsigned() {
 		return errors.New("Transaction is not fully unsigned")
 	}
 
-	if err := VerifyCreatedInvariants(p, txn, inputs); err != nil {
+	if err := VerifyCreatedInvariants(p, txn, inputs, headTime); err != nil {
 		return err
 	}
 
@@ -402,7 +407,8 @@ func verifyCreatedUnignedInvariants(p Params, txn *coin.Transaction, inputs []Ux
 // daemon.Gateway checks that the transaction passes additional visor verification methods.
 // TODO -- could fix the import cycle by having visor create the transaction, passing it to the wallet for verifying params and signing
 // This method still compares some values of Params against the created txn and doesn't only verify that the txn is well formed
-func VerifyCreatedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance) error {
+// The fee is checked against the Params' BurnPolicy at headTime.
+func VerifyCreatedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance, headTime uint64) error {
 	for _, o := range txn.Out {
 		// No outputs should be sent to the null address
 		if o.Address.Null() {
@@ -496,7 +502,7 @@ func VerifyCreatedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance
 		return errors.New("Total input hours is less than the output hours")
 	}
 
-	if inputHours-outputHours < fee.RequiredFee(inputHours, params.UserVerifyTxn.BurnFactor) {
+	if inputHours-outputHours < p.burnPolicy().RequiredFee(inputHours, headTime) {
 		return errors.New("Transaction will not satisfy required fee")
 	}
 
@@ -14,8 +14,11 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/util/fee"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 func TestCreate(t *testing.T) {
@@ -179,7 +182,7 @@ func TestCreate(t *testing.T) {
 					},
 				},
 			},
-			err: NewError(errors.New("total output coins error: uint64 addition overflow")),
+			err: NewError(pcoin.OutputError{Index: 1, Err: pcoin.ErrOutputCoinsOverflow}),
 		},
 
 		{
@@ -763,6 +766,63 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateBurnPolicy(t *testing.T) {
+	headTime := uint64(time.Now().UTC().Unix())
+	_, secKeys := cipher.MustGenerateDeterministicKeyPairsSeed([]byte("seed"), 1)
+	addr := cipher.MustAddressFromSecKey(secKeys[0])
+
+	uxout := makeUxOut(t, secKeys[0], 2e6, 100)
+	uxout.Head.Time = headTime
+	auxs := coin.AddressUxOuts{
+		addr: []coin.UxOut{uxout},
+	}
+
+	changeAddr := testutil.MakeAddress()
+	p := Params{
+		HoursSelection: HoursSelection{
+			Type: HoursSelectionTypeManual,
+		},
+		To: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   10,
+			},
+		},
+		ChangeAddress: &changeAddr,
+	}
+
+	policy, err := fee.NewScheduledBurnPolicy(10, []fee.BurnFactorChange{
+		{Time: headTime + 1, BurnFactor: 2},
+	})
+	require.NoError(t, err)
+	p.BurnPolicy = policy
+
+	cases := []struct {
+		headTime    uint64
+		changeHours uint64
+	}{
+		// Burn factor 10 applies before the scheduled change
+		{headTime, 80},
+		// Burn factor 2 applies after the scheduled change
+		{headTime + 1, 40},
+	}
+
+	for _, tc := range cases {
+		txn, inputs, err := Create(p, auxs, tc.headTime)
+		require.NoError(t, err)
+		require.Len(t, txn.Out, 2)
+		require.Equal(t, tc.changeHours, txn.Out[1].Hours)
+		require.NoError(t, VerifyCreatedInvariants(p, txn, inputs, tc.headTime))
+	}
+
+	// The default policy uses the burn factor of params.UserVerifyTxn at all times
+	p.BurnPolicy = nil
+	txn, _, err := Create(p, auxs, headTime+1)
+	require.NoError(t, err)
+	require.Equal(t, 100-10-fee.RequiredFee(100, params.UserVerifyTxn.BurnFactor), txn.Out[1].Hours)
+}
+
 func makeUxOut(t *testing.T, s cipher.SecKey, coins, hours uint64) coin.UxOut { //nolint:unparam
 	body := makeUxBody(t, s, coins, hours)
 	tm := rand.Int31n(1000)
@@ -5,8 +5,9 @@ import (
 	"math/big"
 
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/mathutil"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 // DistributeSpendHours calculates how many coin hours to transfer to the change address and how
@@ -21,7 +22,12 @@ import (
 // an array of length nAddrs with the hours to give to each destination address,
 // and a sum of these values.
 func DistributeSpendHours(inputHours, nAddrs uint64, haveChange bool) (uint64, []uint64, uint64) {
-	feeHours := fee.RequiredFee(inputHours, params.UserVerifyTxn.BurnFactor)
+	return DistributeSpendHoursWithPolicy(inputHours, nAddrs, haveChange, 0, fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor))
+}
+
+// DistributeSpendHoursWithPolicy is DistributeSpendHours, with the fee required by burnPolicy at headTime
+func DistributeSpendHoursWithPolicy(inputHours, nAddrs uint64, haveChange bool, headTime uint64, burnPolicy fee.BurnPolicy) (uint64, []uint64, uint64) {
+	feeHours := burnPolicy.RequiredFee(inputHours, headTime)
 	remainingHours := inputHours - feeHours
 
 	var changeHours uint64
@@ -7,6 +7,11 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/droplet"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 // Error wraps transaction creation-related errors.
@@ -74,6 +79,31 @@ type Params struct {
 	HoursSelection HoursSelection
 	To             []coin.TransactionOutput
 	ChangeAddress  *cipher.Address
+	// BurnPolicy determines the fee of the created transaction.
+	// If nil, the burn factor of params.UserVerifyTxn applies.
+	BurnPolicy fee.BurnPolicy
+}
+
+// burnPolicy returns the BurnPolicy that applies to the Params
+func (c Params) burnPolicy() fee.BurnPolicy {
+	if c.BurnPolicy == nil {
+		return fee.NewConstantBurnPolicy(params.UserVerifyTxn.BurnFactor)
+	}
+	return c.BurnPolicy
+}
+
+// receiverError converts an error returned by coin.ValidateOutputs for To to the error returned by Params.Validate
+func receiverError(err error) error {
+	switch {
+	case errors.Is(err, pcoin.ErrZeroCoinOutput):
+		return ErrZeroCoinsReceiver
+	case errors.Is(err, pcoin.ErrNullAddressOutput):
+		return ErrNullAddressReceiver
+	case errors.Is(err, pcoin.ErrDuplicateOutput):
+		return ErrDuplicateReceiver
+	default:
+		return NewError(err)
+	}
 }
 
 // Validate validates Params
@@ -86,28 +116,17 @@ func (c Params) Validate() error {
 		return ErrMissingReceivers
 	}
 
-	for _, to := range c.To {
-		if to.Coins == 0 {
-			return ErrZeroCoinsReceiver
-		}
-
-		if to.Address.Null() {
-			return ErrNullAddressReceiver
-		}
-	}
-
-	// Check for duplicate outputs, a transaction can't have outputs with
-	// the same (address, coins, hours)
 	// Auto mode would distribute hours to the outputs and could hypothetically
 	// avoid assigning duplicate hours in many cases, but the complexity for doing
-	// so is very high, so also reject duplicate (address, coins) for auto mode.
-	outputs := make(map[coin.TransactionOutput]struct{}, len(c.To))
-	for _, to := range c.To {
-		outputs[to] = struct{}{}
+	// so is very high, so duplicate (address, coins) are also rejected for auto mode,
+	// where the hours of To are zero.
+	to := make([]pcoin.TransactionOutput, len(c.To))
+	for i, o := range c.To {
+		to[i] = pcoin.TransactionOutput(o)
 	}
-
-	if len(outputs) != len(c.To) {
-		return ErrDuplicateReceiver
+	// The decimal places of the coins are checked by the verification of the created transaction
+	if err := pcoin.ValidateOutputs(to, droplet.Exponent); err != nil {
+		return receiverError(err)
 	}
 
 	switch c.HoursSelection.Type {
@@ -4,19 +4,36 @@ Package apputil provides utility methods for cmd applications
 package apputil
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"runtime/pprof"
 	"syscall"
 )
 
-// CatchInterrupt catches CTRL-C and closes the quit channel if it occurs.
+// CatchInterrupt catches CTRL-C or SIGTERM and closes the quit channel if it occurs.
 // If CTRL-C is called again, the program stack is dumped and the process panics,
 // so that shutdown hangs can be diagnosed.
 func CatchInterrupt(quit chan<- struct{}) {
+	waitInterrupt(notifyInterrupt(), quit)
+}
+
+// CatchInterruptAsync is CatchInterrupt without blocking. The signals are caught
+// from when CatchInterruptAsync returns, unlike when CatchInterrupt is run in a goroutine.
+func CatchInterruptAsync(quit chan<- struct{}) {
+	go waitInterrupt(notifyInterrupt(), quit)
+}
+
+func notifyInterrupt() chan os.Signal {
 	sigchan := make(chan os.Signal, 1)
-	signal.Notify(sigchan, os.Interrupt)
+	signal.Notify(sigchan, os.Interrupt, syscall.SIGTERM)
+	return sigchan
+}
+
+func waitInterrupt(sigchan chan os.Signal, quit chan<- struct{}) {
 	<-sigchan
 	signal.Stop(sigchan)
 	close(quit)
@@ -54,3 +71,25 @@ func PrintProgramStatus() {
 		return
 	}
 }
+
+// ExecutableHash returns the hex-encoded SHA256 hash of the running executable.
+// For a reproducible build, it can be compared to the hash of a binary built from the same source.
+func ExecutableHash() (string, error) {
+	fn, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,263 @@
+/*
+Package datadir manages the identity of a node's data directory.
+
+A node has a persistent identifier, generated once and saved to the data directory,
+and holds a lockfile in the data directory while it is running. The lockfile is
+checked by content rather than with file locks, so that two nodes sharing a data
+directory over a network filesystem, where file locks are unreliable, can be detected.
+*/
+package datadir
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/file"
+)
+
+const (
+	// NodeIDFilename is the name of the file in the data directory that the node ID is saved to
+	NodeIDFilename = "node_id"
+	// NodeKeyFilename is the name of the file in the data directory that the node's secret key is saved to
+	NodeKeyFilename = "node.key"
+	// LockFilename is the name of the lockfile in the data directory
+	LockFilename = "node.lock"
+
+	nodeIDLen = 16
+)
+
+// LoadOrCreateNodeID returns the node ID saved in dir, generating and saving one if there is none
+func LoadOrCreateNodeID(dir string) (string, error) {
+	fn := filepath.Join(dir, NodeIDFilename)
+
+	b, err := ioutil.ReadFile(fn)
+	switch {
+	case err == nil:
+		id := strings.TrimSpace(string(b))
+		if _, err := hex.DecodeString(id); err != nil || len(id) != nodeIDLen*2 {
+			return "", fmt.Errorf("invalid node ID in %s", fn)
+		}
+		return id, nil
+	case os.IsNotExist(err):
+	default:
+		return "", err
+	}
+
+	id := hex.EncodeToString(cipher.RandByte(nodeIDLen))
+	if err := file.SaveBinary(fn, []byte(id+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// LoadOrCreateNodeKey returns the node's secret key saved in dir, generating and saving one if there is none.
+// The node key signs data published by the node, such as peer bundles.
+func LoadOrCreateNodeKey(dir string) (cipher.SecKey, error) {
+	fn := filepath.Join(dir, NodeKeyFilename)
+
+	b, err := ioutil.ReadFile(fn)
+	switch {
+	case err == nil:
+		sk, err := cipher.SecKeyFromHex(strings.TrimSpace(string(b)))
+		if err != nil {
+			return cipher.SecKey{}, fmt.Errorf("invalid node key in %s", fn)
+		}
+		return sk, nil
+	case os.IsNotExist(err):
+	default:
+		return cipher.SecKey{}, err
+	}
+
+	_, sk := cipher.GenerateKeyPair()
+	if err := file.SaveBinary(fn, []byte(sk.Hex()+"\n"), 0600); err != nil {
+		return cipher.SecKey{}, err
+	}
+
+	return sk, nil
+}
+
+// Fingerprint returns the fingerprint of a data directory, the hash of its genesis block hash and DB UID.
+// Two data directories with the same fingerprint are copies of each other, or the same directory.
+func Fingerprint(genesisHash cipher.SHA256, dbUID string) string {
+	return cipher.SumSHA256(append(genesisHash[:], dbUID...)).Hex()
+}
+
+// LockInfo is the content of a lockfile
+type LockInfo struct {
+	NodeID   string `json:"node_id"`
+	Hostname string `json:"hostname"`
+	PID      int    `json:"pid"`
+	// Created is the unix time the lock was acquired
+	Created int64 `json:"created"`
+}
+
+func (l LockInfo) equal(o LockInfo) bool {
+	return l.NodeID == o.NodeID && l.Hostname == o.Hostname && l.PID == o.PID && l.Created == o.Created
+}
+
+// Lock statuses
+const (
+	// LockStatusHeld means the lockfile is held by this process
+	LockStatusHeld = "held"
+	// LockStatusConflict means another process has used the data directory while this process was running,
+	// or was possibly using it when this process started
+	LockStatusConflict = "conflict"
+	// LockStatusMissing means the lockfile was removed while this process was running
+	LockStatusMissing = "missing"
+	// LockStatusError means the lockfile could not be read
+	LockStatusError = "error"
+)
+
+// LockStatus is the status of a data directory lock
+type LockStatus struct {
+	Status string `json:"status"`
+	// Holder is the current content of the lockfile, if it is not held by this process
+	Holder *LockInfo `json:"holder,omitempty"`
+	// Previous is the lockfile found when this process acquired the lock, if it may have still been in use
+	Previous *LockInfo `json:"previous,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Lock is a lock of a data directory, acquired with AcquireLock
+type Lock struct {
+	fn       string
+	info     LockInfo
+	previous *LockInfo
+
+	sync.Mutex
+	conflict *LockInfo
+}
+
+// AcquireLock writes a lockfile for this process to dir.
+// A lockfile left in dir is replaced. If it may still be in use, because it was written on another host
+// or by a process that is still running on this host, the lock's status is LockStatusConflict.
+func AcquireLock(dir, nodeID string) (*Lock, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	return acquireLock(dir, LockInfo{
+		NodeID:   nodeID,
+		Hostname: hostname,
+		PID:      os.Getpid(),
+		Created:  time.Now().Unix(),
+	}, processExists)
+}
+
+func acquireLock(dir string, info LockInfo, processExists func(pid int) bool) (*Lock, error) {
+	l := &Lock{
+		fn:   filepath.Join(dir, LockFilename),
+		info: info,
+	}
+
+	prev, err := readLockInfo(l.fn)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if prev != nil && (prev.Hostname != info.Hostname || (prev.PID != info.PID && processExists(prev.PID))) {
+		l.previous = prev
+	}
+
+	b, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.SaveBinary(l.fn, b, 0600); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func readLockInfo(fn string) (*LockInfo, error) {
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %v", fn, err)
+	}
+
+	return &info, nil
+}
+
+// Info returns the lockfile content written by this process
+func (l *Lock) Info() LockInfo {
+	return l.info
+}
+
+// Status checks the lockfile and returns the lock's status.
+// Once another process is seen holding the lockfile, the status stays LockStatusConflict,
+// even if the lockfile is held by this process again.
+func (l *Lock) Status() LockStatus {
+	s := LockStatus{
+		Status:   LockStatusHeld,
+		Previous: l.previous,
+	}
+	if l.previous != nil {
+		s.Status = LockStatusConflict
+	}
+
+	info, err := readLockInfo(l.fn)
+
+	l.Lock()
+	defer l.Unlock()
+
+	switch {
+	case err == nil:
+		if !info.equal(l.info) {
+			l.conflict = info
+		}
+	case os.IsNotExist(err):
+		if l.conflict == nil {
+			s.Status = LockStatusMissing
+			return s
+		}
+	default:
+		s.Status = LockStatusError
+		s.Error = err.Error()
+		return s
+	}
+
+	if l.conflict != nil {
+		s.Status = LockStatusConflict
+		s.Holder = l.conflict
+	}
+
+	return s
+}
+
+// ErrLockNotHeld is returned by Release if the lockfile is not held by this process
+var ErrLockNotHeld = errors.New("lockfile is not held by this process")
+
+// Release removes the lockfile, if it is still held by this process
+func (l *Lock) Release() error {
+	info, err := readLockInfo(l.fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !info.equal(l.info) {
+		return ErrLockNotHeld
+	}
+
+	return os.Remove(l.fn)
+}
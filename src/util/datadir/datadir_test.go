@@ -0,0 +1,237 @@
+package datadir
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "datadir")
+	require.NoError(t, err)
+	return dir, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func writeLockInfo(t *testing.T, dir string, info LockInfo) {
+	b, err := json.Marshal(info)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, LockFilename), b, 0600))
+}
+
+func TestLoadOrCreateNodeID(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	id, err := LoadOrCreateNodeID(dir)
+	require.NoError(t, err)
+	require.Len(t, id, 32)
+
+	id2, err := LoadOrCreateNodeID(dir)
+	require.NoError(t, err)
+	require.Equal(t, id, id2)
+
+	fi, err := os.Stat(filepath.Join(dir, NodeIDFilename))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, NodeIDFilename), []byte("foo"), 0600))
+	_, err = LoadOrCreateNodeID(dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid node ID")
+}
+
+func TestLoadOrCreateNodeKey(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	sk, err := LoadOrCreateNodeKey(dir)
+	require.NoError(t, err)
+	require.NoError(t, sk.Verify())
+
+	sk2, err := LoadOrCreateNodeKey(dir)
+	require.NoError(t, err)
+	require.Equal(t, sk, sk2)
+
+	fi, err := os.Stat(filepath.Join(dir, NodeKeyFilename))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, NodeKeyFilename), []byte("foo"), 0600))
+	_, err = LoadOrCreateNodeKey(dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid node key")
+}
+
+func TestFingerprint(t *testing.T) {
+	h := cipher.SumSHA256([]byte("genesis"))
+	require.Equal(t, Fingerprint(h, "a"), Fingerprint(h, "a"))
+	require.NotEqual(t, Fingerprint(h, "a"), Fingerprint(h, "b"))
+	require.NotEqual(t, Fingerprint(h, "a"), Fingerprint(cipher.SumSHA256([]byte("other")), "a"))
+}
+
+func TestLock(t *testing.T) {
+	self := LockInfo{
+		NodeID:   "self",
+		Hostname: "host",
+		PID:      10,
+		Created:  100,
+	}
+
+	running := func(pids ...int) func(int) bool {
+		return func(pid int) bool {
+			for _, p := range pids {
+				if p == pid {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	cases := []struct {
+		name     string
+		previous *LockInfo
+		running  []int
+		status   string
+	}{
+		{
+			name:   "no previous lockfile",
+			status: LockStatusHeld,
+		},
+		{
+			name: "stale lockfile",
+			previous: &LockInfo{
+				NodeID:   "self",
+				Hostname: "host",
+				PID:      9,
+				Created:  50,
+			},
+			status: LockStatusHeld,
+		},
+		{
+			name: "lockfile of a running process",
+			previous: &LockInfo{
+				NodeID:   "self",
+				Hostname: "host",
+				PID:      9,
+				Created:  50,
+			},
+			running: []int{9},
+			status:  LockStatusConflict,
+		},
+		{
+			name: "lockfile of another host",
+			previous: &LockInfo{
+				NodeID:   "other",
+				Hostname: "other-host",
+				PID:      9,
+				Created:  50,
+			},
+			status: LockStatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			if tc.previous != nil {
+				writeLockInfo(t, dir, *tc.previous)
+			}
+
+			l, err := acquireLock(dir, self, running(tc.running...))
+			require.NoError(t, err)
+			require.Equal(t, self, l.Info())
+
+			s := l.Status()
+			require.Equal(t, tc.status, s.Status)
+			require.Nil(t, s.Holder)
+			if tc.status == LockStatusConflict {
+				require.Equal(t, tc.previous, s.Previous)
+			} else {
+				require.Nil(t, s.Previous)
+			}
+
+			require.NoError(t, l.Release())
+			_, err = os.Stat(filepath.Join(dir, LockFilename))
+			require.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestLockStatusChanges(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	self := LockInfo{
+		NodeID:   "self",
+		Hostname: "host",
+		PID:      10,
+		Created:  100,
+	}
+	other := LockInfo{
+		NodeID:   "other",
+		Hostname: "other-host",
+		PID:      20,
+		Created:  200,
+	}
+
+	l, err := acquireLock(dir, self, func(int) bool { return false })
+	require.NoError(t, err)
+	require.Equal(t, LockStatusHeld, l.Status().Status)
+
+	// The lockfile is removed
+	require.NoError(t, os.Remove(filepath.Join(dir, LockFilename)))
+	require.Equal(t, LockStatusMissing, l.Status().Status)
+	require.NoError(t, l.Release())
+
+	// Another node takes over the data directory
+	writeLockInfo(t, dir, other)
+	s := l.Status()
+	require.Equal(t, LockStatusConflict, s.Status)
+	require.Equal(t, &other, s.Holder)
+
+	// The lockfile of another node is not released
+	require.Equal(t, ErrLockNotHeld, l.Release())
+
+	// The conflict is remembered after the other node leaves
+	require.NoError(t, os.Remove(filepath.Join(dir, LockFilename)))
+	s = l.Status()
+	require.Equal(t, LockStatusConflict, s.Status)
+	require.Equal(t, &other, s.Holder)
+
+	// Unreadable lockfile
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, LockFilename), []byte("{"), 0600))
+	s = l.Status()
+	require.Equal(t, LockStatusError, s.Status)
+	require.Contains(t, s.Error, "invalid lockfile")
+}
+
+func TestAcquireLock(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	l, err := AcquireLock(dir, "id")
+	require.NoError(t, err)
+	require.Equal(t, LockStatusHeld, l.Status().Status)
+	require.Equal(t, os.Getpid(), l.Info().PID)
+
+	// A second lock by a running process conflicts
+	l2, err := acquireLock(dir, LockInfo{
+		NodeID:   "id",
+		Hostname: l.Info().Hostname,
+		PID:      os.Getpid() + 1,
+	}, processExists)
+	require.NoError(t, err)
+	require.Equal(t, LockStatusConflict, l2.Status().Status)
+	require.Equal(t, LockStatusConflict, l.Status().Status)
+}
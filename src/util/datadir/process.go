@@ -0,0 +1,24 @@
+// +build !windows
+
+package datadir
+
+import (
+	"os"
+	"syscall"
+)
+
+// processExists returns true if a process with the pid is running
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// EPERM means the process exists but belongs to another user
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
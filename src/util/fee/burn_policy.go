@@ -0,0 +1,137 @@
+package fee
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+)
+
+// BurnPolicy determines the coinhour fee that a transaction must burn
+type BurnPolicy interface {
+	// RequiredFee returns the coinhours fee required for an amount of input hours,
+	// for a transaction verified against a blockchain head with time headTime
+	RequiredFee(hours, headTime uint64) uint64
+}
+
+// ConstantBurnPolicy requires a fee of hours/BurnFactor, rounded up, at all times.
+// It is the policy used by VerifyTransactionFee and RequiredFee.
+type ConstantBurnPolicy struct {
+	BurnFactor uint32
+}
+
+// NewConstantBurnPolicy creates a ConstantBurnPolicy
+func NewConstantBurnPolicy(burnFactor uint32) ConstantBurnPolicy {
+	return ConstantBurnPolicy{
+		BurnFactor: burnFactor,
+	}
+}
+
+// RequiredFee returns the coinhours fee required for an amount of hours
+func (p ConstantBurnPolicy) RequiredFee(hours, headTime uint64) uint64 {
+	return RequiredFee(hours, p.BurnFactor)
+}
+
+// BurnFactorChange switches the burn factor for transactions verified against
+// a blockchain head with a time greater than or equal to Time
+type BurnFactorChange struct {
+	Time       uint64
+	BurnFactor uint32
+}
+
+// ScheduledBurnPolicy applies BurnFactor until the first change in Schedule takes effect.
+// Each change applies until the next change in Schedule.
+type ScheduledBurnPolicy struct {
+	BurnFactor uint32
+	Schedule   []BurnFactorChange
+}
+
+// NewScheduledBurnPolicy creates a ScheduledBurnPolicy.
+// The schedule is sorted by time. Each burn factor must be at least params.MinBurnFactor
+// and no two changes may take effect at the same time.
+func NewScheduledBurnPolicy(burnFactor uint32, schedule []BurnFactorChange) (*ScheduledBurnPolicy, error) {
+	if burnFactor < params.MinBurnFactor {
+		return nil, fmt.Errorf("burn factor must be >= %d", params.MinBurnFactor)
+	}
+
+	sorted := make([]BurnFactorChange, len(schedule))
+	copy(sorted, schedule)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time < sorted[j].Time
+	})
+
+	for i, c := range sorted {
+		if c.BurnFactor < params.MinBurnFactor {
+			return nil, fmt.Errorf("burn factor scheduled at time %d must be >= %d", c.Time, params.MinBurnFactor)
+		}
+		if i > 0 && sorted[i-1].Time == c.Time {
+			return nil, fmt.Errorf("burn factor is scheduled to change more than once at time %d", c.Time)
+		}
+	}
+
+	return &ScheduledBurnPolicy{
+		BurnFactor: burnFactor,
+		Schedule:   sorted,
+	}, nil
+}
+
+// BurnFactorAt returns the burn factor in effect for a blockchain head with time headTime
+func (p ScheduledBurnPolicy) BurnFactorAt(headTime uint64) uint32 {
+	// Find the first change that has not yet taken effect
+	i := sort.Search(len(p.Schedule), func(i int) bool {
+		return p.Schedule[i].Time > headTime
+	})
+
+	if i == 0 {
+		return p.BurnFactor
+	}
+
+	return p.Schedule[i-1].BurnFactor
+}
+
+// RequiredFee returns the coinhours fee required for an amount of hours,
+// using the burn factor in effect at headTime
+func (p ScheduledBurnPolicy) RequiredFee(hours, headTime uint64) uint64 {
+	return RequiredFee(hours, p.BurnFactorAt(headTime))
+}
+
+// VerifyTransactionFeeWithPolicy verifies a transaction's fee against a BurnPolicy
+func VerifyTransactionFeeWithPolicy(t *coin.Transaction, fee, headTime uint64, policy BurnPolicy) error {
+	hours, err := t.OutputHours()
+	if err != nil {
+		return err
+	}
+	return VerifyTransactionFeeForHoursWithPolicy(hours, fee, headTime, policy)
+}
+
+// VerifyTransactionFeeForHoursWithPolicy verifies the fee given fee and hours against a BurnPolicy,
+// where hours is the number of hours in a transaction's outputs,
+// and hours+fee is the number of hours in a transaction's inputs
+func VerifyTransactionFeeForHoursWithPolicy(hours, fee, headTime uint64, policy BurnPolicy) error {
+	// Require non-zero coinhour fee
+	if fee == 0 {
+		return ErrTxnNoFee
+	}
+
+	// Calculate total number of coinhours
+	total, err := mathutil.AddUint64(hours, fee)
+	if err != nil {
+		return errors.New("Hours and fee overflow")
+	}
+
+	// Ensure that the required fee is met
+	if fee < policy.RequiredFee(total, headTime) {
+		return ErrTxnInsufficientFee
+	}
+
+	return nil
+}
+
+// RemainingHoursWithPolicy returns the amount of coinhours leftover after paying the fee
+// required by a BurnPolicy for the input
+func RemainingHoursWithPolicy(hours, headTime uint64, policy BurnPolicy) uint64 {
+	return hours - policy.RequiredFee(hours, headTime)
+}
@@ -0,0 +1,139 @@
+package fee
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstantBurnPolicyGolden(t *testing.T) {
+	// The constant policy must match RequiredFee and VerifyTransactionFeeForHours bit-for-bit
+	requiredFeeCases := []struct {
+		burnFactor uint32
+		cases      []requiredFeeTestCase
+	}{
+		{2, burnFactor2RequiredFeeTestCases},
+		{3, burnFactor3RequiredFeeTestCases},
+		{10, burnFactor10RequiredFeeTestCases},
+	}
+
+	for _, tcc := range requiredFeeCases {
+		p := NewConstantBurnPolicy(tcc.burnFactor)
+		for _, tc := range tcc.cases {
+			for _, headTime := range []uint64{0, 1, math.MaxUint64} {
+				require.Equal(t, tc.fee, p.RequiredFee(tc.hours, headTime), "burnFactor=%d hours=%d", tcc.burnFactor, tc.hours)
+				require.Equal(t, tc.hours-tc.fee, RemainingHoursWithPolicy(tc.hours, headTime, p))
+			}
+		}
+	}
+
+	verifyCases := []struct {
+		burnFactor uint32
+		cases      []verifyTxnFeeTestCase
+	}{
+		{2, burnFactor2VerifyTxnFeeTestCases},
+		{3, burnFactor3VerifyTxnFeeTestCases},
+		{10, burnFactor10VerifyTxnFeeTestCases},
+	}
+
+	for _, tcc := range verifyCases {
+		p := NewConstantBurnPolicy(tcc.burnFactor)
+		for _, tc := range tcc.cases {
+			name := fmt.Sprintf("burnFactor=%d input=%d output=%d", tcc.burnFactor, tc.inputHours, tc.outputHours)
+			t.Run(name, func(t *testing.T) {
+				err := VerifyTransactionFeeForHoursWithPolicy(tc.outputHours, tc.inputHours-tc.outputHours, 0, p)
+				require.Equal(t, tc.err, err)
+				require.Equal(t, VerifyTransactionFeeForHours(tc.outputHours, tc.inputHours-tc.outputHours, tcc.burnFactor), err)
+			})
+		}
+	}
+
+	// Pinned outputs of the default policy
+	golden := []struct {
+		burnFactor uint32
+		hours      uint64
+		fee        uint64
+	}{
+		{2, 0, 0},
+		{2, 1, 1},
+		{2, 1e6, 5e5},
+		{2, math.MaxUint64, 1 << 63},
+		{10, 1, 1},
+		{10, 10, 1},
+		{10, 11, 2},
+		{10, 123456789, 12345679},
+		{10, math.MaxUint64, 1844674407370955162},
+	}
+
+	for _, tc := range golden {
+		require.Equal(t, tc.fee, NewConstantBurnPolicy(tc.burnFactor).RequiredFee(tc.hours, 0), "burnFactor=%d hours=%d", tc.burnFactor, tc.hours)
+	}
+}
+
+func TestNewScheduledBurnPolicy(t *testing.T) {
+	_, err := NewScheduledBurnPolicy(1, nil)
+	require.EqualError(t, err, "burn factor must be >= 2")
+
+	_, err = NewScheduledBurnPolicy(2, []BurnFactorChange{{Time: 10, BurnFactor: 1}})
+	require.EqualError(t, err, "burn factor scheduled at time 10 must be >= 2")
+
+	_, err = NewScheduledBurnPolicy(2, []BurnFactorChange{{Time: 10, BurnFactor: 3}, {Time: 10, BurnFactor: 4}})
+	require.EqualError(t, err, "burn factor is scheduled to change more than once at time 10")
+
+	schedule := []BurnFactorChange{
+		{Time: 200, BurnFactor: 4},
+		{Time: 100, BurnFactor: 10},
+	}
+	p, err := NewScheduledBurnPolicy(2, schedule)
+	require.NoError(t, err)
+	require.Equal(t, []BurnFactorChange{
+		{Time: 100, BurnFactor: 10},
+		{Time: 200, BurnFactor: 4},
+	}, p.Schedule)
+	// The input schedule is not modified
+	require.Equal(t, uint64(200), schedule[0].Time)
+}
+
+func TestScheduledBurnPolicy(t *testing.T) {
+	p, err := NewScheduledBurnPolicy(2, []BurnFactorChange{
+		{Time: 100, BurnFactor: 10},
+		{Time: 200, BurnFactor: 4},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		headTime   uint64
+		burnFactor uint32
+	}{
+		{0, 2},
+		{99, 2},
+		{100, 10},
+		{199, 10},
+		{200, 4},
+		{math.MaxUint64, 4},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("headTime=%d", tc.headTime), func(t *testing.T) {
+			require.Equal(t, tc.burnFactor, p.BurnFactorAt(tc.headTime))
+			for _, hours := range []uint64{0, 1, 9, 10, 11, 1e6} {
+				require.Equal(t, RequiredFee(hours, tc.burnFactor), p.RequiredFee(hours, tc.headTime))
+			}
+		})
+	}
+
+	// 100 input hours require a fee of 50 before the first change, 10 after it and 25 after the second
+	require.NoError(t, VerifyTransactionFeeForHoursWithPolicy(50, 50, 99, p))
+	require.Equal(t, ErrTxnInsufficientFee, VerifyTransactionFeeForHoursWithPolicy(51, 49, 99, p))
+	require.NoError(t, VerifyTransactionFeeForHoursWithPolicy(90, 10, 100, p))
+	require.NoError(t, VerifyTransactionFeeForHoursWithPolicy(75, 25, 200, p))
+	require.Equal(t, ErrTxnInsufficientFee, VerifyTransactionFeeForHoursWithPolicy(76, 24, 200, p))
+
+	// A policy with no schedule behaves as a constant policy
+	c := ScheduledBurnPolicy{BurnFactor: 10}
+	for _, hours := range []uint64{0, 1, 10, 11, 1e6} {
+		require.Equal(t, NewConstantBurnPolicy(10).RequiredFee(hours, 0), c.RequiredFee(hours, 12345))
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"errors"
 
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/util/mathutil"
 )
 
 var (
@@ -37,26 +36,7 @@ func VerifyTransactionFee(t *coin.Transaction, fee uint64, burnFactor uint32) er
 // where hours is the number of hours in a transaction's outputs,
 // and hours+fee is the number of hours in a transaction's inputs
 func VerifyTransactionFeeForHours(hours, fee uint64, burnFactor uint32) error {
-	// Require non-zero coinhour fee
-	if fee == 0 {
-		return ErrTxnNoFee
-	}
-
-	// Calculate total number of coinhours
-	total, err := mathutil.AddUint64(hours, fee)
-	if err != nil {
-		return errors.New("Hours and fee overflow")
-	}
-
-	// Calculate the required fee
-	requiredFee := RequiredFee(total, burnFactor)
-
-	// Ensure that the required fee is met
-	if fee < requiredFee {
-		return ErrTxnInsufficientFee
-	}
-
-	return nil
+	return VerifyTransactionFeeForHoursWithPolicy(hours, fee, 0, NewConstantBurnPolicy(burnFactor))
 }
 
 // RequiredFee returns the coinhours fee required for an amount of hours
@@ -7,21 +7,33 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	plogging "github.com/ness-network/privateness/src/util/logging"
 )
 
-// ElapsedHandler records and logs an HTTP request with the elapsed time and status code
+// ElapsedHandler records and logs an HTTP request with the elapsed time and status code.
+// They are logged in the message and in the status, method, path and elapsed_ms fields.
+// The log entry includes the request ID carried by the request context, if any.
 func ElapsedHandler(logger logrus.FieldLogger, handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := plogging.FromContext(r.Context(), logger)
 		lrw := newWrappedResponseWriter(w)
 		start := time.Now()
 		handler.ServeHTTP(lrw, r)
+		elapsed := time.Since(start)
+		logger = logger.WithFields(logrus.Fields{
+			"status":     lrw.statusCode,
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"elapsed_ms": float64(elapsed) / float64(time.Millisecond),
+		})
 		logMethod := logger.Infof
 		if lrw.statusCode >= 400 {
 			logMethod = logger.WithFields(logrus.Fields{
 				"body": strings.TrimSpace(lrw.response.String()),
 			}).Errorf
 		}
-		logMethod("%d %s %s %s", lrw.statusCode, r.Method, r.URL.Path, time.Since(start))
+		logMethod("%d %s %s %s", lrw.statusCode, r.Method, r.URL.Path, elapsed)
 	})
 }
 
@@ -51,3 +63,10 @@ func (lrw *wrappedResponseWriter) Write(buff []byte) (int, error) {
 	}
 	return retVal, err
 }
+
+// Flush implements http.Flusher if the wrapped http.ResponseWriter does, so that streamed responses can be flushed
+func (lrw *wrappedResponseWriter) Flush() {
+	if f, ok := lrw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,47 @@
+package httphelper
+
+import (
+	"net/http"
+
+	plogging "github.com/ness-network/privateness/src/util/logging"
+)
+
+const (
+	// RequestIDHeader is the header carrying the ID of a request, used to trace a request through the logs
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLen is the maximum length of a request ID accepted from a client
+	maxRequestIDLen = 128
+)
+
+// RequestIDHandler assigns an ID to each request, taken from the X-Request-ID header
+// or generated if the header is missing or invalid.
+// The ID is returned in the X-Request-ID response header and is carried by the request context,
+// so that loggers obtained with logging.FromContext include it.
+func RequestIDHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = plogging.NewRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		handler.ServeHTTP(w, r.WithContext(plogging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID returns true if a request ID is not empty, not too long and made of printable ASCII characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+package httphelper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	plogging "github.com/ness-network/privateness/src/util/logging"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		requestID string
+		expect    string
+	}{
+		{
+			name:      "valid",
+			requestID: "5ab7e1c0-9f1d-4d11",
+			expect:    "5ab7e1c0-9f1d-4d11",
+		},
+		{
+			name:      "max length",
+			requestID: strings.Repeat("a", maxRequestIDLen),
+			expect:    strings.Repeat("a", maxRequestIDLen),
+		},
+		{
+			name: "missing",
+		},
+		{
+			name:      "too long",
+			requestID: strings.Repeat("a", maxRequestIDLen+1),
+		},
+		{
+			name:      "space",
+			requestID: "foo bar",
+		},
+		{
+			name:      "control character",
+			requestID: "foo\tbar",
+		},
+		{
+			name:      "non-ASCII",
+			requestID: "føø",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ctxRequestID string
+			handler := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var ok bool
+				ctxRequestID, ok = plogging.RequestIDFromContext(r.Context())
+				require.True(t, ok)
+			}))
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				req.Header.Set(RequestIDHeader, tc.requestID)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			requestID := rr.Header().Get(RequestIDHeader)
+			require.Equal(t, requestID, ctxRequestID)
+
+			if tc.expect != "" {
+				require.Equal(t, tc.expect, requestID)
+			} else {
+				// A request ID is generated
+				require.Len(t, requestID, 32)
+				require.NotEqual(t, tc.requestID, requestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logRequestIDKey is the log entry key for the ID of the request a log statement was made for
+const logRequestIDKey = "request_id"
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying a request ID, which FromContext adds to log entries
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// NewRequestID generates a random request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// FromContext returns a logger that adds the request ID carried by ctx to its log entries.
+// If ctx has no request ID, logger is returned.
+func FromContext(ctx context.Context, logger logrus.FieldLogger) logrus.FieldLogger {
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return logger
+	}
+
+	return logger.WithField(logRequestIDKey, id)
+}
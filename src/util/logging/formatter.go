@@ -439,3 +439,17 @@ func (f *TextFormatter) appendValue(b *bytes.Buffer, value interface{}) {
 		fmt.Fprint(b, value)
 	}
 }
+
+// NewTextFormatter returns the TextFormatter of the logger, for a hook writing to w.
+// Colors are only added if w is a terminal.
+func NewTextFormatter(w io.Writer, colors bool) *TextFormatter {
+	f := &TextFormatter{
+		FullTimestamp:      true,
+		AlwaysQuoteStrings: true,
+		QuoteEmptyFields:   true,
+		ForceFormatting:    true,
+		DisableColors:      !colors,
+	}
+	f.ForceColors = colors && f.checkIfTerminal(w)
+	return f
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FormatText is the human readable log format
+	FormatText = "text"
+	// FormatJSON is the log format with one JSON object per line
+	FormatJSON = "json"
+)
+
+// Keys of the JSONFormatter's entries
+const (
+	jsonTimestampKey = "timestamp"
+	jsonLevelKey     = "level"
+	jsonSubsystemKey = "subsystem"
+	jsonMessageKey   = "message"
+	jsonCriticalKey  = "critical"
+)
+
+// JSONFormatter formats a log entry as a JSON object on a single line.
+// The object has the timestamp, level, subsystem and message of the entry, and its fields.
+// The subsystem is the module name of the package logger. Critical log statements have "critical": true.
+// A field that has the name of one of these keys is renamed with a "fields." prefix.
+type JSONFormatter struct {
+	// TimestampFormat is the time.Format layout of the timestamp, defaults to time.RFC3339Nano
+	TimestampFormat string
+}
+
+// Format formats a logrus.Entry
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data)+4)
+	for k, v := range entry.Data {
+		switch k {
+		case logModuleKey:
+			data[jsonSubsystemKey] = v
+			continue
+		case logPriorityKey:
+			if v == logPriorityCritical {
+				data[jsonCriticalKey] = true
+			}
+			continue
+		case jsonTimestampKey, jsonLevelKey, jsonSubsystemKey, jsonMessageKey, jsonCriticalKey:
+			k = "fields." + k
+		}
+
+		// An error is encoded as its message, most error types marshal to an empty object
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+
+		data[k] = v
+	}
+
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = time.RFC3339Nano
+	}
+
+	data[jsonTimestampKey] = entry.Time.Format(timestampFormat)
+	data[jsonLevelKey] = entry.Level.String()
+	data[jsonMessageKey] = entry.Message
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal log entry to JSON: %v", err)
+	}
+
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SubsystemLevels are log levels of subsystems, overriding a default log level.
+// A subsystem is the module name of a package logger, e.g. "daemon", "visor", "gnet", "api" or "wallet".
+type SubsystemLevels struct {
+	Default    logrus.Level
+	Subsystems map[string]logrus.Level
+}
+
+// ParseSubsystemLevels parses a comma separated list of subsystem=level pairs, e.g. "gnet=debug,visor=info"
+func ParseSubsystemLevels(s string) (map[string]logrus.Level, error) {
+	levels := make(map[string]logrus.Level)
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return levels, nil
+	}
+
+	for _, p := range strings.Split(s, ",") {
+		pts := strings.Split(p, "=")
+		if len(pts) != 2 {
+			return nil, fmt.Errorf("invalid subsystem log level %q, must be subsystem=level", p)
+		}
+
+		subsystem := strings.TrimSpace(pts[0])
+		if subsystem == "" {
+			return nil, fmt.Errorf("invalid subsystem log level %q, missing subsystem", p)
+		}
+
+		if _, ok := levels[subsystem]; ok {
+			return nil, fmt.Errorf("duplicate subsystem log level %q", subsystem)
+		}
+
+		level, err := LevelFromString(strings.TrimSpace(pts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid subsystem log level %q: %v", p, err)
+		}
+
+		levels[subsystem] = level
+	}
+
+	return levels, nil
+}
+
+// Level returns the log level of a subsystem
+func (l SubsystemLevels) Level(subsystem string) logrus.Level {
+	if level, ok := l.Subsystems[subsystem]; ok {
+		return level
+	}
+	return l.Default
+}
+
+// Max returns the most verbose log level of the default and subsystem levels.
+// The logger's level must be set to it, for the entries of the most verbose subsystem to be logged.
+func (l SubsystemLevels) Max() logrus.Level {
+	max := l.Default
+	for _, level := range l.Subsystems {
+		if level > max {
+			max = level
+		}
+	}
+	return max
+}
+
+// Enabled returns true if the entry's level is enabled for the entry's subsystem
+func (l SubsystemLevels) Enabled(e *logrus.Entry) bool {
+	subsystem, _ := e.Data[logModuleKey].(string)
+	return e.Level <= l.Level(subsystem)
+}
+
+// LevelWriteHook is a logrus.Hook that writes the log entries enabled by its SubsystemLevels to an io.Writer
+type LevelWriteHook struct {
+	w         io.Writer
+	formatter logrus.Formatter
+	levels    SubsystemLevels
+}
+
+// NewLevelWriteHook returns a new LevelWriteHook
+func NewLevelWriteHook(w io.Writer, formatter logrus.Formatter, levels SubsystemLevels) *LevelWriteHook {
+	return &LevelWriteHook{
+		w:         w,
+		formatter: formatter,
+		levels:    levels,
+	}
+}
+
+// Levels returns Levels accepted by the LevelWriteHook.
+// All logrus.Levels are returned, the entries are filtered by subsystem in Fire.
+func (f *LevelWriteHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire writes a logrus.Entry, if its level is enabled for its subsystem
+func (f *LevelWriteHook) Fire(e *logrus.Entry) error {
+	if !f.levels.Enabled(e) {
+		return nil
+	}
+
+	b, err := f.formatter.Format(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.w.Write(b)
+	return err
+}
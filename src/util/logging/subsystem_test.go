@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubsystemLevels(t *testing.T) {
+	cases := []struct {
+		name   string
+		s      string
+		levels map[string]logrus.Level
+		err    string
+	}{
+		{
+			name:   "empty",
+			levels: map[string]logrus.Level{},
+		},
+		{
+			name: "levels",
+			s:    "gnet=debug, visor = info,api=warn",
+			levels: map[string]logrus.Level{
+				"gnet":  logrus.DebugLevel,
+				"visor": logrus.InfoLevel,
+				"api":   logrus.WarnLevel,
+			},
+		},
+		{
+			name: "missing level",
+			s:    "gnet",
+			err:  `invalid subsystem log level "gnet", must be subsystem=level`,
+		},
+		{
+			name: "missing subsystem",
+			s:    "=debug",
+			err:  `invalid subsystem log level "=debug", missing subsystem`,
+		},
+		{
+			name: "invalid level",
+			s:    "gnet=loud",
+			err:  `invalid subsystem log level "gnet=loud": could not convert string to log level`,
+		},
+		{
+			name: "duplicate subsystem",
+			s:    "gnet=debug,gnet=info",
+			err:  `duplicate subsystem log level "gnet"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels, err := ParseSubsystemLevels(tc.s)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.levels, levels)
+		})
+	}
+}
+
+func TestSubsystemLevels(t *testing.T) {
+	levels := SubsystemLevels{
+		Default: logrus.InfoLevel,
+		Subsystems: map[string]logrus.Level{
+			"gnet": logrus.DebugLevel,
+			"api":  logrus.ErrorLevel,
+		},
+	}
+
+	require.Equal(t, logrus.DebugLevel, levels.Max())
+	require.Equal(t, logrus.InfoLevel, SubsystemLevels{Default: logrus.InfoLevel}.Max())
+
+	var buf bytes.Buffer
+	m := NewMasterLogger()
+	m.Out = &buf
+	m.SetLevel(levels.Max())
+
+	var out bytes.Buffer
+	m.AddHook(NewLevelWriteHook(&out, &JSONFormatter{}, levels))
+
+	m.PackageLogger("gnet").Debug("gnet debug")
+	m.PackageLogger("visor").Debug("visor debug")
+	m.PackageLogger("visor").Info("visor info")
+	m.PackageLogger("api").Warning("api warning")
+	m.PackageLogger("api").Error("api error")
+	m.WithField("foo", "bar").Info("no subsystem")
+
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		msgs = append(msgs, e["message"].(string))
+	}
+
+	require.Equal(t, []string{
+		"gnet debug",
+		"visor info",
+		"api error",
+		"no subsystem",
+	}, msgs)
+}
+
+func TestJSONFormatter(t *testing.T) {
+	m := NewMasterLogger()
+	var buf bytes.Buffer
+	m.Out = &buf
+	m.Formatter = &JSONFormatter{}
+
+	logger := m.PackageLogger("daemon")
+	ts := time.Date(2018, 10, 1, 12, 30, 15, 500, time.UTC)
+
+	logger.WithTime(ts).WithFields(logrus.Fields{
+		"addr":    "127.0.0.1:6000",
+		"seq":     uint64(10),
+		"message": "collides",
+	}).WithError(errors.New("foo")).Warning("Added new block")
+
+	require.Equal(t, `{"addr":"127.0.0.1:6000","error":"foo","fields.message":"collides","level":"warning","message":"Added new block","seq":10,"subsystem":"daemon","timestamp":"2018-10-01T12:30:15.0000005Z"}`+"\n", buf.String())
+
+	buf.Reset()
+	logger.Critical().(*logrus.Entry).WithTime(ts).Error("critical")
+	require.Equal(t, `{"critical":true,"level":"error","message":"critical","subsystem":"daemon","timestamp":"2018-10-01T12:30:15.0000005Z"}`+"\n", buf.String())
+}
@@ -0,0 +1,300 @@
+package qr
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// The data codewords of "HELLO WORLD" as a version 1-M QR code, and their error correction codewords
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	require.Equal(t, ecc, reedSolomonRemainder(data, reedSolomonDivisor(len(ecc))))
+}
+
+func TestFormatBits(t *testing.T) {
+	require.Equal(t, 0x77C4, formatBits(L, 0))
+	require.Equal(t, 0x5412, formatBits(M, 0))
+	require.Equal(t, 0x355F, formatBits(Q, 0))
+	require.Equal(t, 0x1689, formatBits(H, 0))
+	require.Equal(t, 0x72F3, formatBits(L, 1))
+	require.Equal(t, 0x083B, formatBits(H, 7))
+}
+
+func TestVersionBits(t *testing.T) {
+	require.Equal(t, 0x07C94, versionBits(7))
+	require.Equal(t, 0x085BC, versionBits(8))
+	require.Equal(t, 0x28C69, versionBits(40))
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	require.Empty(t, alignmentPatternPositions(1))
+	require.Equal(t, []int{6, 18}, alignmentPatternPositions(2))
+	require.Equal(t, []int{6, 22, 38}, alignmentPatternPositions(7))
+	require.Equal(t, []int{6, 34, 60, 86, 112, 138}, alignmentPatternPositions(32))
+	require.Equal(t, []int{6, 30, 58, 86, 114, 142, 170}, alignmentPatternPositions(40))
+}
+
+func TestByteCapacity(t *testing.T) {
+	// The byte mode capacities of ISO/IEC 18004 table 7
+	capacities := map[int][4]int{
+		1:  {17, 14, 11, 7},
+		2:  {32, 26, 20, 14},
+		7:  {154, 122, 86, 64},
+		10: {271, 213, 151, 119},
+		40: {2953, 2331, 1663, 1273},
+	}
+
+	for version, c := range capacities {
+		for level := L; level <= H; level++ {
+			n := (numDataCodewords(version, level)*8 - 4 - charCountBits(version)) / 8
+			require.Equal(t, c[level], n, "version %d level %d", version, level)
+		}
+	}
+
+	for level := L; level <= H; level++ {
+		for version := minVersion; version <= maxVersion; version++ {
+			blocks := numErrorCorrectionBlocks[level][version]
+			require.True(t, numRawDataModules(version)/8 >= blocks*eccCodewordsPerBlock[level][version])
+		}
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	c, err := Encode(make([]byte, 17), L)
+	require.NoError(t, err)
+	require.Equal(t, 1, c.Version)
+	require.Equal(t, 21, c.Size)
+
+	c, err = Encode(make([]byte, 18), L)
+	require.NoError(t, err)
+	require.Equal(t, 2, c.Version)
+
+	c, err = Encode(make([]byte, 2953), L)
+	require.NoError(t, err)
+	require.Equal(t, 40, c.Version)
+	require.Equal(t, 177, c.Size)
+
+	_, err = Encode(make([]byte, 2954), L)
+	require.Equal(t, ErrDataTooLong, err)
+
+	_, err = Encode(make([]byte, 1274), H)
+	require.Equal(t, ErrDataTooLong, err)
+
+	_, err = Encode(nil, Level(4))
+	require.Equal(t, ErrInvalidLevel, err)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, n := range []int{0, 1, 17, 100, 300, 1000, 2331} {
+		for level := L; level <= H; level++ {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(i*7 + n)
+			}
+
+			c, err := Encode(data, level)
+			if level == H && n > 1273 {
+				require.Equal(t, ErrDataTooLong, err)
+				continue
+			}
+			if level == Q && n > 1663 {
+				require.Equal(t, ErrDataTooLong, err)
+				continue
+			}
+			require.NoError(t, err)
+
+			t.Run(fmt.Sprintf("%d-%d", n, level), func(t *testing.T) {
+				requireFinderPatterns(t, c)
+				require.Equal(t, data, decode(t, c))
+			})
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	c, err := Encode([]byte("privateness"), M)
+	require.NoError(t, err)
+
+	img := c.Image(3)
+	width := (c.Size + 2*QuietZone) * 3
+	require.Equal(t, image.Rect(0, 0, width, width), img.Bounds())
+
+	// The top left module of the finder pattern is dark, the quiet zone is light
+	r, _, _, _ := img.At(QuietZone*3, QuietZone*3).RGBA()
+	require.Equal(t, uint32(0), r)
+	r, _, _, _ = img.At(QuietZone*3-1, QuietZone*3).RGBA()
+	require.Equal(t, uint32(0xFFFF), r)
+
+	lines := strings.Split(strings.TrimSuffix(c.ASCII(false), "\n"), "\n")
+	require.Len(t, lines, c.Size+2*QuietZone)
+	require.Equal(t, strings.Repeat("  ", QuietZone)+strings.Repeat("██", 7)+"  ", string([]rune(lines[QuietZone])[:2*QuietZone+16]))
+
+	inverted := strings.Split(c.ASCII(true), "\n")
+	require.Equal(t, strings.Repeat("██", c.Size+2*QuietZone), inverted[0])
+}
+
+// requireFinderPatterns checks the finder patterns, their separators and the timing patterns
+func requireFinderPatterns(t *testing.T, c *Code) {
+	finder := []string{
+		"#######.",
+		"#.....#.",
+		"#.###.#.",
+		"#.###.#.",
+		"#.###.#.",
+		"#.....#.",
+		"#######.",
+		"........",
+	}
+
+	for y, row := range finder {
+		for x, m := range row {
+			dark := m == '#'
+			require.Equal(t, dark, c.Module(x, y))
+			require.Equal(t, dark, c.Module(c.Size-1-x, y))
+			require.Equal(t, dark, c.Module(x, c.Size-1-y))
+		}
+	}
+
+	for i := 8; i < c.Size-8; i++ {
+		require.Equal(t, i%2 == 0, c.Module(i, 6))
+		require.Equal(t, i%2 == 0, c.Module(6, i))
+	}
+
+	// The dark module
+	require.True(t, c.Module(8, c.Size-8))
+}
+
+// decode decodes a QR code's data, checking its format information and error correction codewords
+func decode(t *testing.T, c *Code) []byte {
+	// Read the first copy of the format information
+	var bits int
+	for i := 0; i <= 5; i++ {
+		bits |= b2i(c.Module(8, i)) << uint(i)
+	}
+	bits |= b2i(c.Module(8, 7)) << 6
+	bits |= b2i(c.Module(8, 8)) << 7
+	bits |= b2i(c.Module(7, 8)) << 8
+	for i := 9; i < 15; i++ {
+		bits |= b2i(c.Module(14-i, 8)) << uint(i)
+	}
+
+	// Read the second copy
+	var bits2 int
+	for i := 0; i < 8; i++ {
+		bits2 |= b2i(c.Module(c.Size-1-i, 8)) << uint(i)
+	}
+	for i := 8; i < 15; i++ {
+		bits2 |= b2i(c.Module(8, c.Size-15+i)) << uint(i)
+	}
+	require.Equal(t, bits, bits2)
+	require.Equal(t, formatBits(c.Level, c.Mask), bits)
+
+	// Locate the data modules
+	fc := newCode(c.Version, c.Level)
+	fc.drawFunctionPatterns()
+
+	// Read the codewords, in two module wide columns from the right, alternating upward and downward
+	var raw []byte
+	var cur byte
+	n := 0
+	upward := true
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for k := 0; k < c.Size; k++ {
+			y := k
+			if upward {
+				y = c.Size - 1 - k
+			}
+			for _, x := range []int{right, right - 1} {
+				if fc.isFunction[y][x] {
+					continue
+				}
+				m := c.Module(x, y) != maskFunc(c.Mask, x, y)
+				cur = cur<<1 | byte(b2i(m))
+				n++
+				if n%8 == 0 {
+					raw = append(raw, cur)
+					cur = 0
+				}
+			}
+		}
+		upward = !upward
+	}
+
+	rawCodewords := numRawDataModules(c.Version) / 8
+	require.Len(t, raw, rawCodewords)
+
+	// Deinterleave the blocks
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLen := rawCodewords/numBlocks - eccLen
+
+	dataBlocks := make([][]byte, numBlocks)
+	eccBlocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortDataLen+1; i++ {
+		for j := 0; j < numBlocks; j++ {
+			if i == shortDataLen && j < numShortBlocks {
+				continue
+			}
+			dataBlocks[j] = append(dataBlocks[j], raw[k])
+			k++
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := 0; j < numBlocks; j++ {
+			eccBlocks[j] = append(eccBlocks[j], raw[k])
+			k++
+		}
+	}
+
+	var data []byte
+	divisor := reedSolomonDivisor(eccLen)
+	for j := range dataBlocks {
+		require.Equal(t, eccBlocks[j], reedSolomonRemainder(dataBlocks[j], divisor))
+		data = append(data, dataBlocks[j]...)
+	}
+	require.Len(t, data, numDataCodewords(c.Version, c.Level))
+
+	// Parse the byte mode segment
+	r := bitReader{data: data}
+	require.Equal(t, 0x4, r.read(4))
+	count := r.read(charCountBits(c.Version))
+
+	decoded := make([]byte, count)
+	for i := range decoded {
+		decoded[i] = byte(r.read(8))
+	}
+
+	return decoded
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v = v<<1 | int(r.data[r.pos>>3]>>uint(7-r.pos&7)&1)
+		r.pos++
+	}
+	return v
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// GetAddressTransactionsInBlockRange returns the confirmed transactions of the addresses executed
+// in the blocks from start to end, including both. The transactions of the address transaction index
+// are filtered by block seq, so only the blocks of the returned transactions are read.
+// The transactions are ordered by block seq, then by their index in the block.
+func (vs *Visor) GetAddressTransactionsInBlockRange(addrs []cipher.Address, start, end uint64) ([]Transaction, error) {
+	var txns []Transaction
+
+	if err := vs.view("GetAddressTransactionsInBlockRange", func(tx *dbutil.Tx) error {
+		var err error
+		txns, err = vs.getAddressTransactionsInBlockRange(tx, addrs, start, end)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return txns, nil
+}
+
+// GetAddressTransactionsInBlockRangeWithInputs is the same as GetAddressTransactionsInBlockRange
+// but also returns verbose transaction input data
+func (vs *Visor) GetAddressTransactionsInBlockRangeWithInputs(addrs []cipher.Address, start, end uint64) ([]Transaction, [][]TransactionInput, error) {
+	var txns []Transaction
+	var inputs [][]TransactionInput
+
+	if err := vs.view("GetAddressTransactionsInBlockRangeWithInputs", func(tx *dbutil.Tx) error {
+		var err error
+		txns, err = vs.getAddressTransactionsInBlockRange(tx, addrs, start, end)
+		if err != nil {
+			return err
+		}
+
+		inputs = make([][]TransactionInput, len(txns))
+		for i, txn := range txns {
+			feeCalcTime, err := vs.getFeeCalcTimeForTransaction(tx, txn)
+			if err != nil {
+				return err
+			}
+			if feeCalcTime == nil {
+				continue
+			}
+
+			txnInputs, err := vs.getTransactionInputs(tx, *feeCalcTime, txn.Transaction.In)
+			if err != nil {
+				return err
+			}
+
+			inputs[i] = txnInputs
+		}
+
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return txns, inputs, nil
+}
+
+// blockRangeTxn is a transaction found in a block range, with its index in its block
+type blockRangeTxn struct {
+	txn   Transaction
+	index int
+}
+
+func (vs *Visor) getAddressTransactionsInBlockRange(tx *dbutil.Tx, addrs []cipher.Address, start, end uint64) ([]Transaction, error) {
+	if start > end {
+		return nil, fmt.Errorf("block range start %d is greater than end %d", start, end)
+	}
+
+	headBkSeq, ok, err := vs.blockchain.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("No head block seq")
+	}
+
+	if start > headBkSeq {
+		return nil, nil
+	}
+	if end > headBkSeq {
+		end = headBkSeq
+	}
+
+	// The blocks of the transactions, and the index of each transaction in its block
+	blocks := make(map[uint64]*coin.SignedBlock)
+	blockIndexes := make(map[uint64]map[cipher.SHA256]int)
+
+	seen := make(map[cipher.SHA256]struct{})
+	var found []blockRangeTxn
+	for _, a := range addrs {
+		addrTxns, err := vs.history.GetTransactionsForAddress(tx, a)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, htxn := range addrTxns {
+			if htxn.BlockSeq < start || htxn.BlockSeq > end {
+				continue
+			}
+
+			h := htxn.Hash()
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+
+			bk, ok := blocks[htxn.BlockSeq]
+			if !ok {
+				bk, err = vs.blockchain.GetSignedBlockBySeq(tx, htxn.BlockSeq)
+				if err != nil {
+					return nil, err
+				}
+				if bk == nil {
+					return nil, fmt.Errorf("block seq=%d doesn't exist", htxn.BlockSeq)
+				}
+
+				indexes := make(map[cipher.SHA256]int, len(bk.Body.Transactions))
+				for i, txn := range bk.Body.Transactions {
+					indexes[txn.Hash()] = i
+				}
+
+				blocks[htxn.BlockSeq] = bk
+				blockIndexes[htxn.BlockSeq] = indexes
+			}
+
+			index, ok := blockIndexes[htxn.BlockSeq][h]
+			if !ok {
+				return nil, fmt.Errorf("transaction %s is not in its block seq=%d", h.Hex(), htxn.BlockSeq)
+			}
+
+			found = append(found, blockRangeTxn{
+				txn: Transaction{
+					Transaction: htxn.Txn,
+					Status:      NewConfirmedTransactionStatus(headBkSeq-htxn.BlockSeq+1, htxn.BlockSeq),
+					Time:        bk.Time(),
+				},
+				index: index,
+			})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a := found[i]
+		b := found[j]
+		if a.txn.Status.BlockSeq == b.txn.Status.BlockSeq {
+			return a.index < b.index
+		}
+		return a.txn.Status.BlockSeq < b.txn.Status.BlockSeq
+	})
+
+	var txns []Transaction
+	for _, f := range found {
+		txns = append(txns, f.txn)
+	}
+
+	return txns, nil
+}
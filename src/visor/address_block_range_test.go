@@ -0,0 +1,169 @@
+package visor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+func TestGetAddressTransactionsInBlockRange(t *testing.T) {
+	addrs := []cipher.Address{testutil.MakeAddress(), testutil.MakeAddress()}
+
+	txns := make([]coin.Transaction, 5)
+	for i := range txns {
+		txns[i] = makeUnconfirmedFileTxn(t)
+	}
+
+	makeBlock := func(seq uint64, txns ...coin.Transaction) coin.SignedBlock {
+		return coin.SignedBlock{
+			Block: coin.Block{
+				Head: coin.BlockHeader{
+					BkSeq: seq,
+					Time:  100 * seq,
+				},
+				Body: coin.BlockBody{
+					Transactions: txns,
+				},
+			},
+		}
+	}
+
+	// txns[2] and txns[4] are not transactions of the addresses
+	blocks := []coin.SignedBlock{
+		makeBlock(0, txns[4]),
+		makeBlock(1, txns[0], txns[1]),
+		makeBlock(2, txns[2], txns[3]),
+		makeBlock(3, txns[4]),
+	}
+	headSeq := uint64(3)
+
+	// txns[3] is a transaction of both addresses.
+	// The address transactions are not in the order of the blocks.
+	addrTxns := map[cipher.Address][]historydb.Transaction{
+		addrs[0]: {
+			{Txn: txns[3], BlockSeq: 2},
+			{Txn: txns[1], BlockSeq: 1},
+		},
+		addrs[1]: {
+			{Txn: txns[0], BlockSeq: 1},
+			{Txn: txns[3], BlockSeq: 2},
+		},
+	}
+
+	expect := func(seqs []uint64, txns ...coin.Transaction) []Transaction {
+		expected := make([]Transaction, len(txns))
+		for i, txn := range txns {
+			expected[i] = Transaction{
+				Transaction: txn,
+				Status:      NewConfirmedTransactionStatus(headSeq-seqs[i]+1, seqs[i]),
+				Time:        100 * seqs[i],
+			}
+		}
+		return expected
+	}
+
+	cases := []struct {
+		name       string
+		addrs      []cipher.Address
+		start, end uint64
+		readBlocks []uint64
+		txns       []Transaction
+		err        error
+	}{
+		{
+			name:       "ordered by block seq and index in block",
+			addrs:      addrs,
+			start:      0,
+			end:        3,
+			readBlocks: []uint64{1, 2},
+			txns:       expect([]uint64{1, 1, 2}, txns[0], txns[1], txns[3]),
+		},
+		{
+			name:       "one address",
+			addrs:      addrs[:1],
+			start:      0,
+			end:        3,
+			readBlocks: []uint64{1, 2},
+			txns:       expect([]uint64{1, 2}, txns[1], txns[3]),
+		},
+		{
+			name:       "only the blocks in the range are read",
+			addrs:      addrs,
+			start:      2,
+			end:        2,
+			readBlocks: []uint64{2},
+			txns:       expect([]uint64{2}, txns[3]),
+		},
+		{
+			name:       "end past the head",
+			addrs:      addrs,
+			start:      2,
+			end:        100,
+			readBlocks: []uint64{2},
+			txns:       expect([]uint64{2}, txns[3]),
+		},
+		{
+			name:  "start past the head",
+			addrs: addrs,
+			start: 4,
+			end:   5,
+		},
+		{
+			name:  "no transactions in the range",
+			addrs: addrs,
+			start: 3,
+			end:   3,
+		},
+		{
+			name:  "start after end",
+			addrs: addrs,
+			start: 2,
+			end:   1,
+			err:   errors.New("block range start 2 is greater than end 1"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchDBTx := mock.MatchedBy(func(tx *dbutil.Tx) bool {
+				return true
+			})
+
+			his := &MockHistoryer{}
+			for _, a := range addrs {
+				his.On("GetTransactionsForAddress", matchDBTx, a).Return(addrTxns[a], nil)
+			}
+
+			// Reading a block that is not expected to be read fails the test
+			bc := &MockBlockchainer{}
+			for _, seq := range tc.readBlocks {
+				bc.On("GetSignedBlockBySeq", matchDBTx, seq).Return(&blocks[seq], nil)
+			}
+			bc.On("HeadSeq", matchDBTx).Return(headSeq, true, nil)
+
+			db, shutdown := prepareDB(t)
+			defer shutdown()
+
+			v := &Visor{
+				db:         db,
+				history:    his,
+				blockchain: bc,
+			}
+
+			txns, err := v.GetAddressTransactionsInBlockRange(tc.addrs, tc.start, tc.end)
+			require.Equal(t, tc.err, err)
+			if err != nil {
+				return
+			}
+			require.Equal(t, tc.txns, txns)
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package visor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+// AddressExplorerOptions selects the sections of an AddressExplorer.
+// Each section has its own cost, explorers skip the sections that a page does not show.
+type AddressExplorerOptions struct {
+	// Balance returns the confirmed balance
+	Balance bool
+	// Outputs returns the number of confirmed unspent outputs and the TopOutputs outputs with the most coins
+	Outputs    bool
+	TopOutputs int
+	// Pending returns the unconfirmed incoming and outgoing outputs
+	Pending bool
+	// Transactions returns the Limit most recent confirmed transactions
+	Transactions bool
+	Limit        int
+	// Activity returns when the address was first seen and last active
+	Activity bool
+}
+
+// AddressExplorer is the data of the address page of a block explorer.
+// Sections that were not selected in the AddressExplorerOptions are nil.
+type AddressExplorer struct {
+	Address  cipher.Address
+	HeadSeq  uint64
+	HeadTime uint64
+
+	Balance      *wallet.Balance
+	Outputs      *AddressExplorerOutputs
+	Pending      *AddressExplorerPending
+	Transactions *AddressExplorerTransactions
+	Activity     *AddressActivity
+}
+
+// AddressExplorerOutputs are the confirmed unspent outputs of an address
+type AddressExplorerOutputs struct {
+	Count uint64
+	// Top are the outputs with the most coins, ties are broken by the lowest hash
+	Top []UnspentOutput
+}
+
+// AddressExplorerPending are the outputs of an address involved in unconfirmed transactions
+type AddressExplorerPending struct {
+	// Incoming are the outputs created for the address by unconfirmed transactions
+	Incoming []UnspentOutput
+	// Outgoing are the confirmed outputs of the address spent by unconfirmed transactions
+	Outgoing []UnspentOutput
+}
+
+// AddressExplorerTransactions are the most recent confirmed transactions of an address
+type AddressExplorerTransactions struct {
+	// Total is the number of confirmed transactions of the address
+	Total uint64
+	// Recent are the most recent transactions, the most recent first
+	Recent []AddressTransactionSummary
+}
+
+// AddressTransactionSummary summarizes a confirmed transaction from the point of view of an address
+type AddressTransactionSummary struct {
+	Transaction
+	// Received is the number of coins sent to the address
+	Received uint64
+	// Sent is the number of coins spent from the address
+	Sent uint64
+}
+
+// AddressActivity is the first and last confirmed transactions involving an address
+type AddressActivity struct {
+	FirstSeenSeq   uint64
+	FirstSeenTime  uint64
+	LastActiveSeq  uint64
+	LastActiveTime uint64
+}
+
+// GetAddressExplorer returns the sections of an address page selected by opts,
+// reading them in a single database transaction so that they are consistent with each other.
+func (vs *Visor) GetAddressExplorer(addr cipher.Address, opts AddressExplorerOptions) (*AddressExplorer, error) {
+	var ae *AddressExplorer
+
+	if err := vs.view("GetAddressExplorer", func(tx *dbutil.Tx) error {
+		head, err := vs.blockchain.Head(tx)
+		if err != nil {
+			return err
+		}
+
+		ae = &AddressExplorer{
+			Address:  addr,
+			HeadSeq:  head.Head.BkSeq,
+			HeadTime: head.Time(),
+		}
+
+		if opts.Balance || opts.Outputs {
+			if err := vs.addressExplorerUnspents(tx, ae, opts); err != nil {
+				return err
+			}
+		}
+
+		if opts.Pending {
+			if err := vs.addressExplorerPending(tx, ae); err != nil {
+				return err
+			}
+		}
+
+		if opts.Transactions || opts.Activity {
+			return vs.addressExplorerHistory(tx, ae, opts)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ae, nil
+}
+
+func (vs *Visor) addressExplorerUnspents(tx *dbutil.Tx, ae *AddressExplorer, opts AddressExplorerOptions) error {
+	auxs, err := vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, []cipher.Address{ae.Address})
+	if err != nil {
+		return err
+	}
+	uxa := auxs[ae.Address]
+
+	if opts.Balance {
+		var s AddressOutputsSummary
+		for i := range uxa {
+			if err := s.add(&uxa[i], ae.HeadTime); err != nil {
+				return err
+			}
+		}
+
+		ae.Balance = &wallet.Balance{
+			Coins: s.Coins,
+			Hours: s.Hours,
+		}
+	}
+
+	if opts.Outputs {
+		top := append(coin.UxArray{}, uxa...)
+		sort.Slice(top, func(i, j int) bool {
+			if top[i].Body.Coins != top[j].Body.Coins {
+				return top[i].Body.Coins > top[j].Body.Coins
+			}
+			hi := top[i].Hash()
+			hj := top[j].Hash()
+			return bytes.Compare(hi[:], hj[:]) < 0
+		})
+
+		if opts.TopOutputs < len(top) {
+			top = top[:opts.TopOutputs]
+		}
+
+		outs, err := NewUnspentOutputs(top, ae.HeadTime)
+		if err != nil {
+			return err
+		}
+
+		ae.Outputs = &AddressExplorerOutputs{
+			Count: uint64(len(uxa)),
+			Top:   outs,
+		}
+	}
+
+	return nil
+}
+
+func (vs *Visor) addressExplorerPending(tx *dbutil.Tx, ae *AddressExplorer) error {
+	incoming, err := vs.unconfirmed.GetUnspentsOfAddr(tx, ae.Address)
+	if err != nil {
+		return err
+	}
+
+	outgoing, err := vs.unconfirmedSpendsOfAddresses(tx, []cipher.Address{ae.Address})
+	if err != nil {
+		return err
+	}
+
+	ae.Pending = &AddressExplorerPending{}
+
+	ae.Pending.Incoming, err = NewUnspentOutputs(incoming, ae.HeadTime)
+	if err != nil {
+		return err
+	}
+
+	ae.Pending.Outgoing, err = NewUnspentOutputs(outgoing[ae.Address], ae.HeadTime)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (vs *Visor) addressExplorerHistory(tx *dbutil.Tx, ae *AddressExplorer, opts AddressExplorerOptions) error {
+	txns, err := vs.history.GetTransactionsForAddress(tx, ae.Address)
+	if err != nil {
+		return err
+	}
+
+	// The address index lists the transactions in the order of their blocks
+	blockTime := func(seq uint64) (uint64, error) {
+		b, err := vs.blockchain.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return 0, err
+		}
+		if b == nil {
+			return 0, fmt.Errorf("block seq=%d doesn't exist", seq)
+		}
+		return b.Time(), nil
+	}
+
+	if opts.Activity && len(txns) > 0 {
+		first := txns[0].BlockSeq
+		last := txns[len(txns)-1].BlockSeq
+
+		firstTime, err := blockTime(first)
+		if err != nil {
+			return err
+		}
+
+		lastTime, err := blockTime(last)
+		if err != nil {
+			return err
+		}
+
+		ae.Activity = &AddressActivity{
+			FirstSeenSeq:   first,
+			FirstSeenTime:  firstTime,
+			LastActiveSeq:  last,
+			LastActiveTime: lastTime,
+		}
+	}
+
+	if !opts.Transactions {
+		return nil
+	}
+
+	ae.Transactions = &AddressExplorerTransactions{
+		Total: uint64(len(txns)),
+	}
+
+	n := len(txns)
+	if opts.Limit < n {
+		n = opts.Limit
+	}
+
+	for i := len(txns) - 1; i >= len(txns)-n; i-- {
+		txn := txns[i]
+
+		if txn.BlockSeq > ae.HeadSeq {
+			return fmt.Errorf("transaction %s block seq %d is greater than the head block seq %d", txn.Hash().Hex(), txn.BlockSeq, ae.HeadSeq)
+		}
+
+		t, err := blockTime(txn.BlockSeq)
+		if err != nil {
+			return err
+		}
+
+		s := AddressTransactionSummary{
+			Transaction: Transaction{
+				Transaction: txn.Txn,
+				Status:      NewConfirmedTransactionStatus(ae.HeadSeq-txn.BlockSeq+1, txn.BlockSeq),
+				Time:        t,
+			},
+		}
+
+		for _, o := range txn.Txn.Out {
+			if o.Address == ae.Address {
+				if s.Received, err = mathutil.AddUint64(s.Received, o.Coins); err != nil {
+					return err
+				}
+			}
+		}
+
+		inputs, err := vs.history.GetUxOuts(tx, txn.Txn.In)
+		if err != nil {
+			return err
+		}
+
+		for _, in := range inputs {
+			if in.Out.Body.Address == ae.Address {
+				if s.Sent, err = mathutil.AddUint64(s.Sent, in.Out.Body.Coins); err != nil {
+					return err
+				}
+			}
+		}
+
+		ae.Transactions.Recent = append(ae.Transactions.Recent, s)
+	}
+
+	return nil
+}
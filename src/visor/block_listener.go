@@ -0,0 +1,164 @@
+package visor
+
+import (
+	"sync"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// BlockListener is called after a block is executed, with the outputs spent by each of its transactions.
+// Listeners are called in order of execution, outside of the database transaction.
+// An error is logged and does not undo the block.
+type BlockListener func(b coin.SignedBlock, inputs [][]coin.UxOut) error
+
+// blockListeners are the listeners of executed blocks
+type blockListeners struct {
+	sync.RWMutex
+	listeners []BlockListener
+}
+
+func (l *blockListeners) add(f BlockListener) {
+	l.Lock()
+	defer l.Unlock()
+	l.listeners = append(l.listeners, f)
+}
+
+func (l *blockListeners) get() []BlockListener {
+	if l == nil {
+		return nil
+	}
+
+	l.RLock()
+	defer l.RUnlock()
+	return l.listeners
+}
+
+// AddBlockListener registers a listener called after each executed block
+func (vs *Visor) AddBlockListener(f BlockListener) {
+	vs.blockListeners.add(f)
+}
+
+// executedBlockInputs returns the outputs spent by each transaction of a block that was just executed,
+// or nil if there are no block listeners
+func (vs *Visor) executedBlockInputs(tx *dbutil.Tx, b *coin.SignedBlock) ([][]coin.UxOut, error) {
+	if len(vs.blockListeners.get()) == 0 {
+		return nil, nil
+	}
+
+	inputs, err := vs.getBlockInputs(tx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	uxa := make([][]coin.UxOut, len(inputs))
+	for i, txnInputs := range inputs {
+		uxa[i] = make([]coin.UxOut, len(txnInputs))
+		for j, in := range txnInputs {
+			uxa[i][j] = in.UxOut
+		}
+	}
+
+	return uxa, nil
+}
+
+// notifyBlockListeners updates the wallet balance cache, calls the block listeners
+// and notifies the block subscriptions with an executed block
+func (vs *Visor) notifyBlockListeners(b coin.SignedBlock, inputs [][]coin.UxOut) {
+	vs.walletBalances.applyBlock(b)
+
+	for _, f := range vs.blockListeners.get() {
+		if err := f(b, inputs); err != nil {
+			logger.WithError(err).WithField("seq", b.Seq()).Error("Block listener failed")
+		}
+	}
+
+	vs.blockSubscriptions.Notify(b.Seq())
+}
+
+// BlockSubscription receives the seqs of executed blocks in a bounded buffer.
+// A subscriber that falls behind by more than the buffer size is dropped instead of
+// blocking block execution: its channel is closed and Overflowed returns true.
+type BlockSubscription struct {
+	c          chan uint64
+	subs       *BlockSubscriptions
+	overflowed bool
+}
+
+// C returns the channel of executed block seqs, which is closed when the subscription is closed or overflows
+func (s *BlockSubscription) C() <-chan uint64 {
+	return s.c
+}
+
+// Overflowed returns true if the subscription was closed because its buffer was full
+func (s *BlockSubscription) Overflowed() bool {
+	s.subs.Lock()
+	defer s.subs.Unlock()
+	return s.overflowed
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *BlockSubscription) Close() {
+	s.subs.remove(s)
+}
+
+// BlockSubscriptions is a set of block subscriptions notified of executed blocks.
+// The zero value is ready to use.
+type BlockSubscriptions struct {
+	sync.Mutex
+	subs map[*BlockSubscription]struct{}
+}
+
+// Subscribe adds a subscription that buffers up to bufferSize seqs
+func (l *BlockSubscriptions) Subscribe(bufferSize int) *BlockSubscription {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.subs == nil {
+		l.subs = make(map[*BlockSubscription]struct{})
+	}
+
+	s := &BlockSubscription{
+		c:    make(chan uint64, bufferSize),
+		subs: l,
+	}
+	l.subs[s] = struct{}{}
+	return s
+}
+
+func (l *BlockSubscriptions) remove(s *BlockSubscription) {
+	l.Lock()
+	defer l.Unlock()
+
+	if _, ok := l.subs[s]; ok {
+		delete(l.subs, s)
+		close(s.c)
+	}
+}
+
+// Notify sends seq to the subscriptions without blocking, closing the subscriptions whose buffer is full
+func (l *BlockSubscriptions) Notify(seq uint64) {
+	if l == nil {
+		return
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	for s := range l.subs {
+		select {
+		case s.c <- seq:
+		default:
+			s.overflowed = true
+			delete(l.subs, s)
+			close(s.c)
+			logger.WithField("seq", seq).Warning("Block subscription buffer is full, closing the subscription")
+		}
+	}
+}
+
+// SubscribeBlocks subscribes to the seqs of executed blocks. Up to bufferSize seqs are buffered,
+// if the subscriber falls further behind the subscription is closed. The subscriber must call Close when done.
+func (vs *Visor) SubscribeBlocks(bufferSize int) *BlockSubscription {
+	return vs.blockSubscriptions.Subscribe(bufferSize)
+}
@@ -0,0 +1,146 @@
+package visor
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// BlockStage is a stage of applying a block to the blockchain
+type BlockStage string
+
+const (
+	// BlockStageVerifySigs verifies the block signature
+	BlockStageVerifySigs BlockStage = "verify_sigs"
+	// BlockStageVerifyConstraints verifies the block header, its transactions and the uxhash
+	BlockStageVerifyConstraints BlockStage = "verify_constraints"
+	// BlockStageUpdateUnspents adds the block to the chain and updates the unspent pool
+	BlockStageUpdateUnspents BlockStage = "update_unspents"
+	// BlockStageUpdateUnconfirmed removes the block's transactions and their conflicts from the unconfirmed pool
+	BlockStageUpdateUnconfirmed BlockStage = "update_unconfirmed"
+	// BlockStageUpdateHistory indexes the block in the historydb
+	BlockStageUpdateHistory BlockStage = "update_history"
+	// BlockStageFsync commits the database transaction
+	BlockStageFsync BlockStage = "fsync"
+)
+
+var promBlockStageDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "visor_block_stage_duration_seconds",
+		Help:    "Time spent applying a block to the blockchain, by stage",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 18),
+	}, []string{"stage"})
+
+var promBlockDuration = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "visor_block_duration_seconds",
+		Help:    "Time spent applying a block to the blockchain",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 18),
+	})
+
+func init() {
+	prometheus.MustRegister(promBlockStageDuration, promBlockDuration)
+}
+
+// BlockStageTiming is the time spent in a BlockStage
+type BlockStageTiming struct {
+	Stage    BlockStage
+	Duration time.Duration
+}
+
+// BlockStageTimer records the time spent in each stage of applying a block.
+// The methods of a nil *BlockStageTimer run the stages without recording them.
+type BlockStageTimer struct {
+	start   time.Time
+	timings []BlockStageTiming
+}
+
+// NewBlockStageTimer creates a BlockStageTimer, starting its total time
+func NewBlockStageTimer() *BlockStageTimer {
+	return &BlockStageTimer{
+		start: time.Now(),
+	}
+}
+
+// Run runs the stage f and records its duration. The error returned by f is returned unchanged,
+// and the duration is recorded whether or not f fails.
+func (t *BlockStageTimer) Run(stage BlockStage, f func() error) error {
+	if t == nil {
+		return f()
+	}
+
+	start := time.Now()
+	err := f()
+	t.Add(stage, time.Since(start))
+	return err
+}
+
+// Add records the duration of a stage that was timed by the caller
+func (t *BlockStageTimer) Add(stage BlockStage, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.timings = append(t.timings, BlockStageTiming{
+		Stage:    stage,
+		Duration: d,
+	})
+}
+
+// Timings returns the recorded stage durations, in the order the stages ran
+func (t *BlockStageTimer) Timings() []BlockStageTiming {
+	if t == nil {
+		return nil
+	}
+	return t.timings
+}
+
+// Elapsed returns the time since the timer was created
+func (t *BlockStageTimer) Elapsed() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Since(t.start)
+}
+
+// observeBlockStages records the stage durations of applying b in the metrics registry,
+// and logs a breakdown of the stages if applying b took longer than traceSlow.
+// traceSlow of 0 disables the logging.
+func observeBlockStages(t *BlockStageTimer, b coin.SignedBlock, traceSlow time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	elapsed := t.Elapsed()
+	for _, s := range t.timings {
+		promBlockStageDuration.WithLabelValues(string(s.Stage)).Observe(s.Duration.Seconds())
+	}
+	promBlockDuration.Observe(elapsed.Seconds())
+
+	if traceSlow == 0 || elapsed < traceSlow {
+		return
+	}
+
+	fields := logrus.Fields{
+		"seq":     b.Seq(),
+		"hash":    b.HashHeader().Hex(),
+		"txns":    len(b.Body.Transactions),
+		"totalMs": durationMs(elapsed),
+	}
+	for _, s := range t.timings {
+		fields[string(s.Stage)+"Ms"] = durationMs(s.Duration)
+	}
+
+	l := logger.WithFields(fields)
+	if err != nil {
+		l = l.WithError(err)
+	}
+	l.Warning("Slow block")
+}
+
+// durationMs returns d in milliseconds, with microsecond precision
+func durationMs(d time.Duration) float64 {
+	return float64(d/time.Microsecond) / 1000
+}
@@ -0,0 +1,65 @@
+package visor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+func TestBlockStageTimer(t *testing.T) {
+	errStage := errors.New("stage failed")
+
+	// A nil timer runs the stages without recording them
+	var nilTimer *BlockStageTimer
+	ran := false
+	err := nilTimer.Run(BlockStageVerifySigs, func() error {
+		ran = true
+		return errStage
+	})
+	require.True(t, ran)
+	require.Equal(t, errStage, err)
+	nilTimer.Add(BlockStageFsync, time.Second)
+	require.Nil(t, nilTimer.Timings())
+	require.Equal(t, time.Duration(0), nilTimer.Elapsed())
+	observeBlockStages(nilTimer, coin.SignedBlock{}, time.Nanosecond, nil)
+
+	timer := NewBlockStageTimer()
+	err = timer.Run(BlockStageVerifySigs, func() error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The error of a stage is returned unchanged and its duration is recorded
+	err = timer.Run(BlockStageVerifyConstraints, func() error {
+		return errStage
+	})
+	require.Equal(t, errStage, err)
+
+	timer.Add(BlockStageFsync, time.Second)
+
+	timings := timer.Timings()
+	require.Len(t, timings, 3)
+	require.Equal(t, BlockStageVerifySigs, timings[0].Stage)
+	require.True(t, timings[0].Duration >= time.Millisecond)
+	require.Equal(t, BlockStageVerifyConstraints, timings[1].Stage)
+	require.Equal(t, BlockStageTiming{
+		Stage:    BlockStageFsync,
+		Duration: time.Second,
+	}, timings[2])
+	require.True(t, timer.Elapsed() >= timings[0].Duration)
+
+	// Observing a slow block, with and without an error
+	observeBlockStages(timer, coin.SignedBlock{}, time.Nanosecond, nil)
+	observeBlockStages(timer, coin.SignedBlock{}, time.Nanosecond, errStage)
+}
+
+func TestDurationMs(t *testing.T) {
+	require.Equal(t, 1.5, durationMs(1500*time.Microsecond))
+	require.Equal(t, 0.001, durationMs(1999*time.Nanosecond))
+	require.Equal(t, 2000.0, durationMs(2*time.Second))
+}
@@ -9,10 +9,13 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/coin/invariants"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 const (
@@ -98,6 +101,10 @@ func CreateBuckets(db *dbutil.DB) error {
 		return dbutil.CreateBuckets(tx, [][]byte{
 			UnconfirmedTxnsBkt,
 			UnconfirmedUnspentsBkt,
+			UnconfirmedSpendsBkt,
+			UnconfirmedOriginsBkt,
+			UnconfirmedAbandonedBkt,
+			UnconfirmedAnnouncedPeersBkt,
 		})
 	})
 }
@@ -109,6 +116,15 @@ type BlockchainConfig struct {
 	// node will throw the error and return.
 	Arbitrating bool
 	Pubkey      cipher.PubKey
+	// BurnFactorSchedule changes the user burn factor at scheduled block times, see NewBurnPolicy.
+	// It must be sorted by time.
+	BurnFactorSchedule []fee.BurnFactorChange
+	// GenesisOutputs are the outputs of a multi-output genesis block, empty if the genesis block has a single output
+	GenesisOutputs []coin.TransactionOutput
+	// GenesisCoinVolume is the coin supply of the genesis block, that the GenesisOutputs must sum to
+	GenesisCoinVolume uint64
+	// Paranoid checks the coin invariants of every executed block, see coin/invariants
+	Paranoid bool
 }
 
 // Blockchain maintains blockchain and provides apis for accessing the chain.
@@ -260,34 +276,83 @@ func (bc *Blockchain) processBlock(tx *dbutil.Tx, b coin.SignedBlock) (coin.Sign
 			}
 
 		}
+	} else if err := verifyGenesisBlock(b.Block, bc.cfg.GenesisOutputs, bc.cfg.GenesisCoinVolume); err != nil {
+		return coin.SignedBlock{}, err
 	}
 
 	return b, nil
 }
 
-// ExecuteBlock attempts to append block to blockchain with *dbutil.Tx
-func (bc *Blockchain) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
-	length, err := bc.Len(tx)
-	if err != nil {
+// ExecuteBlock attempts to append block to blockchain with *dbutil.Tx.
+// The durations of the verify constraints and update unspents stages are recorded in t, which may be nil.
+func (bc *Blockchain) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock, t *BlockStageTimer) error {
+	var nb coin.SignedBlock
+	if err := t.Run(BlockStageVerifyConstraints, func() error {
+		length, err := bc.Len(tx)
+		if err != nil {
+			return err
+		}
+
+		if length > 0 {
+			head, err := bc.Head(tx)
+			if err != nil {
+				return err
+			}
+
+			// TODO -- why do we modify the block here?
+			sb.Head.PrevHash = head.HashHeader()
+		}
+
+		nb, err = bc.processBlock(tx, *sb)
+		return err
+	}); err != nil {
 		return err
 	}
 
-	if length > 0 {
-		head, err := bc.Head(tx)
-		if err != nil {
-			return err
+	return t.Run(BlockStageUpdateUnspents, func() error {
+		if !bc.cfg.Paranoid {
+			return bc.store.AddBlock(tx, &nb)
 		}
 
-		// TODO -- why do we modify the block here?
-		sb.Head.PrevHash = head.HashHeader()
+		return bc.addBlockParanoid(tx, &nb)
+	})
+}
+
+// addBlockParanoid adds a block to the store, checking the coin invariants of the block
+// against the unspent pool before and after it
+func (bc *Blockchain) addBlockParanoid(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	var prev *coin.Block
+	if head, err := bc.Head(tx); err != nil && err != blockdb.ErrNoHeadBlock {
+		return err
+	} else if head != nil {
+		prev = &head.Block
 	}
 
-	nb, err := bc.processBlock(tx, *sb)
+	before, err := bc.Unspent().GetAll(tx)
 	if err != nil {
 		return err
 	}
 
-	if err := bc.store.AddBlock(tx, &nb); err != nil {
+	if err := bc.store.AddBlock(tx, b); err != nil {
+		return err
+	}
+
+	after, err := bc.Unspent().GetAll(tx)
+	if err != nil {
+		return err
+	}
+
+	uxHash, err := bc.Unspent().GetUxHash(tx)
+	if err != nil {
+		return err
+	}
+
+	err = invariants.CheckBlock(prev, &b.Block, before, after)
+	if err == nil && uxHash != invariants.UxHash(after) {
+		err = fmt.Errorf("block %d: unspent pool hash %s does not match its outputs", b.Head.BkSeq, uxHash.Hex())
+	}
+	if err != nil {
+		logger.Critical().WithError(err).WithField("seq", b.Head.BkSeq).Error("Block violates the coin invariants, not applying it")
 		return err
 	}
 
@@ -422,7 +487,7 @@ func (bc Blockchain) VerifySingleTxnSoftHardConstraints(tx *dbutil.Tx, txn coin.
 		return nil, nil, err
 	}
 
-	if err := VerifySingleTxnSoftConstraints(txn, head.Time(), uxIn, distParams, verifyParams); err != nil {
+	if err := VerifySingleTxnSoftConstraintsWithPolicy(txn, head.Time(), uxIn, distParams, verifyParams, NewBurnPolicy(verifyParams, bc.cfg.BurnFactorSchedule)); err != nil {
 		return nil, nil, err
 	}
 
@@ -635,35 +700,37 @@ func (bc Blockchain) processTransactions(tx *dbutil.Tx, txs coin.Transactions) (
 		skip = make(map[int]struct{})
 	}
 
-	// Check to ensure that there are no duplicate spends in the entire block,
-	// and that we aren't creating duplicate outputs.  Duplicate outputs
-	// within a single Transaction are already checked by VerifyBlockTxnConstraints
-	hashes := txns.Hashes()
-	for i := 0; i < len(txns)-1; i++ {
-		s := txns[i]
-		for j := i + 1; j < len(txns); j++ {
-			t := txns[j]
-			if DebugLevel1 {
-				if hashes[i] == hashes[j] {
-					// This is a non-recoverable error for filtering, and
-					// should never occur.  It indicates a hash collision
-					// amongst different txns. Duplicate transactions are
-					// caught earlier, when duplicate expected outputs are
-					// checked for, and will not trigger this.
-					return nil, errors.New("Unexpected duplicate transaction")
+	// Check to ensure that there are no duplicate transactions or duplicate spends
+	// in the entire block. Duplicate outputs within a single Transaction are already
+	// checked by VerifyBlockTxnConstraints
+	if !bc.cfg.Arbitrating {
+		if err := validateBlockTxns(txns); err != nil {
+			return nil, err
+		}
+	} else {
+		hashes := txns.Hashes()
+		for i := 0; i < len(txns)-1; i++ {
+			s := txns[i]
+			for j := i + 1; j < len(txns); j++ {
+				t := txns[j]
+				if DebugLevel1 {
+					if hashes[i] == hashes[j] {
+						// This is a non-recoverable error for filtering, and
+						// should never occur.  It indicates a hash collision
+						// amongst different txns. Duplicate transactions are
+						// caught earlier, when duplicate expected outputs are
+						// checked for, and will not trigger this.
+						return nil, errors.New("Unexpected duplicate transaction")
+					}
 				}
-			}
-			for a := range s.In {
-				for b := range t.In {
-					if s.In[a] == t.In[b] {
-						if bc.cfg.Arbitrating {
+				for a := range s.In {
+					for b := range t.In {
+						if s.In[a] == t.In[b] {
 							// The txn with the highest fee and lowest hash
 							// is chosen when attempting a double spend.
 							// Since the txns are sorted, we skip the 2nd
 							// iterable
 							skip[j] = struct{}{}
-						} else {
-							return nil, errors.New("Cannot spend output twice in the same block")
 						}
 					}
 				}
@@ -685,6 +752,28 @@ func (bc Blockchain) processTransactions(tx *dbutil.Tx, txs coin.Transactions) (
 	return txns, nil
 }
 
+// validateBlockTxns checks the transactions of a block against each other with coin.Transactions.Validate
+func validateBlockTxns(txns coin.Transactions) error {
+	ptxns := make(pcoin.Transactions, len(txns))
+	for i, txn := range txns {
+		ptxns[i] = toPcoinTransaction(txn)
+	}
+
+	return ptxns.Validate()
+}
+
+// toPcoinTransaction converts a coin.Transaction to a pcoin.Transaction, sharing its slices
+func toPcoinTransaction(txn coin.Transaction) pcoin.Transaction {
+	return pcoin.Transaction{
+		Length:    txn.Length,
+		Type:      txn.Type,
+		InnerHash: txn.InnerHash,
+		Sigs:      txn.Sigs,
+		In:        txn.In,
+		Out:       toPcoinOutputs(txn.Out),
+	}
+}
+
 // TransactionFee calculates the current transaction fee in coinhours of a Transaction
 func (bc Blockchain) TransactionFee(tx *dbutil.Tx, headTime uint64) coin.FeeCalculator {
 	return func(txn *coin.Transaction) (uint64, error) {
@@ -702,7 +791,7 @@ func (bc Blockchain) TransactionFee(tx *dbutil.Tx, headTime uint64) coin.FeeCalc
 func (bc *Blockchain) VerifySignature(block *coin.SignedBlock) error {
 	err := block.VerifySignature(bc.cfg.Pubkey)
 	if err != nil {
-		logger.Errorf("Blockchain signature verification failed for block %d: %v", block.Head.BkSeq, err)
+		logger.WithError(err).WithField("seq", block.Head.BkSeq).Error("Blockchain signature verification failed")
 	}
 	return err
 }
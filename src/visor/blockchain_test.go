@@ -2,6 +2,7 @@ package visor
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,6 +12,9 @@ import (
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/coin/invariants"
 )
 
 var (
@@ -597,7 +601,10 @@ func TestProcessTransactions(t *testing.T) {
 					Coins:   10e6,
 				},
 			},
-			errors.New("Cannot spend output twice in the same block"),
+			// Hash and Err are filled in once the transactions are created
+			pcoin.TransactionError{
+				Index: 1,
+			},
 		},
 		{
 			"arbitrating no transactions",
@@ -695,9 +702,17 @@ func TestProcessTransactions(t *testing.T) {
 				txns[i] = txn
 			}
 
+			// The transaction spending the output a second time is rejected
+			expectedErr := tc.err
+			if e, ok := tc.err.(pcoin.TransactionError); ok {
+				e.Hash = txns[e.Index].Hash()
+				e.Err = fmt.Errorf("Output %s is already spent by transaction 0", txns[e.Index].In[0].Hex())
+				expectedErr = e
+			}
+
 			err = db.View("", func(tx *dbutil.Tx) error {
 				_, err := bc.processTransactions(tx, txns)
-				require.EqualValues(t, tc.err, err)
+				require.EqualValues(t, expectedErr, err)
 				return nil
 			})
 			require.NoError(t, err)
@@ -839,7 +854,7 @@ func TestExecuteBlock(t *testing.T) {
 
 	// test with empty chain
 	err = db.Update("", func(tx *dbutil.Tx) error {
-		err := bc.ExecuteBlock(tx, &sb)
+		err := bc.ExecuteBlock(tx, &sb, nil)
 		require.NoError(t, err)
 		return nil
 	})
@@ -852,12 +867,146 @@ func TestExecuteBlock(t *testing.T) {
 	uxHash := getUxHash(t, db, bc)
 	b, err := coin.NewBlock(*gb, genTime+100, uxHash, coin.Transactions{tx}, feeCalc)
 	require.NoError(t, err)
+	sb2 := coin.SignedBlock{
+		Block: *b,
+		Sig:   cipher.MustSignHash(b.HashHeader(), genSecret),
+	}
+
+	timer := NewBlockStageTimer()
 	err = db.Update("", func(tx *dbutil.Tx) error {
-		err := bc.ExecuteBlock(tx, &coin.SignedBlock{
-			Block: *b,
-			Sig:   cipher.MustSignHash(b.HashHeader(), genSecret),
-		})
+		err := bc.ExecuteBlock(tx, &sb2, timer)
+		require.NoError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	stages := make([]BlockStage, 0, len(timer.Timings()))
+	for _, s := range timer.Timings() {
+		stages = append(stages, s.Stage)
+	}
+	require.Equal(t, []BlockStage{BlockStageVerifyConstraints, BlockStageUpdateUnspents}, stages)
+
+	// Executing the block again fails verification with the same error with or without a timer,
+	// and stops after the verify constraints stage
+	var errNoTimer error
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		sb := sb2
+		errNoTimer = bc.ExecuteBlock(tx, &sb, nil)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Error(t, errNoTimer)
+
+	timer = NewBlockStageTimer()
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		sb := sb2
+		err := bc.ExecuteBlock(tx, &sb, timer)
+		require.Equal(t, errNoTimer, err)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, timer.Timings(), 1)
+	require.Equal(t, BlockStageVerifyConstraints, timer.Timings()[0].Stage)
+}
+
+// lossyChainStore loses the last output of the unspent pool returned by GetAll once lose is set
+type lossyChainStore struct {
+	chainStore
+	lose bool
+}
+
+func (s *lossyChainStore) UnspentPool() blockdb.UnspentPooler {
+	return lossyUnspentPool{
+		UnspentPooler: s.chainStore.UnspentPool(),
+		lose:          s.lose,
+	}
+}
+
+type lossyUnspentPool struct {
+	blockdb.UnspentPooler
+	lose bool
+}
+
+func (p lossyUnspentPool) GetAll(tx *dbutil.Tx) (coin.UxArray, error) {
+	uxs, err := p.UnspentPooler.GetAll(tx)
+	if err != nil || !p.lose || len(uxs) == 0 {
+		return uxs, err
+	}
+	return uxs[:len(uxs)-1], nil
+}
+
+func TestExecuteBlockParanoid(t *testing.T) {
+	db, closeDB := prepareDB(t)
+	defer closeDB()
+
+	err := CreateBuckets(db)
+	require.NoError(t, err)
+
+	store, err := blockdb.NewBlockchain(db, DefaultWalker)
+	require.NoError(t, err)
+
+	lossy := &lossyChainStore{chainStore: store}
+	bc := &Blockchain{
+		db:    db,
+		store: lossy,
+		cfg: BlockchainConfig{
+			Paranoid: true,
+		},
+	}
+
+	gb, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
+	require.NoError(t, err)
+
+	sb := coin.SignedBlock{
+		Block: *gb,
+		Sig:   cipher.MustSignHash(gb.HashHeader(), genSecret),
+	}
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.ExecuteBlock(tx, &sb, nil)
+	})
+	require.NoError(t, err)
+
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6)
+	uxHash := getUxHash(t, db, bc)
+	b, err := coin.NewBlock(*gb, genTime+100, uxHash, coin.Transactions{txn}, feeCalc)
+	require.NoError(t, err)
+	sb2 := coin.SignedBlock{
+		Block: *b,
+		Sig:   cipher.MustSignHash(b.HashHeader(), genSecret),
+	}
+
+	// A block that the unspent pool does not match is not applied
+	lossy.lose = true
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		sb := sb2
+		return bc.ExecuteBlock(tx, &sb, nil)
+	})
+	require.Error(t, err)
+	_, ok := err.(invariants.Error)
+	require.True(t, ok, err.Error())
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		length, err := bc.Len(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), length)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The block satisfies the invariants
+	lossy.lose = false
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		sb := sb2
+		return bc.ExecuteBlock(tx, &sb, nil)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		length, err := bc.Len(tx)
 		require.NoError(t, err)
+		require.Equal(t, uint64(2), length)
 		return nil
 	})
 	require.NoError(t, err)
@@ -15,6 +15,8 @@ import (
 	"github.com/skycoin/skycoin/src/util/mathutil"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 const (
@@ -59,7 +61,7 @@ func MakeBlockchain(t *testing.T, db *dbutil.DB, seckey cipher.SecKey) *Blockcha
 		return b.ExecuteBlock(tx, &coin.SignedBlock{
 			Block: *gb,
 			Sig:   sig,
-		})
+		}, nil)
 	})
 	require.NoError(t, err)
 	return b
@@ -110,7 +112,7 @@ func ExecuteGenesisSpendTransaction(t *testing.T, db *dbutil.DB, bc *Blockchain,
 	}
 
 	err = db.Update("", func(tx *dbutil.Tx) error {
-		err = bc.ExecuteBlock(tx, &sb)
+		err = bc.ExecuteBlock(tx, &sb, nil)
 		require.NoError(t, err)
 		return nil
 	})
@@ -531,6 +533,81 @@ func testVerifyTransactionSoftHardConstraints(t *testing.T, signed TxnSignedFlag
 	requireHardViolation(t, "Duplicate output in transaction", err)
 }
 
+func TestVerifySingleTxnSoftHardConstraintsBurnFactorSchedule(t *testing.T) {
+	db, closeDB := prepareDB(t)
+	defer closeDB()
+
+	err := CreateBuckets(db)
+	require.NoError(t, err)
+
+	store, err := blockdb.NewBlockchain(db, DefaultWalker)
+	require.NoError(t, err)
+
+	bc := &Blockchain{
+		db:    db,
+		store: store,
+	}
+
+	gb := addGenesisBlockToBlockchain(t, bc)
+
+	// Burn the minimum fee required by the default burn factor
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	var hours uint64
+	for _, ux := range uxs {
+		hours += ux.Body.Hours
+	}
+	minFee := fee.RequiredFee(hours, params.UserVerifyTxn.BurnFactor)
+	txn := makeSpendTxWithHoursBurned(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6, minFee)
+
+	verify := func() error {
+		return db.View("", func(tx *dbutil.Tx) error {
+			_, _, err := bc.VerifySingleTxnSoftHardConstraints(tx, txn, params.MainNetDistribution, params.UserVerifyTxn, TxnSigned)
+			return err
+		})
+	}
+
+	require.NoError(t, verify())
+
+	// A scheduled change has no effect before the head reaches its time
+	bc.cfg.BurnFactorSchedule = []fee.BurnFactorChange{
+		{Time: gb.Time() + 1, BurnFactor: 2},
+	}
+	require.NoError(t, verify())
+
+	// Once the head reaches its time, the scheduled burn factor applies
+	bc.cfg.BurnFactorSchedule = []fee.BurnFactorChange{
+		{Time: gb.Time(), BurnFactor: 2},
+	}
+	requireSoftViolation(t, "Transaction coinhour fee minimum not met", verify())
+}
+
+func TestNewBurnPolicy(t *testing.T) {
+	user := params.UserVerifyTxn
+	unconfirmed := params.UserVerifyTxn
+	unconfirmed.BurnFactor = user.BurnFactor * 2
+
+	schedule := []fee.BurnFactorChange{
+		{Time: 100, BurnFactor: user.BurnFactor / 2},
+		{Time: 200, BurnFactor: math.MaxUint32},
+	}
+
+	// Without a schedule, the burn factor of the verification params applies
+	p := NewBurnPolicy(unconfirmed, nil)
+	require.Equal(t, fee.NewConstantBurnPolicy(unconfirmed.BurnFactor), p)
+
+	// The scheduled changes apply to the user burn factor
+	p = NewBurnPolicy(user, schedule)
+	require.Equal(t, fee.RequiredFee(1e6, user.BurnFactor), p.RequiredFee(1e6, 99))
+	require.Equal(t, fee.RequiredFee(1e6, user.BurnFactor/2), p.RequiredFee(1e6, 100))
+	require.Equal(t, fee.RequiredFee(1e6, math.MaxUint32), p.RequiredFee(1e6, 200))
+
+	// Other burn factors keep their ratio to the user burn factor
+	p = NewBurnPolicy(unconfirmed, schedule)
+	require.Equal(t, fee.RequiredFee(1e6, unconfirmed.BurnFactor), p.RequiredFee(1e6, 99))
+	require.Equal(t, fee.RequiredFee(1e6, user.BurnFactor), p.RequiredFee(1e6, 100))
+	require.Equal(t, fee.RequiredFee(1e6, math.MaxUint32), p.RequiredFee(1e6, 200))
+}
+
 func TestVerifyTxnFeeCoinHoursAdditionFails(t *testing.T) {
 	// Test that VerifySingleTxnSoftConstraints fails if a uxIn.CoinHours() call fails.
 	// This is a separate test on its own, because it's not possible to reach the line
@@ -0,0 +1,526 @@
+package visor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+const (
+	// compactTxMaxSize is the number of bytes copied to the compacted database per transaction
+	compactTxMaxSize = 64 * 1024 * 1024
+	// compactAbortCheckInterval is the number of keys copied between checks for an abort of the compaction
+	compactAbortCheckInterval = 1000
+	// compactFileSuffix is appended to the database path to name the compacted copy until it replaces the database
+	compactFileSuffix = ".compact"
+)
+
+var (
+	// ErrDBCompactionRunning is returned when a database compaction is started while one is running
+	ErrDBCompactionRunning = errors.New("Database compaction is already running")
+	// ErrNoDBCompaction is returned when aborting a database compaction while none is running
+	ErrNoDBCompaction = errors.New("No database compaction is running")
+	// ErrDBCompactionAborted is returned by a database compaction that was aborted
+	ErrDBCompactionAborted = errors.New("Database compaction was aborted")
+	// ErrDBReadOnly is returned when compacting a read-only database
+	ErrDBReadOnly = errors.New("Database is read-only")
+
+	lastCompactionKey = []byte("last_compaction")
+)
+
+// dbGate guards the database transactions of the visor against a database compaction.
+// Updates hold writes and reads, views hold reads. A compaction holds writes while it copies
+// the database, so no block is applied during the copy, and reads while it swaps the database file.
+type dbGate struct {
+	writes sync.RWMutex
+	reads  sync.RWMutex
+}
+
+// view runs f in a db.View transaction, waiting for a database compaction to swap the database file
+func (vs *Visor) view(name string, f func(*dbutil.Tx) error) error {
+	vs.dbGate.reads.RLock()
+	defer vs.dbGate.reads.RUnlock()
+	return vs.db.View(name, f)
+}
+
+// update runs f in a db.Update transaction, waiting for a database compaction to finish
+func (vs *Visor) update(name string, f func(*dbutil.Tx) error) error {
+	vs.dbGate.writes.RLock()
+	defer vs.dbGate.writes.RUnlock()
+	vs.dbGate.reads.RLock()
+	defer vs.dbGate.reads.RUnlock()
+	return vs.db.Update(name, f)
+}
+
+// DBCompaction is the result of a database compaction
+type DBCompaction struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// SizeBefore is the size of the database file before the compaction, in bytes
+	SizeBefore int64 `json:"size_before"`
+	// SizeAfter is the size of the database file after the compaction, in bytes. 0 if the compaction failed
+	SizeAfter int64 `json:"size_after"`
+	// Error is why the compaction failed or was aborted, empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// BucketStats are the statistics of a database bucket
+type BucketStats struct {
+	Name string
+	// Keys is the number of keys in the bucket and its nested buckets
+	Keys int
+}
+
+// StorageStats are the statistics of the database
+type StorageStats struct {
+	Path string
+	// Size is the size of the database file, in bytes
+	Size    int64
+	Buckets []BucketStats
+	// Compacting is true while the database is compacted
+	Compacting bool
+	// CompactionStartedAt is when the running compaction started, zero if none is running
+	CompactionStartedAt time.Time
+	// LastCompaction is the last compaction since the node started, or the last successful compaction
+	// stored in the database. nil if the database was never compacted
+	LastCompaction *DBCompaction
+}
+
+// dbCompactionStatus holds the running and the last database compaction
+type dbCompactionStatus struct {
+	sync.Mutex
+	// cancel aborts the running compaction, nil if none is running
+	cancel    context.CancelFunc
+	startedAt time.Time
+	last      *DBCompaction
+}
+
+func (s *dbCompactionStatus) begin(cancel context.CancelFunc) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.cancel != nil {
+		return time.Time{}, ErrDBCompactionRunning
+	}
+
+	s.cancel = cancel
+	s.startedAt = time.Now().UTC()
+	return s.startedAt, nil
+}
+
+func (s *dbCompactionStatus) end(c *DBCompaction) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.cancel = nil
+	s.startedAt = time.Time{}
+	s.last = c
+}
+
+func (s *dbCompactionStatus) abort() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.cancel == nil {
+		return ErrNoDBCompaction
+	}
+
+	s.cancel()
+	return nil
+}
+
+// StorageStats returns the size of the database file, the number of keys of its buckets and the state of its compaction
+func (vs *Visor) StorageStats() (*StorageStats, error) {
+	var stats StorageStats
+	var stored *DBCompaction
+	if err := vs.view("StorageStats", func(tx *dbutil.Tx) error {
+		stats.Path = vs.db.Path()
+		fi, err := os.Stat(stats.Path)
+		if err != nil {
+			return err
+		}
+		stats.Size = fi.Size()
+
+		if err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			stats.Buckets = append(stats.Buckets, BucketStats{
+				Name: string(name),
+				Keys: b.Stats().KeyN,
+			})
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		stored, err = getLastDBCompaction(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	vs.compaction.Lock()
+	defer vs.compaction.Unlock()
+
+	stats.Compacting = vs.compaction.cancel != nil
+	stats.CompactionStartedAt = vs.compaction.startedAt
+	stats.LastCompaction = vs.compaction.last
+	if stats.LastCompaction == nil {
+		stats.LastCompaction = stored
+	}
+
+	return &stats, nil
+}
+
+// StartDBCompaction compacts the database in the background, see CompactDB.
+// The compaction can be aborted with AbortDBCompaction, its result is reported by StorageStats.
+func (vs *Visor) StartDBCompaction() error {
+	if vs.db.IsReadOnly() {
+		return ErrDBReadOnly
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	startedAt, err := vs.compaction.begin(cancel)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		if _, err := vs.compactDB(ctx, cancel, startedAt); err != nil {
+			if err == ErrDBCompactionAborted {
+				logger.Info("Database compaction was aborted")
+			} else {
+				logger.WithError(err).Error("Database compaction failed")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// AbortDBCompaction aborts the running database compaction. The database is left as it was before the compaction.
+// The compaction can't be aborted once it swaps the database file.
+func (vs *Visor) AbortDBCompaction() error {
+	return vs.compaction.abort()
+}
+
+// CompactDB copies the live buckets of the database into a new database file, which replaces the database file.
+// Pruned and churned data leaves free pages in a bolt database that are never returned to the file system, the copy has none.
+// Database writes wait while the database is copied, so the compaction never runs while a block is applied.
+// Reads only wait while the database file is swapped. The compaction is aborted if ctx is canceled before the swap.
+func (vs *Visor) CompactDB(ctx context.Context) (*DBCompaction, error) {
+	if vs.db.IsReadOnly() {
+		return nil, ErrDBReadOnly
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	startedAt, err := vs.compaction.begin(cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return vs.compactDB(ctx, cancel, startedAt)
+}
+
+func (vs *Visor) compactDB(ctx context.Context, cancel context.CancelFunc, startedAt time.Time) (*DBCompaction, error) {
+	defer cancel()
+
+	c := &DBCompaction{
+		StartedAt: startedAt,
+	}
+
+	logger.Info("Compacting the database")
+
+	err := vs.swapCompactedDB(ctx, c)
+	c.FinishedAt = time.Now().UTC()
+	if err != nil {
+		c.Error = err.Error()
+	}
+
+	vs.compaction.end(c)
+
+	if err != nil {
+		return c, err
+	}
+
+	logger.Infof("Compacted the database from %d to %d bytes in %s", c.SizeBefore, c.SizeAfter, c.FinishedAt.Sub(c.StartedAt))
+
+	if err := vs.update("CompactDB", func(tx *dbutil.Tx) error {
+		return putLastDBCompaction(tx, c)
+	}); err != nil {
+		logger.WithError(err).Error("Saving the database compaction failed")
+	}
+
+	return c, nil
+}
+
+// swapCompactedDB copies the database to a new file with copyDB and replaces the database file with it
+func (vs *Visor) swapCompactedDB(ctx context.Context, c *DBCompaction) error {
+	// Wait for the block being applied, and hold off writes until the compacted database replaces the database
+	vs.dbGate.writes.Lock()
+	defer vs.dbGate.writes.Unlock()
+
+	path := vs.db.Path()
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	c.SizeBefore = fi.Size()
+
+	tmpPath := path + compactFileSuffix
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{
+		Timeout: 5000 * time.Millisecond,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := vs.view("CompactDB", func(tx *dbutil.Tx) error {
+		return copyDB(ctx, dst, tx.Tx, compactTxMaxSize)
+	}); err != nil {
+		dst.Close()        //nolint:errcheck
+		os.Remove(tmpPath) //nolint:errcheck
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return err
+	}
+
+	// Last chance to abort, the database file is replaced from here on
+	select {
+	case <-ctx.Done():
+		os.Remove(tmpPath) //nolint:errcheck
+		return ErrDBCompactionAborted
+	default:
+	}
+
+	vs.dbGate.reads.Lock()
+	defer vs.dbGate.reads.Unlock()
+
+	if err := vs.db.Close(); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		if reopenErr := vs.reopenDB(path); reopenErr != nil {
+			return reopenErr
+		}
+		return err
+	}
+
+	if err := vs.reopenDB(path); err != nil {
+		return err
+	}
+
+	fi, err = os.Stat(path)
+	if err != nil {
+		return err
+	}
+	c.SizeAfter = fi.Size()
+
+	return nil
+}
+
+// reopenDB opens the database file at path in place of the closed database.
+// The node can't use its database if it fails.
+func (vs *Visor) reopenDB(path string) error {
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout: 5000 * time.Millisecond,
+	})
+	if err != nil {
+		logger.Critical().WithError(err).Errorf("Reopening the database %s failed, restart the node", path)
+		return err
+	}
+
+	vs.db.DB = db
+	return nil
+}
+
+// copyDB copies the buckets of src to dst, committing the copy every txMaxSize bytes.
+// Bucket sequences are copied with the buckets. It returns ErrDBCompactionAborted if ctx is canceled.
+func copyDB(ctx context.Context, dst *bolt.DB, src *bolt.Tx, txMaxSize int64) error {
+	c := &dbCopier{
+		ctx:       ctx,
+		dst:       dst,
+		txMaxSize: txMaxSize,
+	}
+
+	tx, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	c.tx = tx
+
+	if err := src.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if err := c.put(nil, name, nil, b.Sequence()); err != nil {
+			return err
+		}
+		return c.copyBucket([][]byte{name}, b)
+	}); err != nil {
+		c.tx.Rollback() //nolint:errcheck
+		return err
+	}
+
+	return c.tx.Commit()
+}
+
+// dbCopier copies keys to a database in transactions of at most txMaxSize bytes
+type dbCopier struct {
+	ctx       context.Context
+	dst       *bolt.DB
+	tx        *bolt.Tx
+	size      int64
+	txMaxSize int64
+	n         int
+}
+
+func (c *dbCopier) copyBucket(path [][]byte, b *bolt.Bucket) error {
+	return b.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return c.put(path, k, v, 0)
+		}
+
+		nb := b.Bucket(k)
+		if err := c.put(path, k, nil, nb.Sequence()); err != nil {
+			return err
+		}
+
+		nbPath := make([][]byte, len(path)+1)
+		copy(nbPath, path)
+		nbPath[len(path)] = k
+		return c.copyBucket(nbPath, nb)
+	})
+}
+
+// put puts k and v in the bucket at path, or creates the bucket k with sequence seq in it if v is nil.
+// A nil path is the root of the database.
+func (c *dbCopier) put(path [][]byte, k, v []byte, seq uint64) error {
+	c.n++
+	if c.n%compactAbortCheckInterval == 0 {
+		select {
+		case <-c.ctx.Done():
+			return ErrDBCompactionAborted
+		default:
+		}
+	}
+
+	if sz := int64(len(k) + len(v)); c.size+sz > c.txMaxSize && c.size != 0 {
+		if err := c.tx.Commit(); err != nil {
+			return err
+		}
+
+		tx, err := c.dst.Begin(true)
+		if err != nil {
+			return err
+		}
+		c.tx = tx
+		c.size = 0
+	}
+	c.size += int64(len(k) + len(v))
+
+	if len(path) == 0 {
+		b, err := c.tx.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return b.SetSequence(seq)
+	}
+
+	b := c.tx.Bucket(path[0])
+	for _, name := range path[1:] {
+		b = b.Bucket(name)
+	}
+
+	if v == nil {
+		nb, err := b.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return nb.SetSequence(seq)
+	}
+
+	return b.Put(k, v)
+}
+
+func getLastDBCompaction(tx *dbutil.Tx) (*DBCompaction, error) {
+	var c DBCompaction
+	ok, err := dbutil.GetBucketObjectJSON(tx, MetaBkt, lastCompactionKey, &c)
+	if err != nil {
+		switch err.(type) {
+		case dbutil.ErrBucketNotExist:
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+func putLastDBCompaction(tx *dbutil.Tx, c *DBCompaction) error {
+	if _, err := tx.CreateBucketIfNotExists(MetaBkt); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, MetaBkt, lastCompactionKey, b)
+}
+
+// RunDBCompactions compacts the database every Config.DBCompactionInterval until quit is closed.
+// A compaction running when quit is closed is aborted.
+func (vs *Visor) RunDBCompactions(quit <-chan struct{}) {
+	if vs.Config.DBCompactionInterval == 0 || vs.db.IsReadOnly() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-quit
+		cancel()
+	}()
+
+	ticker := time.NewTicker(vs.Config.DBCompactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			if _, err := vs.CompactDB(ctx); err != nil {
+				switch err {
+				case ErrDBCompactionRunning:
+					logger.Info("Skipping the scheduled database compaction, a compaction is running")
+				case ErrDBCompactionAborted:
+					logger.Info("Scheduled database compaction was aborted")
+				default:
+					logger.WithError(err).Error("Scheduled database compaction failed")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package visor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+func TestCompactDB(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey: genPublic,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+	defer v.db.Close()
+
+	gb := addGenesisBlockToVisor(t, v)
+
+	// Grow the database with data that is deleted again, leaving free pages behind
+	churnBkt := []byte("churn")
+	err = v.db.Update("", func(tx *dbutil.Tx) error {
+		b, err := tx.CreateBucket(churnBkt)
+		if err != nil {
+			return err
+		}
+		if err := b.SetSequence(7); err != nil {
+			return err
+		}
+		nested, err := b.CreateBucket([]byte("nested"))
+		if err != nil {
+			return err
+		}
+		if err := nested.Put([]byte("k"), []byte("v")); err != nil {
+			return err
+		}
+		for i := 0; i < 20000; i++ {
+			if err := b.Put([]byte(fmt.Sprintf("key-%05d", i)), make([]byte, 256)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = v.db.Update("", func(tx *dbutil.Tx) error {
+		b := tx.Bucket(churnBkt)
+		for i := 0; i < 20000; i++ {
+			if err := b.Delete([]byte(fmt.Sprintf("key-%05d", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	stats, err := v.StorageStats()
+	require.NoError(t, err)
+	require.Equal(t, db.Path(), stats.Path)
+	require.False(t, stats.Compacting)
+	require.Nil(t, stats.LastCompaction)
+	require.Contains(t, stats.Buckets, BucketStats{
+		Name: string(churnBkt),
+		Keys: 2,
+	})
+
+	fi, err := os.Stat(db.Path())
+	require.NoError(t, err)
+	require.Equal(t, fi.Size(), stats.Size)
+
+	// A canceled compaction leaves the database as it was
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c, err := v.CompactDB(ctx)
+	require.Equal(t, ErrDBCompactionAborted, err)
+	require.Equal(t, ErrDBCompactionAborted.Error(), c.Error)
+	require.Equal(t, stats.Size, c.SizeBefore)
+	require.Zero(t, c.SizeAfter)
+	_, err = os.Stat(db.Path() + compactFileSuffix)
+	require.True(t, os.IsNotExist(err))
+
+	require.Equal(t, ErrNoDBCompaction, v.AbortDBCompaction())
+
+	c, err = v.CompactDB(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, c.Error)
+	require.Equal(t, stats.Size, c.SizeBefore)
+	require.True(t, c.SizeAfter < c.SizeBefore, "%d >= %d", c.SizeAfter, c.SizeBefore)
+
+	// The compacted database has the same blocks, buckets and sequences
+	head, err := v.GetHeadBlock()
+	require.NoError(t, err)
+	require.Equal(t, gb.HashHeader(), head.HashHeader())
+
+	err = v.db.View("", func(tx *dbutil.Tx) error {
+		b := tx.Bucket(churnBkt)
+		require.NotNil(t, b)
+		require.Equal(t, uint64(7), b.Sequence())
+		require.Equal(t, []byte("v"), b.Bucket([]byte("nested")).Get([]byte("k")))
+		return nil
+	})
+	require.NoError(t, err)
+
+	stats, err = v.StorageStats()
+	require.NoError(t, err)
+	require.Equal(t, c, stats.LastCompaction)
+
+	// The last compaction is stored in the database
+	v.compaction.last = nil
+	stats, err = v.StorageStats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.LastCompaction)
+	require.Equal(t, c.SizeAfter, stats.LastCompaction.SizeAfter)
+	require.True(t, c.FinishedAt.Equal(stats.LastCompaction.FinishedAt))
+
+	// Only one compaction runs at a time
+	_, err = v.compaction.begin(func() {})
+	require.NoError(t, err)
+	_, err = v.CompactDB(context.Background())
+	require.Equal(t, ErrDBCompactionRunning, err)
+	require.Equal(t, ErrDBCompactionRunning, v.StartDBCompaction())
+	require.NoError(t, v.AbortDBCompaction())
+}
+
+func TestCompactDBReadOnly(t *testing.T) {
+	f, err := ioutil.TempFile("", "testdb")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	defer os.Remove(f.Name())
+
+	db, err := OpenDB(f.Name(), false)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = OpenDB(f.Name(), true)
+	require.NoError(t, err)
+	defer db.Close()
+
+	v := &Visor{
+		db: db,
+	}
+
+	_, err = v.CompactDB(context.Background())
+	require.Equal(t, ErrDBReadOnly, err)
+	require.Equal(t, ErrDBReadOnly, v.StartDBCompaction())
+}
@@ -3,9 +3,13 @@ package visor
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 // Config configuration parameters for the Visor
@@ -25,6 +29,20 @@ type Config struct {
 	CreateBlockVerifyTxn params.VerifyTxn
 	// Maximum size of a block, in bytes for creating blocks
 	MaxBlockTransactionsSize uint32
+	// Changes of the user burn factor at scheduled block times. The burn factors of the verification
+	// parameters apply until the first change, after which they keep their ratio to the user burn factor.
+	BurnFactorSchedule []fee.BurnFactorChange
+
+	// How long a transaction relayed by a peer stays in the unconfirmed pool without being received again.
+	// 0 keeps them until they are confirmed or become invalid.
+	UnconfirmedPeerTxnExpiry time.Duration
+	// How long a locally created transaction can stay unconfirmed before it is reported as stuck.
+	// Local transactions are never removed by expiry, they are rebroadcast until they are
+	// confirmed, become invalid or are abandoned. 0 never reports them as stuck.
+	UnconfirmedLocalTxnExpiry time.Duration
+	// How long a transaction abandoned by the user is refused when peers relay it again.
+	// It is not requested from peers announcing it either. 0 accepts it again right away.
+	UnconfirmedAbandonedTxnRefusal time.Duration
 
 	// Coin distribution parameters (necessary for txn verification)
 	Distribution params.Distribution
@@ -42,10 +60,27 @@ type Config struct {
 	GenesisTimestamp uint64
 	// Number of coins in genesis block
 	GenesisCoinVolume uint64
+	// Outputs of a multi-output genesis block, distributing GenesisCoinVolume at genesis.
+	// If set, the genesis block has no output to GenesisAddress
+	GenesisOutputs []coin.TransactionOutput
 	// enable arbitrating mode
 	Arbitrating bool
+
+	// Log a breakdown of the stages of applying a block if it takes longer than this. 0 disables the logging
+	TraceSlowBlocks time.Duration
+	// Check the coin invariants of every executed block against snapshots of the unspent pool, see coin/invariants.
+	// A block violating them is not applied. This reads the whole unspent pool twice per block
+	Paranoid bool
+
+	// Directory where database snapshots are written. If empty, a "snapshots" directory next to the database file
+	SnapshotDirectory string
+	// How often the database is compacted, see Visor.CompactDB. 0 only compacts it when requested through the API
+	DBCompactionInterval time.Duration
 }
 
+// DefaultUnconfirmedAbandonedTxnRefusal is the default Config.UnconfirmedAbandonedTxnRefusal
+const DefaultUnconfirmedAbandonedTxnRefusal = 24 * time.Hour
+
 // NewConfig creates Config
 func NewConfig() Config {
 	c := Config{
@@ -62,6 +97,8 @@ func NewConfig() Config {
 		GenesisSignature:  cipher.Sig{},
 		GenesisTimestamp:  0,
 		GenesisCoinVolume: 0, //100e12, 100e6 * 10e6
+
+		UnconfirmedAbandonedTxnRefusal: DefaultUnconfirmedAbandonedTxnRefusal,
 	}
 
 	return c
@@ -107,6 +144,38 @@ func (c Config) Verify() error {
 		return fmt.Errorf("CreateBlockVerifyTxn.MaxDropletPrecision must be >= params.UserVerifyTxn.MaxDropletPrecision (%d)", params.UserVerifyTxn.MaxDropletPrecision)
 	}
 
+	if len(c.GenesisOutputs) != 0 {
+		if err := verifyGenesisOutputs(c.GenesisOutputs, c.GenesisCoinVolume); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fee.NewScheduledBurnPolicy(params.MinBurnFactor, c.BurnFactorSchedule); err != nil {
+		return err
+	}
+
+	for i := 1; i < len(c.BurnFactorSchedule); i++ {
+		if c.BurnFactorSchedule[i].Time <= c.BurnFactorSchedule[i-1].Time {
+			return errors.New("BurnFactorSchedule must be sorted by time")
+		}
+	}
+
+	if c.UnconfirmedPeerTxnExpiry < 0 {
+		return errors.New("UnconfirmedPeerTxnExpiry must be >= 0")
+	}
+
+	if c.UnconfirmedLocalTxnExpiry < 0 {
+		return errors.New("UnconfirmedLocalTxnExpiry must be >= 0")
+	}
+
+	if c.UnconfirmedAbandonedTxnRefusal < 0 {
+		return errors.New("UnconfirmedAbandonedTxnRefusal must be >= 0")
+	}
+
+	if c.DBCompactionInterval < 0 {
+		return errors.New("DBCompactionInterval must be >= 0")
+	}
+
 	if c.MaxBlockTransactionsSize < c.CreateBlockVerifyTxn.MaxTransactionSize {
 		return errors.New("MaxBlockTransactionsSize must be >= CreateBlockVerifyTxn.MaxTransactionSize")
 	}
@@ -352,8 +352,16 @@ func verifyDBSkyencoderSafe(tx *dbutil.Tx, quit <-chan struct{}) error {
 		default:
 		}
 
+		// Records written before announcements were counted are checked in the current layout,
+		// they are migrated when the unconfirmed pool is created
 		var b1 UnconfirmedTransaction
-		if err := decodeUnconfirmedTransactionExact(v, &b1); err != nil {
+		if err := decodeUnconfirmedTransactionExact(v, &b1); err == encoder.ErrBufferUnderflow {
+			v = upgradeLegacyUnconfirmedTxnRecord(v)
+			err = decodeUnconfirmedTransactionExact(v, &b1)
+			if err != nil {
+				return err
+			}
+		} else if err != nil {
 			return err
 		}
 
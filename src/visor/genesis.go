@@ -0,0 +1,116 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+)
+
+// newGenesisBlock creates the genesis block of the Config, with the GenesisOutputs if set,
+// otherwise with a single output of GenesisCoinVolume to GenesisAddress
+func newGenesisBlock(c Config) (*coin.Block, error) {
+	if len(c.GenesisOutputs) == 0 {
+		return coin.NewGenesisBlock(c.GenesisAddress, c.GenesisCoinVolume, c.GenesisTimestamp)
+	}
+
+	pb, err := pcoin.NewGenesisBlockMulti(toPcoinOutputs(c.GenesisOutputs), c.GenesisTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	txns := make(coin.Transactions, len(pb.Body.Transactions))
+	for i, txn := range pb.Body.Transactions {
+		out := make([]coin.TransactionOutput, len(txn.Out))
+		for j, o := range txn.Out {
+			out[j] = coin.TransactionOutput(o)
+		}
+
+		txns[i] = coin.Transaction{
+			Length:    txn.Length,
+			Type:      txn.Type,
+			InnerHash: txn.InnerHash,
+			Sigs:      txn.Sigs,
+			In:        txn.In,
+			Out:       out,
+		}
+	}
+
+	return &coin.Block{
+		Head: coin.BlockHeader(pb.Head),
+		Body: coin.BlockBody{
+			Transactions: txns,
+		},
+	}, nil
+}
+
+// verifyGenesisOutputs verifies the outputs of a multi-output genesis block with pcoin.VerifyGenesisOutputs
+func verifyGenesisOutputs(outputs []coin.TransactionOutput, coinVolume uint64) error {
+	return pcoin.VerifyGenesisOutputs(toPcoinOutputs(outputs), coinVolume)
+}
+
+func toPcoinOutputs(outputs []coin.TransactionOutput) []pcoin.TransactionOutput {
+	pout := make([]pcoin.TransactionOutput, len(outputs))
+	for i, o := range outputs {
+		pout[i] = pcoin.TransactionOutput(o)
+	}
+	return pout
+}
+
+// verifyGenesisBlock checks that a block has the form of a genesis block: a single transaction without
+// inputs or signatures, with a single output whose coin hours are its coins, as created by coin.NewGenesisBlock.
+// If outputs is not empty, the transaction must have these outputs of a multi-output genesis block instead,
+// as created by pcoin.NewGenesisBlockMulti, and their coins must sum to coinVolume.
+func verifyGenesisBlock(b coin.Block, outputs []coin.TransactionOutput, coinVolume uint64) error {
+	if b.Head.BkSeq != 0 {
+		return fmt.Errorf("genesis block seq is %d, must be 0", b.Head.BkSeq)
+	}
+
+	if b.Head.PrevHash != (cipher.SHA256{}) {
+		return errors.New("genesis block has a previous block hash")
+	}
+
+	if b.Head.Fee != 0 {
+		return errors.New("genesis block has a fee")
+	}
+
+	if len(b.Body.Transactions) != 1 {
+		return fmt.Errorf("genesis block has %d transactions, must have 1", len(b.Body.Transactions))
+	}
+
+	txn := b.Body.Transactions[0]
+	if len(txn.In) != 0 || len(txn.Sigs) != 0 {
+		return errors.New("genesis block transaction has inputs")
+	}
+
+	if len(outputs) != 0 {
+		if err := verifyGenesisOutputs(txn.Out, coinVolume); err != nil {
+			return err
+		}
+
+		if len(txn.Out) != len(outputs) {
+			return fmt.Errorf("genesis block transaction has %d outputs, must have %d", len(txn.Out), len(outputs))
+		}
+
+		for i, o := range txn.Out {
+			if o != outputs[i] {
+				return fmt.Errorf("genesis block output %d does not match the configured genesis output", i)
+			}
+		}
+
+		return nil
+	}
+
+	if len(txn.Out) != 1 {
+		return fmt.Errorf("genesis block transaction has %d outputs, must have 1", len(txn.Out))
+	}
+
+	if txn.Out[0].Hours != txn.Out[0].Coins {
+		return errors.New("genesis block output coin hours must be its coins")
+	}
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+)
+
+func makeGenesisOutputs(n int) []coin.TransactionOutput {
+	outputs := make([]coin.TransactionOutput, n)
+	for i := range outputs {
+		p, _ := cipher.MustGenerateDeterministicKeyPair([]byte(fmt.Sprintf("genesis-%d", i)))
+		outputs[i] = coin.TransactionOutput{
+			Address: cipher.AddressFromPubKey(p),
+			Coins:   uint64(i+1) * 1e6,
+		}
+	}
+	return outputs
+}
+
+func TestNewGenesisBlock(t *testing.T) {
+	cfg := NewConfig()
+	cfg.GenesisAddress = genAddress
+	cfg.GenesisCoinVolume = genCoins
+	cfg.GenesisTimestamp = genTime
+
+	gb, err := newGenesisBlock(cfg)
+	require.NoError(t, err)
+	expected, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
+	require.NoError(t, err)
+	require.Equal(t, expected, gb)
+	require.NoError(t, verifyGenesisBlock(*gb, nil, 0))
+	require.Error(t, verifyGenesisBlock(*gb, makeGenesisOutputs(3), 6e6))
+
+	cfg.GenesisOutputs = makeGenesisOutputs(3)
+	cfg.GenesisCoinVolume = 6e6
+
+	gb, err = newGenesisBlock(cfg)
+	require.NoError(t, err)
+	require.Len(t, gb.Body.Transactions, 1)
+	require.Equal(t, cfg.GenesisOutputs, gb.Body.Transactions[0].Out)
+	require.NoError(t, verifyGenesisBlock(*gb, cfg.GenesisOutputs, cfg.GenesisCoinVolume))
+	require.Error(t, verifyGenesisBlock(*gb, nil, 0))
+
+	// The converted block has the hashes of the pcoin block
+	pb, err := pcoin.NewGenesisBlockMulti(toPcoinOutputs(cfg.GenesisOutputs), genTime)
+	require.NoError(t, err)
+	require.Equal(t, pb.HashHeader(), gb.HashHeader())
+	require.Equal(t, pb.Body.Hash(), gb.Body.Hash())
+	require.Equal(t, gb.Body.Hash(), gb.Head.BodyHash)
+}
+
+func TestVerifyGenesisBlock(t *testing.T) {
+	single, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.GenesisOutputs = makeGenesisOutputs(2)
+	cfg.GenesisTimestamp = genTime
+	multi, err := newGenesisBlock(cfg)
+	require.NoError(t, err)
+
+	withSeq := *single
+	withSeq.Head.BkSeq = 1
+
+	withFee := *single
+	withFee.Head.Fee = 1
+
+	withPrevHash := *single
+	withPrevHash.Head.PrevHash = cipher.SumSHA256([]byte("prev"))
+
+	noTxns := *single
+	noTxns.Body.Transactions = nil
+
+	withInputs := *single
+	withInputs.Body.Transactions = coin.Transactions{single.Body.Transactions[0]}
+	withInputs.Body.Transactions[0].In = []cipher.SHA256{cipher.SumSHA256([]byte("in"))}
+
+	twoOutputs := *single
+	twoOutputs.Body.Transactions = coin.Transactions{single.Body.Transactions[0]}
+	twoOutputs.Body.Transactions[0].Out = append(twoOutputs.Body.Transactions[0].Out, single.Body.Transactions[0].Out[0])
+
+	// Outputs with the same coins as the block's outputs, to other addresses
+	otherOutputs := makeGenesisOutputs(2)
+	otherOutputs[0].Address, otherOutputs[1].Address = otherOutputs[1].Address, otherOutputs[0].Address
+
+	zeroHours := *single
+	zeroHours.Body.Transactions = coin.Transactions{single.Body.Transactions[0]}
+	zeroHours.Body.Transactions[0].Out = []coin.TransactionOutput{single.Body.Transactions[0].Out[0]}
+	zeroHours.Body.Transactions[0].Out[0].Hours = 0
+
+	cases := []struct {
+		name       string
+		b          coin.Block
+		outputs    []coin.TransactionOutput
+		coinVolume uint64
+		err        error
+	}{
+		{
+			name: "single output",
+			b:    *single,
+		},
+		{
+			name:       "multi output",
+			b:          *multi,
+			outputs:    cfg.GenesisOutputs,
+			coinVolume: 3e6,
+		},
+		{
+			name:       "multi output coin volume mismatch",
+			b:          *multi,
+			outputs:    cfg.GenesisOutputs,
+			coinVolume: 4e6,
+			err:        errors.New("genesis outputs coins 3000000 do not match the genesis coin volume 4000000"),
+		},
+		{
+			name:       "multi output other outputs",
+			b:          *multi,
+			outputs:    otherOutputs,
+			coinVolume: 3e6,
+			err:        errors.New("genesis block output 0 does not match the configured genesis output"),
+		},
+		{
+			name:       "multi output fewer outputs",
+			b:          *multi,
+			outputs:    cfg.GenesisOutputs[:1],
+			coinVolume: 3e6,
+			err:        errors.New("genesis block transaction has 2 outputs, must have 1"),
+		},
+		{
+			name: "single output zero hours",
+			b:    zeroHours,
+			err:  errors.New("genesis block output coin hours must be its coins"),
+		},
+		{
+			name:       "single output as multi output",
+			b:          *single,
+			outputs:    cfg.GenesisOutputs,
+			coinVolume: 3e6,
+			err:        errors.New("genesis output 0 has coin hours, genesis outputs must have zero coin hours"),
+		},
+		{
+			name: "multi output as single output",
+			b:    *multi,
+			err:  errors.New("genesis block transaction has 2 outputs, must have 1"),
+		},
+		{
+			name: "two outputs",
+			b:    twoOutputs,
+			err:  errors.New("genesis block transaction has 2 outputs, must have 1"),
+		},
+		{
+			name: "seq",
+			b:    withSeq,
+			err:  errors.New("genesis block seq is 1, must be 0"),
+		},
+		{
+			name: "fee",
+			b:    withFee,
+			err:  errors.New("genesis block has a fee"),
+		},
+		{
+			name: "prev hash",
+			b:    withPrevHash,
+			err:  errors.New("genesis block has a previous block hash"),
+		},
+		{
+			name: "no transactions",
+			b:    noTxns,
+			err:  errors.New("genesis block has 0 transactions, must have 1"),
+		},
+		{
+			name: "inputs",
+			b:    withInputs,
+			err:  errors.New("genesis block transaction has inputs"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyGenesisBlock(tc.b, tc.outputs, tc.coinVolume)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestConfigVerifyGenesisOutputs(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Distribution = params.MainNetDistribution
+	cfg.GenesisOutputs = makeGenesisOutputs(3)
+	cfg.GenesisCoinVolume = 6e6
+	require.NoError(t, cfg.Verify())
+
+	cfg.GenesisCoinVolume = 5e6
+	require.EqualError(t, cfg.Verify(), "genesis outputs coins 6000000 do not match the genesis coin volume 5000000")
+
+	cfg.GenesisCoinVolume = 6e6
+	cfg.GenesisOutputs[0].Hours = 1
+	require.EqualError(t, cfg.Verify(), "genesis output 0 has coin hours, genesis outputs must have zero coin hours")
+}
+
+func TestVisorMultiOutputGenesis(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.BlockchainSeckey = genSecret
+	cfg.Distribution = params.MainNetDistribution
+	cfg.GenesisOutputs = makeGenesisOutputs(3)
+	cfg.GenesisCoinVolume = 6e6
+	cfg.GenesisTimestamp = genTime
+	require.NoError(t, cfg.Verify())
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:            genPublic,
+		GenesisOutputs:    cfg.GenesisOutputs,
+		GenesisCoinVolume: cfg.GenesisCoinVolume,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return v.maybeCreateGenesisBlock(tx)
+	})
+	require.NoError(t, err)
+
+	addrs := make([]cipher.Address, len(cfg.GenesisOutputs))
+	for i, o := range cfg.GenesisOutputs {
+		addrs[i] = o.Address
+	}
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		gb, err := bc.GetGenesisBlock(tx)
+		require.NoError(t, err)
+		require.NotNil(t, gb)
+		require.NoError(t, gb.VerifySignature(genPublic))
+		require.Equal(t, cfg.GenesisOutputs, gb.Body.Transactions[0].Out)
+
+		uxOuts, err := bc.Unspent().GetUnspentsOfAddrs(tx, addrs)
+		require.NoError(t, err)
+		for i, o := range cfg.GenesisOutputs {
+			require.Len(t, uxOuts[o.Address], 1, "output %d", i)
+			ux := uxOuts[o.Address][0]
+			require.Equal(t, o.Coins, ux.Body.Coins)
+			require.Equal(t, uint64(0), ux.Body.Hours)
+			require.Equal(t, uint64(0), ux.Head.BkSeq)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	// A single output genesis block is rejected by a blockchain with a multi-output genesis block
+	db2, shutdown2 := prepareDB(t)
+	defer shutdown2()
+
+	bc2, err := NewBlockchain(db2, BlockchainConfig{
+		Pubkey:            genPublic,
+		GenesisOutputs:    cfg.GenesisOutputs,
+		GenesisCoinVolume: cfg.GenesisCoinVolume,
+	})
+	require.NoError(t, err)
+
+	gb, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
+	require.NoError(t, err)
+	err = db2.Update("", func(tx *dbutil.Tx) error {
+		return bc2.ExecuteBlock(tx, &coin.SignedBlock{
+			Block: *gb,
+			Sig:   cipher.MustSignHash(gb.HashHeader(), genSecret),
+		}, nil)
+	})
+	require.EqualError(t, err, "genesis output 0 has coin hours, genesis outputs must have zero coin hours")
+}
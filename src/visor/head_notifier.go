@@ -0,0 +1,70 @@
+package visor
+
+import (
+	"context"
+	"sync"
+)
+
+// headNotifier tracks the blockchain head seq in memory and wakes waiters when it changes.
+// Waiters block on a channel that is closed when the head changes, so waiting does not
+// hold a database transaction.
+// The zero value is ready to use, with an unknown head seq.
+type headNotifier struct {
+	sync.Mutex
+	seq    uint64
+	hasSeq bool
+	change chan struct{}
+}
+
+// changed returns the channel that is closed on the next head change. Caller must hold the lock.
+func (n *headNotifier) changed() chan struct{} {
+	if n.change == nil {
+		n.change = make(chan struct{})
+	}
+	return n.change
+}
+
+// init sets the head seq without waking waiters
+func (n *headNotifier) init(seq uint64, hasSeq bool) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.seq = seq
+	n.hasSeq = hasSeq
+}
+
+// set sets the head seq and wakes all waiters
+func (n *headNotifier) set(seq uint64) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.seq = seq
+	n.hasSeq = true
+
+	close(n.changed())
+	n.change = make(chan struct{})
+}
+
+func (n *headNotifier) get() (uint64, bool, <-chan struct{}) {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.seq, n.hasSeq, n.changed()
+}
+
+// waitAfter blocks until the head seq is greater than seq, or ctx is done.
+// Returns true if the head seq is greater than seq.
+func (n *headNotifier) waitAfter(ctx context.Context, seq uint64) bool {
+	for {
+		headSeq, hasSeq, change := n.get()
+		if hasSeq && headSeq > seq {
+			return true
+		}
+
+		select {
+		case <-change:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package visor
 
 import (
+	"time"
+
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
@@ -41,7 +43,7 @@ type Blockchainer interface {
 	HeadSeq(tx *dbutil.Tx) (uint64, bool, error)
 	Time(tx *dbutil.Tx) (uint64, error)
 	NewBlock(tx *dbutil.Tx, txns coin.Transactions, currentTime uint64) (*coin.Block, error)
-	ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error
+	ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock, t *BlockStageTimer) error
 	VerifyBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error
 	VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transaction) error
 	VerifySingleTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, signed TxnSignedFlag) error
@@ -52,12 +54,25 @@ type Blockchainer interface {
 // UnconfirmedTransactionPooler is the interface that provides methods for
 // accessing the unconfirmed transaction pool
 type UnconfirmedTransactionPooler interface {
-	SetTransactionsAnnounced(tx *dbutil.Tx, hashes map[cipher.SHA256]int64) error
-	InjectTransaction(tx *dbutil.Tx, bc Blockchainer, t coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn) (bool, *ErrTxnViolatesSoftConstraint, error)
+	SetTransactionsAnnounced(tx *dbutil.Tx, announcements map[cipher.SHA256]TxnAnnouncement) error
+	GetAnnouncement(tx *dbutil.Tx, hash cipher.SHA256) (*TxnAnnouncement, error)
+	RestoreAnnouncement(tx *dbutil.Tx, hash cipher.SHA256, a TxnAnnouncement) (bool, error)
+	InjectTransaction(tx *dbutil.Tx, bc Blockchainer, t coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn, origin TxnOrigin) (bool, *ErrTxnViolatesSoftConstraint, error)
 	AllRawTransactions(tx *dbutil.Tx) (coin.Transactions, error)
 	RemoveTransactions(tx *dbutil.Tx, txns []cipher.SHA256) error
 	Refresh(tx *dbutil.Tx, bc Blockchainer, distParams params.Distribution, verifyParams params.VerifyTxn) ([]cipher.SHA256, error)
 	RemoveInvalid(tx *dbutil.Tx, bc Blockchainer) ([]cipher.SHA256, error)
+	RemoveExpired(tx *dbutil.Tx, now time.Time, peerExpiry time.Duration) ([]cipher.SHA256, error)
+	GetExpiredLocal(tx *dbutil.Tx, now time.Time, localExpiry time.Duration) ([]cipher.SHA256, error)
+	GetOrigin(tx *dbutil.Tx, hash cipher.SHA256) (*UnconfirmedTxnOrigin, error)
+	SetAbandoned(tx *dbutil.Tx, hash cipher.SHA256, until time.Time) error
+	ClearAbandoned(tx *dbutil.Tx, hash cipher.SHA256) error
+	IsAbandoned(tx *dbutil.Tx, hash cipher.SHA256, now time.Time) (bool, error)
+	RemoveExpiredAbandoned(tx *dbutil.Tx, now time.Time) (int, error)
+	RemoveConflicts(tx *dbutil.Tx, txns coin.Transactions) ([]cipher.SHA256, error)
+	Conflicts(tx *dbutil.Tx) (map[cipher.SHA256][]cipher.SHA256, error)
+	SpentBy(tx *dbutil.Tx, uxids []cipher.SHA256) (map[cipher.SHA256][]cipher.SHA256, error)
+	RebuildSpendsIndex(tx *dbutil.Tx) error
 	FilterKnown(tx *dbutil.Tx, txns []cipher.SHA256) ([]cipher.SHA256, error)
 	GetKnown(tx *dbutil.Tx, txns []cipher.SHA256) (coin.Transactions, error)
 	RecvOfAddresses(tx *dbutil.Tx, bh coin.BlockHeader, addrs []cipher.Address) (coin.AddressUxOuts, error)
@@ -1,10 +1,12 @@
 package visor
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/blang/semver"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
@@ -13,6 +15,7 @@ var (
 	MetaBkt = []byte("db_meta")
 
 	versionKey = []byte("version")
+	uidKey     = []byte("uid")
 )
 
 // GetDBVersion returns the saved DB version
@@ -69,3 +72,56 @@ func SetDBVersion(db *dbutil.DB, version semver.Version) error {
 		return dbutil.PutBucketValue(tx, MetaBkt, versionKey, []byte(version.String()))
 	})
 }
+
+// GetDBUID returns the DB's unique identifier, or an empty string if it has none
+func GetDBUID(db *dbutil.DB) (string, error) {
+	var uid string
+	if err := db.View("GetDBUID", func(tx *dbutil.Tx) error {
+		var err error
+		uid, err = getDBUID(tx)
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	return uid, nil
+}
+
+func getDBUID(tx *dbutil.Tx) (string, error) {
+	v, err := dbutil.GetBucketValue(tx, MetaBkt, uidKey)
+	if err != nil {
+		switch err.(type) {
+		case dbutil.ErrBucketNotExist:
+			return "", nil
+		default:
+			return "", err
+		}
+	}
+
+	return string(v), nil
+}
+
+// GetOrCreateDBUID returns the DB's unique identifier, generating one if it has none.
+// The identifier is generated once when the DB is created, and is not changed by migrations,
+// so that copies of a DB can be told apart from separately synced DBs.
+func GetOrCreateDBUID(db *dbutil.DB) (string, error) {
+	var uid string
+	if err := db.Update("GetOrCreateDBUID", func(tx *dbutil.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(MetaBkt); err != nil {
+			return err
+		}
+
+		var err error
+		uid, err = getDBUID(tx)
+		if err != nil || uid != "" {
+			return err
+		}
+
+		uid = hex.EncodeToString(cipher.RandByte(16))
+		return dbutil.PutBucketValue(tx, MetaBkt, uidKey, []byte(uid))
+	}); err != nil {
+		return "", err
+	}
+
+	return uid, nil
+}
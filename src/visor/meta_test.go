@@ -43,3 +43,34 @@ func TestGetSetDBVersion(t *testing.T) {
 	err = SetDBVersion(db, x)
 	testutil.RequireError(t, err, "SetDBVersion cannot regress version from 0.26.0 to 0.25.0")
 }
+
+func TestGetOrCreateDBUID(t *testing.T) {
+	db, shutdown := testutil.PrepareDB(t)
+	defer shutdown()
+
+	// No UID yet
+	uid, err := GetDBUID(db)
+	require.NoError(t, err)
+	require.Empty(t, uid)
+
+	// The UID is created once
+	uid, err = GetOrCreateDBUID(db)
+	require.NoError(t, err)
+	require.Len(t, uid, 32)
+
+	uid2, err := GetOrCreateDBUID(db)
+	require.NoError(t, err)
+	require.Equal(t, uid, uid2)
+
+	uid2, err = GetDBUID(db)
+	require.NoError(t, err)
+	require.Equal(t, uid, uid2)
+
+	// Setting the version does not change the UID
+	err = SetDBVersion(db, semver.MustParse("0.25.0"))
+	require.NoError(t, err)
+
+	uid2, err = GetDBUID(db)
+	require.NoError(t, err)
+	require.Equal(t, uid, uid2)
+}
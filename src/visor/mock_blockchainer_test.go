@@ -20,13 +20,13 @@ type MockBlockchainer struct {
 	mock.Mock
 }
 
-// ExecuteBlock provides a mock function with given fields: tx, sb
-func (_m *MockBlockchainer) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
-	ret := _m.Called(tx, sb)
+// ExecuteBlock provides a mock function with given fields: tx, sb, t
+func (_m *MockBlockchainer) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock, t *BlockStageTimer) error {
+	ret := _m.Called(tx, sb, t)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*dbutil.Tx, *coin.SignedBlock) error); ok {
-		r0 = rf(tx, sb)
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, *coin.SignedBlock, *BlockStageTimer) error); ok {
+		r0 = rf(tx, sb, t)
 	} else {
 		r0 = ret.Error(0)
 	}
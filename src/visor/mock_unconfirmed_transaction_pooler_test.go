@@ -11,6 +11,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	params "github.com/skycoin/skycoin/src/params"
+
+	time "time"
 )
 
 // MockUnconfirmedTransactionPooler is an autogenerated mock type for the UnconfirmedTransactionPooler type
@@ -41,6 +43,43 @@ func (_m *MockUnconfirmedTransactionPooler) AllRawTransactions(tx *dbutil.Tx) (c
 	return r0, r1
 }
 
+// ClearAbandoned provides a mock function with given fields: tx, hash
+func (_m *MockUnconfirmedTransactionPooler) ClearAbandoned(tx *dbutil.Tx, hash cipher.SHA256) error {
+	ret := _m.Called(tx, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256) error); ok {
+		r0 = rf(tx, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Conflicts provides a mock function with given fields: tx
+func (_m *MockUnconfirmedTransactionPooler) Conflicts(tx *dbutil.Tx) (map[cipher.SHA256][]cipher.SHA256, error) {
+	ret := _m.Called(tx)
+
+	var r0 map[cipher.SHA256][]cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) map[cipher.SHA256][]cipher.SHA256); ok {
+		r0 = rf(tx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.SHA256][]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FilterKnown provides a mock function with given fields: tx, txns
 func (_m *MockUnconfirmedTransactionPooler) FilterKnown(tx *dbutil.Tx, txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	ret := _m.Called(tx, txns)
@@ -101,6 +140,52 @@ func (_m *MockUnconfirmedTransactionPooler) Get(tx *dbutil.Tx, hash cipher.SHA25
 	return r0, r1
 }
 
+// GetAnnouncement provides a mock function with given fields: tx, hash
+func (_m *MockUnconfirmedTransactionPooler) GetAnnouncement(tx *dbutil.Tx, hash cipher.SHA256) (*TxnAnnouncement, error) {
+	ret := _m.Called(tx, hash)
+
+	var r0 *TxnAnnouncement
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256) *TxnAnnouncement); ok {
+		r0 = rf(tx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*TxnAnnouncement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, cipher.SHA256) error); ok {
+		r1 = rf(tx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExpiredLocal provides a mock function with given fields: tx, now, localExpiry
+func (_m *MockUnconfirmedTransactionPooler) GetExpiredLocal(tx *dbutil.Tx, now time.Time, localExpiry time.Duration) ([]cipher.SHA256, error) {
+	ret := _m.Called(tx, now, localExpiry)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, time.Time, time.Duration) []cipher.SHA256); ok {
+		r0 = rf(tx, now, localExpiry)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, time.Time, time.Duration) error); ok {
+		r1 = rf(tx, now, localExpiry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFiltered provides a mock function with given fields: tx, filter
 func (_m *MockUnconfirmedTransactionPooler) GetFiltered(tx *dbutil.Tx, filter func(UnconfirmedTransaction) bool) ([]UnconfirmedTransaction, error) {
 	ret := _m.Called(tx, filter)
@@ -193,6 +278,29 @@ func (_m *MockUnconfirmedTransactionPooler) GetKnown(tx *dbutil.Tx, txns []ciphe
 	return r0, r1
 }
 
+// GetOrigin provides a mock function with given fields: tx, hash
+func (_m *MockUnconfirmedTransactionPooler) GetOrigin(tx *dbutil.Tx, hash cipher.SHA256) (*UnconfirmedTxnOrigin, error) {
+	ret := _m.Called(tx, hash)
+
+	var r0 *UnconfirmedTxnOrigin
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256) *UnconfirmedTxnOrigin); ok {
+		r0 = rf(tx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*UnconfirmedTxnOrigin)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, cipher.SHA256) error); ok {
+		r1 = rf(tx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUnspentsOfAddr provides a mock function with given fields: tx, addr
 func (_m *MockUnconfirmedTransactionPooler) GetUnspentsOfAddr(tx *dbutil.Tx, addr cipher.Address) (coin.UxArray, error) {
 	ret := _m.Called(tx, addr)
@@ -216,20 +324,20 @@ func (_m *MockUnconfirmedTransactionPooler) GetUnspentsOfAddr(tx *dbutil.Tx, add
 	return r0, r1
 }
 
-// InjectTransaction provides a mock function with given fields: tx, bc, t, distParams, verifyParams
-func (_m *MockUnconfirmedTransactionPooler) InjectTransaction(tx *dbutil.Tx, bc Blockchainer, t coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn) (bool, *ErrTxnViolatesSoftConstraint, error) {
-	ret := _m.Called(tx, bc, t, distParams, verifyParams)
+// InjectTransaction provides a mock function with given fields: tx, bc, t, distParams, verifyParams, origin
+func (_m *MockUnconfirmedTransactionPooler) InjectTransaction(tx *dbutil.Tx, bc Blockchainer, t coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn, origin TxnOrigin) (bool, *ErrTxnViolatesSoftConstraint, error) {
+	ret := _m.Called(tx, bc, t, distParams, verifyParams, origin)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.Distribution, params.VerifyTxn) bool); ok {
-		r0 = rf(tx, bc, t, distParams, verifyParams)
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.Distribution, params.VerifyTxn, TxnOrigin) bool); ok {
+		r0 = rf(tx, bc, t, distParams, verifyParams, origin)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 *ErrTxnViolatesSoftConstraint
-	if rf, ok := ret.Get(1).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.Distribution, params.VerifyTxn) *ErrTxnViolatesSoftConstraint); ok {
-		r1 = rf(tx, bc, t, distParams, verifyParams)
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.Distribution, params.VerifyTxn, TxnOrigin) *ErrTxnViolatesSoftConstraint); ok {
+		r1 = rf(tx, bc, t, distParams, verifyParams, origin)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*ErrTxnViolatesSoftConstraint)
@@ -237,8 +345,8 @@ func (_m *MockUnconfirmedTransactionPooler) InjectTransaction(tx *dbutil.Tx, bc
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.Distribution, params.VerifyTxn) error); ok {
-		r2 = rf(tx, bc, t, distParams, verifyParams)
+	if rf, ok := ret.Get(2).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.Distribution, params.VerifyTxn, TxnOrigin) error); ok {
+		r2 = rf(tx, bc, t, distParams, verifyParams, origin)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -246,6 +354,27 @@ func (_m *MockUnconfirmedTransactionPooler) InjectTransaction(tx *dbutil.Tx, bc
 	return r0, r1, r2
 }
 
+// IsAbandoned provides a mock function with given fields: tx, hash, now
+func (_m *MockUnconfirmedTransactionPooler) IsAbandoned(tx *dbutil.Tx, hash cipher.SHA256, now time.Time) (bool, error) {
+	ret := _m.Called(tx, hash, now)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256, time.Time) bool); ok {
+		r0 = rf(tx, hash, now)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, cipher.SHA256, time.Time) error); ok {
+		r1 = rf(tx, hash, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Len provides a mock function with given fields: tx
 func (_m *MockUnconfirmedTransactionPooler) Len(tx *dbutil.Tx) (uint64, error) {
 	ret := _m.Called(tx)
@@ -267,6 +396,20 @@ func (_m *MockUnconfirmedTransactionPooler) Len(tx *dbutil.Tx) (uint64, error) {
 	return r0, r1
 }
 
+// RebuildSpendsIndex provides a mock function with given fields: tx
+func (_m *MockUnconfirmedTransactionPooler) RebuildSpendsIndex(tx *dbutil.Tx) error {
+	ret := _m.Called(tx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) error); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RecvOfAddresses provides a mock function with given fields: tx, bh, addrs
 func (_m *MockUnconfirmedTransactionPooler) RecvOfAddresses(tx *dbutil.Tx, bh coin.BlockHeader, addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	ret := _m.Called(tx, bh, addrs)
@@ -313,6 +456,73 @@ func (_m *MockUnconfirmedTransactionPooler) Refresh(tx *dbutil.Tx, bc Blockchain
 	return r0, r1
 }
 
+// RemoveConflicts provides a mock function with given fields: tx, txns
+func (_m *MockUnconfirmedTransactionPooler) RemoveConflicts(tx *dbutil.Tx, txns coin.Transactions) ([]cipher.SHA256, error) {
+	ret := _m.Called(tx, txns)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Transactions) []cipher.SHA256); ok {
+		r0 = rf(tx, txns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, coin.Transactions) error); ok {
+		r1 = rf(tx, txns)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveExpired provides a mock function with given fields: tx, now, peerExpiry
+func (_m *MockUnconfirmedTransactionPooler) RemoveExpired(tx *dbutil.Tx, now time.Time, peerExpiry time.Duration) ([]cipher.SHA256, error) {
+	ret := _m.Called(tx, now, peerExpiry)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, time.Time, time.Duration) []cipher.SHA256); ok {
+		r0 = rf(tx, now, peerExpiry)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, time.Time, time.Duration) error); ok {
+		r1 = rf(tx, now, peerExpiry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveExpiredAbandoned provides a mock function with given fields: tx, now
+func (_m *MockUnconfirmedTransactionPooler) RemoveExpiredAbandoned(tx *dbutil.Tx, now time.Time) (int, error) {
+	ret := _m.Called(tx, now)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, time.Time) int); ok {
+		r0 = rf(tx, now)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, time.Time) error); ok {
+		r1 = rf(tx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RemoveInvalid provides a mock function with given fields: tx, bc
 func (_m *MockUnconfirmedTransactionPooler) RemoveInvalid(tx *dbutil.Tx, bc Blockchainer) ([]cipher.SHA256, error) {
 	ret := _m.Called(tx, bc)
@@ -350,16 +560,74 @@ func (_m *MockUnconfirmedTransactionPooler) RemoveTransactions(tx *dbutil.Tx, tx
 	return r0
 }
 
-// SetTransactionsAnnounced provides a mock function with given fields: tx, hashes
-func (_m *MockUnconfirmedTransactionPooler) SetTransactionsAnnounced(tx *dbutil.Tx, hashes map[cipher.SHA256]int64) error {
-	ret := _m.Called(tx, hashes)
+// RestoreAnnouncement provides a mock function with given fields: tx, hash, a
+func (_m *MockUnconfirmedTransactionPooler) RestoreAnnouncement(tx *dbutil.Tx, hash cipher.SHA256, a TxnAnnouncement) (bool, error) {
+	ret := _m.Called(tx, hash, a)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256, TxnAnnouncement) bool); ok {
+		r0 = rf(tx, hash, a)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, cipher.SHA256, TxnAnnouncement) error); ok {
+		r1 = rf(tx, hash, a)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAbandoned provides a mock function with given fields: tx, hash, until
+func (_m *MockUnconfirmedTransactionPooler) SetAbandoned(tx *dbutil.Tx, hash cipher.SHA256, until time.Time) error {
+	ret := _m.Called(tx, hash, until)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256, time.Time) error); ok {
+		r0 = rf(tx, hash, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetTransactionsAnnounced provides a mock function with given fields: tx, announcements
+func (_m *MockUnconfirmedTransactionPooler) SetTransactionsAnnounced(tx *dbutil.Tx, announcements map[cipher.SHA256]TxnAnnouncement) error {
+	ret := _m.Called(tx, announcements)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*dbutil.Tx, map[cipher.SHA256]int64) error); ok {
-		r0 = rf(tx, hashes)
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, map[cipher.SHA256]TxnAnnouncement) error); ok {
+		r0 = rf(tx, announcements)
 	} else {
 		r0 = ret.Error(0)
 	}
 
 	return r0
 }
+
+// SpentBy provides a mock function with given fields: tx, uxids
+func (_m *MockUnconfirmedTransactionPooler) SpentBy(tx *dbutil.Tx, uxids []cipher.SHA256) (map[cipher.SHA256][]cipher.SHA256, error) {
+	ret := _m.Called(tx, uxids)
+
+	var r0 map[cipher.SHA256][]cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, []cipher.SHA256) map[cipher.SHA256][]cipher.SHA256); ok {
+		r0 = rf(tx, uxids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.SHA256][]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, []cipher.SHA256) error); ok {
+		r1 = rf(tx, uxids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -4,7 +4,8 @@ import (
 	"time"
 
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/transaction"
+
+	"github.com/ness-network/privateness/src/transaction"
 )
 
 // Transaction wraps around coin.Transaction, tagged with its status.  This allows us
@@ -150,6 +151,18 @@ type UnconfirmedTransaction struct {
 	Announced int64
 	// If this txn is valid
 	IsValid int8
+	// Number of times we announced this txn
+	AnnounceCount uint64
+}
+
+// TxnAnnouncement is the announcement state of an unconfirmed transaction
+type TxnAnnouncement struct {
+	// Time the txn was last announced, in unix nanoseconds
+	Time int64
+	// Number of times the txn was announced
+	Count uint64
+	// Number of peers the txn was sent to when it was last announced, an estimate of how far it propagated
+	Peers uint64
 }
 
 // NewUnconfirmedTransaction creates an UnconfirmedTransaction
@@ -8,7 +8,8 @@ import (
 
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/transaction"
+
+	"github.com/ness-network/privateness/src/transaction"
 )
 
 func TestNewTransactionInputsFromUxBalance(t *testing.T) {
@@ -0,0 +1,106 @@
+package visor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// ErrInvalidPublisherKey is returned when the block publisher role is armed with a secret key
+	// that is not the secret key of the blockchain pubkey
+	ErrInvalidPublisherKey = errors.New("Secret key is not the blockchain secret key")
+	// ErrBlockPublisherArmed is returned when the block publisher role is armed while it is already armed
+	ErrBlockPublisherArmed = errors.New("Node is already a block publisher")
+)
+
+// publisherRole guards Config.IsBlockPublisher and Config.BlockchainSeckey, which are changed
+// when the block publisher role is armed or disarmed at runtime
+type publisherRole struct {
+	sync.RWMutex
+	// configured is true if the visor was created as a block publisher
+	configured bool
+	// changedAt is the last time the role was armed or disarmed at runtime
+	changedAt time.Time
+}
+
+// BlockPublisherStatus is the block publisher role of the node
+type BlockPublisherStatus struct {
+	// Armed is true if the node creates and publishes blocks
+	Armed bool
+	// Configured is true if the node was started as a block publisher
+	Configured bool
+	// BlockchainPubkey is the pubkey that signs the blocks
+	BlockchainPubkey cipher.PubKey
+	// ChangedAt is the last time the role was armed or disarmed at runtime, zero if it was never changed
+	ChangedAt time.Time
+}
+
+// IsBlockPublisher returns true if the block publisher role is armed
+func (vs *Visor) IsBlockPublisher() bool {
+	vs.publisher.RLock()
+	defer vs.publisher.RUnlock()
+	return vs.Config.IsBlockPublisher
+}
+
+// BlockPublisherStatus returns the block publisher role of the node
+func (vs *Visor) BlockPublisherStatus() BlockPublisherStatus {
+	vs.publisher.RLock()
+	defer vs.publisher.RUnlock()
+
+	return BlockPublisherStatus{
+		Armed:            vs.Config.IsBlockPublisher,
+		Configured:       vs.publisher.configured,
+		BlockchainPubkey: vs.Config.BlockchainPubkey,
+		ChangedAt:        vs.publisher.changedAt,
+	}
+}
+
+// ArmBlockPublisher makes the node a block publisher, signing blocks with seckey.
+// seckey must be the secret key of the blockchain pubkey. It is only held in memory.
+func (vs *Visor) ArmBlockPublisher(seckey cipher.SecKey) error {
+	if seckey == (cipher.SecKey{}) {
+		return ErrInvalidPublisherKey
+	}
+
+	pubkey, err := cipher.PubKeyFromSecKey(seckey)
+	if err != nil || pubkey != vs.Config.BlockchainPubkey {
+		return ErrInvalidPublisherKey
+	}
+
+	vs.publisher.Lock()
+	defer vs.publisher.Unlock()
+
+	if vs.Config.IsBlockPublisher {
+		return ErrBlockPublisherArmed
+	}
+
+	vs.Config.BlockchainSeckey = seckey
+	vs.Config.IsBlockPublisher = true
+	vs.publisher.changedAt = time.Now().UTC()
+
+	logger.Info("Visor armed in block publisher mode")
+
+	return nil
+}
+
+// DisarmBlockPublisher stops the node from being a block publisher and forgets the blockchain secret key.
+// Waits for a block being created to be executed. Returns ErrNotBlockPublisher if the role is not armed.
+func (vs *Visor) DisarmBlockPublisher() error {
+	vs.publisher.Lock()
+	defer vs.publisher.Unlock()
+
+	if !vs.Config.IsBlockPublisher {
+		return ErrNotBlockPublisher
+	}
+
+	vs.Config.BlockchainSeckey = cipher.SecKey{}
+	vs.Config.IsBlockPublisher = false
+	vs.publisher.changedAt = time.Now().UTC()
+
+	logger.Info("Visor disarmed from block publisher mode")
+
+	return nil
+}
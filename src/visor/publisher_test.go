@@ -0,0 +1,54 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestArmDisarmBlockPublisher(t *testing.T) {
+	cfg := NewConfig()
+	cfg.BlockchainPubkey = genPublic
+
+	v := &Visor{
+		Config: cfg,
+	}
+
+	status := v.BlockPublisherStatus()
+	require.False(t, status.Armed)
+	require.False(t, status.Configured)
+	require.Equal(t, genPublic, status.BlockchainPubkey)
+	require.True(t, status.ChangedAt.IsZero())
+
+	// Blocks are not created by a node that is not a block publisher
+	_, err := v.CreateAndExecuteBlock()
+	require.Equal(t, ErrNotBlockPublisher, err)
+
+	require.Equal(t, ErrNotBlockPublisher, v.DisarmBlockPublisher())
+
+	// Only the blockchain secret key arms the role
+	_, otherSecret := cipher.GenerateKeyPair()
+	require.Equal(t, ErrInvalidPublisherKey, v.ArmBlockPublisher(otherSecret))
+	require.Equal(t, ErrInvalidPublisherKey, v.ArmBlockPublisher(cipher.SecKey{}))
+	require.False(t, v.IsBlockPublisher())
+
+	require.NoError(t, v.ArmBlockPublisher(genSecret))
+	require.True(t, v.IsBlockPublisher())
+	require.Equal(t, genSecret, v.Config.BlockchainSeckey)
+
+	status = v.BlockPublisherStatus()
+	require.True(t, status.Armed)
+	require.False(t, status.ChangedAt.IsZero())
+
+	require.Equal(t, ErrBlockPublisherArmed, v.ArmBlockPublisher(genSecret))
+
+	// Disarming forgets the secret key
+	require.NoError(t, v.DisarmBlockPublisher())
+	require.False(t, v.IsBlockPublisher())
+	require.Equal(t, cipher.SecKey{}, v.Config.BlockchainSeckey)
+
+	_, err = v.CreateAndExecuteBlock()
+	require.Equal(t, ErrNotBlockPublisher, err)
+}
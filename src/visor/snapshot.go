@@ -0,0 +1,336 @@
+package visor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+const (
+	// SnapshotManifestSuffix is appended to the path of a database snapshot to name its manifest
+	SnapshotManifestSuffix = ".manifest.json"
+
+	snapshotsDirName = "snapshots"
+)
+
+var (
+	// ErrSnapshotEmpty is returned if a database snapshot has no blocks
+	ErrSnapshotEmpty = errors.New("snapshot has no blocks")
+)
+
+// ErrSnapshotGenesisMismatch is returned if the genesis block of a snapshot is not the configured genesis block,
+// which means the snapshot is for another coin
+type ErrSnapshotGenesisMismatch struct {
+	SnapshotGenesisHash string
+	GenesisHash         string
+}
+
+func (e ErrSnapshotGenesisMismatch) Error() string {
+	return fmt.Sprintf("snapshot genesis block hash %s does not match the configured genesis block hash %s, the snapshot is for another coin",
+		e.SnapshotGenesisHash, e.GenesisHash)
+}
+
+// SnapshotManifest describes a database snapshot
+type SnapshotManifest struct {
+	HeadSeq     uint64 `json:"head_seq"`
+	HeadHash    string `json:"head_hash"`
+	GenesisHash string `json:"genesis_hash"`
+	// Size of the snapshot file, in bytes
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded sha256 of the snapshot file
+	SHA256    string `json:"sha256"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Snapshot is a database snapshot written by CreateSnapshot
+type Snapshot struct {
+	Path         string           `json:"path"`
+	ManifestPath string           `json:"manifest_path"`
+	Manifest     SnapshotManifest `json:"manifest"`
+}
+
+// WriteSnapshot writes a consistent copy of the database to w and returns its manifest.
+// The copy is made in a read transaction, so the node keeps running while it is written.
+func (vs *Visor) WriteSnapshot(w io.Writer) (*SnapshotManifest, error) {
+	var m *SnapshotManifest
+	if err := vs.view("WriteSnapshot", func(tx *dbutil.Tx) error {
+		var err error
+		m, err = snapshotManifest(tx, vs.blockchain)
+		if err != nil {
+			return err
+		}
+
+		h := sha256.New()
+		m.Size, err = tx.WriteTo(io.MultiWriter(w, h))
+		if err != nil {
+			return err
+		}
+
+		m.SHA256 = hex.EncodeToString(h.Sum(nil))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	m.CreatedAt = time.Now().UTC().Unix()
+	return m, nil
+}
+
+// CreateSnapshot writes a snapshot of the database and its manifest to Config.SnapshotDirectory.
+// The snapshot is named after the head block sequence and the time of the snapshot.
+func (vs *Visor) CreateSnapshot() (*Snapshot, error) {
+	dir := vs.Config.SnapshotDirectory
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(vs.db.Path()), snapshotsDirName)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(dir, "snapshot-")
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := writeSnapshotFile(f, vs.WriteSnapshot)
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+		return nil, err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("data-%d-%s.db", m.HeadSeq, time.Unix(m.CreatedAt, 0).UTC().Format("20060102T150405Z")))
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+		return nil, err
+	}
+
+	manifestPath := path + SnapshotManifestSuffix
+	if err := SaveSnapshotManifest(manifestPath, m); err != nil {
+		return nil, err
+	}
+
+	logger.WithField("path", path).Infof("Created database snapshot at head seq %d", m.HeadSeq)
+
+	return &Snapshot{
+		Path:         path,
+		ManifestPath: manifestPath,
+		Manifest:     *m,
+	}, nil
+}
+
+// writeSnapshotFile writes a snapshot to f with write, and syncs and closes f
+func writeSnapshotFile(f *os.File, write func(io.Writer) (*SnapshotManifest, error)) (*SnapshotManifest, error) {
+	m, err := write(f)
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return nil, err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close() //nolint:errcheck
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// snapshotManifest returns the manifest of the head and genesis blocks of the database, without the file checksum
+func snapshotManifest(tx *dbutil.Tx, bc Blockchainer) (*SnapshotManifest, error) {
+	genesis, err := bc.GetGenesisBlock(tx)
+	if err != nil {
+		return nil, err
+	}
+	if genesis == nil {
+		return nil, ErrSnapshotEmpty
+	}
+
+	head, err := bc.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnapshotManifest{
+		HeadSeq:     head.Seq(),
+		HeadHash:    head.HashHeader().Hex(),
+		GenesisHash: genesis.HashHeader().Hex(),
+	}, nil
+}
+
+// SaveSnapshotManifest writes a snapshot manifest to a JSON file
+func SaveSnapshotManifest(path string, m *SnapshotManifest) error {
+	b, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(b, '\n'), 0600)
+}
+
+// LoadSnapshotManifest reads a snapshot manifest from a JSON file
+func LoadSnapshotManifest(path string) (*SnapshotManifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m SnapshotManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid snapshot manifest %s: %v", path, err)
+	}
+
+	return &m, nil
+}
+
+// VerifySnapshot checks the size and sha256 of a snapshot file against its manifest,
+// which is read from the snapshot path with SnapshotManifestSuffix appended
+func VerifySnapshot(path string) (*SnapshotManifest, error) {
+	m, err := LoadSnapshotManifest(path + SnapshotManifestSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if err := verifySnapshotChecksum(f, h, m); err != nil {
+		return nil, fmt.Errorf("snapshot %s: %v", path, err)
+	}
+
+	return m, nil
+}
+
+func verifySnapshotChecksum(r io.Reader, h hash.Hash, m *SnapshotManifest) error {
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+
+	if n != m.Size {
+		return fmt.Errorf("size %d does not match the manifest size %d", n, m.Size)
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != m.SHA256 {
+		return fmt.Errorf("sha256 %s does not match the manifest sha256 %s", sum, m.SHA256)
+	}
+
+	return nil
+}
+
+// BootstrapFromSnapshot verifies a snapshot against its manifest and copies it to dbPath.
+// The genesis block of the snapshot must be the block with hash genesisHash.
+// dbPath must not exist, to avoid replacing a node's database.
+// The copied database is not verified beyond its head and genesis blocks, see CheckDatabase.
+func BootstrapFromSnapshot(snapshotPath, dbPath string, genesisHash cipher.SHA256) (*SnapshotManifest, error) {
+	if _, err := os.Stat(dbPath); err == nil {
+		return nil, fmt.Errorf("database %s already exists, remove it to bootstrap from a snapshot", dbPath)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	m, err := VerifySnapshot(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.GenesisHash != genesisHash.Hex() {
+		return nil, ErrSnapshotGenesisMismatch{
+			SnapshotGenesisHash: m.GenesisHash,
+			GenesisHash:         genesisHash.Hex(),
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0700); err != nil {
+		return nil, err
+	}
+
+	tmpPath := dbPath + ".snapshot.tmp"
+	if err := copySnapshot(snapshotPath, tmpPath, m); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return nil, err
+	}
+
+	// The manifest is trusted for its checksum only, check that the blocks of the copy match it
+	if err := checkSnapshotBlocks(tmpPath, m); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return nil, err
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// copySnapshot copies a snapshot to path, checking the checksum of the copied data against the manifest
+func copySnapshot(snapshotPath, path string, m *SnapshotManifest) error {
+	src, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = writeSnapshotFile(dst, func(w io.Writer) (*SnapshotManifest, error) {
+		return m, verifySnapshotChecksum(io.TeeReader(src, w), sha256.New(), m)
+	})
+	return err
+}
+
+// checkSnapshotBlocks checks that the head and genesis blocks of the database at path match the manifest
+func checkSnapshotBlocks(path string, m *SnapshotManifest) error {
+	db, err := OpenDB(path, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{})
+	if err != nil {
+		return err
+	}
+
+	var dbm *SnapshotManifest
+	if err := db.View("checkSnapshotBlocks", func(tx *dbutil.Tx) error {
+		var err error
+		dbm, err = snapshotManifest(tx, bc)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if dbm.GenesisHash != m.GenesisHash {
+		return fmt.Errorf("snapshot genesis block hash %s does not match the manifest genesis hash %s", dbm.GenesisHash, m.GenesisHash)
+	}
+
+	if dbm.HeadSeq != m.HeadSeq || dbm.HeadHash != m.HeadHash {
+		return fmt.Errorf("snapshot head block %d %s does not match the manifest head block %d %s", dbm.HeadSeq, dbm.HeadHash, m.HeadSeq, m.HeadHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package visor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+func TestSnapshot(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey: genPublic,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := NewConfig()
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.SnapshotDirectory = filepath.Join(dir, "snapshots")
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	// A database without blocks can't be snapshotted
+	_, err = v.CreateSnapshot()
+	require.Equal(t, ErrSnapshotEmpty, err)
+
+	gb := addGenesisBlockToVisor(t, v)
+
+	s, err := v.CreateSnapshot()
+	require.NoError(t, err)
+	require.Equal(t, cfg.SnapshotDirectory, filepath.Dir(s.Path))
+	require.Equal(t, s.Path+SnapshotManifestSuffix, s.ManifestPath)
+	require.Equal(t, uint64(0), s.Manifest.HeadSeq)
+	require.Equal(t, gb.HashHeader().Hex(), s.Manifest.HeadHash)
+	require.Equal(t, gb.HashHeader().Hex(), s.Manifest.GenesisHash)
+	require.NotZero(t, s.Manifest.Size)
+
+	m, err := LoadSnapshotManifest(s.ManifestPath)
+	require.NoError(t, err)
+	require.Equal(t, s.Manifest, *m)
+
+	m, err = VerifySnapshot(s.Path)
+	require.NoError(t, err)
+	require.Equal(t, s.Manifest, *m)
+
+	// Bootstrap a new database from the snapshot
+	dbPath := filepath.Join(dir, "node", "data.db")
+	m, err = BootstrapFromSnapshot(s.Path, dbPath, gb.HashHeader())
+	require.NoError(t, err)
+	require.Equal(t, s.Manifest, *m)
+
+	newDB, err := OpenDB(dbPath, true)
+	require.NoError(t, err)
+	err = newDB.View("", func(tx *dbutil.Tx) error {
+		newBc, err := NewBlockchain(newDB, BlockchainConfig{})
+		require.NoError(t, err)
+		head, err := newBc.Head(tx)
+		require.NoError(t, err)
+		require.Equal(t, gb.HashHeader(), head.HashHeader())
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, newDB.Close())
+
+	// The database must not exist
+	_, err = BootstrapFromSnapshot(s.Path, dbPath, gb.HashHeader())
+	testutil.RequireError(t, err, "database "+dbPath+" already exists, remove it to bootstrap from a snapshot")
+
+	// The snapshot must be of the configured coin
+	otherGenesisHash := testutil.RandSHA256(t)
+	_, err = BootstrapFromSnapshot(s.Path, filepath.Join(dir, "other.db"), otherGenesisHash)
+	require.Equal(t, ErrSnapshotGenesisMismatch{
+		SnapshotGenesisHash: gb.HashHeader().Hex(),
+		GenesisHash:         otherGenesisHash.Hex(),
+	}, err)
+	_, err = os.Stat(filepath.Join(dir, "other.db"))
+	require.True(t, os.IsNotExist(err))
+
+	// A modified snapshot fails the verification
+	b, err := ioutil.ReadFile(s.Path)
+	require.NoError(t, err)
+	b[len(b)-1]++
+	require.NoError(t, ioutil.WriteFile(s.Path, b, 0600))
+
+	_, err = VerifySnapshot(s.Path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match the manifest sha256")
+
+	_, err = BootstrapFromSnapshot(s.Path, filepath.Join(dir, "other.db"), gb.HashHeader())
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "other.db"))
+	require.True(t, os.IsNotExist(err))
+
+	// WriteSnapshot writes the same data as CreateSnapshot
+	var buf bytes.Buffer
+	m, err = v.WriteSnapshot(&buf)
+	require.NoError(t, err)
+	require.Equal(t, s.Manifest.SHA256, m.SHA256)
+	require.Equal(t, int64(buf.Len()), m.Size)
+}
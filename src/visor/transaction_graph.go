@@ -0,0 +1,262 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// TxnGraphDirection is the direction of a transaction graph traversal from an output
+type TxnGraphDirection string
+
+const (
+	// TxnGraphAncestry walks backwards, from an output to the inputs of the transaction that created it
+	TxnGraphAncestry TxnGraphDirection = "ancestry"
+	// TxnGraphDescendants walks forwards, from an output to the outputs of the transaction that spent it
+	TxnGraphDescendants TxnGraphDirection = "descendants"
+)
+
+const (
+	// TxnGraphCreatedBy is the kind of the edge from an output to the transaction that created it
+	TxnGraphCreatedBy = "created_by"
+	// TxnGraphSpentBy is the kind of the edge from an output to the transaction that spent it
+	TxnGraphSpentBy = "spent_by"
+)
+
+const (
+	// TxnGraphTruncatedDepth marks an output whose neighbours are further than TxnGraphOptions.Depth
+	TxnGraphTruncatedDepth = "depth"
+	// TxnGraphTruncatedMaxNodes marks an output whose neighbours were not all added because of TxnGraphOptions.MaxNodes
+	TxnGraphTruncatedMaxNodes = "max_nodes"
+)
+
+var (
+	// ErrInvalidTxnGraphDirection is returned for a TxnGraphOptions.Direction other than ancestry or descendants
+	ErrInvalidTxnGraphDirection = NewUserError(errors.New("invalid transaction graph direction"))
+	// ErrInvalidTxnGraphDepth is returned for a negative TxnGraphOptions.Depth
+	ErrInvalidTxnGraphDepth = NewUserError(errors.New("transaction graph depth must be >= 0"))
+	// ErrInvalidTxnGraphMaxNodes is returned for a TxnGraphOptions.MaxNodes less than 1
+	ErrInvalidTxnGraphMaxNodes = NewUserError(errors.New("transaction graph max nodes must be >= 1"))
+)
+
+// TxnGraphOptions bound a transaction graph traversal
+type TxnGraphOptions struct {
+	Direction TxnGraphDirection
+	// Depth is the maximum number of transactions between the root output and an output of the graph
+	Depth int
+	// MaxNodes is the maximum number of outputs of the graph, including the root output
+	MaxNodes int
+}
+
+// TxnGraphNode is an output of a transaction graph
+type TxnGraphNode struct {
+	historydb.UxOut
+	// Depth is the number of transactions between the root output and this output
+	Depth int
+	// Truncated is why the neighbours of this output are not all in the graph, empty if they are
+	Truncated string
+}
+
+// TxnGraphEdge links an output to the transaction that created or spent it
+type TxnGraphEdge struct {
+	Kind string
+	UxID cipher.SHA256
+	Txid cipher.SHA256
+}
+
+// TxnGraph is the directed acyclic graph of the outputs reached from an output
+// by following the transactions that created (ancestry) or spent (descendants) them.
+// The nodes are in breadth-first order, the root output first.
+type TxnGraph struct {
+	Root      cipher.SHA256
+	Direction TxnGraphDirection
+	Nodes     []TxnGraphNode
+	Edges     []TxnGraphEdge
+	// Truncated is true if any node is truncated
+	Truncated bool
+}
+
+// GetTxnGraph walks the history of the confirmed output uxid in the direction of opts, breadth first.
+// The walk stops at opts.Depth transactions from the root output and at opts.MaxNodes outputs,
+// marking the outputs whose neighbours were left out as truncated.
+// Outputs only ever spend older outputs so the graph can't have cycles,
+// but a transaction is expanded once even if several outputs of the graph lead to it.
+func (vs *Visor) GetTxnGraph(uxid cipher.SHA256, opts TxnGraphOptions) (*TxnGraph, error) {
+	switch opts.Direction {
+	case TxnGraphAncestry, TxnGraphDescendants:
+	default:
+		return nil, ErrInvalidTxnGraphDirection
+	}
+	if opts.Depth < 0 {
+		return nil, ErrInvalidTxnGraphDepth
+	}
+	if opts.MaxNodes < 1 {
+		return nil, ErrInvalidTxnGraphMaxNodes
+	}
+
+	var g *TxnGraph
+
+	if err := vs.view("GetTxnGraph", func(tx *dbutil.Tx) error {
+		outs, err := vs.history.GetUxOuts(tx, []cipher.SHA256{uxid})
+		if err != nil {
+			return err
+		}
+
+		b := txnGraphBuilder{
+			tx:      tx,
+			history: vs.history,
+			opts:    opts,
+			graph: &TxnGraph{
+				Root:      uxid,
+				Direction: opts.Direction,
+			},
+			nodes: make(map[cipher.SHA256]struct{}),
+			txns:  make(map[cipher.SHA256]struct{}),
+		}
+
+		b.addNode(outs[0], 0)
+
+		// Nodes are appended while they are expanded, which makes the walk breadth first
+		for i := 0; i < len(b.graph.Nodes); i++ {
+			if err := b.expand(i); err != nil {
+				return err
+			}
+		}
+
+		g = b.graph
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// txnGraphBuilder builds a TxnGraph in a database transaction
+type txnGraphBuilder struct {
+	tx      *dbutil.Tx
+	history Historyer
+	opts    TxnGraphOptions
+	graph   *TxnGraph
+	// nodes are the outputs in the graph
+	nodes map[cipher.SHA256]struct{}
+	// txns are the expanded transactions
+	txns map[cipher.SHA256]struct{}
+}
+
+func (b *txnGraphBuilder) addNode(o historydb.UxOut, depth int) {
+	b.nodes[o.Hash()] = struct{}{}
+	b.graph.Nodes = append(b.graph.Nodes, TxnGraphNode{
+		UxOut: o,
+		Depth: depth,
+	})
+}
+
+func (b *txnGraphBuilder) addEdge(kind string, uxid, txid cipher.SHA256) {
+	b.graph.Edges = append(b.graph.Edges, TxnGraphEdge{
+		Kind: kind,
+		UxID: uxid,
+		Txid: txid,
+	})
+}
+
+func (b *txnGraphBuilder) truncate(i int, reason string) {
+	b.graph.Nodes[i].Truncated = reason
+	b.graph.Truncated = true
+}
+
+// expand adds the transaction linking the i-th node to its neighbours, and the neighbours
+func (b *txnGraphBuilder) expand(i int) error {
+	n := b.graph.Nodes[i]
+
+	// The transaction to expand, and the kinds of the edges from the node and from its neighbours to it
+	var txid cipher.SHA256
+	var nodeEdge, neighbourEdge string
+	switch b.opts.Direction {
+	case TxnGraphAncestry:
+		// The outputs of the genesis block have no source transaction
+		txid = n.Out.Body.SrcTransaction
+		nodeEdge, neighbourEdge = TxnGraphCreatedBy, TxnGraphSpentBy
+	case TxnGraphDescendants:
+		// Unspent outputs have no spending transaction
+		txid = n.SpentTxnID
+		nodeEdge, neighbourEdge = TxnGraphSpentBy, TxnGraphCreatedBy
+	}
+
+	if txid == (cipher.SHA256{}) {
+		return nil
+	}
+
+	b.addEdge(nodeEdge, n.Hash(), txid)
+
+	if _, ok := b.txns[txid]; ok {
+		return nil
+	}
+
+	if n.Depth >= b.opts.Depth {
+		b.truncate(i, TxnGraphTruncatedDepth)
+		return nil
+	}
+
+	htxn, err := b.history.GetTransaction(b.tx, txid)
+	if err != nil {
+		return err
+	}
+	if htxn == nil {
+		return fmt.Errorf("transaction %s of output %s does not exist in history db", txid.Hex(), n.Hash().Hex())
+	}
+
+	b.txns[txid] = struct{}{}
+
+	var neighbours []cipher.SHA256
+	switch b.opts.Direction {
+	case TxnGraphAncestry:
+		neighbours = htxn.Txn.In
+	case TxnGraphDescendants:
+		for _, ux := range coin.CreateUnspents(coin.BlockHeader{BkSeq: htxn.BlockSeq}, htxn.Txn) {
+			neighbours = append(neighbours, ux.Hash())
+		}
+	}
+
+	// Add the neighbours that are not in the graph yet, up to MaxNodes
+	var added []cipher.SHA256
+	addedSet := make(map[cipher.SHA256]struct{})
+	for _, id := range neighbours {
+		if _, ok := b.nodes[id]; ok {
+			continue
+		}
+		if _, ok := addedSet[id]; ok {
+			continue
+		}
+		if len(b.graph.Nodes)+len(added) >= b.opts.MaxNodes {
+			b.truncate(i, TxnGraphTruncatedMaxNodes)
+			break
+		}
+		added = append(added, id)
+		addedSet[id] = struct{}{}
+	}
+
+	if len(added) > 0 {
+		outs, err := b.history.GetUxOuts(b.tx, added)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range outs {
+			b.addNode(o, n.Depth+1)
+		}
+	}
+
+	// Only the edges between outputs in the graph are added
+	for _, id := range neighbours {
+		if _, ok := b.nodes[id]; ok {
+			b.addEdge(neighbourEdge, id, txid)
+		}
+	}
+
+	return nil
+}
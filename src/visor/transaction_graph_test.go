@@ -0,0 +1,251 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+func TestGetTxnGraph(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	history := historydb.New()
+
+	makeTxn := func(in []cipher.SHA256, nOut int) coin.Transaction {
+		var txn coin.Transaction
+		for _, h := range in {
+			require.NoError(t, txn.PushInput(h))
+		}
+		for i := 0; i < nOut; i++ {
+			require.NoError(t, txn.PushOutput(testutil.MakeAddress(), 1e6, 10))
+		}
+		require.NoError(t, txn.UpdateHeader())
+		return txn
+	}
+
+	// The outputs of each transaction, by transaction name
+	outs := make(map[string][]cipher.SHA256)
+	txids := make(map[string]cipher.SHA256)
+	parse := func(seq uint64, name string, txn coin.Transaction) {
+		b := coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq: seq,
+				Time:  100 * seq,
+			},
+			Body: coin.BlockBody{
+				Transactions: coin.Transactions{txn},
+			},
+		}
+
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return history.ParseBlock(tx, b)
+		})
+		require.NoError(t, err)
+
+		for _, ux := range coin.CreateUnspents(b.Head, txn) {
+			outs[name] = append(outs[name], ux.Hash())
+		}
+		txids[name] = txn.Hash()
+	}
+
+	// genesis -> a -> b (spends both outputs of a) -> c
+	parse(0, "genesis", makeTxn(nil, 1))
+	parse(1, "a", makeTxn(outs["genesis"], 2))
+	parse(2, "b", makeTxn(outs["a"], 1))
+	parse(3, "c", makeTxn(outs["b"], 3))
+
+	v := &Visor{
+		db:      db,
+		history: history,
+	}
+
+	type node struct {
+		uxid      cipher.SHA256
+		depth     int
+		truncated string
+	}
+
+	type edge = TxnGraphEdge
+
+	created := func(uxid cipher.SHA256, txn string) edge {
+		return edge{Kind: TxnGraphCreatedBy, UxID: uxid, Txid: txids[txn]}
+	}
+	spent := func(uxid cipher.SHA256, txn string) edge {
+		return edge{Kind: TxnGraphSpentBy, UxID: uxid, Txid: txids[txn]}
+	}
+
+	cases := []struct {
+		name      string
+		uxid      cipher.SHA256
+		opts      TxnGraphOptions
+		nodes     []node
+		edges     []edge
+		truncated bool
+		err       error
+	}{
+		{
+			name: "ancestry",
+			uxid: outs["c"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphAncestry, Depth: 10, MaxNodes: 100},
+			nodes: []node{
+				{uxid: outs["c"][0]},
+				{uxid: outs["b"][0], depth: 1},
+				{uxid: outs["a"][0], depth: 2},
+				{uxid: outs["a"][1], depth: 2},
+				{uxid: outs["genesis"][0], depth: 3},
+			},
+			edges: []edge{
+				created(outs["c"][0], "c"),
+				spent(outs["b"][0], "c"),
+				created(outs["b"][0], "b"),
+				spent(outs["a"][0], "b"),
+				spent(outs["a"][1], "b"),
+				created(outs["a"][0], "a"),
+				spent(outs["genesis"][0], "a"),
+				// The transaction a was already expanded from the other output
+				created(outs["a"][1], "a"),
+			},
+		},
+		{
+			name: "ancestry depth",
+			uxid: outs["c"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphAncestry, Depth: 1, MaxNodes: 100},
+			nodes: []node{
+				{uxid: outs["c"][0]},
+				{uxid: outs["b"][0], depth: 1, truncated: TxnGraphTruncatedDepth},
+			},
+			edges: []edge{
+				created(outs["c"][0], "c"),
+				spent(outs["b"][0], "c"),
+				created(outs["b"][0], "b"),
+			},
+			truncated: true,
+		},
+		{
+			name: "ancestry max nodes",
+			uxid: outs["c"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphAncestry, Depth: 10, MaxNodes: 3},
+			nodes: []node{
+				{uxid: outs["c"][0]},
+				{uxid: outs["b"][0], depth: 1, truncated: TxnGraphTruncatedMaxNodes},
+				{uxid: outs["a"][0], depth: 2, truncated: TxnGraphTruncatedMaxNodes},
+			},
+			edges: []edge{
+				created(outs["c"][0], "c"),
+				spent(outs["b"][0], "c"),
+				created(outs["b"][0], "b"),
+				spent(outs["a"][0], "b"),
+				created(outs["a"][0], "a"),
+			},
+			truncated: true,
+		},
+		{
+			name: "ancestry of a genesis output",
+			uxid: outs["genesis"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphAncestry, Depth: 10, MaxNodes: 100},
+			nodes: []node{
+				{uxid: outs["genesis"][0]},
+			},
+		},
+		{
+			name: "descendants",
+			uxid: outs["genesis"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphDescendants, Depth: 10, MaxNodes: 100},
+			nodes: []node{
+				{uxid: outs["genesis"][0]},
+				{uxid: outs["a"][0], depth: 1},
+				{uxid: outs["a"][1], depth: 1},
+				{uxid: outs["b"][0], depth: 2},
+				{uxid: outs["c"][0], depth: 3},
+				{uxid: outs["c"][1], depth: 3},
+				{uxid: outs["c"][2], depth: 3},
+			},
+			edges: []edge{
+				spent(outs["genesis"][0], "a"),
+				created(outs["a"][0], "a"),
+				created(outs["a"][1], "a"),
+				spent(outs["a"][0], "b"),
+				created(outs["b"][0], "b"),
+				spent(outs["a"][1], "b"),
+				spent(outs["b"][0], "c"),
+				created(outs["c"][0], "c"),
+				created(outs["c"][1], "c"),
+				created(outs["c"][2], "c"),
+			},
+		},
+		{
+			name: "descendants depth",
+			uxid: outs["genesis"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphDescendants, Depth: 0, MaxNodes: 100},
+			nodes: []node{
+				{uxid: outs["genesis"][0], truncated: TxnGraphTruncatedDepth},
+			},
+			edges: []edge{
+				spent(outs["genesis"][0], "a"),
+			},
+			truncated: true,
+		},
+		{
+			name: "descendants of an unspent output",
+			uxid: outs["c"][1],
+			opts: TxnGraphOptions{Direction: TxnGraphDescendants, Depth: 10, MaxNodes: 100},
+			nodes: []node{
+				{uxid: outs["c"][1]},
+			},
+		},
+		{
+			name: "unknown output",
+			uxid: testutil.RandSHA256(t),
+			opts: TxnGraphOptions{Direction: TxnGraphAncestry, Depth: 10, MaxNodes: 100},
+			err:  historydb.ErrUxOutNotExist{},
+		},
+		{
+			name: "invalid direction",
+			uxid: outs["c"][0],
+			opts: TxnGraphOptions{Direction: "sideways", Depth: 10, MaxNodes: 100},
+			err:  ErrInvalidTxnGraphDirection,
+		},
+		{
+			name: "invalid max nodes",
+			uxid: outs["c"][0],
+			opts: TxnGraphOptions{Direction: TxnGraphAncestry, Depth: 10},
+			err:  ErrInvalidTxnGraphMaxNodes,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := v.GetTxnGraph(tc.uxid, tc.opts)
+			if tc.err != nil {
+				require.IsType(t, tc.err, err)
+				if _, ok := tc.err.(historydb.ErrUxOutNotExist); !ok {
+					require.Equal(t, tc.err, err)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.uxid, g.Root)
+			require.Equal(t, tc.opts.Direction, g.Direction)
+			require.Equal(t, tc.truncated, g.Truncated)
+			require.Equal(t, tc.edges, g.Edges)
+
+			nodes := make([]node, len(g.Nodes))
+			for i, n := range g.Nodes {
+				nodes[i] = node{
+					uxid:      n.Hash(),
+					depth:     n.Depth,
+					truncated: n.Truncated,
+				}
+			}
+			require.Equal(t, tc.nodes, nodes)
+		})
+	}
+}
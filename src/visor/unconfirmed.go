@@ -1,11 +1,13 @@
 package visor
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
@@ -16,8 +18,23 @@ var (
 	UnconfirmedTxnsBkt = []byte("unconfirmed_txns")
 	// UnconfirmedUnspentsBkt holds unconfirmed unspent outputs
 	UnconfirmedUnspentsBkt = []byte("unconfirmed_unspents")
+	// UnconfirmedSpendsBkt maps the uxids spent by unconfirmed transactions to the hashes of those transactions
+	UnconfirmedSpendsBkt = []byte("unconfirmed_spends")
+	// UnconfirmedOriginsBkt records where each unconfirmed transaction entered the pool from
+	UnconfirmedOriginsBkt = []byte("unconfirmed_origins")
+	// UnconfirmedAbandonedBkt records the transactions abandoned by the user and until when they are refused from peers
+	UnconfirmedAbandonedBkt = []byte("unconfirmed_abandoned")
+	// UnconfirmedAnnouncedPeersBkt records the number of peers each unconfirmed transaction was last announced to
+	UnconfirmedAnnouncedPeersBkt = []byte("unconfirmed_announced_peers")
 
 	errUpdateObjectDoesNotExist = errors.New("object does not exist in bucket")
+
+	// ErrUnconfirmedTxnNotFound is returned if a transaction is not in the unconfirmed pool
+	ErrUnconfirmedTxnNotFound = errors.New("transaction is not in the unconfirmed pool")
+	// ErrUnconfirmedTxnNotLocal is returned when abandoning an unconfirmed transaction that was relayed by a peer
+	ErrUnconfirmedTxnNotLocal = errors.New("transaction was relayed by a peer, only locally created transactions can be abandoned")
+	// ErrUnconfirmedTxnAbandoned is returned when a peer relays a transaction that the user abandoned recently
+	ErrUnconfirmedTxnAbandoned = errors.New("transaction was abandoned by the user of this node")
 )
 
 //go:generate skyencoder -unexported -struct UnconfirmedTransaction
@@ -41,7 +58,7 @@ func (utb *unconfirmedTxns) get(tx *dbutil.Tx, hash cipher.SHA256) (*Unconfirmed
 		return nil, nil
 	}
 
-	if err := decodeUnconfirmedTransactionExact(v, &txn); err != nil {
+	if err := decodeUnconfirmedTxnRecord(v, &txn); err != nil {
 		return nil, err
 	}
 
@@ -89,7 +106,7 @@ func (utb *unconfirmedTxns) getAll(tx *dbutil.Tx) ([]UnconfirmedTransaction, err
 
 	if err := dbutil.ForEach(tx, UnconfirmedTxnsBkt, func(_, v []byte) error {
 		var txn UnconfirmedTransaction
-		if err := decodeUnconfirmedTransactionExact(v, &txn); err != nil {
+		if err := decodeUnconfirmedTxnRecord(v, &txn); err != nil {
 			return err
 		}
 
@@ -114,7 +131,7 @@ func (utb *unconfirmedTxns) forEach(tx *dbutil.Tx, f func(hash cipher.SHA256, tx
 		}
 
 		var txn UnconfirmedTransaction
-		if err := decodeUnconfirmedTransactionExact(v, &txn); err != nil {
+		if err := decodeUnconfirmedTxnRecord(v, &txn); err != nil {
 			return err
 		}
 
@@ -126,6 +143,65 @@ func (utb *unconfirmedTxns) len(tx *dbutil.Tx) (uint64, error) {
 	return dbutil.Len(tx, UnconfirmedTxnsBkt)
 }
 
+// legacyAnnounceCountSize is the size of UnconfirmedTransaction.AnnounceCount,
+// which is missing from the records written before announcements were counted
+const legacyAnnounceCountSize = 8
+
+// decodeUnconfirmedTxnRecord decodes an UnconfirmedTransaction record.
+// Records written before announcements were counted are decoded with an AnnounceCount of 0,
+// so that a read-only DB that can't be migrated is still readable.
+func decodeUnconfirmedTxnRecord(v []byte, txn *UnconfirmedTransaction) error {
+	err := decodeUnconfirmedTransactionExact(v, txn)
+	if err != encoder.ErrBufferUnderflow {
+		return err
+	}
+
+	if decodeUnconfirmedTransactionExact(upgradeLegacyUnconfirmedTxnRecord(v), txn) != nil {
+		return err
+	}
+
+	return nil
+}
+
+// upgradeLegacyUnconfirmedTxnRecord converts a record written before announcements were counted
+// to the current layout, by appending an AnnounceCount of 0
+func upgradeLegacyUnconfirmedTxnRecord(v []byte) []byte {
+	upgraded := make([]byte, len(v)+legacyAnnounceCountSize)
+	copy(upgraded, v)
+	return upgraded
+}
+
+// migrateLegacy rewrites the records written before announcements were counted with the current layout.
+// Returns the number of migrated records.
+func (utb *unconfirmedTxns) migrateLegacy(tx *dbutil.Tx) (int, error) {
+	var legacy []UnconfirmedTransaction
+	if err := dbutil.ForEach(tx, UnconfirmedTxnsBkt, func(_, v []byte) error {
+		var txn UnconfirmedTransaction
+		if err := decodeUnconfirmedTransactionExact(v, &txn); err == nil {
+			return nil
+		} else if err != encoder.ErrBufferUnderflow {
+			return err
+		}
+
+		if err := decodeUnconfirmedTxnRecord(v, &txn); err != nil {
+			return err
+		}
+
+		legacy = append(legacy, txn)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	for i := range legacy {
+		if err := utb.put(tx, &legacy[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(legacy), nil
+}
+
 type txnUnspents struct{}
 
 func (txus *txnUnspents) put(tx *dbutil.Tx, hash cipher.SHA256, uxs coin.UxArray) error {
@@ -166,6 +242,256 @@ func (txus *txnUnspents) getByAddr(tx *dbutil.Tx, a cipher.Address) (coin.UxArra
 	return uxo, nil
 }
 
+// txnSpends indexes the unconfirmed transactions spending each uxid.
+// Values are the concatenated bytes of the spending transaction hashes.
+type txnSpends struct{}
+
+func (txs *txnSpends) get(tx *dbutil.Tx, uxid cipher.SHA256) ([]cipher.SHA256, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnconfirmedSpendsBkt, []byte(uxid.Hex()))
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSpendHashes(v)
+}
+
+func (txs *txnSpends) put(tx *dbutil.Tx, uxid cipher.SHA256, hashes []cipher.SHA256) error {
+	if len(hashes) == 0 {
+		return dbutil.Delete(tx, UnconfirmedSpendsBkt, []byte(uxid.Hex()))
+	}
+
+	buf := make([]byte, 0, len(hashes)*len(cipher.SHA256{}))
+	for _, h := range hashes {
+		buf = append(buf, h[:]...)
+	}
+
+	return dbutil.PutBucketValue(tx, UnconfirmedSpendsBkt, []byte(uxid.Hex()), buf)
+}
+
+// add records that txn spends its inputs
+func (txs *txnSpends) add(tx *dbutil.Tx, txn coin.Transaction) error {
+	hash := txn.Hash()
+	for _, uxid := range txn.In {
+		hashes, err := txs.get(tx, uxid)
+		if err != nil {
+			return err
+		}
+
+		if containsHash(hashes, hash) {
+			continue
+		}
+
+		if err := txs.put(tx, uxid, append(hashes, hash)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remove deletes txn from the spenders of its inputs
+func (txs *txnSpends) remove(tx *dbutil.Tx, txn coin.Transaction) error {
+	hash := txn.Hash()
+	for _, uxid := range txn.In {
+		hashes, err := txs.get(tx, uxid)
+		if err != nil {
+			return err
+		}
+
+		kept := hashes[:0]
+		for _, h := range hashes {
+			if h != hash {
+				kept = append(kept, h)
+			}
+		}
+
+		if err := txs.put(tx, uxid, kept); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (txs *txnSpends) forEach(tx *dbutil.Tx, f func(uxid cipher.SHA256, hashes []cipher.SHA256) error) error {
+	return dbutil.ForEach(tx, UnconfirmedSpendsBkt, func(k, v []byte) error {
+		uxid, err := cipher.SHA256FromHex(string(k))
+		if err != nil {
+			return err
+		}
+
+		hashes, err := decodeSpendHashes(v)
+		if err != nil {
+			return err
+		}
+
+		return f(uxid, hashes)
+	})
+}
+
+func (txs *txnSpends) reset(tx *dbutil.Tx) error {
+	return dbutil.Reset(tx, UnconfirmedSpendsBkt)
+}
+
+func decodeSpendHashes(v []byte) ([]cipher.SHA256, error) {
+	n := len(cipher.SHA256{})
+	if len(v)%n != 0 {
+		return nil, fmt.Errorf("invalid unconfirmed spends value length %d", len(v))
+	}
+
+	hashes := make([]cipher.SHA256, 0, len(v)/n)
+	for i := 0; i < len(v); i += n {
+		hashes = append(hashes, cipher.MustSHA256FromBytes(v[i:i+n]))
+	}
+
+	return hashes, nil
+}
+
+func containsHash(hashes []cipher.SHA256, h cipher.SHA256) bool {
+	for _, x := range hashes {
+		if x == h {
+			return true
+		}
+	}
+	return false
+}
+
+// TxnOrigin is where an unconfirmed transaction entered the pool from
+type TxnOrigin byte
+
+const (
+	// TxnOriginPeer transactions were relayed by a peer
+	TxnOriginPeer TxnOrigin = iota
+	// TxnOriginLocal transactions were created or injected by the user of this node
+	TxnOriginLocal
+)
+
+func (o TxnOrigin) String() string {
+	switch o {
+	case TxnOriginPeer:
+		return "peer"
+	case TxnOriginLocal:
+		return "local"
+	default:
+		return fmt.Sprintf("TxnOrigin(%d)", byte(o))
+	}
+}
+
+// UnconfirmedTxnOrigin records where and when an unconfirmed transaction entered the pool
+type UnconfirmedTxnOrigin struct {
+	Origin TxnOrigin
+	// Time the txn was first added to the pool, in unix nanoseconds
+	Added int64
+}
+
+// txnOrigins records the origin of the transactions in the pool.
+// Values are the origin byte followed by the big-endian added time.
+// Transactions added before origins were recorded have no entry and are treated as relayed by a peer.
+type txnOrigins struct{}
+
+func (txo *txnOrigins) get(tx *dbutil.Tx, hash cipher.SHA256) (*UnconfirmedTxnOrigin, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnconfirmedOriginsBkt, []byte(hash.Hex()))
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, nil
+	}
+
+	if len(v) != 9 {
+		return nil, fmt.Errorf("invalid unconfirmed origin value length %d", len(v))
+	}
+
+	return &UnconfirmedTxnOrigin{
+		Origin: TxnOrigin(v[0]),
+		Added:  int64(binary.BigEndian.Uint64(v[1:])),
+	}, nil
+}
+
+func (txo *txnOrigins) put(tx *dbutil.Tx, hash cipher.SHA256, o UnconfirmedTxnOrigin) error {
+	v := make([]byte, 9)
+	v[0] = byte(o.Origin)
+	binary.BigEndian.PutUint64(v[1:], uint64(o.Added))
+	return dbutil.PutBucketValue(tx, UnconfirmedOriginsBkt, []byte(hash.Hex()), v)
+}
+
+func (txo *txnOrigins) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, UnconfirmedOriginsBkt, []byte(hash.Hex()))
+}
+
+// abandonedTxns records the transactions abandoned by the user, which are refused from peers until they expire.
+// Values are the big-endian expiry time in unix nanoseconds.
+type abandonedTxns struct{}
+
+func (atx *abandonedTxns) get(tx *dbutil.Tx, hash cipher.SHA256) (time.Time, bool, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnconfirmedAbandonedBkt, []byte(hash.Hex()))
+	if err != nil {
+		return time.Time{}, false, err
+	} else if v == nil {
+		return time.Time{}, false, nil
+	}
+
+	if len(v) != 8 {
+		return time.Time{}, false, fmt.Errorf("invalid abandoned txn value length %d", len(v))
+	}
+
+	return nanoToTime(int64(binary.BigEndian.Uint64(v))), true, nil
+}
+
+func (atx *abandonedTxns) put(tx *dbutil.Tx, hash cipher.SHA256, until time.Time) error {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(until.UnixNano()))
+	return dbutil.PutBucketValue(tx, UnconfirmedAbandonedBkt, []byte(hash.Hex()), v)
+}
+
+func (atx *abandonedTxns) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, UnconfirmedAbandonedBkt, []byte(hash.Hex()))
+}
+
+// expired returns the keys of the records that expired at now
+func (atx *abandonedTxns) expired(tx *dbutil.Tx, now time.Time) ([][]byte, error) {
+	var keys [][]byte
+	if err := dbutil.ForEach(tx, UnconfirmedAbandonedBkt, func(k, v []byte) error {
+		if len(v) != 8 || !now.Before(nanoToTime(int64(binary.BigEndian.Uint64(v)))) {
+			keys = append(keys, append([]byte{}, k...))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// announcedPeers records the number of peers each transaction was last announced to,
+// completing the announce time and count of the transaction's record.
+// Values are the big-endian number of peers.
+// Transactions announced before the peers were recorded have no entry and were announced to 0 peers.
+type announcedPeers struct{}
+
+func (ap *announcedPeers) get(tx *dbutil.Tx, hash cipher.SHA256) (uint64, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnconfirmedAnnouncedPeersBkt, []byte(hash.Hex()))
+	if err != nil {
+		return 0, err
+	} else if v == nil {
+		return 0, nil
+	}
+
+	if len(v) != 8 {
+		return 0, fmt.Errorf("invalid announced peers value length %d", len(v))
+	}
+
+	return binary.BigEndian.Uint64(v), nil
+}
+
+func (ap *announcedPeers) put(tx *dbutil.Tx, hash cipher.SHA256, peers uint64) error {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, peers)
+	return dbutil.PutBucketValue(tx, UnconfirmedAnnouncedPeersBkt, []byte(hash.Hex()), v)
+}
+
+func (ap *announcedPeers) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, UnconfirmedAnnouncedPeersBkt, []byte(hash.Hex()))
+}
+
 // UnconfirmedTransactionPool manages unconfirmed transactions
 type UnconfirmedTransactionPool struct {
 	db   *dbutil.DB
@@ -174,6 +500,14 @@ type UnconfirmedTransactionPool struct {
 	// our future balance and avoid double spending our own coins
 	// Maps from Transaction.Hash() to UxArray.
 	unspent *txnUnspents
+	// Index of the unconfirmed transactions spending each uxid, used to find double spends
+	spends *txnSpends
+	// Where each transaction entered the pool from
+	origins *txnOrigins
+	// Transactions abandoned by the user, refused from peers for a while
+	abandoned *abandonedTxns
+	// Number of peers each transaction was last announced to
+	announcedPeers *announcedPeers
 }
 
 // NewUnconfirmedTransactionPool creates an UnconfirmedTransactionPool instance
@@ -190,17 +524,40 @@ func NewUnconfirmedTransactionPool(db *dbutil.DB) (*UnconfirmedTransactionPool,
 		return nil, err
 	}
 
+	txns := &unconfirmedTxns{}
+
+	if !db.IsReadOnly() {
+		if err := db.Update("Migrate unconfirmed txn pool", func(tx *dbutil.Tx) error {
+			n, err := txns.migrateLegacy(tx)
+			if err != nil {
+				return err
+			}
+
+			if n > 0 {
+				logger.Infof("Migrated %d unconfirmed transactions to the announce count record layout", n)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	return &UnconfirmedTransactionPool{
-		db:      db,
-		txns:    &unconfirmedTxns{},
-		unspent: &txnUnspents{},
+		db:             db,
+		txns:           txns,
+		unspent:        &txnUnspents{},
+		spends:         &txnSpends{},
+		origins:        &txnOrigins{},
+		abandoned:      &abandonedTxns{},
+		announcedPeers: &announcedPeers{},
 	}, nil
 }
 
-// SetTransactionsAnnounced updates announced time of specific tx
-func (utp *UnconfirmedTransactionPool) SetTransactionsAnnounced(tx *dbutil.Tx, hashes map[cipher.SHA256]int64) error {
-	var txns []*UnconfirmedTransaction
-	for h, t := range hashes {
+// SetTransactionsAnnounced records the announcements of transactions, counting them.
+// The announcements older than the last recorded announcement of a transaction are ignored.
+// The Count of the announcements is ignored, each announcement counts once.
+func (utp *UnconfirmedTransactionPool) SetTransactionsAnnounced(tx *dbutil.Tx, announcements map[cipher.SHA256]TxnAnnouncement) error {
+	for h, a := range announcements {
 		txn, err := utp.txns.get(tx, h)
 		if err != nil {
 			return err
@@ -211,27 +568,81 @@ func (utp *UnconfirmedTransactionPool) SetTransactionsAnnounced(tx *dbutil.Tx, h
 			continue
 		}
 
-		if t > txn.Announced {
-			txn.Announced = t
-			txns = append(txns, txn)
+		if a.Time <= txn.Announced {
+			continue
 		}
-	}
 
-	for _, txn := range txns {
+		txn.Announced = a.Time
+		txn.AnnounceCount++
 		if err := utp.txns.put(tx, txn); err != nil {
 			return err
 		}
+
+		if err := utp.announcedPeers.put(tx, h, a.Peers); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// GetAnnouncement returns the announcement state of a transaction in the pool, or nil if the transaction is not in the pool.
+// A transaction that was never announced has a zero Count.
+func (utp *UnconfirmedTransactionPool) GetAnnouncement(tx *dbutil.Tx, hash cipher.SHA256) (*TxnAnnouncement, error) {
+	txn, err := utp.txns.get(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if txn == nil {
+		return nil, nil
+	}
+
+	peers, err := utp.announcedPeers.get(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxnAnnouncement{
+		Time:  txn.Announced,
+		Count: txn.AnnounceCount,
+		Peers: peers,
+	}, nil
+}
+
+// RestoreAnnouncement restores the announcement state of a transaction in the pool, e.g. saved before a restart,
+// unless the transaction was announced more recently. Returns false if the announcement was not restored.
+func (utp *UnconfirmedTransactionPool) RestoreAnnouncement(tx *dbutil.Tx, hash cipher.SHA256, a TxnAnnouncement) (bool, error) {
+	txn, err := utp.txns.get(tx, hash)
+	if err != nil {
+		return false, err
+	}
+	if txn == nil || a.Time <= txn.Announced {
+		return false, nil
+	}
+
+	txn.Announced = a.Time
+	if a.Count > txn.AnnounceCount {
+		txn.AnnounceCount = a.Count
+	}
+	if err := utp.txns.put(tx, txn); err != nil {
+		return false, err
+	}
+
+	if err := utp.announcedPeers.put(tx, hash, a.Peers); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // InjectTransaction adds a coin.Transaction to the pool, or updates an existing one's timestamps
 // Returns an error if txn is invalid, and whether the transaction already
 // existed in the pool.
 // If the transaction violates hard constraints, it is rejected.
 // Soft constraints violations mark a txn as invalid, but the txn is inserted. The soft violation is returned.
-func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn) (bool, *ErrTxnViolatesSoftConstraint, error) {
+// The origin is recorded when the transaction is added. A known transaction relayed by a peer
+// becomes local if it is injected again with TxnOriginLocal; a local transaction stays local.
+func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn, origin TxnOrigin) (bool, *ErrTxnViolatesSoftConstraint, error) {
 	var isValid int8 = 1
 	var softErr *ErrTxnViolatesSoftConstraint
 	if _, _, err := bc.VerifySingleTxnSoftHardConstraints(tx, txn, distParams, verifyParams, TxnSigned); err != nil {
@@ -267,6 +678,22 @@ func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Block
 			return false, nil, err
 		}
 
+		if origin == TxnOriginLocal {
+			o, err := utp.GetOrigin(tx, hash)
+			if err != nil {
+				logger.Errorf("InjectTransaction get known txn origin failed: %v", err)
+				return false, nil, err
+			}
+
+			if o != nil && o.Origin != TxnOriginLocal {
+				o.Origin = TxnOriginLocal
+				if err := utp.origins.put(tx, hash, *o); err != nil {
+					logger.Errorf("InjectTransaction update known txn origin failed: %v", err)
+					return false, nil, err
+				}
+			}
+		}
+
 		return true, softErr, nil
 	}
 
@@ -292,6 +719,20 @@ func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Block
 		return false, nil, err
 	}
 
+	// update the spent uxid index
+	if err := utp.spends.add(tx, txn); err != nil {
+		logger.Errorf("InjectTransaction index spent outputs: %v", err)
+		return false, nil, err
+	}
+
+	if err := utp.origins.put(tx, hash, UnconfirmedTxnOrigin{
+		Origin: origin,
+		Added:  utx.Received,
+	}); err != nil {
+		logger.Errorf("InjectTransaction put txn origin: %v", err)
+		return false, nil, err
+	}
+
 	return false, softErr, nil
 }
 
@@ -311,10 +752,29 @@ func (utp *UnconfirmedTransactionPool) AllRawTransactions(tx *dbutil.Tx) (coin.T
 
 // Remove a single txn by hash
 func (utp *UnconfirmedTransactionPool) removeTransaction(tx *dbutil.Tx, txHash cipher.SHA256) error {
+	txn, err := utp.txns.get(tx, txHash)
+	if err != nil {
+		return err
+	}
+
+	if txn != nil {
+		if err := utp.spends.remove(tx, txn.Transaction); err != nil {
+			return err
+		}
+	}
+
 	if err := utp.txns.delete(tx, txHash); err != nil {
 		return err
 	}
 
+	if err := utp.origins.delete(tx, txHash); err != nil {
+		return err
+	}
+
+	if err := utp.announcedPeers.delete(tx, txHash); err != nil {
+		return err
+	}
+
 	return utp.unspent.delete(tx, txHash)
 }
 
@@ -329,6 +789,86 @@ func (utp *UnconfirmedTransactionPool) RemoveTransactions(tx *dbutil.Tx, txHashe
 	return nil
 }
 
+// RemoveConflicts removes the transactions in the pool that spend any of the inputs spent by txns,
+// excluding txns themselves. It is used to evict the double spends of transactions confirmed in a block.
+// The transactions that were removed are returned.
+func (utp *UnconfirmedTransactionPool) RemoveConflicts(tx *dbutil.Tx, txns coin.Transactions) ([]cipher.SHA256, error) {
+	exclude := make(map[cipher.SHA256]struct{}, len(txns))
+	for _, txn := range txns {
+		exclude[txn.Hash()] = struct{}{}
+	}
+
+	var removed []cipher.SHA256
+	for _, txn := range txns {
+		for _, uxid := range txn.In {
+			hashes, err := utp.spends.get(tx, uxid)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, h := range hashes {
+				if _, ok := exclude[h]; ok {
+					continue
+				}
+				exclude[h] = struct{}{}
+				removed = append(removed, h)
+			}
+		}
+	}
+
+	if err := utp.RemoveTransactions(tx, removed); err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+// Conflicts returns the uxids spent by more than one transaction in the pool,
+// mapped to the hashes of the transactions spending them
+func (utp *UnconfirmedTransactionPool) Conflicts(tx *dbutil.Tx) (map[cipher.SHA256][]cipher.SHA256, error) {
+	conflicts := make(map[cipher.SHA256][]cipher.SHA256)
+	if err := utp.spends.forEach(tx, func(uxid cipher.SHA256, hashes []cipher.SHA256) error {
+		if len(hashes) > 1 {
+			conflicts[uxid] = hashes
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
+// SpentBy returns the hashes of the transactions in the pool spending each of uxids.
+// uxids that are not spent by any transaction in the pool are omitted.
+func (utp *UnconfirmedTransactionPool) SpentBy(tx *dbutil.Tx, uxids []cipher.SHA256) (map[cipher.SHA256][]cipher.SHA256, error) {
+	spentBy := make(map[cipher.SHA256][]cipher.SHA256)
+	for _, uxid := range uxids {
+		hashes, err := utp.spends.get(tx, uxid)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(hashes) != 0 {
+			spentBy[uxid] = hashes
+		}
+	}
+
+	return spentBy, nil
+}
+
+// RebuildSpendsIndex rebuilds the index of uxids spent by transactions in the pool.
+// The index is not present in databases created by older versions.
+func (utp *UnconfirmedTransactionPool) RebuildSpendsIndex(tx *dbutil.Tx) error {
+	if err := utp.spends.reset(tx); err != nil {
+		return err
+	}
+
+	return utp.txns.forEach(tx, func(_ cipher.SHA256, txn UnconfirmedTransaction) error {
+		return utp.spends.add(tx, txn.Transaction)
+	})
+}
+
 // Refresh checks all unconfirmed txns against the blockchain.
 // If the transaction becomes invalid it is marked invalid.
 // If the transaction becomes valid it is marked valid and is returned to the caller.
@@ -396,6 +936,131 @@ func (utp *UnconfirmedTransactionPool) RemoveInvalid(tx *dbutil.Tx, bc Blockchai
 	return removeUtxns, nil
 }
 
+// RemoveExpired removes the transactions relayed by peers that have not been received
+// again within peerExpiry of now. Local transactions are never removed by expiry.
+// A peerExpiry of 0 disables expiry.
+// The transactions that were removed are returned.
+func (utp *UnconfirmedTransactionPool) RemoveExpired(tx *dbutil.Tx, now time.Time, peerExpiry time.Duration) ([]cipher.SHA256, error) {
+	if peerExpiry <= 0 {
+		return nil, nil
+	}
+
+	var removeUtxns []cipher.SHA256
+	if err := utp.txns.forEach(tx, func(hash cipher.SHA256, txn UnconfirmedTransaction) error {
+		o, err := utp.origins.get(tx, hash)
+		if err != nil {
+			return err
+		}
+
+		if o != nil && o.Origin == TxnOriginLocal {
+			return nil
+		}
+
+		if now.Sub(nanoToTime(txn.Received)) > peerExpiry {
+			removeUtxns = append(removeUtxns, hash)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := utp.RemoveTransactions(tx, removeUtxns); err != nil {
+		return nil, err
+	}
+
+	return removeUtxns, nil
+}
+
+// GetExpiredLocal returns the hashes of the local transactions that were added
+// to the pool more than localExpiry before now. A localExpiry of 0 disables expiry.
+func (utp *UnconfirmedTransactionPool) GetExpiredLocal(tx *dbutil.Tx, now time.Time, localExpiry time.Duration) ([]cipher.SHA256, error) {
+	if localExpiry <= 0 {
+		return nil, nil
+	}
+
+	var hashes []cipher.SHA256
+	if err := utp.txns.forEach(tx, func(hash cipher.SHA256, _ UnconfirmedTransaction) error {
+		o, err := utp.origins.get(tx, hash)
+		if err != nil {
+			return err
+		}
+
+		if o != nil && o.Origin == TxnOriginLocal && now.Sub(nanoToTime(o.Added)) > localExpiry {
+			hashes = append(hashes, hash)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// GetOrigin returns the origin of a transaction in the pool, or nil if the transaction is not in the pool.
+// Transactions added before origins were recorded are reported as relayed by a peer when they were last received.
+func (utp *UnconfirmedTransactionPool) GetOrigin(tx *dbutil.Tx, hash cipher.SHA256) (*UnconfirmedTxnOrigin, error) {
+	o, err := utp.origins.get(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if o != nil {
+		return o, nil
+	}
+
+	txn, err := utp.txns.get(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if txn == nil {
+		return nil, nil
+	}
+
+	return &UnconfirmedTxnOrigin{
+		Origin: TxnOriginPeer,
+		Added:  txn.Received,
+	}, nil
+}
+
+// SetAbandoned records that the user abandoned a transaction, refusing it from peers until the given time
+func (utp *UnconfirmedTransactionPool) SetAbandoned(tx *dbutil.Tx, hash cipher.SHA256, until time.Time) error {
+	return utp.abandoned.put(tx, hash, until)
+}
+
+// ClearAbandoned forgets that the user abandoned a transaction
+func (utp *UnconfirmedTransactionPool) ClearAbandoned(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return utp.abandoned.delete(tx, hash)
+}
+
+// IsAbandoned returns true if the user abandoned a transaction and its record has not expired at now
+func (utp *UnconfirmedTransactionPool) IsAbandoned(tx *dbutil.Tx, hash cipher.SHA256, now time.Time) (bool, error) {
+	until, ok, err := utp.abandoned.get(tx, hash)
+	if err != nil || !ok {
+		return false, err
+	}
+	return now.Before(until), nil
+}
+
+// RemoveExpiredAbandoned forgets the abandoned transactions whose records expired at now.
+// Returns the number of records removed.
+func (utp *UnconfirmedTransactionPool) RemoveExpiredAbandoned(tx *dbutil.Tx, now time.Time) (int, error) {
+	keys, err := utp.abandoned.expired(tx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, k := range keys {
+		if err := dbutil.Delete(tx, UnconfirmedAbandonedBkt, k); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+func nanoToTime(n int64) time.Time {
+	return time.Unix(0, n)
+}
+
 // FilterKnown returns txn hashes with known ones removed
 func (utp *UnconfirmedTransactionPool) FilterKnown(tx *dbutil.Tx, txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	var unknown []cipher.SHA256
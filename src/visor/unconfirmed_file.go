@@ -0,0 +1,122 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/file"
+)
+
+const (
+	// UnconfirmedTxnsFilename is the name of the file in the data directory that the
+	// unconfirmed transaction pool is saved to on shutdown
+	UnconfirmedTxnsFilename = "unconfirmed_txns.json"
+)
+
+// UnconfirmedTxnsFileEntry is a transaction saved in an UnconfirmedTxnsFile
+type UnconfirmedTxnsFileEntry struct {
+	Hash string `json:"hash"`
+	// Received is the time the txn was last received, in unix nanoseconds
+	Received int64 `json:"received"`
+	// Txn is the hex encoded serialized transaction
+	Txn string `json:"txn"`
+	// Announced is the time the txn was last announced to peers, in unix nanoseconds
+	Announced int64 `json:"announced,omitempty"`
+	// AnnounceCount is the number of times the txn was announced
+	AnnounceCount uint64 `json:"announce_count,omitempty"`
+	// AnnouncedPeers is the number of peers the txn was last announced to
+	AnnouncedPeers uint64 `json:"announced_peers,omitempty"`
+}
+
+// NewUnconfirmedTxnsFileEntry creates an UnconfirmedTxnsFileEntry
+func NewUnconfirmedTxnsFileEntry(txn coin.Transaction, received int64) (UnconfirmedTxnsFileEntry, error) {
+	txnHex, err := txn.SerializeHex()
+	if err != nil {
+		return UnconfirmedTxnsFileEntry{}, err
+	}
+
+	return UnconfirmedTxnsFileEntry{
+		Hash:     txn.Hash().Hex(),
+		Received: received,
+		Txn:      txnHex,
+	}, nil
+}
+
+// Transaction decodes the entry's transaction and checks that it matches the entry's hash
+func (e UnconfirmedTxnsFileEntry) Transaction() (coin.Transaction, error) {
+	hash, err := cipher.SHA256FromHex(e.Hash)
+	if err != nil {
+		return coin.Transaction{}, fmt.Errorf("invalid hash: %v", err)
+	}
+
+	txn, err := coin.DeserializeTransactionHex(e.Txn)
+	if err != nil {
+		return coin.Transaction{}, err
+	}
+
+	if txn.Hash() != hash {
+		return coin.Transaction{}, errors.New("transaction hash does not match the entry's hash")
+	}
+
+	return txn, nil
+}
+
+// Announcement returns the announcement state of the entry's transaction, nil if it was never announced
+func (e UnconfirmedTxnsFileEntry) Announcement() *TxnAnnouncement {
+	if e.Announced <= 0 {
+		return nil
+	}
+
+	return &TxnAnnouncement{
+		Time:  e.Announced,
+		Count: e.AnnounceCount,
+		Peers: e.AnnouncedPeers,
+	}
+}
+
+// UnconfirmedTxnsFile is the unconfirmed transaction pool saved to disk on shutdown
+type UnconfirmedTxnsFile struct {
+	Transactions []UnconfirmedTxnsFileEntry `json:"transactions"`
+}
+
+// SaveUnconfirmedTxnsFile writes the unconfirmed transactions to filename,
+// with the announcement state of the announced transactions
+func SaveUnconfirmedTxnsFile(filename string, txns []UnconfirmedTransaction, announcements map[cipher.SHA256]TxnAnnouncement) error {
+	f := UnconfirmedTxnsFile{
+		Transactions: make([]UnconfirmedTxnsFileEntry, len(txns)),
+	}
+
+	for i, txn := range txns {
+		e, err := NewUnconfirmedTxnsFileEntry(txn.Transaction, txn.Received)
+		if err != nil {
+			return err
+		}
+
+		if a, ok := announcements[txn.Transaction.Hash()]; ok {
+			e.Announced = a.Time
+			e.AnnounceCount = a.Count
+			e.AnnouncedPeers = a.Peers
+		}
+
+		f.Transactions[i] = e
+	}
+
+	return file.SaveJSON(filename, f, 0600)
+}
+
+// LoadUnconfirmedTxnsFile reads an UnconfirmedTxnsFile from filename.
+// Returns a nil file and no error if filename does not exist.
+func LoadUnconfirmedTxnsFile(filename string) (*UnconfirmedTxnsFile, error) {
+	var f UnconfirmedTxnsFile
+	if err := file.LoadJSON(filename, &f); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &f, nil
+}
@@ -0,0 +1,93 @@
+package visor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func makeUnconfirmedFileTxn(t *testing.T) coin.Transaction {
+	txn := coin.Transaction{}
+	err := txn.PushInput(testutil.RandSHA256(t))
+	require.NoError(t, err)
+	err = txn.PushOutput(testutil.MakeAddress(), 1e6, 10)
+	require.NoError(t, err)
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+	return txn
+}
+
+func TestUnconfirmedTxnsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unconfirmed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, UnconfirmedTxnsFilename)
+
+	// A missing file is not an error
+	f, err := LoadUnconfirmedTxnsFile(filename)
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	txns := []UnconfirmedTransaction{
+		{Transaction: makeUnconfirmedFileTxn(t), Received: 100},
+		{Transaction: makeUnconfirmedFileTxn(t), Received: 200},
+	}
+
+	announcements := map[cipher.SHA256]TxnAnnouncement{
+		txns[1].Transaction.Hash(): {Time: 300, Count: 2, Peers: 5},
+	}
+
+	err = SaveUnconfirmedTxnsFile(filename, txns, announcements)
+	require.NoError(t, err)
+
+	fi, err := os.Stat(filename)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	f, err = LoadUnconfirmedTxnsFile(filename)
+	require.NoError(t, err)
+	require.Len(t, f.Transactions, 2)
+
+	for i, e := range f.Transactions {
+		require.Equal(t, txns[i].Transaction.Hash().Hex(), e.Hash)
+		require.Equal(t, txns[i].Received, e.Received)
+
+		txn, err := e.Transaction()
+		require.NoError(t, err)
+		require.Equal(t, txns[i].Transaction, txn)
+	}
+
+	// Only the announced transactions have an announcement
+	require.Nil(t, f.Transactions[0].Announcement())
+	require.Equal(t, &TxnAnnouncement{Time: 300, Count: 2, Peers: 5}, f.Transactions[1].Announcement())
+
+	// Entries whose transaction doesn't match the hash are rejected
+	e := f.Transactions[0]
+	e.Hash = f.Transactions[1].Hash
+	_, err = e.Transaction()
+	require.EqualError(t, err, "transaction hash does not match the entry's hash")
+
+	e = f.Transactions[0]
+	e.Hash = "foo"
+	_, err = e.Transaction()
+	require.Error(t, err)
+
+	e = f.Transactions[0]
+	e.Txn = e.Txn[:len(e.Txn)-2]
+	_, err = e.Transaction()
+	require.Error(t, err)
+
+	// A corrupt file is an error
+	err = ioutil.WriteFile(filename, []byte("{"), 0600)
+	require.NoError(t, err)
+	_, err = LoadUnconfirmedTxnsFile(filename)
+	require.Error(t, err)
+}
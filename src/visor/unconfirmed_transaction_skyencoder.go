@@ -78,6 +78,9 @@ func encodeSizeUnconfirmedTransaction(obj *UnconfirmedTransaction) uint64 {
 	// obj.IsValid
 	i0++
 
+	// obj.AnnounceCount
+	i0 += 8
+
 	return i0
 }
 
@@ -198,6 +201,9 @@ func encodeUnconfirmedTransactionToBuffer(buf []byte, obj *UnconfirmedTransactio
 	// obj.IsValid
 	e.Int8(obj.IsValid)
 
+	// obj.AnnounceCount
+	e.Uint64(obj.AnnounceCount)
+
 	return nil
 }
 
@@ -401,6 +407,15 @@ func decodeUnconfirmedTransaction(buf []byte, obj *UnconfirmedTransaction) (uint
 		obj.IsValid = i
 	}
 
+	{
+		// obj.AnnounceCount
+		i, err := d.Uint64()
+		if err != nil {
+			return 0, err
+		}
+		obj.AnnounceCount = i
+	}
+
 	return uint64(len(buf) - len(d.Buffer)), nil
 }
 
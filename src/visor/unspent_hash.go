@@ -0,0 +1,234 @@
+package visor
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// UnspentHashReport is the result of checking the unspent pool hash (UxHash) against the blockchain.
+// The unspent pool hash is the XOR of the snapshot hashes of all unspent outputs.
+// A block header's UxHash is the unspent pool hash before the block was executed,
+// so the hash of the current pool is derived from the head block's UxHash and the outputs the head block spent and created.
+type UnspentHashReport struct {
+	// HeadSeq is the seq of the head block the pool was checked against
+	HeadSeq uint64
+	// Stored is the unspent pool hash stored in the unspent pool metadata, which is used for new block headers
+	Stored cipher.SHA256
+	// Computed is the unspent pool hash recomputed from the unspent outputs
+	Computed cipher.SHA256
+	// Expected is the unspent pool hash derived from the head block
+	Expected cipher.SHA256
+	// Unspents is the number of unspent outputs in the pool
+	Unspents uint64
+	// CheckedAt is when the check was made
+	CheckedAt time.Time
+}
+
+// OK returns true if the stored and computed unspent pool hashes match the expected hash
+func (r UnspentHashReport) OK() bool {
+	return r.Stored == r.Expected && r.Computed == r.Expected
+}
+
+// Err returns an ErrUnspentHashMismatch if the unspent pool hashes do not match, nil otherwise
+func (r UnspentHashReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return ErrUnspentHashMismatch{r}
+}
+
+// ErrUnspentHashMismatch is returned if the unspent pool diverged from the blockchain
+type ErrUnspentHashMismatch struct {
+	Report UnspentHashReport
+}
+
+func (e ErrUnspentHashMismatch) Error() string {
+	r := e.Report
+	return fmt.Sprintf("unspent pool hash does not match the blockchain at head seq %d: expected %s, stored %s, computed from %d unspent outputs %s. Restart the node with -repair-unspents to rebuild the unspent pool",
+		r.HeadSeq, r.Expected.Hex(), r.Stored.Hex(), r.Unspents, r.Computed.Hex())
+}
+
+// unspentHashStatus holds the result of the last unspent pool hash check. A nil unspentHashStatus holds nothing.
+type unspentHashStatus struct {
+	sync.RWMutex
+	report *UnspentHashReport
+}
+
+func (s *unspentHashStatus) set(r *UnspentHashReport) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.report = r
+}
+
+func (s *unspentHashStatus) get() *UnspentHashReport {
+	if s == nil {
+		return nil
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	if s.report == nil {
+		return nil
+	}
+	r := *s.report
+	return &r
+}
+
+// CheckUnspentHash recomputes the unspent pool hash and compares it to the hash expected by the head block.
+// The report is kept and returned by LastUnspentHashReport.
+// A mismatch is reported by the report, not by an error.
+func (vs *Visor) CheckUnspentHash() (*UnspentHashReport, error) {
+	var r *UnspentHashReport
+	if err := vs.view("CheckUnspentHash", func(tx *dbutil.Tx) error {
+		var err error
+		r, err = checkUnspentHash(tx, vs.blockchain, vs.history)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if r != nil {
+		vs.unspentHash.set(r)
+	}
+
+	return r, nil
+}
+
+// LastUnspentHashReport returns the report of the last unspent pool hash check,
+// or nil if the unspent pool hash has not been checked
+func (vs *Visor) LastUnspentHashReport() *UnspentHashReport {
+	return vs.unspentHash.get()
+}
+
+// CheckUnspentHash checks the unspent pool hash of a database against its blockchain.
+// Returns a nil report if the blockchain is empty.
+func CheckUnspentHash(db *dbutil.DB) (*UnspentHashReport, error) {
+	var blocksBktExist bool
+	if err := db.View("CheckUnspentHash", func(tx *dbutil.Tx) error {
+		blocksBktExist = dbutil.Exists(tx, blockdb.BlocksBkt)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if !blocksBktExist {
+		return nil, nil
+	}
+
+	bc, err := NewBlockchain(db, BlockchainConfig{})
+	if err != nil {
+		return nil, err
+	}
+
+	var r *UnspentHashReport
+	if err := db.View("CheckUnspentHash", func(tx *dbutil.Tx) error {
+		var err error
+		r, err = checkUnspentHash(tx, bc, historydb.New())
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func checkUnspentHash(tx *dbutil.Tx, bc Blockchainer, history Historyer) (*UnspentHashReport, error) {
+	head, err := bc.Head(tx)
+	if err != nil {
+		if err == blockdb.ErrNoHeadBlock {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	stored, err := bc.Unspent().GetUxHash(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	computed, n, err := computeUnspentHash(tx, blockdb.UnspentPoolBkt)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := headUnspentHash(tx, history, head)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnspentHashReport{
+		HeadSeq:   head.Seq(),
+		Stored:    stored,
+		Computed:  computed,
+		Expected:  expected,
+		Unspents:  n,
+		CheckedAt: time.Now().UTC(),
+	}, nil
+}
+
+// computeUnspentHash returns the XOR of the snapshot hashes of the unspent outputs in a bucket, and the number of outputs
+func computeUnspentHash(tx *dbutil.Tx, bkt []byte) (cipher.SHA256, uint64, error) {
+	var h cipher.SHA256
+	var n uint64
+	if err := dbutil.ForEach(tx, bkt, func(k, v []byte) error {
+		var ux coin.UxOut
+		if err := encoder.DeserializeRawExact(v, &ux); err != nil {
+			return err
+		}
+
+		if uxHash := ux.Hash(); !bytes.Equal(k, uxHash[:]) {
+			return fmt.Errorf("unspent output %s is stored under key %x", uxHash.Hex(), k)
+		}
+
+		h = h.Xor(ux.SnapshotHash())
+		n++
+		return nil
+	}); err != nil {
+		return cipher.SHA256{}, 0, err
+	}
+
+	return h, n, nil
+}
+
+// headUnspentHash returns the unspent pool hash after the head block was executed,
+// from the head block's UxHash and the outputs it spent and created.
+// The spent outputs are read from the history.
+func headUnspentHash(tx *dbutil.Tx, history Historyer, head *coin.SignedBlock) (cipher.SHA256, error) {
+	h := head.Head.UxHash
+
+	var inputs []cipher.SHA256
+	for _, txn := range head.Body.Transactions {
+		inputs = append(inputs, txn.In...)
+		for _, ux := range coin.CreateUnspents(head.Head, txn) {
+			h = h.Xor(ux.SnapshotHash())
+		}
+	}
+
+	if len(inputs) == 0 {
+		return h, nil
+	}
+
+	spent, err := history.GetUxOuts(tx, inputs)
+	if err != nil {
+		return cipher.SHA256{}, fmt.Errorf("get outputs spent by head block %d failed: %v", head.Seq(), err)
+	}
+
+	for _, ux := range spent {
+		h = h.Xor(ux.Out.SnapshotHash())
+	}
+
+	return h, nil
+}
@@ -0,0 +1,336 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/elapse"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// The unspent pool is rebuilt into staging buckets over many db transactions, so that the repair can be
+// resumed after a crash. The staging buckets replace the unspent pool buckets in a single db transaction.
+var (
+	// UnspentRepairPoolBkt holds the unspent outputs of an unspent pool being rebuilt
+	UnspentRepairPoolBkt = []byte("unspent_pool_repair")
+	// UnspentRepairMetaBkt holds the progress of an unspent pool being rebuilt
+	UnspentRepairMetaBkt = []byte("unspent_repair_meta")
+
+	unspentRepairHeightKey  = []byte("height")
+	unspentRepairXorHashKey = []byte("xorhash")
+
+	// unspentXorHashKey is the key of the unspent pool hash in blockdb.UnspentMetaBkt
+	unspentXorHashKey = []byte("xorhash")
+
+	// ErrRepairStopped is returned when the unspent pool repair is interrupted
+	ErrRepairStopped = errors.New("unspent pool repair stopped")
+)
+
+const (
+	// unspentRepairBatchSize is the number of blocks replayed into the staging unspent pool per db transaction
+	unspentRepairBatchSize = 1000
+	// unspentRepairLogInterval is how often the progress of the repair is logged
+	unspentRepairLogInterval = time.Second * 10
+)
+
+// RepairUnspents rebuilds the unspent pool from the blockchain, replaying the outputs spent and created by every block.
+// Block signatures and transactions are not verified again. The unspent pool hash of each block header
+// is checked against the rebuilt pool before the block is replayed, so a block that the rebuilt pool diverges from
+// fails the repair instead of being written.
+// The rebuilt pool only replaces the unspent pool once every block has been replayed, and a repair interrupted by
+// a crash or by quit resumes from the last replayed batch of blocks.
+// The rebuilt pool is checked against the head block before it replaces the unspent pool,
+// the unspent address index is rebuilt from the new pool, and the unspent pool hash is checked again afterwards.
+func RepairUnspents(db *dbutil.DB, quit <-chan struct{}) (*UnspentHashReport, error) {
+	elapser := elapse.NewElapser(time.Second*30, logger)
+	elapser.Register("RepairUnspents")
+	defer elapser.CheckForDone()
+
+	if quit == nil {
+		quit = make(chan struct{})
+	}
+
+	var blocksBktExist bool
+	if err := db.View("RepairUnspents", func(tx *dbutil.Tx) error {
+		blocksBktExist = dbutil.Exists(tx, blockdb.BlocksBkt)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if !blocksBktExist {
+		logger.Info("RepairUnspents: blockchain is empty, nothing to repair")
+		return nil, nil
+	}
+
+	bc, err := NewBlockchain(db, BlockchainConfig{})
+	if err != nil {
+		return nil, err
+	}
+
+	history := historydb.New()
+
+	var headSeq uint64
+	var next uint64
+	if err := db.Update("RepairUnspents init", func(tx *dbutil.Tx) error {
+		var ok bool
+		headSeq, ok, err = bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return blockdb.ErrNoHeadBlock
+		}
+
+		if err := dbutil.CreateBuckets(tx, [][]byte{UnspentRepairPoolBkt, UnspentRepairMetaBkt}); err != nil {
+			return err
+		}
+
+		height, ok, err := getUnspentRepairHeight(tx)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			if height > headSeq {
+				return fmt.Errorf("staged unspent pool height %d is greater than the head seq %d", height, headSeq)
+			}
+			next = height + 1
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if next == 0 {
+		logger.Infof("RepairUnspents: rebuilding the unspent pool from %d blocks", headSeq+1)
+	} else {
+		logger.Infof("RepairUnspents: resuming the unspent pool rebuild from block %d of %d", next, headSeq)
+	}
+
+	lastLog := time.Now()
+	for next <= headSeq {
+		select {
+		case <-quit:
+			return nil, ErrRepairStopped
+		default:
+		}
+
+		end := next + unspentRepairBatchSize - 1
+		if end > headSeq {
+			end = headSeq
+		}
+
+		if err := db.Update("RepairUnspents replay blocks", func(tx *dbutil.Tx) error {
+			return replayUnspents(tx, bc, next, end)
+		}); err != nil {
+			return nil, err
+		}
+
+		next = end + 1
+
+		if time.Since(lastLog) >= unspentRepairLogInterval || next > headSeq {
+			logger.Infof("RepairUnspents: replayed %d/%d blocks (%.1f%%)", next, headSeq+1, float64(next)*100/float64(headSeq+1))
+			lastLog = time.Now()
+		}
+	}
+
+	logger.Info("RepairUnspents: replacing the unspent pool with the rebuilt pool")
+	if err := db.Update("RepairUnspents swap", func(tx *dbutil.Tx) error {
+		return swapRepairedUnspents(tx, bc, history, headSeq)
+	}); err != nil {
+		return nil, err
+	}
+
+	var r *UnspentHashReport
+	if err := db.View("RepairUnspents check", func(tx *dbutil.Tx) error {
+		var err error
+		r, err = checkUnspentHash(tx, bc, history)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := r.Err(); err != nil {
+		return r, err
+	}
+
+	logger.Infof("RepairUnspents: rebuilt the unspent pool of %d unspent outputs, unspent pool hash %s", r.Unspents, r.Computed.Hex())
+
+	return r, nil
+}
+
+// replayUnspents replays the outputs spent and created by the blocks from start to end, including both, into the staging unspent pool
+func replayUnspents(tx *dbutil.Tx, bc Blockchainer, start, end uint64) error {
+	xorHash, err := getUnspentRepairXorHash(tx)
+	if err != nil {
+		return err
+	}
+
+	for seq := start; seq <= end; seq++ {
+		b, err := bc.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return fmt.Errorf("block %d does not exist", seq)
+		}
+
+		if b.Head.UxHash != xorHash {
+			return fmt.Errorf("block %d unspent pool hash %s does not match the rebuilt unspent pool hash %s", seq, b.Head.UxHash.Hex(), xorHash.Hex())
+		}
+
+		xorHash, err = replayBlockUnspents(tx, b, xorHash)
+		if err != nil {
+			return fmt.Errorf("replay block %d failed: %v", seq, err)
+		}
+	}
+
+	if err := dbutil.PutBucketValue(tx, UnspentRepairMetaBkt, unspentRepairXorHashKey, xorHash[:]); err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, UnspentRepairMetaBkt, unspentRepairHeightKey, dbutil.Itob(end))
+}
+
+// replayBlockUnspents removes the outputs spent by a block from the staging unspent pool and adds the outputs it creates,
+// returning the updated unspent pool hash
+func replayBlockUnspents(tx *dbutil.Tx, b *coin.SignedBlock, xorHash cipher.SHA256) (cipher.SHA256, error) {
+	for _, txn := range b.Body.Transactions {
+		for _, h := range txn.In {
+			v, err := dbutil.GetBucketValueNoCopy(tx, UnspentRepairPoolBkt, h[:])
+			if err != nil {
+				return cipher.SHA256{}, err
+			}
+			if v == nil {
+				return cipher.SHA256{}, blockdb.NewErrUnspentNotExist(h.Hex())
+			}
+
+			var ux coin.UxOut
+			if err := encoder.DeserializeRawExact(v, &ux); err != nil {
+				return cipher.SHA256{}, err
+			}
+
+			xorHash = xorHash.Xor(ux.SnapshotHash())
+
+			if err := dbutil.Delete(tx, UnspentRepairPoolBkt, h[:]); err != nil {
+				return cipher.SHA256{}, err
+			}
+		}
+	}
+
+	for _, txn := range b.Body.Transactions {
+		for _, ux := range coin.CreateUnspents(b.Head, txn) {
+			h := ux.Hash()
+
+			if ok, err := dbutil.BucketHasKey(tx, UnspentRepairPoolBkt, h[:]); err != nil {
+				return cipher.SHA256{}, err
+			} else if ok {
+				return cipher.SHA256{}, fmt.Errorf("attempted to insert uxout:%v twice into the unspent pool", h.Hex())
+			}
+
+			if err := dbutil.PutBucketValue(tx, UnspentRepairPoolBkt, h[:], encoder.Serialize(ux)); err != nil {
+				return cipher.SHA256{}, err
+			}
+
+			xorHash = xorHash.Xor(ux.SnapshotHash())
+		}
+	}
+
+	return xorHash, nil
+}
+
+// swapRepairedUnspents replaces the unspent pool with the staging unspent pool, if its hash matches the head block,
+// rebuilds the unspent address index and deletes the staging buckets
+func swapRepairedUnspents(tx *dbutil.Tx, bc Blockchainer, history Historyer, headSeq uint64) error {
+	height, ok, err := getUnspentRepairHeight(tx)
+	if err != nil {
+		return err
+	}
+	if !ok || height != headSeq {
+		return fmt.Errorf("staged unspent pool height %d does not match the head seq %d", height, headSeq)
+	}
+
+	xorHash, err := getUnspentRepairXorHash(tx)
+	if err != nil {
+		return err
+	}
+
+	// Check the staged pool against the head block before it replaces the unspent pool
+	head, err := bc.Head(tx)
+	if err != nil {
+		return err
+	}
+
+	expected, err := headUnspentHash(tx, history, head)
+	if err != nil {
+		return err
+	}
+
+	computed, _, err := computeUnspentHash(tx, UnspentRepairPoolBkt)
+	if err != nil {
+		return err
+	}
+
+	if computed != expected || xorHash != expected {
+		return fmt.Errorf("rebuilt unspent pool hash does not match head block %d: expected %s, staged %s, computed %s", headSeq, expected.Hex(), xorHash.Hex(), computed.Hex())
+	}
+
+	if err := dbutil.Reset(tx, blockdb.UnspentPoolBkt); err != nil {
+		return err
+	}
+
+	if err := dbutil.ForEach(tx, UnspentRepairPoolBkt, func(k, v []byte) error {
+		return dbutil.PutBucketValue(tx, blockdb.UnspentPoolBkt, k, v)
+	}); err != nil {
+		return err
+	}
+
+	// Resetting the unspent metadata removes the address index height, so that the address index is rebuilt
+	if err := dbutil.Reset(tx, blockdb.UnspentMetaBkt); err != nil {
+		return err
+	}
+
+	if err := dbutil.PutBucketValue(tx, blockdb.UnspentMetaBkt, unspentXorHashKey, xorHash[:]); err != nil {
+		return err
+	}
+
+	if err := bc.Unspent().MaybeBuildIndexes(tx, headSeq); err != nil {
+		return err
+	}
+
+	if err := tx.DeleteBucket(UnspentRepairPoolBkt); err != nil {
+		return err
+	}
+
+	return tx.DeleteBucket(UnspentRepairMetaBkt)
+}
+
+func getUnspentRepairHeight(tx *dbutil.Tx) (uint64, bool, error) {
+	v, err := dbutil.GetBucketValue(tx, UnspentRepairMetaBkt, unspentRepairHeightKey)
+	if err != nil {
+		return 0, false, err
+	} else if v == nil {
+		return 0, false, nil
+	}
+
+	return dbutil.Btoi(v), true, nil
+}
+
+func getUnspentRepairXorHash(tx *dbutil.Tx) (cipher.SHA256, error) {
+	v, err := dbutil.GetBucketValue(tx, UnspentRepairMetaBkt, unspentRepairXorHashKey)
+	if err != nil {
+		return cipher.SHA256{}, err
+	} else if v == nil {
+		return cipher.SHA256{}, nil
+	}
+
+	return cipher.SHA256FromBytes(v)
+}
@@ -0,0 +1,257 @@
+package visor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// openTestDBCopy opens a copy of a database from testdata
+func openTestDBCopy(t *testing.T, name string) (*dbutil.DB, func()) {
+	dir, err := ioutil.TempDir("", "unspent-repair")
+	require.NoError(t, err)
+
+	dbPath := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(dbPath, readAll(t, filepath.Join("testdata", name)), 0600))
+
+	db, err := OpenDB(dbPath, false)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// unspentPoolContents returns the raw contents of the unspent pool bucket
+func unspentPoolContents(t *testing.T, db *dbutil.DB) map[string][]byte {
+	pool := make(map[string][]byte)
+	err := db.View("", func(tx *dbutil.Tx) error {
+		return dbutil.ForEach(tx, blockdb.UnspentPoolBkt, func(k, v []byte) error {
+			pool[string(k)] = append([]byte{}, v...)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	return pool
+}
+
+// deleteFirstUnspent removes an unspent output from the unspent pool without updating the unspent pool hash
+func deleteFirstUnspent(t *testing.T, db *dbutil.DB) {
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		k, _ := tx.Bucket(blockdb.UnspentPoolBkt).Cursor().First()
+		require.NotNil(t, k)
+		return dbutil.Delete(tx, blockdb.UnspentPoolBkt, append([]byte{}, k...))
+	})
+	require.NoError(t, err)
+}
+
+func TestCheckUnspentHash(t *testing.T) {
+	db, shutdown := openTestDBCopy(t, "data.db.ok")
+	defer shutdown()
+
+	r, err := CheckUnspentHash(db)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	require.True(t, r.OK())
+	require.NoError(t, r.Err())
+	require.NotZero(t, r.Unspents)
+	require.Equal(t, r.Expected, r.Stored)
+	require.Equal(t, r.Expected, r.Computed)
+
+	var headSeq uint64
+	err = db.View("", func(tx *dbutil.Tx) error {
+		bc, err := NewBlockchain(db, BlockchainConfig{})
+		require.NoError(t, err)
+		headSeq, _, err = bc.HeadSeq(tx)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, headSeq, r.HeadSeq)
+
+	// An output missing from the pool diverges the computed hash from the stored and expected hashes
+	deleteFirstUnspent(t, db)
+
+	r2, err := CheckUnspentHash(db)
+	require.NoError(t, err)
+	require.False(t, r2.OK())
+	require.Equal(t, r.Expected, r2.Expected)
+	require.Equal(t, r.Stored, r2.Stored)
+	require.NotEqual(t, r.Computed, r2.Computed)
+	require.Equal(t, r.Unspents-1, r2.Unspents)
+
+	err = r2.Err()
+	require.IsType(t, ErrUnspentHashMismatch{}, err)
+	require.Equal(t, *r2, err.(ErrUnspentHashMismatch).Report)
+
+	// A wrong stored hash diverges from the expected hash
+	fooHash := cipher.SumSHA256([]byte("foo"))
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return dbutil.PutBucketValue(tx, blockdb.UnspentMetaBkt, unspentXorHashKey, fooHash[:])
+	})
+	require.NoError(t, err)
+
+	r3, err := CheckUnspentHash(db)
+	require.NoError(t, err)
+	require.False(t, r3.OK())
+	require.Equal(t, cipher.SumSHA256([]byte("foo")), r3.Stored)
+
+	// An empty database has nothing to check
+	emptyDB, shutdownEmpty := prepareDB(t)
+	defer shutdownEmpty()
+
+	r, err = CheckUnspentHash(emptyDB)
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func TestVisorCheckUnspentHash(t *testing.T) {
+	db, shutdown := openTestDBCopy(t, "data.db.ok")
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{})
+	require.NoError(t, err)
+
+	v := &Visor{
+		db:          db,
+		blockchain:  bc,
+		history:     historydb.New(),
+		unspentHash: &unspentHashStatus{},
+	}
+
+	require.Nil(t, v.LastUnspentHashReport())
+
+	r, err := v.CheckUnspentHash()
+	require.NoError(t, err)
+	require.True(t, r.OK())
+	require.Equal(t, r, v.LastUnspentHashReport())
+}
+
+func TestRepairUnspents(t *testing.T) {
+	db, shutdown := openTestDBCopy(t, "data.db.ok")
+	defer shutdown()
+
+	pool := unspentPoolContents(t, db)
+
+	var addrIndexLen uint64
+	err := db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		addrIndexLen, err = dbutil.Len(tx, blockdb.UnspentPoolAddrIndexBkt)
+		return err
+	})
+	require.NoError(t, err)
+
+	before, err := CheckUnspentHash(db)
+	require.NoError(t, err)
+	require.True(t, before.OK())
+
+	deleteFirstUnspent(t, db)
+
+	r, err := CheckUnspentHash(db)
+	require.NoError(t, err)
+	require.False(t, r.OK())
+
+	// A stopped repair leaves the unspent pool as it was
+	quit := make(chan struct{})
+	close(quit)
+	_, err = RepairUnspents(db, quit)
+	require.Equal(t, ErrRepairStopped, err)
+	require.Len(t, unspentPoolContents(t, db), len(pool)-1)
+
+	r, err = RepairUnspents(db, nil)
+	require.NoError(t, err)
+	require.True(t, r.OK())
+	require.Equal(t, before.Expected, r.Computed)
+	require.Equal(t, pool, unspentPoolContents(t, db))
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		// The staging buckets are removed
+		require.False(t, dbutil.Exists(tx, UnspentRepairPoolBkt))
+		require.False(t, dbutil.Exists(tx, UnspentRepairMetaBkt))
+
+		// The address index is rebuilt
+		n, err := dbutil.Len(tx, blockdb.UnspentPoolAddrIndexBkt)
+		require.NoError(t, err)
+		require.Equal(t, addrIndexLen, n)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRepairUnspentsResume(t *testing.T) {
+	db, shutdown := openTestDBCopy(t, "data.db.ok")
+	defer shutdown()
+
+	pool := unspentPoolContents(t, db)
+
+	bc, err := NewBlockchain(db, BlockchainConfig{})
+	require.NoError(t, err)
+
+	// Stage part of the blocks, as if the repair crashed after the first batch
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		if err := dbutil.CreateBuckets(tx, [][]byte{UnspentRepairPoolBkt, UnspentRepairMetaBkt}); err != nil {
+			return err
+		}
+		return replayUnspents(tx, bc, 0, 10)
+	})
+	require.NoError(t, err)
+
+	deleteFirstUnspent(t, db)
+
+	r, err := RepairUnspents(db, nil)
+	require.NoError(t, err)
+	require.True(t, r.OK())
+	require.Equal(t, pool, unspentPoolContents(t, db))
+
+	// A staged pool that diverges from the block headers fails the repair, and the unspent pool is not replaced
+	fooHash := cipher.SumSHA256([]byte("foo"))
+	stageDiverged := func(end uint64) {
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			if err := dbutil.CreateBuckets(tx, [][]byte{UnspentRepairPoolBkt, UnspentRepairMetaBkt}); err != nil {
+				return err
+			}
+			if err := replayUnspents(tx, bc, 0, end); err != nil {
+				return err
+			}
+			return dbutil.PutBucketValue(tx, UnspentRepairMetaBkt, unspentRepairXorHashKey, fooHash[:])
+		})
+		require.NoError(t, err)
+	}
+
+	stageDiverged(5)
+	_, err = RepairUnspents(db, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "block 6 unspent pool hash")
+	require.Equal(t, pool, unspentPoolContents(t, db))
+
+	// A fully staged pool that diverges from the head block does not replace the unspent pool
+	require.NoError(t, db.Update("", func(tx *dbutil.Tx) error {
+		if err := tx.DeleteBucket(UnspentRepairPoolBkt); err != nil {
+			return err
+		}
+		return tx.DeleteBucket(UnspentRepairMetaBkt)
+	}))
+
+	var headSeq uint64
+	require.NoError(t, db.View("", func(tx *dbutil.Tx) error {
+		headSeq, _, err = bc.HeadSeq(tx)
+		return err
+	}))
+
+	stageDiverged(headSeq)
+	_, err = RepairUnspents(db, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rebuilt unspent pool hash does not match head block")
+	require.Equal(t, pool, unspentPoolContents(t, db))
+}
@@ -3,10 +3,12 @@ package visor
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/util/fee"
+
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 /*
@@ -152,14 +154,59 @@ func (e ErrTxnViolatesUserConstraint) Error() string {
 //      * That if that transaction does not spend from a locked distribution address
 //      * That the transaction does not create outputs with a higher decimal precision than is allowed
 func VerifySingleTxnSoftConstraints(txn coin.Transaction, headTime uint64, uxIn coin.UxArray, distParams params.Distribution, verifyParams params.VerifyTxn) error {
-	if err := verifyTxnSoftConstraints(txn, headTime, uxIn, distParams, verifyParams); err != nil {
+	return VerifySingleTxnSoftConstraintsWithPolicy(txn, headTime, uxIn, distParams, verifyParams, fee.NewConstantBurnPolicy(verifyParams.BurnFactor))
+}
+
+// VerifySingleTxnSoftConstraintsWithPolicy is VerifySingleTxnSoftConstraints, with the fee
+// verified against burnPolicy instead of verifyParams.BurnFactor
+func VerifySingleTxnSoftConstraintsWithPolicy(txn coin.Transaction, headTime uint64, uxIn coin.UxArray, distParams params.Distribution, verifyParams params.VerifyTxn, burnPolicy fee.BurnPolicy) error {
+	if err := verifyTxnSoftConstraints(txn, headTime, uxIn, distParams, verifyParams, burnPolicy); err != nil {
 		return NewErrTxnViolatesSoftConstraint(err)
 	}
 
 	return nil
 }
 
-func verifyTxnSoftConstraints(txn coin.Transaction, headTime uint64, uxIn coin.UxArray, distParams params.Distribution, verifyParams params.VerifyTxn) error {
+// NewBurnPolicy returns the fee.BurnPolicy to verify transactions with verifyParams.
+// schedule changes the burn factor of params.UserVerifyTxn and must be sorted by time.
+// verifyParams.BurnFactor applies until the first scheduled change takes effect.
+// After that, the burn factor keeps its ratio to the user burn factor, so that the
+// unconfirmed and create block burn factors stay distinct from the user burn factor.
+func NewBurnPolicy(verifyParams params.VerifyTxn, schedule []fee.BurnFactorChange) fee.BurnPolicy {
+	if len(schedule) == 0 {
+		return fee.NewConstantBurnPolicy(verifyParams.BurnFactor)
+	}
+
+	scaled := make([]fee.BurnFactorChange, len(schedule))
+	for i, c := range schedule {
+		scaled[i] = fee.BurnFactorChange{
+			Time:       c.Time,
+			BurnFactor: scaleBurnFactor(c.BurnFactor, verifyParams.BurnFactor),
+		}
+	}
+
+	return fee.ScheduledBurnPolicy{
+		BurnFactor: verifyParams.BurnFactor,
+		Schedule:   scaled,
+	}
+}
+
+// scaleBurnFactor multiplies a scheduled user burn factor by the ratio of burnFactor
+// to params.UserVerifyTxn.BurnFactor, capped at math.MaxUint32
+func scaleBurnFactor(userBurnFactor, burnFactor uint32) uint32 {
+	if burnFactor == params.UserVerifyTxn.BurnFactor {
+		return userBurnFactor
+	}
+
+	f := uint64(userBurnFactor) * uint64(burnFactor) / uint64(params.UserVerifyTxn.BurnFactor)
+	if f > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return uint32(f)
+}
+
+func verifyTxnSoftConstraints(txn coin.Transaction, headTime uint64, uxIn coin.UxArray, distParams params.Distribution, verifyParams params.VerifyTxn, burnPolicy fee.BurnPolicy) error {
 	txnSize, err := txn.Size()
 	if err != nil {
 		return ErrTxnExceedsMaxBlockSize
@@ -174,7 +221,7 @@ func verifyTxnSoftConstraints(txn coin.Transaction, headTime uint64, uxIn coin.U
 		return err
 	}
 
-	if err := fee.VerifyTransactionFee(&txn, f, verifyParams.BurnFactor); err != nil {
+	if err := fee.VerifyTransactionFeeWithPolicy(&txn, f, headTime, burnPolicy); err != nil {
 		return err
 	}
 
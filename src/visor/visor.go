@@ -11,6 +11,8 @@ Wallets are conceptually a second database.
 package visor
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"sort"
@@ -28,11 +30,21 @@ import (
 	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 	"github.com/skycoin/skycoin/src/visor/historydb"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/util/fee"
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 var logger = logging.MustGetLogger("visor")
 
+// contextLogger returns the package logger, adding the request ID carried by ctx to its log entries
+func contextLogger(ctx context.Context) *logging.Logger {
+	return &logging.Logger{
+		FieldLogger: plogging.FromContext(ctx, logger),
+	}
+}
+
 // Visor manages the blockchain
 type Visor struct {
 	Config Config
@@ -43,6 +55,21 @@ type Visor struct {
 	blockchain  Blockchainer
 	history     Historyer
 	wallets     *wallet.Service
+	head        *headNotifier
+
+	blockListeners   *blockListeners
+	walletBalances   *walletBalanceCache
+	balanceHistories *balanceHistoryCache
+
+	blockSubscriptions *BlockSubscriptions
+	unspentHash        *unspentHashStatus
+
+	// publisher guards the block publisher role of Config, which can be armed and disarmed at runtime
+	publisher publisherRole
+
+	// dbGate lets a database compaction quiesce the database transactions, see view and update
+	dbGate     dbGate
+	compaction dbCompactionStatus
 }
 
 // New creates a Visor for managing the blockchain database
@@ -72,8 +99,12 @@ func New(c Config, db *dbutil.DB, wltServ *wallet.Service) (*Visor, error) {
 	}
 
 	bc, err := NewBlockchain(db, BlockchainConfig{
-		Pubkey:      c.BlockchainPubkey,
-		Arbitrating: c.Arbitrating,
+		Pubkey:             c.BlockchainPubkey,
+		Arbitrating:        c.Arbitrating,
+		BurnFactorSchedule: c.BurnFactorSchedule,
+		GenesisOutputs:     c.GenesisOutputs,
+		GenesisCoinVolume:  c.GenesisCoinVolume,
+		Paranoid:           c.Paranoid,
 	})
 	if err != nil {
 		return nil, err
@@ -111,6 +142,29 @@ func New(c Config, db *dbutil.DB, wltServ *wallet.Service) (*Visor, error) {
 		unconfirmed: utp,
 		history:     history,
 		wallets:     wltServ,
+		head:        &headNotifier{},
+
+		blockListeners:   &blockListeners{},
+		walletBalances:   &walletBalanceCache{},
+		balanceHistories: &balanceHistoryCache{},
+
+		blockSubscriptions: &BlockSubscriptions{},
+		unspentHash:        &unspentHashStatus{},
+
+		publisher: publisherRole{
+			configured: c.IsBlockPublisher,
+		},
+	}
+
+	if err := db.View("init head notifier", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		v.head.init(headSeq, ok)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	return v, nil
@@ -129,7 +183,9 @@ func (vs *Visor) Init() error {
 		return nil
 	}
 
-	return vs.db.Update("visor init", func(tx *dbutil.Tx) error {
+	var headSeq uint64
+	var hasHead bool
+	if err := vs.update("visor init", func(tx *dbutil.Tx) error {
 		if err := vs.maybeCreateGenesisBlock(tx); err != nil {
 			return err
 		}
@@ -138,10 +194,38 @@ func (vs *Visor) Init() error {
 		if err != nil {
 			return err
 		}
-		logger.Infof("Removed %d invalid txns from pool", len(removed))
+		logger.WithField("removed", len(removed)).Info("Removed invalid txns from pool")
 
-		return nil
-	})
+		if err := vs.unconfirmed.RebuildSpendsIndex(tx); err != nil {
+			return err
+		}
+
+		headSeq, hasHead, err = vs.blockchain.HeadSeq(tx)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if hasHead {
+		vs.notifyHead(headSeq)
+	}
+
+	// A diverged unspent pool is reported but does not stop the node, the divergence is reported by the health status
+	r, err := vs.CheckUnspentHash()
+	if err != nil {
+		logger.WithError(err).Error("CheckUnspentHash failed")
+	} else if r != nil {
+		if err := r.Err(); err != nil {
+			logger.Critical().WithError(err).Error("Unspent pool diverged from the blockchain")
+		} else {
+			logger.WithFields(logrus.Fields{
+				"hash": r.Computed.Hex(),
+				"seq":  r.HeadSeq,
+			}).Info("Unspent pool hash matches head block")
+		}
+	}
+
+	return nil
 }
 
 func initHistory(tx *dbutil.Tx, bc *Blockchain, history *historydb.HistoryDB) error {
@@ -215,7 +299,7 @@ func (vs *Visor) maybeCreateGenesisBlock(tx *dbutil.Tx) error {
 
 	logger.Info("Create genesis block")
 	vs.GenesisPreconditions()
-	b, err := coin.NewGenesisBlock(vs.Config.GenesisAddress, vs.Config.GenesisCoinVolume, vs.Config.GenesisTimestamp)
+	b, err := newGenesisBlock(vs.Config)
 	if err != nil {
 		return err
 	}
@@ -224,7 +308,7 @@ func (vs *Visor) maybeCreateGenesisBlock(tx *dbutil.Tx) error {
 	// record the signature of genesis block
 	if vs.Config.IsBlockPublisher {
 		sb = vs.signBlock(*b)
-		logger.Infof("Genesis block signature=%s", sb.Sig.Hex())
+		logger.WithField("sig", sb.Sig.Hex()).Info("Signed genesis block")
 	} else {
 		sb = coin.SignedBlock{
 			Block: *b,
@@ -232,7 +316,7 @@ func (vs *Visor) maybeCreateGenesisBlock(tx *dbutil.Tx) error {
 		}
 	}
 
-	return vs.executeSignedBlock(tx, sb)
+	return vs.executeSignedBlock(tx, sb, nil)
 }
 
 // GenesisPreconditions panics if conditions for genesis block are not met
@@ -253,7 +337,7 @@ func (vs *Visor) StartedAt() time.Time {
 // all transaction that turn to valid.
 func (vs *Visor) RefreshUnconfirmed() ([]cipher.SHA256, error) {
 	var hashes []cipher.SHA256
-	if err := vs.db.Update("RefreshUnconfirmed", func(tx *dbutil.Tx) error {
+	if err := vs.update("RefreshUnconfirmed", func(tx *dbutil.Tx) error {
 		var err error
 		hashes, err = vs.unconfirmed.Refresh(tx, vs.blockchain, vs.Config.Distribution, vs.Config.UnconfirmedVerifyTxn)
 		return err
@@ -265,13 +349,53 @@ func (vs *Visor) RefreshUnconfirmed() ([]cipher.SHA256, error) {
 }
 
 // RemoveInvalidUnconfirmed removes transactions that become permanently invalid
-// (by violating hard constraints) from the pool.
+// (by violating hard constraints) from the pool, and transactions relayed by peers
+// that expired (see Config.UnconfirmedPeerTxnExpiry).
+// It also forgets abandoned transactions once they may be accepted again (see Config.UnconfirmedAbandonedTxnRefusal).
 // Returns the transaction hashes that were removed.
 func (vs *Visor) RemoveInvalidUnconfirmed() ([]cipher.SHA256, error) {
 	var hashes []cipher.SHA256
-	if err := vs.db.Update("RemoveInvalidUnconfirmed", func(tx *dbutil.Tx) error {
+	if err := vs.update("RemoveInvalidUnconfirmed", func(tx *dbutil.Tx) error {
 		var err error
 		hashes, err = vs.unconfirmed.RemoveInvalid(tx, vs.blockchain)
+		if err != nil {
+			return err
+		}
+
+		expired, err := vs.unconfirmed.RemoveExpired(tx, time.Now(), vs.Config.UnconfirmedPeerTxnExpiry)
+		if err != nil {
+			return err
+		}
+		if len(expired) > 0 {
+			logger.WithField("removed", len(expired)).Info("Removed expired peer txns from pool")
+		}
+
+		hashes = append(hashes, expired...)
+
+		n, err := vs.unconfirmed.RemoveExpiredAbandoned(tx, time.Now())
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logger.WithField("removed", n).Info("Forgot expired abandoned txns")
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// GetStuckUnconfirmedTxnHashes returns the hashes of the locally created transactions
+// that have been unconfirmed for longer than Config.UnconfirmedLocalTxnExpiry.
+// The user should replace them, for example with a higher fee, or abandon them.
+func (vs *Visor) GetStuckUnconfirmedTxnHashes() ([]cipher.SHA256, error) {
+	var hashes []cipher.SHA256
+	if err := vs.view("GetStuckUnconfirmedTxnHashes", func(tx *dbutil.Tx) error {
+		var err error
+		hashes, err = vs.unconfirmed.GetExpiredLocal(tx, time.Now(), vs.Config.UnconfirmedLocalTxnExpiry)
 		return err
 	}); err != nil {
 		return nil, err
@@ -280,6 +404,68 @@ func (vs *Visor) RemoveInvalidUnconfirmed() ([]cipher.SHA256, error) {
 	return hashes, nil
 }
 
+// GetUnconfirmedTxnAnnounceCounts returns the number of times each unconfirmed transaction was announced to peers
+func (vs *Visor) GetUnconfirmedTxnAnnounceCounts() (map[cipher.SHA256]uint64, error) {
+	counts := make(map[cipher.SHA256]uint64)
+	if err := vs.view("GetUnconfirmedTxnAnnounceCounts", func(tx *dbutil.Tx) error {
+		return vs.unconfirmed.ForEach(tx, func(hash cipher.SHA256, txn UnconfirmedTransaction) error {
+			counts[hash] = txn.AnnounceCount
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetUnconfirmedTxnOrigin returns where and when an unconfirmed transaction entered the pool.
+// Returns nil if the transaction is not in the pool.
+func (vs *Visor) GetUnconfirmedTxnOrigin(hash cipher.SHA256) (*UnconfirmedTxnOrigin, error) {
+	var o *UnconfirmedTxnOrigin
+	if err := vs.view("GetUnconfirmedTxnOrigin", func(tx *dbutil.Tx) error {
+		var err error
+		o, err = vs.unconfirmed.GetOrigin(tx, hash)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// AbandonUnconfirmedTransaction removes a locally created transaction from the pool.
+// Only the local pool is affected, peers that received the transaction may still have it.
+// If they relay it back, it is refused for Config.UnconfirmedAbandonedTxnRefusal.
+// Returns ErrUnconfirmedTxnNotFound if the transaction is not in the pool,
+// and ErrUnconfirmedTxnNotLocal if it was relayed by a peer.
+func (vs *Visor) AbandonUnconfirmedTransaction(hash cipher.SHA256) error {
+	return vs.update("AbandonUnconfirmedTransaction", func(tx *dbutil.Tx) error {
+		o, err := vs.unconfirmed.GetOrigin(tx, hash)
+		if err != nil {
+			return err
+		}
+
+		if o == nil {
+			return ErrUnconfirmedTxnNotFound
+		}
+
+		if o.Origin != TxnOriginLocal {
+			return ErrUnconfirmedTxnNotLocal
+		}
+
+		if err := vs.unconfirmed.RemoveTransactions(tx, []cipher.SHA256{hash}); err != nil {
+			return err
+		}
+
+		if vs.Config.UnconfirmedAbandonedTxnRefusal == 0 {
+			return nil
+		}
+
+		return vs.unconfirmed.SetAbandoned(tx, hash, time.Now().Add(vs.Config.UnconfirmedAbandonedTxnRefusal))
+	})
+}
+
 // createBlock creates a SignedBlock from pending transactions
 func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, error) {
 	if !vs.Config.IsBlockPublisher {
@@ -302,11 +488,20 @@ func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, erro
 
 // createBlockFromTxns creates a Block from specified set of transactions according to set of determinstic rules.
 func (vs *Visor) createBlockFromTxns(tx *dbutil.Tx, txns coin.Transactions, when uint64) (coin.Block, error) {
+	b, _, err := vs.packBlock(tx, txns, when)
+	return b, err
+}
+
+// packBlock creates a Block from specified set of transactions according to set of determinstic rules,
+// and returns the transactions that were excluded from the block with the reason for each.
+func (vs *Visor) packBlock(tx *dbutil.Tx, txns coin.Transactions, when uint64) (coin.Block, []ExcludedTxn, error) {
 	if len(txns) == 0 {
-		return coin.Block{}, errors.New("No transactions")
+		return coin.Block{}, nil, errNoTxns
 	}
 
-	logger.Infof("unconfirmed pool has %d transactions pending", len(txns))
+	logger.WithField("pending", len(txns)).Info("Packing block from unconfirmed pool")
+
+	var excluded []ExcludedTxn
 
 	// Filter transactions that violate all constraints
 	var filteredTxns coin.Transactions
@@ -314,9 +509,14 @@ func (vs *Visor) createBlockFromTxns(tx *dbutil.Tx, txns coin.Transactions, when
 		if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.Distribution, vs.Config.CreateBlockVerifyTxn, TxnSigned); err != nil {
 			switch err.(type) {
 			case ErrTxnViolatesHardConstraint, ErrTxnViolatesSoftConstraint:
-				logger.Warningf("Transaction %s violates constraints: %v", txn.Hash().Hex(), err)
+				logger.WithError(err).WithField("txid", txn.Hash().Hex()).Warning("Transaction violates constraints")
+				excluded = append(excluded, ExcludedTxn{
+					Txid:   txn.Hash(),
+					Reason: ExcludeReasonConstraint,
+					Detail: err.Error(),
+				})
 			default:
-				return coin.Block{}, err
+				return coin.Block{}, nil, err
 			}
 		} else {
 			filteredTxns = append(filteredTxns, txn)
@@ -325,36 +525,52 @@ func (vs *Visor) createBlockFromTxns(tx *dbutil.Tx, txns coin.Transactions, when
 
 	nRemoved := len(txns) - len(filteredTxns)
 	if nRemoved > 0 {
-		logger.Infof("CreateBlock ignored %d transactions violating constraints", nRemoved)
+		logger.WithField("ignored", nRemoved).Info("CreateBlock ignored transactions violating constraints")
 	}
 
 	txns = filteredTxns
 
 	if len(txns) == 0 {
 		logger.Info("No transactions after filtering for constraint violations")
-		return coin.Block{}, errors.New("No transactions after filtering for constraint violations")
+		return coin.Block{}, excluded, errNoTxnsAfterFiltering
 	}
 
 	head, err := vs.blockchain.Head(tx)
 	if err != nil {
-		return coin.Block{}, err
+		return coin.Block{}, nil, err
 	}
 
 	// Sort them by highest fee per kilobyte
 	txns, err = coin.SortTransactions(txns, vs.blockchain.TransactionFee(tx, head.Time()))
 	if err != nil {
 		logger.Critical().WithError(err).Error("SortTransactions failed, no block can be made until the offending transaction is removed")
-		return coin.Block{}, err
+		return coin.Block{}, nil, err
 	}
 
 	// Apply block size transaction limit
+	sortedTxns := txns
 	txns, err = txns.TruncateBytesTo(vs.Config.MaxBlockTransactionsSize)
 	if err != nil {
 		logger.Critical().WithError(err).Error("TruncateBytesTo failed, no block can be made until the offending transaction is removed")
-		return coin.Block{}, err
+		return coin.Block{}, nil, err
+	}
+
+	for _, txn := range sortedTxns[len(txns):] {
+		excluded = append(excluded, ExcludedTxn{
+			Txid:   txn.Hash(),
+			Reason: ExcludeReasonBlockSize,
+			Detail: fmt.Sprintf("block transactions size limit of %d bytes reached", vs.Config.MaxBlockTransactionsSize),
+		})
 	}
 
 	if len(txns) > coin.MaxBlockTransactions {
+		for _, txn := range txns[coin.MaxBlockTransactions:] {
+			excluded = append(excluded, ExcludedTxn{
+				Txid:   txn.Hash(),
+				Reason: ExcludeReasonMaxTransactions,
+				Detail: fmt.Sprintf("block transactions limit of %d reached", coin.MaxBlockTransactions),
+			})
+		}
 		txns = txns[:coin.MaxBlockTransactions]
 	}
 
@@ -362,39 +578,86 @@ func (vs *Visor) createBlockFromTxns(tx *dbutil.Tx, txns coin.Transactions, when
 		logger.Panic("TruncateBytesTo removed all transactions")
 	}
 
-	logger.Infof("Creating new block with %d transactions, head time %d", len(txns), when)
+	logger.WithFields(logrus.Fields{
+		"txns": len(txns),
+		"time": when,
+	}).Info("Creating new block")
 
 	b, err := vs.blockchain.NewBlock(tx, txns, when)
 	if err != nil {
-		logger.Warningf("blockchain.NewBlock failed: %v", err)
-		return coin.Block{}, err
+		logger.WithError(err).Warning("blockchain.NewBlock failed")
+		return coin.Block{}, nil, err
 	}
 
-	return *b, nil
+	// Transactions dropped by arbitration in NewBlock conflict with a transaction of a higher fee
+	included := make(map[cipher.SHA256]struct{}, len(b.Body.Transactions))
+	for _, txn := range b.Body.Transactions {
+		included[txn.Hash()] = struct{}{}
+	}
+	for _, txn := range txns {
+		if _, ok := included[txn.Hash()]; !ok {
+			excluded = append(excluded, ExcludedTxn{
+				Txid:   txn.Hash(),
+				Reason: ExcludeReasonConflict,
+				Detail: "conflicts with another transaction of the block",
+			})
+		}
+	}
+
+	return *b, excluded, nil
 }
 
-// CreateAndExecuteBlock creates a SignedBlock from pending transactions and executes it
+// CreateAndExecuteBlock creates a SignedBlock from pending transactions and executes it.
+// Returns ErrNotBlockPublisher if the block publisher role is not armed.
 func (vs *Visor) CreateAndExecuteBlock() (coin.SignedBlock, error) {
 	var sb coin.SignedBlock
+	var inputs [][]coin.UxOut
+	var t *BlockStageTimer
+	var commitStart time.Time
+
+	// The role can't be disarmed while a block is created
+	vs.publisher.RLock()
+	defer vs.publisher.RUnlock()
+	if !vs.Config.IsBlockPublisher {
+		return sb, ErrNotBlockPublisher
+	}
 
-	err := vs.db.Update("CreateAndExecuteBlock", func(tx *dbutil.Tx) error {
+	err := vs.update("CreateAndExecuteBlock", func(tx *dbutil.Tx) error {
 		var err error
 		sb, err = vs.createBlock(tx, uint64(time.Now().UTC().Unix()))
 		if err != nil {
 			return err
 		}
 
-		return vs.executeSignedBlock(tx, sb)
+		t = NewBlockStageTimer()
+		if err := vs.executeSignedBlock(tx, sb, t); err != nil {
+			return err
+		}
+
+		inputs, err = vs.executedBlockInputs(tx, &sb)
+		if err != nil {
+			return err
+		}
+
+		commitStart = time.Now()
+		return nil
 	})
+	vs.observeBlockStages(t, sb, commitStart, err)
+	if err != nil {
+		return sb, err
+	}
 
-	return sb, err
+	vs.notifyHead(sb.Seq())
+	vs.notifyBlockListeners(sb, inputs)
+
+	return sb, nil
 }
 
 // CreateBlockFromTxns creates a Block from specified set of transactions according to set of determinstic rules.
 func (vs *Visor) CreateBlockFromTxns(txns coin.Transactions, when uint64) (coin.Block, error) {
 	var sb coin.Block
 
-	err := vs.db.Update("CreateBlockFromTxns", func(tx *dbutil.Tx) error {
+	err := vs.update("CreateBlockFromTxns", func(tx *dbutil.Tx) error {
 		var err error
 		if sb, err = vs.createBlockFromTxns(tx, txns, when); err != nil {
 			return err
@@ -406,10 +669,95 @@ func (vs *Visor) CreateBlockFromTxns(txns coin.Transactions, when uint64) (coin.
 	return sb, err
 }
 
+// Reasons for excluding an unconfirmed transaction from a created block
+const (
+	// ExcludeReasonConstraint the transaction violates hard or soft constraints
+	ExcludeReasonConstraint = "constraint"
+	// ExcludeReasonBlockSize the transaction does not fit in the block transactions size limit
+	ExcludeReasonBlockSize = "block-size"
+	// ExcludeReasonMaxTransactions the block already has the maximum number of transactions
+	ExcludeReasonMaxTransactions = "max-transactions"
+	// ExcludeReasonConflict the transaction conflicts with another transaction of the block
+	ExcludeReasonConflict = "conflict"
+)
+
+var (
+	// ErrNotBlockPublisher is returned when a block is created or previewed by a node that is not a block publisher
+	ErrNotBlockPublisher = errors.New("Node is not a block publisher")
+
+	errNoTxns               = errors.New("No transactions")
+	errNoTxnsAfterFiltering = errors.New("No transactions after filtering for constraint violations")
+)
+
+// ExcludedTxn is an unconfirmed transaction excluded from a created block
+type ExcludedTxn struct {
+	Txid   cipher.SHA256
+	Reason string
+	Detail string
+}
+
+// BlockPreview is the block that a block publisher would create from the unconfirmed pool
+type BlockPreview struct {
+	// PoolSize is the number of transactions in the unconfirmed pool
+	PoolSize int
+	// Block is the unsigned block, nil if no transaction can be included in a block
+	Block *coin.Block
+	// Excluded are the unconfirmed transactions not included in the block
+	Excluded []ExcludedTxn
+}
+
+// PreviewBlock returns the block that would be created from the unconfirmed pool now,
+// without signing or executing it. Only a block publisher node can preview blocks.
+func (vs *Visor) PreviewBlock() (*BlockPreview, error) {
+	if !vs.IsBlockPublisher() {
+		return nil, ErrNotBlockPublisher
+	}
+
+	var p BlockPreview
+
+	if err := vs.view("PreviewBlock", func(tx *dbutil.Tx) error {
+		txns, err := vs.unconfirmed.AllRawTransactions(tx)
+		if err != nil {
+			return err
+		}
+
+		p.PoolSize = len(txns)
+
+		head, err := vs.blockchain.Head(tx)
+		if err != nil {
+			return err
+		}
+
+		// A block must be newer than its parent, if the head block was created in the current second
+		// preview the block that would be created in the next second
+		when := uint64(time.Now().UTC().Unix())
+		if when <= head.Time() {
+			when = head.Time() + 1
+		}
+
+		b, excluded, err := vs.packBlock(tx, txns, when)
+		switch err {
+		case nil:
+			p.Block = &b
+		case errNoTxns, errNoTxnsAfterFiltering:
+		default:
+			return err
+		}
+
+		p.Excluded = excluded
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
 // VerifyBlock verifies specified block against local copy of blockchain.
 // Signature is not verified.
 func (vs *Visor) VerifyBlock(b coin.SignedBlock) error {
-	return vs.db.View("VerifyBlock", func(tx *dbutil.Tx) error {
+	return vs.view("VerifyBlock", func(tx *dbutil.Tx) error {
 		return vs.blockchain.VerifyBlock(tx, &b)
 	})
 }
@@ -417,48 +765,145 @@ func (vs *Visor) VerifyBlock(b coin.SignedBlock) error {
 // ExecuteSignedBlock adds a block to the blockchain, or returns error.
 // Blocks must be executed in sequence, and be signed by a block publisher node.
 func (vs *Visor) ExecuteSignedBlock(b coin.SignedBlock) error {
-	return vs.db.Update("ExecuteSignedBlock", func(tx *dbutil.Tx) error {
-		return vs.executeSignedBlock(tx, b)
+	var inputs [][]coin.UxOut
+	var t *BlockStageTimer
+	var commitStart time.Time
+	err := vs.update("ExecuteSignedBlock", func(tx *dbutil.Tx) error {
+		t = NewBlockStageTimer()
+		if err := vs.executeSignedBlock(tx, b, t); err != nil {
+			return err
+		}
+
+		var err error
+		inputs, err = vs.executedBlockInputs(tx, &b)
+		if err != nil {
+			return err
+		}
+
+		commitStart = time.Now()
+		return nil
 	})
+	vs.observeBlockStages(t, b, commitStart, err)
+	if err != nil {
+		return err
+	}
+
+	vs.notifyHead(b.Seq())
+	vs.notifyBlockListeners(b, inputs)
+
+	return nil
 }
 
 // ExecuteSignedBlockUnsafe adds block to the blockchain, or returns error.
 // Blocks must be executed in sequence. Block signature is not verified.
 func (vs *Visor) ExecuteSignedBlockUnsafe(b coin.SignedBlock) error {
-	return vs.db.Update("ExecuteSignedBlockUnsafe", func(tx *dbutil.Tx) error {
-		return vs.executeSignedBlockUnsafe(tx, b)
+	var inputs [][]coin.UxOut
+	var t *BlockStageTimer
+	var commitStart time.Time
+	err := vs.update("ExecuteSignedBlockUnsafe", func(tx *dbutil.Tx) error {
+		t = NewBlockStageTimer()
+		if err := vs.executeSignedBlockUnsafe(tx, b, t); err != nil {
+			return err
+		}
+
+		var err error
+		inputs, err = vs.executedBlockInputs(tx, &b)
+		if err != nil {
+			return err
+		}
+
+		commitStart = time.Now()
+		return nil
 	})
+	vs.observeBlockStages(t, b, commitStart, err)
+	if err != nil {
+		return err
+	}
+
+	vs.notifyHead(b.Seq())
+	vs.notifyBlockListeners(b, inputs)
+
+	return nil
+}
+
+// notifyHead wakes the waiters of WaitHeadSeqAfter after the head changed to seq
+func (vs *Visor) notifyHead(seq uint64) {
+	if vs.head != nil {
+		vs.head.set(seq)
+	}
+}
+
+// WaitHeadSeqAfter blocks until the blockchain head seq is greater than seq, or ctx is done.
+// Returns true if the head seq is greater than seq.
+// Waiting does not hold a database transaction; waiters are woken when a block is executed.
+func (vs *Visor) WaitHeadSeqAfter(ctx context.Context, seq uint64) bool {
+	if vs.head == nil {
+		return false
+	}
+	return vs.head.waitAfter(ctx, seq)
+}
+
+// observeBlockStages records the stage durations of applying b in the metrics registry and traces a slow block.
+// If the database transaction was committed, commitStart is the time the commit started.
+func (vs *Visor) observeBlockStages(t *BlockStageTimer, b coin.SignedBlock, commitStart time.Time, err error) {
+	if t != nil && !commitStart.IsZero() {
+		t.Add(BlockStageFsync, time.Since(commitStart))
+	}
+	observeBlockStages(t, b, vs.Config.TraceSlowBlocks, err)
 }
 
 // executeSignedBlock adds a block to the blockchain, or returns error.
 // Blocks must be executed in sequence, and be signed by a block publisher node.
-func (vs *Visor) executeSignedBlock(tx *dbutil.Tx, b coin.SignedBlock) error {
-	if err := b.VerifySignature(vs.Config.BlockchainPubkey); err != nil {
+// The durations of the stages are recorded in t, which may be nil.
+func (vs *Visor) executeSignedBlock(tx *dbutil.Tx, b coin.SignedBlock, t *BlockStageTimer) error {
+	if err := t.Run(BlockStageVerifySigs, func() error {
+		return b.VerifySignature(vs.Config.BlockchainPubkey)
+	}); err != nil {
 		return err
 	}
 
-	return vs.executeSignedBlockUnsafe(tx, b)
+	return vs.executeSignedBlockUnsafe(tx, b, t)
 }
 
 // executeSignedBlockUnsafe add a block to the blockchain, or returns error.
 // Blocks must be executed in sequence. Block signature is not verified.
-func (vs *Visor) executeSignedBlockUnsafe(tx *dbutil.Tx, b coin.SignedBlock) error {
-	if err := vs.blockchain.ExecuteBlock(tx, &b); err != nil {
+// The durations of the stages are recorded in t, which may be nil.
+func (vs *Visor) executeSignedBlockUnsafe(tx *dbutil.Tx, b coin.SignedBlock, t *BlockStageTimer) error {
+	if err := vs.blockchain.ExecuteBlock(tx, &b, t); err != nil {
 		return err
 	}
 
-	// Remove the transactions in the Block from the unconfirmed pool
-	txnHashes := make([]cipher.SHA256, 0, len(b.Block.Body.Transactions))
-	for _, txn := range b.Block.Body.Transactions {
-		txnHashes = append(txnHashes, txn.Hash())
-	}
+	if err := t.Run(BlockStageUpdateUnconfirmed, func() error {
+		// Remove the transactions in the Block from the unconfirmed pool
+		txnHashes := make([]cipher.SHA256, 0, len(b.Block.Body.Transactions))
+		for _, txn := range b.Block.Body.Transactions {
+			txnHashes = append(txnHashes, txn.Hash())
+		}
 
-	if err := vs.unconfirmed.RemoveTransactions(tx, txnHashes); err != nil {
+		if err := vs.unconfirmed.RemoveTransactions(tx, txnHashes); err != nil {
+			return err
+		}
+
+		// Remove the transactions that double spend an input of the Block's transactions
+		removed, err := vs.unconfirmed.RemoveConflicts(tx, b.Block.Body.Transactions)
+		if err != nil {
+			return err
+		}
+		if len(removed) > 0 {
+			logger.WithFields(logrus.Fields{
+				"seq":     b.Seq(),
+				"removed": len(removed),
+			}).Info("Removed conflicting txns from pool after executing block")
+		}
+		return nil
+	}); err != nil {
 		return err
 	}
 
 	// Update the HistoryDB
-	return vs.history.ParseBlock(tx, b.Block)
+	return t.Run(BlockStageUpdateHistory, func() error {
+		return vs.history.ParseBlock(tx, b.Block)
+	})
 }
 
 // signBlock signs a block for a block publisher node. Will panic if anything is invalid
@@ -482,7 +927,7 @@ func (vs *Visor) signBlock(b coin.Block) coin.SignedBlock {
 // GetAllUnspentOutputs returns all unspent outputs
 func (vs *Visor) GetAllUnspentOutputs() (coin.UxArray, error) {
 	var ux []coin.UxOut
-	if err := vs.db.View("GetAllUnspentOutputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetAllUnspentOutputs", func(tx *dbutil.Tx) error {
 		var err error
 		ux, err = vs.blockchain.Unspent().GetAll(tx)
 		return err
@@ -497,7 +942,7 @@ func (vs *Visor) GetAllUnspentOutputs() (coin.UxArray, error) {
 // If any do not exist, ErrUnspentNotExist is returned
 func (vs *Visor) GetUnspentOutputs(hashes []cipher.SHA256) (coin.UxArray, error) {
 	var outputs coin.UxArray
-	if err := vs.db.View("GetUnspentOutputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUnspentOutputs", func(tx *dbutil.Tx) error {
 		var err error
 		outputs, err = vs.blockchain.Unspent().GetArray(tx, hashes)
 		return err
@@ -512,7 +957,7 @@ func (vs *Visor) GetUnspentOutputs(hashes []cipher.SHA256) (coin.UxArray, error)
 func (vs *Visor) UnconfirmedOutgoingOutputs() (coin.UxArray, error) {
 	var uxa coin.UxArray
 
-	if err := vs.db.View("UnconfirmedOutgoingOutputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("UnconfirmedOutgoingOutputs", func(tx *dbutil.Tx) error {
 		var err error
 		uxa, err = vs.unconfirmedOutgoingOutputs(tx)
 		return err
@@ -541,7 +986,7 @@ func (vs *Visor) unconfirmedOutgoingOutputs(tx *dbutil.Tx) (coin.UxArray, error)
 func (vs *Visor) UnconfirmedIncomingOutputs() (coin.UxArray, error) {
 	var uxa coin.UxArray
 
-	if err := vs.db.View("UnconfirmedIncomingOutputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("UnconfirmedIncomingOutputs", func(tx *dbutil.Tx) error {
 		var err error
 		uxa, err = vs.unconfirmedIncomingOutputs(tx)
 		return err
@@ -565,7 +1010,7 @@ func (vs *Visor) unconfirmedIncomingOutputs(tx *dbutil.Tx) (coin.UxArray, error)
 func (vs *Visor) GetSignedBlocksSince(seq, ct uint64) ([]coin.SignedBlock, error) {
 	var blocks []coin.SignedBlock
 
-	if err := vs.db.View("GetSignedBlocksSince", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetSignedBlocksSince", func(tx *dbutil.Tx) error {
 		avail := uint64(0)
 		head, err := vs.blockchain.Head(tx)
 		if err != nil {
@@ -608,7 +1053,7 @@ func (vs *Visor) HeadBkSeq() (uint64, bool, error) {
 	var headSeq uint64
 	var ok bool
 
-	if err := vs.db.View("HeadBkSeq", func(tx *dbutil.Tx) error {
+	if err := vs.view("HeadBkSeq", func(tx *dbutil.Tx) error {
 		var err error
 		headSeq, ok, err = vs.blockchain.HeadSeq(tx)
 		return err
@@ -624,7 +1069,7 @@ func (vs *Visor) GetBlockchainMetadata() (*BlockchainMetadata, error) {
 	var head *coin.SignedBlock
 	var unconfirmedLen, unspentsLen uint64
 
-	if err := vs.db.View("GetBlockchainMetadata", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetBlockchainMetadata", func(tx *dbutil.Tx) error {
 		var err error
 		head, err = vs.blockchain.Head(tx)
 		if err != nil {
@@ -649,7 +1094,7 @@ func (vs *Visor) GetBlockchainMetadata() (*BlockchainMetadata, error) {
 func (vs *Visor) GetBlock(seq uint64) (*coin.SignedBlock, error) {
 	var b *coin.SignedBlock
 
-	if err := vs.db.View("GetBlock", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetBlock", func(tx *dbutil.Tx) error {
 		headSeq, ok, err := vs.blockchain.HeadSeq(tx)
 		if err != nil {
 			return err
@@ -672,7 +1117,7 @@ func (vs *Visor) GetBlock(seq uint64) (*coin.SignedBlock, error) {
 func (vs *Visor) GetBlocks(seqs []uint64) ([]coin.SignedBlock, error) {
 	var blocks []coin.SignedBlock
 
-	if err := vs.db.View("GetBlocks", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetBlocks", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, err = vs.blockchain.GetBlocks(tx, seqs)
 		return err
@@ -688,7 +1133,7 @@ func (vs *Visor) GetBlocksVerbose(seqs []uint64) ([]coin.SignedBlock, [][][]Tran
 	var blocks []coin.SignedBlock
 	var inputs [][][]TransactionInput
 
-	if err := vs.db.View("GetBlocksVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetBlocksVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, inputs, err = vs.getBlocksVerbose(tx, func(tx *dbutil.Tx) ([]coin.SignedBlock, error) {
 			return vs.blockchain.GetBlocks(tx, seqs)
@@ -706,7 +1151,7 @@ func (vs *Visor) GetBlocksVerbose(seqs []uint64) ([]coin.SignedBlock, [][][]Tran
 func (vs *Visor) GetBlocksInRange(start, end uint64) ([]coin.SignedBlock, error) {
 	var blocks []coin.SignedBlock
 
-	if err := vs.db.View("GetBlocksInRange", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetBlocksInRange", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, err = vs.blockchain.GetBlocksInRange(tx, start, end)
 		return err
@@ -724,7 +1169,7 @@ func (vs *Visor) GetBlocksInRangeVerbose(start, end uint64) ([]coin.SignedBlock,
 	var blocks []coin.SignedBlock
 	var inputs [][][]TransactionInput
 
-	if err := vs.db.View("GetBlocksInRangeVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetBlocksInRangeVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, inputs, err = vs.getBlocksVerbose(tx, func(tx *dbutil.Tx) ([]coin.SignedBlock, error) {
 			return vs.blockchain.GetBlocksInRange(tx, start, end)
@@ -741,7 +1186,7 @@ func (vs *Visor) GetBlocksInRangeVerbose(start, end uint64) ([]coin.SignedBlock,
 func (vs *Visor) GetLastBlocks(num uint64) ([]coin.SignedBlock, error) {
 	var blocks []coin.SignedBlock
 
-	if err := vs.db.View("GetLastBlocks", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetLastBlocks", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, err = vs.blockchain.GetLastBlocks(tx, num)
 		return err
@@ -757,7 +1202,7 @@ func (vs *Visor) GetLastBlocksVerbose(num uint64) ([]coin.SignedBlock, [][][]Tra
 	var blocks []coin.SignedBlock
 	var inputs [][][]TransactionInput
 
-	if err := vs.db.View("GetLastBlocksVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetLastBlocksVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, inputs, err = vs.getBlocksVerbose(tx, func(tx *dbutil.Tx) ([]coin.SignedBlock, error) {
 			return vs.blockchain.GetLastBlocks(tx, num)
@@ -797,14 +1242,22 @@ func (vs *Visor) getBlocksVerbose(tx *dbutil.Tx, getBlocks func(*dbutil.Tx) ([]c
 // The bool return value is whether or not the transaction was already in the pool.
 // If the transaction violates hard constraints, it is rejected, and error will not be nil.
 // If the transaction only violates soft constraints, it is still injected, and the soft constraint violation is returned.
+// If the user abandoned the transaction recently, it is refused with ErrUnconfirmedTxnAbandoned.
 // This method is intended for transactions received over the network.
 func (vs *Visor) InjectForeignTransaction(txn coin.Transaction) (bool, *ErrTxnViolatesSoftConstraint, error) {
 	var known bool
 	var softErr *ErrTxnViolatesSoftConstraint
 
-	if err := vs.db.Update("InjectForeignTransaction", func(tx *dbutil.Tx) error {
-		var err error
-		known, softErr, err = vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.Distribution, vs.Config.UnconfirmedVerifyTxn)
+	if err := vs.update("InjectForeignTransaction", func(tx *dbutil.Tx) error {
+		abandoned, err := vs.unconfirmed.IsAbandoned(tx, txn.Hash(), time.Now())
+		if err != nil {
+			return err
+		}
+		if abandoned {
+			return ErrUnconfirmedTxnAbandoned
+		}
+
+		known, softErr, err = vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.Distribution, vs.Config.UnconfirmedVerifyTxn, TxnOriginPeer)
 		return err
 	}); err != nil {
 		return false, nil, err
@@ -822,7 +1275,7 @@ func (vs *Visor) InjectUserTransaction(txn coin.Transaction) (bool, *coin.Signed
 	var head *coin.SignedBlock
 	var inputs coin.UxArray
 
-	if err := vs.db.Update("InjectUserTransaction", func(tx *dbutil.Tx) error {
+	if err := vs.update("InjectUserTransaction", func(tx *dbutil.Tx) error {
 		var err error
 		known, head, inputs, err = vs.InjectUserTransactionTx(tx, txn)
 		return err
@@ -839,21 +1292,160 @@ func (vs *Visor) InjectUserTransaction(txn coin.Transaction) (bool, *coin.Signed
 // If the transaction violates hard or soft constraints, it is rejected, and error will not be nil.
 // This method is only exported for use by the daemon gateway's InjectBroadcastTransaction method.
 func (vs *Visor) InjectUserTransactionTx(tx *dbutil.Tx, txn coin.Transaction) (bool, *coin.SignedBlock, coin.UxArray, error) {
-	if err := VerifySingleTxnUserConstraints(txn); err != nil {
+	head, inputs, err := vs.verifyUserTransactionTx(tx, txn)
+	if err != nil {
 		return false, nil, nil, err
 	}
 
-	head, inputs, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.Distribution, params.UserVerifyTxn, TxnSigned)
+	known, softErr, err := vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.Distribution, params.UserVerifyTxn, TxnOriginLocal)
+	if softErr != nil {
+		logger.WithError(softErr).Warning("InjectUserTransaction vs.unconfirmed.InjectTransaction returned a softErr unexpectedly")
+	}
 	if err != nil {
 		return false, nil, nil, err
 	}
 
-	known, softErr, err := vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.Distribution, params.UserVerifyTxn)
-	if softErr != nil {
-		logger.WithError(softErr).Warning("InjectUserTransaction vs.unconfirmed.InjectTransaction returned a softErr unexpectedly")
+	// The user injects the transaction again deliberately, stop refusing it from peers
+	if err := vs.unconfirmed.ClearAbandoned(tx, txn.Hash()); err != nil {
+		return false, nil, nil, err
+	}
+
+	return known, head, inputs, nil
+}
+
+// verifyUserTransactionTx checks that a user-initiated transaction satisfies user, hard and soft constraints.
+// It is shared by InjectUserTransactionTx and TestAcceptTransactions, so that their results cannot diverge.
+func (vs *Visor) verifyUserTransactionTx(tx *dbutil.Tx, txn coin.Transaction) (*coin.SignedBlock, coin.UxArray, error) {
+	if err := VerifySingleTxnUserConstraints(txn); err != nil {
+		return nil, nil, err
+	}
+
+	return vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.Distribution, params.UserVerifyTxn, TxnSigned)
+}
+
+// Reject codes reported by TestAcceptTransactions
+const (
+	// RejectCodeUserConstraint the transaction violates user constraints
+	RejectCodeUserConstraint = "user-constraint"
+	// RejectCodeHardConstraint the transaction violates hard constraints
+	RejectCodeHardConstraint = "hard-constraint"
+	// RejectCodeSoftConstraint the transaction violates soft constraints
+	RejectCodeSoftConstraint = "soft-constraint"
+	// RejectCodeBatchDuplicate the transaction appears earlier in the batch
+	RejectCodeBatchDuplicate = "batch-duplicate"
+	// RejectCodeBatchDoubleSpend the transaction spends an input spent by an earlier transaction in the batch
+	RejectCodeBatchDoubleSpend = "batch-double-spend"
+	// RejectCodeBatchDependency the transaction spends an output created by another transaction in the batch
+	RejectCodeBatchDependency = "batch-dependency"
+)
+
+// TxnAcceptResult reports whether a transaction would be accepted by InjectUserTransaction
+type TxnAcceptResult struct {
+	Txid         cipher.SHA256
+	Accepted     bool
+	RejectCode   string
+	RejectReason string
+	Fee          uint64
+	Size         uint32
+}
+
+// TestAcceptTransactions reports whether each transaction would be accepted by InjectUserTransaction,
+// if the transactions were injected in order. The unconfirmed pool is not modified.
+// Unconfirmed transactions can't spend the outputs of other unconfirmed transactions,
+// so a transaction spending the output of another transaction in the batch is rejected,
+// as is a transaction spending an input already spent by an earlier accepted transaction in the batch.
+func (vs *Visor) TestAcceptTransactions(txns []coin.Transaction) ([]TxnAcceptResult, error) {
+	results := make([]TxnAcceptResult, len(txns))
+
+	if err := vs.view("TestAcceptTransactions", func(tx *dbutil.Tx) error {
+		head, err := vs.blockchain.Head(tx)
+		if err != nil {
+			return err
+		}
+
+		// Outputs created by any transaction in the batch, mapped to the creating transaction
+		created := make(map[cipher.SHA256]cipher.SHA256)
+		for _, txn := range txns {
+			txid := txn.Hash()
+			for _, ux := range coin.CreateUnspents(head.Head, txn) {
+				created[ux.Hash()] = txid
+			}
+		}
+
+		seen := make(map[cipher.SHA256]struct{}, len(txns))
+		spent := make(map[cipher.SHA256]cipher.SHA256)
+
+		for i, txn := range txns {
+			r := &results[i]
+			r.Txid = txn.Hash()
+
+			size, err := txn.Size()
+			if err != nil {
+				return err
+			}
+			r.Size = size
+
+			if _, ok := seen[r.Txid]; ok {
+				r.RejectCode = RejectCodeBatchDuplicate
+				r.RejectReason = "transaction appears earlier in the batch"
+				continue
+			}
+			seen[r.Txid] = struct{}{}
+
+			if code, reason := checkBatchInputs(txn, created, spent); code != "" {
+				r.RejectCode = code
+				r.RejectReason = reason
+				continue
+			}
+
+			_, inputs, err := vs.verifyUserTransactionTx(tx, txn)
+			if err != nil {
+				switch err.(type) {
+				case ErrTxnViolatesUserConstraint:
+					r.RejectCode = RejectCodeUserConstraint
+				case ErrTxnViolatesHardConstraint:
+					r.RejectCode = RejectCodeHardConstraint
+				case ErrTxnViolatesSoftConstraint:
+					r.RejectCode = RejectCodeSoftConstraint
+				default:
+					return err
+				}
+				r.RejectReason = err.Error()
+				continue
+			}
+
+			f, err := fee.TransactionFee(&txn, head.Time(), inputs)
+			if err != nil {
+				return err
+			}
+
+			r.Accepted = true
+			r.Fee = f
+			for _, h := range txn.In {
+				spent[h] = r.Txid
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
-	return known, head, inputs, err
+	return results, nil
+}
+
+// checkBatchInputs returns a reject code and reason if a transaction in a TestAcceptTransactions batch
+// spends an output created in the batch, or an input spent by an earlier accepted transaction in the batch
+func checkBatchInputs(txn coin.Transaction, created, spent map[cipher.SHA256]cipher.SHA256) (string, string) {
+	for _, h := range txn.In {
+		if txid, ok := created[h]; ok {
+			return RejectCodeBatchDependency, fmt.Sprintf("input %s is created by unconfirmed transaction %s in the batch", h.Hex(), txid.Hex())
+		}
+		if txid, ok := spent[h]; ok {
+			return RejectCodeBatchDoubleSpend, fmt.Sprintf("input %s is already spent by transaction %s in the batch", h.Hex(), txid.Hex())
+		}
+	}
+	return "", ""
 }
 
 // GetTransactionsForAddress returns the Transactions whose unspents give coins to a cipher.Address.
@@ -861,7 +1453,7 @@ func (vs *Visor) InjectUserTransactionTx(tx *dbutil.Tx, txn coin.Transaction) (b
 func (vs *Visor) GetTransactionsForAddress(a cipher.Address) ([]Transaction, error) {
 	var txns map[cipher.Address][]Transaction
 
-	if err := vs.db.View("GetTransactionsForAddress", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetTransactionsForAddress", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.getTransactionsForAddresses(tx, []cipher.Address{a})
 		return err
@@ -876,7 +1468,7 @@ func (vs *Visor) GetTransactionsForAddress(a cipher.Address) ([]Transaction, err
 func (vs *Visor) GetTransaction(txnHash cipher.SHA256) (*Transaction, error) {
 	var txn *Transaction
 
-	if err := vs.db.View("GetTransaction", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetTransaction", func(tx *dbutil.Tx) error {
 		var err error
 		txn, err = vs.getTransaction(tx, txnHash)
 		return err
@@ -892,7 +1484,7 @@ func (vs *Visor) GetTransactionWithInputs(txnHash cipher.SHA256) (*Transaction,
 	var txn *Transaction
 	var inputs []TransactionInput
 
-	if err := vs.db.View("GetTransactionWithInputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetTransactionWithInputs", func(tx *dbutil.Tx) error {
 		var err error
 		txn, err = vs.getTransaction(tx, txnHash)
 		if err != nil {
@@ -1013,7 +1605,7 @@ func NewConfirmedTxFilter(isConfirmed bool) TxFilter {
 func (vs *Visor) GetTransactions(flts []TxFilter) ([]Transaction, error) {
 	var txns []Transaction
 
-	if err := vs.db.View("GetTransactions", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetTransactions", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.getTransactions(tx, flts)
 		return err
@@ -1029,7 +1621,7 @@ func (vs *Visor) GetTransactionsWithInputs(flts []TxFilter) ([]Transaction, [][]
 	var txns []Transaction
 	var inputs [][]TransactionInput
 
-	if err := vs.db.View("GetTransactionsWithInputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetTransactionsWithInputs", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.getTransactions(tx, flts)
 		if err != nil {
@@ -1348,7 +1940,7 @@ func (vs *Visor) AddressBalances(head *coin.SignedBlock, auxs coin.AddressUxOuts
 func (vs *Visor) GetUnconfirmedTransactions(filter func(UnconfirmedTransaction) bool) ([]UnconfirmedTransaction, error) {
 	var txns []UnconfirmedTransaction
 
-	if err := vs.db.View("GetUnconfirmedTransactions", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUnconfirmedTransactions", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.unconfirmed.GetFiltered(tx, filter)
 		return err
@@ -1364,7 +1956,7 @@ func (vs *Visor) GetUnconfirmedTransactionsVerbose(filter func(UnconfirmedTransa
 	var txns []UnconfirmedTransaction
 	var inputs [][]TransactionInput
 
-	if err := vs.db.View("GetUnconfirmedTransactionsVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUnconfirmedTransactionsVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.unconfirmed.GetFiltered(tx, filter)
 		if err != nil {
@@ -1404,7 +1996,7 @@ func SendsToAddresses(addresses []cipher.Address) func(UnconfirmedTransaction) b
 func (vs *Visor) GetAllUnconfirmedTransactions() ([]UnconfirmedTransaction, error) {
 	var txns []UnconfirmedTransaction
 
-	if err := vs.db.View("GetAllUnconfirmedTransactions", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetAllUnconfirmedTransactions", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.unconfirmed.GetFiltered(tx, All)
 		return err
@@ -1415,12 +2007,27 @@ func (vs *Visor) GetAllUnconfirmedTransactions() ([]UnconfirmedTransaction, erro
 	return txns, nil
 }
 
+// GetUnconfirmedConflicts returns the uxids spent by more than one unconfirmed transaction,
+// mapped to the hashes of the transactions spending them
+func (vs *Visor) GetUnconfirmedConflicts() (map[cipher.SHA256][]cipher.SHA256, error) {
+	var conflicts map[cipher.SHA256][]cipher.SHA256
+	if err := vs.view("GetUnconfirmedConflicts", func(tx *dbutil.Tx) error {
+		var err error
+		conflicts, err = vs.unconfirmed.Conflicts(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
 // GetAllUnconfirmedTransactionsVerbose returns all unconfirmed transactions with verbose transaction input data
 func (vs *Visor) GetAllUnconfirmedTransactionsVerbose() ([]UnconfirmedTransaction, [][]TransactionInput, error) {
 	var txns []UnconfirmedTransaction
 	var inputs [][]TransactionInput
 
-	if err := vs.db.View("GetAllUnconfirmedTransactionsVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetAllUnconfirmedTransactionsVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		txns, err = vs.unconfirmed.GetFiltered(tx, All)
 		if err != nil {
@@ -1512,7 +2119,7 @@ func (vs *Visor) getFeeCalcTimeForTransaction(tx *dbutil.Tx, txn Transaction) (*
 func (vs *Visor) GetAllValidUnconfirmedTxHashes() ([]cipher.SHA256, error) {
 	var hashes []cipher.SHA256
 
-	if err := vs.db.View("GetAllValidUnconfirmedTxHashes", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetAllValidUnconfirmedTxHashes", func(tx *dbutil.Tx) error {
 		var err error
 		hashes, err = vs.unconfirmed.GetHashes(tx, IsValid)
 		return err
@@ -1523,11 +2130,30 @@ func (vs *Visor) GetAllValidUnconfirmedTxHashes() ([]cipher.SHA256, error) {
 	return hashes, nil
 }
 
+// GetValidUnconfirmedTxHashesToAnnounce returns the valid unconfirmed transaction hashes
+// that were not announced to peers since reannounceInterval before now
+func (vs *Visor) GetValidUnconfirmedTxHashesToAnnounce(now time.Time, reannounceInterval time.Duration) ([]cipher.SHA256, error) {
+	before := now.Add(-reannounceInterval).UnixNano()
+
+	var hashes []cipher.SHA256
+	if err := vs.view("GetValidUnconfirmedTxHashesToAnnounce", func(tx *dbutil.Tx) error {
+		var err error
+		hashes, err = vs.unconfirmed.GetHashes(tx, func(txn UnconfirmedTransaction) bool {
+			return IsValid(txn) && txn.Announced <= before
+		})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
 // GetConfirmedTransaction returns transaction, which has been already included in some block.
 func (vs *Visor) GetConfirmedTransaction(txnHash cipher.SHA256) (*coin.Transaction, error) {
 	var histTxn *historydb.Transaction
 
-	if err := vs.db.View("GetConfirmedTransaction", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetConfirmedTransaction", func(tx *dbutil.Tx) error {
 		var err error
 		histTxn, err = vs.history.GetTransaction(tx, txnHash)
 		return err
@@ -1547,7 +2173,7 @@ func (vs *Visor) GetConfirmedTransaction(txnHash cipher.SHA256) (*coin.Transacti
 func (vs *Visor) GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error) {
 	var sb *coin.SignedBlock
 
-	if err := vs.db.View("GetSignedBlockByHash", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetSignedBlockByHash", func(tx *dbutil.Tx) error {
 		var err error
 		sb, err = vs.blockchain.GetSignedBlockByHash(tx, hash)
 		return err
@@ -1562,7 +2188,7 @@ func (vs *Visor) GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, er
 func (vs *Visor) GetSignedBlockBySeq(seq uint64) (*coin.SignedBlock, error) {
 	var b *coin.SignedBlock
 
-	if err := vs.db.View("GetSignedBlockBySeq", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetSignedBlockBySeq", func(tx *dbutil.Tx) error {
 		var err error
 		b, err = vs.blockchain.GetSignedBlockBySeq(tx, seq)
 		return err
@@ -1578,7 +2204,7 @@ func (vs *Visor) GetSignedBlockByHashVerbose(hash cipher.SHA256) (*coin.SignedBl
 	var b *coin.SignedBlock
 	var inputs [][]TransactionInput
 
-	if err := vs.db.View("GetSignedBlockByHashVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetSignedBlockByHashVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		b, inputs, err = vs.getBlockVerbose(tx, func(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 			return vs.blockchain.GetSignedBlockByHash(tx, hash)
@@ -1596,7 +2222,7 @@ func (vs *Visor) GetSignedBlockBySeqVerbose(seq uint64) (*coin.SignedBlock, [][]
 	var b *coin.SignedBlock
 	var inputs [][]TransactionInput
 
-	if err := vs.db.View("GetSignedBlockBySeqVerbose", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetSignedBlockBySeqVerbose", func(tx *dbutil.Tx) error {
 		var err error
 		b, inputs, err = vs.getBlockVerbose(tx, func(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 			return vs.blockchain.GetSignedBlockBySeq(tx, seq)
@@ -1703,10 +2329,10 @@ func (vs *Visor) getTransactionInputs(tx *dbutil.Tx, feeCalcTime uint64, inputs
 }
 
 // GetHeadBlock gets head block.
-func (vs Visor) GetHeadBlock() (*coin.SignedBlock, error) {
+func (vs *Visor) GetHeadBlock() (*coin.SignedBlock, error) {
 	var b *coin.SignedBlock
 
-	if err := vs.db.View("GetHeadBlock", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetHeadBlock", func(tx *dbutil.Tx) error {
 		var err error
 		b, err = vs.blockchain.Head(tx)
 		return err
@@ -1718,10 +2344,10 @@ func (vs Visor) GetHeadBlock() (*coin.SignedBlock, error) {
 }
 
 // GetHeadBlockTime returns the time of the head block.
-func (vs Visor) GetHeadBlockTime() (uint64, error) {
+func (vs *Visor) GetHeadBlockTime() (uint64, error) {
 	var t uint64
 
-	if err := vs.db.View("GetHeadBlockTime", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetHeadBlockTime", func(tx *dbutil.Tx) error {
 		var err error
 		t, err = vs.blockchain.Time(tx)
 		return err
@@ -1733,10 +2359,10 @@ func (vs Visor) GetHeadBlockTime() (uint64, error) {
 }
 
 // GetUxOutByID gets UxOut by hash id.
-func (vs Visor) GetUxOutByID(id cipher.SHA256) (*historydb.UxOut, error) {
+func (vs *Visor) GetUxOutByID(id cipher.SHA256) (*historydb.UxOut, error) {
 	var outs []historydb.UxOut
 
-	if err := vs.db.View("GetUxOutByID", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUxOutByID", func(tx *dbutil.Tx) error {
 		var err error
 		outs, err = vs.history.GetUxOuts(tx, []cipher.SHA256{id})
 		return err
@@ -1752,10 +2378,10 @@ func (vs Visor) GetUxOutByID(id cipher.SHA256) (*historydb.UxOut, error) {
 }
 
 // GetSpentOutputsForAddresses gets all the spent outputs of a set of addresses
-func (vs Visor) GetSpentOutputsForAddresses(addresses []cipher.Address) ([][]historydb.UxOut, error) {
+func (vs *Visor) GetSpentOutputsForAddresses(addresses []cipher.Address) ([][]historydb.UxOut, error) {
 	out := make([][]historydb.UxOut, len(addresses))
 
-	if err := vs.db.View("GetSpentOutputsForAddresses", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetSpentOutputsForAddresses", func(tx *dbutil.Tx) error {
 		for i, addr := range addresses {
 			addrUxOuts, err := vs.history.GetOutputsForAddress(tx, addr)
 			if err != nil {
@@ -1777,7 +2403,7 @@ func (vs Visor) GetSpentOutputsForAddresses(addresses []cipher.Address) ([][]his
 func (vs *Visor) RecvOfAddresses(addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	var uxouts coin.AddressUxOuts
 
-	if err := vs.db.View("RecvOfAddresses", func(tx *dbutil.Tx) error {
+	if err := vs.view("RecvOfAddresses", func(tx *dbutil.Tx) error {
 		head, err := vs.blockchain.Head(tx)
 		if err != nil {
 			return err
@@ -1796,7 +2422,7 @@ func (vs *Visor) RecvOfAddresses(addrs []cipher.Address) (coin.AddressUxOuts, er
 func (vs *Visor) GetIncomingOutputs() (coin.UxArray, error) {
 	var uxa coin.UxArray
 
-	if err := vs.db.View("GetIncomingOutputs", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetIncomingOutputs", func(tx *dbutil.Tx) error {
 		head, err := vs.blockchain.Head(tx)
 		if err != nil {
 			return err
@@ -1815,7 +2441,7 @@ func (vs *Visor) GetIncomingOutputs() (coin.UxArray, error) {
 func (vs *Visor) GetUnconfirmedTxn(hash cipher.SHA256) (*UnconfirmedTransaction, error) {
 	var txn *UnconfirmedTransaction
 
-	if err := vs.db.View("GetUnconfirmedTxn", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUnconfirmedTxn", func(tx *dbutil.Tx) error {
 		var err error
 		txn, err = vs.unconfirmed.Get(tx, hash)
 		return err
@@ -1826,14 +2452,30 @@ func (vs *Visor) GetUnconfirmedTxn(hash cipher.SHA256) (*UnconfirmedTransaction,
 	return txn, nil
 }
 
-// FilterKnownUnconfirmed returns unconfirmed txn hashes with known ones removed
+// FilterKnownUnconfirmed returns unconfirmed txn hashes with known ones removed.
+// Transactions abandoned by the user recently count as known.
 func (vs *Visor) FilterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	var hashes []cipher.SHA256
 
-	if err := vs.db.View("FilterKnownUnconfirmed", func(tx *dbutil.Tx) error {
-		var err error
-		hashes, err = vs.unconfirmed.FilterKnown(tx, txns)
-		return err
+	if err := vs.view("FilterKnownUnconfirmed", func(tx *dbutil.Tx) error {
+		unknown, err := vs.unconfirmed.FilterKnown(tx, txns)
+		if err != nil {
+			return err
+		}
+
+		// Transactions abandoned by the user are not requested from peers
+		now := time.Now()
+		for _, h := range unknown {
+			abandoned, err := vs.unconfirmed.IsAbandoned(tx, h, now)
+			if err != nil {
+				return err
+			}
+			if !abandoned {
+				hashes = append(hashes, h)
+			}
+		}
+
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -1845,7 +2487,7 @@ func (vs *Visor) FilterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256,
 func (vs *Visor) GetKnownUnconfirmed(txns []cipher.SHA256) (coin.Transactions, error) {
 	var hashes coin.Transactions
 
-	if err := vs.db.View("GetKnownUnconfirmed", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetKnownUnconfirmed", func(tx *dbutil.Tx) error {
 		var err error
 		hashes, err = vs.unconfirmed.GetKnown(tx, txns)
 		return err
@@ -1860,7 +2502,7 @@ func (vs *Visor) GetKnownUnconfirmed(txns []cipher.SHA256) (coin.Transactions, e
 func (vs *Visor) UnconfirmedSpendsOfAddresses(addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	var outs coin.AddressUxOuts
 
-	if err := vs.db.View("UnconfirmedSpendsOfAddresses", func(tx *dbutil.Tx) error {
+	if err := vs.view("UnconfirmedSpendsOfAddresses", func(tx *dbutil.Tx) error {
 		var err error
 		outs, err = vs.unconfirmedSpendsOfAddresses(tx, addrs)
 		return err
@@ -1904,29 +2546,92 @@ func (vs *Visor) unconfirmedSpendsOfAddresses(tx *dbutil.Tx, addrs []cipher.Addr
 	return outs, nil
 }
 
-// SetTransactionsAnnounced updates announced time of specific tx
-func (vs *Visor) SetTransactionsAnnounced(hashes map[cipher.SHA256]int64) error {
-	if len(hashes) == 0 {
+// SetTransactionsAnnounced records the announcements of transactions to peers
+func (vs *Visor) SetTransactionsAnnounced(announcements map[cipher.SHA256]TxnAnnouncement) error {
+	if len(announcements) == 0 {
 		return nil
 	}
 
-	return vs.db.Update("SetTransactionsAnnounced", func(tx *dbutil.Tx) error {
-		return vs.unconfirmed.SetTransactionsAnnounced(tx, hashes)
+	return vs.update("SetTransactionsAnnounced", func(tx *dbutil.Tx) error {
+		return vs.unconfirmed.SetTransactionsAnnounced(tx, announcements)
 	})
 }
 
+// GetUnconfirmedTxnAnnouncements returns the announcement state of the unconfirmed transactions that were announced to peers
+func (vs *Visor) GetUnconfirmedTxnAnnouncements() (map[cipher.SHA256]TxnAnnouncement, error) {
+	announcements := make(map[cipher.SHA256]TxnAnnouncement)
+	if err := vs.view("GetUnconfirmedTxnAnnouncements", func(tx *dbutil.Tx) error {
+		return vs.unconfirmed.ForEach(tx, func(hash cipher.SHA256, txn UnconfirmedTransaction) error {
+			if txn.Announced <= 0 {
+				return nil
+			}
+
+			a, err := vs.unconfirmed.GetAnnouncement(tx, hash)
+			if err != nil {
+				return err
+			}
+			if a != nil {
+				announcements[hash] = *a
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return announcements, nil
+}
+
+// RestoreUnconfirmedTxnAnnouncements restores the announcement state of unconfirmed transactions saved before a restart,
+// so that they are not announced again before DaemonConfig.TxnReannounceInterval elapsed.
+// The transactions that are not in the pool, or were announced since, are skipped.
+// Returns the number of restored announcements.
+func (vs *Visor) RestoreUnconfirmedTxnAnnouncements(announcements map[cipher.SHA256]TxnAnnouncement) (int, error) {
+	if len(announcements) == 0 {
+		return 0, nil
+	}
+
+	var n int
+	if err := vs.update("RestoreUnconfirmedTxnAnnouncements", func(tx *dbutil.Tx) error {
+		n = 0
+		for hash, a := range announcements {
+			ok, err := vs.unconfirmed.RestoreAnnouncement(tx, hash, a)
+			if err != nil {
+				return err
+			}
+			if ok {
+				n++
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
 // GetBalanceOfAddresses returns balance pairs of given addreses
-func (vs Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalancePair, error) {
+func (vs *Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalancePair, error) {
 	if len(addrs) == 0 {
 		return nil, nil
 	}
 
+	bps, _, err := vs.balanceOfAddresses("GetBalanceOfAddresses", addrs, func(tx *dbutil.Tx, _ *coin.SignedBlock) (coin.AddressUxOuts, error) {
+		return vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, addrs)
+	})
+	return bps, err
+}
+
+// balanceOfAddresses returns balance pairs of given addresses and the head block they were computed at.
+// getUnspents returns the confirmed unspent outputs of the addresses at the head block.
+func (vs *Visor) balanceOfAddresses(name string, addrs []cipher.Address, getUnspents func(*dbutil.Tx, *coin.SignedBlock) (coin.AddressUxOuts, error)) ([]wallet.BalancePair, *coin.SignedBlock, error) {
 	auxs := make(coin.AddressUxOuts, len(addrs))
 	recvUxs := make(coin.AddressUxOuts, len(addrs))
 	var uxa coin.UxArray
 	var head *coin.SignedBlock
 
-	if err := vs.db.View("GetBalanceOfAddresses", func(tx *dbutil.Tx) error {
+	if err := vs.view(name, func(tx *dbutil.Tx) error {
 		var err error
 		head, err = vs.blockchain.Head(tx)
 		if err != nil {
@@ -1957,14 +2662,14 @@ func (vs Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalanceP
 		}
 
 		// Get unspents owned by the addresses
-		auxs, err = vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, addrs)
+		auxs, err = getUnspents(tx, head)
 		if err != nil {
 			return fmt.Errorf("GetUnspentsOfAddrs failed when checking addresses balance: %v", err)
 		}
 
 		return nil
 	}); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Build all unconfirmed transaction inputs that are associated with the addresses
@@ -1997,7 +2702,7 @@ func (vs Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalanceP
 
 		coins, err := uxs.Coins()
 		if err != nil {
-			return nil, fmt.Errorf("uxs.Coins failed: %v", err)
+			return nil, nil, fmt.Errorf("uxs.Coins failed: %v", err)
 		}
 
 		coinHours, err := uxs.CoinHours(headTime)
@@ -2006,13 +2711,13 @@ func (vs Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalanceP
 			case coin.ErrAddEarnedCoinHoursAdditionOverflow:
 				coinHours = 0
 			default:
-				return nil, fmt.Errorf("uxs.CoinHours failed: %v", err)
+				return nil, nil, fmt.Errorf("uxs.CoinHours failed: %v", err)
 			}
 		}
 
 		pcoins, err := predictedUxs.Coins()
 		if err != nil {
-			return nil, fmt.Errorf("predictedUxs.Coins failed: %v", err)
+			return nil, nil, fmt.Errorf("predictedUxs.Coins failed: %v", err)
 		}
 
 		pcoinHours, err := predictedUxs.CoinHours(headTime)
@@ -2021,7 +2726,7 @@ func (vs Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalanceP
 			case coin.ErrAddEarnedCoinHoursAdditionOverflow:
 				coinHours = 0
 			default:
-				return nil, fmt.Errorf("predictedUxs.CoinHours failed: %v", err)
+				return nil, nil, fmt.Errorf("predictedUxs.CoinHours failed: %v", err)
 			}
 		}
 
@@ -2039,14 +2744,14 @@ func (vs Visor) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet.BalanceP
 		bps = append(bps, bp)
 	}
 
-	return bps, nil
+	return bps, head, nil
 }
 
 // GetUnspentsOfAddrs returns unspent outputs of multiple addresses
 func (vs *Visor) GetUnspentsOfAddrs(addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	var uxa coin.AddressUxOuts
 
-	if err := vs.db.View("GetUnspentsOfAddrs", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUnspentsOfAddrs", func(tx *dbutil.Tx) error {
 		var err error
 		uxa, err = vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, addrs)
 		return err
@@ -2057,6 +2762,94 @@ func (vs *Visor) GetUnspentsOfAddrs(addrs []cipher.Address) (coin.AddressUxOuts,
 	return uxa, nil
 }
 
+// AddressOutputsSummary aggregates the confirmed unspent outputs owned by an address
+type AddressOutputsSummary struct {
+	Address cipher.Address
+	Coins   uint64
+	Hours   uint64
+	Count   uint64
+	// LargestUxID is the hash of the output with the most coins, ties are broken by the lowest hash
+	LargestUxID  cipher.SHA256
+	LargestCoins uint64
+}
+
+// GetAddressOutputsSummary returns per-address totals of confirmed unspent outputs.
+// Coin hours are calculated at the head block time.
+// One summary is returned for each address in addrs, in the same order,
+// including zero value summaries for addresses without outputs.
+func (vs *Visor) GetAddressOutputsSummary(addrs []cipher.Address) ([]AddressOutputsSummary, error) {
+	summaries := make([]AddressOutputsSummary, len(addrs))
+
+	if err := vs.view("GetAddressOutputsSummary", func(tx *dbutil.Tx) error {
+		headTime, err := vs.blockchain.Time(tx)
+		if err != nil {
+			return err
+		}
+
+		addrHashes, err := vs.blockchain.Unspent().GetUnspentHashesOfAddrs(tx, addrs)
+		if err != nil {
+			return fmt.Errorf("GetUnspentHashesOfAddrs failed: %v", err)
+		}
+
+		for i, addr := range addrs {
+			summaries[i].Address = addr
+
+			for _, h := range addrHashes[addr] {
+				ux, err := vs.blockchain.Unspent().Get(tx, h)
+				if err != nil {
+					return fmt.Errorf("Unspent().Get(%s) failed: %v", h.Hex(), err)
+				}
+
+				if err := summaries[i].add(ux, headTime); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// add accumulates an unspent output into the summary
+func (s *AddressOutputsSummary) add(ux *coin.UxOut, headTime uint64) error {
+	coins, err := mathutil.AddUint64(s.Coins, ux.Body.Coins)
+	if err != nil {
+		return err
+	}
+
+	uxHours, err := ux.CoinHours(headTime)
+	if err != nil {
+		switch err {
+		case coin.ErrAddEarnedCoinHoursAdditionOverflow:
+			uxHours = 0
+		default:
+			return err
+		}
+	}
+
+	hours, err := mathutil.AddUint64(s.Hours, uxHours)
+	if err != nil {
+		return err
+	}
+
+	s.Coins = coins
+	s.Hours = hours
+	s.Count++
+
+	h := ux.Hash()
+	if s.Count == 1 || ux.Body.Coins > s.LargestCoins ||
+		(ux.Body.Coins == s.LargestCoins && bytes.Compare(h[:], s.LargestUxID[:]) < 0) {
+		s.LargestUxID = h
+		s.LargestCoins = ux.Body.Coins
+	}
+
+	return nil
+}
+
 // VerifyTxnVerbose verifies a transaction, it returns transaction's input uxouts, whether the
 // transaction is confirmed, and error if any
 func (vs *Visor) VerifyTxnVerbose(txn *coin.Transaction, signed TxnSignedFlag) ([]TransactionInput, bool, error) {
@@ -2064,7 +2857,7 @@ func (vs *Visor) VerifyTxnVerbose(txn *coin.Transaction, signed TxnSignedFlag) (
 	var isTxnConfirmed bool
 	var feeCalcTime uint64
 
-	verifyErr := vs.db.View("VerifyTxnVerbose", func(tx *dbutil.Tx) error {
+	verifyErr := vs.view("VerifyTxnVerbose", func(tx *dbutil.Tx) error {
 		head, err := vs.blockchain.Head(tx)
 		if err != nil {
 			return err
@@ -2131,7 +2924,7 @@ func (vs *Visor) VerifyTxnVerbose(txn *coin.Transaction, signed TxnSignedFlag) (
 			return err
 		}
 
-		if err := VerifySingleTxnSoftConstraints(*txn, feeCalcTime, uxa, vs.Config.Distribution, params.UserVerifyTxn); err != nil {
+		if err := VerifySingleTxnSoftConstraintsWithPolicy(*txn, feeCalcTime, uxa, vs.Config.Distribution, params.UserVerifyTxn, NewBurnPolicy(params.UserVerifyTxn, vs.Config.BurnFactorSchedule)); err != nil {
 			return err
 		}
 
@@ -2155,7 +2948,7 @@ func (vs *Visor) VerifyTxnVerbose(txn *coin.Transaction, signed TxnSignedFlag) (
 // AddressCount returns the total number of addresses with unspents
 func (vs *Visor) AddressCount() (uint64, error) {
 	var count uint64
-	if err := vs.db.View("AddressCount", func(tx *dbutil.Tx) error {
+	if err := vs.view("AddressCount", func(tx *dbutil.Tx) error {
 		var err error
 		count, err = vs.blockchain.Unspent().AddressCount(tx)
 		return err
@@ -2171,7 +2964,7 @@ func (vs *Visor) GetVerboseTransactionsForAddress(a cipher.Address) ([]Transacti
 	var txns []Transaction
 	var inputs [][]TransactionInput
 
-	if err := vs.db.View("GetVerboseTransactionsForAddress", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetVerboseTransactionsForAddress", func(tx *dbutil.Tx) error {
 		addrTxns, err := vs.getTransactionsForAddresses(tx, []cipher.Address{a})
 		if err != nil {
 			logger.Errorf("GetVerboseTransactionsForAddress: vs.GetTransactionsForAddress failed: %v", err)
@@ -2315,7 +3108,7 @@ func (vs *Visor) GetUnspentOutputsSummary(filters []OutputsFilter) (*UnspentOutp
 	var incomingOutputs coin.UxArray
 	var head *coin.SignedBlock
 
-	if err := vs.db.View("GetUnspentOutputsSummary", func(tx *dbutil.Tx) error {
+	if err := vs.view("GetUnspentOutputsSummary", func(tx *dbutil.Tx) error {
 		var err error
 		head, err = vs.blockchain.Head(tx)
 		if err != nil {
@@ -2418,7 +3211,7 @@ func (vs *Visor) GetRichlist(includeDistribution bool) (Richlist, error) {
 // This is exported for use by the daemon gateway's InjectBroadcastTransaction method.
 // Do not use it for other purposes.
 func (vs *Visor) WithUpdateTx(name string, f func(tx *dbutil.Tx) error) error {
-	return vs.db.Update(name, func(tx *dbutil.Tx) error {
+	return vs.update(name, func(tx *dbutil.Tx) error {
 		return f(tx)
 	})
 }
@@ -2436,7 +3229,7 @@ func (vs *Visor) AddressesActivity(addrs []cipher.Address) ([]bool, error) {
 		return nil, errors.New("duplicates addresses not allowed")
 	}
 
-	if err := vs.db.View("AddressActivity", func(tx *dbutil.Tx) error {
+	if err := vs.view("AddressActivity", func(tx *dbutil.Tx) error {
 		// Check if the addresses appear in the blockchain
 		for i, a := range addrs {
 			ok, err := vs.history.AddressSeen(tx, a)
@@ -2,6 +2,7 @@ package visor
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -18,15 +19,18 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
 	_require "github.com/skycoin/skycoin/src/testutil/require"
-	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/timeutil"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/util/fee"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 const (
@@ -102,7 +106,7 @@ func addGenesisBlockToVisor(t *testing.T, vs *Visor) *coin.SignedBlock {
 
 	// add genesis block to blockchain
 	err = vs.db.Update("", func(tx *dbutil.Tx) error {
-		return vs.executeSignedBlock(tx, sb)
+		return vs.executeSignedBlock(tx, sb, nil)
 	})
 	require.NoError(t, err)
 
@@ -297,6 +301,10 @@ func TestVisorCreateBlock(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	// PreviewBlock fails if not a block publisher
+	_, err = v.PreviewBlock()
+	require.Equal(t, ErrNotBlockPublisher, err)
+
 	v.Config.IsBlockPublisher = true
 	v.Config.BlockchainSeckey = genSecret
 
@@ -318,6 +326,11 @@ func TestVisorCreateBlock(t *testing.T) {
 	})
 	require.NoError(t, err)
 
+	// If no transactions in the unconfirmed pool, the preview has no block
+	preview, err := v.PreviewBlock()
+	require.NoError(t, err)
+	require.Equal(t, &BlockPreview{}, preview)
+
 	// Create enough unspent outputs to create all of these transactions
 	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
 
@@ -328,7 +341,7 @@ func TestVisorCreateBlock(t *testing.T) {
 	var softErr *ErrTxnViolatesSoftConstraint
 	err = db.Update("", func(tx *dbutil.Tx) error {
 		var err error
-		known, softErr, err = unconfirmed.InjectTransaction(tx, bc, txn, params.MainNetDistribution, v.Config.UnconfirmedVerifyTxn)
+		known, softErr, err = unconfirmed.InjectTransaction(tx, bc, txn, params.MainNetDistribution, v.Config.UnconfirmedVerifyTxn, TxnOriginPeer)
 		return err
 	})
 	require.NoError(t, err)
@@ -417,7 +430,7 @@ func TestVisorCreateBlock(t *testing.T) {
 		var softErr *ErrTxnViolatesSoftConstraint
 		err = db.Update("", func(tx *dbutil.Tx) error {
 			var err error
-			known, softErr, err = unconfirmed.InjectTransaction(tx, bc, txn, params.MainNetDistribution, v.Config.UnconfirmedVerifyTxn)
+			known, softErr, err = unconfirmed.InjectTransaction(tx, bc, txn, params.MainNetDistribution, v.Config.UnconfirmedVerifyTxn, TxnOriginPeer)
 			return err
 		})
 		require.False(t, known)
@@ -442,6 +455,9 @@ func TestVisorCreateBlock(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, len(txns), len(allInjectedTxns))
 
+	preview, err = v.PreviewBlock()
+	require.NoError(t, err)
+
 	err = db.Update("", func(tx *dbutil.Tx) error {
 		var err error
 		sb, err = v.createBlock(tx, when+100)
@@ -454,6 +470,24 @@ func TestVisorCreateBlock(t *testing.T) {
 	require.NotEqual(t, len(txns), len(blockTxns), "Transactions should be truncated")
 	require.Equal(t, 18, len(blockTxns))
 
+	// The preview has the same transactions as the created block
+	require.Equal(t, len(txns), preview.PoolSize)
+	require.NotNil(t, preview.Block)
+	require.Equal(t, blockTxns, preview.Block.Body.Transactions)
+	require.Equal(t, sb.Block.Head.Fee, preview.Block.Head.Fee)
+	require.Equal(t, sb.Block.Head.BodyHash, preview.Block.Head.BodyHash)
+
+	// Every other transaction of the pool is excluded, for violating constraints or exceeding the block size
+	require.Len(t, preview.Excluded, len(txns)-len(blockTxns))
+	reasons := make(map[string]int)
+	for _, e := range preview.Excluded {
+		require.NotEmpty(t, e.Detail)
+		reasons[e.Reason]++
+	}
+	require.NotZero(t, reasons[ExcludeReasonConstraint])
+	require.NotZero(t, reasons[ExcludeReasonBlockSize])
+	require.Equal(t, len(preview.Excluded), reasons[ExcludeReasonConstraint]+reasons[ExcludeReasonBlockSize])
+
 	// Check fee ordering
 	err = db.View("", func(tx *dbutil.Tx) error {
 		inUxs, err := v.blockchain.Unspent().GetArray(tx, blockTxns[0].In)
@@ -2093,8 +2127,8 @@ func TestRemoveInvalidUnconfirmedDoubleSpendArbitrating(t *testing.T) {
 
 	// Create two valid transactions, both spending the same inputs, one with a higher fee
 	// Then, create a block from these transactions.
-	// The one with the higher fee should be included in the block, and the other should be ignored.
-	// A call to RemoveInvalidUnconfirmed will remove the other txn, because it would now be a double spend.
+	// The one with the higher fee should be included in the block, and the other should be
+	// evicted from the pool when the block is executed, because it is now a double spend.
 
 	var coins uint64 = 10e6
 	txn1 := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, coins)
@@ -2126,6 +2160,22 @@ func TestRemoveInvalidUnconfirmedDoubleSpendArbitrating(t *testing.T) {
 	})
 	require.NoError(t, err)
 
+	// Both txns spend the same input, and are reported as conflicting
+	conflicts, err := v.GetUnconfirmedConflicts()
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	require.ElementsMatch(t, []cipher.SHA256{txn1.Hash(), txn2.Hash()}, conflicts[txn1.In[0]])
+
+	// Rebuilding the index produces the same conflicts
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return unconfirmed.RebuildSpendsIndex(tx)
+	})
+	require.NoError(t, err)
+	conflicts, err = v.GetUnconfirmedConflicts()
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	require.ElementsMatch(t, []cipher.SHA256{txn1.Hash(), txn2.Hash()}, conflicts[txn1.In[0]])
+
 	// Execute a block, txn2 should be included because it has a higher fee
 	sb, err := v.CreateAndExecuteBlock()
 	require.NoError(t, err)
@@ -2133,10 +2183,15 @@ func TestRemoveInvalidUnconfirmedDoubleSpendArbitrating(t *testing.T) {
 	require.Equal(t, 2, len(sb.Body.Transactions[0].Out))
 	require.Equal(t, txn2.Hash().Hex(), sb.Body.Transactions[0].Hash().Hex())
 
+	// The first txn was removed when the block was executed, because it is now a double-spend txn
 	err = db.View("", func(tx *dbutil.Tx) error {
 		length, err := unconfirmed.Len(tx)
 		require.NoError(t, err)
-		require.Equal(t, uint64(1), length)
+		require.Equal(t, uint64(0), length)
+
+		conflicts, err := unconfirmed.Conflicts(tx)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
 
 		length, err = bc.Len(tx)
 		require.NoError(t, err)
@@ -2146,205 +2201,848 @@ func TestRemoveInvalidUnconfirmedDoubleSpendArbitrating(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	// Call RemoveInvalidUnconfirmed, the first txn will be removed because it is now a double-spend txn
+	// Nothing is left for RemoveInvalidUnconfirmed to remove
 	removed, err := v.RemoveInvalidUnconfirmed()
 	require.NoError(t, err)
-	require.Equal(t, []cipher.SHA256{txn1.Hash()}, removed)
-	err = db.View("", func(tx *dbutil.Tx) error {
-		length, err := unconfirmed.Len(tx)
-		require.NoError(t, err)
-		require.Equal(t, uint64(0), length)
-		return nil
+	require.Empty(t, removed)
+}
+
+func TestUnconfirmedTxnExpiry(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
 	})
 	require.NoError(t, err)
-}
 
-func makeTxn(t *testing.T, headTime uint64, in, out []coin.UxOut, keys []cipher.SecKey) (coin.Transaction, []TransactionInput) {
-	inputs := make([]cipher.SHA256, len(in))
-	for i, input := range in {
-		inputs[i] = input.Hash()
-	}
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
 
-	outputs := make([]coin.TransactionOutput, len(out))
-	for i, output := range out {
-		outputs[i] = coin.TransactionOutput{
-			Address: output.Body.Address,
-			Coins:   output.Body.Coins,
-			Hours:   output.Body.Hours,
-		}
-	}
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
 
-	txn := coin.Transaction{
-		In:  inputs,
-		Out: outputs,
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
 	}
 
-	txn.SignInputs(keys)
-	err := txn.UpdateHeader()
+	addGenesisBlockToVisor(t, v)
+	var gb *coin.SignedBlock
+	err = db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		gb, err = v.blockchain.GetGenesisBlock(tx)
+		return err
+	})
 	require.NoError(t, err)
+	require.NotNil(t, gb)
 
-	txnInputs, err := NewTransactionInputs(in, headTime)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, 10e6)
+
+	// A txn received from a peer is a peer txn
+	known, softErr, err := v.InjectForeignTransaction(txn)
+	require.False(t, known)
+	require.Nil(t, softErr)
 	require.NoError(t, err)
-	return txn, txnInputs
-}
 
-func TestVerifyTxnVerbose(t *testing.T) {
-	head := coin.SignedBlock{
-		Block: coin.Block{
-			Head: coin.BlockHeader{
-				Time: uint64(time.Now().UTC().Unix()),
-			},
-		},
-	}
+	o, err := v.GetUnconfirmedTxnOrigin(txn.Hash())
+	require.NoError(t, err)
+	require.NotNil(t, o)
+	require.Equal(t, TxnOriginPeer, o.Origin)
 
-	hashes := make([]cipher.SHA256, 20)
-	for i := 0; i < 20; i++ {
-		hashes[i] = testutil.RandSHA256(t)
-	}
+	o, err = v.GetUnconfirmedTxnOrigin(testutil.RandSHA256(t))
+	require.NoError(t, err)
+	require.Nil(t, o)
 
-	keys := make([]cipher.SecKey, 5)
-	for i := 0; i < 5; i++ {
-		_, keys[i] = cipher.GenerateKeyPair()
-	}
+	// Peer txns are never reported as stuck and can't be abandoned
+	v.Config.UnconfirmedLocalTxnExpiry = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	stuck, err := v.GetStuckUnconfirmedTxnHashes()
+	require.NoError(t, err)
+	require.Empty(t, stuck)
 
-	addrs := make([]cipher.Address, 5)
-	for i := 0; i < 5; i++ {
-		addrs[i] = cipher.MustAddressFromSecKey(keys[i])
-	}
+	err = v.AbandonUnconfirmedTransaction(txn.Hash())
+	require.Equal(t, ErrUnconfirmedTxnNotLocal, err)
 
-	srcTxnHashes := make([]cipher.SHA256, 5)
-	inputs := make([]coin.UxOut, 5)
-	historyOutputs := make([]historydb.UxOut, 5)
+	err = v.AbandonUnconfirmedTransaction(testutil.RandSHA256(t))
+	require.Equal(t, ErrUnconfirmedTxnNotFound, err)
 
-	for i := 0; i < 5; i++ {
-		srcTxnHashes[i] = testutil.RandSHA256(t)
-		inputs[i] = coin.UxOut{
-			Head: coin.UxHead{
-				Time: head.Time(),
-			},
-			Body: coin.UxBody{
-				SrcTransaction: srcTxnHashes[i],
-				Address:        addrs[i],
-				Coins:          10e6,
-				Hours:          1000,
-			},
-		}
+	// Peer txns are kept while peer txn expiry is disabled
+	removed, err := v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+	require.Empty(t, removed)
 
-		historyOutputs[i] = historydb.UxOut{
-			Out: inputs[i],
-		}
-	}
+	// Expired peer txns are removed
+	v.Config.UnconfirmedPeerTxnExpiry = time.Nanosecond
+	removed, err = v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{txn.Hash()}, removed)
 
-	outputs := make([]coin.UxOut, 5)
-	for i := 0; i < 5; i++ {
-		outputs[i] = coin.UxOut{
-			Head: coin.UxHead{
-				Time: head.Time(),
-			},
-			Body: coin.UxBody{
-				Address: testutil.MakeAddress(),
-				Coins:   10e6,
-				Hours:   400 + uint64(i)*200,
-			},
-		}
-	}
+	o, err = v.GetUnconfirmedTxnOrigin(txn.Hash())
+	require.NoError(t, err)
+	require.Nil(t, o)
 
-	// add uxout with math.MaxUint64 hours
-	outputs = append(outputs, coin.UxOut{
-		Head: coin.UxHead{
-			Time: head.Time(),
-		},
-		Body: coin.UxBody{
-			Address: testutil.MakeAddress(),
-			Coins:   10e6,
-			Hours:   math.MaxUint64,
-		},
-	})
+	// A peer txn injected again by the user becomes a local txn
+	known, softErr, err = v.InjectForeignTransaction(txn)
+	require.False(t, known)
+	require.Nil(t, softErr)
+	require.NoError(t, err)
 
-	// add output which has 11e6 coins
-	outputs = append(outputs, coin.UxOut{
-		Head: coin.UxHead{
-			Time: head.Time(),
-		},
-		Body: coin.UxBody{
-			Address: testutil.MakeAddress(),
-			Coins:   11e6,
-			Hours:   500,
-		},
-	})
+	known, _, _, err = v.InjectUserTransaction(txn)
+	require.True(t, known)
+	require.NoError(t, err)
 
-	// create a transaction
-	txn, spentInputs := makeTxn(t, head.Time(), inputs[:1], outputs[:1], keys[:1])
+	o, err = v.GetUnconfirmedTxnOrigin(txn.Hash())
+	require.NoError(t, err)
+	require.NotNil(t, o)
+	require.Equal(t, TxnOriginLocal, o.Origin)
 
-	// create a transaction which sends coin to null address
-	toNullAddrTxn, toNullAddrSpentUxBalances := makeTxn(t, head.Time(), inputs[:1], outputs[:1], keys[:1])
-	toNullAddrTxn.Out[0].Address = cipher.Address{}
+	// Local txns do not expire, they are reported as stuck instead
+	time.Sleep(time.Millisecond)
+	removed, err = v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+	require.Empty(t, removed)
 
-	// create a transaction with insufficient coin hours
-	inSufficientCoinHoursTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[4:5], keys[:1])
+	stuck, err = v.GetStuckUnconfirmedTxnHashes()
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{txn.Hash()}, stuck)
 
-	// create a transaction with zero fee
-	zeroFeeTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[3:4], keys[:1])
+	v.Config.UnconfirmedLocalTxnExpiry = 0
+	stuck, err = v.GetStuckUnconfirmedTxnHashes()
+	require.NoError(t, err)
+	require.Empty(t, stuck)
 
-	// create a transaction with output coin hours overflow
-	coinHourOverflowTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[4:], keys[:1])
+	// Local txns can be abandoned
+	err = v.AbandonUnconfirmedTransaction(txn.Hash())
+	require.NoError(t, err)
 
-	// create a transaction with insufficient fee
-	insufficientFeeOuts := []coin.UxOut{
-		coin.UxOut{
-			Head: coin.UxHead{
-				Time: head.Time(),
-			},
-			Body: coin.UxBody{
-				Address: testutil.MakeAddress(),
-				Coins:   1e6,
-				Hours:   950,
-			},
-		},
-	}
-	insufficientFeeTxn, _ := makeTxn(t, head.Time(), inputs[:1], insufficientFeeOuts[:], keys[:1])
+	err = db.View("", func(tx *dbutil.Tx) error {
+		length, err := unconfirmed.Len(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), length)
+		return nil
+	})
+	require.NoError(t, err)
 
-	// create a transaction with insufficient coins
-	insufficientCoinsTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[6:], keys[:1])
+	// Abandoned txns are not requested from peers, and are refused if peers relay them
+	unknown, err := v.FilterKnownUnconfirmed([]cipher.SHA256{txn.Hash()})
+	require.NoError(t, err)
+	require.Empty(t, unknown)
 
-	// create a transaction with valid signature signed by key that doesn't own the input
-	badSigTxn, badSigTxnSpentInputs := makeTxn(t, head.Time(), inputs[:2], outputs[:2], keys[1:3])
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.Equal(t, ErrUnconfirmedTxnAbandoned, err)
 
-	// create a transaction with an empty signature
-	fullyUnsignedTxn, fullyUnsignedSpentInputs := makeTxn(t, head.Time(), inputs[:2], outputs[:2], keys[:2])
-	fullyUnsignedTxn.Sigs[0] = cipher.Sig{}
-	fullyUnsignedTxn.Sigs[1] = cipher.Sig{}
-	err := fullyUnsignedTxn.UpdateHeader()
+	// The refusal survives removing expired records until it expires itself
+	_, err = v.RemoveInvalidUnconfirmed()
 	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.Equal(t, ErrUnconfirmedTxnAbandoned, err)
 
-	partiallyUnsignedTxn, partiallyUnsignedSpentInputs := makeTxn(t, head.Time(), inputs[:2], outputs[:2], keys[:2])
-	partiallyUnsignedTxn.Sigs[0] = cipher.Sig{}
-	err = partiallyUnsignedTxn.UpdateHeader()
+	// The user can inject an abandoned txn again, and it is no longer refused from peers
+	known, _, _, err = v.InjectUserTransaction(txn)
+	require.False(t, known)
 	require.NoError(t, err)
 
-	type testCase struct {
-		name        string
-		txn         coin.Transaction
-		isConfirmed bool
-		isUnsigned  bool
-		signed      TxnSignedFlag
-		inputs      []TransactionInput
-		err         error
-
-		maxUserTransactionSize uint32
+	err = v.AbandonUnconfirmedTransaction(txn.Hash())
+	require.NoError(t, err)
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return unconfirmed.ClearAbandoned(tx, txn.Hash())
+	})
+	require.NoError(t, err)
 
-		getArrayRet coin.UxArray
-		getArrayErr error
+	unknown, err = v.FilterKnownUnconfirmed([]cipher.SHA256{txn.Hash()})
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{txn.Hash()}, unknown)
 
-		getHistoryTxnRet *historydb.Transaction
-		getHistoryTxnErr error
+	// Expired refusals are forgotten
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return unconfirmed.SetAbandoned(tx, txn.Hash(), time.Now().Add(-time.Second))
+	})
+	require.NoError(t, err)
 
-		getHistoryUxOutsRet []historydb.UxOut
-		getHistoryUxOutsErr error
+	err = db.View("", func(tx *dbutil.Tx) error {
+		abandoned, err := unconfirmed.IsAbandoned(tx, txn.Hash(), time.Now())
+		require.NoError(t, err)
+		require.False(t, abandoned)
+		return nil
+	})
+	require.NoError(t, err)
 
-		getSignedBlocksBySeqRet *coin.SignedBlock
-		getSignedBlocksBySeqErr error
-	}
+	_, err = v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+	err = db.View("", func(tx *dbutil.Tx) error {
+		_, ok, err := unconfirmed.abandoned.get(tx, txn.Hash())
+		require.NoError(t, err)
+		require.False(t, ok)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Nothing is recorded when the refusal is disabled
+	v.Config.UnconfirmedAbandonedTxnRefusal = 0
+	known, softErr, err = v.InjectForeignTransaction(txn)
+	require.False(t, known)
+	require.Nil(t, softErr)
+	require.NoError(t, err)
+	_, _, _, err = v.InjectUserTransaction(txn)
+	require.NoError(t, err)
+
+	err = v.AbandonUnconfirmedTransaction(txn.Hash())
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+}
+
+func TestUnconfirmedTxnAnnounceCount(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	addGenesisBlockToVisor(t, v)
+	var gb *coin.SignedBlock
+	err = db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		gb, err = v.blockchain.GetGenesisBlock(tx)
+		return err
+	})
+	require.NoError(t, err)
+
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, 10e6)
+
+	_, softErr, err := v.InjectForeignTransaction(txn)
+	require.Nil(t, softErr)
+	require.NoError(t, err)
+
+	counts, err := v.GetUnconfirmedTxnAnnounceCounts()
+	require.NoError(t, err)
+	require.Equal(t, map[cipher.SHA256]uint64{txn.Hash(): 0}, counts)
+
+	announce := func(at int64) {
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return unconfirmed.SetTransactionsAnnounced(tx, map[cipher.SHA256]TxnAnnouncement{
+				txn.Hash(): {Time: at},
+			})
+		})
+		require.NoError(t, err)
+	}
+
+	// Each later announcement is counted, a repeated announcement time is not
+	announce(1)
+	announce(2)
+	announce(2)
+
+	counts, err = v.GetUnconfirmedTxnAnnounceCounts()
+	require.NoError(t, err)
+	require.Equal(t, map[cipher.SHA256]uint64{txn.Hash(): 2}, counts)
+
+	// Records written before announcements were counted are migrated when the pool is created
+	var record []byte
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		ut, err := unconfirmed.Get(tx, txn.Hash())
+		require.NoError(t, err)
+		ut.AnnounceCount = 0
+
+		record, err = encodeUnconfirmedTransaction(ut)
+		require.NoError(t, err)
+
+		legacy := record[:len(record)-legacyAnnounceCountSize]
+		return dbutil.PutBucketValue(tx, UnconfirmedTxnsBkt, []byte(txn.Hash().Hex()), legacy)
+	})
+	require.NoError(t, err)
+
+	counts, err = v.GetUnconfirmedTxnAnnounceCounts()
+	require.NoError(t, err)
+	require.Equal(t, map[cipher.SHA256]uint64{txn.Hash(): 0}, counts)
+
+	_, err = NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		v, err := dbutil.GetBucketValue(tx, UnconfirmedTxnsBkt, []byte(txn.Hash().Hex()))
+		require.NoError(t, err)
+		require.Equal(t, record, v)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Invalid records are not mistaken for legacy records
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return dbutil.PutBucketValue(tx, UnconfirmedTxnsBkt, []byte(txn.Hash().Hex()), record[:10])
+	})
+	require.NoError(t, err)
+
+	_, err = NewUnconfirmedTransactionPool(db)
+	require.Equal(t, encoder.ErrBufferUnderflow, err)
+}
+
+func TestUnconfirmedTxnAnnouncements(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	addGenesisBlockToVisor(t, v)
+	var gb *coin.SignedBlock
+	err = db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		gb, err = v.blockchain.GetGenesisBlock(tx)
+		return err
+	})
+	require.NoError(t, err)
+
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, 10e6)
+
+	_, softErr, err := v.InjectForeignTransaction(txn)
+	require.Nil(t, softErr)
+	require.NoError(t, err)
+
+	now := time.Now()
+	interval := 10 * time.Minute
+
+	// A transaction that was never announced is announced
+	announcements, err := v.GetUnconfirmedTxnAnnouncements()
+	require.NoError(t, err)
+	require.Empty(t, announcements)
+
+	hashes, err := v.GetValidUnconfirmedTxHashesToAnnounce(now, interval)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{txn.Hash()}, hashes)
+
+	// A recently announced transaction is not announced again
+	announced := now.Add(-time.Minute).UnixNano()
+	err = v.SetTransactionsAnnounced(map[cipher.SHA256]TxnAnnouncement{
+		txn.Hash(): {Time: announced, Peers: 3},
+	})
+	require.NoError(t, err)
+
+	announcements, err = v.GetUnconfirmedTxnAnnouncements()
+	require.NoError(t, err)
+	require.Equal(t, map[cipher.SHA256]TxnAnnouncement{
+		txn.Hash(): {Time: announced, Count: 1, Peers: 3},
+	}, announcements)
+
+	hashes, err = v.GetValidUnconfirmedTxHashesToAnnounce(now, interval)
+	require.NoError(t, err)
+	require.Empty(t, hashes)
+
+	hashes, err = v.GetValidUnconfirmedTxHashesToAnnounce(now.Add(interval), interval)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{txn.Hash()}, hashes)
+
+	// An older announcement is not restored, a newer one is
+	n, err := v.RestoreUnconfirmedTxnAnnouncements(map[cipher.SHA256]TxnAnnouncement{
+		txn.Hash():             {Time: announced - 1, Count: 5, Peers: 8},
+		testutil.RandSHA256(t): {Time: announced, Count: 1, Peers: 1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	n, err = v.RestoreUnconfirmedTxnAnnouncements(map[cipher.SHA256]TxnAnnouncement{
+		txn.Hash(): {Time: announced + 1, Count: 5, Peers: 8},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	announcements, err = v.GetUnconfirmedTxnAnnouncements()
+	require.NoError(t, err)
+	require.Equal(t, map[cipher.SHA256]TxnAnnouncement{
+		txn.Hash(): {Time: announced + 1, Count: 5, Peers: 8},
+	}, announcements)
+
+	// The announcement is removed with the transaction
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return unconfirmed.RemoveTransactions(tx, []cipher.SHA256{txn.Hash()})
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		v, err := dbutil.GetBucketValue(tx, UnconfirmedAnnouncedPeersBkt, []byte(txn.Hash().Hex()))
+		require.NoError(t, err)
+		require.Nil(t, v)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestGetAddressOutputsSummary(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	require.Len(t, uxs, 1)
+
+	toAddr := testutil.MakeAddress()
+	emptyAddr := testutil.MakeAddress()
+
+	// Send two outputs to toAddr and return the change to the genesis address
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(toAddr, 10e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(toAddr, 20e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-30e6, 100)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	known, softErr, err := v.InjectForeignTransaction(txn)
+	require.False(t, known)
+	require.Nil(t, softErr)
+	require.NoError(t, err)
+
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+	require.Len(t, sb.Body.Transactions, 1)
+
+	outs := coin.CreateUnspents(sb.Head, txn)
+	require.Len(t, outs, 3)
+
+	hours := func(ux coin.UxOut) uint64 {
+		h, err := ux.CoinHours(sb.Time())
+		require.NoError(t, err)
+		return h
+	}
+
+	summaries, err := v.GetAddressOutputsSummary([]cipher.Address{emptyAddr, toAddr, genAddress})
+	require.NoError(t, err)
+	require.Equal(t, []AddressOutputsSummary{
+		{
+			Address: emptyAddr,
+		},
+		{
+			Address:      toAddr,
+			Coins:        30e6,
+			Hours:        hours(outs[0]) + hours(outs[1]),
+			Count:        2,
+			LargestUxID:  outs[1].Hash(),
+			LargestCoins: 20e6,
+		},
+		{
+			Address:      genAddress,
+			Coins:        uxs[0].Body.Coins - 30e6,
+			Hours:        hours(outs[2]),
+			Count:        1,
+			LargestUxID:  outs[2].Hash(),
+			LargestCoins: uxs[0].Body.Coins - 30e6,
+		},
+	}, summaries)
+
+	summaries, err = v.GetAddressOutputsSummary(nil)
+	require.NoError(t, err)
+	require.Empty(t, summaries)
+}
+
+func TestTestAcceptTransactions(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	require.Len(t, uxs, 1)
+
+	newTxn := func(in []cipher.SHA256, to cipher.Address, coins, hours uint64) coin.Transaction {
+		txn := coin.Transaction{}
+		keys := make([]cipher.SecKey, len(in))
+		for i, h := range in {
+			err := txn.PushInput(h)
+			require.NoError(t, err)
+			keys[i] = genSecret
+		}
+		err := txn.PushOutput(to, coins, hours)
+		require.NoError(t, err)
+		txn.SignInputs(keys)
+		err = txn.UpdateHeader()
+		require.NoError(t, err)
+		return txn
+	}
+
+	// Split the genesis output into outputs spendable by the genesis key
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, 10e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, 20e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-30e6, 100)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	outs := coin.CreateUnspents(sb.Head, txn)
+	require.Len(t, outs, 3)
+
+	toAddr := testutil.MakeAddress()
+
+	valid := newTxn([]cipher.SHA256{outs[0].Hash()}, toAddr, 10e6, 10)
+	validOuts := coin.CreateUnspents(sb.Head, valid)
+	dependent := newTxn([]cipher.SHA256{validOuts[0].Hash()}, toAddr, 10e6, 1)
+	doubleSpend := newTxn([]cipher.SHA256{outs[0].Hash()}, toAddr, 10e6, 20)
+	nullAddress := newTxn([]cipher.SHA256{outs[1].Hash()}, cipher.Address{}, 20e6, 10)
+	missingInput := newTxn([]cipher.SHA256{testutil.RandSHA256(t)}, toAddr, 20e6, 10)
+	inputHours, err := outs[1].CoinHours(sb.Time())
+	require.NoError(t, err)
+	zeroFee := newTxn([]cipher.SHA256{outs[1].Hash()}, toAddr, 20e6, inputHours)
+
+	txns := []coin.Transaction{
+		valid,
+		valid,
+		dependent,
+		doubleSpend,
+		nullAddress,
+		missingInput,
+		zeroFee,
+	}
+
+	results, err := v.TestAcceptTransactions(txns)
+	require.NoError(t, err)
+	require.Len(t, results, len(txns))
+
+	validFee := inputHours - 10
+	require.True(t, results[0].Accepted)
+	require.Empty(t, results[0].RejectCode)
+	require.Equal(t, validFee, results[0].Fee)
+
+	expectedCodes := []string{
+		"",
+		RejectCodeBatchDuplicate,
+		RejectCodeBatchDependency,
+		RejectCodeBatchDoubleSpend,
+		RejectCodeUserConstraint,
+		RejectCodeHardConstraint,
+		RejectCodeSoftConstraint,
+	}
+
+	for i, r := range results {
+		size, err := txns[i].Size()
+		require.NoError(t, err)
+
+		require.Equal(t, txns[i].Hash(), r.Txid)
+		require.Equal(t, size, r.Size)
+		require.Equal(t, expectedCodes[i], r.RejectCode, "txn %d", i)
+		if i != 0 {
+			require.False(t, r.Accepted)
+			require.NotEmpty(t, r.RejectReason)
+			require.Equal(t, uint64(0), r.Fee)
+		}
+	}
+
+	require.Equal(t, fmt.Sprintf("input %s is created by unconfirmed transaction %s in the batch", validOuts[0].Hash().Hex(), valid.Hash().Hex()), results[2].RejectReason)
+	require.Equal(t, fmt.Sprintf("input %s is already spent by transaction %s in the batch", outs[0].Hash().Hex(), valid.Hash().Hex()), results[3].RejectReason)
+	require.Equal(t, "Transaction violates soft constraint: Transaction has zero coinhour fee", results[6].RejectReason)
+
+	// The unconfirmed pool is not modified
+	pending, err := v.GetAllUnconfirmedTransactions()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// The results match the real injection path
+	_, _, _, err = v.InjectUserTransaction(zeroFee)
+	require.Equal(t, results[6].RejectReason, err.Error())
+	_, _, _, err = v.InjectUserTransaction(nullAddress)
+	require.Equal(t, results[4].RejectReason, err.Error())
+	_, _, _, err = v.InjectUserTransaction(valid)
+	require.NoError(t, err)
+}
+
+func makeTxn(t *testing.T, headTime uint64, in, out []coin.UxOut, keys []cipher.SecKey) (coin.Transaction, []TransactionInput) {
+	inputs := make([]cipher.SHA256, len(in))
+	for i, input := range in {
+		inputs[i] = input.Hash()
+	}
+
+	outputs := make([]coin.TransactionOutput, len(out))
+	for i, output := range out {
+		outputs[i] = coin.TransactionOutput{
+			Address: output.Body.Address,
+			Coins:   output.Body.Coins,
+			Hours:   output.Body.Hours,
+		}
+	}
+
+	txn := coin.Transaction{
+		In:  inputs,
+		Out: outputs,
+	}
+
+	txn.SignInputs(keys)
+	err := txn.UpdateHeader()
+	require.NoError(t, err)
+
+	txnInputs, err := NewTransactionInputs(in, headTime)
+	require.NoError(t, err)
+	return txn, txnInputs
+}
+
+func TestVerifyTxnVerbose(t *testing.T) {
+	head := coin.SignedBlock{
+		Block: coin.Block{
+			Head: coin.BlockHeader{
+				Time: uint64(time.Now().UTC().Unix()),
+			},
+		},
+	}
+
+	hashes := make([]cipher.SHA256, 20)
+	for i := 0; i < 20; i++ {
+		hashes[i] = testutil.RandSHA256(t)
+	}
+
+	keys := make([]cipher.SecKey, 5)
+	for i := 0; i < 5; i++ {
+		_, keys[i] = cipher.GenerateKeyPair()
+	}
+
+	addrs := make([]cipher.Address, 5)
+	for i := 0; i < 5; i++ {
+		addrs[i] = cipher.MustAddressFromSecKey(keys[i])
+	}
+
+	srcTxnHashes := make([]cipher.SHA256, 5)
+	inputs := make([]coin.UxOut, 5)
+	historyOutputs := make([]historydb.UxOut, 5)
+
+	for i := 0; i < 5; i++ {
+		srcTxnHashes[i] = testutil.RandSHA256(t)
+		inputs[i] = coin.UxOut{
+			Head: coin.UxHead{
+				Time: head.Time(),
+			},
+			Body: coin.UxBody{
+				SrcTransaction: srcTxnHashes[i],
+				Address:        addrs[i],
+				Coins:          10e6,
+				Hours:          1000,
+			},
+		}
+
+		historyOutputs[i] = historydb.UxOut{
+			Out: inputs[i],
+		}
+	}
+
+	outputs := make([]coin.UxOut, 5)
+	for i := 0; i < 5; i++ {
+		outputs[i] = coin.UxOut{
+			Head: coin.UxHead{
+				Time: head.Time(),
+			},
+			Body: coin.UxBody{
+				Address: testutil.MakeAddress(),
+				Coins:   10e6,
+				Hours:   400 + uint64(i)*200,
+			},
+		}
+	}
+
+	// add uxout with math.MaxUint64 hours
+	outputs = append(outputs, coin.UxOut{
+		Head: coin.UxHead{
+			Time: head.Time(),
+		},
+		Body: coin.UxBody{
+			Address: testutil.MakeAddress(),
+			Coins:   10e6,
+			Hours:   math.MaxUint64,
+		},
+	})
+
+	// add output which has 11e6 coins
+	outputs = append(outputs, coin.UxOut{
+		Head: coin.UxHead{
+			Time: head.Time(),
+		},
+		Body: coin.UxBody{
+			Address: testutil.MakeAddress(),
+			Coins:   11e6,
+			Hours:   500,
+		},
+	})
+
+	// create a transaction
+	txn, spentInputs := makeTxn(t, head.Time(), inputs[:1], outputs[:1], keys[:1])
+
+	// create a transaction which sends coin to null address
+	toNullAddrTxn, toNullAddrSpentUxBalances := makeTxn(t, head.Time(), inputs[:1], outputs[:1], keys[:1])
+	toNullAddrTxn.Out[0].Address = cipher.Address{}
+
+	// create a transaction with insufficient coin hours
+	inSufficientCoinHoursTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[4:5], keys[:1])
+
+	// create a transaction with zero fee
+	zeroFeeTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[3:4], keys[:1])
+
+	// create a transaction with output coin hours overflow
+	coinHourOverflowTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[4:], keys[:1])
+
+	// create a transaction with insufficient fee
+	insufficientFeeOuts := []coin.UxOut{
+		coin.UxOut{
+			Head: coin.UxHead{
+				Time: head.Time(),
+			},
+			Body: coin.UxBody{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   950,
+			},
+		},
+	}
+	insufficientFeeTxn, _ := makeTxn(t, head.Time(), inputs[:1], insufficientFeeOuts[:], keys[:1])
+
+	// create a transaction with insufficient coins
+	insufficientCoinsTxn, _ := makeTxn(t, head.Time(), inputs[:1], outputs[6:], keys[:1])
+
+	// create a transaction with valid signature signed by key that doesn't own the input
+	badSigTxn, badSigTxnSpentInputs := makeTxn(t, head.Time(), inputs[:2], outputs[:2], keys[1:3])
+
+	// create a transaction with an empty signature
+	fullyUnsignedTxn, fullyUnsignedSpentInputs := makeTxn(t, head.Time(), inputs[:2], outputs[:2], keys[:2])
+	fullyUnsignedTxn.Sigs[0] = cipher.Sig{}
+	fullyUnsignedTxn.Sigs[1] = cipher.Sig{}
+	err := fullyUnsignedTxn.UpdateHeader()
+	require.NoError(t, err)
+
+	partiallyUnsignedTxn, partiallyUnsignedSpentInputs := makeTxn(t, head.Time(), inputs[:2], outputs[:2], keys[:2])
+	partiallyUnsignedTxn.Sigs[0] = cipher.Sig{}
+	err = partiallyUnsignedTxn.UpdateHeader()
+	require.NoError(t, err)
+
+	type testCase struct {
+		name        string
+		txn         coin.Transaction
+		isConfirmed bool
+		isUnsigned  bool
+		signed      TxnSignedFlag
+		inputs      []TransactionInput
+		err         error
+
+		maxUserTransactionSize uint32
+
+		getArrayRet coin.UxArray
+		getArrayErr error
+
+		getHistoryTxnRet *historydb.Transaction
+		getHistoryTxnErr error
+
+		getHistoryUxOutsRet []historydb.UxOut
+		getHistoryUxOutsErr error
+
+		getSignedBlocksBySeqRet *coin.SignedBlock
+		getSignedBlocksBySeqErr error
+	}
 
 	baseCases := []testCase{
 		{
@@ -2732,3 +3430,371 @@ func TestFbyHashes(t *testing.T) {
 		require.Equal(t, outs, tt.want)
 	}
 }
+
+func TestWaitHeadSeqAfter(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		head:        &headNotifier{},
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	v.head.init(gb.Seq(), true)
+
+	// Waiting times out if no block is executed
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	require.False(t, v.WaitHeadSeqAfter(ctx, gb.Seq()))
+
+	// Executing a block wakes all waiters
+	nWaiters := 100
+	results := make(chan bool, nWaiters)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	for i := 0; i < nWaiters; i++ {
+		go func() {
+			results <- v.WaitHeadSeqAfter(ctx, gb.Seq())
+		}()
+	}
+
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6)
+	known, softErr, err := v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+	require.Nil(t, softErr)
+	require.False(t, known)
+
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+	require.Equal(t, gb.Seq()+1, sb.Seq())
+
+	for i := 0; i < nWaiters; i++ {
+		require.True(t, <-results)
+	}
+
+	// Waiters that start after the block was executed return immediately
+	require.True(t, v.WaitHeadSeqAfter(context.Background(), gb.Seq()))
+}
+
+func TestBlockListener(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		head:        &headNotifier{},
+
+		blockListeners: &blockListeners{},
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+
+	var blocks []coin.SignedBlock
+	var inputs [][][]coin.UxOut
+	v.AddBlockListener(func(b coin.SignedBlock, in [][]coin.UxOut) error {
+		blocks = append(blocks, b)
+		inputs = append(inputs, in)
+		return nil
+	})
+
+	// A failing listener does not undo the block or stop the other listeners
+	calls := 0
+	v.AddBlockListener(func(b coin.SignedBlock, in [][]coin.UxOut) error {
+		calls++
+		return errors.New("listener failed")
+	})
+
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6)
+	_, softErr, err := v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+	require.Nil(t, softErr)
+
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	require.Equal(t, []coin.SignedBlock{sb}, blocks)
+	require.Equal(t, [][][]coin.UxOut{{uxs}}, inputs)
+	require.Equal(t, 1, calls)
+
+	head, err := v.GetHeadBlock()
+	require.NoError(t, err)
+	require.Equal(t, sb.Seq(), head.Seq())
+}
+
+func TestSubscribeBlocks(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		head:        &headNotifier{},
+
+		blockListeners:     &blockListeners{},
+		blockSubscriptions: &BlockSubscriptions{},
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+
+	sub := v.SubscribeBlocks(1)
+	slow := v.SubscribeBlocks(1)
+	closed := v.SubscribeBlocks(1)
+	closed.Close()
+	closed.Close()
+
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6)
+	_, softErr, err := v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+	require.Nil(t, softErr)
+
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	require.Equal(t, sb.Seq(), <-sub.C())
+
+	_, ok := <-closed.C()
+	require.False(t, ok)
+	require.False(t, closed.Overflowed())
+
+	// The slow subscription's buffer is full, it is closed instead of blocking block execution
+	v.blockSubscriptions.Notify(sb.Seq() + 1)
+
+	require.Equal(t, sb.Seq()+1, <-sub.C())
+	require.False(t, sub.Overflowed())
+
+	require.Equal(t, sb.Seq(), <-slow.C())
+	_, ok = <-slow.C()
+	require.False(t, ok)
+	require.True(t, slow.Overflowed())
+	slow.Close()
+
+	sub.Close()
+	_, ok = <-sub.C()
+	require.False(t, ok)
+	require.False(t, sub.Overflowed())
+}
+
+func TestGetAddressExplorer(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	require.Len(t, uxs, 1)
+
+	pubkey, seckey := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pubkey)
+	otherAddr := testutil.MakeAddress()
+
+	all := AddressExplorerOptions{
+		Balance:      true,
+		Outputs:      true,
+		TopOutputs:   1,
+		Pending:      true,
+		Transactions: true,
+		Limit:        1,
+		Activity:     true,
+	}
+
+	// An address without transactions has empty sections and no activity
+	ae, err := v.GetAddressExplorer(addr, all)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), ae.HeadSeq)
+	require.Equal(t, &wallet.Balance{}, ae.Balance)
+	require.Equal(t, uint64(0), ae.Outputs.Count)
+	require.Empty(t, ae.Outputs.Top)
+	require.Empty(t, ae.Pending.Incoming)
+	require.Empty(t, ae.Pending.Outgoing)
+	require.Equal(t, uint64(0), ae.Transactions.Total)
+	require.Empty(t, ae.Transactions.Recent)
+	require.Nil(t, ae.Activity)
+
+	// Block 1 sends two outputs to addr
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(addr, 10e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(addr, 20e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-30e6, 100)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	sb1 := executeTxn(t, v, txn)
+	outs1 := coin.CreateUnspents(sb1.Head, txn)
+
+	// Block 2 spends the 10e6 output of addr, returning 5e6 to it
+	txn2 := makeSpendTxn(t, coin.UxArray{outs1[0]}, []cipher.SecKey{seckey}, otherAddr, 5e6)
+	sb2 := executeTxn(t, v, txn2)
+	outs2 := coin.CreateUnspents(sb2.Head, txn2)
+	require.Equal(t, addr, outs2[1].Body.Address)
+
+	// An unconfirmed transaction sends 1e6 to addr and another spends its 20e6 output
+	incomingTxn := makeSpendTxn(t, coin.UxArray{outs1[2]}, []cipher.SecKey{genSecret}, addr, 1e6)
+	outgoingTxn := makeSpendTxn(t, coin.UxArray{outs1[1]}, []cipher.SecKey{seckey}, otherAddr, 20e6)
+	for _, txn := range []coin.Transaction{incomingTxn, outgoingTxn} {
+		known, softErr, err := v.InjectForeignTransaction(txn)
+		require.False(t, known)
+		require.Nil(t, softErr)
+		require.NoError(t, err)
+	}
+
+	hours := func(ux coin.UxOut) uint64 {
+		h, err := ux.CoinHours(sb2.Time())
+		require.NoError(t, err)
+		return h
+	}
+
+	ae, err = v.GetAddressExplorer(addr, all)
+	require.NoError(t, err)
+	require.Equal(t, addr, ae.Address)
+	require.Equal(t, uint64(2), ae.HeadSeq)
+	require.Equal(t, sb2.Time(), ae.HeadTime)
+
+	require.Equal(t, &wallet.Balance{
+		Coins: 25e6,
+		Hours: hours(outs1[1]) + hours(outs2[1]),
+	}, ae.Balance)
+
+	require.Equal(t, uint64(2), ae.Outputs.Count)
+	require.Len(t, ae.Outputs.Top, 1)
+	require.Equal(t, outs1[1].Hash(), ae.Outputs.Top[0].Hash())
+
+	require.Len(t, ae.Pending.Incoming, 1)
+	require.Equal(t, addr, ae.Pending.Incoming[0].Body.Address)
+	require.Equal(t, uint64(1e6), ae.Pending.Incoming[0].Body.Coins)
+	require.Equal(t, incomingTxn.Hash(), ae.Pending.Incoming[0].Body.SrcTransaction)
+	require.Len(t, ae.Pending.Outgoing, 1)
+	require.Equal(t, outs1[1], ae.Pending.Outgoing[0].UxOut)
+	require.Equal(t, hours(outs1[1]), ae.Pending.Outgoing[0].CalculatedHours)
+
+	require.Equal(t, uint64(2), ae.Transactions.Total)
+	require.Len(t, ae.Transactions.Recent, 1)
+	recent := ae.Transactions.Recent[0]
+	require.Equal(t, txn2.Hash(), recent.Transaction.Transaction.Hash())
+	require.Equal(t, uint64(2), recent.Status.BlockSeq)
+	require.Equal(t, uint64(1), recent.Status.Height)
+	require.Equal(t, sb2.Time(), recent.Time)
+	require.Equal(t, uint64(5e6), recent.Received)
+	require.Equal(t, uint64(10e6), recent.Sent)
+
+	require.Equal(t, &AddressActivity{
+		FirstSeenSeq:   1,
+		FirstSeenTime:  sb1.Time(),
+		LastActiveSeq:  2,
+		LastActiveTime: sb2.Time(),
+	}, ae.Activity)
+
+	// A larger limit returns all transactions, the most recent first
+	opts := all
+	opts.Limit = 10
+	opts.TopOutputs = 10
+	ae, err = v.GetAddressExplorer(addr, opts)
+	require.NoError(t, err)
+	require.Len(t, ae.Outputs.Top, 2)
+	require.Equal(t, outs2[1].Hash(), ae.Outputs.Top[1].Hash())
+	require.Len(t, ae.Transactions.Recent, 2)
+	require.Equal(t, txn.Hash(), ae.Transactions.Recent[1].Transaction.Transaction.Hash())
+	require.Equal(t, uint64(2), ae.Transactions.Recent[1].Status.Height)
+	require.Equal(t, uint64(30e6), ae.Transactions.Recent[1].Received)
+	require.Equal(t, uint64(0), ae.Transactions.Recent[1].Sent)
+
+	// Skipped sections are nil
+	ae, err = v.GetAddressExplorer(addr, AddressExplorerOptions{})
+	require.NoError(t, err)
+	require.Equal(t, &AddressExplorer{
+		Address:  addr,
+		HeadSeq:  2,
+		HeadTime: sb2.Time(),
+	}, ae)
+}
@@ -3,15 +3,21 @@ package visor
 // This file contains Visor method that require wallet access
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
-	"github.com/skycoin/skycoin/src/transaction"
+	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/util/mathutil"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
-	"github.com/skycoin/skycoin/src/wallet"
+
+	"github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 // UserError wraps user input-related errors.
@@ -48,57 +54,99 @@ var (
 	ErrNoSpendableOutputs = NewUserError(errors.New("All selected outputs are unavailable for spending"))
 )
 
+// WalletBalance is the balance of a wallet at a head block
+type WalletBalance struct {
+	Balance   wallet.BalancePair
+	Addresses wallet.AddressBalances
+	// HeadSeq is the seq of the head block the balance was computed at
+	HeadSeq uint64
+	// Stale is true if a block was executed after HeadSeq while the balance was computed
+	Stale bool
+}
+
 // GetWalletBalance returns balance pairs of specific wallet
 func (vs *Visor) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error) {
-	var addressBalances wallet.AddressBalances
-	var walletBalance wallet.BalancePair
-	var addrsBalanceList []wallet.BalancePair
-	var addrs []cipher.Address
+	b, err := vs.GetWalletBalanceAtHead(wltID)
+	if err != nil {
+		return wallet.BalancePair{}, nil, err
+	}
 
+	return b.Balance, b.Addresses, nil
+}
+
+// GetWalletBalanceAtHead returns the balance of a wallet and the head block it was computed at.
+// The confirmed unspent outputs of the wallet are cached and updated by the executed blocks,
+// see walletBalanceCache.
+func (vs *Visor) GetWalletBalanceAtHead(wltID string) (*WalletBalance, error) {
+	var addrs []cipher.Address
 	if err := vs.wallets.View(wltID, func(w wallet.Wallet) error {
 		var err error
 		addrs, err = w.GetSkycoinAddresses()
+		return err
+	}); err != nil {
+		if err == wallet.ErrWalletNotExist {
+			vs.walletBalances.remove(wltID)
+		}
+		return nil, err
+	}
+
+	addrsBalanceList, head, err := vs.balanceOfAddresses("GetWalletBalance", addrs, func(tx *dbutil.Tx, head *coin.SignedBlock) (coin.AddressUxOuts, error) {
+		if auxs, ok := vs.walletBalances.get(wltID, head.Head, addrs); ok {
+			return auxs, nil
+		}
+
+		auxs, err := vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, addrs)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		addrsBalanceList, err = vs.GetBalanceOfAddresses(addrs)
-		return err
-	}); err != nil {
-		return walletBalance, addressBalances, err
+		vs.walletBalances.set(wltID, head.Head, addrs, auxs)
+		return auxs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b := &WalletBalance{
+		HeadSeq: head.Seq(),
+	}
+
+	if vs.head != nil {
+		headSeq, _, _ := vs.head.get()
+		b.Stale = headSeq != b.HeadSeq
 	}
 
 	// create map of address to balance
-	addressBalances = make(wallet.AddressBalances, len(addrs))
+	b.Addresses = make(wallet.AddressBalances, len(addrs))
 	for i, addr := range addrs {
-		addressBalances[addr.String()] = addrsBalanceList[i]
+		b.Addresses[addr.String()] = addrsBalanceList[i]
 	}
 
 	// compute the sum of all addresses
-	for _, addrBalance := range addressBalances {
+	for _, addrBalance := range b.Addresses {
 		var err error
 		// compute confirmed balance
-		walletBalance.Confirmed.Coins, err = mathutil.AddUint64(walletBalance.Confirmed.Coins, addrBalance.Confirmed.Coins)
+		b.Balance.Confirmed.Coins, err = mathutil.AddUint64(b.Balance.Confirmed.Coins, addrBalance.Confirmed.Coins)
 		if err != nil {
-			return walletBalance, addressBalances, err
+			return nil, err
 		}
-		walletBalance.Confirmed.Hours, err = mathutil.AddUint64(walletBalance.Confirmed.Hours, addrBalance.Confirmed.Hours)
+		b.Balance.Confirmed.Hours, err = mathutil.AddUint64(b.Balance.Confirmed.Hours, addrBalance.Confirmed.Hours)
 		if err != nil {
-			return walletBalance, addressBalances, err
+			return nil, err
 		}
 
 		// compute predicted balance
-		walletBalance.Predicted.Coins, err = mathutil.AddUint64(walletBalance.Predicted.Coins, addrBalance.Predicted.Coins)
+		b.Balance.Predicted.Coins, err = mathutil.AddUint64(b.Balance.Predicted.Coins, addrBalance.Predicted.Coins)
 		if err != nil {
-			return walletBalance, addressBalances, err
+			return nil, err
 		}
-		walletBalance.Predicted.Hours, err = mathutil.AddUint64(walletBalance.Predicted.Hours, addrBalance.Predicted.Hours)
+		b.Balance.Predicted.Hours, err = mathutil.AddUint64(b.Balance.Predicted.Hours, addrBalance.Predicted.Hours)
 		if err != nil {
-			return walletBalance, addressBalances, err
+			return nil, err
 		}
 	}
 
-	return walletBalance, addressBalances, nil
+	return b, nil
 }
 
 // GetWalletUnconfirmedTransactions returns all unconfirmed transactions in given wallet
@@ -142,7 +190,10 @@ func (vs *Visor) GetWalletUnconfirmedTransactionsVerbose(wltID string) ([]Unconf
 
 // WalletSignTransaction signs a transaction. Specific inputs may be signed by specifying signIndexes.
 // If signIndexes is empty, all inputs will be signed. The transaction must be fully valid and spendable.
-func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []TransactionInput, error) {
+// The log entries include the request ID carried by ctx, if any.
+func (vs *Visor) WalletSignTransaction(ctx context.Context, wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []TransactionInput, error) {
+	logger := contextLogger(ctx)
+
 	var inputs []TransactionInput
 	var signedTxn *coin.Transaction
 
@@ -151,7 +202,7 @@ func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.
 	}
 
 	if err := vs.wallets.ViewSecrets(wltID, password, func(w wallet.Wallet) error {
-		return vs.db.View("WalletSignTransaction", func(tx *dbutil.Tx) error {
+		return vs.view("WalletSignTransaction", func(tx *dbutil.Tx) error {
 			// Verify the transaction before signing
 			if err := VerifySingleTxnUserConstraints(*txn); err != nil {
 				return err
@@ -205,6 +256,8 @@ func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.
 		return nil, nil, err
 	}
 
+	logger.WithField("wallet", wltID).Infof("Signed transaction %s", signedTxn.Hash().Hex())
+
 	return signedTxn, inputs, nil
 }
 
@@ -249,8 +302,9 @@ func (p CreateTransactionParams) Validate() error {
 	return nil
 }
 
-// WalletCreateTransactionSigned creates a signed transaction based upon the parameters in CreateTransactionParams
-func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
+// WalletCreateTransactionSigned creates a signed transaction based upon the parameters in CreateTransactionParams.
+// The log entries include the request ID carried by ctx, if any.
+func (vs *Visor) WalletCreateTransactionSigned(ctx context.Context, wltID string, password []byte, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
 	// Validate params before unlocking wallet
 	if err := p.Validate(); err != nil {
 		return nil, nil, err
@@ -264,7 +318,7 @@ func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p
 
 	if err := vs.wallets.UpdateSecrets(wltID, password, func(w wallet.Wallet) error {
 		var err error
-		txn, inputs, err = vs.walletCreateTransaction("WalletCreateTransactionSigned", w, p, wp, TxnSigned)
+		txn, inputs, err = vs.walletCreateTransaction(ctx, "WalletCreateTransactionSigned", w, p, wp, TxnSigned)
 		return err
 	}); err != nil {
 		return nil, nil, err
@@ -273,8 +327,9 @@ func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p
 	return txn, inputs, nil
 }
 
-// WalletCreateTransaction creates a transaction based upon the parameters in CreateTransactionParams
-func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
+// WalletCreateTransaction creates a transaction based upon the parameters in CreateTransactionParams.
+// The log entries include the request ID carried by ctx, if any.
+func (vs *Visor) WalletCreateTransaction(ctx context.Context, wltID string, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
 	// Validate params before opening wallet
 	if err := p.Validate(); err != nil {
 		return nil, nil, err
@@ -288,7 +343,7 @@ func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp
 
 	if err := vs.wallets.Update(wltID, func(w wallet.Wallet) error {
 		var err error
-		txn, inputs, err = vs.walletCreateTransaction("WalletCreateTransaction", w, p, wp, TxnUnsigned)
+		txn, inputs, err = vs.walletCreateTransaction(ctx, "WalletCreateTransaction", w, p, wp, TxnUnsigned)
 		return err
 	}); err != nil {
 		return nil, nil, err
@@ -297,7 +352,130 @@ func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp
 	return txn, inputs, nil
 }
 
-func (vs *Visor) walletCreateTransaction(methodName string, w wallet.Wallet, p transaction.Params, wp CreateTransactionParams, signed TxnSignedFlag) (*coin.Transaction, []TransactionInput, error) {
+// WalletBumpTransaction increases the fee of an unsigned transaction created by WalletCreateTransaction,
+// adding an unspent output of the wallet as an input, see transaction.Bump.
+// The fee of the bumped transaction is at least targetFee and at least the required fee.
+// If changeAddress is nil, the coins of the added input go to the last output to a wallet address,
+// or to a new change output if there is none.
+func (vs *Visor) WalletBumpTransaction(wltID string, txn *coin.Transaction, targetFee uint64, changeAddress *cipher.Address) (*coin.Transaction, []TransactionInput, error) {
+	if changeAddress != nil && changeAddress.Null() {
+		return nil, nil, transaction.ErrNullBumpChangeAddress
+	}
+
+	if !txn.IsFullyUnsigned() {
+		return nil, nil, transaction.ErrBumpSignedTransaction
+	}
+
+	var bumped *coin.Transaction
+	var uxb []transaction.UxBalance
+
+	if err := vs.wallets.View(wltID, func(w wallet.Wallet) error {
+		walletAddresses, err := w.GetSkycoinAddresses()
+		if err != nil {
+			return err
+		}
+
+		walletAddressesMap := make(map[cipher.Address]struct{}, len(walletAddresses))
+		for _, a := range walletAddresses {
+			walletAddressesMap[a] = struct{}{}
+		}
+
+		return vs.view("WalletBumpTransaction", func(tx *dbutil.Tx) error {
+			headTime, err := vs.blockchain.Time(tx)
+			if err != nil {
+				logger.WithError(err).Error("blockchain.Time failed")
+				return err
+			}
+
+			inputs, err := vs.getTransactionInputs(tx, headTime, txn.In)
+			if err != nil {
+				return err
+			}
+
+			inputsUxb := make([]transaction.UxBalance, len(inputs))
+			for i, in := range inputs {
+				if _, ok := walletAddressesMap[in.UxOut.Body.Address]; !ok {
+					return wallet.NewError(fmt.Errorf("uxout %s is not owned by any address in the wallet", in.UxOut.Hash().Hex()))
+				}
+
+				inputsUxb[i], err = transaction.NewUxBalance(headTime, in.UxOut)
+				if err != nil {
+					return err
+				}
+			}
+
+			auxs, err := vs.getCreateTransactionAuxsAddress(tx, walletAddresses, true)
+			if err != nil {
+				return err
+			}
+
+			unspents, err := transaction.NewUxBalances(auxs.Flatten(), headTime)
+			if err != nil {
+				return err
+			}
+
+			p := transaction.BumpParams{
+				Fee:        targetFee,
+				BurnPolicy: NewBurnPolicy(params.UserVerifyTxn, vs.Config.BurnFactorSchedule),
+			}
+			if changeAddress != nil {
+				p.ChangeAddress = *changeAddress
+			} else {
+				p.ChangeAddress = bumpChangeAddress(*txn, inputsUxb, walletAddressesMap)
+			}
+
+			bumped, uxb, err = transaction.Bump(*txn, inputsUxb, unspents, p, headTime)
+			if err != nil {
+				return err
+			}
+
+			if err := VerifySingleTxnUserConstraints(*bumped); err != nil {
+				logger.WithError(err).Error("Bumped transaction violates transaction user constraints")
+				return err
+			}
+
+			if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, *bumped, vs.Config.Distribution, params.UserVerifyTxn, TxnUnsigned); err != nil {
+				logger.WithError(err).Error("Bumped transaction violates transaction soft/hard constraints")
+				return err
+			}
+
+			return nil
+		})
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	inputs := make([]TransactionInput, len(uxb))
+	for i, b := range uxb {
+		inputs[i] = TransactionInputFromUxBalance(b)
+	}
+
+	return bumped, inputs, nil
+}
+
+// bumpChangeAddress returns the address that receives the coins of the input added by WalletBumpTransaction:
+// the address of the last output to a wallet address, or if there is none, the address whose bytes are
+// lexically sorted first among the owners of the inputs, as transaction.Create chooses change addresses
+func bumpChangeAddress(txn coin.Transaction, inputs []transaction.UxBalance, walletAddresses map[cipher.Address]struct{}) cipher.Address {
+	for i := len(txn.Out) - 1; i >= 0; i-- {
+		if _, ok := walletAddresses[txn.Out[i].Address]; ok {
+			return txn.Out[i].Address
+		}
+	}
+
+	addrs := make([]cipher.Address, len(inputs))
+	for i, in := range inputs {
+		addrs[i] = in.Address
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+
+	return addrs[0]
+}
+
+func (vs *Visor) walletCreateTransaction(ctx context.Context, methodName string, w wallet.Wallet, p transaction.Params, wp CreateTransactionParams, signed TxnSignedFlag) (*coin.Transaction, []TransactionInput, error) {
 	if err := p.Validate(); err != nil {
 		return nil, nil, err
 	}
@@ -332,24 +510,28 @@ func (vs *Visor) walletCreateTransaction(methodName string, w wallet.Wallet, p t
 	var txn *coin.Transaction
 	var uxb []transaction.UxBalance
 
-	if err := vs.db.View(methodName, func(tx *dbutil.Tx) error {
+	if err := vs.view(methodName, func(tx *dbutil.Tx) error {
 		var err error
-		txn, uxb, err = vs.walletCreateTransactionTx(tx, methodName, w, p, wp, signed, addrs, walletAddressesMap)
+		txn, uxb, err = vs.walletCreateTransactionTx(ctx, tx, methodName, w, p, wp, signed, addrs, walletAddressesMap)
 		return err
 	}); err != nil {
 		return nil, nil, err
 	}
 
+	contextLogger(ctx).WithField("wallet", w.Filename()).Infof("%s: created transaction %s", methodName, txn.Hash().Hex())
+
 	inputs := NewTransactionInputsFromUxBalance(uxb)
 
 	return txn, inputs, nil
 }
 
-func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
+func (vs *Visor) walletCreateTransactionTx(ctx context.Context, tx *dbutil.Tx, methodName string,
 	w wallet.Wallet, p transaction.Params, wp CreateTransactionParams, signed TxnSignedFlag,
 	addrs []cipher.Address, walletAddressesMap map[cipher.Address]struct{}) (*coin.Transaction, []transaction.UxBalance, error) {
 	// Note: assumes inputs have already been validated by walletCreateTransaction
 
+	logger := contextLogger(ctx)
+
 	head, err := vs.blockchain.Head(tx)
 	if err != nil {
 		logger.WithError(err).Error("blockchain.Head failed")
@@ -360,17 +542,10 @@ func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
 	var auxs coin.AddressUxOuts
 	if len(wp.UxOuts) != 0 {
 		var err error
-		auxs, err = vs.getCreateTransactionAuxsUxOut(tx, wp.UxOuts, wp.IgnoreUnconfirmed)
+		auxs, err = vs.getWalletCreateTransactionAuxsUxOut(tx, wp.UxOuts, wp.IgnoreUnconfirmed, walletAddressesMap)
 		if err != nil {
 			return nil, nil, err
 		}
-
-		// Check that UxOut addresses are in the wallet,
-		for a := range auxs {
-			if _, ok := walletAddressesMap[a]; !ok {
-				return nil, nil, wallet.ErrUnknownUxOut
-			}
-		}
 	} else {
 		var err error
 		auxs, err = vs.getCreateTransactionAuxsAddress(tx, addrs, wp.IgnoreUnconfirmed)
@@ -383,10 +558,14 @@ func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
 	var txn *coin.Transaction
 	var uxb []transaction.UxBalance
 
-	switch signed {
-	case TxnSigned:
+	switch {
+	case len(wp.UxOuts) != 0:
+		// The inputs are placed in the order the outputs were requested,
+		// so that the inputs must be reordered before signing
+		txn, uxb, err = walletCreateTransactionOrdered(logger, w, p, auxs, head.Time(), wp.UxOuts, signed)
+	case signed == TxnSigned:
 		txn, uxb, err = wallet.CreateTransactionSigned(w, p, auxs, head.Time())
-	case TxnUnsigned:
+	case signed == TxnUnsigned:
 		txn, uxb, err = wallet.CreateTransaction(w, p, auxs, head.Time())
 	default:
 		logger.Panic("Invalid TxnSignedFlag")
@@ -413,6 +592,93 @@ func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
 	return txn, uxb, nil
 }
 
+// walletCreateTransactionOrdered creates a transaction spending from the unspent outputs in auxs,
+// with the chosen inputs ordered as they appear in uxOuts. The transaction is signed if signed is TxnSigned.
+func walletCreateTransactionOrdered(logger *logging.Logger, w wallet.Wallet, p transaction.Params, auxs coin.AddressUxOuts, headTime uint64, uxOuts []cipher.SHA256, signed TxnSignedFlag) (*coin.Transaction, []transaction.UxBalance, error) {
+	txn, uxb, err := wallet.CreateTransaction(w, p, auxs, headTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := orderTransactionInputs(txn, uxb, uxOuts); err != nil {
+		return nil, nil, err
+	}
+
+	if signed != TxnSigned {
+		return txn, uxb, nil
+	}
+
+	uxa := make([]coin.UxOut, len(txn.In))
+	for i, h := range txn.In {
+		ux, ok := findUxOut(auxs, h)
+		if !ok {
+			err := fmt.Errorf("Chosen input %s not found in auxs", h.Hex())
+			logger.Critical().WithError(err).Error()
+			return nil, nil, err
+		}
+		uxa[i] = ux
+	}
+
+	signedTxn, err := wallet.SignTransaction(w, txn, nil, uxa)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !signedTxn.IsFullySigned() {
+		return nil, nil, errors.New("Transaction is not fully signed")
+	}
+
+	if err := transaction.VerifyCreatedInvariants(p, signedTxn, uxb, headTime); err != nil {
+		return nil, nil, err
+	}
+
+	return signedTxn, uxb, nil
+}
+
+// orderTransactionInputs reorders the inputs of an unsigned transaction, and their matching
+// UxBalances, to follow the order of uxOuts. The transaction header is updated.
+func orderTransactionInputs(txn *coin.Transaction, uxb []transaction.UxBalance, uxOuts []cipher.SHA256) error {
+	if len(txn.In) != len(uxb) {
+		return errors.New("Transaction inputs and UxBalances length mismatch")
+	}
+
+	position := make(map[cipher.SHA256]int, len(uxOuts))
+	for i, h := range uxOuts {
+		position[h] = i
+	}
+
+	for i, h := range txn.In {
+		if uxb[i].Hash != h {
+			return errors.New("Transaction inputs and UxBalances are not in the same order")
+		}
+		if _, ok := position[h]; !ok {
+			return fmt.Errorf("Transaction input %s was not requested", h.Hex())
+		}
+	}
+
+	sort.SliceStable(uxb, func(i, j int) bool {
+		return position[uxb[i].Hash] < position[uxb[j].Hash]
+	})
+
+	for i, b := range uxb {
+		txn.In[i] = b.Hash
+	}
+
+	return txn.UpdateHeader()
+}
+
+// findUxOut returns the unspent output with hash h from auxs
+func findUxOut(auxs coin.AddressUxOuts, h cipher.SHA256) (coin.UxOut, bool) {
+	for _, uxa := range auxs {
+		for _, ux := range uxa {
+			if ux.Hash() == h {
+				return ux, true
+			}
+		}
+	}
+	return coin.UxOut{}, false
+}
+
 // CreateTransaction creates an unsigned transaction from requested coin.UxOut hashes
 func (vs *Visor) CreateTransaction(p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
 	// Validate parameters before starting database transaction
@@ -429,7 +695,7 @@ func (vs *Visor) CreateTransaction(p transaction.Params, wp CreateTransactionPar
 	var txn *coin.Transaction
 	var uxb []transaction.UxBalance
 
-	if err := vs.db.View("CreateTransaction", func(tx *dbutil.Tx) error {
+	if err := vs.view("CreateTransaction", func(tx *dbutil.Tx) error {
 		var err error
 		txn, uxb, err = vs.createTransactionTx(tx, p, wp)
 		return err
@@ -541,6 +807,49 @@ func (vs *Visor) getCreateTransactionAuxsUxOut(tx *dbutil.Tx, uxOutHashes []ciph
 	return coin.NewAddressUxOuts(coin.UxArray(uxOuts)), nil
 }
 
+// getWalletCreateTransactionAuxsUxOut returns a map of addresses to their unspent outputs,
+// given a list of unspent output hashes requested for a wallet transaction.
+// Every requested output must exist in the unspent pool and be owned by an address in walletAddressesMap.
+// Outputs spent by unconfirmed transactions are skipped if ignoreUnconfirmed is true, otherwise an error is returned.
+// Errors identify the offending output.
+func (vs *Visor) getWalletCreateTransactionAuxsUxOut(tx *dbutil.Tx, uxOutHashes []cipher.SHA256, ignoreUnconfirmed bool, walletAddressesMap map[cipher.Address]struct{}) (coin.AddressUxOuts, error) {
+	// Find the unconfirmed transactions spending any of the outputs
+	spentBy, err := vs.unconfirmed.SpentBy(tx, uxOutHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]cipher.SHA256, 0, len(uxOutHashes))
+	for _, h := range uxOutHashes {
+		if txids, ok := spentBy[h]; ok {
+			if ignoreUnconfirmed {
+				continue
+			}
+			return nil, NewUserError(fmt.Errorf("uxout %s is already spent by pending transaction %s", h.Hex(), txids[0].Hex()))
+		}
+		hashes = append(hashes, h)
+	}
+
+	if len(hashes) == 0 {
+		return nil, ErrNoSpendableOutputs
+	}
+
+	// Retrieve the uxouts from the pool.
+	// An error identifying the uxout is returned if any do not exist
+	uxOuts, err := vs.blockchain.Unspent().GetArray(tx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ux := range uxOuts {
+		if _, ok := walletAddressesMap[ux.Body.Address]; !ok {
+			return nil, wallet.NewError(fmt.Errorf("uxout %s is not owned by any address in the wallet", ux.Hash().Hex()))
+		}
+	}
+
+	return coin.NewAddressUxOuts(coin.UxArray(uxOuts)), nil
+}
+
 // getCreateTransactionAuxsAddress returns a map of the addresses to their unspent outputs,
 // filtering or erroring on unconfirmed outputs depending on the value of ignoreUnconfirmed
 func (vs *Visor) getCreateTransactionAuxsAddress(tx *dbutil.Tx, addrs []cipher.Address, ignoreUnconfirmed bool) (coin.AddressUxOuts, error) {
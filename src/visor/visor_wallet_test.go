@@ -2,14 +2,18 @@ package visor
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -17,10 +21,14 @@ import (
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/transaction"
+	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
-	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/transaction"
+	plogging "github.com/ness-network/privateness/src/util/logging"
+	"github.com/ness-network/privateness/src/wallet"
 )
 
 func TestCreateTransaction(t *testing.T) {
@@ -657,7 +665,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 			blockchainHead: headBlock,
 			getArrayInputs: uxOuts,
 			getArray:       unknownUxOutGetArrayRet,
-			err:            wallet.ErrUnknownUxOut,
+			err:            wallet.NewError(fmt.Errorf("uxout %s is not owned by any address in the wallet", unknownUxOutGetArrayRet[1].Hash().Hex())),
 		},
 
 		{
@@ -798,7 +806,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 			blockchainHead: headBlock,
 			getArrayInputs: bip44UxOuts,
 			getArray:       bip44UnknownUxOutGetArrayRet,
-			err:            wallet.ErrUnknownUxOut,
+			err:            wallet.NewError(fmt.Errorf("uxout %s is not owned by any address in the wallet", bip44UnknownUxOutGetArrayRet[1].Hash().Hex())),
 		},
 
 		{
@@ -935,6 +943,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 			ut.On("ForEach", matchDBTx, mock.MatchedBy(func(f func(cipher.SHA256, UnconfirmedTransaction) error) bool {
 				return true
 			})).Return(tc.forEachErr).Run(unconfirmedForEachMockRun(t, tc.unconfirmedTxns, tc.uxOuts, tc.wp.IgnoreUnconfirmed))
+			ut.On("SpentBy", matchDBTx, tc.wp.UxOuts).Return(unconfirmedSpentBy(tc.unconfirmedTxns, tc.wp.UxOuts), tc.forEachErr)
 
 			up.On("GetArray", matchDBTx, mock.MatchedBy(matchUxOutsAnyOrder(tc.getArrayInputs))).Return(tc.getArray, tc.getArrayErr)
 			b.On("Unspent").Return(up)
@@ -976,9 +985,9 @@ func TestWalletCreateTransaction(t *testing.T) {
 			var inputs []TransactionInput
 			switch tc.signed {
 			case TxnSigned:
-				txn, inputs, err = v.WalletCreateTransactionSigned(tc.walletID, tc.password, tc.p, tc.wp)
+				txn, inputs, err = v.WalletCreateTransactionSigned(context.Background(), tc.walletID, tc.password, tc.p, tc.wp)
 			case TxnUnsigned:
-				txn, inputs, err = v.WalletCreateTransaction(tc.walletID, tc.p, tc.wp)
+				txn, inputs, err = v.WalletCreateTransaction(context.Background(), tc.walletID, tc.p, tc.wp)
 			default:
 				t.Fatal("invalid tc.signed value")
 			}
@@ -999,6 +1008,311 @@ func TestWalletCreateTransaction(t *testing.T) {
 	}
 }
 
+func TestWalletCreateTransactionUxOutsOrder(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	password := []byte("foo")
+	_, err = ws.CreateWallet("foo.wlt", wallet.Options{
+		Coin:       wallet.CoinTypeSkycoin,
+		Type:       wallet.WalletTypeBip44,
+		Seed:       "voyage say extend find sheriff surge priority merit ignore maple cash argue",
+		GenerateN:  2,
+		Encrypt:    true,
+		Password:   password,
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+	}, nil)
+	require.NoError(t, err)
+
+	walletAddrs, err := ws.GetSkycoinAddresses("foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, walletAddrs, 2)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+	cfg.Distribution = params.MainNetDistribution
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	require.Len(t, uxs, 1)
+
+	// Send three outputs to the wallet and return the change to the genesis address
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[0], 1e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[1], 2e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[0], 3e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-6e6, 100)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	outs := coin.CreateUnspents(sb.Head, txn)
+	require.Len(t, outs, 4)
+
+	autoParams := func(coins uint64) transaction.Params {
+		shareFactor := decimal.New(5, -1)
+		return transaction.Params{
+			HoursSelection: transaction.HoursSelection{
+				Type:        transaction.HoursSelectionTypeAuto,
+				Mode:        transaction.HoursSelectionModeShare,
+				ShareFactor: &shareFactor,
+			},
+			To: []coin.TransactionOutput{
+				{
+					Address: testutil.MakeAddress(),
+					Coins:   coins,
+				},
+			},
+		}
+	}
+
+	// The inputs follow the requested order
+	requested := []cipher.SHA256{outs[2].Hash(), outs[0].Hash(), outs[1].Hash()}
+	created, inputs, err := v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", password, autoParams(6e6), CreateTransactionParams{
+		UxOuts: requested,
+	})
+	require.NoError(t, err)
+	require.True(t, created.IsFullySigned())
+	require.Equal(t, requested, created.In)
+	require.Len(t, inputs, len(requested))
+	for i, in := range inputs {
+		require.Equal(t, requested[i], in.UxOut.Hash())
+	}
+
+	// Outputs not owned by the wallet are rejected
+	_, _, err = v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", password, autoParams(1e6), CreateTransactionParams{
+		UxOuts: []cipher.SHA256{outs[0].Hash(), outs[3].Hash()},
+	})
+	require.Equal(t, wallet.NewError(fmt.Errorf("uxout %s is not owned by any address in the wallet", outs[3].Hash().Hex())), err)
+
+	// Spent or unknown outputs are rejected
+	for _, h := range []cipher.SHA256{uxs[0].Hash(), testutil.RandSHA256(t)} {
+		_, _, err = v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", password, autoParams(1e6), CreateTransactionParams{
+			UxOuts: []cipher.SHA256{outs[0].Hash(), h},
+		})
+		require.Equal(t, blockdb.NewErrUnspentNotExist(h.Hex()), err)
+	}
+
+	// Outputs spent by a pending transaction are rejected
+	pending, _, err := v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", password, autoParams(2e6), CreateTransactionParams{
+		UxOuts: []cipher.SHA256{outs[1].Hash()},
+	})
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(*pending)
+	require.NoError(t, err)
+
+	_, _, err = v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", password, autoParams(1e6), CreateTransactionParams{
+		UxOuts: []cipher.SHA256{outs[0].Hash(), outs[1].Hash()},
+	})
+	require.Equal(t, NewUserError(fmt.Errorf("uxout %s is already spent by pending transaction %s", outs[1].Hash().Hex(), pending.Hash().Hex())), err)
+
+	// Unless unconfirmed outputs are ignored
+	created, _, err = v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", password, autoParams(1e6), CreateTransactionParams{
+		UxOuts:            []cipher.SHA256{outs[1].Hash(), outs[0].Hash()},
+		IgnoreUnconfirmed: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{outs[0].Hash()}, created.In)
+}
+
+// logCapture is a logrus.Hook that records the log entries carrying a request ID
+type logCapture struct {
+	sync.Mutex
+	entries map[string][]*logrus.Entry
+}
+
+func newLogCapture() *logCapture {
+	return &logCapture{
+		entries: make(map[string][]*logrus.Entry),
+	}
+}
+
+func (c *logCapture) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (c *logCapture) Fire(e *logrus.Entry) error {
+	id, ok := e.Data["request_id"].(string)
+	if !ok {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.entries[id] = append(c.entries[id], e)
+	return nil
+}
+
+func (c *logCapture) messages(id string) []string {
+	c.Lock()
+	defer c.Unlock()
+	var msgs []string
+	for _, e := range c.entries[id] {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestWalletTransactionRequestIDLogged(t *testing.T) {
+	capture := newLogCapture()
+	logging.AddHook(capture)
+
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	password := []byte("foo")
+	_, err = ws.CreateWallet("foo.wlt", wallet.Options{
+		Coin:       wallet.CoinTypeSkycoin,
+		Type:       wallet.WalletTypeBip44,
+		Seed:       "voyage say extend find sheriff surge priority merit ignore maple cash argue",
+		GenerateN:  1,
+		Encrypt:    true,
+		Password:   password,
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+	}, nil)
+	require.NoError(t, err)
+
+	walletAddrs, err := ws.GetSkycoinAddresses("foo.wlt")
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+	cfg.Distribution = params.MainNetDistribution
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	require.Len(t, uxs, 1)
+
+	// Send an output to the wallet
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[0], 5e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-5e6, 100)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+
+	_, err = v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	p := transaction.Params{
+		HoursSelection: transaction.HoursSelection{
+			Type: transaction.HoursSelectionTypeManual,
+		},
+		To: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   1,
+			},
+		},
+		ChangeAddress: &walletAddrs[0],
+	}
+
+	ctx := plogging.WithRequestID(context.Background(), "create-and-sign")
+
+	unsigned, _, err := v.WalletCreateTransaction(ctx, "foo.wlt", p, CreateTransactionParams{})
+	require.NoError(t, err)
+	require.False(t, unsigned.IsFullySigned())
+
+	signed, _, err := v.WalletSignTransaction(ctx, "foo.wlt", password, unsigned, nil)
+	require.NoError(t, err)
+	require.True(t, signed.IsFullySigned())
+
+	require.Equal(t, []string{
+		fmt.Sprintf("WalletCreateTransaction: created transaction %s", unsigned.Hash().Hex()),
+		fmt.Sprintf("Signed transaction %s", signed.Hash().Hex()),
+	}, capture.messages("create-and-sign"))
+
+	created, _, err := v.WalletCreateTransactionSigned(plogging.WithRequestID(context.Background(), "create-signed"), "foo.wlt", password, p, CreateTransactionParams{})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		fmt.Sprintf("WalletCreateTransactionSigned: created transaction %s", created.Hash().Hex()),
+	}, capture.messages("create-signed"))
+
+	// Log entries for a context without a request ID have no request ID
+	_, _, err = v.WalletCreateTransaction(context.Background(), "foo.wlt", p, CreateTransactionParams{})
+	require.NoError(t, err)
+	require.Empty(t, capture.messages(""))
+}
+
 func TestCreateTransactionParamsValidate(t *testing.T) {
 	var nullAddress cipher.Address
 	addr := testutil.MakeAddress()
@@ -1109,10 +1423,10 @@ func TestWalletCreateTransactionValidation(t *testing.T) {
 			// setup visor
 			v := &Visor{}
 
-			_, _, err := v.WalletCreateTransaction("foo.wlt", tc.p, tc.wp)
+			_, _, err := v.WalletCreateTransaction(context.Background(), "foo.wlt", tc.p, tc.wp)
 			require.Equal(t, tc.err, err)
 
-			_, _, err = v.WalletCreateTransactionSigned("foo.wlt", nil, tc.p, tc.wp)
+			_, _, err = v.WalletCreateTransactionSigned(context.Background(), "foo.wlt", nil, tc.p, tc.wp)
 			require.Equal(t, tc.err, err)
 
 			if tc.err != nil {
@@ -1624,3 +1938,69 @@ func unconfirmedForEachMockRun(t *testing.T, unconfirmedTxns []coin.Transaction,
 		}
 	}
 }
+
+// unconfirmedSpentBy returns the transactions of unconfirmedTxns spending each of uxOuts,
+// as returned by UnconfirmedTransactionPool.SpentBy
+func unconfirmedSpentBy(unconfirmedTxns []coin.Transaction, uxOuts []cipher.SHA256) map[cipher.SHA256][]cipher.SHA256 {
+	spentBy := make(map[cipher.SHA256][]cipher.SHA256)
+	for _, u := range unconfirmedTxns {
+		for _, h := range u.In {
+			if hashesIntersect([]cipher.SHA256{h}, uxOuts) {
+				spentBy[h] = append(spentBy[h], u.Hash())
+			}
+		}
+	}
+	return spentBy
+}
+
+func TestBumpChangeAddress(t *testing.T) {
+	addrs := make([]cipher.Address, 4)
+	for i := range addrs {
+		addrs[i] = testutil.MakeAddress()
+	}
+
+	walletAddrs := map[cipher.Address]struct{}{
+		addrs[0]: {},
+		addrs[1]: {},
+		addrs[2]: {},
+	}
+
+	inputs := []transaction.UxBalance{
+		{Address: addrs[2]},
+		{Address: addrs[1]},
+	}
+
+	firstInputAddr := addrs[1]
+	if bytes.Compare(addrs[2].Bytes(), addrs[1].Bytes()) < 0 {
+		firstInputAddr = addrs[2]
+	}
+
+	cases := []struct {
+		name    string
+		outputs []cipher.Address
+		addr    cipher.Address
+	}{
+		{
+			name:    "last output to a wallet address",
+			outputs: []cipher.Address{addrs[3], addrs[0], addrs[1], addrs[3]},
+			addr:    addrs[1],
+		},
+		{
+			name:    "no output to a wallet address",
+			outputs: []cipher.Address{addrs[3]},
+			addr:    firstInputAddr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var txn coin.Transaction
+			for _, a := range tc.outputs {
+				require.NoError(t, txn.PushOutput(a, 1e6, 0))
+			}
+
+			addr := bumpChangeAddress(txn, inputs, walletAddrs)
+			require.Equal(t, tc.addr, addr)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package visor
+
+import (
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+/*
+walletBalanceCache caches the confirmed unspent outputs of the wallets whose balance was requested,
+so that the balance of a wallet is not read from the unspent pool on every request.
+
+An entry is valid for the head block it was read at. Executed blocks update the entries of the
+previous head incrementally, removing the outputs spent by the block and adding the outputs
+it created for the wallet's addresses. An entry of any other head, e.g. after a block was
+executed before the entry was stored, is dropped and read again on the next request.
+
+The coin hours of the outputs depend on the head time, so the balances are computed from
+the cached outputs on each request.
+*/
+
+// walletBalanceEntry is the confirmed unspent outputs of a wallet's addresses at a head block
+type walletBalanceEntry struct {
+	seq   uint64
+	hash  cipher.SHA256
+	addrs []cipher.Address
+	// addrSet is the set of addrs
+	addrSet map[cipher.Address]struct{}
+	outputs map[cipher.SHA256]coin.UxOut
+}
+
+// addressUxOuts returns the outputs of the entry by address
+func (e *walletBalanceEntry) addressUxOuts() coin.AddressUxOuts {
+	auxs := make(coin.AddressUxOuts, len(e.addrs))
+	for _, ux := range e.outputs {
+		auxs[ux.Body.Address] = append(auxs[ux.Body.Address], ux)
+	}
+	return auxs
+}
+
+// walletBalanceCache caches the confirmed unspent outputs of wallets by wallet ID.
+// The zero value is ready to use. A nil cache caches nothing.
+type walletBalanceCache struct {
+	sync.Mutex
+	entries map[string]*walletBalanceEntry
+}
+
+// get returns the cached outputs of a wallet at a head block, false if the wallet has no entry
+// for the head block or its addresses changed
+func (c *walletBalanceCache) get(wltID string, head coin.BlockHeader, addrs []cipher.Address) (coin.AddressUxOuts, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[wltID]
+	if !ok || e.seq != head.BkSeq || e.hash != head.Hash() || !sameAddresses(e.addrs, addrs) {
+		return nil, false
+	}
+
+	return e.addressUxOuts(), true
+}
+
+// set caches the outputs of a wallet's addresses at a head block.
+// An entry of a later head block is kept.
+func (c *walletBalanceCache) set(wltID string, head coin.BlockHeader, addrs []cipher.Address, auxs coin.AddressUxOuts) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if e, ok := c.entries[wltID]; ok && e.seq > head.BkSeq {
+		return
+	}
+
+	e := &walletBalanceEntry{
+		seq:     head.BkSeq,
+		hash:    head.Hash(),
+		addrs:   append([]cipher.Address{}, addrs...),
+		addrSet: make(map[cipher.Address]struct{}, len(addrs)),
+		outputs: make(map[cipher.SHA256]coin.UxOut),
+	}
+
+	for _, a := range addrs {
+		e.addrSet[a] = struct{}{}
+	}
+
+	for _, uxs := range auxs {
+		for _, ux := range uxs {
+			e.outputs[ux.Hash()] = ux
+		}
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]*walletBalanceEntry)
+	}
+	c.entries[wltID] = e
+}
+
+// remove drops the entry of a wallet
+func (c *walletBalanceCache) remove(wltID string) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.entries, wltID)
+}
+
+// applyBlock updates the entries of the block's previous head with an executed block,
+// and drops the entries of other heads
+func (c *walletBalanceCache) applyBlock(b coin.SignedBlock) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	hash := b.HashHeader()
+	for wltID, e := range c.entries {
+		if e.seq == b.Seq() && e.hash == hash {
+			// The entry was read after the block was executed
+			continue
+		}
+
+		if e.seq+1 != b.Seq() || e.hash != b.Head.PrevHash {
+			delete(c.entries, wltID)
+			continue
+		}
+
+		for _, txn := range b.Body.Transactions {
+			for _, in := range txn.In {
+				delete(e.outputs, in)
+			}
+
+			for _, ux := range coin.CreateUnspents(b.Head, txn) {
+				if _, ok := e.addrSet[ux.Body.Address]; ok {
+					e.outputs[ux.Hash()] = ux
+				}
+			}
+		}
+
+		e.seq = b.Seq()
+		e.hash = hash
+	}
+}
+
+func sameAddresses(a, b []cipher.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,388 @@
+package visor
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func makeUxOut(t *testing.T, head coin.BlockHeader, addr cipher.Address, coins uint64) coin.UxOut {
+	return coin.UxOut{
+		Head: coin.UxHead{
+			Time:  head.Time,
+			BkSeq: head.BkSeq,
+		},
+		Body: coin.UxBody{
+			SrcTransaction: testutil.RandSHA256(t),
+			Address:        addr,
+			Coins:          coins,
+			Hours:          10,
+		},
+	}
+}
+
+func TestWalletBalanceCache(t *testing.T) {
+	addr1 := testutil.MakeAddress()
+	addr2 := testutil.MakeAddress()
+	other := testutil.MakeAddress()
+	addrs := []cipher.Address{addr1, addr2}
+
+	head := coin.BlockHeader{
+		BkSeq: 5,
+		Time:  1000,
+	}
+
+	ux1 := makeUxOut(t, head, addr1, 1e6)
+	ux2 := makeUxOut(t, head, addr2, 2e6)
+
+	var c walletBalanceCache
+	c.set("foo.wlt", head, addrs, coin.AddressUxOuts{
+		addr1: coin.UxArray{ux1},
+		addr2: coin.UxArray{ux2},
+	})
+
+	auxs, ok := c.get("foo.wlt", head, addrs)
+	require.True(t, ok)
+	require.Equal(t, coin.AddressUxOuts{
+		addr1: coin.UxArray{ux1},
+		addr2: coin.UxArray{ux2},
+	}, auxs)
+
+	// Other wallets, heads and addresses miss
+	_, ok = c.get("bar.wlt", head, addrs)
+	require.False(t, ok)
+	_, ok = c.get("foo.wlt", head, []cipher.Address{addr1})
+	require.False(t, ok)
+	_, ok = c.get("foo.wlt", head, []cipher.Address{addr2, addr1})
+	require.False(t, ok)
+	otherHead := head
+	otherHead.Fee = 1
+	_, ok = c.get("foo.wlt", otherHead, addrs)
+	require.False(t, ok)
+
+	// A block spending ux1 and sending to addr2 and another address
+	txn := coin.Transaction{
+		In: []cipher.SHA256{ux1.Hash()},
+		Out: []coin.TransactionOutput{
+			{Address: addr2, Coins: 1e5, Hours: 1},
+			{Address: other, Coins: 9e5, Hours: 1},
+		},
+	}
+	b := coin.SignedBlock{
+		Block: coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq:    head.BkSeq + 1,
+				Time:     head.Time + 10,
+				PrevHash: head.Hash(),
+			},
+			Body: coin.BlockBody{
+				Transactions: coin.Transactions{txn},
+			},
+		},
+	}
+
+	c.applyBlock(b)
+
+	_, ok = c.get("foo.wlt", head, addrs)
+	require.False(t, ok)
+
+	auxs, ok = c.get("foo.wlt", b.Head, addrs)
+	require.True(t, ok)
+	require.Empty(t, auxs[addr1])
+	require.Len(t, auxs[addr2], 2)
+	require.Empty(t, auxs[other])
+	created := coin.CreateUnspents(b.Head, txn)
+	require.ElementsMatch(t, coin.UxArray{ux2, created[0]}, auxs[addr2])
+
+	// Applying the head block again does not change the entry
+	c.applyBlock(b)
+	auxs2, ok := c.get("foo.wlt", b.Head, addrs)
+	require.True(t, ok)
+	require.ElementsMatch(t, auxs[addr2], auxs2[addr2])
+
+	// An entry of an earlier head is not stored over a later one
+	c.set("foo.wlt", head, addrs, nil)
+	_, ok = c.get("foo.wlt", b.Head, addrs)
+	require.True(t, ok)
+
+	// A block that doesn't follow the head drops the entry
+	gap := b
+	gap.Head.BkSeq += 2
+	c.applyBlock(gap)
+	_, ok = c.get("foo.wlt", b.Head, addrs)
+	require.False(t, ok)
+
+	// So does a different block at the next seq
+	c.set("foo.wlt", head, addrs, nil)
+	fork := b
+	fork.Head.PrevHash = testutil.RandSHA256(t)
+	c.applyBlock(fork)
+	_, ok = c.get("foo.wlt", head, addrs)
+	require.False(t, ok)
+
+	c.set("foo.wlt", head, addrs, nil)
+	c.remove("foo.wlt")
+	_, ok = c.get("foo.wlt", head, addrs)
+	require.False(t, ok)
+
+	// A nil cache caches nothing
+	var nc *walletBalanceCache
+	nc.set("foo.wlt", head, addrs, nil)
+	nc.applyBlock(b)
+	nc.remove("foo.wlt")
+	_, ok = nc.get("foo.wlt", head, addrs)
+	require.False(t, ok)
+}
+
+// setupWalletBalanceVisor creates a block publishing visor with a genesis block,
+// and a wallet holding the genesis address and another address
+func setupWalletBalanceVisor(t *testing.T) (*Visor, cipher.Address, cipher.SecKey, func()) {
+	db, shutdown := prepareDB(t)
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	_, err = ws.CreateWallet("foo.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	}, nil)
+	require.NoError(t, err)
+
+	pubkey, seckey := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pubkey)
+	err = ws.UpdateSecrets("foo.wlt", nil, func(w wallet.Wallet) error {
+		for _, e := range []wallet.Entry{
+			{Address: genAddress, Public: genPublic, Secret: genSecret},
+			{Address: addr, Public: pubkey, Secret: seckey},
+		} {
+			if err := w.(*wallet.CollectionWallet).AddEntry(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+		head:        &headNotifier{},
+
+		blockListeners: &blockListeners{},
+		walletBalances: &walletBalanceCache{},
+	}
+
+	addGenesisBlockToVisor(t, v)
+
+	return v, addr, seckey, shutdown
+}
+
+// executeWalletSpend creates and executes a block with a transaction spending the outputs of
+// the genesis address and addr, sending coins to addr and the change to the genesis address
+func executeWalletSpend(t *testing.T, v *Visor, addr cipher.Address, seckey cipher.SecKey, coins uint64) coin.SignedBlock {
+	auxs, err := v.GetUnspentsOfAddrs([]cipher.Address{genAddress, addr})
+	require.NoError(t, err)
+
+	uxs := append(auxs[genAddress], auxs[addr]...)
+	keys := make([]cipher.SecKey, len(uxs))
+	for i, ux := range uxs {
+		keys[i] = genSecret
+		if ux.Body.Address == addr {
+			keys[i] = seckey
+		}
+	}
+
+	txn := makeSpendTxn(t, uxs, keys, addr, coins)
+	return executeTxn(t, v, txn)
+}
+
+// executeTxn creates and executes a block with a transaction
+func executeTxn(t *testing.T, v *Visor, txn coin.Transaction) coin.SignedBlock {
+	_, softErr, err := v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+	require.Nil(t, softErr)
+
+	// Blocks are created a second apart, since CreateAndExecuteBlock can't create blocks within a second
+	var sb coin.SignedBlock
+	err = v.db.View("", func(tx *dbutil.Tx) error {
+		head, err := v.blockchain.Head(tx)
+		if err != nil {
+			return err
+		}
+		sb, err = v.createBlock(tx, head.Time()+1)
+		return err
+	})
+	require.NoError(t, err)
+
+	err = v.ExecuteSignedBlock(sb)
+	require.NoError(t, err)
+	return sb
+}
+
+func requireUncachedWalletBalance(t *testing.T, v *Visor, b *WalletBalance) {
+	addrs := []cipher.Address{genAddress}
+	for a := range b.Addresses {
+		if a != genAddress.String() {
+			addrs = append(addrs, cipher.MustDecodeBase58Address(a))
+		}
+	}
+
+	bps, err := v.GetBalanceOfAddresses(addrs)
+	require.NoError(t, err)
+	for i, a := range addrs {
+		require.Equal(t, bps[i], b.Addresses[a.String()], a.String())
+	}
+}
+
+func TestGetWalletBalanceAtHead(t *testing.T) {
+	v, addr, seckey, shutdown := setupWalletBalanceVisor(t)
+	defer shutdown()
+
+	b, err := v.GetWalletBalanceAtHead("foo.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), b.HeadSeq)
+	require.False(t, b.Stale)
+	require.Equal(t, genCoins, b.Balance.Confirmed.Coins)
+	require.Equal(t, genCoins, b.Balance.Predicted.Coins)
+	require.Equal(t, genCoins, b.Addresses[genAddress.String()].Confirmed.Coins)
+	requireUncachedWalletBalance(t, v, b)
+
+	// The cached outputs are updated by the executed blocks
+	for i := uint64(1); i <= 3; i++ {
+		sb := executeWalletSpend(t, v, addr, seckey, i*100e6)
+
+		auxs, ok := v.walletBalances.get("foo.wlt", sb.Head, []cipher.Address{genAddress, addr})
+		require.True(t, ok)
+		require.Len(t, auxs[addr], 1)
+		require.Equal(t, i*100e6, auxs[addr][0].Body.Coins)
+
+		b, err := v.GetWalletBalanceAtHead("foo.wlt")
+		require.NoError(t, err)
+		require.Equal(t, i, b.HeadSeq)
+		require.False(t, b.Stale)
+		require.Equal(t, genCoins, b.Balance.Confirmed.Coins)
+		require.Equal(t, i*100e6, b.Addresses[addr.String()].Confirmed.Coins)
+		requireUncachedWalletBalance(t, v, b)
+	}
+
+	// The balance of the wallet's new addresses is read again
+	err = v.wallets.UpdateSecrets("foo.wlt", nil, func(w wallet.Wallet) error {
+		p, s := cipher.GenerateKeyPair()
+		return w.(*wallet.CollectionWallet).AddEntry(wallet.Entry{
+			Address: cipher.AddressFromPubKey(p),
+			Public:  p,
+			Secret:  s,
+		})
+	})
+	require.NoError(t, err)
+
+	b, err = v.GetWalletBalanceAtHead("foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, b.Addresses, 3)
+	requireUncachedWalletBalance(t, v, b)
+
+	// An unloaded wallet is loaded again
+	err = v.wallets.UnloadWallet("foo.wlt")
+	require.NoError(t, err)
+
+	b, err = v.GetWalletBalanceAtHead("foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, b.Addresses, 3)
+
+	// The entry of a wallet that doesn't exist is dropped
+	head, err := v.GetHeadBlock()
+	require.NoError(t, err)
+	v.walletBalances.set("bar.wlt", head.Head, nil, nil)
+
+	_, err = v.GetWalletBalanceAtHead("bar.wlt")
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+	require.Len(t, v.walletBalances.entries, 1)
+}
+
+func TestGetWalletBalanceAtHeadConcurrentBlocks(t *testing.T) {
+	v, addr, seckey, shutdown := setupWalletBalanceVisor(t)
+	defer shutdown()
+
+	const nBlocks = 10
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				b, err := v.GetWalletBalanceAtHead("foo.wlt")
+				if !assertNoError(t, err) {
+					return
+				}
+
+				// The wallet spends to itself, so its confirmed balance never changes
+				if b.Balance.Confirmed.Coins != genCoins {
+					t.Errorf("balance of head %d is %d coins, expected %d coins", b.HeadSeq, b.Balance.Confirmed.Coins, genCoins)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := uint64(1); i <= nBlocks; i++ {
+		executeWalletSpend(t, v, addr, seckey, i*10e6)
+	}
+
+	close(done)
+	wg.Wait()
+
+	b, err := v.GetWalletBalanceAtHead("foo.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(nBlocks), b.HeadSeq)
+	require.False(t, b.Stale)
+	require.Equal(t, uint64(nBlocks*10e6), b.Addresses[addr.String()].Confirmed.Coins)
+	requireUncachedWalletBalance(t, v, b)
+}
+
+func assertNoError(t *testing.T, err error) bool {
+	if err != nil {
+		t.Error(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,333 @@
+package visor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+/*
+The balance history of a wallet is computed from the transactions of its addresses in the history database.
+The transactions are applied block by block to the wallet's unspent outputs, which gives the balance after
+each block that touches the wallet's addresses. The coin hours of a point are the hours of the unspent
+outputs at the time of its block.
+
+Reading the transactions and blocks of a wallet with a long history is expensive, so the history is cached
+by wallet for the head block it was computed at, and recomputed after a block is executed.
+*/
+
+// BalanceHistoryGranularity is the interval of the points of a balance history
+type BalanceHistoryGranularity string
+
+const (
+	// BalanceHistoryBlock has a point for each block touching the wallet's addresses
+	BalanceHistoryBlock BalanceHistoryGranularity = "block"
+	// BalanceHistoryDay has a point for each UTC day with a block touching the wallet's addresses
+	BalanceHistoryDay BalanceHistoryGranularity = "day"
+	// BalanceHistoryWeek has a point for each UTC week, starting on Monday, with a block touching the wallet's addresses
+	BalanceHistoryWeek BalanceHistoryGranularity = "week"
+)
+
+// BalanceHistoryGranularityFromString parses a BalanceHistoryGranularity
+func BalanceHistoryGranularityFromString(s string) (BalanceHistoryGranularity, error) {
+	switch g := BalanceHistoryGranularity(s); g {
+	case BalanceHistoryBlock, BalanceHistoryDay, BalanceHistoryWeek:
+		return g, nil
+	default:
+		return "", fmt.Errorf("Invalid balance history granularity %q", s)
+	}
+}
+
+// BalanceHistoryPoint is the confirmed balance of a wallet after a block touching its addresses
+type BalanceHistoryPoint struct {
+	// Time is the time of the block, or the start of the day or week of a grouped point
+	Time  uint64
+	BkSeq uint64
+	Coins uint64
+	Hours uint64
+	// Txns is the number of the wallet's transactions in the block, or in the blocks of a grouped point
+	Txns int
+}
+
+// BalanceHistory is the balance history of a wallet at a head block
+type BalanceHistory struct {
+	// HeadSeq is the seq of the head block the history was computed at
+	HeadSeq uint64
+	// Points are ordered by block seq
+	Points []BalanceHistoryPoint
+}
+
+// FilterBalanceHistory returns the points with a time from from to to, including both. A zero to has no limit.
+func FilterBalanceHistory(points []BalanceHistoryPoint, from, to uint64) []BalanceHistoryPoint {
+	var filtered []BalanceHistoryPoint
+	for _, p := range points {
+		if p.Time < from || (to != 0 && p.Time > to) {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// CountBalanceHistoryTxns returns the number of the wallet's transactions of the points
+func CountBalanceHistoryTxns(points []BalanceHistoryPoint) int {
+	n := 0
+	for _, p := range points {
+		n += p.Txns
+	}
+	return n
+}
+
+// GroupBalanceHistory groups the points of consecutive blocks by granularity.
+// A grouped point has the balance after its last block, its time is the start of its day or week.
+func GroupBalanceHistory(points []BalanceHistoryPoint, g BalanceHistoryGranularity) []BalanceHistoryPoint {
+	if g == BalanceHistoryBlock {
+		return points
+	}
+
+	var grouped []BalanceHistoryPoint
+	for _, p := range points {
+		start := balanceHistoryPeriodStart(p.Time, g)
+
+		if n := len(grouped); n != 0 && grouped[n-1].Time == start {
+			txns := grouped[n-1].Txns
+			grouped[n-1] = p
+			grouped[n-1].Time = start
+			grouped[n-1].Txns += txns
+			continue
+		}
+
+		p.Time = start
+		grouped = append(grouped, p)
+	}
+
+	return grouped
+}
+
+// balanceHistoryPeriodStart returns the start of the UTC day or week of a unix time
+func balanceHistoryPeriodStart(t uint64, g BalanceHistoryGranularity) uint64 {
+	day := time.Unix(int64(t), 0).UTC().Truncate(24 * time.Hour)
+	if g == BalanceHistoryWeek {
+		// time.Weekday starts on Sunday
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return uint64(day.Unix())
+}
+
+// GetWalletBalanceHistory returns the confirmed balance of a wallet after each block touching its addresses.
+// The history is cached for the head block it was computed at.
+func (vs *Visor) GetWalletBalanceHistory(wltID string) (*BalanceHistory, error) {
+	var addrs []cipher.Address
+	if err := vs.wallets.View(wltID, func(w wallet.Wallet) error {
+		var err error
+		addrs, err = w.GetSkycoinAddresses()
+		return err
+	}); err != nil {
+		if err == wallet.ErrWalletNotExist {
+			vs.balanceHistories.remove(wltID)
+		}
+		return nil, err
+	}
+
+	var h *BalanceHistory
+	if err := vs.view("GetWalletBalanceHistory", func(tx *dbutil.Tx) error {
+		head, err := vs.blockchain.Head(tx)
+		if err != nil {
+			return err
+		}
+
+		if points, ok := vs.balanceHistories.get(wltID, head.Head, addrs); ok {
+			h = &BalanceHistory{
+				HeadSeq: head.Seq(),
+				Points:  points,
+			}
+			return nil
+		}
+
+		points, err := vs.walletBalanceHistory(tx, addrs)
+		if err != nil {
+			return err
+		}
+
+		vs.balanceHistories.set(wltID, head.Head, addrs, points)
+
+		h = &BalanceHistory{
+			HeadSeq: head.Seq(),
+			Points:  points,
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// walletBalanceHistory applies the transactions of the addresses block by block to their unspent outputs
+func (vs *Visor) walletBalanceHistory(tx *dbutil.Tx, addrs []cipher.Address) ([]BalanceHistoryPoint, error) {
+	addrSet := make(map[cipher.Address]struct{}, len(addrs))
+	for _, a := range addrs {
+		addrSet[a] = struct{}{}
+	}
+
+	// The transactions of the addresses by block seq, without duplicates of the transactions of several addresses
+	seen := make(map[cipher.SHA256]struct{})
+	blockTxns := make(map[uint64][]coin.Transaction)
+	for _, a := range addrs {
+		addrTxns, err := vs.history.GetTransactionsForAddress(tx, a)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, htxn := range addrTxns {
+			h := htxn.Hash()
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+
+			blockTxns[htxn.BlockSeq] = append(blockTxns[htxn.BlockSeq], htxn.Txn)
+		}
+	}
+
+	seqs := make([]uint64, 0, len(blockTxns))
+	for seq := range blockTxns {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		return seqs[i] < seqs[j]
+	})
+
+	unspents := make(map[cipher.SHA256]coin.UxOut)
+	points := make([]BalanceHistoryPoint, 0, len(seqs))
+	for _, seq := range seqs {
+		b, err := vs.blockchain.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			return nil, fmt.Errorf("block seq=%d doesn't exist", seq)
+		}
+
+		// The outputs of a block can't be spent in the same block, the outputs are added before the inputs are removed
+		txns := blockTxns[seq]
+		for _, txn := range txns {
+			for _, ux := range coin.CreateUnspents(b.Head, txn) {
+				if _, ok := addrSet[ux.Body.Address]; ok {
+					unspents[ux.Hash()] = ux
+				}
+			}
+		}
+		for _, txn := range txns {
+			for _, in := range txn.In {
+				delete(unspents, in)
+			}
+		}
+
+		p := BalanceHistoryPoint{
+			Time:  b.Time(),
+			BkSeq: seq,
+			Txns:  len(txns),
+		}
+
+		for _, ux := range unspents {
+			p.Coins, err = mathutil.AddUint64(p.Coins, ux.Body.Coins)
+			if err != nil {
+				return nil, err
+			}
+
+			hours, err := ux.CoinHours(b.Time())
+			if err != nil {
+				return nil, err
+			}
+
+			p.Hours, err = mathutil.AddUint64(p.Hours, hours)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// balanceHistoryEntry is the balance history of a wallet's addresses at a head block
+type balanceHistoryEntry struct {
+	seq    uint64
+	hash   cipher.SHA256
+	addrs  []cipher.Address
+	points []BalanceHistoryPoint
+}
+
+// balanceHistoryCache caches the balance histories of wallets by wallet ID.
+// The zero value is ready to use. A nil cache caches nothing.
+type balanceHistoryCache struct {
+	sync.Mutex
+	entries map[string]*balanceHistoryEntry
+}
+
+// get returns the cached history of a wallet at a head block, false if the wallet has no entry
+// for the head block or its addresses changed
+func (c *balanceHistoryCache) get(wltID string, head coin.BlockHeader, addrs []cipher.Address) ([]BalanceHistoryPoint, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[wltID]
+	if !ok || e.seq != head.BkSeq || e.hash != head.Hash() || !sameAddresses(e.addrs, addrs) {
+		return nil, false
+	}
+
+	return e.points, true
+}
+
+// set caches the history of a wallet's addresses at a head block.
+// An entry of a later head block is kept.
+func (c *balanceHistoryCache) set(wltID string, head coin.BlockHeader, addrs []cipher.Address, points []BalanceHistoryPoint) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if e, ok := c.entries[wltID]; ok && e.seq > head.BkSeq {
+		return
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]*balanceHistoryEntry)
+	}
+	c.entries[wltID] = &balanceHistoryEntry{
+		seq:    head.BkSeq,
+		hash:   head.Hash(),
+		addrs:  append([]cipher.Address{}, addrs...),
+		points: points,
+	}
+}
+
+// remove drops the entry of a wallet
+func (c *balanceHistoryCache) remove(wltID string) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.entries, wltID)
+}
@@ -0,0 +1,140 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestGroupBalanceHistory(t *testing.T) {
+	// Tuesday 2018-10-23 14:33:29 UTC
+	tue := uint64(1540305209)
+
+	points := []BalanceHistoryPoint{
+		{Time: tue, BkSeq: 1, Coins: 1, Hours: 10, Txns: 1},
+		{Time: tue + 3600, BkSeq: 2, Coins: 2, Hours: 20, Txns: 2},
+		{Time: tue + 86400*5, BkSeq: 3, Coins: 3, Hours: 30, Txns: 1},
+		{Time: tue + 86400*6, BkSeq: 4, Coins: 4, Hours: 40, Txns: 1},
+	}
+
+	require.Equal(t, points, GroupBalanceHistory(points, BalanceHistoryBlock))
+
+	require.Equal(t, []BalanceHistoryPoint{
+		// Tuesday 2018-10-23
+		{Time: 1540252800, BkSeq: 2, Coins: 2, Hours: 20, Txns: 3},
+		// Sunday 2018-10-28
+		{Time: 1540684800, BkSeq: 3, Coins: 3, Hours: 30, Txns: 1},
+		// Monday 2018-10-29
+		{Time: 1540771200, BkSeq: 4, Coins: 4, Hours: 40, Txns: 1},
+	}, GroupBalanceHistory(points, BalanceHistoryDay))
+
+	require.Equal(t, []BalanceHistoryPoint{
+		// Weeks start on Monday 2018-10-22 and Monday 2018-10-29
+		{Time: 1540166400, BkSeq: 3, Coins: 3, Hours: 30, Txns: 4},
+		{Time: 1540771200, BkSeq: 4, Coins: 4, Hours: 40, Txns: 1},
+	}, GroupBalanceHistory(points, BalanceHistoryWeek))
+
+	require.Nil(t, GroupBalanceHistory(nil, BalanceHistoryDay))
+
+	// The grouped points are copies
+	require.Equal(t, tue, points[0].Time)
+
+	require.Equal(t, points[1:3], FilterBalanceHistory(points, tue+1, tue+86400*5))
+	require.Equal(t, points[2:], FilterBalanceHistory(points, tue+3601, 0))
+	require.Nil(t, FilterBalanceHistory(points, tue+86400*7, 0))
+	require.Equal(t, 5, CountBalanceHistoryTxns(points))
+
+	for _, s := range []string{"block", "day", "week"} {
+		g, err := BalanceHistoryGranularityFromString(s)
+		require.NoError(t, err)
+		require.Equal(t, BalanceHistoryGranularity(s), g)
+	}
+	_, err := BalanceHistoryGranularityFromString("month")
+	require.EqualError(t, err, `Invalid balance history granularity "month"`)
+}
+
+func TestGetWalletBalanceHistory(t *testing.T) {
+	v, addr, seckey, shutdown := setupWalletBalanceVisor(t)
+	defer shutdown()
+
+	v.balanceHistories = &balanceHistoryCache{}
+
+	// A wallet holding only addr
+	_, err := v.wallets.CreateWallet("bar.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	}, nil)
+	require.NoError(t, err)
+	err = v.wallets.UpdateSecrets("bar.wlt", nil, func(w wallet.Wallet) error {
+		return w.(*wallet.CollectionWallet).AddEntry(wallet.Entry{
+			Address: addr,
+			Public:  cipher.MustPubKeyFromSecKey(seckey),
+			Secret:  seckey,
+		})
+	})
+	require.NoError(t, err)
+
+	h, err := v.GetWalletBalanceHistory("bar.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), h.HeadSeq)
+	require.Empty(t, h.Points)
+
+	for i := uint64(1); i <= 3; i++ {
+		executeWalletSpend(t, v, addr, seckey, i*100e6)
+	}
+
+	h, err = v.GetWalletBalanceHistory("bar.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), h.HeadSeq)
+	require.Len(t, h.Points, 3)
+
+	for i, p := range h.Points {
+		sb, err := v.GetSignedBlockBySeq(uint64(i + 1))
+		require.NoError(t, err)
+
+		require.Equal(t, uint64(i+1), p.BkSeq)
+		require.Equal(t, sb.Time(), p.Time)
+		require.Equal(t, uint64(i+1)*100e6, p.Coins)
+		require.Equal(t, 1, p.Txns)
+	}
+
+	// The hours of the last point are the hours of the balance at the head block
+	bps, err := v.GetBalanceOfAddresses([]cipher.Address{addr})
+	require.NoError(t, err)
+	require.Equal(t, bps[0].Confirmed.Hours, h.Points[2].Hours)
+
+	// The history is cached for the head block
+	head, err := v.GetHeadBlock()
+	require.NoError(t, err)
+	points, ok := v.balanceHistories.get("bar.wlt", head.Head, []cipher.Address{addr})
+	require.True(t, ok)
+	require.Equal(t, h.Points, points)
+
+	// The spends between the wallet's addresses don't change its coins
+	h, err = v.GetWalletBalanceHistory("foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, h.Points, 4)
+	for i, p := range h.Points {
+		require.Equal(t, uint64(i), p.BkSeq)
+		require.Equal(t, genCoins, p.Coins)
+	}
+
+	// An unloaded wallet is loaded again
+	err = v.wallets.UnloadWallet("bar.wlt")
+	require.NoError(t, err)
+
+	h, err = v.GetWalletBalanceHistory("bar.wlt")
+	require.NoError(t, err)
+	require.Len(t, h.Points, 3)
+
+	// The entry of a wallet that doesn't exist is dropped
+	v.balanceHistories.set("baz.wlt", head.Head, []cipher.Address{addr}, h.Points)
+
+	_, err = v.GetWalletBalanceHistory("baz.wlt")
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+	_, ok = v.balanceHistories.get("baz.wlt", head.Head, []cipher.Address{addr})
+	require.False(t, ok)
+}
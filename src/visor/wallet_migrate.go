@@ -0,0 +1,554 @@
+package visor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+var (
+	// ErrMigrateUnsupportedType the wallet can't be migrated to the requested wallet type
+	ErrMigrateUnsupportedType = NewUserError(fmt.Errorf("Wallets can only be migrated to %q wallets", wallet.WalletTypeBip44))
+	// ErrMigrateNotDeterministic the wallet to migrate is not a deterministic wallet
+	ErrMigrateNotDeterministic = NewUserError(fmt.Errorf("Only %q wallets can be migrated", wallet.WalletTypeDeterministic))
+	// ErrMigrateSeedNotMnemonic the seed of the wallet to migrate is not a bip39 mnemonic, which a bip44 wallet requires
+	ErrMigrateSeedNotMnemonic = NewUserError(errors.New("The wallet seed is not a valid bip39 mnemonic and can't be used by a bip44 wallet"))
+)
+
+// WalletMigrateOptions are the options of a wallet migration
+type WalletMigrateOptions struct {
+	// To is the wallet type to migrate to, only bip44 is supported
+	To string
+	// Bip44Coin is the bip44 coin type of the new wallet, defaults to the skycoin coin type
+	Bip44Coin *bip44.CoinType
+	// Label is the label of the new wallet, defaults to the label of the migrated wallet
+	Label string
+	// ScanN is the number of addresses of the new wallet to scan for balances
+	ScanN uint64
+	// DryRun plans the migration without creating the new wallet or signing the transactions
+	DryRun bool
+}
+
+// WalletMigrateTransaction is a transaction sending funds of the migrated wallet to an address of the new wallet
+type WalletMigrateTransaction struct {
+	Transaction *coin.Transaction
+	Inputs      []TransactionInput
+	// Address is the new wallet address that receives the funds
+	Address cipher.Address
+	// Fee is the coin hours burned by the transaction
+	Fee uint64
+}
+
+// WalletMigratePlan is the result of a wallet migration
+type WalletMigratePlan struct {
+	// Wallet is the ID of the migrated wallet
+	Wallet string
+	// NewWallet is the ID of the new wallet, empty in a dry run
+	NewWallet string
+	// Bip44Coin is the bip44 coin type of the new wallet
+	Bip44Coin bip44.CoinType
+	// NewWalletAddresses are the addresses of the new wallet, after scanning it for balances
+	NewWalletAddresses []cipher.Address
+	// NewWalletBalance is the confirmed balance of the new wallet before the migration
+	NewWalletBalance wallet.Balance
+	// Transactions send the confirmed funds of the migrated wallet to the first addresses of the new wallet.
+	// They are signed, unless DryRun is set. They spend distinct outputs and can be injected in any order.
+	Transactions []WalletMigrateTransaction
+	// Warnings describe the funds of the migrated wallet that are not moved by the transactions
+	Warnings []string
+}
+
+// WalletMigrate creates a bip44 wallet from the mnemonic of a deterministic wallet, scans it for balances,
+// and creates transactions moving the confirmed funds of the deterministic wallet to the first addresses of the new wallet.
+// The funds are moved in as few transactions as the transaction size limit allows.
+// Outputs spent by pending transactions, pending transactions sending coins to the wallet and outputs
+// whose coin hours can't pay a fee are not moved, and are reported in the plan's warnings.
+// The new wallet is encrypted with the password of the migrated wallet, if it is encrypted.
+// The migrated wallet is not modified and the transactions are not injected.
+// In a dry run, the new wallet is not created and the transactions are not signed.
+func (vs *Visor) WalletMigrate(wltID string, password []byte, opts WalletMigrateOptions) (*WalletMigratePlan, error) {
+	switch opts.To {
+	case "", wallet.WalletTypeBip44:
+	default:
+		return nil, ErrMigrateUnsupportedType
+	}
+
+	oldWlt, err := vs.wallets.GetWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletOpts wallet.Options
+	var oldAddrs []cipher.Address
+	if err := vs.wallets.ViewSecrets(wltID, password, func(w wallet.Wallet) error {
+		if w.Type() != wallet.WalletTypeDeterministic {
+			return ErrMigrateNotDeterministic
+		}
+
+		if err := bip39.ValidateMnemonic(w.Seed()); err != nil {
+			return ErrMigrateSeedNotMnemonic
+		}
+
+		label := opts.Label
+		if label == "" {
+			label = w.Label()
+		}
+
+		walletOpts = wallet.Options{
+			Type:      wallet.WalletTypeBip44,
+			Coin:      wallet.CoinTypeSkycoin,
+			Bip44Coin: opts.Bip44Coin,
+			Label:     label,
+			Seed:      w.Seed(),
+			ScanN:     opts.ScanN,
+		}
+
+		if oldWlt.IsEncrypted() {
+			walletOpts.Encrypt = true
+			walletOpts.Password = password
+			walletOpts.CryptoType = oldWlt.CryptoType()
+		}
+
+		var err error
+		oldAddrs, err = w.GetSkycoinAddresses()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		walletOpts.Seed = ""
+		walletOpts.Password = nil
+	}()
+
+	// Plan the transactions before the new wallet addresses are known, since their number depends on the plan
+	var batches [][]coin.UxOut
+	var warnings []string
+	if err := vs.view("WalletMigrate", func(tx *dbutil.Tx) error {
+		var err error
+		batches, warnings, err = vs.planWalletMigrateTx(tx, oldAddrs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	// Derive the new wallet in memory, to find its addresses and scan them for balances.
+	// The addresses to generate are the destinations of the transactions.
+	generateN := uint64(len(batches))
+	if generateN == 0 {
+		generateN = 1
+	}
+
+	memOpts := walletOpts
+	memOpts.Encrypt = false
+	memOpts.Password = nil
+	memOpts.CryptoType = ""
+	memOpts.GenerateN = generateN
+	newWlt, err := wallet.NewWalletScanAhead(wallet.NewWalletFilename(), memOpts, vs)
+	if err != nil {
+		return nil, err
+	}
+
+	newAddrs, err := newWlt.GetSkycoinAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &WalletMigratePlan{
+		Wallet:             wltID,
+		Bip44Coin:          newWlt.Bip44Coin(),
+		NewWalletAddresses: newAddrs,
+		Warnings:           warnings,
+	}
+
+	signed := TxnUnsigned
+	if !opts.DryRun {
+		signed = TxnSigned
+	}
+
+	if err := vs.view("WalletMigrate", func(tx *dbutil.Tx) error {
+		plan.NewWalletBalance, err = vs.confirmedBalanceOfAddrsTx(tx, newAddrs)
+		if err != nil {
+			return err
+		}
+
+		plan.Transactions, err = vs.createWalletMigrateTransactionsTx(tx, batches, newAddrs[:len(batches)])
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if signed == TxnSigned && len(plan.Transactions) != 0 {
+		if err := vs.wallets.ViewSecrets(wltID, password, func(w wallet.Wallet) error {
+			for i, t := range plan.Transactions {
+				uxOuts := make([]coin.UxOut, len(t.Inputs))
+				for j, in := range t.Inputs {
+					uxOuts[j] = in.UxOut
+				}
+
+				signedTxn, err := wallet.SignTransaction(w, t.Transaction, nil, uxOuts)
+				if err != nil {
+					return err
+				}
+				plan.Transactions[i].Transaction = signedTxn
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := vs.view("WalletMigrate", func(tx *dbutil.Tx) error {
+		for _, t := range plan.Transactions {
+			if err := VerifySingleTxnUserConstraints(*t.Transaction); err != nil {
+				return err
+			}
+			if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, *t.Transaction, vs.Config.Distribution, params.UserVerifyTxn, signed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// A wallet created by an earlier migration of the wallet is reused, so that funds left behind can be moved later
+	existing, err := vs.findWalletByFingerprint(newWlt.Fingerprint())
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		if existing != nil {
+			plan.NewWallet = existing.Filename()
+		}
+		return plan, nil
+	}
+
+	var addrs []cipher.Address
+	if existing != nil {
+		plan.NewWallet = existing.Filename()
+
+		addrs, err = existing.GetSkycoinAddresses()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(addrs) < len(newAddrs) {
+			if _, err := vs.wallets.NewAddresses(plan.NewWallet, password, uint64(len(newAddrs)-len(addrs))); err != nil {
+				return nil, err
+			}
+
+			addrs, err = vs.wallets.GetSkycoinAddresses(plan.NewWallet)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		walletOpts.GenerateN = uint64(len(newAddrs))
+		walletOpts.ScanN = 0
+		w, err := vs.wallets.CreateWallet("", walletOpts, vs)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.NewWallet = w.Filename()
+
+		addrs, err = w.GetSkycoinAddresses()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The saved wallet is derived from the same seed as the wallet in memory and has the same addresses
+	for i, t := range plan.Transactions {
+		if i >= len(addrs) || addrs[i] != t.Address {
+			return nil, fmt.Errorf("new wallet address %d does not match the transaction destination %s", i, t.Address)
+		}
+	}
+
+	logger.WithField("wallet", wltID).Infof("WalletMigrate: moving funds to bip44 wallet %s in %d transactions", plan.NewWallet, len(plan.Transactions))
+
+	return plan, nil
+}
+
+// findWalletByFingerprint returns the wallet with a fingerprint, or nil if there is none
+func (vs *Visor) findWalletByFingerprint(fingerprint string) (wallet.Wallet, error) {
+	wlts, err := vs.wallets.GetWallets()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range wlts {
+		if w.Fingerprint() == fingerprint {
+			return w, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// planWalletMigrateTx groups the confirmed unspent outputs of addrs that are not spent by pending transactions into
+// batches that fit in a transaction, returning warnings for the outputs that are not moved
+func (vs *Visor) planWalletMigrateTx(tx *dbutil.Tx, addrs []cipher.Address) ([][]coin.UxOut, []string, error) {
+	addrsMap := make(map[cipher.Address]struct{}, len(addrs))
+	for _, a := range addrs {
+		addrsMap[a] = struct{}{}
+	}
+
+	addrHashes, err := vs.blockchain.Unspent().GetUnspentHashesOfAddrs(tx, addrs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := addrHashes.Flatten()
+	hashesMap := make(map[cipher.SHA256]struct{}, len(hashes))
+	for _, h := range hashes {
+		hashesMap[h] = struct{}{}
+	}
+
+	// Find the outputs spent by pending transactions, and the pending transactions sending coins to the wallet
+	spentUnconfirmed := make(map[cipher.SHA256]struct{})
+	var receiving int
+	if err := vs.unconfirmed.ForEach(tx, func(_ cipher.SHA256, txn UnconfirmedTransaction) error {
+		for _, h := range txn.Transaction.In {
+			if _, ok := hashesMap[h]; ok {
+				spentUnconfirmed[h] = struct{}{}
+			}
+		}
+
+		for _, o := range txn.Transaction.Out {
+			if _, ok := addrsMap[o.Address]; ok {
+				receiving++
+				break
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []string
+	if len(spentUnconfirmed) != 0 {
+		warnings = append(warnings, fmt.Sprintf("%d outputs are spent by pending transactions and were not moved", len(spentUnconfirmed)))
+	}
+	if receiving != 0 {
+		warnings = append(warnings, fmt.Sprintf("%d pending transactions send coins to the wallet, their outputs were not moved", receiving))
+	}
+
+	spendable := hashes[:0]
+	for _, h := range hashes {
+		if _, ok := spentUnconfirmed[h]; !ok {
+			spendable = append(spendable, h)
+		}
+	}
+
+	if len(spendable) == 0 {
+		return nil, warnings, nil
+	}
+
+	uxOuts, err := vs.blockchain.Unspent().GetArray(tx, spendable)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headTime, err := vs.blockchain.Time(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Spend the outputs with the most coin hours first, so that the coin hours of each transaction can pay its fee
+	hours := make(map[cipher.SHA256]uint64, len(uxOuts))
+	for _, ux := range uxOuts {
+		h, err := ux.CoinHours(headTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		hours[ux.Hash()] = h
+	}
+
+	sort.Slice(uxOuts, func(i, j int) bool {
+		hi := hours[uxOuts[i].Hash()]
+		hj := hours[uxOuts[j].Hash()]
+		if hi != hj {
+			return hi > hj
+		}
+		hashI := uxOuts[i].Hash()
+		hashJ := uxOuts[j].Hash()
+		return bytes.Compare(hashI[:], hashJ[:]) < 0
+	})
+
+	maxInputs, err := maxSweepTransactionInputs(params.UserVerifyTxn.MaxTransactionSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	burnPolicy := NewBurnPolicy(params.UserVerifyTxn, vs.Config.BurnFactorSchedule)
+
+	var batches [][]coin.UxOut
+	var dust int
+	for len(uxOuts) != 0 {
+		n := maxInputs
+		if n > len(uxOuts) {
+			n = len(uxOuts)
+		}
+
+		batch := uxOuts[:n]
+		uxOuts = uxOuts[n:]
+
+		var batchHours uint64
+		for _, ux := range batch {
+			batchHours, err = mathutil.AddUint64(batchHours, hours[ux.Hash()])
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// A transaction must burn coin hours. The outputs are sorted by coin hours,
+		// so the remaining outputs can't pay a fee either.
+		if batchHours == 0 || burnPolicy.RequiredFee(batchHours, headTime) == 0 {
+			dust += len(batch) + len(uxOuts)
+			break
+		}
+
+		batches = append(batches, batch)
+	}
+
+	if dust != 0 {
+		warnings = append(warnings, fmt.Sprintf("%d outputs have no coin hours to pay a transaction fee and were not moved", dust))
+	}
+
+	return batches, warnings, nil
+}
+
+// maxSweepTransactionInputs returns the number of inputs of a signed transaction with one output
+// that fit in maxSize bytes
+func maxSweepTransactionInputs(maxSize uint32) (int, error) {
+	size := func(n int) (uint32, error) {
+		txn := coin.Transaction{
+			In:   make([]cipher.SHA256, n),
+			Sigs: make([]cipher.Sig, n),
+			Out:  make([]coin.TransactionOutput, 1),
+		}
+		return txn.Size()
+	}
+
+	s0, err := size(0)
+	if err != nil {
+		return 0, err
+	}
+
+	s1, err := size(1)
+	if err != nil {
+		return 0, err
+	}
+
+	if maxSize < s1 {
+		return 0, fmt.Errorf("max transaction size %d is too small for a transaction with one input", maxSize)
+	}
+
+	return int((maxSize - s0) / (s1 - s0)), nil
+}
+
+// createWalletMigrateTransactionsTx creates an unsigned transaction for each batch of outputs,
+// sending them in a single output to the address of the same index
+func (vs *Visor) createWalletMigrateTransactionsTx(tx *dbutil.Tx, batches [][]coin.UxOut, dests []cipher.Address) ([]WalletMigrateTransaction, error) {
+	headTime, err := vs.blockchain.Time(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	burnPolicy := NewBurnPolicy(params.UserVerifyTxn, vs.Config.BurnFactorSchedule)
+
+	txns := make([]WalletMigrateTransaction, len(batches))
+	for i, batch := range batches {
+		var txn coin.Transaction
+		inputs := make([]TransactionInput, len(batch))
+		var coins, hours uint64
+		for j, ux := range batch {
+			inputs[j], err = NewTransactionInput(ux, headTime)
+			if err != nil {
+				return nil, err
+			}
+
+			coins, err = mathutil.AddUint64(coins, ux.Body.Coins)
+			if err != nil {
+				return nil, err
+			}
+
+			hours, err = mathutil.AddUint64(hours, inputs[j].CalculatedHours)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := txn.PushInput(ux.Hash()); err != nil {
+				return nil, err
+			}
+		}
+
+		fee := burnPolicy.RequiredFee(hours, headTime)
+		if err := txn.PushOutput(dests[i], coins, hours-fee); err != nil {
+			return nil, err
+		}
+
+		// Unsigned transactions have null signatures, which the size limit accounts for
+		txn.Sigs = make([]cipher.Sig, len(txn.In))
+		if err := txn.UpdateHeader(); err != nil {
+			return nil, err
+		}
+
+		txns[i] = WalletMigrateTransaction{
+			Transaction: &txn,
+			Inputs:      inputs,
+			Address:     dests[i],
+			Fee:         fee,
+		}
+	}
+
+	return txns, nil
+}
+
+// confirmedBalanceOfAddrsTx returns the confirmed balance of addrs
+func (vs *Visor) confirmedBalanceOfAddrsTx(tx *dbutil.Tx, addrs []cipher.Address) (wallet.Balance, error) {
+	auxs, err := vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, addrs)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+
+	headTime, err := vs.blockchain.Time(tx)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+
+	var b wallet.Balance
+	for _, ux := range auxs.Flatten() {
+		b.Coins, err = mathutil.AddUint64(b.Coins, ux.Body.Coins)
+		if err != nil {
+			return wallet.Balance{}, err
+		}
+
+		h, err := ux.CoinHours(headTime)
+		if err != nil {
+			return wallet.Balance{}, err
+		}
+
+		b.Hours, err = mathutil.AddUint64(b.Hours, h)
+		if err != nil {
+			return wallet.Balance{}, err
+		}
+	}
+
+	return b, nil
+}
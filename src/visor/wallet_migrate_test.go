@@ -0,0 +1,252 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletMigrate(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	seed := "voyage say extend find sheriff surge priority merit ignore maple cash argue"
+	password := []byte("foo")
+	_, err = ws.CreateWallet("foo.wlt", wallet.Options{
+		Coin:       wallet.CoinTypeSkycoin,
+		Type:       wallet.WalletTypeDeterministic,
+		Label:      "foo",
+		Seed:       seed,
+		GenerateN:  3,
+		Encrypt:    true,
+		Password:   password,
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = ws.CreateWallet("bar.wlt", wallet.Options{
+		Coin:  wallet.CoinTypeSkycoin,
+		Type:  wallet.WalletTypeDeterministic,
+		Label: "bar",
+		Seed:  "not a mnemonic",
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = ws.CreateWallet("baz.wlt", wallet.Options{
+		Coin:  wallet.CoinTypeSkycoin,
+		Type:  wallet.WalletTypeBip44,
+		Label: "baz",
+		Seed:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+	}, nil)
+	require.NoError(t, err)
+
+	walletAddrs, err := ws.GetSkycoinAddresses("foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, walletAddrs, 3)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+	cfg.Distribution = params.MainNetDistribution
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	require.Len(t, uxs, 1)
+
+	// Send three outputs to the deterministic wallet
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[0], 1e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[1], 2e6, 200)
+	require.NoError(t, err)
+	err = txn.PushOutput(walletAddrs[2], 3e6, 300)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-6e6, 100)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, err = v.InjectForeignTransaction(txn)
+	require.NoError(t, err)
+
+	_, err = v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	// The bip44 wallet of the same mnemonic
+	bip44Wlt, err := wallet.NewWallet("bip44.wlt", wallet.Options{
+		Coin:      wallet.CoinTypeSkycoin,
+		Type:      wallet.WalletTypeBip44,
+		Seed:      seed,
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+	bip44Addrs, err := bip44Wlt.GetSkycoinAddresses()
+	require.NoError(t, err)
+
+	// Invalid migrations
+	_, err = v.WalletMigrate("foo.wlt", password, WalletMigrateOptions{To: wallet.WalletTypeXPub})
+	require.Equal(t, ErrMigrateUnsupportedType, err)
+
+	_, err = v.WalletMigrate("bar.wlt", nil, WalletMigrateOptions{})
+	require.Equal(t, ErrMigrateSeedNotMnemonic, err)
+
+	_, err = v.WalletMigrate("baz.wlt", nil, WalletMigrateOptions{})
+	require.Equal(t, ErrMigrateNotDeterministic, err)
+
+	_, err = v.WalletMigrate("foo.wlt", []byte("bar"), WalletMigrateOptions{})
+	require.Equal(t, wallet.ErrInvalidPassword, err)
+
+	_, err = v.WalletMigrate("qux.wlt", nil, WalletMigrateOptions{})
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+
+	// A dry run plans the transactions without creating the wallet
+	plan, err := v.WalletMigrate("foo.wlt", password, WalletMigrateOptions{
+		DryRun: true,
+		ScanN:  5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "foo.wlt", plan.Wallet)
+	require.Empty(t, plan.NewWallet)
+	require.Equal(t, bip44.CoinTypeSkycoin, plan.Bip44Coin)
+	require.Equal(t, bip44Addrs, plan.NewWalletAddresses)
+	require.Equal(t, wallet.Balance{}, plan.NewWalletBalance)
+	require.Empty(t, plan.Warnings)
+	require.Len(t, plan.Transactions, 1)
+
+	mt := plan.Transactions[0]
+	require.False(t, mt.Transaction.IsFullySigned())
+	require.Equal(t, bip44Addrs[0], mt.Address)
+	require.Len(t, mt.Inputs, 3)
+	require.Len(t, mt.Transaction.Out, 1)
+	require.Equal(t, bip44Addrs[0], mt.Transaction.Out[0].Address)
+	require.Equal(t, uint64(6e6), mt.Transaction.Out[0].Coins)
+	require.NotZero(t, mt.Fee)
+
+	var inputHours uint64
+	for _, in := range mt.Inputs {
+		inputHours += in.CalculatedHours
+	}
+	require.Equal(t, inputHours-mt.Fee, mt.Transaction.Out[0].Hours)
+
+	wlts, err := ws.GetWallets()
+	require.NoError(t, err)
+	require.Len(t, wlts, 3)
+
+	// A custom bip44 coin type derives other addresses
+	coinType := bip44.CoinTypeBitcoin
+	plan, err = v.WalletMigrate("foo.wlt", password, WalletMigrateOptions{
+		DryRun:    true,
+		Bip44Coin: &coinType,
+	})
+	require.NoError(t, err)
+	require.Equal(t, bip44.CoinTypeBitcoin, plan.Bip44Coin)
+	require.NotEqual(t, bip44Addrs[0], plan.Transactions[0].Address)
+
+	// The migration creates the encrypted bip44 wallet and signs the transactions
+	plan, err = v.WalletMigrate("foo.wlt", password, WalletMigrateOptions{
+		To:    wallet.WalletTypeBip44,
+		Label: "foo bip44",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, plan.NewWallet)
+	require.Len(t, plan.Transactions, 1)
+	require.True(t, plan.Transactions[0].Transaction.IsFullySigned())
+
+	newWlt, err := ws.GetWallet(plan.NewWallet)
+	require.NoError(t, err)
+	require.Equal(t, wallet.WalletTypeBip44, newWlt.Type())
+	require.Equal(t, "foo bip44", newWlt.Label())
+	require.True(t, newWlt.IsEncrypted())
+	newAddrs, err := newWlt.GetSkycoinAddresses()
+	require.NoError(t, err)
+	require.Equal(t, bip44Addrs[0], newAddrs[0])
+
+	// The migrated wallet is kept
+	_, err = ws.GetWallet("foo.wlt")
+	require.NoError(t, err)
+
+	_, _, err = v.InjectForeignTransaction(*plan.Transactions[0].Transaction)
+	require.NoError(t, err)
+
+	// Migrating again reuses the new wallet and reports the pending outputs that can't be moved
+	again, err := v.WalletMigrate("foo.wlt", password, WalletMigrateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, plan.NewWallet, again.NewWallet)
+	require.Empty(t, again.Transactions)
+	require.Equal(t, []string{"3 outputs are spent by pending transactions and were not moved"}, again.Warnings)
+
+	_, err = v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+
+	// The funds are in the new wallet
+	again, err = v.WalletMigrate("foo.wlt", password, WalletMigrateOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, plan.NewWallet, again.NewWallet)
+	require.Empty(t, again.Transactions)
+	require.Empty(t, again.Warnings)
+	require.Equal(t, uint64(6e6), again.NewWalletBalance.Coins)
+}
+
+func TestMaxSweepTransactionInputs(t *testing.T) {
+	maxSize := params.UserVerifyTxn.MaxTransactionSize
+
+	n, err := maxSweepTransactionInputs(maxSize)
+	require.NoError(t, err)
+	require.True(t, n > 0)
+
+	size := func(n int) uint32 {
+		txn := coin.Transaction{
+			In:   make([]cipher.SHA256, n),
+			Sigs: make([]cipher.Sig, n),
+			Out:  make([]coin.TransactionOutput, 1),
+		}
+		s, err := txn.Size()
+		require.NoError(t, err)
+		return s
+	}
+
+	require.True(t, size(n) <= maxSize)
+	require.True(t, size(n+1) > maxSize)
+
+	_, err = maxSweepTransactionInputs(10)
+	require.Error(t, err)
+}
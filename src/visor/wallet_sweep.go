@@ -0,0 +1,250 @@
+package visor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+var (
+	// ErrSweepNoSecretKeys no secret keys to sweep were provided
+	ErrSweepNoSecretKeys = NewUserError(errors.New("No secret keys to sweep"))
+	// ErrSweepInvalidSecretKey a secret key to sweep is invalid
+	ErrSweepInvalidSecretKey = NewUserError(errors.New("Invalid secret key"))
+	// ErrSweepDuplicateSecretKeys the secret keys to sweep contain duplicates
+	ErrSweepDuplicateSecretKeys = NewUserError(errors.New("Secret keys contain duplicate values"))
+	// ErrSweepNoFunds the addresses of the secret keys have no unspent outputs, confirmed or pending
+	ErrSweepNoFunds = NewUserError(errors.New("The addresses of the secret keys have no unspent outputs"))
+	// ErrSweepUnconfirmedOnly the outputs of the secret keys are all spent or received by pending transactions
+	ErrSweepUnconfirmedOnly = NewUserError(errors.New("The outputs of the secret keys are all spent or received by pending transactions, sweep after they are confirmed"))
+	// ErrSweepDustOnly the confirmed outputs of the secret keys can't pay the fee of a transaction or can't be sent
+	ErrSweepDustOnly = NewUserError(errors.New("The outputs of the secret keys are dust: their coin hours can't pay the transaction fee or their coins can't be sent"))
+)
+
+// SweepResult is a signed transaction sending the confirmed funds of secret keys to a wallet
+type SweepResult struct {
+	Transaction *coin.Transaction
+	Inputs      []TransactionInput
+	// Address is the new wallet address that receives the funds
+	Address cipher.Address
+	// Warnings describe the funds of the secret keys that were not swept
+	Warnings []string
+}
+
+// WalletSweep creates a signed transaction that sends the entire confirmed balance of the addresses
+// of secret keys, minus the coin hours fee, to a new address of a wallet.
+// The secret keys are only used to sign the transaction, they are not stored.
+// Outputs that are spent by pending transactions, and pending transactions sending coins to the addresses,
+// are not swept and are reported in the result's warnings.
+// The transaction is not injected.
+func (vs *Visor) WalletSweep(wltID string, password []byte, keys []cipher.SecKey) (*SweepResult, error) {
+	addrKeys, err := sweepAddressKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := vs.wallets.GetWallet(wltID); err != nil {
+		return nil, err
+	}
+
+	// Check that the secret keys can be swept before creating a wallet address.
+	// The size and fee of the transaction don't depend on its destination address.
+	var placeholder cipher.Address
+	for a := range addrKeys {
+		placeholder = a
+		break
+	}
+
+	if err := vs.view("WalletSweep", func(tx *dbutil.Tx) error {
+		_, _, _, err := vs.createSweepTransactionTx(tx, addrKeys, placeholder)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	addrs, err := vs.wallets.NewAddresses(wltID, password, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SweepResult{
+		Address: addrs[0],
+	}
+
+	if err := vs.view("WalletSweep", func(tx *dbutil.Tx) error {
+		var err error
+		result.Transaction, result.Inputs, result.Warnings, err = vs.createSweepTransactionTx(tx, addrKeys, result.Address)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// sweepAddressKeys maps the addresses of secret keys to the keys
+func sweepAddressKeys(keys []cipher.SecKey) (map[cipher.Address]cipher.SecKey, error) {
+	if len(keys) == 0 {
+		return nil, ErrSweepNoSecretKeys
+	}
+
+	addrKeys := make(map[cipher.Address]cipher.SecKey, len(keys))
+	for _, k := range keys {
+		a, err := cipher.AddressFromSecKey(k)
+		if err != nil {
+			return nil, ErrSweepInvalidSecretKey
+		}
+
+		if _, ok := addrKeys[a]; ok {
+			return nil, ErrSweepDuplicateSecretKeys
+		}
+		addrKeys[a] = k
+	}
+
+	return addrKeys, nil
+}
+
+// createSweepTransactionTx creates a signed transaction spending the confirmed outputs of the addresses of addrKeys
+// that are not spent by pending transactions, sending them in a single output to dest
+func (vs *Visor) createSweepTransactionTx(tx *dbutil.Tx, addrKeys map[cipher.Address]cipher.SecKey, dest cipher.Address) (*coin.Transaction, []TransactionInput, []string, error) {
+	addrs := make([]cipher.Address, 0, len(addrKeys))
+	for a := range addrKeys {
+		addrs = append(addrs, a)
+	}
+
+	addrHashes, err := vs.blockchain.Unspent().GetUnspentHashesOfAddrs(tx, addrs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	hashes := addrHashes.Flatten()
+	hashesMap := make(map[cipher.SHA256]struct{}, len(hashes))
+	for _, h := range hashes {
+		hashesMap[h] = struct{}{}
+	}
+
+	// Find the outputs spent by pending transactions, and the pending transactions sending coins to the addresses
+	spentUnconfirmed := make(map[cipher.SHA256]struct{})
+	var receiving int
+	if err := vs.unconfirmed.ForEach(tx, func(_ cipher.SHA256, txn UnconfirmedTransaction) error {
+		for _, h := range txn.Transaction.In {
+			if _, ok := hashesMap[h]; ok {
+				spentUnconfirmed[h] = struct{}{}
+			}
+		}
+
+		for _, o := range txn.Transaction.Out {
+			if _, ok := addrKeys[o.Address]; ok {
+				receiving++
+				break
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var warnings []string
+	if len(spentUnconfirmed) != 0 {
+		warnings = append(warnings, fmt.Sprintf("%d outputs are spent by pending transactions and were not swept", len(spentUnconfirmed)))
+	}
+	if receiving != 0 {
+		warnings = append(warnings, fmt.Sprintf("%d pending transactions send coins to the addresses of the secret keys, their outputs were not swept", receiving))
+	}
+
+	spendable := hashes[:0]
+	for _, h := range hashes {
+		if _, ok := spentUnconfirmed[h]; !ok {
+			spendable = append(spendable, h)
+		}
+	}
+
+	if len(spendable) == 0 {
+		if len(hashes) == 0 && receiving == 0 {
+			return nil, nil, nil, ErrSweepNoFunds
+		}
+		return nil, nil, nil, ErrSweepUnconfirmedOnly
+	}
+
+	// Spend the outputs in a deterministic order
+	sort.Slice(spendable, func(i, j int) bool {
+		return bytes.Compare(spendable[i][:], spendable[j][:]) < 0
+	})
+
+	uxOuts, err := vs.blockchain.Unspent().GetArray(tx, spendable)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	headTime, err := vs.blockchain.Time(tx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var txn coin.Transaction
+	inputs := make([]TransactionInput, len(uxOuts))
+	keys := make([]cipher.SecKey, len(uxOuts))
+	var coins, hours uint64
+	for i, ux := range uxOuts {
+		inputs[i], err = NewTransactionInput(ux, headTime)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		coins, err = mathutil.AddUint64(coins, ux.Body.Coins)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		hours, err = mathutil.AddUint64(hours, inputs[i].CalculatedHours)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if err := txn.PushInput(ux.Hash()); err != nil {
+			return nil, nil, nil, err
+		}
+		keys[i] = addrKeys[ux.Body.Address]
+	}
+
+	// A transaction must burn coin hours, and its outputs must not exceed the droplet precision.
+	// Since the coins of the inputs and outputs must be equal, the coins can't be swept
+	// in one output unless their sum is within the precision.
+	burnPolicy := NewBurnPolicy(params.UserVerifyTxn, vs.Config.BurnFactorSchedule)
+	fee := burnPolicy.RequiredFee(hours, headTime)
+	if hours == 0 || fee == 0 || params.DropletPrecisionCheck(params.UserVerifyTxn.MaxDropletPrecision, coins) != nil {
+		return nil, nil, nil, ErrSweepDustOnly
+	}
+
+	if err := txn.PushOutput(dest, coins, hours-fee); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Sign with pcoin so that the signature nonces are deterministic
+	ptxn := toPcoinTransaction(txn)
+	ptxn.SignInputs(keys)
+	txn.InnerHash = ptxn.InnerHash
+	txn.Sigs = ptxn.Sigs
+
+	if err := txn.UpdateHeader(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := VerifySingleTxnUserConstraints(txn); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.Distribution, params.UserVerifyTxn, TxnSigned); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &txn, inputs, warnings, nil
+}
@@ -0,0 +1,150 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+
+	"github.com/ness-network/privateness/src/util/fee"
+	"github.com/ness-network/privateness/src/wallet"
+)
+
+func TestWalletSweep(t *testing.T) {
+	v, addr, seckey, shutdown := setupWalletBalanceVisor(t)
+	defer shutdown()
+
+	w, err := v.wallets.CreateWallet("bar.wlt", wallet.Options{
+		Type: wallet.WalletTypeDeterministic,
+		Seed: "sweep test seed",
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, w.GetEntries(), 1)
+
+	_, emptyKey := cipher.GenerateKeyPair()
+
+	// Send coins without coin hours to dustAddr
+	dustPubkey, dustKey := cipher.GenerateKeyPair()
+	dustAddr := cipher.AddressFromPubKey(dustPubkey)
+	auxs, err := v.GetUnspentsOfAddrs([]cipher.Address{genAddress})
+	require.NoError(t, err)
+	require.Len(t, auxs[genAddress], 1)
+	genUx := auxs[genAddress][0]
+	var dustTxn coin.Transaction
+	err = dustTxn.PushInput(genUx.Hash())
+	require.NoError(t, err)
+	err = dustTxn.PushOutput(dustAddr, 1e6, 0)
+	require.NoError(t, err)
+	err = dustTxn.PushOutput(genAddress, genUx.Body.Coins-1e6, genUx.Body.Hours/4)
+	require.NoError(t, err)
+	dustTxn.SignInputs([]cipher.SecKey{genSecret})
+	err = dustTxn.UpdateHeader()
+	require.NoError(t, err)
+	executeTxn(t, v, dustTxn)
+
+	for _, tc := range []struct {
+		name  string
+		wltID string
+		keys  []cipher.SecKey
+		err   error
+	}{
+		{
+			name:  "no keys",
+			wltID: "bar.wlt",
+			err:   ErrSweepNoSecretKeys,
+		},
+		{
+			name:  "invalid key",
+			wltID: "bar.wlt",
+			keys:  []cipher.SecKey{{}},
+			err:   ErrSweepInvalidSecretKey,
+		},
+		{
+			name:  "duplicate keys",
+			wltID: "bar.wlt",
+			keys:  []cipher.SecKey{seckey, emptyKey, seckey},
+			err:   ErrSweepDuplicateSecretKeys,
+		},
+		{
+			name:  "unknown wallet",
+			wltID: "baz.wlt",
+			keys:  []cipher.SecKey{genSecret},
+			err:   wallet.ErrWalletNotExist,
+		},
+		{
+			name:  "no funds",
+			wltID: "bar.wlt",
+			keys:  []cipher.SecKey{emptyKey},
+			err:   ErrSweepNoFunds,
+		},
+		{
+			name:  "dust",
+			wltID: "bar.wlt",
+			keys:  []cipher.SecKey{dustKey},
+			err:   ErrSweepDustOnly,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.WalletSweep(tc.wltID, nil, tc.keys)
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	// Failed sweeps don't create wallet addresses
+	w, err = v.wallets.GetWallet("bar.wlt")
+	require.NoError(t, err)
+	require.Len(t, w.GetEntries(), 1)
+
+	executeWalletSpend(t, v, addr, seckey, 100e6)
+
+	// A pending transaction sends coins from the genesis address to addr
+	auxs, err = v.GetUnspentsOfAddrs([]cipher.Address{genAddress})
+	require.NoError(t, err)
+	require.Len(t, auxs[genAddress], 1)
+	pending := makeSpendTxn(t, auxs[genAddress], []cipher.SecKey{genSecret}, addr, 10e6)
+	_, softErr, err := v.InjectForeignTransaction(pending)
+	require.NoError(t, err)
+	require.Nil(t, softErr)
+
+	// The genesis address' output is spent by the pending transaction
+	_, err = v.WalletSweep("bar.wlt", nil, []cipher.SecKey{genSecret})
+	require.Equal(t, ErrSweepUnconfirmedOnly, err)
+
+	// The confirmed output of addr is swept, the pending outputs are reported
+	result, err := v.WalletSweep("bar.wlt", nil, []cipher.SecKey{genSecret, seckey})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"1 outputs are spent by pending transactions and were not swept",
+		"1 pending transactions send coins to the addresses of the secret keys, their outputs were not swept",
+	}, result.Warnings)
+
+	w, err = v.wallets.GetWallet("bar.wlt")
+	require.NoError(t, err)
+	require.Len(t, w.GetEntries(), 2)
+	require.Equal(t, w.GetEntryAt(1).Address, result.Address)
+
+	txn := result.Transaction
+	require.Len(t, txn.In, 1)
+	require.Len(t, result.Inputs, 1)
+	require.Equal(t, addr, result.Inputs[0].UxOut.Body.Address)
+	require.Equal(t, txn.In[0], result.Inputs[0].UxOut.Hash())
+	hours := result.Inputs[0].CalculatedHours
+	require.Equal(t, []coin.TransactionOutput{
+		{
+			Address: result.Address,
+			Coins:   100e6,
+			Hours:   hours - fee.RequiredFee(hours, params.UserVerifyTxn.BurnFactor),
+		},
+	}, txn.Out)
+	require.NoError(t, txn.Verify())
+
+	_, _, _, err = v.InjectUserTransaction(*txn)
+	require.NoError(t, err)
+
+	// The output of addr is now spent by the sweep
+	_, err = v.WalletSweep("bar.wlt", nil, []cipher.SecKey{seckey})
+	require.Equal(t, ErrSweepUnconfirmedOnly, err)
+}
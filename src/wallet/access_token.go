@@ -0,0 +1,122 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+/*
+A wallet can have an access token, which the wallet API endpoints require for that wallet,
+so that a shared node's API users can only operate their own wallets.
+Wallets without an access token can be operated by anyone who can reach the wallet API.
+
+The token is random and returned only when it is set. The wallet stores its sha256, which is enough
+because the token is not guessable. Setting or removing a token requires the wallet password,
+rotating it only requires the current token, which the API checks.
+*/
+
+const accessTokenLen = 32
+
+var (
+	// ErrWalletAccessTokenRequired is returned when a wallet has an access token and none was provided
+	ErrWalletAccessTokenRequired = NewError(errors.New("wallet access token required"))
+	// ErrWalletAccessTokenInvalid is returned when the access token provided for a wallet is wrong
+	ErrWalletAccessTokenInvalid = NewError(errors.New("invalid wallet access token"))
+	// ErrWalletAccessTokenNotSet is returned when rotating the access token of a wallet that has none
+	ErrWalletAccessTokenNotSet = NewError(errors.New("wallet has no access token"))
+)
+
+// newAccessToken returns a random access token and its hash
+func newAccessToken() (string, string) {
+	token := base64.RawURLEncoding.EncodeToString(cipher.RandByte(accessTokenLen))
+	return token, hashAccessToken(token)
+}
+
+// hashAccessToken returns the hex encoded sha256 of an access token, as stored in the wallet
+func hashAccessToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// verifyAccessToken checks an access token against the access token hash of a wallet's metadata.
+// Any token is accepted if the wallet has no access token.
+func verifyAccessToken(m Meta, token string) error {
+	h := m.AccessTokenHash()
+	if h == "" {
+		return nil
+	}
+
+	if token == "" {
+		return ErrWalletAccessTokenRequired
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAccessToken(token)), []byte(h)) != 1 {
+		return ErrWalletAccessTokenInvalid
+	}
+
+	return nil
+}
+
+// SetWalletAccessToken sets a new access token for a wallet, replacing its access token if it has one,
+// and returns the token. The wallet password must be provided if the wallet is encrypted.
+func (serv *Service) SetWalletAccessToken(wltID string, password []byte) (string, error) {
+	token, h := newAccessToken()
+	if err := serv.UpdateSecrets(wltID, password, func(w Wallet) error {
+		w.SetAccessTokenHash(h)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RotateWalletAccessToken replaces the access token of a wallet and returns the new token.
+// The caller must have checked the current token with VerifyWalletAccessToken.
+// Returns ErrWalletAccessTokenNotSet if the wallet has no access token.
+func (serv *Service) RotateWalletAccessToken(wltID string) (string, error) {
+	token, h := newAccessToken()
+	if err := serv.Update(wltID, func(w Wallet) error {
+		if w.AccessTokenHash() == "" {
+			return ErrWalletAccessTokenNotSet
+		}
+		w.SetAccessTokenHash(h)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RemoveWalletAccessToken removes the access token of a wallet.
+// The wallet password must be provided if the wallet is encrypted.
+func (serv *Service) RemoveWalletAccessToken(wltID string, password []byte) error {
+	return serv.UpdateSecrets(wltID, password, func(w Wallet) error {
+		w.SetAccessTokenHash("")
+		return nil
+	})
+}
+
+// VerifyWalletAccessToken checks the access token provided for a wallet, without loading the wallet.
+// Returns nil if the wallet has no access token, ErrWalletAccessTokenRequired if token is empty
+// and ErrWalletAccessTokenInvalid if it is wrong.
+func (serv *Service) VerifyWalletAccessToken(wltID, token string) error {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	m, ok := serv.headers[wltID]
+	if !ok {
+		return ErrWalletNotExist
+	}
+
+	return verifyAccessToken(m, token)
+}
@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceWalletAccessToken(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	password := []byte("pwd")
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Type:     WalletTypeDeterministic,
+		Encrypt:  true,
+		Password: password,
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("u.wlt", Options{
+		Seed: "seed2",
+		Type: WalletTypeDeterministic,
+	}, nil)
+	require.NoError(t, err)
+
+	// Wallets without an access token accept any token
+	require.NoError(t, s.VerifyWalletAccessToken("t.wlt", ""))
+	require.NoError(t, s.VerifyWalletAccessToken("t.wlt", "token"))
+	require.Equal(t, ErrWalletNotExist, s.VerifyWalletAccessToken("x.wlt", ""))
+
+	_, err = s.RotateWalletAccessToken("t.wlt")
+	require.Equal(t, ErrWalletAccessTokenNotSet, err)
+
+	// Setting a token requires the password of an encrypted wallet
+	_, err = s.SetWalletAccessToken("t.wlt", nil)
+	require.Equal(t, ErrMissingPassword, err)
+	_, err = s.SetWalletAccessToken("t.wlt", []byte("wrong"))
+	require.Equal(t, ErrInvalidPassword, err)
+	_, err = s.SetWalletAccessToken("u.wlt", password)
+	require.Equal(t, ErrWalletNotEncrypted, err)
+
+	token, err := s.SetWalletAccessToken("t.wlt", password)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	require.NoError(t, s.VerifyWalletAccessToken("t.wlt", token))
+	require.Equal(t, ErrWalletAccessTokenRequired, s.VerifyWalletAccessToken("t.wlt", ""))
+	require.Equal(t, ErrWalletAccessTokenInvalid, s.VerifyWalletAccessToken("t.wlt", token+"x"))
+	require.NoError(t, s.VerifyWalletAccessToken("u.wlt", ""))
+
+	// The token hash is saved, and the wallet can still be unlocked
+	w, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, hashAccessToken(token), w.AccessTokenHash())
+	require.NoError(t, w.Validate())
+	_, err = Unlock(w, password)
+	require.NoError(t, err)
+
+	// The token survives unloading the wallet
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	require.Equal(t, ErrWalletAccessTokenRequired, s.VerifyWalletAccessToken("t.wlt", ""))
+
+	// Rotating replaces the token
+	newToken, err := s.RotateWalletAccessToken("t.wlt")
+	require.NoError(t, err)
+	require.NotEqual(t, token, newToken)
+	require.Equal(t, ErrWalletAccessTokenInvalid, s.VerifyWalletAccessToken("t.wlt", token))
+	require.NoError(t, s.VerifyWalletAccessToken("t.wlt", newToken))
+
+	// Unencrypted wallets don't need a password
+	token, err = s.SetWalletAccessToken("u.wlt", nil)
+	require.NoError(t, err)
+	require.NoError(t, s.VerifyWalletAccessToken("u.wlt", token))
+
+	require.Equal(t, ErrInvalidPassword, s.RemoveWalletAccessToken("t.wlt", []byte("wrong")))
+	require.NoError(t, s.RemoveWalletAccessToken("t.wlt", password))
+	require.NoError(t, s.VerifyWalletAccessToken("t.wlt", ""))
+
+	w, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Empty(t, w.AccessTokenHash())
+}
+
+func TestMetaValidateAccessToken(t *testing.T) {
+	m := Meta{
+		metaFilename: "t.wlt",
+		metaType:     WalletTypeCollection,
+		metaCoin:     string(CoinTypeSkycoin),
+	}
+	require.NoError(t, m.validate())
+
+	m.SetAccessTokenHash(hashAccessToken("token"))
+	require.NoError(t, m.validate())
+
+	m.SetAccessTokenHash("abc")
+	require.EqualError(t, m.validate(), "accessToken field is not a valid sha256 hash")
+}
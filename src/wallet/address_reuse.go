@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// AddressReuse is an address used before by a transaction created for a wallet.
+// Reusing an address links the transactions using it, which harms the privacy of the wallet's owner.
+type AddressReuse struct {
+	Address cipher.Address
+	// Change is true if the address receives the transaction's change,
+	// otherwise it is a destination address that belongs to the wallet
+	Change bool
+}
+
+// String describes the reuse
+func (r AddressReuse) String() string {
+	if r.Change {
+		return fmt.Sprintf("change address %s has been used before", r.Address)
+	}
+	return fmt.Sprintf("destination address %s belongs to the wallet and has been used before", r.Address)
+}
+
+// FindAddressReuse returns the addresses of a transaction created for a wallet that were used before:
+// the change address, and the destination addresses that belong to the wallet.
+// The first receivers outputs of the transaction are its destinations, the output following them is its change.
+// An address was used before if it appears in the blockchain or in the unconfirmed pool, according to tf.
+func FindAddressReuse(w Wallet, txn *coin.Transaction, receivers int, tf TransactionsFinder) ([]AddressReuse, error) {
+	if receivers > len(txn.Out) {
+		return nil, fmt.Errorf("transaction has %d outputs, less than its %d receivers", len(txn.Out), receivers)
+	}
+
+	walletAddrs, err := w.GetSkycoinAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[cipher.Address]struct{}, len(walletAddrs))
+	for _, a := range walletAddrs {
+		owned[a] = struct{}{}
+	}
+
+	var candidates []AddressReuse
+	seen := make(map[cipher.Address]struct{})
+	add := func(a cipher.Address, change bool) {
+		if _, ok := seen[a]; ok {
+			return
+		}
+		seen[a] = struct{}{}
+		candidates = append(candidates, AddressReuse{
+			Address: a,
+			Change:  change,
+		})
+	}
+
+	if receivers < len(txn.Out) {
+		add(txn.Out[receivers].Address, true)
+	}
+
+	for _, o := range txn.Out[:receivers] {
+		if _, ok := owned[o.Address]; ok {
+			add(o.Address, false)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	addrs := make([]cipher.Address, len(candidates))
+	for i, c := range candidates {
+		addrs[i] = c.Address
+	}
+
+	active, err := tf.AddressesActivity(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	var reuse []AddressReuse
+	for i, c := range candidates {
+		if active[i] {
+			reuse = append(reuse, c)
+		}
+	}
+
+	return reuse, nil
+}
+
+// WalletAddressReuse returns the addresses of a transaction created for a wallet that were used before, see FindAddressReuse
+func (serv *Service) WalletAddressReuse(wltID string, txn *coin.Transaction, receivers int, tf TransactionsFinder) ([]AddressReuse, error) {
+	w, err := serv.GetWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	return FindAddressReuse(w, txn, receivers, tf)
+}
+
+// WalletStrictPrivacy returns true if the wallet API refuses to create transactions of a wallet that reuse addresses
+func (serv *Service) WalletStrictPrivacy(wltID string) (bool, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return false, ErrWalletAPIDisabled
+	}
+
+	m, ok := serv.headers[wltID]
+	if !ok {
+		return false, ErrWalletNotExist
+	}
+
+	return m.StrictPrivacy(), nil
+}
+
+// SetWalletStrictPrivacy sets whether the wallet API refuses to create transactions of a wallet that reuse addresses,
+// instead of warning about the reuse
+func (serv *Service) SetWalletStrictPrivacy(wltID string, strict bool) error {
+	defer serv.use(wltID)()
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return err
+	}
+
+	w.SetStrictPrivacy(strict)
+
+	if err := serv.save(w); err != nil {
+		return err
+	}
+
+	serv.setWallet(w)
+	return nil
+}
@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestFindAddressReuse(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed:      "seed",
+		Type:      WalletTypeDeterministic,
+		GenerateN: 3,
+	})
+	require.NoError(t, err)
+
+	addrs, err := w.GetSkycoinAddresses()
+	require.NoError(t, err)
+
+	external := testutil.MakeAddress()
+	external2 := testutil.MakeAddress()
+
+	newTxn := func(outs ...cipher.Address) *coin.Transaction {
+		txn := &coin.Transaction{}
+		for _, a := range outs {
+			txn.Out = append(txn.Out, coin.TransactionOutput{
+				Address: a,
+				Coins:   1e6,
+			})
+		}
+		return txn
+	}
+
+	cases := []struct {
+		name      string
+		txn       *coin.Transaction
+		receivers int
+		tf        mockTxnsFinder
+		reuse     []AddressReuse
+		err       string
+	}{
+		{
+			name:      "no change, external destination",
+			txn:       newTxn(external),
+			receivers: 1,
+			tf:        mockTxnsFinder{external: true},
+		},
+		{
+			name:      "new change address",
+			txn:       newTxn(external, addrs[1]),
+			receivers: 1,
+			tf:        mockTxnsFinder{external: true, addrs[0]: true},
+		},
+		{
+			name:      "used change address",
+			txn:       newTxn(external, addrs[0]),
+			receivers: 1,
+			tf:        mockTxnsFinder{addrs[0]: true},
+			reuse:     []AddressReuse{{Address: addrs[0], Change: true}},
+		},
+		{
+			name:      "used external change address",
+			txn:       newTxn(external, external2),
+			receivers: 1,
+			tf:        mockTxnsFinder{external2: true},
+			reuse:     []AddressReuse{{Address: external2, Change: true}},
+		},
+		{
+			name:      "used wallet destinations",
+			txn:       newTxn(addrs[1], external, addrs[2], addrs[1], addrs[0]),
+			receivers: 4,
+			tf:        mockTxnsFinder{addrs[0]: true, addrs[1]: true, external: true},
+			reuse: []AddressReuse{
+				{Address: addrs[0], Change: true},
+				{Address: addrs[1]},
+			},
+		},
+		{
+			name:      "change address is a destination",
+			txn:       newTxn(addrs[0], addrs[0]),
+			receivers: 1,
+			tf:        mockTxnsFinder{addrs[0]: true},
+			reuse:     []AddressReuse{{Address: addrs[0], Change: true}},
+		},
+		{
+			name:      "more receivers than outputs",
+			txn:       newTxn(external),
+			receivers: 2,
+			err:       "transaction has 1 outputs, less than its 2 receivers",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reuse, err := FindAddressReuse(w, tc.txn, tc.receivers, tc.tf)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.reuse, reuse)
+		})
+	}
+
+	require.Equal(t, "change address "+addrs[0].String()+" has been used before", AddressReuse{Address: addrs[0], Change: true}.String())
+	require.Equal(t, "destination address "+addrs[0].String()+" belongs to the wallet and has been used before", AddressReuse{Address: addrs[0]}.String())
+}
+
+func TestServiceWalletStrictPrivacy(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed: "seed",
+		Type: WalletTypeDeterministic,
+	}, nil)
+	require.NoError(t, err)
+
+	strict, err := s.WalletStrictPrivacy("t.wlt")
+	require.NoError(t, err)
+	require.False(t, strict)
+	_, err = s.WalletStrictPrivacy("x.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+	require.Equal(t, ErrWalletNotExist, s.SetWalletStrictPrivacy("x.wlt", true))
+
+	require.NoError(t, s.SetWalletStrictPrivacy("t.wlt", true))
+	strict, err = s.WalletStrictPrivacy("t.wlt")
+	require.NoError(t, err)
+	require.True(t, strict)
+
+	// The flag is saved and survives unloading the wallet
+	w, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.NoError(t, w.Validate())
+	require.True(t, w.StrictPrivacy())
+
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	strict, err = s.WalletStrictPrivacy("t.wlt")
+	require.NoError(t, err)
+	require.True(t, strict)
+
+	require.NoError(t, s.SetWalletStrictPrivacy("t.wlt", false))
+	strict, err = s.WalletStrictPrivacy("t.wlt")
+	require.NoError(t, err)
+	require.False(t, strict)
+
+	s.config.EnableWalletAPI = false
+	_, err = s.WalletStrictPrivacy("t.wlt")
+	require.Equal(t, ErrWalletAPIDisabled, err)
+	require.Equal(t, ErrWalletAPIDisabled, s.SetWalletStrictPrivacy("t.wlt", true))
+}
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+A wallet can have an approval policy, which holds the transactions the wallet API creates or signs
+for more than a threshold until an approver approves them with the approver password.
+The approver password must differ from the wallet password.
+
+The wallet stores the threshold, the validity window of pending approvals and a verifier of the
+approver password, a known value encrypted with the approver password.
+Changing or removing the policy requires both the wallet password and the approver password.
+
+The policy is node-local: it applies to the wallet API of this node, not to the wallet's keys.
+*/
+
+// approverCheck is encrypted with the approver password to verify it
+const approverCheck = "approver"
+
+var (
+	// ErrMissingApproverPassword is returned when the approver password of a wallet approval policy is required and was not provided
+	ErrMissingApproverPassword = NewError(errors.New("missing approver password"))
+	// ErrInvalidApproverPassword is returned when the approver password of a wallet approval policy is wrong
+	ErrInvalidApproverPassword = NewError(errors.New("invalid approver password"))
+	// ErrApproverPasswordIsWalletPassword is returned when setting an approver password equal to the wallet password
+	ErrApproverPasswordIsWalletPassword = NewError(errors.New("approver password must differ from the wallet password"))
+	// ErrInvalidApprovalWindow is returned when setting an approval policy whose validity window is not positive
+	ErrInvalidApprovalWindow = NewError(errors.New("approval window must be positive"))
+	// ErrWalletApprovalPolicyNotSet is returned when removing the approval policy of a wallet that has none
+	ErrWalletApprovalPolicyNotSet = NewError(errors.New("wallet has no approval policy"))
+)
+
+// ApprovalPolicy is the approval policy of a wallet
+type ApprovalPolicy struct {
+	// Threshold is the number of droplets above which a transaction requires approval
+	Threshold uint64
+	// Window is how long a transaction waits for approval before it expires
+	Window time.Duration
+	// approver is the crypto type and the approver check encrypted with the approver password, separated by ":"
+	approver string
+}
+
+// RequiresApproval returns true if a transaction sending amount droplets requires approval
+func (p ApprovalPolicy) RequiresApproval(amount uint64) bool {
+	return amount > p.Threshold
+}
+
+// newApprovalPolicy creates an approval policy for an approver password
+func newApprovalPolicy(threshold uint64, window time.Duration, approverPassword []byte, cryptoType CryptoType) (*ApprovalPolicy, error) {
+	if len(approverPassword) == 0 {
+		return nil, ErrMissingApproverPassword
+	}
+
+	if window <= 0 {
+		return nil, ErrInvalidApprovalWindow
+	}
+
+	crypto, err := getCrypto(cryptoType)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := crypto.Encrypt([]byte(approverCheck), approverPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApprovalPolicy{
+		Threshold: threshold,
+		Window:    window,
+		approver:  string(cryptoType) + ":" + string(check),
+	}, nil
+}
+
+// verifyApproverPassword checks the approver password of the policy
+func (p ApprovalPolicy) verifyApproverPassword(approverPassword []byte) error {
+	if len(approverPassword) == 0 {
+		return ErrMissingApproverPassword
+	}
+
+	ss := strings.SplitN(p.approver, ":", 2)
+	if len(ss) != 2 {
+		return errors.New("invalid approver field")
+	}
+
+	crypto, err := getCrypto(CryptoType(ss[0]))
+	if err != nil {
+		return err
+	}
+
+	check, err := crypto.Decrypt([]byte(ss[1]), approverPassword)
+	if err != nil || string(check) != approverCheck {
+		return ErrInvalidApproverPassword
+	}
+
+	return nil
+}
+
+// SetWalletApprovalPolicy sets the approval policy of a wallet, replacing its policy if it has one.
+// The wallet password must be provided if the wallet is encrypted, and currentApproverPassword
+// if the wallet has an approval policy.
+func (serv *Service) SetWalletApprovalPolicy(wltID string, password, approverPassword, currentApproverPassword []byte, threshold uint64, window time.Duration) error {
+	p, err := newApprovalPolicy(threshold, window, approverPassword, serv.config.CryptoType)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(password, approverPassword) {
+		return ErrApproverPasswordIsWalletPassword
+	}
+
+	return serv.UpdateSecrets(wltID, password, func(w Wallet) error {
+		if current := w.ApprovalPolicy(); current != nil {
+			if err := current.verifyApproverPassword(currentApproverPassword); err != nil {
+				return err
+			}
+		}
+
+		w.SetApprovalPolicy(p)
+		return nil
+	})
+}
+
+// RemoveWalletApprovalPolicy removes the approval policy of a wallet.
+// The wallet password must be provided if the wallet is encrypted.
+// Returns ErrWalletApprovalPolicyNotSet if the wallet has no approval policy.
+func (serv *Service) RemoveWalletApprovalPolicy(wltID string, password, approverPassword []byte) error {
+	return serv.UpdateSecrets(wltID, password, func(w Wallet) error {
+		p := w.ApprovalPolicy()
+		if p == nil {
+			return ErrWalletApprovalPolicyNotSet
+		}
+
+		if err := p.verifyApproverPassword(approverPassword); err != nil {
+			return err
+		}
+
+		w.SetApprovalPolicy(nil)
+		return nil
+	})
+}
+
+// WalletApprovalPolicy returns the approval policy of a wallet, nil if it has none
+func (serv *Service) WalletApprovalPolicy(wltID string) (*ApprovalPolicy, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	m, ok := serv.headers[wltID]
+	if !ok {
+		return nil, ErrWalletNotExist
+	}
+
+	return m.ApprovalPolicy(), nil
+}
+
+// VerifyWalletApproverPassword checks the approver password of a wallet's approval policy.
+// Returns ErrWalletApprovalPolicyNotSet if the wallet has no approval policy.
+func (serv *Service) VerifyWalletApproverPassword(wltID string, approverPassword []byte) error {
+	p, err := serv.WalletApprovalPolicy(wltID)
+	if err != nil {
+		return err
+	}
+
+	if p == nil {
+		return ErrWalletApprovalPolicyNotSet
+	}
+
+	return p.verifyApproverPassword(approverPassword)
+}
+
+// parseApprovalPolicy parses the approval policy fields of the wallet metadata, nil if they are not set
+func parseApprovalPolicy(m Meta) (*ApprovalPolicy, error) {
+	approver, ok := m[metaApprover]
+	if !ok {
+		if _, ok := m[metaApprovalThreshold]; ok {
+			return nil, errors.New("approvalThreshold field is set without approver field")
+		}
+		if _, ok := m[metaApprovalWindow]; ok {
+			return nil, errors.New("approvalWindow field is set without approver field")
+		}
+		return nil, nil
+	}
+
+	if !strings.Contains(approver, ":") {
+		return nil, errors.New("approver field is invalid")
+	}
+
+	threshold, err := strconv.ParseUint(m[metaApprovalThreshold], 10, 64)
+	if err != nil {
+		return nil, errors.New("approvalThreshold field is not a valid uint64")
+	}
+
+	window, err := time.ParseDuration(m[metaApprovalWindow])
+	if err != nil || window <= 0 {
+		return nil, errors.New("approvalWindow field is not a valid positive duration")
+	}
+
+	return &ApprovalPolicy{
+		Threshold: threshold,
+		Window:    window,
+		approver:  approver,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceWalletApprovalPolicy(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	password := []byte("pwd")
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Type:     WalletTypeDeterministic,
+		Encrypt:  true,
+		Password: password,
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("u.wlt", Options{
+		Seed: "seed2",
+		Type: WalletTypeDeterministic,
+	}, nil)
+	require.NoError(t, err)
+
+	p, err := s.WalletApprovalPolicy("t.wlt")
+	require.NoError(t, err)
+	require.Nil(t, p)
+	_, err = s.WalletApprovalPolicy("x.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+	require.Equal(t, ErrWalletApprovalPolicyNotSet, s.VerifyWalletApproverPassword("t.wlt", []byte("approver")))
+	require.Equal(t, ErrWalletApprovalPolicyNotSet, s.RemoveWalletApprovalPolicy("t.wlt", password, []byte("approver")))
+
+	approver := []byte("approver")
+	window := time.Hour
+
+	// Setting a policy requires the password of an encrypted wallet and a distinct approver password
+	require.Equal(t, ErrMissingPassword, s.SetWalletApprovalPolicy("t.wlt", nil, approver, nil, 1e6, window))
+	require.Equal(t, ErrInvalidPassword, s.SetWalletApprovalPolicy("t.wlt", []byte("wrong"), approver, nil, 1e6, window))
+	require.Equal(t, ErrMissingApproverPassword, s.SetWalletApprovalPolicy("t.wlt", password, nil, nil, 1e6, window))
+	require.Equal(t, ErrApproverPasswordIsWalletPassword, s.SetWalletApprovalPolicy("t.wlt", password, password, nil, 1e6, window))
+	require.Equal(t, ErrInvalidApprovalWindow, s.SetWalletApprovalPolicy("t.wlt", password, approver, nil, 1e6, 0))
+
+	require.NoError(t, s.SetWalletApprovalPolicy("t.wlt", password, approver, nil, 1e6, window))
+
+	p, err = s.WalletApprovalPolicy("t.wlt")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	require.Equal(t, uint64(1e6), p.Threshold)
+	require.Equal(t, window, p.Window)
+	require.False(t, p.RequiresApproval(1e6))
+	require.True(t, p.RequiresApproval(1e6+1))
+
+	require.NoError(t, s.VerifyWalletApproverPassword("t.wlt", approver))
+	require.Equal(t, ErrInvalidApproverPassword, s.VerifyWalletApproverPassword("t.wlt", []byte("wrong")))
+	require.Equal(t, ErrMissingApproverPassword, s.VerifyWalletApproverPassword("t.wlt", nil))
+
+	// The policy is saved, and the wallet can still be unlocked
+	w, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.NoError(t, w.Validate())
+	require.Equal(t, p, w.ApprovalPolicy())
+	_, err = Unlock(w, password)
+	require.NoError(t, err)
+
+	// The policy survives unloading the wallet
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	require.NoError(t, s.VerifyWalletApproverPassword("t.wlt", approver))
+
+	// Replacing the policy requires the current approver password
+	newApprover := []byte("approver2")
+	require.Equal(t, ErrMissingApproverPassword, s.SetWalletApprovalPolicy("t.wlt", password, newApprover, nil, 2e6, window))
+	require.Equal(t, ErrInvalidApproverPassword, s.SetWalletApprovalPolicy("t.wlt", password, newApprover, []byte("wrong"), 2e6, window))
+	require.NoError(t, s.SetWalletApprovalPolicy("t.wlt", password, newApprover, approver, 2e6, window))
+	require.Equal(t, ErrInvalidApproverPassword, s.VerifyWalletApproverPassword("t.wlt", approver))
+	require.NoError(t, s.VerifyWalletApproverPassword("t.wlt", newApprover))
+
+	// Unencrypted wallets don't need a password
+	require.NoError(t, s.SetWalletApprovalPolicy("u.wlt", nil, approver, nil, 0, window))
+	require.NoError(t, s.VerifyWalletApproverPassword("u.wlt", approver))
+
+	// Removing the policy requires the wallet password and the approver password
+	require.Equal(t, ErrInvalidPassword, s.RemoveWalletApprovalPolicy("t.wlt", []byte("wrong"), newApprover))
+	require.Equal(t, ErrInvalidApproverPassword, s.RemoveWalletApprovalPolicy("t.wlt", password, approver))
+	require.NoError(t, s.RemoveWalletApprovalPolicy("t.wlt", password, newApprover))
+
+	p, err = s.WalletApprovalPolicy("t.wlt")
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	w, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Nil(t, w.ApprovalPolicy())
+}
+
+func TestMetaValidateApprovalPolicy(t *testing.T) {
+	m := Meta{
+		metaFilename: "t.wlt",
+		metaType:     WalletTypeCollection,
+		metaCoin:     string(CoinTypeSkycoin),
+	}
+	require.NoError(t, m.validate())
+
+	p, err := newApprovalPolicy(100, time.Minute, []byte("approver"), CryptoTypeSha256Xor)
+	require.NoError(t, err)
+	m.SetApprovalPolicy(p)
+	require.NoError(t, m.validate())
+	require.Equal(t, p, m.ApprovalPolicy())
+
+	m[metaApprovalWindow] = "-1m"
+	require.EqualError(t, m.validate(), "approvalWindow field is not a valid positive duration")
+
+	m[metaApprovalWindow] = "1m"
+	m[metaApprovalThreshold] = "x"
+	require.EqualError(t, m.validate(), "approvalThreshold field is not a valid uint64")
+
+	m.SetApprovalPolicy(nil)
+	require.NoError(t, m.validate())
+	require.Nil(t, m.ApprovalPolicy())
+
+	m[metaApprovalThreshold] = "100"
+	require.EqualError(t, m.validate(), "approvalThreshold field is set without approver field")
+}
@@ -0,0 +1,208 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BackupsDir is the name of the directory in the wallet dir where wallet backups are kept
+	BackupsDir = "backups"
+	// DefaultMaxBackups is the default number of backups kept for each wallet
+	DefaultMaxBackups = 10
+
+	walletFileExt = "." + WalletExt
+)
+
+var (
+	// ErrInvalidWalletID is returned if a wallet ID is not a plain wallet filename
+	ErrInvalidWalletID = NewError(errors.New("invalid wallet id"))
+	// ErrBackupNotExist is returned if a wallet backup does not exist
+	ErrBackupNotExist = NewError(errors.New("wallet backup doesn't exist"))
+)
+
+// Backup is a copy of a wallet file, made before the wallet file was overwritten
+type Backup struct {
+	WalletID string
+	Filename string
+	// Time is when the backup was made
+	Time time.Time
+	Size int64
+}
+
+// backupFilename returns the backup filename of a wallet for a given time,
+// in the form <wallet name>.<unix nanoseconds>.wlt
+func backupFilename(wltID string, t time.Time) string {
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(wltID, walletFileExt), t.UnixNano(), walletFileExt)
+}
+
+// parseBackupFilename returns the time of a backup file of a wallet.
+// Returns false if the file is not a backup of the wallet.
+func parseBackupFilename(wltID, filename string) (time.Time, bool) {
+	prefix := strings.TrimSuffix(wltID, walletFileExt) + "."
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, walletFileExt) {
+		return time.Time{}, false
+	}
+
+	ts := strings.TrimSuffix(strings.TrimPrefix(filename, prefix), walletFileExt)
+	if ts == "" || strings.TrimLeft(ts, "0123456789") != "" {
+		return time.Time{}, false
+	}
+
+	ns, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, ns), true
+}
+
+// validateWalletID checks that a wallet ID is a filename without any path components
+func validateWalletID(wltID string) error {
+	if wltID == "" || wltID == "." || wltID == ".." || filepath.Base(wltID) != wltID {
+		return ErrInvalidWalletID
+	}
+	return nil
+}
+
+// BackupWalletFile copies the wallet file wltID in dir to the backups directory in dir,
+// then removes the oldest backups of the wallet so that at most maxBackups are kept.
+// The file is copied as it is on disk, so an encrypted wallet's secrets stay encrypted in the backup.
+// Nothing is done if the wallet file does not exist or maxBackups is 0.
+func BackupWalletFile(dir, wltID string, maxBackups int) error {
+	if err := validateWalletID(wltID); err != nil {
+		return err
+	}
+
+	if maxBackups <= 0 {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, wltID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	backupsDir := filepath.Join(dir, BackupsDir)
+	if err := os.MkdirAll(backupsDir, os.FileMode(0700)); err != nil {
+		return err
+	}
+
+	// O_EXCL prevents overwriting an existing backup if two backups are made at the same time
+	t := time.Now()
+	var f *os.File
+	for {
+		f, err = os.OpenFile(filepath.Join(backupsDir, backupFilename(wltID, t)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		t = t.Add(time.Nanosecond)
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups(dir, wltID)
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > maxBackups {
+		if err := os.Remove(filepath.Join(backupsDir, backups[0].Filename)); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// ListBackups returns the backups of the wallet file wltID in dir, oldest first
+func ListBackups(dir, wltID string) ([]Backup, error) {
+	if err := validateWalletID(wltID); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, BackupsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, err
+	}
+
+	backups := []Backup{}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		t, ok := parseBackupFilename(wltID, f.Name())
+		if !ok {
+			continue
+		}
+
+		backups = append(backups, Backup{
+			WalletID: wltID,
+			Filename: f.Name(),
+			Time:     t,
+			Size:     f.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.Before(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup replaces the wallet file wltID in dir with one of its backups.
+// The backup must be a loadable wallet. The wallet file being replaced is backed up first,
+// so that the restore can be undone.
+func RestoreBackup(dir, wltID, filename string, maxBackups int) error {
+	if err := validateWalletID(wltID); err != nil {
+		return err
+	}
+
+	if _, ok := parseBackupFilename(wltID, filename); !ok || filepath.Base(filename) != filename {
+		return ErrBackupNotExist
+	}
+
+	backupPath := filepath.Join(dir, BackupsDir, filename)
+	b, err := ioutil.ReadFile(backupPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrBackupNotExist
+		}
+		return err
+	}
+
+	if _, err := Load(backupPath); err != nil {
+		return fmt.Errorf("backup %q is not a valid wallet: %v", filename, err)
+	}
+
+	if err := BackupWalletFile(dir, wltID, maxBackups); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, wltID), b, 0600)
+}
@@ -0,0 +1,224 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+)
+
+func TestBackupFilename(t *testing.T) {
+	ts := time.Unix(0, 1700000000123456789)
+	require.Equal(t, "foo.1700000000123456789.wlt", backupFilename("foo.wlt", ts))
+
+	cases := []struct {
+		wltID    string
+		filename string
+		ok       bool
+	}{
+		{"foo.wlt", "foo.1700000000123456789.wlt", true},
+		{"foo.wlt", "foo.wlt", false},
+		{"foo.wlt", "foo..wlt", false},
+		{"foo.wlt", "foo.bar.1700000000123456789.wlt", false},
+		{"foo.wlt", "foo.-1.wlt", false},
+		{"foo.wlt", "foo.1700000000123456789.wlt.bak", false},
+		{"foo.bar.wlt", "foo.1700000000123456789.wlt", false},
+		{"foo.bar.wlt", "foo.bar.1700000000123456789.wlt", true},
+	}
+
+	for _, tc := range cases {
+		parsed, ok := parseBackupFilename(tc.wltID, tc.filename)
+		require.Equal(t, tc.ok, ok, "%s %s", tc.wltID, tc.filename)
+		if ok {
+			require.True(t, ts.Equal(parsed))
+		}
+	}
+}
+
+func TestBackupWalletFile(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+
+	// Invalid wallet IDs are rejected
+	for _, wltID := range []string{"", ".", "..", "../foo.wlt", "backups/foo.wlt"} {
+		require.Equal(t, ErrInvalidWalletID, BackupWalletFile(dir, wltID, 3), wltID)
+		_, err := ListBackups(dir, wltID)
+		require.Equal(t, ErrInvalidWalletID, err, wltID)
+	}
+
+	// Nothing is backed up if the wallet file doesn't exist
+	require.NoError(t, BackupWalletFile(dir, "foo.wlt", 3))
+	_, err := os.Stat(filepath.Join(dir, BackupsDir))
+	require.True(t, os.IsNotExist(err))
+
+	backups, err := ListBackups(dir, "foo.wlt")
+	require.NoError(t, err)
+	require.Empty(t, backups)
+
+	writeWallet := func(wltID, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, wltID), []byte(content), 0600)
+		require.NoError(t, err)
+	}
+
+	writeWallet("foo.bar.wlt", "foo.bar")
+	require.NoError(t, BackupWalletFile(dir, "foo.bar.wlt", 3))
+
+	// Only the last maxBackups backups are kept
+	for _, content := range []string{"a", "bb", "ccc", "dddd", "eeeee"} {
+		writeWallet("foo.wlt", content)
+		require.NoError(t, BackupWalletFile(dir, "foo.wlt", 3))
+	}
+
+	backups, err = ListBackups(dir, "foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+
+	for i, content := range []string{"ccc", "dddd", "eeeee"} {
+		b := backups[i]
+		require.Equal(t, "foo.wlt", b.WalletID)
+		require.Equal(t, int64(len(content)), b.Size)
+		if i > 0 {
+			require.True(t, backups[i-1].Time.Before(b.Time))
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, BackupsDir, b.Filename))
+		require.NoError(t, err)
+		require.Equal(t, content, string(data))
+
+		fi, err := os.Stat(filepath.Join(dir, BackupsDir, b.Filename))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	// Backups of other wallets are not listed or rotated
+	backups, err = ListBackups(dir, "foo.bar.wlt")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	// maxBackups 0 disables backups
+	require.NoError(t, BackupWalletFile(dir, "foo.wlt", 0))
+	backups, err = ListBackups(dir, "foo.wlt")
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+}
+
+func TestServiceBackups(t *testing.T) {
+	for ct := range cryptoTable {
+		t.Run(string(ct), func(t *testing.T) {
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+
+			s, err := NewService(Config{
+				WalletDir:       dir,
+				CryptoType:      ct,
+				EnableWalletAPI: true,
+				MaxBackups:      3,
+			})
+			require.NoError(t, err)
+
+			seed := bip39.MustNewDefaultMnemonic()
+			password := []byte("pwd")
+			w, err := s.CreateWallet("t.wlt", Options{
+				Seed:     seed,
+				Label:    "label",
+				Type:     WalletTypeBip44,
+				Encrypt:  true,
+				Password: password,
+			}, nil)
+			require.NoError(t, err)
+
+			// Creating a wallet writes a new file, there is nothing to back up
+			backups, err := s.ListWalletBackups(w.Filename())
+			require.NoError(t, err)
+			require.Empty(t, backups)
+
+			// The metadata of encrypted wallets must be unlocked to update the label
+			err = s.UpdateWalletLabel(w.Filename(), "new-label")
+			require.Equal(t, ErrWalletMetadataLocked, err)
+			err = s.UnlockWalletMetadata(w.Filename(), password)
+			require.NoError(t, err)
+
+			err = s.UpdateWalletLabel(w.Filename(), "new-label")
+			require.NoError(t, err)
+
+			backups, err = s.ListWalletBackups(w.Filename())
+			require.NoError(t, err)
+			require.Len(t, backups, 1)
+
+			bw, err := Load(filepath.Join(dir, BackupsDir, backups[0].Filename))
+			require.NoError(t, err)
+			require.Equal(t, "label", bw.Label())
+
+			// Each generated address backs up the previous wallet file, keeping the last 3
+			for i := 0; i < 4; i++ {
+				_, err := s.NewAddresses(w.Filename(), password, 1)
+				require.NoError(t, err)
+			}
+
+			backups, err = s.ListWalletBackups(w.Filename())
+			require.NoError(t, err)
+			require.Len(t, backups, 3)
+
+			// The backups of an encrypted wallet are copies of the encrypted file, without decrypted secrets
+			for i, b := range backups {
+				path := filepath.Join(dir, BackupsDir, b.Filename)
+				data, err := ioutil.ReadFile(path)
+				require.NoError(t, err)
+				require.False(t, strings.Contains(string(data), seed))
+
+				bw, err := Load(path)
+				require.NoError(t, err)
+				require.True(t, bw.IsEncrypted())
+				checkNoSensitiveData(t, bw)
+				require.Equal(t, i+2, bw.EntriesLen())
+
+				for _, e := range bw.GetEntries() {
+					require.True(t, e.Secret.Null())
+				}
+			}
+
+			// Restore the oldest backup, the replaced wallet file is backed up
+			cur, err := ioutil.ReadFile(filepath.Join(dir, w.Filename()))
+			require.NoError(t, err)
+			oldest, err := ioutil.ReadFile(filepath.Join(dir, BackupsDir, backups[0].Filename))
+			require.NoError(t, err)
+
+			err = RestoreBackup(dir, w.Filename(), backups[0].Filename, 3)
+			require.NoError(t, err)
+
+			restored, err := ioutil.ReadFile(filepath.Join(dir, w.Filename()))
+			require.NoError(t, err)
+			require.Equal(t, oldest, restored)
+
+			backups, err = s.ListWalletBackups(w.Filename())
+			require.NoError(t, err)
+			require.Len(t, backups, 3)
+			latest, err := ioutil.ReadFile(filepath.Join(dir, BackupsDir, backups[2].Filename))
+			require.NoError(t, err)
+			require.Equal(t, cur, latest)
+
+			err = RestoreBackup(dir, w.Filename(), "t.1.wlt", 3)
+			require.Equal(t, ErrBackupNotExist, err)
+			err = RestoreBackup(dir, w.Filename(), "other.1.wlt", 3)
+			require.Equal(t, ErrBackupNotExist, err)
+
+			// Backups are disabled when MaxBackups is 0
+			s.config.MaxBackups = 0
+			err = s.UpdateWalletLabel(w.Filename(), "label-2")
+			require.NoError(t, err)
+			backups2, err := s.ListWalletBackups(w.Filename())
+			require.NoError(t, err)
+			require.Equal(t, backups, backups2)
+
+			s.config.EnableWalletAPI = false
+			_, err = s.ListWalletBackups(w.Filename())
+			require.Equal(t, ErrWalletAPIDisabled, err)
+		})
+	}
+}
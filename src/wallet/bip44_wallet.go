@@ -151,6 +151,12 @@ func (w *Bip44Wallet) HasEntry(a cipher.Address) bool {
 	return w.ExternalEntries.has(a) || w.ChangeEntries.has(a)
 }
 
+// SetEntryLabel sets the label of the entry with a given cipher.Address.
+// Returns false if the wallet has no such entry.
+func (w *Bip44Wallet) SetEntryLabel(a cipher.Address, label string) bool {
+	return w.ExternalEntries.setLabel(a, label) || w.ChangeEntries.setLabel(a, label)
+}
+
 // CoinHDNode return the "coin" level bip44 HDNode
 func (w *Bip44Wallet) CoinHDNode() (*bip44.Coin, error) {
 	// w.Meta.Seed() must return a valid bip39 mnemonic
@@ -171,6 +177,32 @@ func (w *Bip44Wallet) CoinHDNode() (*bip44.Coin, error) {
 	return c, nil
 }
 
+// AccountXPub derives the xpub of the wallet's bip44 account from its seed
+func (w *Bip44Wallet) AccountXPub() (string, error) {
+	c, err := w.CoinHDNode()
+	if err != nil {
+		return "", err
+	}
+
+	acct, err := c.Account(0)
+	if err != nil {
+		return "", err
+	}
+
+	return acct.PrivateKey.PublicKey().String(), nil
+}
+
+// storeAccountXPub derives the account xpub from the seed and records it in the wallet's metadata
+func (w *Bip44Wallet) storeAccountXPub() error {
+	xpub, err := w.AccountXPub()
+	if err != nil {
+		return err
+	}
+
+	w.Meta.setXPub(xpub)
+	return nil
+}
+
 // nextChildIdx returns the next child index from a sequence of entries.
 // This assumes that entries are sorted by child number ascending.
 func nextChildIdx(e Entries) uint32 {
@@ -279,6 +311,7 @@ func (w *Bip44Wallet) generateEntries(num uint64, changeIdx, initialChildIdx uin
 			Public:      pk,
 			ChildNumber: addressIndices[i],
 			Change:      changeIdx,
+			Path:        Bip44Path(w.Meta.Bip44Coin(), Bip44Account, changeIdx, addressIndices[i]),
 		}
 	}
 
@@ -353,6 +386,7 @@ func (w *Bip44Wallet) ScanAddresses(scanN uint64, tf TransactionsFinder) error {
 
 	w2 := w.Clone().(*Bip44Wallet)
 
+	observeScanChain(tf, bip44.ExternalChainIndex, nextChildIdx(w2.ExternalEntries))
 	externalEntries, err := scanAddressesBip32(func(num uint64, childIdx uint32) (Entries, error) {
 		return w.generateEntries(num, bip44.ExternalChainIndex, childIdx)
 	}, scanN, tf, nextChildIdx(w2.ExternalEntries))
@@ -360,6 +394,7 @@ func (w *Bip44Wallet) ScanAddresses(scanN uint64, tf TransactionsFinder) error {
 		return err
 	}
 
+	observeScanChain(tf, bip44.ChangeChainIndex, nextChildIdx(w2.ChangeEntries))
 	changeEntries, err := scanAddressesBip32(func(num uint64, childIdx uint32) (Entries, error) {
 		return w.generateEntries(num, bip44.ChangeChainIndex, childIdx)
 	}, scanN, tf, nextChildIdx(w2.ChangeEntries))
@@ -513,6 +548,13 @@ func (rw *ReadableBip44Wallet) ToWallet() (Wallet, error) {
 	// Split the single array of entries into separate external and change chains,
 	// for easier internal management
 	for _, e := range ets {
+		// A recorded path must be the path of the entry's change and child numbers
+		if e.Path != "" {
+			if path := Bip44Path(w.Meta.Bip44Coin(), Bip44Account, e.Change, e.ChildNumber); e.Path != path {
+				return nil, fmt.Errorf("path %s of address %s is not its bip44 path %s", e.Path, e.Address, path)
+			}
+		}
+
 		switch e.Change {
 		case bip44.ExternalChainIndex:
 			w.ExternalEntries = append(w.ExternalEntries, e)
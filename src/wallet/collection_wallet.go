@@ -30,8 +30,11 @@ func (w *CollectionWallet) PackSecrets(ss Secrets) {
 	ss.set(secretSeed, w.Meta.Seed())
 	ss.set(secretLastSeed, w.Meta.LastSeed())
 
-	// Saves entry secret keys in secrets
+	// Saves entry secret keys in secrets, except for the secret keys encrypted with their own password
 	for _, e := range w.Entries {
+		if e.IsSecretEncrypted() {
+			continue
+		}
 		ss.set(e.Address.String(), e.Secret.Hex())
 	}
 }
@@ -101,6 +104,12 @@ func (w *CollectionWallet) HasEntry(a cipher.Address) bool {
 	return w.Entries.has(a)
 }
 
+// SetEntryLabel sets the label of the entry with a given cipher.Address.
+// Returns false if the wallet has no such entry.
+func (w *CollectionWallet) SetEntryLabel(a cipher.Address, label string) bool {
+	return w.Entries.setLabel(a, label)
+}
+
 // GenerateAddresses is a no-op for "collection" wallets
 func (w *CollectionWallet) GenerateAddresses(num uint64) ([]cipher.Addresser, error) {
 	return nil, NewError(errors.New("A collection wallet does not implement GenerateAddresses"))
@@ -156,6 +165,37 @@ func (w *CollectionWallet) AddEntry(e Entry) error {
 	return nil
 }
 
+// AddWatchAddress adds a watch-only entry for an address, without keys.
+// The wallet tracks the address but can't sign for its outputs.
+// Watch-only entries hold no secrets, so they can be added to encrypted wallets.
+func (w *CollectionWallet) AddWatchAddress(a cipher.Addresser) error {
+	if a.Null() {
+		return errors.New("address is null")
+	}
+
+	switch w.Meta.Coin() {
+	case CoinTypeSkycoin:
+		if _, ok := a.(cipher.Address); !ok {
+			return fmt.Errorf("address %s is not a %s address", a, CoinTypeSkycoin)
+		}
+	case CoinTypeBitcoin:
+		if _, ok := a.(cipher.BitcoinAddress); !ok {
+			return fmt.Errorf("address %s is not a %s address", a, CoinTypeBitcoin)
+		}
+	}
+
+	for _, entry := range w.Entries {
+		if entry.Address.String() == a.String() {
+			return errors.New("wallet already contains entry with this address")
+		}
+	}
+
+	w.Entries = append(w.Entries, Entry{
+		Address: a,
+	})
+	return nil
+}
+
 // ReadableCollectionWallet used for [de]serialization of a collection wallet
 type ReadableCollectionWallet struct {
 	Meta            `json:"meta"`
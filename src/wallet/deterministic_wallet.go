@@ -127,6 +127,12 @@ func (w *DeterministicWallet) HasEntry(a cipher.Address) bool {
 	return w.Entries.has(a)
 }
 
+// SetEntryLabel sets the label of the entry with a given cipher.Address.
+// Returns false if the wallet has no such entry.
+func (w *DeterministicWallet) SetEntryLabel(a cipher.Address, label string) bool {
+	return w.Entries.setLabel(a, label)
+}
+
 // GenerateAddresses generates addresses
 func (w *DeterministicWallet) GenerateAddresses(num uint64) ([]cipher.Addresser, error) {
 	if w.Meta.IsEncrypted() {
@@ -15,6 +15,13 @@ type Entry struct {
 	Secret      cipher.SecKey
 	ChildNumber uint32 // For bip32/bip44
 	Change      uint32 // For bip44
+	Path        string // For bip44, the derivation path, see Bip44Path
+	Label       string // User defined address label
+
+	// EncryptedSecret is the secret key encrypted with the entry's own password
+	// [collection wallets]. Secret is null while it is set, see EncryptEntry.
+	EncryptedSecret  string
+	SecretCryptoType CryptoType
 }
 
 // SkycoinAddress returns the Skycoin address of an entry. Panics if Address is not a Skycoin address
@@ -27,6 +34,17 @@ func (we Entry) BitcoinAddress() cipher.BitcoinAddress {
 	return we.Address.(cipher.BitcoinAddress)
 }
 
+// IsWatchOnly returns true if the entry has an address but no keys.
+// Watch-only entries track an address but can't sign for it.
+func (we Entry) IsWatchOnly() bool {
+	return we.Public.Null() && we.Secret.Null()
+}
+
+// IsSecretEncrypted returns true if the entry's secret key is encrypted with its own password
+func (we Entry) IsSecretEncrypted() bool {
+	return we.EncryptedSecret != ""
+}
+
 // Verify checks that the public key is derivable from the secret key,
 // and that the public key is associated with the address
 func (we *Entry) Verify() error {
@@ -80,6 +98,16 @@ func (entries Entries) get(a cipher.Address) (Entry, bool) {
 	return Entry{}, false
 }
 
+func (entries Entries) setLabel(a cipher.Address, label string) bool {
+	for i, e := range entries {
+		if e.SkycoinAddress() == a {
+			entries[i].Label = label
+			return true
+		}
+	}
+	return false
+}
+
 func (entries Entries) getSkycoinAddresses() []cipher.Address {
 	addrs := make([]cipher.Address, len(entries))
 	for i, e := range entries {
@@ -106,9 +134,24 @@ func (entries Entries) erase() {
 	}
 }
 
+func (entries Entries) hasEncryptedSecrets() bool {
+	for _, e := range entries {
+		if e.IsSecretEncrypted() {
+			return true
+		}
+	}
+	return false
+}
+
 // unpackSecretKeys for each entry, look for the secret key in the Secrets dict, keyed by address
 func (entries Entries) unpackSecretKeys(ss Secrets) error {
 	for i, e := range entries {
+		// Watch-only entries have no secret key, and the secret keys of
+		// entries encrypted with their own password are not in the secrets
+		if e.Public.Null() || e.IsSecretEncrypted() {
+			continue
+		}
+
 		sstr, ok := ss.get(e.Address.String())
 		if !ok {
 			return fmt.Errorf("secret of address %s doesn't exist in secrets", e.Address)
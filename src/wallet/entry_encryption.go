@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+/*
+The secret key of a collection wallet entry can be encrypted with its own password, so that one
+collection wallet can hold the keys of several owners. The encrypted secret key is stored in the
+entry rather than in the wallet secrets, so the wallet password, if any, does not protect it, and
+it is not needed to sign for the entry. Signing for the entry requires the entry's password instead,
+see UnlockEntries.
+*/
+
+var (
+	// ErrEntryEncryptionNotSupported is returned when encrypting an entry of a wallet that is not a collection wallet
+	ErrEntryEncryptionNotSupported = NewError(fmt.Errorf("only %q wallet entries can be encrypted with their own password", WalletTypeCollection))
+	// ErrEntryEncrypted is returned when encrypting an entry that is already encrypted with its own password
+	ErrEntryEncrypted = NewError(errors.New("address is encrypted"))
+	// ErrEntryNotEncrypted is returned when decrypting an entry that is not encrypted with its own password
+	ErrEntryNotEncrypted = NewError(errors.New("address is not encrypted"))
+	// ErrEntryWatchOnly is returned when encrypting a watch-only entry
+	ErrEntryWatchOnly = NewError(errors.New("address is watch-only"))
+)
+
+// AddressPasswordError is the error of an entry encrypted with its own password, naming the entry's address
+type AddressPasswordError struct {
+	Address cipher.Addresser
+	// Err is ErrMissingPassword, ErrInvalidPassword, ErrEntryEncrypted or ErrEntryNotEncrypted
+	Err error
+}
+
+func (e AddressPasswordError) Error() string {
+	return fmt.Sprintf("address %s: %v", e.Address, e.Err)
+}
+
+func newAddressPasswordError(a cipher.Addresser, err error) error {
+	return NewError(AddressPasswordError{
+		Address: a,
+		Err:     err,
+	})
+}
+
+// entryIndex returns the index of the entry of address a in a collection wallet
+func entryIndex(w Wallet, a cipher.Address) (*CollectionWallet, int, error) {
+	cw, ok := w.(*CollectionWallet)
+	if !ok {
+		return nil, 0, ErrEntryEncryptionNotSupported
+	}
+
+	for i, e := range cw.Entries {
+		if e.Address.String() == a.String() {
+			return cw, i, nil
+		}
+	}
+
+	return nil, 0, ErrUnknownAddress
+}
+
+// EncryptEntry encrypts the secret key of the entry of address a with its own password.
+// The wallet must be decrypted. If the wallet is encrypted, use GuardUpdate, then the
+// secret key is no longer part of the wallet secrets once the wallet is locked again.
+func EncryptEntry(w Wallet, a cipher.Address, password []byte, cryptoType CryptoType) error {
+	if len(password) == 0 {
+		return newAddressPasswordError(a, ErrMissingPassword)
+	}
+
+	cw, i, err := entryIndex(w, a)
+	if err != nil {
+		return err
+	}
+
+	e := &cw.Entries[i]
+	if e.IsSecretEncrypted() {
+		return newAddressPasswordError(a, ErrEntryEncrypted)
+	}
+	if e.IsWatchOnly() {
+		return ErrEntryWatchOnly
+	}
+	if w.IsEncrypted() || e.Secret.Null() {
+		return ErrWalletEncrypted
+	}
+
+	crypto, err := getCrypto(cryptoType)
+	if err != nil {
+		return err
+	}
+
+	secret := []byte(e.Secret.Hex())
+	defer wipeBytes(secret)
+
+	encSecret, err := crypto.Encrypt(secret, password)
+	if err != nil {
+		return err
+	}
+
+	e.EncryptedSecret = string(encSecret)
+	e.SecretCryptoType = cryptoType
+	wipeBytes(e.Secret[:])
+	return nil
+}
+
+// DecryptEntry removes the encryption of the secret key of the entry of address a with its own password.
+// The wallet must be decrypted, the secret key becomes part of the wallet secrets when it is locked again.
+func DecryptEntry(w Wallet, a cipher.Address, password []byte) error {
+	cw, i, err := entryIndex(w, a)
+	if err != nil {
+		return err
+	}
+
+	e := &cw.Entries[i]
+	if !e.IsSecretEncrypted() {
+		return newAddressPasswordError(a, ErrEntryNotEncrypted)
+	}
+	if w.IsEncrypted() {
+		return ErrWalletEncrypted
+	}
+
+	secret, err := decryptEntrySecret(*e, password)
+	if err != nil {
+		return err
+	}
+
+	e.Secret = secret
+	e.EncryptedSecret = ""
+	e.SecretCryptoType = ""
+	return nil
+}
+
+// UnlockEntries decrypts the secret keys of the entries of the addresses in passwords
+// into a temporary copy of the wallet, which can sign for these entries.
+// The wallet itself may stay encrypted, the copy can sign for the decrypted entries only.
+// The copy should be erased from memory when done.
+func UnlockEntries(w Wallet, passwords map[cipher.Address][]byte) (Wallet, error) {
+	wlt := w.Clone()
+	for a, password := range passwords {
+		cw, i, err := entryIndex(wlt, a)
+		if err != nil {
+			wlt.Erase()
+			return nil, err
+		}
+
+		e := &cw.Entries[i]
+		if !e.IsSecretEncrypted() {
+			wlt.Erase()
+			return nil, newAddressPasswordError(a, ErrEntryNotEncrypted)
+		}
+
+		secret, err := decryptEntrySecret(*e, password)
+		if err != nil {
+			wlt.Erase()
+			return nil, err
+		}
+
+		e.Secret = secret
+	}
+
+	return wlt, nil
+}
+
+// decryptEntrySecret decrypts the secret key of an entry encrypted with its own password,
+// and checks that it matches the entry's public key
+func decryptEntrySecret(e Entry, password []byte) (cipher.SecKey, error) {
+	if len(password) == 0 {
+		return cipher.SecKey{}, newAddressPasswordError(e.Address, ErrMissingPassword)
+	}
+
+	crypto, err := getCrypto(e.SecretCryptoType)
+	if err != nil {
+		return cipher.SecKey{}, err
+	}
+
+	b, err := crypto.Decrypt([]byte(e.EncryptedSecret), password)
+	if err != nil {
+		return cipher.SecKey{}, newAddressPasswordError(e.Address, ErrInvalidPassword)
+	}
+	defer wipeBytes(b)
+
+	secret, err := cipher.SecKeyFromHex(string(b))
+	if err != nil {
+		return cipher.SecKey{}, newAddressPasswordError(e.Address, ErrInvalidPassword)
+	}
+
+	e.Secret = secret
+	if err := e.Verify(); err != nil {
+		return cipher.SecKey{}, fmt.Errorf("address %s: decrypted secret key does not match the entry: %v", e.Address, err)
+	}
+
+	return secret, nil
+}
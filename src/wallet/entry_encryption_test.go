@@ -0,0 +1,270 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+func TestEncryptEntry(t *testing.T) {
+	ct := CryptoTypeScryptChacha20poly1305Insecure
+
+	w, err := NewWallet("t.wlt", Options{
+		Type: WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	e1 := makeEntry()
+	e2 := makeEntry()
+	require.NoError(t, w.(*CollectionWallet).AddEntry(e1))
+	require.NoError(t, w.(*CollectionWallet).AddEntry(e2))
+	watch := makeAddress()
+	require.NoError(t, w.(*CollectionWallet).AddWatchAddress(watch))
+
+	a1 := e1.SkycoinAddress()
+	a2 := e2.SkycoinAddress()
+
+	require.Equal(t, ErrUnknownAddress, EncryptEntry(w, makeAddress(), []byte("pwd1"), ct))
+	require.Equal(t, ErrEntryWatchOnly, EncryptEntry(w, watch, []byte("pwd1"), ct))
+	require.Equal(t, newAddressPasswordError(a1, ErrMissingPassword), EncryptEntry(w, a1, nil, ct))
+
+	require.NoError(t, EncryptEntry(w, a1, []byte("pwd1"), ct))
+	require.NoError(t, EncryptEntry(w, a2, []byte("pwd2"), ct))
+	require.Equal(t, newAddressPasswordError(a1, ErrEntryEncrypted), EncryptEntry(w, a1, []byte("pwd1"), ct))
+
+	e, ok := w.GetEntry(a1)
+	require.True(t, ok)
+	require.True(t, e.Secret.Null())
+	require.True(t, e.IsSecretEncrypted())
+	require.Equal(t, ct, e.SecretCryptoType)
+	require.Equal(t, e1.Public, e.Public)
+
+	// The encrypted secret keys survive a save and load
+	dir := prepareWltDir()
+	require.NoError(t, Save(w, dir))
+	w, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+
+	// Wrong or missing passwords name the address
+	_, err = UnlockEntries(w, map[cipher.Address][]byte{
+		a1: []byte("pwd1"),
+		a2: []byte("pwd1"),
+	})
+	require.Equal(t, newAddressPasswordError(a2, ErrInvalidPassword), err)
+	require.EqualError(t, err, "address "+a2.String()+": invalid password")
+
+	_, err = UnlockEntries(w, map[cipher.Address][]byte{
+		a1: nil,
+	})
+	require.Equal(t, newAddressPasswordError(a1, ErrMissingPassword), err)
+
+	_, err = UnlockEntries(w, map[cipher.Address][]byte{
+		watch: []byte("pwd"),
+	})
+	require.Equal(t, newAddressPasswordError(watch, ErrEntryNotEncrypted), err)
+
+	// Only the unlocked entries are decrypted
+	wlt, err := UnlockEntries(w, map[cipher.Address][]byte{
+		a1: []byte("pwd1"),
+	})
+	require.NoError(t, err)
+	e, ok = wlt.GetEntry(a1)
+	require.True(t, ok)
+	require.Equal(t, e1.Secret, e.Secret)
+	e, ok = wlt.GetEntry(a2)
+	require.True(t, ok)
+	require.True(t, e.Secret.Null())
+
+	// The wallet itself is not modified
+	e, ok = w.GetEntry(a1)
+	require.True(t, ok)
+	require.True(t, e.Secret.Null())
+
+	// Wallet encryption does not include the secret keys encrypted with their own password
+	require.NoError(t, Lock(w, []byte("wpwd"), ct))
+	require.NoError(t, GuardView(w, []byte("wpwd"), func(w Wallet) error {
+		e, ok := w.GetEntry(a1)
+		require.True(t, ok)
+		require.True(t, e.Secret.Null())
+		return nil
+	}))
+
+	// The entry is decrypted within the encrypted wallet
+	require.Equal(t, newAddressPasswordError(a1, ErrInvalidPassword), GuardUpdate(w, []byte("wpwd"), func(w Wallet) error {
+		return DecryptEntry(w, a1, []byte("pwd2"))
+	}))
+	require.NoError(t, GuardUpdate(w, []byte("wpwd"), func(w Wallet) error {
+		return DecryptEntry(w, a1, []byte("pwd1"))
+	}))
+
+	e, ok = w.GetEntry(a1)
+	require.True(t, ok)
+	require.False(t, e.IsSecretEncrypted())
+
+	unlocked, err := Unlock(w, []byte("wpwd"))
+	require.NoError(t, err)
+	e, ok = unlocked.GetEntry(a1)
+	require.True(t, ok)
+	require.Equal(t, e1.Secret, e.Secret)
+
+	// Only collection wallet entries can be encrypted
+	dw, err := NewWallet("t2.wlt", Options{
+		Type:      WalletTypeDeterministic,
+		Seed:      "seed",
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+	addrs, err := dw.GetSkycoinAddresses()
+	require.NoError(t, err)
+	require.Equal(t, ErrEntryEncryptionNotSupported, EncryptEntry(dw, addrs[0], []byte("pwd"), ct))
+}
+
+func TestSignTransactionEncryptedEntries(t *testing.T) {
+	ct := CryptoTypeScryptChacha20poly1305Insecure
+
+	txn, uxs, seckeys := makeTransaction(t, 2)
+	txn.Sigs = make([]cipher.Sig, len(txn.Sigs))
+
+	w := &CollectionWallet{
+		Meta: Meta{
+			metaFilename: "t.wlt",
+			metaType:     WalletTypeCollection,
+			metaCoin:     string(CoinTypeSkycoin),
+		},
+	}
+	addrs := make([]cipher.Address, len(seckeys))
+	for i, s := range seckeys {
+		p := cipher.MustPubKeyFromSecKey(s)
+		addrs[i] = cipher.AddressFromPubKey(p)
+		require.NoError(t, w.AddEntry(Entry{
+			Address: addrs[i],
+			Public:  p,
+			Secret:  s,
+		}))
+	}
+
+	require.NoError(t, EncryptEntry(w, addrs[0], []byte("pwd0"), ct))
+	require.NoError(t, Lock(w, []byte("wpwd"), ct))
+
+	// The wallet password can sign for the entries that are not encrypted with their own password
+	err := GuardView(w, []byte("wpwd"), func(w Wallet) error {
+		_, err := SignTransaction(w, &txn, nil, uxs)
+		require.Equal(t, newAddressPasswordError(addrs[0], ErrEntryEncrypted), err)
+
+		signedTxn, err := SignTransaction(w, &txn, []int{1}, uxs)
+		require.NoError(t, err)
+		require.False(t, signedTxn.Sigs[1].Null())
+		require.True(t, signedTxn.Sigs[0].Null())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The entry password signs for the entry without the wallet password
+	wlt, err := UnlockEntries(w, map[cipher.Address][]byte{
+		addrs[0]: []byte("pwd0"),
+	})
+	require.NoError(t, err)
+
+	signedTxn, err := SignTransaction(wlt, &txn, []int{0}, uxs)
+	require.NoError(t, err)
+	require.False(t, signedTxn.Sigs[0].Null())
+	require.True(t, signedTxn.Sigs[1].Null())
+
+	_, err = SignTransaction(wlt, &txn, nil, uxs)
+	require.Equal(t, ErrWalletEncrypted, err)
+
+	// Wallets without entries encrypted with their own password can't sign while encrypted
+	w2 := &CollectionWallet{
+		Meta: Meta{
+			metaFilename: "t2.wlt",
+			metaType:     WalletTypeCollection,
+			metaCoin:     string(CoinTypeSkycoin),
+		},
+	}
+	require.NoError(t, w2.AddEntry(makeEntry()))
+	require.NoError(t, Lock(w2, []byte("wpwd"), ct))
+	_, err = SignTransaction(w2, &txn, nil, []coin.UxOut{uxs[0], uxs[1]})
+	require.Equal(t, ErrWalletEncrypted, err)
+}
+
+func TestServiceSignTransactionWithAddressPasswords(t *testing.T) {
+	ct := CryptoTypeScryptChacha20poly1305Insecure
+
+	s, err := NewService(Config{
+		WalletDir:       prepareWltDir(),
+		CryptoType:      ct,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	txn, uxs, seckeys := makeTransaction(t, 2)
+	txn.Sigs = make([]cipher.Sig, len(txn.Sigs))
+
+	_, err = s.CreateWallet("t.wlt", Options{
+		Type:     WalletTypeCollection,
+		Encrypt:  true,
+		Password: []byte("wpwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	addrs := make([]cipher.Address, len(seckeys))
+	require.NoError(t, s.UpdateSecrets("t.wlt", []byte("wpwd"), func(w Wallet) error {
+		for i, sk := range seckeys {
+			p := cipher.MustPubKeyFromSecKey(sk)
+			addrs[i] = cipher.AddressFromPubKey(p)
+			if err := w.(*CollectionWallet).AddEntry(Entry{
+				Address: addrs[i],
+				Public:  p,
+				Secret:  sk,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	require.Equal(t, ErrMissingPassword, s.EncryptWalletAddress("t.wlt", nil, addrs[0], []byte("pwd0")))
+	require.NoError(t, s.EncryptWalletAddress("t.wlt", []byte("wpwd"), addrs[0], []byte("pwd0")))
+	require.NoError(t, s.EncryptWalletAddress("t.wlt", []byte("wpwd"), addrs[1], []byte("pwd1")))
+
+	// The encrypted entries are saved
+	w, err := Load(filepath.Join(s.config.WalletDir, "t.wlt"))
+	require.NoError(t, err)
+	require.True(t, w.GetEntryAt(0).IsSecretEncrypted())
+	require.True(t, w.GetEntryAt(1).IsSecretEncrypted())
+
+	_, err = s.SignTransactionWithAddressPasswords("t.wlt", nil, map[cipher.Address][]byte{
+		addrs[0]: []byte("pwd0"),
+		addrs[1]: []byte("pwd0"),
+	}, &txn, nil, uxs)
+	require.Equal(t, newAddressPasswordError(addrs[1], ErrInvalidPassword), err)
+
+	_, err = s.SignTransactionWithAddressPasswords("t.wlt", nil, map[cipher.Address][]byte{
+		addrs[0]: []byte("pwd0"),
+	}, &txn, nil, uxs)
+	require.Equal(t, newAddressPasswordError(addrs[1], ErrEntryEncrypted), err)
+
+	// The wallet password is not needed
+	signedTxn, err := s.SignTransactionWithAddressPasswords("t.wlt", nil, map[cipher.Address][]byte{
+		addrs[0]: []byte("pwd0"),
+		addrs[1]: []byte("pwd1"),
+	}, &txn, nil, uxs)
+	require.NoError(t, err)
+	require.NoError(t, signedTxn.VerifyInputSignatures(uxs))
+
+	_, err = s.SignTransactionWithAddressPasswords("t.wlt", []byte("wrong"), map[cipher.Address][]byte{
+		addrs[0]: []byte("pwd0"),
+	}, &txn, []int{0}, uxs)
+	require.Equal(t, ErrInvalidPassword, err)
+
+	require.NoError(t, s.DecryptWalletAddress("t.wlt", []byte("wpwd"), addrs[1], []byte("pwd1")))
+	signedTxn, err = s.SignTransactionWithAddressPasswords("t.wlt", []byte("wpwd"), map[cipher.Address][]byte{
+		addrs[0]: []byte("pwd0"),
+	}, &txn, nil, uxs)
+	require.NoError(t, err)
+	require.NoError(t, signedTxn.VerifyInputSignatures(uxs))
+}
@@ -0,0 +1,282 @@
+package wallet
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+The wallet file format changed several times without changing how wallet files are read: encrypted wallets
+got a metadata key (version 0.5), bip44 wallets got their account xpub and the bip44 paths of their entries.
+Wallet files written before a change are upgraded when the data they miss is needed, or when they are loaded.
+
+WalletFormatStatus lists the migrations each wallet file needs, and MigrateWalletFormat runs them explicitly,
+backing up the wallet file first. With Config.ExplicitFormatMigrations, MigrateWalletFormat is the only way
+wallet files are upgraded.
+*/
+
+// WalletMigration is an upgrade of a wallet file to the current wallet format
+type WalletMigration string
+
+const (
+	// MigrationMetadataKey adds a metadata key to an encrypted wallet from before version 0.5
+	MigrationMetadataKey WalletMigration = "metadata-key"
+	// MigrationAccountXPub stores the account xpub of a bip44 wallet created before it was stored
+	MigrationAccountXPub WalletMigration = "account-xpub"
+	// MigrationEntryPaths records the bip44 paths of the entries of a bip44 wallet, see MigrateEntryPaths
+	MigrationEntryPaths WalletMigration = "entry-paths"
+	// MigrationVersion sets the version of the wallet file to Version
+	MigrationVersion WalletMigration = "version"
+)
+
+// ErrWalletMigrationNeedsPassword is returned by MigrateWalletFormat for an encrypted wallet
+// with migrations that need its seed, if the password is missing
+var ErrWalletMigrationNeedsPassword = NewError(errors.New("the migrations of the encrypted wallet need the wallet password"))
+
+// WalletFormatMigration is a migration needed by a wallet file
+type WalletFormatMigration struct {
+	Migration WalletMigration
+	// NeedsUnlock is true if the wallet is encrypted and the migration needs its seed, so its password
+	NeedsUnlock bool
+}
+
+// WalletFormatStatus is the format of a wallet file and the migrations it needs
+type WalletFormatStatus struct {
+	WalletID  string
+	Version   string
+	Encrypted bool
+	// Migrations are the migrations MigrateWalletFormat would run, in order
+	Migrations []WalletFormatMigration
+	// Error is why the wallet file could not be read, its version and migrations are unknown
+	Error string
+}
+
+// NeedsUnlock returns true if a migration of the wallet needs its password
+func (s WalletFormatStatus) NeedsUnlock() bool {
+	for _, m := range s.Migrations {
+		if m.NeedsUnlock {
+			return true
+		}
+	}
+	return false
+}
+
+// WalletFormatMigrationResult is the result of MigrateWalletFormat
+type WalletFormatMigrationResult struct {
+	WalletID string
+	// Version is the version of the wallet file after the migrations
+	Version string
+	// Migrated are the migrations that were run, empty if the wallet file needed none
+	Migrated []WalletMigration
+	// Backup is the filename of the backup of the wallet file made before the migrations,
+	// empty if no migration was run or backups are disabled
+	Backup string
+}
+
+// walletFormatMigrations returns the migrations the wallet needs, in the order they are run
+func walletFormatMigrations(w Wallet) []WalletFormatMigration {
+	var ms []WalletFormatMigration
+	add := func(m WalletMigration, needsSeed bool) {
+		ms = append(ms, WalletFormatMigration{
+			Migration:   m,
+			NeedsUnlock: needsSeed && w.IsEncrypted(),
+		})
+	}
+
+	if w.IsEncrypted() && w.MetadataKey() == "" {
+		add(MigrationMetadataKey, true)
+	}
+	if needsAccountXPub(w) {
+		add(MigrationAccountXPub, true)
+	}
+	if needsEntryPaths(w) {
+		add(MigrationEntryPaths, true)
+	}
+	if olderVersion(w.Version(), Version) {
+		add(MigrationVersion, false)
+	}
+
+	return ms
+}
+
+// olderVersion returns true if the wallet version a is older than b.
+// Versions are compared by their dot separated numbers, an unparsable version is older than any other.
+func olderVersion(a, b string) bool {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		var err error
+		if i < len(pa) {
+			if na, err = strconv.Atoi(pa[i]); err != nil {
+				return true
+			}
+		}
+		if i < len(pb) {
+			if nb, err = strconv.Atoi(pb[i]); err != nil {
+				return false
+			}
+		}
+		if na != nb {
+			return na < nb
+		}
+	}
+	return false
+}
+
+// diskWallet returns a clone of the loaded wallet, or else the wallet read from its file without loading it,
+// so that reading the format of a wallet does not run the migrations made on load.
+// Caller must hold the read lock or the lock.
+func (serv *Service) diskWallet(wltID string) (Wallet, bool, error) {
+	serv.loadLock.Lock()
+	defer serv.loadLock.Unlock()
+
+	if w := serv.wallets.get(wltID); w != nil {
+		return w.Clone(), true, nil
+	}
+
+	if _, ok := serv.headers[wltID]; !ok {
+		return nil, false, ErrWalletNotExist
+	}
+
+	w, err := Load(filepath.Join(serv.config.WalletDir, wltID))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	return w, false, nil
+}
+
+// WalletFormatStatus returns the format of all wallet files and the migrations they need, sorted by wallet ID.
+// A wallet file that can't be read is listed with its error.
+func (serv *Service) WalletFormatStatus() ([]WalletFormatStatus, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	ss := make([]WalletFormatStatus, 0, len(serv.headers))
+	for wltID := range serv.headers {
+		s := WalletFormatStatus{
+			WalletID: wltID,
+		}
+
+		w, _, err := serv.diskWallet(wltID)
+		if err != nil {
+			s.Error = err.Error()
+		} else {
+			s.Version = w.Version()
+			s.Encrypted = w.IsEncrypted()
+			s.Migrations = walletFormatMigrations(w)
+			eraseWallet(w)
+		}
+
+		ss = append(ss, s)
+	}
+
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].WalletID < ss[j].WalletID
+	})
+
+	return ss, nil
+}
+
+// MigrateWalletFormat runs the migrations the wallet file needs, see WalletFormatStatus, backing it up first.
+// The migrations of an encrypted wallet that need its seed need the password, and
+// ErrWalletMigrationNeedsPassword is returned without migrating the wallet if it is missing.
+// Entries whose address is not derived from the seed at their bip44 path are left without a path,
+// so the entry-paths migration stays listed for such a wallet.
+func (serv *Service) MigrateWalletFormat(wltID string, password []byte) (*WalletFormatMigrationResult, error) {
+	defer serv.use(wltID)()
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, loaded, err := serv.diskWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+	defer eraseWallet(w)
+
+	migrations := walletFormatMigrations(w)
+	r := &WalletFormatMigrationResult{
+		WalletID: wltID,
+		Version:  w.Version(),
+		Migrated: []WalletMigration{},
+	}
+	if len(migrations) == 0 {
+		return r, nil
+	}
+
+	needsUnlock := false
+	for _, m := range migrations {
+		needsUnlock = needsUnlock || m.NeedsUnlock
+	}
+
+	switch {
+	case needsUnlock:
+		if len(password) == 0 {
+			return nil, ErrWalletMigrationNeedsPassword
+		}
+
+		// Relocking the wallet adds a metadata key, stores the account xpub and entry paths and sets the version
+		if err := GuardUpdate(w, password, func(Wallet) error { return nil }); err != nil {
+			return nil, err
+		}
+	case w.IsEncrypted():
+		// Only the version needs to change
+		w.SetVersion(Version)
+	default:
+		if needsAccountXPub(w) {
+			if err := w.(*Bip44Wallet).storeAccountXPub(); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := migrateEntryPaths(w, w); err != nil {
+			return nil, err
+		}
+
+		w.SetVersion(Version)
+	}
+
+	if err := serv.save(w); err != nil {
+		return nil, err
+	}
+
+	if loaded {
+		serv.setWallet(w.Clone())
+	} else {
+		serv.headers[wltID] = headerMeta(walletMeta(w))
+	}
+
+	for _, m := range migrations {
+		r.Migrated = append(r.Migrated, m.Migration)
+	}
+	r.Version = w.Version()
+
+	if backups, err := ListBackups(serv.config.WalletDir, wltID); err != nil {
+		logger.WithError(err).WithField("wallet", wltID).Error("MigrateWalletFormat: failed to list the wallet backups")
+	} else if serv.config.MaxBackups > 0 && len(backups) > 0 {
+		r.Backup = backups[len(backups)-1].Filename
+	}
+
+	logger.WithFields(logrus.Fields{
+		"wallet":     wltID,
+		"migrations": r.Migrated,
+		"version":    r.Version,
+	}).Info("Migrated the wallet file format")
+
+	return r, nil
+}
@@ -0,0 +1,153 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+)
+
+func TestOlderVersion(t *testing.T) {
+	require.True(t, olderVersion("0.1", "0.5"))
+	require.True(t, olderVersion("0.4", "0.5"))
+	require.True(t, olderVersion("0.5", "0.10"))
+	require.True(t, olderVersion("", "0.5"))
+	require.True(t, olderVersion("abc", "0.5"))
+	require.False(t, olderVersion("0.5", "0.5"))
+	require.False(t, olderVersion("0.5.0", "0.5"))
+	require.False(t, olderVersion("0.6", "0.5"))
+	require.False(t, olderVersion("1.0", "0.5"))
+}
+
+func TestServiceWalletFormat(t *testing.T) {
+	dir := prepareWltDir()
+	for _, f := range []string{"test5-bip44.wlt", "sha256xor-encrypted.wlt"} {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", f))
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, f), b, 0600))
+	}
+
+	s, err := NewService(Config{
+		WalletDir:                dir,
+		CryptoType:               CryptoTypeScryptChacha20poly1305Insecure,
+		EnableWalletAPI:          true,
+		MaxBackups:               DefaultMaxBackups,
+		ExplicitFormatMigrations: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("current.wlt", Options{
+		Seed: bip39.MustNewDefaultMnemonic(),
+		Type: WalletTypeBip44,
+	}, nil)
+	require.NoError(t, err)
+
+	// Loading a wallet does not migrate it
+	_, err = s.GetWallet("test5-bip44.wlt")
+	require.NoError(t, err)
+
+	xpub, err := s.GetWalletAccountXPub("test5-bip44.wlt", 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, xpub.XPub)
+
+	ss, err := s.WalletFormatStatus()
+	require.NoError(t, err)
+	require.Equal(t, []WalletFormatStatus{
+		{
+			WalletID: "current.wlt",
+			Version:  Version,
+		},
+		{
+			WalletID:  "sha256xor-encrypted.wlt",
+			Version:   "0.2",
+			Encrypted: true,
+			Migrations: []WalletFormatMigration{
+				{Migration: MigrationMetadataKey, NeedsUnlock: true},
+				{Migration: MigrationVersion},
+			},
+		},
+		{
+			WalletID: "test5-bip44.wlt",
+			Version:  "0.3",
+			Migrations: []WalletFormatMigration{
+				{Migration: MigrationAccountXPub},
+				{Migration: MigrationEntryPaths},
+				{Migration: MigrationVersion},
+			},
+		},
+	}, ss)
+	require.True(t, ss[1].NeedsUnlock())
+	require.False(t, ss[2].NeedsUnlock())
+
+	// A wallet that needs no migration is left unchanged
+	r, err := s.MigrateWalletFormat("current.wlt", nil)
+	require.NoError(t, err)
+	require.Equal(t, &WalletFormatMigrationResult{
+		WalletID: "current.wlt",
+		Version:  Version,
+		Migrated: []WalletMigration{},
+	}, r)
+
+	backups, err := s.ListWalletBackups("current.wlt")
+	require.NoError(t, err)
+	require.Empty(t, backups)
+
+	// An unencrypted wallet is migrated without its password, and its file is backed up first
+	r, err = s.MigrateWalletFormat("test5-bip44.wlt", nil)
+	require.NoError(t, err)
+	require.Equal(t, []WalletMigration{MigrationAccountXPub, MigrationEntryPaths, MigrationVersion}, r.Migrated)
+	require.Equal(t, Version, r.Version)
+
+	backups, err = s.ListWalletBackups("test5-bip44.wlt")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	require.Equal(t, backups[0].Filename, r.Backup)
+
+	b, err := Load(filepath.Join(dir, BackupsDir, r.Backup))
+	require.NoError(t, err)
+	require.Equal(t, "0.3", b.Version())
+
+	w, err := Load(filepath.Join(dir, "test5-bip44.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, Version, w.Version())
+	require.Equal(t, xpub.XPub, w.XPub())
+	require.False(t, needsEntryPaths(w))
+
+	// The loaded wallet is migrated too
+	w, err = s.GetWallet("test5-bip44.wlt")
+	require.NoError(t, err)
+	require.Equal(t, xpub.XPub, w.XPub())
+
+	// An encrypted wallet whose migrations need the seed needs its password
+	_, err = s.MigrateWalletFormat("sha256xor-encrypted.wlt", nil)
+	require.Equal(t, ErrWalletMigrationNeedsPassword, err)
+
+	_, err = s.MigrateWalletFormat("sha256xor-encrypted.wlt", []byte("wrong"))
+	require.Equal(t, ErrInvalidPassword, err)
+
+	r, err = s.MigrateWalletFormat("sha256xor-encrypted.wlt", []byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, []WalletMigration{MigrationMetadataKey, MigrationVersion}, r.Migrated)
+
+	w, err = Load(filepath.Join(dir, "sha256xor-encrypted.wlt"))
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	require.NotEmpty(t, w.MetadataKey())
+	require.Equal(t, Version, w.Version())
+
+	// The migrated wallet can be unlocked
+	require.NoError(t, s.UnlockWalletMetadata("sha256xor-encrypted.wlt", []byte("pwd")))
+
+	ss, err = s.WalletFormatStatus()
+	require.NoError(t, err)
+	for _, st := range ss {
+		require.Empty(t, st.Migrations, st.WalletID)
+		require.Equal(t, Version, st.Version, st.WalletID)
+	}
+
+	_, err = s.MigrateWalletFormat("missing.wlt", nil)
+	require.Equal(t, ErrWalletNotExist, err)
+}
@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// walletHeader is the part of a wallet file read without loading the wallet:
+// its metadata, without secrets, and the fingerprint computed from its first address
+type walletHeader struct {
+	meta        Meta
+	fingerprint string
+}
+
+// loadWalletHeader reads the metadata and the first address of a wallet file.
+// Decoding stops once both are found, so the rest of the entries are not read.
+func loadWalletHeader(filename string) (*walletHeader, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := json.NewDecoder(bufio.NewReader(f))
+
+	if err := expectDelim(d, '{'); err != nil {
+		return nil, fmt.Errorf("invalid wallet %q: %v", filename, err)
+	}
+
+	var meta Meta
+	var firstAddr string
+	entriesRead := false
+	for d.More() && (meta == nil || !entriesRead) {
+		t, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid wallet %q: %v", filename, err)
+		}
+
+		switch t {
+		case "meta":
+			err = d.Decode(&meta)
+		case "entries":
+			firstAddr, err = readFirstAddress(d, meta)
+			entriesRead = true
+		default:
+			var v json.RawMessage
+			err = d.Decode(&v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid wallet %q: %v", filename, err)
+		}
+	}
+
+	if meta == nil {
+		meta = Meta{}
+	}
+
+	if !IsValidWalletType(meta.Type()) {
+		return nil, fmt.Errorf("invalid wallet %q: %v", filename, ErrInvalidWalletType)
+	}
+
+	ct, err := ResolveCoinType(string(meta.Coin()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet %q: %v", filename, err)
+	}
+	if ct != CoinTypeSkycoin {
+		return nil, fmt.Errorf("LoadWallets only support skycoin wallets, %s is a %s wallet", filepath.Base(filename), ct)
+	}
+	meta.SetCoin(ct)
+	meta.SetFilename(filepath.Base(filename))
+
+	h := &walletHeader{
+		meta: headerMeta(meta),
+	}
+
+	// See the Fingerprint method of each wallet type. Collection wallets have no fingerprint.
+	if firstAddr != "" && meta.Type() != WalletTypeCollection {
+		h.fingerprint = fmt.Sprintf("%s-%s", meta.Type(), firstAddr)
+	}
+
+	return h, nil
+}
+
+// readFirstAddress reads the entries array of a wallet file up to its first address.
+// For bip44 wallets, the first address of the external chain is returned.
+// If meta has not been read yet, the rest of the array is read so that decoding can continue.
+func readFirstAddress(d *json.Decoder, meta Meta) (string, error) {
+	if err := expectDelim(d, '['); err != nil {
+		return "", err
+	}
+
+	var addr string
+	for d.More() {
+		var e ReadableEntry
+		if err := d.Decode(&e); err != nil {
+			return "", err
+		}
+
+		if addr == "" && (e.Change == nil || *e.Change == 0) {
+			addr = e.Address
+			if meta != nil {
+				return addr, nil
+			}
+		}
+	}
+
+	return addr, expectDelim(d, ']')
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	t, err := d.Token()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("expected %q, found %v", delim, t)
+	}
+	return nil
+}
+
+// headerMeta returns a copy of a wallet's metadata without its secrets, encrypted or not
+func headerMeta(m Meta) Meta {
+	hm := m.clone()
+	hm.eraseSeeds()
+	hm.setSecrets("")
+	return hm
+}
+
+// walletMeta returns the metadata of a wallet
+func walletMeta(w Wallet) Meta {
+	switch wlt := w.(type) {
+	case *DeterministicWallet:
+		return wlt.Meta
+	case *Bip44Wallet:
+		return wlt.Meta
+	case *CollectionWallet:
+		return wlt.Meta
+	case *XPubWallet:
+		return wlt.Meta
+	default:
+		logger.WithField("walletType", w.Type()).Panic("walletMeta: unhandled wallet type")
+		return nil
+	}
+}
@@ -1,6 +1,8 @@
 package wallet
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strconv"
@@ -25,7 +27,16 @@ const (
 	metaSecrets        = "secrets"        // secrets which records the encrypted seeds and secrets of address entries
 	metaBip44Coin      = "bip44Coin"      // bip44 coin type
 	metaSeedPassphrase = "seedPassphrase" // seed passphrase [bip44 wallets]
-	metaXPub           = "xpub"           // xpub key [xpub wallets]
+	metaXPub           = "xpub"           // xpub key [xpub wallets], or the account 0 xpub [bip44 wallets]
+	metaReuseChange    = "reuseChange"    // whether change is sent to a spent address instead of a new change address [bip44 wallets]
+	metaMetadataKey    = "metadataKey"    // metadata key, encrypted with the wallet password [encrypted wallets]
+	metaMetadataMAC    = "metadataMAC"    // HMAC of the label and the entry addresses and labels, keyed by the metadata key [encrypted wallets]
+	metaAccessToken    = "accessToken"    // sha256 of the access token required by the wallet API endpoints, if set
+	metaStrictPrivacy  = "strictPrivacy"  // whether the address reuse warnings of the transactions created by the wallet API are errors
+
+	metaApprovalThreshold = "approvalThreshold" // droplets above which spends require approval, if an approval policy is set
+	metaApprovalWindow    = "approvalWindow"    // validity window of pending approvals, if an approval policy is set
+	metaApprover          = "approver"          // crypto type and encrypted approver check, if an approval policy is set
 )
 
 // Meta holds wallet metadata
@@ -102,10 +113,19 @@ func (m Meta) validate() error {
 		if s := m[metaLastSeed]; s != "" {
 			return errors.New("lastSeed should not be visible in encrypted wallets")
 		}
+
+		// Wallets encrypted before version 0.5 have neither
+		if (m[metaMetadataKey] == "") != (m[metaMetadataMAC] == "") {
+			return errors.New("metadataKey and metadataMAC must be set together")
+		}
 	} else {
 		if s := m[metaSecrets]; s != "" {
 			return errors.New("secrets should not be in unencrypted wallets")
 		}
+
+		if m[metaMetadataKey] != "" || m[metaMetadataMAC] != "" {
+			return errors.New("metadataKey and metadataMAC should not be in unencrypted wallets")
+		}
 	}
 
 	switch walletType {
@@ -158,8 +178,34 @@ func (m Meta) validate() error {
 		return errors.New("unhandled wallet type")
 	}
 
-	if m[metaXPub] != "" && walletType != WalletTypeXPub {
-		return errors.New("xpub is only used for xpub wallets")
+	if m[metaXPub] != "" && walletType != WalletTypeXPub && walletType != WalletTypeBip44 {
+		return errors.New("xpub is only used for xpub and bip44 wallets")
+	}
+
+	if s, ok := m[metaReuseChange]; ok {
+		if _, err := strconv.ParseBool(s); err != nil {
+			return errors.New("reuseChange field is not a valid bool")
+		}
+
+		if walletType != WalletTypeBip44 {
+			return errors.New("reuseChange is only used for bip44 wallets")
+		}
+	}
+
+	if s, ok := m[metaAccessToken]; ok {
+		if b, err := hex.DecodeString(s); err != nil || len(b) != sha256.Size {
+			return errors.New("accessToken field is not a valid sha256 hash")
+		}
+	}
+
+	if s, ok := m[metaStrictPrivacy]; ok {
+		if _, err := strconv.ParseBool(s); err != nil {
+			return errors.New("strictPrivacy field is not a valid bool")
+		}
+	}
+
+	if _, err := parseApprovalPolicy(m); err != nil {
+		return err
 	}
 
 	return nil
@@ -232,6 +278,74 @@ func (m Meta) setSeedPassphrase(p string) {
 	m[metaSeedPassphrase] = p
 }
 
+// ReuseChange returns true if the wallet sends change to one of the spent addresses,
+// instead of a new change chain address [bip44 wallets]
+func (m Meta) ReuseChange() bool {
+	v, _ := strconv.ParseBool(m[metaReuseChange]) //nolint:errcheck
+	return v
+}
+
+// SetReuseChange sets whether the wallet sends change to one of the spent addresses [bip44 wallets]
+func (m Meta) SetReuseChange(reuse bool) {
+	if !reuse {
+		delete(m, metaReuseChange)
+		return
+	}
+	m[metaReuseChange] = strconv.FormatBool(reuse)
+}
+
+// StrictPrivacy returns true if the wallet API refuses to create transactions of the wallet that reuse addresses,
+// instead of warning about the reuse, see FindAddressReuse
+func (m Meta) StrictPrivacy() bool {
+	v, _ := strconv.ParseBool(m[metaStrictPrivacy]) //nolint:errcheck
+	return v
+}
+
+// SetStrictPrivacy sets whether the wallet API refuses to create transactions of the wallet that reuse addresses
+func (m Meta) SetStrictPrivacy(strict bool) {
+	if !strict {
+		delete(m, metaStrictPrivacy)
+		return
+	}
+	m[metaStrictPrivacy] = strconv.FormatBool(strict)
+}
+
+// AccessTokenHash returns the hex encoded sha256 of the wallet's access token, empty if the wallet has no access token
+func (m Meta) AccessTokenHash() string {
+	return m[metaAccessToken]
+}
+
+// SetAccessTokenHash sets the hex encoded sha256 of the wallet's access token, or removes it if empty
+func (m Meta) SetAccessTokenHash(h string) {
+	if h == "" {
+		delete(m, metaAccessToken)
+		return
+	}
+	m[metaAccessToken] = h
+}
+
+// ApprovalPolicy returns the wallet's approval policy, nil if the wallet has none
+func (m Meta) ApprovalPolicy() *ApprovalPolicy {
+	p, err := parseApprovalPolicy(m)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// SetApprovalPolicy sets the wallet's approval policy, or removes it if nil
+func (m Meta) SetApprovalPolicy(p *ApprovalPolicy) {
+	if p == nil {
+		delete(m, metaApprovalThreshold)
+		delete(m, metaApprovalWindow)
+		delete(m, metaApprover)
+		return
+	}
+	m[metaApprovalThreshold] = strconv.FormatUint(p.Threshold, 10)
+	m[metaApprovalWindow] = p.Window.String()
+	m[metaApprover] = p.approver
+}
+
 // Coin returns the wallet's coin type
 func (m Meta) Coin() CoinType {
 	return CoinType(m[metaCoin])
@@ -278,6 +392,8 @@ func (m Meta) SetDecrypted() {
 	m.setIsEncrypted(false)
 	m.setSecrets("")
 	m.setCryptoType("")
+	delete(m, metaMetadataKey)
+	delete(m, metaMetadataMAC)
 }
 
 // IsEncrypted checks whether the wallet is encrypted.
@@ -316,6 +432,22 @@ func (m Meta) setSecrets(s string) {
 	m[metaSecrets] = s
 }
 
+// MetadataKey returns the metadata key of an encrypted wallet, encrypted with the wallet password
+func (m Meta) MetadataKey() string {
+	return m[metaMetadataKey]
+}
+
+// MetadataMAC returns the hex-encoded HMAC of the metadata of an encrypted wallet
+func (m Meta) MetadataMAC() string {
+	return m[metaMetadataMAC]
+}
+
+// SetMetadataAuth sets the encrypted metadata key and the metadata HMAC
+func (m Meta) SetMetadataAuth(encryptedKey, mac string) {
+	m[metaMetadataKey] = encryptedKey
+	m[metaMetadataMAC] = mac
+}
+
 // Timestamp returns the timestamp
 func (m Meta) Timestamp() int64 {
 	// Intentionally ignore the error when parsing the timestamp,
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+/*
+The metadata of an encrypted wallet, its label, the address and label of each entry in order
+and the account xpub of bip44 wallets, is not encrypted, so it can be listed and edited without
+decrypting the seeds and secret keys. The account xpub is deliberately left unencrypted, so that
+the wallet's addresses can be watched without the password.
+
+Its integrity is covered by an HMAC keyed by a random metadata key, which is created when the wallet
+is encrypted. The metadata key is stored twice: in the encrypted secrets, so that it is available
+whenever the wallet is unlocked, and on its own, encrypted with the wallet password, so that it can be
+decrypted without decrypting the secrets ("metadata-only unlock").
+
+Unlock checks the HMAC, so that metadata edited offline is detected at the next unlock.
+Wallets from before version 0.5 have no metadata key, they get one the next time they are locked.
+*/
+
+const metadataKeyLen = 32
+
+var (
+	// ErrWalletMetadataTampered is returned when unlocking an encrypted wallet whose metadata does not match its HMAC
+	ErrWalletMetadataTampered = NewError(errors.New("wallet metadata was modified without the wallet password"))
+	// ErrWalletMetadataLocked is returned when editing the metadata of an encrypted wallet whose metadata is not unlocked
+	ErrWalletMetadataLocked = NewError(errors.New("wallet metadata is locked"))
+	// ErrWalletMetadataNotAuthenticated is returned for metadata-only unlocks of encrypted wallets without a metadata key
+	ErrWalletMetadataNotAuthenticated = NewError(errors.New("wallet metadata is not authenticated, unlock the wallet once to add a metadata key"))
+)
+
+// metadataSection is the part of the wallet covered by the metadata HMAC
+type metadataSection struct {
+	Label   string          `json:"label"`
+	Entries []metadataEntry `json:"entries"`
+	// XPub is the account xpub of bip44 wallets, omitted if it is not stored
+	XPub string `json:"xpub,omitempty"`
+}
+
+type metadataEntry struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+}
+
+func newMetadataKey() []byte {
+	return cipher.RandByte(metadataKeyLen)
+}
+
+// metadataMAC computes the hex-encoded HMAC of the wallet's metadata section
+func metadataMAC(w Wallet, key []byte) (string, error) {
+	entries := w.GetEntries()
+	defer entries.erase()
+
+	s := metadataSection{
+		Label:   w.Label(),
+		Entries: make([]metadataEntry, len(entries)),
+	}
+	if w.Type() == WalletTypeBip44 {
+		s.XPub = w.XPub()
+	}
+	for i, e := range entries {
+		s.Entries[i] = metadataEntry{
+			Address: e.Address.String(),
+			Label:   e.Label,
+		}
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	if _, err := mac.Write(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyMetadataMAC checks the wallet's metadata HMAC with the metadata key
+func verifyMetadataMAC(w Wallet, key []byte) error {
+	expected, err := hex.DecodeString(w.MetadataMAC())
+	if err != nil {
+		return ErrWalletMetadataTampered
+	}
+
+	mac, err := metadataMAC(w, key)
+	if err != nil {
+		return err
+	}
+
+	actual, err := hex.DecodeString(mac)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(expected, actual) {
+		return ErrWalletMetadataTampered
+	}
+
+	return nil
+}
+
+// setMetadataAuth encrypts the metadata key with the password and records it with the metadata HMAC in the wallet
+func setMetadataAuth(w Wallet, key, password []byte, crypto cryptor) error {
+	mac, err := metadataMAC(w, key)
+	if err != nil {
+		return err
+	}
+
+	encKey, err := crypto.Encrypt([]byte(hex.EncodeToString(key)), password)
+	if err != nil {
+		return err
+	}
+
+	w.SetMetadataAuth(string(encKey), mac)
+	return nil
+}
+
+// decodeMetadataKey decodes a hex-encoded metadata key
+func decodeMetadataKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != metadataKeyLen {
+		return nil, errors.New("invalid metadata key length")
+	}
+
+	return key, nil
+}
+
+// UnlockMetadata decrypts the metadata key of an encrypted wallet, without decrypting its secrets,
+// and checks the wallet's metadata against its HMAC.
+// The metadata key is used with UpdateMetadata to edit the metadata without the password,
+// and should be wiped from memory when done.
+func UnlockMetadata(w Wallet, password []byte) ([]byte, error) {
+	if !w.IsEncrypted() {
+		return nil, ErrWalletNotEncrypted
+	}
+
+	if len(password) == 0 {
+		return nil, ErrMissingPassword
+	}
+
+	if w.MetadataKey() == "" {
+		return nil, ErrWalletMetadataNotAuthenticated
+	}
+
+	crypto, err := getCrypto(w.CryptoType())
+	if err != nil {
+		return nil, err
+	}
+
+	kb, err := crypto.Decrypt([]byte(w.MetadataKey()), password)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	key, err := decodeMetadataKey(string(kb))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyMetadataMAC(w, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// UpdateMetadata executes a function that edits the metadata of an encrypted wallet,
+// then updates the metadata HMAC with the metadata key returned by UnlockMetadata.
+// The wallet is not modified if the metadata does not match its HMAC before the edit.
+func UpdateMetadata(w Wallet, key []byte, fn func(w Wallet) error) error {
+	if !w.IsEncrypted() {
+		return ErrWalletNotEncrypted
+	}
+
+	if w.MetadataKey() == "" {
+		return ErrWalletMetadataNotAuthenticated
+	}
+
+	if err := verifyMetadataMAC(w, key); err != nil {
+		return err
+	}
+
+	// Encrypted wallets hold no secrets, so the copy does not need to be erased
+	wlt := w.Clone()
+	if err := fn(wlt); err != nil {
+		return err
+	}
+
+	mac, err := metadataMAC(wlt, key)
+	if err != nil {
+		return err
+	}
+
+	wlt.SetMetadataAuth(wlt.MetadataKey(), mac)
+
+	w.CopyFrom(wlt)
+	return nil
+}
@@ -0,0 +1,372 @@
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+)
+
+func makeEncryptedWallet(t *testing.T, ct CryptoType, password []byte) *DeterministicWallet {
+	w, err := NewWallet("t.wlt", Options{
+		Seed:       "seed",
+		Label:      "label",
+		Type:       WalletTypeDeterministic,
+		Encrypt:    true,
+		Password:   password,
+		CryptoType: ct,
+		GenerateN:  3,
+	})
+	require.NoError(t, err)
+	return w.(*DeterministicWallet)
+}
+
+func TestWalletMetadataAuth(t *testing.T) {
+	password := []byte("pwd")
+
+	cases := []struct {
+		name   string
+		tamper func(w *DeterministicWallet)
+	}{
+		{
+			name: "wallet label",
+			tamper: func(w *DeterministicWallet) {
+				w.SetLabel("tampered")
+			},
+		},
+		{
+			name: "address label",
+			tamper: func(w *DeterministicWallet) {
+				w.Entries[1].Label = "tampered"
+			},
+		},
+		{
+			name: "entry order",
+			tamper: func(w *DeterministicWallet) {
+				w.Entries[0], w.Entries[1] = w.Entries[1], w.Entries[0]
+			},
+		},
+		{
+			name: "removed entry",
+			tamper: func(w *DeterministicWallet) {
+				w.Entries = w.Entries[:2]
+			},
+		},
+		{
+			name: "stripped metadata key",
+			tamper: func(w *DeterministicWallet) {
+				delete(w.Meta, metaMetadataKey)
+				delete(w.Meta, metaMetadataMAC)
+			},
+		},
+	}
+
+	for ct := range cryptoTable {
+		t.Run(string(ct), func(t *testing.T) {
+			w := makeEncryptedWallet(t, ct, password)
+			require.Equal(t, Version, w.Version())
+			require.NotEmpty(t, w.MetadataKey())
+			require.NotEmpty(t, w.MetadataMAC())
+			require.NoError(t, w.Validate())
+
+			wlt, err := Unlock(w, password)
+			require.NoError(t, err)
+			require.Empty(t, wlt.MetadataKey())
+			require.Empty(t, wlt.MetadataMAC())
+			require.NoError(t, wlt.Validate())
+
+			for _, tc := range cases {
+				t.Run(tc.name, func(t *testing.T) {
+					w2 := w.Clone().(*DeterministicWallet)
+					tc.tamper(w2)
+
+					_, err := Unlock(w2, password)
+					require.Equal(t, ErrWalletMetadataTampered, err)
+
+					// Without a metadata key, the metadata can't be checked without decrypting the secrets
+					if w2.MetadataKey() == "" {
+						_, err = UnlockMetadata(w2, password)
+						require.Equal(t, ErrWalletMetadataNotAuthenticated, err)
+						return
+					}
+
+					_, err = UnlockMetadata(w2, password)
+					require.Equal(t, ErrWalletMetadataTampered, err)
+				})
+			}
+		})
+	}
+}
+
+func TestUnlockMetadata(t *testing.T) {
+	password := []byte("pwd")
+
+	for ct := range cryptoTable {
+		t.Run(string(ct), func(t *testing.T) {
+			w := makeEncryptedWallet(t, ct, password)
+
+			_, err := UnlockMetadata(w, nil)
+			require.Equal(t, ErrMissingPassword, err)
+
+			_, err = UnlockMetadata(w, []byte("wrong"))
+			require.Equal(t, ErrInvalidPassword, err)
+
+			key, err := UnlockMetadata(w, password)
+			require.NoError(t, err)
+			require.Len(t, key, metadataKeyLen)
+
+			// Edit the labels with the metadata key
+			addr := w.Entries[2].SkycoinAddress()
+			err = UpdateMetadata(w, key, func(w Wallet) error {
+				w.SetLabel("new-label")
+				require.True(t, w.SetEntryLabel(addr, "change"))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, "new-label", w.Label())
+			require.Equal(t, "change", w.Entries[2].Label)
+			checkNoSensitiveData(t, w)
+
+			wlt, err := Unlock(w, password)
+			require.NoError(t, err)
+			require.Equal(t, "new-label", wlt.Label())
+
+			// The wallet is not modified if the edit fails
+			err = UpdateMetadata(w, key, func(w Wallet) error {
+				w.SetLabel("not-saved")
+				return ErrUnknownAddress
+			})
+			require.Equal(t, ErrUnknownAddress, err)
+			require.Equal(t, "new-label", w.Label())
+
+			// A wrong metadata key is rejected
+			err = UpdateMetadata(w, newMetadataKey(), func(w Wallet) error {
+				w.SetLabel("not-saved")
+				return nil
+			})
+			require.Equal(t, ErrWalletMetadataTampered, err)
+			require.Equal(t, "new-label", w.Label())
+
+			// Generating addresses keeps the metadata key
+			err = GuardUpdate(w, password, func(w Wallet) error {
+				_, err := w.GenerateAddresses(1)
+				return err
+			})
+			require.NoError(t, err)
+			require.Len(t, w.Entries, 4)
+
+			err = UpdateMetadata(w, key, func(w Wallet) error {
+				w.SetLabel("after-new-address")
+				return nil
+			})
+			require.NoError(t, err)
+
+			_, err = Unlock(w, password)
+			require.NoError(t, err)
+
+			// Unencrypted wallets have no metadata key
+			wlt, err = Unlock(w, password)
+			require.NoError(t, err)
+			_, err = UnlockMetadata(wlt, password)
+			require.Equal(t, ErrWalletNotEncrypted, err)
+			err = UpdateMetadata(wlt, key, func(Wallet) error { return nil })
+			require.Equal(t, ErrWalletNotEncrypted, err)
+		})
+	}
+}
+
+func TestServiceWalletMetadata(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+
+	// A wallet encrypted before version 0.5, with the password "pwd"
+	legacy := "scrypt-chacha20poly1305-encrypted.wlt"
+	data, err := ioutil.ReadFile(filepath.Join("testdata", legacy))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, legacy), data, 0600))
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	password := []byte("pwd")
+
+	// The labels of a wallet encrypted before version 0.5 can be updated without unlocking its metadata
+	w, err := s.GetWallet(legacy)
+	require.NoError(t, err)
+	require.Empty(t, w.MetadataKey())
+	require.NoError(t, s.UpdateWalletLabel(legacy, "legacy"))
+
+	// Unlocking its metadata adds a metadata key to the wallet file
+	require.NoError(t, s.UnlockWalletMetadata(legacy, password))
+	w, err = Load(filepath.Join(dir, legacy))
+	require.NoError(t, err)
+	require.Equal(t, Version, w.Version())
+	require.Equal(t, "legacy", w.Label())
+	require.NotEmpty(t, w.MetadataKey())
+	_, err = Unlock(w, password)
+	require.NoError(t, err)
+
+	w, err = s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Type:     WalletTypeDeterministic,
+		Encrypt:  true,
+		Password: password,
+	}, nil)
+	require.NoError(t, err)
+	addr := w.GetEntryAt(0).SkycoinAddress()
+
+	require.Equal(t, ErrWalletMetadataLocked, s.UpdateWalletLabel("t.wlt", "label"))
+	require.Equal(t, ErrWalletMetadataLocked, s.UpdateAddressLabel("t.wlt", addr, "label"))
+
+	require.Equal(t, ErrInvalidPassword, s.UnlockWalletMetadata("t.wlt", []byte("wrong")))
+	require.NoError(t, s.UnlockWalletMetadata("t.wlt", password))
+
+	require.NoError(t, s.UpdateWalletLabel("t.wlt", "label"))
+	require.NoError(t, s.UpdateAddressLabel("t.wlt", addr, "address-label"))
+	require.Equal(t, ErrUnknownAddress, s.UpdateAddressLabel("t.wlt", cipher.Address{}, "label"))
+
+	// The saved wallet's metadata is authenticated
+	w, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, "label", w.Label())
+	require.Equal(t, "address-label", w.GetEntryAt(0).Label)
+	_, err = Unlock(w, password)
+	require.NoError(t, err)
+
+	// Locking or unloading the wallet locks its metadata
+	require.NoError(t, s.LockWalletMetadata("t.wlt"))
+	require.Equal(t, ErrWalletMetadataLocked, s.UpdateWalletLabel("t.wlt", "label2"))
+
+	require.NoError(t, s.UnlockWalletMetadata("t.wlt", password))
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	require.Equal(t, ErrWalletMetadataLocked, s.UpdateWalletLabel("t.wlt", "label2"))
+
+	// Decrypted wallets don't need their metadata unlocked
+	require.NoError(t, s.UnlockWalletMetadata("t.wlt", password))
+	_, err = s.DecryptWallet("t.wlt", password)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateWalletLabel("t.wlt", "label2"))
+	require.Equal(t, ErrWalletNotEncrypted, s.UnlockWalletMetadata("t.wlt", password))
+	_, ok := s.metadataKeys["t.wlt"]
+	require.False(t, ok)
+
+	require.Equal(t, ErrWalletNotExist, s.UnlockWalletMetadata("missing.wlt", password))
+	require.Equal(t, ErrWalletNotExist, s.LockWalletMetadata("missing.wlt"))
+}
+
+func TestServiceGetWalletAccountXPub(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305Insecure,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	password := []byte("pwd")
+	seed := "voyage say extend find sheriff surge priority merit ignore maple cash argue"
+	xpub := "xpub6BvenwtHLSGqw9v1GiwRe2uuDZW93Gi9H61Lqo9AaegvdcmysqLGYBmn1eku88NFb1nKJgp11vrvcEi8kxvpoZBNwxemDRWPustZzbUgUZt"
+
+	// The xpub is stored when the wallet is created, unencrypted with the metadata of an encrypted wallet
+	_, err = s.CreateWallet("encrypted.wlt", Options{
+		Seed:     seed,
+		Type:     WalletTypeBip44,
+		Encrypt:  true,
+		Password: password,
+	}, nil)
+	require.NoError(t, err)
+
+	x, err := s.GetWalletAccountXPub("encrypted.wlt", 0)
+	require.NoError(t, err)
+	require.Equal(t, &AccountXPub{
+		Account:   0,
+		XPub:      xpub,
+		Encrypted: true,
+	}, x)
+
+	_, err = s.GetWalletAccountXPub("encrypted.wlt", 1)
+	require.Equal(t, NewError(errors.New(`"bip44" wallets only use account 0, the xpub of account 1 is not stored`)), err)
+
+	// The xpub is covered by the metadata HMAC
+	w, err := Load(filepath.Join(dir, "encrypted.wlt"))
+	require.NoError(t, err)
+	w.(*Bip44Wallet).Meta.setXPub("xpub")
+	_, err = Unlock(w, password)
+	require.Equal(t, ErrWalletMetadataTampered, err)
+
+	// Wallets created before the xpub was stored
+	removeXPub := func(name string, encrypted bool) {
+		w, err := Load(filepath.Join(dir, name))
+		require.NoError(t, err)
+		if encrypted {
+			key, err := UnlockMetadata(w, password)
+			require.NoError(t, err)
+			err = UpdateMetadata(w, key, func(w Wallet) error {
+				delete(w.(*Bip44Wallet).Meta, metaXPub)
+				return nil
+			})
+			require.NoError(t, err)
+		} else {
+			delete(w.(*Bip44Wallet).Meta, metaXPub)
+		}
+		require.NoError(t, Save(w, dir))
+		require.NoError(t, s.UnloadWallet(name))
+	}
+
+	// An encrypted wallet stores it when its metadata is unlocked
+	removeXPub("encrypted.wlt", true)
+	_, err = s.GetWalletAccountXPub("encrypted.wlt", 0)
+	require.Equal(t, ErrAccountXPubNotStored, err)
+
+	require.NoError(t, s.UnlockWalletMetadata("encrypted.wlt", password))
+	x, err = s.GetWalletAccountXPub("encrypted.wlt", 0)
+	require.NoError(t, err)
+	require.Equal(t, xpub, x.XPub)
+
+	w, err = Load(filepath.Join(dir, "encrypted.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, xpub, w.XPub())
+	_, err = Unlock(w, password)
+	require.NoError(t, err)
+
+	// An unencrypted wallet stores it from its seed on the first request
+	_, err = s.CreateWallet("unencrypted.wlt", Options{
+		Seed: bip39.MustNewDefaultMnemonic(),
+		Type: WalletTypeBip44,
+	}, nil)
+	require.NoError(t, err)
+	removeXPub("unencrypted.wlt", false)
+
+	x, err = s.GetWalletAccountXPub("unencrypted.wlt", 0)
+	require.NoError(t, err)
+	require.False(t, x.Encrypted)
+	require.NotEmpty(t, x.XPub)
+
+	w, err = Load(filepath.Join(dir, "unencrypted.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, x.XPub, w.XPub())
+
+	// Other wallet types have no account xpub
+	_, err = s.CreateWallet("deterministic.wlt", Options{
+		Seed: "seed",
+		Type: WalletTypeDeterministic,
+	}, nil)
+	require.NoError(t, err)
+	_, err = s.GetWalletAccountXPub("deterministic.wlt", 0)
+	require.Equal(t, NewError(errors.New(`account xpubs are only stored in "bip44" wallets`)), err)
+
+	_, err = s.GetWalletAccountXPub("missing.wlt", 0)
+	require.Equal(t, ErrWalletNotExist, err)
+}
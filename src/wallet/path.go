@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+)
+
+/*
+Each entry of a bip44 wallet records its derivation path, m/44'/coin'/account'/change/index, so that
+the address can be matched with the same address on a hardware wallet. The path is recorded when the
+address is generated. Wallets created before paths were recorded get them by MigrateEntryPaths, which
+derives each address again from the seed and only records the path of the addresses it derives.
+Unencrypted wallets get their paths when they are loaded. The seed of an encrypted wallet is needed,
+so their paths are recorded the next time they are unlocked and locked again, e.g. to generate an address.
+The addresses that the seed does not derive at their path are reported, and left without a path.
+*/
+
+// Bip44Account is the bip44 account of the addresses of bip44 wallets. Multiple accounts are not supported.
+const Bip44Account = 0
+
+// Bip44Path returns the bip44 derivation path m/44'/coin'/account'/change/index
+func Bip44Path(coin bip44.CoinType, account, change, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/%d/%d", coin, account, change, index)
+}
+
+// EntryPaths returns the derivation path of each entry of a wallet, in the order of GetEntries.
+// The entries of bip44 wallets have their bip44 path, which is derived from the coin type and
+// the change and child numbers of the entry if it is not recorded yet.
+// The entries of deterministic wallets have their generation index instead, the position of the
+// address in the wallet. The entries of other wallets have no path.
+func EntryPaths(w Wallet) []string {
+	entries := w.GetEntries()
+	defer entries.erase()
+	return entryPaths(w.Type(), w.Bip44Coin, entries)
+}
+
+// EntryPaths returns the derivation path of each entry of the wallet, like EntryPaths.
+// The header has no entries if its wallet is not loaded.
+func (h WalletHeader) EntryPaths() []string {
+	return entryPaths(h.Meta.Type(), h.Meta.Bip44Coin, h.Entries)
+}
+
+// entryPaths returns the paths of the entries of a wallet of type walletType. bip44Coin is only called for bip44 wallets.
+func entryPaths(walletType string, bip44Coin func() bip44.CoinType, entries Entries) []string {
+	paths := make([]string, len(entries))
+	switch walletType {
+	case WalletTypeBip44:
+		coin := bip44Coin()
+		for i, e := range entries {
+			paths[i] = e.Path
+			if paths[i] == "" {
+				paths[i] = Bip44Path(coin, Bip44Account, e.Change, e.ChildNumber)
+			}
+		}
+	case WalletTypeDeterministic:
+		for i := range entries {
+			paths[i] = strconv.Itoa(i)
+		}
+	}
+
+	return paths
+}
+
+// PathMismatch is an entry of a bip44 wallet whose address is not the address derived from the seed at its path
+type PathMismatch struct {
+	Address cipher.Addresser
+	Path    string
+	// Derived is the address derived at Path, nil if no address can be derived at it
+	Derived cipher.Addresser
+}
+
+func (m PathMismatch) String() string {
+	if m.Derived == nil {
+		return fmt.Sprintf("address %s is at %s, where no address is derived", m.Address, m.Path)
+	}
+	return fmt.Sprintf("address %s is not the address %s derived at %s", m.Address, m.Derived, m.Path)
+}
+
+// VerifyEntryPaths derives the address of each entry again from the seed, at the change and child numbers
+// of the entry, and returns the entries whose address does not match. The wallet must not be encrypted.
+func (w *Bip44Wallet) VerifyEntryPaths() ([]PathMismatch, error) {
+	if w.Meta.IsEncrypted() {
+		return nil, ErrWalletEncrypted
+	}
+
+	if len(w.ExternalEntries) == 0 && len(w.ChangeEntries) == 0 {
+		return nil, nil
+	}
+
+	c, err := w.CoinHDNode()
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := c.Account(Bip44Account)
+	if err != nil {
+		return nil, err
+	}
+
+	makeAddress := w.Meta.AddressConstructor()
+
+	var mismatches []PathMismatch
+	for _, chain := range []struct {
+		index   uint32
+		entries Entries
+	}{
+		{bip44.ExternalChainIndex, w.ExternalEntries},
+		{bip44.ChangeChainIndex, w.ChangeEntries},
+	} {
+		if len(chain.entries) == 0 {
+			continue
+		}
+
+		node, err := account.NewPrivateChildKey(chain.index)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range chain.entries {
+			path := Bip44Path(w.Meta.Bip44Coin(), Bip44Account, e.Change, e.ChildNumber)
+
+			var derived cipher.Addresser
+			k, err := node.NewPrivateChildKey(e.ChildNumber)
+			switch {
+			case err == nil:
+				derived = makeAddress(cipher.MustPubKeyFromSecKey(cipher.MustNewSecKey(k.Key)))
+			case bip32.IsImpossibleChildError(err):
+				// No address is derived at an impossible child, so it can't be the entry's address
+			default:
+				return nil, err
+			}
+
+			if derived == nil || derived.String() != e.Address.String() {
+				mismatches = append(mismatches, PathMismatch{
+					Address: e.Address,
+					Path:    path,
+					Derived: derived,
+				})
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+// MigrateEntryPaths records the bip44 path of the entries of w that have none, if the seed derives their
+// address at it. dw is the decrypted copy of w, or w itself if it is not encrypted.
+// Returns the number of paths recorded, and the entries whose address is not derived at their path,
+// which are left without a path. Other wallet types are left unchanged.
+func MigrateEntryPaths(w, dw Wallet) (int, []PathMismatch, error) {
+	bw, ok := w.(*Bip44Wallet)
+	if !ok || !bw.ExternalEntries.missingPaths() && !bw.ChangeEntries.missingPaths() {
+		return 0, nil, nil
+	}
+
+	dbw, ok := dw.(*Bip44Wallet)
+	if !ok {
+		return 0, nil, fmt.Errorf("decrypted wallet type is %q, not %q", dw.Type(), WalletTypeBip44)
+	}
+
+	mismatches, err := dbw.VerifyEntryPaths()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	mismatched := make(map[string]struct{}, len(mismatches))
+	for _, m := range mismatches {
+		mismatched[m.Address.String()] = struct{}{}
+	}
+
+	n := bw.ExternalEntries.setPaths(bw.Meta.Bip44Coin(), mismatched)
+	n += bw.ChangeEntries.setPaths(bw.Meta.Bip44Coin(), mismatched)
+
+	return n, mismatches, nil
+}
+
+// migrateEntryPaths migrates the entry paths of w with MigrateEntryPaths, logging the mismatches.
+// Returns true if paths were recorded, in which case w must be saved.
+func migrateEntryPaths(w, dw Wallet) (bool, error) {
+	n, mismatches, err := MigrateEntryPaths(w, dw)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range mismatches {
+		logger.Critical().WithFields(logrus.Fields{
+			"wallet":  w.Filename(),
+			"address": m.Address,
+			"path":    m.Path,
+			"derived": m.Derived,
+		}).Error("Wallet address is not derived from the seed at its bip44 path, its path is not recorded")
+	}
+
+	if n > 0 {
+		logger.WithFields(logrus.Fields{
+			"wallet": w.Filename(),
+			"paths":  n,
+		}).Info("Recorded the bip44 paths of wallet addresses")
+	}
+
+	return n > 0, nil
+}
+
+func (entries Entries) missingPaths() bool {
+	for _, e := range entries {
+		if e.Path == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// setPaths records the bip44 path of the entries without one, except for the skipped addresses.
+// Returns the number of paths recorded.
+func (entries Entries) setPaths(coin bip44.CoinType, skip map[string]struct{}) int {
+	var n int
+	for i, e := range entries {
+		if e.Path != "" {
+			continue
+		}
+		if _, ok := skip[e.Address.String()]; ok {
+			continue
+		}
+		entries[i].Path = Bip44Path(coin, Bip44Account, e.Change, e.ChildNumber)
+		n++
+	}
+	return n
+}
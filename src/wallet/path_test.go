@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+)
+
+func newPathTestWallet(t *testing.T) *Bip44Wallet {
+	w, err := NewWallet("path.wlt", Options{
+		Seed:      bip39.MustNewDefaultMnemonic(),
+		Type:      WalletTypeBip44,
+		GenerateN: 3,
+	})
+	require.NoError(t, err)
+
+	bw := w.(*Bip44Wallet)
+	_, err = bw.GenerateChangeEntry()
+	require.NoError(t, err)
+
+	return bw
+}
+
+func clearEntryPaths(w *Bip44Wallet) {
+	for i := range w.ExternalEntries {
+		w.ExternalEntries[i].Path = ""
+	}
+	for i := range w.ChangeEntries {
+		w.ChangeEntries[i].Path = ""
+	}
+}
+
+func TestBip44Path(t *testing.T) {
+	require.Equal(t, "m/44'/8000'/0'/0/3", Bip44Path(bip44.CoinTypeSkycoin, 0, 0, 3))
+	require.Equal(t, "m/44'/0'/2'/1/0", Bip44Path(bip44.CoinTypeBitcoin, 2, 1, 0))
+}
+
+func TestEntryPaths(t *testing.T) {
+	bw := newPathTestWallet(t)
+
+	expected := []string{
+		"m/44'/8000'/0'/0/0",
+		"m/44'/8000'/0'/0/1",
+		"m/44'/8000'/0'/0/2",
+		"m/44'/8000'/0'/1/0",
+	}
+
+	// Generated entries record their path
+	for i, e := range bw.GetEntries() {
+		require.Equal(t, expected[i], e.Path)
+	}
+	require.Equal(t, expected, EntryPaths(bw))
+
+	// Entries without a recorded path have the path of their change and child numbers
+	clearEntryPaths(bw)
+	require.Equal(t, expected, EntryPaths(bw))
+
+	// Deterministic wallets have the generation index
+	dw, err := NewWallet("d.wlt", Options{
+		Seed:      "seed",
+		Type:      WalletTypeDeterministic,
+		GenerateN: 3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "1", "2"}, EntryPaths(dw))
+}
+
+func TestMigrateEntryPaths(t *testing.T) {
+	bw := newPathTestWallet(t)
+	expected := EntryPaths(bw)
+
+	// Nothing to migrate
+	n, mismatches, err := MigrateEntryPaths(bw, bw)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	require.Empty(t, mismatches)
+
+	// The paths of the derived addresses are recorded
+	clearEntryPaths(bw)
+	n, mismatches, err = MigrateEntryPaths(bw, bw)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Empty(t, mismatches)
+	for i, e := range bw.GetEntries() {
+		require.Equal(t, expected[i], e.Path)
+	}
+
+	// An address that is not derived at its path is reported and left without a path
+	clearEntryPaths(bw)
+	bw.ExternalEntries[1].ChildNumber = 7
+	n, mismatches, err = MigrateEntryPaths(bw, bw)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Len(t, mismatches, 1)
+	require.Equal(t, bw.ExternalEntries[1].Address, mismatches[0].Address)
+	require.Equal(t, "m/44'/8000'/0'/0/7", mismatches[0].Path)
+	require.NotNil(t, mismatches[0].Derived)
+	require.Equal(t, "", bw.ExternalEntries[1].Path)
+	require.Equal(t, expected[0], bw.ExternalEntries[0].Path)
+
+	// The seed is needed
+	bw = newPathTestWallet(t)
+	require.NoError(t, Lock(bw, []byte("pwd"), CryptoTypeSha256Xor))
+	clearEntryPaths(bw)
+	_, _, err = MigrateEntryPaths(bw, bw)
+	require.Equal(t, ErrWalletEncrypted, err)
+
+	// Other wallets are left unchanged
+	dw, err := NewWallet("d.wlt", Options{
+		Seed:      "seed",
+		Type:      WalletTypeDeterministic,
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+	n, _, err = MigrateEntryPaths(dw, dw)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestLockMigratesEntryPaths(t *testing.T) {
+	bw := newPathTestWallet(t)
+	expected := EntryPaths(bw)
+
+	clearEntryPaths(bw)
+	require.True(t, needsEntryPaths(bw))
+
+	require.NoError(t, Lock(bw, []byte("pwd"), CryptoTypeSha256Xor))
+	require.False(t, needsEntryPaths(bw))
+	for i, e := range bw.GetEntries() {
+		require.Equal(t, expected[i], e.Path)
+	}
+}
+
+func TestLoadEntryPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet-paths")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bw := newPathTestWallet(t)
+	require.NoError(t, Save(bw, dir))
+
+	w, err := Load(filepath.Join(dir, bw.Filename()))
+	require.NoError(t, err)
+	require.Equal(t, bw.GetEntries(), w.GetEntries())
+
+	// A recorded path must match the change and child numbers
+	bw.ExternalEntries[0].Path = "m/44'/8000'/0'/0/5"
+	require.NoError(t, Save(bw, dir))
+	_, err = Load(filepath.Join(dir, bw.Filename()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not its bip44 path m/44'/8000'/0'/0/0")
+
+	// Unencrypted wallets record the missing paths when the service loads them
+	clearEntryPaths(bw)
+	require.NoError(t, Save(bw, dir))
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.GetWallet(bw.Filename())
+	require.NoError(t, err)
+
+	w, err = Load(filepath.Join(dir, bw.Filename()))
+	require.NoError(t, err)
+	require.False(t, needsEntryPaths(w))
+	require.Equal(t, EntryPaths(bw), EntryPaths(w))
+}
@@ -15,11 +15,20 @@ type ReadableEntry struct {
 	Secret      string  `json:"secret_key"`
 	ChildNumber *uint32 `json:"child_number,omitempty"` // For bip32/bip44
 	Change      *uint32 `json:"change,omitempty"`       // For bip44
+	Path        string  `json:"path,omitempty"`         // For bip44
+	Label       string  `json:"label,omitempty"`
+	// EncryptedSecret is the secret key encrypted with the entry's own password [collection wallets]
+	EncryptedSecret  string `json:"encrypted_secret_key,omitempty"`
+	SecretCryptoType string `json:"secret_crypto_type,omitempty"`
 }
 
 // NewReadableEntry creates readable wallet entry
 func NewReadableEntry(coinType CoinType, walletType string, e Entry) ReadableEntry {
-	re := ReadableEntry{}
+	re := ReadableEntry{
+		Label:            e.Label,
+		EncryptedSecret:  e.EncryptedSecret,
+		SecretCryptoType: string(e.SecretCryptoType),
+	}
 	if !e.Address.Null() {
 		re.Address = e.Address.String()
 	}
@@ -45,6 +54,7 @@ func NewReadableEntry(coinType CoinType, walletType string, e Entry) ReadableEnt
 		re.ChildNumber = &cn
 		change := e.Change
 		re.Change = &change
+		re.Path = e.Path
 	case WalletTypeXPub:
 		cn := e.ChildNumber
 		re.ChildNumber = &cn
@@ -119,9 +129,29 @@ func newEntryFromReadable(coinType CoinType, walletType string, re *ReadableEntr
 		return nil, err
 	}
 
-	p, err := cipher.PubKeyFromHex(re.Public)
-	if err != nil {
-		return nil, err
+	var secretCryptoType CryptoType
+	if re.EncryptedSecret != "" {
+		if walletType != WalletTypeCollection {
+			return nil, fmt.Errorf("encrypted_secret_key should not be set for %q wallet type", walletType)
+		}
+		if re.Secret != "" {
+			return nil, errors.New("secret_key and encrypted_secret_key should not be set together")
+		}
+		secretCryptoType, err = CryptoTypeFromString(re.SecretCryptoType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret_crypto_type: %v", err)
+		}
+	} else if re.SecretCryptoType != "" {
+		return nil, errors.New("secret_crypto_type is set without encrypted_secret_key")
+	}
+
+	// Watch-only entries of collection wallets have no keys
+	var p cipher.PubKey
+	if re.Public != "" || re.Secret != "" || re.EncryptedSecret != "" || walletType != WalletTypeCollection {
+		p, err = cipher.PubKeyFromHex(re.Public)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Decodes the secret hex string if any
@@ -171,6 +201,10 @@ func newEntryFromReadable(coinType CoinType, walletType string, re *ReadableEntr
 			return nil, fmt.Errorf("change should not be set for %q wallet type", walletType)
 		}
 
+		if re.Path != "" {
+			return nil, fmt.Errorf("path should not be set for %q wallet type", walletType)
+		}
+
 	default:
 		if re.ChildNumber != nil {
 			return nil, fmt.Errorf("child_number should not be set for %q wallet type", walletType)
@@ -178,6 +212,9 @@ func newEntryFromReadable(coinType CoinType, walletType string, re *ReadableEntr
 		if re.Change != nil {
 			return nil, fmt.Errorf("change should not be set for %q wallet type", walletType)
 		}
+		if re.Path != "" {
+			return nil, fmt.Errorf("path should not be set for %q wallet type", walletType)
+		}
 	}
 
 	return &Entry{
@@ -186,6 +223,11 @@ func newEntryFromReadable(coinType CoinType, walletType string, re *ReadableEntr
 		Secret:      secret,
 		ChildNumber: childNumber,
 		Change:      change,
+		Path:        re.Path,
+		Label:       re.Label,
+
+		EncryptedSecret:  re.EncryptedSecret,
+		SecretCryptoType: secretCryptoType,
 	}, nil
 }
 
@@ -0,0 +1,441 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// MaxFinishedRecoveries is the number of finished recovery jobs whose status is kept
+const MaxFinishedRecoveries = 16
+
+var (
+	// ErrRecoveryInProgress is returned if a recovery is started while another recovery is running
+	ErrRecoveryInProgress = NewError(errors.New("a wallet recovery is already in progress"))
+	// ErrRecoveryNotExist is returned if a recovery job is not found
+	ErrRecoveryNotExist = NewError(errors.New("wallet recovery job doesn't exist"))
+	// ErrRecoveryFinished is returned if a recovery job that has finished is cancelled
+	ErrRecoveryFinished = NewError(errors.New("wallet recovery job has finished"))
+	// ErrRecoveryCancelled is the error of a recovery job that was cancelled
+	ErrRecoveryCancelled = NewError(errors.New("wallet recovery was cancelled"))
+	// ErrInvalidWalletFilename is returned if a new wallet's filename is not a file name with the wallet extension
+	ErrInvalidWalletFilename = NewError(fmt.Errorf("wallet filename must be a file name with the .%s extension", WalletExt))
+)
+
+// RecoveryState is the state of a wallet recovery job
+type RecoveryState string
+
+const (
+	// RecoveryStateRunning the recovery is scanning addresses
+	RecoveryStateRunning RecoveryState = "running"
+	// RecoveryStateCompleted the recovered wallet was saved
+	RecoveryStateCompleted RecoveryState = "completed"
+	// RecoveryStateFailed the recovery failed, the wallets are unchanged
+	RecoveryStateFailed RecoveryState = "failed"
+	// RecoveryStateCancelled the recovery was cancelled, the wallets are unchanged
+	RecoveryStateCancelled RecoveryState = "cancelled"
+)
+
+// RecoveryOptions are the options of a wallet recovery, see StartWalletRecovery
+type RecoveryOptions struct {
+	// WalletID is the wallet to recover. If it is not an existing wallet, a new wallet is created with this filename.
+	WalletID       string
+	Seed           string
+	SeedPassphrase string
+	// Password encrypts the recovered wallet, if not empty
+	Password []byte
+	// Type is the type of a new wallet, WalletTypeBip44 if empty. Ignored when recovering an existing wallet.
+	Type string
+	// Label is the label of a new wallet. Ignored when recovering an existing wallet.
+	Label string
+	// ScanN is the number of addresses scanned ahead for transaction history
+	ScanN uint64
+}
+
+// RecoveryStatus is the progress of a wallet recovery job
+type RecoveryStatus struct {
+	JobID    string
+	WalletID string
+	State    RecoveryState
+	ScanN    uint64
+	// AddressesScanned is the number of addresses whose transaction history was checked
+	AddressesScanned uint64
+	// AddressesFound is the number of scanned addresses with transaction history
+	AddressesFound uint64
+	// ChainIndex is the bip44 chain being scanned, 0 for external and 1 for change addresses
+	ChainIndex uint32
+	// ChildIndex is the child index of the next address to scan on the chain
+	ChildIndex uint32
+	Started    time.Time
+	Finished   time.Time
+	// Error is set if the recovery failed
+	Error string
+}
+
+// recoveryJob is a wallet recovery running in the background
+type recoveryJob struct {
+	sync.Mutex
+	status RecoveryStatus
+	quit   chan struct{}
+}
+
+func (j *recoveryJob) getStatus() RecoveryStatus {
+	j.Lock()
+	defer j.Unlock()
+	return j.status
+}
+
+func (j *recoveryJob) running() bool {
+	j.Lock()
+	defer j.Unlock()
+	return j.status.State == RecoveryStateRunning
+}
+
+func (j *recoveryJob) cancelled() bool {
+	select {
+	case <-j.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// finish records the result of the recovery
+func (j *recoveryJob) finish(err error) {
+	j.Lock()
+	defer j.Unlock()
+
+	j.status.Finished = time.Now().UTC()
+	switch err {
+	case nil:
+		j.status.State = RecoveryStateCompleted
+	case ErrRecoveryCancelled:
+		j.status.State = RecoveryStateCancelled
+	default:
+		j.status.State = RecoveryStateFailed
+		j.status.Error = err.Error()
+	}
+}
+
+// chainScanObserver is implemented by a TransactionsFinder that follows the progress of
+// a scan of a bip32 based wallet. It is told when the scan of a chain starts.
+type chainScanObserver interface {
+	scanningChain(chain, childIdx uint32)
+}
+
+// observeScanChain tells tf that the scan of a chain starts, if tf follows the progress of scans
+func observeScanChain(tf TransactionsFinder, chain, childIdx uint32) {
+	if o, ok := tf.(chainScanObserver); ok {
+		o.scanningChain(chain, childIdx)
+	}
+}
+
+// recoveryScanner is the TransactionsFinder of a recovery job, recording its progress
+// and stopping the scan when the job is cancelled
+type recoveryScanner struct {
+	tf  TransactionsFinder
+	job *recoveryJob
+}
+
+func (s recoveryScanner) AddressesActivity(addrs []cipher.Address) ([]bool, error) {
+	if s.job.cancelled() {
+		return nil, ErrRecoveryCancelled
+	}
+
+	active, err := s.tf.AddressesActivity(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	var found uint64
+	for _, a := range active {
+		if a {
+			found++
+		}
+	}
+
+	s.job.Lock()
+	defer s.job.Unlock()
+	s.job.status.AddressesScanned += uint64(len(addrs))
+	s.job.status.AddressesFound += found
+	s.job.status.ChildIndex += uint32(len(addrs))
+
+	return active, nil
+}
+
+func (s recoveryScanner) scanningChain(chain, childIdx uint32) {
+	s.job.Lock()
+	defer s.job.Unlock()
+	s.job.status.ChainIndex = chain
+	s.job.status.ChildIndex = childIdx
+}
+
+// StartWalletRecovery starts recovering a wallet from its seed in the background, scanning opts.ScanN addresses ahead,
+// and returns the ID of the recovery job. Only one recovery runs at a time.
+// If opts.WalletID is an existing encrypted wallet, it is recovered as by RecoverWallet, otherwise a new wallet is
+// created from the seed. The wallets are only changed when the recovery completes.
+// Recovery jobs are kept in memory; a recovery running when the node stops is abandoned.
+func (serv *Service) StartWalletRecovery(opts RecoveryOptions, tf TransactionsFinder) (string, error) {
+	if opts.Seed == "" {
+		return "", ErrMissingSeed
+	}
+
+	if opts.ScanN > 0 && tf == nil {
+		return "", ErrNilTransactionsFinder
+	}
+
+	serv.recoveryLock.Lock()
+	defer serv.recoveryLock.Unlock()
+
+	for _, j := range serv.recoveries {
+		if j.running() {
+			return "", ErrRecoveryInProgress
+		}
+	}
+
+	existing, err := serv.recoveryPrecheck(opts)
+	if err != nil {
+		return "", err
+	}
+
+	job := &recoveryJob{
+		status: RecoveryStatus{
+			JobID:    hex.EncodeToString(cipher.RandByte(8)),
+			WalletID: opts.WalletID,
+			State:    RecoveryStateRunning,
+			ScanN:    opts.ScanN,
+			Started:  time.Now().UTC(),
+		},
+		quit: make(chan struct{}),
+	}
+
+	serv.pruneRecoveries()
+	serv.recoveries[job.status.JobID] = job
+
+	logger.WithFields(logrus.Fields{
+		"jobID":    job.status.JobID,
+		"walletID": opts.WalletID,
+		"existing": existing,
+		"scanN":    opts.ScanN,
+	}).Info("Starting wallet recovery")
+
+	done := serv.use(opts.WalletID)
+	go func() {
+		defer done()
+
+		err := serv.runRecovery(job, opts, existing, recoveryScanner{
+			tf:  tf,
+			job: job,
+		})
+		job.finish(err)
+
+		fields := logrus.Fields{
+			"jobID":    job.status.JobID,
+			"walletID": opts.WalletID,
+		}
+		switch err {
+		case nil:
+			logger.WithFields(fields).Info("Wallet recovery completed")
+		case ErrRecoveryCancelled:
+			logger.WithFields(fields).Info("Wallet recovery cancelled")
+		default:
+			logger.WithError(err).WithFields(fields).Error("Wallet recovery failed")
+		}
+	}()
+
+	return job.status.JobID, nil
+}
+
+// recoveryPrecheck checks that opts can recover a wallet before the recovery starts,
+// and returns whether opts.WalletID is an existing wallet
+func (serv *Service) recoveryPrecheck(opts RecoveryOptions) (bool, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return false, ErrWalletAPIDisabled
+	}
+
+	if _, ok := serv.headers[opts.WalletID]; ok {
+		w, err := serv.getWallet(opts.WalletID)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = recoveryOptions(w, opts.Seed, opts.SeedPassphrase, opts.Password)
+		return true, err
+	}
+
+	if filepath.Base(opts.WalletID) != opts.WalletID || !strings.HasSuffix(opts.WalletID, "."+WalletExt) {
+		return false, ErrInvalidWalletFilename
+	}
+
+	switch opts.Type {
+	case "", WalletTypeDeterministic, WalletTypeBip44:
+	default:
+		return false, ErrWalletTypeNotRecoverable
+	}
+
+	w, err := NewWallet(opts.WalletID, serv.newRecoveryOptions(opts))
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := serv.fingerprints[w.Fingerprint()]; ok {
+		return false, ErrSeedUsed
+	}
+
+	return false, nil
+}
+
+// newRecoveryOptions returns the unencrypted Options of a new wallet recovered with opts
+func (serv *Service) newRecoveryOptions(opts RecoveryOptions) Options {
+	wltType := opts.Type
+	if wltType == "" {
+		wltType = WalletTypeBip44
+	}
+
+	return serv.updateOptions(Options{
+		Type:           wltType,
+		Coin:           CoinTypeSkycoin,
+		Label:          opts.Label,
+		Seed:           opts.Seed,
+		SeedPassphrase: opts.SeedPassphrase,
+		GenerateN:      1,
+	})
+}
+
+// runRecovery recovers the wallet, scanning addresses with tf, and saves it
+func (serv *Service) runRecovery(job *recoveryJob, opts RecoveryOptions, existing bool, tf TransactionsFinder) error {
+	var wltOpts Options
+	if existing {
+		w, err := serv.GetWallet(opts.WalletID)
+		if err != nil {
+			return err
+		}
+
+		wltOpts, err = recoveryOptions(w, opts.Seed, opts.SeedPassphrase, opts.Password)
+		if err != nil {
+			return err
+		}
+	} else {
+		wltOpts = serv.newRecoveryOptions(opts)
+		if len(opts.Password) != 0 {
+			wltOpts.Encrypt = true
+			wltOpts.Password = opts.Password
+		}
+	}
+
+	// Scan before encrypting, then encrypt as requested
+	encrypt, password, cryptoType := wltOpts.Encrypt, wltOpts.Password, wltOpts.CryptoType
+	if cryptoType == "" {
+		cryptoType = serv.config.CryptoType
+	}
+	wltOpts.Encrypt = false
+	wltOpts.Password = nil
+
+	w, err := NewWallet(opts.WalletID, wltOpts)
+	if err != nil {
+		return err
+	}
+
+	if err := w.ScanAddresses(opts.ScanN, tf); err != nil {
+		return err
+	}
+
+	if encrypt {
+		if err := Lock(w, password, cryptoType); err != nil {
+			return err
+		}
+	}
+
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	if job.cancelled() {
+		return ErrRecoveryCancelled
+	}
+
+	if !existing {
+		return serv.addWallet(w)
+	}
+
+	old, err := serv.getWallet(opts.WalletID)
+	if err != nil {
+		return err
+	}
+
+	if old.Fingerprint() != w.Fingerprint() {
+		return ErrWalletRecoverSeedWrong
+	}
+
+	return serv.setRecoveredWallet(old, w)
+}
+
+// pruneRecoveries removes the oldest finished recovery jobs, keeping MaxFinishedRecoveries - 1 of them.
+// Caller must hold the recovery lock.
+func (serv *Service) pruneRecoveries() {
+	for len(serv.recoveries) >= MaxFinishedRecoveries {
+		var oldest *recoveryJob
+		for _, j := range serv.recoveries {
+			if j.running() {
+				continue
+			}
+			if oldest == nil || j.getStatus().Started.Before(oldest.getStatus().Started) {
+				oldest = j
+			}
+		}
+
+		if oldest == nil {
+			return
+		}
+
+		delete(serv.recoveries, oldest.getStatus().JobID)
+	}
+}
+
+// WalletRecoveryStatus returns the status of a recovery job
+func (serv *Service) WalletRecoveryStatus(jobID string) (*RecoveryStatus, error) {
+	serv.recoveryLock.Lock()
+	defer serv.recoveryLock.Unlock()
+
+	j, ok := serv.recoveries[jobID]
+	if !ok {
+		return nil, ErrRecoveryNotExist
+	}
+
+	status := j.getStatus()
+	return &status, nil
+}
+
+// CancelWalletRecovery cancels a running recovery job. The job stops before the next batch of
+// addresses is scanned and its state becomes RecoveryStateCancelled; the wallets are not changed.
+func (serv *Service) CancelWalletRecovery(jobID string) error {
+	serv.recoveryLock.Lock()
+	defer serv.recoveryLock.Unlock()
+
+	j, ok := serv.recoveries[jobID]
+	if !ok {
+		return ErrRecoveryNotExist
+	}
+
+	if !j.running() || j.cancelled() {
+		return ErrRecoveryFinished
+	}
+
+	close(j.quit)
+
+	logger.WithField("jobID", jobID).Info("Cancelling wallet recovery")
+
+	return nil
+}
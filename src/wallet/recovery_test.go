@@ -0,0 +1,195 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+)
+
+// blockingTxnsFinder blocks each AddressesActivity call until release is closed
+type blockingTxnsFinder struct {
+	mockTxnsFinder
+	called  chan struct{}
+	release chan struct{}
+}
+
+func (b blockingTxnsFinder) AddressesActivity(addrs []cipher.Address) ([]bool, error) {
+	select {
+	case b.called <- struct{}{}:
+	default:
+	}
+	<-b.release
+	return b.mockTxnsFinder.AddressesActivity(addrs)
+}
+
+func waitRecovery(t *testing.T, s *Service, jobID string) *RecoveryStatus {
+	for i := 0; i < 500; i++ {
+		status, err := s.WalletRecoveryStatus(jobID)
+		require.NoError(t, err)
+		if status.State != RecoveryStateRunning {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("wallet recovery did not finish")
+	return nil
+}
+
+func TestServiceStartWalletRecovery(t *testing.T) {
+	bip44Seed := "voyage say extend find sheriff surge priority merit ignore maple cash argue"
+	externalAddr := cipher.MustDecodeBase58Address("Aee3J9qoFPLoUEJes6YVzdKHdeuvCrMZeJ")
+	changeAddr := cipher.MustDecodeBase58Address("2ymjULRdbiFoUNJKNhWbQ3JqdE8TXnZkyU")
+
+	tf := mockTxnsFinder{
+		externalAddr: true,
+		changeAddr:   true,
+	}
+
+	dir := prepareWltDir()
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305Insecure,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "../t.wlt",
+		Seed:     bip44Seed,
+	}, tf)
+	require.Equal(t, ErrInvalidWalletFilename, err)
+
+	_, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t.wlt",
+		Seed:     bip44Seed,
+		Type:     WalletTypeCollection,
+	}, tf)
+	require.Equal(t, ErrWalletTypeNotRecoverable, err)
+
+	_, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t.wlt",
+		Seed:     bip44Seed,
+		ScanN:    5,
+	}, nil)
+	require.Equal(t, ErrNilTransactionsFinder, err)
+
+	jobID, err := s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t.wlt",
+		Seed:     bip44Seed,
+		Label:    "recovered",
+		Password: []byte("pwd"),
+		ScanN:    5,
+	}, tf)
+	require.NoError(t, err)
+
+	status := waitRecovery(t, s, jobID)
+	require.Equal(t, RecoveryStateCompleted, status.State, status.Error)
+	require.Equal(t, "t.wlt", status.WalletID)
+	require.Equal(t, uint64(2), status.AddressesFound)
+	require.True(t, status.AddressesScanned >= 10)
+	require.Equal(t, bip44.ChangeChainIndex, status.ChainIndex)
+	require.False(t, status.Finished.IsZero())
+
+	w, err := s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	require.Equal(t, "recovered", w.Label())
+
+	addrs := w.GetAddresses()
+	require.Contains(t, addrs, externalAddr)
+	require.Contains(t, addrs, changeAddr)
+
+	// The seed is used by a wallet now
+	_, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t2.wlt",
+		Seed:     bip44Seed,
+	}, tf)
+	require.Equal(t, ErrSeedUsed, err)
+
+	// The wrong seed does not recover the existing wallet
+	_, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID:       "t.wlt",
+		Seed:           bip44Seed,
+		SeedPassphrase: "foo",
+	}, tf)
+	require.Equal(t, ErrWalletRecoverSeedWrong, err)
+
+	// The existing encrypted wallet is recovered with a new password
+	jobID, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t.wlt",
+		Seed:     bip44Seed,
+		Password: []byte("pwd2"),
+	}, tf)
+	require.NoError(t, err)
+
+	status = waitRecovery(t, s, jobID)
+	require.Equal(t, RecoveryStateCompleted, status.State, status.Error)
+
+	noop := func(Wallet) error { return nil }
+	require.Equal(t, ErrInvalidPassword, s.ViewSecrets("t.wlt", []byte("pwd"), noop))
+	require.NoError(t, s.ViewSecrets("t.wlt", []byte("pwd2"), noop))
+
+	require.Equal(t, ErrRecoveryFinished, s.CancelWalletRecovery(jobID))
+	require.Equal(t, ErrRecoveryNotExist, s.CancelWalletRecovery("foo"))
+
+	_, err = s.WalletRecoveryStatus("foo")
+	require.Equal(t, ErrRecoveryNotExist, err)
+}
+
+func TestServiceCancelWalletRecovery(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305Insecure,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	tf := blockingTxnsFinder{
+		mockTxnsFinder: mockTxnsFinder{},
+		called:         make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+
+	jobID, err := s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t.wlt",
+		Seed:     "voyage say extend find sheriff surge priority merit ignore maple cash argue",
+		ScanN:    5,
+	}, tf)
+	require.NoError(t, err)
+
+	<-tf.called
+
+	// The wallet is not visible while the recovery runs
+	hs, err := s.ListWallets()
+	require.NoError(t, err)
+	require.Empty(t, hs)
+
+	status, err := s.WalletRecoveryStatus(jobID)
+	require.NoError(t, err)
+	require.Equal(t, RecoveryStateRunning, status.State)
+
+	// Only one recovery runs at a time
+	_, err = s.StartWalletRecovery(RecoveryOptions{
+		WalletID: "t2.wlt",
+		Seed:     "foo",
+		Type:     WalletTypeDeterministic,
+	}, tf)
+	require.Equal(t, ErrRecoveryInProgress, err)
+
+	require.NoError(t, s.CancelWalletRecovery(jobID))
+	require.Equal(t, ErrRecoveryFinished, s.CancelWalletRecovery(jobID))
+	close(tf.release)
+
+	status = waitRecovery(t, s, jobID)
+	require.Equal(t, RecoveryStateCancelled, status.State)
+	require.Empty(t, status.Error)
+
+	_, err = s.GetWallet("t.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+}
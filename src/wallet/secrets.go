@@ -6,6 +6,7 @@ const (
 	secretSeed           = "seed"
 	secretLastSeed       = "lastSeed"
 	secretSeedPassphrase = "seedPassphrase"
+	secretMetadataKey    = "metadataKey"
 )
 
 // Secrets hold secret data, to be encrypted
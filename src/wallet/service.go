@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
+	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/util/file"
 )
 
@@ -18,13 +20,30 @@ type TransactionsFinder interface {
 	AddressesActivity(addrs []cipher.Address) ([]bool, error)
 }
 
-// Service wallet service struct
+// Service wallet service struct.
+// Wallets are loaded from disk on first use, see loadedWallet, and can be unloaded with UnloadWallet.
 type Service struct {
 	sync.RWMutex
+	// wallets are the wallets loaded in memory
 	wallets Wallets
-	config  Config
+	// headers are the metadata of all wallets in the wallet directory, loaded or not, without secrets
+	headers map[string]Meta
+	// loadLock serializes access to wallets and fingerprints by wallet loads, which happen under the read lock
+	loadLock sync.Mutex
+	config   Config
 	// fingerprints is used to check for duplicate deterministic wallets
 	fingerprints map[string]string
+
+	// inUse counts the operations using each wallet, see use
+	inUse     map[string]int
+	inUseLock sync.Mutex
+
+	// metadataKeys are the metadata keys of encrypted wallets whose metadata is unlocked, see UnlockWalletMetadata
+	metadataKeys map[string][]byte
+
+	// recoveries are the wallet recovery jobs, kept in memory only, see StartWalletRecovery
+	recoveries   map[string]*recoveryJob
+	recoveryLock sync.Mutex
 }
 
 // Config wallet service config
@@ -34,6 +53,13 @@ type Config struct {
 	EnableWalletAPI bool
 	EnableSeedAPI   bool
 	Bip44Coin       *bip44.CoinType
+	// MaxBackups is the number of backups kept for each wallet, 0 disables backups
+	MaxBackups int
+	// RequireUniqueLabels makes creating a wallet or changing its label fail if another wallet has the label
+	RequireUniqueLabels bool
+	// ExplicitFormatMigrations makes MigrateWalletFormat the only way wallet files are upgraded to the current format.
+	// Otherwise unencrypted bip44 wallets get their entry paths when they are loaded and their account xpub when it is requested.
+	ExplicitFormatMigrations bool
 }
 
 // NewConfig creates a default Config
@@ -45,6 +71,7 @@ func NewConfig() Config {
 		EnableWalletAPI: false,
 		EnableSeedAPI:   false,
 		Bip44Coin:       &bc,
+		MaxBackups:      DefaultMaxBackups,
 	}
 }
 
@@ -52,7 +79,12 @@ func NewConfig() Config {
 func NewService(c Config) (*Service, error) {
 	serv := &Service{
 		config:       c,
+		wallets:      Wallets{},
+		headers:      make(map[string]Meta),
 		fingerprints: make(map[string]string),
+		inUse:        make(map[string]int),
+		metadataKeys: make(map[string][]byte),
+		recoveries:   make(map[string]*recoveryJob),
 	}
 
 	if !serv.config.EnableWalletAPI {
@@ -68,23 +100,40 @@ func NewService(c Config) (*Service, error) {
 		return nil, fmt.Errorf("remove .wlt.bak files in %v failed: %v", serv.config.WalletDir, err)
 	}
 
-	// Load all wallets from disk
-	w, err := loadWallets(serv.config.WalletDir)
+	// Read the metadata headers of all wallets on disk. The wallets are loaded when they are first used.
+	headers, err := loadWalletHeaders(serv.config.WalletDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load all wallets: %v", err)
 	}
 
 	// Abort if there are duplicate wallets (identified by fingerprint) on disk
-	if wltID, fp, hasDup := w.containsDuplicate(); hasDup {
+	if wltID, fp, hasDup := headers.containsDuplicate(); hasDup {
 		return nil, fmt.Errorf("duplicate wallet found with fingerprint %s in file %q", fp, wltID)
 	}
 
 	// Abort if there are empty deterministic wallets on disk
-	if wltID, hasEmpty := w.containsEmpty(); hasEmpty {
+	if wltID, hasEmpty := headers.containsEmpty(); hasEmpty {
 		return nil, fmt.Errorf("empty wallet file found: %q", wltID)
 	}
 
-	serv.setWallets(w)
+	serv.setHeaders(headers)
+
+	// Wallets created before unique labels were required may share a label, they are not renamed
+	if serv.config.RequireUniqueLabels {
+		labels := make(map[string]string, len(serv.headers))
+		for wltID, m := range serv.headers {
+			if m.Label() == "" {
+				continue
+			}
+			if id, ok := labels[m.Label()]; ok {
+				logger.WithFields(logrus.Fields{
+					"label":   m.Label(),
+					"wallets": []string{id, wltID},
+				}).Warning("Wallets share a label while unique labels are required")
+			}
+			labels[m.Label()] = wltID
+		}
+	}
 
 	fields := logrus.Fields{
 		"walletDir": serv.config.WalletDir,
@@ -135,6 +184,36 @@ func (serv *Service) CreateWallet(wltName string, options Options, tf Transactio
 	return serv.loadWallet(wltName, options, tf)
 }
 
+// backup backs up the wallet's file, if it exists. Caller must hold the lock.
+func (serv *Service) backup(w Wallet) error {
+	if err := BackupWalletFile(serv.config.WalletDir, w.Filename(), serv.config.MaxBackups); err != nil {
+		return fmt.Errorf("wallet backup failed: %v", err)
+	}
+	return nil
+}
+
+// save backs up the wallet's file, if it exists, then saves the wallet.
+// Caller must hold the lock.
+func (serv *Service) save(w Wallet) error {
+	if err := serv.backup(w); err != nil {
+		return err
+	}
+
+	return Save(w, serv.config.WalletDir)
+}
+
+// ListWalletBackups returns the backups of a wallet, oldest first.
+// The wallet does not need to be loaded, so that backups of a removed wallet can be found.
+func (serv *Service) ListWalletBackups(wltID string) ([]Backup, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	return ListBackups(serv.config.WalletDir, wltID)
+}
+
 // loadWallet loads wallet from seed and scan the first N addresses
 func (serv *Service) loadWallet(wltName string, options Options, tf TransactionsFinder) (Wallet, error) {
 	options = serv.updateOptions(options)
@@ -143,15 +222,25 @@ func (serv *Service) loadWallet(wltName string, options Options, tf Transactions
 		return nil, err
 	}
 
+	if err := serv.addWallet(w); err != nil {
+		return nil, err
+	}
+
+	return w.Clone(), nil
+}
+
+// addWallet saves a new wallet and adds it to the wallets, checking that its name and fingerprint
+// are not used by another wallet. Caller must hold the lock.
+func (serv *Service) addWallet(w Wallet) error {
 	fingerprint := w.Fingerprint()
 	if fingerprint != "" {
 		if _, ok := serv.fingerprints[fingerprint]; ok {
 			// Note: collection wallets do not have fingerprints
 			switch w.Type() {
 			case WalletTypeDeterministic, WalletTypeBip44:
-				return nil, ErrSeedUsed
+				return ErrSeedUsed
 			case WalletTypeXPub:
-				return nil, ErrXPubKeyUsed
+				return ErrXPubKeyUsed
 			default:
 				logger.WithFields(logrus.Fields{
 					"walletType":  w.Type(),
@@ -161,27 +250,47 @@ func (serv *Service) loadWallet(wltName string, options Options, tf Transactions
 		}
 	}
 
-	if err := serv.wallets.add(w); err != nil {
-		return nil, err
+	if _, ok := serv.headers[w.Filename()]; ok {
+		return ErrWalletNameConflict
 	}
 
-	if err := Save(w, serv.config.WalletDir); err != nil {
-		// If save fails, remove the added wallet
-		serv.wallets.remove(w.Filename())
-		return nil, err
+	if serv.labelConflict(w.Filename(), w.Label()) {
+		return ErrWalletLabelConflict
 	}
 
+	if err := serv.save(w); err != nil {
+		return err
+	}
+
+	serv.setWallet(w)
+
 	if fingerprint != "" {
 		serv.fingerprints[fingerprint] = w.Filename()
 	}
 
-	return w.Clone(), nil
+	return nil
+}
+
+// labelConflict returns true if unique labels are required and a wallet other than wltID has the label.
+// Empty labels never conflict. Caller must hold the lock.
+func (serv *Service) labelConflict(wltID, label string) bool {
+	if !serv.config.RequireUniqueLabels || label == "" {
+		return false
+	}
+
+	for id, m := range serv.headers {
+		if id != wltID && m.Label() == label {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (serv *Service) generateUniqueWalletFilename() string {
 	wltName := NewWalletFilename()
 	for {
-		if w := serv.wallets.get(wltName); w == nil {
+		if _, ok := serv.headers[wltName]; !ok {
 			break
 		}
 		wltName = NewWalletFilename()
@@ -192,6 +301,7 @@ func (serv *Service) generateUniqueWalletFilename() string {
 
 // EncryptWallet encrypts wallet with password
 func (serv *Service) EncryptWallet(wltID string, password []byte) (Wallet, error) {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
@@ -212,17 +322,18 @@ func (serv *Service) EncryptWallet(wltID string, password []byte) (Wallet, error
 	}
 
 	// Save to disk first
-	if err := Save(w, serv.config.WalletDir); err != nil {
+	if err := serv.save(w); err != nil {
 		return nil, err
 	}
 
 	// Sets the encrypted wallet
-	serv.wallets.set(w)
+	serv.setWallet(w)
 	return w, nil
 }
 
 // DecryptWallet decrypts wallet with password
 func (serv *Service) DecryptWallet(wltID string, password []byte) (Wallet, error) {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
@@ -246,19 +357,133 @@ func (serv *Service) DecryptWallet(wltID string, password []byte) (Wallet, error
 	}
 
 	// Updates the wallet file
-	if err := Save(unlockWlt, serv.config.WalletDir); err != nil {
+	if err := serv.save(unlockWlt); err != nil {
 		return nil, err
 	}
 
+	// Decrypted wallets have no metadata key
+	serv.lockMetadata(wltID)
+
 	// Sets the decrypted wallet in memory
-	serv.wallets.set(unlockWlt)
+	serv.setWallet(unlockWlt)
 	return unlockWlt, nil
 }
 
+// EncryptWalletAddress encrypts the secret key of an address of a collection wallet with the address password.
+// The wallet password must be provided if the wallet is encrypted.
+func (serv *Service) EncryptWalletAddress(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error {
+	return serv.UpdateSecrets(wltID, password, func(w Wallet) error {
+		return EncryptEntry(w, addr, addrPassword, serv.config.CryptoType)
+	})
+}
+
+// DecryptWalletAddress removes the encryption of the secret key of an address of a collection wallet
+// with the address password. The wallet password must be provided if the wallet is encrypted.
+func (serv *Service) DecryptWalletAddress(wltID string, password []byte, addr cipher.Address, addrPassword []byte) error {
+	return serv.UpdateSecrets(wltID, password, func(w Wallet) error {
+		return DecryptEntry(w, addr, addrPassword)
+	})
+}
+
+// SignTransactionWithAddressPasswords signs a transaction like SignTransaction, with the secret keys of the
+// addresses encrypted with their own password decrypted by addrPasswords.
+// The wallet password is only needed to sign for addresses that are not encrypted with their own password.
+func (serv *Service) SignTransactionWithAddressPasswords(wltID string, password []byte, addrPasswords map[cipher.Address][]byte,
+	txn *coin.Transaction, signIndexes []int, uxOuts []coin.UxOut) (*coin.Transaction, error) {
+	var signedTxn *coin.Transaction
+	sign := func(w Wallet) error {
+		wlt, err := UnlockEntries(w, addrPasswords)
+		if err != nil {
+			return err
+		}
+		defer wlt.Erase()
+
+		signedTxn, err = SignTransaction(wlt, txn, signIndexes, uxOuts)
+		return err
+	}
+
+	var err error
+	if len(password) == 0 {
+		err = serv.View(wltID, sign)
+	} else {
+		err = serv.ViewSecrets(wltID, password, sign)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return signedTxn, nil
+}
+
 // NewAddresses generate address entries in given wallet,
 // return nil if wallet does not exist.
 // Set password as nil if the wallet is not encrypted, otherwise the password must be provided.
 func (serv *Service) NewAddresses(wltID string, password []byte, num uint64) ([]cipher.Address, error) {
+	return serv.newAddresses(wltID, password, num, nil)
+}
+
+// PreviewNewAddresses returns the next num addresses that NewAddresses would generate in a wallet, without generating them.
+// The wallet is not modified, so the same addresses are returned until they are generated.
+// A preview does not reserve the addresses: NewAddresses generates them for any caller,
+// use CommitNewAddresses to generate the previewed addresses only if no other caller did since the preview.
+func (serv *Service) PreviewNewAddresses(wltID string, password []byte, num uint64) ([]cipher.Address, error) {
+	defer serv.use(wltID)()
+	serv.RLock()
+	defer serv.RUnlock()
+
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	return previewAddresses(w, password, num)
+}
+
+// CommitNewAddresses generates the addresses returned by PreviewNewAddresses in a wallet.
+// Returns ErrPreviewedAddressesUsed and generates nothing if addrs are not the next addresses of the wallet,
+// because they were generated since the preview.
+func (serv *Service) CommitNewAddresses(wltID string, password []byte, addrs []cipher.Address) ([]cipher.Address, error) {
+	if len(addrs) == 0 {
+		return nil, NewError(errors.New("no previewed addresses to commit"))
+	}
+	return serv.newAddresses(wltID, password, uint64(len(addrs)), addrs)
+}
+
+// previewAddresses returns the next num addresses of a wallet, generated in a copy of the wallet
+func previewAddresses(w Wallet, password []byte, num uint64) ([]cipher.Address, error) {
+	var addrs []cipher.Address
+	f := func(wlt Wallet) error {
+		var err error
+		addrs, err = wlt.GenerateSkycoinAddresses(num)
+		return err
+	}
+
+	if w.IsEncrypted() {
+		if err := GuardView(w, password, f); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(password) != 0 {
+			return nil, ErrWalletNotEncrypted
+		}
+
+		if err := f(w.Clone()); err != nil {
+			return nil, err
+		}
+	}
+
+	return addrs, nil
+}
+
+// newAddresses generates num addresses in a wallet.
+// If previewed is not nil, the addresses are generated only if they are the previewed addresses.
+// The lock is held from the check to the save, so that no other caller generates the previewed addresses in between.
+func (serv *Service) newAddresses(wltID string, password []byte, num uint64, previewed []cipher.Address) ([]cipher.Address, error) {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 
@@ -271,6 +496,19 @@ func (serv *Service) NewAddresses(wltID string, password []byte, num uint64) ([]
 		return nil, err
 	}
 
+	if previewed != nil {
+		next, err := previewAddresses(w, password, num)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, a := range next {
+			if a != previewed[i] {
+				return nil, ErrPreviewedAddressesUsed
+			}
+		}
+	}
+
 	var addrs []cipher.Address
 	f := func(wlt Wallet) error {
 		var err error
@@ -292,6 +530,11 @@ func (serv *Service) NewAddresses(wltID string, password []byte, num uint64) ([]
 		}
 	}
 
+	// Back up the wallet file before checking that it is writable, because the check truncates it
+	if err := serv.backup(w); err != nil {
+		return nil, err
+	}
+
 	// Checks if the wallet file is writable
 	wf := filepath.Join(serv.config.WalletDir, w.Filename())
 	if !file.IsWritable(wf) {
@@ -303,13 +546,14 @@ func (serv *Service) NewAddresses(wltID string, password []byte, num uint64) ([]
 		return nil, err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
 
 	return addrs, nil
 }
 
 // GetSkycoinAddresses returns all addresses in given wallet
 func (serv *Service) GetSkycoinAddresses(wltID string) ([]cipher.Address, error) {
+	defer serv.use(wltID)()
 	serv.RLock()
 	defer serv.RUnlock()
 	if !serv.config.EnableWalletAPI {
@@ -326,6 +570,7 @@ func (serv *Service) GetSkycoinAddresses(wltID string) ([]cipher.Address, error)
 
 // GetWallet returns wallet by id
 func (serv *Service) GetWallet(wltID string) (Wallet, error) {
+	defer serv.use(wltID)()
 	serv.RLock()
 	defer serv.RUnlock()
 	if !serv.config.EnableWalletAPI {
@@ -337,14 +582,89 @@ func (serv *Service) GetWallet(wltID string) (Wallet, error) {
 
 // returns the clone of the wallet of given id
 func (serv *Service) getWallet(wltID string) (Wallet, error) {
-	w := serv.wallets.get(wltID)
-	if w == nil {
-		return nil, ErrWalletNotExist
+	w, err := serv.loadedWallet(wltID)
+	if err != nil {
+		return nil, err
 	}
 	return w.Clone(), nil
 }
 
-// GetWallets returns all wallet clones
+// loadedWallet returns the wallet of given id, loading it from disk if it is not loaded.
+// Caller must hold the read lock or the lock.
+func (serv *Service) loadedWallet(wltID string) (Wallet, error) {
+	serv.loadLock.Lock()
+	defer serv.loadLock.Unlock()
+
+	if w := serv.wallets.get(wltID); w != nil {
+		return w, nil
+	}
+
+	if _, ok := serv.headers[wltID]; !ok {
+		return nil, ErrWalletNotExist
+	}
+
+	fn := filepath.Join(serv.config.WalletDir, wltID)
+	w, err := Load(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.Validate(); err != nil {
+		logger.WithError(err).WithField("filename", fn).Error("loadedWallet: wallet.Validate failed")
+		return nil, err
+	}
+
+	// Unencrypted bip44 wallets created before the entry paths were recorded get them when they are loaded.
+	// Encrypted wallets get them the next time they are unlocked and locked again.
+	if !w.IsEncrypted() && !serv.config.ExplicitFormatMigrations {
+		migrated, err := migrateEntryPaths(w, w)
+		if err != nil {
+			logger.WithError(err).WithField("filename", fn).Error("loadedWallet: failed to record the entry paths")
+		} else if migrated {
+			if err := Save(w, serv.config.WalletDir); err != nil {
+				logger.WithError(err).WithField("filename", fn).Error("loadedWallet: failed to save the entry paths")
+			}
+		}
+	}
+
+	// The fingerprint of a wallet is known from its header, unless it is an empty xpub wallet
+	if fp := w.Fingerprint(); fp != "" {
+		if id, ok := serv.fingerprints[fp]; ok && id != wltID {
+			return nil, fmt.Errorf("duplicate wallet found with fingerprint %s in file %q", fp, wltID)
+		}
+		serv.fingerprints[fp] = wltID
+	}
+
+	logger.WithField("filename", fn).Info("loadedWallet: loaded wallet")
+
+	serv.wallets[wltID] = w
+	return w, nil
+}
+
+// setWallet sets a wallet into the loaded wallets and updates its header. Caller must hold the lock.
+func (serv *Service) setWallet(w Wallet) {
+	serv.wallets.set(w)
+	serv.headers[w.Filename()] = headerMeta(walletMeta(w))
+}
+
+// use marks a wallet as in use by an operation, until the returned function is called.
+// UnloadWallet refuses to unload a wallet that is in use.
+func (serv *Service) use(wltID string) func() {
+	serv.inUseLock.Lock()
+	defer serv.inUseLock.Unlock()
+	serv.inUse[wltID]++
+
+	return func() {
+		serv.inUseLock.Lock()
+		defer serv.inUseLock.Unlock()
+		serv.inUse[wltID]--
+		if serv.inUse[wltID] == 0 {
+			delete(serv.inUse, wltID)
+		}
+	}
+}
+
+// GetWallets returns all wallet clones, loading the wallets that are not loaded
 func (serv *Service) GetWallets() (Wallets, error) {
 	serv.RLock()
 	defer serv.RUnlock()
@@ -352,15 +672,225 @@ func (serv *Service) GetWallets() (Wallets, error) {
 		return nil, ErrWalletAPIDisabled
 	}
 
-	wlts := make(Wallets, len(serv.wallets))
-	for k, w := range serv.wallets {
-		wlts[k] = w.Clone()
+	wlts := make(Wallets, len(serv.headers))
+	for wltID := range serv.headers {
+		w, err := serv.getWallet(wltID)
+		if err != nil {
+			return nil, err
+		}
+		wlts[wltID] = w
 	}
 	return wlts, nil
 }
 
-// UpdateWalletLabel updates the wallet label
+// WalletHeader is the metadata of a wallet, without secrets, and its entries if the wallet is loaded
+type WalletHeader struct {
+	Meta   Meta
+	Loaded bool
+	// Entries are the wallet's entries without secret keys, only set if the wallet is loaded
+	Entries Entries
+}
+
+// ListWallets returns the headers of all wallets, without loading the wallets that are not loaded
+func (serv *Service) ListWallets() ([]WalletHeader, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	serv.loadLock.Lock()
+	defer serv.loadLock.Unlock()
+
+	hs := make([]WalletHeader, 0, len(serv.headers))
+	for wltID, m := range serv.headers {
+		h := WalletHeader{
+			Meta: m.clone(),
+		}
+
+		if w := serv.wallets.get(wltID); w != nil {
+			h.Loaded = true
+			h.Entries = w.GetEntries()
+			h.Entries.erase()
+		}
+
+		hs = append(hs, h)
+	}
+
+	return hs, nil
+}
+
+// UnlockWalletMetadata unlocks the metadata of an encrypted wallet with its password, without decrypting its secrets.
+// The wallet and address labels can then be updated without the password, until LockWalletMetadata is called
+// or the wallet is unloaded. The wallet's metadata is checked against its HMAC, see UnlockMetadata.
+// A wallet encrypted before version 0.5 is unlocked once to add a metadata key to it,
+// and a bip44 wallet created before its account xpub or entry paths were stored is unlocked once to store them.
+func (serv *Service) UnlockWalletMetadata(wltID string, password []byte) error {
+	defer serv.use(wltID)()
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return err
+	}
+
+	if !w.IsEncrypted() {
+		return ErrWalletNotEncrypted
+	}
+
+	if w.MetadataKey() == "" || needsAccountXPub(w) || needsEntryPaths(w) {
+		// Relocking the wallet adds a metadata key, and stores the account xpub and entry paths of bip44 wallets
+		if err := GuardUpdate(w, password, func(Wallet) error { return nil }); err != nil {
+			return err
+		}
+
+		if err := serv.save(w); err != nil {
+			return err
+		}
+
+		serv.setWallet(w)
+	}
+
+	key, err := UnlockMetadata(w, password)
+	if err != nil {
+		return err
+	}
+
+	serv.lockMetadata(wltID)
+	serv.metadataKeys[wltID] = key
+	return nil
+}
+
+// AccountXPub is the stored xpub of an account of a bip44 wallet
+type AccountXPub struct {
+	Account uint32
+	XPub    string
+	// Encrypted is true if the wallet is encrypted, its xpub is stored unencrypted with its metadata
+	Encrypted bool
+}
+
+// needsAccountXPub returns true for bip44 wallets created before the account xpub was stored
+func needsAccountXPub(w Wallet) bool {
+	return w.Type() == WalletTypeBip44 && w.XPub() == ""
+}
+
+// needsEntryPaths returns true for bip44 wallets with entries whose path is not recorded, see MigrateEntryPaths
+func needsEntryPaths(w Wallet) bool {
+	bw, ok := w.(*Bip44Wallet)
+	return ok && (bw.ExternalEntries.missingPaths() || bw.ChangeEntries.missingPaths())
+}
+
+// GetWalletAccountXPub returns the stored xpub of an account of a bip44 wallet, without using its seed.
+// The xpub of account 0, the only account used by bip44 wallets, is stored when the wallet is created.
+// An unencrypted wallet created before the xpub was stored gets it from its seed on the first request,
+// or has it derived on each request with Config.ExplicitFormatMigrations.
+// Returns ErrAccountXPubNotStored for such an encrypted wallet, whose xpub is stored by UnlockWalletMetadata.
+func (serv *Service) GetWalletAccountXPub(wltID string, account uint32) (*AccountXPub, error) {
+	defer serv.use(wltID)()
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.Type() != WalletTypeBip44 {
+		return nil, NewError(fmt.Errorf("account xpubs are only stored in %q wallets", WalletTypeBip44))
+	}
+
+	if account != 0 {
+		return nil, NewError(fmt.Errorf("%q wallets only use account 0, the xpub of account %d is not stored", WalletTypeBip44, account))
+	}
+
+	if needsAccountXPub(w) {
+		if w.IsEncrypted() {
+			return nil, ErrAccountXPubNotStored
+		}
+
+		// The xpub is derived from the seed without being stored until the wallet is migrated
+		if serv.config.ExplicitFormatMigrations {
+			xpub, err := w.(*Bip44Wallet).AccountXPub()
+			if err != nil {
+				return nil, err
+			}
+
+			return &AccountXPub{
+				Account: account,
+				XPub:    xpub,
+			}, nil
+		}
+
+		if err := w.(*Bip44Wallet).storeAccountXPub(); err != nil {
+			return nil, err
+		}
+
+		if err := serv.save(w); err != nil {
+			return nil, err
+		}
+
+		serv.setWallet(w)
+	}
+
+	return &AccountXPub{
+		Account:   account,
+		XPub:      w.XPub(),
+		Encrypted: w.IsEncrypted(),
+	}, nil
+}
+
+// LockWalletMetadata wipes the metadata key of a wallet unlocked with UnlockWalletMetadata from memory
+func (serv *Service) LockWalletMetadata(wltID string) error {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	if _, err := serv.getWallet(wltID); err != nil {
+		return err
+	}
+
+	serv.lockMetadata(wltID)
+	return nil
+}
+
+// lockMetadata wipes the metadata key of a wallet, if its metadata is unlocked
+func (serv *Service) lockMetadata(wltID string) {
+	if key, ok := serv.metadataKeys[wltID]; ok {
+		wipeBytes(key)
+		delete(serv.metadataKeys, wltID)
+	}
+}
+
+// updateMetadata executes a function that edits the labels of a wallet.
+// The metadata of an encrypted wallet must be unlocked with UnlockWalletMetadata,
+// unless it was encrypted before version 0.5 and has no metadata key.
+func (serv *Service) updateMetadata(w Wallet, f func(Wallet) error) error {
+	if !w.IsEncrypted() || w.MetadataKey() == "" {
+		return f(w)
+	}
+
+	key, ok := serv.metadataKeys[w.Filename()]
+	if !ok {
+		return ErrWalletMetadataLocked
+	}
+
+	return UpdateMetadata(w, key, f)
+}
+
+// UpdateWalletLabel updates the wallet label.
+// Returns ErrWalletMetadataLocked if the wallet is encrypted and its metadata is not unlocked,
+// and ErrWalletLabelConflict if unique labels are required and another wallet has the label.
 func (serv *Service) UpdateWalletLabel(wltID, label string) error {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
@@ -372,41 +902,144 @@ func (serv *Service) UpdateWalletLabel(wltID, label string) error {
 		return err
 	}
 
-	w.SetLabel(label)
+	// The check and the update happen under the lock, so no other wallet can take the label in between
+	if serv.labelConflict(wltID, label) {
+		return ErrWalletLabelConflict
+	}
 
-	if err := Save(w, serv.config.WalletDir); err != nil {
+	if err := serv.updateMetadata(w, func(w Wallet) error {
+		w.SetLabel(label)
+		return nil
+	}); err != nil {
 		return err
 	}
 
-	serv.wallets.set(w)
+	if err := serv.save(w); err != nil {
+		return err
+	}
+
+	serv.setWallet(w)
 	return nil
 }
 
-// UnloadWallet removes wallet of given wallet id from the service
-func (serv *Service) UnloadWallet(wltID string) error {
+// SetWalletReuseChange sets whether a bip44 wallet sends change to one of the spent addresses,
+// instead of a new change chain address
+func (serv *Service) SetWalletReuseChange(wltID string, reuse bool) error {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
 		return ErrWalletAPIDisabled
 	}
 
-	wlt := serv.wallets.get(wltID)
-	if wlt != nil {
-		if fp := wlt.Fingerprint(); fp != "" {
-			delete(serv.fingerprints, fp)
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return err
+	}
+
+	if w.Type() != WalletTypeBip44 {
+		return NewError(fmt.Errorf("reuseChange is only used for %q wallets", WalletTypeBip44))
+	}
+
+	w.SetReuseChange(reuse)
+
+	if err := serv.save(w); err != nil {
+		return err
+	}
+
+	serv.setWallet(w)
+	return nil
+}
+
+// UpdateAddressLabel updates the label of an address in the wallet.
+// Returns ErrWalletMetadataLocked if the wallet is encrypted and its metadata is not unlocked.
+func (serv *Service) UpdateAddressLabel(wltID string, addr cipher.Address, label string) error {
+	defer serv.use(wltID)()
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return err
+	}
+
+	if err := serv.updateMetadata(w, func(w Wallet) error {
+		if !w.SetEntryLabel(addr, label) {
+			return ErrUnknownAddress
 		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := serv.save(w); err != nil {
+		return err
 	}
 
-	serv.wallets.remove(wltID)
+	serv.setWallet(w)
 	return nil
 }
 
-func (serv *Service) setWallets(wlts Wallets) {
-	serv.wallets = wlts
+// GetWalletAddressesByLabels returns the addresses in the wallet whose label matches any of the given labels
+func (serv *Service) GetWalletAddressesByLabels(wltID string, labels []string) ([]cipher.Address, error) {
+	defer serv.use(wltID)()
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, err := serv.loadedWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
 
-	for wltID, wlt := range wlts {
-		if fp := wlt.Fingerprint(); fp != "" {
-			serv.fingerprints[fp] = wltID
+	return AddressesWithLabels(w, labels)
+}
+
+// UnloadWallet removes wallet of given wallet id from memory, wiping its secrets.
+// The wallet is loaded from disk again when it is next used.
+// Returns ErrWalletInUse if the wallet is used by another operation, including one waiting to start.
+func (serv *Service) UnloadWallet(wltID string) error {
+	serv.inUseLock.Lock()
+	inUse := serv.inUse[wltID] != 0
+	serv.inUseLock.Unlock()
+	if inUse {
+		return ErrWalletInUse
+	}
+
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	if w := serv.wallets.get(wltID); w != nil {
+		eraseWallet(w)
+		serv.wallets.remove(wltID)
+	}
+
+	serv.lockMetadata(wltID)
+
+	return nil
+}
+
+// eraseWallet wipes the secrets of a wallet, including its encrypted secrets
+func eraseWallet(w Wallet) {
+	w.Erase()
+	if w.IsEncrypted() {
+		w.SetEncrypted(w.CryptoType(), "")
+	}
+}
+
+func (serv *Service) setHeaders(hs walletHeaders) {
+	for wltID, h := range hs {
+		serv.headers[wltID] = h.meta
+		if h.fingerprint != "" {
+			serv.fingerprints[h.fingerprint] = wltID
 		}
 	}
 }
@@ -414,6 +1047,7 @@ func (serv *Service) setWallets(wlts Wallets) {
 // GetWalletSeed returns seed and seed passphrase of encrypted wallet of given wallet id
 // Returns ErrWalletNotEncrypted if it's not encrypted
 func (serv *Service) GetWalletSeed(wltID string, password []byte) (string, string, error) {
+	defer serv.use(wltID)()
 	serv.RLock()
 	defer serv.RUnlock()
 	if !serv.config.EnableWalletAPI {
@@ -447,6 +1081,7 @@ func (serv *Service) GetWalletSeed(wltID string, password []byte) (string, strin
 
 // UpdateSecrets opens a wallet for modification of secret data and saves it safely
 func (serv *Service) UpdateSecrets(wltID string, password []byte, f func(Wallet) error) error {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
@@ -471,17 +1106,18 @@ func (serv *Service) UpdateSecrets(wltID string, password []byte, f func(Wallet)
 	}
 
 	// Save the wallet first
-	if err := Save(w, serv.config.WalletDir); err != nil {
+	if err := serv.save(w); err != nil {
 		return err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
 
 	return nil
 }
 
 // Update opens a wallet for modification of non-secret data and saves it safely
 func (serv *Service) Update(wltID string, f func(Wallet) error) error {
+	defer serv.use(wltID)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
@@ -498,17 +1134,18 @@ func (serv *Service) Update(wltID string, f func(Wallet) error) error {
 	}
 
 	// Save the wallet first
-	if err := Save(w, serv.config.WalletDir); err != nil {
+	if err := serv.save(w); err != nil {
 		return err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
 
 	return nil
 }
 
 // ViewSecrets opens a wallet for reading secret data
 func (serv *Service) ViewSecrets(wltID string, password []byte, f func(Wallet) error) error {
+	defer serv.use(wltID)()
 	serv.RLock()
 	defer serv.RUnlock()
 	if !serv.config.EnableWalletAPI {
@@ -531,6 +1168,7 @@ func (serv *Service) ViewSecrets(wltID string, password []byte, f func(Wallet) e
 
 // View opens a wallet for reading non-secret data
 func (serv *Service) View(wltID string, f func(Wallet) error) error {
+	defer serv.use(wltID)()
 	serv.RLock()
 	defer serv.RUnlock()
 	if !serv.config.EnableWalletAPI {
@@ -548,6 +1186,7 @@ func (serv *Service) View(wltID string, f func(Wallet) error) error {
 // RecoverWallet recovers an encrypted wallet from seed.
 // The recovered wallet will be encrypted with the new password, if provided.
 func (serv *Service) RecoverWallet(wltName, seed, seedPassphrase string, password []byte) (Wallet, error) {
+	defer serv.use(wltName)()
 	serv.Lock()
 	defer serv.Unlock()
 	if !serv.config.EnableWalletAPI {
@@ -559,18 +1198,39 @@ func (serv *Service) RecoverWallet(wltName, seed, seedPassphrase string, passwor
 		return nil, err
 	}
 
+	opts, err := recoveryOptions(w, seed, seedPassphrase, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new wallet with the same number of addresses, encrypting if needed
+	w2, err := NewWallet(wltName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := serv.setRecoveredWallet(w, w2); err != nil {
+		return nil, err
+	}
+
+	return w2.Clone(), nil
+}
+
+// recoveryOptions checks that the seed recovers the encrypted wallet w, and returns the Options
+// of the recovered wallet, with the same number of addresses, encrypted if password is not empty
+func recoveryOptions(w Wallet, seed, seedPassphrase string, password []byte) (Options, error) {
 	if !w.IsEncrypted() {
-		return nil, ErrWalletNotEncrypted
+		return Options{}, ErrWalletNotEncrypted
 	}
 
 	switch w.Type() {
 	case WalletTypeDeterministic, WalletTypeBip44:
 	default:
-		return nil, ErrWalletTypeNotRecoverable
+		return Options{}, ErrWalletTypeNotRecoverable
 	}
 
 	// Create a wallet from this seed and compare the fingerprint
-	w2, err := NewWallet(wltName, Options{
+	w2, err := NewWallet(w.Filename(), Options{
 		Type:           w.Type(),
 		Coin:           w.Coin(),
 		Seed:           seed,
@@ -580,14 +1240,13 @@ func (serv *Service) RecoverWallet(wltName, seed, seedPassphrase string, passwor
 	if err != nil {
 		err = NewError(fmt.Errorf("RecoverWallet failed to create temporary wallet for fingerprint comparison: %v", err))
 		logger.Critical().WithError(err).Error()
-		return nil, err
+		return Options{}, err
 	}
 	if w.Fingerprint() != w2.Fingerprint() {
-		return nil, ErrWalletRecoverSeedWrong
+		return Options{}, ErrWalletRecoverSeedWrong
 	}
 
-	// Create a new wallet with the same number of addresses, encrypting if needed
-	w3, err := NewWallet(wltName, Options{
+	return Options{
 		Type:           w.Type(),
 		Coin:           w.Coin(),
 		Label:          w.Label(),
@@ -597,20 +1256,23 @@ func (serv *Service) RecoverWallet(wltName, seed, seedPassphrase string, passwor
 		Password:       password,
 		CryptoType:     w.CryptoType(),
 		GenerateN:      uint64(w.EntriesLen()),
-	})
-	if err != nil {
-		return nil, err
-	}
+	}, nil
+}
 
+// setRecoveredWallet replaces the wallet w with the wallet recovered from its seed. Caller must hold the lock.
+func (serv *Service) setRecoveredWallet(w, recovered Wallet) error {
 	// Preserve the timestamp of the old wallet
-	w3.SetTimestamp(w.Timestamp())
+	recovered.SetTimestamp(w.Timestamp())
 
 	// Save to disk
-	if err := Save(w3, serv.config.WalletDir); err != nil {
-		return nil, err
+	if err := serv.save(recovered); err != nil {
+		return err
 	}
 
-	serv.wallets.set(w3)
+	// The recovered wallet has a new metadata key
+	serv.lockMetadata(w.Filename())
 
-	return w3.Clone(), nil
+	serv.setWallet(recovered)
+
+	return nil
 }
@@ -61,7 +61,9 @@ func TestNewService(t *testing.T) {
 			})
 			require.NoError(t, err)
 
-			require.Equal(t, 11, len(s.wallets))
+			// The wallets are loaded when they are first used
+			require.Equal(t, 11, len(s.headers))
+			require.Equal(t, 0, len(s.wallets))
 
 		})
 	}
@@ -868,6 +870,98 @@ func TestServiceNewAddresses(t *testing.T) {
 	}
 }
 
+func TestServicePreviewNewAddresses(t *testing.T) {
+	bip44Seed := "voyage say extend find sheriff surge priority merit ignore maple cash argue"
+	var bip44Addrs []cipher.Address
+	for _, a := range []string{
+		"29cnQPHuWHCRF26LEAb2gR83ywnF3F9HduW",
+		"2ZUAv9MGSpDKR3dnKMUnrKqLenV22JXAxzP",
+		"fwNVThqdzH7JMsStoLrTpkVsemesbdGftm",
+	} {
+		bip44Addrs = append(bip44Addrs, cipher.MustDecodeBase58Address(a))
+	}
+
+	for _, encrypt := range []bool{false, true} {
+		for ct := range cryptoTable {
+			t.Run(fmt.Sprintf("encrypted=%v crypto=%v", encrypt, ct), func(t *testing.T) {
+				dir := prepareWltDir()
+				s, err := NewService(Config{
+					WalletDir:       dir,
+					CryptoType:      ct,
+					EnableWalletAPI: true,
+				})
+				require.NoError(t, err)
+
+				opts := Options{
+					Label: "label",
+					Seed:  bip44Seed,
+					Type:  WalletTypeBip44,
+				}
+				var pwd []byte
+				if encrypt {
+					pwd = []byte("pwd")
+					opts.Encrypt = true
+					opts.Password = pwd
+				}
+
+				w, err := s.CreateWallet(NewWalletFilename(), opts, nil)
+				require.NoError(t, err)
+
+				// Previews don't generate the addresses, so they return the same addresses
+				addrs, err := s.PreviewNewAddresses(w.Filename(), pwd, 2)
+				require.NoError(t, err)
+				require.Equal(t, bip44Addrs[:2], addrs)
+
+				addrs, err = s.PreviewNewAddresses(w.Filename(), pwd, 1)
+				require.NoError(t, err)
+				require.Equal(t, bip44Addrs[:1], addrs)
+
+				lw, err := Load(filepath.Join(dir, w.Filename()))
+				require.NoError(t, err)
+				require.Equal(t, 1, lw.EntriesLen())
+
+				// Committing the previewed addresses generates them
+				addrs, err = s.CommitNewAddresses(w.Filename(), pwd, bip44Addrs[:1])
+				require.NoError(t, err)
+				require.Equal(t, bip44Addrs[:1], addrs)
+
+				// The previewed addresses were generated since the preview, so they can't be committed again
+				_, err = s.CommitNewAddresses(w.Filename(), pwd, bip44Addrs[:2])
+				require.Equal(t, ErrPreviewedAddressesUsed, err)
+
+				lw, err = Load(filepath.Join(dir, w.Filename()))
+				require.NoError(t, err)
+				require.Equal(t, 2, lw.EntriesLen())
+
+				// A normal call generates the previewed address too
+				addrs, err = s.PreviewNewAddresses(w.Filename(), pwd, 1)
+				require.NoError(t, err)
+				require.Equal(t, bip44Addrs[1:2], addrs)
+
+				addrs, err = s.NewAddresses(w.Filename(), pwd, 1)
+				require.NoError(t, err)
+				require.Equal(t, bip44Addrs[1:2], addrs)
+
+				_, err = s.CommitNewAddresses(w.Filename(), pwd, bip44Addrs[1:2])
+				require.Equal(t, ErrPreviewedAddressesUsed, err)
+
+				addrs, err = s.PreviewNewAddresses(w.Filename(), pwd, 1)
+				require.NoError(t, err)
+				require.Equal(t, bip44Addrs[2:3], addrs)
+
+				if encrypt {
+					_, err = s.PreviewNewAddresses(w.Filename(), nil, 1)
+					require.Equal(t, ErrMissingPassword, err)
+					checkNoSensitiveData(t, s.wallets[w.Filename()])
+				} else {
+					_, err = s.PreviewNewAddresses(w.Filename(), []byte("pwd"), 1)
+					require.Equal(t, ErrWalletNotEncrypted, err)
+				}
+			})
+		}
+	}
+}
+
 func TestServiceGetAddress(t *testing.T) {
 	for _, enableWalletAPI := range []bool{true, false} {
 		for ct := range cryptoTable {
@@ -1028,6 +1122,124 @@ func TestServiceGetWallets(t *testing.T) {
 	}
 }
 
+func TestServiceLazyLoad(t *testing.T) {
+	s, err := NewService(Config{
+		WalletDir:       "./testdata",
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, s.wallets)
+
+	_, err = s.GetWallet("test1.wlt")
+	require.NoError(t, err)
+	require.Len(t, s.wallets, 1)
+
+	// Listing the wallets does not load them
+	hs, err := s.ListWallets()
+	require.NoError(t, err)
+	require.Len(t, hs, 11)
+	require.Len(t, s.wallets, 1)
+
+	for _, h := range hs {
+		require.Empty(t, h.Meta.Seed())
+		require.Empty(t, h.Meta.Secrets())
+
+		switch h.Meta.Filename() {
+		case "test1.wlt":
+			require.True(t, h.Loaded)
+			require.Len(t, h.Entries, 1)
+			require.True(t, h.Entries[0].Secret.Null())
+		case "sha256xor-encrypted.wlt":
+			require.True(t, h.Meta.IsEncrypted())
+			fallthrough
+		default:
+			require.False(t, h.Loaded)
+			require.Empty(t, h.Entries)
+		}
+	}
+}
+
+func TestServiceUnloadWallet(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	seed := bip39.MustNewDefaultMnemonic()
+	password := []byte("pwd")
+	w, err := s.CreateWallet("t.wlt", Options{
+		Seed:     seed,
+		Type:     WalletTypeDeterministic,
+		Encrypt:  true,
+		Password: password,
+	}, nil)
+	require.NoError(t, err)
+
+	loaded := s.wallets["t.wlt"]
+	require.NotEmpty(t, loaded.Secrets())
+
+	// A wallet used by another operation is not unloaded
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := s.View("t.wlt", func(Wallet) error {
+			close(started)
+			<-release
+			return nil
+		})
+		require.NoError(t, err)
+	}()
+
+	<-started
+	require.Equal(t, ErrWalletInUse, s.UnloadWallet("t.wlt"))
+	close(release)
+	<-done
+
+	// The unloaded wallet's secrets are wiped
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	require.Empty(t, s.wallets)
+	require.Empty(t, loaded.Secrets())
+	checkNoSensitiveData(t, loaded)
+
+	hs, err := s.ListWallets()
+	require.NoError(t, err)
+	require.Len(t, hs, 1)
+	require.False(t, hs[0].Loaded)
+
+	// The wallet is loaded again on next use
+	w2, err := s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, w, w2)
+	require.NoError(t, s.ViewSecrets("t.wlt", password, func(w Wallet) error {
+		require.Equal(t, seed, w.Seed())
+		return nil
+	}))
+
+	// The seed of an unloaded wallet can't be reused
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	_, err = s.CreateWallet("t2.wlt", Options{
+		Seed: seed,
+		Type: WalletTypeDeterministic,
+	}, nil)
+	require.Equal(t, ErrSeedUsed, err)
+
+	// Nor can its name
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed: bip39.MustNewDefaultMnemonic(),
+		Type: WalletTypeDeterministic,
+	}, nil)
+	require.Equal(t, ErrWalletNameConflict, err)
+
+	// Unloading a wallet that is not loaded does nothing
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	require.NoError(t, s.UnloadWallet("does_not_exist.wlt"))
+}
+
 func TestServiceUpdateWalletLabel(t *testing.T) {
 	tt := []struct {
 		name             string
@@ -1113,6 +1325,129 @@ func TestServiceUpdateWalletLabel(t *testing.T) {
 	}
 }
 
+func TestServiceUniqueWalletLabels(t *testing.T) {
+	for _, unique := range []bool{false, true} {
+		t.Run(fmt.Sprintf("unique=%v", unique), func(t *testing.T) {
+			dir := prepareWltDir()
+			s, err := NewService(Config{
+				WalletDir:           dir,
+				CryptoType:          CryptoTypeScryptChacha20poly1305Insecure,
+				EnableWalletAPI:     true,
+				RequireUniqueLabels: unique,
+			})
+			require.NoError(t, err)
+
+			_, err = s.CreateWallet("t1.wlt", Options{
+				Seed:  bip39.MustNewDefaultMnemonic(),
+				Label: "savings",
+				Type:  WalletTypeBip44,
+			}, nil)
+			require.NoError(t, err)
+
+			_, err = s.CreateWallet("t2.wlt", Options{
+				Seed:  bip39.MustNewDefaultMnemonic(),
+				Label: "spending",
+				Type:  WalletTypeBip44,
+			}, nil)
+			require.NoError(t, err)
+
+			// Creating a wallet with a label in use
+			_, err = s.CreateWallet("t3.wlt", Options{
+				Seed:  bip39.MustNewDefaultMnemonic(),
+				Label: "savings",
+				Type:  WalletTypeBip44,
+			}, nil)
+			if unique {
+				require.Equal(t, ErrWalletLabelConflict, err)
+				_, err = s.GetWallet("t3.wlt")
+				require.Equal(t, ErrWalletNotExist, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Renaming a wallet to a label in use fails without changing the wallet
+			err = s.UpdateWalletLabel("t2.wlt", "savings")
+			if unique {
+				require.Equal(t, ErrWalletLabelConflict, err)
+				w, err := s.GetWallet("t2.wlt")
+				require.NoError(t, err)
+				require.Equal(t, "spending", w.Label())
+
+				w, err = Load(filepath.Join(dir, "t2.wlt"))
+				require.NoError(t, err)
+				require.Equal(t, "spending", w.Label())
+			} else {
+				require.NoError(t, err)
+			}
+
+			// A wallet keeps its own label, and empty labels never conflict
+			require.NoError(t, s.UpdateWalletLabel("t1.wlt", "savings"))
+			require.NoError(t, s.UpdateWalletLabel("t1.wlt", ""))
+			require.NoError(t, s.UpdateWalletLabel("t2.wlt", ""))
+		})
+	}
+}
+
+func TestServiceAddressLabels(t *testing.T) {
+	for ct := range cryptoTable {
+		t.Run(fmt.Sprintf("%v", ct), func(t *testing.T) {
+			dir := prepareWltDir()
+			s, err := NewService(Config{
+				WalletDir:       dir,
+				CryptoType:      ct,
+				EnableWalletAPI: true,
+			})
+			require.NoError(t, err)
+
+			w, err := s.CreateWallet("t.wlt", Options{
+				Seed:      bip39.MustNewDefaultMnemonic(),
+				Label:     "label",
+				Type:      WalletTypeBip44,
+				GenerateN: 3,
+			}, nil)
+			require.NoError(t, err)
+
+			addrs, err := w.GetSkycoinAddresses()
+			require.NoError(t, err)
+			require.Len(t, addrs, 3)
+
+			_, err = s.GetWalletAddressesByLabels("t.wlt", []string{"hot"})
+			require.Equal(t, ErrNoAddressesWithLabels, err)
+
+			err = s.UpdateAddressLabel("t.wlt", addrs[0], "hot")
+			require.NoError(t, err)
+			err = s.UpdateAddressLabel("t.wlt", addrs[2], "cold")
+			require.NoError(t, err)
+
+			err = s.UpdateAddressLabel("t.wlt", testutil.MakeAddress(), "hot")
+			require.Equal(t, ErrUnknownAddress, err)
+			err = s.UpdateAddressLabel("t1.wlt", addrs[0], "hot")
+			require.Equal(t, ErrWalletNotExist, err)
+
+			labelled, err := s.GetWalletAddressesByLabels("t.wlt", []string{"hot"})
+			require.NoError(t, err)
+			require.Equal(t, []cipher.Address{addrs[0]}, labelled)
+
+			labelled, err = s.GetWalletAddressesByLabels("t.wlt", []string{"cold", "hot", "foo"})
+			require.NoError(t, err)
+			require.Equal(t, []cipher.Address{addrs[0], addrs[2]}, labelled)
+
+			_, err = s.GetWalletAddressesByLabels("t1.wlt", []string{"hot"})
+			require.Equal(t, ErrWalletNotExist, err)
+
+			// Labels are persisted to disk
+			lw, err := Load(filepath.Join(dir, "t.wlt"))
+			require.NoError(t, err)
+			e, ok := lw.GetEntry(addrs[0])
+			require.True(t, ok)
+			require.Equal(t, "hot", e.Label)
+			e, ok = lw.GetEntry(addrs[1])
+			require.True(t, ok)
+			require.Empty(t, e.Label)
+		})
+	}
+}
+
 func TestServiceEncryptWallet(t *testing.T) {
 	tt := []struct {
 		name             string
@@ -1608,6 +1943,9 @@ func TestServiceCreateWalletWithScan(t *testing.T) {
 		bip44ChangeAddrs[i] = cipher.MustDecodeBase58Address(a)
 	}
 
+	// The account 0 xpub of bip44Seed, which bip44 wallets store
+	bip44XPub := "xpub6BvenwtHLSGqw9v1GiwRe2uuDZW93Gi9H61Lqo9AaegvdcmysqLGYBmn1eku88NFb1nKJgp11vrvcEi8kxvpoZBNwxemDRWPustZzbUgUZt"
+
 	xpub := "xpub6E5WPk37XdM79dy6oJ7iH6NkCvVzxmrCo4zMFFHSZMc5ymZYhReQFWaDcGNZeYYe1ahY2e3RcRZDHLHC98FfzPRfNRcU6ecURpS4RCQRP2w"
 	xpubAddrStrs := []string{
 		"2mhaS6SE2TPSmRRbJvngWQSNXCCVuTic5Zg",
@@ -2692,7 +3030,11 @@ func TestServiceCreateWalletWithScan(t *testing.T) {
 					require.Equal(t, tc.expect.seed, w.Seed())
 					require.Equal(t, tc.expect.lastSeed, w.LastSeed())
 				}
-				require.Equal(t, tc.expect.xpub, w.XPub())
+				expectXPub := tc.expect.xpub
+				if tc.opts.Type == WalletTypeBip44 {
+					expectXPub = bip44XPub
+				}
+				require.Equal(t, expectXPub, w.XPub())
 				require.Equal(t, tc.expect.entryNum, w.EntriesLen())
 				for i, e := range w.GetEntries() {
 					require.Equal(t, tc.expect.addrs[i].String(), e.Address.String())
@@ -3778,3 +4120,57 @@ func checkNoSensitiveData(t *testing.T, w Wallet) {
 		require.True(t, e.Secret.Null())
 	}
 }
+
+// writeBenchmarkWallets writes n copies of a collection wallet with 20 entries to a new wallet directory
+func writeBenchmarkWallets(b *testing.B, n int) string {
+	w, err := NewWallet("bench.wlt", Options{
+		Type: WalletTypeCollection,
+	})
+	require.NoError(b, err)
+
+	cw := w.(*CollectionWallet)
+	for i := 0; i < 20; i++ {
+		pk, sk := cipher.GenerateKeyPair()
+		require.NoError(b, cw.AddEntry(Entry{
+			Address: cipher.AddressFromPubKey(pk),
+			Public:  pk,
+			Secret:  sk,
+		}))
+	}
+
+	dir := prepareWltDir()
+	for i := 0; i < n; i++ {
+		cw.Meta.SetFilename(fmt.Sprintf("bench%d.wlt", i))
+		require.NoError(b, Save(cw, dir))
+	}
+
+	return dir
+}
+
+func BenchmarkNewService(b *testing.B) {
+	dir := writeBenchmarkWallets(b, 500)
+	defer os.RemoveAll(dir)
+
+	b.Run("lazy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := NewService(Config{
+				WalletDir:       dir,
+				EnableWalletAPI: true,
+			})
+			require.NoError(b, err)
+		}
+	})
+
+	// Loading all wallets, as done at startup before wallets were loaded lazily
+	b.Run("load all", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s, err := NewService(Config{
+				WalletDir:       dir,
+				EnableWalletAPI: true,
+			})
+			require.NoError(b, err)
+			_, err = s.GetWallets()
+			require.NoError(b, err)
+		}
+	})
+}
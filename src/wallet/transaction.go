@@ -6,7 +6,9 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
-	"github.com/skycoin/skycoin/src/transaction"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	"github.com/ness-network/privateness/src/transaction"
 )
 
 var (
@@ -19,6 +21,23 @@ var (
 	ErrWalletCantSign = NewError(errors.New("wallet does not have the signing capability"))
 )
 
+// signInput signs an input of txn like coin.Transaction.SignInput, with pcoin.Transaction.SignInput
+// so that the signature nonces are deterministic
+func signInput(txn *coin.Transaction, key cipher.SecKey, index int) error {
+	// SignInput only reads the inner hash, the inputs and the signatures
+	ptxn := pcoin.Transaction{
+		InnerHash: txn.InnerHash,
+		Sigs:      txn.Sigs,
+		In:        txn.In,
+	}
+	if err := ptxn.SignInput(key, index); err != nil {
+		return err
+	}
+
+	txn.Sigs = ptxn.Sigs
+	return nil
+}
+
 func validateSignIndexes(x []int, uxOuts []coin.UxOut) error {
 	if len(x) > len(uxOuts) {
 		return errors.New("Number of signature indexes exceeds number of inputs")
@@ -77,7 +96,9 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 	signedTxn := copyTransaction(txn)
 	txnInnerHash := signedTxn.HashInner()
 
-	if w.IsEncrypted() {
+	// Collection wallets with entries encrypted with their own password can
+	// sign for the entries decrypted by UnlockEntries while the wallet is encrypted
+	if w.IsEncrypted() && !w.GetEntries().hasEncryptedSecrets() {
 		return nil, ErrWalletEncrypted
 	}
 
@@ -143,6 +164,20 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 		return nil, NewError(errors.New("Wallet cannot sign all requested inputs"))
 	}
 
+	// Check that the secret keys of the entries are available
+	for k := range toSign {
+		e := w.GetEntryAt(k)
+		if !e.Secret.Null() {
+			continue
+		}
+		if e.IsSecretEncrypted() {
+			return nil, newAddressPasswordError(e.Address, ErrEntryEncrypted)
+		}
+		if w.IsEncrypted() {
+			return nil, ErrWalletEncrypted
+		}
+	}
+
 	// Sign the selected inputs
 	for k, v := range toSign {
 		for _, x := range v {
@@ -150,7 +185,7 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 				return nil, NewError(fmt.Errorf("Transaction is already signed at index %d", x))
 			}
 
-			if err := signedTxn.SignInput(w.GetEntryAt(k).Secret, x); err != nil {
+			if err := signInput(signedTxn, w.GetEntryAt(k).Secret, x); err != nil {
 				return nil, err
 			}
 		}
@@ -192,7 +227,8 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 //     such that there would be no change output but hours remain as change, another output will be chosen to create change,
 //     if the coinhour cost of adding that output is less than the coinhours that would be lost as change
 // If receiving hours are not explicitly specified, hours are allocated amongst the receiving outputs proportional to the number of coins being sent to them.
-// If the change address is not specified, the address whose bytes are lexically sorted first is chosen from the owners of the outputs being spent.
+// If the change address is not specified, bip44 wallets send change to the next address of their change chain, which is added to the wallet,
+// unless the wallet's ReuseChange option is set. Otherwise, the address whose bytes are lexically sorted first is chosen from the owners of the outputs being spent.
 // WARNING: This method is not concurrent-safe if operating on the same wallet. Use Service.View or Service.ViewSecrets to lock the wallet, or use your own lock.
 func CreateTransaction(w Wallet, p transaction.Params, auxs coin.AddressUxOuts, headTime uint64) (*coin.Transaction, []transaction.UxBalance, error) {
 	if err := p.Validate(); err != nil {
@@ -208,7 +244,7 @@ func CreateTransaction(w Wallet, p transaction.Params, auxs coin.AddressUxOuts,
 
 	// Generate a new change address for bip44 wallets
 	var changeEntry *Entry
-	if p.ChangeAddress == nil && w.Type() == WalletTypeBip44 {
+	if p.ChangeAddress == nil && w.Type() == WalletTypeBip44 && !w.ReuseChange() {
 		e, err := w.(*Bip44Wallet).PeekChangeEntry()
 		if err != nil {
 			logger.Critical().WithError(err).Error("PeekChangeEntry failed")
@@ -259,26 +295,26 @@ func CreateTransactionSigned(w Wallet, p transaction.Params, auxs coin.AddressUx
 			entriesMap[s.Address] = entry
 		}
 
-		if err := txn.SignInput(entry.Secret, i); err != nil {
+		if err := signInput(txn, entry.Secret, i); err != nil {
 			logger.Critical().WithError(err).Errorf("CreateTransaction SignInput(%d) failed", i)
 			return nil, nil, err
 		}
 	}
 
 	// Sanity check the signed transaction
-	if err := verifyCreatedSignedInvariants(p, txn, uxb); err != nil {
+	if err := verifyCreatedSignedInvariants(p, txn, uxb, headTime); err != nil {
 		return nil, nil, err
 	}
 
 	return txn, uxb, nil
 }
 
-func verifyCreatedSignedInvariants(p transaction.Params, txn *coin.Transaction, inputs []transaction.UxBalance) error {
+func verifyCreatedSignedInvariants(p transaction.Params, txn *coin.Transaction, inputs []transaction.UxBalance, headTime uint64) error {
 	if !txn.IsFullySigned() {
 		return errors.New("Transaction is not fully signed")
 	}
 
-	if err := transaction.VerifyCreatedInvariants(p, txn, inputs); err != nil {
+	if err := transaction.VerifyCreatedInvariants(p, txn, inputs, headTime); err != nil {
 		return err
 	}
 
@@ -14,10 +14,15 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/testutil"
-	"github.com/skycoin/skycoin/src/transaction"
-	"github.com/skycoin/skycoin/src/util/fee"
+
+	pcoin "github.com/ness-network/privateness/src/coin"
+	ptestutil "github.com/ness-network/privateness/src/testutil"
+	"github.com/ness-network/privateness/src/transaction"
+	"github.com/ness-network/privateness/src/util/fee"
 )
 
 func TestWalletSignTransaction(t *testing.T) {
@@ -311,6 +316,36 @@ func TestWalletSignTransaction(t *testing.T) {
 	}
 }
 
+func TestWalletSignTransactionStableFixtures(t *testing.T) {
+	// The transaction is made from stable test key pairs, so its unsigned bytes are the same in every run
+	ux, s := makeStableUxOutWithSecret(0)
+
+	txn := coin.Transaction{}
+	err := txn.PushInput(ux.Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(makeStableAddress(1), 1e6, 50)
+	require.NoError(t, err)
+	txn.Sigs = make([]cipher.Sig, 1)
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+	require.Equal(t, "da1490fec3b650e5a58098b6bc1595cdf6b09907825513692b55f1a37b508187", txn.InnerHash.Hex())
+
+	w := &CollectionWallet{}
+	p := cipher.MustPubKeyFromSecKey(s)
+	err = w.AddEntry(Entry{
+		Address: cipher.AddressFromPubKey(p),
+		Public:  p,
+		Secret:  s,
+	})
+	require.NoError(t, err)
+
+	signedTxn, err := SignTransaction(w, &txn, nil, []coin.UxOut{ux})
+	require.NoError(t, err)
+	require.Equal(t, txn.InnerHash, signedTxn.InnerHash)
+	require.NoError(t, signedTxn.Verify())
+	require.NoError(t, signedTxn.VerifyInputSignatures([]coin.UxOut{ux}))
+}
+
 func TestWalletCreateTransaction(t *testing.T) {
 	headTime := uint64(time.Now().UTC().Unix())
 	seed := []byte("seed")
@@ -476,7 +511,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 					},
 				},
 			},
-			err: transaction.NewError(errors.New("total output coins error: uint64 addition overflow")),
+			err: transaction.NewError(pcoin.OutputError{Index: 1, Err: pcoin.ErrOutputCoinsOverflow}),
 		},
 
 		{
@@ -742,6 +777,85 @@ func TestWalletCreateTransaction(t *testing.T) {
 	}
 }
 
+func TestBip44WalletCreateTransactionChange(t *testing.T) {
+	headTime := uint64(time.Now().UTC().Unix())
+
+	w, err := NewWallet("test.wlt", Options{
+		Seed:  bip39.MustNewDefaultMnemonic(),
+		Label: "label",
+		Type:  WalletTypeBip44,
+	})
+	require.NoError(t, err)
+	require.False(t, w.ReuseChange())
+
+	addrs, err := w.GenerateSkycoinAddresses(2)
+	require.NoError(t, err)
+	entry, ok := w.GetEntry(addrs[1])
+	require.True(t, ok)
+
+	auxs := coin.AddressUxOuts{
+		addrs[1]: []coin.UxOut{
+			makeUxOut(t, entry.Secret, 10e6, 100),
+		},
+	}
+	auxs[addrs[1]][0].Head.Time = headTime
+
+	params := transaction.Params{
+		HoursSelection: transaction.HoursSelection{
+			Type: transaction.HoursSelectionTypeManual,
+		},
+		To: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Hours:   10,
+				Coins:   1e6,
+			},
+		},
+	}
+
+	changeAddress := func(txn *coin.Transaction) cipher.Address {
+		require.Len(t, txn.Out, 2)
+		return txn.Out[1].Address
+	}
+
+	// Change is sent to the next change chain address, which is added to the wallet
+	bw := w.(*Bip44Wallet)
+	for i := 0; i < 2; i++ {
+		txn, _, err := CreateTransaction(w, params, auxs, headTime)
+		require.NoError(t, err)
+		require.Len(t, bw.ChangeEntries, i+1)
+		require.Equal(t, uint32(i), bw.ChangeEntries[i].ChildNumber)
+		require.Equal(t, bip44.ChangeChainIndex, bw.ChangeEntries[i].Change)
+		require.Equal(t, bw.ChangeEntries[i].SkycoinAddress(), changeAddress(txn))
+	}
+
+	// An explicit change address is used as is
+	explicit := testutil.MakeAddress()
+	p := params
+	p.ChangeAddress = &explicit
+	txn, _, err := CreateTransaction(w, p, auxs, headTime)
+	require.NoError(t, err)
+	require.Equal(t, explicit, changeAddress(txn))
+	require.Len(t, bw.ChangeEntries, 2)
+
+	// With reuseChange, change is sent to a spent address
+	w.SetReuseChange(true)
+	require.NoError(t, w.Validate())
+	txn, _, err = CreateTransaction(w, params, auxs, headTime)
+	require.NoError(t, err)
+	require.Equal(t, addrs[1], changeAddress(txn))
+	require.Len(t, bw.ChangeEntries, 2)
+
+	// reuseChange is only used for bip44 wallets
+	_, err = NewWallet("test.wlt", Options{
+		Seed:        "seed",
+		Label:       "label",
+		Type:        WalletTypeDeterministic,
+		ReuseChange: true,
+	})
+	testutil.RequireError(t, err, `reuseChange is only used for "bip44" wallets`)
+}
+
 func makeTransaction(t *testing.T, nInputs int) (coin.Transaction, []coin.UxOut, []cipher.SecKey) {
 	txn := coin.Transaction{}
 
@@ -810,11 +924,35 @@ func makeUxBodyWithSecret(t *testing.T) (coin.UxBody, cipher.SecKey) {
 	}, s
 }
 
+// makeStableUxOutWithSecret creates a UxOut owned by the i-th stable test key pair.
+// The same i always creates the same UxOut, for golden tests.
+func makeStableUxOutWithSecret(i int) (coin.UxOut, cipher.SecKey) {
+	p, s := ptestutil.KeyPair(i)
+	return coin.UxOut{
+		Head: coin.UxHead{
+			Time:  100,
+			BkSeq: 2,
+		},
+		Body: coin.UxBody{
+			SrcTransaction: cipher.SumSHA256([]byte(fmt.Sprintf("src transaction %d", i))),
+			Address:        cipher.AddressFromPubKey(p),
+			Coins:          1e6,
+			Hours:          100,
+		},
+	}, s
+}
+
 func makeAddress() cipher.Address {
 	p, _ := cipher.GenerateKeyPair()
 	return cipher.AddressFromPubKey(p)
 }
 
+// makeStableAddress returns the address of the i-th stable test key pair
+func makeStableAddress(i int) cipher.Address {
+	p, _ := ptestutil.KeyPair(i)
+	return cipher.AddressFromPubKey(p)
+}
+
 func makeEntry() Entry {
 	p, s := cipher.GenerateKeyPair()
 	a := cipher.AddressFromPubKey(p)
@@ -38,7 +38,7 @@ func NewError(err error) error {
 
 var (
 	// Version represents the current wallet version
-	Version = "0.4"
+	Version = "0.5"
 
 	logger = logging.MustGetLogger("wallet")
 
@@ -84,6 +84,16 @@ var (
 	ErrWalletTypeNotRecoverable = NewError(errors.New("wallet type is not recoverable"))
 	// ErrWalletPermission is returned when updating a wallet without writing permission
 	ErrWalletPermission = NewError(errors.New("saving wallet permission denied"))
+	// ErrNoAddressesWithLabels is returned if no wallet address has any of the requested labels
+	ErrNoAddressesWithLabels = NewError(errors.New("no addresses in wallet match the given labels"))
+	// ErrWalletInUse is returned if a wallet can't be unloaded because another operation is using it
+	ErrWalletInUse = NewError(errors.New("wallet is in use"))
+	// ErrAccountXPubNotStored is returned for the account xpub of an encrypted bip44 wallet created before it was stored
+	ErrAccountXPubNotStored = NewError(errors.New("the account xpub is not stored in the wallet, unlock the wallet metadata once with the password to store it"))
+	// ErrPreviewedAddressesUsed is returned by CommitNewAddresses if the previewed addresses were generated since the preview
+	ErrPreviewedAddressesUsed = NewError(errors.New("the previewed addresses are no longer the next addresses of the wallet, they were generated since the preview"))
+	// ErrWalletLabelConflict is returned if unique wallet labels are required and another wallet has the label
+	ErrWalletLabelConflict = NewError(errors.New("another wallet already has this label"))
 )
 
 const (
@@ -162,6 +172,7 @@ type Options struct {
 	ScanN          uint64          // number of addresses that're going to be scanned for a balance. The highest address with a balance will be used.
 	GenerateN      uint64          // number of addresses to generate, regardless of balance
 	XPub           string          // xpub key (xpub wallets only)
+	ReuseChange    bool            // send change to a spent address instead of a new change address (bip44 wallets only)
 }
 
 // newWallet creates a wallet instance with given name and options.
@@ -203,6 +214,10 @@ func newWallet(wltName string, opts Options, tf TransactionsFinder) (Wallet, err
 		return nil, NewError(fmt.Errorf("xpub is only used for %q wallets", WalletTypeXPub))
 	}
 
+	if opts.ReuseChange && wltType != WalletTypeBip44 {
+		return nil, NewError(fmt.Errorf("reuseChange is only used for %q wallets", WalletTypeBip44))
+	}
+
 	switch wltType {
 	case WalletTypeDeterministic, WalletTypeBip44:
 		if opts.Seed == "" {
@@ -255,6 +270,7 @@ func newWallet(wltName string, opts Options, tf TransactionsFinder) (Wallet, err
 		metaSecrets:        "",
 		metaXPub:           opts.XPub,
 	}
+	meta.SetReuseChange(opts.ReuseChange)
 
 	// Create the wallet
 	var w Wallet
@@ -265,7 +281,13 @@ func newWallet(wltName string, opts Options, tf TransactionsFinder) (Wallet, err
 		w, err = newCollectionWallet(meta)
 	case WalletTypeBip44:
 		meta.setBip44Coin(bip44Coin)
-		w, err = newBip44Wallet(meta)
+		var bw *Bip44Wallet
+		bw, err = newBip44Wallet(meta)
+		if err == nil {
+			// The account xpub is stored, so that it can be returned without the seed
+			err = bw.storeAccountXPub()
+		}
+		w = bw
 	case WalletTypeXPub:
 		meta.setXPub(opts.XPub)
 		w, err = newXPubWallet(meta)
@@ -371,6 +393,12 @@ func NewWalletScanAhead(wltName string, opts Options, tf TransactionsFinder) (Wa
 
 // Lock encrypts the wallet with the given password and specific crypto type
 func Lock(w Wallet, password []byte, cryptoType CryptoType) error {
+	return lock(w, password, cryptoType, nil)
+}
+
+// lock encrypts the wallet and authenticates its metadata with the metadata key,
+// creating a new metadata key if it is nil
+func lock(w Wallet, password []byte, cryptoType CryptoType, metadataKey []byte) error {
 	if len(password) == 0 {
 		return ErrMissingPassword
 	}
@@ -381,6 +409,18 @@ func Lock(w Wallet, password []byte, cryptoType CryptoType) error {
 
 	wlt := w.Clone()
 
+	// Bip44 wallets created before the account xpub was stored get it while their seed is available
+	if bw, ok := wlt.(*Bip44Wallet); ok && bw.XPub() == "" {
+		if err := bw.storeAccountXPub(); err != nil {
+			return err
+		}
+	}
+
+	// Bip44 wallets created before the entry paths were recorded get them while their seed is available
+	if _, err := migrateEntryPaths(wlt, wlt); err != nil {
+		return err
+	}
+
 	// Records seeds in secrets
 	ss := make(Secrets)
 	defer func() {
@@ -391,6 +431,12 @@ func Lock(w Wallet, password []byte, cryptoType CryptoType) error {
 
 	wlt.PackSecrets(ss)
 
+	if metadataKey == nil {
+		metadataKey = newMetadataKey()
+		defer wipeBytes(metadataKey)
+	}
+	ss.set(secretMetadataKey, hex.EncodeToString(metadataKey))
+
 	sb, err := ss.serialize()
 	if err != nil {
 		return err
@@ -410,6 +456,11 @@ func Lock(w Wallet, password []byte, cryptoType CryptoType) error {
 	// Sets wallet as encrypted
 	wlt.SetEncrypted(cryptoType, string(encSecret))
 
+	// Authenticates the metadata, which is not encrypted
+	if err := setMetadataAuth(wlt, metadataKey, password, crypto); err != nil {
+		return err
+	}
+
 	// Update the wallet to the latest version, which indicates encryption support
 	wlt.SetVersion(Version)
 
@@ -425,15 +476,24 @@ func Lock(w Wallet, password []byte, cryptoType CryptoType) error {
 }
 
 // Unlock decrypts the wallet into a temporary decrypted copy of the wallet
-// Returns error if the decryption fails
+// Returns error if the decryption fails, or ErrWalletMetadataTampered if the
+// wallet's metadata does not match its HMAC.
 // The temporary decrypted wallet should be erased from memory when done.
 func Unlock(w Wallet, password []byte) (Wallet, error) {
+	wlt, metadataKey, err := unlock(w, password)
+	wipeBytes(metadataKey)
+	return wlt, err
+}
+
+// unlock decrypts the wallet like Unlock, and also returns its metadata key,
+// which is nil for wallets encrypted before version 0.5
+func unlock(w Wallet, password []byte) (Wallet, []byte, error) {
 	if !w.IsEncrypted() {
-		return nil, ErrWalletNotEncrypted
+		return nil, nil, ErrWalletNotEncrypted
 	}
 
 	if len(password) == 0 {
-		return nil, ErrMissingPassword
+		return nil, nil, ErrMissingPassword
 	}
 
 	wlt := w.Clone()
@@ -441,24 +501,24 @@ func Unlock(w Wallet, password []byte) (Wallet, error) {
 	// Gets the secrets string
 	sstr := w.Secrets()
 	if sstr == "" {
-		return nil, errors.New("secrets missing from wallet")
+		return nil, nil, errors.New("secrets missing from wallet")
 	}
 
 	ct := w.CryptoType()
 	if ct == "" {
-		return nil, errors.New("missing crypto type")
+		return nil, nil, errors.New("missing crypto type")
 	}
 
 	// Gets the crypto module
 	crypto, err := getCrypto(ct)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Decrypts the secrets
 	sb, err := crypto.Decrypt([]byte(sstr), password)
 	if err != nil {
-		return nil, ErrInvalidPassword
+		return nil, nil, ErrInvalidPassword
 	}
 
 	defer func() {
@@ -472,16 +532,38 @@ func Unlock(w Wallet, password []byte) (Wallet, error) {
 	ss := make(Secrets)
 	defer ss.erase()
 	if err := ss.deserialize(sb); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Checks the metadata, wallets encrypted before version 0.5 have no metadata key
+	var metadataKey []byte
+	if s, ok := ss.get(secretMetadataKey); ok {
+		metadataKey, err = decodeMetadataKey(s)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := verifyMetadataMAC(w, metadataKey); err != nil {
+			wipeBytes(metadataKey)
+			return nil, nil, err
+		}
 	}
 
 	if err := wlt.UnpackSecrets(ss); err != nil {
-		return nil, err
+		wipeBytes(metadataKey)
+		return nil, nil, err
 	}
 
 	wlt.SetDecrypted()
 
-	return wlt, nil
+	return wlt, metadataKey, nil
+}
+
+// wipeBytes zeroes b
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
 }
 
 // Wallet defines the wallet API
@@ -506,7 +588,18 @@ type Wallet interface {
 	SetVersion(string)
 	AddressConstructor() func(cipher.PubKey) cipher.Addresser
 	Secrets() string
+	MetadataKey() string
+	MetadataMAC() string
+	SetMetadataAuth(encryptedKey, mac string)
 	XPub() string
+	ReuseChange() bool
+	SetReuseChange(bool)
+	StrictPrivacy() bool
+	SetStrictPrivacy(bool)
+	AccessTokenHash() string
+	SetAccessTokenHash(string)
+	ApprovalPolicy() *ApprovalPolicy
+	SetApprovalPolicy(*ApprovalPolicy)
 
 	UnpackSecrets(ss Secrets) error
 	PackSecrets(ss Secrets)
@@ -526,6 +619,7 @@ type Wallet interface {
 	GetEntryAt(i int) Entry
 	GetEntry(cipher.Address) (Entry, bool)
 	HasEntry(cipher.Address) bool
+	SetEntryLabel(cipher.Address, string) bool
 	EntriesLen() int
 	GetEntries() Entries
 
@@ -534,6 +628,32 @@ type Wallet interface {
 	ScanAddresses(scanN uint64, tf TransactionsFinder) error
 }
 
+// AddressesWithLabels returns the addresses of the wallet's entries whose label matches
+// any of the given labels, in the order they appear in the wallet.
+// Returns ErrNoAddressesWithLabels if no entry matches.
+func AddressesWithLabels(w Wallet, labels []string) ([]cipher.Address, error) {
+	labelsMap := make(map[string]struct{}, len(labels))
+	for _, l := range labels {
+		labelsMap[l] = struct{}{}
+	}
+
+	var addrs []cipher.Address
+	for _, e := range w.GetEntries() {
+		if e.Label == "" {
+			continue
+		}
+		if _, ok := labelsMap[e.Label]; ok {
+			addrs = append(addrs, e.SkycoinAddress())
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil, ErrNoAddressesWithLabels
+	}
+
+	return addrs, nil
+}
+
 // GuardUpdate executes a function within the context of a read-write managed decrypted wallet.
 // Returns ErrWalletNotEncrypted if wallet is not encrypted.
 func GuardUpdate(w Wallet, password []byte, fn func(w Wallet) error) error {
@@ -546,18 +666,20 @@ func GuardUpdate(w Wallet, password []byte, fn func(w Wallet) error) error {
 	}
 
 	cryptoType := w.CryptoType()
-	wlt, err := Unlock(w, password)
+	wlt, metadataKey, err := unlock(w, password)
 	if err != nil {
 		return err
 	}
 
 	defer wlt.Erase()
+	defer wipeBytes(metadataKey)
 
 	if err := fn(wlt); err != nil {
 		return err
 	}
 
-	if err := Lock(wlt, password, cryptoType); err != nil {
+	// Keeps the metadata key, so that metadata unlocked with UnlockMetadata stays unlocked
+	if err := lock(wlt, password, cryptoType, metadataKey); err != nil {
 		return err
 	}
 
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/cipher/bip44"
 	"github.com/skycoin/skycoin/src/cipher/encrypt"
+	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/util/logging"
 )
 
@@ -1090,6 +1093,50 @@ func TestWalletCollectionAddEntry(t *testing.T) {
 	}
 }
 
+func TestWalletCollectionAddWatchAddress(t *testing.T) {
+	w, err := Load("./testdata/test4-collection.wlt")
+	require.NoError(t, err)
+	cw := w.(*CollectionWallet)
+	n := cw.EntriesLen()
+
+	addr := testutil.MakeAddress()
+	require.NoError(t, cw.AddWatchAddress(addr))
+	require.Equal(t, errors.New("wallet already contains entry with this address"), cw.AddWatchAddress(addr))
+	require.Equal(t, errors.New("address is null"), cw.AddWatchAddress(cipher.Address{}))
+
+	// Addresses of existing keypair entries are duplicates too
+	require.Equal(t, errors.New("wallet already contains entry with this address"), cw.AddWatchAddress(cw.GetEntryAt(0).Address))
+
+	btcAddr := cipher.BitcoinAddressFromPubKey(cipher.MustPubKeyFromSecKey(cipher.MustSecKeyFromHex("1fc5396e91e60b9fc613d004ea5bd2ccea17053a12127301b3857ead76fdb93e")))
+	require.Error(t, cw.AddWatchAddress(btcAddr))
+
+	require.Equal(t, n+1, cw.EntriesLen())
+	e, ok := cw.GetEntry(addr)
+	require.True(t, ok)
+	require.True(t, e.IsWatchOnly())
+	require.False(t, cw.GetEntryAt(0).IsWatchOnly())
+
+	// Watch-only entries survive saving, loading, locking and unlocking
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, Lock(cw, []byte("pwd"), CryptoTypeSha256Xor))
+	require.NoError(t, Save(cw, dir))
+
+	w2, err := Load(filepath.Join(dir, cw.Filename()))
+	require.NoError(t, err)
+	require.True(t, w2.IsEncrypted())
+
+	w3, err := Unlock(w2, []byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, n+1, w3.EntriesLen())
+	e, ok = w3.GetEntry(addr)
+	require.True(t, ok)
+	require.True(t, e.IsWatchOnly())
+	require.False(t, w3.GetEntryAt(0).Secret.Null())
+}
+
 func TestWalletGuard(t *testing.T) {
 	cases := []struct {
 		name       string
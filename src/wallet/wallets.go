@@ -1,7 +1,6 @@
 package wallet
 
 import (
-	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
@@ -10,62 +9,76 @@ import (
 // Wallets wallets map
 type Wallets map[string]Wallet
 
-// loadWallets Loads all wallets contained in wallet dir.  If any regular file in wallet
-// dir fails to load, loading is aborted and error returned.  Only files with
-// extension WalletExt are considered.
-func loadWallets(dir string) (Wallets, error) {
+// walletHeaders are the headers of the wallet files in a directory, by wallet ID
+type walletHeaders map[string]*walletHeader
+
+// loadWalletHeaders reads the headers of all wallets contained in wallet dir, see loadWalletHeader.
+// If any regular file in wallet dir fails to load, loading is aborted and error returned.
+// Only files with extension WalletExt are considered.
+func loadWalletHeaders(dir string) (walletHeaders, error) {
 	entries, err := ioutil.ReadDir(dir)
 	if err != nil {
-		logger.WithError(err).WithField("dir", dir).Error("loadWallets: ioutil.ReadDir failed")
+		logger.WithError(err).WithField("dir", dir).Error("loadWalletHeaders: ioutil.ReadDir failed")
 		return nil, err
 	}
 
-	wallets := Wallets{}
+	headers := walletHeaders{}
 	for _, e := range entries {
 		if e.Mode().IsRegular() {
 			name := e.Name()
 			if !strings.HasSuffix(name, WalletExt) {
-				logger.WithField("filename", name).Info("loadWallets: skipping file")
+				logger.WithField("filename", name).Info("loadWalletHeaders: skipping file")
 				continue
 			}
 
 			fullpath := filepath.Join(dir, name)
-			w, err := Load(fullpath)
+			h, err := loadWalletHeader(fullpath)
 			if err != nil {
-				logger.WithError(err).WithField("filename", fullpath).Error("loadWallets: loadWallet failed")
+				logger.WithError(err).WithField("filename", fullpath).Error("loadWalletHeaders: loadWalletHeader failed")
 				return nil, err
 			}
 
-			logger.WithField("filename", fullpath).Info("loadWallets: loaded wallet")
-
-			wallets[name] = w
+			headers[name] = h
 		}
 	}
 
-	for name, w := range wallets {
-		if err := w.Validate(); err != nil {
-			logger.WithError(err).WithField("name", name).Error("loadWallets: wallet.Validate failed")
-			return nil, err
+	return headers, nil
+}
+
+// containsDuplicate returns true if there is a duplicate wallet identified by
+// the wallet's fingerprint. This is to detect duplicate generative wallets;
+// wallets with no defined generation method do not have a concept of being
+// a duplicate of another wallet
+func (hs walletHeaders) containsDuplicate() (string, string, bool) {
+	m := make(map[string]struct{}, len(hs))
+	for wltID, h := range hs {
+		if h.fingerprint == "" {
+			continue
 		}
 
-		if w.Coin() != CoinTypeSkycoin {
-			err := fmt.Errorf("LoadWallets only support skycoin wallets, %s is a %s wallet", name, w.Coin())
-			logger.WithError(err).WithField("name", name).Error()
-			return nil, err
+		if _, ok := m[h.fingerprint]; ok {
+			return wltID, h.fingerprint, true
 		}
+
+		m[h.fingerprint] = struct{}{}
 	}
 
-	return wallets, nil
+	return "", "", false
 }
 
-// add add walet to current wallet
-func (wlts Wallets) add(w Wallet) error {
-	if _, dup := wlts[w.Filename()]; dup {
-		return ErrWalletNameConflict
+// containsEmpty returns true there is an empty wallet and the ID of that wallet if true.
+// Does not apply to collection and xpub wallets
+func (hs walletHeaders) containsEmpty() (string, bool) {
+	for wltID, h := range hs {
+		switch h.meta.Type() {
+		case WalletTypeDeterministic, WalletTypeBip44:
+			// The fingerprint is only missing if the wallet has no (external chain) addresses
+			if h.fingerprint == "" {
+				return wltID, true
+			}
+		}
 	}
-
-	wlts[w.Filename()] = w
-	return nil
+	return "", false
 }
 
 // remove wallet of specific id
@@ -82,45 +95,3 @@ func (wlts Wallets) get(id string) Wallet {
 func (wlts Wallets) set(w Wallet) {
 	wlts[w.Filename()] = w.Clone()
 }
-
-// containsDuplicate returns true if there is a duplicate wallet identified by
-// the wallet's fingerprint. This is to detect duplicate generative wallets;
-// wallets with no defined generation method do not have a concept of being
-// a duplicate of another wallet
-func (wlts Wallets) containsDuplicate() (string, string, bool) {
-	m := make(map[string]struct{}, len(wlts))
-	for wltID, wlt := range wlts {
-		fp := wlt.Fingerprint()
-		if fp == "" {
-			continue
-		}
-
-		if _, ok := m[fp]; ok {
-			return wltID, fp, true
-		}
-
-		m[fp] = struct{}{}
-	}
-
-	return "", "", false
-}
-
-// containsEmpty returns true there is an empty wallet and the ID of that wallet if true.
-// Does not apply to collection wallets
-func (wlts Wallets) containsEmpty() (string, bool) {
-	for wltID, wlt := range wlts {
-		switch wlt.Type() {
-		case WalletTypeCollection:
-			continue
-		case WalletTypeDeterministic:
-			if wlt.EntriesLen() == 0 {
-				return wltID, true
-			}
-		case WalletTypeBip44:
-			if len(wlt.(*Bip44Wallet).ExternalEntries) == 0 {
-				return wltID, true
-			}
-		}
-	}
-	return "", false
-}
@@ -147,6 +147,12 @@ func (w *XPubWallet) HasEntry(a cipher.Address) bool {
 	return w.Entries.has(a)
 }
 
+// SetEntryLabel sets the label of the entry with a given cipher.Address.
+// Returns false if the wallet has no such entry.
+func (w *XPubWallet) SetEntryLabel(a cipher.Address, label string) bool {
+	return w.Entries.setLabel(a, label)
+}
+
 // generateEntries generates up to `num` addresses
 func (w *XPubWallet) generateEntries(num uint64, initialChildIdx uint32) (Entries, error) {
 	if w.Meta.IsEncrypted() {